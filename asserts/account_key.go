@@ -115,9 +115,24 @@ func checkPublicKey(ab *assertionBase, keyIDName string) (PublicKey, error) {
 	if keyID != pubKey.ID() {
 		return nil, fmt.Errorf("public key does not match provided key id")
 	}
+	if isEd25519PublicKey(pubKey) && ab.Format() < 1 {
+		return nil, fmt.Errorf("ed25519 public keys require format 1 or greater")
+	}
 	return pubKey, nil
 }
 
+func accountKeyFormatAnalyze(headers map[string]interface{}, body []byte) (formatnum int, err error) {
+	formatnum = 0
+
+	// an invalid key is reported by the assembler
+	pubKey, err := DecodePublicKey(body)
+	if err == nil && isEd25519PublicKey(pubKey) {
+		formatnum = 1
+	}
+
+	return formatnum, nil
+}
+
 // Implement further consistency checks.
 func (ak *AccountKey) checkConsistency(db RODatabase, acck *AccountKey) error {
 	if !db.IsTrustedAccount(ak.AuthorityID()) {
@@ -132,6 +147,13 @@ func (ak *AccountKey) checkConsistency(db RODatabase, acck *AccountKey) error {
 	if err != nil {
 		return err
 	}
+	revoked, err := isKeyRevoked(db, ak.PublicKeyID())
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return fmt.Errorf("account-key assertion for %q has been revoked: %s", ak.AccountID(), ak.PublicKeyID())
+	}
 	// XXX: Make this unconditional once account-key assertions are required to have a name.
 	if ak.Name() != "" {
 		// Check that we don't end up with multiple keys with
@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts
+
+import (
+	"fmt"
+	"time"
+)
+
+// AccountKeyRevocation holds an account-key-revocation assertion,
+// asserting that the public key with the given key id must not be
+// trusted anymore for signing assertions.
+type AccountKeyRevocation struct {
+	assertionBase
+	timestamp time.Time
+}
+
+// PublicKeyID returns the key id of the revoked public key.
+func (akr *AccountKeyRevocation) PublicKeyID() string {
+	return akr.HeaderString("public-key-sha3-384")
+}
+
+// AccountID returns the account-id of the account the revoked key
+// belonged to.
+func (akr *AccountKeyRevocation) AccountID() string {
+	return akr.HeaderString("account-id")
+}
+
+// Reason returns the optional free-form reason for the revocation.
+func (akr *AccountKeyRevocation) Reason() string {
+	return akr.HeaderString("reason")
+}
+
+// Timestamp returns the time when the revocation was issued.
+func (akr *AccountKeyRevocation) Timestamp() time.Time {
+	return akr.timestamp
+}
+
+// Implement further consistency checks.
+func (akr *AccountKeyRevocation) checkConsistency(db RODatabase, acck *AccountKey) error {
+	if !db.IsTrustedAccount(akr.AuthorityID()) {
+		return fmt.Errorf("account-key-revocation assertion for %q is not signed by a directly trusted authority: %s", akr.PublicKeyID(), akr.AuthorityID())
+	}
+	a, err := db.Find(AccountKeyType, map[string]string{
+		"public-key-sha3-384": akr.PublicKeyID(),
+	})
+	if IsNotFound(err) {
+		// keys can be revoked before they are ever seen locally
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if accountID := a.(*AccountKey).AccountID(); accountID != akr.AccountID() {
+		return fmt.Errorf("account-key-revocation assertion for %q does not match the account of the revoked key: %q != %q", akr.PublicKeyID(), akr.AccountID(), accountID)
+	}
+	return nil
+}
+
+// sanity
+var _ consistencyChecker = (*AccountKeyRevocation)(nil)
+
+func assembleAccountKeyRevocation(assert assertionBase) (Assertion, error) {
+	_, err := checkNotEmptyString(assert.headers, "account-id")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = checkOptionalString(assert.headers, "reason")
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := checkRFC3339Date(assert.headers, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountKeyRevocation{
+		assertionBase: assert,
+		timestamp:     timestamp,
+	}, nil
+}
+
+// isKeyRevoked returns whether the database holds a revocation for the
+// key with the given key id.
+func isKeyRevoked(db RODatabase, keyID string) (bool, error) {
+	_, err := db.Find(AccountKeyRevocationType, map[string]string{
+		"public-key-sha3-384": keyID,
+	})
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
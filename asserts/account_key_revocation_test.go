@@ -0,0 +1,158 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts_test
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+)
+
+type accountKeyRevocationSuite struct {
+	ts     time.Time
+	tsLine string
+
+	hub      *assertstest.StoreStack
+	accounts *assertstest.SigningAccounts
+	dev1     *assertstest.SigningDB
+}
+
+var _ = Suite(&accountKeyRevocationSuite{})
+
+func (akrs *accountKeyRevocationSuite) SetUpTest(c *C) {
+	akrs.ts = time.Now().Truncate(time.Second).UTC()
+	akrs.tsLine = "timestamp: " + akrs.ts.Format(time.RFC3339) + "\n"
+
+	akrs.hub = assertstest.NewStoreStack("hub", nil)
+	akrs.accounts = assertstest.NewSigningAccounts(akrs.hub)
+	akrs.dev1 = akrs.accounts.Register("developer1", testPrivKey2, nil)
+}
+
+const accountKeyRevocationExample = `type: account-key-revocation
+authority-id: canonical
+public-key-sha3-384: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij
+account-id: acc-id1
+reason: key compromised
+` + "TSLINE" +
+	"body-length: 0\n" +
+	"sign-key-sha3-384: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij" +
+	"\n\n" +
+	"AXNpZw=="
+
+func (akrs *accountKeyRevocationSuite) TestDecodeOK(c *C) {
+	encoded := strings.Replace(accountKeyRevocationExample, "TSLINE", akrs.tsLine, 1)
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.AccountKeyRevocationType)
+	akr := a.(*asserts.AccountKeyRevocation)
+	c.Check(akr.PublicKeyID(), Equals, "Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij")
+	c.Check(akr.AccountID(), Equals, "acc-id1")
+	c.Check(akr.Reason(), Equals, "key compromised")
+	c.Check(akr.Timestamp(), Equals, akrs.ts)
+}
+
+func (akrs *accountKeyRevocationSuite) TestDecodeInvalid(c *C) {
+	const errPrefix = "assertion account-key-revocation: "
+
+	encoded := strings.Replace(accountKeyRevocationExample, "TSLINE", akrs.tsLine, 1)
+	invalidTests := []struct{ original, invalid, expectedErr string }{
+		{"account-id: acc-id1\n", "", `"account-id" header is mandatory`},
+		{"account-id: acc-id1\n", "account-id: \n", `"account-id" header should not be empty`},
+		{"reason: key compromised\n", "reason:\n  - foo\n", `"reason" header must be a string`},
+		{akrs.tsLine, "", `"timestamp" header is mandatory`},
+		{akrs.tsLine, "timestamp: 12:30\n", `"timestamp" header is not a RFC3339 date: .*`},
+	}
+
+	for _, test := range invalidTests {
+		invalid := strings.Replace(encoded, test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, errPrefix+test.expectedErr)
+	}
+}
+
+func (akrs *accountKeyRevocationSuite) openDB(c *C) *asserts.Database {
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   akrs.hub.Trusted,
+	})
+	c.Assert(err, IsNil)
+	assertstest.AddMany(db, akrs.hub.StoreAccountKey(""))
+	assertstest.AddMany(db, akrs.accounts.AccountsAndKeys("developer1")...)
+	return db
+}
+
+func (akrs *accountKeyRevocationSuite) revocation(c *C, signer assertstest.SignerDB, accountID string) *asserts.AccountKeyRevocation {
+	a, err := signer.Sign(asserts.AccountKeyRevocationType, map[string]interface{}{
+		"public-key-sha3-384": akrs.dev1.KeyID,
+		"account-id":          accountID,
+		"timestamp":           akrs.ts.Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	return a.(*asserts.AccountKeyRevocation)
+}
+
+func (akrs *accountKeyRevocationSuite) TestRevokedSigningKeyRejected(c *C) {
+	db := akrs.openDB(c)
+
+	a1, err := akrs.dev1.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"primary-key": "one",
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Check(db.Check(a1), IsNil)
+
+	err = db.Add(akrs.revocation(c, akrs.hub.RootSigning, "developer1"))
+	c.Assert(err, IsNil)
+
+	c.Check(db.Check(a1), ErrorMatches, `assertion is signed with revoked public key ".*" from "developer1"`)
+}
+
+func (akrs *accountKeyRevocationSuite) TestRevokedAccountKeyRejected(c *C) {
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   akrs.hub.Trusted,
+	})
+	c.Assert(err, IsNil)
+	assertstest.AddMany(db, akrs.hub.StoreAccountKey(""), akrs.accounts.Account("developer1"))
+
+	// the key can be revoked before it is ever seen
+	err = db.Add(akrs.revocation(c, akrs.hub.RootSigning, "developer1"))
+	c.Assert(err, IsNil)
+
+	err = db.Add(akrs.accounts.AccountKey("developer1"))
+	c.Check(err, ErrorMatches, `account-key assertion for "developer1" has been revoked: .*`)
+}
+
+func (akrs *accountKeyRevocationSuite) TestRevocationUntrustedAuthority(c *C) {
+	db := akrs.openDB(c)
+
+	err := db.Add(akrs.revocation(c, akrs.dev1, "developer1"))
+	c.Check(err, ErrorMatches, `account-key-revocation assertion for ".*" is not signed by a directly trusted authority: developer1`)
+}
+
+func (akrs *accountKeyRevocationSuite) TestRevocationAccountMismatch(c *C) {
+	db := akrs.openDB(c)
+
+	err := db.Add(akrs.revocation(c, akrs.hub.RootSigning, "developer2"))
+	c.Check(err, ErrorMatches, `account-key-revocation assertion for ".*" does not match the account of the revoked key: "developer2" != "developer1"`)
+}
@@ -207,7 +207,8 @@ func (aks *accountKeySuite) TestDecodeInvalidPublicKey(c *C) {
 		{"", "cannot decode public key: no data"},
 		{"==", "cannot decode public key: .*"},
 		{"stuff", "cannot decode public key: .*"},
-		{"AnNpZw==", "unsupported public key format version: 2"},
+		{"AnNpZw==", "unsupported public key algorithm"},
+		{"A3NpZw==", "unsupported public key format version: 3"},
 		{"AUJST0tFTg==", "cannot decode public key: .*"},
 		{spurious, "public key has spurious trailing data"},
 	}
@@ -940,7 +941,8 @@ func (aks *accountKeySuite) TestAccountKeyRequestDecodeInvalidPublicKey(c *C) {
 		{"", "cannot decode public key: no data"},
 		{"==", "cannot decode public key: .*"},
 		{"stuff", "cannot decode public key: .*"},
-		{"AnNpZw==", "unsupported public key format version: 2"},
+		{"AnNpZw==", "unsupported public key algorithm"},
+		{"A3NpZw==", "unsupported public key format version: 3"},
 		{"AUJST0tFTg==", "cannot decode public key: .*"},
 		{spurious, "public key has spurious trailing data"},
 	}
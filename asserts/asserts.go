@@ -78,20 +78,24 @@ func (at *AssertionType) SequenceForming() bool {
 
 // Understood assertion types.
 var (
-	AccountType         = &AssertionType{"account", []string{"account-id"}, assembleAccount, 0}
-	AccountKeyType      = &AssertionType{"account-key", []string{"public-key-sha3-384"}, assembleAccountKey, 0}
-	RepairType          = &AssertionType{"repair", []string{"brand-id", "repair-id"}, assembleRepair, sequenceForming}
-	ModelType           = &AssertionType{"model", []string{"series", "brand-id", "model"}, assembleModel, 0}
-	SerialType          = &AssertionType{"serial", []string{"brand-id", "model", "serial"}, assembleSerial, 0}
-	BaseDeclarationType = &AssertionType{"base-declaration", []string{"series"}, assembleBaseDeclaration, 0}
-	SnapDeclarationType = &AssertionType{"snap-declaration", []string{"series", "snap-id"}, assembleSnapDeclaration, 0}
-	SnapBuildType       = &AssertionType{"snap-build", []string{"snap-sha3-384"}, assembleSnapBuild, 0}
-	SnapRevisionType    = &AssertionType{"snap-revision", []string{"snap-sha3-384"}, assembleSnapRevision, 0}
-	SnapDeveloperType   = &AssertionType{"snap-developer", []string{"snap-id", "publisher-id"}, assembleSnapDeveloper, 0}
-	SystemUserType      = &AssertionType{"system-user", []string{"brand-id", "email"}, assembleSystemUser, 0}
-	ValidationType      = &AssertionType{"validation", []string{"series", "snap-id", "approved-snap-id", "approved-snap-revision"}, assembleValidation, 0}
-	ValidationSetType   = &AssertionType{"validation-set", []string{"series", "account-id", "name", "sequence"}, assembleValidationSet, sequenceForming}
-	StoreType           = &AssertionType{"store", []string{"store"}, assembleStore, 0}
+	AccountType              = &AssertionType{"account", []string{"account-id"}, assembleAccount, 0}
+	AccountKeyType           = &AssertionType{"account-key", []string{"public-key-sha3-384"}, assembleAccountKey, 0}
+	RepairType               = &AssertionType{"repair", []string{"brand-id", "repair-id"}, assembleRepair, sequenceForming}
+	ModelType                = &AssertionType{"model", []string{"series", "brand-id", "model"}, assembleModel, 0}
+	SerialType               = &AssertionType{"serial", []string{"brand-id", "model", "serial"}, assembleSerial, 0}
+	BaseDeclarationType      = &AssertionType{"base-declaration", []string{"series"}, assembleBaseDeclaration, 0}
+	SnapDeclarationType      = &AssertionType{"snap-declaration", []string{"series", "snap-id"}, assembleSnapDeclaration, 0}
+	SnapBuildType            = &AssertionType{"snap-build", []string{"snap-sha3-384"}, assembleSnapBuild, 0}
+	SnapRevisionType         = &AssertionType{"snap-revision", []string{"snap-sha3-384"}, assembleSnapRevision, 0}
+	SnapDeveloperType        = &AssertionType{"snap-developer", []string{"snap-id", "publisher-id"}, assembleSnapDeveloper, 0}
+	SystemUserType           = &AssertionType{"system-user", []string{"brand-id", "email"}, assembleSystemUser, 0}
+	ValidationType           = &AssertionType{"validation", []string{"series", "snap-id", "approved-snap-id", "approved-snap-revision"}, assembleValidation, 0}
+	ValidationSetType        = &AssertionType{"validation-set", []string{"series", "account-id", "name", "sequence"}, assembleValidationSet, sequenceForming}
+	StoreType                = &AssertionType{"store", []string{"store"}, assembleStore, 0}
+	DeviceGroupType          = &AssertionType{"device-group", []string{"brand-id", "model", "group"}, assembleDeviceGroup, 0}
+	AccountKeyRevocationType = &AssertionType{"account-key-revocation", []string{"public-key-sha3-384"}, assembleAccountKeyRevocation, 0}
+	RegistryType             = &AssertionType{"registry", []string{"account-id", "name"}, assembleRegistry, 0}
+	SnapPolicyType           = &AssertionType{"snap-policy", []string{"brand-id", "model"}, assembleSnapPolicy, 0}
 
 // ...
 )
@@ -104,20 +108,24 @@ var (
 )
 
 var typeRegistry = map[string]*AssertionType{
-	AccountType.Name:         AccountType,
-	AccountKeyType.Name:      AccountKeyType,
-	ModelType.Name:           ModelType,
-	SerialType.Name:          SerialType,
-	BaseDeclarationType.Name: BaseDeclarationType,
-	SnapDeclarationType.Name: SnapDeclarationType,
-	SnapBuildType.Name:       SnapBuildType,
-	SnapRevisionType.Name:    SnapRevisionType,
-	SnapDeveloperType.Name:   SnapDeveloperType,
-	SystemUserType.Name:      SystemUserType,
-	ValidationType.Name:      ValidationType,
-	ValidationSetType.Name:   ValidationSetType,
-	RepairType.Name:          RepairType,
-	StoreType.Name:           StoreType,
+	AccountType.Name:              AccountType,
+	AccountKeyType.Name:           AccountKeyType,
+	ModelType.Name:                ModelType,
+	SerialType.Name:               SerialType,
+	BaseDeclarationType.Name:      BaseDeclarationType,
+	SnapDeclarationType.Name:      SnapDeclarationType,
+	SnapBuildType.Name:            SnapBuildType,
+	SnapRevisionType.Name:         SnapRevisionType,
+	SnapDeveloperType.Name:        SnapDeveloperType,
+	SystemUserType.Name:           SystemUserType,
+	ValidationType.Name:           ValidationType,
+	ValidationSetType.Name:        ValidationSetType,
+	RepairType.Name:               RepairType,
+	StoreType.Name:                StoreType,
+	DeviceGroupType.Name:          DeviceGroupType,
+	AccountKeyRevocationType.Name: AccountKeyRevocationType,
+	RegistryType.Name:             RegistryType,
+	SnapPolicyType.Name:           SnapPolicyType,
 	// no authority
 	DeviceSessionRequestType.Name: DeviceSessionRequestType,
 	SerialRequestType.Name:        SerialRequestType,
@@ -150,10 +158,19 @@ func init() {
 	// 2: support for $SLOT()/$PLUG()/$MISSING
 	// 3: support for on-store/on-brand/on-model device scope constraints
 	// 4: support for plug-names/slot-names constraints
-	maxSupportedFormat[SnapDeclarationType.Name] = 4
+	// 5: support for $VERSION() attribute constraints
+	maxSupportedFormat[SnapDeclarationType.Name] = 5
 
 	// 1: support to limit to device serials
-	maxSupportedFormat[SystemUserType.Name] = 1
+	// 2: support for serial-ranges and max-uses
+	maxSupportedFormat[SystemUserType.Name] = 2
+
+	// 1: support for required snap configuration
+	maxSupportedFormat[ValidationSetType.Name] = 1
+
+	// 1: support for ed25519 public keys
+	maxSupportedFormat[AccountKeyType.Name] = 1
+	maxSupportedFormat[AccountKeyRequestType.Name] = 1
 }
 
 func MockMaxSupportedFormat(assertType *AssertionType, maxFormat int) (restore func()) {
@@ -165,8 +182,11 @@ func MockMaxSupportedFormat(assertType *AssertionType, maxFormat int) (restore f
 }
 
 var formatAnalyzer = map[*AssertionType]func(headers map[string]interface{}, body []byte) (formatnum int, err error){
-	SnapDeclarationType: snapDeclarationFormatAnalyze,
-	SystemUserType:      systemUserFormatAnalyze,
+	SnapDeclarationType:   snapDeclarationFormatAnalyze,
+	SystemUserType:        systemUserFormatAnalyze,
+	ValidationSetType:     validationSetFormatAnalyze,
+	AccountKeyType:        accountKeyFormatAnalyze,
+	AccountKeyRequestType: accountKeyFormatAnalyze,
 }
 
 // MaxSupportedFormats returns a mapping between assertion type names
@@ -547,26 +567,26 @@ var _ Assertion = (*assertionBase)(nil)
 //
 // The expected serialisation format looks like:
 //
-//   HEADER ("\n\n" BODY?)? "\n\n" SIGNATURE
+//	HEADER ("\n\n" BODY?)? "\n\n" SIGNATURE
 //
 // where:
 //
-//    HEADER is a set of header entries separated by "\n"
-//    BODY can be arbitrary text,
-//    SIGNATURE is the signature
+//	HEADER is a set of header entries separated by "\n"
+//	BODY can be arbitrary text,
+//	SIGNATURE is the signature
 //
 // Both BODY and HEADER must be UTF8.
 //
 // A header entry for a single line value (no '\n' in it) looks like:
 //
-//   NAME ": " SIMPLEVALUE
+//	NAME ": " SIMPLEVALUE
 //
 // The format supports multiline text values (with '\n's in them) and
 // lists or maps, possibly nested, with string scalars in them.
 //
 // For those a header entry looks like:
 //
-//   NAME ":\n" MULTI(baseindent)
+//	NAME ":\n" MULTI(baseindent)
 //
 // where MULTI can be
 //
@@ -574,11 +594,11 @@ var _ Assertion = (*assertionBase)(nil)
 //
 // * entries of a list each of the form:
 //
-//     " "*baseindent "  -"  ( " " SIMPLEVALUE | "\n" MULTI )
+//	" "*baseindent "  -"  ( " " SIMPLEVALUE | "\n" MULTI )
 //
 // * entries of map each of the form:
 //
-//     " "*baseindent "  " NAME ":"  ( " " SIMPLEVALUE | "\n" MULTI )
+//	" "*baseindent "  " NAME ":"  ( " " SIMPLEVALUE | "\n" MULTI )
 //
 // baseindent starts at 0 and then grows with nesting matching the
 // previous level introduction (e.g. the " "*baseindent " -" bit)
@@ -586,8 +606,8 @@ var _ Assertion = (*assertionBase)(nil)
 //
 // In general the following headers are mandatory:
 //
-//   type
-//   authority-id (except for on the wire/self-signed assertions like serial-request)
+//	type
+//	authority-id (except for on the wire/self-signed assertions like serial-request)
 //
 // Further for a given assertion type all the primary key headers
 // must be non empty and must not contain '/'.
@@ -595,12 +615,11 @@ var _ Assertion = (*assertionBase)(nil)
 // The following headers expect string representing integer values and
 // if omitted otherwise are assumed to be 0:
 //
-//   revision (a positive int)
-//   body-length (expected to be equal to the length of BODY)
-//   format (a positive int for the format iteration of the type used)
+//	revision (a positive int)
+//	body-length (expected to be equal to the length of BODY)
+//	format (a positive int for the format iteration of the type used)
 //
 // Times are expected to be in the RFC3339 format: "2006-01-02T15:04:05Z07:00".
-//
 func Decode(serializedAssertion []byte) (Assertion, error) {
 	// copy to get an independent backstorage that can't be mutated later
 	assertionSnapshot := make([]byte, len(serializedAssertion))
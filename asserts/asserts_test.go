@@ -51,15 +51,19 @@ func (as *assertsSuite) TestTypeNames(c *C) {
 		"account",
 		"account-key",
 		"account-key-request",
+		"account-key-revocation",
 		"base-declaration",
+		"device-group",
 		"device-session-request",
 		"model",
+		"registry",
 		"repair",
 		"serial",
 		"serial-request",
 		"snap-build",
 		"snap-declaration",
 		"snap-developer",
+		"snap-policy",
 		"snap-revision",
 		"store",
 		"system-user",
@@ -82,10 +86,13 @@ func (as *assertsSuite) TestMaxSupportedFormats(c *C) {
 	c.Check(snapDeclMaxFormat >= 4, Equals, true)
 	c.Check(systemUserMaxFormat >= 1, Equals, true)
 	c.Check(asserts.MaxSupportedFormats(1), DeepEquals, map[string]int{
-		"snap-declaration": snapDeclMaxFormat,
-		"system-user":      systemUserMaxFormat,
-		"test-only":        1,
-		"test-only-seq":    2,
+		"account-key":         1,
+		"account-key-request": 1,
+		"snap-declaration":    snapDeclMaxFormat,
+		"system-user":         systemUserMaxFormat,
+		"test-only":           1,
+		"test-only-seq":       2,
+		"validation-set":      1,
 	})
 
 	// all
@@ -952,6 +959,10 @@ func (as *assertsSuite) TestWithAuthority(c *C) {
 		"validation",
 		"validation-set",
 		"repair",
+		"device-group",
+		"account-key-revocation",
+		"registry",
+		"snap-policy",
 	}
 	c.Check(withAuthority, HasLen, asserts.NumAssertionType-3) // excluding device-session-request, serial-request, account-key-request
 	for _, name := range withAuthority {
@@ -22,6 +22,7 @@ package asserts
 import (
 	"bytes"
 	"crypto"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 
@@ -41,19 +42,33 @@ import (
 
 const (
 	maxEncodeLineLength = 76
-	v1                  = 0x1
+	// v1 keys and signatures are OpenPGP packets
+	v1 = 0x1
+	// v2 keys and signatures are an algorithm identifier followed
+	// by the raw key or signature
+	v2 = 0x2
+)
+
+// algorithm identifiers for v2 keys and signatures
+const (
+	algoEd25519 = 0x1
 )
 
 var (
 	v1Header         = []byte{v1}
+	v2Ed25519Header  = []byte{v2, algoEd25519}
 	v1FixedTimestamp = time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
 )
 
 func encodeV1(data []byte) []byte {
+	return encodeWithHeader(v1Header, data)
+}
+
+func encodeWithHeader(header, data []byte) []byte {
 	buf := new(bytes.Buffer)
-	buf.Grow(base64.StdEncoding.EncodedLen(len(data) + 1))
+	buf.Grow(base64.StdEncoding.EncodedLen(len(header) + len(data)))
 	enc := base64.NewEncoder(base64.StdEncoding, buf)
-	enc.Write(v1Header)
+	enc.Write(header)
 	enc.Write(data)
 	enc.Close()
 	flat := buf.Bytes()
@@ -82,12 +97,21 @@ type keyEncoder interface {
 	keyEncode(w io.Writer) error
 }
 
+// encodingHeaderer is implemented by keys that are not encoded as
+// v1 OpenPGP packets.
+type encodingHeaderer interface {
+	encodingHeader() []byte
+}
+
 func encodeKey(key keyEncoder, kind string) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	err := key.keyEncode(buf)
 	if err != nil {
 		return nil, fmt.Errorf("cannot encode %s: %v", kind, err)
 	}
+	if eh, ok := key.(encodingHeaderer); ok {
+		return encodeWithHeader(eh.encodingHeader(), buf.Bytes()), nil
+	}
 	return encodeV1(buf.Bytes()), nil
 }
 
@@ -96,6 +120,17 @@ type openpgpSigner interface {
 }
 
 func signContent(content []byte, privateKey PrivateKey) ([]byte, error) {
+	if edPrivK, ok := privateKey.(ed25519PrivateKey); ok {
+		return encodeWithHeader(v2Ed25519Header, edPrivK.sign(content)), nil
+	}
+	if extEdPrivK, ok := privateKey.(*extEd25519PrivateKey); ok {
+		sig, err := extEdPrivK.sign(content)
+		if err != nil {
+			return nil, err
+		}
+		return encodeWithHeader(v2Ed25519Header, sig), nil
+	}
+
 	signer, ok := privateKey.(openpgpSigner)
 	if !ok {
 		panic(fmt.Errorf("not an internally supported PrivateKey: %T", privateKey))
@@ -115,23 +150,29 @@ func signContent(content []byte, privateKey PrivateKey) ([]byte, error) {
 	return encodeV1(buf.Bytes()), nil
 }
 
-func decodeV1(b []byte, kind string) (packet.Packet, error) {
+// decodeVersioned decodes b returning its format version and the
+// data following the version.
+func decodeVersioned(b []byte, kind string) (version byte, data []byte, err error) {
 	if len(b) == 0 {
-		return nil, fmt.Errorf("cannot decode %s: no data", kind)
+		return 0, nil, fmt.Errorf("cannot decode %s: no data", kind)
 	}
 	buf := make([]byte, base64.StdEncoding.DecodedLen(len(b)))
 	n, err := base64.StdEncoding.Decode(buf, b)
 	if err != nil {
-		return nil, fmt.Errorf("cannot decode %s: %v", kind, err)
+		return 0, nil, fmt.Errorf("cannot decode %s: %v", kind, err)
 	}
 	if n == 0 {
-		return nil, fmt.Errorf("cannot decode %s: base64 without data", kind)
+		return 0, nil, fmt.Errorf("cannot decode %s: base64 without data", kind)
 	}
 	buf = buf[:n]
-	if buf[0] != v1 {
-		return nil, fmt.Errorf("unsupported %s format version: %d", kind, buf[0])
+	if buf[0] != v1 && buf[0] != v2 {
+		return 0, nil, fmt.Errorf("unsupported %s format version: %d", kind, buf[0])
 	}
-	rd := bytes.NewReader(buf[1:])
+	return buf[0], buf[1:], nil
+}
+
+func decodeV1Packet(data []byte, kind string) (packet.Packet, error) {
+	rd := bytes.NewReader(data)
 	pkt, err := packet.Read(rd)
 	if err != nil {
 		return nil, fmt.Errorf("cannot decode %s: %v", kind, err)
@@ -142,16 +183,47 @@ func decodeV1(b []byte, kind string) (packet.Packet, error) {
 	return pkt, nil
 }
 
-func decodeSignature(signature []byte) (*packet.Signature, error) {
-	pkt, err := decodeV1(signature, "signature")
+// decodeV2Ed25519 checks that data is an ed25519 v2 encoding of the
+// given size and returns the raw bytes.
+func decodeV2Ed25519(data []byte, kind string, size int) ([]byte, error) {
+	if len(data) == 0 || data[0] != algoEd25519 {
+		return nil, fmt.Errorf("unsupported %s algorithm", kind)
+	}
+	raw := data[1:]
+	if len(raw) != size {
+		return nil, fmt.Errorf("cannot decode %s: expected ed25519 %s of %d bytes, got %d", kind, kind, size, len(raw))
+	}
+	return raw, nil
+}
+
+// signature is a decoded assertion signature, either an OpenPGP or
+// an ed25519 one.
+type signature struct {
+	openpgp *packet.Signature
+	ed25519 []byte
+}
+
+func decodeSignature(sig []byte) (*signature, error) {
+	version, data, err := decodeVersioned(sig, "signature")
 	if err != nil {
 		return nil, err
 	}
-	sig, ok := pkt.(*packet.Signature)
+	if version == v2 {
+		raw, err := decodeV2Ed25519(data, "signature", ed25519.SignatureSize)
+		if err != nil {
+			return nil, err
+		}
+		return &signature{ed25519: raw}, nil
+	}
+	pkt, err := decodeV1Packet(data, "signature")
+	if err != nil {
+		return nil, err
+	}
+	pgpSig, ok := pkt.(*packet.Signature)
 	if !ok {
 		return nil, fmt.Errorf("expected signature, got instead: %T", pkt)
 	}
-	return sig, nil
+	return &signature{openpgp: pgpSig}, nil
 }
 
 // PublicKey is the public part of a cryptographic private/public key pair.
@@ -160,7 +232,7 @@ type PublicKey interface {
 	ID() string
 
 	// verify verifies signature is valid for content using the key.
-	verify(content []byte, sig *packet.Signature) error
+	verify(content []byte, sig *signature) error
 
 	keyEncoder
 }
@@ -174,10 +246,13 @@ func (opgPubKey *openpgpPubKey) ID() string {
 	return opgPubKey.sha3_384
 }
 
-func (opgPubKey *openpgpPubKey) verify(content []byte, sig *packet.Signature) error {
-	h := sig.Hash.New()
+func (opgPubKey *openpgpPubKey) verify(content []byte, sig *signature) error {
+	if sig.openpgp == nil {
+		return fmt.Errorf("cannot verify ed25519 signature with OpenPGP public key")
+	}
+	h := sig.openpgp.Hash.New()
 	h.Write(content)
-	return opgPubKey.pubKey.VerifySignature(h, sig)
+	return opgPubKey.pubKey.VerifySignature(h, sig.openpgp)
 }
 
 func (opgPubKey openpgpPubKey) keyEncode(w io.Writer) error {
@@ -206,7 +281,18 @@ func RSAPublicKey(pubKey *rsa.PublicKey) PublicKey {
 
 // DecodePublicKey deserializes a public key.
 func DecodePublicKey(pubKey []byte) (PublicKey, error) {
-	pkt, err := decodeV1(pubKey, "public key")
+	version, data, err := decodeVersioned(pubKey, "public key")
+	if err != nil {
+		return nil, err
+	}
+	if version == v2 {
+		raw, err := decodeV2Ed25519(data, "public key", ed25519.PublicKeySize)
+		if err != nil {
+			return nil, err
+		}
+		return Ed25519PublicKey(ed25519.PublicKey(raw)), nil
+	}
+	pkt, err := decodeV1Packet(data, "public key")
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +355,18 @@ func (opgPrivK openpgpPrivateKey) sign(content []byte) (*packet.Signature, error
 }
 
 func decodePrivateKey(privKey []byte) (PrivateKey, error) {
-	pkt, err := decodeV1(privKey, "private key")
+	version, data, err := decodeVersioned(privKey, "private key")
+	if err != nil {
+		return nil, err
+	}
+	if version == v2 {
+		seed, err := decodeV2Ed25519(data, "private key", ed25519.SeedSize)
+		if err != nil {
+			return nil, err
+		}
+		return Ed25519PrivateKey(ed25519.NewKeyFromSeed(seed)), nil
+	}
+	pkt, err := decodeV1Packet(data, "private key")
 	if err != nil {
 		return nil, err
 	}
@@ -302,6 +399,91 @@ func encodePrivateKey(privKey PrivateKey) ([]byte, error) {
 	return encodeKey(privKey, "private key")
 }
 
+// ed25519 key pairs
+
+type ed25519PubKey struct {
+	pubKey   ed25519.PublicKey
+	sha3_384 string
+}
+
+func (edPubKey *ed25519PubKey) ID() string {
+	return edPubKey.sha3_384
+}
+
+func (edPubKey *ed25519PubKey) verify(content []byte, sig *signature) error {
+	if sig.ed25519 == nil {
+		return fmt.Errorf("cannot verify OpenPGP signature with ed25519 public key")
+	}
+	if !ed25519.Verify(edPubKey.pubKey, content, sig.ed25519) {
+		return fmt.Errorf("ed25519 verification failure")
+	}
+	return nil
+}
+
+func (edPubKey *ed25519PubKey) keyEncode(w io.Writer) error {
+	_, err := w.Write(edPubKey.pubKey)
+	return err
+}
+
+func (edPubKey *ed25519PubKey) encodingHeader() []byte {
+	return v2Ed25519Header
+}
+
+// Ed25519PublicKey returns a database useable public key out of
+// ed25519.PublicKey.
+func Ed25519PublicKey(pubKey ed25519.PublicKey) PublicKey {
+	h := sha3.New384()
+	h.Write(v2Ed25519Header)
+	h.Write(pubKey)
+	sha3_384, err := EncodeDigest(crypto.SHA3_384, h.Sum(nil))
+	if err != nil {
+		panic("internal error: cannot compute public key sha3-384")
+	}
+	return &ed25519PubKey{pubKey: pubKey, sha3_384: sha3_384}
+}
+
+// isEd25519PublicKey returns whether pubKey is an ed25519 key.
+func isEd25519PublicKey(pubKey PublicKey) bool {
+	_, ok := pubKey.(*ed25519PubKey)
+	return ok
+}
+
+type ed25519PrivateKey struct {
+	privk ed25519.PrivateKey
+}
+
+func (edPrivK ed25519PrivateKey) PublicKey() PublicKey {
+	return Ed25519PublicKey(edPrivK.privk.Public().(ed25519.PublicKey))
+}
+
+func (edPrivK ed25519PrivateKey) keyEncode(w io.Writer) error {
+	_, err := w.Write(edPrivK.privk.Seed())
+	return err
+}
+
+func (edPrivK ed25519PrivateKey) encodingHeader() []byte {
+	return v2Ed25519Header
+}
+
+func (edPrivK ed25519PrivateKey) sign(content []byte) []byte {
+	return ed25519.Sign(edPrivK.privk, content)
+}
+
+// Ed25519PrivateKey returns a PrivateKey for database use out of a
+// ed25519.PrivateKey.
+func Ed25519PrivateKey(privk ed25519.PrivateKey) PrivateKey {
+	return ed25519PrivateKey{privk}
+}
+
+// GenerateEd25519Key generates an ed25519 private/public key pair.
+func GenerateEd25519Key() (PrivateKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return Ed25519PrivateKey(priv), nil
+}
+
 // externally held key pairs
 
 type extPGPPrivateKey struct {
@@ -379,10 +561,38 @@ func (expk *extPGPPrivateKey) sign(content []byte) (*packet.Signature, error) {
 		return nil, fmt.Errorf(badSig + "expected SHA512 digest")
 	}
 
-	err = expk.pubKey.verify(content, sig)
+	err = expk.pubKey.verify(content, &signature{openpgp: sig})
 	if err != nil {
 		return nil, fmt.Errorf(badSig+"it does not verify: %v", err)
 	}
 
 	return sig, nil
 }
+
+type extEd25519PrivateKey struct {
+	pubKey PublicKey
+	from   string
+	doSign func(content []byte) ([]byte, error)
+}
+
+func (expk *extEd25519PrivateKey) PublicKey() PublicKey {
+	return expk.pubKey
+}
+
+func (expk *extEd25519PrivateKey) keyEncode(w io.Writer) error {
+	return fmt.Errorf("cannot access external private key to encode it")
+}
+
+func (expk *extEd25519PrivateKey) sign(content []byte) ([]byte, error) {
+	sig, err := expk.doSign(content)
+	if err != nil {
+		return nil, err
+	}
+
+	err = expk.pubKey.verify(content, &signature{ed25519: sig})
+	if err != nil {
+		return nil, fmt.Errorf("bad %s produced signature: it does not verify: %v", expk.from, err)
+	}
+
+	return sig, nil
+}
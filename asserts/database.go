@@ -707,6 +707,24 @@ func CheckSigningKeyIsNotExpired(assert Assertion, signingKey *AccountKey, roDB
 	return nil
 }
 
+// CheckSigningKeyIsNotRevoked checks that the signing key has not
+// been revoked by an account-key-revocation assertion.
+func CheckSigningKeyIsNotRevoked(assert Assertion, signingKey *AccountKey, roDB RODatabase, checkTimeEarliest, checkTimeLatest time.Time) error {
+	if signingKey == nil {
+		// assert isn't signed with an account-key key, CheckSignature
+		// will fail anyway unless we teach it more stuff.
+		return nil
+	}
+	revoked, err := isKeyRevoked(roDB, signingKey.PublicKeyID())
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return fmt.Errorf("assertion is signed with revoked public key %q from %q", assert.SignKeyID(), assert.AuthorityID())
+	}
+	return nil
+}
+
 // CheckSignature checks that the signature is valid.
 func CheckSignature(assert Assertion, signingKey *AccountKey, roDB RODatabase, checkTimeEarliest, checkTimeLatest time.Time) error {
 	var pubKey PublicKey
@@ -784,6 +802,7 @@ func CheckCrossConsistency(assert Assertion, signingKey *AccountKey, roDB ROData
 // DatabaseConfig.Checkers.
 var DefaultCheckers = []Checker{
 	CheckSigningKeyIsNotExpired,
+	CheckSigningKeyIsNotRevoked,
 	CheckSignature,
 	CheckTimestampVsSigningKeyValidity,
 	CheckCrossConsistency,
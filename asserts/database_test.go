@@ -142,6 +142,31 @@ func (dbs *databaseSuite) TestImportKey(c *C) {
 	c.Check(privKeyFromDisk.PublicKey().ID(), Equals, testPrivKey1SHA3_384)
 }
 
+func (dbs *databaseSuite) TestImportKeyEd25519(c *C) {
+	privk, err := asserts.GenerateEd25519Key()
+	c.Assert(err, IsNil)
+	keyID := privk.PublicKey().ID()
+
+	err = dbs.db.ImportKey(privk)
+	c.Assert(err, IsNil)
+
+	privKey, err := ioutil.ReadFile(filepath.Join(dbs.topDir, "private-keys-v1", keyID))
+	c.Assert(err, IsNil)
+
+	privKeyFromDisk, err := asserts.DecodePrivateKeyInTest(privKey)
+	c.Assert(err, IsNil)
+
+	c.Check(privKeyFromDisk.PublicKey().ID(), Equals, keyID)
+
+	pubKey, err := dbs.db.PublicKey(keyID)
+	c.Assert(err, IsNil)
+	encoded, err := asserts.EncodePublicKey(pubKey)
+	c.Assert(err, IsNil)
+	decoded, err := asserts.DecodePublicKey(encoded)
+	c.Assert(err, IsNil)
+	c.Check(decoded.ID(), Equals, keyID)
+}
+
 func (dbs *databaseSuite) TestImportKeyAlreadyExists(c *C) {
 	err := dbs.db.ImportKey(testPrivKey1)
 	c.Assert(err, IsNil)
@@ -454,6 +479,61 @@ func (safs *signAddFindSuite) TestSign(c *C) {
 	c.Check(err, IsNil)
 }
 
+func (safs *signAddFindSuite) TestSignEd25519(c *C) {
+	edPrivKey, err := asserts.GenerateEd25519Key()
+	c.Assert(err, IsNil)
+	edSigningDB, err := asserts.OpenDatabase(&asserts.DatabaseConfig{})
+	c.Assert(err, IsNil)
+	err = edSigningDB.ImportKey(edPrivKey)
+	c.Assert(err, IsNil)
+	edKeyID := edPrivKey.PublicKey().ID()
+
+	acct, err := safs.signingDB.Sign(asserts.AccountType, map[string]interface{}{
+		"authority-id": "canonical",
+		"account-id":   "acc-id1",
+		"display-name": "Acct1",
+		"validation":   "unproven",
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}, nil, safs.signingKeyID)
+	c.Assert(err, IsNil)
+	err = safs.db.Add(acct)
+	c.Assert(err, IsNil)
+
+	pubKeyEncoded, err := asserts.EncodePublicKey(edPrivKey.PublicKey())
+	c.Assert(err, IsNil)
+	acctKeyHeaders := map[string]interface{}{
+		"authority-id":        "canonical",
+		"account-id":          "acc-id1",
+		"name":                "default",
+		"public-key-sha3-384": edKeyID,
+		"since":               time.Now().Add(-time.Hour).Format(time.RFC3339),
+	}
+	_, err = safs.signingDB.Sign(asserts.AccountKeyType, acctKeyHeaders, pubKeyEncoded, safs.signingKeyID)
+	c.Check(err, ErrorMatches, `cannot sign "account-key" assertion with format set to 0 lower than min format 1 covering included features`)
+
+	acctKeyHeaders["format"] = "1"
+	acctKey, err := safs.signingDB.Sign(asserts.AccountKeyType, acctKeyHeaders, pubKeyEncoded, safs.signingKeyID)
+	c.Assert(err, IsNil)
+	err = safs.db.Add(acctKey)
+	c.Assert(err, IsNil)
+
+	a1, err := edSigningDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "acc-id1",
+		"primary-key":  "a",
+	}, nil, edKeyID)
+	c.Assert(err, IsNil)
+
+	err = safs.db.Check(a1)
+	c.Check(err, IsNil)
+
+	// tampering is detected
+	tampered := bytes.Replace(asserts.Encode(a1), []byte("primary-key: a"), []byte("primary-key: b"), 1)
+	forged, err := asserts.Decode(tampered)
+	c.Assert(err, IsNil)
+	err = safs.db.Check(forged)
+	c.Check(err, ErrorMatches, "failed signature verification: ed25519 verification failure")
+}
+
 func (safs *signAddFindSuite) TestSignEmptyKeyID(c *C) {
 	headers := map[string]interface{}{
 		"authority-id": "canonical",
@@ -0,0 +1,215 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/snapcore/snapd/snap/channel"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+var validDeviceGroupName = regexp.MustCompile("^[a-z0-9](?:-?[a-z0-9])*$")
+
+// DeviceGroup holds a device-group assertion, which is a statement by
+// a brand grouping devices of one of its models by serial and setting
+// policy for them.
+type DeviceGroup struct {
+	assertionBase
+
+	serials      []string
+	serialRanges []SerialRange
+
+	allowedChannels []channel.Channel
+
+	timestamp time.Time
+}
+
+// BrandID returns the brand identifier of the devices in the group.
+func (dg *DeviceGroup) BrandID() string {
+	return dg.HeaderString("brand-id")
+}
+
+// Model returns the model name of the devices in the group.
+func (dg *DeviceGroup) Model() string {
+	return dg.HeaderString("model")
+}
+
+// Group returns the name of the group.
+func (dg *DeviceGroup) Group() string {
+	return dg.HeaderString("group")
+}
+
+// Serials returns the serials of the devices in the group.
+func (dg *DeviceGroup) Serials() []string {
+	return dg.serials
+}
+
+// SerialRanges returns the ranges of serials of the devices in the
+// group, in addition to the ones returned by Serials.
+func (dg *DeviceGroup) SerialRanges() []SerialRange {
+	return dg.serialRanges
+}
+
+// Contains returns whether the device with the given serial is part
+// of the group.
+func (dg *DeviceGroup) Contains(serial string) bool {
+	if strutil.ListContains(dg.serials, serial) {
+		return true
+	}
+	for _, r := range dg.serialRanges {
+		if r.Contains(serial) {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshSchedule returns the refresh schedule, in refresh.timer
+// format, to use for the devices in the group, if any.
+func (dg *DeviceGroup) RefreshSchedule() string {
+	return dg.HeaderString("refresh-schedule")
+}
+
+// AllowedChannels returns the channels the devices in the group are
+// allowed to track, if restricted at all. Entries consisting only of
+// a risk apply to any track.
+func (dg *DeviceGroup) AllowedChannels() []string {
+	if len(dg.allowedChannels) == 0 {
+		return nil
+	}
+	chs := make([]string, len(dg.allowedChannels))
+	for i, ch := range dg.allowedChannels {
+		chs[i] = ch.Name
+	}
+	return chs
+}
+
+// AllowsChannel returns whether the devices in the group can track
+// the given channel. Branches are allowed whenever their track and
+// risk are.
+func (dg *DeviceGroup) AllowsChannel(ch string) bool {
+	if len(dg.allowedChannels) == 0 {
+		return true
+	}
+	c, err := channel.Parse(ch, "")
+	if err != nil {
+		return false
+	}
+	for _, allowed := range dg.allowedChannels {
+		if allowed.Risk != c.Risk {
+			continue
+		}
+		if allowed.VerbatimRiskOnly() || allowed.Clean().Track == c.Track {
+			return true
+		}
+	}
+	return false
+}
+
+// Timestamp returns the time when the device-group was issued.
+func (dg *DeviceGroup) Timestamp() time.Time {
+	return dg.timestamp
+}
+
+func checkAllowedChannels(headers map[string]interface{}) ([]channel.Channel, error) {
+	entries, err := checkStringList(headers, "allowed-channels")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	chs := make([]channel.Channel, 0, len(entries))
+	for _, entry := range entries {
+		ch, err := channel.ParseVerbatim(entry, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid channel %q in \"allowed-channels\" header: %v", entry, err)
+		}
+		if ch.Risk == "" || ch.Branch != "" {
+			return nil, fmt.Errorf("invalid channel %q in \"allowed-channels\" header: must be a risk optionally preceded by a track", entry)
+		}
+		// ParseVerbatim does not set the name
+		ch.Name = entry
+		chs = append(chs, ch)
+	}
+	return chs, nil
+}
+
+func assembleDeviceGroup(assert assertionBase) (Assertion, error) {
+	authorityID := assert.AuthorityID()
+	brandID := assert.HeaderString("brand-id")
+	if brandID != authorityID {
+		return nil, fmt.Errorf("authority-id and brand-id must match, device-group assertions are expected to be signed by the brand: %q != %q", authorityID, brandID)
+	}
+
+	_, err := checkModel(assert.headers)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = checkStringMatches(assert.headers, "group", validDeviceGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	serials, err := checkStringList(assert.headers, "serials")
+	if err != nil {
+		return nil, err
+	}
+	serialRanges, err := checkSerialRanges(assert.headers)
+	if err != nil {
+		return nil, err
+	}
+	if len(serials) == 0 && len(serialRanges) == 0 {
+		return nil, fmt.Errorf(`at least one of "serials" or "serial-ranges" headers must be specified`)
+	}
+
+	schedule, err := checkOptionalString(assert.headers, "refresh-schedule")
+	if err != nil {
+		return nil, err
+	}
+	if schedule != "" {
+		if _, err := timeutil.ParseSchedule(schedule); err != nil {
+			return nil, fmt.Errorf("invalid \"refresh-schedule\" header: %v", err)
+		}
+	}
+
+	allowedChannels, err := checkAllowedChannels(assert.headers)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := checkRFC3339Date(assert.headers, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeviceGroup{
+		assertionBase:   assert,
+		serials:         serials,
+		serialRanges:    serialRanges,
+		allowedChannels: allowedChannels,
+		timestamp:       timestamp,
+	}, nil
+}
@@ -0,0 +1,174 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts_test
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+)
+
+type deviceGroupSuite struct {
+	ts     time.Time
+	tsLine string
+}
+
+var _ = Suite(&deviceGroupSuite{})
+
+func (dgs *deviceGroupSuite) SetUpSuite(c *C) {
+	dgs.ts = time.Now().Truncate(time.Second).UTC()
+	dgs.tsLine = "timestamp: " + dgs.ts.Format(time.RFC3339) + "\n"
+}
+
+const deviceGroupExample = `type: device-group
+authority-id: brand-id1
+brand-id: brand-id1
+model: baz-3000
+group: fleet-a
+serials:
+  - serial1
+  - serial2
+serial-ranges:
+  -
+    first: A0000
+    last: A0999
+refresh-schedule: mon,10:00-12:00
+allowed-channels:
+  - stable
+  - 2.0/candidate
+` + "TSLINE" +
+	"body-length: 0\n" +
+	"sign-key-sha3-384: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij" +
+	"\n\n" +
+	"AXNpZw=="
+
+func (dgs *deviceGroupSuite) TestDecodeOK(c *C) {
+	encoded := strings.Replace(deviceGroupExample, "TSLINE", dgs.tsLine, 1)
+
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.DeviceGroupType)
+	dg := a.(*asserts.DeviceGroup)
+	c.Check(dg.AuthorityID(), Equals, "brand-id1")
+	c.Check(dg.Timestamp(), Equals, dgs.ts)
+	c.Check(dg.BrandID(), Equals, "brand-id1")
+	c.Check(dg.Model(), Equals, "baz-3000")
+	c.Check(dg.Group(), Equals, "fleet-a")
+	c.Check(dg.Serials(), DeepEquals, []string{"serial1", "serial2"})
+	c.Check(dg.SerialRanges(), DeepEquals, []asserts.SerialRange{{First: "A0000", Last: "A0999"}})
+	c.Check(dg.RefreshSchedule(), Equals, "mon,10:00-12:00")
+	c.Check(dg.AllowedChannels(), DeepEquals, []string{"stable", "2.0/candidate"})
+}
+
+func (dgs *deviceGroupSuite) TestDecodeMinimal(c *C) {
+	encoded := strings.Replace(deviceGroupExample, "TSLINE", dgs.tsLine, 1)
+	encoded = strings.Replace(encoded, "serial-ranges:\n  -\n    first: A0000\n    last: A0999\n", "", 1)
+	encoded = strings.Replace(encoded, "refresh-schedule: mon,10:00-12:00\n", "", 1)
+	encoded = strings.Replace(encoded, "allowed-channels:\n  - stable\n  - 2.0/candidate\n", "", 1)
+
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	dg := a.(*asserts.DeviceGroup)
+	c.Check(dg.SerialRanges(), HasLen, 0)
+	c.Check(dg.RefreshSchedule(), Equals, "")
+	c.Check(dg.AllowedChannels(), IsNil)
+	c.Check(dg.AllowsChannel("latest/edge"), Equals, true)
+}
+
+func (dgs *deviceGroupSuite) TestContains(c *C) {
+	encoded := strings.Replace(deviceGroupExample, "TSLINE", dgs.tsLine, 1)
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	dg := a.(*asserts.DeviceGroup)
+
+	for _, tc := range []struct {
+		serial   string
+		contains bool
+	}{
+		{"serial1", true},
+		{"serial2", true},
+		{"serial3", false},
+		{"A0000", true},
+		{"A0500", true},
+		{"A1000", false},
+		{"A00000", false},
+	} {
+		c.Check(dg.Contains(tc.serial), Equals, tc.contains, Commentf("%s", tc.serial))
+	}
+}
+
+func (dgs *deviceGroupSuite) TestAllowsChannel(c *C) {
+	encoded := strings.Replace(deviceGroupExample, "TSLINE", dgs.tsLine, 1)
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	dg := a.(*asserts.DeviceGroup)
+
+	for _, tc := range []struct {
+		channel string
+		allowed bool
+	}{
+		{"stable", true},
+		{"latest/stable", true},
+		{"2.0/stable", true},
+		{"stable/hotfix", true},
+		{"candidate", false},
+		{"2.0/candidate", true},
+		{"2.0/candidate/fix", true},
+		{"3.0/candidate", false},
+		{"edge", false},
+		{"a/b/c/d", false},
+	} {
+		c.Check(dg.AllowsChannel(tc.channel), Equals, tc.allowed, Commentf("%s", tc.channel))
+	}
+}
+
+func (dgs *deviceGroupSuite) TestDecodeInvalid(c *C) {
+	const deviceGroupErrPrefix = "assertion device-group: "
+
+	encoded := strings.Replace(deviceGroupExample, "TSLINE", dgs.tsLine, 1)
+
+	serialsStanza := "serials:\n  - serial1\n  - serial2\n"
+	serialRangesStanza := "serial-ranges:\n  -\n    first: A0000\n    last: A0999\n"
+	invalidTests := []struct{ original, invalid, expectedErr string }{
+		{"brand-id: brand-id1\n", "", `"brand-id" header is mandatory`},
+		{"brand-id: brand-id1\n", "brand-id: random\n", `authority-id and brand-id must match, device-group assertions are expected to be signed by the brand: "brand-id1" != "random"`},
+		{"model: baz-3000\n", "", `"model" header is mandatory`},
+		{"model: baz-3000\n", "model: Baz-3000\n", `"model" header cannot contain uppercase letters`},
+		{"group: fleet-a\n", "", `"group" header is mandatory`},
+		{"group: fleet-a\n", "group: fleet_a\n", `"group" header contains invalid characters: "fleet_a"`},
+		{serialsStanza + serialRangesStanza, "", `at least one of "serials" or "serial-ranges" headers must be specified`},
+		{serialsStanza, "serials: foo\n", `"serials" header must be a list of strings`},
+		{serialRangesStanza, "serial-ranges: foo\n", `"serial-ranges" header must be a list of maps`},
+		{"refresh-schedule: mon,10:00-12:00\n", "refresh-schedule: foo\n", `invalid "refresh-schedule" header: .*`},
+		{"  - 2.0/candidate\n", "  - 2.0\n", `invalid channel "2.0" in "allowed-channels" header: must be a risk optionally preceded by a track`},
+		{"  - 2.0/candidate\n", "  - 2.0/candidate/fix\n", `invalid channel "2.0/candidate/fix" in "allowed-channels" header: must be a risk optionally preceded by a track`},
+		{"  - 2.0/candidate\n", "  - a/b/c/d\n", `invalid channel "a/b/c/d" in "allowed-channels" header: .*`},
+		{dgs.tsLine, "timestamp: 12:30\n", `"timestamp" header is not a RFC3339 date: .*`},
+	}
+
+	for _, test := range invalidTests {
+		invalid := strings.Replace(encoded, test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, deviceGroupErrPrefix+test.expectedErr)
+	}
+}
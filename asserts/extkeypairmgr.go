@@ -22,6 +22,7 @@ package asserts
 import (
 	"bytes"
 	"crypto"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/json"
@@ -43,6 +44,7 @@ type ExternalKeyInfo struct {
 // TODO: points to interface docs
 type ExternalKeypairManager struct {
 	keyMgrPath string
+	ed25519    bool
 	nameToID   map[string]string
 	cache      map[string]*cachedExtKey
 }
@@ -101,6 +103,8 @@ func (em *ExternalKeypairManager) checkFeatures() error {
 	if !strutil.ListContains(feats.PublicKeys, "DER") {
 		return fmt.Errorf("external keypair manager %q missing support for public key DER output format", em.keyMgrPath)
 	}
+	// ed25519 keys are optional
+	em.ed25519 = strutil.ListContains(feats.Signing, "Ed25519")
 	return nil
 }
 
@@ -124,12 +128,18 @@ func (em *ExternalKeypairManager) findByName(name string) (PublicKey, *rsa.Publi
 	if err != nil {
 		return nil, nil, fmt.Errorf("cannot decode external key %q: %v", name, err)
 	}
-	rsaPub, ok := pubk.(*rsa.PublicKey)
-	if !ok {
-		return nil, nil, fmt.Errorf("expected RSA public key, got instead: %T", pubk)
+	switch k := pubk.(type) {
+	case *rsa.PublicKey:
+		return RSAPublicKey(k), k, nil
+	case ed25519.PublicKey:
+		if !em.ed25519 {
+			return nil, nil, fmt.Errorf("external keypair manager %q missing support for Ed25519 signing needed by key %q", em.keyMgrPath, name)
+		}
+		// there is no RSA public key
+		return Ed25519PublicKey(k), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("expected RSA or ed25519 public key, got instead: %T", pubk)
 	}
-	pubKey := RSAPublicKey(rsaPub)
-	return pubKey, rsaPub, nil
 }
 
 func (em *ExternalKeypairManager) Export(keyName string) ([]byte, error) {
@@ -152,18 +162,31 @@ func (em *ExternalKeypairManager) loadKey(name string) (*cachedExtKey, error) {
 	id = pubKey.ID()
 	em.nameToID[name] = id
 	cachedKey := &cachedExtKey{
-		pubKey: pubKey,
-		signer: &extSigner{
+		keyName: name,
+		pubKey:  pubKey,
+	}
+	if rsaPub != nil {
+		cachedKey.signer = &extSigner{
 			keyName: name,
 			rsaPub:  rsaPub,
 			// signWith is filled later
-		},
+		}
 	}
 	em.cache[id] = cachedKey
 	return cachedKey, nil
 }
 
 func (em *ExternalKeypairManager) privateKey(cachedKey *cachedExtKey) PrivateKey {
+	if cachedKey.privKey == nil && cachedKey.signer == nil {
+		keyName := cachedKey.keyName
+		cachedKey.privKey = &extEd25519PrivateKey{
+			pubKey: cachedKey.pubKey,
+			from:   fmt.Sprintf("external keypair manager %q", em.keyMgrPath),
+			doSign: func(content []byte) ([]byte, error) {
+				return em.signEd25519With(keyName, content)
+			},
+		}
+	}
 	if cachedKey.privKey == nil {
 		extSigner := cachedKey.signer
 		// fill signWith
@@ -273,8 +296,19 @@ func (em *ExternalKeypairManager) signWith(keyName string, digest []byte) (signa
 	return signature, nil
 }
 
+func (em *ExternalKeypairManager) signEd25519With(keyName string, content []byte) (signature []byte, err error) {
+	// ed25519 signs the content itself, not a digest of it
+	err = em.keyMgr("sign", []string{"-m", "Ed25519", "-k", keyName}, content, &signature)
+	if err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
 type cachedExtKey struct {
+	keyName string
 	pubKey  PublicKey
+	// signer is nil for ed25519 keys
 	signer  *extSigner
 	privKey PrivateKey
 }
@@ -20,6 +20,7 @@
 package asserts_test
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -251,6 +252,99 @@ func (s *extKeypairMgrSuite) TestSignFlow(c *C) {
 	})
 }
 
+func mockEd25519ExtKeyMgr(c *C, features string) (pgm *testutil.MockCmd, pubKey asserts.PublicKey) {
+	tmpdir := c.MkDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+	derPub, err := x509.MarshalPKIXPublicKey(pub)
+	c.Assert(err, IsNil)
+	err = ioutil.WriteFile(filepath.Join(tmpdir, "ed.pub"), derPub, 0644)
+	c.Assert(err, IsNil)
+	derPriv, err := x509.MarshalPKCS8PrivateKey(priv)
+	c.Assert(err, IsNil)
+	err = ioutil.WriteFile(filepath.Join(tmpdir, "ed.key"), derPriv, 0600)
+	c.Assert(err, IsNil)
+
+	pgm = testutil.MockCommand(c, "keymgr", fmt.Sprintf(`
+keydir=%q
+case $1 in
+  features)
+    echo '%s'
+    ;;
+  key-names)
+    echo '{"key-names": ["ed"]}'
+    ;;
+  get-public-key)
+    cat ${keydir}/"$5".pub
+    ;;
+  sign)
+    cat > ${keydir}/content
+    openssl pkeyutl -sign -rawin -keyform DER -inkey ${keydir}/"$5".key -in ${keydir}/content
+    ;;
+  *)
+    exit 1
+    ;;
+esac
+`, tmpdir, features))
+	return pgm, asserts.Ed25519PublicKey(pub)
+}
+
+func (s *extKeypairMgrSuite) TestSignFlowEd25519(c *C) {
+	// the signing uses openssl
+	_, err := exec.LookPath("openssl")
+	if err != nil {
+		c.Skip("cannot locate openssl on this system to test signing")
+	}
+	pgm, pubKey := mockEd25519ExtKeyMgr(c, `{"signing":["RSA-PKCS", "Ed25519"] , "public-keys":["DER"]}`)
+	defer pgm.Restore()
+
+	kmgr, err := asserts.NewExternalKeypairManager("keymgr")
+	c.Assert(err, IsNil)
+
+	pk, err := kmgr.GetByName("ed")
+	c.Assert(err, IsNil)
+	c.Check(pk.PublicKey().ID(), Equals, pubKey.ID())
+
+	keys, err := kmgr.List()
+	c.Assert(err, IsNil)
+	c.Check(keys, DeepEquals, []asserts.ExternalKeyInfo{
+		{Name: "ed", ID: pubKey.ID()},
+	})
+
+	exported, err := kmgr.Export("ed")
+	c.Assert(err, IsNil)
+	expected, err := asserts.EncodePublicKey(pubKey)
+	c.Assert(err, IsNil)
+	c.Check(exported, DeepEquals, expected)
+
+	signDB, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: kmgr,
+	})
+	c.Assert(err, IsNil)
+	pgm.ForgetCalls()
+	a, err := signDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "dev1-id",
+		"primary-key":  "a",
+	}, nil, pubKey.ID())
+	c.Assert(err, IsNil)
+	c.Check(asserts.SignatureCheck(a, pubKey), IsNil)
+
+	c.Check(pgm.Calls(), DeepEquals, [][]string{
+		{"keymgr", "sign", "-m", "Ed25519", "-k", "ed"},
+	})
+}
+
+func (s *extKeypairMgrSuite) TestEd25519NotSupported(c *C) {
+	pgm, _ := mockEd25519ExtKeyMgr(c, `{"signing":["RSA-PKCS"] , "public-keys":["DER"]}`)
+	defer pgm.Restore()
+
+	kmgr, err := asserts.NewExternalKeypairManager("keymgr")
+	c.Assert(err, IsNil)
+
+	_, err = kmgr.GetByName("ed")
+	c.Check(err, ErrorMatches, `external keypair manager "keymgr" missing support for Ed25519 signing needed by key "ed"`)
+}
+
 func (s *extKeypairMgrSuite) TestExport(c *C) {
 	kmgr, err := asserts.NewExternalKeypairManager("keymgr")
 	c.Assert(err, IsNil)
@@ -29,6 +29,7 @@ import (
 	"unicode"
 
 	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/strutil"
 )
 
 // AttrMatchContext has contextual helpers for evaluating attribute constraints.
@@ -44,6 +45,8 @@ const (
 	deviceScopeConstraintsFeature = "device-scope-constraints"
 	// feature label for plug-names/slot-names constraints
 	nameConstraintsFeature = "name-constraints"
+	// feature label for $VERSION()
+	versionConstraintsFeature = "version-constraints"
 )
 
 type attrMatcher interface {
@@ -103,6 +106,9 @@ func compileAttrMatcher(cc compileContext, constraints interface{}) (attrMatcher
 			if x == "$MISSING" {
 				return missingAttrMatcher{}, nil
 			}
+			if strings.HasPrefix(x, "$VERSION(") {
+				return compileVersionAttrMatcher(cc, x)
+			}
 			return compileEvalAttrMatcher(cc, x)
 		}
 		return compileRegexpAttrMatcher(cc, x)
@@ -238,6 +244,44 @@ func (matcher evalAttrMatcher) match(apath string, v interface{}, ctx AttrMatchC
 	return nil
 }
 
+type versionAttrMatcher struct {
+	*strutil.VersionRange
+}
+
+var (
+	validVersionAttrMatcher = regexp.MustCompile(`^\$VERSION\((.+)\)$`)
+)
+
+func compileVersionAttrMatcher(cc compileContext, s string) (attrMatcher, error) {
+	m := validVersionAttrMatcher.FindStringSubmatch(s)
+	if len(m) == 0 {
+		return nil, fmt.Errorf("cannot compile %q constraint %q: not a valid $VERSION() constraint", cc, s)
+	}
+	vr, err := strutil.ParseVersionRange(m[1])
+	if err != nil {
+		return nil, fmt.Errorf("cannot compile %q constraint %q: %v", cc, s, err)
+	}
+	return versionAttrMatcher{vr}, nil
+}
+
+func (matcher versionAttrMatcher) feature(flabel string) bool {
+	return flabel == versionConstraintsFeature
+}
+
+func (matcher versionAttrMatcher) match(apath string, v interface{}, ctx AttrMatchContext) error {
+	switch x := v.(type) {
+	case string:
+		if !matcher.VersionRange.Match(x) {
+			return fmt.Errorf("attribute %q value %q is not in version range %q", apath, x, matcher.VersionRange)
+		}
+		return nil
+	case []interface{}:
+		return matchList(apath, matcher, x, ctx)
+	default:
+		return fmt.Errorf("attribute %q must be a string or list of strings to match a version range", apath)
+	}
+}
+
 type regexpAttrMatcher struct {
 	*regexp.Regexp
 }
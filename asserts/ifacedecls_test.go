@@ -455,6 +455,52 @@ bar: bar.baz
 	c.Check(err, ErrorMatches, `attribute "foo" does not match \$SLOT\(foo\): foo != other-value`)
 }
 
+func (s *attrConstraintsSuite) TestVersionCheck(c *C) {
+	m, err := asserts.ParseHeaders([]byte(`attrs:
+  version: $VERSION(>=1.2 <2.0)`))
+	c.Assert(err, IsNil)
+
+	cstrs, err := asserts.CompileAttributeConstraints(m["attrs"].(map[string]interface{}))
+	c.Assert(err, IsNil)
+	c.Check(asserts.RuleFeature(cstrs, "version-constraints"), Equals, true)
+	c.Check(asserts.RuleFeature(cstrs, "dollar-attr-constraints"), Equals, false)
+
+	err = cstrs.Check(attrs(`
+version: "1.10"
+`), nil)
+	c.Check(err, IsNil)
+
+	err = cstrs.Check(attrs(`
+version: ["1.2", "1.9~pre1"]
+`), nil)
+	c.Check(err, IsNil)
+
+	err = cstrs.Check(attrs(`
+version: "2.0"
+`), nil)
+	c.Check(err, ErrorMatches, `attribute "version" value "2.0" is not in version range ">=1.2 <2.0"`)
+
+	err = cstrs.Check(attrs(`
+version: ["1.2", "1.1"]
+`), nil)
+	c.Check(err, ErrorMatches, `attribute "version\.1" value "1.1" is not in version range ">=1.2 <2.0"`)
+
+	err = cstrs.Check(attrs(`
+version: 1
+`), nil)
+	c.Check(err, ErrorMatches, `attribute "version" must be a string or list of strings to match a version range`)
+
+	_, err = asserts.CompileAttributeConstraints(map[string]interface{}{
+		"foo": "$VERSION()",
+	})
+	c.Check(err, ErrorMatches, `cannot compile "foo" constraint "\$VERSION\(\)": not a valid \$VERSION\(\) constraint`)
+
+	_, err = asserts.CompileAttributeConstraints(map[string]interface{}{
+		"foo": "$VERSION(1.2)",
+	})
+	c.Check(err, ErrorMatches, `cannot compile "foo" constraint "\$VERSION\(1.2\)": invalid version constraint "1.2": missing operator`)
+}
+
 func (s *attrConstraintsSuite) TestMatchingListsMap(c *C) {
 	m, err := asserts.ParseHeaders([]byte(`attrs:
   foo:
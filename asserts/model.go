@@ -51,6 +51,10 @@ type ModelSnap struct {
 	PinnedTrack string
 	// Presence is one of: required|optional
 	Presence string
+	// Replaces is the name of a snap from the same publisher that this
+	// snap replaces, its data, connections and service state get
+	// migrated to this snap when remodeling
+	Replaces string
 }
 
 // SnapName implements naming.SnapRef.
@@ -181,9 +185,117 @@ func checkExtendedSnaps(extendedSnaps interface{}, base string, grade ModelGrade
 		}
 	}
 
+	for _, modelSnap := range modelSnaps.snapsNoEssential {
+		if modelSnap.Replaces != "" && seen[modelSnap.Replaces] {
+			return nil, fmt.Errorf("snap %q replaced by snap %q cannot be listed in the model as well", modelSnap.Replaces, modelSnap.Name)
+		}
+	}
+
 	return &modelSnaps, nil
 }
 
+// ModelValidationSetMode is the mode in which a validation set declared
+// by a model is to be applied.
+type ModelValidationSetMode string
+
+const (
+	// ModelValidationSetModePreferEnforce means the validation set
+	// should be enforced when possible.
+	ModelValidationSetModePreferEnforce ModelValidationSetMode = "prefer-enforce"
+	// ModelValidationSetModeEnforce means the validation set must always
+	// be enforced.
+	ModelValidationSetModeEnforce ModelValidationSetMode = "enforce"
+)
+
+var validModelValidationSetModes = []string{string(ModelValidationSetModePreferEnforce), string(ModelValidationSetModeEnforce)}
+
+// ModelValidationSet represents a reference to a validation set
+// assertion as declared by a model.
+type ModelValidationSet struct {
+	// AccountID is the account of the validation set, defaults to the
+	// brand of the model.
+	AccountID string
+	// Name is the name of the validation set.
+	Name string
+	// Sequence is the sequence the validation set is pinned to, 0 if
+	// not pinned.
+	Sequence int
+	// Mode is the mode in which the validation set is applied.
+	Mode ModelValidationSetMode
+}
+
+// SequenceKey returns the sequence key of the validation set.
+func (mvs *ModelValidationSet) SequenceKey() string {
+	return fmt.Sprintf("%s/%s", mvs.AccountID, mvs.Name)
+}
+
+func checkModelValidationSets(headers map[string]interface{}, brandID string) ([]*ModelValidationSet, error) {
+	const wrongHeaderType = `"validation-sets" header must be a list of maps`
+
+	valSetsHeader, ok := headers["validation-sets"]
+	if !ok {
+		return nil, nil
+	}
+	entries, ok := valSetsHeader.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf(wrongHeaderType)
+	}
+
+	valSets := make([]*ModelValidationSet, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		valSet, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf(wrongHeaderType)
+		}
+		name, err := checkStringMatchesWhat(valSet, "name", "of validation set", validValidationSetName)
+		if err != nil {
+			return nil, err
+		}
+		what := fmt.Sprintf("of validation set %q", name)
+
+		accountID := brandID
+		if _, ok := valSet["account-id"]; ok {
+			accountID, err = checkStringMatchesWhat(valSet, "account-id", what, validAccountID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		sequence := 0
+		if _, ok := valSet["sequence"]; ok {
+			sequence, err = checkIntWhat(valSet, "sequence", what)
+			if err != nil {
+				return nil, err
+			}
+			if sequence < 1 {
+				return nil, fmt.Errorf("\"sequence\" %s must be >=1: %v", what, sequence)
+			}
+		}
+
+		mode, err := checkNotEmptyStringWhat(valSet, "mode", what)
+		if err != nil {
+			return nil, err
+		}
+		if !strutil.ListContains(validModelValidationSetModes, mode) {
+			return nil, fmt.Errorf("\"mode\" %s must be %s, not %q", what, strings.Join(validModelValidationSetModes, "|"), mode)
+		}
+
+		mvs := &ModelValidationSet{
+			AccountID: accountID,
+			Name:      name,
+			Sequence:  sequence,
+			Mode:      ModelValidationSetMode(mode),
+		}
+		if seen[mvs.SequenceKey()] {
+			return nil, fmt.Errorf("cannot list the same validation set %q multiple times", mvs.SequenceKey())
+		}
+		seen[mvs.SequenceKey()] = true
+		valSets = append(valSets, mvs)
+	}
+	return valSets, nil
+}
+
 var (
 	validSnapTypes     = []string{"app", "base", "gadget", "kernel", "core", "snapd"}
 	validSnapMode      = regexp.MustCompile("^[a-z][-a-z]+$")
@@ -256,6 +368,22 @@ func checkModelSnap(snap map[string]interface{}, grade ModelGrade) (*ModelSnap,
 		return nil, fmt.Errorf("presence of snap %q must be one of required|optional", name)
 	}
 
+	replaces, err := checkOptionalStringWhat(snap, "replaces", what)
+	if err != nil {
+		return nil, err
+	}
+	if replaces != "" {
+		if err := naming.ValidateSnap(replaces); err != nil {
+			return nil, fmt.Errorf("invalid snap name %q replaced by snap %q", replaces, name)
+		}
+		if replaces == name {
+			return nil, fmt.Errorf("snap %q cannot replace itself", name)
+		}
+		if typ != "app" {
+			return nil, fmt.Errorf("only app snaps can replace other snaps, snap %q is of type %q", name, typ)
+		}
+	}
+
 	return &ModelSnap{
 		Name:           name,
 		SnapID:         snapID,
@@ -263,6 +391,7 @@ func checkModelSnap(snap map[string]interface{}, grade ModelGrade) (*ModelSnap,
 		Modes:          modes, // can be empty
 		DefaultChannel: defaultChannel,
 		Presence:       presence, // can be empty
+		Replaces:       replaces, // can be empty
 	}, nil
 }
 
@@ -403,7 +532,10 @@ type Model struct {
 
 	serialAuthority  []string
 	sysUserAuthority []string
-	timestamp        time.Time
+
+	validationSets []*ModelValidationSet
+
+	timestamp time.Time
 }
 
 // BrandID returns the brand identifier. Same as the authority id.
@@ -552,6 +684,12 @@ func (mod *Model) SystemUserAuthority() []string {
 	return mod.sysUserAuthority
 }
 
+// ValidationSets returns the validation sets declared by the model, in
+// the order of mention.
+func (mod *Model) ValidationSets() []*ModelValidationSet {
+	return mod.validationSets
+}
+
 // Timestamp returns the time when the model assertion was issued.
 func (mod *Model) Timestamp() time.Time {
 	return mod.timestamp
@@ -824,6 +962,11 @@ func assembleModel(assert assertionBase) (Assertion, error) {
 		return nil, err
 	}
 
+	validationSets, err := checkModelValidationSets(assert.headers, brandID)
+	if err != nil {
+		return nil, err
+	}
+
 	timestamp, err := checkRFC3339Date(assert.headers, "timestamp")
 	if err != nil {
 		return nil, err
@@ -852,6 +995,7 @@ func assembleModel(assert assertionBase) (Assertion, error) {
 		numEssentialSnaps:          numEssentialSnaps,
 		serialAuthority:            serialAuthority,
 		sysUserAuthority:           sysUserAuthority,
+		validationSets:             validationSets,
 		timestamp:                  timestamp,
 	}, nil
 }
@@ -402,6 +402,41 @@ func (mods *modelSuite) TestDecodeSystemUserAuthorityIsOptional(c *C) {
 	c.Check(model.SystemUserAuthority(), DeepEquals, []string{"brand-id1", "foo", "bar"})
 }
 
+func (mods *modelSuite) TestDecodeValidationSets(c *C) {
+	withTimestamp := strings.Replace(modelExample, "TSLINE", mods.tsLine, 1)
+	a, err := asserts.Decode([]byte(withTimestamp))
+	c.Assert(err, IsNil)
+	c.Check(a.(*asserts.Model).ValidationSets(), HasLen, 0)
+
+	encoded := strings.Replace(withTimestamp, sysUserAuths, sysUserAuths+`validation-sets:
+  -
+    name: base-set
+    mode: enforce
+  -
+    account-id: foo
+    name: other-set
+    sequence: 3
+    mode: prefer-enforce
+`, 1)
+	a, err = asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	model := a.(*asserts.Model)
+	// the account defaults to the brand
+	c.Check(model.ValidationSets(), DeepEquals, []*asserts.ModelValidationSet{
+		{
+			AccountID: "brand-id1",
+			Name:      "base-set",
+			Mode:      asserts.ModelValidationSetModeEnforce,
+		}, {
+			AccountID: "foo",
+			Name:      "other-set",
+			Sequence:  3,
+			Mode:      asserts.ModelValidationSetModePreferEnforce,
+		},
+	})
+	c.Check(model.ValidationSets()[1].SequenceKey(), Equals, "foo/other-set")
+}
+
 func (mods *modelSuite) TestDecodeKernelTrack(c *C) {
 	withTimestamp := strings.Replace(modelExample, "TSLINE", mods.tsLine, 1)
 	encoded := strings.Replace(withTimestamp, "kernel: baz-linux\n", "kernel: baz-linux=18\n", 1)
@@ -481,6 +516,16 @@ func (mods *modelSuite) TestDecodeInvalid(c *C) {
 		{sysUserAuths, "system-user-authority:\n  a: 1\n", `"system-user-authority" header must be '\*' or a list of account ids`},
 		{sysUserAuths, "system-user-authority:\n  - 5_6\n", `"system-user-authority" header must be '\*' or a list of account ids`},
 		{reqSnaps, "grade: dangerous\n", `cannot specify a grade for model without the extended snaps header`},
+		{reqSnaps, "validation-sets: foo\n", `"validation-sets" header must be a list of maps`},
+		{reqSnaps, "validation-sets:\n  - foo\n", `"validation-sets" header must be a list of maps`},
+		{reqSnaps, "validation-sets:\n  -\n    mode: enforce\n", `"name" of validation set is mandatory`},
+		{reqSnaps, "validation-sets:\n  -\n    name: Set\n    mode: enforce\n", `"name" of validation set contains invalid characters: "Set"`},
+		{reqSnaps, "validation-sets:\n  -\n    account-id: 5_6\n    name: set\n    mode: enforce\n", `"account-id" of validation set "set" contains invalid characters: "5_6"`},
+		{reqSnaps, "validation-sets:\n  -\n    name: set\n    sequence: x\n    mode: enforce\n", `"sequence" of validation set "set" is not an integer: x`},
+		{reqSnaps, "validation-sets:\n  -\n    name: set\n    sequence: 0\n    mode: enforce\n", `"sequence" of validation set "set" must be >=1: 0`},
+		{reqSnaps, "validation-sets:\n  -\n    name: set\n", `"mode" of validation set "set" is mandatory`},
+		{reqSnaps, "validation-sets:\n  -\n    name: set\n    mode: monitor\n", `"mode" of validation set "set" must be prefer-enforce\|enforce, not "monitor"`},
+		{reqSnaps, "validation-sets:\n  -\n    name: set\n    mode: enforce\n  -\n    account-id: brand-id1\n    name: set\n    mode: enforce\n", `cannot list the same validation set "brand-id1/set" multiple times`},
 	}
 
 	for _, test := range invalidTests {
@@ -777,6 +822,29 @@ func (mods *modelSuite) TestCore20ExplictSnapd(c *C) {
 	})
 }
 
+func (mods *modelSuite) TestCore20SnapReplaces(c *C) {
+	encoded := strings.Replace(core20ModelExample, "TSLINE", mods.tsLine, 1)
+	encoded = strings.Replace(encoded, "OTHER", `  -
+    name: newapp
+    id: newappididididididididididididid
+    replaces: oldapp
+`, 1)
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	model := a.(*asserts.Model)
+	snaps := model.SnapsWithoutEssential()
+	newApp := snaps[len(snaps)-1]
+	c.Check(newApp, DeepEquals, &asserts.ModelSnap{
+		Name:           "newapp",
+		SnapID:         "newappididididididididididididid",
+		SnapType:       "app",
+		Modes:          []string{"run"},
+		DefaultChannel: "latest/stable",
+		Presence:       "required",
+		Replaces:       "oldapp",
+	})
+}
+
 func (mods *modelSuite) TestCore20GradeOptionalDefaultSigned(c *C) {
 	encoded := strings.Replace(core20ModelExample, "TSLINE", mods.tsLine, 1)
 	encoded = strings.Replace(encoded, "OTHER", "", 1)
@@ -929,6 +997,11 @@ func (mods *modelSuite) TestCore20DecodeInvalid(c *C) {
 		{"OTHER", "kernel: foo\n", `cannot specify separate "kernel" header once using the extended snaps header`},
 		{"OTHER", "gadget: foo\n", `cannot specify separate "gadget" header once using the extended snaps header`},
 		{"OTHER", "required-snaps:\n  - foo\n", `cannot specify separate "required-snaps" header once using the extended snaps header`},
+		{"presence: optional\n", "presence: optional\n    replaces:\n      - x\n", `"replaces" of snap "myappopt" must be a string`},
+		{"presence: optional\n", "presence: optional\n    replaces: old_app\n", `invalid snap name "old_app" replaced by snap "myappopt"`},
+		{"presence: optional\n", "presence: optional\n    replaces: myappopt\n", `snap "myappopt" cannot replace itself`},
+		{"presence: optional\n", "presence: optional\n    replaces: myapp\n", `snap "myapp" replaced by snap "myappopt" cannot be listed in the model as well`},
+		{"type: base\n", "type: base\n    replaces: old-base\n", `only app snaps can replace other snaps, snap "other-base" is of type "base"`},
 		{"grade: secured\n", "grade: foo\n", `grade for model must be secured|signed|dangerous`},
 		{"storage-safety: encrypted\n", "storage-safety: foo\n", `storage-safety for model must be encrypted\|prefer-encrypted\|prefer-unencrypted, not "foo"`},
 		{"storage-safety: encrypted\n", "storage-safety: prefer-unencrypted\n", `secured grade model must not have storage-safety overridden, only "encrypted" is valid`},
@@ -948,6 +948,36 @@ func (p *Pool) AddSequenceToUpdate(toUpdate *AtSequence, group string) error {
 	return nil
 }
 
+// poolFetcher implements Fetcher on top of a Pool.
+type poolFetcher struct {
+	p *Pool
+}
+
+// Fetcher returns a Fetcher that instead of retrieving assertions
+// one at a time adds the ones referenced via Fetch to the Pool as
+// unresolved, each in its own group named after ref.Unique(). They can
+// then be resolved, together with their prerequisites, in batches
+// using the usual ToResolve and Add/AddBatch loop. Any error about an
+// assertion, including the ones about unresolvable or circular
+// prerequisites, is then associated with the groups requiring it and
+// can be retrieved with Err or Errors after CommitTo.
+// Save is not supported by the returned Fetcher.
+func (p *Pool) Fetcher() Fetcher {
+	return &poolFetcher{p: p}
+}
+
+func (f *poolFetcher) Fetch(ref *Ref) error {
+	at := &AtRevision{
+		Ref:      *ref,
+		Revision: RevisionNotKnown,
+	}
+	return f.p.AddUnresolved(at, ref.Unique())
+}
+
+func (f *poolFetcher) Save(a Assertion) error {
+	return fmt.Errorf("internal error: cannot save %v through a Pool fetcher", a.Ref())
+}
+
 // CommitTo adds the assertions from groups without errors to the
 // given assertion database. Commit errors can be retrieved via Err
 // per group. An error is returned directly only if CommitTo is called
@@ -1742,3 +1742,87 @@ func (s *poolSuite) TestBackstore(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(a, NotNil)
 }
+
+func (s *poolSuite) resolvePool(c *C, pool *asserts.Pool, avail ...asserts.Assertion) {
+	byUnique := make(map[string]asserts.Assertion, len(avail))
+	for _, a := range avail {
+		byUnique[a.Ref().Unique()] = a
+	}
+	for {
+		toResolve, _, err := pool.ToResolve()
+		c.Assert(err, IsNil)
+		if len(toResolve) == 0 {
+			break
+		}
+		for g, ats := range toResolve {
+			for _, at := range ats {
+				a := byUnique[at.Unique()]
+				if a == nil {
+					notFound := &asserts.NotFoundError{Type: at.Type}
+					c.Assert(pool.AddError(notFound, &at.Ref), IsNil)
+					continue
+				}
+				_, err := pool.Add(a, g)
+				c.Assert(err, IsNil)
+			}
+		}
+	}
+}
+
+func (s *poolSuite) TestFetcher(c *C) {
+	pool := asserts.NewPool(s.db, 64)
+
+	f := pool.Fetcher()
+	c.Assert(f.Fetch(s.rev1_1111.Ref()), IsNil)
+	c.Assert(f.Fetch(s.rev2_2222.Ref()), IsNil)
+
+	// developer2 declaration is not available
+	s.resolvePool(c, pool, s.hub.StoreAccountKey(""), s.dev1Acct, s.dev2Acct, s.decl1, s.rev1_1111, s.rev2_2222)
+
+	err := pool.CommitTo(s.db)
+	c.Assert(err, IsNil)
+
+	c.Check(pool.Errors(), DeepEquals, map[string]error{
+		s.rev2_2222.Ref().Unique(): &asserts.NotFoundError{Type: asserts.TestOnlyDeclType},
+	})
+
+	a, err := s.rev1_1111.Ref().Resolve(s.db.Find)
+	c.Assert(err, IsNil)
+	c.Check(a.(*asserts.TestOnlyRev).H(), Equals, "1111")
+
+	_, err = s.rev2_2222.Ref().Resolve(s.db.Find)
+	c.Check(asserts.IsNotFound(err), Equals, true)
+}
+
+func (s *poolSuite) TestFetcherCircular(c *C) {
+	// an account signed with its own account-key
+	selfSigning := assertstest.NewSigningDB("selfie", testPrivKey2)
+	selfAcct := assertstest.NewAccount(selfSigning, "selfie", map[string]interface{}{
+		"account-id": "selfie",
+	}, "")
+	selfKey := assertstest.NewAccountKey(s.hub, selfAcct, nil, testPrivKey2.PublicKey(), "")
+
+	pool := asserts.NewPool(s.db, 64)
+
+	f := pool.Fetcher()
+	c.Assert(f.Fetch(selfAcct.Ref()), IsNil)
+	c.Assert(f.Fetch(s.decl1.Ref()), IsNil)
+
+	s.resolvePool(c, pool, s.hub.StoreAccountKey(""), selfAcct, selfKey, s.dev1Acct, s.decl1)
+
+	err := pool.CommitTo(s.db)
+	c.Assert(err, IsNil)
+
+	c.Check(pool.Err(selfAcct.Ref().Unique()), ErrorMatches, "circular assertions are not expected: .*")
+	c.Check(pool.Err(s.decl1.Ref().Unique()), IsNil)
+
+	_, err = s.decl1.Ref().Resolve(s.db.Find)
+	c.Check(err, IsNil)
+}
+
+func (s *poolSuite) TestFetcherSaveUnsupported(c *C) {
+	pool := asserts.NewPool(s.db, 64)
+
+	err := pool.Fetcher().Save(s.decl1)
+	c.Check(err, ErrorMatches, `internal error: cannot save .* through a Pool fetcher`)
+}
@@ -0,0 +1,114 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/registry"
+	"github.com/snapcore/snapd/snap/configschema"
+)
+
+// Registry holds a registry assertion, which is a definition by an
+// account of access views over a configuration registry shared between
+// snaps. The body, if not empty, holds the JSON schema the data of the
+// registry must comply with.
+type Registry struct {
+	assertionBase
+
+	registry  *registry.Registry
+	timestamp time.Time
+}
+
+// AccountID returns the identifier of the account that defined the
+// registry.
+func (r *Registry) AccountID() string {
+	return r.HeaderString("account-id")
+}
+
+// Name returns the name of the registry.
+func (r *Registry) Name() string {
+	return r.HeaderString("name")
+}
+
+// Summary returns the optional summary of the registry.
+func (r *Registry) Summary() string {
+	return r.HeaderString("summary")
+}
+
+// Registry returns the registry defined by the assertion.
+func (r *Registry) Registry() *registry.Registry {
+	return r.registry
+}
+
+// Timestamp returns the time when the registry was defined.
+func (r *Registry) Timestamp() time.Time {
+	return r.timestamp
+}
+
+func assembleRegistry(assert assertionBase) (Assertion, error) {
+	authorityID := assert.AuthorityID()
+	accountID := assert.HeaderString("account-id")
+	if accountID != authorityID {
+		return nil, fmt.Errorf("authority-id and account-id must match, registry assertions are expected to be signed by the issuer account: %q != %q", authorityID, accountID)
+	}
+
+	name, err := checkNotEmptyString(assert.headers, "name")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := checkOptionalString(assert.headers, "summary"); err != nil {
+		return nil, err
+	}
+
+	views, err := checkMap(assert.headers, "views")
+	if err != nil {
+		return nil, err
+	}
+	if views == nil {
+		return nil, fmt.Errorf(`"views" header is mandatory`)
+	}
+
+	var schema *configschema.Schema
+	if len(assert.body) > 0 {
+		schema, err = configschema.Parse(assert.body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid registry schema: %v", err)
+		}
+	}
+
+	reg, err := registry.New(accountID, name, views, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := checkRFC3339Date(assert.headers, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Registry{
+		assertionBase: assert,
+		registry:      reg,
+		timestamp:     timestamp,
+	}, nil
+}
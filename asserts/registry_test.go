@@ -0,0 +1,134 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/registry"
+)
+
+type registrySuite struct {
+	ts     time.Time
+	tsLine string
+}
+
+var _ = Suite(&registrySuite{})
+
+func (s *registrySuite) SetUpSuite(c *C) {
+	s.ts = time.Now().Truncate(time.Second).UTC()
+	s.tsLine = "timestamp: " + s.ts.Format(time.RFC3339) + "\n"
+}
+
+const registrySchema = `{"type": "object", "properties": {"wifi": {"type": "object"}}}`
+
+const registryExample = `type: registry
+authority-id: acme
+account-id: acme
+name: network
+summary: Network configuration
+views:
+  wifi-setup:
+    rules:
+      -
+        request: ssid
+        storage: wifi.ssid
+      -
+        request: password
+        storage: wifi.psk
+        access: write
+` + "TSLINE" +
+	"body-length: BODYLEN\n" +
+	"sign-key-sha3-384: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij" +
+	"\n\n" +
+	"BODY" +
+	"\n\n" +
+	"AXNpZw=="
+
+func (s *registrySuite) encode(body string) string {
+	encoded := strings.Replace(registryExample, "TSLINE", s.tsLine, 1)
+	encoded = strings.Replace(encoded, "BODYLEN", fmt.Sprint(len(body)), 1)
+	return strings.Replace(encoded, "BODY", body, 1)
+}
+
+func (s *registrySuite) TestDecodeOK(c *C) {
+	a, err := asserts.Decode([]byte(s.encode(registrySchema)))
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.RegistryType)
+	reg := a.(*asserts.Registry)
+	c.Check(reg.AuthorityID(), Equals, "acme")
+	c.Check(reg.AccountID(), Equals, "acme")
+	c.Check(reg.Name(), Equals, "network")
+	c.Check(reg.Summary(), Equals, "Network configuration")
+	c.Check(reg.Timestamp(), Equals, s.ts)
+
+	r := reg.Registry()
+	c.Assert(r, NotNil)
+	c.Check(r.Account, Equals, "acme")
+	c.Check(r.Name, Equals, "network")
+	c.Check(r.Schema, NotNil)
+	c.Check(r.Views(), DeepEquals, []string{"wifi-setup"})
+
+	bag := registry.NewJSONDataBag()
+	view := r.View("wifi-setup")
+	c.Assert(view.Set(bag, "ssid", "home"), IsNil)
+	c.Check(view.Set(bag, "password", "secret"), IsNil)
+	value, err := view.Get(bag, "")
+	c.Assert(err, IsNil)
+	c.Check(value, DeepEquals, map[string]interface{}{"ssid": "home"})
+}
+
+func (s *registrySuite) TestDecodeNoSchema(c *C) {
+	encoded := strings.Replace(s.encode(""), "\n\n\n\n", "\n\n", 1)
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(a.(*asserts.Registry).Registry().Schema, IsNil)
+}
+
+func (s *registrySuite) TestDecodeInvalid(c *C) {
+	const registryErrPrefix = "assertion registry: "
+
+	encoded := s.encode(registrySchema)
+
+	viewsStanza := "views:\n  wifi-setup:\n    rules:\n"
+	invalidTests := []struct{ original, invalid, expectedErr string }{
+		{"account-id: acme\n", "", `"account-id" header is mandatory`},
+		{"account-id: acme\n", "account-id: random\n", `authority-id and account-id must match, registry assertions are expected to be signed by the issuer account: "acme" != "random"`},
+		{"name: network\n", "", `"name" header is mandatory`},
+		{"name: network\n", "name: Network\n", `invalid registry name "Network"`},
+		{"summary: Network configuration\n", "summary:\n  - foo\n", `"summary" header must be a string`},
+		{viewsStanza, "views: foo\nfoo:\n  wifi-setup:\n    rules:\n", `"views" header must be a map`},
+		{viewsStanza, "foo:\n  wifi-setup:\n    rules:\n", `"views" header is mandatory`},
+		{"        access: write\n", "        access: all\n", `cannot define view "wifi-setup": invalid rule for request "password": .*`},
+		{registrySchema, `{"type": "foo"}` + strings.Repeat(" ", len(registrySchema)-len(`{"type": "foo"}`)), `invalid registry schema: .*`},
+		{s.tsLine, "timestamp: 12:30\n", `"timestamp" header is not a RFC3339 date: .*`},
+	}
+
+	for _, test := range invalidTests {
+		invalid := strings.Replace(encoded, test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, registryErrPrefix+test.expectedErr, Commentf("%s", test.invalid))
+	}
+}
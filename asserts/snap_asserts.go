@@ -175,6 +175,9 @@ func snapDeclarationFormatAnalyze(headers map[string]interface{}, body []byte) (
 		if rule.feature(nameConstraintsFeature) {
 			setFormatNum(4)
 		}
+		if rule.feature(versionConstraintsFeature) {
+			setFormatNum(5)
+		}
 	})
 	if err != nil {
 		return 0, err
@@ -194,6 +197,9 @@ func snapDeclarationFormatAnalyze(headers map[string]interface{}, body []byte) (
 		if rule.feature(nameConstraintsFeature) {
 			setFormatNum(4)
 		}
+		if rule.feature(versionConstraintsFeature) {
+			setFormatNum(5)
+		}
 	})
 	if err != nil {
 		return 0, err
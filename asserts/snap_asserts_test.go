@@ -546,6 +546,24 @@ func (sds *snapDeclSuite) TestSuggestedFormat(c *C) {
 			c.Check(fmtnum, Equals, 4)
 		}
 	}
+
+	// $VERSION() => format 5
+	for _, side := range []string{"plugs", "slots"} {
+		headers := map[string]interface{}{
+			side: map[string]interface{}{
+				"interface3": map[string]interface{}{
+					"allow-auto-connection": map[string]interface{}{
+						"slot-attributes": map[string]interface{}{
+							"version": "$VERSION(>=1.2)",
+						},
+					},
+				},
+			},
+		}
+		fmtnum, err = asserts.SuggestFormat(asserts.SnapDeclarationType, headers, nil)
+		c.Assert(err, IsNil)
+		c.Check(fmtnum, Equals, 5)
+	}
 }
 
 func prereqDevAccount(c *C, storeDB assertstest.SignerDB, db *asserts.Database) {
@@ -0,0 +1,192 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/snap/naming"
+)
+
+// HeldSnap holds the details about a snap that a snap-policy holds at a
+// specific revision.
+type HeldSnap struct {
+	Name     string
+	Revision int
+}
+
+// SnapPolicy holds a snap-policy assertion, which is a statement by a
+// brand about the snaps that devices of one of its models must never
+// install, and the snaps that must stay at a specific revision.
+type SnapPolicy struct {
+	assertionBase
+
+	forbidden []string
+	held      []*HeldSnap
+
+	timestamp time.Time
+}
+
+// BrandID returns the brand identifier of the devices the policy applies
+// to.
+func (sp *SnapPolicy) BrandID() string {
+	return sp.HeaderString("brand-id")
+}
+
+// Model returns the model name of the devices the policy applies to.
+func (sp *SnapPolicy) Model() string {
+	return sp.HeaderString("model")
+}
+
+// ForbiddenSnaps returns the names of the snaps that must never be
+// installed.
+func (sp *SnapPolicy) ForbiddenSnaps() []string {
+	return sp.forbidden
+}
+
+// HeldSnaps returns the snaps that must stay at a specific revision.
+func (sp *SnapPolicy) HeldSnaps() []*HeldSnap {
+	return sp.held
+}
+
+// IsForbidden returns whether the snap with the given name must never be
+// installed.
+func (sp *SnapPolicy) IsForbidden(snapName string) bool {
+	for _, name := range sp.forbidden {
+		if name == snapName {
+			return true
+		}
+	}
+	return false
+}
+
+// HeldRevision returns the revision the snap with the given name must
+// stay at, or 0 if the snap is not held.
+func (sp *SnapPolicy) HeldRevision(snapName string) int {
+	for _, held := range sp.held {
+		if held.Name == snapName {
+			return held.Revision
+		}
+	}
+	return 0
+}
+
+// Timestamp returns the time when the snap-policy was issued.
+func (sp *SnapPolicy) Timestamp() time.Time {
+	return sp.timestamp
+}
+
+func checkForbiddenSnaps(headers map[string]interface{}) ([]string, error) {
+	names, err := checkStringList(headers, "forbidden-snaps")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if err := naming.ValidateSnap(name); err != nil {
+			return nil, fmt.Errorf("invalid snap name %q in \"forbidden-snaps\" header", name)
+		}
+	}
+	return names, nil
+}
+
+func checkHeldSnaps(headers map[string]interface{}) ([]*HeldSnap, error) {
+	const wrongHeaderType = `"held-snaps" header must be a list of maps`
+
+	value, ok := headers["held-snaps"]
+	if !ok {
+		return nil, nil
+	}
+	entries, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf(wrongHeaderType)
+	}
+	held := make([]*HeldSnap, 0, len(entries))
+	for _, entry := range entries {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf(wrongHeaderType)
+		}
+		name, err := checkNotEmptyStringWhat(m, "name", "of held snap")
+		if err != nil {
+			return nil, err
+		}
+		if err := naming.ValidateSnap(name); err != nil {
+			return nil, fmt.Errorf("invalid snap name %q in \"held-snaps\" header", name)
+		}
+		revision, err := checkSnapRevisionWhat(m, "revision", fmt.Sprintf("of held snap %q", name))
+		if err != nil {
+			return nil, err
+		}
+		held = append(held, &HeldSnap{Name: name, Revision: revision})
+	}
+	return held, nil
+}
+
+func assembleSnapPolicy(assert assertionBase) (Assertion, error) {
+	authorityID := assert.AuthorityID()
+	brandID := assert.HeaderString("brand-id")
+	if brandID != authorityID {
+		return nil, fmt.Errorf("authority-id and brand-id must match, snap-policy assertions are expected to be signed by the brand: %q != %q", authorityID, brandID)
+	}
+
+	_, err := checkModel(assert.headers)
+	if err != nil {
+		return nil, err
+	}
+
+	forbidden, err := checkForbiddenSnaps(assert.headers)
+	if err != nil {
+		return nil, err
+	}
+	held, err := checkHeldSnaps(assert.headers)
+	if err != nil {
+		return nil, err
+	}
+	if len(forbidden) == 0 && len(held) == 0 {
+		return nil, fmt.Errorf(`at least one of "forbidden-snaps" or "held-snaps" headers must be specified`)
+	}
+
+	seen := make(map[string]bool, len(forbidden)+len(held))
+	for _, name := range forbidden {
+		if seen[name] {
+			return nil, fmt.Errorf("cannot list snap %q more than once", name)
+		}
+		seen[name] = true
+	}
+	for _, h := range held {
+		if seen[h.Name] {
+			return nil, fmt.Errorf("cannot list snap %q more than once", h.Name)
+		}
+		seen[h.Name] = true
+	}
+
+	timestamp, err := checkRFC3339Date(assert.headers, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &SnapPolicy{
+		assertionBase: assert,
+		forbidden:     forbidden,
+		held:          held,
+		timestamp:     timestamp,
+	}, nil
+}
@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts_test
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+)
+
+type snapPolicySuite struct {
+	ts     time.Time
+	tsLine string
+}
+
+var _ = Suite(&snapPolicySuite{})
+
+func (sps *snapPolicySuite) SetUpSuite(c *C) {
+	sps.ts = time.Now().Truncate(time.Second).UTC()
+	sps.tsLine = "timestamp: " + sps.ts.Format(time.RFC3339) + "\n"
+}
+
+const snapPolicyExample = `type: snap-policy
+authority-id: brand-id1
+brand-id: brand-id1
+model: baz-3000
+forbidden-snaps:
+  - foo
+  - bar
+held-snaps:
+  -
+    name: baz
+    revision: 12
+` + "TSLINE" +
+	"body-length: 0\n" +
+	"sign-key-sha3-384: Jv8_JiHiIzJVcO9M55pPdqSDWUvuhfDIBJUS-3VW7F_idjix7Ffn5qMxB21ZQuij" +
+	"\n\n" +
+	"AXNpZw=="
+
+func (sps *snapPolicySuite) TestDecodeOK(c *C) {
+	encoded := strings.Replace(snapPolicyExample, "TSLINE", sps.tsLine, 1)
+
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.SnapPolicyType)
+	sp := a.(*asserts.SnapPolicy)
+	c.Check(sp.AuthorityID(), Equals, "brand-id1")
+	c.Check(sp.Timestamp(), Equals, sps.ts)
+	c.Check(sp.BrandID(), Equals, "brand-id1")
+	c.Check(sp.Model(), Equals, "baz-3000")
+	c.Check(sp.ForbiddenSnaps(), DeepEquals, []string{"foo", "bar"})
+	c.Check(sp.HeldSnaps(), DeepEquals, []*asserts.HeldSnap{{Name: "baz", Revision: 12}})
+
+	c.Check(sp.IsForbidden("foo"), Equals, true)
+	c.Check(sp.IsForbidden("baz"), Equals, false)
+	c.Check(sp.HeldRevision("baz"), Equals, 12)
+	c.Check(sp.HeldRevision("foo"), Equals, 0)
+}
+
+func (sps *snapPolicySuite) TestDecodeOnlyHeld(c *C) {
+	encoded := strings.Replace(snapPolicyExample, "TSLINE", sps.tsLine, 1)
+	encoded = strings.Replace(encoded, "forbidden-snaps:\n  - foo\n  - bar\n", "", 1)
+
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	sp := a.(*asserts.SnapPolicy)
+	c.Check(sp.ForbiddenSnaps(), HasLen, 0)
+	c.Check(sp.IsForbidden("foo"), Equals, false)
+	c.Check(sp.HeldRevision("baz"), Equals, 12)
+}
+
+func (sps *snapPolicySuite) TestDecodeInvalid(c *C) {
+	const snapPolicyErrPrefix = "assertion snap-policy: "
+
+	encoded := strings.Replace(snapPolicyExample, "TSLINE", sps.tsLine, 1)
+
+	forbiddenStanza := "forbidden-snaps:\n  - foo\n  - bar\n"
+	heldStanza := "held-snaps:\n  -\n    name: baz\n    revision: 12\n"
+	invalidTests := []struct{ original, invalid, expectedErr string }{
+		{"brand-id: brand-id1\n", "", `"brand-id" header is mandatory`},
+		{"brand-id: brand-id1\n", "brand-id: random\n", `authority-id and brand-id must match, snap-policy assertions are expected to be signed by the brand: "brand-id1" != "random"`},
+		{"model: baz-3000\n", "", `"model" header is mandatory`},
+		{"model: baz-3000\n", "model: Baz-3000\n", `"model" header cannot contain uppercase letters`},
+		{forbiddenStanza + heldStanza, "", `at least one of "forbidden-snaps" or "held-snaps" headers must be specified`},
+		{forbiddenStanza, "forbidden-snaps: foo\n", `"forbidden-snaps" header must be a list of strings`},
+		{"  - bar\n", "  - -bar\n", `invalid snap name "-bar" in "forbidden-snaps" header`},
+		{"  - bar\n", "  - baz\n", `cannot list snap "baz" more than once`},
+		{heldStanza, "held-snaps: foo\n", `"held-snaps" header must be a list of maps`},
+		{heldStanza, "held-snaps:\n  - foo\n", `"held-snaps" header must be a list of maps`},
+		{"    name: baz\n", "", `"name" of held snap is mandatory`},
+		{"    name: baz\n", "    name: -baz\n", `invalid snap name "-baz" in "held-snaps" header`},
+		{"    revision: 12\n", "", `"revision" of held snap "baz" is mandatory`},
+		{"    revision: 12\n", "    revision: 0\n", `"revision" of held snap "baz" must be >=1: 0`},
+		{sps.tsLine, "timestamp: 12:30\n", `"timestamp" header is not a RFC3339 date: .*`},
+	}
+
+	for _, test := range invalidTests {
+		invalid := strings.Replace(encoded, test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, snapPolicyErrPrefix+test.expectedErr)
+	}
+}
@@ -251,6 +251,65 @@ func (e *snapConflictsError) Error() string {
 	return msg
 }
 
+// configConflict returns an error if the validation sets require
+// different values for the same configuration key of the snap.
+func (c *snapContraints) configConflict() *snapConfigConflictError {
+	// values maps configuration keys to required values to the keys
+	// of the validation sets requiring them
+	values := make(map[string]map[string][]string)
+	for _, rcs := range c.revisions {
+		for _, rc := range rcs {
+			for key, value := range rc.Configuration {
+				if values[key] == nil {
+					values[key] = make(map[string][]string)
+				}
+				values[key][value] = append(values[key][value], rc.validationSetKey)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if len(values[key]) > 1 {
+			for _, which := range values[key] {
+				sort.Strings(which)
+			}
+			return &snapConfigConflictError{
+				name:   c.name,
+				key:    key,
+				values: values[key],
+			}
+		}
+	}
+	return nil
+}
+
+type snapConfigConflictError struct {
+	name string
+	key  string
+	// values maps the different values required for the
+	// configuration key to the keys of the validation-sets requiring
+	// them
+	values map[string][]string
+}
+
+func (e *snapConfigConflictError) Error() string {
+	values := make([]string, 0, len(e.values))
+	for value := range e.values {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	l := make([]string, 0, len(values))
+	for _, value := range values {
+		l = append(l, fmt.Sprintf("%q (%s)", value, strings.Join(e.values[value], ",")))
+	}
+	return fmt.Sprintf("cannot constrain snap %q configuration %q to different values %s", e.name, e.key, strings.Join(l, ", "))
+}
+
 // NewValidationSets returns a new ValidationSets.
 func NewValidationSets() *ValidationSets {
 	return &ValidationSets{
@@ -346,6 +405,16 @@ func (v *ValidationSets) Conflict() error {
 					sets[valsetKey] = v.sets[valsetKey]
 				}
 			}
+			continue
+		}
+		snConfigConflictErr := snConstrs.configConflict()
+		if snConfigConflictErr != nil {
+			snaps[snapID] = snConfigConflictErr
+			for _, valsetKeys := range snConfigConflictErr.values {
+				for _, valsetKey := range valsetKeys {
+					sets[valsetKey] = v.sets[valsetKey]
+				}
+			}
 		}
 	}
 
@@ -511,6 +580,44 @@ func (v *ValidationSets) CheckPresenceRequired(snapRef naming.SnapRef) ([]string
 	return keys, snapRev, nil
 }
 
+// ConfigurationConstraint holds the value required for a configuration
+// key of a snap and the validation sets requiring it.
+type ConfigurationConstraint struct {
+	Value string
+	Sets  []string
+}
+
+// RequiredConfiguration returns the configuration values required for
+// the given snap by the validation sets, keyed by configuration key.
+// The method assumes that validation sets are not in conflict.
+func (v *ValidationSets) RequiredConfiguration(snapRef naming.SnapRef) map[string]*ConfigurationConstraint {
+	cstrs := v.constraintsForSnap(snapRef)
+	if cstrs == nil {
+		return nil
+	}
+
+	var required map[string]*ConfigurationConstraint
+	for _, revCstr := range cstrs.revisions {
+		for _, rc := range revCstr {
+			for key, value := range rc.Configuration {
+				if required == nil {
+					required = make(map[string]*ConfigurationConstraint)
+				}
+				cc := required[key]
+				if cc == nil {
+					cc = &ConfigurationConstraint{Value: value}
+					required[key] = cc
+				}
+				cc.Sets = append(cc.Sets, rc.validationSetKey)
+			}
+		}
+	}
+	for _, cc := range required {
+		sort.Strings(cc.Sets)
+	}
+	return required
+}
+
 // CheckPresenceInvalid returns the list of all validation sets that declare
 // presence of the given snap as invalid. PresenceConstraintError is returned if
 // presence of the snap is "optional" or "required".
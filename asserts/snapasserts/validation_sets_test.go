@@ -843,3 +843,65 @@ func (s *validationSetsSuite) TestIsPresenceInvalid(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(vsKeys, HasLen, 0)
 }
+
+func (s *validationSetsSuite) makeValidationSetWithConfiguration(c *C, name string, configuration map[string]string) *asserts.ValidationSet {
+	var entries []interface{}
+	for key, value := range configuration {
+		entries = append(entries, map[string]interface{}{
+			"key":   key,
+			"value": value,
+		})
+	}
+	return assertstest.FakeAssertion(map[string]interface{}{
+		"type":         "validation-set",
+		"format":       "1",
+		"authority-id": "account-id",
+		"series":       "16",
+		"account-id":   "account-id",
+		"name":         name,
+		"sequence":     "1",
+		"snaps": []interface{}{
+			map[string]interface{}{
+				"name":          "my-snap",
+				"id":            "mysnapididididididididididididid",
+				"presence":      "required",
+				"configuration": entries,
+			},
+		},
+	}).(*asserts.ValidationSet)
+}
+
+func (s *validationSetsSuite) TestRequiredConfiguration(c *C) {
+	valsets := snapasserts.NewValidationSets()
+	c.Assert(valsets.Add(s.makeValidationSetWithConfiguration(c, "one", map[string]string{
+		"foo.bar": "42",
+		"baz":     "on",
+	})), IsNil)
+	c.Assert(valsets.Add(s.makeValidationSetWithConfiguration(c, "two", map[string]string{
+		"baz": "on",
+	})), IsNil)
+	c.Assert(valsets.Conflict(), IsNil)
+
+	required := valsets.RequiredConfiguration(naming.Snap("my-snap"))
+	c.Check(required, DeepEquals, map[string]*snapasserts.ConfigurationConstraint{
+		"foo.bar": {Value: "42", Sets: []string{"account-id/one"}},
+		"baz":     {Value: "on", Sets: []string{"account-id/one", "account-id/two"}},
+	})
+
+	c.Check(valsets.RequiredConfiguration(naming.Snap("other-snap")), IsNil)
+}
+
+func (s *validationSetsSuite) TestConflictConfiguration(c *C) {
+	valsets := snapasserts.NewValidationSets()
+	c.Assert(valsets.Add(s.makeValidationSetWithConfiguration(c, "one", map[string]string{
+		"foo.bar": "42",
+	})), IsNil)
+	c.Assert(valsets.Add(s.makeValidationSetWithConfiguration(c, "two", map[string]string{
+		"foo.bar": "43",
+	})), IsNil)
+
+	err := valsets.Conflict()
+	c.Check(err, ErrorMatches, `validation sets are in conflict:
+- cannot constrain snap "my-snap" configuration "foo.bar" to different values "42" \(account-id/one\), "43" \(account-id/two\)`)
+	c.Check(err.(*snapasserts.ValidationSetsConflictError).Sets, HasLen, 2)
+}
@@ -26,6 +26,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/snapcore/snapd/strutil"
 )
 
 var validSystemUserUsernames = regexp.MustCompile(`^[a-z0-9][-a-z0-9+.-_]*$`)
@@ -41,9 +43,27 @@ type SystemUser struct {
 	since   time.Time
 	until   time.Time
 
+	serialRanges []SerialRange
+	maxUses      int
+
 	forcePasswordChange bool
 }
 
+// SerialRange is an inclusive range of device serials of the same
+// length, compared lexicographically, e.g. from A0000 to A0999.
+type SerialRange struct {
+	First string
+	Last  string
+}
+
+// Contains returns whether the serial is in the range.
+func (r SerialRange) Contains(serial string) bool {
+	if len(serial) != len(r.First) {
+		return false
+	}
+	return serial >= r.First && serial <= r.Last
+}
+
 // BrandID returns the brand identifier that signed this assertion.
 func (su *SystemUser) BrandID() string {
 	return su.HeaderString("brand-id")
@@ -69,6 +89,33 @@ func (su *SystemUser) Serials() []string {
 	return su.serials
 }
 
+// SerialRanges returns the ranges of serials that this assertion is
+// valid for, in addition to the ones returned by Serials.
+func (su *SystemUser) SerialRanges() []SerialRange {
+	return su.serialRanges
+}
+
+// ValidForSerial returns whether the assertion is valid for the device
+// with the given serial, only relevant if the assertion is bound to
+// serials.
+func (su *SystemUser) ValidForSerial(serial string) bool {
+	if strutil.ListContains(su.serials, serial) {
+		return true
+	}
+	for _, r := range su.serialRanges {
+		if r.Contains(serial) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxUses returns how many times the assertion can be used to create a
+// user on a given device, 0 means unlimited.
+func (su *SystemUser) MaxUses() int {
+	return su.maxUses
+}
+
 // Name returns the full name of the user (e.g. Random Guy).
 func (su *SystemUser) Name() string {
 	return su.HeaderString("name")
@@ -246,6 +293,26 @@ func assembleSystemUser(assert assertionBase) (Assertion, error) {
 	if len(serials) > 0 && len(models) != 1 {
 		return nil, fmt.Errorf(`in the presence of the "serials" header "models" must specify exactly one model`)
 	}
+	serialRanges, err := checkSerialRanges(assert.headers)
+	if err != nil {
+		return nil, err
+	}
+	if len(serialRanges) > 0 && assert.Format() < 2 {
+		return nil, fmt.Errorf(`the "serial-ranges" header is only supported for format 2 or greater`)
+	}
+	if len(serialRanges) > 0 && len(models) != 1 {
+		return nil, fmt.Errorf(`in the presence of the "serial-ranges" header "models" must specify exactly one model`)
+	}
+	maxUses, err := checkIntWithDefault(assert.headers, "max-uses", 0)
+	if err != nil {
+		return nil, err
+	}
+	if maxUses < 0 {
+		return nil, fmt.Errorf(`"max-uses" header cannot be negative`)
+	}
+	if maxUses > 0 && assert.Format() < 2 {
+		return nil, fmt.Errorf(`the "max-uses" header is only supported for format 2 or greater`)
+	}
 
 	if _, err := checkOptionalString(assert.headers, "name"); err != nil {
 		return nil, err
@@ -291,6 +358,8 @@ func assembleSystemUser(assert assertionBase) (Assertion, error) {
 		series:              series,
 		models:              models,
 		serials:             serials,
+		serialRanges:        serialRanges,
+		maxUses:             maxUses,
 		sshKeys:             sshKeys,
 		since:               since,
 		until:               until,
@@ -309,5 +378,46 @@ func systemUserFormatAnalyze(headers map[string]interface{}, body []byte) (forma
 		formatnum = 1
 	}
 
+	if _, ok := headers["serial-ranges"]; ok {
+		formatnum = 2
+	}
+	if _, ok := headers["max-uses"]; ok {
+		formatnum = 2
+	}
+
 	return formatnum, nil
 }
+
+func checkSerialRanges(headers map[string]interface{}) ([]SerialRange, error) {
+	value, ok := headers["serial-ranges"]
+	if !ok {
+		return nil, nil
+	}
+	l, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`"serial-ranges" header must be a list of maps`)
+	}
+	ranges := make([]SerialRange, 0, len(l))
+	for _, v := range l {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf(`"serial-ranges" header must be a list of maps`)
+		}
+		first, err := checkNotEmptyStringWhat(m, "first", "of serial range")
+		if err != nil {
+			return nil, err
+		}
+		last, err := checkNotEmptyStringWhat(m, "last", "of serial range")
+		if err != nil {
+			return nil, err
+		}
+		if len(first) != len(last) {
+			return nil, fmt.Errorf("serial range bounds must have the same length: %s, %s", first, last)
+		}
+		if first > last {
+			return nil, fmt.Errorf("serial range first serial cannot be after last serial: %s, %s", first, last)
+		}
+		ranges = append(ranges, SerialRange{First: first, Last: last})
+	}
+	return ranges, nil
+}
@@ -255,6 +255,77 @@ func (s *systemUserSuite) TestDecodeOKFormat1Serials(c *C) {
 
 }
 
+// The following tests deal with "format: 2" which adds support for
+// serial ranges and limiting the number of uses per device.
+
+var serialRangesLine = "serial-ranges:\n  -\n    first: A0000\n    last: A0999\n"
+
+func (s *systemUserSuite) TestDecodeInvalidFormat2(c *C) {
+	s.systemUserStr = strings.Replace(s.systemUserStr, s.formatLine, "format: 2\n", 1)
+
+	invalidTests := []struct{ original, invalid, expectedErr string }{
+		{s.modelsLine, "models:\n  - m1\n  - m2\n" + serialRangesLine, `in the presence of the "serial-ranges" header "models" must specify exactly one model`},
+		{s.modelsLine, s.modelsLine + "serial-ranges: foo\n", `"serial-ranges" header must be a list of maps`},
+		{s.modelsLine, s.modelsLine + "serial-ranges:\n  - foo\n", `"serial-ranges" header must be a list of maps`},
+		{s.modelsLine, s.modelsLine + "serial-ranges:\n  -\n    last: A0999\n", `"first" of serial range is mandatory`},
+		{s.modelsLine, s.modelsLine + "serial-ranges:\n  -\n    first: A0000\n", `"last" of serial range is mandatory`},
+		{s.modelsLine, s.modelsLine + "serial-ranges:\n  -\n    first: A0000\n    last: A999\n", `serial range bounds must have the same length: A0000, A999`},
+		{s.modelsLine, s.modelsLine + "serial-ranges:\n  -\n    first: A0999\n    last: A0000\n", `serial range first serial cannot be after last serial: A0999, A0000`},
+		{s.modelsLine, s.modelsLine + "max-uses: -1\n", `"max-uses" header cannot be negative`},
+		{s.modelsLine, s.modelsLine + "max-uses: x\n", `"max-uses" header is not an integer: x`},
+	}
+	for _, test := range invalidTests {
+		invalid := strings.Replace(s.systemUserStr, test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, systemUserErrPrefix+test.expectedErr)
+	}
+}
+
+func (s *systemUserSuite) TestDecodeFormat2HeadersNeedFormat2(c *C) {
+	s.systemUserStr = strings.Replace(s.systemUserStr, s.formatLine, "format: 1\n", 1)
+
+	invalidTests := []struct{ original, invalid, expectedErr string }{
+		{s.modelsLine, s.modelsLine + serialRangesLine, `the "serial-ranges" header is only supported for format 2 or greater`},
+		{s.modelsLine, s.modelsLine + "max-uses: 1\n", `the "max-uses" header is only supported for format 2 or greater`},
+	}
+	for _, test := range invalidTests {
+		invalid := strings.Replace(s.systemUserStr, test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, systemUserErrPrefix+test.expectedErr)
+	}
+}
+
+func (s *systemUserSuite) TestDecodeOKFormat2(c *C) {
+	s.systemUserStr = strings.Replace(s.systemUserStr, s.formatLine, "format: 2\n", 1)
+
+	s.systemUserStr = strings.Replace(s.systemUserStr, s.modelsLine, s.modelsLine+serialsLine+serialRangesLine+"max-uses: 2\n", 1)
+	a, err := asserts.Decode([]byte(s.systemUserStr))
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.SystemUserType)
+	systemUser := a.(*asserts.SystemUser)
+	c.Check(systemUser.Serials(), DeepEquals, []string{"7c7f435d-ed28-4281-bd77-e271e0846904"})
+	c.Check(systemUser.SerialRanges(), DeepEquals, []asserts.SerialRange{
+		{First: "A0000", Last: "A0999"},
+	})
+	c.Check(systemUser.MaxUses(), Equals, 2)
+
+	for _, t := range []struct {
+		serial string
+		valid  bool
+	}{
+		{"7c7f435d-ed28-4281-bd77-e271e0846904", true},
+		{"A0000", true},
+		{"A0500", true},
+		{"A0999", true},
+		{"A1000", false},
+		{"A00000", false},
+		{"A050", false},
+		{"B0000", false},
+	} {
+		c.Check(systemUser.ValidForSerial(t.serial), Equals, t.valid, Commentf(t.serial))
+	}
+}
+
 func (s *systemUserSuite) TestSuggestedFormat(c *C) {
 	fmtnum, err := asserts.SuggestFormat(asserts.SystemUserType, nil, nil)
 	c.Assert(err, IsNil)
@@ -266,4 +337,20 @@ func (s *systemUserSuite) TestSuggestedFormat(c *C) {
 	fmtnum, err = asserts.SuggestFormat(asserts.SystemUserType, headers, nil)
 	c.Assert(err, IsNil)
 	c.Check(fmtnum, Equals, 1)
+
+	headers = map[string]interface{}{
+		"serial-ranges": []interface{}{
+			map[string]interface{}{"first": "A0000", "last": "A0999"},
+		},
+	}
+	fmtnum, err = asserts.SuggestFormat(asserts.SystemUserType, headers, nil)
+	c.Assert(err, IsNil)
+	c.Check(fmtnum, Equals, 2)
+
+	headers = map[string]interface{}{
+		"max-uses": "1",
+	}
+	fmtnum, err = asserts.SuggestFormat(asserts.SystemUserType, headers, nil)
+	c.Assert(err, IsNil)
+	c.Check(fmtnum, Equals, 2)
 }
@@ -67,6 +67,11 @@ type ValidationSetSnap struct {
 	Presence Presence
 
 	Revision int
+
+	// Configuration maps configuration keys of the snap, possibly
+	// dotted, to the values they are required to have, expressed as
+	// they would be given to snap set.
+	Configuration map[string]string
 }
 
 // SnapName implements naming.SnapRef.
@@ -112,14 +117,63 @@ func checkValidationSetSnap(snap map[string]interface{}) (*ValidationSetSnap, er
 		return nil, fmt.Errorf(`cannot specify revision %s at the same time as stating its presence is invalid`, what)
 	}
 
+	configuration, err := checkValidationSetSnapConfiguration(snap, what)
+	if err != nil {
+		return nil, err
+	}
+	if len(configuration) != 0 && presence == PresenceInvalid {
+		return nil, fmt.Errorf(`cannot specify configuration %s at the same time as stating its presence is invalid`, what)
+	}
+
 	return &ValidationSetSnap{
-		Name:     name,
-		SnapID:   snapID,
-		Presence: presence,
-		Revision: snapRevision,
+		Name:          name,
+		SnapID:        snapID,
+		Presence:      presence,
+		Revision:      snapRevision,
+		Configuration: configuration,
 	}, nil
 }
 
+var validConfigurationSubkey = regexp.MustCompile("^(?:[a-z0-9]+-?)*[a-z](?:-?[a-z0-9])*$")
+
+func checkValidationSetSnapConfiguration(snap map[string]interface{}, what string) (map[string]string, error) {
+	wrongHeaderType := fmt.Sprintf(`"configuration" %s must be a list of maps`, what)
+
+	value, ok := snap["configuration"]
+	if !ok {
+		return nil, nil
+	}
+	entries, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf(wrongHeaderType)
+	}
+	configuration := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf(wrongHeaderType)
+		}
+		key, err := checkNotEmptyStringWhat(m, "key", "of configuration "+what)
+		if err != nil {
+			return nil, err
+		}
+		for _, subkey := range strings.Split(key, ".") {
+			if !validConfigurationSubkey.MatchString(subkey) {
+				return nil, fmt.Errorf("invalid configuration key %q %s", key, what)
+			}
+		}
+		value, err := checkNotEmptyStringWhat(m, "value", fmt.Sprintf("of configuration %q %s", key, what))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := configuration[key]; ok {
+			return nil, fmt.Errorf("cannot specify configuration %q multiple times %s", key, what)
+		}
+		configuration[key] = value
+	}
+	return configuration, nil
+}
+
 func checkValidationSetSnaps(snapList interface{}) ([]*ValidationSetSnap, error) {
 	const wrongHeaderType = `"snaps" header must be a list of maps`
 
@@ -247,6 +301,13 @@ func assembleValidationSet(assert assertionBase) (Assertion, error) {
 	if err != nil {
 		return nil, err
 	}
+	if assert.Format() < 1 {
+		for _, sn := range snaps {
+			if len(sn.Configuration) != 0 {
+				return nil, fmt.Errorf(`the "configuration" header of snaps is only supported for format 1 or greater`)
+			}
+		}
+	}
 
 	timestamp, err := checkRFC3339Date(assert.headers, "timestamp")
 	if err != nil {
@@ -261,6 +322,26 @@ func assembleValidationSet(assert assertionBase) (Assertion, error) {
 	}, nil
 }
 
+func validationSetFormatAnalyze(headers map[string]interface{}, body []byte) (formatnum int, err error) {
+	formatnum = 0
+
+	snaps, ok := headers["snaps"].([]interface{})
+	if !ok {
+		return 0, nil
+	}
+	for _, entry := range snaps {
+		snap, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := snap["configuration"]; ok {
+			formatnum = 1
+		}
+	}
+
+	return formatnum, nil
+}
+
 func IsValidValidationSetName(name string) bool {
 	return validValidationSetName.MatchString(name)
 }
@@ -167,6 +167,85 @@ func (vss *validationSetSuite) TestSnapRevisionOptional(c *C) {
 	c.Check(snaps[0].Revision, Equals, 0)
 }
 
+const validationSetConfiguration = `    configuration:
+      -
+        key: foo.bar
+        value: 42
+      -
+        key: baz
+        value: on
+`
+
+func (vss *validationSetSuite) TestDecodeConfiguration(c *C) {
+	encoded := strings.Replace(validationSetExample, "TSLINE", vss.tsLine, 1)
+	encoded = strings.Replace(encoded, "OTHER", "", 1)
+	encoded = strings.Replace(encoded, "type: validation-set\n", "type: validation-set\nformat: 1\n", 1)
+	encoded = strings.Replace(encoded, "    revision: 99\n", "    revision: 99\n"+validationSetConfiguration, 1)
+
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	valset := a.(*asserts.ValidationSet)
+	snaps := valset.Snaps()
+	c.Assert(snaps, HasLen, 1)
+	c.Check(snaps[0].Configuration, DeepEquals, map[string]string{
+		"foo.bar": "42",
+		"baz":     "on",
+	})
+}
+
+func (vss *validationSetSuite) TestSuggestFormat(c *C) {
+	headers := map[string]interface{}{
+		"snaps": []interface{}{
+			map[string]interface{}{"name": "baz-linux"},
+		},
+	}
+	fmtnum, err := asserts.SuggestFormat(asserts.ValidationSetType, headers, nil)
+	c.Assert(err, IsNil)
+	c.Check(fmtnum, Equals, 0)
+
+	headers["snaps"] = []interface{}{
+		map[string]interface{}{
+			"name": "baz-linux",
+			"configuration": []interface{}{
+				map[string]interface{}{"key": "foo", "value": "bar"},
+			},
+		},
+	}
+	fmtnum, err = asserts.SuggestFormat(asserts.ValidationSetType, headers, nil)
+	c.Assert(err, IsNil)
+	c.Check(fmtnum, Equals, 1)
+}
+
+func (vss *validationSetSuite) TestDecodeInvalidConfiguration(c *C) {
+	const validationSetErrPrefix = "assertion validation-set: "
+
+	encoded := strings.Replace(validationSetExample, "TSLINE", vss.tsLine, 1)
+	encoded = strings.Replace(encoded, "OTHER", "", 1)
+	encoded = strings.Replace(encoded, "    revision: 99\n", "    revision: 99\n"+validationSetConfiguration, 1)
+	withFormat := strings.Replace(encoded, "type: validation-set\n", "type: validation-set\nformat: 1\n", 1)
+
+	_, err := asserts.Decode([]byte(encoded))
+	c.Check(err, ErrorMatches, validationSetErrPrefix+`the "configuration" header of snaps is only supported for format 1 or greater`)
+
+	invalidTests := []struct{ original, invalid, expectedErr string }{
+		{validationSetConfiguration, "    configuration: foo\n", `"configuration" of snap "baz-linux" must be a list of maps`},
+		{validationSetConfiguration, "    configuration:\n      - foo\n", `"configuration" of snap "baz-linux" must be a list of maps`},
+		{"        key: baz\n", "", `"key" of configuration of snap "baz-linux" is mandatory`},
+		{"        key: baz\n", "        key: Baz\n", `invalid configuration key "Baz" of snap "baz-linux"`},
+		{"        key: baz\n", "        key: foo..baz\n", `invalid configuration key "foo..baz" of snap "baz-linux"`},
+		{"        value: 42\n", "", `"value" of configuration "foo.bar" of snap "baz-linux" is mandatory`},
+		{"        key: baz\n", "        key: foo.bar\n", `cannot specify configuration "foo.bar" multiple times of snap "baz-linux"`},
+		{"presence: optional\n", "presence: invalid\n", `cannot specify revision of snap "baz-linux" at the same time as stating its presence is invalid`},
+		{"presence: optional\n    revision: 99\n", "presence: invalid\n", `cannot specify configuration of snap "baz-linux" at the same time as stating its presence is invalid`},
+	}
+
+	for _, test := range invalidTests {
+		invalid := strings.Replace(withFormat, test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, validationSetErrPrefix+test.expectedErr, Commentf("%s", test.invalid))
+	}
+}
+
 func (vss *validationSetSuite) TestIsValidValidationSetName(c *C) {
 	names := []struct {
 		name  string
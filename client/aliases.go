@@ -28,10 +28,11 @@ import (
 // With action "unalias" if Snap and Alias are set to the same value,
 // snapd will check if what is referred to is indeed a snap or an alias.
 type aliasAction struct {
-	Action string `json:"action"`
-	Snap   string `json:"snap,omitempty"`
-	App    string `json:"app,omitempty"`
-	Alias  string `json:"alias,omitempty"`
+	Action string   `json:"action"`
+	Snap   string   `json:"snap,omitempty"`
+	App    string   `json:"app,omitempty"`
+	Alias  string   `json:"alias,omitempty"`
+	Args   []string `json:"args,omitempty"`
 }
 
 // performAliasAction performs a single action on aliases.
@@ -53,6 +54,18 @@ func (client *Client) Alias(snapName, app, alias string) (changeID string, err e
 	})
 }
 
+// AliasWithArgs sets up a manual alias from alias to app in snapName
+// that runs app with args prepended to the arguments given to the alias.
+func (client *Client) AliasWithArgs(snapName, app, alias string, args []string) (changeID string, err error) {
+	return client.performAliasAction(&aliasAction{
+		Action: "alias",
+		Snap:   snapName,
+		App:    app,
+		Alias:  alias,
+		Args:   args,
+	})
+}
+
 // // DisableAllAliases disables all aliases of a snap, removing all manual ones.
 func (client *Client) DisableAllAliases(snapName string) (changeID string, err error) {
 	return client.performAliasAction(&aliasAction{
@@ -89,10 +102,11 @@ func (client *Client) Prefer(snapName string) (changeID string, err error) {
 
 // AliasStatus represents the status of an alias.
 type AliasStatus struct {
-	Command string `json:"command"`
-	Status  string `json:"status"`
-	Manual  string `json:"manual,omitempty"`
-	Auto    string `json:"auto,omitempty"`
+	Command string   `json:"command"`
+	Status  string   `json:"status"`
+	Manual  string   `json:"manual,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	Auto    string   `json:"auto,omitempty"`
 }
 
 // Aliases returns a map snap -> alias -> AliasStatus for all snaps and aliases in the system.
@@ -56,6 +56,30 @@ func (cs *clientSuite) TestClientAlias(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientAliasWithArgs(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "chgid"
+	}`
+	id, err := cs.cli.AliasWithArgs("alias-snap", "cmd1", "alias1", []string{"-E", "-v"})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "chgid")
+	var body map[string]interface{}
+	decoder := json.NewDecoder(cs.req.Body)
+	err = decoder.Decode(&body)
+	c.Check(err, check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action": "alias",
+		"snap":   "alias-snap",
+		"app":    "cmd1",
+		"alias":  "alias1",
+		"args":   []interface{}{"-E", "-v"},
+	})
+}
+
 func (cs *clientSuite) TestClientUnaliasCallsEndpoint(c *check.C) {
 	cs.cli.Unalias("alias1")
 	c.Check(cs.req.Method, check.Equals, "POST")
@@ -69,11 +69,28 @@ func (a *AppInfo) IsService() bool {
 	return true
 }
 
+// UserSelector selects the user sessions in which user daemons are
+// operated on. The zero value operates on system services as well as on
+// user daemons in all sessions.
+type UserSelector string
+
+const (
+	// UsersSelf selects only user daemons, in the session of the
+	// user making the request.
+	UsersSelf UserSelector = "self"
+	// UsersAll selects only user daemons, in the sessions of all
+	// users.
+	UsersAll UserSelector = "all"
+)
+
 // AppOptions represent the options of the Apps call.
 type AppOptions struct {
 	// If Service is true, only return apps that are services
 	// (app.IsService() is true); otherwise, return all.
 	Service bool
+	// Users set to UsersSelf reports the status of user daemons in
+	// the session of the user making the request.
+	Users UserSelector
 }
 
 // Apps returns information about all matching apps. Each name can be
@@ -87,6 +104,9 @@ func (client *Client) Apps(names []string, opts AppOptions) ([]*AppInfo, error)
 	if opts.Service {
 		q.Add("select", "service")
 	}
+	if opts.Users != "" {
+		q.Add("users", string(opts.Users))
+	}
 
 	var appInfos []*AppInfo
 	_, err := client.doSync("GET", "/v2/apps", q, nil, nil, &appInfos)
@@ -188,8 +208,9 @@ func (client *Client) Logs(names []string, opts LogOptions) (<-chan Log, error)
 var ErrNoNames = errors.New(`"names" must not be empty`)
 
 type appInstruction struct {
-	Action string   `json:"action"`
-	Names  []string `json:"names"`
+	Action string       `json:"action"`
+	Names  []string     `json:"names"`
+	Users  UserSelector `json:"users,omitempty"`
 	StartOptions
 	StopOptions
 	RestartOptions
@@ -206,8 +227,9 @@ type StartOptions struct {
 //
 // It takes a list of names that can be snaps, of which all their
 // services are started, or snap.service which are individual
-// services to start; it shouldn't be empty.
-func (client *Client) Start(names []string, opts StartOptions) (changeID string, err error) {
+// services to start; it shouldn't be empty. The users selector
+// restricts the operation to user daemons in the selected sessions.
+func (client *Client) Start(names []string, users UserSelector, opts StartOptions) (changeID string, err error) {
 	if len(names) == 0 {
 		return "", ErrNoNames
 	}
@@ -215,6 +237,7 @@ func (client *Client) Start(names []string, opts StartOptions) (changeID string,
 	buf, err := json.Marshal(appInstruction{
 		Action:       "start",
 		Names:        names,
+		Users:        users,
 		StartOptions: opts,
 	})
 	if err != nil {
@@ -234,8 +257,9 @@ type StopOptions struct {
 //
 // It takes a list of names that can be snaps, of which all their
 // services are stopped, or snap.service which are individual
-// services to stop; it shouldn't be empty.
-func (client *Client) Stop(names []string, opts StopOptions) (changeID string, err error) {
+// services to stop; it shouldn't be empty. The users selector
+// restricts the operation to user daemons in the selected sessions.
+func (client *Client) Stop(names []string, users UserSelector, opts StopOptions) (changeID string, err error) {
 	if len(names) == 0 {
 		return "", ErrNoNames
 	}
@@ -243,6 +267,7 @@ func (client *Client) Stop(names []string, opts StopOptions) (changeID string, e
 	buf, err := json.Marshal(appInstruction{
 		Action:      "stop",
 		Names:       names,
+		Users:       users,
 		StopOptions: opts,
 	})
 	if err != nil {
@@ -263,8 +288,9 @@ type RestartOptions struct {
 // It takes a list of names that can be snaps, of which all their
 // services are restarted, or snap.service which are individual
 // services to restart; it shouldn't be empty. If the service is not
-// running, starts it.
-func (client *Client) Restart(names []string, opts RestartOptions) (changeID string, err error) {
+// running, starts it. The users selector restricts the operation to
+// user daemons in the selected sessions.
+func (client *Client) Restart(names []string, users UserSelector, opts RestartOptions) (changeID string, err error) {
 	if len(names) == 0 {
 		return "", ErrNoNames
 	}
@@ -272,6 +298,7 @@ func (client *Client) Restart(names []string, opts RestartOptions) (changeID str
 	buf, err := json.Marshal(appInstruction{
 		Action:         "restart",
 		Names:          names,
+		Users:          users,
 		RestartOptions: opts,
 	})
 	if err != nil {
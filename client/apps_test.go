@@ -64,7 +64,20 @@ func testClientAppsService(cs *clientSuite, c *check.C) ([]*client.AppInfo, erro
 	return services, err
 }
 
-var appcheckers = []func(*clientSuite, *check.C) ([]*client.AppInfo, error){testClientApps, testClientAppsService}
+func testClientAppsServiceUsers(cs *clientSuite, c *check.C) ([]*client.AppInfo, error) {
+	services, err := cs.cli.Apps([]string{"foo", "bar"}, client.AppOptions{Service: true, Users: client.UsersSelf})
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/apps")
+	c.Check(cs.req.Method, check.Equals, "GET")
+	query := cs.req.URL.Query()
+	c.Check(query, check.HasLen, 3)
+	c.Check(query.Get("names"), check.Equals, "foo,bar")
+	c.Check(query.Get("select"), check.Equals, "service")
+	c.Check(query.Get("users"), check.Equals, "self")
+
+	return services, err
+}
+
+var appcheckers = []func(*clientSuite, *check.C) ([]*client.AppInfo, error){testClientApps, testClientAppsService, testClientAppsServiceUsers}
 
 func (cs *clientSuite) TestClientServiceGetHappy(c *check.C) {
 	expected := []*client.AppInfo{mksvc("foo", "foo"), mksvc("bar", "bar1")}
@@ -247,7 +260,7 @@ func (cs *clientSuite) TestClientServiceStart(c *check.C) {
 	}
 
 	for _, sc := range scenarios {
-		id, err := cs.cli.Start(sc.names, sc.opts)
+		id, err := cs.cli.Start(sc.names, "", sc.opts)
 		if len(sc.names) == 0 {
 			c.Check(id, check.Equals, "", sc.comment)
 			c.Check(err, check.Equals, client.ErrNoNames, sc.comment)
@@ -309,7 +322,7 @@ func (cs *clientSuite) TestClientServiceStop(c *check.C) {
 	}
 
 	for _, sc := range scs {
-		id, err := cs.cli.Stop(sc.names, sc.opts)
+		id, err := cs.cli.Stop(sc.names, "", sc.opts)
 		if len(sc.names) == 0 {
 			c.Check(id, check.Equals, "", sc.comment)
 			c.Check(err, check.Equals, client.ErrNoNames, sc.comment)
@@ -371,7 +384,7 @@ func (cs *clientSuite) TestClientServiceRestart(c *check.C) {
 	}
 
 	for _, sc := range scs {
-		id, err := cs.cli.Restart(sc.names, sc.opts)
+		id, err := cs.cli.Restart(sc.names, "", sc.opts)
 		if len(sc.names) == 0 {
 			c.Check(id, check.Equals, "", sc.comment)
 			c.Check(err, check.Equals, client.ErrNoNames, sc.comment)
@@ -402,3 +415,31 @@ func (cs *clientSuite) TestClientServiceRestart(c *check.C) {
 		}
 	}
 }
+
+func (cs *clientSuite) TestClientServiceControlUsers(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{"type": "async", "status-code": 202, "change": "24"}`
+
+	for _, t := range []struct {
+		action string
+		call   func() (string, error)
+	}{
+		{"start", func() (string, error) { return cs.cli.Start([]string{"foo"}, client.UsersSelf, client.StartOptions{}) }},
+		{"stop", func() (string, error) { return cs.cli.Stop([]string{"foo"}, client.UsersSelf, client.StopOptions{}) }},
+		{"restart", func() (string, error) {
+			return cs.cli.Restart([]string{"foo"}, client.UsersSelf, client.RestartOptions{})
+		}},
+	} {
+		id, err := t.call()
+		c.Assert(err, check.IsNil, check.Commentf(t.action))
+		c.Check(id, check.Equals, "24")
+
+		var reqOp map[string]interface{}
+		c.Assert(json.NewDecoder(cs.req.Body).Decode(&reqOp), check.IsNil)
+		c.Check(reqOp, check.DeepEquals, map[string]interface{}{
+			"action": t.action,
+			"names":  []interface{}{"foo"},
+			"users":  "self",
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// APIAuditEntry is the record of a mutating request to the snapd API.
+type APIAuditEntry struct {
+	Time time.Time `json:"time"`
+	// UID is the uid of the peer which sent the request.
+	UID uint32 `json:"uid"`
+	// Snap is the snap which sent the request through snapctl, if any.
+	Snap   string `json:"snap,omitempty"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Change is the ID of the change started by the request, if any.
+	Change string `json:"change,omitempty"`
+	// Summary is the summary of the change, if any.
+	Summary string `json:"summary,omitempty"`
+	// Error is the message of the error returned, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// APIAudit returns the audit log of the mutating requests to the snapd
+// API, oldest first.
+func (client *Client) APIAudit() ([]*APIAuditEntry, error) {
+	var entries []*APIAuditEntry
+	if _, err := client.doSync("GET", "/v2/debug/api-audit", nil, nil, nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
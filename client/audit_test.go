@@ -0,0 +1,53 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestAPIAudit(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": [
+			{"time": "2021-06-01T10:00:00Z", "uid": 1000, "snap": "foo", "method": "POST", "path": "/v2/snapctl", "status": 202, "change": "1", "summary": "Restart foo"}
+		]
+	}`
+
+	entries, err := cs.cli.APIAudit()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/debug/api-audit")
+	c.Check(entries, check.DeepEquals, []*client.APIAuditEntry{{
+		Time:    time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+		UID:     1000,
+		Snap:    "foo",
+		Method:  "POST",
+		Path:    "/v2/snapctl",
+		Status:  202,
+		Change:  "1",
+		Summary: "Restart foo",
+	}})
+}
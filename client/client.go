@@ -651,6 +651,24 @@ type SysInfo struct {
 	Refresh         RefreshInfo         `json:"refresh,omitempty"`
 	Confinement     string              `json:"confinement"`
 	SandboxFeatures map[string][]string `json:"sandbox-features,omitempty"`
+
+	DataStorage *DataStorageInfo `json:"data-storage,omitempty"`
+}
+
+// DataStorageInfo holds the health of the disk backing ubuntu-data and the
+// usage of the partition, on UC20 systems in run mode.
+type DataStorageInfo struct {
+	// Disk is the "major:minor" number of the disk.
+	Disk string `json:"disk"`
+	// LifeTimeUsed is the estimated percentage of the rated life time of
+	// the disk that is used, or 0 if unknown.
+	LifeTimeUsed int `json:"life-time-used,omitempty"`
+	// PreEOL is the pre end-of-life state of the disk, one of "normal",
+	// "warning" or "urgent", or empty if unknown.
+	PreEOL string `json:"pre-eol,omitempty"`
+	// Size and Free are the size and free space of ubuntu-data in bytes.
+	Size uint64 `json:"size"`
+	Free uint64 `json:"free"`
 }
 
 func (rsp *response) err(cli *Client, statusCode int) error {
@@ -738,6 +756,35 @@ func (client *Client) DebugGet(aspect string, result interface{}, params map[str
 	return err
 }
 
+// DebugTools lists the tools supported by "snap run --debug-tool".
+var DebugTools = []string{"gdb", "gdbserver", "perf", "strace", "trace-exec"}
+
+// DebugLaunch describes how to launch an application of a snap, possibly
+// under a debugging tool, with the same confinement and environment as when
+// launched normally.
+type DebugLaunch struct {
+	// Command is the command line to execute as the user.
+	Command []string `json:"command"`
+	// SecurityTag is the security tag the application will run under.
+	SecurityTag string `json:"security-tag"`
+	// Confinement is the confinement of the snap.
+	Confinement string `json:"confinement"`
+}
+
+// DebugLaunch returns how to launch the given application under the given
+// debugging tool. An empty tool means no debugging tool.
+func (client *Client) DebugLaunch(snapApp, tool string) (*DebugLaunch, error) {
+	var launch DebugLaunch
+	params := map[string]string{"app": snapApp}
+	if tool != "" {
+		params["debug-tool"] = tool
+	}
+	if err := client.DebugGet("launch", &launch, params); err != nil {
+		return nil, err
+	}
+	return &launch, nil
+}
+
 type SystemRecoveryKeysResponse struct {
 	RecoveryKey  string `json:"recovery-key"`
 	ReinstallKey string `json:"reinstall-key"`
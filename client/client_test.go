@@ -338,7 +338,8 @@ func (cs *clientSuite) TestClientSysInfo(c *C) {
                       "confinement": "strict",
                       "architecture": "TI-99/4A",
                       "virtualization": "MESS",
-                      "sandbox-features": {"backend": ["feature-1", "feature-2"]}}}`
+                      "sandbox-features": {"backend": ["feature-1", "feature-2"]},
+                      "data-storage": {"disk": "179:0", "life-time-used": 40, "pre-eol": "normal", "size": 1000, "free": 200}}}`
 	sysInfo, err := cs.cli.SysInfo()
 	c.Check(err, IsNil)
 	c.Check(sysInfo, DeepEquals, &client.SysInfo{
@@ -356,6 +357,13 @@ func (cs *clientSuite) TestClientSysInfo(c *C) {
 		BuildID:        "1234",
 		Architecture:   "TI-99/4A",
 		Virtualization: "MESS",
+		DataStorage: &client.DataStorageInfo{
+			Disk:         "179:0",
+			LifeTimeUsed: 40,
+			PreEOL:       "normal",
+			Size:         1000,
+			Free:         200,
+		},
 	})
 }
 
@@ -609,6 +617,22 @@ func (cs *clientSuite) TestDebugGet(c *C) {
 	c.Check(cs.reqs[0].URL.Query(), DeepEquals, url.Values{"aspect": []string{"do-something"}, "foo": []string{"bar"}})
 }
 
+func (cs *clientSuite) TestDebugLaunch(c *C) {
+	cs.rsp = `{"type": "sync", "result": {"command": ["snap", "run", "--debug-tool=gdbserver", "foo.app"], "security-tag": "snap.foo.app", "confinement": "strict"}}`
+
+	launch, err := cs.cli.DebugLaunch("foo.app", "gdbserver")
+	c.Assert(err, IsNil)
+	c.Check(launch, DeepEquals, &client.DebugLaunch{
+		Command:     []string{"snap", "run", "--debug-tool=gdbserver", "foo.app"},
+		SecurityTag: "snap.foo.app",
+		Confinement: "strict",
+	})
+	c.Check(cs.reqs, HasLen, 1)
+	c.Check(cs.reqs[0].Method, Equals, "GET")
+	c.Check(cs.reqs[0].URL.Path, Equals, "/v2/debug")
+	c.Check(cs.reqs[0].URL.Query(), DeepEquals, url.Values{"aspect": []string{"launch"}, "app": []string{"foo.app"}, "debug-tool": []string{"gdbserver"}})
+}
+
 type integrationSuite struct{}
 
 var _ = Suite(&integrationSuite{})
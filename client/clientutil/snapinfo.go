@@ -94,17 +94,19 @@ func ClientAppInfoNotes(app *client.AppInfo) string {
 		return "-"
 	}
 
-	var notes = make([]string, 0, 4)
+	var notes = make([]string, 0, 5)
 	if app.DaemonScope == snap.UserDaemon {
 		notes = append(notes, "user")
 	}
-	var seenTimer, seenSocket, seenDbus bool
+	var seenTimer, seenSocket, seenPath, seenDbus bool
 	for _, act := range app.Activators {
 		switch act.Type {
 		case "timer":
 			seenTimer = true
 		case "socket":
 			seenSocket = true
+		case "path":
+			seenPath = true
 		case "dbus":
 			seenDbus = true
 		}
@@ -115,6 +117,9 @@ func ClientAppInfoNotes(app *client.AppInfo) string {
 	if seenSocket {
 		notes = append(notes, "socket-activated")
 	}
+	if seenPath {
+		notes = append(notes, "path-activated")
+	}
 	if seenDbus {
 		notes = append(notes, "dbus-activated")
 	}
@@ -112,6 +112,8 @@ func (*cmdSuite) TestClientSnapFromSnapInfo(c *C) {
 		"TryMode",
 		"JailMode",
 		"MountedFrom",
+		"PendingRefresh",
+		"RefreshInhibit",
 	}
 	var checker func(string, reflect.Value)
 	checker = func(pfx string, x reflect.Value) {
@@ -284,6 +286,14 @@ func (*cmdSuite) TestAppStatusNotes(c *C) {
 	}
 	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "socket-activated")
 
+	ai = client.AppInfo{
+		Daemon: "oneshot",
+		Activators: []client.AppActivator{
+			{Type: "path"},
+		},
+	}
+	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "path-activated")
+
 	ai = client.AppInfo{
 		Daemon: "oneshot",
 		Activators: []client.AppActivator{
@@ -307,9 +317,10 @@ func (*cmdSuite) TestAppStatusNotes(c *C) {
 		DaemonScope: snap.UserDaemon,
 		Activators: []client.AppActivator{
 			{Type: "dbus"},
+			{Type: "path"},
 			{Type: "socket"},
 			{Type: "timer"},
 		},
 	}
-	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "user,timer-activated,socket-activated,dbus-activated")
+	c.Check(clientutil.ClientAppInfoNotes(&ai), Equals, "user,timer-activated,socket-activated,path-activated,dbus-activated")
 }
@@ -24,6 +24,10 @@ import (
 	"encoding/json"
 	"net/url"
 	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/configschema"
 )
 
 // SetConf requests a snap to apply the provided patch to the configuration.
@@ -50,3 +54,62 @@ func (client *Client) Conf(snapName string, keys []string) (configuration map[st
 
 	return configuration, nil
 }
+
+// ConfigSchema returns the configuration schema of the snap, or nil if
+// the snap does not ship one.
+func (client *Client) ConfigSchema(snapName string) (*configschema.Schema, error) {
+	var raw json.RawMessage
+	if _, err := client.doSync("GET", "/v2/snaps/"+snapName+"/config-schema", nil, nil, nil, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	return configschema.Parse(raw)
+}
+
+// ConfExportFormat is the version of the configuration export format
+// produced and understood by this client.
+const ConfExportFormat = 1
+
+// ConfExport holds the full configuration of a snap, along with the
+// metadata describing where it was captured.
+type ConfExport struct {
+	Format     int                    `json:"format"`
+	Snap       string                 `json:"snap"`
+	Revision   snap.Revision          `json:"revision"`
+	Version    string                 `json:"version,omitempty"`
+	ExportedAt time.Time              `json:"exported-at"`
+	Config     map[string]interface{} `json:"config"`
+}
+
+// ExportConf asks for the full configuration of a snap in a form that
+// can be imported on another device.
+//
+// Note that the configuration may include json.Numbers.
+func (client *Client) ExportConf(snapName string) (*ConfExport, error) {
+	query := url.Values{}
+	query.Set("export", "true")
+
+	var export ConfExport
+	if _, err := client.doSync("GET", "/v2/snaps/"+snapName+"/conf", query, nil, nil, &export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+type confImportAction struct {
+	Action string      `json:"action"`
+	Export *ConfExport `json:"export"`
+}
+
+// ImportConf requests the configuration of the snap named in the export
+// to be replaced with the exported one. Options not present in the export
+// are unset.
+func (client *Client) ImportConf(export *ConfExport) (changeID string, err error) {
+	b, err := json.Marshal(&confImportAction{Action: "import", Export: export})
+	if err != nil {
+		return "", err
+	}
+	return client.doAsync("POST", "/v2/snaps/"+export.Snap+"/conf", nil, nil, bytes.NewReader(b))
+}
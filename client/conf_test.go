@@ -21,8 +21,12 @@ package client_test
 
 import (
 	"encoding/json"
+	"time"
 
 	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
 )
 
 func (cs *clientSuite) TestClientSetConfCallsEndpoint(c *check.C) {
@@ -103,3 +107,91 @@ func (cs *clientSuite) TestClientGetConfMultipleKeys(c *check.C) {
 		"test-key2": "test-value2",
 	})
 }
+
+func (cs *clientSuite) TestClientConfigSchema(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"type": "object",
+			"properties": {
+				"port": {"type": "integer", "default": 8080}
+			}
+		}
+	}`
+	schema, err := cs.cli.ConfigSchema("snap-name")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/snap-name/config-schema")
+	c.Assert(schema, check.NotNil)
+	c.Check(schema.Type, check.Equals, "object")
+	c.Check(schema.Properties["port"].Default, check.Equals, 8080.0)
+}
+
+func (cs *clientSuite) TestClientConfigSchemaNone(c *check.C) {
+	cs.rsp = `{"type": "sync", "status-code": 200, "result": null}`
+	schema, err := cs.cli.ConfigSchema("snap-name")
+	c.Assert(err, check.IsNil)
+	c.Check(schema, check.IsNil)
+}
+
+func (cs *clientSuite) TestClientExportConf(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"format": 1,
+			"snap": "snap-name",
+			"revision": "7",
+			"version": "1.0",
+			"exported-at": "2021-03-01T10:00:00Z",
+			"config": {"key": "value", "port": 8080}
+		}
+	}`
+	export, err := cs.cli.ExportConf("snap-name")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/snap-name/conf")
+	c.Check(cs.req.URL.Query().Get("export"), check.Equals, "true")
+	c.Check(export, check.DeepEquals, &client.ConfExport{
+		Format:     1,
+		Snap:       "snap-name",
+		Revision:   snap.R(7),
+		Version:    "1.0",
+		ExportedAt: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC),
+		Config: map[string]interface{}{
+			"key":  "value",
+			"port": json.Number("8080"),
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientImportConf(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "foo"
+	}`
+	id, err := cs.cli.ImportConf(&client.ConfExport{
+		Format:   1,
+		Snap:     "snap-name",
+		Revision: snap.R(7),
+		Config:   map[string]interface{}{"key": "value"},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "foo")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/snap-name/conf")
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body["action"], check.Equals, "import")
+	c.Check(body["export"], check.DeepEquals, map[string]interface{}{
+		"format":      float64(1),
+		"snap":        "snap-name",
+		"revision":    "7",
+		"exported-at": "0001-01-01T00:00:00Z",
+		"config":      map[string]interface{}{"key": "value"},
+	})
+}
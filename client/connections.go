@@ -21,6 +21,7 @@ package client
 
 import (
 	"net/url"
+	"time"
 )
 
 // Connection describes a connection between a plug and a slot.
@@ -79,3 +80,33 @@ func (client *Client) Connections(opts *ConnectionOptions) (Connections, error)
 	_, err := client.doSync("GET", "/v2/connections", query, nil, nil, &conns)
 	return conns, err
 }
+
+// ConnectionEvent describes a past connection or disconnection of a plug and
+// a slot.
+type ConnectionEvent struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	Interface string    `json:"interface,omitempty"`
+	Plug      PlugRef   `json:"plug"`
+	Slot      SlotRef   `json:"slot"`
+	// By is the cause of the event, e.g. "user", "auto-connect" or "undo".
+	By string `json:"by"`
+	// RequestedBy identifies the user that requested the event, if known.
+	RequestedBy string `json:"requested-by,omitempty"`
+	// Justification describes the declaration rule that allowed the
+	// connection.
+	Justification string `json:"justification,omitempty"`
+}
+
+// ConnectionHistory returns the recorded connect and disconnect events,
+// oldest first. If snapName is not empty only the events involving the
+// given snap are returned.
+func (client *Client) ConnectionHistory(snapName string) ([]*ConnectionEvent, error) {
+	query := url.Values{}
+	if snapName != "" {
+		query.Set("snap", snapName)
+	}
+	var history []*ConnectionEvent
+	_, err := client.doSync("GET", "/v2/interfaces/history", query, nil, nil, &history)
+	return history, err
+}
@@ -21,6 +21,7 @@ package client_test
 
 import (
 	"net/url"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -268,3 +269,40 @@ func (cs *clientSuite) TestClientConnectionsFilter(c *check.C) {
 		"snap":      []string{"foo"},
 	})
 }
+
+func (cs *clientSuite) TestClientConnectionHistory(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [
+			{
+				"time": "2021-03-01T10:00:00Z",
+				"action": "connect",
+				"interface": "test",
+				"plug": {"snap": "consumer", "plug": "plug"},
+				"slot": {"snap": "producer", "slot": "slot"},
+				"by": "user",
+				"requested-by": "uid=1000",
+				"justification": "plug rule of base-declaration"
+			}
+		]
+	}`
+	history, err := cs.cli.ConnectionHistory("")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/history")
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+	c.Check(history, check.DeepEquals, []*client.ConnectionEvent{{
+		Time:          time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC),
+		Action:        "connect",
+		Interface:     "test",
+		Plug:          client.PlugRef{Snap: "consumer", Name: "plug"},
+		Slot:          client.SlotRef{Snap: "producer", Name: "slot"},
+		By:            "user",
+		RequestedBy:   "uid=1000",
+		Justification: "plug rule of base-declaration",
+	}})
+
+	_, err = cs.cli.ConnectionHistory("producer")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.RawQuery, check.Equals, "snap=producer")
+}
@@ -142,6 +142,10 @@ const (
 
 	// ErrorKindValidationSetNotFound: validation set cannot be found.
 	ErrorKindValidationSetNotFound ErrorKind = "validation-set-not-found"
+
+	// ErrorKindRateLimited: too many requests were sent by the user, the
+	// request should be retried later.
+	ErrorKindRateLimited ErrorKind = "rate-limited"
 )
 
 // Maintenance error kinds.
@@ -75,6 +75,8 @@ type InterfaceAction struct {
 	Forget bool   `json:"forget,omitempty"`
 	Plugs  []Plug `json:"plugs,omitempty"`
 	Slots  []Slot `json:"slots,omitempty"`
+	// Operations are the connect and disconnect actions of a batch.
+	Operations []InterfaceAction `json:"operations,omitempty"`
 }
 
 // InterfaceOptions represents opt-in elements include in responses.
@@ -86,6 +88,12 @@ type InterfaceOptions struct {
 	Connected bool
 }
 
+// ConnectOptions represents extra options for connect op
+type ConnectOptions struct {
+	// PlugAttrs are plug attributes overridden for the connection.
+	PlugAttrs map[string]interface{}
+}
+
 // DisconnectOptions represents extra options for disconnect op
 type DisconnectOptions struct {
 	Forget bool
@@ -119,6 +127,28 @@ func (client *Client) Interfaces(opts *InterfaceOptions) ([]*Interface, error) {
 	return interfaces, err
 }
 
+// StaleProfile holds the running processes of an application or hook of a
+// snap that still use a seccomp profile older than the installed one.
+type StaleProfile struct {
+	Snap        string `json:"snap"`
+	SecurityTag string `json:"security-tag"`
+	Pids        []int  `json:"pids"`
+}
+
+// StaleProfiles returns the processes of the given snaps, or of all snaps if
+// none are given, that need to be restarted to use their current seccomp
+// profile, for instance after a connection was made.
+func (client *Client) StaleProfiles(snaps []string) ([]*StaleProfile, error) {
+	query := url.Values{}
+	query.Set("select", "stale-profiles")
+	if len(snaps) > 0 {
+		query.Set("snaps", strings.Join(snaps, ","))
+	}
+	var staleProfiles []*StaleProfile
+	_, err := client.doSync("GET", "/v2/interfaces", query, nil, nil, &staleProfiles)
+	return staleProfiles, err
+}
+
 // performInterfaceAction performs a single action on the interface system.
 func (client *Client) performInterfaceAction(sa *InterfaceAction) (changeID string, err error) {
 	b, err := json.Marshal(sa)
@@ -130,10 +160,14 @@ func (client *Client) performInterfaceAction(sa *InterfaceAction) (changeID stri
 
 // Connect establishes a connection between a plug and a slot.
 // The plug and the slot must have the same interface.
-func (client *Client) Connect(plugSnapName, plugName, slotSnapName, slotName string) (changeID string, err error) {
+func (client *Client) Connect(plugSnapName, plugName, slotSnapName, slotName string, opts *ConnectOptions) (changeID string, err error) {
+	var plugAttrs map[string]interface{}
+	if opts != nil {
+		plugAttrs = opts.PlugAttrs
+	}
 	return client.performInterfaceAction(&InterfaceAction{
 		Action: "connect",
-		Plugs:  []Plug{{Snap: plugSnapName, Name: plugName}},
+		Plugs:  []Plug{{Snap: plugSnapName, Name: plugName, Attrs: plugAttrs}},
 		Slots:  []Slot{{Snap: slotSnapName, Name: slotName}},
 	})
 }
@@ -147,3 +181,13 @@ func (client *Client) Disconnect(plugSnapName, plugName, slotSnapName, slotName
 		Slots:  []Slot{{Snap: slotSnapName, Name: slotName}},
 	})
 }
+
+// InterfaceBatch performs the given connect and disconnect actions, in
+// order, as a single change. The security profiles of the affected snaps
+// are regenerated only once, after all the actions were performed.
+func (client *Client) InterfaceBatch(actions []InterfaceAction) (changeID string, err error) {
+	return client.performInterfaceAction(&InterfaceAction{
+		Action:     "batch",
+		Operations: actions,
+	})
+}
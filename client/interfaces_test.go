@@ -21,6 +21,7 @@ package client_test
 
 import (
 	"encoding/json"
+	"net/url"
 
 	"gopkg.in/check.v1"
 
@@ -93,6 +94,26 @@ func (cs *clientSuite) TestClientInterfacesConnected(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientStaleProfiles(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [
+			{"snap": "foo", "security-tag": "snap.foo.app", "pids": [10, 20]}
+		]
+	}`
+	staleProfiles, err := cs.cli.StaleProfiles([]string{"foo", "bar"})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"select": []string{"stale-profiles"},
+		"snaps":  []string{"foo,bar"},
+	})
+	c.Check(staleProfiles, check.DeepEquals, []*client.StaleProfile{
+		{Snap: "foo", SecurityTag: "snap.foo.app", Pids: []int{10, 20}},
+	})
+}
+
 func (cs *clientSuite) TestClientInterfacesSelectedDetails(c *check.C) {
 	// Ask for single element and request docs, plugs and slots.
 	cs.rsp = `{
@@ -157,11 +178,45 @@ func (cs *clientSuite) TestClientInterfacesMultiple(c *check.C) {
 }
 
 func (cs *clientSuite) TestClientConnectCallsEndpoint(c *check.C) {
-	cs.cli.Connect("producer", "plug", "consumer", "slot")
+	cs.cli.Connect("producer", "plug", "consumer", "slot", nil)
 	c.Check(cs.req.Method, check.Equals, "POST")
 	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces")
 }
 
+func (cs *clientSuite) TestClientConnectWithPlugAttrs(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "foo"
+	}`
+	opts := &client.ConnectOptions{PlugAttrs: map[string]interface{}{"read-only": true}}
+	id, err := cs.cli.Connect("producer", "plug", "consumer", "slot", opts)
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "foo")
+	var body map[string]interface{}
+	decoder := json.NewDecoder(cs.req.Body)
+	err = decoder.Decode(&body)
+	c.Check(err, check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action": "connect",
+		"plugs": []interface{}{
+			map[string]interface{}{
+				"snap":  "producer",
+				"plug":  "plug",
+				"attrs": map[string]interface{}{"read-only": true},
+			},
+		},
+		"slots": []interface{}{
+			map[string]interface{}{
+				"snap": "consumer",
+				"slot": "slot",
+			},
+		},
+	})
+}
+
 func (cs *clientSuite) TestClientConnect(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
@@ -170,7 +225,7 @@ func (cs *clientSuite) TestClientConnect(c *check.C) {
 		"result": { },
                 "change": "foo"
 	}`
-	id, err := cs.cli.Connect("producer", "plug", "consumer", "slot")
+	id, err := cs.cli.Connect("producer", "plug", "consumer", "slot", nil)
 	c.Assert(err, check.IsNil)
 	c.Check(id, check.Equals, "foo")
 	var body map[string]interface{}
@@ -233,6 +288,46 @@ func (cs *clientSuite) TestClientDisconnect(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientInterfaceBatch(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "42"
+	}`
+	id, err := cs.cli.InterfaceBatch([]client.InterfaceAction{{
+		Action: "disconnect",
+		Plugs:  []client.Plug{{Snap: "consumer", Name: "plug"}},
+		Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+	}, {
+		Action: "connect",
+		Plugs:  []client.Plug{{Snap: "consumer2", Name: "plug"}},
+		Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+	}})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "42")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces")
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action": "batch",
+		"operations": []interface{}{
+			map[string]interface{}{
+				"action": "disconnect",
+				"plugs":  []interface{}{map[string]interface{}{"snap": "consumer", "plug": "plug"}},
+				"slots":  []interface{}{map[string]interface{}{"snap": "producer", "slot": "slot"}},
+			},
+			map[string]interface{}{
+				"action": "connect",
+				"plugs":  []interface{}{map[string]interface{}{"snap": "consumer2", "plug": "plug"}},
+				"slots":  []interface{}{map[string]interface{}{"snap": "producer", "slot": "slot"}},
+			},
+		},
+	})
+}
+
 func (cs *clientSuite) TestClientDisconnectForget(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
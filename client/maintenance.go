@@ -0,0 +1,77 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SystemMaintenance is the maintenance hold of the system, during which
+// auto-refreshes, hotplug changes and other background activity are paused.
+type SystemMaintenance struct {
+	Held  bool      `json:"held"`
+	Since time.Time `json:"since,omitempty"`
+	// Until is when the hold is released on its own, if set.
+	Until time.Time `json:"until,omitempty"`
+}
+
+type maintenanceAction struct {
+	Action string     `json:"action"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// SystemMaintenance returns the maintenance hold of the system.
+func (client *Client) SystemMaintenance() (*SystemMaintenance, error) {
+	var res *SystemMaintenance
+	if _, err := client.doSync("GET", "/v2/system-maintenance", nil, nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// HoldMaintenance puts the system on maintenance hold until it is released,
+// or until the given time if not zero.
+func (client *Client) HoldMaintenance(until time.Time) (*SystemMaintenance, error) {
+	action := maintenanceAction{Action: "hold"}
+	if !until.IsZero() {
+		action.Until = &until
+	}
+	return client.postMaintenance(&action)
+}
+
+// ReleaseMaintenance releases the maintenance hold of the system.
+func (client *Client) ReleaseMaintenance() (*SystemMaintenance, error) {
+	return client.postMaintenance(&maintenanceAction{Action: "release"})
+}
+
+func (client *Client) postMaintenance(action *maintenanceAction) (*SystemMaintenance, error) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(action); err != nil {
+		return nil, fmt.Errorf("cannot marshal maintenance action: %v", err)
+	}
+	var res *SystemMaintenance
+	if _, err := client.doSync("POST", "/v2/system-maintenance", nil, nil, &body, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
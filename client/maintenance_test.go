@@ -0,0 +1,86 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+func (cs *clientSuite) TestSystemMaintenance(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"held": true, "since": "2021-06-01T10:00:00Z"}
+	}`
+
+	res, err := cs.cli.SystemMaintenance()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/system-maintenance")
+	c.Check(res.Held, check.Equals, true)
+	c.Check(res.Since.Equal(time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)), check.Equals, true)
+	c.Check(res.Until.IsZero(), check.Equals, true)
+}
+
+func (cs *clientSuite) TestHoldMaintenance(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"held": true, "since": "2021-06-01T10:00:00Z", "until": "2021-06-01T12:00:00Z"}
+	}`
+
+	until := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	res, err := cs.cli.HoldMaintenance(until)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/system-maintenance")
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var data map[string]interface{}
+	c.Assert(json.Unmarshal(body, &data), check.IsNil)
+	c.Check(data, check.DeepEquals, map[string]interface{}{
+		"action": "hold",
+		"until":  "2021-06-01T12:00:00Z",
+	})
+	c.Check(res.Held, check.Equals, true)
+	c.Check(res.Until.Equal(until), check.Equals, true)
+}
+
+func (cs *clientSuite) TestReleaseMaintenance(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"held": false}
+	}`
+
+	res, err := cs.cli.ReleaseMaintenance()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var data map[string]interface{}
+	c.Assert(json.Unmarshal(body, &data), check.IsNil)
+	c.Check(data, check.DeepEquals, map[string]interface{}{"action": "release"})
+	c.Check(res.Held, check.Equals, false)
+}
@@ -33,6 +33,8 @@ import (
 
 type remodelData struct {
 	NewModel string `json:"new-model"`
+	DryRun   bool   `json:"dry-run,omitempty"`
+	Queue    bool   `json:"queue,omitempty"`
 }
 
 // Remodel tries to remodel the system with the given assertion data
@@ -50,6 +52,65 @@ func (client *Client) Remodel(b []byte) (changeID string, err error) {
 	return client.doAsync("POST", "/v2/model", nil, headers, bytes.NewReader(data))
 }
 
+// QueueRemodel queues the remodel of the system with the given assertion
+// data, to be started at the next maintenance window or reboot.
+func (client *Client) QueueRemodel(b []byte) error {
+	data, err := json.Marshal(&remodelData{
+		NewModel: string(b),
+		Queue:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot marshal remodel data: %v", err)
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	_, err = client.doSync("POST", "/v2/model", nil, headers, bytes.NewReader(data), nil)
+	return err
+}
+
+// RemodelPlan describes what remodeling the device would do.
+type RemodelPlan struct {
+	Kind             string            `json:"kind"`
+	Install          []RemodelPlanSnap `json:"install,omitempty"`
+	Switch           []RemodelPlanSnap `json:"switch,omitempty"`
+	Remove           []string          `json:"remove,omitempty"`
+	NoLongerRequired []string          `json:"no-longer-required,omitempty"`
+	Assertions       []string          `json:"assertions"`
+	RecoverySystem   bool              `json:"recovery-system,omitempty"`
+	Reboot           bool              `json:"reboot"`
+	Reseal           bool              `json:"reseal"`
+}
+
+// RemodelPlanSnap describes a snap of a remodel plan.
+type RemodelPlanSnap struct {
+	Name     string `json:"name"`
+	Channel  string `json:"channel,omitempty"`
+	Replaces string `json:"replaces,omitempty"`
+}
+
+// PlanRemodel returns what remodeling the system with the given
+// assertion data would do, without remodeling it.
+func (client *Client) PlanRemodel(b []byte) (*RemodelPlan, error) {
+	data, err := json.Marshal(&remodelData{
+		NewModel: string(b),
+		DryRun:   true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal remodel data: %v", err)
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	var plan RemodelPlan
+	if _, err := client.doSync("POST", "/v2/model", nil, headers, bytes.NewReader(data), &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
 // CurrentModelAssertion returns the current model assertion
 func (client *Client) CurrentModelAssertion() (*asserts.Model, error) {
 	assert, err := currentAssertion(client, "/v2/model")
@@ -29,6 +29,7 @@ import (
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/client"
 )
 
 const happyModelAssertionResponse = `type: model
@@ -129,6 +130,68 @@ func (cs *clientSuite) TestClientRemodel(c *C) {
 	c.Check(jsonBody["new-model"], Equals, string(remodelJsonData))
 }
 
+func (cs *clientSuite) TestClientPlanRemodel(c *C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"kind": "revision update remodel",
+			"install": [{"name": "new-snap", "replaces": "old-snap"}],
+			"switch": [{"name": "pc-kernel", "channel": "20"}],
+			"remove": ["old-snap"],
+			"assertions": ["model my-brand/my-model (2)"],
+			"reboot": true,
+			"reseal": false
+		}
+	}`
+	remodelJsonData := []byte(`{"new-model": "some-model"}`)
+	plan, err := cs.cli.PlanRemodel(remodelJsonData)
+	c.Assert(err, IsNil)
+	c.Check(plan, DeepEquals, &client.RemodelPlan{
+		Kind:       "revision update remodel",
+		Install:    []client.RemodelPlanSnap{{Name: "new-snap", Replaces: "old-snap"}},
+		Switch:     []client.RemodelPlanSnap{{Name: "pc-kernel", Channel: "20"}},
+		Remove:     []string{"old-snap"},
+		Assertions: []string{"model my-brand/my-model (2)"},
+		Reboot:     true,
+	})
+	c.Check(cs.req.Method, Equals, "POST")
+	c.Check(cs.req.URL.Path, Equals, "/v2/model")
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, IsNil)
+	var jsonBody map[string]interface{}
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, IsNil)
+	c.Check(jsonBody, DeepEquals, map[string]interface{}{
+		"new-model": string(remodelJsonData),
+		"dry-run":   true,
+	})
+}
+
+func (cs *clientSuite) TestClientQueueRemodel(c *C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": null
+	}`
+	remodelJsonData := []byte(`{"new-model": "some-model"}`)
+	err := cs.cli.QueueRemodel(remodelJsonData)
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "POST")
+	c.Check(cs.req.URL.Path, Equals, "/v2/model")
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, IsNil)
+	var jsonBody map[string]interface{}
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, IsNil)
+	c.Check(jsonBody, DeepEquals, map[string]interface{}{
+		"new-model": string(remodelJsonData),
+		"queue":     true,
+	})
+}
+
 func (cs *clientSuite) TestClientGetModelHappy(c *C) {
 	cs.status = 200
 	cs.rsp = happyModelAssertionResponse
@@ -0,0 +1,81 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// A Notice is something that happened which the user should be informed
+// about, it is either for all users or for a single one. Repeated
+// occurrences of a notice with the same type and key are recorded in the
+// same notice.
+type Notice struct {
+	ID            string            `json:"id"`
+	UserID        *uint32           `json:"user-id,omitempty"`
+	Type          string            `json:"type"`
+	Key           string            `json:"key"`
+	FirstOccurred time.Time         `json:"first-occurred"`
+	LastOccurred  time.Time         `json:"last-occurred"`
+	Occurrences   int               `json:"occurrences"`
+	Data          map[string]string `json:"data,omitempty"`
+	RequiresAck   bool              `json:"requires-ack,omitempty"`
+	LastAcked     time.Time         `json:"last-acked,omitempty"`
+}
+
+// NoticesOptions contains options for querying snapd for notices.
+type NoticesOptions struct {
+	// Types selects notices of any of the given types, if set.
+	Types []string
+	// Pending selects only the notices waiting for acknowledgment.
+	Pending bool
+}
+
+// Notices returns the notices visible to the current user.
+func (client *Client) Notices(opts NoticesOptions) ([]*Notice, error) {
+	q := make(url.Values)
+	if len(opts.Types) > 0 {
+		q.Set("types", strings.Join(opts.Types, ","))
+	}
+	if opts.Pending {
+		q.Set("select", "pending")
+	}
+	var notices []*Notice
+	_, err := client.doSync("GET", "/v2/notices", q, nil, nil, &notices)
+	return notices, err
+}
+
+// AckNotice acknowledges the notice with the given ID.
+func (client *Client) AckNotice(id string) error {
+	var body bytes.Buffer
+	op := struct {
+		Action string `json:"action"`
+		ID     string `json:"id"`
+	}{Action: "ack", ID: id}
+	if err := json.NewEncoder(&body).Encode(op); err != nil {
+		return err
+	}
+	_, err := client.doSync("POST", "/v2/notices", nil, nil, &body, nil)
+	return err
+}
@@ -0,0 +1,93 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestNotices(c *check.C) {
+	cs.rsp = `{
+		"result": [
+		    {
+			"id": "1",
+			"user-id": 1000,
+			"type": "refresh-inhibit",
+			"key": "foo",
+			"first-occurred": "2021-06-01T10:00:00Z",
+			"last-occurred": "2021-06-01T11:00:00Z",
+			"occurrences": 2,
+			"data": {"busy-app": "foo"},
+			"requires-ack": true,
+			"expire-after": "168h0m0s"
+		    }
+		],
+		"status": "OK",
+		"status-code": 200,
+		"type": "sync"
+	}`
+
+	notices, err := cs.cli.Notices(client.NoticesOptions{
+		Types:   []string{"refresh-inhibit", "other"},
+		Pending: true,
+	})
+	c.Assert(err, check.IsNil)
+	uid := uint32(1000)
+	c.Check(notices, check.DeepEquals, []*client.Notice{{
+		ID:            "1",
+		UserID:        &uid,
+		Type:          "refresh-inhibit",
+		Key:           "foo",
+		FirstOccurred: time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+		LastOccurred:  time.Date(2021, 6, 1, 11, 0, 0, 0, time.UTC),
+		Occurrences:   2,
+		Data:          map[string]string{"busy-app": "foo"},
+		RequiresAck:   true,
+	}})
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/notices")
+	query := cs.req.URL.Query()
+	c.Check(query, check.HasLen, 2)
+	c.Check(query.Get("types"), check.Equals, "refresh-inhibit,other")
+	c.Check(query.Get("select"), check.Equals, "pending")
+}
+
+func (cs *clientSuite) TestAckNotice(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": true
+	}`
+	err := cs.cli.AckNotice("1")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/notices")
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action": "ack",
+		"id":     "1",
+	})
+}
@@ -80,6 +80,14 @@ type Snap struct {
 	Tracks []string `json:"tracks,omitempty"`
 
 	Health *SnapHealth `json:"health,omitempty"`
+
+	// PendingRefresh is set when a refresh of the snap was staged by an
+	// auto-refresh and awaits approval.
+	PendingRefresh *SnapPendingRefresh `json:"pending-refresh,omitempty"`
+
+	// RefreshInhibit is set when refreshes of the snap are inhibited by
+	// its running apps.
+	RefreshInhibit *SnapRefreshInhibit `json:"refresh-inhibit,omitempty"`
 }
 
 type SnapHealth struct {
@@ -90,6 +98,20 @@ type SnapHealth struct {
 	Code      string        `json:"code,omitempty"`
 }
 
+type SnapPendingRefresh struct {
+	Revision   snap.Revision `json:"revision"`
+	Version    string        `json:"version,omitempty"`
+	Channel    string        `json:"channel,omitempty"`
+	StagedTime time.Time     `json:"staged-time"`
+}
+
+type SnapRefreshInhibit struct {
+	ProceedTime time.Time `json:"proceed-time"`
+	// Action is what happens once ProceedTime is reached, one of
+	// "force", "skip" or "notify".
+	Action string `json:"action"`
+}
+
 func (s *Snap) MarshalJSON() ([]byte, error) {
 	type auxSnap Snap // use auxiliary type so that Go does not call Snap.MarshalJSON()
 	// separate type just for marshalling
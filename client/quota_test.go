@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"io/ioutil"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -32,7 +33,7 @@ import (
 )
 
 func (cs *clientSuite) TestCreateQuotaGroupInvalidName(c *check.C) {
-	_, err := cs.cli.EnsureQuota("", "", nil, 0)
+	_, err := cs.cli.EnsureQuota("", "", nil, nil)
 	c.Check(err, check.ErrorMatches, `cannot create or update quota group without a name`)
 }
 
@@ -44,7 +45,15 @@ func (cs *clientSuite) TestEnsureQuotaGroup(c *check.C) {
 		"change": "42"
 	}`
 
-	chgID, err := cs.cli.EnsureQuota("foo", "bar", []string{"snap-a", "snap-b"}, 1001)
+	chgID, err := cs.cli.EnsureQuota("foo", "bar", []string{"snap-a", "snap-b"}, &client.QuotaValues{
+		Memory: 1001,
+		CPUSet: []int{0, 1},
+		Journal: &client.QuotaJournalValues{
+			Size:       1002,
+			RateCount:  10,
+			RatePeriod: time.Second,
+		},
+	})
 	c.Assert(err, check.IsNil)
 	c.Assert(chgID, check.Equals, "42")
 	c.Check(cs.req.Method, check.Equals, "POST")
@@ -60,7 +69,13 @@ func (cs *clientSuite) TestEnsureQuotaGroup(c *check.C) {
 		"parent":     "bar",
 		"snaps":      []interface{}{"snap-a", "snap-b"},
 		"constraints": map[string]interface{}{
-			"memory": json.Number("1001"),
+			"memory":  json.Number("1001"),
+			"cpu-set": []interface{}{json.Number("0"), json.Number("1")},
+			"journal": map[string]interface{}{
+				"size":        json.Number("1002"),
+				"rate-count":  json.Number("10"),
+				"rate-period": json.Number("1000000000"),
+			},
 		},
 	})
 }
@@ -68,7 +83,7 @@ func (cs *clientSuite) TestEnsureQuotaGroup(c *check.C) {
 func (cs *clientSuite) TestEnsureQuotaGroupError(c *check.C) {
 	cs.status = 500
 	cs.rsp = `{"type": "error"}`
-	_, err := cs.cli.EnsureQuota("foo", "bar", []string{"snap-a"}, 1)
+	_, err := cs.cli.EnsureQuota("foo", "bar", []string{"snap-a"}, &client.QuotaValues{Memory: 1})
 	c.Check(err, check.ErrorMatches, `server error: "Internal Server Error"`)
 }
 
@@ -0,0 +1,68 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+func registryViewPath(account, registryName, view string) string {
+	return fmt.Sprintf("/v2/registry/%s/%s/%s", account, registryName, view)
+}
+
+// RegistryGet asks for the values of the keys through the registry view.
+// If no keys are given, everything readable through the view is returned.
+//
+// Note that the values may include json.Numbers.
+func (client *Client) RegistryGet(account, registryName, view string, keys []string) (map[string]interface{}, error) {
+	query := url.Values{}
+	if len(keys) > 0 {
+		query.Set("keys", strings.Join(keys, ","))
+	}
+
+	var values map[string]interface{}
+	if _, err := client.doSync("GET", registryViewPath(account, registryName, view), query, nil, nil, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// RegistrySet sets the values of the keys through the registry view, a nil
+// value unsets the key. The returned change ID is that of the change
+// notifying the snaps observing the registry, it is empty if there are
+// none.
+func (client *Client) RegistrySet(account, registryName, view string, values map[string]interface{}) (changeID string, err error) {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	var res struct {
+		Change string `json:"change"`
+	}
+	if _, err := client.doSync("PUT", registryViewPath(account, registryName, view), nil, nil, bytes.NewReader(b), &res); err != nil {
+		return "", err
+	}
+	return res.Change, nil
+}
@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+
+	"gopkg.in/check.v1"
+)
+
+func (cs *clientSuite) TestClientRegistryGet(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"ssid": "home"}
+	}`
+	values, err := cs.cli.RegistryGet("acc", "network", "wifi-setup", []string{"ssid", "password"})
+	c.Assert(err, check.IsNil)
+	c.Check(values, check.DeepEquals, map[string]interface{}{"ssid": "home"})
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/registry/acc/network/wifi-setup")
+	c.Check(cs.req.URL.Query().Get("keys"), check.Equals, "ssid,password")
+}
+
+func (cs *clientSuite) TestClientRegistryGetAll(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {}
+	}`
+	values, err := cs.cli.RegistryGet("acc", "network", "wifi-setup", nil)
+	c.Assert(err, check.IsNil)
+	c.Check(values, check.DeepEquals, map[string]interface{}{})
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+}
+
+func (cs *clientSuite) TestClientRegistrySet(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"change": "42"}
+	}`
+	id, err := cs.cli.RegistrySet("acc", "network", "wifi-setup", map[string]interface{}{"ssid": "home", "password": nil})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "42")
+	c.Check(cs.req.Method, check.Equals, "PUT")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/registry/acc/network/wifi-setup")
+
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{"ssid": "home", "password": nil})
+}
+
+func (cs *clientSuite) TestClientRegistrySetNoObservers(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {}
+	}`
+	id, err := cs.cli.RegistrySet("acc", "network", "wifi-setup", map[string]interface{}{"ssid": "home"})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "")
+}
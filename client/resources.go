@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"time"
+
+	"github.com/snapcore/snapd/gadget/quantity"
+)
+
+// SnapResourceUsage is the disk, memory and CPU used by a snap.
+type SnapResourceUsage struct {
+	Snap string `json:"snap"`
+	// QuotaGroup is the quota group the snap is in, if any.
+	QuotaGroup string `json:"quota-group,omitempty"`
+	// Blobs is the size of the snap files of all the revisions of the
+	// snap kept on the system.
+	Blobs quantity.Size `json:"blobs"`
+	// Data is the size of the system data of all the revisions.
+	Data quantity.Size `json:"data"`
+	// Common is the size of the system data common to all revisions.
+	Common quantity.Size `json:"common"`
+	// Cache is the size of the snap files of the snap kept only in the
+	// download cache.
+	Cache quantity.Size `json:"cache"`
+	// Snapshots is the size of the snapshots of the snap.
+	Snapshots quantity.Size `json:"snapshots"`
+	// Memory is the memory currently used by the services of the snap.
+	Memory quantity.Size `json:"memory"`
+	// CPUTime is the CPU time consumed by the running services of the snap.
+	CPUTime time.Duration `json:"cpu-time"`
+}
+
+// Disk returns the disk space used by the snap.
+func (u *SnapResourceUsage) Disk() quantity.Size {
+	return u.Blobs + u.Data + u.Common + u.Cache + u.Snapshots
+}
+
+// QuotaGroupResourceUsage is the disk, memory and CPU used by the snaps in
+// a quota group.
+type QuotaGroupResourceUsage struct {
+	GroupName string        `json:"group-name"`
+	Snaps     []string      `json:"snaps,omitempty"`
+	Disk      quantity.Size `json:"disk"`
+	Memory    quantity.Size `json:"memory"`
+	CPUTime   time.Duration `json:"cpu-time"`
+}
+
+// SystemResources is the usage of system resources by snaps.
+type SystemResources struct {
+	Snaps       []*SnapResourceUsage       `json:"snaps"`
+	QuotaGroups []*QuotaGroupResourceUsage `json:"quota-groups,omitempty"`
+}
+
+// SystemResources returns the disk, memory and CPU used by the installed
+// snaps, and aggregated by quota group.
+func (client *Client) SystemResources() (*SystemResources, error) {
+	var res *SystemResources
+	if _, err := client.doSync("GET", "/v2/system-resources", nil, nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,70 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/gadget/quantity"
+)
+
+func (cs *clientSuite) TestSystemResources(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"snaps": [
+				{"snap": "foo", "quota-group": "grp", "blobs": 1000, "data": 200, "common": 30, "cache": 5, "snapshots": 4, "memory": 500, "cpu-time": 2000000000}
+			],
+			"quota-groups": [
+				{"group-name": "grp", "snaps": ["foo"], "disk": 1239, "memory": 500, "cpu-time": 2000000000}
+			]
+		}
+	}`
+
+	res, err := cs.cli.SystemResources()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/system-resources")
+	c.Check(res, check.DeepEquals, &client.SystemResources{
+		Snaps: []*client.SnapResourceUsage{{
+			Snap:       "foo",
+			QuotaGroup: "grp",
+			Blobs:      1000,
+			Data:       200,
+			Common:     30,
+			Cache:      5,
+			Snapshots:  4,
+			Memory:     500,
+			CPUTime:    2 * time.Second,
+		}},
+		QuotaGroups: []*client.QuotaGroupResourceUsage{{
+			GroupName: "grp",
+			Snaps:     []string{"foo"},
+			Disk:      1239,
+			Memory:    500,
+			CPUTime:   2 * time.Second,
+		}},
+	})
+	c.Check(res.Snaps[0].Disk(), check.Equals, quantity.Size(1239))
+}
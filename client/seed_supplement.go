@@ -0,0 +1,43 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// InstallSeedSupplement installs the snaps of the seed supplement in the
+// given directory on the device, with seed semantics. The directory must
+// hold a "snaps" and an "assertions" subdirectory, the latter with all the
+// assertions needed to verify the snaps.
+func (client *Client) InstallSeedSupplement(dir string) (changeID string, err error) {
+	req := struct {
+		Path string `json:"path"`
+	}{
+		Path: dir,
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&req); err != nil {
+		return "", err
+	}
+	return client.doAsync("POST", "/v2/seed-supplement", nil, nil, &body)
+}
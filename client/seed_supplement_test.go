@@ -0,0 +1,61 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"gopkg.in/check.v1"
+)
+
+func (cs *clientSuite) TestInstallSeedSupplement(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+	    "type": "async",
+	    "status-code": 202,
+	    "result": {},
+	    "change": "42"
+	}`
+	id, err := cs.cli.InstallSeedSupplement("/var/lib/supplement")
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "42")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/seed-supplement")
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]interface{}
+	c.Assert(json.Unmarshal(body, &req), check.IsNil)
+	c.Check(req, check.DeepEquals, map[string]interface{}{
+		"path": "/var/lib/supplement",
+	})
+}
+
+func (cs *clientSuite) TestInstallSeedSupplementError(c *check.C) {
+	cs.status = 400
+	cs.rsp = `{
+	    "type": "error",
+	    "status-code": 400,
+	    "result": {"message": "cannot install seed supplement: all its snaps are already installed"}
+	}`
+	_, err := cs.cli.InstallSeedSupplement("/var/lib/supplement")
+	c.Check(err, check.ErrorMatches, "cannot install seed supplement: all its snaps are already installed")
+}
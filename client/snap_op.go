@@ -28,6 +28,19 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"reflect"
+)
+
+// TransactionType specifies how a multi-snap operation is carried out.
+type TransactionType string
+
+const (
+	// TransactionPerSnap carries out the operation independently for each
+	// snap, a failure for one snap does not affect the others.
+	TransactionPerSnap TransactionType = "per-snap"
+	// TransactionAllSnaps carries out the operation for all snaps as a
+	// single transaction, a failure for one snap undoes it for all of them.
+	TransactionAllSnaps TransactionType = "all-snaps"
 )
 
 type SnapOptions struct {
@@ -44,6 +57,10 @@ type SnapOptions struct {
 	Unaliased        bool   `json:"unaliased,omitempty"`
 	Purge            bool   `json:"purge,omitempty"`
 	Amend            bool   `json:"amend,omitempty"`
+	OCIRef           string `json:"oci-ref,omitempty"`
+
+	Transaction    TransactionType `json:"transaction,omitempty"`
+	CascadeContent bool            `json:"cascade-content,omitempty"`
 
 	Users []string `json:"users,omitempty"`
 }
@@ -89,9 +106,13 @@ type actionData struct {
 }
 
 type multiActionData struct {
-	Action string   `json:"action"`
-	Snaps  []string `json:"snaps,omitempty"`
-	Users  []string `json:"users,omitempty"`
+	Action       string   `json:"action"`
+	Snaps        []string `json:"snaps,omitempty"`
+	Users        []string `json:"users,omitempty"`
+	ApplyPending bool     `json:"apply-pending,omitempty"`
+
+	Transaction    TransactionType `json:"transaction,omitempty"`
+	CascadeContent bool            `json:"cascade-content,omitempty"`
 }
 
 // Install adds the snap with the given name from the given channel (or
@@ -123,6 +144,26 @@ func (client *Client) RefreshMany(names []string, options *SnapOptions) (changeI
 	return client.doMultiSnapAction("refresh", names, options)
 }
 
+// ApplyPendingRefreshes refreshes the given snaps, or all snaps if none
+// are given, to the revisions staged by auto-refreshes awaiting approval.
+func (client *Client) ApplyPendingRefreshes(names []string) (changeID string, err error) {
+	action := multiActionData{
+		Action:       "refresh",
+		Snaps:        names,
+		ApplyPending: true,
+	}
+	data, err := json.Marshal(&action)
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal multi-snap action: %s", err)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	return client.doAsync("POST", "/v2/snaps", nil, headers, bytes.NewBuffer(data))
+}
+
 func (client *Client) Enable(name string, options *SnapOptions) (changeID string, err error) {
 	return client.doSnapAction("enable", name, options)
 }
@@ -182,8 +223,18 @@ func (client *Client) doSnapAction(actionName string, snapName string, options *
 	return client.doAsync("POST", path, nil, headers, bytes.NewBuffer(data))
 }
 
+// onlyMultiActionOptions returns whether only options supported by
+// multi-snap actions are set.
+func (opts *SnapOptions) onlyMultiActionOptions() bool {
+	multiOpts := SnapOptions{
+		Transaction:    opts.Transaction,
+		CascadeContent: opts.CascadeContent,
+	}
+	return reflect.DeepEqual(*opts, multiOpts)
+}
+
 func (client *Client) doMultiSnapAction(actionName string, snaps []string, options *SnapOptions) (changeID string, err error) {
-	if options != nil {
+	if options != nil && !options.onlyMultiActionOptions() {
 		return "", fmt.Errorf("cannot use options for multi-action") // (yet)
 	}
 	_, changeID, err = client.doMultiSnapActionFull(actionName, snaps, options)
@@ -198,6 +249,8 @@ func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, o
 	}
 	if options != nil {
 		action.Users = options.Users
+		action.Transaction = options.Transaction
+		action.CascadeContent = options.CascadeContent
 	}
 	data, err := json.Marshal(&action)
 	if err != nil {
@@ -228,7 +281,40 @@ func (client *Client) InstallPath(path, name string, options *SnapOptions) (chan
 
 	pr, pw := io.Pipe()
 	mw := multipart.NewWriter(pw)
-	go sendSnapFile(path, f, pw, mw, &action)
+	go sendSnapFiles([]string{path}, []*os.File{f}, pw, mw, &action)
+
+	headers := map[string]string{
+		"Content-Type": mw.FormDataContentType(),
+	}
+
+	_, changeID, err = client.doAsyncFull("POST", "/v2/snaps", nil, headers, pr, doNoTimeoutAndRetry)
+	return changeID, err
+}
+
+// InstallPathMany installs the snaps from the given local files together.
+// Bases, content providers and default-providers found among the files are
+// installed before the snaps needing them.
+func (client *Client) InstallPathMany(paths []string, options *SnapOptions) (changeID string, err error) {
+	files := make([]*os.File, 0, len(paths))
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return "", fmt.Errorf("cannot open: %q", path)
+		}
+		files = append(files, f)
+	}
+
+	action := actionData{
+		Action:      "install",
+		SnapOptions: options,
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go sendSnapFiles(paths, files, pw, mw, &action)
 
 	headers := map[string]string{
 		"Content-Type": mw.FormDataContentType(),
@@ -261,8 +347,12 @@ func (client *Client) Try(path string, options *SnapOptions) (changeID string, e
 	return client.doAsync("POST", "/v2/snaps", nil, headers, buf)
 }
 
-func sendSnapFile(snapPath string, snapFile *os.File, pw *io.PipeWriter, mw *multipart.Writer, action *actionData) {
-	defer snapFile.Close()
+func sendSnapFiles(snapPaths []string, snapFiles []*os.File, pw *io.PipeWriter, mw *multipart.Writer, action *actionData) {
+	defer func() {
+		for _, f := range snapFiles {
+			f.Close()
+		}
+	}()
 
 	if action.SnapOptions == nil {
 		action.SnapOptions = &SnapOptions{}
@@ -296,16 +386,18 @@ func sendSnapFile(snapPath string, snapFile *os.File, pw *io.PipeWriter, mw *mul
 		return
 	}
 
-	fw, err := mw.CreateFormFile("snap", filepath.Base(snapPath))
-	if err != nil {
-		pw.CloseWithError(err)
-		return
-	}
+	for i, snapFile := range snapFiles {
+		fw, err := mw.CreateFormFile("snap", filepath.Base(snapPaths[i]))
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
 
-	_, err = io.Copy(fw, snapFile)
-	if err != nil {
-		pw.CloseWithError(err)
-		return
+		_, err = io.Copy(fw, snapFile)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
 	}
 
 	mw.Close()
@@ -397,3 +489,25 @@ func (client *Client) Download(name string, options *DownloadOptions) (dlInfo *D
 
 	return dlInfo, rsp.Body, nil
 }
+
+// SnapFile streams the snap file of the current revision of the given
+// installed snap.
+func (client *Client) SnapFile(name string) (r io.ReadCloser, size int64, err error) {
+	// no deadline for downloads
+	ctx := context.Background()
+	rsp, err := client.raw(ctx, "GET", fmt.Sprintf("/v2/snaps/%s/file", name), nil, nil, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if rsp.StatusCode != 200 {
+		var r response
+		defer rsp.Body.Close()
+		if err := decodeInto(rsp.Body, &r); err != nil {
+			return nil, 0, err
+		}
+		return nil, 0, r.err(client, rsp.StatusCode)
+	}
+
+	return rsp.Body, rsp.ContentLength, nil
+}
@@ -212,6 +212,69 @@ func (cs *clientSuite) TestClientMultiSnapshot(c *check.C) {
 	c.Check(changeID, check.Equals, "d728")
 }
 
+func (cs *clientSuite) TestClientApplyPendingRefreshes(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	id, err := cs.cli.ApplyPendingRefreshes([]string{pkgName})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	jsonBody := make(map[string]interface{})
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":        "refresh",
+		"snaps":         []interface{}{pkgName},
+		"apply-pending": true,
+	})
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+	c.Check(id, check.Equals, "d728")
+}
+
+func (cs *clientSuite) TestClientRemoveManyTransactional(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	opts := &client.SnapOptions{
+		Transaction:    client.TransactionAllSnaps,
+		CascadeContent: true,
+	}
+	id, err := cs.cli.RemoveMany([]string{pkgName, "other"}, opts)
+	c.Assert(err, check.IsNil)
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	jsonBody := make(map[string]interface{})
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":          "remove",
+		"snaps":           []interface{}{pkgName, "other"},
+		"transaction":     "all-snaps",
+		"cascade-content": true,
+	})
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+	c.Check(id, check.Equals, "d728")
+}
+
+func (cs *clientSuite) TestClientMultiOpUnsupportedOptions(c *check.C) {
+	opts := &client.SnapOptions{
+		Transaction: client.TransactionAllSnaps,
+		Purge:       true,
+	}
+	_, err := cs.cli.RemoveMany([]string{pkgName}, opts)
+	c.Assert(err, check.ErrorMatches, "cannot use options for multi-action")
+}
+
 func (cs *clientSuite) TestClientOpInstallPath(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
@@ -242,6 +305,45 @@ func (cs *clientSuite) TestClientOpInstallPath(c *check.C) {
 	c.Check(id, check.Equals, "66b3")
 }
 
+func (cs *clientSuite) TestClientOpInstallPathMany(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "66b3",
+		"status-code": 202,
+		"type": "async"
+	}`
+
+	dir := c.MkDir()
+	var paths []string
+	for _, name := range []string{"foo", "bar"} {
+		path := filepath.Join(dir, name+".snap")
+		err := ioutil.WriteFile(path, []byte(name+"-data"), 0644)
+		c.Assert(err, check.IsNil)
+		paths = append(paths, path)
+	}
+
+	id, err := cs.cli.InstallPathMany(paths, &client.SnapOptions{Dangerous: true})
+	c.Assert(err, check.IsNil)
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(string(body), check.Matches, "(?s).*Content-Disposition: form-data; name=\"action\"\r\n\r\ninstall\r\n.*")
+	c.Assert(string(body), check.Matches, "(?s).*Content-Disposition: form-data; name=\"dangerous\"\r\n\r\ntrue\r\n.*")
+	c.Assert(string(body), check.Matches, "(?s).*name=\"snap\"; filename=\"foo.snap\".*\r\nfoo-data\r\n.*name=\"snap\"; filename=\"bar.snap\".*\r\nbar-data\r\n.*")
+	c.Check(string(body), check.Not(check.Matches), "(?s).*name=\"snap-path\".*")
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+	c.Assert(cs.req.Header.Get("Content-Type"), check.Matches, "multipart/form-data; boundary=.*")
+	c.Check(id, check.Equals, "66b3")
+}
+
+func (cs *clientSuite) TestClientOpInstallPathManyMissingFile(c *check.C) {
+	_, err := cs.cli.InstallPathMany([]string{filepath.Join(c.MkDir(), "missing.snap")}, nil)
+	c.Assert(err, check.ErrorMatches, `cannot open: ".*/missing.snap"`)
+}
+
 func (cs *clientSuite) TestClientOpInstallPathIgnoreRunning(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
@@ -519,6 +621,32 @@ func (cs *clientSuite) TestClientOpDownload(c *check.C) {
 	c.Check(rc.Close(), check.IsNil)
 }
 
+func (cs *clientSuite) TestClientSnapFile(c *check.C) {
+	cs.status = 200
+	cs.contentLength = 16
+	cs.rsp = `lots-of-foo-data`
+
+	rc, size, err := cs.cli.SnapFile("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(size, check.Equals, int64(16))
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo/file")
+
+	content, err := ioutil.ReadAll(rc)
+	c.Assert(err, check.IsNil)
+	c.Check(string(content), check.Equals, cs.rsp)
+	c.Check(rc.Close(), check.IsNil)
+}
+
+func (cs *clientSuite) TestClientSnapFileError(c *check.C) {
+	cs.status = 404
+	cs.rsp = `{"type": "error", "result": {"message": "snap not installed", "kind": "snap-not-found", "value": "foo"}}`
+
+	_, _, err := cs.cli.SnapFile("foo")
+	c.Assert(err, check.ErrorMatches, "snap not installed")
+	c.Check(err.(*client.Error).Kind, check.Equals, client.ErrorKindSnapNotFound)
+}
+
 func (cs *clientSuite) TestClientOpDownloadResume(c *check.C) {
 	cs.status = 200
 	cs.header = http.Header{
@@ -79,6 +79,9 @@ type Snapshot struct {
 	Size int64 `json:"size,omitempty"`
 	// if the snapshot failed to open this will be the reason why
 	Broken string `json:"broken,omitempty"`
+	// the format the archives and configuration are encrypted
+	// with, if the snapshot is encrypted
+	Encryption string `json:"encryption,omitempty"`
 
 	// set if the snapshot was created automatically on snap removal;
 	// note, this is only set inside actual snapshot file for old snapshots;
@@ -52,6 +52,11 @@ type System struct {
 	Brand snap.StoreAccount `json:"brand,omitempty"`
 	// Actions available for this system
 	Actions []SystemAction `json:"actions,omitempty"`
+	// Description is the human readable description of the system, if
+	// it was created with one
+	Description string `json:"description,omitempty"`
+	// Tags of the system, if it was created with any
+	Tags []string `json:"tags,omitempty"`
 }
 
 type SystemAction struct {
@@ -104,6 +109,47 @@ func (client *Client) DoSystemAction(systemLabel string, action *SystemAction) e
 	return nil
 }
 
+// CreateSystemOptions holds the optional metadata of a recovery system
+// to create.
+type CreateSystemOptions struct {
+	// Description is a human readable description of the system
+	Description string `json:"description,omitempty"`
+	// Tags are short labels to tell the system apart from others
+	Tags []string `json:"tags,omitempty"`
+}
+
+// CreateSystem issues a request to create a recovery system with the
+// given label from the snaps currently installed, returning the ID of
+// the change doing it.
+func (client *Client) CreateSystem(systemLabel string, opts *CreateSystemOptions) (changeID string, err error) {
+	if systemLabel == "" {
+		return "", fmt.Errorf("cannot create a recovery system without a label")
+	}
+	if opts == nil {
+		opts = &CreateSystemOptions{}
+	}
+
+	req := struct {
+		Action string `json:"action"`
+		Label  string `json:"label"`
+		*CreateSystemOptions
+	}{
+		Action:              "create",
+		Label:               systemLabel,
+		CreateSystemOptions: opts,
+	}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&req); err != nil {
+		return "", err
+	}
+	changeID, err = client.doAsync("POST", "/v2/systems", nil, nil, &body)
+	if err != nil {
+		return "", xerrors.Errorf("cannot create recovery system %q: %v", systemLabel, err)
+	}
+	return changeID, nil
+}
+
 // RebootToSystem issues a request to reboot into system with the
 // given label and the given mode.
 //
@@ -138,3 +184,32 @@ func (client *Client) RebootToSystem(systemLabel, mode string) error {
 	}
 	return nil
 }
+
+// InstallProgress describes the progress of installing the system in
+// install mode.
+type InstallProgress struct {
+	// Stage is the current stage of the installation, one of
+	// "seeding", "partitioning", "encryption-setup", "copying",
+	// "configuring", "making-bootable" or "done".
+	Stage string `json:"stage"`
+	// Done and Total are the number of completed stages and the total
+	// number of stages of the installation.
+	Done  int `json:"done"`
+	Total int `json:"total"`
+	// StageDone and StageTotal describe the progress within the
+	// current stage, when known.
+	StageDone  int    `json:"stage-done,omitempty"`
+	StageTotal int    `json:"stage-total,omitempty"`
+	ChangeID   string `json:"change-id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// InstallProgress returns the progress of installing the system, it is
+// only available in install mode.
+func (client *Client) InstallProgress() (*InstallProgress, error) {
+	var progress InstallProgress
+	if _, err := client.doSync("GET", "/v2/install-progress", nil, nil, nil, &progress); err != nil {
+		return nil, xerrors.Errorf("cannot get install progress: %v", err)
+	}
+	return &progress, nil
+}
@@ -215,3 +215,103 @@ func (cs *clientSuite) TestRequestSystemRebootErrorWithSystem(c *check.C) {
 	c.Check(cs.req.Method, check.Equals, "POST")
 	c.Check(cs.req.URL.Path, check.Equals, "/v2/systems/1234")
 }
+
+func (cs *clientSuite) TestCreateSystemHappy(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+	    "type": "async",
+	    "status-code": 202,
+	    "result": {},
+	    "change": "42"
+	}`
+	id, err := cs.cli.CreateSystem("pre-upgrade", &client.CreateSystemOptions{
+		Description: "before the upgrade",
+		Tags:        []string{"manual"},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "42")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/systems")
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]interface{}
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]interface{}{
+		"action":      "create",
+		"label":       "pre-upgrade",
+		"description": "before the upgrade",
+		"tags":        []interface{}{"manual"},
+	})
+}
+
+func (cs *clientSuite) TestCreateSystemNoOptions(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+	    "type": "async",
+	    "status-code": 202,
+	    "result": {},
+	    "change": "42"
+	}`
+	_, err := cs.cli.CreateSystem("pre-upgrade", nil)
+	c.Assert(err, check.IsNil)
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var req map[string]interface{}
+	err = json.Unmarshal(body, &req)
+	c.Assert(err, check.IsNil)
+	c.Assert(req, check.DeepEquals, map[string]interface{}{
+		"action": "create",
+		"label":  "pre-upgrade",
+	})
+}
+
+func (cs *clientSuite) TestCreateSystemError(c *check.C) {
+	_, err := cs.cli.CreateSystem("", nil)
+	c.Assert(err, check.ErrorMatches, "cannot create a recovery system without a label")
+
+	cs.status = 400
+	cs.rsp = `{
+	    "type": "error",
+	    "status-code": 400,
+	    "result": {"message": "invalid recovery system tag \"Foo\""}
+	}`
+	_, err = cs.cli.CreateSystem("pre-upgrade", &client.CreateSystemOptions{Tags: []string{"Foo"}})
+	c.Assert(err, check.ErrorMatches, `cannot create recovery system "pre-upgrade": invalid recovery system tag "Foo"`)
+}
+
+func (cs *clientSuite) TestInstallProgress(c *check.C) {
+	cs.rsp = `{
+	    "type": "sync",
+	    "status-code": 200,
+	    "result": {
+	        "stage": "copying",
+	        "done": 3,
+	        "total": 6,
+	        "change-id": "42"
+	    }
+	}`
+	progress, err := cs.cli.InstallProgress()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/install-progress")
+	c.Check(progress, check.DeepEquals, &client.InstallProgress{
+		Stage:    "copying",
+		Done:     3,
+		Total:    6,
+		ChangeID: "42",
+	})
+}
+
+func (cs *clientSuite) TestInstallProgressError(c *check.C) {
+	cs.status = 400
+	cs.rsp = `{
+	    "type": "error",
+	    "status-code": 400,
+	    "result": {"message": "cannot report install progress in \"run\" mode"}
+	}`
+	_, err := cs.cli.InstallProgress()
+	c.Assert(err, check.ErrorMatches, `cannot get install progress: cannot report install progress in "run" mode`)
+}
@@ -0,0 +1,61 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ThemesReport holds the result of reporting the themes used by a
+// desktop session to snapd.
+type ThemesReport struct {
+	// Snaps lists the snaps that could provide the missing themes.
+	Snaps []string `json:"snaps,omitempty"`
+	// NoticeID is the ID of the notice recorded for the user to
+	// decide on installing Snaps.
+	NoticeID string `json:"notice-id,omitempty"`
+}
+
+type themesReportRequest struct {
+	GtkThemes   []string `json:"gtk-themes"`
+	IconThemes  []string `json:"icon-themes"`
+	SoundThemes []string `json:"sound-themes"`
+}
+
+// ReportThemes tells snapd about the themes used by the calling user's
+// desktop session, so that snaps providing missing themes can be
+// offered for installation.
+func (client *Client) ReportThemes(gtkThemes, iconThemes, soundThemes []string) (*ThemesReport, error) {
+	var body bytes.Buffer
+	req := themesReportRequest{
+		GtkThemes:   gtkThemes,
+		IconThemes:  iconThemes,
+		SoundThemes: soundThemes,
+	}
+	if err := json.NewEncoder(&body).Encode(req); err != nil {
+		return nil, err
+	}
+	var report ThemesReport
+	if _, err := client.doSync("POST", "/v2/accessories/themes/report", nil, nil, &body, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
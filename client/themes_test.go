@@ -0,0 +1,61 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestReportThemes(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"snaps": ["gtk-theme-foo"], "notice-id": "7"}
+	}`
+
+	report, err := cs.cli.ReportThemes([]string{"Foo-gtk"}, []string{"Bar"}, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(report, check.DeepEquals, &client.ThemesReport{
+		Snaps:    []string{"gtk-theme-foo"},
+		NoticeID: "7",
+	})
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/accessories/themes/report")
+
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"gtk-themes":   []interface{}{"Foo-gtk"},
+		"icon-themes":  []interface{}{"Bar"},
+		"sound-themes": nil,
+	})
+}
+
+func (cs *clientSuite) TestReportThemesError(c *check.C) {
+	cs.status = 500
+	cs.rsp = `{"type": "error", "result": {"message": "boom"}}`
+
+	_, err := cs.cli.ReportThemes([]string{"Foo-gtk"}, nil, nil)
+	c.Check(err, check.ErrorMatches, "boom")
+}
@@ -0,0 +1,301 @@
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+#ifdef HAVE_CONFIG_H
+#include "config.h"
+#endif
+
+#include "landlock-support.h"
+
+#include "../libsnap-confine-private/utils.h"
+
+#ifdef HAVE_LANDLOCK
+
+#include <errno.h>
+#include <fcntl.h>
+#include <limits.h>
+#include <pwd.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/stat.h>
+#include <sys/syscall.h>
+#include <sys/types.h>
+#include <unistd.h>
+
+#include <linux/landlock.h>
+
+#include "../libsnap-confine-private/cleanup-funcs.h"
+#include "../libsnap-confine-private/string-utils.h"
+
+/* The system calls have the same number on all architectures. */
+#ifndef __NR_landlock_create_ruleset
+#define __NR_landlock_create_ruleset 444
+#endif
+#ifndef __NR_landlock_add_rule
+#define __NR_landlock_add_rule 445
+#endif
+#ifndef __NR_landlock_restrict_self
+#define __NR_landlock_restrict_self 446
+#endif
+
+/* Access rights introduced by later versions of the landlock ABI. */
+#ifndef LANDLOCK_ACCESS_FS_REFER
+#define LANDLOCK_ACCESS_FS_REFER (1ULL << 13)
+#endif
+#ifndef LANDLOCK_ACCESS_FS_TRUNCATE
+#define LANDLOCK_ACCESS_FS_TRUNCATE (1ULL << 14)
+#endif
+
+static const char *landlock_rules_dir = "/var/lib/snapd/landlock";
+
+#define LANDLOCK_ACCESS_FS_READ \
+    (LANDLOCK_ACCESS_FS_EXECUTE | LANDLOCK_ACCESS_FS_READ_FILE | LANDLOCK_ACCESS_FS_READ_DIR)
+
+#define LANDLOCK_ACCESS_FS_WRITE                                                                      \
+    (LANDLOCK_ACCESS_FS_WRITE_FILE | LANDLOCK_ACCESS_FS_REMOVE_DIR | LANDLOCK_ACCESS_FS_REMOVE_FILE | \
+     LANDLOCK_ACCESS_FS_MAKE_DIR | LANDLOCK_ACCESS_FS_MAKE_REG | LANDLOCK_ACCESS_FS_MAKE_SOCK |       \
+     LANDLOCK_ACCESS_FS_MAKE_FIFO | LANDLOCK_ACCESS_FS_MAKE_SYM)
+
+/* Creating devices is never granted, not even by "rw" rules. */
+#define LANDLOCK_ACCESS_FS_MAKE_DEV (LANDLOCK_ACCESS_FS_MAKE_CHAR | LANDLOCK_ACCESS_FS_MAKE_BLOCK)
+
+/* Access rights that apply to files, as opposed to directories. */
+#define LANDLOCK_ACCESS_FS_FILE                                                                    \
+    (LANDLOCK_ACCESS_FS_EXECUTE | LANDLOCK_ACCESS_FS_WRITE_FILE | LANDLOCK_ACCESS_FS_READ_FILE | \
+     LANDLOCK_ACCESS_FS_TRUNCATE)
+
+static int landlock_create_ruleset(const struct landlock_ruleset_attr *attr, size_t size, uint32_t flags) {
+    return syscall(__NR_landlock_create_ruleset, attr, size, flags);
+}
+
+static int landlock_add_rule(int ruleset_fd, enum landlock_rule_type rule_type, const void *rule_attr,
+                             uint32_t flags) {
+    return syscall(__NR_landlock_add_rule, ruleset_fd, rule_type, rule_attr, flags);
+}
+
+static int landlock_restrict_self(int ruleset_fd, uint32_t flags) {
+    return syscall(__NR_landlock_restrict_self, ruleset_fd, flags);
+}
+
+static void validate_fd_has_strict_perms(int fd, const char *path) {
+    struct stat stat_buf;
+    if (fstat(fd, &stat_buf) < 0) {
+        die("cannot stat %s", path);
+    }
+    errno = 0;
+    if (stat_buf.st_uid != 0 || stat_buf.st_gid != 0) {
+        die("%s not root-owned %i:%i", path, stat_buf.st_uid, stat_buf.st_gid);
+    }
+    if (stat_buf.st_mode & S_IWOTH) {
+        die("%s has 'other' write %o", path, stat_buf.st_mode);
+    }
+}
+
+/* Values of the variables which may start the path of a rule, see
+ * interfaces/landlock. These are derived from the invocation and the password
+ * database rather than taken from the environment, which is controlled by
+ * the caller. */
+typedef struct sc_landlock_vars {
+    const char *snap_name;
+    const char *snap_instance;
+    /* home is NULL if the home directory of the user is not known. */
+    const char *home;
+    uid_t uid;
+} sc_landlock_vars;
+
+static bool is_variable(const char *name, size_t name_len, const char *var) {
+    return strlen(var) == name_len && strncmp(var, name, name_len) == 0;
+}
+
+/**
+ * Expand the variable at the start of the given path into buf. Returns false
+ * if the variable cannot be expanded for the user, in which case the rule is
+ * skipped.
+ *
+ * Parallel instances see their directories under the snap name in the mount
+ * namespace, except for the XDG_RUNTIME_DIR one.
+ **/
+static bool expand_rule_path(const sc_landlock_vars *vars, const char *path, char *buf, size_t buf_size) {
+    if (path[0] != '$') {
+        sc_must_snprintf(buf, buf_size, "%s", path);
+        return true;
+    }
+    const char *name = path + 1;
+    size_t name_len = strcspn(name, "/");
+    const char *rest = name + name_len;
+    bool needs_home = is_variable(name, name_len, "SNAP_USER_DATA") ||
+                      is_variable(name, name_len, "SNAP_USER_COMMON") || is_variable(name, name_len, "SNAP_REAL_HOME");
+    if (needs_home && vars->home == NULL) {
+        debug("cannot expand landlock rule path %s: unknown home directory", path);
+        return false;
+    }
+    if (is_variable(name, name_len, "SNAP")) {
+        sc_must_snprintf(buf, buf_size, "%s/%s/current%s", SNAP_MOUNT_DIR, vars->snap_name, rest);
+    } else if (is_variable(name, name_len, "SNAP_DATA")) {
+        sc_must_snprintf(buf, buf_size, "/var/snap/%s/current%s", vars->snap_name, rest);
+    } else if (is_variable(name, name_len, "SNAP_COMMON")) {
+        sc_must_snprintf(buf, buf_size, "/var/snap/%s/common%s", vars->snap_name, rest);
+    } else if (is_variable(name, name_len, "SNAP_USER_DATA")) {
+        sc_must_snprintf(buf, buf_size, "%s/snap/%s/current%s", vars->home, vars->snap_name, rest);
+    } else if (is_variable(name, name_len, "SNAP_USER_COMMON")) {
+        sc_must_snprintf(buf, buf_size, "%s/snap/%s/common%s", vars->home, vars->snap_name, rest);
+    } else if (is_variable(name, name_len, "SNAP_REAL_HOME")) {
+        sc_must_snprintf(buf, buf_size, "%s%s", vars->home, rest);
+    } else if (is_variable(name, name_len, "XDG_RUNTIME_DIR")) {
+        sc_must_snprintf(buf, buf_size, "/run/user/%u/snap.%s%s", (unsigned)vars->uid, vars->snap_instance, rest);
+    } else {
+        die("cannot expand landlock rule path %s: unknown variable", path);
+    }
+    return true;
+}
+
+static void add_rule(int ruleset_fd, __u64 handled_access, const sc_landlock_vars *vars, const char *line) {
+    __u64 allowed_access = 0;
+    const char *path = NULL;
+    if (sc_startswith(line, "r ")) {
+        allowed_access = LANDLOCK_ACCESS_FS_READ;
+        path = line + 2;
+    } else if (sc_startswith(line, "rw ")) {
+        allowed_access = handled_access & ~LANDLOCK_ACCESS_FS_MAKE_DEV;
+        path = line + 3;
+    } else {
+        die("cannot parse landlock rule %s", line);
+    }
+    char expanded[PATH_MAX] = {0};
+    if (!expand_rule_path(vars, path, expanded, sizeof expanded)) {
+        return;
+    }
+    int fd SC_CLEANUP(sc_cleanup_close) = -1;
+    fd = open(expanded, O_PATH | O_CLOEXEC);
+    if (fd < 0) {
+        if (errno == ENOENT || errno == EACCES) {
+            debug("skipping landlock rule for inaccessible path %s", expanded);
+            return;
+        }
+        die("cannot open %s", expanded);
+    }
+    struct stat stat_buf;
+    if (fstat(fd, &stat_buf) < 0) {
+        die("cannot stat %s", expanded);
+    }
+    if (!S_ISDIR(stat_buf.st_mode)) {
+        /* Rules for files may only grant access rights that apply to files. */
+        allowed_access &= LANDLOCK_ACCESS_FS_FILE;
+    }
+    struct landlock_path_beneath_attr path_beneath = {
+        .allowed_access = allowed_access & handled_access,
+        .parent_fd = fd,
+    };
+    if (landlock_add_rule(ruleset_fd, LANDLOCK_RULE_PATH_BENEATH, &path_beneath, 0) < 0) {
+        die("cannot add landlock rule for %s", expanded);
+    }
+    debug("added landlock rule %s", line);
+}
+
+void sc_apply_landlock_ruleset_for_security_tag(const char *security_tag, const char *snap_name,
+                                                 const char *snap_instance) {
+    char rules_path[PATH_MAX] = {0};
+    sc_must_snprintf(rules_path, sizeof rules_path, "%s/%s.rules", landlock_rules_dir, security_tag);
+
+    FILE *file SC_CLEANUP(sc_cleanup_file) = NULL;
+    file = fopen(rules_path, "re");
+    if (file == NULL) {
+        if (errno == ENOENT) {
+            /* Without rules the process is not confined by landlock. */
+            return;
+        }
+        die("cannot open landlock rules %s", rules_path);
+    }
+    validate_fd_has_strict_perms(fileno(file), rules_path);
+    int dir_fd SC_CLEANUP(sc_cleanup_close) = -1;
+    dir_fd = open(landlock_rules_dir, O_PATH | O_DIRECTORY | O_CLOEXEC);
+    if (dir_fd < 0) {
+        die("cannot open %s", landlock_rules_dir);
+    }
+    validate_fd_has_strict_perms(dir_fd, landlock_rules_dir);
+
+    int abi = landlock_create_ruleset(NULL, 0, LANDLOCK_CREATE_RULESET_VERSION);
+    if (abi < 0) {
+        if (errno == ENOSYS || errno == EOPNOTSUPP) {
+            debug("landlock is not supported by the kernel, not applying %s", rules_path);
+            return;
+        }
+        die("cannot probe landlock ABI version");
+    }
+    __u64 handled_access = LANDLOCK_ACCESS_FS_READ | LANDLOCK_ACCESS_FS_WRITE | LANDLOCK_ACCESS_FS_MAKE_DEV;
+    if (abi >= 2) {
+        handled_access |= LANDLOCK_ACCESS_FS_REFER;
+    }
+    if (abi >= 3) {
+        handled_access |= LANDLOCK_ACCESS_FS_TRUNCATE;
+    }
+    /* Only the first field of the attribute structure is relevant, later ABI
+     * versions extend it with other kinds of access rights. */
+    struct landlock_ruleset_attr ruleset_attr = {0};
+    ruleset_attr.handled_access_fs = handled_access;
+    int ruleset_fd SC_CLEANUP(sc_cleanup_close) = -1;
+    ruleset_fd = landlock_create_ruleset(&ruleset_attr, sizeof ruleset_attr.handled_access_fs, 0);
+    if (ruleset_fd < 0) {
+        die("cannot create landlock ruleset");
+    }
+
+    sc_landlock_vars vars = {
+        .snap_name = snap_name,
+        .snap_instance = snap_instance,
+        .uid = getuid(),
+    };
+    struct passwd *pw = getpwuid(vars.uid);
+    if (pw != NULL && pw->pw_dir != NULL && pw->pw_dir[0] == '/') {
+        vars.home = pw->pw_dir;
+    } else {
+        debug("cannot find the home directory of user %u", (unsigned)vars.uid);
+    }
+
+    char *line SC_CLEANUP(sc_cleanup_string) = NULL;
+    size_t line_size = 0;
+    ssize_t line_len;
+    while ((line_len = getline(&line, &line_size, file)) != -1) {
+        if (line_len > 0 && line[line_len - 1] == '\n') {
+            line[line_len - 1] = '\0';
+        }
+        if (line[0] == '\0' || line[0] == '#') {
+            continue;
+        }
+        add_rule(ruleset_fd, handled_access, &vars, line);
+    }
+    if (ferror(file)) {
+        die("cannot read landlock rules %s", rules_path);
+    }
+
+    /* By this point we have dropped to the calling user but still retain
+     * CAP_SYS_ADMIN, which allows enforcing the ruleset without setting
+     * NO_NEW_PRIVS, see sc_apply_seccomp_filter. */
+    if (landlock_restrict_self(ruleset_fd, 0) < 0) {
+        die("cannot apply landlock ruleset %s", rules_path);
+    }
+    debug("applied landlock ruleset %s (ABI version %d)", rules_path, abi);
+}
+
+#else
+
+void sc_apply_landlock_ruleset_for_security_tag(const char *security_tag, const char *snap_name,
+                                                 const char *snap_instance) {
+    debug("snap-confine was built without landlock support, not applying rules for %s", security_tag);
+}
+
+#endif  // HAVE_LANDLOCK
@@ -0,0 +1,47 @@
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+#ifndef SNAP_CONFINE_LANDLOCK_SUPPORT_H
+#define SNAP_CONFINE_LANDLOCK_SUPPORT_H
+
+/**
+ * sc_apply_landlock_ruleset_for_security_tag confines access to the
+ * filesystem of the current process with landlock. The rules are loaded from
+ * "/var/lib/snapd/landlock" using the security tag and the extension ".rules".
+ * The file and the directory containing it must be owned by root and cannot be
+ * writable by UNIX _other_.
+ *
+ * Each rule is a line of the form "<access> <path>" where access is either "r"
+ * or "rw", the latter never allows creating devices. The path may start with
+ * one of the variables $SNAP, $SNAP_DATA, $SNAP_COMMON, $SNAP_USER_DATA,
+ * $SNAP_USER_COMMON, $SNAP_REAL_HOME or $XDG_RUNTIME_DIR. These are expanded
+ * using the snap name, the snap instance name and the home directory of the
+ * calling user from the password database, never from the environment. Paths
+ * that do not exist are skipped.
+ *
+ * Rules are only written by snapd on systems without apparmor. When there are
+ * no rules for the security tag, the kernel does not support landlock or
+ * snap-confine was built without landlock support, no action takes place.
+ *
+ * The ruleset applies to snap-confine itself so this must be called once all
+ * the privileged operations that touch the filesystem are done, but while the
+ * process still retains CAP_SYS_ADMIN.
+ **/
+void sc_apply_landlock_ruleset_for_security_tag(const char *security_tag, const char *snap_name,
+                                                 const char *snap_instance);
+
+#endif
@@ -51,42 +51,23 @@
 
 static void sc_detach_views_of_writable(sc_distro distro, bool normal_mode);
 
-// TODO: simplify this, after all it is just a tmpfs
-// TODO: fold this into bootstrap
-static void setup_private_mount(const char *snap_name)
+// setup_tmp_dir creates, or re-uses, a "tmp" directory with mode 01777 inside
+// a given base directory with mode 0700. The path of the former is stored in
+// tmp_dir.
+static void setup_tmp_dir(const char *base_dir, char *tmp_dir,
+			  size_t tmp_dir_size)
 {
-	// Create a 0700 base directory. This is the "base" directory that is
-	// protected from other users. This directory name is NOT randomly
-	// generated. This has several properties:
-	//
-	// Users can relate to the name and can find the temporary directory as
-	// visible from within the snap. If this directory was random it would be
-	// harder to find because there may be situations in which multiple
-	// directories related to the same snap name would exist.
-	//
-	// Snapd can partially manage the directory. Specifically on snap remove
-	// snapd could remove the directory and everything in it, potentially
-	// avoiding runaway disk use on a machine that either never reboots or uses
-	// persistent /tmp directory.
-	//
-	// Underneath the base directory there is a "tmp" sub-directory that has
-	// mode 1777 and behaves as a typical /tmp directory would. That directory
-	// is used as a bind-mounted /tmp directory.
-	//
 	// Because the directories are reused across invocations by distinct users
 	// and because the directories are trivially guessable, each invocation
 	// unconditionally chowns/chmods them to appropriate values.
-	char base_dir[MAX_BUF] = { 0 };
-	char tmp_dir[MAX_BUF] = { 0 };
 	int base_dir_fd SC_CLEANUP(sc_cleanup_close) = -1;
 	int tmp_dir_fd SC_CLEANUP(sc_cleanup_close) = -1;
-	sc_must_snprintf(base_dir, sizeof(base_dir), "/tmp/snap.%s", snap_name);
-	sc_must_snprintf(tmp_dir, sizeof(tmp_dir), "%s/tmp", base_dir);
+	sc_must_snprintf(tmp_dir, tmp_dir_size, "%s/tmp", base_dir);
 
 	/* Switch to root group so that mkdir and open calls below create filesystem
 	 * elements that are not owned by the user calling into snap-confine. */
 	sc_identity old = sc_set_effective_identity(sc_root_group_identity());
-	// Create /tmp/snap.$SNAP_NAME/ 0700 root.root. Ignore EEXIST since we want
+	// Create the base directory 0700 root.root. Ignore EEXIST since we want
 	// to reuse and we will open with O_NOFOLLOW, below.
 	if (mkdir(base_dir, 0700) < 0 && errno != EEXIST) {
 		die("cannot create base directory %s", base_dir);
@@ -109,7 +90,7 @@ static void setup_private_mount(const char *snap_name)
 	if (fchown(base_dir_fd, 0, 0) < 0) {
 		die("cannot chown base directory %s to root.root", base_dir);
 	}
-	// Create /tmp/snap.$SNAP_NAME/tmp 01777 root.root Ignore EEXIST since we
+	// Create the tmp directory 01777 root.root Ignore EEXIST since we
 	// want to reuse and we will open with O_NOFOLLOW, below.
 	if (mkdirat(base_dir_fd, "tmp", 01777) < 0 && errno != EEXIST) {
 		die("cannot create private tmp directory %s/tmp", base_dir);
@@ -128,6 +109,33 @@ static void setup_private_mount(const char *snap_name)
 		die("cannot chown private tmp directory %s/tmp to root.root",
 		    base_dir);
 	}
+}
+
+// TODO: simplify this, after all it is just a tmpfs
+// TODO: fold this into bootstrap
+static void setup_private_mount(const char *snap_name)
+{
+	// Create a 0700 base directory. This is the "base" directory that is
+	// protected from other users. This directory name is NOT randomly
+	// generated. This has several properties:
+	//
+	// Users can relate to the name and can find the temporary directory as
+	// visible from within the snap. If this directory was random it would be
+	// harder to find because there may be situations in which multiple
+	// directories related to the same snap name would exist.
+	//
+	// Snapd can partially manage the directory. Specifically on snap remove
+	// snapd could remove the directory and everything in it, potentially
+	// avoiding runaway disk use on a machine that either never reboots or uses
+	// persistent /tmp directory.
+	//
+	// Underneath the base directory there is a "tmp" sub-directory that has
+	// mode 1777 and behaves as a typical /tmp directory would. That directory
+	// is used as a bind-mounted /tmp directory.
+	char base_dir[MAX_BUF] = { 0 };
+	char tmp_dir[MAX_BUF] = { 0 };
+	sc_must_snprintf(base_dir, sizeof(base_dir), "/tmp/snap.%s", snap_name);
+	setup_tmp_dir(base_dir, tmp_dir, sizeof tmp_dir);
 	sc_do_mount(tmp_dir, "/tmp", NULL, MS_BIND, NULL);
 	sc_do_mount("none", "/tmp", NULL, MS_PRIVATE, NULL);
 }
@@ -834,3 +842,56 @@ void sc_setup_parallel_instance_classic_mounts(const char *snap_name,
 	sc_must_snprintf(dst, sizeof dst, "/var/snap/%s", snap_name);
 	sc_do_mount(src, dst, "none", MS_BIND | MS_REC, NULL);
 }
+
+void sc_setup_shared_tmp(const sc_invocation * inv)
+{
+	char mode_path[PATH_MAX] = { 0 };
+	sc_must_snprintf(mode_path, sizeof mode_path,
+			 "/var/lib/snapd/mount/%s.tmp", inv->security_tag);
+	FILE *f SC_CLEANUP(sc_cleanup_file) = NULL;
+	f = fopen(mode_path, "re");
+	if (f == NULL) {
+		if (errno == ENOENT) {
+			// The application uses the private /tmp of the snap.
+			return;
+		}
+		die("cannot open %s", mode_path);
+	}
+	char mode[32] = { 0 };
+	if (fgets(mode, sizeof mode, f) == NULL) {
+		die("cannot read %s", mode_path);
+	}
+	mode[strcspn(mode, "\n")] = '\0';
+	if (!inv->is_normal_mode) {
+		// In legacy mode the host /tmp is not reachable once the private
+		// /tmp of the snap is mounted over it.
+		die("cannot use %s /tmp in legacy mode", mode);
+	}
+
+	char tmp_dir[MAX_BUF] = { 0 };
+	if (sc_streq(mode, "host")) {
+		sc_must_snprintf(tmp_dir, sizeof tmp_dir, "%s/tmp",
+				 SC_HOSTFS_DIR);
+	} else if (sc_streq(mode, "shared")) {
+		// The shared /tmp lives next to the private /tmp directories
+		// of all the snaps, see setup_private_mount.
+		char base_dir[MAX_BUF] = { 0 };
+		sc_must_snprintf(base_dir, sizeof base_dir,
+				 "%s/tmp/snap-shared-tmp", SC_HOSTFS_DIR);
+		setup_tmp_dir(base_dir, tmp_dir, sizeof tmp_dir);
+	} else {
+		die("unsupported /tmp mode %s in %s", mode, mode_path);
+	}
+
+	// The mount namespace of the snap is shared by all its applications so
+	// the /tmp of this application is mounted in a new, ephemeral, mount
+	// namespace. Like for user mounts, all mounts are changed to slave mode
+	// so that changes to the per-snap mount namespace are still seen.
+	debug("unsharing the mount namespace (per-app) to use %s /tmp", mode);
+	if (unshare(CLONE_NEWNS) < 0) {
+		die("cannot unshare the mount namespace");
+	}
+	sc_do_mount("none", "/", NULL, MS_REC | MS_SLAVE, NULL);
+	sc_do_mount(tmp_dir, "/tmp", NULL, MS_BIND, NULL);
+	sc_do_mount("none", "/tmp", NULL, MS_PRIVATE, NULL);
+}
@@ -56,6 +56,20 @@ void sc_ensure_shared_snap_mount(void);
 void sc_setup_user_mounts(struct sc_apparmor *apparmor, int snap_update_ns_fd,
 			  const char *snap_name);
 
+/**
+ * Set up the /tmp directory of an application that does not use the private
+ * /tmp of the snap.
+ *
+ * The application may use the /tmp directory of the host or one that is
+ * shared with other snaps, as instructed by snapd via the file
+ * /var/lib/snapd/mount/$SECURITY_TAG.tmp. If the file exists this does the
+ * following:
+ * - create a new mount namespace
+ * - reconfigure all existing mounts to slave mode
+ * - bind mount the requested directory over /tmp
+ */
+void sc_setup_shared_tmp(const sc_invocation * inv);
+
 /**
  * Ensure that SNAP_MOUNT_DIR and /var/snap are mount points.
  *
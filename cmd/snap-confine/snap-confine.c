@@ -45,6 +45,7 @@
 #include "../libsnap-confine-private/tool.h"
 #include "../libsnap-confine-private/utils.h"
 #include "cookie-support.h"
+#include "landlock-support.h"
 #include "mount-support.h"
 #include "ns-support.h"
 #include "seccomp-support.h"
@@ -514,8 +515,13 @@ int main(int argc, char **argv)
 			die("capset regain failed");
 		}
 	}
-	// Now that we've dropped and regained SYS_ADMIN, we can load the
-	// seccomp profiles.
+	// Now that we've dropped and regained SYS_ADMIN, we can apply the
+	// landlock ruleset, if any. This is done before loading the seccomp
+	// profiles as those do not allow the landlock system calls.
+	sc_apply_landlock_ruleset_for_security_tag(invocation.security_tag,
+						   invocation.snap_name,
+						   invocation.snap_instance);
+	// Load the seccomp profiles.
 	if (sc_apply_seccomp_profile_for_security_tag(invocation.security_tag)) {
 		// If the process is not explicitly unconfined then load the
 		// global profile as well.
@@ -713,6 +719,10 @@ static void enter_non_classic_execution_environment(sc_invocation * inv,
 			}
 		}
 	}
+	/* Applications using the host or a shared /tmp get their own mount
+	 * namespace on top of the per-snap or per-user one. */
+	sc_setup_shared_tmp(inv);
+
 	// With cgroups v1, associate each snap process with a dedicated
 	// snap freezer cgroup and snap pids cgroup. All snap processes
 	// belonging to one snap share the freezer cgroup. All snap
@@ -36,6 +36,7 @@ type cmdAlias struct {
 	Positionals struct {
 		SnapApp appName `required:"yes"`
 		Alias   string  `required:"yes"`
+		Args    []string
 	} `positional-args:"true"`
 }
 
@@ -47,6 +48,9 @@ The alias command aliases the given snap application to the given alias.
 
 Once this manual alias is setup the respective application command can be
 invoked just using the alias.
+
+Any further arguments are passed to the application ahead of the ones given
+when invoking the alias. Use -- to separate arguments starting with a dash.
 `)
 
 func init() {
@@ -56,6 +60,8 @@ func init() {
 		{name: "<snap.app>"},
 		// TRANSLATORS: This needs to begin with < and end with >
 		{name: i18n.G("<alias>")},
+		// TRANSLATORS: This needs to begin with < and end with >
+		{name: i18n.G("<argument>")},
 	})
 }
 
@@ -67,7 +73,13 @@ func (x *cmdAlias) Execute(args []string) error {
 	snapName, appName := snap.SplitSnapApp(string(x.Positionals.SnapApp))
 	alias := x.Positionals.Alias
 
-	id, err := x.client.Alias(snapName, appName, alias)
+	var id string
+	var err error
+	if len(x.Positionals.Args) > 0 {
+		id, err = x.client.AliasWithArgs(snapName, appName, alias, x.Positionals.Args)
+	} else {
+		id, err = x.client.Alias(snapName, appName, alias)
+	}
 	if err != nil {
 		return err
 	}
@@ -30,13 +30,16 @@ import (
 
 func (s *SnapSuite) TestAliasHelp(c *C) {
 	msg := `Usage:
-  snap.test alias [alias-OPTIONS] [<snap.app>] [<alias>]
+  snap.test alias [alias-OPTIONS] [<snap.app>] [<alias>] [<argument>...]
 
 The alias command aliases the given snap application to the given alias.
 
 Once this manual alias is setup the respective application command can be
 invoked just using the alias.
 
+Any further arguments are passed to the application ahead of the ones given
+when invoking the alias. Use -- to separate arguments starting with a dash.
+
 [alias command options]
       --no-wait       Do not wait for the operation to finish but just print
                       the change id.
@@ -73,3 +76,34 @@ func (s *SnapSuite) TestAlias(c *C) {
 	)
 	c.Assert(s.Stderr(), Equals, "")
 }
+
+func (s *SnapSuite) TestAliasWithArgs(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/aliases":
+			c.Check(r.Method, Equals, "POST")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action": "alias",
+				"snap":   "alias-snap",
+				"app":    "python3",
+				"alias":  "py",
+				"args":   []interface{}{"-E", "-v"},
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "zzz"}`)
+		case "/v2/changes/zzz":
+			c.Check(r.Method, Equals, "GET")
+			fmt.Fprintln(w, `{"type":"sync", "result":{"ready": true, "status": "Done", "data": {"aliases-added": [{"alias": "py", "snap": "alias-snap", "app": "python3"}]}}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"alias", "alias-snap.python3", "py", "--", "-E", "-v"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Assert(s.Stdout(), Equals, ""+
+		"Added:\n"+
+		"  - alias-snap.python3 as py\n",
+	)
+	c.Assert(s.Stderr(), Equals, "")
+}
@@ -0,0 +1,458 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/jsonutil"
+	"github.com/snapcore/snapd/snap"
+)
+
+var shortExportHelp = i18n.G("Export an installed snap into a bundle")
+var longExportHelp = i18n.G(`
+The export command writes the current revision of an installed snap into a
+single bundle file, together with its assertions, its configuration and its
+manually established connections.
+
+The bundle can be installed on another machine with the import command:
+
+    $ snap export snap-name --output snap-name.bundle
+    $ snap import snap-name.bundle
+
+Snap data is not part of the bundle, use snapshots for that.
+`)
+
+var shortImportHelp = i18n.G("Import a snap bundle")
+var longImportHelp = i18n.G(`
+The import command installs the snap in a bundle written by the export
+command, and then applies the configuration and re-establishes the
+connections recorded in it.
+
+The assertions in the bundle are acknowledged first, so that a snap that was
+installed from the store can be installed without --dangerous. Connections
+that are not allowed by the interface policy on this machine, or whose other
+side is not installed, are reported and skipped.
+`)
+
+type cmdExport struct {
+	clientMixin
+	Output     flags.Filename `long:"output" short:"o"`
+	Positional struct {
+		Snap installedSnapName
+	} `positional-args:"yes" required:"yes"`
+}
+
+type cmdImport struct {
+	clientMixin
+	Positional struct {
+		File flags.Filename
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addCommand("export", shortExportHelp, longExportHelp, func() flags.Commander { return &cmdExport{} },
+		map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"output": i18n.G("Write the bundle to the given file instead of <snap>_<revision>.bundle"),
+		}, []argDesc{{
+			name: "<snap>",
+			// TRANSLATORS: This should not start with a lowercase letter.
+			desc: i18n.G("The snap to export"),
+		}})
+	addCommand("import", shortImportHelp, longImportHelp, func() flags.Commander { return &cmdImport{} },
+		nil, []argDesc{{
+			// TRANSLATORS: This needs to begin with < and end with >
+			name: i18n.G("<bundle>"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			desc: i18n.G("The bundle written by export"),
+		}})
+}
+
+// bundleFormat is the version of the bundle format written by export
+// and understood by import.
+const bundleFormat = 1
+
+// names of the members of a bundle
+const (
+	bundleMetaFile   = "meta.json"
+	bundleConfigFile = "config.json"
+	bundleSnapFile   = "snap.snap"
+	bundleAssertFile = "snap.assert"
+)
+
+type bundleMeta struct {
+	Format      int                `json:"format"`
+	Snap        string             `json:"snap"`
+	Revision    snap.Revision      `json:"revision"`
+	Channel     string             `json:"channel,omitempty"`
+	Classic     bool               `json:"classic,omitempty"`
+	DevMode     bool               `json:"devmode,omitempty"`
+	JailMode    bool               `json:"jailmode,omitempty"`
+	Connections []bundleConnection `json:"connections,omitempty"`
+}
+
+type bundleConnection struct {
+	Plug client.PlugRef `json:"plug"`
+	Slot client.SlotRef `json:"slot"`
+}
+
+func (x *cmdExport) Execute(args []string) (err error) {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	name := string(x.Positional.Snap)
+	info, _, err := x.client.Snap(name)
+	if err != nil {
+		return err
+	}
+	meta := bundleMeta{
+		Format:   bundleFormat,
+		Snap:     name,
+		Revision: info.Revision,
+		Channel:  info.TrackingChannel,
+		Classic:  info.Confinement == client.ClassicConfinement,
+		DevMode:  info.DevMode,
+		JailMode: info.JailMode,
+	}
+
+	conns, err := x.client.Connections(&client.ConnectionOptions{Snap: name})
+	if err != nil {
+		return err
+	}
+	for _, conn := range conns.Established {
+		// automatic connections are re-established by policy on import
+		if conn.Manual {
+			meta.Connections = append(meta.Connections, bundleConnection{Plug: conn.Plug, Slot: conn.Slot})
+		}
+	}
+
+	conf, err := x.client.ExportConf(name)
+	if err != nil {
+		return err
+	}
+
+	snapFile, size, err := x.client.SnapFile(name)
+	if err != nil {
+		return err
+	}
+	defer snapFile.Close()
+	if size < 0 {
+		return fmt.Errorf(i18n.G("cannot export %q: unknown snap file size"), name)
+	}
+
+	filename := string(x.Output)
+	if filename == "" {
+		filename = fmt.Sprintf("%s_%s.bundle", name, info.Revision)
+	}
+	f, err := os.Create(filename + ".part")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer func() {
+		if err != nil {
+			os.Remove(filename + ".part")
+		}
+	}()
+
+	tw := tar.NewWriter(f)
+	for _, member := range []struct {
+		name string
+		v    interface{}
+	}{
+		{bundleMetaFile, &meta},
+		{bundleConfigFile, conf},
+	} {
+		data, err := json.MarshalIndent(member.v, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeBundleMember(tw, member.name, int64(len(data)), bytes.NewReader(data)); err != nil {
+			return err
+		}
+	}
+
+	h := crypto.SHA3_384.New()
+	if err := writeBundleMember(tw, bundleSnapFile, size, io.TeeReader(snapFile, h)); err != nil {
+		return err
+	}
+
+	if !info.Revision.Local() {
+		digest, err := asserts.EncodeDigest(crypto.SHA3_384, h.Sum(nil))
+		if err != nil {
+			return err
+		}
+		as, err := snapAssertions(x.client, digest)
+		if err != nil {
+			return fmt.Errorf(i18n.G("cannot export assertions of %q: %v"), name, err)
+		}
+		var buf bytes.Buffer
+		enc := asserts.NewEncoder(&buf)
+		for _, a := range as {
+			if err := enc.Encode(a); err != nil {
+				return err
+			}
+		}
+		if err := writeBundleMember(tw, bundleAssertFile, int64(buf.Len()), &buf); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(filename+".part", filename); err != nil {
+		return err
+	}
+
+	// TRANSLATORS: the first argument is a snap name, the second a revision, the third a file name
+	fmt.Fprintf(Stdout, i18n.G("Exported %q revision %s into %q\n"), name, info.Revision, filename)
+	return nil
+}
+
+func writeBundleMember(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0600,
+		Size:     size,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	n, err := io.Copy(tw, r)
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf(i18n.G("unexpected size of %s, got: %v but wanted %v"), name, n, size)
+	}
+	return nil
+}
+
+// snapAssertions returns the snap-revision assertion for the snap file
+// with the given digest, and all the assertions it needs, as known to
+// snapd.
+func snapAssertions(cli *client.Client, digest string) ([]asserts.Assertion, error) {
+	var res []asserts.Assertion
+	seen := make(map[string]bool)
+
+	var fetch func(ref *asserts.Ref) error
+	fetch = func(ref *asserts.Ref) error {
+		u := ref.Unique()
+		if seen[u] {
+			return nil
+		}
+		seen[u] = true
+
+		headers := make(map[string]string, len(ref.PrimaryKey))
+		for i, k := range ref.Type.PrimaryKey {
+			headers[k] = ref.PrimaryKey[i]
+		}
+		as, err := cli.Known(ref.Type.Name, headers, nil)
+		if err != nil {
+			return err
+		}
+		if len(as) == 0 {
+			return fmt.Errorf("cannot find %s assertion %v", ref.Type.Name, ref.PrimaryKey)
+		}
+		a := as[0]
+
+		for _, pre := range a.Prerequisites() {
+			if err := fetch(pre); err != nil {
+				return err
+			}
+		}
+		signKey := &asserts.Ref{Type: asserts.AccountKeyType, PrimaryKey: []string{a.SignKeyID()}}
+		if err := fetch(signKey); err != nil {
+			return err
+		}
+		res = append(res, a)
+		return nil
+	}
+
+	if err := fetch(&asserts.Ref{Type: asserts.SnapRevisionType, PrimaryKey: []string{digest}}); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (x *cmdImport) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	dir, err := ioutil.TempDir("", "snap-import-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := extractBundle(string(x.Positional.File), dir); err != nil {
+		return fmt.Errorf(i18n.G("cannot read bundle: %v"), err)
+	}
+
+	var meta bundleMeta
+	if err := readBundleJSON(filepath.Join(dir, bundleMetaFile), &meta); err != nil {
+		return fmt.Errorf(i18n.G("cannot read bundle: %v"), err)
+	}
+	if meta.Format != bundleFormat {
+		return fmt.Errorf(i18n.G("cannot import bundle: unsupported format %d"), meta.Format)
+	}
+	if meta.Snap == "" {
+		return fmt.Errorf(i18n.G("cannot import bundle: no snap name in %q"), x.Positional.File)
+	}
+
+	wmx := waitMixin{clientMixin: x.clientMixin}
+
+	asserted := false
+	assertions, err := ioutil.ReadFile(filepath.Join(dir, bundleAssertFile))
+	switch {
+	case err == nil:
+		if err := x.client.Ack(assertions); err != nil {
+			return fmt.Errorf(i18n.G("cannot add assertions of %q: %v"), meta.Snap, err)
+		}
+		asserted = true
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	opts := &client.SnapOptions{
+		Classic:   meta.Classic,
+		DevMode:   meta.DevMode,
+		JailMode:  meta.JailMode,
+		Dangerous: !asserted,
+	}
+	id, err := x.client.InstallPath(filepath.Join(dir, bundleSnapFile), meta.Snap, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := wmx.wait(id); err != nil {
+		return err
+	}
+
+	// without a channel a snap installed from a file is not refreshed
+	// from the store
+	if asserted && meta.Channel != "" {
+		id, err := x.client.Switch(meta.Snap, &client.SnapOptions{Channel: meta.Channel})
+		if err != nil {
+			return err
+		}
+		if _, err := wmx.wait(id); err != nil {
+			return err
+		}
+	}
+
+	var conf client.ConfExport
+	if err := readBundleJSON(filepath.Join(dir, bundleConfigFile), &conf); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf(i18n.G("cannot read bundle: %v"), err)
+	}
+	if len(conf.Config) > 0 {
+		conf.Snap = meta.Snap
+		id, err := x.client.ImportConf(&conf)
+		if err != nil {
+			return err
+		}
+		if _, err := wmx.wait(id); err != nil {
+			return err
+		}
+	}
+
+	for _, conn := range meta.Connections {
+		plug := conn.Plug.Snap + ":" + conn.Plug.Name
+		slot := conn.Slot.Snap + ":" + conn.Slot.Name
+		id, err := x.client.Connect(conn.Plug.Snap, conn.Plug.Name, conn.Slot.Snap, conn.Slot.Name, nil)
+		if err == nil {
+			_, err = wmx.wait(id)
+		}
+		if err != nil {
+			// TRANSLATORS: the first two arguments are a plug and a slot, e.g. foo:home and core:home
+			fmt.Fprintf(Stderr, i18n.G("WARNING: cannot connect %s to %s: %v\n"), plug, slot, err)
+		}
+	}
+
+	fmt.Fprintf(Stdout, i18n.G("Imported %q\n"), meta.Snap)
+	return nil
+}
+
+// extractBundle writes the known members of the bundle to dir.
+func extractBundle(bundle, dir string) error {
+	f, err := os.Open(bundle)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch hdr.Name {
+		case bundleMetaFile, bundleConfigFile, bundleSnapFile, bundleAssertFile:
+			// ok
+		default:
+			// ignore what a newer export might have added
+			continue
+		}
+		out, err := os.OpenFile(filepath.Join(dir, hdr.Name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, tr)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readBundleJSON(path string, v interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return jsonutil.DecodeWithNumber(f, v)
+}
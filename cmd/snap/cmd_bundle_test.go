@@ -0,0 +1,402 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func readBundle(c *C, path string) map[string][]byte {
+	f, err := os.Open(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	members := make(map[string][]byte)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(tr)
+		c.Assert(err, IsNil)
+		members[hdr.Name] = data
+	}
+	return members
+}
+
+func writeBundle(c *C, path string, members map[string]string) {
+	f, err := os.Create(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	for name, content := range members {
+		c.Assert(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0600, Size: int64(len(content))}), IsNil)
+		_, err := tw.Write([]byte(content))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(tw.Close(), IsNil)
+}
+
+func (s *SnapSuite) mockExportServer(c *C, snapJSON string, assertsDB *asserts.Database) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		switch {
+		case r.URL.Path == "/v2/snaps/foo":
+			fmt.Fprintf(w, `{"type": "sync", "result": %s}`, snapJSON)
+		case r.URL.Path == "/v2/connections":
+			c.Check(r.URL.Query().Get("snap"), Equals, "foo")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"established": [
+{"plug": {"snap": "foo", "plug": "home"}, "slot": {"snap": "core", "slot": "home"}, "interface": "home", "manual": true},
+{"plug": {"snap": "foo", "plug": "network"}, "slot": {"snap": "core", "slot": "network"}, "interface": "network"}
+]}}`)
+		case r.URL.Path == "/v2/snaps/foo/conf":
+			c.Check(r.URL.Query().Get("export"), Equals, "true")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"format": 1, "snap": "foo", "revision": "5", "exported-at": "2021-01-01T00:00:00Z", "config": {"key": "value"}}}`)
+		case r.URL.Path == "/v2/snaps/foo/file":
+			fmt.Fprint(w, "snap-data")
+		case strings.HasPrefix(r.URL.Path, "/v2/assertions/"):
+			c.Assert(assertsDB, NotNil)
+			at := asserts.Type(strings.TrimPrefix(r.URL.Path, "/v2/assertions/"))
+			c.Assert(at, NotNil)
+			headers := make(map[string]string)
+			for k := range r.URL.Query() {
+				headers[k] = r.URL.Query().Get(k)
+			}
+			as, err := assertsDB.FindMany(at, headers)
+			if asserts.IsNotFound(err) {
+				err = nil
+			}
+			c.Assert(err, IsNil)
+			w.Header().Set("X-Ubuntu-Assertions-Count", fmt.Sprint(len(as)))
+			enc := asserts.NewEncoder(w)
+			for _, a := range as {
+				c.Assert(enc.Encode(a), IsNil)
+			}
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	})
+}
+
+func (s *SnapSuite) TestExportLocalRevision(c *C) {
+	s.mockExportServer(c, `{"name": "foo", "revision": "x1", "confinement": "strict", "devmode": true}`, nil)
+
+	out := filepath.Join(c.MkDir(), "foo.bundle")
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"export", "foo", "--output", out})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, fmt.Sprintf("Exported \"foo\" revision x1 into %q\n", out))
+	c.Check(s.Stderr(), Equals, "")
+	c.Check(out+".part", testutil.FileAbsent)
+
+	members := readBundle(c, out)
+	c.Check(members, HasLen, 3)
+	c.Check(string(members["snap.snap"]), Equals, "snap-data")
+
+	var meta map[string]interface{}
+	c.Assert(json.Unmarshal(members["meta.json"], &meta), IsNil)
+	c.Check(meta, DeepEquals, map[string]interface{}{
+		"format":   1.0,
+		"snap":     "foo",
+		"revision": "x1",
+		"devmode":  true,
+		"connections": []interface{}{
+			map[string]interface{}{
+				"plug": map[string]interface{}{"snap": "foo", "plug": "home"},
+				"slot": map[string]interface{}{"snap": "core", "slot": "home"},
+			},
+		},
+	})
+
+	var conf map[string]interface{}
+	c.Assert(json.Unmarshal(members["config.json"], &conf), IsNil)
+	c.Check(conf["config"], DeepEquals, map[string]interface{}{"key": "value"})
+}
+
+func (s *SnapSuite) TestExportWithAssertions(c *C) {
+	storeStack := assertstest.NewStoreStack("can0nical", nil)
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   storeStack.Trusted,
+	})
+	c.Assert(err, IsNil)
+	storeKey := storeStack.StoreAccountKey("")
+	c.Assert(db.Add(storeKey), IsNil)
+	dev := assertstest.NewAccount(storeStack, "developer1", nil, "")
+	c.Assert(db.Add(dev), IsNil)
+
+	now := time.Now().Format(time.RFC3339)
+	decl, err := storeStack.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"series":       "16",
+		"snap-id":      "foo-id",
+		"snap-name":    "foo",
+		"publisher-id": dev.AccountID(),
+		"timestamp":    now,
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(db.Add(decl), IsNil)
+
+	h := crypto.SHA3_384.New()
+	h.Write([]byte("snap-data"))
+	digest, err := asserts.EncodeDigest(crypto.SHA3_384, h.Sum(nil))
+	c.Assert(err, IsNil)
+	rev, err := storeStack.Sign(asserts.SnapRevisionType, map[string]interface{}{
+		"snap-sha3-384": digest,
+		"snap-size":     "9",
+		"snap-id":       "foo-id",
+		"developer-id":  dev.AccountID(),
+		"snap-revision": "5",
+		"timestamp":     now,
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(db.Add(rev), IsNil)
+
+	s.mockExportServer(c, `{"name": "foo", "revision": "5", "tracking-channel": "latest/stable", "confinement": "classic"}`, db)
+
+	out := filepath.Join(c.MkDir(), "foo.bundle")
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"export", "foo", "-o", out})
+	c.Assert(err, IsNil)
+
+	members := readBundle(c, out)
+	c.Check(members, HasLen, 4)
+
+	var meta map[string]interface{}
+	c.Assert(json.Unmarshal(members["meta.json"], &meta), IsNil)
+	c.Check(meta["revision"], Equals, "5")
+	c.Check(meta["channel"], Equals, "latest/stable")
+	c.Check(meta["classic"], Equals, true)
+
+	// the whole chain, prerequisites first
+	var refs []string
+	dec := asserts.NewDecoder(bytes.NewReader(members["snap.assert"]))
+	for {
+		a, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		refs = append(refs, a.Ref().Unique())
+	}
+	c.Check(refs, DeepEquals, []string{
+		storeStack.TrustedKey.Ref().Unique(),
+		storeStack.TrustedAccount.Ref().Unique(),
+		storeKey.Ref().Unique(),
+		dev.Ref().Unique(),
+		decl.Ref().Unique(),
+		rev.Ref().Unique(),
+	})
+}
+
+func (s *SnapSuite) TestExportSnapFileError(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snaps/foo":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"name": "foo", "revision": "1"}}`)
+		case "/v2/connections":
+			fmt.Fprintln(w, `{"type": "sync", "result": {}}`)
+		case "/v2/snaps/foo/conf":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"format": 1, "snap": "foo"}}`)
+		case "/v2/snaps/foo/file":
+			w.WriteHeader(400)
+			fmt.Fprintln(w, `{"type": "error", "result": {"message": "cannot download file for try-mode snap \"foo\""}}`)
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	})
+
+	out := filepath.Join(c.MkDir(), "foo.bundle")
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"export", "foo", "-o", out})
+	c.Assert(err, ErrorMatches, `cannot download file for try-mode snap "foo"`)
+	c.Check(out, testutil.FileAbsent)
+	c.Check(out+".part", testutil.FileAbsent)
+}
+
+const bundleMetaJSON = `{
+  "format": 1,
+  "snap": "foo",
+  "revision": "5",
+  "channel": "latest/stable",
+  "classic": true,
+  "connections": [
+    {"plug": {"snap": "foo", "plug": "home"}, "slot": {"snap": "core", "slot": "home"}},
+    {"plug": {"snap": "foo", "plug": "content"}, "slot": {"snap": "bar", "slot": "content"}}
+  ]
+}`
+
+func (s *SnapSuite) TestImport(c *C) {
+	var seen []string
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/assertions":
+			c.Check(r.Method, Equals, "POST")
+			body, err := ioutil.ReadAll(r.Body)
+			c.Assert(err, IsNil)
+			c.Check(string(body), Equals, "assertion-data")
+			fmt.Fprintln(w, `{"type": "sync", "result": {}}`)
+		case "/v2/snaps":
+			c.Check(r.Method, Equals, "POST")
+			c.Assert(r.ParseMultipartForm(1<<20), IsNil)
+			c.Check(r.MultipartForm.Value["action"], DeepEquals, []string{"install"})
+			c.Check(r.MultipartForm.Value["name"], DeepEquals, []string{"foo"})
+			c.Check(r.MultipartForm.Value["classic"], DeepEquals, []string{"true"})
+			c.Check(r.MultipartForm.Value["dangerous"], HasLen, 0)
+			f, err := r.MultipartForm.File["snap"][0].Open()
+			c.Assert(err, IsNil)
+			data, err := ioutil.ReadAll(f)
+			c.Assert(err, IsNil)
+			c.Check(string(data), Equals, "snap-data")
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type": "async", "status-code": 202, "change": "1"}`)
+		case "/v2/snaps/foo":
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action":  "switch",
+				"channel": "latest/stable",
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type": "async", "status-code": 202, "change": "2"}`)
+		case "/v2/snaps/foo/conf":
+			body := DecodedRequestBody(c, r)
+			c.Check(body["action"], Equals, "import")
+			c.Check(body["export"].(map[string]interface{})["config"], DeepEquals, map[string]interface{}{"key": "value"})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type": "async", "status-code": 202, "change": "3"}`)
+		case "/v2/interfaces":
+			body := DecodedRequestBody(c, r)
+			c.Check(body["action"], Equals, "connect")
+			plugs := body["plugs"].([]interface{})
+			if plugs[0].(map[string]interface{})["plug"] == "content" {
+				w.WriteHeader(400)
+				fmt.Fprintln(w, `{"type": "error", "result": {"message": "snap \"bar\" has no \"content\" slot"}}`)
+				return
+			}
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type": "async", "status-code": 202, "change": "4"}`)
+		case "/v2/changes/1", "/v2/changes/2", "/v2/changes/3", "/v2/changes/4":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done"}}`)
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	})
+
+	bundle := filepath.Join(c.MkDir(), "foo.bundle")
+	writeBundle(c, bundle, map[string]string{
+		"meta.json":   bundleMetaJSON,
+		"config.json": `{"format": 1, "snap": "foo", "revision": "5", "config": {"key": "value"}}`,
+		"snap.snap":   "snap-data",
+		"snap.assert": "assertion-data",
+		"future.json": "{}",
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"import", bundle})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, "Imported \"foo\"\n")
+	c.Check(s.Stderr(), Equals, `WARNING: cannot connect foo:content to bar:content: snap "bar" has no "content" slot`+"\n")
+	c.Check(seen, DeepEquals, []string{
+		"POST /v2/assertions",
+		"POST /v2/snaps",
+		"GET /v2/changes/1",
+		"POST /v2/snaps/foo",
+		"GET /v2/changes/2",
+		"POST /v2/snaps/foo/conf",
+		"GET /v2/changes/3",
+		"POST /v2/interfaces",
+		"GET /v2/changes/4",
+		"POST /v2/interfaces",
+	})
+}
+
+func (s *SnapSuite) TestImportUnasserted(c *C) {
+	var seen []string
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/snaps":
+			c.Assert(r.ParseMultipartForm(1<<20), IsNil)
+			c.Check(r.MultipartForm.Value["dangerous"], DeepEquals, []string{"true"})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type": "async", "status-code": 202, "change": "1"}`)
+		case "/v2/changes/1":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done"}}`)
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	})
+
+	bundle := filepath.Join(c.MkDir(), "foo.bundle")
+	writeBundle(c, bundle, map[string]string{
+		"meta.json":   `{"format": 1, "snap": "foo", "revision": "x1", "channel": "latest/stable"}`,
+		"config.json": `{"format": 1, "snap": "foo", "revision": "x1", "config": {}}`,
+		"snap.snap":   "snap-data",
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"import", bundle})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "Imported \"foo\"\n")
+	// no channel to switch to, no configuration, no connections
+	c.Check(seen, DeepEquals, []string{"POST /v2/snaps", "GET /v2/changes/1"})
+}
+
+func (s *SnapSuite) TestImportErrors(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to %q", r.URL.Path)
+	})
+
+	dir := c.MkDir()
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"import", filepath.Join(dir, "missing.bundle")})
+	c.Check(err, ErrorMatches, "cannot read bundle: open .*/missing.bundle: no such file or directory")
+
+	notTar := filepath.Join(dir, "not-tar.bundle")
+	c.Assert(ioutil.WriteFile(notTar, []byte("not a tar"), 0644), IsNil)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"import", notTar})
+	c.Check(err, ErrorMatches, "cannot read bundle: .*")
+
+	noMeta := filepath.Join(dir, "no-meta.bundle")
+	writeBundle(c, noMeta, map[string]string{"snap.snap": "snap-data"})
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"import", noMeta})
+	c.Check(err, ErrorMatches, "cannot read bundle: open .*/meta.json: no such file or directory")
+
+	future := filepath.Join(dir, "future.bundle")
+	writeBundle(c, future, map[string]string{"meta.json": `{"format": 2, "snap": "foo"}`})
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"import", future})
+	c.Check(err, ErrorMatches, "cannot import bundle: unsupported format 2")
+}
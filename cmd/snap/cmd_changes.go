@@ -43,6 +43,7 @@ change.
 type cmdChanges struct {
 	clientMixin
 	timeMixin
+	formatMixin
 	Positional struct {
 		Snap string `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
@@ -50,15 +51,16 @@ type cmdChanges struct {
 
 type cmdTasks struct {
 	timeMixin
+	formatMixin
 	changeIDMixin
 }
 
 func init() {
 	addCommand("changes", shortChangesHelp, longChangesHelp,
-		func() flags.Commander { return &cmdChanges{} }, timeDescs, nil)
+		func() flags.Commander { return &cmdChanges{} }, timeDescs.also(formatDescs), nil)
 	addCommand("tasks", shortTasksHelp, longTasksHelp,
 		func() flags.Commander { return &cmdTasks{} },
-		changeIDMixinOptDesc.also(timeDescs),
+		changeIDMixinOptDesc.also(timeDescs).also(formatDescs),
 		changeIDMixinArgDesc).alias = "change"
 }
 
@@ -106,13 +108,21 @@ func (c *cmdChanges) Execute(args []string) error {
 		return err
 	}
 
+	sort.Sort(changesByTime(changes))
+
+	if c.machineReadable() {
+		out := make([]changeFormatted, 0, len(changes))
+		for _, chg := range changes {
+			out = append(out, changeFormattedFrom(chg))
+		}
+		return c.writeFormatted(out)
+	}
+
 	if len(changes) == 0 {
 		fmt.Fprintln(Stderr, i18n.G("no changes found"))
 		return nil
 	}
 
-	sort.Sort(changesByTime(changes))
-
 	w := tabWriter()
 
 	fmt.Fprintf(w, i18n.G("ID\tStatus\tSpawn\tReady\tSummary\n"))
@@ -160,6 +170,28 @@ func (c *cmdTasks) showChange(chid string) error {
 		return err
 	}
 
+	if c.machineReadable() {
+		out := changeFormattedFrom(chg)
+		out.Tasks = make([]taskFormatted, 0, len(chg.Tasks))
+		for _, t := range chg.Tasks {
+			out.Tasks = append(out.Tasks, taskFormatted{
+				ID:        t.ID,
+				Kind:      t.Kind,
+				Summary:   t.Summary,
+				Status:    t.Status,
+				SpawnTime: fmtTimeFormatted(t.SpawnTime),
+				ReadyTime: fmtTimeFormatted(t.ReadyTime),
+				Progress: taskProgressFormatted{
+					Label: t.Progress.Label,
+					Done:  t.Progress.Done,
+					Total: t.Progress.Total,
+				},
+				Log: t.Log,
+			})
+		}
+		return c.writeFormatted(out)
+	}
+
 	w := tabWriter()
 
 	fmt.Fprintf(w, i18n.G("Status\tSpawn\tReady\tSummary\n"))
@@ -196,6 +228,50 @@ func (c *cmdTasks) showChange(chid string) error {
 	return nil
 }
 
+// changeFormatted is the machine-readable form of a change.
+type changeFormatted struct {
+	ID        string          `json:"id" yaml:"id"`
+	Kind      string          `json:"kind" yaml:"kind"`
+	Summary   string          `json:"summary" yaml:"summary"`
+	Status    string          `json:"status" yaml:"status"`
+	Ready     bool            `json:"ready" yaml:"ready"`
+	Err       string          `json:"err,omitempty" yaml:"err,omitempty"`
+	SpawnTime string          `json:"spawn-time" yaml:"spawn-time"`
+	ReadyTime string          `json:"ready-time,omitempty" yaml:"ready-time,omitempty"`
+	Tasks     []taskFormatted `json:"tasks,omitempty" yaml:"tasks,omitempty"`
+}
+
+// taskFormatted is the machine-readable form of a task of a change.
+type taskFormatted struct {
+	ID        string                `json:"id" yaml:"id"`
+	Kind      string                `json:"kind" yaml:"kind"`
+	Summary   string                `json:"summary" yaml:"summary"`
+	Status    string                `json:"status" yaml:"status"`
+	SpawnTime string                `json:"spawn-time" yaml:"spawn-time"`
+	ReadyTime string                `json:"ready-time,omitempty" yaml:"ready-time,omitempty"`
+	Progress  taskProgressFormatted `json:"progress" yaml:"progress"`
+	Log       []string              `json:"log,omitempty" yaml:"log,omitempty"`
+}
+
+type taskProgressFormatted struct {
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+	Done  int    `json:"done" yaml:"done"`
+	Total int    `json:"total" yaml:"total"`
+}
+
+func changeFormattedFrom(chg *client.Change) changeFormatted {
+	return changeFormatted{
+		ID:        chg.ID,
+		Kind:      chg.Kind,
+		Summary:   chg.Summary,
+		Status:    chg.Status,
+		Ready:     chg.Ready,
+		Err:       chg.Err,
+		SpawnTime: fmtTimeFormatted(chg.SpawnTime),
+		ReadyTime: fmtTimeFormatted(chg.ReadyTime),
+	}
+}
+
 const line = "......................................................................"
 
 func warnMaintenance(cli *client.Client) error {
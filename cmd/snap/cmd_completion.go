@@ -0,0 +1,260 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortCompletionHelp = i18n.G("Print a shell completion script")
+var longCompletionHelp = i18n.G(`
+The completion command prints a completion script for the given shell to
+standard output. Supported shells are bash, zsh and fish.
+
+The scripts ask snap itself for completions, so installed snap names,
+channels, interface names and so on are completed by querying snapd.
+
+For example, to enable completion for the current fish session:
+
+    $ snap completion fish | source
+`)
+
+type cmdCompletion struct {
+	Positional struct {
+		Shell shellName `required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	addCommand("completion", shortCompletionHelp, longCompletionHelp, func() flags.Commander {
+		return &cmdCompletion{}
+	}, nil, []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<shell>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("The shell to print the completion script for"),
+	}})
+}
+
+var completionScripts = map[string]string{
+	"bash": bashCompletionScript,
+	"zsh":  zshCompletionScript,
+	"fish": fishCompletionScript,
+}
+
+type shellName string
+
+func (s shellName) Complete(match string) []flags.Completion {
+	var ret []flags.Completion
+	for _, name := range supportedShells() {
+		if strings.HasPrefix(name, match) {
+			ret = append(ret, flags.Completion{Item: name})
+		}
+	}
+	return ret
+}
+
+func supportedShells() []string {
+	shells := make([]string, 0, len(completionScripts))
+	for name := range completionScripts {
+		shells = append(shells, name)
+	}
+	sort.Strings(shells)
+	return shells
+}
+
+func (x *cmdCompletion) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	script, ok := completionScripts[string(x.Positional.Shell)]
+	if !ok {
+		return fmt.Errorf(i18n.G("unsupported shell %q (expected one of: %s)"), x.Positional.Shell, strings.Join(supportedShells(), ", "))
+	}
+	fmt.Fprint(Stdout, script)
+	return nil
+}
+
+const bashCompletionScript = `# bash completion for snap
+
+_complete_snap() {
+    local cur prev words cword split
+    _init_completion -s -n : || return
+
+    if [[ ${#words[@]} -le 2 ]]; then
+        # we're completing on the first word
+        COMPREPLY=($(GO_FLAGS_COMPLETION=1 "${words[@]}"))
+        return 0
+    fi
+
+    local command
+    if [[ ${words[1]} =~ ^- ]]; then
+        # global options take no args
+        return 0
+    fi
+
+    for w in "${words[@]:1}"; do
+        if [[ "$w" == "-h" || "$w" == "--help" ]]; then
+            # completing on help gets confusing
+            return 0
+        fi
+    done
+
+    command="${words[1]}"
+
+    # Only split on newlines
+    local IFS=$'\n'
+
+    # now we pass _just the bit that's being completed_ of the command
+    # to snap for it to figure it out, together with the option it is
+    # the value of, if any. go-flags isn't smart enough to look at
+    # COMP_WORDS etc. itself.
+    local -a what=("$cur")
+    if $split; then
+        what=("$prev=$cur")
+    elif [[ "$prev" == -* ]]; then
+        what=("$prev" "$cur")
+    fi
+
+    if [ "$command" = "debug" ] || [ "$command" = "routine" ]; then
+        COMPREPLY=($(GO_FLAGS_COMPLETION=1 snap "$command" "${words[2]}" "${what[@]}"))
+        command="${words[2]}"
+    else
+        COMPREPLY=($(GO_FLAGS_COMPLETION=1 snap "$command" "${what[@]}"))
+    fi
+
+    if $split; then
+        # go-flags completes the whole --option=value word, but bash
+        # only wants the value
+        COMPREPLY=("${COMPREPLY[@]#"$prev="}")
+    fi
+
+    case $command in
+        install|info|sign-build)
+            _filedir "snap"
+            ;;
+        ack)
+            _filedir
+            ;;
+        try)
+            _filedir -d
+            ;;
+        connect|disconnect|interfaces)
+            # interface completions will only end in ':' when they all
+            # end in ':' (i.e. you either get offered snap names up to
+            # and including the ':', or you get offered the whole thing)
+            if [[ "$COMPREPLY" == *: ]]; then
+                compopt -o nospace
+            fi
+    esac
+
+    __ltrim_colon_completions "$cur"
+
+    return 0
+}
+
+complete -F _complete_snap snap
+`
+
+const zshCompletionScript = `#compdef snap
+# zsh completion for snap
+
+_snap() {
+    # group --style options and arguments separately, so that users can
+    # customize the presentation of available completion matches
+    local optexpl argexpl
+    _description options optexpl option
+    _description arguments argexpl argument
+
+    local command
+    if [[ ${#words[@]} -ge 2 ]]; then
+        # keep track of the command for later reference
+        command="${words[2]}"
+    fi
+
+    # get completion options with what we have so far
+    local -a matches
+    matches=(${(f)"$(GO_FLAGS_COMPLETION=1 "${words[@]}")"})
+
+    local match
+    # we don't have a command yet, try to complete one first
+    if [[ "$command" == "" ]]; then
+        for match in $matches; do compadd $optexpl[@] "$match"; done
+        return 0
+    fi
+
+    for match in $matches; do
+        case "$match" in
+            -*) compadd $optexpl[@] -- $match ;;
+            *) compadd $argexpl[@] $match ;;
+        esac
+    done
+
+    # some commands take files/directories too
+    case "$command" in
+        install)
+            # include *.snap files
+            _path_files -g "*.snap"
+            ;;
+        try)
+            # limit matches to directories
+            _path_files -/
+            ;;
+        ack)
+            # there are no rules about assertion file names
+            _files
+            ;;
+    esac
+}
+
+if [[ "$funcstack[1]" == "_snap" ]]; then
+    # autoloaded from fpath
+    _snap "$@"
+else
+    compdef _snap snap
+fi
+`
+
+const fishCompletionScript = `# fish completion for snap
+
+function __snap_complete
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    set -l current (commandline -ct)
+    # in verbose mode go-flags appends "# description" to matches, turn
+    # that into what fish expects
+    GO_FLAGS_COMPLETION=verbose snap $tokens $current 2>/dev/null | string replace -r '\s+# ' '\t'
+end
+
+function __snap_wants_files
+    set -l tokens (commandline -opc)
+    contains -- "$tokens[2]" install try ack sign-build
+end
+
+complete -c snap -f -a '(__snap_complete)'
+complete -c snap -n __snap_wants_files -F
+`
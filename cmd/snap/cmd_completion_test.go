@@ -0,0 +1,92 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jessevdk/go-flags"
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestCompletionBash(c *C) {
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"completion", "bash"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Matches, `(?s)# bash completion for snap\n.*GO_FLAGS_COMPLETION=1 snap .*\ncomplete -F _complete_snap snap\n`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestCompletionZsh(c *C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"completion", "zsh"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Matches, `(?s)#compdef snap\n.*GO_FLAGS_COMPLETION=1 .*compdef _snap snap\nfi\n`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestCompletionFish(c *C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"completion", "fish"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Matches, `(?s)# fish completion for snap\n.*GO_FLAGS_COMPLETION=verbose snap .*complete -c snap -f -a '\(__snap_complete\)'\n.*`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestCompletionUnsupportedShell(c *C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"completion", "tcsh"})
+	c.Assert(err, ErrorMatches, `unsupported shell "tcsh" \(expected one of: bash, fish, zsh\)`)
+	c.Check(s.Stdout(), Equals, "")
+}
+
+func (s *SnapSuite) TestCompletionExtraArgs(c *C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"completion", "bash", "extra"})
+	c.Assert(err, Equals, snap.ErrExtraArgs)
+}
+
+func (s *SnapSuite) TestChannelNameComplete(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/snaps")
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+{"name": "foo", "tracking-channel": "latest/edge"},
+{"name": "bar", "tracking-channel": "2.0/stable"},
+{"name": "baz", "tracking-channel": "latest/edge"},
+{"name": "quux"}
+]}`)
+		n++
+	})
+
+	c.Check(snap.ChannelName("").Complete(""), DeepEquals, []flags.Completion{
+		{Item: "stable"},
+		{Item: "candidate"},
+		{Item: "beta"},
+		{Item: "edge"},
+		{Item: "latest/edge", Description: "tracked by foo"},
+		{Item: "2.0/stable", Description: "tracked by bar"},
+	})
+	c.Check(snap.ChannelName("").Complete("l"), DeepEquals, []flags.Completion{
+		{Item: "latest/edge", Description: "tracked by foo"},
+	})
+	c.Check(snap.ChannelName("").Complete("x"), HasLen, 0)
+	c.Check(n, Equals, 3)
+}
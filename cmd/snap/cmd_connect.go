@@ -20,13 +20,19 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/jsonutil"
 )
 
 type cmdConnect struct {
 	waitMixin
+	Attrs       []string `long:"attr"`
 	Positionals struct {
 		PlugSpec connectPlugSpec `required:"yes"`
 		SlotSpec connectSlotSpec
@@ -52,12 +58,19 @@ $ snap connect <snap>:<plug>
 
 Connects the provided plug to the slot in the core snap with a name matching
 the plug name.
+
+The --attr option overrides an attribute of the plug for this connection
+only, e.g. --attr=read-only=true. Only some interfaces support overriding
+attributes, and only in ways that narrow down the granted permissions.
 `)
 
 func init() {
 	addCommand("connect", shortConnectHelp, longConnectHelp, func() flags.Commander {
 		return &cmdConnect{}
-	}, waitDescs, []argDesc{
+	}, waitDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"attr": i18n.G("Override a plug attribute (key=value)"),
+	}), []argDesc{
 		// TRANSLATORS: This needs to begin with < and end with >
 		{name: i18n.G("<snap>:<plug>")},
 		// TRANSLATORS: This needs to begin with < and end with >
@@ -77,7 +90,16 @@ func (x *cmdConnect) Execute(args []string) error {
 		x.Positionals.PlugSpec.Snap = ""
 	}
 
-	id, err := x.client.Connect(x.Positionals.PlugSpec.Snap, x.Positionals.PlugSpec.Name, x.Positionals.SlotSpec.Snap, x.Positionals.SlotSpec.Name)
+	var opts *client.ConnectOptions
+	if len(x.Attrs) > 0 {
+		attrs, err := parseConnectAttrs(x.Attrs)
+		if err != nil {
+			return err
+		}
+		opts = &client.ConnectOptions{PlugAttrs: attrs}
+	}
+
+	id, err := x.client.Connect(x.Positionals.PlugSpec.Snap, x.Positionals.PlugSpec.Name, x.Positionals.SlotSpec.Snap, x.Positionals.SlotSpec.Name, opts)
 	if err != nil {
 		return err
 	}
@@ -91,3 +113,21 @@ func (x *cmdConnect) Execute(args []string) error {
 
 	return nil
 }
+
+// parseConnectAttrs parses key=value attribute overrides, values are decoded
+// as JSON when possible and used as strings otherwise.
+func parseConnectAttrs(attrs []string) (map[string]interface{}, error) {
+	parsed := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		parts := strings.SplitN(attr, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf(i18n.G("invalid attribute: %q (want key=value)"), attr)
+		}
+		var value interface{}
+		if err := jsonutil.DecodeWithNumber(strings.NewReader(parts[1]), &value); err != nil {
+			value = parts[1]
+		}
+		parsed[parts[0]] = value
+	}
+	return parsed, nil
+}
@@ -32,7 +32,10 @@ import (
 
 type cmdConnections struct {
 	clientMixin
+	timeMixin
+	formatMixin
 	All         bool `long:"all"`
+	History     bool `long:"history"`
 	Positionals struct {
 		Snap installedSnapName
 	} `positional-args:"true"`
@@ -51,14 +54,22 @@ $ snap connections <snap>
 
 Lists connected and unconnected plugs and slots for the specified
 snap.
+
+$ snap connections --history [<snap>]
+
+Lists past connect and disconnect operations, optionally only those
+involving the specified snap, together with what caused them.
 `)
 
 func init() {
 	addCommand("connections", shortConnectionsHelp, longConnectionsHelp, func() flags.Commander {
 		return &cmdConnections{}
-	}, map[string]string{
+	}, timeDescs.also(formatDescs).also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
 		"all": i18n.G("Show connected and unconnected plugs and slots"),
-	}, []argDesc{{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"history": i18n.G("Show past connect and disconnect operations"),
+	}), []argDesc{{
 		// TRANSLATORS: This needs to be wrapped in <>s.
 		name: "<snap>",
 		// TRANSLATORS: This should not start with a lowercase letter.
@@ -100,6 +111,44 @@ func (cn connection) String() string {
 	return strings.Join(opts, ",")
 }
 
+// connectionFormatted is the machine-readable form of a connection.
+type connectionFormatted struct {
+	Interface string `json:"interface" yaml:"interface"`
+	Plug      string `json:"plug,omitempty" yaml:"plug,omitempty"`
+	Slot      string `json:"slot,omitempty" yaml:"slot,omitempty"`
+	Manual    bool   `json:"manual,omitempty" yaml:"manual,omitempty"`
+	Gadget    bool   `json:"gadget,omitempty" yaml:"gadget,omitempty"`
+}
+
+func (cn connection) formatted() connectionFormatted {
+	out := connectionFormatted{
+		Interface: cn.interfaceName,
+		Manual:    cn.manual,
+		Gadget:    cn.gadget,
+	}
+	// unconnected plugs and slots are marked with "-"
+	if cn.plug != "-" {
+		out.Plug = cn.plug
+	}
+	if cn.slot != "-" {
+		out.Slot = cn.slot
+	}
+	return out
+}
+
+// connectionEventFormatted is the machine-readable form of a past
+// connect or disconnect operation.
+type connectionEventFormatted struct {
+	Time          string `json:"time" yaml:"time"`
+	Action        string `json:"action" yaml:"action"`
+	Interface     string `json:"interface,omitempty" yaml:"interface,omitempty"`
+	Plug          string `json:"plug" yaml:"plug"`
+	Slot          string `json:"slot" yaml:"slot"`
+	By            string `json:"by" yaml:"by"`
+	RequestedBy   string `json:"requested-by,omitempty" yaml:"requested-by,omitempty"`
+	Justification string `json:"justification,omitempty" yaml:"justification,omitempty"`
+}
+
 type byConnectionData []connection
 
 func (b byConnectionData) Len() int      { return len(b) }
@@ -131,11 +180,65 @@ func interfaceDeterminant(conn *client.Connection) string {
 	return fmt.Sprintf("[%v]", value)
 }
 
+func (x *cmdConnections) showHistory() error {
+	if x.All {
+		return fmt.Errorf(i18n.G("cannot use --all with --history"))
+	}
+
+	history, err := x.client.ConnectionHistory(string(x.Positionals.Snap))
+	if err != nil {
+		return err
+	}
+	if x.machineReadable() {
+		out := make([]connectionEventFormatted, 0, len(history))
+		for _, ev := range history {
+			out = append(out, connectionEventFormatted{
+				Time:          fmtTimeFormatted(ev.Time),
+				Action:        ev.Action,
+				Interface:     ev.Interface,
+				Plug:          endpoint(ev.Plug.Snap, ev.Plug.Name),
+				Slot:          endpoint(ev.Slot.Snap, ev.Slot.Name),
+				By:            ev.By,
+				RequestedBy:   ev.RequestedBy,
+				Justification: ev.Justification,
+			})
+		}
+		return x.writeFormatted(out)
+	}
+	if len(history) == 0 {
+		return nil
+	}
+
+	w := tabWriter()
+	fmt.Fprintln(w, i18n.G("Time\tAction\tInterface\tPlug\tSlot\tBy\tJustification"))
+	for _, ev := range history {
+		by := ev.By
+		if ev.RequestedBy != "" {
+			by = fmt.Sprintf("%s (%s)", ev.By, ev.RequestedBy)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", x.fmtTime(ev.Time), ev.Action, orDash(ev.Interface),
+			endpoint(ev.Plug.Snap, ev.Plug.Name), endpoint(ev.Slot.Snap, ev.Slot.Name), by, orDash(ev.Justification))
+	}
+	w.Flush()
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
 func (x *cmdConnections) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
 
+	if x.History {
+		return x.showHistory()
+	}
+
 	opts := client.ConnectionOptions{
 		All: x.All,
 	}
@@ -159,6 +262,9 @@ func (x *cmdConnections) Execute(args []string) error {
 		return err
 	}
 	if len(connections.Plugs) == 0 && len(connections.Slots) == 0 {
+		if x.machineReadable() {
+			return x.writeFormatted([]connectionFormatted{})
+		}
 		return nil
 	}
 
@@ -203,6 +309,14 @@ func (x *cmdConnections) Execute(args []string) error {
 
 	sort.Sort(byConnectionData(annotatedConns))
 
+	if x.machineReadable() {
+		out := make([]connectionFormatted, 0, len(annotatedConns))
+		for _, conn := range annotatedConns {
+			out = append(out, conn.formatted())
+		}
+		return x.writeFormatted(out)
+	}
+
 	for _, note := range annotatedConns {
 		fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\n", note.interfaceName, note.interfaceDeterminant, note.plug, note.slot, note)
 	}
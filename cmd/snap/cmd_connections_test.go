@@ -134,6 +134,69 @@ func (s *SnapSuite) TestConnectionsNoneConnectedPlugs(c *C) {
 	c.Assert(s.Stderr(), Equals, "")
 }
 
+func (s *SnapSuite) TestConnectionsFormatJSON(c *C) {
+	result := client.Connections{
+		Established: []client.Connection{
+			{
+				Plug:      client.PlugRef{Snap: "keyboard-lights", Name: "capslock"},
+				Slot:      client.SlotRef{Snap: "leds-provider", Name: "capslock-led"},
+				Interface: "leds",
+				Manual:    true,
+			},
+		},
+		Plugs: []client.Plug{
+			{
+				Snap:      "keyboard-lights",
+				Name:      "capslock",
+				Interface: "leds",
+				Connections: []client.SlotRef{
+					{Snap: "leds-provider", Name: "capslock-led"},
+				},
+			}, {
+				Snap:      "keyboard-lights",
+				Name:      "numlock",
+				Interface: "leds",
+			},
+		},
+		Slots: []client.Slot{
+			{
+				Snap:      "leds-provider",
+				Name:      "capslock-led",
+				Interface: "leds",
+				Connections: []client.PlugRef{
+					{Snap: "keyboard-lights", Name: "capslock"},
+				},
+			},
+		},
+	}
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/connections")
+		EncodeResponseBody(c, w, map[string]interface{}{
+			"type":   "sync",
+			"result": result,
+		})
+	})
+
+	rest, err := Parser(Client()).ParseArgs([]string{"connections", "--all", "--format=json"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Assert(s.Stdout(), Equals, `[
+  {
+    "interface": "leds",
+    "plug": "keyboard-lights:capslock",
+    "slot": "leds-provider:capslock-led",
+    "manual": true
+  },
+  {
+    "interface": "leds",
+    "plug": "keyboard-lights:numlock"
+  }
+]
+`)
+	c.Assert(s.Stderr(), Equals, "")
+}
+
 func (s *SnapSuite) TestConnectionsNoneConnectedSlots(c *C) {
 	result := client.Connections{}
 	query := url.Values{}
@@ -851,3 +914,41 @@ func (s *SnapSuite) TestConnectionsDefiningAttribute(c *C) {
 	c.Assert(s.Stdout(), Equals, expectedStdout)
 	c.Assert(s.Stderr(), Equals, "")
 }
+
+func (s *SnapSuite) TestConnectionsHistory(c *C) {
+	query := url.Values{}
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/interfaces/history")
+		c.Check(r.URL.Query(), DeepEquals, query)
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+			{"time": "2021-03-01T10:00:00Z", "action": "connect", "interface": "home",
+			 "plug": {"snap": "foo", "plug": "home"}, "slot": {"snap": "core", "slot": "home"},
+			 "by": "auto-connect", "justification": "slot rule of base-declaration"},
+			{"time": "2021-03-01T11:00:00Z", "action": "disconnect", "interface": "home",
+			 "plug": {"snap": "foo", "plug": "home"}, "slot": {"snap": "core", "slot": "home"},
+			 "by": "user", "requested-by": "uid=1000"}
+		]}`)
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"connections", "--history", "--abs-time"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	expectedStdout := "" +
+		"Time                  Action      Interface  Plug      Slot   By               Justification\n" +
+		"2021-03-01T10:00:00Z  connect     home       foo:home  :home  auto-connect     slot rule of base-declaration\n" +
+		"2021-03-01T11:00:00Z  disconnect  home       foo:home  :home  user (uid=1000)  -\n"
+	c.Assert(s.Stdout(), Equals, expectedStdout)
+	c.Assert(s.Stderr(), Equals, "")
+
+	s.ResetStdStreams()
+
+	query = url.Values{"snap": []string{"foo"}}
+	_, err = Parser(Client()).ParseArgs([]string{"connections", "--history", "--abs-time", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(s.Stdout(), Equals, expectedStdout)
+}
+
+func (s *SnapSuite) TestConnectionsHistoryWithAll(c *C) {
+	_, err := Parser(Client()).ParseArgs([]string{"connections", "--history", "--all"})
+	c.Assert(err, ErrorMatches, "cannot use --all with --history")
+}
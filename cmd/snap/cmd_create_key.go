@@ -40,6 +40,10 @@ func init() {
 		i18n.G(`
 The create-key command creates a cryptographic key pair that can be
 used for signing assertions.
+
+A 4096-bit RSA key is created in the GnuPG keyring. ed25519 keys cannot
+be kept there, they need to be created and held by an external keypair
+manager, selected with SNAPD_EXT_KEYMGR.
 `),
 		func() flags.Commander {
 			return &cmdCreateKey{}
@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdAPIAudit struct {
+	clientMixin
+	timeMixin
+}
+
+func init() {
+	addDebugCommand("api-audit",
+		i18n.G("Show the audit log of mutating API requests"),
+		i18n.G(`
+The api-audit command shows the mutating requests sent to the snapd API,
+oldest first, with the uid of the sender, the snap if they were sent through
+snapctl, the status of the response and the change started by the request.
+`),
+		func() flags.Commander {
+			return &cmdAPIAudit{}
+		}, timeDescs, nil)
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func (x *cmdAPIAudit) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	entries, err := x.client.APIAudit()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No API requests were recorded."))
+		return nil
+	}
+
+	w := tabWriter()
+	fmt.Fprintln(w, i18n.G("Time\tUID\tSnap\tRequest\tStatus\tChange\tSummary"))
+	for _, e := range entries {
+		summary := e.Summary
+		if e.Error != "" {
+			summary = e.Error
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s %s\t%d\t%s\t%s\n", x.fmtTime(e.Time), e.UID, dashIfEmpty(e.Snap), e.Method, e.Path, e.Status, dashIfEmpty(e.Change), dashIfEmpty(summary))
+	}
+	w.Flush()
+	return nil
+}
@@ -0,0 +1,66 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestDebugAPIAudit(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(n, check.Equals, 1)
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/debug/api-audit")
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+			{"time": "2021-06-01T10:00:00Z", "uid": 0, "method": "POST", "path": "/v2/snaps/foo", "status": 202, "change": "1", "summary": "Install \"foo\" snap"},
+			{"time": "2021-06-01T10:01:00Z", "uid": 1000, "method": "POST", "path": "/v2/snaps/foo", "status": 401},
+			{"time": "2021-06-01T10:02:00Z", "uid": 0, "snap": "bar", "method": "POST", "path": "/v2/snapctl", "status": 400, "error": "nope"}
+		]}`)
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "api-audit", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stdout(), check.Equals, `
+Time                  UID   Snap  Request             Status  Change  Summary
+2021-06-01T10:00:00Z  0     -     POST /v2/snaps/foo  202     1       Install "foo" snap
+2021-06-01T10:01:00Z  1000  -     POST /v2/snaps/foo  401     -       -
+2021-06-01T10:02:00Z  0     bar   POST /v2/snapctl    400     -       nope
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestDebugAPIAuditEmpty(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "api-audit"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "No API requests were recorded.\n")
+}
@@ -0,0 +1,350 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/snap/configschema"
+	"github.com/snapcore/snapd/snap/snapfile"
+)
+
+var shortDiffConfigHelp = i18n.G("Show configuration changes of a refresh")
+var longDiffConfigHelp = i18n.G(`
+The diff-config command compares the configuration schema of the installed
+revision of a snap with the one of its refresh candidate, and lists the
+options that are new, were removed, or whose default changed, along with
+their current value. Current values the candidate would not accept are
+reported too.
+
+Defaults set by the gadget take precedence over the ones in the schema.
+
+By default the refresh candidate is downloaded from the channel the snap
+is tracking; use --channel to pick another channel, or give the path of
+a snap file to compare with instead.
+`)
+
+type cmdDiffConfig struct {
+	clientMixin
+	Channel    channelName `long:"channel"`
+	Positional struct {
+		Snap installedSnapName `required:"yes"`
+		File flags.Filename
+	} `positional-args:"yes"`
+}
+
+func init() {
+	addCommand("diff-config", shortDiffConfigHelp, longDiffConfigHelp, func() flags.Commander { return &cmdDiffConfig{} },
+		map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"channel": i18n.G("Compare with the revision in this channel instead of the tracked one"),
+		}, []argDesc{{
+			name: "<snap>",
+			// TRANSLATORS: This should not start with a lowercase letter.
+			desc: i18n.G("The snap whose configuration to compare"),
+		}, {
+			// TRANSLATORS: This needs to begin with < and end with >
+			name: i18n.G("<snap file>"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			desc: i18n.G("The snap file to compare with, instead of the refresh candidate"),
+		}})
+}
+
+func (x *cmdDiffConfig) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	if x.Positional.File != "" && x.Channel != "" {
+		return fmt.Errorf(i18n.G("cannot use --channel with a snap file"))
+	}
+
+	name := string(x.Positional.Snap)
+	info, _, err := x.client.Snap(name)
+	if err != nil {
+		return err
+	}
+
+	installed, err := x.client.ConfigSchema(name)
+	if err != nil {
+		return err
+	}
+
+	var candidate *configschema.Schema
+	if x.Positional.File != "" {
+		candidate, err = snapFileConfigSchema(string(x.Positional.File))
+	} else {
+		channel := string(x.Channel)
+		if channel == "" {
+			channel = info.TrackingChannel
+		}
+		candidate, err = x.candidateConfigSchema(name, channel)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := x.client.Conf(name, nil)
+	if err != nil {
+		return err
+	}
+
+	defaults, err := x.gadgetDefaults(info.ID)
+	if err != nil {
+		return err
+	}
+
+	diffs, invalidConfig := diffConfigSchemas(installed, candidate, defaults, current)
+	if len(diffs) == 0 && invalidConfig == nil {
+		fmt.Fprintf(Stdout, i18n.G("No configuration changes for %q.\n"), name)
+		return nil
+	}
+
+	if len(diffs) > 0 {
+		w := tabWriter()
+		fmt.Fprintln(w, i18n.G("Key\tCurrent\tInstalled default\tCandidate default\tNotes"))
+		for _, d := range diffs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.key, fmtConfigValue(d.current), fmtConfigValue(d.installedDefault), fmtConfigValue(d.candidateDefault), strings.Join(d.notes, "; "))
+		}
+		w.Flush()
+	}
+	if invalidConfig != nil {
+		fmt.Fprintf(Stderr, i18n.G("WARNING: the current configuration is not accepted by the candidate: %v\n"), invalidConfig)
+	}
+	return nil
+}
+
+// candidateConfigSchema downloads the snap from the given channel and
+// returns its configuration schema.
+func (x *cmdDiffConfig) candidateConfigSchema(name, channel string) (*configschema.Schema, error) {
+	_, r, err := x.client.Download(name, &client.DownloadOptions{SnapOptions: client.SnapOptions{Channel: channel}})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	dir, err := ioutil.TempDir("", "snap-diff-config-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, name+".snap")
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot download %q: %v"), name, err)
+	}
+
+	return snapFileConfigSchema(path)
+}
+
+func snapFileConfigSchema(path string) (*configschema.Schema, error) {
+	snapf, err := snapfile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := snapf.ReadFile(configschema.Filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return configschema.Parse(data)
+}
+
+// gadgetDefaults returns the defaults the gadget sets for the snap with
+// the given id, if any.
+func (x *cmdDiffConfig) gadgetDefaults(snapID string) (map[string]interface{}, error) {
+	if snapID == "" {
+		// gadget defaults are keyed by snap-id
+		return nil, nil
+	}
+	snaps, err := x.client.List(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, sn := range snaps {
+		if sn.Type != "gadget" {
+			continue
+		}
+		gi, err := gadget.ReadInfo(filepath.Join(dirs.SnapMountDir, sn.Name, "current"), nil)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.G("cannot read gadget defaults: %v"), err)
+		}
+		return gi.Defaults[snapID], nil
+	}
+	return nil, nil
+}
+
+type configDiff struct {
+	key              string
+	current          interface{}
+	installedDefault interface{}
+	candidateDefault interface{}
+	notes            []string
+}
+
+// diffConfigSchemas returns the options whose description changed between
+// the installed and candidate schemas, or whose current value the
+// candidate schema does not accept. Problems with the current
+// configuration as a whole are returned separately.
+func diffConfigSchemas(installed, candidate *configschema.Schema, defaults, current map[string]interface{}) (diffs []*configDiff, invalidConfig error) {
+	installedOpts := make(map[string]*configschema.Schema)
+	schemaOptions(installed, "", installedOpts)
+	candidateOpts := make(map[string]*configschema.Schema)
+	schemaOptions(candidate, "", candidateOpts)
+
+	keys := make(map[string]bool, len(installedOpts)+len(candidateOpts))
+	for key := range installedOpts {
+		keys[key] = true
+	}
+	for key := range candidateOpts {
+		keys[key] = true
+	}
+
+	var invalidKey, invalid string
+	if candidate != nil {
+		err := candidate.Validate(current)
+		if verr, ok := err.(*configschema.ValidationError); ok && verr.Path != "" {
+			invalidKey, invalid = verr.Path, verr.Message
+			keys[invalidKey] = true
+		} else {
+			invalidConfig = err
+		}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		d := &configDiff{key: key}
+		d.current, _ = lookupConfigKey(current, key)
+
+		installedOpt, inInstalled := installedOpts[key]
+		candidateOpt, inCandidate := candidateOpts[key]
+		if inInstalled {
+			d.installedDefault = installedOpt.Default
+		}
+		if inCandidate {
+			d.candidateDefault = candidateOpt.Default
+		}
+		gadgetDefault, hasGadgetDefault := lookupConfigKey(defaults, key)
+
+		switch {
+		case key == invalidKey && !inInstalled && !inCandidate:
+			// a current option the candidate does not know about
+		case !inInstalled:
+			d.notes = append(d.notes, i18n.G("new option"))
+		case !inCandidate:
+			d.notes = append(d.notes, i18n.G("option removed"))
+		case hasGadgetDefault:
+			// takes precedence over what the schemas say
+		case !reflect.DeepEqual(d.installedDefault, d.candidateDefault):
+			d.notes = append(d.notes, i18n.G("default changed"))
+		}
+		if hasGadgetDefault && (inInstalled || inCandidate) {
+			d.installedDefault = gadgetDefault
+			d.candidateDefault = gadgetDefault
+			if len(d.notes) > 0 {
+				d.notes = append(d.notes, i18n.G("default set by the gadget"))
+			}
+		}
+		if key == invalidKey {
+			d.notes = append(d.notes, fmt.Sprintf(i18n.G("current value not accepted: %s"), invalid))
+		}
+
+		if len(d.notes) > 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs, invalidConfig
+}
+
+// schemaOptions collects the options described by the schema by their
+// dotted key.
+func schemaOptions(s *configschema.Schema, prefix string, opts map[string]*configschema.Schema) {
+	if s == nil {
+		return
+	}
+	for name, prop := range s.Properties {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		if len(prop.Properties) > 0 {
+			schemaOptions(prop, key, opts)
+			continue
+		}
+		opts[key] = prop
+	}
+}
+
+func lookupConfigKey(config map[string]interface{}, key string) (interface{}, bool) {
+	if key == "" {
+		return nil, false
+	}
+	var v interface{} = config
+	for _, sub := range strings.Split(key, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		v, ok = m[sub]
+		if !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func fmtConfigValue(v interface{}) string {
+	if v == nil {
+		return "-"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,177 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+const installedConfigSchema = `{
+  "type": "object",
+  "properties": {
+    "port": {"type": "integer", "default": 8080},
+    "mode": {"type": "string", "default": "fast"},
+    "old": {"type": "boolean"},
+    "server": {
+      "type": "object",
+      "properties": {
+        "host": {"type": "string", "default": "localhost"}
+      }
+    }
+  }
+}`
+
+const candidateConfigSchema = `{
+  "type": "object",
+  "properties": {
+    "port": {"type": "integer", "default": 9090},
+    "mode": {"type": "string", "default": "fast", "enum": ["fast", "normal"]},
+    "debug": {"type": "boolean", "default": false},
+    "server": {
+      "type": "object",
+      "properties": {
+        "host": {"type": "string", "default": "localhost"}
+      }
+    }
+  }
+}`
+
+func (s *SnapSuite) mockDiffConfigServer(c *C, schema string) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		switch r.URL.Path {
+		case "/v2/snaps/foo":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"name": "foo", "id": "foosnapididididididididididididi", "revision": "1", "tracking-channel": "latest/stable"}}`)
+		case "/v2/snaps/foo/config-schema":
+			fmt.Fprintf(w, `{"type": "sync", "result": %s}`, schema)
+		case "/v2/snaps/foo/conf":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"port": 8080, "mode": "slow"}}`)
+		case "/v2/snaps":
+			fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "pc", "type": "gadget"}, {"name": "foo", "type": "app"}]}`)
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	})
+}
+
+// mockCandidateSnap returns an unpacked snap directory with the given
+// configuration schema, if any.
+func mockCandidateSnap(c *C, schema string) string {
+	snapDir := c.MkDir()
+	files := [][]string{{"meta/snap.yaml", "name: foo\nversion: 2.0\n"}}
+	if schema != "" {
+		files = append(files, []string{"meta/config-schema.json", schema})
+	}
+	snaptest.PopulateDir(snapDir, files)
+	return snapDir
+}
+
+func mockGadgetDefaults(c *C) {
+	gadgetYaml := filepath.Join(dirs.SnapMountDir, "pc", "current", "meta", "gadget.yaml")
+	c.Assert(os.MkdirAll(filepath.Dir(gadgetYaml), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(gadgetYaml, []byte(`defaults:
+  foosnapididididididididididididi:
+    debug: true
+`), 0644), IsNil)
+}
+
+func (s *SnapSuite) TestDiffConfig(c *C) {
+	s.mockDiffConfigServer(c, installedConfigSchema)
+	mockGadgetDefaults(c)
+	candidate := mockCandidateSnap(c, candidateConfigSchema)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"diff-config", "foo", candidate})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, `Key    Current  Installed default  Candidate default  Notes
+debug  -        true               true               new option; default set by the gadget
+mode   "slow"   "fast"             "fast"             current value not accepted: must be one of "fast", "normal"
+old    -        -                  -                  option removed
+port   8080     8080               9090               default changed
+`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDiffConfigNoChanges(c *C) {
+	s.mockDiffConfigServer(c, installedConfigSchema)
+	candidate := mockCandidateSnap(c, installedConfigSchema)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"diff-config", "foo", candidate})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "No configuration changes for \"foo\".\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDiffConfigNoSchemas(c *C) {
+	s.mockDiffConfigServer(c, "null")
+	candidate := mockCandidateSnap(c, "")
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"diff-config", "foo", candidate})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "No configuration changes for \"foo\".\n")
+}
+
+func (s *SnapSuite) TestDiffConfigInvalidAsAWhole(c *C) {
+	s.mockDiffConfigServer(c, "null")
+	candidate := mockCandidateSnap(c, `{"type": "object", "required": ["name"]}`)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"diff-config", "foo", candidate})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, `WARNING: the current configuration is not accepted by the candidate: invalid configuration: missing required option "name"`+"\n")
+}
+
+func (s *SnapSuite) TestDiffConfigDownloadsCandidate(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snaps/foo":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"name": "foo", "id": "foosnapididididididididididididi", "revision": "1", "tracking-channel": "latest/stable"}}`)
+		case "/v2/snaps/foo/config-schema":
+			fmt.Fprintln(w, `{"type": "sync", "result": null}`)
+		case "/v2/download":
+			c.Check(r.Method, Equals, "POST")
+			body := DecodedRequestBody(c, r)
+			c.Check(body["snap-name"], Equals, "foo")
+			c.Check(body["channel"], Equals, "latest/stable")
+			w.WriteHeader(404)
+			fmt.Fprintln(w, `{"type": "error", "result": {"message": "no snap revision on specified channel", "kind": "snap-channel-not-available"}}`)
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"diff-config", "foo"})
+	c.Assert(err, ErrorMatches, "no snap revision on specified channel")
+}
+
+func (s *SnapSuite) TestDiffConfigChannelWithFile(c *C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"diff-config", "--channel=edge", "foo", "foo.snap"})
+	c.Assert(err, ErrorMatches, "cannot use --channel with a snap file")
+}
@@ -0,0 +1,405 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/squashfs"
+	"github.com/snapcore/snapd/sandbox/cgroup"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/sysconfig"
+)
+
+var shortDoctorHelp = i18n.G("Diagnose common problems with the system")
+var longDoctorHelp = i18n.G(`
+The doctor command runs a series of checks on the system snapd is running
+on, and prints what it found together with suggestions on how to address
+any problems.
+
+The checks cover the availability of the AppArmor and seccomp sandboxes,
+squashfs support, the cgroup version in use, the status of cloud-init,
+connectivity to the store, the system clock, and free disk space.
+
+The command fails if any of the checks found an error.
+`)
+
+type cmdDoctor struct {
+	clientMixin
+}
+
+func init() {
+	addCommand("doctor", shortDoctorHelp, longDoctorHelp, func() flags.Commander {
+		return &cmdDoctor{}
+	}, nil, nil)
+}
+
+var (
+	cgroupVersion            = cgroup.Version
+	squashfsNeedsFuse        = squashfs.NeedsFuse
+	sysconfigCloudInitStatus = sysconfig.CloudInitStatus
+	osutilCheckFreeSpace     = osutil.CheckFreeSpace
+
+	procFilesystems = "/proc/filesystems"
+)
+
+// doctorMinFreeSpace is the free space below which the doctor warns
+// about the disk holding the snapd state and snaps.
+const doctorMinFreeSpace = 500 * 1000 * 1000
+
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorWarning
+	doctorError
+)
+
+func (st doctorStatus) String() string {
+	switch st {
+	case doctorOK:
+		return i18n.G("ok")
+	case doctorWarning:
+		return i18n.G("warning")
+	default:
+		return i18n.G("error")
+	}
+}
+
+type doctorResult struct {
+	status doctorStatus
+	// notes is a short description of what was found
+	notes string
+	// advice is what the user can do about a problem
+	advice string
+}
+
+// doctor holds what is shared between the checks.
+type doctor struct {
+	cli *client.Client
+
+	sysInfo    *client.SysInfo
+	sysInfoErr error
+}
+
+var doctorChecks = []struct {
+	name  string
+	check func(*doctor) doctorResult
+}{
+	{"confinement", (*doctor).checkConfinement},
+	{"apparmor", (*doctor).checkAppArmor},
+	{"seccomp", (*doctor).checkSeccomp},
+	{"squashfs", (*doctor).checkSquashfs},
+	{"cgroup", (*doctor).checkCgroup},
+	{"cloud-init", (*doctor).checkCloudInit},
+	{"store", (*doctor).checkStore},
+	{"clock", (*doctor).checkClock},
+	{"disk-space", (*doctor).checkDiskSpace},
+}
+
+func (x *cmdDoctor) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	d := &doctor{cli: x.client}
+	d.sysInfo, d.sysInfoErr = x.client.SysInfo()
+
+	type finding struct {
+		name string
+		doctorResult
+	}
+	var findings []finding
+	failed := 0
+
+	w := tabWriter()
+	fmt.Fprintln(w, i18n.G("Check\tStatus\tNotes"))
+	for _, chk := range doctorChecks {
+		res := chk.check(d)
+		fmt.Fprintf(w, "%s\t%s\t%s\n", chk.name, res.status, res.notes)
+		if res.status == doctorOK {
+			continue
+		}
+		if res.status == doctorError {
+			failed++
+		}
+		if res.advice != "" {
+			findings = append(findings, finding{chk.name, res})
+		}
+	}
+	w.Flush()
+
+	if len(findings) > 0 {
+		fmt.Fprintln(Stdout)
+		fmt.Fprintln(Stdout, i18n.G("Suggestions:"))
+		for _, f := range findings {
+			fmt.Fprintf(Stdout, " * %s: %s\n", f.name, f.advice)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf(i18n.NG("%d check failed", "%d checks failed", failed), failed)
+	}
+	return nil
+}
+
+func (d *doctor) sysInfoUnavailable() (doctorResult, bool) {
+	if d.sysInfoErr == nil {
+		return doctorResult{}, false
+	}
+	return doctorResult{
+		status: doctorError,
+		notes:  i18n.G("cannot get system information"),
+		advice: fmt.Sprintf(i18n.G("cannot get system information from snapd (%v); make sure snapd is running"), d.sysInfoErr),
+	}, true
+}
+
+func (d *doctor) checkConfinement() doctorResult {
+	if res, bad := d.sysInfoUnavailable(); bad {
+		return res
+	}
+	if d.sysInfo.Confinement != "strict" {
+		return doctorResult{
+			status: doctorWarning,
+			notes:  d.sysInfo.Confinement,
+			advice: i18n.G("snaps are not fully confined on this system; strict confinement needs a kernel with AppArmor enabled and the AppArmor parser installed"),
+		}
+	}
+	return doctorResult{notes: d.sysInfo.Confinement}
+}
+
+func (d *doctor) checkSandboxBackend(backend, advice string) doctorResult {
+	if res, bad := d.sysInfoUnavailable(); bad {
+		return res
+	}
+	features := d.sysInfo.SandboxFeatures[backend]
+	if len(features) == 0 {
+		return doctorResult{
+			status: doctorWarning,
+			notes:  i18n.G("not available"),
+			advice: advice,
+		}
+	}
+	return doctorResult{notes: strings.Join(features, " ")}
+}
+
+func (d *doctor) checkAppArmor() doctorResult {
+	return d.checkSandboxBackend("apparmor", i18n.G("AppArmor is not in use; check that it is enabled on the kernel command line and that the apparmor service is running"))
+}
+
+func (d *doctor) checkSeccomp() doctorResult {
+	return d.checkSandboxBackend("seccomp", i18n.G("seccomp is not available; a kernel built with seccomp filter support is required"))
+}
+
+func (d *doctor) checkSquashfs() doctorResult {
+	if squashfsNeedsFuse() {
+		// squashfuse or snapfuse are known to be there if fuse is needed
+		if !osutil.ExecutableExists("mount.fuse") {
+			return doctorResult{
+				status: doctorError,
+				notes:  i18n.G("fuse needed but not available"),
+				advice: i18n.G(`the "fuse" filesystem is required on this system but not available; install the fuse package`),
+			}
+		}
+		return doctorResult{notes: i18n.G("using fuse")}
+	}
+
+	found, err := kernelSupportsFilesystem("squashfs")
+	if err != nil {
+		return doctorResult{
+			status: doctorWarning,
+			notes:  i18n.G("cannot check kernel support"),
+			advice: fmt.Sprintf(i18n.G("cannot check kernel support for squashfs: %v"), err),
+		}
+	}
+	if !found {
+		// the module may just not be loaded yet
+		return doctorResult{
+			status: doctorWarning,
+			notes:  i18n.G("not known to the kernel"),
+			advice: i18n.G(`the kernel does not list squashfs as a supported filesystem; make sure the "squashfs" module is available, for example with "modprobe squashfs"`),
+		}
+	}
+	return doctorResult{notes: i18n.G("supported by the kernel")}
+}
+
+func kernelSupportsFilesystem(fstype string) (bool, error) {
+	f, err := os.Open(procFilesystems)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// lines are like "nodev	tmpfs" or "	squashfs"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && fields[len(fields)-1] == fstype {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+func (d *doctor) checkCgroup() doctorResult {
+	version, err := cgroupVersion()
+	if err != nil {
+		return doctorResult{
+			status: doctorError,
+			notes:  i18n.G("unknown"),
+			advice: fmt.Sprintf(i18n.G("cannot determine the cgroup version: %v"), err),
+		}
+	}
+	switch version {
+	case cgroup.V1:
+		return doctorResult{notes: "v1"}
+	case cgroup.V2:
+		return doctorResult{notes: i18n.G("v2 (unified hierarchy)")}
+	}
+	return doctorResult{
+		status: doctorError,
+		notes:  i18n.G("unknown"),
+		advice: i18n.G("cannot determine the cgroup version"),
+	}
+}
+
+func (d *doctor) checkCloudInit() doctorResult {
+	state, err := sysconfigCloudInitStatus()
+	if err != nil || state == sysconfig.CloudInitErrored {
+		res := doctorResult{
+			status: doctorWarning,
+			notes:  i18n.G("errored"),
+			advice: i18n.G(`cloud-init failed; run "cloud-init status --long" for details`),
+		}
+		if err != nil {
+			res.advice = fmt.Sprintf(i18n.G(`cloud-init failed (%v); run "cloud-init status --long" for details`), err)
+		}
+		return res
+	}
+
+	var notes string
+	switch state {
+	case sysconfig.CloudInitDisabledPermanently:
+		notes = i18n.G("disabled")
+	case sysconfig.CloudInitRestrictedBySnapd:
+		notes = i18n.G("restricted by snapd")
+	case sysconfig.CloudInitUntriggered:
+		notes = i18n.G("not triggered")
+	case sysconfig.CloudInitDone:
+		notes = i18n.G("done")
+	case sysconfig.CloudInitEnabled:
+		notes = i18n.G("enabled")
+	case sysconfig.CloudInitNotFound:
+		notes = i18n.G("not installed")
+	}
+	return doctorResult{notes: notes}
+}
+
+func (d *doctor) checkStore() doctorResult {
+	var status struct {
+		Unreachable []string
+	}
+	if err := d.cli.DebugGet("connectivity", &status, nil); err != nil {
+		return doctorResult{
+			status: doctorError,
+			notes:  i18n.G("cannot check connectivity"),
+			advice: fmt.Sprintf(i18n.G("cannot check connectivity to the store: %v"), err),
+		}
+	}
+	if len(status.Unreachable) > 0 {
+		return doctorResult{
+			status: doctorError,
+			notes:  fmt.Sprintf(i18n.G("unreachable: %s"), strings.Join(status.Unreachable, ", ")),
+			advice: i18n.G(`the store cannot be reached; check the network connection, and the proxy settings with "snap get system proxy"`),
+		}
+	}
+	return doctorResult{notes: i18n.G("reachable")}
+}
+
+func (d *doctor) checkClock() doctorResult {
+	// the system clock cannot be earlier than when the assertions
+	// snapd has accepted for the device were signed
+	var latest string
+	var ts time.Time
+	model, err := d.cli.CurrentModelAssertion()
+	if err != nil && !client.IsAssertionNotFoundError(err) {
+		return deviceAssertionsUnavailable(err)
+	}
+	if err == nil {
+		latest, ts = "model", model.Timestamp()
+	}
+	serial, err := d.cli.CurrentSerialAssertion()
+	if err != nil && !client.IsAssertionNotFoundError(err) {
+		return deviceAssertionsUnavailable(err)
+	}
+	if err == nil && serial.Timestamp().After(ts) {
+		latest, ts = "serial", serial.Timestamp()
+	}
+	if latest == "" {
+		return doctorResult{notes: i18n.G("no device assertions to compare with")}
+	}
+
+	now := timeNow()
+	if now.Before(ts) {
+		return doctorResult{
+			status: doctorError,
+			notes:  fmt.Sprintf(i18n.G("earlier than the %s assertion"), latest),
+			advice: fmt.Sprintf(i18n.G(`the system clock (%s) is earlier than the timestamp of the %s assertion (%s); make sure the time is correct, for example with "timedatectl set-ntp true"`), now.UTC().Format(time.RFC3339), latest, ts.UTC().Format(time.RFC3339)),
+		}
+	}
+	return doctorResult{notes: i18n.G("consistent with device assertions")}
+}
+
+func deviceAssertionsUnavailable(err error) doctorResult {
+	return doctorResult{
+		status: doctorError,
+		notes:  i18n.G("unknown"),
+		advice: fmt.Sprintf(i18n.G("cannot get the device assertions from snapd (%v); make sure snapd is running"), err),
+	}
+}
+
+func (d *doctor) checkDiskSpace() doctorResult {
+	path := dirs.SnapdStateDir(dirs.GlobalRootDir)
+	if err := osutilCheckFreeSpace(path, doctorMinFreeSpace); err != nil {
+		if _, ok := err.(*osutil.NotEnoughDiskSpaceError); ok {
+			return doctorResult{
+				status: doctorWarning,
+				notes:  fmt.Sprintf(i18n.G("less than %s free"), strutil.SizeToStr(doctorMinFreeSpace)),
+				advice: fmt.Sprintf(i18n.G("little space is left in %s, installing and refreshing snaps may fail; free some disk space"), path),
+			}
+		}
+		return doctorResult{
+			status: doctorWarning,
+			notes:  i18n.G("cannot check"),
+			advice: fmt.Sprintf(i18n.G("cannot check free disk space: %v"), err),
+		}
+	}
+	return doctorResult{notes: fmt.Sprintf(i18n.G("at least %s free"), strutil.SizeToStr(doctorMinFreeSpace))}
+}
@@ -0,0 +1,198 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/sandbox/cgroup"
+	"github.com/snapcore/snapd/sysconfig"
+)
+
+type doctorSuite struct {
+	BaseSnapSuite
+
+	procFilesystems string
+}
+
+var _ = Suite(&doctorSuite{})
+
+func (s *doctorSuite) SetUpTest(c *C) {
+	s.BaseSnapSuite.SetUpTest(c)
+
+	s.procFilesystems = filepath.Join(c.MkDir(), "filesystems")
+	c.Assert(ioutil.WriteFile(s.procFilesystems, []byte("nodev\tsysfs\n\text4\n\tsquashfs\n"), 0644), IsNil)
+	s.AddCleanup(snap.MockDoctorProcFilesystems(s.procFilesystems))
+	s.AddCleanup(snap.MockDoctorSquashfsNeedsFuse(func() bool { return false }))
+	s.AddCleanup(snap.MockDoctorCgroupVersion(func() (int, error) { return cgroup.V1, nil }))
+	s.AddCleanup(snap.MockDoctorCloudInitStatus(func() (sysconfig.CloudInitState, error) {
+		return sysconfig.CloudInitDone, nil
+	}))
+	s.AddCleanup(snap.MockDoctorCheckFreeSpace(func(path string, minSize uint64) error {
+		c.Check(path, Equals, dirs.SnapdStateDir(dirs.GlobalRootDir))
+		return nil
+	}))
+	s.AddCleanup(snap.MockTimeNow(func() time.Time {
+		return time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	}))
+}
+
+func (s *doctorSuite) mockServer(c *C, sysInfo, connectivity string) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		switch r.URL.Path {
+		case "/v2/system-info":
+			fmt.Fprintf(w, `{"type": "sync", "result": %s}`, sysInfo)
+		case "/v2/debug":
+			c.Check(r.URL.Query().Get("aspect"), Equals, "connectivity")
+			fmt.Fprintf(w, `{"type": "sync", "result": %s}`, connectivity)
+		case "/v2/model":
+			fmt.Fprint(w, happyModelAssertionResponse)
+		case "/v2/model/serial":
+			fmt.Fprint(w, happySerialAssertionResponse)
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	})
+}
+
+func (s *doctorSuite) TestDoctorAllGood(c *C) {
+	s.mockServer(c, `{
+  "confinement": "strict",
+  "sandbox-features": {
+    "apparmor": ["kernel:caps", "policy:default"],
+    "seccomp": ["bpf-argument-filtering"]
+  }
+}`, `{"connectivity": true}`)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"doctor"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, `Check        Status  Notes
+confinement  ok      strict
+apparmor     ok      kernel:caps policy:default
+seccomp      ok      bpf-argument-filtering
+squashfs     ok      supported by the kernel
+cgroup       ok      v1
+cloud-init   ok      done
+store        ok      reachable
+clock        ok      consistent with device assertions
+disk-space   ok      at least 500MB free
+`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *doctorSuite) TestDoctorProblems(c *C) {
+	c.Assert(ioutil.WriteFile(s.procFilesystems, []byte("nodev\tsysfs\n\text4\n"), 0644), IsNil)
+	s.AddCleanup(snap.MockDoctorCgroupVersion(func() (int, error) { return cgroup.V2, nil }))
+	s.AddCleanup(snap.MockDoctorCloudInitStatus(func() (sysconfig.CloudInitState, error) {
+		return sysconfig.CloudInitErrored, errors.New("boom")
+	}))
+	s.AddCleanup(snap.MockDoctorCheckFreeSpace(func(path string, minSize uint64) error {
+		return &osutil.NotEnoughDiskSpaceError{Path: path, Delta: 100}
+	}))
+	// earlier than the serial assertion
+	s.AddCleanup(snap.MockTimeNow(func() time.Time {
+		return time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	}))
+	s.mockServer(c, `{
+  "confinement": "partial",
+  "sandbox-features": {
+    "seccomp": ["bpf-argument-filtering"]
+  }
+}`, `{"connectivity": false, "unreachable": ["https://api.snapcraft.io"]}`)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"doctor"})
+	c.Assert(err, ErrorMatches, "2 checks failed")
+	c.Check(s.Stdout(), Equals, `Check        Status   Notes
+confinement  warning  partial
+apparmor     warning  not available
+seccomp      ok       bpf-argument-filtering
+squashfs     warning  not known to the kernel
+cgroup       ok       v2 (unified hierarchy)
+cloud-init   warning  errored
+store        error    unreachable: https://api.snapcraft.io
+clock        error    earlier than the serial assertion
+disk-space   warning  less than 500MB free
+
+Suggestions:
+ * confinement: snaps are not fully confined on this system; strict confinement needs a kernel with AppArmor enabled and the AppArmor parser installed
+ * apparmor: AppArmor is not in use; check that it is enabled on the kernel command line and that the apparmor service is running
+ * squashfs: the kernel does not list squashfs as a supported filesystem; make sure the "squashfs" module is available, for example with "modprobe squashfs"
+ * cloud-init: cloud-init failed (boom); run "cloud-init status --long" for details
+ * store: the store cannot be reached; check the network connection, and the proxy settings with "snap get system proxy"
+ * clock: the system clock (2018-01-01T00:00:00Z) is earlier than the timestamp of the serial assertion (2019-08-26T21:34:21Z); make sure the time is correct, for example with "timedatectl set-ntp true"
+`+fmt.Sprintf(` * disk-space: little space is left in %s, installing and refreshing snaps may fail; free some disk space
+`, dirs.SnapdStateDir(dirs.GlobalRootDir)))
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *doctorSuite) TestDoctorNoSystemInfo(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(500)
+		fmt.Fprintln(w, `{"type": "error", "result": {"message": "boom"}}`)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"doctor"})
+	c.Assert(err, ErrorMatches, "5 checks failed")
+	c.Check(s.Stdout(), Matches, `(?s)Check +Status +Notes
+confinement +error +cannot get system information
+apparmor +error +cannot get system information
+seccomp +error +cannot get system information
+.*store +error +cannot check connectivity
+clock +error +unknown
+.* \* confinement: cannot get system information from snapd \(cannot obtain system details: boom\); make sure snapd is running
+.* \* clock: cannot get the device assertions from snapd \(boom\); make sure snapd is running
+.*`)
+}
+
+func (s *doctorSuite) TestDoctorNoDeviceAssertions(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/system-info":
+			fmt.Fprint(w, `{"type": "sync", "result": {"confinement": "strict", "sandbox-features": {"apparmor": ["kernel:caps"], "seccomp": ["bpf-argument-filtering"]}}}`)
+		case "/v2/debug":
+			fmt.Fprint(w, `{"type": "sync", "result": {"connectivity": true}}`)
+		case "/v2/model", "/v2/model/serial":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(404)
+			fmt.Fprint(w, `{"type": "error", "status-code": 404, "result": {"message": "no model assertion yet", "kind": "assertion-not-found", "value": "model"}}`)
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"doctor"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Matches, `(?s).*
+clock +ok +no device assertions to compare with
+.*`)
+}
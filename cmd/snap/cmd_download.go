@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/jessevdk/go-flags"
@@ -39,6 +40,7 @@ type cmdDownload struct {
 	Revision  string `long:"revision"`
 	Basename  string `long:"basename"`
 	TargetDir string `long:"target-directory"`
+	MirrorDir string `long:"mirror-dir"`
 
 	CohortKey  string `long:"cohort"`
 	Positional struct {
@@ -50,6 +52,11 @@ var shortDownloadHelp = i18n.G("Download the given snap")
 var longDownloadHelp = i18n.G(`
 The download command downloads the given snap and its supporting assertions
 to the current directory with .snap and .assert file extensions, respectively.
+
+With --mirror-dir, the snaps needed to install the given snap, that is its
+base and the default providers of its content plugs, are downloaded along
+with it and their assertions into the given directory, so that they can be
+installed on a system without access to the store.
 `)
 
 func init() {
@@ -64,6 +71,8 @@ func init() {
 		"basename": i18n.G("Use this basename for the snap and assertion files (defaults to <snap>_<revision>)"),
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"target-directory": i18n.G("Download to this directory (defaults to the current directory)"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"mirror-dir": i18n.G("Download the snap and the snaps it needs, with their assertions, to this directory"),
 	}), []argDesc{{
 		name: "<snap>",
 		// TRANSLATORS: This should not start with a lowercase letter.
@@ -113,6 +122,44 @@ func printInstallHint(assertPath, snapPath string) {
 `), assertPath, snapPath)
 }
 
+// printMirrorInstallHint prints how to install the mirrored snaps, the
+// paths are expected in download order so prerequisites come last.
+func printMirrorInstallHint(assertPaths, snapPaths []string) {
+	wd, _ := os.Getwd()
+	rel := func(path string) string {
+		if p, err := filepath.Rel(wd, path); err == nil {
+			return p
+		}
+		return path
+	}
+	fmt.Fprintf(Stdout, i18n.G("Install the snaps with:\n"))
+	for i := len(assertPaths) - 1; i >= 0; i-- {
+		fmt.Fprintf(Stdout, "   snap ack %s\n", rel(assertPaths[i]))
+	}
+	for i := len(snapPaths) - 1; i >= 0; i-- {
+		fmt.Fprintf(Stdout, "   snap install %s\n", rel(snapPaths[i]))
+	}
+}
+
+// snapPrerequisites returns the names of the snaps that need to be
+// installed for the given snap to be installed.
+func snapPrerequisites(info *snap.Info) []string {
+	var prereqs []string
+	switch {
+	case info.Base != "" && info.Base != "none":
+		prereqs = append(prereqs, info.Base)
+	case info.Base == "" && info.Type() == snap.TypeApp:
+		// snaps without a base use core
+		prereqs = append(prereqs, "core")
+	}
+	var providers []string
+	for provider := range snap.NeededDefaultProviders(info) {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return append(prereqs, providers...)
+}
+
 // for testing
 var downloadDirect = downloadDirectImpl
 
@@ -137,16 +184,70 @@ func downloadDirectImpl(snapName string, revision snap.Revision, dlOpts image.Do
 	return nil
 }
 
+// for testing
+var downloadMirror = downloadMirrorImpl
+
+func downloadMirrorImpl(snapName string, revision snap.Revision, dlOpts image.DownloadOptions) error {
+	tsto, err := image.NewToolingStore()
+	if err != nil {
+		return err
+	}
+
+	var assertPaths, snapPaths []string
+	seen := map[string]bool{snapName: true}
+	pending := []string{snapName}
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+
+		opts := dlOpts
+		if name != snapName {
+			// prerequisites are downloaded from their default channel
+			opts = image.DownloadOptions{
+				TargetDir:           dlOpts.TargetDir,
+				LeavePartialOnError: dlOpts.LeavePartialOnError,
+			}
+		}
+
+		fmt.Fprintf(Stdout, i18n.G("Fetching snap %q\n"), name)
+		snapPath, snapInfo, _, err := tsto.DownloadSnap(name, opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(Stdout, i18n.G("Fetching assertions for %q\n"), name)
+		assertPath, err := fetchSnapAssertionsDirect(tsto, snapPath, snapInfo)
+		if err != nil {
+			return err
+		}
+		assertPaths = append(assertPaths, assertPath)
+		snapPaths = append(snapPaths, snapPath)
+
+		for _, prereq := range snapPrerequisites(snapInfo) {
+			if !seen[prereq] {
+				seen[prereq] = true
+				pending = append(pending, prereq)
+			}
+		}
+	}
+	printMirrorInstallHint(assertPaths, snapPaths)
+	return nil
+}
+
 func (x *cmdDownload) downloadFromStore(snapName string, revision snap.Revision) error {
 	dlOpts := image.DownloadOptions{
 		TargetDir: x.TargetDir,
 		Basename:  x.Basename,
-		Channel:   x.Channel,
+		Channel:   string(x.Channel),
 		CohortKey: x.CohortKey,
 		Revision:  revision,
 		// if something goes wrong, don't force it to start over again
 		LeavePartialOnError: true,
 	}
+	if x.MirrorDir != "" {
+		dlOpts.TargetDir = x.MirrorDir
+		return downloadMirror(snapName, revision, dlOpts)
+	}
 	return downloadDirect(snapName, revision, dlOpts)
 }
 
@@ -154,6 +255,9 @@ func (x *cmdDownload) Execute(args []string) error {
 	if strings.ContainsRune(x.Basename, filepath.Separator) {
 		return fmt.Errorf(i18n.G("cannot specify a path in basename (use --target-dir for that)"))
 	}
+	if x.MirrorDir != "" && (x.TargetDir != "" || x.Basename != "") {
+		return fmt.Errorf(i18n.G("cannot specify --mirror-dir with --target-directory or --basename"))
+	}
 	if err := x.setChannelFromCommandline(); err != nil {
 		return err
 	}
@@ -29,6 +29,7 @@ import (
 	snapCmd "github.com/snapcore/snapd/cmd/snap"
 	"github.com/snapcore/snapd/image"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
 )
 
 // these only cover errors that happen before hitting the network,
@@ -128,3 +129,69 @@ func (s *SnapSuite) TestDownloadDirectErrors(c *check.C) {
 	c.Assert(err, check.ErrorMatches, "some-error")
 	c.Check(n, check.Equals, 1)
 }
+
+func (s *SnapSuite) TestDownloadMirrorDir(c *check.C) {
+	var n int
+	restore := snapCmd.MockDownloadMirror(func(snapName string, revision snap.Revision, dlOpts image.DownloadOptions) error {
+		c.Check(snapName, check.Equals, "a-snap")
+		c.Check(revision, check.Equals, snap.R(0))
+		c.Check(dlOpts.TargetDir, check.Equals, "some-mirror-dir")
+		c.Check(dlOpts.Channel, check.Equals, "some-channel")
+		n++
+		return nil
+	})
+	defer restore()
+	restore = snapCmd.MockDownloadDirect(func(snapName string, revision snap.Revision, dlOpts image.DownloadOptions) error {
+		c.Fatalf("unexpected direct download")
+		return nil
+	})
+	defer restore()
+
+	_, err := snapCmd.Parser(snapCmd.Client()).ParseArgs([]string{
+		"download",
+		"--mirror-dir=some-mirror-dir",
+		"--channel=some-channel",
+		"a-snap"},
+	)
+	c.Assert(err, check.IsNil)
+	c.Check(n, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestDownloadMirrorDirConflicts(c *check.C) {
+	for _, opt := range []string{"--target-directory=foo", "--basename=foo"} {
+		_, err := snapCmd.Parser(snapCmd.Client()).ParseArgs([]string{
+			"download", "--mirror-dir=some-mirror-dir", opt, "a-snap",
+		})
+		c.Check(err, check.ErrorMatches, "cannot specify --mirror-dir with --target-directory or --basename")
+	}
+}
+
+func (s *SnapSuite) TestSnapPrerequisites(c *check.C) {
+	info := snaptest.MockInfo(c, `name: app
+version: 1
+plugs:
+  gtk-3-themes:
+    interface: content
+    default-provider: gtk-common-themes
+  icons:
+    interface: content
+    default-provider: adwaita-icons:icons
+`, nil)
+	c.Check(snapCmd.SnapPrerequisites(info), check.DeepEquals, []string{"core", "adwaita-icons", "gtk-common-themes"})
+
+	info = snaptest.MockInfo(c, "name: app\nversion: 1\nbase: core20\n", nil)
+	c.Check(snapCmd.SnapPrerequisites(info), check.DeepEquals, []string{"core20"})
+
+	info = snaptest.MockInfo(c, "name: core20\nversion: 1\ntype: base\n", nil)
+	c.Check(snapCmd.SnapPrerequisites(info), check.HasLen, 0)
+}
+
+func (s *SnapSuite) TestPrintMirrorInstallHint(c *check.C) {
+	snapCmd.PrintMirrorInstallHint([]string{"app_1.assert", "core20_2.assert"}, []string{"app_1.snap", "core20_2.snap"})
+	c.Check(s.Stdout(), check.Equals, `Install the snaps with:
+   snap ack core20_2.assert
+   snap ack app_1.assert
+   snap install core20_2.snap
+   snap install app_1.snap
+`)
+}
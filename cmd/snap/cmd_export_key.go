@@ -21,6 +21,7 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/jessevdk/go-flags"
@@ -87,6 +88,13 @@ func (x *cmdExportKey) Execute(args []string) error {
 		if err != nil {
 			return err
 		}
+		format, err := asserts.SuggestFormat(asserts.AccountKeyRequestType, headers, body)
+		if err != nil {
+			return err
+		}
+		if format > 0 {
+			headers["format"] = strconv.Itoa(format)
+		}
 		assertion, err := asserts.SignWithoutAuthority(asserts.AccountKeyRequestType, headers, body, privKey)
 		if err != nil {
 			return err
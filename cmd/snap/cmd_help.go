@@ -190,9 +190,10 @@ var helpCategories = []helpCategory{
 		Description: i18n.G("basic snap management"),
 		Commands:    []string{"find", "info", "install", "remove", "list"},
 	}, {
-		Label:       i18n.G("...more"),
-		Description: i18n.G("slightly more advanced snap management"),
-		Commands:    []string{"refresh", "revert", "switch", "disable", "enable", "create-cohort"},
+		Label:           i18n.G("...more"),
+		Description:     i18n.G("slightly more advanced snap management"),
+		Commands:        []string{"refresh", "revert", "switch", "disable", "enable", "create-cohort"},
+		AllOnlyCommands: []string{"export", "import"},
 	}, {
 		Label:       i18n.G("History"),
 		Description: i18n.G("manage system change transactions"),
@@ -208,7 +209,7 @@ var helpCategories = []helpCategory{
 	}, {
 		Label:       i18n.G("Configuration"),
 		Description: i18n.G("system administration and configuration"),
-		Commands:    []string{"get", "set", "unset", "wait"},
+		Commands:    []string{"get", "set", "unset", "wait", "save-config", "restore-config", "diff-config"},
 	}, {
 		Label:       i18n.G("App Aliases"),
 		Description: i18n.G("manage aliases"),
@@ -225,7 +226,7 @@ var helpCategories = []helpCategory{
 	}, {
 		Label:       i18n.G("Device"),
 		Description: i18n.G("manage device"),
-		Commands:    []string{"model", "reboot", "recovery"},
+		Commands:    []string{"model", "reboot", "recovery", "usage"},
 	}, {
 		Label:       i18n.G("Warnings"),
 		Other:       true,
@@ -240,8 +241,8 @@ var helpCategories = []helpCategory{
 		Label:           i18n.G("Introspection"),
 		Other:           true,
 		Description:     i18n.G("introspection and debugging of snapd"),
-		Commands:        []string{"version"},
-		AllOnlyCommands: []string{"debug"},
+		Commands:        []string{"version", "doctor"},
+		AllOnlyCommands: []string{"debug", "completion"},
 	},
 	{
 		Label:           i18n.G("Development"),
@@ -48,6 +48,7 @@ type infoCmd struct {
 	clientMixin
 	colorMixin
 	timeMixin
+	formatMixin
 
 	Verbose    bool `long:"verbose"`
 	Positional struct {
@@ -71,7 +72,7 @@ func init() {
 		longInfoHelp,
 		func() flags.Commander {
 			return &infoCmd{}
-		}, colorDescs.also(timeDescs).also(map[string]string{
+		}, colorDescs.also(timeDescs).also(formatDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"verbose": i18n.G("Include more details on the snap (expanded notes, base, etc.)"),
 		}), nil)
@@ -675,7 +676,150 @@ func (chInfos *channelInfos) dump(w io.Writer) {
 	}
 }
 
+// infoSnapFormatted is the machine-readable form of the information
+// about a snap.
+type infoSnapFormatted struct {
+	Name        string                  `json:"name" yaml:"name"`
+	Path        string                  `json:"path,omitempty" yaml:"path,omitempty"`
+	Summary     string                  `json:"summary" yaml:"summary"`
+	Publisher   string                  `json:"publisher,omitempty" yaml:"publisher,omitempty"`
+	StoreURL    string                  `json:"store-url,omitempty" yaml:"store-url,omitempty"`
+	Contact     string                  `json:"contact,omitempty" yaml:"contact,omitempty"`
+	License     string                  `json:"license,omitempty" yaml:"license,omitempty"`
+	Description string                  `json:"description" yaml:"description"`
+	Type        string                  `json:"type" yaml:"type"`
+	Base        string                  `json:"base,omitempty" yaml:"base,omitempty"`
+	SnapID      string                  `json:"snap-id,omitempty" yaml:"snap-id,omitempty"`
+	Version     string                  `json:"version,omitempty" yaml:"version,omitempty"`
+	Commands    []string                `json:"commands,omitempty" yaml:"commands,omitempty"`
+	Services    []string                `json:"services,omitempty" yaml:"services,omitempty"`
+	Notes       []string                `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Tracking    string                  `json:"tracking,omitempty" yaml:"tracking,omitempty"`
+	RefreshDate string                  `json:"refresh-date,omitempty" yaml:"refresh-date,omitempty"`
+	Installed   *infoInstalledFormatted `json:"installed,omitempty" yaml:"installed,omitempty"`
+	Channels    []infoChannelFormatted  `json:"channels,omitempty" yaml:"channels,omitempty"`
+}
+
+type infoInstalledFormatted struct {
+	Version  string `json:"version" yaml:"version"`
+	Revision string `json:"revision" yaml:"revision"`
+	Size     int64  `json:"size,omitempty" yaml:"size,omitempty"`
+}
+
+type infoChannelFormatted struct {
+	Channel    string `json:"channel" yaml:"channel"`
+	Version    string `json:"version" yaml:"version"`
+	Revision   string `json:"revision" yaml:"revision"`
+	ReleasedAt string `json:"released-at,omitempty" yaml:"released-at,omitempty"`
+	Size       int64  `json:"size,omitempty" yaml:"size,omitempty"`
+}
+
+func infoFormatted(path string, theSnap, localSnap, remoteSnap *client.Snap) *infoSnapFormatted {
+	out := &infoSnapFormatted{
+		Name:        theSnap.Name,
+		Path:        path,
+		Summary:     theSnap.Summary,
+		StoreURL:    theSnap.StoreURL,
+		Contact:     strings.TrimPrefix(theSnap.Contact, "mailto:"),
+		License:     theSnap.License,
+		Description: theSnap.Description,
+		Type:        theSnap.Type,
+		Base:        theSnap.Base,
+		SnapID:      theSnap.ID,
+	}
+	if out.Type == "" {
+		out.Type = string(snap.TypeApp)
+	}
+	if path != "" {
+		out.Version = theSnap.Version
+	} else if theSnap.Publisher != nil {
+		out.Publisher = theSnap.Publisher.Username
+	}
+	if out.StoreURL == "" && remoteSnap != nil {
+		out.StoreURL = remoteSnap.StoreURL
+	}
+	for _, app := range theSnap.Apps {
+		appName := snap.JoinSnapApp(theSnap.Name, app.Name)
+		if app.IsService() {
+			out.Services = append(out.Services, appName)
+		} else {
+			out.Commands = append(out.Commands, appName)
+		}
+	}
+	if localSnap != nil {
+		out.Notes = NotesFromLocal(localSnap).list()
+		out.Tracking = localSnap.TrackingChannel
+		out.RefreshDate = fmtTimeFormatted(localSnap.InstallDate)
+		out.Installed = &infoInstalledFormatted{
+			Version:  localSnap.Version,
+			Revision: localSnap.Revision.String(),
+			Size:     localSnap.InstalledSize,
+		}
+	}
+	if remoteSnap != nil {
+		for _, tr := range remoteSnap.Tracks {
+			for _, risk := range channelRisks {
+				chName := fmt.Sprintf("%s/%s", tr, risk)
+				ch, ok := remoteSnap.Channels[chName]
+				if !ok {
+					continue
+				}
+				out.Channels = append(out.Channels, infoChannelFormatted{
+					Channel:    chName,
+					Version:    ch.Version,
+					Revision:   ch.Revision.String(),
+					ReleasedAt: fmtTimeFormatted(ch.ReleasedAt),
+					Size:       ch.Size,
+				})
+			}
+		}
+	}
+	return out
+}
+
+func (x *infoCmd) executeFormatted() error {
+	out := make([]*infoSnapFormatted, 0, len(x.Positional.Snaps))
+	for _, snapName := range x.Positional.Snaps {
+		snapName := string(snapName)
+		if snapName == "system" {
+			continue
+		}
+
+		var entry *infoSnapFormatted
+		if diskSnap, err := clientSnapFromPath(snapName); err == nil {
+			entry = infoFormatted(norm(snapName), diskSnap, nil, nil)
+		} else {
+			remoteSnap, _, _ := x.client.FindOne(snap.InstanceSnap(snapName))
+			localSnap, _, _ := x.client.Snap(snapName)
+			switch {
+			case localSnap != nil:
+				entry = infoFormatted("", localSnap, localSnap, remoteSnap)
+			case remoteSnap != nil:
+				entry = infoFormatted("", remoteSnap, nil, remoteSnap)
+			}
+		}
+		if entry == nil {
+			if len(x.Positional.Snaps) == 1 {
+				return fmt.Errorf("no snap found for %q", snapName)
+			}
+			fmt.Fprintf(Stderr, i18n.G("warning: no snap found for %q\n"), snapName)
+			continue
+		}
+		out = append(out, entry)
+	}
+
+	if len(out) == 0 {
+		return fmt.Errorf(i18n.G("no valid snaps given"))
+	}
+
+	return x.writeFormatted(out)
+}
+
 func (x *infoCmd) Execute([]string) error {
+	if x.machineReadable() {
+		return x.executeFormatted()
+	}
+
 	termWidth, _ := termSize()
 	termWidth -= 3
 	if termWidth > 100 {
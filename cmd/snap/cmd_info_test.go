@@ -712,6 +712,54 @@ installed:    2.10 (1) 1kB disabled,blocked
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *infoSuite) TestInfoFormatJSON(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/find")
+			w.WriteHeader(404)
+			fmt.Fprintln(w, `{"type":"error","status-code":404,"status":"Not Found","result":{"message":"No.","kind":"snap-not-found","value":"hello"}}`)
+		case 1:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/snaps/hello")
+			fmt.Fprintln(w, mockInfoJSONOtherLicense)
+		default:
+			c.Fatalf("expected to get 2 requests, now on %d (%v)", n+1, r)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"info", "--format=json", "hello"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `[
+  {
+    "name": "hello",
+    "summary": "The GNU Hello snap",
+    "publisher": "canonical",
+    "license": "BSD-3",
+    "description": "GNU hello prints a friendly greeting. This is part of the snapcraft tour at https://snapcraft.io/",
+    "type": "app",
+    "snap-id": "mVyGrEwiqSi5PugCwyH7WgpoQLemtTd6",
+    "notes": [
+      "disabled",
+      "blocked"
+    ],
+    "tracking": "beta",
+    "refresh-date": "2006-01-02T22:04:07Z",
+    "installed": {
+      "version": "2.10",
+      "revision": "1",
+      "size": 1024
+    }
+  }
+]
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
 func (s *infoSuite) TestInfoNotFound(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
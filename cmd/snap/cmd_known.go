@@ -31,6 +31,7 @@ import (
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
 )
 
 type cmdKnown struct {
@@ -41,8 +42,9 @@ type cmdKnown struct {
 		HeaderFilters  []string       `required:"0"`
 	} `positional-args:"true" required:"true"`
 
-	Remote bool `long:"remote"`
-	Direct bool `long:"direct"`
+	Remote           bool `long:"remote"`
+	Direct           bool `long:"direct"`
+	CheckRevocations bool `long:"check-revocations"`
 }
 
 var shortKnownHelp = i18n.G("Show known assertions of the provided type")
@@ -50,6 +52,9 @@ var longKnownHelp = i18n.G(`
 The known command shows known assertions of the provided type.
 If header=value pairs are provided after the assertion type, the assertions
 shown must also have the specified headers matching the provided values.
+
+With --check-revocations the command fails if any of the shown assertions
+is signed with a key that has been revoked.
 `)
 
 func init() {
@@ -60,6 +65,8 @@ func init() {
 		"remote": i18n.G("Query the store for the assertion, via snapd if possible"),
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"direct": i18n.G("Query the store for the assertion, without attempting to go via snapd"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"check-revocations": i18n.G("Fail if any of the assertions is signed with a revoked key"),
 	}, []argDesc{
 		{
 			// TRANSLATORS: This needs to begin with < and end with >
@@ -138,6 +145,12 @@ func (x *cmdKnown) Execute(args []string) error {
 		return err
 	}
 
+	if x.CheckRevocations {
+		if err := x.checkRevocations(assertions); err != nil {
+			return err
+		}
+	}
+
 	enc := asserts.NewEncoder(Stdout)
 	for _, a := range assertions {
 		enc.Encode(a)
@@ -145,3 +158,43 @@ func (x *cmdKnown) Execute(args []string) error {
 
 	return nil
 }
+
+// checkRevocations looks for account-key-revocation assertions for the
+// keys that signed the given assertions, querying the store as well if
+// --remote or --direct were given.
+func (x *cmdKnown) checkRevocations(assertions []asserts.Assertion) error {
+	revocationType := asserts.AccountKeyRevocationType.Name
+	checked := make(map[string]bool)
+	var revoked []string
+	for _, a := range assertions {
+		keyID := a.SignKeyID()
+		if checked[keyID] {
+			continue
+		}
+		checked[keyID] = true
+
+		headers := map[string]string{
+			"public-key-sha3-384": keyID,
+		}
+		var revocations []asserts.Assertion
+		var err error
+		if x.Direct {
+			revocations, err = downloadAssertion(revocationType, headers)
+			if asserts.IsNotFound(err) {
+				err = nil
+			}
+		} else {
+			revocations, err = x.client.Known(revocationType, headers, &client.KnownOptions{Remote: x.Remote})
+		}
+		if err != nil {
+			return fmt.Errorf(i18n.G("cannot check revocation of key %q: %v"), keyID, err)
+		}
+		if len(revocations) != 0 {
+			revoked = append(revoked, keyID)
+		}
+	}
+	if len(revoked) != 0 {
+		return fmt.Errorf(i18n.NG("assertions are signed with revoked key: %s", "assertions are signed with revoked keys: %s", len(revoked)), strutil.Quoted(revoked))
+	}
+	return nil
+}
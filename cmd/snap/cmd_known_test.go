@@ -209,6 +209,60 @@ func (s *SnapSuite) TestKnownRemoteMissingPrimaryKey(c *check.C) {
 	c.Assert(err, check.ErrorMatches, `cannot query remote assertion: must provide primary key: model`)
 }
 
+const mockRevocationAssertion = `type: account-key-revocation
+authority-id: canonical
+public-key-sha3-384: 9tydnLa6MTJ-jaQTFUXEwHl1yRx7ZS4K5cyFDhYDcPzhS7uyEkDxdUjg9g08BtNn
+account-id: canonical
+timestamp: 2021-08-31T00:00:00.0Z
+sign-key-sha3-384: -CvQKAwRQ5h3Ffn10FILJoEZUXOv6km9FwA80-Rcj-f-6jadQ89VRswHNiEB9Lxk
+
+AcLorsomethingthatlooksvaguelylikeasignature==
+`
+
+func (s *SnapSuite) testKnownCheckRevocations(c *check.C, revocations string) error {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.URL.Path, check.Equals, "/v2/assertions/model")
+			w.Header().Set("X-Ubuntu-Assertions-Count", "1")
+			fmt.Fprintln(w, mockModelAssertion)
+		case 1:
+			c.Check(r.URL.Path, check.Equals, "/v2/assertions/account-key-revocation")
+			c.Check(r.URL.Query(), check.DeepEquals, url.Values{
+				"public-key-sha3-384": []string{"9tydnLa6MTJ-jaQTFUXEwHl1yRx7ZS4K5cyFDhYDcPzhS7uyEkDxdUjg9g08BtNn"},
+			})
+			if revocations == "" {
+				w.Header().Set("X-Ubuntu-Assertions-Count", "0")
+				n++
+				return
+			}
+			w.Header().Set("X-Ubuntu-Assertions-Count", "1")
+			fmt.Fprintln(w, revocations)
+		default:
+			c.Fatalf("expected to get 2 requests, now on %d", n+1)
+		}
+		n++
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"known", "--check-revocations", "model", "series=16", "brand-id=canonical", "model=pi99"})
+	c.Check(n, check.Equals, 2)
+	return err
+}
+
+func (s *SnapSuite) TestKnownCheckRevocationsNotRevoked(c *check.C) {
+	err := s.testKnownCheckRevocations(c, "")
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, mockModelAssertion)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestKnownCheckRevocationsRevoked(c *check.C) {
+	err := s.testKnownCheckRevocations(c, mockRevocationAssertion)
+	c.Assert(err, check.ErrorMatches, `assertions are signed with revoked key: "9tydnLa6MTJ-jaQTFUXEwHl1yRx7ZS4K5cyFDhYDcPzhS7uyEkDxdUjg9g08BtNn"`)
+	c.Check(s.Stdout(), check.Equals, "")
+}
+
 func (s *SnapSuite) TestAssertTypeNameCompletion(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
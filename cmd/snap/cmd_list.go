@@ -48,11 +48,12 @@ type cmdList struct {
 
 	All bool `long:"all"`
 	colorMixin
+	formatMixin
 }
 
 func init() {
 	addCommand("list", shortListHelp, longListHelp, func() flags.Commander { return &cmdList{} },
-		colorDescs.also(map[string]string{
+		colorDescs.also(formatDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"all": i18n.G("Show all revisions"),
 		}), nil)
@@ -94,6 +95,9 @@ func (x *cmdList) Execute(args []string) error {
 	if err != nil {
 		if err == client.ErrNoSnapsInstalled {
 			if len(names) == 0 {
+				if x.machineReadable() {
+					return x.writeFormatted([]listSnapFormatted{})
+				}
 				fmt.Fprintln(Stderr, i18n.G("No snaps are installed yet. Try 'snap install hello-world'."))
 				return nil
 			} else {
@@ -106,6 +110,10 @@ func (x *cmdList) Execute(args []string) error {
 	}
 	sort.Sort(snapsByName(snaps))
 
+	if x.machineReadable() {
+		return x.writeFormatted(listFormatted(snaps))
+	}
+
 	esc := x.getEscapes()
 	w := tabWriter()
 
@@ -129,6 +137,34 @@ func (x *cmdList) Execute(args []string) error {
 	return nil
 }
 
+// listSnapFormatted is the machine-readable form of a snap list entry.
+type listSnapFormatted struct {
+	Name      string   `json:"name" yaml:"name"`
+	Version   string   `json:"version" yaml:"version"`
+	Revision  string   `json:"revision" yaml:"revision"`
+	Tracking  string   `json:"tracking,omitempty" yaml:"tracking,omitempty"`
+	Publisher string   `json:"publisher,omitempty" yaml:"publisher,omitempty"`
+	Notes     []string `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
+
+func listFormatted(snaps []*client.Snap) []listSnapFormatted {
+	out := make([]listSnapFormatted, 0, len(snaps))
+	for _, snap := range snaps {
+		entry := listSnapFormatted{
+			Name:     snap.Name,
+			Version:  snap.Version,
+			Revision: snap.Revision.String(),
+			Tracking: snap.TrackingChannel,
+			Notes:    NotesFromLocal(snap).list(),
+		}
+		if snap.Publisher != nil {
+			entry.Publisher = snap.Publisher.Username
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
 func tabWriter() *tabwriter.Writer {
 	return tabwriter.NewWriter(Stdout, 5, 3, 2, ' ', 0)
 }
@@ -43,6 +43,8 @@ indicates that the publisher has been verified.
                                       some things. (default: auto)
       --unicode=[auto|never|always]   Use a little bit of Unicode to improve
                                       legibility. (default: auto)
+      --format=[json|yaml]            Output in the given machine-readable
+                                      format instead (json or yaml)
 `
 	s.testSubCommandHelp(c, "list", msg)
 }
@@ -82,6 +84,76 @@ foo   4.2      17   potatoes  bar        blocked
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *SnapSuite) mockListOneSnap(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+			fmt.Fprintln(w, `{"type": "sync", "result": [
+{
+  "name": "foo",
+  "status": "active",
+  "version": "4.2",
+  "developer": "bar",
+  "publisher": {"id": "bar-id", "username": "bar", "display-name": "Bar", "validation": "unproven"},
+  "health": {"status": "blocked"},
+  "revision": 17,
+  "tracking-channel": "potatoes"
+}]}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+}
+
+func (s *SnapSuite) TestListFormatJSON(c *check.C) {
+	s.mockListOneSnap(c)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--format=json"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `[
+  {
+    "name": "foo",
+    "version": "4.2",
+    "revision": "17",
+    "tracking": "potatoes",
+    "publisher": "bar",
+    "notes": [
+      "blocked"
+    ]
+  }
+]
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestListFormatYAML(c *check.C) {
+	s.mockListOneSnap(c)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--format=yaml"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `- name: foo
+  version: "4.2"
+  revision: "17"
+  tracking: potatoes
+  publisher: bar
+  notes:
+  - blocked
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestListFormatInvalid(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--format=xml"})
+	c.Assert(err, check.ErrorMatches, `(?s)Invalid value .xml. for option .*--format.*`)
+}
+
 func (s *SnapSuite) TestListAll(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
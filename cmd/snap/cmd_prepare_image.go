@@ -22,13 +22,18 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/image"
+	"github.com/snapcore/snapd/osutil"
 )
 
 type cmdPrepareImage struct {
@@ -47,6 +52,10 @@ type cmdPrepareImage struct {
 	// TODO: introduce SnapWithChannel?
 	Snaps      []string `long:"snap" value-name:"<snap>[=<channel>]"`
 	ExtraSnaps []string `long:"extra-snaps" hidden:"yes"` // DEPRECATED
+
+	VerifyReproducible bool `long:"verify-reproducible"`
+
+	DiskImage string `long:"disk-image" value-name:"<format>" choice:"raw" choice:"qcow2"`
 }
 
 func init() {
@@ -59,7 +68,17 @@ creating device images.
 For core images it is not invoked directly but usually via
 ubuntu-image.
 
-For preparing classic images it supports a --classic mode`),
+For preparing classic images it supports a --classic mode.
+
+If SOURCE_DATE_EPOCH is set in the environment it is used in place of
+the current time and file modification times are clamped to it, so
+that the same inputs produce an identical image. With
+--verify-reproducible the image is prepared twice and the two results
+are compared.
+
+For UC20 models --disk-image additionally assembles a bootable disk
+image of the gadget volume carrying the seed, in raw or qcow2 format,
+into the target directory.`),
 		func() flags.Commander { return &cmdPrepareImage{} },
 		map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
@@ -74,6 +93,10 @@ For preparing classic images it supports a --classic mode`),
 			"channel": i18n.G("The channel to use"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"customize": i18n.G("Image customizations specified as JSON file."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"verify-reproducible": i18n.G("Prepare the image twice and check that the results are identical"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"disk-image": i18n.G("Also assemble a bootable disk image in the given format (raw or qcow2)"),
 		}, []argDesc{
 			{
 				// TRANSLATORS: This needs to begin with < and end with >
@@ -131,8 +154,134 @@ func (x *cmdPrepareImage) Execute(args []string) error {
 
 	opts.PrepareDir = x.Positional.TargetDir
 	opts.Classic = x.Classic
+	opts.DiskImageFormat = x.DiskImage
 
-	return imagePrepare(opts)
+	// reproducible builds, see
+	// https://reproducible-builds.org/specs/source-date-epoch/
+	sourceDateEpoch, err := sourceDateEpochFromEnv()
+	if err != nil {
+		return err
+	}
+	opts.SourceDateEpoch = sourceDateEpoch
+
+	if !x.VerifyReproducible {
+		return imagePrepare(opts)
+	}
+	return prepareImageReproducibly(opts)
+}
+
+func sourceDateEpochFromEnv() (time.Time, error) {
+	v := os.Getenv("SOURCE_DATE_EPOCH")
+	if v == "" {
+		return time.Time{}, nil
+	}
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || secs < 0 {
+		return time.Time{}, fmt.Errorf(i18n.G("cannot use SOURCE_DATE_EPOCH %q: not a number of seconds since the epoch"), v)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// prepareImageReproducibly prepares the image as usual and then a second
+// time in a scratch directory, failing if the two differ.
+func prepareImageReproducibly(opts *image.Options) error {
+	if opts.SourceDateEpoch.IsZero() {
+		// both builds need to agree on the time
+		opts.SourceDateEpoch = timeNow().Truncate(time.Second).UTC()
+	}
+	if err := imagePrepare(opts); err != nil {
+		return err
+	}
+
+	scratchDir, err := ioutil.TempDir("", "snap-prepare-image-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratchDir)
+
+	opts2 := *opts
+	opts2.PrepareDir = scratchDir
+	if err := imagePrepare(&opts2); err != nil {
+		return fmt.Errorf(i18n.G("cannot prepare the image a second time: %v"), err)
+	}
+
+	if err := compareTrees(opts.PrepareDir, scratchDir); err != nil {
+		return fmt.Errorf(i18n.G("image is not reproducible: %v"), err)
+	}
+	fmt.Fprintln(Stdout, i18n.G("Image is reproducible."))
+	return nil
+}
+
+// compareTrees checks that the two given directory trees hold the same
+// files and directories with the same contents, permissions and
+// modification times, returning an error about the first difference.
+func compareTrees(dir1, dir2 string) error {
+	seen := make(map[string]bool)
+	err := filepath.Walk(dir1, func(path1 string, info1 os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir1, path1)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			// the target directories themselves can differ
+			return nil
+		}
+		seen[rel] = true
+		path2 := filepath.Join(dir2, rel)
+		info2, err := os.Lstat(path2)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("%q is only present in the first build", rel)
+			}
+			return err
+		}
+		if info1.Mode() != info2.Mode() {
+			return fmt.Errorf("%q has mode %v instead of %v", rel, info2.Mode(), info1.Mode())
+		}
+		switch {
+		case info1.Mode()&os.ModeSymlink != 0:
+			target1, err := os.Readlink(path1)
+			if err != nil {
+				return err
+			}
+			target2, err := os.Readlink(path2)
+			if err != nil {
+				return err
+			}
+			if target1 != target2 {
+				return fmt.Errorf("symlink %q points to %q instead of %q", rel, target2, target1)
+			}
+			// symlink modification times are not clamped
+			return nil
+		case info1.Mode().IsRegular():
+			if !osutil.FilesAreEqual(path1, path2) {
+				return fmt.Errorf("%q has different contents", rel)
+			}
+		}
+		if !info1.ModTime().Equal(info2.ModTime()) {
+			return fmt.Errorf("%q has modification time %v instead of %v", rel, info2.ModTime(), info1.ModTime())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return filepath.Walk(dir2, func(path2 string, info2 os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir2, path2)
+		if err != nil {
+			return err
+		}
+		if rel != "." && !seen[rel] {
+			return fmt.Errorf("%q is only present in the second build", rel)
+		}
+		return nil
+	})
 }
 
 func readImageCustomizations(customizationsFile string) (*image.Customizations, error) {
@@ -20,14 +20,17 @@
 package main_test
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "gopkg.in/check.v1"
 
 	snap "github.com/snapcore/snapd/cmd/snap"
 	"github.com/snapcore/snapd/image"
+	"github.com/snapcore/snapd/testutil"
 )
 
 type SnapPrepareImageSuite struct {
@@ -55,6 +58,37 @@ func (s *SnapPrepareImageSuite) TestPrepareImageCore(c *C) {
 	})
 }
 
+func (s *SnapPrepareImageSuite) TestPrepareImageDiskImage(c *C) {
+	var opts *image.Options
+	prep := func(o *image.Options) error {
+		opts = o
+		return nil
+	}
+	r := snap.MockImagePrepare(prep)
+	defer r()
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"prepare-image", "--disk-image=qcow2", "model", "prepare-dir"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+
+	c.Check(opts, DeepEquals, &image.Options{
+		ModelFile:       "model",
+		PrepareDir:      "prepare-dir",
+		DiskImageFormat: "qcow2",
+	})
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageDiskImageInvalidFormat(c *C) {
+	r := snap.MockImagePrepare(func(*image.Options) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer r()
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prepare-image", "--disk-image=vmdk", "model", "prepare-dir"})
+	c.Assert(err, ErrorMatches, `(?s)Invalid value .vmdk. for option .*--disk-image.*`)
+}
+
 func (s *SnapPrepareImageSuite) TestPrepareImageClassic(c *C) {
 	var opts *image.Options
 	prep := func(o *image.Options) error {
@@ -173,3 +207,114 @@ func (s *SnapPrepareImageSuite) TestPrepareImageCustomize(c *C) {
 		},
 	})
 }
+
+func (s *SnapPrepareImageSuite) TestPrepareImageSourceDateEpoch(c *C) {
+	var opts *image.Options
+	prep := func(o *image.Options) error {
+		opts = o
+		return nil
+	}
+	r := snap.MockImagePrepare(prep)
+	defer r()
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1590969600")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"prepare-image", "model", "prepare-dir"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+
+	c.Check(opts, DeepEquals, &image.Options{
+		ModelFile:       "model",
+		PrepareDir:      "prepare-dir",
+		SourceDateEpoch: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+	})
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageBadSourceDateEpoch(c *C) {
+	r := snap.MockImagePrepare(func(*image.Options) error {
+		c.Fatalf("unexpected call")
+		return nil
+	})
+	defer r()
+
+	os.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prepare-image", "model", "prepare-dir"})
+	c.Assert(err, ErrorMatches, `cannot use SOURCE_DATE_EPOCH "yesterday": not a number of seconds since the epoch`)
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageVerifyReproducible(c *C) {
+	now := time.Date(2020, 6, 1, 12, 30, 15, 500, time.UTC)
+	defer snap.MockTimeNow(func() time.Time { return now })()
+
+	var seen []*image.Options
+	prep := func(o *image.Options) error {
+		seen = append(seen, o)
+		p := filepath.Join(o.PrepareDir, "image", "seed.yaml")
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(p, []byte("snaps: []\n"), 0644); err != nil {
+			return err
+		}
+		for _, p := range []string{p, filepath.Dir(p)} {
+			if err := os.Chtimes(p, o.SourceDateEpoch, o.SourceDateEpoch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	r := snap.MockImagePrepare(prep)
+	defer r()
+
+	prepareDir := c.MkDir()
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"prepare-image", "--verify-reproducible", "model", prepareDir})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, "Image is reproducible.\n")
+
+	c.Assert(seen, HasLen, 2)
+	// both builds used the same time
+	c.Check(seen[0].SourceDateEpoch, Equals, time.Date(2020, 6, 1, 12, 30, 15, 0, time.UTC))
+	c.Check(seen[1].SourceDateEpoch, Equals, seen[0].SourceDateEpoch)
+	c.Check(seen[0].PrepareDir, Equals, prepareDir)
+	c.Check(seen[1].PrepareDir, Not(Equals), prepareDir)
+	// the scratch build was removed
+	c.Check(seen[1].PrepareDir, testutil.FileAbsent)
+	c.Check(filepath.Join(prepareDir, "image", "seed.yaml"), testutil.FilePresent)
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageVerifyReproducibleDiffers(c *C) {
+	n := 0
+	prep := func(o *image.Options) error {
+		n++
+		return ioutil.WriteFile(filepath.Join(o.PrepareDir, "seed.yaml"), []byte(fmt.Sprintf("build: %d\n", n)), 0644)
+	}
+	r := snap.MockImagePrepare(prep)
+	defer r()
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1590969600")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prepare-image", "--verify-reproducible", "model", c.MkDir()})
+	c.Assert(err, ErrorMatches, `image is not reproducible: "seed.yaml" has different contents`)
+	c.Check(n, Equals, 2)
+}
+
+func (s *SnapPrepareImageSuite) TestPrepareImageVerifyReproducibleExtraFile(c *C) {
+	n := 0
+	prep := func(o *image.Options) error {
+		n++
+		if n == 1 {
+			return nil
+		}
+		return ioutil.WriteFile(filepath.Join(o.PrepareDir, "extra"), nil, 0644)
+	}
+	r := snap.MockImagePrepare(prep)
+	defer r()
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prepare-image", "--verify-reproducible", "model", c.MkDir()})
+	c.Assert(err, ErrorMatches, `image is not reproducible: "extra" is only present in the second build`)
+}
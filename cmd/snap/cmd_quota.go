@@ -22,7 +22,9 @@ package main
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 
@@ -64,6 +66,16 @@ groups can be nested. Nested quota groups are subject to the restriction that
 the total sum of maximum memory in sub-groups cannot exceed that of the parent
 group the nested groups are part of.
 
+The processes of the snaps in a quota group can additionally be pinned to a set
+of CPUs given as a comma-separated list with --cpu-set, for example to isolate
+them from real-time workloads. The CPU set of a sub-group must be part of the
+CPU set of its parent group.
+
+The services of the snaps in a quota group can log to a dedicated journal
+namespace, so that they cannot fill the system journal. The disk space of the
+namespace is limited with --journal-size, and the number of messages logged in a
+given period with --journal-rate-limit, for example 100/10s.
+
 All provided snaps are appended to the group; to remove a snap from a
 quota group, the entire group must be removed with remove-quota and recreated 
 without the quota group. To remove a sub-group from the quota group, the 
@@ -99,19 +111,65 @@ func init() {
 type cmdSetQuota struct {
 	waitMixin
 
-	MemoryMax  string `long:"memory" optional:"true"`
-	Parent     string `long:"parent" optional:"true"`
-	Positional struct {
+	MemoryMax        string `long:"memory" optional:"true"`
+	CPUSet           string `long:"cpu-set" optional:"true"`
+	JournalSize      string `long:"journal-size" optional:"true"`
+	JournalRateLimit string `long:"journal-rate-limit" optional:"true"`
+	Parent           string `long:"parent" optional:"true"`
+	Positional       struct {
 		GroupName string              `positional-arg-name:"<group-name>" required:"true"`
 		Snaps     []installedSnapName `positional-arg-name:"<snap>" optional:"true"`
 	} `positional-args:"yes"`
 }
 
+// parseConstraints returns the constraints set with the options, or nil if
+// none was set.
+func (x *cmdSetQuota) parseConstraints() (*client.QuotaValues, error) {
+	if x.MemoryMax == "" && x.CPUSet == "" && x.JournalSize == "" && x.JournalRateLimit == "" {
+		return nil, nil
+	}
+
+	constraints := &client.QuotaValues{}
+	if x.MemoryMax != "" {
+		mem, err := strutil.ParseByteSize(x.MemoryMax)
+		if err != nil {
+			return nil, err
+		}
+		constraints.Memory = quantity.Size(mem)
+	}
+
+	cpuSet, err := parseCPUSet(x.CPUSet)
+	if err != nil {
+		return nil, err
+	}
+	constraints.CPUSet = cpuSet
+
+	if x.JournalSize != "" || x.JournalRateLimit != "" {
+		constraints.Journal = &client.QuotaJournalValues{}
+	}
+	if x.JournalSize != "" {
+		size, err := strutil.ParseByteSize(x.JournalSize)
+		if err != nil {
+			return nil, err
+		}
+		constraints.Journal.Size = quantity.Size(size)
+	}
+	if x.JournalRateLimit != "" {
+		count, period, err := parseJournalRateLimit(x.JournalRateLimit)
+		if err != nil {
+			return nil, err
+		}
+		constraints.Journal.RateCount = count
+		constraints.Journal.RatePeriod = period
+	}
+
+	return constraints, nil
+}
+
 func (x *cmdSetQuota) Execute(args []string) (err error) {
-	var maxMemory string
-	switch {
-	case x.MemoryMax != "":
-		maxMemory = x.MemoryMax
+	constraints, err := x.parseConstraints()
+	if err != nil {
+		return err
 	}
 
 	names := installedSnapNames(x.Positional.Snaps)
@@ -125,7 +183,7 @@ func (x *cmdSetQuota) Execute(args []string) (err error) {
 	var chgID string
 
 	switch {
-	case maxMemory == "" && x.Parent == "" && len(x.Positional.Snaps) == 0:
+	case constraints == nil && x.Parent == "" && len(x.Positional.Snaps) == 0:
 		// no snaps were specified, no memory limit was specified, and no parent
 		// was specified, so just the group name was provided - this is not
 		// supported since there is nothing to change/create
@@ -135,7 +193,7 @@ func (x *cmdSetQuota) Execute(args []string) (err error) {
 		}
 		return fmt.Errorf("cannot create quota group without memory limit")
 
-	case maxMemory == "" && x.Parent != "" && len(x.Positional.Snaps) == 0:
+	case constraints == nil && x.Parent != "" && len(x.Positional.Snaps) == 0:
 		// this is either trying to create a new group with a parent and forgot
 		// to specify the memory limit for the new group, or the user is trying
 		// to re-parent a group, i.e. move it from the current parent to a
@@ -149,22 +207,17 @@ func (x *cmdSetQuota) Execute(args []string) (err error) {
 		}
 		return fmt.Errorf("cannot create quota group without memory limit")
 
-	case maxMemory != "":
-		// we have a memory limit to set for this group, so specify that along
+	case constraints != nil:
+		// we have constraints to set for this group, so specify them along
 		// with whatever snaps may have been provided and whatever parent may
 		// have been specified
 
-		mem, err := strutil.ParseByteSize(maxMemory)
-		if err != nil {
-			return err
-		}
-
 		// note that the group could currently exist with a parent, and we could
 		// be specifying x.Parent as "" here - in the future that may mean to
 		// orphan a sub-group to no longer have a parent, but currently it just
 		// means leave the group with whatever parent it has, or if it doesn't
 		// currently exist, create the group without a parent group
-		chgID, err = x.client.EnsureQuota(x.Positional.GroupName, x.Parent, names, quantity.Size(mem))
+		chgID, err = x.client.EnsureQuota(x.Positional.GroupName, x.Parent, names, constraints)
 		if err != nil {
 			return err
 		}
@@ -178,7 +231,7 @@ func (x *cmdSetQuota) Execute(args []string) (err error) {
 		// currently support that, so currently all snaps specified here are
 		// just added to the group
 
-		chgID, err = x.client.EnsureQuota(x.Positional.GroupName, x.Parent, names, 0)
+		chgID, err = x.client.EnsureQuota(x.Positional.GroupName, x.Parent, names, nil)
 		if err != nil {
 			return err
 		}
@@ -197,6 +250,50 @@ func (x *cmdSetQuota) Execute(args []string) (err error) {
 	return nil
 }
 
+// parseCPUSet parses a comma-separated list of CPU numbers.
+func parseCPUSet(cpuSet string) ([]int, error) {
+	if cpuSet == "" {
+		return nil, nil
+	}
+	var cpus []int
+	for _, s := range strings.Split(cpuSet, ",") {
+		cpu, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || cpu < 0 {
+			return nil, fmt.Errorf("cannot parse CPU set %q: invalid CPU %q", cpuSet, s)
+		}
+		cpus = append(cpus, cpu)
+	}
+	return cpus, nil
+}
+
+// parseJournalRateLimit parses a journal rate limit of the form
+// <count>/<period>, as in 100/10s.
+func parseJournalRateLimit(rateLimit string) (count int, period time.Duration, err error) {
+	parts := strings.Split(rateLimit, "/")
+	if len(parts) == 2 {
+		count, err = strconv.Atoi(parts[0])
+		if err == nil {
+			period, err = time.ParseDuration(parts[1])
+		}
+	}
+	if len(parts) != 2 || err != nil || count <= 0 || period <= 0 {
+		return 0, 0, fmt.Errorf("cannot parse journal rate limit %q: expected <count>/<period>, as in 100/10s", rateLimit)
+	}
+	return count, period, nil
+}
+
+func fmtJournalRateLimit(journal *client.QuotaJournalValues) string {
+	return fmt.Sprintf("%d/%s", journal.RateCount, journal.RatePeriod)
+}
+
+func fmtCPUSet(cpus []int) string {
+	strs := make([]string, len(cpus))
+	for i, cpu := range cpus {
+		strs[i] = strconv.Itoa(cpu)
+	}
+	return strings.Join(strs, ",")
+}
+
 type cmdQuota struct {
 	clientMixin
 
@@ -232,6 +329,17 @@ func (x *cmdQuota) Execute(args []string) (err error) {
 	}
 	val := strings.TrimSpace(fmtSize(int64(group.Constraints.Memory)))
 	fmt.Fprintf(w, "  memory:\t%s\n", val)
+	if len(group.Constraints.CPUSet) > 0 {
+		fmt.Fprintf(w, "  cpu-set:\t%s\n", fmtCPUSet(group.Constraints.CPUSet))
+	}
+	if journal := group.Constraints.Journal; journal != nil {
+		if journal.Size != 0 {
+			fmt.Fprintf(w, "  journal-size:\t%s\n", strings.TrimSpace(fmtSize(int64(journal.Size))))
+		}
+		if journal.RateCount != 0 {
+			fmt.Fprintf(w, "  journal-rate-limit:\t%s\n", fmtJournalRateLimit(journal))
+		}
+	}
 
 	fmt.Fprintf(w, "current:\n")
 	if group.Current == nil {
@@ -306,6 +414,17 @@ func (x *cmdQuotas) Execute(args []string) (err error) {
 		}
 
 		constraintVal := "memory=" + strings.TrimSpace(fmtSize(int64(q.Constraints.Memory)))
+		if len(q.Constraints.CPUSet) > 0 {
+			constraintVal += ",cpu-set=" + fmtCPUSet(q.Constraints.CPUSet)
+		}
+		if journal := q.Constraints.Journal; journal != nil {
+			if journal.Size != 0 {
+				constraintVal += ",journal-size=" + strings.TrimSpace(fmtSize(int64(journal.Size)))
+			}
+			if journal.RateCount != 0 {
+				constraintVal += ",journal-rate-limit=" + fmtJournalRateLimit(journal)
+			}
+		}
 		currentVal := ""
 		if q.Current != nil && q.Current.Memory != 0 {
 			currentVal = "memory=" + strings.TrimSpace(fmtSize(int64(q.Current.Memory)))
@@ -100,6 +100,8 @@ type fakeQuotaGroupPostHandlerOpts struct {
 	parentName string
 	snaps      []string
 	maxMemory  int64
+	cpuSet     []int
+	journal    map[string]interface{}
 }
 
 type quotasEnsureBody struct {
@@ -137,6 +139,20 @@ func makeFakeQuotaPostHandler(c *check.C, opts fakeQuotaGroupPostHandlerOpts) fu
 			if opts.maxMemory != 0 {
 				exp.Constraints["memory"] = json.Number(fmt.Sprintf("%d", opts.maxMemory))
 			}
+			if len(opts.cpuSet) != 0 {
+				var cpus []interface{}
+				for _, cpu := range opts.cpuSet {
+					cpus = append(cpus, json.Number(fmt.Sprintf("%d", cpu)))
+				}
+				exp.Constraints["cpu-set"] = cpus
+			}
+			if opts.journal != nil {
+				exp.Constraints["journal"] = opts.journal
+			}
+			if len(exp.Constraints) == 0 {
+				// no constraints are sent when only adding snaps
+				exp.Constraints = nil
+			}
 
 			postJSON := quotasEnsureBody{}
 			err := jsonutil.DecodeWithNumber(bytes.NewReader(buf), &postJSON)
@@ -178,6 +194,12 @@ func (s *quotaSuite) TestSetQuotaInvalidArgs(c *check.C) {
 		{[]string{"set-quota", "--memory=99B"}, "the required argument `<group-name>` was not provided"},
 		{[]string{"set-quota", "--memory=99", "foo"}, `cannot parse "99": need a number with a unit as input`},
 		{[]string{"set-quota", "--memory=888X", "foo"}, `cannot parse "888X\": try 'kB' or 'MB'`},
+		{[]string{"set-quota", "--memory=1MB", "--cpu-set=0,x", "foo"}, `cannot parse CPU set "0,x": invalid CPU "x"`},
+		{[]string{"set-quota", "--memory=1MB", "--cpu-set=-1", "foo"}, `cannot parse CPU set "-1": invalid CPU "-1"`},
+		{[]string{"set-quota", "--journal-size=12", "foo"}, `cannot parse "12": need a number with a unit as input`},
+		{[]string{"set-quota", "--journal-rate-limit=10", "foo"}, `cannot parse journal rate limit "10": expected <count>/<period>, as in 100/10s`},
+		{[]string{"set-quota", "--journal-rate-limit=x/1s", "foo"}, `cannot parse journal rate limit "x/1s": expected <count>/<period>, as in 100/10s`},
+		{[]string{"set-quota", "--journal-rate-limit=10/0s", "foo"}, `cannot parse journal rate limit "10/0s": expected <count>/<period>, as in 100/10s`},
 		// remove-quota command
 		{[]string{"remove-quota"}, "the required argument `<group-name>` was not provided"},
 	} {
@@ -227,6 +249,42 @@ snaps:
 `[1:])
 }
 
+func (s *quotaSuite) TestGetQuotaGroupAllConstraints(c *check.C) {
+	restore := main.MockIsStdinTTY(true)
+	defer restore()
+
+	const json = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"group-name":"foo",
+			"constraints": {
+				"memory": 1000,
+				"cpu-set": [0, 2],
+				"journal": {"size": 64000000, "rate-count": 100, "rate-period": 10000000000}
+			},
+			"current": { "memory": 900 }
+		}
+	}`
+
+	s.RedirectClientToTestServer(makeFakeGetQuotaGroupHandler(c, json))
+
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"quota", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `
+name:  foo
+constraints:
+  memory:              1000B
+  cpu-set:             0,2
+  journal-size:        64.0MB
+  journal-rate-limit:  100/10s
+current:
+  memory:  900B
+`[1:])
+}
+
 func (s *quotaSuite) TestGetQuotaGroupSimple(c *check.C) {
 	restore := main.MockIsStdinTTY(true)
 	defer restore()
@@ -300,6 +358,72 @@ func (s *quotaSuite) TestSetQuotaGroupCreateNew(c *check.C) {
 	c.Check(s.Stdout(), check.Equals, "")
 }
 
+func (s *quotaSuite) TestSetQuotaGroupCreateNewWithCPUSet(c *check.C) {
+	const postJSON = `{"type": "async", "status-code": 202,"change":"42", "result": []}`
+	fakeHandlerOpts := fakeQuotaGroupPostHandlerOpts{
+		action:    "ensure",
+		body:      postJSON,
+		groupName: "foo",
+		snaps:     []string{"snap-a"},
+		maxMemory: 999,
+		cpuSet:    []int{0, 2},
+	}
+
+	routes := map[string]http.HandlerFunc{
+		"/v2/quotas": makeFakeQuotaPostHandler(
+			c,
+			fakeHandlerOpts,
+		),
+		"/v2/quotas/foo": makeFakeGetQuotaGroupNotFoundHandler(c, "foo"),
+
+		"/v2/changes/42": makeChangesHandler(c),
+	}
+
+	s.RedirectClientToTestServer(dispatchFakeHandlers(c, routes))
+
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"set-quota", "foo", "--memory=999B", "--cpu-set=0,2", "snap-a"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, "")
+}
+
+func (s *quotaSuite) TestSetQuotaGroupUpdateJournal(c *check.C) {
+	const postJSON = `{"type": "async", "status-code": 202,"change":"42", "result": []}`
+	fakeHandlerOpts := fakeQuotaGroupPostHandlerOpts{
+		action:    "ensure",
+		body:      postJSON,
+		groupName: "foo",
+		journal: map[string]interface{}{
+			"size":        json.Number("64000000"),
+			"rate-count":  json.Number("100"),
+			"rate-period": json.Number("10000000000"),
+		},
+	}
+
+	routes := map[string]http.HandlerFunc{
+		"/v2/quotas": makeFakeQuotaPostHandler(
+			c,
+			fakeHandlerOpts,
+		),
+		"/v2/quotas/foo": makeFakeGetQuotaGroupHandler(c, `{
+			"type": "sync",
+			"status-code": 200,
+			"result": {"group-name":"foo", "constraints": {"memory": 1000}}
+		}`),
+
+		"/v2/changes/42": makeChangesHandler(c),
+	}
+
+	s.RedirectClientToTestServer(dispatchFakeHandlers(c, routes))
+
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"set-quota", "foo", "--journal-size=64MB", "--journal-rate-limit=100/10s"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, "")
+}
+
 func (s *quotaSuite) TestSetQuotaGroupUpdateExistingUnhappy(c *check.C) {
 	const exists = true
 	s.testSetQuotaGroupUpdateExistingUnhappy(c, "no options set to change quota group", exists)
@@ -58,10 +58,15 @@ func init() {
 }
 
 func notesForSystem(sys *client.System) string {
+	var notes []string
 	if sys.Current {
-		return "current"
+		notes = append(notes, "current")
 	}
-	return "-"
+	notes = append(notes, sys.Tags...)
+	if len(notes) == 0 {
+		return "-"
+	}
+	return strings.Join(notes, ",")
 }
 
 func (x *cmdRecovery) showKeys(w io.Writer) error {
@@ -100,7 +105,19 @@ func (x *cmdRecovery) Execute(args []string) error {
 		return nil
 	}
 
-	fmt.Fprintf(w, i18n.G("Label\tBrand%s\tModel\tNotes\n"), fillerPublisher(esc))
+	withDescription := false
+	for _, sys := range systems {
+		if sys.Description != "" {
+			withDescription = true
+			break
+		}
+	}
+
+	if withDescription {
+		fmt.Fprintf(w, i18n.G("Label\tBrand%s\tModel\tNotes\tDescription\n"), fillerPublisher(esc))
+	} else {
+		fmt.Fprintf(w, i18n.G("Label\tBrand%s\tModel\tNotes\n"), fillerPublisher(esc))
+	}
 	for _, sys := range systems {
 		// doing it this way because otherwise it's a sea of %s\t%s\t%s
 		line := []string{
@@ -109,6 +126,13 @@ func (x *cmdRecovery) Execute(args []string) error {
 			sys.Model.Model,
 			notesForSystem(&sys),
 		}
+		if withDescription {
+			description := sys.Description
+			if description == "" {
+				description = "-"
+			}
+			line = append(line, description)
+		}
 		fmt.Fprintln(w, strings.Join(line, "\t"))
 	}
 
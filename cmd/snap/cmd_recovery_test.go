@@ -113,6 +113,54 @@ Label     Brand    Model       Notes
 	c.Check(s.Stderr(), Equals, "")
 }
 
+func (s *SnapSuite) TestRecoveryWithMetadata(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, Equals, "GET")
+			c.Check(r.URL.Path, Equals, "/v2/systems")
+			fmt.Fprintln(w, `{"type": "sync", "result": {
+        "systems": [
+           {
+                "current": true,
+                "label": "20200101",
+                "model": {"model": "model-id-1", "brand-id": "brand-id-1"},
+                "brand": {"id": "brand-id-1", "username": "brand-1"}
+           },
+           {
+                "label": "pre-upgrade",
+                "model": {"model": "model-id-1", "brand-id": "brand-id-1"},
+                "brand": {"id": "brand-id-1", "username": "brand-1"},
+                "description": "Before the June upgrade",
+                "tags": ["manual", "pre-upgrade"]
+           },
+           {
+                "label": "20200802",
+                "model": {"model": "model-id-1", "brand-id": "brand-id-1"},
+                "brand": {"id": "brand-id-1", "username": "brand-1"},
+                "tags": ["auto"]
+           }
+        ]
+}}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"recovery"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `
+Label        Brand    Model       Notes               Description
+20200101     brand-1  model-id-1  current             -
+pre-upgrade  brand-1  model-id-1  manual,pre-upgrade  Before the June upgrade
+20200802     brand-1  model-id-1  auto                -
+`[1:])
+	c.Check(s.Stderr(), Equals, "")
+}
+
 func (s *SnapSuite) TestNoRecoverySystems(c *C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -25,6 +25,7 @@ import (
 
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
 )
 
@@ -36,11 +37,19 @@ revision or a full new model.
 
 In the process it applies any implied changes to the device: new required
 snaps, new kernel or gadget etc.
+
+With --dry-run the device is not remodeled, instead the snaps and assertions
+the remodel would need, and whether it would reboot the device, are shown.
+
+With --queue the remodel is started at the next maintenance window, that is
+after the next automatic refresh, or on the next boot, whichever comes first.
 `)
 )
 
 type cmdRemodel struct {
 	waitMixin
+	DryRun         bool `long:"dry-run"`
+	Queue          bool `long:"queue"`
 	RemodelOptions struct {
 		NewModelFile flags.Filename
 	} `positional-args:"true" required:"true"`
@@ -52,7 +61,12 @@ func init() {
 		longRemodelHelp,
 		func() flags.Commander {
 			return &cmdRemodel{}
-		}, nil, []argDesc{{
+		}, waitDescs.also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"dry-run": i18n.G("Show what the remodel would do without remodeling"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"queue": i18n.G("Remodel at the next maintenance window or reboot"),
+		}), []argDesc{{
 			// TRANSLATORS: This needs to begin with < and end with >
 			name: i18n.G("<new model file>"),
 			// TRANSLATORS: This should not start with a lowercase letter.
@@ -65,11 +79,29 @@ func (x *cmdRemodel) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
+	if x.DryRun && x.Queue {
+		return fmt.Errorf(i18n.G("cannot use --dry-run and --queue together"))
+	}
 	newModelFile := x.RemodelOptions.NewModelFile
 	modelData, err := ioutil.ReadFile(string(newModelFile))
 	if err != nil {
 		return err
 	}
+	if x.Queue {
+		if err := x.client.QueueRemodel(modelData); err != nil {
+			return fmt.Errorf("cannot remodel: %v", err)
+		}
+		fmt.Fprintf(Stdout, i18n.G("Remodel to %s queued\n"), newModelFile)
+		return nil
+	}
+	if x.DryRun {
+		plan, err := x.client.PlanRemodel(modelData)
+		if err != nil {
+			return fmt.Errorf("cannot remodel: %v", err)
+		}
+		x.showPlan(plan)
+		return nil
+	}
 	changeID, err := x.client.Remodel(modelData)
 	if err != nil {
 		return fmt.Errorf("cannot remodel: %v", err)
@@ -84,3 +116,46 @@ func (x *cmdRemodel) Execute(args []string) error {
 	fmt.Fprintf(Stdout, i18n.G("New model %s set\n"), newModelFile)
 	return nil
 }
+
+func (x *cmdRemodel) showPlan(plan *client.RemodelPlan) {
+	fmt.Fprintf(Stdout, i18n.G("Kind: %s\n"), plan.Kind)
+	showSnaps := func(header string, snaps []client.RemodelPlanSnap) {
+		if len(snaps) == 0 {
+			return
+		}
+		fmt.Fprintf(Stdout, "%s:\n", header)
+		for _, sn := range snaps {
+			line := sn.Name
+			if sn.Channel != "" {
+				line += fmt.Sprintf(" (%s)", sn.Channel)
+			}
+			if sn.Replaces != "" {
+				line += fmt.Sprintf(i18n.G(", replacing %s"), sn.Replaces)
+			}
+			fmt.Fprintf(Stdout, "  %s\n", line)
+		}
+	}
+	showNames := func(header string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		fmt.Fprintf(Stdout, "%s:\n", header)
+		for _, name := range names {
+			fmt.Fprintf(Stdout, "  %s\n", name)
+		}
+	}
+	showSnaps(i18n.G("Install"), plan.Install)
+	showSnaps(i18n.G("Switch"), plan.Switch)
+	showNames(i18n.G("Remove"), plan.Remove)
+	showNames(i18n.G("No longer required"), plan.NoLongerRequired)
+	showNames(i18n.G("Assertions"), plan.Assertions)
+	if plan.RecoverySystem {
+		fmt.Fprintln(Stdout, i18n.G("A new recovery system would be created."))
+	}
+	if plan.Reseal {
+		fmt.Fprintln(Stdout, i18n.G("Disk encryption keys would be resealed."))
+	}
+	if plan.Reboot {
+		fmt.Fprintln(Stdout, i18n.G("The device would be rebooted."))
+	}
+}
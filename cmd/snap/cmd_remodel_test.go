@@ -0,0 +1,119 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestRemodelDryRun(c *check.C) {
+	modelFile := filepath.Join(c.MkDir(), "new-model")
+	c.Assert(ioutil.WriteFile(modelFile, []byte("new-model-assertion"), 0644), check.IsNil)
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/model")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"new-model": "new-model-assertion",
+			"dry-run":   true,
+		})
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+			"kind": "revision update remodel",
+			"install": [{"name": "new-snap", "replaces": "old-snap"}],
+			"switch": [{"name": "pc-kernel", "channel": "20"}],
+			"remove": ["old-snap"],
+			"no-longer-required": ["some-snap"],
+			"assertions": ["model my-brand/my-model (2)", "snap-declaration of new-snap", "snap-revision of new-snap"],
+			"reboot": true,
+			"reseal": false
+		}}`)
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"remodel", "--dry-run", modelFile})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, `Kind: revision update remodel
+Install:
+  new-snap, replacing old-snap
+Switch:
+  pc-kernel (20)
+Remove:
+  old-snap
+No longer required:
+  some-snap
+Assertions:
+  model my-brand/my-model (2)
+  snap-declaration of new-snap
+  snap-revision of new-snap
+The device would be rebooted.
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestRemodelDryRunError(c *check.C) {
+	modelFile := filepath.Join(c.MkDir(), "new-model")
+	c.Assert(ioutil.WriteFile(modelFile, []byte("new-model-assertion"), 0644), check.IsNil)
+
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		fmt.Fprintln(w, `{"type": "error", "result": {"message": "cannot remodel device: cannot remodel until fully seeded"}}`)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"remodel", "--dry-run", modelFile})
+	c.Assert(err, check.ErrorMatches, "cannot remodel: cannot remodel device: cannot remodel until fully seeded")
+}
+
+func (s *SnapSuite) TestRemodelQueue(c *check.C) {
+	modelFile := filepath.Join(c.MkDir(), "new-model")
+	c.Assert(ioutil.WriteFile(modelFile, []byte("new-model-assertion"), 0644), check.IsNil)
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/model")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"new-model": "new-model-assertion",
+			"queue":     true,
+		})
+		fmt.Fprintln(w, `{"type": "sync", "result": null}`)
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"remodel", "--queue", modelFile})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, fmt.Sprintf("Remodel to %s queued\n", modelFile))
+}
+
+func (s *SnapSuite) TestRemodelQueueDryRun(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"remodel", "--queue", "--dry-run", "some-file"})
+	c.Assert(err, check.ErrorMatches, "cannot use --dry-run and --queue together")
+}
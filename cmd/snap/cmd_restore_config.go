@@ -0,0 +1,100 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/jsonutil"
+)
+
+var shortRestoreConfigHelp = i18n.G("Restore the configuration of a snap")
+var longRestoreConfigHelp = i18n.G(`
+The restore-config command replaces the configuration of a snap with the
+one saved by save-config, possibly on another device. Options that are not
+part of the saved configuration are unset.
+
+The configuration is applied at once, and only after the snap's
+configuration hook returns successfully. Use "-" to read the saved
+configuration from standard input.
+`)
+
+type cmdRestoreConfig struct {
+	waitMixin
+	Positional struct {
+		File flags.Filename
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addCommand("restore-config", shortRestoreConfigHelp, longRestoreConfigHelp, func() flags.Commander { return &cmdRestoreConfig{} },
+		waitDescs, []argDesc{{
+			// TRANSLATORS: This needs to begin with < and end with >
+			name: i18n.G("<file>"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			desc: i18n.G("The configuration saved with save-config"),
+		}})
+}
+
+func (x *cmdRestoreConfig) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	var data []byte
+	var err error
+	if x.Positional.File == "-" {
+		data, err = ioutil.ReadAll(Stdin)
+	} else {
+		data, err = ioutil.ReadFile(string(x.Positional.File))
+	}
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot read configuration: %v"), err)
+	}
+
+	var export client.ConfExport
+	if err := jsonutil.DecodeWithNumber(bytes.NewReader(data), &export); err != nil {
+		return fmt.Errorf(i18n.G("cannot parse configuration: %v"), err)
+	}
+	if export.Snap == "" {
+		return fmt.Errorf(i18n.G("cannot restore configuration: no snap name in %q"), x.Positional.File)
+	}
+
+	id, err := x.client.ImportConf(&export)
+	if err != nil {
+		return err
+	}
+
+	if _, err := x.wait(id); err != nil {
+		if err == noWait {
+			return nil
+		}
+		return err
+	}
+
+	fmt.Fprintf(Stdout, i18n.G("Configuration of %q restored\n"), export.Snap)
+	return nil
+}
@@ -0,0 +1,104 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) mockRestoreConfigServer(c *check.C, calls *int) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snaps/snapname/conf":
+			c.Check(r.Method, check.Equals, "POST")
+			body := DecodedRequestBody(c, r)
+			c.Check(body["action"], check.Equals, "import")
+			export := body["export"].(map[string]interface{})
+			c.Check(export["snap"], check.Equals, "snapname")
+			c.Check(export["revision"], check.Equals, "7")
+			// large integers are preserved
+			c.Check(export["config"], check.DeepEquals, map[string]interface{}{
+				"key":  "value",
+				"port": json.Number("1234567890"),
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "zzz"}`)
+			*calls++
+		case "/v2/changes/zzz":
+			c.Check(r.Method, check.Equals, "GET")
+			fmt.Fprintln(w, `{"type":"sync", "result":{"ready": true, "status": "Done"}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+}
+
+func (s *SnapSuite) TestRestoreConfig(c *check.C) {
+	var calls int
+	s.mockRestoreConfigServer(c, &calls)
+
+	saved := filepath.Join(c.MkDir(), "snapname.json")
+	c.Assert(ioutil.WriteFile(saved, []byte(savedConfig), 0644), check.IsNil)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"restore-config", saved})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(calls, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, "Configuration of \"snapname\" restored\n")
+}
+
+func (s *SnapSuite) TestRestoreConfigStdin(c *check.C) {
+	var calls int
+	s.mockRestoreConfigServer(c, &calls)
+
+	s.stdin.WriteString(savedConfig)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"restore-config", "-"})
+	c.Assert(err, check.IsNil)
+	c.Check(calls, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestRestoreConfigErrors(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request %q", r.URL.Path)
+	})
+
+	dir := c.MkDir()
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"restore-config", filepath.Join(dir, "missing.json")})
+	c.Check(err, check.ErrorMatches, "cannot read configuration: .*")
+
+	bad := filepath.Join(dir, "bad.json")
+	c.Assert(ioutil.WriteFile(bad, []byte("{"), 0644), check.IsNil)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"restore-config", bad})
+	c.Check(err, check.ErrorMatches, "cannot parse configuration: .*")
+
+	noSnap := filepath.Join(dir, "no-snap.json")
+	c.Assert(ioutil.WriteFile(noSnap, []byte(`{"format": 1, "config": {}}`), 0644), check.IsNil)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"restore-config", noSnap})
+	c.Check(err, check.ErrorMatches, `cannot restore configuration: no snap name in ".*/no-snap.json"`)
+}
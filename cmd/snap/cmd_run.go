@@ -81,11 +81,18 @@ type cmdRun struct {
 	Gdbserver             string `long:"gdbserver" default:"no-gdbserver" optional-value:":0" optional:"true"`
 	ExperimentalGdbserver string `long:"experimental-gdbserver" default:"no-gdbserver" optional-value:":0" optional:"true" hidden:"yes"`
 	TraceExec             bool   `long:"trace-exec"`
+	// DebugTool selects any of the above, or perf, in a uniform way
+	DebugTool string `long:"debug-tool" value-name:"<tool>[:<options>]"`
 
 	// not a real option, used to check if cmdRun is initialized by
 	// the parser
 	ParserRan int    `long:"parser-ran" default:"1" hidden:"yes"`
 	Timer     string `long:"timer" hidden:"yes"`
+
+	// launch cache of the snap revision being run
+	cache *runCache
+	// options for perf, set through --debug-tool
+	perf string
 }
 
 func init() {
@@ -117,6 +124,8 @@ and environment.
 			"timer": i18n.G("Run as a timer service with given schedule"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"trace-exec": i18n.G("Display exec calls timing data"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"debug-tool": i18n.G("Run the command under one of gdb, gdbserver, perf, strace or trace-exec, optionally followed by a colon and options for the tool"),
 			"parser-ran": "",
 		}, nil)
 }
@@ -141,11 +150,18 @@ func isStopping() (bool, error) {
 	return false, err
 }
 
-func maybeWaitForSecurityProfileRegeneration(cli *client.Client) error {
+func maybeWaitForSecurityProfileRegeneration(cli *client.Client, cache *runCache) error {
+	// the profiles were found to be up to date by an earlier launch and
+	// snapd did not re-generate them since, computing the system-key is
+	// comparatively expensive so skip it
+	if cache.systemKeyChecked() {
+		return nil
+	}
 	// check if the security profiles key has changed, if so, we need
 	// to wait for snapd to re-generate all profiles
 	mismatch, err := interfaces.SystemKeyMismatch()
 	if err == nil && !mismatch {
+		cache.recordSystemKeyChecked()
 		return nil
 	}
 	// something went wrong with the system-key compare, try to
@@ -228,7 +244,12 @@ func (x *cmdRun) Execute(args []string) error {
 		return fmt.Errorf(i18n.G("too many arguments for hook %q: %s"), x.HookName, strings.Join(args, " "))
 	}
 
-	if err := maybeWaitForSecurityProfileRegeneration(x.client); err != nil {
+	x.cache = x.loadRunCache(snapApp)
+	if err := x.applyDebugTool(); err != nil {
+		return err
+	}
+
+	if err := maybeWaitForSecurityProfileRegeneration(x.client, x.cache); err != nil {
 		return err
 	}
 
@@ -248,6 +269,63 @@ func (x *cmdRun) Execute(args []string) error {
 	return x.snapRunApp(snapApp, args)
 }
 
+// applyDebugTool translates --debug-tool into the options specific to the
+// selected tool.
+func (x *cmdRun) applyDebugTool() error {
+	if x.DebugTool == "" {
+		return nil
+	}
+	if x.useStrace() || x.Gdb || x.useGdbserver() || x.TraceExec {
+		return fmt.Errorf(i18n.G("cannot use --debug-tool together with --strace, --gdb, --gdbserver or --trace-exec"))
+	}
+
+	tool, opts := x.DebugTool, ""
+	if idx := strings.IndexRune(tool, ':'); idx >= 0 {
+		tool, opts = tool[:idx], tool[idx+1:]
+	}
+	switch tool {
+	case "strace":
+		x.Strace = "with-strace"
+		if opts != "" {
+			x.Strace = opts
+		}
+	case "gdbserver":
+		x.Gdbserver = ":0"
+		if opts != "" {
+			x.Gdbserver = opts
+		}
+	case "perf":
+		x.perf = "with-perf"
+		if opts != "" {
+			x.perf = opts
+		}
+	case "gdb", "trace-exec":
+		if opts != "" {
+			// TRANSLATORS: %q is the name of a debugging tool
+			return fmt.Errorf(i18n.G("debug tool %q does not take options"), tool)
+		}
+		x.Gdb = tool == "gdb"
+		x.TraceExec = tool == "trace-exec"
+	default:
+		// TRANSLATORS: %q is the name of a debugging tool, %s a comma-separated list of tools
+		return fmt.Errorf(i18n.G("unknown debug tool %q, expected one of: %s"), tool, strings.Join(client.DebugTools, ", "))
+	}
+	return nil
+}
+
+// loadRunCache loads the launch cache of the snap revision that is about to
+// be run.
+func (x *cmdRun) loadRunCache(snapApp string) *runCache {
+	if x.HookName != "" && x.Revision != "unset" && x.Revision != "" {
+		revision, err := snap.ParseRevision(x.Revision)
+		if err != nil {
+			return &runCache{}
+		}
+		return loadRunCache(snapApp, revision)
+	}
+	return loadRunCacheForApp(snapApp)
+}
+
 // antialias changes snapApp and args if snapApp is actually an alias
 // for something else. If not, or if the args aren't what's expected
 // for completion, it returns them unchanged.
@@ -899,6 +977,42 @@ func (x *cmdRun) runCmdWithTraceExec(origCmd []string, envForExec envForExecFunc
 	return err
 }
 
+func (x *cmdRun) usePerf() bool {
+	return x.perf != ""
+}
+
+func (x *cmdRun) runCmdUnderPerf(origCmd []string, envForExec envForExecFunc) error {
+	var perfOpts []string
+	if x.perf != "with-perf" {
+		var err error
+		perfOpts, err = shlex.Split(x.perf)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := exec.LookPath("perf"); err != nil {
+		return fmt.Errorf(i18n.G("please install perf on your system"))
+	}
+	u, err := userCurrent()
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot get the current user: %s"), err)
+	}
+
+	// perf needs to run as root to follow the application through
+	// snap-confine, the application itself keeps running as the user
+	cmd := []string{"sudo", "-E", "perf", "record"}
+	cmd = append(cmd, perfOpts...)
+	cmd = append(cmd, "--", "sudo", "-E", "-u", "#"+u.Uid, "--")
+	cmd = append(cmd, origCmd...)
+
+	pcmd := exec.Command(cmd[0], cmd[1:]...)
+	pcmd.Stdin = Stdin
+	pcmd.Stdout = Stdout
+	pcmd.Stderr = Stderr
+	pcmd.Env = envForExec(nil)
+	return pcmd.Run()
+}
+
 func (x *cmdRun) runCmdUnderStrace(origCmd []string, envForExec envForExecFunc) error {
 	extraStraceOpts, raw, err := x.straceOpts()
 	if err != nil {
@@ -998,9 +1112,18 @@ func (x *cmdRun) runSnapConfine(info *snap.Info, securityTag, snapApp, hook stri
 		return fmt.Errorf(i18n.G("missing snap-confine: try updating your core/snapd package"))
 	}
 
-	if err := createUserDataDirs(info); err != nil {
-		logger.Noticef("WARNING: cannot create user data directory: %s", err)
+	if x.cache == nil {
+		x.cache = &runCache{}
 	}
+	if !x.cache.userDataReady(info) {
+		if err := createUserDataDirs(info); err != nil {
+			logger.Noticef("WARNING: cannot create user data directory: %s", err)
+		} else {
+			x.cache.recordUserDataReady(info)
+		}
+	}
+	// the cache is saved before exec-ing snap-confine
+	x.cache.save()
 
 	xauthPath, err := migrateXauthority(info)
 	if err != nil {
@@ -1156,6 +1279,11 @@ func (x *cmdRun) runSnapConfine(info *snap.Info, securityTag, snapApp, hook stri
 	var trackingErr error
 	if needsTracking {
 		opts := &cgroup.TrackingOptions{AllowSessionBus: allowSessionBus}
+		if hookInfo := info.Hooks[hook]; hookInfo != nil && hookInfo.Limits != nil {
+			// enforce the resource limits of the hook through its scope
+			opts.MemoryMax = uint64(hookInfo.Limits.Memory)
+			opts.CPUQuota = hookInfo.Limits.CPUPercentage
+		}
 		trackingErr = cgroupCreateTransientScopeForTracking(securityTag, opts)
 	} else {
 		trackingErr = cgroupConfirmSystemdServiceTracking(securityTag)
@@ -1188,6 +1316,8 @@ func (x *cmdRun) runSnapConfine(info *snap.Info, securityTag, snapApp, hook stri
 		return x.runCmdUnderGdbserver(cmd, envForExec)
 	} else if x.useStrace() {
 		return x.runCmdUnderStrace(cmd, envForExec)
+	} else if x.usePerf() {
+		return x.runCmdUnderPerf(cmd, envForExec)
 	} else {
 		return syscallExec(cmd[0], cmd, envForExec(nil))
 	}
@@ -0,0 +1,214 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snapdtool"
+)
+
+// runCacheEntry records the outcome of the checks "snap run" performed when a
+// given revision of a snap was last launched by the current user. Repeated
+// launches of the same revision use it to skip the checks that are known to
+// have passed already.
+type runCacheEntry struct {
+	// SystemKeyStamp identifies the system-key file that was found to
+	// match the running system.
+	SystemKeyStamp string `json:"system-key-stamp,omitempty"`
+	// UserDataReady is set once the per-user data directories of the
+	// snap revision were created.
+	UserDataReady bool `json:"user-data-ready,omitempty"`
+}
+
+// runCache is the launch cache of one revision of a snap.
+//
+// The cache lives in $XDG_RUNTIME_DIR so that it is private to the user and
+// is cleared over full logout/login or reboot cycles. Failing to load or save
+// the cache is never fatal, "snap run" then performs all the checks as usual.
+type runCache struct {
+	instanceName string
+	revision     snap.Revision
+	path         string
+	entry        runCacheEntry
+	dirty        bool
+}
+
+// loadRunCache loads the launch cache of the given revision of a snap. The
+// returned cache is never nil.
+func loadRunCache(instanceName string, revision snap.Revision) *runCache {
+	cache := &runCache{instanceName: instanceName, revision: revision}
+	if instanceName == "" || revision.Unset() {
+		return cache
+	}
+	u, err := userCurrent()
+	if err != nil {
+		logger.Debugf("cannot use launch cache: %v", err)
+		return cache
+	}
+	xdgRuntimeDir := filepath.Join(dirs.XdgRuntimeDirBase, u.Uid)
+	if !osutil.IsDirectory(xdgRuntimeDir) {
+		// there is no session, do not create $XDG_RUNTIME_DIR ourselves
+		return cache
+	}
+	cache.path = filepath.Join(xdgRuntimeDir, ".snap-run-cache", fmt.Sprintf("%s_%s.json", instanceName, revision))
+
+	data, err := ioutil.ReadFile(cache.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Debugf("cannot read launch cache: %v", err)
+		}
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache.entry); err != nil {
+		logger.Debugf("cannot decode launch cache %s: %v", cache.path, err)
+		cache.entry = runCacheEntry{}
+	}
+	return cache
+}
+
+// loadRunCacheForApp loads the launch cache of the current revision of the
+// snap providing the given application.
+func loadRunCacheForApp(snapApp string) *runCache {
+	snapName, _ := snap.SplitSnapApp(snapApp)
+	// resolving the symlink is much cheaper than reading the snap.yaml
+	target, err := os.Readlink(filepath.Join(dirs.SnapMountDir, snapName, "current"))
+	if err != nil {
+		return &runCache{}
+	}
+	revision, err := snap.ParseRevision(filepath.Base(target))
+	if err != nil {
+		return &runCache{}
+	}
+	return loadRunCache(snapName, revision)
+}
+
+// save writes the launch cache back to disk, if it was changed.
+func (c *runCache) save() {
+	if c.path == "" || !c.dirty {
+		return
+	}
+	data, err := json.Marshal(&c.entry)
+	if err != nil {
+		logger.Debugf("cannot encode launch cache: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		logger.Debugf("cannot create launch cache directory: %v", err)
+		return
+	}
+	if err := osutil.AtomicWriteFile(c.path, data, 0600, 0); err != nil {
+		logger.Debugf("cannot write launch cache: %v", err)
+		return
+	}
+	c.dirty = false
+}
+
+var snapdBuildID = func() (string, error) {
+	snapdPath, err := snapdtool.InternalToolPath("snapd")
+	if err != nil {
+		return "", err
+	}
+	buildID, err := osutil.ReadBuildID(snapdPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	return buildID, nil
+}
+
+// systemKeyStamp returns a string identifying the current system-key file,
+// along with the build-id of the snapd the system-key is computed against.
+//
+// The system-key is re-written by snapd whenever it re-generates the security
+// profiles, which changes the stamp. After a refresh of snapd "snap run" may
+// already use the new snapd before the new system-key is written, the build-id
+// changes the stamp then.
+func systemKeyStamp() string {
+	fi, err := os.Stat(dirs.SnapSystemKeyFile)
+	if err != nil {
+		return ""
+	}
+	buildID, err := snapdBuildID()
+	if err != nil {
+		logger.Debugf("cannot use launch cache for the system-key: %v", err)
+		return ""
+	}
+	return fmt.Sprintf("%d-%d-%s", fi.ModTime().UnixNano(), fi.Size(), buildID)
+}
+
+// systemKeyChecked returns true if the security profiles were found to be up
+// to date by an earlier launch and the system-key was not re-written since.
+func (c *runCache) systemKeyChecked() bool {
+	stamp := systemKeyStamp()
+	return stamp != "" && c.entry.SystemKeyStamp == stamp
+}
+
+// recordSystemKeyChecked records that the security profiles were found to be
+// up to date.
+func (c *runCache) recordSystemKeyChecked() {
+	if stamp := systemKeyStamp(); stamp != c.entry.SystemKeyStamp {
+		c.entry.SystemKeyStamp = stamp
+		c.dirty = true
+	}
+}
+
+// matches returns true if the cache is the one of the given snap revision.
+func (c *runCache) matches(info *snap.Info) bool {
+	return c.instanceName == info.InstanceName() && c.revision == info.Revision
+}
+
+// userDataReady returns true if the user data directories of the snap were
+// created by an earlier launch and are still in place.
+func (c *runCache) userDataReady(info *snap.Info) bool {
+	if !c.entry.UserDataReady || !c.matches(info) {
+		return false
+	}
+	usr, err := userCurrent()
+	if err != nil {
+		return false
+	}
+	userData := info.UserDataDir(usr.HomeDir)
+	if !osutil.IsDirectory(userData) || !osutil.IsDirectory(info.UserCommonDataDir(usr.HomeDir)) {
+		return false
+	}
+	// another revision may have been run since
+	current, err := os.Readlink(filepath.Join(filepath.Dir(userData), "current"))
+	return err == nil && current == filepath.Base(userData)
+}
+
+// recordUserDataReady records that the user data directories of the snap
+// were created.
+func (c *runCache) recordUserDataReady(info *snap.Info) {
+	if !c.matches(info) {
+		return
+	}
+	if !c.entry.UserDataReady {
+		c.entry.UserDataReady = true
+		c.dirty = true
+	}
+}
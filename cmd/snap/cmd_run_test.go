@@ -22,6 +22,7 @@ package main_test
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/user"
@@ -33,6 +34,7 @@ import (
 
 	snaprun "github.com/snapcore/snapd/cmd/snap"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/sandbox/cgroup"
@@ -205,6 +207,89 @@ func (s *RunSuite) TestSnapRunAppIntegration(c *check.C) {
 	c.Check(execEnv, testutil.Contains, fmt.Sprintf("TMPDIR=%s", tmpdir))
 }
 
+func (s *RunSuite) TestSnapRunLaunchCache(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+
+	u, err := user.Current()
+	c.Assert(err, check.IsNil)
+	// the cache is only used when XDG_RUNTIME_DIR exists
+	err = os.MkdirAll(filepath.Join(dirs.XdgRuntimeDirBase, u.Uid), 0700)
+	c.Assert(err, check.IsNil)
+	cacheFile := filepath.Join(dirs.XdgRuntimeDirBase, u.Uid, ".snap-run-cache", "snapname_x2.json")
+
+	// a system-key mismatch is reported right away
+	os.Setenv("SNAPD_DEBUG_SYSTEM_KEY_RETRY", "0")
+	defer os.Unsetenv("SNAPD_DEBUG_SYSTEM_KEY_RETRY")
+	systemctl := testutil.MockCommand(c, "systemctl", "")
+	defer systemctl.Restore()
+
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+
+	execCalls := 0
+	restorer := snaprun.MockSyscallExec(func(arg0 string, args []string, envv []string) error {
+		execCalls++
+		return nil
+	})
+	defer restorer()
+	buildID := "build-id-1"
+	restorer = snaprun.MockSnapdBuildID(func() (string, error) {
+		return buildID, nil
+	})
+	defer restorer()
+
+	// the first launch performs all checks and fills the cache
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app"})
+	c.Assert(err, check.IsNil)
+	c.Check(execCalls, check.Equals, 1)
+	fi, err := os.Stat(dirs.SnapSystemKeyFile)
+	c.Assert(err, check.IsNil)
+	c.Check(cacheFile, testutil.FileEquals, fmt.Sprintf(`{"system-key-stamp":"%d-%d-build-id-1","user-data-ready":true}`, fi.ModTime().UnixNano(), fi.Size()))
+
+	// the system-key is no longer computed on subsequent launches
+	restore := interfaces.MockSystemKey(`{"build-id": "something-else"}`)
+	defer restore()
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app"})
+	c.Assert(err, check.IsNil)
+	c.Check(execCalls, check.Equals, 2)
+
+	// missing user data directories are created again
+	userData := filepath.Join(s.fakeHome, "snap/snapname/x2")
+	c.Assert(os.RemoveAll(userData), check.IsNil)
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app"})
+	c.Assert(err, check.IsNil)
+	c.Check(execCalls, check.Equals, 3)
+	c.Check(osutil.IsDirectory(userData), check.Equals, true)
+
+	// after a refresh of snapd the check is performed again, even if the
+	// new snapd did not re-write the system-key yet
+	buildID = "build-id-2"
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app"})
+	c.Assert(err, check.ErrorMatches, "timeout waiting for snap system profiles to get updated")
+	c.Check(execCalls, check.Equals, 3)
+
+	// the cache is not used when the build-id cannot be read
+	restorer = snaprun.MockSnapdBuildID(func() (string, error) {
+		return "", fmt.Errorf("boom")
+	})
+	defer restorer()
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app"})
+	c.Assert(err, check.ErrorMatches, "timeout waiting for snap system profiles to get updated")
+	c.Check(execCalls, check.Equals, 3)
+
+	// once snapd re-writes the system-key the check is performed again
+	buildID = "build-id-1"
+	restorer = snaprun.MockSnapdBuildID(func() (string, error) {
+		return buildID, nil
+	})
+	defer restorer()
+	c.Assert(ioutil.WriteFile(dirs.SnapSystemKeyFile, []byte(`{"build-id": "old"}`), 0644), check.IsNil)
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--", "snapname.app"})
+	c.Assert(err, check.ErrorMatches, "timeout waiting for snap system profiles to get updated")
+	c.Check(execCalls, check.Equals, 3)
+}
+
 func (s *RunSuite) TestSnapRunClassicAppIntegration(c *check.C) {
 	defer mockSnapConfine(dirs.DistroLibExecDir)()
 
@@ -942,6 +1027,93 @@ func (s *RunSuite) TestSnapRunAppWithStraceOptions(c *check.C) {
 	})
 }
 
+func (s *RunSuite) TestSnapRunAppWithDebugToolStrace(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+
+	// mock installed snap
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+
+	// pretend we have sudo
+	sudoCmd := testutil.MockCommand(c, "sudo", "")
+	defer sudoCmd.Restore()
+
+	// pretend we have strace
+	straceCmd := testutil.MockCommand(c, "strace", "")
+	defer straceCmd.Restore()
+
+	user, err := user.Current()
+	c.Assert(err, check.IsNil)
+
+	rest, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--debug-tool=strace:-tt", "--", "snapname.app", "--arg1"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{"snapname.app", "--arg1"})
+	c.Check(sudoCmd.Calls(), check.DeepEquals, [][]string{
+		{
+			"sudo", "-E",
+			filepath.Join(straceCmd.BinDir(), "strace"),
+			"-u", user.Username,
+			"-f",
+			"-e", "!select,pselect6,_newselect,clock_gettime,sigaltstack,gettid,gettimeofday,nanosleep",
+			"-tt",
+			filepath.Join(dirs.DistroLibExecDir, "snap-confine"),
+			"snap.snapname.app",
+			filepath.Join(dirs.CoreLibExecDir, "snap-exec"),
+			"snapname.app", "--arg1",
+		},
+	})
+}
+
+func (s *RunSuite) TestSnapRunAppWithDebugToolPerf(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+
+	// mock installed snap
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+
+	// pretend we have sudo and perf
+	sudoCmd := testutil.MockCommand(c, "sudo", "")
+	defer sudoCmd.Restore()
+	perfCmd := testutil.MockCommand(c, "perf", "")
+	defer perfCmd.Restore()
+
+	u, err := user.Current()
+	c.Assert(err, check.IsNil)
+
+	rest, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", `--debug-tool=perf:-g -o "out file"`, "--", "snapname.app", "--arg1"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{"snapname.app", "--arg1"})
+	c.Check(sudoCmd.Calls(), check.DeepEquals, [][]string{
+		{
+			"sudo", "-E", "perf", "record", "-g", "-o", "out file",
+			"--", "sudo", "-E", "-u", "#" + u.Uid, "--",
+			filepath.Join(dirs.DistroLibExecDir, "snap-confine"),
+			"snap.snapname.app",
+			filepath.Join(dirs.CoreLibExecDir, "snap-exec"),
+			"snapname.app", "--arg1",
+		},
+	})
+}
+
+func (s *RunSuite) TestSnapRunDebugToolErrors(c *check.C) {
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--debug-tool=valgrind"}, `unknown debug tool "valgrind", expected one of: gdb, gdbserver, perf, strace, trace-exec`},
+		{[]string{"--debug-tool=gdb:-q"}, `debug tool "gdb" does not take options`},
+		{[]string{"--debug-tool=strace", "--gdb"}, `cannot use --debug-tool together with --strace, --gdb, --gdbserver or --trace-exec`},
+		{[]string{"--debug-tool=gdb", "--strace"}, `cannot use --debug-tool together with --strace, --gdb, --gdbserver or --trace-exec`},
+	} {
+		args := append([]string{"run"}, t.args...)
+		args = append(args, "--", "snapname.app")
+		_, err := snaprun.Parser(snaprun.Client()).ParseArgs(args)
+		c.Check(err, check.ErrorMatches, t.err, check.Commentf("%v", t.args))
+	}
+}
+
 func (s *RunSuite) TestSnapRunShellIntegration(c *check.C) {
 	defer mockSnapConfine(dirs.DistroLibExecDir)()
 
@@ -1393,6 +1565,41 @@ func (s *RunSuite) TestSnapRunTrackingHooks(c *check.C) {
 	c.Assert(created, check.Equals, true)
 }
 
+func (s *RunSuite) TestSnapRunTrackingHookLimits(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+
+	snaptest.MockSnapCurrent(c, `name: snapname
+version: 1.0
+hooks:
+ configure:
+  limits:
+   memory: 64M
+   cpu-percentage: 50
+`, &snap.SideInfo{
+		Revision: snap.R(42),
+	})
+
+	var trackingOpts *cgroup.TrackingOptions
+	restore := snaprun.MockCreateTransientScopeForTracking(func(securityTag string, opts *cgroup.TrackingOptions) error {
+		c.Check(securityTag, check.Equals, "snap.snapname.hook.configure")
+		trackingOpts = opts
+		return nil
+	})
+	defer restore()
+
+	restore = snaprun.MockSyscallExec(func(arg0 string, args []string, envv []string) error {
+		return nil
+	})
+	defer restore()
+
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--hook", "configure", "snapname"})
+	c.Assert(err, check.IsNil)
+	c.Check(trackingOpts, check.DeepEquals, &cgroup.TrackingOptions{
+		MemoryMax: 64 * 1024 * 1024,
+		CPUQuota:  50,
+	})
+}
+
 func (s *RunSuite) TestSnapRunTrackingServices(c *check.C) {
 	restore := mockSnapConfine(filepath.Join(dirs.SnapMountDir, "core", "111", dirs.CoreLibExecDir))
 	defer restore()
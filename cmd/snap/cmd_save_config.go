@@ -0,0 +1,87 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortSaveConfigHelp = i18n.G("Save the configuration of a snap")
+var longSaveConfigHelp = i18n.G(`
+The save-config command writes the whole configuration of the given snap,
+along with the revision and version of the snap it was saved from, as a
+JSON document.
+
+The document can be applied on other devices with restore-config:
+
+    $ snap save-config snap-name --output snap-name.json
+    $ snap restore-config snap-name.json
+`)
+
+type cmdSaveConfig struct {
+	clientMixin
+	Output     flags.Filename `long:"output" short:"o"`
+	Positional struct {
+		Snap installedSnapName
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addCommand("save-config", shortSaveConfigHelp, longSaveConfigHelp, func() flags.Commander { return &cmdSaveConfig{} },
+		map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"output": i18n.G("Write the configuration to the given file instead of standard output"),
+		}, []argDesc{{
+			name: "<snap>",
+			// TRANSLATORS: This should not start with a lowercase letter.
+			desc: i18n.G("The snap whose configuration to save (e.g. hello-world)"),
+		}})
+}
+
+func (x *cmdSaveConfig) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	export, err := x.client.ExportConf(string(x.Positional.Snap))
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if x.Output == "" {
+		_, err = Stdout.Write(data)
+		return err
+	}
+	if err := ioutil.WriteFile(string(x.Output), data, 0600); err != nil {
+		return fmt.Errorf(i18n.G("cannot write configuration: %v"), err)
+	}
+	return nil
+}
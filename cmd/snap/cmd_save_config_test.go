@@ -0,0 +1,82 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+const savedConfig = `{
+  "format": 1,
+  "snap": "snapname",
+  "revision": "7",
+  "version": "1.0",
+  "exported-at": "2021-03-01T10:00:00Z",
+  "config": {
+    "key": "value",
+    "port": 1234567890
+  }
+}
+`
+
+func (s *SnapSuite) mockSaveConfigServer(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/snapname/conf")
+		c.Check(r.URL.Query().Get("export"), check.Equals, "true")
+		fmt.Fprintln(w, `{"type": "sync", "status-code": 200, "result": {
+			"format": 1,
+			"snap": "snapname",
+			"revision": "7",
+			"version": "1.0",
+			"exported-at": "2021-03-01T10:00:00Z",
+			"config": {"key": "value", "port": 1234567890}
+		}}`)
+	})
+}
+
+func (s *SnapSuite) TestSaveConfig(c *check.C) {
+	s.mockSaveConfigServer(c)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"save-config", "snapname"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stdout(), check.Equals, savedConfig)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestSaveConfigOutput(c *check.C) {
+	s.mockSaveConfigServer(c)
+
+	output := filepath.Join(c.MkDir(), "snapname.json")
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"save-config", "snapname", "--output", output})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	data, err := ioutil.ReadFile(output)
+	c.Assert(err, check.IsNil)
+	c.Check(string(data), check.Equals, savedConfig)
+}
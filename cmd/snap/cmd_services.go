@@ -29,13 +29,16 @@ import (
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 type svcStatus struct {
 	clientMixin
+	formatMixin
 	Positional struct {
 		ServiceNames []serviceName
 	} `positional-args:"yes"`
+	User bool `long:"user"`
 }
 
 type svcLogs struct {
@@ -53,6 +56,9 @@ var (
 	longServicesHelp  = i18n.G(`
 The services command lists information about the services specified, or about
 the services in all currently installed snaps.
+
+If the --user option is given, the current state of user daemons is reported
+for the session of the calling user.
 `)
 	shortLogsHelp = i18n.G("Retrieve logs for services")
 	longLogsHelp  = i18n.G(`
@@ -62,10 +68,16 @@ chronological order.
 	shortStartHelp = i18n.G("Start services")
 	longStartHelp  = i18n.G(`
 The start command starts, and optionally enables, the given services.
+
+With the --user option only user daemons are started, in the session of the
+calling user; with --users=all they are started in the sessions of all users.
 `)
 	shortStopHelp = i18n.G("Stop services")
 	longStopHelp  = i18n.G(`
 The stop command stops, and optionally disables, the given services.
+
+With the --user option only user daemons are stopped, in the session of the
+calling user; with --users=all they are stopped in the sessions of all users.
 `)
 	shortRestartHelp = i18n.G("Restart services")
 	longRestartHelp  = i18n.G(`
@@ -73,9 +85,39 @@ The restart command restarts the given services.
 
 If the --reload option is given, for each service whose app has a reload
 command, a reload is performed instead of a restart.
+
+With the --user option only user daemons are restarted, in the session of the
+calling user; with --users=all they are restarted in the sessions of all users.
 `)
 )
 
+type svcUsersMixin struct {
+	User  bool   `long:"user"`
+	Users string `long:"users"`
+}
+
+var svcUsersDescs = mixinDescs{
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"user": i18n.G("Operate only on user daemons, in the session of the calling user."),
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"users": i18n.G("Operate only on user daemons, in the sessions of the given users (only 'all' is supported)."),
+}
+
+func (mx svcUsersMixin) userSelector() (client.UserSelector, error) {
+	switch {
+	case mx.User && mx.Users != "":
+		return "", fmt.Errorf(i18n.G("cannot use --user and --users together"))
+	case mx.User:
+		return client.UsersSelf, nil
+	case mx.Users == "":
+		return "", nil
+	case mx.Users == "all":
+		return client.UsersAll, nil
+	default:
+		return "", fmt.Errorf(i18n.G("invalid value for --users: %q (only \"all\" is supported)"), mx.Users)
+	}
+}
+
 func init() {
 	argdescs := []argDesc{{
 		// TRANSLATORS: This needs to begin with < and end with >
@@ -83,7 +125,10 @@ func init() {
 		// TRANSLATORS: This should not start with a lowercase letter.
 		desc: i18n.G("A service specification, which can be just a snap name (for all services in the snap), or <snap>.<app> for a single service."),
 	}}
-	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, nil, argdescs)
+	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, formatDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"user": i18n.G("Report the current state of user daemons in the session of the calling user."),
+	}), argdescs)
 	addCommand("logs", shortLogsHelp, longLogsHelp, func() flags.Commander { return &svcLogs{} },
 		timeDescs.also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
@@ -93,17 +138,17 @@ func init() {
 		}), argdescs)
 
 	addCommand("start", shortStartHelp, longStartHelp, func() flags.Commander { return &svcStart{} },
-		waitDescs.also(map[string]string{
+		waitDescs.also(svcUsersDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"enable": i18n.G("As well as starting the service now, arrange for it to be started on boot."),
 		}), argdescs)
 	addCommand("stop", shortStopHelp, longStopHelp, func() flags.Commander { return &svcStop{} },
-		waitDescs.also(map[string]string{
+		waitDescs.also(svcUsersDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"disable": i18n.G("As well as stopping the service now, arrange for it to no longer be started on boot."),
 		}), argdescs)
 	addCommand("restart", shortRestartHelp, longRestartHelp, func() flags.Commander { return &svcRestart{} },
-		waitDescs.also(map[string]string{
+		waitDescs.also(svcUsersDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"reload": i18n.G("If the service has a reload command, use it instead of restarting."),
 		}), argdescs)
@@ -117,16 +162,60 @@ func svcNames(s []serviceName) []string {
 	return svcNames
 }
 
+// serviceFormatted is the machine-readable form of the status of a
+// service.
+type serviceFormatted struct {
+	Service     string   `json:"service" yaml:"service"`
+	Daemon      string   `json:"daemon" yaml:"daemon"`
+	DaemonScope string   `json:"daemon-scope" yaml:"daemon-scope"`
+	Enabled     bool     `json:"enabled" yaml:"enabled"`
+	Active      *bool    `json:"active,omitempty" yaml:"active,omitempty"`
+	Activators  []string `json:"activators,omitempty" yaml:"activators,omitempty"`
+}
+
+func (s *svcStatus) formatted(services []*client.AppInfo) []serviceFormatted {
+	out := make([]serviceFormatted, 0, len(services))
+	for _, svc := range services {
+		entry := serviceFormatted{
+			Service:     snap.JoinSnapApp(svc.Snap, svc.Name),
+			Daemon:      svc.Daemon,
+			DaemonScope: string(svc.DaemonScope),
+			Enabled:     svc.Enabled,
+		}
+		// the state of user daemons is only known for the
+		// session of the calling user
+		if svc.DaemonScope != snap.UserDaemon || s.User {
+			active := svc.Active
+			entry.Active = &active
+		}
+		for _, act := range svc.Activators {
+			if !strutil.ListContains(entry.Activators, act.Type) {
+				entry.Activators = append(entry.Activators, act.Type)
+			}
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
 func (s *svcStatus) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
 
-	services, err := s.client.Apps(svcNames(s.Positional.ServiceNames), client.AppOptions{Service: true})
+	opts := client.AppOptions{Service: true}
+	if s.User {
+		opts.Users = client.UsersSelf
+	}
+	services, err := s.client.Apps(svcNames(s.Positional.ServiceNames), opts)
 	if err != nil {
 		return err
 	}
 
+	if s.machineReadable() {
+		return s.writeFormatted(s.formatted(services))
+	}
+
 	if len(services) == 0 {
 		fmt.Fprintln(Stderr, i18n.G("There are no services provided by installed snaps."))
 		return nil
@@ -143,7 +232,7 @@ func (s *svcStatus) Execute(args []string) error {
 			startup = i18n.G("enabled")
 		}
 		current := i18n.G("inactive")
-		if svc.DaemonScope == snap.UserDaemon {
+		if svc.DaemonScope == snap.UserDaemon && !s.User {
 			current = "-"
 		} else if svc.Active {
 			current = i18n.G("active")
@@ -186,6 +275,7 @@ func (s *svcLogs) Execute(args []string) error {
 
 type svcStart struct {
 	waitMixin
+	svcUsersMixin
 	Positional struct {
 		ServiceNames []serviceName `required:"1"`
 	} `positional-args:"yes" required:"yes"`
@@ -196,8 +286,12 @@ func (s *svcStart) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
+	users, err := s.userSelector()
+	if err != nil {
+		return err
+	}
 	names := svcNames(s.Positional.ServiceNames)
-	changeID, err := s.client.Start(names, client.StartOptions{Enable: s.Enable})
+	changeID, err := s.client.Start(names, users, client.StartOptions{Enable: s.Enable})
 	if err != nil {
 		return err
 	}
@@ -215,6 +309,7 @@ func (s *svcStart) Execute(args []string) error {
 
 type svcStop struct {
 	waitMixin
+	svcUsersMixin
 	Positional struct {
 		ServiceNames []serviceName `required:"1"`
 	} `positional-args:"yes" required:"yes"`
@@ -225,8 +320,12 @@ func (s *svcStop) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
+	users, err := s.userSelector()
+	if err != nil {
+		return err
+	}
 	names := svcNames(s.Positional.ServiceNames)
-	changeID, err := s.client.Stop(names, client.StopOptions{Disable: s.Disable})
+	changeID, err := s.client.Stop(names, users, client.StopOptions{Disable: s.Disable})
 	if err != nil {
 		return err
 	}
@@ -244,6 +343,7 @@ func (s *svcStop) Execute(args []string) error {
 
 type svcRestart struct {
 	waitMixin
+	svcUsersMixin
 	Positional struct {
 		ServiceNames []serviceName `required:"1"`
 	} `positional-args:"yes" required:"yes"`
@@ -254,8 +354,12 @@ func (s *svcRestart) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
+	users, err := s.userSelector()
+	if err != nil {
+		return err
+	}
 	names := svcNames(s.Positional.ServiceNames)
-	changeID, err := s.client.Restart(names, client.RestartOptions{Reload: s.Reload})
+	changeID, err := s.client.Restart(names, users, client.RestartOptions{Reload: s.Reload})
 	if err != nil {
 		return err
 	}
@@ -168,6 +168,99 @@ func (s *appOpSuite) TestAppOps(c *check.C) {
 	}
 }
 
+func (s *appOpSuite) TestAppOpsUsers(c *check.C) {
+	for _, t := range []struct {
+		args  []string
+		op    string
+		users string
+	}{
+		{[]string{"start", "--user", "foo"}, "start", "self"},
+		{[]string{"stop", "--users=all", "foo"}, "stop", "all"},
+		{[]string{"restart", "--user", "foo"}, "restart", "self"},
+	} {
+		n := 0
+		s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch n {
+			case 0:
+				c.Check(r.Method, check.Equals, "POST")
+				c.Check(r.URL.Path, check.Equals, "/v2/apps")
+				c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+					"action": t.op,
+					"names":  []interface{}{"foo"},
+					"users":  t.users,
+				})
+				w.WriteHeader(202)
+				fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+			case 1:
+				c.Check(r.Method, check.Equals, "GET")
+				c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+				fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done"}}`)
+			default:
+				c.Fatalf("expected to get 2 requests, now on %d", n+1)
+			}
+			n++
+		})
+		_, err := snap.Parser(snap.Client()).ParseArgs(t.args)
+		c.Assert(err, check.IsNil)
+		c.Check(n, check.Equals, 2)
+	}
+}
+
+func (s *appOpSuite) TestAppOpsUsersErrors(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to %q", r.URL.Path)
+	})
+	for _, op := range []string{"start", "stop", "restart"} {
+		_, err := snap.Parser(snap.Client()).ParseArgs([]string{op, "--user", "--users=all", "foo"})
+		c.Check(err, check.ErrorMatches, "cannot use --user and --users together")
+		_, err = snap.Parser(snap.Client()).ParseArgs([]string{op, "--users=bob", "foo"})
+		c.Check(err, check.ErrorMatches, `invalid value for --users: "bob" \(only "all" is supported\)`)
+	}
+}
+
+func (s *appOpSuite) TestAppStatusUser(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.URL.Path, check.Equals, "/v2/apps")
+			c.Check(r.URL.Query(), check.HasLen, 2)
+			c.Check(r.URL.Query().Get("select"), check.Equals, "service")
+			c.Check(r.URL.Query().Get("users"), check.Equals, "self")
+			c.Check(r.Method, check.Equals, "GET")
+			w.WriteHeader(200)
+			enc := json.NewEncoder(w)
+			enc.Encode(map[string]interface{}{
+				"type": "sync",
+				"result": []map[string]interface{}{
+					{
+						"snap":         "foo",
+						"name":         "qux",
+						"daemon":       "simple",
+						"daemon-scope": "user",
+						"active":       true,
+						"enabled":      true,
+					},
+				},
+				"status":      "OK",
+				"status-code": 200,
+			})
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"services", "--user"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, `Service  Startup  Current  Notes
+foo.qux  enabled  active   user
+`)
+	c.Check(n, check.Equals, 1)
+}
+
 func (s *appOpSuite) TestAppStatus(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -240,6 +333,67 @@ foo.zed  enabled  active    -
 	c.Check(n, check.Equals, 1)
 }
 
+func (s *appOpSuite) TestAppStatusFormatYAML(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.URL.Path, check.Equals, "/v2/apps")
+			c.Check(r.URL.Query().Get("select"), check.Equals, "service")
+			c.Check(r.Method, check.Equals, "GET")
+			w.WriteHeader(200)
+			enc := json.NewEncoder(w)
+			enc.Encode(map[string]interface{}{
+				"type": "sync",
+				"result": []map[string]interface{}{
+					{
+						"snap":         "foo",
+						"name":         "bar",
+						"daemon":       "oneshot",
+						"daemon-scope": "system",
+						"active":       false,
+						"enabled":      true,
+						"activators": []map[string]interface{}{
+							{"name": "bar", "type": "timer", "active": true, "enabled": true},
+						},
+					}, {
+						"snap":         "foo",
+						"name":         "qux",
+						"daemon":       "simple",
+						"daemon-scope": "user",
+						"active":       false,
+						"enabled":      true,
+					},
+				},
+				"status":      "OK",
+				"status-code": 200,
+			})
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"services", "--format=yaml"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	// the state of the user daemon is not known
+	c.Check(s.Stdout(), check.Equals, `- service: foo.bar
+  daemon: oneshot
+  daemon-scope: system
+  enabled: true
+  active: false
+  activators:
+  - timer
+- service: foo.qux
+  daemon: simple
+  daemon-scope: user
+  enabled: true
+`)
+	c.Check(n, check.Equals, 1)
+}
+
 func (s *appOpSuite) TestServiceCompletion(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
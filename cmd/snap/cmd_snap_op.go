@@ -22,6 +22,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -66,6 +67,11 @@ Note that a later refresh will typically undo a revision override, taking the sn
 back to the current revision of the channel it's tracking.
 
 Use --name to set the instance name when installing from snap file.
+
+Several snap files can be installed together, their bases, content providers
+and default-providers are then installed first. With --with-asserts, the
+assertions found in the given directory are acknowledged beforehand, so that
+such a set of snaps can be installed without reaching the store.
 `)
 
 var longRemoveHelp = i18n.G(`
@@ -78,6 +84,11 @@ revision is removed.
 Unless automatic snapshots are disabled, a snapshot of all data for the snap is 
 saved upon removal, which is then available for future restoration with snap
 restore. The --purge option disables automatically creating snapshots.
+
+When removing multiple snaps, --transaction=all-snaps removes either all of
+them or, if removing any of them fails, none at all. Snaps providing content
+to other snaps that are not being removed are then refused, unless --cascade
+is passed to remove those snaps as well.
 `)
 
 var longRefreshHelp = i18n.G(`
@@ -118,9 +129,11 @@ and the snap can easily be enabled again.
 type cmdRemove struct {
 	waitMixin
 
-	Revision   string `long:"revision"`
-	Purge      bool   `long:"purge"`
-	Positional struct {
+	Revision    string                 `long:"revision"`
+	Purge       bool                   `long:"purge"`
+	Transaction client.TransactionType `long:"transaction" choice:"per-snap" choice:"all-snaps"`
+	Cascade     bool                   `long:"cascade"`
+	Positional  struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>" required:"1"`
 	} `positional-args:"yes" required:"yes"`
 }
@@ -191,6 +204,16 @@ func (x *cmdRemove) removeMany(opts *client.SnapOptions) error {
 }
 
 func (x *cmdRemove) Execute([]string) error {
+	if x.Cascade && x.Transaction != client.TransactionAllSnaps {
+		return errors.New(i18n.G("--cascade requires --transaction=all-snaps"))
+	}
+	if x.Transaction != "" {
+		if x.Purge || x.Revision != "" {
+			return errors.New(i18n.G("--transaction cannot be combined with --purge or --revision"))
+		}
+		return x.removeMany(&client.SnapOptions{Transaction: x.Transaction, CascadeContent: x.Cascade})
+	}
+
 	opts := &client.SnapOptions{Revision: x.Revision, Purge: x.Purge}
 	if len(x.Positional.Snaps) == 1 {
 		return x.removeOne(opts)
@@ -203,7 +226,7 @@ func (x *cmdRemove) Execute([]string) error {
 }
 
 type channelMixin struct {
-	Channel string `long:"channel"`
+	Channel channelName `long:"channel"`
 
 	// shortcuts
 	EdgeChannel      bool `long:"edge"`
@@ -254,12 +277,12 @@ func (mx *channelMixin) setChannelFromCommandline() error {
 		if mx.Channel != "" {
 			return fmt.Errorf("Please specify a single channel")
 		}
-		mx.Channel = ch.chName
+		mx.Channel = channelName(ch.chName)
 	}
 
 	if mx.Channel != "" {
-		if _, err := channel.Parse(mx.Channel, ""); err != nil {
-			full, er := channel.Full(mx.Channel)
+		if _, err := channel.Parse(string(mx.Channel), ""); err != nil {
+			full, er := channel.Full(string(mx.Channel))
 			if er != nil {
 				// the parse error has more detailed info
 				return err
@@ -270,7 +293,7 @@ func (mx *channelMixin) setChannelFromCommandline() error {
 			msg := i18n.G("Specifying a channel %q is relying on undefined behaviour. Interpreting it as %q for now, but this will be an error later.\n")
 			warn := fill(fmt.Sprintf(msg, mx.Channel, full), utf8.RuneCountInString(head)+1) // +1 for the space
 			fmt.Fprint(Stderr, head, " ", warn, "\n\n")
-			mx.Channel = full // so a malformed-but-eh channel will always be full, i.e. //stable// -> latest/stable
+			mx.Channel = channelName(full) // so a malformed-but-eh channel will always be full, i.e. //stable// -> latest/stable
 		}
 	}
 
@@ -475,19 +498,42 @@ type cmdInstall struct {
 	Name string `long:"name"`
 
 	Cohort        string `long:"cohort"`
+	OCIRef        string `long:"oci-ref"`
+	WithAsserts   string `long:"with-asserts"`
 	IgnoreRunning bool   `long:"ignore-running" hidden:"yes"`
 	Positional    struct {
 		Snaps []remoteSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes" required:"yes"`
 }
 
+func isSnapFilePath(nameOrPath string) bool {
+	return strings.Contains(nameOrPath, "/") || strings.HasSuffix(nameOrPath, ".snap") || strings.Contains(nameOrPath, ".snap.")
+}
+
+// ackAssertsDir acknowledges all the assertion files found in dir.
+func (x *cmdInstall) ackAssertsDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot read assertions directory: %v"), err)
+	}
+	for _, fi := range files {
+		if fi.IsDir() {
+			continue
+		}
+		if err := ackFile(x.client, filepath.Join(dir, fi.Name())); err != nil {
+			return fmt.Errorf(i18n.G("cannot acknowledge assertions from %q: %v"), fi.Name(), err)
+		}
+	}
+	return nil
+}
+
 func (x *cmdInstall) installOne(nameOrPath, desiredName string, opts *client.SnapOptions) error {
 	var err error
 	var changeID string
 	var snapName string
 	var path string
 
-	if strings.Contains(nameOrPath, "/") || strings.HasSuffix(nameOrPath, ".snap") || strings.Contains(nameOrPath, ".snap.") {
+	if isSnapFilePath(nameOrPath) {
 		path = nameOrPath
 		changeID, err = x.client.InstallPath(path, x.Name, opts)
 	} else {
@@ -526,13 +572,6 @@ func (x *cmdInstall) installOne(nameOrPath, desiredName string, opts *client.Sna
 }
 
 func (x *cmdInstall) installMany(names []string, opts *client.SnapOptions) error {
-	// sanity check
-	for _, name := range names {
-		if strings.Contains(name, "/") || strings.HasSuffix(name, ".snap") || strings.Contains(name, ".snap.") {
-			return fmt.Errorf("only one snap file can be installed at a time")
-		}
-	}
-
 	changeID, err := x.client.InstallMany(names, opts)
 	if err != nil {
 		var snapName string
@@ -582,6 +621,36 @@ func (x *cmdInstall) installMany(names []string, opts *client.SnapOptions) error
 	return nil
 }
 
+func (x *cmdInstall) installPathMany(paths []string, opts *client.SnapOptions) error {
+	changeID, err := x.client.InstallPathMany(paths, opts)
+	if err != nil {
+		var snapName string
+		if err, ok := err.(*client.Error); ok {
+			snapName, _ = err.Value.(string)
+		}
+		msg, err := errorToCmdMessage(snapName, err, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(Stderr, msg)
+		return nil
+	}
+
+	chg, err := x.wait(changeID)
+	if err != nil {
+		if err == noWait {
+			return nil
+		}
+		return err
+	}
+
+	var installed []string
+	if err := chg.Get("snap-names", &installed); err != nil {
+		return fmt.Errorf("cannot extract the snap names from local files: %s", err)
+	}
+	return showDone(x.client, installed, "install", opts, x.getEscapes())
+}
+
 func (x *cmdInstall) Execute([]string) error {
 	if err := x.setChannelFromCommandline(); err != nil {
 		return err
@@ -592,7 +661,7 @@ func (x *cmdInstall) Execute([]string) error {
 
 	dangerous := x.Dangerous || x.ForceDangerous
 	opts := &client.SnapOptions{
-		Channel:       x.Channel,
+		Channel:       string(x.Channel),
 		Revision:      x.Revision,
 		Dangerous:     dangerous,
 		Unaliased:     x.Unaliased,
@@ -611,17 +680,53 @@ func (x *cmdInstall) Execute([]string) error {
 		}
 	}
 
-	if len(names) == 1 {
-		return x.installOne(names[0], x.Name, opts)
+	if x.OCIRef != "" {
+		if len(names) != 1 || x.Name != "" {
+			return errors.New(i18n.G("a single snap name is needed to install from an OCI registry"))
+		}
+		if x.asksForChannel() || x.Revision != "" || x.Cohort != "" || dangerous {
+			return errors.New(i18n.G("--oci-ref does not take channel, revision, cohort or dangerous flags"))
+		}
+		opts.OCIRef = x.OCIRef
 	}
 
-	if x.asksForMode() || x.asksForChannel() {
-		return errors.New(i18n.G("a single snap name is needed to specify mode or channel flags"))
+	var paths []string
+	for _, name := range names {
+		if isSnapFilePath(name) {
+			paths = append(paths, name)
+		}
+	}
+	if len(paths) > 0 && len(paths) != len(names) {
+		return errors.New(i18n.G("cannot install snap files together with snaps from the store"))
+	}
+
+	if x.WithAsserts != "" {
+		if len(paths) == 0 {
+			return errors.New(i18n.G("--with-asserts can only be used when installing snap files"))
+		}
+		if err := x.ackAssertsDir(x.WithAsserts); err != nil {
+			return err
+		}
+	}
+
+	if len(names) == 1 {
+		return x.installOne(names[0], x.Name, opts)
 	}
 
 	if x.Name != "" {
 		return errors.New(i18n.G("cannot use instance name when installing multiple snaps"))
 	}
+
+	if len(paths) > 0 {
+		if x.asksForChannel() || x.Revision != "" || x.Cohort != "" {
+			return errors.New(i18n.G("cannot specify channel, revision or cohort when installing snap files"))
+		}
+		return x.installPathMany(paths, opts)
+	}
+
+	if x.asksForMode() || x.asksForChannel() {
+		return errors.New(i18n.G("a single snap name is needed to specify mode or channel flags"))
+	}
 	return x.installMany(names, nil)
 }
 
@@ -638,6 +743,7 @@ type cmdRefresh struct {
 	LeaveCohort      bool   `long:"leave-cohort"`
 	List             bool   `long:"list"`
 	Time             bool   `long:"time"`
+	ApplyPending     bool   `long:"apply-pending"`
 	IgnoreValidation bool   `long:"ignore-validation"`
 	IgnoreRunning    bool   `long:"ignore-running" hidden:"yes"`
 	Positional       struct {
@@ -673,6 +779,34 @@ func (x *cmdRefresh) refreshMany(snaps []string, opts *client.SnapOptions) error
 	return nil
 }
 
+func (x *cmdRefresh) applyPendingRefreshes(snaps []string) error {
+	changeID, err := x.client.ApplyPendingRefreshes(snaps)
+	if err != nil {
+		return err
+	}
+
+	chg, err := x.wait(changeID)
+	if err != nil {
+		if err == noWait {
+			return nil
+		}
+		return err
+	}
+
+	var refreshed []string
+	if err := chg.Get("snap-names", &refreshed); err != nil && err != client.ErrNoData {
+		return err
+	}
+
+	if len(refreshed) > 0 {
+		return showDone(x.client, refreshed, "refresh", nil, x.getEscapes())
+	}
+
+	fmt.Fprintln(Stderr, i18n.G("No pending refreshes to apply."))
+
+	return nil
+}
+
 func (x *cmdRefresh) refreshOne(name string, opts *client.SnapOptions) error {
 	changeID, err := x.client.Refresh(name, opts)
 	if err != nil {
@@ -794,6 +928,17 @@ func (x *cmdRefresh) Execute([]string) error {
 		return x.listRefresh()
 	}
 
+	if x.ApplyPending {
+		if x.asksForMode() || x.asksForChannel() || x.Revision != "" || x.Cohort != "" || x.LeaveCohort || x.Amend {
+			return errors.New(i18n.G("--apply-pending does not take mode, channel, revision or cohort flags"))
+		}
+		if x.IgnoreValidation || x.IgnoreRunning {
+			return errors.New(i18n.G("--apply-pending cannot be combined with ignoring validation or running apps"))
+		}
+
+		return x.applyPendingRefreshes(installedSnapNames(x.Positional.Snaps))
+	}
+
 	if len(x.Positional.Snaps) == 0 && os.Getenv("SNAP_REFRESH_FROM_TIMER") == "1" {
 		fmt.Fprintf(Stdout, "Ignoring `snap refresh` from the systemd timer")
 		return nil
@@ -803,7 +948,7 @@ func (x *cmdRefresh) Execute([]string) error {
 	if len(names) == 1 {
 		opts := &client.SnapOptions{
 			Amend:            x.Amend,
-			Channel:          x.Channel,
+			Channel:          string(x.Channel),
 			IgnoreValidation: x.IgnoreValidation,
 			IgnoreRunning:    x.IgnoreRunning,
 			Revision:         x.Revision,
@@ -978,6 +1123,7 @@ type cmdRevert struct {
 
 	modeMixin
 	Revision      string `long:"revision"`
+	To            string `long:"to"`
 	IgnoreRunning bool   `long:"ignore-running" hidden:"yes"`
 	Positional    struct {
 		Snap installedSnapName `positional-arg-name:"<snap>"`
@@ -992,6 +1138,12 @@ and will use the original data that was associated with that revision,
 discarding any data changes that were done by the latest revision. As
 an exception, data which the snap explicitly chooses to share across
 revisions is not touched by the revert process.
+
+With --to, the snap can be reverted to any older revision that is still
+available from the store, even if it is not installed anymore. In that
+case the data of the current revision is saved in a snapshot before
+switching, which can be restored with 'snap restore' should the older
+revision not work out, or the revert fail.
 `)
 
 func (x *cmdRevert) Execute(args []string) error {
@@ -1002,22 +1154,33 @@ func (x *cmdRevert) Execute(args []string) error {
 	if err := x.validateMode(); err != nil {
 		return err
 	}
+	if x.Revision != "" && x.To != "" {
+		return errors.New(i18n.G("cannot use --revision and --to together"))
+	}
 
 	name := string(x.Positional.Snap)
 	opts := &client.SnapOptions{
 		Revision:      x.Revision,
 		IgnoreRunning: x.IgnoreRunning,
 	}
+	if x.To != "" {
+		opts.Revision = x.To
+	}
 	x.setModes(opts)
 	changeID, err := x.client.Revert(name, opts)
 	if err != nil {
 		return err
 	}
 
-	if _, err := x.wait(changeID); err != nil {
+	if chg, err := x.wait(changeID); err != nil {
 		if err == noWait {
 			return nil
 		}
+		var setID uint64
+		if chg != nil && chg.Get("snapshot-set-id", &setID) == nil {
+			// TRANSLATORS: %v is the error, %d the id of a snapshot set
+			return fmt.Errorf(i18n.G("%v\n\nThe data of the snap was saved before the revert and can be restored with:\n  snap restore %d"), err, setID)
+		}
 		return err
 	}
 
@@ -1091,6 +1254,10 @@ func init() {
 			"revision": i18n.G("Remove only the given revision"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"purge": i18n.G("Remove the snap without saving a snapshot of its data"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"transaction": i18n.G("Have one transaction per-snap or one for all the specified snaps"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"cascade": i18n.G("Also remove the snaps consuming content from the removed snaps"),
 		}), nil)
 	addCommand("install", shortInstallHelp, longInstallHelp, func() flags.Commander { return &cmdInstall{} },
 		colorDescs.also(waitDescs).also(channelDescs).also(modeDescs).also(map[string]string{
@@ -1107,6 +1274,10 @@ func init() {
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"cohort": i18n.G("Install the snap in the given cohort"),
 			// TRANSLATORS: This should not start with a lowercase letter.
+			"oci-ref": i18n.G("Install the snap mirrored in an OCI registry, referenced as <registry>/<repository>@sha256:<digest>"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"with-asserts": i18n.G("Acknowledge the assertions found in the given directory before installing the given snap files"),
+			// TRANSLATORS: This should not start with a lowercase letter.
 			"ignore-running": i18n.G("Ignore running hooks or applications blocking the installation"),
 		}), nil)
 	addCommand("refresh", shortRefreshHelp, longRefreshHelp, func() flags.Commander { return &cmdRefresh{} },
@@ -1120,6 +1291,8 @@ func init() {
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"time": i18n.G("Show auto refresh information but do not perform a refresh"),
 			// TRANSLATORS: This should not start with a lowercase letter.
+			"apply-pending": i18n.G("Apply the refreshes staged by auto-refresh awaiting approval"),
+			// TRANSLATORS: This should not start with a lowercase letter.
 			"ignore-validation": i18n.G("Ignore validation by other snaps blocking the refresh"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"ignore-running": i18n.G("Ignore running hooks or applications blocking the refresh"),
@@ -1135,6 +1308,8 @@ func init() {
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"revision": i18n.G("Revert to the given revision"),
 		// TRANSLATORS: This should not start with a lowercase letter.
+		"to": i18n.G("Revert to the given older revision, getting it from the store if needed"),
+		// TRANSLATORS: This should not start with a lowercase letter.
 		"ignore-running": i18n.G("Ignore running hooks or applications blocking the revert"),
 	}), nil)
 	addCommand("switch", shortSwitchHelp, longSwitchHelp, func() flags.Commander { return &cmdSwitch{} }, waitDescs.also(channelDescs).also(map[string]string{
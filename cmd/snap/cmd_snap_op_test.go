@@ -226,6 +226,41 @@ func (s *SnapOpSuite) TestInstallIgnoreRunning(c *check.C) {
 	c.Check(s.srv.n, check.Equals, s.srv.total)
 }
 
+func (s *SnapOpSuite) TestInstallOCIRef(c *check.C) {
+	const ociRef = "registry.example.com/snaps/foo@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":  "install",
+			"oci-ref": ociRef,
+		})
+	}
+
+	s.RedirectClientToTestServer(s.srv.handle)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"install", "--oci-ref", ociRef, "foo"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Matches, `(?sm).*foo 1.0 from Bar installed`)
+	c.Check(s.Stderr(), check.Equals, "")
+	// ensure that the fake server api was actually hit
+	c.Check(s.srv.n, check.Equals, s.srv.total)
+}
+
+func (s *SnapOpSuite) TestInstallOCIRefErrors(c *check.C) {
+	s.RedirectClientToTestServer(nil)
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"install", "--oci-ref", "ref", "foo", "bar"}, `a single snap name is needed to install from an OCI registry`},
+		{[]string{"install", "--oci-ref", "ref", "--beta", "foo"}, `--oci-ref does not take channel, revision, cohort or dangerous flags`},
+		{[]string{"install", "--oci-ref", "ref", "--dangerous", "foo"}, `--oci-ref does not take channel, revision, cohort or dangerous flags`},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(t.args)
+		c.Check(err, check.ErrorMatches, t.err, check.Commentf("%v", t.args))
+	}
+}
+
 func (s *SnapOpSuite) TestInstallNoPATH(c *check.C) {
 	// PATH restored by test tear down
 	os.Setenv("PATH", "/bin:/usr/bin:/sbin:/usr/sbin")
@@ -1050,6 +1085,52 @@ func (s *SnapOpSuite) TestRevertClassic(c *check.C) {
 	s.runRevertTest(c, &client.SnapOptions{Classic: true})
 }
 
+func (s *SnapOpSuite) TestRevertTo(c *check.C) {
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":   "revert",
+			"revision": "3",
+		})
+	}
+
+	s.RedirectClientToTestServer(s.srv.handle)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"revert", "--to=3", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, "foo reverted to 1.0\n")
+	c.Check(s.srv.n, check.Equals, s.srv.total)
+}
+
+func (s *SnapOpSuite) TestRevertToFailedOffersRestore(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+		case 1:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Error", "err": "boom", "data": {"snapshot-set-id": 7}}}`)
+		default:
+			c.Fatalf("expected to get 2 requests, now on %d", n+1)
+		}
+		n++
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"revert", "--to=3", "foo"})
+	c.Assert(err, check.ErrorMatches, `(?s)boom.*snap restore 7`)
+	c.Check(n, check.Equals, 2)
+}
+
+func (s *SnapOpSuite) TestRevertRevisionAndToConflict(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"revert", "--revision=3", "--to=3", "foo"})
+	c.Assert(err, check.ErrorMatches, "cannot use --revision and --to together")
+}
+
 func (s *SnapOpSuite) TestRevertMissingName(c *check.C) {
 	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"revert"})
 	c.Assert(err, check.NotNil)
@@ -1445,6 +1526,38 @@ func (s *SnapOpSuite) TestRefreshOneAmend(c *check.C) {
 	c.Assert(err, check.IsNil)
 }
 
+func (s *SnapOpSuite) TestRefreshApplyPending(c *check.C) {
+	s.RedirectClientToTestServer(s.srv.handle)
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":        "refresh",
+			"snaps":         []interface{}{"one", "two"},
+			"apply-pending": true,
+		})
+	}
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--apply-pending", "one", "two"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stderr(), check.Equals, "No pending refreshes to apply.\n")
+	c.Check(s.srv.n, check.Equals, 3)
+}
+
+func (s *SnapOpSuite) TestRefreshApplyPendingFlagsErr(c *check.C) {
+	s.RedirectClientToTestServer(nil)
+	for _, args := range [][]string{
+		{"refresh", "--apply-pending", "--beta"},
+		{"refresh", "--apply-pending", "--devmode", "one"},
+		{"refresh", "--apply-pending", "--revision=2", "one"},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(args)
+		c.Check(err, check.ErrorMatches, `--apply-pending does not take mode, channel, revision or cohort flags`)
+	}
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--apply-pending", "--ignore-validation"})
+	c.Check(err, check.ErrorMatches, `--apply-pending cannot be combined with ignoring validation or running apps`)
+}
+
 func (s *SnapOpSuite) runTryTest(c *check.C, opts *client.SnapOptions) {
 	// pass relative path to cmd
 	tryDir := "some-dir"
@@ -1835,6 +1948,59 @@ func (s *SnapOpSuite) TestRemoveMany(c *check.C) {
 	c.Check(n, check.Equals, total)
 }
 
+func (s *SnapOpSuite) TestRemoveManyTransactionalCascade(c *check.C) {
+	total := 2
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+				"action":          "remove",
+				"snaps":           []interface{}{"provider"},
+				"transaction":     "all-snaps",
+				"cascade-content": true,
+			})
+
+			c.Check(r.Method, check.Equals, "POST")
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+		case 1:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done", "data": {"snap-names": ["provider","consumer"]}}}`)
+		default:
+			c.Fatalf("expected to get %d requests, now on %d", total, n+1)
+		}
+
+		n++
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"remove", "--transaction=all-snaps", "--cascade", "provider"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, "provider removed\nconsumer removed\n")
+	c.Check(s.Stderr(), check.Equals, "")
+	// ensure that the fake server api was actually hit
+	c.Check(n, check.Equals, total)
+}
+
+func (s *SnapOpSuite) TestRemoveTransactionErrors(c *check.C) {
+	s.RedirectClientToTestServer(nil)
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"remove", "--cascade", "one", "two"}, `--cascade requires --transaction=all-snaps`},
+		{[]string{"remove", "--transaction=per-snap", "--cascade", "one"}, `--cascade requires --transaction=all-snaps`},
+		{[]string{"remove", "--transaction=all-snaps", "--purge", "one", "two"}, `--transaction cannot be combined with --purge or --revision`},
+		{[]string{"remove", "--transaction=some-snaps", "one", "two"}, `(?s)Invalid value .* for option .*--transaction.*`},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(t.args)
+		c.Check(err, check.ErrorMatches, t.err, check.Commentf("%v", t.args))
+	}
+}
+
 func (s *SnapOpSuite) TestInstallManyChannel(c *check.C) {
 	s.RedirectClientToTestServer(nil)
 	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"install", "--beta", "one", "two"})
@@ -1844,7 +2010,78 @@ func (s *SnapOpSuite) TestInstallManyChannel(c *check.C) {
 func (s *SnapOpSuite) TestInstallManyMixFileAndStore(c *check.C) {
 	s.RedirectClientToTestServer(nil)
 	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"install", "store-snap", "./local.snap"})
-	c.Assert(err, check.ErrorMatches, `only one snap file can be installed at a time`)
+	c.Assert(err, check.ErrorMatches, `cannot install snap files together with snaps from the store`)
+}
+
+func (s *SnapOpSuite) TestInstallManyPathsWithAsserts(c *check.C) {
+	dir := c.MkDir()
+	assertsDir := filepath.Join(dir, "asserts")
+	c.Assert(os.Mkdir(assertsDir, 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(assertsDir, "one.assert"), []byte("one-assertions"), 0644), check.IsNil)
+	var paths []string
+	for _, name := range []string{"one", "two"} {
+		path := filepath.Join(dir, name+".snap")
+		c.Assert(ioutil.WriteFile(path, []byte(name+"-data"), 0644), check.IsNil)
+		paths = append(paths, path)
+	}
+
+	total := 5
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/assertions")
+			body, err := ioutil.ReadAll(r.Body)
+			c.Check(err, check.IsNil)
+			c.Check(string(body), check.Equals, "one-assertions")
+			fmt.Fprintln(w, `{"type": "sync", "result": {}}`)
+		case 1:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+			form := testForm(r, c)
+			defer form.RemoveAll()
+			c.Check(form.Value["action"], check.DeepEquals, []string{"install"})
+			c.Check(form.Value["snap-path"], check.IsNil)
+			c.Assert(form.File["snap"], check.HasLen, 2)
+			c.Check(form.File["snap"][0].Filename, check.Equals, "one.snap")
+			c.Check(form.File["snap"][1].Filename, check.Equals, "two.snap")
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+		case 2:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"status": "Doing"}}`)
+		case 3:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done", "data": {"snap-names": ["one","two"]}}}`)
+		case 4:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+			fmt.Fprintf(w, `{"type": "sync", "result": [{"name": "one", "status": "active", "version": "1.0", "developer": "bar", "publisher": {"id": "bar-id", "username": "bar", "display-name": "Bar", "validation": "unproven"}, "revision":42},{"name": "two", "status": "active", "version": "2.0", "developer": "baz", "publisher": {"id": "baz-id", "username": "baz", "display-name": "Baz", "validation": "unproven"}, "revision":42}]}\n`)
+		default:
+			c.Fatalf("expected to get %d requests, now on %d", total, n+1)
+		}
+
+		n++
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs(append([]string{"install", "--with-asserts", assertsDir}, paths...))
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Matches, `(?sm).*one 1.0 from Bar installed`)
+	c.Check(s.Stdout(), check.Matches, `(?sm).*two 2.0 from Baz installed`)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(n, check.Equals, total)
+}
+
+func (s *SnapOpSuite) TestInstallWithAssertsErrors(c *check.C) {
+	s.RedirectClientToTestServer(nil)
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"install", "--with-asserts", c.MkDir(), "store-snap"})
+	c.Assert(err, check.ErrorMatches, `--with-asserts can only be used when installing snap files`)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"install", "--beta", "./one.snap", "./two.snap"})
+	c.Assert(err, check.ErrorMatches, `cannot specify channel, revision or cohort when installing snap files`)
 }
 
 func (s *SnapOpSuite) TestInstallMany(c *check.C) {
@@ -0,0 +1,103 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortUsageHelp = i18n.G("Show the resources used by snaps")
+var longUsageHelp = i18n.G(`
+The usage command shows the disk space used by each installed snap, split
+between the snap files of the revisions kept on the system, the snap files kept
+only in the download cache, the system data of these revisions, the system data
+common to all revisions and the snapshots of the snap, as well as the memory
+currently used by its services and the CPU time they consumed.
+
+The disk space, memory and CPU time used by the snaps of each quota group is
+shown as well.
+`)
+
+type cmdUsage struct {
+	clientMixin
+}
+
+func init() {
+	addCommand("usage", shortUsageHelp, longUsageHelp, func() flags.Commander { return &cmdUsage{} }, nil, nil)
+}
+
+func fmtUsage(size quantity.Size) string {
+	if size == 0 {
+		return "-"
+	}
+	return strings.TrimSpace(fmtSize(int64(size)))
+}
+
+func fmtCPUTime(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Truncate(time.Millisecond).String()
+}
+
+func (x *cmdUsage) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	res, err := x.client.SystemResources()
+	if err != nil {
+		return err
+	}
+	if len(res.Snaps) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No snaps are installed yet."))
+		return nil
+	}
+
+	w := tabWriter()
+	fmt.Fprintln(w, i18n.G("Snap\tBlobs\tCache\tData\tCommon\tSnapshots\tMemory\tCPU\tQuota"))
+	for _, u := range res.Snaps {
+		quotaGroup := u.QuotaGroup
+		if quotaGroup == "" {
+			quotaGroup = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", u.Snap, fmtUsage(u.Blobs), fmtUsage(u.Cache), fmtUsage(u.Data), fmtUsage(u.Common), fmtUsage(u.Snapshots), fmtUsage(u.Memory), fmtCPUTime(u.CPUTime), quotaGroup)
+	}
+	w.Flush()
+
+	if len(res.QuotaGroups) == 0 {
+		return nil
+	}
+	fmt.Fprintln(Stdout)
+	w = tabWriter()
+	fmt.Fprintln(w, i18n.G("Quota\tDisk\tMemory\tCPU"))
+	for _, g := range res.QuotaGroups {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", g.GroupName, fmtUsage(g.Disk), fmtUsage(g.Memory), fmtCPUTime(g.CPUTime))
+	}
+	w.Flush()
+	return nil
+}
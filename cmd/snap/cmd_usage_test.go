@@ -0,0 +1,93 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) mockSystemResources(c *check.C, body string) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(n, check.Equals, 1)
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/system-resources")
+		fmt.Fprintln(w, body)
+	})
+}
+
+func (s *SnapSuite) TestUsage(c *check.C) {
+	s.mockSystemResources(c, `{"type": "sync", "result": {
+		"snaps": [
+			{"snap": "bar"},
+			{"snap": "foo", "quota-group": "grp", "blobs": 300, "cache": 40, "data": 10, "common": 5, "snapshots": 7, "memory": 9900, "cpu-time": 2500400000}
+		],
+		"quota-groups": [
+			{"group-name": "grp", "snaps": ["foo"], "disk": 362, "memory": 9900, "cpu-time": 2500400000}
+		]
+	}}`)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"usage"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stdout(), check.Equals, `
+Snap  Blobs  Cache  Data  Common  Snapshots  Memory  CPU   Quota
+bar   -      -      -     -       -          -       -     -
+foo   300B   40B    10B   5B      7B         9.9kB   2.5s  grp
+
+Quota  Disk  Memory  CPU
+grp    362B  9.9kB   2.5s
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestUsageNoQuotaGroups(c *check.C) {
+	s.mockSystemResources(c, `{"type": "sync", "result": {
+		"snaps": [{"snap": "foo", "blobs": 300}]
+	}}`)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"usage"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `
+Snap  Blobs  Cache  Data  Common  Snapshots  Memory  CPU  Quota
+foo   300B   -      -     -       -          -       -    -
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestUsageNoSnaps(c *check.C) {
+	s.mockSystemResources(c, `{"type": "sync", "result": {"snaps": []}}`)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"usage"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "No snaps are installed yet.\n")
+}
+
+func (s *SnapSuite) TestUsageExtraArgs(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"usage", "foo"})
+	c.Assert(err, check.ErrorMatches, "too many arguments for command")
+}
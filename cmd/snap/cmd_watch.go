@@ -20,29 +20,52 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
 )
 
-type cmdWatch struct{ changeIDMixin }
+type cmdWatch struct {
+	changeIDMixin
+	All bool `long:"all"`
+}
 
 var shortWatchHelp = i18n.G("Watch a change in progress")
 var longWatchHelp = i18n.G(`
 The watch command waits for the given change-id to finish and shows progress
 (if available).
+
+With --all, a continuously updated table of all the changes in progress is
+shown instead, together with the progress of their tasks and the logs of any
+task in error, until no change is left in progress.
 `)
 
 func init() {
 	addCommand("watch", shortWatchHelp, longWatchHelp, func() flags.Commander {
 		return &cmdWatch{}
-	}, changeIDMixinOptDesc, changeIDMixinArgDesc)
+	}, changeIDMixinOptDesc.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"all": i18n.G("Watch all the changes in progress"),
+	}), changeIDMixinArgDesc)
 }
 
 func (x *cmdWatch) Execute(args []string) error {
 	if len(args) > 0 {
 		return ErrExtraArgs
 	}
+	if x.All {
+		if x.Positional.ID != "" || x.LastChangeType != "" {
+			return fmt.Errorf(i18n.G("cannot use --all with a change ID or --last"))
+		}
+		return x.watchAll()
+	}
 	id, err := x.GetChangeID()
 	if err != nil {
 		if err == noChangeFoundOK {
@@ -59,3 +82,93 @@ func (x *cmdWatch) Execute(args []string) error {
 
 	return err
 }
+
+// watchAll shows the changes in progress until there are none left,
+// redrawing them in place on a terminal and otherwise writing out
+// every updated view.
+func (x *cmdWatch) watchAll() error {
+	tMax := time.Time{}
+	var last string
+	// XXX: snapd cannot stream updates to changes yet, so poll them
+	for {
+		chgs, err := x.client.Changes(&client.ChangesOptions{Selector: client.ChangesInProgress})
+		if err != nil {
+			if e, ok := err.(*client.Error); ok {
+				return e
+			}
+			// the server most likely went away, see waitMixin.wait
+			now := time.Now()
+			if tMax.IsZero() {
+				tMax = now.Add(maxGoneTime)
+			}
+			if now.After(tMax) {
+				return err
+			}
+			time.Sleep(pollTime)
+			continue
+		}
+		tMax = time.Time{}
+
+		if len(chgs) == 0 {
+			if last == "" {
+				fmt.Fprintln(Stderr, i18n.G("no changes in progress"))
+			}
+			return nil
+		}
+
+		cur := renderChangesInProgress(chgs)
+		if cur != last {
+			switch {
+			case isStdoutTTY:
+				// move to the top-left corner and clear the screen
+				fmt.Fprint(Stdout, "\033[H\033[2J")
+			case last != "":
+				fmt.Fprintln(Stdout)
+			}
+			fmt.Fprint(Stdout, cur)
+			last = cur
+		}
+		time.Sleep(pollTime)
+	}
+}
+
+func renderChangesInProgress(chgs []*client.Change) string {
+	sort.Sort(changesByTime(chgs))
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 5, 3, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.G("ID\tStatus\tProgress\tSummary"))
+	var inError []*client.Task
+	for _, chg := range chgs {
+		fmt.Fprintf(w, "%s\t%s\t-\t%s\n", chg.ID, chg.Status, chg.Summary)
+		for _, t := range chg.Tasks {
+			switch t.Status {
+			case "Done", "Hold", "Undone":
+				continue
+			case "Error":
+				inError = append(inError, t)
+			}
+			progress := "-"
+			if t.Progress.Total > 1 {
+				progress = fmt.Sprintf("%.2f%%", float64(t.Progress.Done)/float64(t.Progress.Total)*100.0)
+			}
+			fmt.Fprintf(w, "\t%s\t%s\t%s\n", t.Status, progress, t.Summary)
+		}
+	}
+	w.Flush()
+
+	for _, t := range inError {
+		if len(t.Log) == 0 {
+			continue
+		}
+		fmt.Fprintln(&buf)
+		fmt.Fprintln(&buf, line)
+		fmt.Fprintln(&buf, t.Summary)
+		fmt.Fprintln(&buf)
+		for _, l := range t.Log {
+			fmt.Fprintln(&buf, l)
+		}
+	}
+
+	return buf.String()
+}
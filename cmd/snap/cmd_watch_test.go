@@ -152,3 +152,81 @@ func (s *SnapSuite) TestWatchLastQuestionmark(c *C) {
 
 	c.Check(n, Equals, 4)
 }
+
+var mockWatchAllChangesJSON = `{"type": "sync", "result": [
+  {
+    "id":   "42",
+    "kind": "install-snap",
+    "summary": "Install \"foo\" snap",
+    "status": "Doing",
+    "ready": false,
+    "spawn-time": "2016-04-21T01:02:03Z",
+    "tasks": [
+      {"id": "1", "kind": "prerequisites", "summary": "Ensure prerequisites for \"foo\" are available", "status": "Done", "progress": {"done": 1, "total": 1}, "spawn-time": "2016-04-21T01:02:03Z"},
+      {"id": "2", "kind": "download-snap", "summary": "Download snap \"foo\"", "status": "Doing", "progress": {"label": "foo", "done": 50, "total": 100}, "spawn-time": "2016-04-21T01:02:03Z"},
+      {"id": "3", "kind": "setup-profiles", "summary": "Setup snap \"foo\" security profiles", "status": "Error", "log": ["2016-04-21T01:02:04Z ERROR boom"], "progress": {"done": 1, "total": 1}, "spawn-time": "2016-04-21T01:02:03Z"}
+    ]
+  }
+]}`
+
+func (s *SnapSuite) TestWatchAll(c *C) {
+	defer snap.MockMaxGoneTime(time.Millisecond)()
+	defer snap.MockPollTime(time.Millisecond)()
+	defer snap.MockIsStdoutTTY(false)()
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/changes")
+		c.Check(r.URL.Query().Get("select"), Equals, "in-progress")
+		switch n {
+		case 1, 2:
+			// unchanged views are shown only once
+			fmt.Fprintln(w, mockWatchAllChangesJSON)
+		case 3:
+			fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+		default:
+			c.Errorf("expected 3 queries, currently on %d", n)
+		}
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"watch", "--all"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, HasLen, 0)
+	c.Check(n, Equals, 3)
+	c.Check(s.Stdout(), Equals, `ID   Status  Progress  Summary
+42   Doing   -         Install "foo" snap
+     Doing   50.00%    Download snap "foo"
+     Error   -         Setup snap "foo" security profiles
+
+......................................................................
+Setup snap "foo" security profiles
+
+2016-04-21T01:02:04Z ERROR boom
+`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestWatchAllNothingInProgress(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.URL.Path, Equals, "/v2/changes")
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"watch", "--all"})
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 1)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, "no changes in progress\n")
+}
+
+func (s *SnapSuite) TestWatchAllWithChangeID(c *C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"watch", "--all", "42"})
+	c.Assert(err, ErrorMatches, "cannot use --all with a change ID or --last")
+
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"watch", "--all", "--last=install"})
+	c.Assert(err, ErrorMatches, "cannot use --all with a change ID or --last")
+}
@@ -61,6 +61,37 @@ func installedSnapNames(snaps []installedSnapName) []string {
 	return names
 }
 
+type channelName string
+
+func (s channelName) Complete(match string) []flags.Completion {
+	var ret []flags.Completion
+	seen := make(map[string]bool)
+	add := func(ch, desc string) {
+		if seen[ch] || !strings.HasPrefix(ch, match) {
+			return
+		}
+		seen[ch] = true
+		ret = append(ret, flags.Completion{Item: ch, Description: desc})
+	}
+
+	for _, risk := range channelRisks {
+		add(risk, "")
+	}
+
+	// also offer the channels installed snaps are tracking
+	snaps, err := mkClient().List(nil, nil)
+	if err != nil {
+		return ret
+	}
+	for _, snap := range snaps {
+		if snap.TrackingChannel != "" {
+			add(snap.TrackingChannel, fmt.Sprintf(i18n.G("tracked by %s"), snap.Name))
+		}
+	}
+
+	return ret
+}
+
 func completeFromSortedFile(filename, match string) ([]flags.Completion, error) {
 	file, err := os.Open(filename)
 	if err != nil {
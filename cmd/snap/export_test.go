@@ -32,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/sandbox/selinux"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/sysconfig"
 )
 
 var RunMain = run
@@ -88,7 +89,9 @@ var (
 
 	SortTimingsTasks = sortTimingsTasks
 
-	PrintInstallHint = printInstallHint
+	PrintInstallHint       = printInstallHint
+	PrintMirrorInstallHint = printMirrorInstallHint
+	SnapPrerequisites      = snapPrerequisites
 
 	IsStopping = isStopping
 
@@ -163,6 +166,14 @@ func MockSyscallExec(f func(string, []string, []string) error) (restore func())
 	}
 }
 
+func MockSnapdBuildID(f func() (string, error)) (restore func()) {
+	old := snapdBuildID
+	snapdBuildID = f
+	return func() {
+		snapdBuildID = old
+	}
+}
+
 func MockUserCurrent(f func() (*user.User, error)) (restore func()) {
 	userCurrentOrig := userCurrent
 	userCurrent = f
@@ -332,6 +343,8 @@ func MockSignalNotify(newSignalNotify func(sig ...os.Signal) (chan os.Signal, fu
 
 type ServiceName = serviceName
 
+type ChannelName = channelName
+
 func MockCreateTransientScopeForTracking(fn func(securityTag string, opts *cgroup.TrackingOptions) error) (restore func()) {
 	old := cgroupCreateTransientScopeForTracking
 	cgroupCreateTransientScopeForTracking = fn
@@ -388,6 +401,14 @@ func MockDownloadDirect(f func(snapName string, revision snap.Revision, dlOpts i
 	}
 }
 
+func MockDownloadMirror(f func(snapName string, revision snap.Revision, dlOpts image.DownloadOptions) error) (restore func()) {
+	old := downloadMirror
+	downloadMirror = f
+	return func() {
+		downloadMirror = old
+	}
+}
+
 func MockSnapdAPIInterval(t time.Duration) (restore func()) {
 	old := snapdAPIInterval
 	snapdAPIInterval = t
@@ -411,3 +432,43 @@ func MockOsChmod(f func(string, os.FileMode) error) (restore func()) {
 		osChmod = old
 	}
 }
+
+func MockDoctorCgroupVersion(f func() (int, error)) (restore func()) {
+	old := cgroupVersion
+	cgroupVersion = f
+	return func() {
+		cgroupVersion = old
+	}
+}
+
+func MockDoctorSquashfsNeedsFuse(f func() bool) (restore func()) {
+	old := squashfsNeedsFuse
+	squashfsNeedsFuse = f
+	return func() {
+		squashfsNeedsFuse = old
+	}
+}
+
+func MockDoctorCloudInitStatus(f func() (sysconfig.CloudInitState, error)) (restore func()) {
+	old := sysconfigCloudInitStatus
+	sysconfigCloudInitStatus = f
+	return func() {
+		sysconfigCloudInitStatus = old
+	}
+}
+
+func MockDoctorCheckFreeSpace(f func(path string, minSize uint64) error) (restore func()) {
+	old := osutilCheckFreeSpace
+	osutilCheckFreeSpace = f
+	return func() {
+		osutilCheckFreeSpace = old
+	}
+}
+
+func MockDoctorProcFilesystems(path string) (restore func()) {
+	old := procFilesystems
+	procFilesystems = path
+	return func() {
+		procFilesystems = old
+	}
+}
@@ -0,0 +1,74 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+// formatMixin provides the --format option for commands that can
+// emit their output as machine-readable structures instead of
+// columns meant for humans.
+type formatMixin struct {
+	Format string `long:"format" choice:"json" choice:"yaml"`
+}
+
+var formatDescs = mixinDescs{
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"format": i18n.G("Output in the given machine-readable format instead (json or yaml)"),
+}
+
+// machineReadable returns whether a machine-readable output format
+// was requested.
+func (mx formatMixin) machineReadable() bool {
+	return mx.Format != ""
+}
+
+// writeFormatted writes v to Stdout in the requested machine-readable
+// format. The types of v are expected to carry both json and yaml
+// field tags.
+func (mx formatMixin) writeFormatted(v interface{}) error {
+	switch mx.Format {
+	case "json":
+		enc := json.NewEncoder(Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		enc := yaml.NewEncoder(Stdout)
+		defer enc.Close()
+		return enc.Encode(v)
+	}
+	return fmt.Errorf("internal error: unsupported output format %q", mx.Format)
+}
+
+// fmtTimeFormatted formats t for machine-readable output, the zero
+// time is formatted as "".
+func fmtTimeFormatted(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -110,6 +110,19 @@ func (n *Notes) String() string {
 	if n == nil {
 		return ""
 	}
+	ns := n.list()
+	if len(ns) == 0 {
+		return "-"
+	}
+
+	return strings.Join(ns, ",")
+}
+
+// list returns the individual notes that apply.
+func (n *Notes) list() []string {
+	if n == nil {
+		return nil
+	}
 	var ns []string
 
 	switch n.SnapType {
@@ -166,9 +179,5 @@ func (n *Notes) String() string {
 		ns = append(ns, n.Health)
 	}
 
-	if len(ns) == 0 {
-		return "-"
-	}
-
-	return strings.Join(ns, ",")
+	return ns
 }
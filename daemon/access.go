@@ -21,6 +21,7 @@ package daemon
 
 import (
 	"net/http"
+	"os/user"
 	"strconv"
 
 	"github.com/snapcore/snapd/client"
@@ -34,6 +35,40 @@ var polkitCheckAuthorization = polkit.CheckAuthorization
 
 var checkPolkitAction = checkPolkitActionImpl
 
+var (
+	checkLocalUserRole = checkLocalUserRoleImpl
+	userLookupId       = user.LookupId
+)
+
+// checkLocalUserRoleImpl checks that the local user the request was received
+// from was granted the role, if they are also a snapd user, as created by
+// create-user.
+func checkLocalUserRoleImpl(d *Daemon, ucred *ucrednet, role string) *apiError {
+	u, err := userLookupId(strconv.FormatUint(uint64(ucred.Uid), 10))
+	if _, ok := err.(user.UnknownUserIdError); ok {
+		// without a name the user cannot be a snapd user
+		return nil
+	}
+	if err != nil {
+		return InternalError("cannot look up user %d: %v", ucred.Uid, err)
+	}
+
+	st := d.state
+	st.Lock()
+	snapdUser, err := auth.UserByUsername(st, u.Username)
+	st.Unlock()
+	if err == auth.ErrInvalidUser {
+		return nil
+	}
+	if err != nil {
+		return InternalError("cannot check the roles of user %q: %v", u.Username, err)
+	}
+	if !snapdUser.HasRole(role) {
+		return Forbidden("access denied")
+	}
+	return nil
+}
+
 func checkPolkitActionImpl(r *http.Request, ucred *ucrednet, action string) *apiError {
 	var flags polkit.CheckFlags
 	allowHeader := r.Header.Get(client.AllowInteractionHeader)
@@ -95,8 +130,24 @@ func (ac openAccess) CheckAccess(d *Daemon, r *http.Request, ucred *ucrednet, us
 // A user is considered authenticated if they provide a macaroon, are
 // the root user according to peer credentials, or granted access by
 // Polkit.
+//
+// Users authenticated by macaroon also need to have been granted Role,
+// which defaults to read-only for GET requests and to system-admin
+// otherwise. The same applies to users granted access by Polkit that are
+// also snapd users.
 type authenticatedAccess struct {
 	Polkit string
+	Role   string
+}
+
+func (ac authenticatedAccess) requiredRole(r *http.Request) string {
+	if ac.Role != "" {
+		return ac.Role
+	}
+	if r.Method == "GET" {
+		return auth.RoleReadOnly
+	}
+	return auth.RoleSystemAdmin
 }
 
 func (ac authenticatedAccess) CheckAccess(d *Daemon, r *http.Request, ucred *ucrednet, user *auth.UserState) *apiError {
@@ -105,7 +156,10 @@ func (ac authenticatedAccess) CheckAccess(d *Daemon, r *http.Request, ucred *ucr
 	}
 
 	if user != nil {
-		return nil
+		if ucred.Uid == 0 || user.HasRole(ac.requiredRole(r)) {
+			return nil
+		}
+		return Forbidden("access denied")
 	}
 
 	if ucred.Uid == 0 {
@@ -116,6 +170,10 @@ func (ac authenticatedAccess) CheckAccess(d *Daemon, r *http.Request, ucred *ucr
 	// being prompted for authorisation. This should be avoided if
 	// access is otherwise granted.
 	if ac.Polkit != "" {
+		// checked first so that users are not prompted in vain
+		if rspe := checkLocalUserRole(d, ucred, ac.requiredRole(r)); rspe != nil {
+			return rspe
+		}
 		return checkPolkitAction(r, ucred, ac.Polkit)
 	}
 
@@ -20,8 +20,11 @@
 package daemon_test
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os/user"
+	"strconv"
 
 	. "gopkg.in/check.v1"
 
@@ -29,15 +32,26 @@ import (
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/polkit"
 	"github.com/snapcore/snapd/testutil"
 )
 
-type accessSuite struct{}
+type accessSuite struct {
+	testutil.BaseTest
+}
 
 var _ = Suite(&accessSuite{})
 
+func (s *accessSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	// none of the users are snapd users by default
+	s.AddCleanup(daemon.MockUserLookupId(func(uid string) (*user.User, error) {
+		return nil, user.UnknownUserIdError(42)
+	}))
+}
+
 var (
 	errForbidden    = daemon.Forbidden("access denied")
 	errUnauthorized = daemon.Unauthorized("access denied")
@@ -96,6 +110,36 @@ func (s *accessSuite) TestAuthenticatedAccess(c *C) {
 	c.Check(ac.CheckAccess(nil, req, ucred, nil), IsNil)
 }
 
+func (s *accessSuite) TestAuthenticatedAccessRoles(c *C) {
+	restore := daemon.MockCheckPolkitAction(func(r *http.Request, ucred *daemon.Ucrednet, action string) *daemon.APIError {
+		// Polkit is not consulted for users with macaroon auth
+		c.Fail()
+		return daemon.Forbidden("access denied")
+	})
+	defer restore()
+
+	get := httptest.NewRequest("GET", "/", nil)
+	post := httptest.NewRequest("POST", "/", nil)
+	ucred := &daemon.Ucrednet{Uid: 42, Pid: 100, Socket: dirs.SnapdSocket}
+	readOnly := &auth.UserState{Roles: []string{auth.RoleReadOnly}}
+	installer := &auth.UserState{Roles: []string{auth.RoleSnapInstall}}
+
+	// without an explicit role, read-only is needed to GET and
+	// system-admin otherwise
+	var ac daemon.AccessChecker = daemon.AuthenticatedAccess{Polkit: "action-id"}
+	c.Check(ac.CheckAccess(nil, get, ucred, readOnly), IsNil)
+	c.Check(ac.CheckAccess(nil, post, ucred, readOnly), DeepEquals, errForbidden)
+	c.Check(ac.CheckAccess(nil, post, ucred, installer), DeepEquals, errForbidden)
+
+	ac = daemon.AuthenticatedAccess{Polkit: "action-id", Role: auth.RoleSnapInstall}
+	c.Check(ac.CheckAccess(nil, post, ucred, readOnly), DeepEquals, errForbidden)
+	c.Check(ac.CheckAccess(nil, post, ucred, installer), IsNil)
+
+	// root is not restricted by the roles of the macaroon
+	ucred = &daemon.Ucrednet{Uid: 0, Pid: 100, Socket: dirs.SnapdSocket}
+	c.Check(ac.CheckAccess(nil, post, ucred, readOnly), IsNil)
+}
+
 func (s *accessSuite) TestAuthenticatedAccessPolkit(c *C) {
 	var ac daemon.AccessChecker = daemon.AuthenticatedAccess{Polkit: "action-id"}
 
@@ -128,6 +172,67 @@ func (s *accessSuite) TestAuthenticatedAccessPolkit(c *C) {
 	c.Check(ac.CheckAccess(nil, req, ucred, nil), IsNil)
 }
 
+func (s *accessSuite) TestAuthenticatedAccessPolkitRoles(c *C) {
+	o := overlord.Mock()
+	d := daemon.NewWithOverlord(o)
+	st := o.State()
+	st.Lock()
+	readOnly, err := auth.NewUser(st, "read-only-user", "", "", nil)
+	c.Assert(err, IsNil)
+	readOnly.Roles = []string{auth.RoleReadOnly}
+	c.Assert(auth.UpdateUser(st, readOnly), IsNil)
+	_, err = auth.NewUser(st, "unrestricted-user", "", "", nil)
+	c.Assert(err, IsNil)
+	st.Unlock()
+
+	usernames := map[uint32]string{1000: "read-only-user", 1001: "unrestricted-user"}
+	restore := daemon.MockUserLookupId(func(uid string) (*user.User, error) {
+		n, err := strconv.Atoi(uid)
+		c.Assert(err, IsNil)
+		if name, ok := usernames[uint32(n)]; ok {
+			return &user.User{Uid: uid, Username: name}, nil
+		}
+		return nil, user.UnknownUserIdError(n)
+	})
+	defer restore()
+	var polkitChecked []uint32
+	restore = daemon.MockCheckPolkitAction(func(r *http.Request, ucred *daemon.Ucrednet, action string) *daemon.APIError {
+		polkitChecked = append(polkitChecked, ucred.Uid)
+		return nil
+	})
+	defer restore()
+
+	get := httptest.NewRequest("GET", "/", nil)
+	post := httptest.NewRequest("POST", "/", nil)
+	var ac daemon.AccessChecker = daemon.AuthenticatedAccess{Polkit: "action-id"}
+
+	// snapd users authorized by polkit are restricted by their roles,
+	// without being prompted
+	ucred := &daemon.Ucrednet{Uid: 1000, Pid: 100, Socket: dirs.SnapdSocket}
+	c.Check(ac.CheckAccess(d, get, ucred, nil), IsNil)
+	c.Check(ac.CheckAccess(d, post, ucred, nil), DeepEquals, errForbidden)
+	c.Check(polkitChecked, DeepEquals, []uint32{1000})
+
+	// snapd users without roles are not
+	polkitChecked = nil
+	ucred = &daemon.Ucrednet{Uid: 1001, Pid: 100, Socket: dirs.SnapdSocket}
+	c.Check(ac.CheckAccess(d, post, ucred, nil), IsNil)
+	// nor are other local users
+	ucred = &daemon.Ucrednet{Uid: 1002, Pid: 100, Socket: dirs.SnapdSocket}
+	c.Check(ac.CheckAccess(d, post, ucred, nil), IsNil)
+	c.Check(polkitChecked, DeepEquals, []uint32{1001, 1002})
+
+	// failing to look up the user denies access
+	restore = daemon.MockUserLookupId(func(uid string) (*user.User, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	defer restore()
+	rspe := ac.CheckAccess(d, post, ucred, nil)
+	c.Assert(rspe, NotNil)
+	c.Check(rspe.Status, Equals, 500)
+	c.Check(rspe.Message, Equals, "cannot look up user 1002: boom")
+}
+
 func (s *accessSuite) TestCheckPolkitActionImpl(c *C) {
 	logbuf, restore := logger.MockLogger()
 	defer restore()
@@ -47,7 +47,11 @@ var api = []*Command{
 	snapFileCmd,
 	snapDownloadCmd,
 	snapConfCmd,
+	snapConfSchemaCmd,
 	interfacesCmd,
+	interfacesHistoryCmd,
+	promptingRulesCmd,
+	noticesCmd,
 	assertsCmd,
 	assertsFindManyCmd,
 	stateChangeCmd,
@@ -70,15 +74,24 @@ var api = []*Command{
 	modelCmd,
 	cohortsCmd,
 	serialModelCmd,
+	registrationModelCmd,
 	systemsCmd,
 	systemsActionCmd,
+	installProgressCmd,
 	themesCmd,
+	themesReportCmd,
 	validationSetsListCmd,
 	validationSetsCmd,
 	routineConsoleConfStartCmd,
 	systemRecoveryKeysCmd,
 	quotaGroupsCmd,
 	quotaGroupInfoCmd,
+	apiAuditCmd,
+	systemResourcesCmd,
+	metricsCmd,
+	systemMaintenanceCmd,
+	seedSupplementCmd,
+	registryCmd,
 }
 
 const (
@@ -131,17 +144,20 @@ func storeFrom(d *Daemon) snapstate.StoreService {
 }
 
 var (
-	snapstateInstall           = snapstate.Install
-	snapstateInstallPath       = snapstate.InstallPath
-	snapstateRefreshCandidates = snapstate.RefreshCandidates
-	snapstateTryPath           = snapstate.TryPath
-	snapstateUpdate            = snapstate.Update
-	snapstateUpdateMany        = snapstate.UpdateMany
-	snapstateInstallMany       = snapstate.InstallMany
-	snapstateRemoveMany        = snapstate.RemoveMany
-	snapstateRevert            = snapstate.Revert
-	snapstateRevertToRevision  = snapstate.RevertToRevision
-	snapstateSwitch            = snapstate.Switch
+	snapstateInstall               = snapstate.Install
+	snapstateInstallPath           = snapstate.InstallPath
+	snapstateInstallPathMany       = snapstate.InstallPathMany
+	snapstateRefreshCandidates     = snapstate.RefreshCandidates
+	snapstateTryPath               = snapstate.TryPath
+	snapstateUpdate                = snapstate.Update
+	snapstateUpdateMany            = snapstate.UpdateMany
+	snapstateApplyPendingRefreshes = snapstate.ApplyPendingRefreshes
+	snapstateInstallMany           = snapstate.InstallMany
+	snapstateRemoveMany            = snapstate.RemoveMany
+	snapstateRevert                = snapstate.Revert
+	snapstateRevertToRevision      = snapstate.RevertToRevision
+	snapstateDowngrade             = snapstate.Downgrade
+	snapstateSwitch                = snapstate.Switch
 
 	assertstateRefreshSnapDeclarations = assertstate.RefreshSnapDeclarations
 )
@@ -47,6 +47,8 @@ type aliasAction struct {
 	Snap   string `json:"snap"`
 	App    string `json:"app"`
 	Alias  string `json:"alias"`
+	// Args are prepended to the arguments of a manual alias
+	Args []string `json:"args,omitempty"`
 	// old now unsupported api
 	Aliases []string `json:"aliases"`
 }
@@ -72,7 +74,7 @@ func changeAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 	default:
 		return BadRequest("unsupported alias action: %q", a.Action)
 	case "alias":
-		taskset, err = snapstate.Alias(st, a.Snap, a.App, a.Alias)
+		taskset, err = snapstate.AliasWithArgs(st, a.Snap, a.App, a.Alias, a.Args)
 	case "unalias":
 		if a.Alias == a.Snap {
 			// Do What I mean:
@@ -121,10 +123,11 @@ func changeAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 }
 
 type aliasStatus struct {
-	Command string `json:"command"`
-	Status  string `json:"status"`
-	Manual  string `json:"manual,omitempty"`
-	Auto    string `json:"auto,omitempty"`
+	Command string   `json:"command"`
+	Status  string   `json:"status"`
+	Manual  string   `json:"manual,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	Auto    string   `json:"auto,omitempty"`
 }
 
 // getAliases produces a response with a map snap -> alias -> aliasStatus
@@ -160,6 +163,7 @@ func getAliases(c *Command, r *http.Request, user *auth.UserState) Response {
 					tgt = aliasTarget.Auto
 				} else if aliasTarget.Manual != "" {
 					status = "manual"
+					aliasStatus.Args = aliasTarget.EffectiveArgs()
 				}
 				aliasStatus.Status = status
 				aliasStatus.Command = snap.JoinSnapApp(snapName, tgt)
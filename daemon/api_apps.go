@@ -27,6 +27,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/servicestate"
@@ -43,7 +44,7 @@ var (
 		GET:         getAppsInfo,
 		POST:        postApps,
 		ReadAccess:  openAccess{},
-		WriteAccess: authenticatedAccess{},
+		WriteAccess: authenticatedAccess{Role: auth.RoleServiceControl},
 	}
 
 	logsCmd = &Command{
@@ -66,13 +67,25 @@ func getAppsInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("invalid select parameter: %q", sel)
 	}
 
+	var sd *servicestate.StatusDecorator
+	switch users := client.UserSelector(query.Get("users")); users {
+	case "":
+		sd = servicestate.NewStatusDecorator(progress.Null)
+	case client.UsersSelf:
+		ucred, err := ucrednetGet(r.RemoteAddr)
+		if err != nil {
+			return Forbidden("cannot get remote user: %s", err)
+		}
+		sd = servicestate.NewUserStatusDecorator(progress.Null, int(ucred.Uid))
+	default:
+		return BadRequest("invalid users parameter: %q", users)
+	}
+
 	appInfos, rspe := appInfosFor(c.d.overlord.State(), strutil.CommaSeparatedList(query.Get("names")), opts)
 	if rspe != nil {
 		return rspe
 	}
 
-	sd := servicestate.NewStatusDecorator(progress.Null)
-
 	clientAppInfos, err := clientutil.ClientAppInfosFromSnapAppInfos(appInfos, sd)
 	if err != nil {
 		return InternalError("%v", err)
@@ -239,6 +252,18 @@ func postApps(c *Command, r *http.Request, user *auth.UserState) Response {
 		// on POST, don't allow empty to mean all
 		return BadRequest("cannot perform operation on services without a list of services to operate on")
 	}
+	switch inst.Users {
+	case "", client.UsersAll:
+		// nothing to do
+	case client.UsersSelf:
+		ucred, err := ucrednetGet(r.RemoteAddr)
+		if err != nil {
+			return Forbidden("cannot get remote user: %s", err)
+		}
+		inst.RequesterUID = int(ucred.Uid)
+	default:
+		return BadRequest("invalid users parameter: %q", inst.Users)
+	}
 
 	st := c.d.overlord.State()
 	appInfos, rspe := appInfosFor(st, inst.Names, appInfoOptions{service: true})
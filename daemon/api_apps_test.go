@@ -37,6 +37,8 @@ import (
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -83,6 +85,8 @@ type serviceControlArgs struct {
 	action  string
 	options string
 	names   []string
+	users   client.UserSelector
+	uid     int
 }
 
 func (s *appsSuite) fakeServiceControl(st *state.State, appInfos []*snap.AppInfo, inst *servicestate.Instruction, flags *servicestate.Flags, context *hookstate.Context) ([]*state.TaskSet, error) {
@@ -94,7 +98,7 @@ func (s *appsSuite) fakeServiceControl(st *state.State, appInfos []*snap.AppInfo
 		return nil, s.serviceControlError
 	}
 
-	serviceCommand := serviceControlArgs{action: inst.Action}
+	serviceCommand := serviceControlArgs{action: inst.Action, users: inst.Users, uid: inst.RequesterUID}
 	if inst.RestartOptions.Reload {
 		serviceCommand.options = "reload"
 	}
@@ -135,6 +139,8 @@ func (s *appsSuite) SetUpTest(c *check.C) {
 	s.jctlRCs = nil
 	s.jctlErrs = nil
 
+	s.expectWriteAccess(daemon.AuthenticatedAccess{Role: auth.RoleServiceControl})
+
 	d := s.daemon(c)
 
 	s.serviceControlCalls = nil
@@ -325,6 +331,15 @@ func (s *appsSuite) TestGetAppsInfoBadSelect(c *check.C) {
 	c.Assert(rspe.Status, check.Equals, 400)
 }
 
+func (s *appsSuite) TestGetAppsInfoBadUsers(c *check.C) {
+	req, err := http.NewRequest("GET", "/v2/apps?select=service&users=potato", nil)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Assert(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid users parameter: "potato"`)
+}
+
 func (s *appsSuite) TestGetAppsInfoBadName(c *check.C) {
 	req, err := http.NewRequest("GET", "/v2/apps?names=potato", nil)
 	c.Assert(err, check.IsNil)
@@ -572,6 +587,42 @@ func (s *appsSuite) TestPostAppsDisableNow(c *check.C) {
 	s.testPostApps(c, inst, expected)
 }
 
+func (s *appsSuite) TestPostAppsUsersSelf(c *check.C) {
+	req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBufferString(`{"action": "restart", "names": ["snap-a.svc2"], "users": "self"}`))
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=1000;socket=%s;", dirs.SnapdSocket)
+
+	rsp := s.asyncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 202)
+	c.Check(s.serviceControlCalls, check.DeepEquals, []serviceControlArgs{
+		{action: "restart", names: []string{"snap-a.svc2"}, users: client.UsersSelf, uid: 1000},
+	})
+}
+
+func (s *appsSuite) TestPostAppsUsersAll(c *check.C) {
+	inst := servicestate.Instruction{Action: "stop", Names: []string{"snap-a.svc2"}, Users: client.UsersAll}
+	expected := []serviceControlArgs{
+		{action: "stop", names: []string{"snap-a.svc2"}, users: client.UsersAll},
+	}
+	s.testPostApps(c, inst, expected)
+}
+
+func (s *appsSuite) TestPostAppsBadUsers(c *check.C) {
+	req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBufferString(`{"action": "stop", "names": ["snap-a.svc2"], "users": "potato"}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid users parameter: "potato"`)
+}
+
+func (s *appsSuite) TestPostAppsUsersSelfNoUser(c *check.C) {
+	req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBufferString(`{"action": "stop", "names": ["snap-a.svc2"], "users": "self"}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 403)
+	c.Check(rspe.Message, check.Matches, `cannot get remote user: .*`)
+}
+
 func (s *appsSuite) TestPostAppsBadJSON(c *check.C) {
 	req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBufferString(`'junk`))
 	c.Assert(err, check.IsNil)
@@ -0,0 +1,237 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/sandbox/cgroup"
+)
+
+var apiAuditCmd = &Command{
+	Path:       "/v2/debug/api-audit",
+	GET:        getAPIAudit,
+	ReadAccess: rootAccess{},
+}
+
+// apiAuditLogMaxSize is the size from which the API audit log is rotated.
+var apiAuditLogMaxSize int64 = 4 * 1024 * 1024
+
+// apiAuditLogRotations is the number of rotated API audit logs kept.
+const apiAuditLogRotations = 3
+
+// apiAuditSyncInterval is the minimum interval between two syncs of the API
+// audit log to disk. Syncing after each entry would make every mutating
+// request, including the frequent snapctl ones, wait for the disk. The
+// trade-off is that the entries recorded since the last sync can be lost on
+// a crash or power failure. The log is also synced when snapd stops.
+var apiAuditSyncInterval = 5 * time.Second
+
+var (
+	cgroupSnapNameFromPid = cgroup.SnapNameFromPid
+	apiAuditTimeNow       = time.Now
+)
+
+// apiAuditMu serializes the writes to and the rotations of the API audit
+// log, as well as the accesses to its sync state.
+var apiAuditMu sync.Mutex
+
+var (
+	apiAuditLastSync time.Time
+	apiAuditUnsynced bool
+
+	apiAuditFileSync = (*os.File).Sync
+)
+
+func apiAuditLogPath(n int) string {
+	if n == 0 {
+		return dirs.SnapAPIAuditLogFile
+	}
+	return fmt.Sprintf("%s.%d", dirs.SnapAPIAuditLogFile, n)
+}
+
+// newAPIAuditEntry returns the audit entry of a mutating request, to be
+// completed once the request has been served.
+func newAPIAuditEntry(r *http.Request, ucred *ucrednet) *client.APIAuditEntry {
+	entry := &client.APIAuditEntry{
+		Time:   apiAuditTimeNow(),
+		Method: r.Method,
+		Path:   r.URL.Path,
+	}
+	if ucred != nil {
+		entry.UID = ucred.Uid
+		if ucred.Socket == dirs.SnapSocket {
+			if snapName, err := cgroupSnapNameFromPid(int(ucred.Pid)); err == nil {
+				entry.Snap = snapName
+			}
+		}
+	}
+	return entry
+}
+
+// recordAPIAudit completes the entry with the summary of the change it
+// started, if any, and appends it to the API audit log.
+func (d *Daemon) recordAPIAudit(entry *client.APIAuditEntry) {
+	if entry.Change != "" {
+		d.state.Lock()
+		if chg := d.state.Change(entry.Change); chg != nil {
+			entry.Summary = chg.Summary()
+		}
+		d.state.Unlock()
+	}
+	if err := appendAPIAuditEntry(entry); err != nil {
+		logger.Noticef("Cannot record API request in the audit log: %v", err)
+	}
+}
+
+func appendAPIAuditEntry(entry *client.APIAuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	apiAuditMu.Lock()
+	defer apiAuditMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(dirs.SnapAPIAuditLogFile), 0755); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(dirs.SnapAPIAuditLogFile); err == nil && fi.Size()+int64(len(line)) > apiAuditLogMaxSize {
+		// do not rotate away entries that were never synced
+		if err := syncAPIAuditLog(); err != nil {
+			return err
+		}
+		if err := rotateAPIAuditLog(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(dirs.SnapAPIAuditLogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(line); err != nil {
+		return err
+	}
+	apiAuditUnsynced = true
+	if apiAuditTimeNow().Sub(apiAuditLastSync) < apiAuditSyncInterval {
+		return nil
+	}
+	return syncAPIAuditLogFile(f)
+}
+
+func syncAPIAuditLogFile(f *os.File) error {
+	if err := apiAuditFileSync(f); err != nil {
+		return err
+	}
+	apiAuditUnsynced = false
+	apiAuditLastSync = apiAuditTimeNow()
+	return nil
+}
+
+// syncAPIAuditLog syncs the current API audit log to disk if entries were
+// written to it since the last sync, the lock must be held.
+func syncAPIAuditLog() error {
+	if !apiAuditUnsynced {
+		return nil
+	}
+	f, err := os.OpenFile(dirs.SnapAPIAuditLogFile, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return syncAPIAuditLogFile(f)
+}
+
+// flushAPIAuditLog syncs to disk the API audit log entries that were not
+// synced yet.
+func flushAPIAuditLog() {
+	apiAuditMu.Lock()
+	defer apiAuditMu.Unlock()
+
+	if err := syncAPIAuditLog(); err != nil {
+		logger.Noticef("Cannot sync the API audit log: %v", err)
+	}
+}
+
+// rotateAPIAuditLog shifts the rotated logs, dropping the oldest one, and
+// rotates the current log, the lock must be held.
+func rotateAPIAuditLog() error {
+	for n := apiAuditLogRotations; n > 0; n-- {
+		if err := os.Rename(apiAuditLogPath(n-1), apiAuditLogPath(n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// readAPIAuditLog returns the entries of the rotated and current API audit
+// logs, oldest first.
+func readAPIAuditLog() ([]*client.APIAuditEntry, error) {
+	apiAuditMu.Lock()
+	defer apiAuditMu.Unlock()
+
+	entries := []*client.APIAuditEntry{}
+	for n := apiAuditLogRotations; n >= 0; n-- {
+		f, err := os.Open(apiAuditLogPath(n))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry client.APIAuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("cannot decode entry of %s: %v", f.Name(), err)
+			}
+			entries = append(entries, &entry)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func getAPIAudit(c *Command, r *http.Request, user *auth.UserState) Response {
+	entries, err := readAPIAuditLog()
+	if err != nil {
+		return InternalError("cannot read API audit log: %v", err)
+	}
+	return SyncResponse(entries)
+}
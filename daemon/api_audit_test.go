@@ -0,0 +1,169 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+)
+
+var _ = check.Suite(&apiAuditSuite{})
+
+type apiAuditSuite struct {
+	apiBaseSuite
+}
+
+func (s *apiAuditSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.RootAccess{})
+}
+
+func auditEntry(n int) *client.APIAuditEntry {
+	return &client.APIAuditEntry{
+		Time:   time.Date(2021, 6, 1, 10, n, 0, 0, time.UTC),
+		UID:    1000,
+		Method: "POST",
+		Path:   fmt.Sprintf("/v2/snaps/snap-%d", n),
+		Status: 202,
+		Change: fmt.Sprint(n),
+	}
+}
+
+func (s *apiAuditSuite) TestGetAPIAuditEmpty(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug/api-audit", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, []*client.APIAuditEntry{})
+}
+
+func (s *apiAuditSuite) TestGetAPIAudit(c *check.C) {
+	s.daemon(c)
+
+	c.Assert(daemon.AppendAPIAuditEntry(auditEntry(1)), check.IsNil)
+	c.Assert(daemon.AppendAPIAuditEntry(auditEntry(2)), check.IsNil)
+
+	req, err := http.NewRequest("GET", "/v2/debug/api-audit", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, []*client.APIAuditEntry{auditEntry(1), auditEntry(2)})
+}
+
+func (s *apiAuditSuite) TestAPIAuditLogRotation(c *check.C) {
+	s.daemon(c)
+
+	// each entry is a bit more than 100 bytes, so that the log is rotated
+	// every two entries
+	defer daemon.MockAPIAuditLogMaxSize(300)()
+
+	for n := 1; n <= 10; n++ {
+		c.Assert(daemon.AppendAPIAuditEntry(auditEntry(n)), check.IsNil)
+	}
+
+	// only 3 rotated logs are kept
+	matches, err := filepath.Glob(dirs.SnapAPIAuditLogFile + "*")
+	c.Assert(err, check.IsNil)
+	c.Check(matches, check.HasLen, 4)
+	content, err := ioutil.ReadFile(dirs.SnapAPIAuditLogFile)
+	c.Assert(err, check.IsNil)
+	c.Check(strings.Count(string(content), "\n"), check.Equals, 2)
+
+	req, err := http.NewRequest("GET", "/v2/debug/api-audit", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	var expected []*client.APIAuditEntry
+	for n := 3; n <= 10; n++ {
+		expected = append(expected, auditEntry(n))
+	}
+	c.Check(rsp.Result, check.DeepEquals, expected)
+}
+
+func (s *apiAuditSuite) TestAPIAuditLogSync(c *check.C) {
+	s.daemon(c)
+
+	var synced []string
+	defer daemon.MockAPIAuditFileSync(func(f *os.File) error {
+		content, err := ioutil.ReadFile(f.Name())
+		c.Assert(err, check.IsNil)
+		synced = append(synced, fmt.Sprintf("%s:%d", filepath.Base(f.Name()), strings.Count(string(content), "\n")))
+		return nil
+	})()
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	defer daemon.MockAPIAuditTimeNow(func() time.Time { return now })()
+
+	// the first entry is synced right away
+	c.Assert(daemon.AppendAPIAuditEntry(auditEntry(1)), check.IsNil)
+	c.Check(synced, check.DeepEquals, []string{"api-audit.log:1"})
+
+	// but not the following ones for a while
+	now = now.Add(time.Second)
+	c.Assert(daemon.AppendAPIAuditEntry(auditEntry(2)), check.IsNil)
+	now = now.Add(time.Second)
+	c.Assert(daemon.AppendAPIAuditEntry(auditEntry(3)), check.IsNil)
+	c.Check(synced, check.HasLen, 1)
+
+	now = now.Add(5 * time.Second)
+	c.Assert(daemon.AppendAPIAuditEntry(auditEntry(4)), check.IsNil)
+	c.Check(synced, check.DeepEquals, []string{"api-audit.log:1", "api-audit.log:4"})
+
+	// entries written since the last sync are synced when snapd stops
+	now = now.Add(time.Second)
+	c.Assert(daemon.AppendAPIAuditEntry(auditEntry(5)), check.IsNil)
+	daemon.FlushAPIAuditLog()
+	c.Check(synced, check.DeepEquals, []string{"api-audit.log:1", "api-audit.log:4", "api-audit.log:5"})
+	// there is nothing left to sync
+	daemon.FlushAPIAuditLog()
+	c.Check(synced, check.HasLen, 3)
+
+	// and before rotating the log, each entry is 111 bytes so that the
+	// seventh one rotates it
+	defer daemon.MockAPIAuditLogMaxSize(700)()
+	now = now.Add(time.Second)
+	c.Assert(daemon.AppendAPIAuditEntry(auditEntry(6)), check.IsNil)
+	c.Check(synced, check.HasLen, 3)
+	now = now.Add(time.Second)
+	c.Assert(daemon.AppendAPIAuditEntry(auditEntry(7)), check.IsNil)
+	c.Check(synced, check.DeepEquals, []string{"api-audit.log:1", "api-audit.log:4", "api-audit.log:5", "api-audit.log:6"})
+}
+
+func (s *apiAuditSuite) TestGetAPIAuditBadLog(c *check.C) {
+	s.daemon(c)
+
+	c.Assert(ioutil.WriteFile(dirs.SnapAPIAuditLogFile, []byte("garbage\n"), 0600), check.IsNil)
+
+	req, err := http.NewRequest("GET", "/v2/debug/api-audit", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 500)
+	c.Check(rspe.Message, check.Matches, `cannot read API audit log: cannot decode entry of .*/api-audit.log: .*`)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/timings"
 )
 
@@ -305,8 +306,16 @@ func getDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		startupTag := query.Get("startup")
 		all := query.Get("all")
 		return getChangeTimings(st, chgID, ensureTag, startupTag, all == "true")
+	case "timings":
+		return getTimings(st, query)
 	case "seeding":
 		return getSeedingInfo(st)
+	case "security-setup":
+		return getSecuritySetup(st, query.Get("change-id"))
+	case "store-errors":
+		return SyncResponse(store.ErrorCounts())
+	case "launch":
+		return getDebugLaunch(st, query.Get("app"), query.Get("debug-tool"))
 	default:
 		return BadRequest("unknown debug aspect %q", aspect)
 	}
@@ -0,0 +1,68 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// getDebugLaunch returns how to launch the given application under the given
+// debugging tool. This is meant for IDEs: the application is launched by
+// "snap run" in the context of the user so that it gets the same confinement
+// and environment as when launched normally.
+func getDebugLaunch(st *state.State, snapApp, tool string) Response {
+	if snapApp == "" {
+		return BadRequest("cannot get launch command without an application")
+	}
+	if tool != "" && !strutil.ListContains(client.DebugTools, tool) {
+		return BadRequest("unknown debug tool %q", tool)
+	}
+
+	snapName, appName := snap.SplitSnapApp(snapApp)
+	info, err := snapstate.CurrentInfo(st, snapName)
+	switch err.(type) {
+	case nil:
+		// ok
+	case *snap.NotInstalledError:
+		return SnapNotFound(snapName, err)
+	default:
+		return InternalError("%v", err)
+	}
+	app := info.Apps[appName]
+	if app == nil {
+		return AppNotFound("cannot find app %q in %q", appName, snapName)
+	}
+
+	cmd := []string{"snap", "run"}
+	if tool != "" {
+		cmd = append(cmd, "--debug-tool="+tool)
+	}
+	cmd = append(cmd, snapApp)
+
+	return SyncResponse(&client.DebugLaunch{
+		Command:     cmd,
+		SecurityTag: app.SecurityTag(),
+		Confinement: string(info.Confinement),
+	})
+}
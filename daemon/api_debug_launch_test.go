@@ -0,0 +1,81 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = Suite(&launchDebugSuite{})
+
+type launchDebugSuite struct {
+	apiBaseSuite
+}
+
+func (s *launchDebugSuite) SetUpTest(c *C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.daemonWithOverlordMock(c)
+	s.mkInstalledInState(c, s.d, "foo", "", "v1", snap.R(1), true, "apps: {app: {}}")
+}
+
+func (s *launchDebugSuite) TestLaunch(c *C) {
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=launch&app=foo.app&debug-tool=gdbserver", nil)
+	c.Assert(err, IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, DeepEquals, &client.DebugLaunch{
+		Command:     []string{"snap", "run", "--debug-tool=gdbserver", "foo.app"},
+		SecurityTag: "snap.foo.app",
+		Confinement: "strict",
+	})
+}
+
+func (s *launchDebugSuite) TestLaunchNoTool(c *C) {
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=launch&app=foo.app", nil)
+	c.Assert(err, IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result.(*client.DebugLaunch).Command, DeepEquals, []string{"snap", "run", "foo.app"})
+}
+
+func (s *launchDebugSuite) TestLaunchErrors(c *C) {
+	for _, t := range []struct {
+		query  string
+		status int
+		msg    string
+	}{
+		{"", 400, "cannot get launch command without an application"},
+		{"&app=foo.app&debug-tool=valgrind", 400, `unknown debug tool "valgrind"`},
+		{"&app=bar.app", 404, `snap "bar" is not installed`},
+		{"&app=foo.other", 404, `cannot find app "other" in "foo"`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/debug?aspect=launch"+t.query, nil)
+		c.Assert(err, IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, Equals, t.status, Commentf(t.query))
+		c.Check(rspe.Message, Equals, t.msg, Commentf(t.query))
+	}
+}
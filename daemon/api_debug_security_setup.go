@@ -0,0 +1,63 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type securitySetupEntry struct {
+	TaskID  string `json:"task-id"`
+	Kind    string `json:"kind"`
+	Summary string `json:"summary"`
+	*ifacestate.SecuritySetupScope
+}
+
+// getSecuritySetup reports, for each task of the given change, which snaps
+// had their security profiles regenerated and by which security backends,
+// as well as the backends that were skipped as not affected by the task.
+func getSecuritySetup(st *state.State, changeID string) Response {
+	if changeID == "" {
+		return BadRequest("missing change-id parameter")
+	}
+	chg := st.Change(changeID)
+	if chg == nil {
+		return BadRequest("cannot find change: %v", changeID)
+	}
+
+	entries := []*securitySetupEntry{}
+	for _, t := range chg.Tasks() {
+		scope, err := ifacestate.TaskSecuritySetupScope(t)
+		if err == state.ErrNoState {
+			continue
+		}
+		if err != nil {
+			return InternalError("cannot get security setup of task %s: %v", t.ID(), err)
+		}
+		entries = append(entries, &securitySetupEntry{
+			TaskID:             t.ID(),
+			Kind:               t.Kind(),
+			Summary:            t.Summary(),
+			SecuritySetupScope: scope,
+		})
+	}
+	return SyncResponse(entries)
+}
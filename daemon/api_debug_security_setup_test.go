@@ -0,0 +1,92 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+)
+
+var _ = Suite(&securitySetupDebugSuite{})
+
+type securitySetupDebugSuite struct {
+	apiBaseSuite
+
+	chgID string
+}
+
+func (s *securitySetupDebugSuite) SetUpTest(c *C) {
+	s.apiBaseSuite.SetUpTest(c)
+	d := s.daemonWithOverlordMock(c)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.NewChange("connect-snap", "...")
+	t1 := st.NewTask("connect", "Connect consumer:plug to producer:slot")
+	t1.Set("security-setup-scope", &ifacestate.SecuritySetupScope{
+		Snaps:    []string{"producer", "consumer"},
+		Backends: []interfaces.SecuritySystem{interfaces.SecurityAppArmor, interfaces.SecuritySecComp},
+		Skipped:  []interfaces.SecuritySystem{interfaces.SecurityUDev},
+	})
+	chg.AddTask(t1)
+	t2 := st.NewTask("auto-connect", "Automatically connect eligible plugs and slots")
+	chg.AddTask(t2)
+	s.chgID = chg.ID()
+}
+
+func (s *securitySetupDebugSuite) getSecuritySetup(c *C, chgID string) []map[string]interface{} {
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=security-setup&change-id="+chgID, nil)
+	c.Assert(err, IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, IsNil)
+	var entries []map[string]interface{}
+	c.Assert(json.Unmarshal(data, &entries), IsNil)
+	return entries
+}
+
+func (s *securitySetupDebugSuite) TestSecuritySetup(c *C) {
+	entries := s.getSecuritySetup(c, s.chgID)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0], DeepEquals, map[string]interface{}{
+		"task-id":  entries[0]["task-id"],
+		"kind":     "connect",
+		"summary":  "Connect consumer:plug to producer:slot",
+		"snaps":    []interface{}{"producer", "consumer"},
+		"backends": []interface{}{"apparmor", "seccomp"},
+		"skipped":  []interface{}{"udev"},
+	})
+}
+
+func (s *securitySetupDebugSuite) TestSecuritySetupErrors(c *C) {
+	for _, chgID := range []string{"", "999"} {
+		req, err := http.NewRequest("GET", "/v2/debug?aspect=security-setup&change-id="+chgID, nil)
+		c.Assert(err, IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, Equals, 400)
+	}
+}
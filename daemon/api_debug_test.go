@@ -28,6 +28,7 @@ import (
 
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/timings"
 )
@@ -116,6 +117,16 @@ func mockDurationThreshold() func() {
 	return restore
 }
 
+func (s *postDebugSuite) TestGetDebugStoreErrors(c *check.C) {
+	_ = s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=store-errors", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, store.ErrorCounts())
+}
+
 func (s *postDebugSuite) getDebugTimings(c *check.C, request string) []interface{} {
 	defer mockDurationThreshold()()
 
@@ -0,0 +1,92 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timings"
+)
+
+type timingsEntry struct {
+	Tags      map[string]string     `json:"tags,omitempty"`
+	StartTime time.Time             `json:"start-time"`
+	Duration  time.Duration         `json:"duration"`
+	Timings   []*timings.TimingJSON `json:"timings,omitempty"`
+}
+
+// getTimings returns the timings kept in the state, oldest first, optionally
+// filtered by the kind of the change they were captured for, by the snap
+// affected by that change and by the time range they started in. Unlike
+// change-timings it does not need the change to still be around.
+func getTimings(st *state.State, query url.Values) Response {
+	var since, until time.Time
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &since}, {"until", &until}} {
+		v := query.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return BadRequest("invalid %q parameter: %v", p.name, err)
+		}
+		*p.t = t
+	}
+	changeKind := query.Get("change-kind")
+	snapName := query.Get("snap")
+
+	filter := func(tags map[string]string) bool {
+		if changeKind != "" && tags["change-kind"] != changeKind {
+			return false
+		}
+		if snapName != "" && !strutil.ListContains(strings.Split(tags["snap-names"], ","), snapName) {
+			return false
+		}
+		return true
+	}
+	infos, err := timings.Get(st, -1, filter)
+	if err != nil {
+		return InternalError("%v", err)
+	}
+
+	entries := []*timingsEntry{}
+	for _, info := range infos {
+		if !since.IsZero() && info.StartTime.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !info.StartTime.Before(until) {
+			continue
+		}
+		entries = append(entries, &timingsEntry{
+			Tags:      info.Tags,
+			StartTime: info.StartTime,
+			Duration:  info.Duration,
+			Timings:   info.NestedTimings,
+		})
+	}
+	return SyncResponse(entries)
+}
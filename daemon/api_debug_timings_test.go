@@ -0,0 +1,129 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+)
+
+var _ = Suite(&timingsDebugSuite{})
+
+type timingsDebugSuite struct {
+	apiBaseSuite
+}
+
+func (s *timingsDebugSuite) SetUpTest(c *C) {
+	s.apiBaseSuite.SetUpTest(c)
+	d := s.daemonWithOverlordMock(c)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	// the change of the first timing is long gone
+	st.Set("timings", []map[string]interface{}{{
+		"tags":       map[string]string{"change-id": "1", "change-kind": "install-snap", "snap-names": "foo", "task-id": "1"},
+		"start-time": "2021-06-01T10:00:00Z",
+		"stop-time":  "2021-06-01T10:00:02Z",
+		"timings":    []map[string]interface{}{{"label": "setup", "duration": 2000000000}},
+	}, {
+		"tags":       map[string]string{"change-id": "2", "change-kind": "refresh-snap", "snap-names": "foo,bar", "task-id": "3"},
+		"start-time": "2021-06-02T10:00:00Z",
+		"stop-time":  "2021-06-02T10:00:01Z",
+		"timings":    []map[string]interface{}{{"label": "setup", "duration": 1000000000}},
+	}, {
+		"tags":       map[string]string{"ensure": "auto-refresh"},
+		"start-time": "2021-06-03T10:00:00Z",
+		"stop-time":  "2021-06-03T10:00:01Z",
+		"timings":    []map[string]interface{}{{"label": "refresh-candidates", "duration": 1000000000}},
+	}})
+}
+
+func (s *timingsDebugSuite) getTimings(c *C, query string) []map[string]interface{} {
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=timings"+query, nil)
+	c.Assert(err, IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	data, err := json.Marshal(rsp.Result)
+	c.Assert(err, IsNil)
+	var entries []map[string]interface{}
+	c.Assert(json.Unmarshal(data, &entries), IsNil)
+	return entries
+}
+
+func (s *timingsDebugSuite) TestAll(c *C) {
+	entries := s.getTimings(c, "")
+	c.Assert(entries, HasLen, 3)
+	c.Check(entries[0], DeepEquals, map[string]interface{}{
+		"tags": map[string]interface{}{
+			"change-id":   "1",
+			"change-kind": "install-snap",
+			"snap-names":  "foo",
+			"task-id":     "1",
+		},
+		"start-time": "2021-06-01T10:00:00Z",
+		"duration":   float64(2000000000),
+		"timings": []interface{}{
+			map[string]interface{}{"label": "setup", "duration": float64(2000000000)},
+		},
+	})
+}
+
+func (s *timingsDebugSuite) TestFilterChangeKind(c *C) {
+	entries := s.getTimings(c, "&change-kind=refresh-snap")
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0]["tags"].(map[string]interface{})["change-id"], Equals, "2")
+}
+
+func (s *timingsDebugSuite) TestFilterSnap(c *C) {
+	entries := s.getTimings(c, "&snap=foo")
+	c.Assert(entries, HasLen, 2)
+	c.Check(entries[0]["tags"].(map[string]interface{})["change-id"], Equals, "1")
+	c.Check(entries[1]["tags"].(map[string]interface{})["change-id"], Equals, "2")
+
+	entries = s.getTimings(c, "&snap=bar")
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0]["tags"].(map[string]interface{})["change-id"], Equals, "2")
+
+	c.Check(s.getTimings(c, "&snap=fo"), HasLen, 0)
+}
+
+func (s *timingsDebugSuite) TestFilterTimeRange(c *C) {
+	entries := s.getTimings(c, "&since=2021-06-02T00:00:00Z")
+	c.Assert(entries, HasLen, 2)
+	c.Check(entries[1]["tags"], DeepEquals, map[string]interface{}{"ensure": "auto-refresh"})
+
+	entries = s.getTimings(c, "&since=2021-06-02T00:00:00Z&until=2021-06-03T10:00:00Z")
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0]["tags"].(map[string]interface{})["change-id"], Equals, "2")
+
+	c.Check(s.getTimings(c, "&until=2021-06-01T00:00:00Z"), HasLen, 0)
+}
+
+func (s *timingsDebugSuite) TestFilterErrors(c *C) {
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=timings&since=yesterday", nil)
+	c.Assert(err, IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, Equals, 400)
+	c.Check(rspe.Message, Matches, `invalid "since" parameter: .*`)
+}
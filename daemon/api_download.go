@@ -44,7 +44,7 @@ import (
 var snapDownloadCmd = &Command{
 	Path:        "/v2/download",
 	POST:        postSnapDownload,
-	WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
+	WriteAccess: authenticatedAccess{Polkit: polkitActionManage, Role: auth.RoleSnapInstall},
 }
 
 var validRangeRegexp = regexp.MustCompile(`^\s*bytes=(\d+)-\s*$`)
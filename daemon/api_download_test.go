@@ -53,7 +53,7 @@ func (s *snapDownloadSuite) SetUpTest(c *check.C) {
 
 	s.daemonWithStore(c, s)
 
-	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage"})
+	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage", Role: auth.RoleSnapInstall})
 }
 
 var snapContent = "SNAP"
@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"os/exec"
+	"reflect"
 	"sort"
 	"time"
 
@@ -148,6 +149,18 @@ func sysInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 		m["virtualization"] = systemdVirt
 	}
 
+	if storage, err := deviceMgr.DataStorage(); err != nil {
+		logger.Debugf("cannot get ubuntu-data storage information: %v", err)
+	} else if storage != nil {
+		m["data-storage"] = client.DataStorageInfo{
+			Disk:         storage.Disk,
+			LifeTimeUsed: storage.Health.LifeTimeUsed,
+			PreEOL:       storage.Health.PreEOL,
+			Size:         storage.Usage.Size,
+			Free:         storage.Usage.Free,
+		}
+	}
+
 	// NOTE: Right now we don't have a good way to differentiate if we
 	// only have partial confinement (ala AppArmor disabled and Seccomp
 	// enabled) or no confinement at all. Once we have a better system
@@ -199,8 +212,28 @@ func sandboxFeatures(backends []interfaces.SecurityBackend) map[string][]string
 	return result
 }
 
+// changeWaitPollInterval is the interval at which a change is checked for
+// updates while a request waits for it.
+var changeWaitPollInterval = 100 * time.Millisecond
+
+// maxChangeWait is the longest a request can wait for a change update.
+const maxChangeWait = 5 * time.Minute
+
 func getChange(c *Command, r *http.Request, user *auth.UserState) Response {
 	chID := muxVars(r)["id"]
+
+	var wait time.Duration
+	if s := r.URL.Query().Get("wait"); s != "" {
+		var err error
+		wait, err = time.ParseDuration(s)
+		if err != nil || wait < 0 {
+			return BadRequest("invalid wait duration %q", s)
+		}
+		if wait > maxChangeWait {
+			wait = maxChangeWait
+		}
+	}
+
 	state := c.d.overlord.State()
 	state.Lock()
 	defer state.Unlock()
@@ -209,7 +242,35 @@ func getChange(c *Command, r *http.Request, user *auth.UserState) Response {
 		return NotFound("cannot find change with id %q", chID)
 	}
 
-	return SyncResponse(change2changeInfo(chg))
+	info := change2changeInfo(chg)
+	if wait == 0 || info.Ready {
+		return SyncResponse(info)
+	}
+
+	// long-poll: return as soon as the change, its tasks progress or
+	// logs are updated, or once the wait is over
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	ticker := time.NewTicker(changeWaitPollInterval)
+	defer ticker.Stop()
+	ready := chg.Ready()
+	for {
+		done := false
+		state.Unlock()
+		select {
+		case <-ready:
+		case <-ticker.C:
+		case <-timeout.C:
+			done = true
+		case <-r.Context().Done():
+			done = true
+		}
+		state.Lock()
+		updated := change2changeInfo(chg)
+		if done || updated.Ready || !reflect.DeepEqual(updated, info) {
+			return SyncResponse(updated)
+		}
+	}
 }
 
 func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -33,14 +33,17 @@ import (
 
 	"github.com/snapcore/snapd/arch"
 	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/osutil/disks"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/sandbox"
+	"github.com/snapcore/snapd/testutil"
 )
 
 var _ = check.Suite(&generalSuite{})
@@ -328,6 +331,41 @@ func (s *generalSuite) TestSysInfoIsManaged(c *check.C) {
 	c.Check(rsp.Result.(map[string]interface{})["managed"], check.Equals, true)
 }
 
+func (s *generalSuite) TestSysInfoDataStorage(c *check.C) {
+	restore := release.MockOnClassic(false)
+	defer restore()
+	m := boot.Modeenv{
+		Mode:           "run",
+		RecoverySystem: "20191127",
+	}
+	c.Assert(m.WriteTo(""), check.IsNil)
+	restore = disks.MockMountPointDisksToPartitionMapping(map[disks.Mountpoint]*disks.MockDiskMapping{
+		{Mountpoint: boot.InitramfsDataDir}: {
+			DevNum:     "179:0",
+			DiskHealth: &disks.Health{LifeTimeUsed: 40, PreEOL: "normal"},
+		},
+	})
+	defer restore()
+	restore = disks.MockUsageForMountPoint(map[string]*disks.Usage{
+		boot.InitramfsDataDir: {Size: 1000, Free: 200},
+	})
+	defer restore()
+
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/system-info", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result.(map[string]interface{})["data-storage"], check.DeepEquals, client.DataStorageInfo{
+		Disk:         "179:0",
+		LifeTimeUsed: 40,
+		PreEOL:       "normal",
+		Size:         1000,
+		Free:         200,
+	})
+}
+
 func (s *generalSuite) TestSysInfoWorksDegraded(c *check.C) {
 	d := s.daemon(c)
 
@@ -594,6 +632,99 @@ func (s *generalSuite) TestStateChange(c *check.C) {
 	})
 }
 
+func (s *generalSuite) TestStateChangeWaitUpdated(c *check.C) {
+	restore := daemon.MockChangeWaitPollInterval(time.Millisecond)
+	defer restore()
+
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		st.Lock()
+		defer st.Unlock()
+		st.Task(ids[2]).Logf("l13")
+	}()
+
+	req, err := http.NewRequest("GET", "/v2/changes/"+ids[0]+"?wait=1m", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	c.Assert(rsp.Result, check.FitsTypeOf, &daemon.ChangeInfo{})
+	info := rsp.Result.(*daemon.ChangeInfo)
+	c.Check(info.Ready, check.Equals, false)
+	c.Assert(info.Tasks, check.HasLen, 2)
+	c.Check(info.Tasks[0].Log, check.HasLen, 3)
+	c.Check(info.Tasks[0].Log[2], testutil.Contains, "INFO l13")
+}
+
+func (s *generalSuite) TestStateChangeWaitReady(c *check.C) {
+	restore := daemon.MockChangeWaitPollInterval(time.Hour)
+	defer restore()
+
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		st.Lock()
+		defer st.Unlock()
+		st.Task(ids[2]).SetStatus(state.DoneStatus)
+		st.Task(ids[3]).SetStatus(state.DoneStatus)
+	}()
+
+	// the closing of the ready channel ends the wait
+	req, err := http.NewRequest("GET", "/v2/changes/"+ids[0]+"?wait=1m", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	info := rsp.Result.(*daemon.ChangeInfo)
+	c.Check(info.Ready, check.Equals, true)
+	c.Check(info.Status, check.Equals, "Done")
+}
+
+func (s *generalSuite) TestStateChangeWaitTimeout(c *check.C) {
+	restore := daemon.MockChangeWaitPollInterval(time.Millisecond)
+	defer restore()
+
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/changes/"+ids[0]+"?wait=20ms", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	info := rsp.Result.(*daemon.ChangeInfo)
+	c.Check(info.ID, check.Equals, ids[0])
+	c.Check(info.Ready, check.Equals, false)
+	c.Check(info.Tasks[0].Log, check.HasLen, 2)
+}
+
+func (s *generalSuite) TestStateChangeWaitInvalid(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	for _, wait := range []string{"foo", "-1s"} {
+		req, err := http.NewRequest("GET", "/v2/changes/"+ids[0]+"?wait="+wait, nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, fmt.Sprintf("invalid wait duration %q", wait))
+	}
+}
+
 func (s *generalSuite) expectManageAccess() {
 	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage"})
 }
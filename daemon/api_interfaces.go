@@ -26,11 +26,14 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/seccomp"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var (
@@ -41,19 +44,129 @@ var (
 		ReadAccess:  openAccess{},
 		WriteAccess: authenticatedAccess{Polkit: polkitActionManageInterfaces},
 	}
+
+	interfacesHistoryCmd = &Command{
+		Path:       "/v2/interfaces/history",
+		GET:        getConnectionHistory,
+		ReadAccess: openAccess{},
+	}
 )
 
 // interfacesConnectionsMultiplexer multiplexes to either legacy (connection) or modern behavior (interfaces).
 func interfacesConnectionsMultiplexer(c *Command, r *http.Request, user *auth.UserState) Response {
 	query := r.URL.Query()
 	qselect := query.Get("select")
-	if qselect == "" {
+	switch qselect {
+	case "":
 		return getLegacyConnections(c, r, user)
-	} else {
+	case "stale-profiles":
+		return getStaleProfiles(c, r, user)
+	default:
 		return getInterfaces(c, r, user)
 	}
 }
 
+var seccompStaleProcesses = seccomp.StaleProcesses
+
+// getStaleProfiles reports the processes of snaps that still use a seccomp
+// profile older than the installed one. Seccomp filters cannot be replaced in
+// running processes, so these need to be restarted to see, for instance, the
+// effect of new connections.
+func getStaleProfiles(c *Command, r *http.Request, user *auth.UserState) Response {
+	var names []string
+	for _, name := range strutil.CommaSeparatedList(r.URL.Query().Get("snaps")) {
+		names = append(names, ifacestate.RemapSnapFromRequest(name))
+	}
+	if len(names) == 0 {
+		st := c.d.overlord.State()
+		st.Lock()
+		all, err := snapstate.All(st)
+		st.Unlock()
+		if err != nil && err != state.ErrNoState {
+			return InternalError("cannot get snaps: %v", err)
+		}
+		for name := range all {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	result := []*client.StaleProfile{}
+	for _, name := range names {
+		staleByTag, err := seccompStaleProcesses(name)
+		if err != nil {
+			return InternalError("cannot get processes of snap %q with stale profiles: %v", name, err)
+		}
+		tags := make([]string, 0, len(staleByTag))
+		for tag := range staleByTag {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		for _, tag := range tags {
+			pids := staleByTag[tag]
+			sort.Ints(pids)
+			result = append(result, &client.StaleProfile{
+				Snap:        name,
+				SecurityTag: tag,
+				Pids:        pids,
+			})
+		}
+	}
+	return SyncResponse(result)
+}
+
+// getConnectionHistory returns the recorded connect and disconnect events,
+// optionally only those involving the given snap.
+func getConnectionHistory(c *Command, r *http.Request, user *auth.UserState) Response {
+	var snapName string
+	if name := r.URL.Query().Get("snap"); name != "" {
+		snapName = ifacestate.RemapSnapFromRequest(name)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	history, err := ifacestate.ConnectionHistory(st)
+	st.Unlock()
+	if err != nil {
+		return InternalError("%v", err)
+	}
+
+	result := []*client.ConnectionEvent{}
+	for _, ev := range history {
+		if snapName != "" && ev.Plug.Snap != snapName && ev.Slot.Snap != snapName {
+			continue
+		}
+		result = append(result, &client.ConnectionEvent{
+			Time:          ev.Time,
+			Action:        ev.Action,
+			Interface:     ev.Interface,
+			Plug:          client.PlugRef{Snap: ev.Plug.Snap, Name: ev.Plug.Name},
+			Slot:          client.SlotRef{Snap: ev.Slot.Snap, Name: ev.Slot.Name},
+			By:            ev.By,
+			RequestedBy:   ev.RequestedBy,
+			Justification: ev.Justification,
+		})
+	}
+	return SyncResponse(result)
+}
+
+// requestedBy identifies the user making the request, as recorded in the
+// connection history.
+func requestedBy(r *http.Request, user *auth.UserState) string {
+	if user != nil {
+		if user.Username != "" {
+			return user.Username
+		}
+		if user.Email != "" {
+			return user.Email
+		}
+	}
+	if ucred, err := ucrednetGet(r.RemoteAddr); err == nil {
+		return fmt.Sprintf("uid=%d", ucred.Uid)
+	}
+	return ""
+}
+
 func getInterfaces(c *Command, r *http.Request, user *auth.UserState) Response {
 	// Collect query options from request arguments.
 	q := r.URL.Query()
@@ -131,6 +244,9 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 	if a.Action == "" {
 		return BadRequest("interface action not specified")
 	}
+	if a.Action == "batch" {
+		return changeInterfacesBatch(c, r, user, a.Operations)
+	}
 	if len(a.Plugs) > 1 || len(a.Slots) > 1 {
 		return NotImplemented("many-to-many operations are not implemented")
 	}
@@ -140,6 +256,12 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 	if len(a.Plugs) == 0 || len(a.Slots) == 0 {
 		return BadRequest("at least one plug and slot is required")
 	}
+	if len(a.Slots[0].Attrs) > 0 {
+		return BadRequest("cannot override slot attributes")
+	}
+	if a.Action != "connect" && len(a.Plugs[0].Attrs) > 0 {
+		return BadRequest("plug attributes can only be overridden when connecting")
+	}
 
 	var summary string
 	var err error
@@ -189,7 +311,7 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 			var ts *state.TaskSet
 			affected = snapNamesFromConns([]*interfaces.ConnRef{connRef})
 			summary = fmt.Sprintf("Connect %s:%s to %s:%s", connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
-			ts, err = ifacestate.Connect(st, connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
+			ts, err = ifacestate.ConnectWithAttrs(st, connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name, a.Plugs[0].Attrs)
 			if _, ok := err.(*ifacestate.ErrAlreadyConnected); ok {
 				change := newChange(st, a.Action+"-snap", summary, nil, affected)
 				change.SetStatus(state.DoneStatus)
@@ -235,6 +357,84 @@ func changeInterfaces(c *Command, r *http.Request, user *auth.UserState) Respons
 	}
 
 	change := newChange(st, a.Action+"-snap", summary, tasksets, affected)
+	if by := requestedBy(r, user); by != "" {
+		change.Set("requested-by", by)
+	}
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, change.ID())
+}
+
+// changeInterfacesBatch performs the given connect and disconnect actions
+// in a single change, setting up the security profiles of the affected
+// snaps only once, after all the actions were performed.
+func changeInterfacesBatch(c *Command, r *http.Request, user *auth.UserState, actions []interfaceAction) Response {
+	if len(actions) == 0 {
+		return BadRequest("batch has no operations")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	repo := c.d.overlord.InterfaceManager().Repository()
+	ops := make([]*ifacestate.ConnectionOp, 0, len(actions))
+	var refs []*interfaces.ConnRef
+	for i, a := range actions {
+		if a.Action != "connect" && a.Action != "disconnect" {
+			return BadRequest("unsupported interface action in batch: %q", a.Action)
+		}
+		if len(a.Plugs) != 1 || len(a.Slots) != 1 {
+			return BadRequest("batch operation %d must have exactly one plug and slot", i)
+		}
+		if len(a.Slots[0].Attrs) > 0 {
+			return BadRequest("cannot override slot attributes")
+		}
+		if a.Action != "connect" && len(a.Plugs[0].Attrs) > 0 {
+			return BadRequest("plug attributes can only be overridden when connecting")
+		}
+		if a.Action != "disconnect" && a.Forget {
+			return BadRequest("only disconnect operations can forget connections")
+		}
+
+		plugSnap := ifacestate.RemapSnapFromRequest(a.Plugs[0].Snap)
+		slotSnap := ifacestate.RemapSnapFromRequest(a.Slots[0].Snap)
+		var connRef *interfaces.ConnRef
+		var err error
+		if a.Action == "connect" {
+			connRef, err = repo.ResolveConnect(plugSnap, a.Plugs[0].Name, slotSnap, a.Slots[0].Name)
+			if err != nil {
+				return BadRequest("%v", err)
+			}
+			refs = append(refs, connRef)
+		} else {
+			var conns []*interfaces.ConnRef
+			conns, err = c.d.overlord.InterfaceManager().ResolveDisconnect(plugSnap, a.Plugs[0].Name, slotSnap, a.Slots[0].Name, a.Forget)
+			if err != nil {
+				return BadRequest("%v", err)
+			}
+			for _, connRef := range conns {
+				ops = append(ops, &ifacestate.ConnectionOp{Action: a.Action, ConnRef: *connRef, Forget: a.Forget})
+			}
+			refs = append(refs, conns...)
+			continue
+		}
+		ops = append(ops, &ifacestate.ConnectionOp{Action: a.Action, ConnRef: *connRef, PlugAttrs: a.Plugs[0].Attrs})
+	}
+
+	ts, err := ifacestate.Batch(st, repo, ops)
+	if err != nil {
+		return errToResponse(err, nil, BadRequest, "%v")
+	}
+	if len(ts.Tasks()) == 0 {
+		return InterfacesUnchanged("nothing to do")
+	}
+
+	summary := fmt.Sprintf("Change %d connections", len(ops))
+	change := newChange(st, "batch-connections", summary, []*state.TaskSet{ts}, snapNamesFromConns(refs))
+	if by := requestedBy(r, user); by != "" {
+		change.Set("requested-by", by)
+	}
 	st.EnsureBefore(0)
 
 	return AsyncResponse(nil, change.ID())
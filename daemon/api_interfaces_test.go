@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -147,6 +148,7 @@ func (s *interfacesSuite) TestConnectPlugSuccess(c *check.C) {
 	buf := bytes.NewBuffer(text)
 	req, err := http.NewRequest("POST", "/v2/interfaces", buf)
 	c.Assert(err, check.IsNil)
+	req.RemoteAddr = "pid=100;uid=42;socket=;"
 	rec := httptest.NewRecorder()
 	s.req(c, req, nil).ServeHTTP(rec, req)
 	c.Check(rec.Code, check.Equals, 202)
@@ -165,8 +167,11 @@ func (s *interfacesSuite) TestConnectPlugSuccess(c *check.C) {
 
 	st.Lock()
 	err = chg.Err()
+	var requestedBy string
+	c.Check(chg.Get("requested-by", &requestedBy), check.IsNil)
 	st.Unlock()
 	c.Assert(err, check.IsNil)
+	c.Check(requestedBy, check.Equals, "uid=42")
 
 	repo := d.Overlord().InterfaceManager().Repository()
 	ifaces := repo.Interfaces()
@@ -253,6 +258,60 @@ func (s *interfacesSuite) TestConnectPlugFailureNoSuchPlug(c *check.C) {
 	c.Assert(ifaces.Connections, check.HasLen, 0)
 }
 
+func (s *interfacesSuite) TestConnectPlugAttrsFailures(c *check.C) {
+	d := s.daemon(c)
+
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	for _, tc := range []struct {
+		action  *client.InterfaceAction
+		message string
+	}{{
+		action: &client.InterfaceAction{
+			Action: "connect",
+			Plugs:  []client.Plug{{Snap: "consumer", Name: "plug", Attrs: map[string]interface{}{"read-only": true}}},
+			Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+		},
+		message: `cannot override attributes of plug consumer:plug: interface "test" does not support it`,
+	}, {
+		action: &client.InterfaceAction{
+			Action: "connect",
+			Plugs:  []client.Plug{{Snap: "consumer", Name: "plug"}},
+			Slots:  []client.Slot{{Snap: "producer", Name: "slot", Attrs: map[string]interface{}{"key": "other"}}},
+		},
+		message: `cannot override slot attributes`,
+	}, {
+		action: &client.InterfaceAction{
+			Action: "disconnect",
+			Plugs:  []client.Plug{{Snap: "consumer", Name: "plug", Attrs: map[string]interface{}{"read-only": true}}},
+			Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+		},
+		message: `plug attributes can only be overridden when connecting`,
+	}} {
+		text, err := json.Marshal(tc.action)
+		c.Assert(err, check.IsNil)
+		buf := bytes.NewBuffer(text)
+		req, err := http.NewRequest("POST", "/v2/interfaces", buf)
+		c.Assert(err, check.IsNil)
+		rec := httptest.NewRecorder()
+		s.req(c, req, nil).ServeHTTP(rec, req)
+		c.Check(rec.Code, check.Equals, 400)
+
+		var body map[string]interface{}
+		err = json.Unmarshal(rec.Body.Bytes(), &body)
+		c.Check(err, check.IsNil)
+		c.Check(body["result"], check.DeepEquals, map[string]interface{}{
+			"message": tc.message,
+		})
+	}
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	ifaces := repo.Interfaces()
+	c.Assert(ifaces.Connections, check.HasLen, 0)
+}
+
 func (s *interfacesSuite) TestConnectAlreadyConnected(c *check.C) {
 	d := s.daemon(c)
 
@@ -936,6 +995,116 @@ func (s *interfacesSuite) TestDisconnectCoreSystemAlias(c *check.C) {
 	c.Assert(ifaces.Connections, check.HasLen, 0)
 }
 
+func (s *interfacesSuite) TestInterfaceBatch(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+	d := s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, strings.Replace(consumerYaml, "name: consumer", "name: consumer2", 1))
+	s.mockSnap(c, producerYaml)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	connRef := &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}
+	_, err := repo.Connect(connRef, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{
+			"interface": "test",
+		},
+	})
+	st.Unlock()
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	action := &client.InterfaceAction{
+		Action: "batch",
+		Operations: []client.InterfaceAction{{
+			Action: "disconnect",
+			Plugs:  []client.Plug{{Snap: "consumer", Name: "plug"}},
+			Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+		}, {
+			Action: "connect",
+			Plugs:  []client.Plug{{Snap: "consumer2", Name: "plug"}},
+			Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+		}},
+	}
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil)
+
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	st.Unlock()
+	c.Assert(chg, check.NotNil)
+
+	<-chg.Ready()
+
+	st.Lock()
+	err = chg.Err()
+	kind := chg.Kind()
+	var snapNames []string
+	c.Check(chg.Get("snap-names", &snapNames), check.IsNil)
+	setupTasks := 0
+	for _, t := range chg.Tasks() {
+		if t.Kind() == "setup-connection-profiles" {
+			setupTasks++
+		}
+	}
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(kind, check.Equals, "batch-connections")
+	c.Check(snapNames, check.DeepEquals, []string{"consumer", "consumer2", "producer"})
+	c.Check(setupTasks, check.Equals, 1)
+
+	c.Check(repo.Interfaces().Connections, check.DeepEquals, []*interfaces.ConnRef{{
+		PlugRef: interfaces.PlugRef{Snap: "consumer2", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}})
+}
+
+func (s *interfacesSuite) TestInterfaceBatchErrors(c *check.C) {
+	s.daemon(c)
+	for _, t := range []struct {
+		ops []client.InterfaceAction
+		err string
+	}{{
+		ops: nil,
+		err: `batch has no operations`,
+	}, {
+		ops: []client.InterfaceAction{{Action: "batch"}},
+		err: `unsupported interface action in batch: "batch"`,
+	}, {
+		ops: []client.InterfaceAction{{Action: "connect", Plugs: []client.Plug{{Snap: "consumer", Name: "plug"}}}},
+		err: `batch operation 0 must have exactly one plug and slot`,
+	}, {
+		ops: []client.InterfaceAction{{
+			Action: "connect",
+			Forget: true,
+			Plugs:  []client.Plug{{Snap: "consumer", Name: "plug"}},
+			Slots:  []client.Slot{{Snap: "producer", Name: "slot"}},
+		}},
+		err: `only disconnect operations can forget connections`,
+	}} {
+		text, err := json.Marshal(&client.InterfaceAction{Action: "batch", Operations: t.ops})
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, t.err)
+	}
+}
+
 func (s *interfacesSuite) TestUnsupportedInterfaceRequest(c *check.C) {
 	s.daemon(c)
 	buf := bytes.NewBuffer([]byte(`garbage`))
@@ -1185,3 +1354,116 @@ func (s *interfacesSuite) TestInterfacesModern(c *check.C) {
 		"type":        "sync",
 	})
 }
+
+func (s *interfacesSuite) TestStaleProfiles(c *check.C) {
+	s.daemon(c)
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	var queried []string
+	restore := daemon.MockSeccompStaleProcesses(func(snapName string) (map[string][]int, error) {
+		queried = append(queried, snapName)
+		if snapName == "consumer" {
+			return map[string][]int{
+				"snap.consumer.app":          {30, 10},
+				"snap.consumer.hook.install": {20},
+			}, nil
+		}
+		return nil, nil
+	})
+	defer restore()
+
+	req, err := http.NewRequest("GET", "/v2/interfaces?select=stale-profiles", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(queried, check.DeepEquals, []string{"consumer", "producer"})
+	c.Check(rsp.Result, check.DeepEquals, []*client.StaleProfile{
+		{Snap: "consumer", SecurityTag: "snap.consumer.app", Pids: []int{10, 30}},
+		{Snap: "consumer", SecurityTag: "snap.consumer.hook.install", Pids: []int{20}},
+	})
+
+	// only the given snaps are checked
+	queried = nil
+	req, err = http.NewRequest("GET", "/v2/interfaces?select=stale-profiles&snaps=producer", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Check(queried, check.DeepEquals, []string{"producer"})
+	c.Check(rsp.Result, check.DeepEquals, []*client.StaleProfile{})
+}
+
+func (s *interfacesSuite) TestStaleProfilesError(c *check.C) {
+	s.daemon(c)
+
+	restore := daemon.MockSeccompStaleProcesses(func(snapName string) (map[string][]int, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	defer restore()
+
+	req, err := http.NewRequest("GET", "/v2/interfaces?select=stale-profiles&snaps=foo", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 500)
+	c.Check(rspe.Message, check.Equals, `cannot get processes of snap "foo" with stale profiles: boom`)
+}
+
+// Tests for GET /v2/interfaces/history
+
+func (s *interfacesSuite) TestConnectionHistory(c *check.C) {
+	d := s.daemon(c)
+
+	t0 := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("conns-history", []*ifacestate.ConnectionEvent{{
+		Time:          t0,
+		Action:        "connect",
+		Interface:     "test",
+		Plug:          interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		Slot:          interfaces.SlotRef{Snap: "producer", Name: "slot"},
+		By:            "user",
+		RequestedBy:   "uid=1000",
+		Justification: "plug rule of base-declaration",
+	}, {
+		Time:      t0.Add(time.Hour),
+		Action:    "disconnect",
+		Interface: "network",
+		Plug:      interfaces.PlugRef{Snap: "other", Name: "network"},
+		Slot:      interfaces.SlotRef{Snap: "core", Name: "network"},
+		By:        "snap-removal",
+	}})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/interfaces/history", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, []*client.ConnectionEvent{{
+		Time:          t0,
+		Action:        "connect",
+		Interface:     "test",
+		Plug:          client.PlugRef{Snap: "consumer", Name: "plug"},
+		Slot:          client.SlotRef{Snap: "producer", Name: "slot"},
+		By:            "user",
+		RequestedBy:   "uid=1000",
+		Justification: "plug rule of base-declaration",
+	}, {
+		Time:      t0.Add(time.Hour),
+		Action:    "disconnect",
+		Interface: "network",
+		Plug:      client.PlugRef{Snap: "other", Name: "network"},
+		Slot:      client.SlotRef{Snap: "core", Name: "network"},
+		By:        "snap-removal",
+	}})
+
+	// only events involving the given snap
+	req, err = http.NewRequest("GET", "/v2/interfaces/history?snap=producer", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil)
+	history := rsp.Result.([]*client.ConnectionEvent)
+	c.Assert(history, check.HasLen, 1)
+	c.Check(history[0].Action, check.Equals, "connect")
+
+	req, err = http.NewRequest("GET", "/v2/interfaces/history?snap=unknown", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, []*client.ConnectionEvent{})
+}
@@ -62,6 +62,8 @@ type interfaceAction struct {
 	Forget bool       `json:"forget,omitempty"`
 	Plugs  []plugJSON `json:"plugs,omitempty"`
 	Slots  []slotJSON `json:"slots,omitempty"`
+	// Operations are the connect and disconnect actions of a batch.
+	Operations []interfaceAction `json:"operations,omitempty"`
 }
 
 // connectionsJSON aids in marshalling information about a single connection
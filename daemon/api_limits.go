@@ -0,0 +1,237 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+const (
+	// apiChangesRetryAfter is the delay after which clients with too
+	// many changes in progress are told to retry.
+	apiChangesRetryAfter = 10 * time.Second
+	// apiLimitsRefreshInterval is how long the limits read from the
+	// configuration are used before reading them again, so that the
+	// state lock is not taken for every request.
+	apiLimitsRefreshInterval = 10 * time.Second
+	// apiBucketsRefillTime is the time it takes for an empty bucket to
+	// be full again, buckets idle for that long are dropped.
+	apiBucketsRefillTime = time.Minute
+)
+
+var apiLimiterTimeNow = time.Now
+
+// apiLimiter enforces the per-uid limits on the requests to the snapd API
+// set with the core api.rate-limit and api.max-changes options.
+type apiLimiter struct {
+	// mu protects the fields below, when both are needed the state
+	// lock is taken first
+	mu sync.Mutex
+
+	rateLimit  int
+	maxChanges int
+	limitsRead time.Time
+
+	// buckets hold the requests each uid can still send right away
+	buckets   map[uint32]*tokenBucket
+	lastSweep time.Time
+	// changes are the IDs of the changes started by each uid
+	changes map[uint32][]string
+	// reserved are the changes each uid may be starting with requests
+	// being served, they count against api.max-changes until the
+	// requests are done so that concurrent requests cannot overshoot it
+	reserved map[uint32]int
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// retryAfterResponse is an error response telling the client when to
+// retry its request.
+type retryAfterResponse struct {
+	*apiError
+	retryAfter time.Duration
+}
+
+func (r *retryAfterResponse) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	secs := int(math.Ceil(r.retryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	r.apiError.ServeHTTP(w, req)
+}
+
+// apiLimits returns the maximum number of requests per minute and of
+// changes in progress per uid, 0 meaning unlimited. The state must be
+// locked.
+func apiLimits(st *state.State) (rateLimit, maxChanges int) {
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "api.rate-limit", &rateLimit); err != nil && !config.IsNoOption(err) {
+		rateLimit = 0
+	}
+	if err := tr.Get("core", "api.max-changes", &maxChanges); err != nil && !config.IsNoOption(err) {
+		maxChanges = 0
+	}
+	return rateLimit, maxChanges
+}
+
+func exemptFromAPILimits(ucred *ucrednet) bool {
+	// requests from snaps come from hooks and apps taking part in
+	// changes in progress
+	return ucred == nil || ucred.Uid == 0 || ucred.Socket == dirs.SnapSocket
+}
+
+// limits returns the limits read from the configuration, reading them
+// again if they are too old.
+func (l *apiLimiter) limits(st *state.State) (rateLimit, maxChanges int) {
+	now := apiLimiterTimeNow()
+
+	l.mu.Lock()
+	if !l.limitsRead.IsZero() && now.Sub(l.limitsRead) < apiLimitsRefreshInterval {
+		defer l.mu.Unlock()
+		return l.rateLimit, l.maxChanges
+	}
+	l.mu.Unlock()
+
+	st.Lock()
+	rateLimit, maxChanges = apiLimits(st)
+	st.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rateLimit, l.maxChanges, l.limitsRead = rateLimit, maxChanges, now
+	return rateLimit, maxChanges
+}
+
+// check returns an error response if the request exceeds the limits of the
+// uid it was sent by. Otherwise, it returns whether a change was reserved
+// for the request, the reservation is released by trackChange once the
+// request is served. The state must not be locked.
+func (l *apiLimiter) check(st *state.State, r *http.Request, ucred *ucrednet) (reserved bool, rsp Response) {
+	if exemptFromAPILimits(ucred) {
+		return false, nil
+	}
+	rateLimit, maxChanges := l.limits(st)
+
+	if rateLimit > 0 {
+		if rsp := l.takeToken(ucred.Uid, rateLimit); rsp != nil {
+			return false, rsp
+		}
+	}
+
+	if maxChanges > 0 && r.Method != "GET" {
+		st.Lock()
+		defer st.Unlock()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		var inProgress []string
+		for _, id := range l.changes[ucred.Uid] {
+			if chg := st.Change(id); chg != nil && !chg.IsReady() {
+				inProgress = append(inProgress, id)
+			}
+		}
+		if len(inProgress) == 0 {
+			delete(l.changes, ucred.Uid)
+		} else {
+			l.changes[ucred.Uid] = inProgress
+		}
+		if len(inProgress)+l.reserved[ucred.Uid] >= maxChanges {
+			return false, &retryAfterResponse{RateLimited("too many changes in progress, try again later"), apiChangesRetryAfter}
+		}
+		if l.reserved == nil {
+			l.reserved = make(map[uint32]int)
+		}
+		l.reserved[ucred.Uid]++
+		return true, nil
+	}
+	return false, nil
+}
+
+// takeToken takes a token from the bucket of the uid, or returns an error
+// response if there is none left.
+func (l *apiLimiter) takeToken(uid uint32, rateLimit int) Response {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := apiLimiterTimeNow()
+	if now.Sub(l.lastSweep) >= apiBucketsRefillTime {
+		// idle buckets are full again, the same as new ones
+		for bucketUid, b := range l.buckets {
+			if now.Sub(b.last) >= apiBucketsRefillTime {
+				delete(l.buckets, bucketUid)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	if l.buckets == nil {
+		l.buckets = make(map[uint32]*tokenBucket)
+	}
+	// the bucket refills at the rate of the limit, up to the limit
+	rate := float64(rateLimit) / apiBucketsRefillTime.Seconds()
+	b := l.buckets[uid]
+	if b == nil {
+		b = &tokenBucket{tokens: float64(rateLimit), last: now}
+		l.buckets[uid] = b
+	}
+	b.tokens = math.Min(float64(rateLimit), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return &retryAfterResponse{RateLimited("too many requests, try again later"), wait}
+	}
+	b.tokens--
+	return nil
+}
+
+// trackChange remembers the change, if any, was started by the uid and
+// releases the change reserved for the request, if any.
+func (l *apiLimiter) trackChange(ucred *ucrednet, chgID string, reserved bool) {
+	if exemptFromAPILimits(ucred) || (chgID == "" && !reserved) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if reserved {
+		l.reserved[ucred.Uid]--
+		if l.reserved[ucred.Uid] <= 0 {
+			delete(l.reserved, ucred.Uid)
+		}
+	}
+	if chgID == "" {
+		return
+	}
+	if l.changes == nil {
+		l.changes = make(map[uint32][]string)
+	}
+	l.changes[ucred.Uid] = append(l.changes[ucred.Uid], chgID)
+}
@@ -0,0 +1,168 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/sysconfig"
+)
+
+var metricsCmd = &Command{
+	Path:       "/v2/metrics",
+	GET:        getMetrics,
+	ReadAccess: openAccess{},
+}
+
+var sysconfigCloudInitStatus = sysconfig.CloudInitStatus
+
+var cloudInitStatusNames = map[sysconfig.CloudInitState]string{
+	sysconfig.CloudInitDisabledPermanently: "disabled",
+	sysconfig.CloudInitRestrictedBySnapd:   "restricted",
+	sysconfig.CloudInitUntriggered:         "untriggered",
+	sysconfig.CloudInitDone:                "done",
+	sysconfig.CloudInitEnabled:             "enabled",
+	sysconfig.CloudInitNotFound:            "not-found",
+	sysconfig.CloudInitErrored:             "errored",
+}
+
+// metricsResponse serves metrics in the Prometheus text exposition format.
+type metricsResponse []byte
+
+func (m metricsResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Header().Set("Content-Length", strconv.Itoa(len(m)))
+	w.WriteHeader(200)
+	w.Write(m)
+}
+
+type metricsWriter struct {
+	bytes.Buffer
+}
+
+func (mw *metricsWriter) header(name, typ, help string) {
+	fmt.Fprintf(mw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (mw *metricsWriter) value(name string, v float64) {
+	fmt.Fprintf(mw, "%s %s\n", name, strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+// labelled writes one sample per label value, sorted by label value.
+func (mw *metricsWriter) labelled(name, label string, values map[string]float64) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		mw.value(fmt.Sprintf("%s{%s=%s}", name, label, strconv.Quote(k)), values[k])
+	}
+}
+
+func metricsEnabled(st *state.State) bool {
+	var enabled bool
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "api.metrics", &enabled); err != nil && !config.IsNoOption(err) {
+		return false
+	}
+	return enabled
+}
+
+func getMetrics(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if !metricsEnabled(st) {
+		return NotFound("metrics are not enabled, see the core api.metrics option")
+	}
+
+	var mw metricsWriter
+
+	type changeKey struct{ kind, status string }
+	changes := make(map[changeKey]float64)
+	failedTasks := make(map[string]float64)
+	var refreshSum float64
+	var refreshCount int
+	for _, chg := range st.Changes() {
+		status := chg.Status()
+		changes[changeKey{chg.Kind(), status.String()}]++
+		for _, t := range chg.Tasks() {
+			if t.Status() == state.ErrorStatus {
+				failedTasks[t.Kind()]++
+			}
+		}
+		switch chg.Kind() {
+		case "refresh-snap", "auto-refresh":
+			if status == state.DoneStatus {
+				refreshSum += chg.ReadyTime().Sub(chg.SpawnTime()).Seconds()
+				refreshCount++
+			}
+		}
+	}
+
+	mw.header("snapd_changes", "gauge", "Number of changes known to snapd by kind and status.")
+	keys := make([]changeKey, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].kind != keys[j].kind {
+			return keys[i].kind < keys[j].kind
+		}
+		return keys[i].status < keys[j].status
+	})
+	for _, k := range keys {
+		mw.value(fmt.Sprintf("snapd_changes{kind=%s,status=%s}", strconv.Quote(k.kind), strconv.Quote(k.status)), changes[k])
+	}
+
+	mw.header("snapd_failed_tasks", "gauge", "Number of failed tasks known to snapd by kind.")
+	mw.labelled("snapd_failed_tasks", "kind", failedTasks)
+
+	mw.header("snapd_refresh_duration_seconds", "summary", "Duration of the successful refreshes known to snapd.")
+	mw.value("snapd_refresh_duration_seconds_sum", refreshSum)
+	mw.value("snapd_refresh_duration_seconds_count", float64(refreshCount))
+
+	mw.header("snapd_downloaded_bytes_total", "counter", "Bytes of snaps downloaded since snapd started.")
+	mw.value("snapd_downloaded_bytes_total", float64(store.DownloadedBytes()))
+
+	storeErrors := make(map[string]float64)
+	for kind, n := range store.ErrorCounts() {
+		storeErrors[string(kind)] = float64(n)
+	}
+	mw.header("snapd_store_errors_total", "counter", "Failed store request attempts since snapd started by kind of error.")
+	mw.labelled("snapd_store_errors_total", "kind", storeErrors)
+
+	if status, err := sysconfigCloudInitStatus(); err == nil {
+		mw.header("snapd_cloud_init_status", "gauge", "Status of cloud-init.")
+		mw.labelled("snapd_cloud_init_status", "status", map[string]float64{cloudInitStatusNames[status]: 1})
+	}
+
+	return metricsResponse(mw.Bytes())
+}
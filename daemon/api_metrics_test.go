@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/sysconfig"
+	"github.com/snapcore/snapd/testutil"
+)
+
+var _ = check.Suite(&metricsSuite{})
+
+type metricsSuite struct {
+	apiBaseSuite
+}
+
+func (s *metricsSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.AddCleanup(daemon.MockSysconfigCloudInitStatus(func() (sysconfig.CloudInitState, error) {
+		return sysconfig.CloudInitDone, nil
+	}))
+}
+
+func (s *metricsSuite) enableMetrics(c *check.C, st *state.State) {
+	st.Lock()
+	defer st.Unlock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "api.metrics", true), check.IsNil)
+	tr.Commit()
+}
+
+func (s *metricsSuite) TestMetricsDisabled(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/metrics", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 404)
+	c.Check(rspe.Message, check.Equals, "metrics are not enabled, see the core api.metrics option")
+}
+
+func (s *metricsSuite) TestMetrics(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	s.enableMetrics(c, st)
+
+	st.Lock()
+	chg := st.NewChange("install-snap", "install")
+	t := st.NewTask("download-snap", "download")
+	t.SetStatus(state.ErrorStatus)
+	chg.AddTask(t)
+	chg = st.NewChange("auto-refresh", "refresh")
+	t = st.NewTask("link-snap", "link")
+	chg.AddTask(t)
+	t.SetStatus(state.DoneStatus)
+	chg.SetStatus(state.DoneStatus)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/metrics", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.req(c, req, nil)
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+	c.Assert(rec.Code, check.Equals, 200)
+	c.Check(rec.Header().Get("Content-Type"), check.Equals, "text/plain; version=0.0.4")
+
+	body := rec.Body.String()
+	c.Check(body, testutil.Contains, `# TYPE snapd_changes gauge
+snapd_changes{kind="auto-refresh",status="Done"} 1
+snapd_changes{kind="install-snap",status="Error"} 1
+`)
+	c.Check(body, testutil.Contains, `# TYPE snapd_failed_tasks gauge
+snapd_failed_tasks{kind="download-snap"} 1
+`)
+	c.Check(body, testutil.Contains, "snapd_refresh_duration_seconds_count 1\n")
+	c.Check(body, testutil.Contains, "# TYPE snapd_downloaded_bytes_total counter\nsnapd_downloaded_bytes_total ")
+	c.Check(body, testutil.Contains, "# TYPE snapd_store_errors_total counter\n")
+	c.Check(body, testutil.Contains, `snapd_cloud_init_status{status="done"} 1`+"\n")
+}
+
+func (s *metricsSuite) TestMetricsCloudInitStatusError(c *check.C) {
+	d := s.daemon(c)
+	s.enableMetrics(c, d.Overlord().State())
+	s.AddCleanup(daemon.MockSysconfigCloudInitStatus(func() (sysconfig.CloudInitState, error) {
+		return 0, errors.New("boom")
+	}))
+
+	req, err := http.NewRequest("GET", "/v2/metrics", nil)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil).ServeHTTP(rec, req)
+	c.Assert(rec.Code, check.Equals, 200)
+	c.Check(rec.Body.String(), check.Not(testutil.Contains), "snapd_cloud_init_status")
+	c.Check(rec.Body.String(), testutil.Contains, "snapd_refresh_duration_seconds_count 0\n")
+}
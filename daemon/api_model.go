@@ -36,6 +36,11 @@ var (
 		GET:        getSerial,
 		ReadAccess: openAccess{},
 	}
+	registrationModelCmd = &Command{
+		Path:       "/v2/model/registration",
+		GET:        getRegistration,
+		ReadAccess: openAccess{},
+	}
 	modelCmd = &Command{
 		Path:        "/v2/model",
 		POST:        postModel,
@@ -45,10 +50,18 @@ var (
 	}
 )
 
-var devicestateRemodel = devicestate.Remodel
+var (
+	devicestateRemodel      = devicestate.Remodel
+	devicestatePlanRemodel  = devicestate.PlanRemodel
+	devicestateQueueRemodel = devicestate.QueueRemodel
+)
 
 type postModelData struct {
 	NewModel string `json:"new-model"`
+	DryRun   bool   `json:"dry-run,omitempty"`
+	// Queue requests the remodel to be started at the next
+	// maintenance window or reboot instead of immediately.
+	Queue bool `json:"queue,omitempty"`
 }
 
 type modelAssertJSON struct {
@@ -63,6 +76,9 @@ func postModel(c *Command, r *http.Request, _ *auth.UserState) Response {
 	if err := decoder.Decode(&data); err != nil {
 		return BadRequest("cannot decode request body into remodel operation: %v", err)
 	}
+	if data.DryRun && data.Queue {
+		return BadRequest("cannot both queue and dry-run a remodel")
+	}
 	rawNewModel, err := asserts.Decode([]byte(data.NewModel))
 	if err != nil {
 		return BadRequest("cannot decode new model assertion: %v", err)
@@ -76,6 +92,20 @@ func postModel(c *Command, r *http.Request, _ *auth.UserState) Response {
 	st.Lock()
 	defer st.Unlock()
 
+	if data.DryRun {
+		plan, err := devicestatePlanRemodel(st, newModel)
+		if err != nil {
+			return BadRequest("cannot remodel device: %v", err)
+		}
+		return SyncResponse(plan)
+	}
+	if data.Queue {
+		if err := devicestateQueueRemodel(st, newModel); err != nil {
+			return BadRequest("cannot remodel device: %v", err)
+		}
+		return SyncResponse(nil)
+	}
+
 	chg, err := devicestateRemodel(st, newModel)
 	if err != nil {
 		return BadRequest("cannot remodel device: %v", err)
@@ -165,3 +195,19 @@ func getSerial(c *Command, r *http.Request, _ *auth.UserState) Response {
 
 	return AssertResponse([]asserts.Assertion{serial}, false)
 }
+
+// getRegistration gets the progress of the device registration using the
+// DeviceManager
+func getRegistration(c *Command, r *http.Request, _ *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	devmgr := c.d.overlord.DeviceManager()
+
+	status, err := devmgr.RegistrationStatus()
+	if err != nil {
+		return InternalError("accessing registration status failed: %v", err)
+	}
+	return SyncResponse(status)
+}
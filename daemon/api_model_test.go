@@ -22,6 +22,7 @@ package daemon_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -132,6 +133,130 @@ func (s *modelSuite) TestPostRemodel(c *check.C) {
 	c.Assert(soon, check.Equals, 1)
 }
 
+func (s *modelSuite) TestPostRemodelDryRun(c *check.C) {
+	s.expectRootAccess()
+
+	newModel := s.Brands.Model("my-brand", "my-old-model", modelDefaults, map[string]interface{}{
+		"revision": "2",
+	})
+
+	d := s.daemonWithOverlordMockAndStore(c)
+	st := d.Overlord().State()
+
+	defer daemon.MockDevicestateRemodel(func(st *state.State, nm *asserts.Model) (*state.Change, error) {
+		c.Fatalf("unexpected remodel")
+		return nil, nil
+	})()
+	var gotModel *asserts.Model
+	plan := &devicestate.RemodelPlan{
+		Kind:       "revision update remodel",
+		Install:    []devicestate.RemodelPlanSnap{{Name: "new-snap"}},
+		Assertions: []string{"model my-brand/my-old-model (2)"},
+	}
+	defer daemon.MockDevicestatePlanRemodel(func(st *state.State, nm *asserts.Model) (*devicestate.RemodelPlan, error) {
+		gotModel = nm
+		return plan, nil
+	})()
+
+	data, err := json.Marshal(daemon.PostModelData{
+		NewModel: string(asserts.Encode(newModel)),
+		DryRun:   true,
+	})
+	c.Check(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/model", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 200)
+	c.Check(rsp.Result, check.Equals, plan)
+	c.Check(gotModel, check.DeepEquals, newModel)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+}
+
+func (s *modelSuite) TestPostRemodelDryRunError(c *check.C) {
+	s.expectRootAccess()
+
+	newModel := s.Brands.Model("my-brand", "my-old-model", modelDefaults, map[string]interface{}{
+		"revision": "2",
+	})
+
+	s.daemonWithOverlordMockAndStore(c)
+
+	defer daemon.MockDevicestatePlanRemodel(func(st *state.State, nm *asserts.Model) (*devicestate.RemodelPlan, error) {
+		return nil, errors.New("cannot remodel until fully seeded")
+	})()
+
+	data, err := json.Marshal(daemon.PostModelData{
+		NewModel: string(asserts.Encode(newModel)),
+		DryRun:   true,
+	})
+	c.Check(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/model", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Assert(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot remodel device: cannot remodel until fully seeded")
+}
+
+func (s *modelSuite) TestPostRemodelQueue(c *check.C) {
+	s.expectRootAccess()
+
+	newModel := s.Brands.Model("my-brand", "my-old-model", modelDefaults, map[string]interface{}{
+		"revision": "2",
+	})
+
+	d := s.daemonWithOverlordMockAndStore(c)
+	st := d.Overlord().State()
+
+	defer daemon.MockDevicestateRemodel(func(st *state.State, nm *asserts.Model) (*state.Change, error) {
+		c.Fatalf("unexpected remodel")
+		return nil, nil
+	})()
+	var gotModel *asserts.Model
+	defer daemon.MockDevicestateQueueRemodel(func(st *state.State, nm *asserts.Model) error {
+		gotModel = nm
+		return nil
+	})()
+
+	data, err := json.Marshal(daemon.PostModelData{
+		NewModel: string(asserts.Encode(newModel)),
+		Queue:    true,
+	})
+	c.Check(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/model", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 200)
+	c.Check(gotModel, check.DeepEquals, newModel)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+}
+
+func (s *modelSuite) TestPostRemodelQueueAndDryRun(c *check.C) {
+	s.daemon(c)
+	s.expectRootAccess()
+
+	data, err := json.Marshal(daemon.PostModelData{
+		NewModel: "model",
+		Queue:    true,
+		DryRun:   true,
+	})
+	c.Check(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/model", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Assert(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot both queue and dry-run a remodel")
+}
+
 func (s *modelSuite) TestGetModelNoModelAssertion(c *check.C) {
 
 	d := s.daemonWithOverlordMockAndStore(c)
@@ -376,3 +501,44 @@ func (s *modelSuite) TestGetModelJSONHasSerialAssertion(c *check.C) {
 	c.Assert(devKey, check.FitsTypeOf, "")
 	c.Assert(devKey.(string), check.Equals, string(encDevKey))
 }
+
+func (s *modelSuite) TestGetModelRegistration(c *check.C) {
+	d := s.daemonWithOverlordMockAndStore(c)
+	hookMgr, err := hookstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
+	c.Assert(err, check.IsNil)
+	deviceMgr, err := devicestate.Manager(d.Overlord().State(), hookMgr, d.Overlord().TaskRunner(), nil)
+	c.Assert(err, check.IsNil)
+	d.Overlord().AddManager(deviceMgr)
+
+	attemptTime := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	st := d.Overlord().State()
+	st.Lock()
+	devicestatetest.SetDevice(st, &auth.DeviceState{
+		Brand: "my-brand",
+		Model: "my-old-model",
+	})
+	st.Set("registration-attempts", []devicestate.RegistrationAttempt{{
+		Time:    attemptTime,
+		URL:     "https://serial-vault.example.com/serial",
+		Outcome: "retry",
+		Error:   "cannot retrieve request-id for making a request for a serial: unexpected status 503",
+	}})
+	chg := st.NewChange("become-operational", "...")
+	// a change without tasks is ready
+	chg.AddTask(st.NewTask("request-serial", "..."))
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/model/registration", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 200)
+	c.Check(rsp.Result, check.DeepEquals, &devicestate.RegistrationStatus{
+		ChangeID: chg.ID(),
+		Attempts: []devicestate.RegistrationAttempt{{
+			Time:    attemptTime,
+			URL:     "https://serial-vault.example.com/serial",
+			Outcome: "retry",
+			Error:   "cannot retrieve request-id for making a request for a serial: unexpected status 503",
+		}},
+	})
+}
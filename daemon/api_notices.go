@@ -0,0 +1,102 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var (
+	noticesCmd = &Command{
+		Path:        "/v2/notices",
+		GET:         getNotices,
+		POST:        postNotices,
+		ReadAccess:  openAccess{},
+		WriteAccess: openAccess{},
+	}
+)
+
+// getNotices returns the notices visible to the user making the request,
+// i.e. the public ones and those for that user, optionally only those of
+// the given types or those waiting for acknowledgment.
+func getNotices(c *Command, r *http.Request, user *auth.UserState) Response {
+	ucred, err := ucrednetGet(r.RemoteAddr)
+	if err != nil {
+		return Forbidden("cannot get remote user: %v", err)
+	}
+
+	query := r.URL.Query()
+	filter := &state.NoticeFilter{UserID: &ucred.Uid}
+	switch sel := query.Get("select"); sel {
+	case "pending":
+		filter.Pending = true
+	case "all", "":
+	default:
+		return BadRequest("invalid select parameter: %q", sel)
+	}
+	if types := query.Get("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			filter.Types = append(filter.Types, state.NoticeType(t))
+		}
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	notices := st.Notices(filter)
+	if notices == nil {
+		notices = []*state.Notice{}
+	}
+	return SyncResponse(notices)
+}
+
+// postNotices acknowledges a notice visible to the user making the request.
+func postNotices(c *Command, r *http.Request, user *auth.UserState) Response {
+	var op struct {
+		Action string `json:"action"`
+		ID     string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&op); err != nil {
+		return BadRequest("cannot decode request body into notices operation: %v", err)
+	}
+	if op.Action != "ack" {
+		return BadRequest("unknown notices action %q", op.Action)
+	}
+
+	ucred, err := ucrednetGet(r.RemoteAddr)
+	if err != nil {
+		return Forbidden("cannot get remote user: %v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if !st.AckNotice(ucred.Uid, op.ID) {
+		return NotFound("cannot find notice %q", op.ID)
+	}
+	return SyncResponse(true)
+}
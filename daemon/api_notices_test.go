@@ -0,0 +1,119 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = check.Suite(&noticesSuite{})
+
+type noticesSuite struct {
+	apiBaseSuite
+}
+
+func (s *noticesSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.daemon(c)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.expectWriteAccess(daemon.OpenAccess{})
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	uid1 := uint32(1000)
+	uid2 := uint32(1001)
+	st.AddNotice(nil, state.RefreshInhibitNotice, "foo", &state.AddNoticeOptions{
+		Data:        map[string]string{"busy-app": "foo"},
+		RequiresAck: true,
+	})
+	st.AddNotice(&uid1, "other", "user1", nil)
+	st.AddNotice(&uid2, "other", "user2", nil)
+}
+
+func (s *noticesSuite) noticesReq(c *check.C, method, uid, query, body string) *http.Request {
+	req, err := http.NewRequest(method, "/v2/notices"+query, bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = "pid=100;uid=" + uid + ";socket=;"
+	return req
+}
+
+func noticeIDs(result interface{}) []string {
+	var ids []string
+	for _, n := range result.([]*state.Notice) {
+		ids = append(ids, n.ID())
+	}
+	return ids
+}
+
+func (s *noticesSuite) TestGetNotices(c *check.C) {
+	rsp := s.syncReq(c, s.noticesReq(c, "GET", "1000", "", ""), nil)
+	c.Check(noticeIDs(rsp.Result), check.DeepEquals, []string{"1", "2"})
+
+	rsp = s.syncReq(c, s.noticesReq(c, "GET", "1001", "", ""), nil)
+	c.Check(noticeIDs(rsp.Result), check.DeepEquals, []string{"1", "3"})
+
+	rsp = s.syncReq(c, s.noticesReq(c, "GET", "0", "?types=other", ""), nil)
+	c.Check(noticeIDs(rsp.Result), check.DeepEquals, []string{"2", "3"})
+
+	rsp = s.syncReq(c, s.noticesReq(c, "GET", "1000", "?select=pending", ""), nil)
+	c.Check(noticeIDs(rsp.Result), check.DeepEquals, []string{"1"})
+
+	rsp = s.syncReq(c, s.noticesReq(c, "GET", "1000", "?types=unknown", ""), nil)
+	c.Check(rsp.Result, check.HasLen, 0)
+
+	rspe := s.errorReq(c, s.noticesReq(c, "GET", "1000", "?select=foo", ""), nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid select parameter: "foo"`)
+}
+
+func (s *noticesSuite) TestAckNotice(c *check.C) {
+	// notices of other users cannot be acknowledged
+	rspe := s.errorReq(c, s.noticesReq(c, "POST", "1000", "", `{"action": "ack", "id": "3"}`), nil)
+	c.Check(rspe.Status, check.Equals, 404)
+	c.Check(rspe.Message, check.Equals, `cannot find notice "3"`)
+
+	rsp := s.syncReq(c, s.noticesReq(c, "POST", "1000", "", `{"action": "ack", "id": "1"}`), nil)
+	c.Check(rsp.Result, check.Equals, true)
+
+	rsp = s.syncReq(c, s.noticesReq(c, "GET", "1000", "?select=pending", ""), nil)
+	c.Check(rsp.Result, check.HasLen, 0)
+}
+
+func (s *noticesSuite) TestPostNoticesErrors(c *check.C) {
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{`, `cannot decode request body into notices operation: .*`},
+		{`{"action": "frob"}`, `unknown notices action "frob"`},
+	} {
+		rspe := s.errorReq(c, s.noticesReq(c, "POST", "1000", "", t.body), nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(t.body))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf(t.body))
+	}
+}
@@ -0,0 +1,118 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/overlord/auth"
+)
+
+var (
+	promptingRulesCmd = &Command{
+		Path:        "/v2/interfaces/requests/rules",
+		GET:         getPromptingRules,
+		POST:        postPromptingRules,
+		ReadAccess:  openAccess{},
+		WriteAccess: openAccess{},
+	}
+)
+
+type promptingRuleData struct {
+	Snap        string            `json:"snap"`
+	Interface   string            `json:"interface"`
+	PathPattern string            `json:"path-pattern"`
+	Permissions []string          `json:"permissions"`
+	Outcome     prompting.Outcome `json:"outcome"`
+}
+
+type postPromptingRulesData struct {
+	// Action can be "add" or "remove"
+	Action string             `json:"action"`
+	Rule   *promptingRuleData `json:"rule,omitempty"`
+	ID     string             `json:"id,omitempty"`
+}
+
+// promptingRulesFor returns the prompting rules and the uid of the user
+// making the request. Users can only see and change their own rules.
+func promptingRulesFor(c *Command, r *http.Request) (*prompting.RuleDB, uint32, Response) {
+	ucred, err := ucrednetGet(r.RemoteAddr)
+	if err != nil {
+		return nil, 0, Forbidden("cannot get remote user: %v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	rules, err := c.d.overlord.InterfaceManager().PromptingRules()
+	if err != nil {
+		return nil, 0, BadRequest("%v", err)
+	}
+	return rules, ucred.Uid, nil
+}
+
+// getPromptingRules returns the rules of the user created in reply to
+// prompts, optionally for the given snap and interface.
+func getPromptingRules(c *Command, r *http.Request, user *auth.UserState) Response {
+	rules, uid, rsp := promptingRulesFor(c, r)
+	if rsp != nil {
+		return rsp
+	}
+	query := r.URL.Query()
+	return SyncResponse(rules.Rules(uid, query.Get("snap"), query.Get("interface")))
+}
+
+// postPromptingRules adds or removes a rule of the user.
+func postPromptingRules(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postPromptingRulesData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		return BadRequest("cannot decode prompting rules action from request body: %v", err)
+	}
+
+	rules, uid, rsp := promptingRulesFor(c, r)
+	if rsp != nil {
+		return rsp
+	}
+
+	switch data.Action {
+	case "add":
+		if data.Rule == nil {
+			return BadRequest("rule must be provided")
+		}
+		rule, err := rules.AddRule(uid, data.Rule.Snap, data.Rule.Interface, data.Rule.PathPattern, data.Rule.Permissions, data.Rule.Outcome)
+		if err != nil {
+			return BadRequest("%v", err)
+		}
+		return SyncResponse(rule)
+	case "remove":
+		rule, err := rules.RemoveRule(uid, data.ID)
+		if err == prompting.ErrRuleNotFound {
+			return NotFound("cannot find prompting rule %q", data.ID)
+		}
+		if err != nil {
+			return InternalError("%v", err)
+		}
+		return SyncResponse(rule)
+	default:
+		return BadRequest("unknown prompting rules action %q", data.Action)
+	}
+}
@@ -0,0 +1,132 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+)
+
+var _ = check.Suite(&promptingSuite{})
+
+type promptingSuite struct {
+	apiBaseSuite
+}
+
+func (s *promptingSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.daemon(c)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "experimental.apparmor-prompting", true)
+	tr.Commit()
+
+	s.expectWriteAccess(daemon.OpenAccess{})
+}
+
+func (s *promptingSuite) postRules(c *check.C, uid string, body string) *http.Request {
+	req, err := http.NewRequest("POST", "/v2/interfaces/requests/rules", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = "pid=100;uid=" + uid + ";socket=;"
+	return req
+}
+
+func (s *promptingSuite) getRules(c *check.C, uid string, query string) *http.Request {
+	req, err := http.NewRequest("GET", "/v2/interfaces/requests/rules"+query, nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = "pid=100;uid=" + uid + ";socket=;"
+	return req
+}
+
+func (s *promptingSuite) TestAddListRemoveRules(c *check.C) {
+	rsp := s.syncReq(c, s.postRules(c, "1000", `{"action": "add", "rule": {"snap": "foo", "interface": "home", "path-pattern": "/home/test/**", "permissions": ["read"], "outcome": "allow"}}`), nil)
+	rule := rsp.Result.(*prompting.Rule)
+	c.Check(rule.ID, check.Equals, "1")
+	c.Check(rule.User, check.Equals, uint32(1000))
+	c.Check(rule.Snap, check.Equals, "foo")
+	c.Check(rule.Outcome, check.Equals, prompting.OutcomeAllow)
+
+	rsp = s.syncReq(c, s.postRules(c, "1000", `{"action": "add", "rule": {"snap": "bar", "interface": "removable-media", "path-pattern": "/media/**", "permissions": ["write"], "outcome": "deny"}}`), nil)
+	c.Check(rsp.Result.(*prompting.Rule).ID, check.Equals, "2")
+
+	rsp = s.syncReq(c, s.getRules(c, "1000", ""), nil)
+	c.Check(rsp.Result, check.HasLen, 2)
+	rsp = s.syncReq(c, s.getRules(c, "1000", "?snap=foo"), nil)
+	c.Check(rsp.Result, check.DeepEquals, []*prompting.Rule{rule})
+	rsp = s.syncReq(c, s.getRules(c, "1000", "?interface=removable-media"), nil)
+	c.Check(rsp.Result, check.HasLen, 1)
+
+	// other users do not see the rules
+	rsp = s.syncReq(c, s.getRules(c, "1001", ""), nil)
+	c.Check(rsp.Result, check.HasLen, 0)
+	rspe := s.errorReq(c, s.postRules(c, "1001", `{"action": "remove", "id": "1"}`), nil)
+	c.Check(rspe.Status, check.Equals, 404)
+	c.Check(rspe.Message, check.Equals, `cannot find prompting rule "1"`)
+
+	rsp = s.syncReq(c, s.postRules(c, "1000", `{"action": "remove", "id": "1"}`), nil)
+	c.Check(rsp.Result, check.DeepEquals, rule)
+	rsp = s.syncReq(c, s.getRules(c, "1000", "?snap=foo"), nil)
+	c.Check(rsp.Result, check.HasLen, 0)
+}
+
+func (s *promptingSuite) TestPostRulesErrors(c *check.C) {
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{`, `cannot decode prompting rules action from request body: .*`},
+		{`{"action": "frob"}`, `unknown prompting rules action "frob"`},
+		{`{"action": "add"}`, `rule must be provided`},
+		{`{"action": "add", "rule": {"snap": "foo", "interface": "camera", "path-pattern": "/dev/video0", "permissions": ["read"], "outcome": "allow"}}`, `cannot add prompting rule: unsupported interface "camera"`},
+	} {
+		rspe := s.errorReq(c, s.postRules(c, "1000", t.body), nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(t.body))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf(t.body))
+	}
+}
+
+func (s *promptingSuite) TestRulesFeatureDisabled(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "experimental.apparmor-prompting", false)
+	tr.Commit()
+	st.Unlock()
+
+	rspe := s.errorReq(c, s.getRules(c, "1000", ""), nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `experimental feature disabled - test it by setting 'experimental.apparmor-prompting' to true`)
+}
+
+func (s *promptingSuite) TestRulesNoUser(c *check.C) {
+	req, err := http.NewRequest("GET", "/v2/interfaces/requests/rules", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 403)
+}
@@ -101,7 +101,9 @@ func getQuotaGroups(c *Command, r *http.Request, _ *auth.UserState) Response {
 			Subgroups: group.SubGroups,
 			Snaps:     group.Snaps,
 			Constraints: &client.QuotaValues{
-				Memory: group.MemoryLimit,
+				Memory:  group.MemoryLimit,
+				CPUSet:  group.CPUSet,
+				Journal: journalQuotaValues(group.JournalLimit),
 			},
 			Current: &client.QuotaValues{
 				Memory: memoryUsage,
@@ -142,7 +144,9 @@ func getQuotaGroupInfo(c *Command, r *http.Request, _ *auth.UserState) Response
 		Snaps:     group.Snaps,
 		Subgroups: group.SubGroups,
 		Constraints: &client.QuotaValues{
-			Memory: group.MemoryLimit,
+			Memory:  group.MemoryLimit,
+			CPUSet:  group.CPUSet,
+			Journal: journalQuotaValues(group.JournalLimit),
 		},
 		Current: &client.QuotaValues{
 			Memory: memoryUsage,
@@ -181,7 +185,7 @@ func postQuotaGroup(c *Command, r *http.Request, _ *auth.UserState) Response {
 		}
 		if err == servicestate.ErrQuotaNotFound {
 			// then we need to create the quota
-			ts, err = servicestateCreateQuota(st, data.GroupName, data.Parent, data.Snaps, data.Constraints.Memory)
+			ts, err = servicestateCreateQuota(st, data.GroupName, data.Parent, data.Snaps, data.Constraints.Memory, data.Constraints.CPUSet, journalQuota(data.Constraints.Journal))
 			if err != nil {
 				return errToResponse(err, nil, BadRequest, "cannot create quota group: %v")
 			}
@@ -189,8 +193,10 @@ func postQuotaGroup(c *Command, r *http.Request, _ *auth.UserState) Response {
 		} else if err == nil {
 			// the quota group already exists, update it
 			updateOpts := servicestate.QuotaGroupUpdate{
-				AddSnaps:       data.Snaps,
-				NewMemoryLimit: data.Constraints.Memory,
+				AddSnaps:        data.Snaps,
+				NewMemoryLimit:  data.Constraints.Memory,
+				NewCPUSet:       data.Constraints.CPUSet,
+				NewJournalLimit: journalQuota(data.Constraints.Journal),
 			}
 			ts, err = servicestateUpdateQuota(st, data.GroupName, updateOpts)
 			if err != nil {
@@ -214,3 +220,25 @@ func postQuotaGroup(c *Command, r *http.Request, _ *auth.UserState) Response {
 	ensureStateSoon(st)
 	return AsyncResponse(nil, chg.ID())
 }
+
+func journalQuota(journal *client.QuotaJournalValues) *quota.JournalQuota {
+	if journal == nil {
+		return nil
+	}
+	return &quota.JournalQuota{
+		Size:       journal.Size,
+		RateCount:  journal.RateCount,
+		RatePeriod: journal.RatePeriod,
+	}
+}
+
+func journalQuotaValues(journal *quota.JournalQuota) *client.QuotaJournalValues {
+	if journal == nil {
+		return nil
+	}
+	return &client.QuotaJournalValues{
+		Size:       journal.Size,
+		RateCount:  journal.RateCount,
+		RatePeriod: journal.RatePeriod,
+	}
+}
@@ -0,0 +1,97 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+
+	"github.com/snapcore/snapd/jsonutil"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/registrystate"
+	"github.com/snapcore/snapd/registry"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	registryCmd = &Command{
+		Path:        "/v2/registry/{account}/{registry}/{view}",
+		GET:         getRegistryView,
+		PUT:         setRegistryView,
+		ReadAccess:  authenticatedAccess{},
+		WriteAccess: authenticatedAccess{},
+	}
+)
+
+// registrySetResult holds the result of setting values through a registry
+// view, the change running the hooks of the snaps observing the registry
+// if there are any.
+type registrySetResult struct {
+	Change string `json:"change,omitempty"`
+}
+
+func getRegistryView(c *Command, r *http.Request, user *auth.UserState) Response {
+	vars := muxVars(r)
+	keys := strutil.CommaSeparatedList(r.URL.Query().Get("keys"))
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	view, err := registrystate.View(st, vars["account"], vars["registry"], vars["view"])
+	if err != nil {
+		return NotFound(err.Error())
+	}
+	values, err := registrystate.Get(st, view, keys)
+	if err != nil {
+		if registry.IsNotFound(err) {
+			return NotFound(err.Error())
+		}
+		return BadRequest(err.Error())
+	}
+	return SyncResponse(values)
+}
+
+func setRegistryView(c *Command, r *http.Request, user *auth.UserState) Response {
+	vars := muxVars(r)
+
+	var values map[string]interface{}
+	if err := jsonutil.DecodeWithNumber(r.Body, &values); err != nil {
+		return BadRequest("cannot decode request body into registry values: %v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	view, err := registrystate.View(st, vars["account"], vars["registry"], vars["view"])
+	if err != nil {
+		return NotFound(err.Error())
+	}
+	chg, err := registrystate.Set(st, view, values, "")
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+
+	var res registrySetResult
+	if chg != nil {
+		res.Change = chg.ID()
+	}
+	return SyncResponse(res)
+}
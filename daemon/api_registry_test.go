@@ -0,0 +1,124 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/assertstate/assertstatetest"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
+)
+
+var _ = check.Suite(&registrySuite{})
+
+type registrySuite struct {
+	apiBaseSuite
+}
+
+func (s *registrySuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+	d := s.daemon(c)
+
+	s.expectAuthenticatedAccess()
+
+	storeSigning := assertstest.NewStoreStack("can0nical", nil)
+	a, err := storeSigning.Sign(asserts.RegistryType, map[string]interface{}{
+		"authority-id": "can0nical",
+		"account-id":   "can0nical",
+		"name":         "network",
+		"views": map[string]interface{}{
+			"wifi-setup": map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{"request": "ssid", "storage": "wifi.ssid"},
+					map[string]interface{}{"request": "password", "storage": "wifi.psk", "access": "write"},
+				},
+			},
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	assertstatetest.AddMany(st, storeSigning.StoreAccountKey(""), a)
+	ifacerepo.Replace(st, interfaces.NewRepository())
+}
+
+func (s *registrySuite) TestSetGetView(c *check.C) {
+	req, err := http.NewRequest("PUT", "/v2/registry/can0nical/network/wifi-setup", bytes.NewBufferString(`{"ssid": "home", "password": "secret"}`))
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+	// no snap observes the registry
+	c.Check(rsp.Result, check.DeepEquals, daemon.RegistrySetResult{})
+
+	req, err = http.NewRequest("GET", "/v2/registry/can0nical/network/wifi-setup?keys=ssid", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, map[string]interface{}{"ssid": "home"})
+
+	req, err = http.NewRequest("GET", "/v2/registry/can0nical/network/wifi-setup", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, map[string]interface{}{"ssid": "home"})
+}
+
+func (s *registrySuite) TestGetViewErrors(c *check.C) {
+	req, err := http.NewRequest("GET", "/v2/registry/can0nical/network/foo", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 404)
+	c.Check(rspe.Message, check.Equals, `cannot find view "foo" in registry can0nical/network`)
+
+	req, err = http.NewRequest("GET", "/v2/registry/can0nical/network/wifi-setup?keys=password", nil)
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 404)
+	c.Check(rspe.Message, check.Matches, `cannot find "password" in view can0nical/network/wifi-setup: .*`)
+}
+
+func (s *registrySuite) TestSetViewErrors(c *check.C) {
+	req, err := http.NewRequest("PUT", "/v2/registry/can0nical/network/wifi-setup", bytes.NewBufferString(`not json`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Matches, `cannot decode request body into registry values: .*`)
+
+	req, err = http.NewRequest("PUT", "/v2/registry/can0nical/other/wifi-setup", bytes.NewBufferString(`{"ssid": "home"}`))
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 404)
+	c.Check(rspe.Message, check.Equals, `cannot find registry can0nical/other: no registry assertion`)
+
+	req, err = http.NewRequest("PUT", "/v2/registry/can0nical/network/wifi-setup", bytes.NewBufferString(`{"foo": "bar"}`))
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `cannot find "foo" in view can0nical/network/wifi-setup: no matching write rule`)
+}
@@ -0,0 +1,73 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var seedSupplementCmd = &Command{
+	Path:        "/v2/seed-supplement",
+	POST:        postSeedSupplement,
+	WriteAccess: rootAccess{},
+}
+
+var devicestateInstallSeedSupplement = devicestate.InstallSeedSupplement
+
+type postSeedSupplementData struct {
+	// Path is the directory of the seed supplement on the device.
+	Path string `json:"path"`
+}
+
+func postSeedSupplement(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postSeedSupplementData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		return BadRequest("cannot decode request body into seed supplement: %v", err)
+	}
+	if data.Path == "" {
+		return BadRequest("seed supplement path must be specified")
+	}
+	if !filepath.IsAbs(data.Path) {
+		return BadRequest("seed supplement path must be absolute, got %q", data.Path)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	names, tss, err := devicestateInstallSeedSupplement(st, data.Path)
+	if err != nil {
+		return errToResponse(err, nil, BadRequest, "cannot install seed supplement: %v")
+	}
+
+	msg := fmt.Sprintf(i18n.G("Install seed supplement snaps %s"), strutil.Quoted(names))
+	chg := newChange(st, "install-seed-supplement", msg, tss, names)
+	ensureStateSoon(st)
+
+	return AsyncResponse(nil, chg.ID())
+}
@@ -0,0 +1,113 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = check.Suite(&seedSupplementSuite{})
+
+type seedSupplementSuite struct {
+	apiBaseSuite
+}
+
+func (s *seedSupplementSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectWriteAccess(daemon.RootAccess{})
+
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {})
+	s.AddCleanup(restore)
+}
+
+func (s *seedSupplementSuite) TestInstall(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	var gotDir string
+	defer daemon.MockDevicestateInstallSeedSupplement(func(st *state.State, dir string) ([]string, []*state.TaskSet, error) {
+		gotDir = dir
+		t := st.NewTask("fake-install", "...")
+		return []string{"foo"}, []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})()
+
+	body := `{"path": "/var/lib/supplement"}`
+	req, err := http.NewRequest("POST", "/v2/seed-supplement", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil)
+	c.Check(gotDir, check.Equals, "/var/lib/supplement")
+
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "install-seed-supplement")
+	c.Check(chg.Summary(), check.Equals, `Install seed supplement snaps "foo"`)
+	c.Check(chg.Tasks(), check.HasLen, 1)
+	var names []string
+	c.Assert(chg.Get("snap-names", &names), check.IsNil)
+	c.Check(names, check.DeepEquals, []string{"foo"})
+}
+
+func (s *seedSupplementSuite) TestInstallError(c *check.C) {
+	s.daemon(c)
+
+	defer daemon.MockDevicestateInstallSeedSupplement(func(st *state.State, dir string) ([]string, []*state.TaskSet, error) {
+		return nil, nil, errors.New("boom")
+	})()
+
+	body := `{"path": "/var/lib/supplement"}`
+	req, err := http.NewRequest("POST", "/v2/seed-supplement", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot install seed supplement: boom")
+}
+
+func (s *seedSupplementSuite) TestInstallBadPath(c *check.C) {
+	s.daemon(c)
+
+	defer daemon.MockDevicestateInstallSeedSupplement(func(st *state.State, dir string) ([]string, []*state.TaskSet, error) {
+		c.Fatalf("unexpected call")
+		return nil, nil, nil
+	})()
+
+	for _, tc := range []struct {
+		body, err string
+	}{
+		{`{}`, "seed supplement path must be specified"},
+		{`{"path": "relative/dir"}`, `seed supplement path must be absolute, got "relative/dir"`},
+		{`{"path": `, "cannot decode request body into seed supplement: unexpected EOF"},
+	} {
+		req, err := http.NewRequest("POST", "/v2/seed-supplement", bytes.NewBufferString(tc.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, tc.err)
+	}
+}
@@ -20,10 +20,12 @@
 package daemon
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
 
@@ -31,6 +33,7 @@ import (
 	"github.com/snapcore/snapd/asserts/snapasserts"
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/assertstate"
@@ -39,6 +42,8 @@ import (
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snapfile"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
 )
 
 const maxReadBuflen = 1024 * 1024
@@ -72,6 +77,11 @@ func sideloadOrTrySnap(c *Command, body io.ReadCloser, boundary string, user *au
 	flags.Unaliased = isTrue(form, "unaliased")
 	flags.IgnoreRunning = isTrue(form, "ignore-running")
 
+	if len(form.File["snap"]) > 1 {
+		defer form.RemoveAll()
+		return sideloadManySnaps(c.d.overlord.State(), form, flags, dangerousOK)
+	}
+
 	// find the file for the "snap" form field
 	var snapBody multipart.File
 	var origPath string
@@ -137,67 +147,253 @@ out:
 	st.Lock()
 	defer st.Unlock()
 
-	var snapName string
-	var sideInfo *snap.SideInfo
+	sideInfo, rsp := sideloadSideInfo(st, tempPath, origPath, dangerousOK, isTrue(form, "devmode"))
+	if rsp != nil {
+		return rsp
+	}
+	snapName := sideInfo.RealName
+
+	if instanceName != "" {
+		requestedSnapName := snap.InstanceSnap(instanceName)
+		if requestedSnapName != snapName {
+			return BadRequest(fmt.Sprintf("instance name %q does not match snap name %q", instanceName, snapName))
+		}
+	} else {
+		instanceName = snapName
+	}
+
+	msg := fmt.Sprintf(i18n.G("Install %q snap from file"), instanceName)
+	if origPath != "" {
+		msg = fmt.Sprintf(i18n.G("Install %q snap from file %q"), instanceName, origPath)
+	}
+
+	tset, _, err := snapstateInstallPath(st, sideInfo, tempPath, instanceName, "", flags)
+	if err != nil {
+		return errToResponse(err, []string{snapName}, InternalError, "cannot install snap file: %v")
+	}
+
+	chg := newChange(st, "install-snap", msg, []*state.TaskSet{tset}, []string{instanceName})
+	chg.Set("api-data", map[string]string{"snap-name": instanceName})
+
+	ensureStateSoon(st)
+
+	// only when the unlock succeeds (as opposed to panicing) is the handoff done
+	// but this is good enough
+	changeTriggered = true
+
+	return AsyncResponse(nil, chg.ID())
+}
 
+// sideloadSideInfo returns the side info of the uploaded snap at tempPath,
+// derived from its assertions unless installing dangerously. origPath is
+// only used for error messages.
+func sideloadSideInfo(st *state.State, tempPath, origPath string, dangerousOK, devmode bool) (*snap.SideInfo, Response) {
 	if !dangerousOK {
 		si, err := snapasserts.DeriveSideInfo(tempPath, assertstate.DB(st))
 		switch {
 		case err == nil:
-			snapName = si.RealName
-			sideInfo = si
+			return si, nil
 		case asserts.IsNotFound(err):
 			// with devmode we try to find assertions but it's ok
 			// if they are not there (implies --dangerous)
-			if !isTrue(form, "devmode") {
+			if !devmode {
 				msg := "cannot find signatures with metadata for snap"
 				if origPath != "" {
 					msg = fmt.Sprintf("%s %q", msg, origPath)
 				}
-				return BadRequest(msg)
+				return nil, BadRequest(msg)
 			}
 			// TODO: set a warning if devmode
 		default:
-			return BadRequest(err.Error())
+			return nil, BadRequest(err.Error())
 		}
 	}
 
-	if snapName == "" {
-		// potentially dangerous but dangerous or devmode params were set
-		info, err := unsafeReadSnapInfo(tempPath)
-		if err != nil {
-			return BadRequest("cannot read snap file: %v", err)
+	// potentially dangerous but dangerous or devmode params were set
+	info, err := unsafeReadSnapInfo(tempPath)
+	if err != nil {
+		return nil, BadRequest("cannot read snap file: %v", err)
+	}
+	return &snap.SideInfo{RealName: info.SnapName()}, nil
+}
+
+// sideloadManySnaps installs the uploaded snap files together, bases,
+// content providers and default-providers found among them are installed
+// before the snaps needing them.
+func sideloadManySnaps(st *state.State, form *multipart.Form, flags snapstate.Flags, dangerousOK bool) Response {
+	if len(form.Value["name"]) > 0 {
+		return BadRequest("cannot specify an instance name when installing several snap files")
+	}
+
+	// we are in charge of the tempfiles life cycle until we hand them off to the change
+	changeTriggered := false
+	var tempPaths []string
+	defer func() {
+		if !changeTriggered {
+			for _, tempPath := range tempPaths {
+				os.Remove(tempPath)
+			}
+		}
+	}()
+
+	var origPaths []string
+	for _, fheader := range form.File["snap"] {
+		tempPath, rsp := copySideloadedSnap(fheader)
+		if tempPath != "" {
+			tempPaths = append(tempPaths, tempPath)
 		}
-		snapName = info.SnapName()
-		sideInfo = &snap.SideInfo{RealName: snapName}
+		if rsp != nil {
+			return rsp
+		}
+		origPaths = append(origPaths, fheader.Filename)
 	}
 
-	if instanceName != "" {
-		requestedSnapName := snap.InstanceSnap(instanceName)
-		if requestedSnapName != snapName {
-			return BadRequest(fmt.Sprintf("instance name %q does not match snap name %q", instanceName, snapName))
+	st.Lock()
+	defer st.Unlock()
+
+	sideInfos := make([]*snap.SideInfo, len(tempPaths))
+	for i, tempPath := range tempPaths {
+		si, rsp := sideloadSideInfo(st, tempPath, origPaths[i], dangerousOK, flags.DevMode)
+		if rsp != nil {
+			return rsp
 		}
-	} else {
-		instanceName = snapName
+		sideInfos[i] = si
 	}
 
-	msg := fmt.Sprintf(i18n.G("Install %q snap from file"), instanceName)
-	if origPath != "" {
-		msg = fmt.Sprintf(i18n.G("Install %q snap from file %q"), instanceName, origPath)
+	tss, infos, err := snapstateInstallPathMany(st, sideInfos, tempPaths, flags)
+	if err != nil {
+		return errToResponse(err, nil, InternalError, "cannot install snap files: %v")
+	}
+
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.InstanceName()
+	}
+	msg := fmt.Sprintf(i18n.G("Install snaps %s from files"), strutil.Quoted(names))
+	chg := newChange(st, "install-snap", msg, tss, names)
+	chg.Set("api-data", map[string][]string{"snap-names": names})
+
+	ensureStateSoon(st)
+
+	changeTriggered = true
+
+	return AsyncResponse(nil, chg.ID())
+}
+
+// copySideloadedSnap copies the uploaded snap file to a temporary file,
+// returning its path.
+func copySideloadedSnap(fheader *multipart.FileHeader) (string, Response) {
+	snapBody, err := fheader.Open()
+	if err != nil {
+		return "", BadRequest(`cannot open uploaded "snap" file: %v`, err)
+	}
+	defer snapBody.Close()
+
+	// also see localInstallCleanup in snapstate/snapmgr.go
+	tmpf, err := ioutil.TempFile(dirs.SnapBlobDir, dirs.LocalInstallBlobTempPrefix)
+	if err != nil {
+		return "", InternalError("cannot create temporary file: %v", err)
+	}
+	defer tmpf.Close()
+
+	if _, err := io.Copy(tmpf, snapBody); err != nil {
+		return tmpf.Name(), InternalError("cannot copy request into temporary file: %v", err)
+	}
+	tmpf.Sync()
+	return tmpf.Name(), nil
+}
+
+var (
+	storeDownloadOCI = store.DownloadOCI
+
+	ociHTTPClient = func() *http.Client {
+		return httputil.NewHTTPClient(&httputil.ClientOptions{
+			MayLogBody: false,
+		})
+	}
+)
+
+// installFromOCI installs the snap mirrored in an OCI registry referenced
+// by inst.OCIRef. The snap must come with the assertions to verify it, the
+// same way as store downloads are verified.
+func installFromOCI(c *Command, inst *snapInstruction) Response {
+	if inst.Action != "install" {
+		return BadRequest("oci-ref can only be specified for install")
+	}
+	if inst.Channel != "" || !inst.Revision.Unset() || inst.CohortKey != "" {
+		return BadRequest("cannot specify channel, revision or cohort-key with oci-ref")
+	}
+	ref, err := store.ParseOCIReference(inst.OCIRef)
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+	instanceName := inst.Snaps[0]
+	if err := snap.ValidateInstanceName(instanceName); err != nil {
+		return BadRequest(err.Error())
+	}
+	flags, err := inst.installFlags()
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+	flags.RemoveSnapPath = true
+
+	// we are in charge of the tempfile life cycle until we hand it off to the change
+	changeTriggered := false
+	// also see localInstallCleanup in snapstate/snapmgr.go
+	tmpf, err := ioutil.TempFile(dirs.SnapBlobDir, dirs.LocalInstallBlobTempPrefix)
+	if err != nil {
+		return InternalError("cannot create temporary file: %v", err)
+	}
+	tmpf.Close()
+	tempPath := tmpf.Name()
+	defer func() {
+		if !changeTriggered {
+			os.Remove(tempPath)
+		}
+	}()
+
+	assertions, err := storeDownloadOCI(inst.ctx, ociHTTPClient(), ref, tempPath)
+	if err != nil {
+		return BadRequest("cannot download snap from OCI registry: %v", err)
+	}
+
+	batch := asserts.NewBatch(nil)
+	if _, err := batch.AddStream(bytes.NewReader(assertions)); err != nil {
+		return BadRequest("cannot decode assertions of %s: %v", ref, err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if err := assertstate.AddBatch(st, batch, &asserts.CommitOptions{
+		Precheck: true,
+	}); err != nil {
+		return BadRequest("cannot add assertions of %s: %v", ref, err)
+	}
+
+	sideInfo, err := snapasserts.DeriveSideInfo(tempPath, assertstate.DB(st))
+	if asserts.IsNotFound(err) {
+		return BadRequest("cannot find signatures with metadata for snap from %s", ref)
+	}
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+	if snap.InstanceSnap(instanceName) != sideInfo.RealName {
+		return BadRequest(fmt.Sprintf("instance name %q does not match snap name %q", instanceName, sideInfo.RealName))
 	}
 
 	tset, _, err := snapstateInstallPath(st, sideInfo, tempPath, instanceName, "", flags)
 	if err != nil {
-		return errToResponse(err, []string{snapName}, InternalError, "cannot install snap file: %v")
+		return errToResponse(err, []string{instanceName}, InternalError, "cannot install snap file: %v")
 	}
 
+	msg := fmt.Sprintf(i18n.G("Install %q snap from OCI registry %q"), instanceName, ref.Registry)
 	chg := newChange(st, "install-snap", msg, []*state.TaskSet{tset}, []string{instanceName})
 	chg.Set("api-data", map[string]string{"snap-name": instanceName})
 
 	ensureStateSoon(st)
 
-	// only when the unlock succeeds (as opposed to panicing) is the handoff done
-	// but this is good enough
 	changeTriggered = true
 
 	return AsyncResponse(nil, chg.ID())
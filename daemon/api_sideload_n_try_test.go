@@ -38,10 +38,12 @@ import (
 	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/assertstate/assertstatetest"
+	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sandbox"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -57,7 +59,7 @@ type sideloadSuite struct {
 func (s *sideloadSuite) SetUpTest(c *check.C) {
 	s.apiBaseSuite.SetUpTest(c)
 
-	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage"})
+	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage", Role: auth.RoleSnapInstall})
 }
 
 var sideLoadBodyWithoutDevMode = "" +
@@ -338,6 +340,116 @@ func (s *sideloadSuite) TestLocalInstallSnapDeriveSideInfo(c *check.C) {
 	})
 }
 
+func (s *sideloadSuite) TestInstallFromOCI(c *check.C) {
+	d := s.daemonWithOverlordMockAndStore(c)
+	st := d.Overlord().State()
+
+	dev1Acct := assertstest.NewAccount(s.StoreSigning, "devel1", nil, "")
+
+	snapDecl, err := s.StoreSigning.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"series":       "16",
+		"snap-id":      "oci-snap-id",
+		"snap-name":    "oci-snap",
+		"publisher-id": dev1Acct.AccountID(),
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	snapRev, err := s.StoreSigning.Sign(asserts.SnapRevisionType, map[string]interface{}{
+		"snap-sha3-384": "YK0GWATaZf09g_fvspYPqm_qtaiqf-KjaNj5uMEQCjQpuXWPjqQbeBINL5H_A0Lo",
+		"snap-size":     "5",
+		"snap-id":       "oci-snap-id",
+		"snap-revision": "41",
+		"developer-id":  dev1Acct.AccountID(),
+		"timestamp":     time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	func() {
+		st.Lock()
+		defer st.Unlock()
+		assertstatetest.AddMany(st, s.StoreSigning.StoreAccountKey(""))
+	}()
+
+	const ociRef = "registry.example.com/snaps/oci-snap@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	defer daemon.MockStoreDownloadOCI(func(_ context.Context, _ *http.Client, ref *store.OCIReference, targetPath string) ([]byte, error) {
+		c.Check(ref.String(), check.Equals, ociRef)
+		c.Assert(ioutil.WriteFile(targetPath, []byte("xyzzy"), 0644), check.IsNil)
+
+		var buf bytes.Buffer
+		enc := asserts.NewEncoder(&buf)
+		for _, a := range []asserts.Assertion{dev1Acct, snapDecl, snapRev} {
+			c.Assert(enc.Encode(a), check.IsNil)
+		}
+		return buf.Bytes(), nil
+	})()
+
+	defer daemon.MockSnapstateInstallPath(func(s *state.State, si *snap.SideInfo, path, name, channel string, flags snapstate.Flags) (*state.TaskSet, *snap.Info, error) {
+		c.Check(name, check.Equals, "oci-snap")
+		c.Check(flags, check.Equals, snapstate.Flags{RemoveSnapPath: true})
+		c.Check(si, check.DeepEquals, &snap.SideInfo{
+			RealName: "oci-snap",
+			SnapID:   "oci-snap-id",
+			Revision: snap.R(41),
+		})
+
+		return state.NewTaskSet(), &snap.Info{SuggestedName: "oci-snap"}, nil
+	})()
+
+	body := fmt.Sprintf(`{"action": "install", "oci-ref": %q}`, ociRef)
+	req, err := http.NewRequest("POST", "/v2/snaps/oci-snap", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+
+	rsp := s.asyncReq(c, req, nil)
+
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Summary(), check.Equals, `Install "oci-snap" snap from OCI registry "registry.example.com"`)
+	var names []string
+	err = chg.Get("snap-names", &names)
+	c.Assert(err, check.IsNil)
+	c.Check(names, check.DeepEquals, []string{"oci-snap"})
+}
+
+func (s *sideloadSuite) TestInstallFromOCINoSignatures(c *check.C) {
+	s.daemonWithOverlordMockAndStore(c)
+
+	defer daemon.MockStoreDownloadOCI(func(_ context.Context, _ *http.Client, ref *store.OCIReference, targetPath string) ([]byte, error) {
+		c.Assert(ioutil.WriteFile(targetPath, []byte("xyzzy"), 0644), check.IsNil)
+		return nil, nil
+	})()
+
+	body := `{"action": "install", "oci-ref": "registry.example.com/snaps/oci-snap@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"}`
+	req, err := http.NewRequest("POST", "/v2/snaps/oci-snap", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Matches, `cannot find signatures with metadata for snap from registry.example.com/snaps/oci-snap@sha256:.*`)
+}
+
+func (s *sideloadSuite) TestInstallFromOCIErrors(c *check.C) {
+	s.daemonWithOverlordMockAndStore(c)
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "refresh", "oci-ref": "registry.example.com/snaps/x@sha256:abc"}`, `oci-ref can only be specified for install`},
+		{`{"action": "install", "channel": "beta", "oci-ref": "registry.example.com/snaps/x@sha256:abc"}`, `cannot specify channel, revision or cohort-key with oci-ref`},
+		{`{"action": "install", "oci-ref": "registry.example.com/snaps/x:latest"}`, `invalid OCI reference .*: must reference the artifact by digest`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps/x", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Matches, t.err)
+	}
+}
+
 func (s *sideloadSuite) TestSideloadSnapNoSignaturesDangerOff(c *check.C) {
 	body := "" +
 		"----hello--\r\n" +
@@ -479,6 +591,83 @@ func (s *sideloadSuite) TestInstallPathUnaliased(c *check.C) {
 	c.Check(chgSummary, check.Equals, `Install "local" snap from file "x"`)
 }
 
+var sideLoadManyBody = "" +
+	"----hello--\r\n" +
+	"Content-Disposition: form-data; name=\"snap\"; filename=\"a.snap\"\r\n" +
+	"\r\n" +
+	"snap-a\r\n" +
+	"----hello--\r\n" +
+	"Content-Disposition: form-data; name=\"snap\"; filename=\"b.snap\"\r\n" +
+	"\r\n" +
+	"snap-b\r\n" +
+	"----hello--\r\n" +
+	"Content-Disposition: form-data; name=\"dangerous\"\r\n" +
+	"\r\n" +
+	"true\r\n" +
+	"----hello--\r\n"
+
+func (s *sideloadSuite) TestSideloadManySnaps(c *check.C) {
+	d := s.daemonWithFakeSnapManager(c)
+
+	defer daemon.MockUnsafeReadSnapInfo(func(path string) (*snap.Info, error) {
+		content, err := ioutil.ReadFile(path)
+		c.Assert(err, check.IsNil)
+		return &snap.Info{SuggestedName: string(content)}, nil
+	})()
+
+	defer daemon.MockSnapstateInstallPathMany(func(st *state.State, sideInfos []*snap.SideInfo, paths []string, flags snapstate.Flags) ([]*state.TaskSet, []*snap.Info, error) {
+		c.Check(flags, check.DeepEquals, snapstate.Flags{RemoveSnapPath: true})
+		c.Assert(paths, check.HasLen, 2)
+		c.Check(paths[0], testutil.FileEquals, "snap-a")
+		c.Check(paths[1], testutil.FileEquals, "snap-b")
+		c.Check(sideInfos, check.DeepEquals, []*snap.SideInfo{{RealName: "snap-a"}, {RealName: "snap-b"}})
+
+		var tss []*state.TaskSet
+		var infos []*snap.Info
+		for _, si := range sideInfos {
+			t := st.NewTask("fake-install-snap", "Doing a fake install")
+			tss = append(tss, state.NewTaskSet(t))
+			infos = append(infos, &snap.Info{SideInfo: *si})
+		}
+		return tss, infos, nil
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snaps", bytes.NewBufferString(sideLoadManyBody))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "multipart/thing; boundary=--hello--")
+
+	rsp := s.asyncReq(c, req, nil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "install-snap")
+	c.Check(chg.Summary(), check.Equals, `Install snaps "snap-a", "snap-b" from files`)
+	c.Check(chg.Tasks(), check.HasLen, 2)
+	var names []string
+	c.Assert(chg.Get("snap-names", &names), check.IsNil)
+	c.Check(names, check.DeepEquals, []string{"snap-a", "snap-b"})
+}
+
+func (s *sideloadSuite) TestSideloadManySnapsNoInstanceName(c *check.C) {
+	s.daemonWithFakeSnapManager(c)
+
+	body := sideLoadManyBody +
+		"Content-Disposition: form-data; name=\"name\"\r\n" +
+		"\r\n" +
+		"foo_instance\r\n" +
+		"----hello--\r\n"
+
+	req, err := http.NewRequest("POST", "/v2/snaps", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "multipart/thing; boundary=--hello--")
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Message, check.Equals, "cannot specify an instance name when installing several snap files")
+}
+
 type trySuite struct {
 	apiBaseSuite
 }
@@ -486,7 +675,7 @@ type trySuite struct {
 func (s *trySuite) SetUpTest(c *check.C) {
 	s.apiBaseSuite.SetUpTest(c)
 
-	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage"})
+	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage", Role: auth.RoleSnapInstall})
 }
 
 func (s *trySuite) TestTrySnap(c *check.C) {
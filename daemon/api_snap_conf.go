@@ -22,14 +22,17 @@ package daemon
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/jsonutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/configschema"
 	"github.com/snapcore/snapd/strutil"
 )
 
@@ -38,16 +41,30 @@ var (
 		Path:        "/v2/snaps/{name}/conf",
 		GET:         getSnapConf,
 		PUT:         setSnapConf,
+		POST:        postSnapConf,
 		ReadAccess:  authenticatedAccess{},
 		WriteAccess: authenticatedAccess{},
 	}
+
+	snapConfSchemaCmd = &Command{
+		Path:       "/v2/snaps/{name}/config-schema",
+		GET:        getSnapConfSchema,
+		ReadAccess: openAccess{},
+	}
 )
 
 func getSnapConf(c *Command, r *http.Request, user *auth.UserState) Response {
 	vars := muxVars(r)
 	snapName := configstate.RemapSnapFromRequest(vars["name"])
 
-	keys := strutil.CommaSeparatedList(r.URL.Query().Get("keys"))
+	query := r.URL.Query()
+	keys := strutil.CommaSeparatedList(query.Get("keys"))
+	if query.Get("export") == "true" {
+		if len(keys) > 0 {
+			return BadRequest("cannot use keys when exporting the configuration")
+		}
+		return exportSnapConf(c, snapName)
+	}
 
 	s := c.d.overlord.State()
 	s.Lock()
@@ -110,6 +127,9 @@ func setSnapConf(c *Command, r *http.Request, user *auth.UserState) Response {
 		if _, ok := err.(*snap.NotInstalledError); ok {
 			return SnapNotFound(snapName, err)
 		}
+		if _, ok := err.(*configschema.ValidationError); ok {
+			return BadRequest(err.Error())
+		}
 		return errToResponse(err, []string{snapName}, InternalError, "%v")
 	}
 
@@ -120,3 +140,110 @@ func setSnapConf(c *Command, r *http.Request, user *auth.UserState) Response {
 
 	return AsyncResponse(nil, change.ID())
 }
+
+// exportSnapConf returns the whole configuration of the snap along with
+// the revision and version it was captured from.
+func exportSnapConf(c *Command, snapName string) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	export := client.ConfExport{
+		Format:     client.ConfExportFormat,
+		Snap:       configstate.RemapSnapToResponse(snapName),
+		ExportedAt: time.Now(),
+		Config:     map[string]interface{}{},
+	}
+	info, err := snapstate.CurrentInfo(st, snapName)
+	switch err.(type) {
+	case nil:
+		export.Revision = info.Revision
+		export.Version = info.Version
+	case *snap.NotInstalledError:
+		// the system configuration can exist without the core snap
+		if snapName != "core" {
+			return SnapNotFound(snapName, err)
+		}
+	default:
+		return InternalError("%v", err)
+	}
+
+	tr := config.NewTransaction(st)
+	if err := tr.Get(snapName, "", &export.Config); err != nil && !config.IsNoOption(err) {
+		return InternalError("%v", err)
+	}
+	return SyncResponse(&export)
+}
+
+type snapConfAction struct {
+	Action string             `json:"action"`
+	Export *client.ConfExport `json:"export"`
+}
+
+func postSnapConf(c *Command, r *http.Request, user *auth.UserState) Response {
+	vars := muxVars(r)
+	snapName := configstate.RemapSnapFromRequest(vars["name"])
+
+	var action snapConfAction
+	if err := jsonutil.DecodeWithNumber(r.Body, &action); err != nil {
+		return BadRequest("cannot decode request body into configuration action: %v", err)
+	}
+	if action.Action != "import" {
+		return BadRequest("unknown configuration action %q", action.Action)
+	}
+	export := action.Export
+	if export == nil {
+		return BadRequest("cannot import configuration: no export provided")
+	}
+	if export.Format != client.ConfExportFormat {
+		return BadRequest("cannot import configuration: unsupported export format %d", export.Format)
+	}
+	if exportedSnap := configstate.RemapSnapFromRequest(export.Snap); exportedSnap != snapName {
+		return BadRequest("cannot import configuration of snap %q into snap %q", export.Snap, vars["name"])
+	}
+	if snapName == "core" {
+		return BadRequest("cannot import the system configuration")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	taskset, err := configstate.ReplaceInstalled(st, snapName, export.Config, 0)
+	if err != nil {
+		if _, ok := err.(*snap.NotInstalledError); ok {
+			return SnapNotFound(snapName, err)
+		}
+		if _, ok := err.(*configschema.ValidationError); ok {
+			return BadRequest(err.Error())
+		}
+		return errToResponse(err, []string{snapName}, InternalError, "%v")
+	}
+
+	summary := fmt.Sprintf("Import configuration of %q snap", snapName)
+	change := newChange(st, "configure-snap", summary, []*state.TaskSet{taskset}, []string{snapName})
+
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, change.ID())
+}
+
+// getSnapConfSchema returns the configuration schema of the snap, or null
+// if it does not ship one.
+func getSnapConfSchema(c *Command, r *http.Request, user *auth.UserState) Response {
+	vars := muxVars(r)
+	snapName := configstate.RemapSnapFromRequest(vars["name"])
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	schema, err := configstate.ConfigSchema(st, snapName)
+	if err != nil {
+		if _, ok := err.(*snap.NotInstalledError); ok {
+			return SnapNotFound(snapName, err)
+		}
+		return InternalError("%v", err)
+	}
+	return SyncResponse(schema)
+}
@@ -31,8 +31,13 @@ import (
 
 	"gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/configschema"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -336,3 +341,205 @@ func (s *snapConfSuite) TestSetConfChangeConflict(c *check.C) {
 		},
 		"type": "error"})
 }
+
+const configSchema = `{
+	"type": "object",
+	"properties": {
+		"port": {"type": "integer", "minimum": 1, "maximum": 65535, "description": "listening port"}
+	}
+}`
+
+func (s *snapConfSuite) mockSnapWithConfigSchema(c *check.C) {
+	info := s.mockSnap(c, configYaml)
+	schemaPath := filepath.Join(info.MountDir(), "meta", "config-schema.json")
+	c.Assert(os.MkdirAll(filepath.Dir(schemaPath), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(schemaPath, []byte(configSchema), 0644), check.IsNil)
+}
+
+func (s *snapConfSuite) TestSetConfInvalidForSchema(c *check.C) {
+	s.daemon(c)
+	s.mockSnapWithConfigSchema(c)
+
+	req, err := http.NewRequest("PUT", "/v2/snaps/config-snap/conf", bytes.NewBufferString(`{"port": 70000}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid configuration option "port": must be at most 65535`)
+}
+
+func (s *snapConfSuite) TestSetConfValidForSchema(c *check.C) {
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {})
+	defer restore()
+
+	d := s.daemon(c)
+	s.mockSnapWithConfigSchema(c)
+
+	req, err := http.NewRequest("PUT", "/v2/snaps/config-snap/conf", bytes.NewBufferString(`{"port": 8080}`))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Change(rsp.Change), check.NotNil)
+}
+
+func (s *snapConfSuite) TestGetConfSchema(c *check.C) {
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.daemon(c)
+	s.mockSnapWithConfigSchema(c)
+
+	req, err := http.NewRequest("GET", "/v2/snaps/config-snap/config-schema", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	schema, ok := rsp.Result.(*configschema.Schema)
+	c.Assert(ok, check.Equals, true)
+	c.Check(schema.Type, check.Equals, "object")
+	c.Check(schema.Properties["port"].Description, check.Equals, "listening port")
+}
+
+func (s *snapConfSuite) TestGetConfSchemaNone(c *check.C) {
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.daemon(c)
+	s.mockSnap(c, configYaml)
+
+	req, err := http.NewRequest("GET", "/v2/snaps/config-snap/config-schema", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.IsNil)
+}
+
+func (s *snapConfSuite) TestGetConfSchemaNotInstalled(c *check.C) {
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/config-schema", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 404)
+}
+
+func (s *snapConfSuite) TestExportConf(c *check.C) {
+	d := s.daemon(c)
+	s.mockSnap(c, configYaml)
+
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("config-snap", "key", "value")
+	tr.Set("config-snap", "port", 8080)
+	tr.Commit()
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps/config-snap/conf?export=true", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	export, ok := rsp.Result.(*client.ConfExport)
+	c.Assert(ok, check.Equals, true)
+	c.Check(export.Format, check.Equals, 1)
+	c.Check(export.Snap, check.Equals, "config-snap")
+	c.Check(export.Revision, check.Equals, snap.R(1))
+	c.Check(export.Version, check.Equals, "1")
+	c.Check(export.ExportedAt.IsZero(), check.Equals, false)
+	c.Check(export.Config, check.DeepEquals, map[string]interface{}{
+		"key":  "value",
+		"port": json.Number("8080"),
+	})
+}
+
+func (s *snapConfSuite) TestExportConfSystem(c *check.C) {
+	d := s.daemon(c)
+
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "refresh.timer", "4:00-6:00")
+	tr.Commit()
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps/system/conf?export=true", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	export := rsp.Result.(*client.ConfExport)
+	c.Check(export.Snap, check.Equals, "system")
+	c.Check(export.Revision.Unset(), check.Equals, true)
+	// the system configuration also holds defaults set by the daemon on
+	// startup, so only check what was set here
+	c.Check(export.Config["refresh"], check.DeepEquals, map[string]interface{}{
+		"timer": "4:00-6:00",
+	})
+}
+
+func (s *snapConfSuite) TestExportConfErrors(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/snaps/config-snap/conf?export=true&keys=foo", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot use keys when exporting the configuration")
+
+	req, err = http.NewRequest("GET", "/v2/snaps/config-snap/conf?export=true", nil)
+	c.Assert(err, check.IsNil)
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 404)
+}
+
+func (s *snapConfSuite) TestImportConf(c *check.C) {
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {})
+	defer restore()
+
+	d := s.daemon(c)
+	s.mockSnap(c, configYaml)
+
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("config-snap", "old", "value")
+	tr.Commit()
+	st.Unlock()
+
+	body := `{"action": "import", "export": {"format": 1, "snap": "config-snap", "revision": "3", "config": {"key": "value", "port": 8080}}}`
+	req, err := http.NewRequest("POST", "/v2/snaps/config-snap/conf", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil)
+
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Summary(), check.Equals, `Import configuration of "config-snap" snap`)
+	var hookContext struct {
+		Patch map[string]interface{} `json:"patch"`
+	}
+	c.Assert(chg.Tasks()[0].Get("hook-context", &hookContext), check.IsNil)
+	c.Check(hookContext.Patch, check.DeepEquals, map[string]interface{}{
+		"old":  nil,
+		"key":  "value",
+		"port": 8080.0,
+	})
+}
+
+func (s *snapConfSuite) TestImportConfErrors(c *check.C) {
+	s.daemon(c)
+	s.mockSnapWithConfigSchema(c)
+
+	for _, t := range []struct {
+		snap string
+		body string
+		err  string
+	}{
+		{"config-snap", `{"action": "foo"}`, `unknown configuration action "foo"`},
+		{"config-snap", `{"action": "import"}`, `cannot import configuration: no export provided`},
+		{"config-snap", `{"action": "import", "export": {"format": 2, "snap": "config-snap"}}`, `cannot import configuration: unsupported export format 2`},
+		{"config-snap", `{"action": "import", "export": {"format": 1, "snap": "other-snap"}}`, `cannot import configuration of snap "other-snap" into snap "config-snap"`},
+		{"system", `{"action": "import", "export": {"format": 1, "snap": "system"}}`, `cannot import the system configuration`},
+		{"config-snap", `{"action": "import", "export": {"format": 1, "snap": "config-snap", "config": {"port": 0}}}`, `invalid configuration option "port": must be at least 1`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps/"+t.snap+"/conf", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(t.body))
+		c.Check(rspe.Message, check.Equals, t.err, check.Commentf(t.body))
+	}
+}
@@ -49,7 +49,7 @@ var (
 		GET:         getSnapInfo,
 		POST:        postSnap,
 		ReadAccess:  openAccess{},
-		WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
+		WriteAccess: authenticatedAccess{Polkit: polkitActionManage, Role: auth.RoleSnapInstall},
 	}
 
 	snapsCmd = &Command{
@@ -57,7 +57,7 @@ var (
 		GET:         getSnapsInfo,
 		POST:        postSnaps,
 		ReadAccess:  openAccess{},
-		WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
+		WriteAccess: authenticatedAccess{Polkit: polkitActionManage, Role: auth.RoleSnapInstall},
 	}
 )
 
@@ -121,6 +121,18 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 	}
 	inst.ctx = r.Context()
 
+	vars := muxVars(r)
+	inst.Snaps = []string{vars["name"]}
+
+	if inst.Transaction != "" {
+		return BadRequest("transaction can only be specified for multi-snap operations")
+	}
+
+	if inst.OCIRef != "" {
+		// fetching from the registry happens without holding the state lock
+		return installFromOCI(c, &inst)
+	}
+
 	state := c.d.overlord.State()
 	state.Lock()
 	defer state.Unlock()
@@ -129,9 +141,6 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 		inst.userID = user.ID
 	}
 
-	vars := muxVars(r)
-	inst.Snaps = []string{vars["name"]}
-
 	if err := inst.validate(); err != nil {
 		return BadRequest("%s", err)
 	}
@@ -147,6 +156,9 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 	}
 
 	chg := newChange(state, inst.Action+"-snap", msg, tsets, inst.Snaps)
+	if inst.apiData != nil {
+		chg.Set("api-data", inst.apiData)
+	}
 
 	ensureStateSoon(state)
 
@@ -193,12 +205,19 @@ type snapInstruction struct {
 	IgnoreRunning    bool     `json:"ignore-running"`
 	Unaliased        bool     `json:"unaliased"`
 	Purge            bool     `json:"purge,omitempty"`
+	ApplyPending     bool     `json:"apply-pending,omitempty"`
+	OCIRef           string   `json:"oci-ref,omitempty"`
 	Snaps            []string `json:"snaps"`
 	Users            []string `json:"users"`
 
+	Transaction    client.TransactionType `json:"transaction,omitempty"`
+	CascadeContent bool                   `json:"cascade-content,omitempty"`
+
 	// The fields below should not be unmarshalled into. Do not export them.
 	userID int
 	ctx    context.Context
+	// apiData is set by the actions to record data on their change
+	apiData map[string]interface{}
 }
 
 func (inst *snapInstruction) revnoOpts() *snapstate.RevisionOptions {
@@ -243,6 +262,20 @@ func (inst *snapInstruction) validate() error {
 			return fmt.Errorf("leave-cohort can only be specified for refresh or switch")
 		}
 	}
+	if inst.ApplyPending && inst.Action != "refresh" {
+		return fmt.Errorf("apply-pending can only be specified for refresh")
+	}
+	switch inst.Transaction {
+	case "", client.TransactionPerSnap, client.TransactionAllSnaps:
+	default:
+		return fmt.Errorf("invalid value for transaction type: %s", inst.Transaction)
+	}
+	if inst.Transaction != "" && inst.Action != "remove" {
+		return fmt.Errorf("transaction can only be specified for remove")
+	}
+	if inst.CascadeContent && inst.Transaction != client.TransactionAllSnaps {
+		return fmt.Errorf("cascade-content can only be specified for all-snaps transactions")
+	}
 	if inst.Action == "install" {
 		for _, snapName := range inst.Snaps {
 			// FIXME: alternatively we could simply mutate *inst
@@ -320,6 +353,14 @@ func snapInstall(inst *snapInstruction, st *state.State) (string, []*state.TaskS
 }
 
 func snapUpdate(inst *snapInstruction, st *state.State) (string, []*state.TaskSet, error) {
+	if inst.ApplyPending {
+		_, tasksets, err := snapstateApplyPendingRefreshes(inst.ctx, st, inst.Snaps, inst.userID)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf(i18n.G("Apply pending refresh of %q snap"), inst.Snaps[0]), tasksets, nil
+	}
+
 	// TODO: bail if revision is given (and != current?), *or* behave as with install --revision?
 	flags, err := inst.modeFlags()
 	if err != nil {
@@ -374,7 +415,21 @@ func snapRevert(inst *snapInstruction, st *state.State) (string, []*state.TaskSe
 	if inst.Revision.Unset() {
 		ts, err = snapstateRevert(st, inst.Snaps[0], flags)
 	} else {
-		ts, err = snapstateRevertToRevision(st, inst.Snaps[0], inst.Revision, flags)
+		var snapst snapstate.SnapState
+		if err := snapstate.Get(st, inst.Snaps[0], &snapst); err != nil && err != state.ErrNoState {
+			return "", nil, err
+		}
+		if snapst.IsInstalled() && snapst.LastIndex(inst.Revision) < 0 {
+			// not around anymore, get it back from the store
+			var setID uint64
+			setID, ts, err = snapstateDowngrade(st, inst.Snaps[0], inst.Revision, inst.userID, flags)
+			if err == nil {
+				// let the client offer to restore the saved data
+				inst.apiData = map[string]interface{}{"snapshot-set-id": setID}
+			}
+		} else {
+			ts, err = snapstateRevertToRevision(st, inst.Snaps[0], inst.Revision, flags)
+		}
 	}
 	if err != nil {
 		return "", nil, err
@@ -498,7 +553,7 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 	}
 
 	// TODO: inst.Amend, etc?
-	if inst.Channel != "" || !inst.Revision.Unset() || inst.DevMode || inst.JailMode || inst.CohortKey != "" || inst.LeaveCohort || inst.Purge {
+	if inst.Channel != "" || !inst.Revision.Unset() || inst.DevMode || inst.JailMode || inst.CohortKey != "" || inst.LeaveCohort || inst.Purge || inst.OCIRef != "" {
 		return BadRequest("unsupported option provided for multi-snap operation")
 	}
 	if err := inst.validate(); err != nil {
@@ -589,6 +644,10 @@ func snapUpdateMany(inst *snapInstruction, st *state.State) (*snapInstructionRes
 		return nil, err
 	}
 
+	if inst.ApplyPending {
+		return snapApplyPendingRefreshes(inst, st)
+	}
+
 	// TODO: use a per-request context
 	updated, tasksets, err := snapstateUpdateMany(context.TODO(), st, inst.Snaps, inst.userID, nil)
 	if err != nil {
@@ -619,8 +678,38 @@ func snapUpdateMany(inst *snapInstruction, st *state.State) (*snapInstructionRes
 	}, nil
 }
 
+func snapApplyPendingRefreshes(inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
+	// TODO: use a per-request context
+	updated, tasksets, err := snapstateApplyPendingRefreshes(context.TODO(), st, inst.Snaps, inst.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg string
+	switch len(updated) {
+	case 0:
+		msg = i18n.G("Apply pending refreshes: no pending refreshes")
+	case 1:
+		msg = fmt.Sprintf(i18n.G("Apply pending refresh of snap %q"), updated[0])
+	default:
+		quoted := strutil.Quoted(updated)
+		// TRANSLATORS: the %s is a comma-separated list of quoted snap names
+		msg = fmt.Sprintf(i18n.G("Apply pending refreshes of snaps %s"), quoted)
+	}
+
+	return &snapInstructionResult{
+		Summary:  msg,
+		Affected: updated,
+		Tasksets: tasksets,
+	}, nil
+}
+
 func snapRemoveMany(inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
-	removed, tasksets, err := snapstateRemoveMany(st, inst.Snaps)
+	flags := &snapstate.RemoveFlags{
+		Transaction:    inst.Transaction,
+		CascadeContent: inst.CascadeContent,
+	}
+	removed, tasksets, err := snapstateRemoveMany(st, inst.Snaps, flags)
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/healthstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -61,7 +62,7 @@ var _ = check.Suite(&snapsSuite{})
 func (s *snapsSuite) SetUpTest(c *check.C) {
 	s.apiBaseSuite.SetUpTest(c)
 
-	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage"})
+	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage", Role: auth.RoleSnapInstall})
 }
 
 func (s *snapsSuite) TestSnapsInfoIntegration(c *check.C) {
@@ -204,6 +205,36 @@ func (s *snapsSuite) TestSnapsInfoOnlyLocal(c *check.C) {
 	})
 }
 
+func (s *snapsSuite) TestSnapsInfoRefreshInhibit(c *check.C) {
+	d := s.daemon(c)
+
+	s.mkInstalledInState(c, d, "local", "foo", "v1", snap.R(10), true, "")
+	st := d.Overlord().State()
+	st.Lock()
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(st, "local", &snapst), check.IsNil)
+	inhibited := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	snapst.RefreshInhibitedTime = &inhibited
+	snapstate.Set(st, "local", &snapst)
+	tr := config.NewTransaction(st)
+	tr.Set("local", "refresh.inhibit-timeout", "48h")
+	tr.Set("local", "refresh.inhibit-action", "notify")
+	tr.Commit()
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps?sources=local", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+
+	snaps := snapList(rsp.Result)
+	c.Assert(snaps, check.HasLen, 1)
+	c.Check(snaps[0]["refresh-inhibit"], check.DeepEquals, map[string]interface{}{
+		"proceed-time": "2021-06-03T10:00:00Z",
+		"action":       "notify",
+	})
+}
+
 func (s *snapsSuite) TestSnapsInfoAllMixedPublishers(c *check.C) {
 	d := s.daemon(c)
 
@@ -626,6 +657,31 @@ func (s *snapsSuite) TestRefreshMany1(c *check.C) {
 	c.Check(refreshSnapDecls, check.Equals, true)
 }
 
+func (s *snapsSuite) TestRefreshManyApplyPending(c *check.C) {
+	defer daemon.MockAssertstateRefreshSnapDeclarations(func(s *state.State, userID int) error {
+		return nil
+	})()
+	defer daemon.MockSnapstateUpdateMany(func(_ context.Context, s *state.State, names []string, userID int, flags *snapstate.Flags) ([]string, []*state.TaskSet, error) {
+		c.Fatalf("unexpected call to UpdateMany")
+		return nil, nil, nil
+	})()
+	defer daemon.MockSnapstateApplyPendingRefreshes(func(_ context.Context, s *state.State, names []string, userID int) ([]string, []*state.TaskSet, error) {
+		c.Check(names, check.HasLen, 0)
+		t := s.NewTask("fake-refresh-2", "Refreshing two")
+		return []string{"bar", "foo"}, []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})()
+
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{Action: "refresh", ApplyPending: true}
+	st := d.Overlord().State()
+	st.Lock()
+	res, err := inst.DispatchForMany()(inst, st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(res.Summary, check.Equals, `Apply pending refreshes of snaps "bar", "foo"`)
+	c.Check(res.Affected, check.DeepEquals, []string{"bar", "foo"})
+}
+
 func (s *snapsSuite) TestInstallMany(c *check.C) {
 	defer daemon.MockSnapstateInstallMany(func(s *state.State, names []string, userID int) ([]string, []*state.TaskSet, error) {
 		c.Check(names, check.HasLen, 2)
@@ -659,8 +715,9 @@ func (s *snapsSuite) TestInstallManyEmptyName(c *check.C) {
 }
 
 func (s *snapsSuite) TestRemoveMany(c *check.C) {
-	defer daemon.MockSnapstateRemoveMany(func(s *state.State, names []string) ([]string, []*state.TaskSet, error) {
+	defer daemon.MockSnapstateRemoveMany(func(s *state.State, names []string, flags *snapstate.RemoveFlags) ([]string, []*state.TaskSet, error) {
 		c.Check(names, check.HasLen, 2)
+		c.Check(flags, check.DeepEquals, &snapstate.RemoveFlags{})
 		t := s.NewTask("fake-remove-2", "Remove two")
 		return names, []*state.TaskSet{state.NewTaskSet(t)}, nil
 	})()
@@ -676,6 +733,68 @@ func (s *snapsSuite) TestRemoveMany(c *check.C) {
 	c.Check(res.Affected, check.DeepEquals, inst.Snaps)
 }
 
+func (s *snapsSuite) TestRemoveManyTransactional(c *check.C) {
+	defer daemon.MockSnapstateRemoveMany(func(s *state.State, names []string, flags *snapstate.RemoveFlags) ([]string, []*state.TaskSet, error) {
+		c.Check(names, check.DeepEquals, []string{"foo"})
+		c.Check(flags, check.DeepEquals, &snapstate.RemoveFlags{
+			Transaction:    client.TransactionAllSnaps,
+			CascadeContent: true,
+		})
+		t := s.NewTask("fake-remove-2", "Remove two")
+		// the consumer of content from foo is removed as well
+		return []string{"foo", "bar"}, []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})()
+
+	d := s.daemon(c)
+	inst := &daemon.SnapInstruction{
+		Action:         "remove",
+		Snaps:          []string{"foo"},
+		Transaction:    client.TransactionAllSnaps,
+		CascadeContent: true,
+	}
+	st := d.Overlord().State()
+	st.Lock()
+	res, err := inst.DispatchForMany()(inst, st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(res.Summary, check.Equals, `Remove snap "foo"`)
+	c.Check(res.Affected, check.DeepEquals, []string{"foo", "bar"})
+}
+
+func (s *snapsSuite) TestPostSnapsTransactionErrors(c *check.C) {
+	s.daemonWithOverlordMockAndStore(c)
+
+	for _, t := range []struct {
+		body   string
+		errmsg string
+	}{
+		{`{"action": "remove", "snaps": ["foo"], "transaction": "some-snaps"}`, `invalid value for transaction type: some-snaps`},
+		{`{"action": "refresh", "snaps": ["foo"], "transaction": "all-snaps"}`, `transaction can only be specified for remove`},
+		{`{"action": "remove", "snaps": ["foo"], "cascade-content": true}`, `cascade-content can only be specified for all-snaps transactions`},
+		{`{"action": "remove", "snaps": ["foo"], "transaction": "per-snap", "cascade-content": true}`, `cascade-content can only be specified for all-snaps transactions`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps", strings.NewReader(t.body))
+		c.Assert(err, check.IsNil)
+		req.Header.Set("Content-Type", "application/json")
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(t.body))
+		c.Check(rspe.Message, check.Equals, t.errmsg, check.Commentf(t.body))
+	}
+}
+
+func (s *snapsSuite) TestPostSnapTransactionUnsupported(c *check.C) {
+	s.daemonWithOverlordMock(c)
+
+	buf := strings.NewReader(`{"action": "remove", "transaction": "all-snaps"}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/some-snap", buf)
+	c.Assert(err, check.IsNil)
+
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "transaction can only be specified for multi-snap operations")
+}
+
 func (s *snapsSuite) TestSnapInfoOneIntegration(c *check.C) {
 	d := s.daemon(c)
 
@@ -1212,6 +1331,21 @@ func (s *snapsSuite) TestPostSnapLeaveCohortUnsupportedAction(c *check.C) {
 	}
 }
 
+func (s *snapsSuite) TestPostSnapApplyPendingUnsupportedAction(c *check.C) {
+	s.daemonWithOverlordMock(c)
+	const expectedErr = "apply-pending can only be specified for refresh"
+
+	for _, action := range []string{"install", "remove", "revert", "enable", "disable", "xyzzy"} {
+		buf := strings.NewReader(fmt.Sprintf(`{"action": "%s", "apply-pending": true}`, action))
+		req, err := http.NewRequest("POST", "/v2/snaps/some-snap", buf)
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%q", action))
+		c.Check(rspe.Message, check.Equals, expectedErr, check.Commentf("%q", action))
+	}
+}
+
 func (s *snapsSuite) TestPostSnapCohortIncompat(c *check.C) {
 	s.daemonWithOverlordMock(c)
 	type T struct {
@@ -1935,6 +2069,49 @@ func (s *snapsSuite) TestRevertSnapClassic(c *check.C) {
 	s.testRevertSnap(&daemon.SnapInstruction{Classic: true}, c)
 }
 
+func (s *snapsSuite) TestRevertSnapDowngrade(c *check.C) {
+	var queue []string
+	defer daemon.MockSnapstateRevertToRevision(func(s *state.State, name string, rev snap.Revision, flags snapstate.Flags) (*state.TaskSet, error) {
+		queue = append(queue, fmt.Sprintf("revert %s (%s)", name, rev))
+		return nil, nil
+	})()
+	defer daemon.MockSnapstateDowngrade(func(s *state.State, name string, rev snap.Revision, userID int, flags snapstate.Flags) (uint64, *state.TaskSet, error) {
+		c.Check(userID, check.Equals, 17)
+		queue = append(queue, fmt.Sprintf("downgrade %s (%s)", name, rev))
+		return 42, nil, nil
+	})()
+
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	snapstate.Set(st, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "some-snap", Revision: snap.R(5)},
+			{RealName: "some-snap", Revision: snap.R(7)},
+		},
+		Current: snap.R(7),
+	})
+
+	for _, t := range []struct {
+		rev     int
+		apiData map[string]interface{}
+	}{
+		{5, nil},
+		{3, map[string]interface{}{"snapshot-set-id": uint64(42)}},
+	} {
+		inst := daemon.MustUnmarshalSnapInstruction(c, fmt.Sprintf(`{"action": "revert", "revision": "%d"}`, t.rev))
+		inst.Snaps = []string{"some-snap"}
+		inst.SetUserID(17)
+		summary, _, err := inst.Dispatch()(inst, st)
+		c.Check(err, check.IsNil)
+		c.Check(summary, check.Equals, `Revert "some-snap" snap`)
+		c.Check(inst.APIData(), check.DeepEquals, t.apiData)
+	}
+	c.Check(queue, check.DeepEquals, []string{"revert some-snap (5)", "downgrade some-snap (3)"})
+}
+
 func (s *snapsSuite) TestRevertSnapToRevision(c *check.C) {
 	inst := &daemon.SnapInstruction{}
 	inst.Revision = snap.R(1)
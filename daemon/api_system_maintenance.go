@@ -0,0 +1,99 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/snapstate"
+)
+
+var systemMaintenanceCmd = &Command{
+	Path:        "/v2/system-maintenance",
+	GET:         getSystemMaintenance,
+	POST:        postSystemMaintenance,
+	ReadAccess:  openAccess{},
+	WriteAccess: rootAccess{},
+}
+
+type postSystemMaintenanceData struct {
+	Action string     `json:"action"`
+	Until  *time.Time `json:"until"`
+}
+
+func maintenanceResponse(hold *snapstate.MaintenanceHold) Response {
+	res := &client.SystemMaintenance{}
+	if hold != nil {
+		res.Held = true
+		res.Since = hold.Since
+		res.Until = hold.Until
+	}
+	return SyncResponse(res)
+}
+
+func getSystemMaintenance(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	hold, err := snapstate.Maintenance(st)
+	if err != nil {
+		return InternalError("cannot get maintenance hold: %v", err)
+	}
+	return maintenanceResponse(hold)
+}
+
+func postSystemMaintenance(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postSystemMaintenanceData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		return BadRequest("cannot decode maintenance action from request body: %v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	switch data.Action {
+	case "hold":
+		var until time.Time
+		if data.Until != nil {
+			until = *data.Until
+		}
+		hold, err := snapstate.HoldMaintenance(st, until)
+		if err != nil {
+			return BadRequest(err.Error())
+		}
+		return maintenanceResponse(hold)
+	case "release":
+		if data.Until != nil {
+			return BadRequest(`"until" can only be used with the "hold" action`)
+		}
+		snapstate.ReleaseMaintenance(st)
+		// let the held activity catch up
+		ensureStateSoon(st)
+		return maintenanceResponse(nil)
+	default:
+		return BadRequest("unknown maintenance action %q", data.Action)
+	}
+}
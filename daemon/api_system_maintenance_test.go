@@ -0,0 +1,121 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var _ = check.Suite(&systemMaintenanceSuite{})
+
+type systemMaintenanceSuite struct {
+	apiBaseSuite
+
+	ensureStateSoonCalled int
+}
+
+func (s *systemMaintenanceSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.ensureStateSoonCalled = 0
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {
+		s.ensureStateSoonCalled++
+	})
+	s.AddCleanup(restore)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.expectWriteAccess(daemon.RootAccess{})
+}
+
+func (s *systemMaintenanceSuite) TestGetNotHeld(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/system-maintenance", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, &client.SystemMaintenance{})
+}
+
+func (s *systemMaintenanceSuite) TestHoldAndRelease(c *check.C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	body := `{"action": "hold", "until": "` + until.Format(time.RFC3339) + `"}`
+	req, err := http.NewRequest("POST", "/v2/system-maintenance", bytes.NewBufferString(body))
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	res := rsp.Result.(*client.SystemMaintenance)
+	c.Check(res.Held, check.Equals, true)
+	c.Check(res.Until.Equal(until), check.Equals, true)
+
+	st.Lock()
+	hold, err := snapstate.Maintenance(st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Assert(hold, check.NotNil)
+	c.Check(hold.Until.Equal(until), check.Equals, true)
+
+	req, err = http.NewRequest("GET", "/v2/system-maintenance", nil)
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Check(rsp.Result.(*client.SystemMaintenance).Held, check.Equals, true)
+
+	req, err = http.NewRequest("POST", "/v2/system-maintenance", bytes.NewBufferString(`{"action": "release"}`))
+	c.Assert(err, check.IsNil)
+	rsp = s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, &client.SystemMaintenance{})
+	c.Check(s.ensureStateSoonCalled, check.Equals, 1)
+
+	st.Lock()
+	hold, err = snapstate.Maintenance(st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(hold, check.IsNil)
+}
+
+func (s *systemMaintenanceSuite) TestPostErrors(c *check.C) {
+	s.daemon(c)
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "foo"}`, `unknown maintenance action "foo"`},
+		{`{"action": "hold", "until": "2001-01-01T00:00:00Z"}`, `cannot hold maintenance until 2001-01-01T00:00:00Z: time is in the past`},
+		{`{"action": "release", "until": "2001-01-01T00:00:00Z"}`, `"until" can only be used with the "hold" action`},
+		{`{`, `cannot decode maintenance action from request body: .*`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/system-maintenance", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(t.body))
+		c.Check(rspe.Message, check.Matches, t.err, check.Commentf(t.body))
+	}
+}
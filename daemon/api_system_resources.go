@@ -0,0 +1,280 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"context"
+	"crypto"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/systemd"
+)
+
+var systemResourcesCmd = &Command{
+	Path:       "/v2/system-resources",
+	GET:        getSystemResources,
+	ReadAccess: openAccess{},
+}
+
+var getSnapMemUsage = func(info *snap.Info) quantity.Size {
+	sysd := systemd.New(systemd.SystemMode, progress.Null)
+	var total quantity.Size
+	for _, app := range info.Services() {
+		// the memory usage of inactive services is not set
+		if usage, err := sysd.CurrentMemoryUsage(app.ServiceName()); err == nil {
+			total += usage
+		}
+	}
+	return total
+}
+
+var getSnapCPUUsage = func(info *snap.Info) time.Duration {
+	sysd := systemd.New(systemd.SystemMode, progress.Null)
+	var total time.Duration
+	for _, app := range info.Services() {
+		// the CPU usage of inactive services is not set
+		if usage, err := sysd.CurrentCPUUsage(app.ServiceName()); err == nil {
+			total += usage
+		}
+	}
+	return total
+}
+
+// snapCacheUsage returns the size of the snap files kept only in the
+// download cache, by snap id. The files are named after their hex encoded
+// sha3-384, which is looked up in the snap-revision assertions.
+func snapCacheUsage(st *state.State) (map[string]quantity.Size, error) {
+	fis, err := ioutil.ReadDir(dirs.SnapDownloadCacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	db := assertstate.DB(st)
+	usage := make(map[string]quantity.Size)
+	for _, fi := range fis {
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		// files also linked from the snaps directory are counted as blobs
+		if stat, ok := fi.Sys().(*syscall.Stat_t); ok && stat.Nlink > 1 {
+			continue
+		}
+		digest, err := cachedSnapDigest(fi.Name())
+		if err != nil {
+			continue
+		}
+		a, err := db.Find(asserts.SnapRevisionType, map[string]string{
+			"snap-sha3-384": digest,
+		})
+		if asserts.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		usage[a.(*asserts.SnapRevision).SnapID()] += quantity.Size(fi.Size())
+	}
+	return usage, nil
+}
+
+// cachedSnapDigest returns the digest, as used by the snap-revision
+// assertions, of the snap file cached under the given name.
+func cachedSnapDigest(name string) (string, error) {
+	h, err := hex.DecodeString(name)
+	if err != nil {
+		return "", err
+	}
+	if len(h) != crypto.SHA3_384.Size() {
+		return "", fmt.Errorf("unexpected hash length %d", len(h))
+	}
+	return asserts.EncodeDigest(crypto.SHA3_384, h)
+}
+
+// diskUsage returns the size of the regular files under the given path,
+// which does not need to exist.
+func diskUsage(path string) (quantity.Size, error) {
+	var total quantity.Size
+	err := filepath.Walk(path, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			total += quantity.Size(fi.Size())
+		}
+		return nil
+	})
+	return total, err
+}
+
+type snapPaths struct {
+	blobs  []string
+	data   []string
+	common string
+	info   *snap.Info
+}
+
+// getSystemResources returns the disk, memory and CPU used by the installed
+// snaps, sorted by name, and aggregated by quota group.
+func getSystemResources(c *Command, r *http.Request, _ *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		st.Unlock()
+		return InternalError("cannot list snaps: %v", err)
+	}
+	quotas, err := servicestate.AllQuotas(st)
+	if err != nil {
+		st.Unlock()
+		return InternalError("cannot list quota groups: %v", err)
+	}
+	cacheBySnapID, err := snapCacheUsage(st)
+	if err != nil {
+		st.Unlock()
+		return InternalError("cannot get disk usage of the download cache: %v", err)
+	}
+	sets, err := snapshotList(context.TODO(), st, 0, nil)
+	st.Unlock()
+	if err != nil {
+		return InternalError("cannot list snapshots: %v", err)
+	}
+
+	names := make([]string, 0, len(snapStates))
+	paths := make(map[string]*snapPaths, len(snapStates))
+	for name, snapst := range snapStates {
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			return InternalError("cannot get information about snap %q: %v", name, err)
+		}
+		p := &snapPaths{common: snap.CommonDataDir(name), info: info}
+		for _, si := range snapst.Sequence {
+			p.blobs = append(p.blobs, snap.MountFile(name, si.Revision))
+			p.data = append(p.data, snap.DataDir(name, si.Revision))
+		}
+		names = append(names, name)
+		paths[name] = p
+	}
+	sort.Strings(names)
+
+	snapshots := make(map[string]quantity.Size)
+	for _, set := range sets {
+		for _, sh := range set.Snapshots {
+			snapshots[sh.Snap] += quantity.Size(sh.Size)
+		}
+	}
+
+	groupOf := make(map[string]string)
+	for _, grp := range quotas {
+		for _, name := range grp.Snaps {
+			groupOf[name] = grp.Name
+		}
+	}
+
+	res := &client.SystemResources{
+		Snaps: make([]*client.SnapResourceUsage, 0, len(names)),
+	}
+	usageOf := make(map[string]*client.SnapResourceUsage, len(names))
+	for _, name := range names {
+		p := paths[name]
+		usage := &client.SnapResourceUsage{
+			Snap:       name,
+			QuotaGroup: groupOf[name],
+			Snapshots:  snapshots[name],
+			Memory:     getSnapMemUsage(p.info),
+			CPUTime:    getSnapCPUUsage(p.info),
+		}
+		// parallel instances share the cached files, count them once
+		if snapID := p.info.SnapID; snapID != "" && p.info.InstanceKey == "" {
+			usage.Cache = cacheBySnapID[snapID]
+		}
+		for _, blob := range p.blobs {
+			size, err := diskUsage(blob)
+			if err != nil {
+				return InternalError("cannot get disk usage of snap %q: %v", name, err)
+			}
+			usage.Blobs += size
+		}
+		for _, data := range p.data {
+			size, err := diskUsage(data)
+			if err != nil {
+				return InternalError("cannot get disk usage of snap %q: %v", name, err)
+			}
+			usage.Data += size
+		}
+		usage.Common, err = diskUsage(p.common)
+		if err != nil {
+			return InternalError("cannot get disk usage of snap %q: %v", name, err)
+		}
+		res.Snaps = append(res.Snaps, usage)
+		usageOf[name] = usage
+	}
+
+	groupNames := make([]string, 0, len(quotas))
+	for name := range quotas {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		grp := quotas[name]
+		memory, err := getQuotaMemUsage(grp)
+		if err != nil {
+			return InternalError(err.Error())
+		}
+		grpUsage := &client.QuotaGroupResourceUsage{
+			GroupName: grp.Name,
+			Snaps:     grp.Snaps,
+			Memory:    memory,
+		}
+		for _, snapName := range grp.Snaps {
+			if usage := usageOf[snapName]; usage != nil {
+				grpUsage.Disk += usage.Disk()
+				grpUsage.CPUTime += usage.CPUTime
+			}
+		}
+		res.QuotaGroups = append(res.QuotaGroups, grpUsage)
+	}
+
+	return SyncResponse(res)
+}
@@ -0,0 +1,201 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/overlord/assertstate/assertstatetest"
+	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
+)
+
+var _ = check.Suite(&systemResourcesSuite{})
+
+type systemResourcesSuite struct {
+	apiBaseSuite
+}
+
+func (s *systemResourcesSuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.OpenAccess{})
+	s.AddCleanup(daemon.MockSnapshotList(func(context.Context, *state.State, uint64, []string) ([]client.SnapshotSet, error) {
+		return nil, nil
+	}))
+	s.AddCleanup(daemon.MockGetSnapMemUsage(func(*snap.Info) quantity.Size { return 0 }))
+	s.AddCleanup(daemon.MockGetSnapCPUUsage(func(*snap.Info) time.Duration { return 0 }))
+}
+
+func writeSized(c *check.C, path string, size int) {
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(path, make([]byte, size), 0644), check.IsNil)
+}
+
+func (s *systemResourcesSuite) TestSystemResources(c *check.C) {
+	d := s.daemon(c)
+
+	s.mkInstalledInState(c, d, "foo", "", "v1", snap.R(1), false, "")
+	s.mkInstalledInState(c, d, "foo", "", "v2", snap.R(2), true, "")
+	s.mkInstalledInState(c, d, "bar", "", "v1", snap.R(1), true, "")
+
+	writeSized(c, snap.MountFile("foo", snap.R(1)), 100)
+	writeSized(c, snap.MountFile("foo", snap.R(2)), 200)
+	writeSized(c, filepath.Join(snap.DataDir("foo", snap.R(2)), "data"), 10)
+	writeSized(c, filepath.Join(snap.CommonDataDir("foo"), "common"), 5)
+
+	st := d.Overlord().State()
+	st.Lock()
+	err := servicestatetest.MockQuotaInState(st, "grp", "", []string{"foo"}, quantity.SizeMiB)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	defer daemon.MockSnapshotList(func(context.Context, *state.State, uint64, []string) ([]client.SnapshotSet, error) {
+		return []client.SnapshotSet{{ID: 1, Snapshots: []*client.Snapshot{
+			{SetID: 1, Snap: "foo", Size: 7},
+		}}}, nil
+	})()
+	defer daemon.MockGetSnapMemUsage(func(info *snap.Info) quantity.Size {
+		if info.InstanceName() == "foo" {
+			c.Check(info.Revision, check.Equals, snap.R(2))
+			return 300
+		}
+		return 0
+	})()
+	defer daemon.MockGetSnapCPUUsage(func(info *snap.Info) time.Duration {
+		if info.InstanceName() == "foo" {
+			return 2 * time.Second
+		}
+		return 0
+	})()
+	defer daemon.MockGetQuotaMemUsage(func(grp *quota.Group) (quantity.Size, error) {
+		c.Check(grp.Name, check.Equals, "grp")
+		return 400, nil
+	})()
+
+	req, err := http.NewRequest("GET", "/v2/system-resources", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, &client.SystemResources{
+		Snaps: []*client.SnapResourceUsage{{
+			Snap: "bar",
+			// the blob written by mkInstalledInState
+			Blobs: 12,
+		}, {
+			Snap:       "foo",
+			QuotaGroup: "grp",
+			Blobs:      300,
+			Data:       10,
+			Common:     5,
+			Snapshots:  7,
+			Memory:     300,
+			CPUTime:    2 * time.Second,
+		}},
+		QuotaGroups: []*client.QuotaGroupResourceUsage{{
+			GroupName: "grp",
+			Snaps:     []string{"foo"},
+			Disk:      322,
+			Memory:    400,
+			CPUTime:   2 * time.Second,
+		}},
+	})
+}
+
+func (s *systemResourcesSuite) TestSystemResourcesCache(c *check.C) {
+	d := s.daemon(c)
+
+	info := s.mkInstalledInState(c, d, "bar", "bar-dev", "v1", snap.R(1), true, "")
+
+	// a cached file still linked from the snaps directory is a blob
+	content, err := ioutil.ReadFile(info.MountFile())
+	c.Assert(err, check.IsNil)
+	c.Assert(os.MkdirAll(dirs.SnapDownloadCacheDir, 0755), check.IsNil)
+	_, cacheName := digestOf(c, content)
+	c.Assert(os.Link(info.MountFile(), filepath.Join(dirs.SnapDownloadCacheDir, cacheName)), check.IsNil)
+
+	// a cached file of a previous revision only lives in the cache
+	oldContent := make([]byte, 42)
+	oldDigest, oldCacheName := digestOf(c, oldContent)
+	writeSized(c, filepath.Join(dirs.SnapDownloadCacheDir, oldCacheName), len(oldContent))
+	// unknown files are ignored
+	writeSized(c, filepath.Join(dirs.SnapDownloadCacheDir, "unknown"), 1000)
+
+	snapRev, err := s.StoreSigning.Sign(asserts.SnapRevisionType, map[string]interface{}{
+		"snap-sha3-384": oldDigest,
+		"snap-size":     "42",
+		"snap-id":       "bar-id",
+		"snap-revision": "7",
+		"developer-id":  "bar-dev-id",
+		"timestamp":     time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, check.IsNil)
+	st := d.Overlord().State()
+	st.Lock()
+	assertstatetest.AddMany(st, snapRev)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/system-resources", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, &client.SystemResources{
+		Snaps: []*client.SnapResourceUsage{{
+			Snap:  "bar",
+			Blobs: quantity.Size(len(content)),
+			Cache: 42,
+		}},
+	})
+}
+
+// digestOf returns the digest of content as used by the snap-revision
+// assertions, and as the name of the file caching it.
+func digestOf(c *check.C, content []byte) (digest, cacheName string) {
+	h := sha3.Sum384(content)
+	dgst, err := asserts.EncodeDigest(crypto.SHA3_384, h[:])
+	c.Assert(err, check.IsNil)
+	return dgst, fmt.Sprintf("%x", h)
+}
+
+func (s *systemResourcesSuite) TestSystemResourcesNoSnaps(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/system-resources", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, &client.SystemResources{
+		Snaps: []*client.SnapResourceUsage{},
+	})
+}
@@ -49,6 +49,12 @@ var systemsActionCmd = &Command{
 	WriteAccess: rootAccess{},
 }
 
+var installProgressCmd = &Command{
+	Path:       "/v2/install-progress",
+	GET:        getInstallProgress,
+	ReadAccess: openAccess{},
+}
+
 type systemsResponse struct {
 	Systems []client.System `json:"systems,omitempty"`
 }
@@ -93,7 +99,9 @@ func getSystems(c *Command, r *http.Request, user *auth.UserState) Response {
 				DisplayName: ss.Brand.DisplayName(),
 				Validation:  ss.Brand.Validation(),
 			},
-			Actions: actions,
+			Actions:     actions,
+			Description: ss.Description,
+			Tags:        ss.Tags,
 		})
 	}
 	return SyncResponse(&rsp)
@@ -102,6 +110,11 @@ func getSystems(c *Command, r *http.Request, user *auth.UserState) Response {
 type systemActionRequest struct {
 	Action string `json:"action"`
 	client.SystemAction
+
+	// only relevant for the "create" action
+	Label       string   `json:"label,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
 }
 
 func postSystemsAction(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -120,6 +133,8 @@ func postSystemsAction(c *Command, r *http.Request, user *auth.UserState) Respon
 		return postSystemActionDo(c, systemLabel, &req)
 	case "reboot":
 		return postSystemActionReboot(c, systemLabel, &req)
+	case "create":
+		return postSystemActionCreate(c, systemLabel, &req)
 	default:
 		return BadRequest("unsupported action %q", req.Action)
 	}
@@ -168,3 +183,49 @@ func postSystemActionDo(c *Command, systemLabel string, req *systemActionRequest
 	}
 	return SyncResponse(nil)
 }
+
+var devicestateCreateRecoverySystemWithMetadata = devicestate.CreateRecoverySystemWithMetadata
+
+func postSystemActionCreate(c *Command, systemLabel string, req *systemActionRequest) Response {
+	if systemLabel == "" {
+		systemLabel = req.Label
+	}
+	if systemLabel == "" {
+		return BadRequest("cannot create a recovery system with no label")
+	}
+	if req.Label != "" && req.Label != systemLabel {
+		return BadRequest("cannot create a recovery system with mismatched labels %q and %q", systemLabel, req.Label)
+	}
+	var meta *devicestate.RecoverySystemMetadata
+	if req.Description != "" || len(req.Tags) > 0 {
+		meta = &devicestate.RecoverySystemMetadata{
+			Description: req.Description,
+			Tags:        req.Tags,
+		}
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	chg, err := devicestateCreateRecoverySystemWithMetadata(st, systemLabel, meta)
+	if err != nil {
+		return BadRequest("cannot create recovery system %q: %v", systemLabel, err)
+	}
+	ensureStateSoon(st)
+	return AsyncResponse(nil, chg.ID())
+}
+
+// getInstallProgress reports the progress of installing the system in
+// install mode, for installers to render it
+func getInstallProgress(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	progress, err := c.d.overlord.DeviceManager().InstallProgress()
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+	return SyncResponse(progress)
+}
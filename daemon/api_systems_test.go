@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -689,3 +690,155 @@ func (s *systemsSuite) TestSystemRebootUnhappy(c *check.C) {
 		c.Check(result["message"], check.Equals, tc.expectedErr)
 	}
 }
+
+func (s *systemsSuite) TestGetInstallProgress(c *check.C) {
+	m := boot.Modeenv{
+		Mode: "install",
+	}
+	err := m.WriteTo("")
+	c.Assert(err, check.IsNil)
+
+	d := s.daemonWithOverlordMockAndStore(c)
+	hookMgr, err := hookstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
+	c.Assert(err, check.IsNil)
+	mgr, err := devicestate.Manager(d.Overlord().State(), hookMgr, d.Overlord().TaskRunner(), nil)
+	c.Assert(err, check.IsNil)
+	d.Overlord().AddManager(mgr)
+
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("seeded", true)
+	chg := st.NewChange("install-system", "Install the system")
+	t := st.NewTask("setup-run-system", "Setup system for run mode")
+	t.Set("install-stage", "encryption-setup")
+	chg.AddTask(t)
+	st.Unlock()
+
+	s.expectOpenAccess()
+
+	req, err := http.NewRequest("GET", "/v2/install-progress", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Assert(rsp.Status, check.Equals, 200)
+	c.Check(rsp.Result, check.DeepEquals, &devicestate.InstallProgress{
+		Stage:    "encryption-setup",
+		Done:     2,
+		Total:    6,
+		ChangeID: chg.ID(),
+	})
+}
+
+func (s *systemsSuite) TestGetInstallProgressNotInstallMode(c *check.C) {
+	m := boot.Modeenv{
+		Mode: "run",
+	}
+	err := m.WriteTo("")
+	c.Assert(err, check.IsNil)
+
+	d := s.daemonWithOverlordMockAndStore(c)
+	hookMgr, err := hookstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
+	c.Assert(err, check.IsNil)
+	mgr, err := devicestate.Manager(d.Overlord().State(), hookMgr, d.Overlord().TaskRunner(), nil)
+	c.Assert(err, check.IsNil)
+	d.Overlord().AddManager(mgr)
+
+	s.expectOpenAccess()
+
+	req, err := http.NewRequest("GET", "/v2/install-progress", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Assert(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `cannot report install progress in "run" mode`)
+}
+
+func (s *systemsSuite) TestSystemsGetWithMetadata(c *check.C) {
+	m := boot.Modeenv{
+		Mode: "run",
+	}
+	err := m.WriteTo("")
+	c.Assert(err, check.IsNil)
+
+	d := s.daemonWithOverlordMockAndStore(c)
+	hookMgr, err := hookstate.Manager(d.Overlord().State(), d.Overlord().TaskRunner())
+	c.Assert(err, check.IsNil)
+	mgr, err := devicestate.Manager(d.Overlord().State(), hookMgr, d.Overlord().TaskRunner(), nil)
+	c.Assert(err, check.IsNil)
+	d.Overlord().AddManager(mgr)
+
+	s.expectAuthenticatedAccess()
+
+	restore := s.mockSystemSeeds(c)
+	defer restore()
+	err = ioutil.WriteFile(filepath.Join(dirs.SnapSeedDir, "systems/20200318/snapd-metadata.json"),
+		[]byte(`{"description":"before the upgrade","tags":["pre-upgrade"]}`), 0644)
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("GET", "/v2/systems", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	c.Assert(rsp.Status, check.Equals, 200)
+	sys := rsp.Result.(*daemon.SystemsResponse)
+	c.Assert(sys.Systems, check.HasLen, 2)
+	c.Check(sys.Systems[0].Label, check.Equals, "20191119")
+	c.Check(sys.Systems[0].Description, check.Equals, "")
+	c.Check(sys.Systems[0].Tags, check.HasLen, 0)
+	c.Check(sys.Systems[1].Label, check.Equals, "20200318")
+	c.Check(sys.Systems[1].Description, check.Equals, "before the upgrade")
+	c.Check(sys.Systems[1].Tags, check.DeepEquals, []string{"pre-upgrade"})
+}
+
+func (s *systemsSuite) TestSystemActionCreate(c *check.C) {
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {})
+	defer restore()
+
+	s.daemon(c)
+
+	for _, tc := range []struct {
+		url, body string
+		meta      *devicestate.RecoverySystemMetadata
+	}{
+		{"/v2/systems", `{"action":"create","label":"pre-upgrade"}`, nil},
+		{"/v2/systems/pre-upgrade", `{"action":"create"}`, nil},
+		{"/v2/systems", `{"action":"create","label":"pre-upgrade","description":"before the upgrade","tags":["manual"]}`,
+			&devicestate.RecoverySystemMetadata{Description: "before the upgrade", Tags: []string{"manual"}}},
+	} {
+		called := 0
+		restore := daemon.MockDevicestateCreateRecoverySystemWithMetadata(func(st *state.State, label string, meta *devicestate.RecoverySystemMetadata) (*state.Change, error) {
+			called++
+			c.Check(label, check.Equals, "pre-upgrade")
+			c.Check(meta, check.DeepEquals, tc.meta)
+			return st.NewChange("create-recovery-system", "..."), nil
+		})
+		req, err := http.NewRequest("POST", tc.url, strings.NewReader(tc.body))
+		c.Assert(err, check.IsNil)
+		rsp := s.asyncReq(c, req, nil)
+		c.Check(rsp.Status, check.Equals, 202)
+		c.Check(rsp.Change, check.Not(check.Equals), "")
+		c.Check(called, check.Equals, 1)
+		restore()
+	}
+}
+
+func (s *systemsSuite) TestSystemActionCreateErrors(c *check.C) {
+	s.daemon(c)
+
+	restore := daemon.MockDevicestateCreateRecoverySystemWithMetadata(func(st *state.State, label string, meta *devicestate.RecoverySystemMetadata) (*state.Change, error) {
+		return nil, fmt.Errorf(`invalid recovery system tag "Foo"`)
+	})
+	defer restore()
+
+	for _, tc := range []struct {
+		url, body, err string
+	}{
+		{"/v2/systems", `{"action":"create"}`, `cannot create a recovery system with no label`},
+		{"/v2/systems/foo", `{"action":"create","label":"bar"}`, `cannot create a recovery system with mismatched labels "foo" and "bar"`},
+		{"/v2/systems/foo", `{"action":"create","tags":["Foo"]}`, `cannot create recovery system "foo": invalid recovery system tag "Foo"`},
+	} {
+		req, err := http.NewRequest("POST", tc.url, strings.NewReader(tc.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, tc.err)
+	}
+}
@@ -31,6 +31,7 @@ import (
 
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -38,6 +39,7 @@ import (
 	"github.com/snapcore/snapd/snap/channel"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/strutil"
+	userclient "github.com/snapcore/snapd/usersession/client"
 )
 
 var (
@@ -48,6 +50,14 @@ var (
 		ReadAccess:  openAccess{},
 		WriteAccess: authenticatedAccess{},
 	}
+
+	// themesReportCmd is used by the session agents, running as the
+	// users, to report the themes used by their desktops.
+	themesReportCmd = &Command{
+		Path:        "/v2/accessories/themes/report",
+		POST:        reportThemes,
+		WriteAccess: openAccess{},
+	}
 )
 
 type themeStatus string
@@ -196,6 +206,13 @@ type themeInstallReq struct {
 	SoundThemes []string `json:"sound-themes"`
 }
 
+// themeReportResponse lists the snaps the user is asked to install to
+// provide the reported themes, along with the notice recorded about it.
+type themeReportResponse struct {
+	Snaps    []string `json:"snaps,omitempty"`
+	NoticeID string   `json:"notice-id,omitempty"`
+}
+
 func installThemes(c *Command, r *http.Request, user *auth.UserState) Response {
 	decoder := json.NewDecoder(r.Body)
 	var req themeInstallReq
@@ -227,10 +244,20 @@ func installThemes(c *Command, r *http.Request, user *auth.UserState) Response {
 	if user != nil {
 		userID = user.ID
 	}
-	installed, tasksets, err := snapstateInstallMany(st, toInstall, userID)
+	chg, err := installThemeSnaps(st, toInstall, userID)
 	if err != nil {
 		return InternalError("cannot install themes: %s", err)
 	}
+	return AsyncResponse(nil, chg.ID())
+}
+
+// installThemeSnaps creates an "install-themes" change installing the
+// given snaps.
+func installThemeSnaps(st *state.State, toInstall []string, userID int) (*state.Change, error) {
+	installed, tasksets, err := snapstateInstallMany(st, toInstall, userID)
+	if err != nil {
+		return nil, err
+	}
 	var summary string
 	switch len(toInstall) {
 	case 1:
@@ -249,5 +276,152 @@ func installThemes(c *Command, r *http.Request, user *auth.UserState) Response {
 		ensureStateSoon(st)
 	}
 	chg.Set("api-data", map[string]interface{}{"snap-names": installed})
-	return AsyncResponse(nil, chg.ID())
+	return chg, nil
+}
+
+// reportThemes handles themes used by the desktop of a user, as reported
+// by their session agent. If some are provided by snaps that are not
+// installed, and that the user did not decline before, a notice is
+// recorded and the user is asked whether to install them in the
+// background.
+func reportThemes(c *Command, r *http.Request, user *auth.UserState) Response {
+	ucred, err := ucrednetGet(r.RemoteAddr)
+	if err != nil {
+		return Forbidden("cannot get remote user: %v", err)
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var req themeInstallReq
+	if err := decoder.Decode(&req); err != nil {
+		return BadRequest("cannot decode request body: %v", err)
+	}
+
+	ctx := store.WithClientUserAgent(r.Context(), r)
+	_, candidateSnaps, err := themeStatusAndCandidateSnaps(ctx, c.d, user, req.GtkThemes, req.IconThemes, req.SoundThemes)
+	if err != nil {
+		return InternalError("cannot get theme status: %s", err)
+	}
+	candidates := make([]string, 0, len(candidateSnaps))
+	for pkg := range candidateSnaps {
+		candidates = append(candidates, pkg)
+	}
+	sort.Strings(candidates)
+
+	userID := 0
+	if user != nil {
+		userID = user.ID
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	snaps, err := undeclinedThemeSnaps(st, ucred.Uid, candidates)
+	if err != nil {
+		st.Unlock()
+		return InternalError("cannot get declined themes: %v", err)
+	}
+	var noticeID string
+	if len(snaps) != 0 {
+		noticeID = st.AddNotice(&ucred.Uid, state.ThemeInstallNotice, strings.Join(snaps, ","), &state.AddNoticeOptions{
+			Data:        map[string]string{"snaps": strings.Join(snaps, ",")},
+			RequiresAck: true,
+		})
+	}
+	st.Unlock()
+
+	if len(snaps) != 0 {
+		asyncPromptThemeInstall(st, ucred.Uid, userID, snaps, noticeID)
+	}
+	return SyncResponse(&themeReportResponse{Snaps: snaps, NoticeID: noticeID})
+}
+
+// declinedThemeSnaps returns the theme snaps that the given user asked to
+// never be prompted about again.
+func declinedThemeSnaps(st *state.State) (map[string][]string, error) {
+	var declined map[string][]string
+	if err := st.Get("declined-theme-snaps", &declined); err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	if declined == nil {
+		declined = make(map[string][]string)
+	}
+	return declined, nil
+}
+
+func undeclinedThemeSnaps(st *state.State, uid uint32, candidates []string) ([]string, error) {
+	declined, err := declinedThemeSnaps(st)
+	if err != nil {
+		return nil, err
+	}
+	userDeclined := declined[fmt.Sprint(uid)]
+	var snaps []string
+	for _, name := range candidates {
+		if !strutil.ListContains(userDeclined, name) {
+			snaps = append(snaps, name)
+		}
+	}
+	return snaps, nil
+}
+
+func declineThemeSnaps(st *state.State, uid uint32, snaps []string) error {
+	declined, err := declinedThemeSnaps(st)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprint(uid)
+	for _, name := range snaps {
+		if !strutil.ListContains(declined[key], name) {
+			declined[key] = append(declined[key], name)
+		}
+	}
+	st.Set("declined-theme-snaps", declined)
+	return nil
+}
+
+// requestThemeInstallDecision asks the user with the given uid, via their
+// session agent, whether to install snaps providing themes.
+var requestThemeInstallDecision = func(ctx context.Context, uid uint32, req *userclient.DecisionRequest) (*userclient.Decision, error) {
+	decisions, err := userclient.NewForUids(int(uid)).RequestDecision(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if decision := decisions[int(uid)]; decision != nil {
+		return decision, nil
+	}
+	return &userclient.Decision{}, nil
+}
+
+// asyncPromptThemeInstall asks the user about installing snaps providing
+// themes in a goroutine, as the user may take their time to answer.
+var asyncPromptThemeInstall = func(st *state.State, uid uint32, userID int, snaps []string, noticeID string) {
+	go promptThemeInstall(st, uid, userID, snaps, noticeID)
+}
+
+func promptThemeInstall(st *state.State, uid uint32, userID int, snaps []string, noticeID string) {
+	decision, err := requestThemeInstallDecision(context.TODO(), uid, &userclient.DecisionRequest{
+		Kind:    "theme-install",
+		Summary: i18n.G("Install missing themes?"),
+		Body:    fmt.Sprintf(i18n.G("Themes used by your desktop are provided by snaps %s."), strutil.Quoted(snaps)),
+	})
+	if err != nil {
+		logger.Noticef("Cannot ask about installing themes: %v", err)
+		return
+	}
+
+	st.Lock()
+	defer st.Unlock()
+
+	if decision.Answered {
+		st.AckNotice(uid, noticeID)
+	}
+	if !decision.Allow {
+		if decision.Remember {
+			if err := declineThemeSnaps(st, uid, snaps); err != nil {
+				logger.Noticef("Cannot record declined themes: %v", err)
+			}
+		}
+		return
+	}
+	if _, err := installThemeSnaps(st, snaps, userID); err != nil {
+		logger.Noticef("Cannot install themes: %v", err)
+	}
 }
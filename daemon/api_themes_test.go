@@ -24,6 +24,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/http/httptest"
 
 	. "gopkg.in/check.v1"
@@ -38,6 +39,7 @@ import (
 	"github.com/snapcore/snapd/snap/channel"
 	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/store"
+	userclient "github.com/snapcore/snapd/usersession/client"
 )
 
 var _ = Suite(&themesSuite{})
@@ -431,3 +433,161 @@ func (s *themesSuite) TestThemesCmdPost(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(names, DeepEquals, []string{"gtk-theme-foo", "icon-theme-foo", "sound-theme-foo"})
 }
+
+func (s *themesSuite) themesReportReq(c *C, uid, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/v2/accessories/themes/report", bytes.NewBufferString(body))
+	req.RemoteAddr = "pid=100;uid=" + uid + ";socket=;"
+	return req
+}
+
+func (s *themesSuite) TestThemesReport(c *C) {
+	s.daemon(c)
+	s.expectWriteAccess(daemon.OpenAccess{})
+
+	s.available = map[string]*snap.Info{
+		"gtk-theme-foo": {
+			SuggestedName: "gtk-theme-foo",
+			SideInfo: snap.SideInfo{
+				Channel: "stable",
+			},
+		},
+		"icon-theme-foo": {
+			SuggestedName: "icon-theme-foo",
+			SideInfo: snap.SideInfo{
+				Channel: "stable",
+			},
+		},
+	}
+	type prompt struct {
+		uid      uint32
+		snaps    []string
+		noticeID string
+	}
+	var prompts []prompt
+	restore := daemon.MockAsyncPromptThemeInstall(func(st *state.State, uid uint32, userID int, snaps []string, noticeID string) {
+		prompts = append(prompts, prompt{uid, snaps, noticeID})
+	})
+	defer restore()
+
+	rsp := s.syncReq(c, s.themesReportReq(c, "1000", `{"gtk-themes":["Foo-gtk"],"icon-themes":["Foo-icons","Bar"]}`), nil)
+	res := rsp.Result.(*daemon.ThemeReportResponse)
+	c.Check(res.Snaps, DeepEquals, []string{"gtk-theme-foo", "icon-theme-foo"})
+	c.Check(res.NoticeID, Not(Equals), "")
+	c.Check(prompts, DeepEquals, []prompt{{1000, []string{"gtk-theme-foo", "icon-theme-foo"}, res.NoticeID}})
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	uid := uint32(1000)
+	notices := st.Notices(&state.NoticeFilter{UserID: &uid, Types: []state.NoticeType{state.ThemeInstallNotice}, Pending: true})
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].ID(), Equals, res.NoticeID)
+	c.Check(notices[0].Data(), DeepEquals, map[string]string{"snaps": "gtk-theme-foo,icon-theme-foo"})
+	// the notice is not visible to other users
+	otherUID := uint32(1001)
+	c.Check(st.Notices(&state.NoticeFilter{UserID: &otherUID}), HasLen, 0)
+	st.Unlock()
+
+	// nothing to install, nothing to ask
+	prompts = nil
+	rsp = s.syncReq(c, s.themesReportReq(c, "1000", `{"gtk-themes":["Bar"]}`), nil)
+	c.Check(rsp.Result, DeepEquals, &daemon.ThemeReportResponse{})
+	c.Check(prompts, HasLen, 0)
+}
+
+func (s *themesSuite) TestThemesReportDeclined(c *C) {
+	s.expectWriteAccess(daemon.OpenAccess{})
+	s.daemon(c)
+	s.available = map[string]*snap.Info{
+		"gtk-theme-foo": {
+			SuggestedName: "gtk-theme-foo",
+			SideInfo: snap.SideInfo{
+				Channel: "stable",
+			},
+		},
+	}
+	restore := daemon.MockAsyncPromptThemeInstall(func(st *state.State, uid uint32, userID int, snaps []string, noticeID string) {
+		c.Errorf("unexpected prompt")
+	})
+	defer restore()
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	st.Set("declined-theme-snaps", map[string][]string{"1000": {"gtk-theme-foo"}})
+	st.Unlock()
+
+	rsp := s.syncReq(c, s.themesReportReq(c, "1000", `{"gtk-themes":["Foo-gtk"]}`), nil)
+	c.Check(rsp.Result, DeepEquals, &daemon.ThemeReportResponse{})
+}
+
+func (s *themesSuite) TestPromptThemeInstallAllow(c *C) {
+	s.daemonWithIfaceMgr(c)
+
+	var decisionReqs []*userclient.DecisionRequest
+	restore := daemon.MockRequestThemeInstallDecision(func(ctx context.Context, uid uint32, req *userclient.DecisionRequest) (*userclient.Decision, error) {
+		c.Check(uid, Equals, uint32(1000))
+		decisionReqs = append(decisionReqs, req)
+		return &userclient.Decision{Answered: true, Allow: true}, nil
+	})
+	defer restore()
+	restore = daemon.MockSnapstateInstallMany(func(s *state.State, names []string, userID int) ([]string, []*state.TaskSet, error) {
+		c.Check(userID, Equals, 42)
+		t := s.NewTask("fake-theme-install", "Theme install")
+		return names, []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})
+	defer restore()
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	uid := uint32(1000)
+	noticeID := st.AddNotice(&uid, state.ThemeInstallNotice, "gtk-theme-foo", &state.AddNoticeOptions{RequiresAck: true})
+	st.Unlock()
+
+	daemon.PromptThemeInstall(st, uid, 42, []string{"gtk-theme-foo"}, noticeID)
+
+	c.Assert(decisionReqs, HasLen, 1)
+	c.Check(decisionReqs[0].Kind, Equals, "theme-install")
+	c.Check(decisionReqs[0].Body, Equals, `Themes used by your desktop are provided by snaps "gtk-theme-foo".`)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Notice(noticeID).Pending(), Equals, false)
+	var chg *state.Change
+	for _, ch := range st.Changes() {
+		if ch.Kind() == "install-themes" {
+			chg = ch
+		}
+	}
+	c.Assert(chg, NotNil)
+	var data map[string][]string
+	c.Assert(chg.Get("api-data", &data), IsNil)
+	c.Check(data["snap-names"], DeepEquals, []string{"gtk-theme-foo"})
+}
+
+func (s *themesSuite) TestPromptThemeInstallDenyAlways(c *C) {
+	s.daemonWithIfaceMgr(c)
+
+	restore := daemon.MockRequestThemeInstallDecision(func(ctx context.Context, uid uint32, req *userclient.DecisionRequest) (*userclient.Decision, error) {
+		return &userclient.Decision{Answered: true, Remember: true}, nil
+	})
+	defer restore()
+	restore = daemon.MockSnapstateInstallMany(func(s *state.State, names []string, userID int) ([]string, []*state.TaskSet, error) {
+		c.Errorf("unexpected install")
+		return nil, nil, nil
+	})
+	defer restore()
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	uid := uint32(1000)
+	noticeID := st.AddNotice(&uid, state.ThemeInstallNotice, "gtk-theme-foo", &state.AddNoticeOptions{RequiresAck: true})
+	st.Unlock()
+
+	daemon.PromptThemeInstall(st, uid, 0, []string{"gtk-theme-foo"}, noticeID)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Notice(noticeID).Pending(), Equals, false)
+	var declined map[string][]string
+	c.Assert(st.Get("declined-theme-snaps", &declined), IsNil)
+	c.Check(declined, DeepEquals, map[string][]string{"1000": {"gtk-theme-foo"}})
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
@@ -46,13 +47,13 @@ var (
 	loginCmd = &Command{
 		Path:        "/v2/login",
 		POST:        loginUser,
-		WriteAccess: authenticatedAccess{Polkit: polkitActionLogin},
+		WriteAccess: authenticatedAccess{Polkit: polkitActionLogin, Role: auth.RoleReadOnly},
 	}
 
 	logoutCmd = &Command{
 		Path:        "/v2/logout",
 		POST:        logoutUser,
-		WriteAccess: authenticatedAccess{Polkit: polkitActionLogin},
+		WriteAccess: authenticatedAccess{Polkit: polkitActionLogin, Role: auth.RoleReadOnly},
 	}
 
 	// backwards compat; to-be-deprecated
@@ -82,6 +83,7 @@ type userResponseData struct {
 	Username string   `json:"username,omitempty"`
 	Email    string   `json:"email,omitempty"`
 	SSHKeys  []string `json:"ssh-keys,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
 
 	Macaroon   string   `json:"macaroon,omitempty"`
 	Discharges []string `json:"discharges,omitempty"`
@@ -208,10 +210,6 @@ var hasUserAdmin = !release.OnClassic
 const noUserAdmin = "system user administration via snapd is not allowed on this system"
 
 func postUsers(c *Command, r *http.Request, user *auth.UserState) Response {
-	if !hasUserAdmin {
-		return MethodNotAllowed(noUserAdmin)
-	}
-
 	var postData postUserData
 
 	decoder := json.NewDecoder(r.Body)
@@ -221,6 +219,13 @@ func postUsers(c *Command, r *http.Request, user *auth.UserState) Response {
 	if decoder.More() {
 		return BadRequest("spurious content after user action")
 	}
+	// the roles of the users of snapd are not about system users
+	if postData.Action == "set-roles" {
+		return setUserRoles(c, postData.postUserSetRolesData)
+	}
+	if !hasUserAdmin {
+		return MethodNotAllowed(noUserAdmin)
+	}
 	switch postData.Action {
 	case "create":
 		return createUser(c, postData.postUserCreateData)
@@ -232,6 +237,38 @@ func postUsers(c *Command, r *http.Request, user *auth.UserState) Response {
 	return BadRequest("unsupported user action %q", postData.Action)
 }
 
+func setUserRoles(c *Command, opts postUserSetRolesData) Response {
+	if opts.ID == 0 {
+		return BadRequest("need a user id to set roles")
+	}
+	if err := auth.ValidateRoles(opts.Roles); err != nil {
+		return BadRequest("cannot set user roles: %v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	u, err := auth.User(st, opts.ID)
+	if err == auth.ErrInvalidUser {
+		return BadRequest("user %d is not known", opts.ID)
+	}
+	if err != nil {
+		return InternalError("cannot get user: %v", err)
+	}
+	u.Roles = opts.Roles
+	if err := auth.UpdateUser(st, u); err != nil {
+		return InternalError("cannot update user: %v", err)
+	}
+
+	return SyncResponse([]userResponseData{{
+		ID:       u.ID,
+		Username: u.Username,
+		Email:    u.Email,
+		Roles:    u.Roles,
+	}})
+}
+
 func removeUser(c *Command, username string, opts postUserDeleteData) Response {
 	// TODO: allow to remove user entries by email as well
 
@@ -365,8 +402,9 @@ func createUser(c *Command, createData postUserCreateData) Response {
 
 	var username string
 	var opts *osutil.AddUserOptions
+	var su *asserts.SystemUser
 	if createKnown {
-		username, opts, err = getUserDetailsFromAssertion(st, model, serial, createData.Email)
+		username, opts, su, err = getUserDetailsFromAssertion(st, model, serial, createData.Email)
 	} else {
 		username, opts, err = getUserDetailsFromStore(storeFrom(c.d), createData.Email)
 	}
@@ -385,6 +423,11 @@ func createUser(c *Command, createData postUserCreateData) Response {
 	if err := setupLocalUser(c.d.overlord.State(), username, createData.Email); err != nil {
 		return InternalError("%s", err)
 	}
+	if su != nil {
+		if err := recordSystemUserImport(st, su, username, serial); err != nil {
+			return InternalError("%s", err)
+		}
+	}
 
 	result := userResponseData{
 		Username: username,
@@ -434,7 +477,7 @@ func createAllKnownSystemUsers(st *state.State, modelAs *asserts.Model, serialAs
 		email := as.(*asserts.SystemUser).Email()
 		// we need to use getUserDetailsFromAssertion as this verifies
 		// the assertion against the current brand/model/time
-		username, opts, err := getUserDetailsFromAssertion(st, modelAs, serialAs, email)
+		username, opts, su, err := getUserDetailsFromAssertion(st, modelAs, serialAs, email)
 		if err != nil {
 			logger.Noticef("ignoring system-user assertion for %q: %s", email, err)
 			continue
@@ -454,6 +497,9 @@ func createAllKnownSystemUsers(st *state.State, modelAs *asserts.Model, serialAs
 		if err := setupLocalUser(st, username, email); err != nil {
 			return InternalError("%s", err)
 		}
+		if err := recordSystemUserImport(st, su, username, serialAs); err != nil {
+			return InternalError("%s", err)
+		}
 		createdUsers = append(createdUsers, userResponseData{
 			Username: username,
 			SSHKeys:  opts.SSHKeys,
@@ -463,7 +509,20 @@ func createAllKnownSystemUsers(st *state.State, modelAs *asserts.Model, serialAs
 	return SyncResponse(createdUsers)
 }
 
-func getUserDetailsFromAssertion(st *state.State, modelAs *asserts.Model, serialAs *asserts.Serial, email string) (string, *osutil.AddUserOptions, error) {
+func recordSystemUserImport(st *state.State, su *asserts.SystemUser, username string, serialAs *asserts.Serial) error {
+	var serial string
+	if serialAs != nil {
+		serial = serialAs.Serial()
+	}
+	st.Lock()
+	defer st.Unlock()
+	if err := devicestate.RecordSystemUserImport(st, su, username, serial); err != nil {
+		return fmt.Errorf("cannot record system-user %q import: %v", su.Email(), err)
+	}
+	return nil
+}
+
+func getUserDetailsFromAssertion(st *state.State, modelAs *asserts.Model, serialAs *asserts.Serial, email string) (string, *osutil.AddUserOptions, *asserts.SystemUser, error) {
 	errorPrefix := fmt.Sprintf("cannot add system-user %q: ", email)
 
 	st.Lock()
@@ -479,7 +538,7 @@ func getUserDetailsFromAssertion(st *state.State, modelAs *asserts.Model, serial
 		"email":    email,
 	})
 	if err != nil {
-		return "", nil, fmt.Errorf(errorPrefix+"%v", err)
+		return "", nil, nil, fmt.Errorf(errorPrefix+"%v", err)
 	}
 	// the asserts package guarantees that this cast will work
 	su := a.(*asserts.SystemUser)
@@ -489,26 +548,36 @@ func getUserDetailsFromAssertion(st *state.State, modelAs *asserts.Model, serial
 	// check that the signer of the assertion is one of the accepted ones
 	sysUserAuths := modelAs.SystemUserAuthority()
 	if len(sysUserAuths) > 0 && !strutil.ListContains(sysUserAuths, su.AuthorityID()) {
-		return "", nil, fmt.Errorf(errorPrefix+"%q not in accepted authorities %q", email, su.AuthorityID(), sysUserAuths)
+		return "", nil, nil, fmt.Errorf(errorPrefix+"%q not in accepted authorities %q", email, su.AuthorityID(), sysUserAuths)
 	}
 	if len(su.Series()) > 0 && !strutil.ListContains(su.Series(), series) {
-		return "", nil, fmt.Errorf(errorPrefix+"%q not in series %q", email, series, su.Series())
+		return "", nil, nil, fmt.Errorf(errorPrefix+"%q not in series %q", email, series, su.Series())
 	}
 	if len(su.Models()) > 0 && !strutil.ListContains(su.Models(), model) {
-		return "", nil, fmt.Errorf(errorPrefix+"%q not in models %q", model, su.Models())
+		return "", nil, nil, fmt.Errorf(errorPrefix+"%q not in models %q", model, su.Models())
 	}
-	if len(su.Serials()) > 0 {
+	if len(su.Serials()) > 0 || len(su.SerialRanges()) > 0 {
 		if serialAs == nil {
-			return "", nil, fmt.Errorf(errorPrefix + "bound to serial assertion but device not yet registered")
+			return "", nil, nil, fmt.Errorf(errorPrefix + "bound to serial assertion but device not yet registered")
 		}
 		serial := serialAs.Serial()
-		if !strutil.ListContains(su.Serials(), serial) {
-			return "", nil, fmt.Errorf(errorPrefix+"%q not in serials %q", serial, su.Serials())
+		if !su.ValidForSerial(serial) {
+			if len(su.SerialRanges()) == 0 {
+				return "", nil, nil, fmt.Errorf(errorPrefix+"%q not in serials %q", serial, su.Serials())
+			}
+			return "", nil, nil, fmt.Errorf(errorPrefix+"%q not in serials %q or serial ranges", serial, su.Serials())
 		}
 	}
 
 	if !su.ValidAt(time.Now()) {
-		return "", nil, fmt.Errorf(errorPrefix + "assertion not valid anymore")
+		return "", nil, nil, fmt.Errorf(errorPrefix + "assertion not valid anymore")
+	}
+
+	st.Lock()
+	err = devicestate.CheckSystemUserMaxUses(st, su)
+	st.Unlock()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf(errorPrefix+"%v", err)
 	}
 
 	gecos := fmt.Sprintf("%s,%s", email, su.Name())
@@ -518,7 +587,7 @@ func getUserDetailsFromAssertion(st *state.State, modelAs *asserts.Model, serial
 		Password:            su.Password(),
 		ForcePasswordChange: su.ForcePasswordChange(),
 	}
-	return su.Username(), opts, nil
+	return su.Username(), opts, su, nil
 }
 
 type postUserData struct {
@@ -526,6 +595,7 @@ type postUserData struct {
 	Username string `json:"username"`
 	postUserCreateData
 	postUserDeleteData
+	postUserSetRolesData
 }
 
 type postUserCreateData struct {
@@ -544,6 +614,11 @@ type postUserCreateData struct {
 
 type postUserDeleteData struct{}
 
+type postUserSetRolesData struct {
+	ID    int      `json:"id"`
+	Roles []string `json:"roles"`
+}
+
 var userLookup = user.Lookup
 
 func setupLocalUser(st *state.State, username, email string) error {
@@ -605,6 +680,7 @@ func getUsers(c *Command, r *http.Request, user *auth.UserState) Response {
 			Username: u.Username,
 			Email:    u.Email,
 			ID:       u.ID,
+			Roles:    u.Roles,
 		}
 	}
 	return SyncResponse(resp)
@@ -37,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/overlord/assertstate/assertstatetest"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/store"
@@ -115,7 +116,7 @@ func mkUserLookup(userHomeDir string) func(string) (*user.User, error) {
 }
 
 func (s *userSuite) expectLoginAccess() {
-	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.login"})
+	s.expectWriteAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.login", Role: auth.RoleReadOnly})
 }
 
 func (s *userSuite) TestLoginUser(c *check.C) {
@@ -599,6 +600,56 @@ func (s *userSuite) TestPostUserBadAction(c *check.C) {
 	c.Check(rspe, check.DeepEquals, daemon.BadRequest(`unsupported user action "patatas"`))
 }
 
+func (s *userSuite) TestPostUserActionSetRoles(c *check.C) {
+	// roles can be set even without system user administration
+	defer daemon.MockHasUserAdmin(false)()
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	u, err := auth.NewUser(st, "some-user", "email@test.com", "macaroon", []string{"discharge"})
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	buf := bytes.NewBufferString(fmt.Sprintf(`{"action":"set-roles","id":%d,"roles":["read-only"]}`, u.ID))
+	req, err := http.NewRequest("POST", "/v2/users", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	expected := []daemon.UserResponseData{
+		{ID: u.ID, Username: "some-user", Email: "email@test.com", Roles: []string{"read-only"}},
+	}
+	c.Check(rsp.Result, check.DeepEquals, expected)
+
+	st.Lock()
+	u, err = auth.User(st, u.ID)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(u.Roles, check.DeepEquals, []string{"read-only"})
+}
+
+func (s *userSuite) TestPostUserActionSetRolesErrors(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	_, err := auth.NewUser(st, "some-user", "email@test.com", "macaroon", []string{"discharge"})
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action":"set-roles","roles":["read-only"]}`, "need a user id to set roles"},
+		{`{"action":"set-roles","id":1,"roles":["root"]}`, `cannot set user roles: invalid role "root"`},
+		{`{"action":"set-roles","id":42,"roles":["read-only"]}`, "user 42 is not known"},
+	} {
+		req, err := http.NewRequest("POST", "/v2/users", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe, check.DeepEquals, daemon.BadRequest(t.err), check.Commentf(t.body))
+	}
+}
+
 func (s *userSuite) TestPostUserActionRemoveNoUsername(c *check.C) {
 	buf := bytes.NewBufferString(`{"action":"remove"}`)
 	req, err := http.NewRequest("POST", "/v2/users", buf)
@@ -847,6 +898,22 @@ var badUserNoMatchingSerial = map[string]interface{}{
 	"until":        time.Now().Add(24 * 30 * time.Hour).Format(time.RFC3339),
 }
 
+var serialRangeUser = map[string]interface{}{
+	"format":        "2",
+	"authority-id":  "my-brand",
+	"brand-id":      "my-brand",
+	"email":         "range@bar.com",
+	"series":        []interface{}{"16", "18"},
+	"models":        []interface{}{"my-model"},
+	"serial-ranges": []interface{}{map[string]interface{}{"first": "serialsera00", "last": "serialserz99"}},
+	"max-uses":      "1",
+	"name":          "Range Guy",
+	"username":      "rangeguy",
+	"password":      "$6$salt$hash",
+	"since":         time.Now().Format(time.RFC3339),
+	"until":         time.Now().Add(24 * 30 * time.Hour).Format(time.RFC3339),
+}
+
 var unknownUser = map[string]interface{}{
 	"authority-id": "unknown",
 	"brand-id":     "my-brand",
@@ -872,8 +939,9 @@ func (s *userSuite) TestGetUserDetailsFromAssertionHappy(c *check.C) {
 
 	// ensure that if we query the details from the assert DB we get
 	// the expected user
-	username, opts, err := daemon.GetUserDetailsFromAssertion(st, model, nil, "foo@bar.com")
+	username, opts, su, err := daemon.GetUserDetailsFromAssertion(st, model, nil, "foo@bar.com")
 	c.Check(username, check.Equals, "guy")
+	c.Check(su.Email(), check.Equals, "foo@bar.com")
 	c.Check(opts, check.DeepEquals, &osutil.AddUserOptions{
 		Gecos:    "foo@bar.com,Boring Guy",
 		Password: "$6$salt$hash",
@@ -962,6 +1030,54 @@ func (s *userSuite) TestPostCreateUserFromAssertionWithForcePasswordChange(c *ch
 	c.Check(users, check.HasLen, 1)
 }
 
+func (s *userSuite) TestPostCreateUserFromAssertionSerialRangeAndMaxUses(c *check.C) {
+	s.makeSystemUsers(c, []map[string]interface{}{serialRangeUser})
+
+	defer daemon.MockOsutilAddUser(func(username string, opts *osutil.AddUserOptions) error {
+		c.Check(username, check.Equals, "rangeguy")
+		c.Check(opts.Gecos, check.Equals, "range@bar.com,Range Guy")
+		return nil
+	})()
+
+	buf := bytes.NewBufferString(`{"action":"create","email": "range@bar.com","known":true}`)
+	req, err := http.NewRequest("POST", "/v2/users", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, []daemon.UserResponseData{{Username: "rangeguy"}})
+
+	// the use of the assertion was recorded
+	st := s.d.Overlord().State()
+	st.Lock()
+	imports, err := devicestate.SystemUserImports(st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Assert(imports, check.HasLen, 1)
+	c.Check(imports[0].Email, check.Equals, "range@bar.com")
+	c.Check(imports[0].Username, check.Equals, "rangeguy")
+	c.Check(imports[0].Serial, check.Equals, "serialserial")
+
+	// and it cannot be used again
+	req, err = http.NewRequest("POST", "/v2/users", bytes.NewBufferString(`{"action":"create","email": "range@bar.com","known":true,"force-managed":true}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Message, check.Equals, `cannot add system-user "range@bar.com": system-user assertion for "range@bar.com" already used 1 time(s), the maximum allowed`)
+}
+
+func (s *userSuite) TestPostCreateUserFromAssertionSerialNotInRange(c *check.C) {
+	user := make(map[string]interface{})
+	for k, v := range serialRangeUser {
+		user[k] = v
+	}
+	user["serial-ranges"] = []interface{}{map[string]interface{}{"first": "serialsert00", "last": "serialserz99"}}
+	s.makeSystemUsers(c, []map[string]interface{}{user})
+
+	req, err := http.NewRequest("POST", "/v2/users", bytes.NewBufferString(`{"action":"create","email": "range@bar.com","known":true}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Message, check.Equals, `cannot add system-user "range@bar.com": "serialserial" not in serials [] or serial ranges`)
+}
+
 func (s *userSuite) TestPostCreateUserFromAssertionAllKnown(c *check.C) {
 	expectSudoer := false
 	s.testPostCreateUserFromAssertion(c, `{"known":true}`, expectSudoer)
@@ -1253,3 +1369,23 @@ func (s *userSuite) TestUsersHasUser(c *check.C) {
 	c.Check(rsp.Result, check.FitsTypeOf, expected)
 	c.Check(rsp.Result, check.DeepEquals, expected)
 }
+
+func (s *userSuite) TestUsersHasUserWithRoles(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	u, err := auth.NewUser(st, "someuser", "mymail@test.com", "macaroon", []string{"discharge"})
+	c.Assert(err, check.IsNil)
+	u.Roles = []string{"read-only"}
+	c.Assert(auth.UpdateUser(st, u), check.IsNil)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/users", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+
+	expected := []daemon.UserResponseData{
+		{ID: u.ID, Username: u.Username, Email: u.Email, Roles: []string{"read-only"}},
+	}
+	c.Check(rsp.Result, check.DeepEquals, expected)
+}
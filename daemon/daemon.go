@@ -36,6 +36,7 @@ import (
 	"github.com/gorilla/mux"
 	"gopkg.in/tomb.v2"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/logger"
@@ -85,6 +86,12 @@ type Daemon struct {
 
 	expectedRebootDidNotHappen bool
 
+	apiLimiter apiLimiter
+
+	// set when the download cache is served to the local network
+	lanCacheListener net.Listener
+	lanCacheServe    *http.Server
+
 	mu sync.Mutex
 }
 
@@ -114,12 +121,6 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	user, _ := userFromRequest(st, r)
 	st.Unlock()
 
-	// check if we are in degradedMode
-	if c.d.degradedErr != nil && r.Method != "GET" {
-		InternalError(c.d.degradedErr.Error()).ServeHTTP(w, r)
-		return
-	}
-
 	ucred, err := ucrednetGet(r.RemoteAddr)
 	if err != nil && err != errNoID {
 		logger.Noticef("unexpected error when attempting to get UID: %s", err)
@@ -127,6 +128,27 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// mutating requests are recorded in the audit log once served
+	var auditEntry *client.APIAuditEntry
+	if r.Method == "POST" || r.Method == "PUT" {
+		ww := &wrappedWriter{w: w}
+		w = ww
+		auditEntry = newAPIAuditEntry(r, ucred)
+		defer func() {
+			auditEntry.Status = ww.s
+			if auditEntry.Status == 0 {
+				auditEntry.Status = 200
+			}
+			c.d.recordAPIAudit(auditEntry)
+		}()
+	}
+
+	// check if we are in degradedMode
+	if c.d.degradedErr != nil && r.Method != "GET" {
+		InternalError(c.d.degradedErr.Error()).ServeHTTP(w, r)
+		return
+	}
+
 	ctx := store.WithClientUserAgent(r.Context(), r)
 	r = r.WithContext(ctx)
 
@@ -155,6 +177,18 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	reserved, rspl := c.d.apiLimiter.check(st, r, ucred)
+	if rspl != nil {
+		rspl.ServeHTTP(w, r)
+		return
+	}
+	// the change reserved for the request, if any, is taken over by the
+	// change it started once it is served
+	var chgID string
+	defer func() {
+		c.d.apiLimiter.trackChange(ucred, chgID, reserved)
+	}()
+
 	rsp := rspf(c, r, user)
 
 	if srsp, ok := rsp.(StructuredResponse); ok {
@@ -170,6 +204,15 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			rjson.addWarningCount(count, stamp)
 		}
 
+		chgID = rjson.Change
+
+		if auditEntry != nil {
+			auditEntry.Change = rjson.Change
+			if errRes, ok := rjson.Result.(*errorResult); ok && rjson.Type == ResponseTypeError {
+				auditEntry.Error = errRes.Message
+			}
+		}
+
 		// serve the updated serialisation
 		rsp = rjson
 	}
@@ -377,6 +420,8 @@ func (d *Daemon) Start() error {
 		return nil
 	})
 
+	d.startLANCache()
+
 	// notify systemd that we are ready
 	systemdSdNotify("READY=1")
 	return nil
@@ -492,6 +537,7 @@ func (d *Daemon) Stop(sigCh chan<- os.Signal) error {
 	}
 
 	d.snapdListener.Close()
+	d.stopLANCache()
 	d.standbyOpinions.Stop()
 
 	if d.snapListener != nil {
@@ -518,6 +564,8 @@ func (d *Daemon) Stop(sigCh chan<- os.Signal) error {
 	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	d.tomb.Kill(d.serve.Shutdown(ctx))
 	cancel()
+	// no more requests are recorded in the audit log
+	flushAPIAuditLog()
 
 	if !needsFullShutdown {
 		// tell systemd that we are stopping
@@ -41,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/patch"
@@ -211,6 +212,311 @@ func (s *daemonSuite) TestCommandMethodDispatchRoot(c *check.C) {
 	c.Check(rec.Code, check.Equals, 405)
 }
 
+func (s *daemonSuite) TestCommandAuditsMutatingRequests(c *check.C) {
+	d := newTestDaemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	chg := st.NewChange("install-snap", "Install \"foo\" snap")
+	st.Unlock()
+
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	oldTimeNow := apiAuditTimeNow
+	apiAuditTimeNow = func() time.Time { return now }
+	defer func() { apiAuditTimeNow = oldTimeNow }()
+	oldSnapNameFromPid := cgroupSnapNameFromPid
+	cgroupSnapNameFromPid = func(pid int) (string, error) {
+		c.Check(pid, check.Equals, 200)
+		return "some-snap", nil
+	}
+	defer func() { cgroupSnapNameFromPid = oldSnapNameFromPid }()
+
+	cmd := &Command{d: d, Path: "/v2/foo"}
+	cmd.GET = func(*Command, *http.Request, *auth.UserState) Response {
+		return SyncResponse(nil)
+	}
+	cmd.POST = func(*Command, *http.Request, *auth.UserState) Response {
+		return AsyncResponse(nil, chg.ID())
+	}
+	cmd.PUT = func(*Command, *http.Request, *auth.UserState) Response {
+		return BadRequest("cannot put foo")
+	}
+	cmd.ReadAccess = openAccess{}
+	cmd.WriteAccess = authenticatedAccess{}
+
+	for _, t := range []struct {
+		method string
+		remote string
+		code   int
+	}{
+		{"GET", fmt.Sprintf("pid=100;uid=0;socket=%s;", dirs.SnapdSocket), 200},
+		{"POST", fmt.Sprintf("pid=100;uid=0;socket=%s;", dirs.SnapdSocket), 202},
+		{"PUT", fmt.Sprintf("pid=100;uid=0;socket=%s;", dirs.SnapdSocket), 400},
+		{"POST", fmt.Sprintf("pid=101;uid=1001;socket=%s;", dirs.SnapdSocket), 401},
+	} {
+		req, err := http.NewRequest(t.method, "/v2/foo", nil)
+		c.Assert(err, check.IsNil)
+		req.RemoteAddr = t.remote
+		rec := httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		c.Check(rec.Code, check.Equals, t.code)
+	}
+
+	// requests from snaps through snapctl record the snap
+	cmd.WriteAccess = snapAccess{}
+	req, err := http.NewRequest("POST", "/v2/foo", nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = fmt.Sprintf("pid=200;uid=0;socket=%s;", dirs.SnapSocket)
+	rec := httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 202)
+
+	entries, err := readAPIAuditLog()
+	c.Assert(err, check.IsNil)
+	c.Check(entries, check.DeepEquals, []*client.APIAuditEntry{{
+		Time:    now,
+		UID:     0,
+		Method:  "POST",
+		Path:    "/v2/foo",
+		Status:  202,
+		Change:  chg.ID(),
+		Summary: `Install "foo" snap`,
+	}, {
+		Time:   now,
+		UID:    0,
+		Method: "PUT",
+		Path:   "/v2/foo",
+		Status: 400,
+		Error:  "cannot put foo",
+	}, {
+		Time:   now,
+		UID:    1001,
+		Method: "POST",
+		Path:   "/v2/foo",
+		Status: 401,
+	}, {
+		Time:    now,
+		UID:     0,
+		Snap:    "some-snap",
+		Method:  "POST",
+		Path:    "/v2/foo",
+		Status:  202,
+		Change:  chg.ID(),
+		Summary: `Install "foo" snap`,
+	}})
+}
+
+func (s *daemonSuite) TestCommandRateLimit(c *check.C) {
+	d := newTestDaemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "api.rate-limit", 2)
+	tr.Commit()
+	st.Unlock()
+
+	now := time.Now()
+	oldTimeNow := apiLimiterTimeNow
+	apiLimiterTimeNow = func() time.Time { return now }
+	defer func() { apiLimiterTimeNow = oldTimeNow }()
+
+	cmd := &Command{d: d}
+	cmd.GET = func(*Command, *http.Request, *auth.UserState) Response {
+		return SyncResponse(nil)
+	}
+	cmd.ReadAccess = openAccess{}
+	cmd.WriteAccess = snapAccess{}
+
+	get := func(uid int, socket string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "", nil)
+		c.Assert(err, check.IsNil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", uid, socket)
+		rec := httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		return rec
+	}
+
+	c.Check(get(1000, dirs.SnapdSocket).Code, check.Equals, 200)
+	c.Check(get(1000, dirs.SnapdSocket).Code, check.Equals, 200)
+	rec := get(1000, dirs.SnapdSocket)
+	c.Check(rec.Code, check.Equals, 429)
+	// a request can be sent every 30s
+	c.Check(rec.Header().Get("Retry-After"), check.Equals, "30")
+	var rsp struct {
+		Result errorResult `json:"result"`
+	}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+	c.Check(rsp.Result.Kind, check.Equals, client.ErrorKindRateLimited)
+	c.Check(rsp.Result.Message, check.Equals, "too many requests, try again later")
+
+	// other uids have their own limit
+	c.Check(get(1001, dirs.SnapdSocket).Code, check.Equals, 200)
+
+	now = now.Add(20 * time.Second)
+	rec = get(1000, dirs.SnapdSocket)
+	c.Check(rec.Code, check.Equals, 429)
+	c.Check(rec.Header().Get("Retry-After"), check.Equals, "10")
+
+	now = now.Add(10 * time.Second)
+	c.Check(get(1000, dirs.SnapdSocket).Code, check.Equals, 200)
+	c.Check(get(1000, dirs.SnapdSocket).Code, check.Equals, 429)
+
+	// root is not limited
+	for i := 0; i < 3; i++ {
+		c.Check(get(0, dirs.SnapdSocket).Code, check.Equals, 200)
+	}
+
+	// idle buckets are dropped once they are full again
+	c.Check(d.apiLimiter.buckets, check.HasLen, 2)
+	now = now.Add(time.Minute)
+	c.Check(get(1000, dirs.SnapdSocket).Code, check.Equals, 200)
+	c.Check(d.apiLimiter.buckets, check.HasLen, 1)
+
+	// changes to the limit are picked up once the limits are read again
+	st.Lock()
+	tr = config.NewTransaction(st)
+	tr.Set("core", "api.rate-limit", 0)
+	tr.Commit()
+	st.Unlock()
+	now = now.Add(apiLimitsRefreshInterval)
+	for i := 0; i < 3; i++ {
+		c.Check(get(1000, dirs.SnapdSocket).Code, check.Equals, 200)
+	}
+}
+
+func (s *daemonSuite) TestCommandMaxChanges(c *check.C) {
+	d := newTestDaemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "api.max-changes", 1)
+	tr.Commit()
+	st.Unlock()
+
+	var chg *state.Change
+	cmd := &Command{d: d}
+	cmd.POST = func(*Command, *http.Request, *auth.UserState) Response {
+		st.Lock()
+		defer st.Unlock()
+		chg = st.NewChange("foo", "Foo")
+		chg.AddTask(st.NewTask("foo", "Foo"))
+		return AsyncResponse(nil, chg.ID())
+	}
+	cmd.WriteAccess = openAccess{}
+
+	post := func(uid int) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "", nil)
+		c.Assert(err, check.IsNil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", uid, dirs.SnapdSocket)
+		rec := httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		return rec
+	}
+
+	c.Check(post(1000).Code, check.Equals, 202)
+	first := chg
+	rec := post(1000)
+	c.Check(rec.Code, check.Equals, 429)
+	c.Check(rec.Header().Get("Retry-After"), check.Equals, "10")
+	c.Check(rec.Body.String(), testutil.Contains, "too many changes in progress, try again later")
+
+	// other uids have their own limit
+	c.Check(post(1001).Code, check.Equals, 202)
+
+	// once the change is ready another one can be started
+	st.Lock()
+	for _, t := range first.Tasks() {
+		t.SetStatus(state.DoneStatus)
+	}
+	c.Assert(first.IsReady(), check.Equals, true)
+	st.Unlock()
+	c.Check(post(1000).Code, check.Equals, 202)
+}
+
+func (s *daemonSuite) TestCommandMaxChangesConcurrent(c *check.C) {
+	d := newTestDaemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "api.max-changes", 1)
+	tr.Commit()
+	st.Unlock()
+
+	var post func(uid int) *httptest.ResponseRecorder
+	var concurrent []int
+	sendConcurrent := true
+	fail := false
+	cmd := &Command{d: d}
+	cmd.POST = func(*Command, *http.Request, *auth.UserState) Response {
+		if sendConcurrent {
+			// requests sent while the first one is being served
+			sendConcurrent = false
+			concurrent = []int{post(1000).Code, post(1001).Code}
+		}
+		if fail {
+			return BadRequest("boom")
+		}
+		st.Lock()
+		defer st.Unlock()
+		chg := st.NewChange("foo", "Foo")
+		chg.AddTask(st.NewTask("foo", "Foo"))
+		return AsyncResponse(nil, chg.ID())
+	}
+	cmd.WriteAccess = openAccess{}
+
+	post = func(uid int) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "", nil)
+		c.Assert(err, check.IsNil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=%d;socket=%s;", uid, dirs.SnapdSocket)
+		rec := httptest.NewRecorder()
+		cmd.ServeHTTP(rec, req)
+		return rec
+	}
+
+	c.Check(post(1000).Code, check.Equals, 202)
+	// the change of the first request was reserved before it was created
+	c.Check(concurrent, check.DeepEquals, []int{429, 202})
+	c.Check(d.apiLimiter.reserved, check.HasLen, 0)
+	c.Check(d.apiLimiter.changes[1000], check.HasLen, 1)
+
+	// the reservation of requests not starting a change is released
+	fail = true
+	c.Check(post(1002).Code, check.Equals, 400)
+	c.Check(d.apiLimiter.reserved, check.HasLen, 0)
+	fail = false
+	c.Check(post(1002).Code, check.Equals, 202)
+}
+
+func (s *daemonSuite) TestLANCacheNotEnabled(c *check.C) {
+	d := newTestDaemon(c)
+
+	d.startLANCache()
+	c.Check(d.lanCacheListener, check.IsNil)
+	d.stopLANCache()
+}
+
+func (s *daemonSuite) TestLANCacheStartStop(c *check.C) {
+	d := newTestDaemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "proxy.lan-cache-listen", "127.0.0.1:0")
+	tr.Commit()
+	st.Unlock()
+
+	d.startLANCache()
+	c.Assert(d.lanCacheListener, check.NotNil)
+	url := fmt.Sprintf("http://%s/v2/lan-cache/not-a-sha3", d.lanCacheListener.Addr())
+	rsp, err := http.Get(url)
+	c.Assert(err, check.IsNil)
+	rsp.Body.Close()
+	c.Check(rsp.StatusCode, check.Equals, 400)
+
+	d.stopLANCache()
+	_, err = http.Get(url)
+	c.Check(err, check.NotNil)
+	c.Check(d.tomb.Wait(), check.IsNil)
+}
+
 func (s *daemonSuite) TestCommandRestartingState(c *check.C) {
 	d := newTestDaemon(c)
 
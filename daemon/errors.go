@@ -244,6 +244,16 @@ func InsufficientSpace(dserr *snapstate.InsufficientSpaceError) *apiError {
 	}
 }
 
+// RateLimited is an error responder used when a client sent too many
+// requests or has too many changes in progress.
+func RateLimited(format string, v ...interface{}) *apiError {
+	return &apiError{
+		Status:  429,
+		Message: fmt.Sprintf(format, v...),
+		Kind:    client.ErrorKindRateLimited,
+	}
+}
+
 // AppNotFound is an error responder used when an operation is
 // requested on a app that doesn't exist.
 func AppNotFound(format string, v ...interface{}) *apiError {
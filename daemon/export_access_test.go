@@ -21,6 +21,7 @@ package daemon
 
 import (
 	"net/http"
+	"os/user"
 
 	"github.com/snapcore/snapd/polkit"
 )
@@ -51,3 +52,11 @@ func MockPolkitCheckAuthorization(new func(pid int32, uid uint32, actionId strin
 		polkitCheckAuthorization = old
 	}
 }
+
+func MockUserLookupId(f func(uid string) (*user.User, error)) (restore func()) {
+	old := userLookupId
+	userLookupId = f
+	return func() {
+		userLookupId = old
+	}
+}
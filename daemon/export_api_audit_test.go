@@ -0,0 +1,57 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"os"
+	"time"
+)
+
+var AppendAPIAuditEntry = appendAPIAuditEntry
+
+func MockAPIAuditLogMaxSize(size int64) (restore func()) {
+	old := apiAuditLogMaxSize
+	apiAuditLogMaxSize = size
+	return func() {
+		apiAuditLogMaxSize = old
+	}
+}
+
+func MockAPIAuditFileSync(f func(*os.File) error) (restore func()) {
+	old := apiAuditFileSync
+	apiAuditFileSync = f
+	apiAuditLastSync = time.Time{}
+	apiAuditUnsynced = false
+	return func() {
+		apiAuditFileSync = old
+		apiAuditLastSync = time.Time{}
+		apiAuditUnsynced = false
+	}
+}
+
+func MockAPIAuditTimeNow(f func() time.Time) (restore func()) {
+	old := apiAuditTimeNow
+	apiAuditTimeNow = f
+	return func() {
+		apiAuditTimeNow = old
+	}
+}
+
+var FlushAPIAuditLog = flushAPIAuditLog
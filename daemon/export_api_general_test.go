@@ -53,6 +53,14 @@ func MockWarningsAccessors(okay func(*state.State, time.Time) int, all func(*sta
 	}
 }
 
+func MockChangeWaitPollInterval(d time.Duration) (restore func()) {
+	old := changeWaitPollInterval
+	changeWaitPollInterval = d
+	return func() {
+		changeWaitPollInterval = old
+	}
+}
+
 type (
 	ChangeInfo = changeInfo
 )
@@ -21,6 +21,7 @@ package daemon
 
 import (
 	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/state"
 )
 
@@ -32,6 +33,22 @@ func MockDevicestateRemodel(mock func(*state.State, *asserts.Model) (*state.Chan
 	}
 }
 
+func MockDevicestatePlanRemodel(mock func(*state.State, *asserts.Model) (*devicestate.RemodelPlan, error)) (restore func()) {
+	oldDevicestatePlanRemodel := devicestatePlanRemodel
+	devicestatePlanRemodel = mock
+	return func() {
+		devicestatePlanRemodel = oldDevicestatePlanRemodel
+	}
+}
+
+func MockDevicestateQueueRemodel(mock func(*state.State, *asserts.Model) error) (restore func()) {
+	oldDevicestateQueueRemodel := devicestateQueueRemodel
+	devicestateQueueRemodel = mock
+	return func() {
+		devicestateQueueRemodel = oldDevicestateQueueRemodel
+	}
+}
+
 type (
 	PostModelData   = postModelData
 	ModelAssertJSON = modelAssertJSON
@@ -19,6 +19,21 @@
 
 package daemon
 
+import (
+	"context"
+	"net/http"
+
+	"github.com/snapcore/snapd/store"
+)
+
 var (
 	TrySnap = trySnap
 )
+
+func MockStoreDownloadOCI(mock func(context.Context, *http.Client, *store.OCIReference, string) ([]byte, error)) (restore func()) {
+	old := storeDownloadOCI
+	storeDownloadOCI = mock
+	return func() {
+		storeDownloadOCI = old
+	}
+}
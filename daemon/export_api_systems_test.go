@@ -21,6 +21,7 @@ package daemon
 
 import (
 	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/state"
 )
 
 func MockDeviceManagerReboot(f func(*devicestate.DeviceManager, string, string) error) (restore func()) {
@@ -31,6 +32,14 @@ func MockDeviceManagerReboot(f func(*devicestate.DeviceManager, string, string)
 	}
 }
 
+func MockDevicestateCreateRecoverySystemWithMetadata(f func(*state.State, string, *devicestate.RecoverySystemMetadata) (*state.Change, error)) (restore func()) {
+	old := devicestateCreateRecoverySystemWithMetadata
+	devicestateCreateRecoverySystemWithMetadata = f
+	return func() {
+		devicestateCreateRecoverySystemWithMetadata = old
+	}
+}
+
 type (
 	SystemsResponse = systemsResponse
 )
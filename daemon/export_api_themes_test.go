@@ -19,9 +19,17 @@
 
 package daemon
 
+import (
+	"context"
+
+	"github.com/snapcore/snapd/overlord/state"
+	userclient "github.com/snapcore/snapd/usersession/client"
+)
+
 type (
 	ThemeStatus         = themeStatus
 	ThemeStatusResponse = themeStatusResponse
+	ThemeReportResponse = themeReportResponse
 )
 
 var (
@@ -33,4 +41,22 @@ var (
 	ThemeInstalled   = themeInstalled
 	ThemeAvailable   = themeAvailable
 	ThemeUnavailable = themeUnavailable
+
+	PromptThemeInstall = promptThemeInstall
 )
+
+func MockAsyncPromptThemeInstall(mock func(st *state.State, uid uint32, userID int, snaps []string, noticeID string)) (restore func()) {
+	old := asyncPromptThemeInstall
+	asyncPromptThemeInstall = mock
+	return func() {
+		asyncPromptThemeInstall = old
+	}
+}
+
+func MockRequestThemeInstallDecision(mock func(ctx context.Context, uid uint32, req *userclient.DecisionRequest) (*userclient.Decision, error)) (restore func()) {
+	old := requestThemeInstallDecision
+	requestThemeInstallDecision = mock
+	return func() {
+		requestThemeInstallDecision = old
+	}
+}
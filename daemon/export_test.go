@@ -105,6 +105,14 @@ func MockUnsafeReadSnapInfo(mock func(string) (*snap.Info, error)) (restore func
 	}
 }
 
+func MockSeccompStaleProcesses(mock func(string) (map[string][]int, error)) (restore func()) {
+	old := seccompStaleProcesses
+	seccompStaleProcesses = mock
+	return func() {
+		seccompStaleProcesses = old
+	}
+}
+
 func MockAssertstateRefreshSnapDeclarations(mock func(*state.State, int) error) (restore func()) {
 	oldAssertstateRefreshSnapDeclarations := assertstateRefreshSnapDeclarations
 	assertstateRefreshSnapDeclarations = mock
@@ -129,6 +137,14 @@ func MockSnapstateInstallPath(mock func(*state.State, *snap.SideInfo, string, st
 	}
 }
 
+func MockSnapstateInstallPathMany(mock func(*state.State, []*snap.SideInfo, []string, snapstate.Flags) ([]*state.TaskSet, []*snap.Info, error)) (restore func()) {
+	oldSnapstateInstallPathMany := snapstateInstallPathMany
+	snapstateInstallPathMany = mock
+	return func() {
+		snapstateInstallPathMany = oldSnapstateInstallPathMany
+	}
+}
+
 func MockSnapstateUpdate(mock func(*state.State, string, *snapstate.RevisionOptions, int, snapstate.Flags) (*state.TaskSet, error)) (restore func()) {
 	oldSnapstateUpdate := snapstateUpdate
 	snapstateUpdate = mock
@@ -169,6 +185,14 @@ func MockSnapstateRevertToRevision(mock func(*state.State, string, snap.Revision
 	}
 }
 
+func MockSnapstateDowngrade(mock func(*state.State, string, snap.Revision, int, snapstate.Flags) (uint64, *state.TaskSet, error)) (restore func()) {
+	oldSnapstateDowngrade := snapstateDowngrade
+	snapstateDowngrade = mock
+	return func() {
+		snapstateDowngrade = oldSnapstateDowngrade
+	}
+}
+
 func MockSnapstateInstallMany(mock func(*state.State, []string, int) ([]string, []*state.TaskSet, error)) (restore func()) {
 	oldSnapstateInstallMany := snapstateInstallMany
 	snapstateInstallMany = mock
@@ -185,7 +209,15 @@ func MockSnapstateUpdateMany(mock func(context.Context, *state.State, []string,
 	}
 }
 
-func MockSnapstateRemoveMany(mock func(*state.State, []string) ([]string, []*state.TaskSet, error)) (restore func()) {
+func MockSnapstateApplyPendingRefreshes(mock func(context.Context, *state.State, []string, int) ([]string, []*state.TaskSet, error)) (restore func()) {
+	oldSnapstateApplyPendingRefreshes := snapstateApplyPendingRefreshes
+	snapstateApplyPendingRefreshes = mock
+	return func() {
+		snapstateApplyPendingRefreshes = oldSnapstateApplyPendingRefreshes
+	}
+}
+
+func MockSnapstateRemoveMany(mock func(*state.State, []string, *snapstate.RemoveFlags) ([]string, []*state.TaskSet, error)) (restore func()) {
 	oldSnapstateRemoveMany := snapstateRemoveMany
 	snapstateRemoveMany = mock
 	return func() {
@@ -194,11 +226,12 @@ func MockSnapstateRemoveMany(mock func(*state.State, []string) ([]string, []*sta
 }
 
 type (
-	RespJSON        = respJSON
-	FileResponse    = fileResponse
-	APIError        = apiError
-	ErrorResult     = errorResult
-	SnapInstruction = snapInstruction
+	RespJSON          = respJSON
+	FileResponse      = fileResponse
+	APIError          = apiError
+	ErrorResult       = errorResult
+	SnapInstruction   = snapInstruction
+	RegistrySetResult = registrySetResult
 )
 
 func (inst *snapInstruction) Dispatch() snapActionFunc {
@@ -213,6 +246,10 @@ func (inst *snapInstruction) SetUserID(userID int) {
 	inst.userID = userID
 }
 
+func (inst *snapInstruction) APIData() map[string]interface{} {
+	return inst.apiData
+}
+
 func (inst *snapInstruction) ModeFlags() (snapstate.Flags, error) {
 	return inst.modeFlags()
 }
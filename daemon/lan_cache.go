@@ -0,0 +1,128 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// lanCacheListenAddress returns the address the download cache is served
+// on to the other snapd instances of the local network, as set with
+// proxy.lan-cache-listen, if any. The state must be locked.
+func lanCacheListenAddress(st *state.State) string {
+	tr := config.NewTransaction(st)
+
+	var addr string
+	if err := tr.Get("core", "proxy.lan-cache-listen", &addr); err != nil {
+		return ""
+	}
+	return addr
+}
+
+// lanCacheHandler serves the snaps kept in the download cache, addressed
+// by their hex encoded sha3-384 as the store does in proxy.lan-peers
+// downloads. Only snaps whose snap-revision assertion is known are served,
+// the peers check the hash of what they get anyway.
+type lanCacheHandler struct {
+	d *Daemon
+}
+
+func (h lanCacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		MethodNotAllowed("method %q not allowed", r.Method).ServeHTTP(w, r)
+		return
+	}
+
+	name := muxVars(r)["sha3"]
+	// this also ensures the name is not a path
+	digest, err := cachedSnapDigest(name)
+	if err != nil {
+		BadRequest("invalid sha3-384 %q", name).ServeHTTP(w, r)
+		return
+	}
+
+	st := h.d.state
+	st.Lock()
+	_, err = assertstate.DB(st).Find(asserts.SnapRevisionType, map[string]string{
+		"snap-sha3-384": digest,
+	})
+	st.Unlock()
+	if err != nil {
+		NotFound("cannot find snap with sha3-384 %q", name).ServeHTTP(w, r)
+		return
+	}
+
+	http.ServeFile(w, r, filepath.Join(dirs.SnapDownloadCacheDir, name))
+}
+
+func lanCacheRouter(d *Daemon) *mux.Router {
+	router := mux.NewRouter()
+	router.Handle("/v2/lan-cache/{sha3}", lanCacheHandler{d: d})
+	router.NotFoundHandler = NotFound("not found")
+	return router
+}
+
+// startLANCache starts serving the download cache to the local network if
+// enabled with proxy.lan-cache-listen. Changes to the option are applied
+// when snapd is restarted. It is not served while snapd waits for socket
+// activation.
+func (d *Daemon) startLANCache() {
+	d.state.Lock()
+	addr := lanCacheListenAddress(d.state)
+	d.state.Unlock()
+	if addr == "" {
+		return
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Noticef("Cannot serve the download cache on %q: %v", addr, err)
+		return
+	}
+	logger.Noticef("Serving the download cache on %s.", listener.Addr())
+	d.lanCacheListener = listener
+	d.lanCacheServe = &http.Server{Handler: logit(lanCacheRouter(d))}
+	d.tomb.Go(func() error {
+		if err := d.lanCacheServe.Serve(listener); err != http.ErrServerClosed && d.tomb.Err() == tomb.ErrStillAlive {
+			return err
+		}
+		return nil
+	})
+}
+
+// stopLANCache stops serving the download cache, without waiting for the
+// transfers in progress.
+func (d *Daemon) stopLANCache() {
+	if d.lanCacheServe != nil {
+		d.lanCacheServe.Close()
+	}
+}
@@ -0,0 +1,123 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
+)
+
+var _ = check.Suite(&lanCacheSuite{})
+
+type lanCacheSuite struct {
+	apiBaseSuite
+}
+
+// mockCachedSnap installs a snap with its assertions and keeps its file in
+// the download cache, it returns the file content and its cache name.
+func (s *lanCacheSuite) mockCachedSnap(c *check.C, d *daemon.Daemon) (content []byte, cacheName string) {
+	info := s.mkInstalledInState(c, d, "bar", "bar-dev", "v1", snap.R(1), true, "")
+	content, err := ioutil.ReadFile(info.MountFile())
+	c.Assert(err, check.IsNil)
+	_, cacheName = digestOf(c, content)
+	c.Assert(os.MkdirAll(dirs.SnapDownloadCacheDir, 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dirs.SnapDownloadCacheDir, cacheName), content, 0644), check.IsNil)
+	return content, cacheName
+}
+
+func (s *lanCacheSuite) get(c *check.C, d *daemon.Daemon, method, path string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, path, nil)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	daemon.LANCacheHandler(d).ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *lanCacheSuite) TestServeCachedSnap(c *check.C) {
+	d := s.daemon(c)
+	content, cacheName := s.mockCachedSnap(c, d)
+
+	rec := s.get(c, d, "GET", "/v2/lan-cache/"+cacheName)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.Body.Bytes(), check.DeepEquals, content)
+}
+
+func (s *lanCacheSuite) TestServeUnknownSnap(c *check.C) {
+	d := s.daemon(c)
+
+	// a file in the cache without a known snap-revision is not served
+	content := []byte("not a snap")
+	_, cacheName := digestOf(c, content)
+	c.Assert(os.MkdirAll(dirs.SnapDownloadCacheDir, 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dirs.SnapDownloadCacheDir, cacheName), content, 0644), check.IsNil)
+
+	rec := s.get(c, d, "GET", "/v2/lan-cache/"+cacheName)
+	c.Check(rec.Code, check.Equals, 404)
+}
+
+func (s *lanCacheSuite) TestServeInvalid(c *check.C) {
+	d := s.daemon(c)
+	_, cacheName := s.mockCachedSnap(c, d)
+
+	c.Check(s.get(c, d, "GET", "/v2/lan-cache/state.json").Code, check.Equals, 400)
+	c.Check(s.get(c, d, "GET", "/v2/lan-cache/abcd").Code, check.Equals, 400)
+	c.Check(s.get(c, d, "POST", "/v2/lan-cache/"+cacheName).Code, check.Equals, 405)
+	// nothing else is served
+	c.Check(s.get(c, d, "GET", "/v2/snaps").Code, check.Equals, 404)
+}
+
+func (s *lanCacheSuite) TestStoreDownloadsFromPeer(c *check.C) {
+	d := s.daemon(c)
+	content, cacheName := s.mockCachedSnap(c, d)
+
+	peer := httptest.NewServer(daemon.LANCacheHandler(d))
+	defer peer.Close()
+	// the store is never reached
+	storeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Errorf("unexpected request to the store: %v", r.URL)
+		w.WriteHeader(500)
+	}))
+	defer storeServer.Close()
+
+	downloadInfo := &snap.DownloadInfo{
+		AnonDownloadURL: storeServer.URL + "/download/bar",
+		Size:            int64(len(content)),
+		Sha3_384:        cacheName,
+	}
+	sto := store.New(&store.Config{}, nil)
+	target := filepath.Join(c.MkDir(), "bar_2.snap")
+	err := sto.Download(context.TODO(), "bar", target, downloadInfo, nil, nil, &store.DownloadOptions{
+		LANPeers: []string{peer.URL},
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(target, testutil.FileEquals, content)
+}
@@ -41,6 +41,9 @@ type aboutSnap struct {
 	info   *snap.Info
 	snapst *snapstate.SnapState
 	health *client.SnapHealth
+
+	pendingRefresh *client.SnapPendingRefresh
+	refreshInhibit *client.SnapRefreshInhibit
 }
 
 // localSnapInfo returns the information about the current snap for the given name plus the SnapState with the active flag and other snap revisions.
@@ -72,10 +75,23 @@ func localSnapInfo(st *state.State, name string) (aboutSnap, error) {
 		return aboutSnap{}, err
 	}
 
+	pending, err := snapstate.PendingRefreshInfo(st, name)
+	if err != nil {
+		return aboutSnap{}, err
+	}
+
+	inhibit, err := refreshInhibit(st, name, &snapst)
+	if err != nil {
+		return aboutSnap{}, err
+	}
+
 	return aboutSnap{
 		info:   info,
 		snapst: &snapst,
 		health: clientHealthFromHealthstate(health),
+
+		pendingRefresh: clientPendingRefreshFromSnapstate(pending),
+		refreshInhibit: inhibit,
 	}, nil
 }
 
@@ -101,9 +117,12 @@ func allLocalSnapInfos(st *state.State, all bool, wanted map[string]bool) ([]abo
 			continue
 		}
 		health := clientHealthFromHealthstate(healths[name])
+		inhibit, err := refreshInhibit(st, name, snapst)
+		if err != nil {
+			return nil, err
+		}
 		var aboutThis []aboutSnap
 		var info *snap.Info
-		var err error
 		if all {
 			for _, seq := range snapst.Sequence {
 				info, err = snap.ReadInfo(name, seq)
@@ -122,13 +141,13 @@ func allLocalSnapInfos(st *state.State, all bool, wanted map[string]bool) ([]abo
 				if err != nil && firstErr == nil {
 					firstErr = err
 				}
-				aboutThis = append(aboutThis, aboutSnap{info, snapst, health})
+				aboutThis = append(aboutThis, aboutSnap{info: info, snapst: snapst, health: health, refreshInhibit: inhibit})
 			}
 		} else {
 			info, err = snapst.CurrentInfo()
 			if err == nil {
 				info.Publisher, err = publisherAccount(st, info.SnapID)
-				aboutThis = append(aboutThis, aboutSnap{info, snapst, health})
+				aboutThis = append(aboutThis, aboutSnap{info: info, snapst: snapst, health: health, refreshInhibit: inhibit})
 			}
 		}
 
@@ -175,6 +194,29 @@ func clientHealthFromHealthstate(h *healthstate.HealthState) *client.SnapHealth
 	}
 }
 
+func clientPendingRefreshFromSnapstate(p *snapstate.PendingRefresh) *client.SnapPendingRefresh {
+	if p == nil {
+		return nil
+	}
+	return &client.SnapPendingRefresh{
+		Revision:   p.Revision,
+		Version:    p.Version,
+		Channel:    p.Channel,
+		StagedTime: p.StagedTime,
+	}
+}
+
+func refreshInhibit(st *state.State, name string, snapst *snapstate.SnapState) (*client.SnapRefreshInhibit, error) {
+	inhibition, err := snapstate.RefreshInhibitionInfo(st, name, snapst)
+	if err != nil || inhibition == nil {
+		return nil, err
+	}
+	return &client.SnapRefreshInhibit{
+		ProceedTime: inhibition.ProceedTime,
+		Action:      string(inhibition.Action),
+	}, nil
+}
+
 func mapLocal(about aboutSnap, sd clientutil.StatusDecorator) *client.Snap {
 	localSnap, snapst := about.info, about.snapst
 	result, err := clientutil.ClientSnapFromSnapInfo(localSnap, sd)
@@ -207,6 +249,8 @@ func mapLocal(about aboutSnap, sd clientutil.StatusDecorator) *client.Snap {
 		result.MountedFrom, _ = os.Readlink(result.MountedFrom)
 	}
 	result.Health = about.health
+	result.PendingRefresh = about.pendingRefresh
+	result.RefreshInhibit = about.refreshInhibit
 
 	return result
 }
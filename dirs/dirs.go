@@ -46,6 +46,7 @@ var (
 	SnapConfineAppArmorDir    string
 	SnapSeccompBase           string
 	SnapSeccompDir            string
+	SnapLandlockDir           string
 	SnapMountPolicyDir        string
 	SnapUdevRulesDir          string
 	SnapKModModulesDir        string
@@ -71,6 +72,10 @@ var (
 	SnapAssertsSpoolDir   string
 	SnapSeqDir            string
 
+	SnapInterfacesRequestsStateDir string
+
+	SnapAPIAuditLogFile string
+
 	SnapStateFile     string
 	SnapSystemKeyFile string
 
@@ -92,6 +97,7 @@ var (
 	SnapServicesDir     string
 	SnapUserServicesDir string
 	SnapSystemdConfDir  string
+	SnapSystemdDir      string
 	SnapDesktopFilesDir string
 	SnapDesktopIconsDir string
 
@@ -99,6 +105,7 @@ var (
 	SnapDBusSystemPolicyDir    string
 	SnapDBusSessionServicesDir string
 	SnapDBusSystemServicesDir  string
+	SnapDBusProxyPolicyDir     string
 
 	SnapModeenvFile   string
 	SnapBootAssetsDir string
@@ -322,6 +329,7 @@ func SetRootDir(rootdir string) {
 	SnapDownloadCacheDir = filepath.Join(rootdir, snappyDir, "cache")
 	SnapSeccompBase = filepath.Join(rootdir, snappyDir, "seccomp")
 	SnapSeccompDir = filepath.Join(SnapSeccompBase, "bpf")
+	SnapLandlockDir = filepath.Join(rootdir, snappyDir, "landlock")
 	SnapMountPolicyDir = filepath.Join(rootdir, snappyDir, "mount")
 	SnapMetaDir = filepath.Join(rootdir, snappyDir, "meta")
 	SnapdMaintenanceFile = filepath.Join(rootdir, snappyDir, "maintenance.json")
@@ -347,6 +355,8 @@ func SetRootDir(rootdir string) {
 	SnapCookieDir = filepath.Join(rootdir, snappyDir, "cookie")
 	SnapAssertsSpoolDir = filepath.Join(rootdir, "run/snapd/auto-import")
 	SnapSeqDir = filepath.Join(rootdir, snappyDir, "sequence")
+	SnapInterfacesRequestsStateDir = filepath.Join(rootdir, snappyDir, "interfaces-requests")
+	SnapAPIAuditLogFile = filepath.Join(rootdir, snappyDir, "api-audit.log")
 
 	SnapStateFile = SnapStateFileUnder(rootdir)
 	SnapSystemKeyFile = filepath.Join(rootdir, snappyDir, "system-key")
@@ -378,6 +388,7 @@ func SetRootDir(rootdir string) {
 	SnapServicesDir = filepath.Join(rootdir, "/etc/systemd/system")
 	SnapUserServicesDir = filepath.Join(rootdir, "/etc/systemd/user")
 	SnapSystemdConfDir = SnapSystemdConfDirUnder(rootdir)
+	SnapSystemdDir = filepath.Join(rootdir, "/etc/systemd")
 
 	SnapDBusSystemPolicyDir = filepath.Join(rootdir, "/etc/dbus-1/system.d")
 	SnapDBusSessionPolicyDir = filepath.Join(rootdir, "/etc/dbus-1/session.d")
@@ -385,6 +396,7 @@ func SetRootDir(rootdir string) {
 	// '/usr/share/dbus-1' hierarchy.
 	SnapDBusSessionServicesDir = filepath.Join(rootdir, snappyDir, "dbus-1", "services")
 	SnapDBusSystemServicesDir = filepath.Join(rootdir, snappyDir, "dbus-1", "system-services")
+	SnapDBusProxyPolicyDir = filepath.Join(rootdir, snappyDir, "dbus-proxy")
 
 	CloudInstanceDataFile = filepath.Join(rootdir, "/run/cloud-init/instance-data.json")
 
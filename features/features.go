@@ -65,6 +65,15 @@ const (
 	// QuotaGroups enable creating resource quota groups for snaps via the rest API and cli.
 	QuotaGroups
 
+	// AppArmorPrompting enables interactive prompting for file access by snaps.
+	AppArmorPrompting
+
+	// DBusProxy enables mediating the D-Bus access of snaps through a per-snap xdg-dbus-proxy.
+	DBusProxy
+
+	// CheckRefreshMetadata enables cross-checking the store metadata of refresh candidates against their assertions.
+	CheckRefreshMetadata
+
 	// lastFeature is the final known feature, it is only used for testing.
 	lastFeature
 )
@@ -103,6 +112,12 @@ var featureNames = map[SnapdFeature]string{
 	GateAutoRefreshHook: "gate-auto-refresh-hook",
 
 	QuotaGroups: "quota-groups",
+
+	AppArmorPrompting: "apparmor-prompting",
+
+	DBusProxy: "dbus-proxy",
+
+	CheckRefreshMetadata: "check-refresh-metadata",
 }
 
 // featuresEnabledWhenUnset contains a set of features that are enabled when not explicitly configured.
@@ -122,6 +137,8 @@ var featuresExported = map[SnapdFeature]bool{
 	ClassicPreservesXdgRuntimeDir: true,
 	RobustMountNamespaceUpdates:   true,
 	HiddenSnapFolder:              true,
+
+	DBusProxy: true,
 }
 
 // String returns the name of a snapd feature.
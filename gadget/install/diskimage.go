@@ -0,0 +1,166 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/internal"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/strutil"
+)
+
+const (
+	// diskImageSectorSize is the sector size of assembled disk images.
+	diskImageSectorSize = quantity.Size(512)
+	// diskImageBackupGPTSize is the space reserved at the end of a disk
+	// image for the backup GPT header and partition entries.
+	diskImageBackupGPTSize = 33 * diskImageSectorSize
+)
+
+// installCreatedRoles lists the roles of the structures that are created
+// on the device at install time and are thus left out of disk images.
+var installCreatedRoles = []string{gadget.SystemBoot, gadget.SystemSave, gadget.SystemData}
+
+var mkfsWithContent = internal.MkfsWithContent
+
+// WriteDiskImage assembles a raw disk image at imagePath from the laid out
+// volume. The image carries the partition table and the structures that
+// exist before install, that is, everything but the system-boot,
+// system-save and system-data structures which are created on first
+// boot. Raw content is taken from the gadget root directory of the volume,
+// while filesystem structures are populated from the directories in
+// fsContentDirs, indexed by the structure index in the gadget.
+func WriteDiskImage(imagePath string, lv *gadget.LaidOutVolume, fsContentDirs map[int]string) error {
+	var included []gadget.LaidOutStructure
+	var size quantity.Size
+	for _, ps := range lv.LaidOutStructure {
+		if strutil.ListContains(installCreatedRoles, ps.Role) {
+			continue
+		}
+		included = append(included, ps)
+		if end := quantity.Size(ps.StartOffset) + ps.Size; end > size {
+			size = end
+		}
+	}
+	if len(included) == 0 {
+		return fmt.Errorf("cannot assemble disk image: volume has no structures to write")
+	}
+	schema := lv.Schema
+	if schema == "" {
+		schema = "gpt"
+	}
+	if schema == "gpt" {
+		size += diskImageBackupGPTSize
+	}
+
+	f, err := os.OpenFile(imagePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot create disk image: %v", err)
+	}
+	defer f.Close()
+	if err := f.Truncate(int64(size)); err != nil {
+		return fmt.Errorf("cannot size disk image: %v", err)
+	}
+
+	// write the partition table first, so that raw structures overlapping
+	// the partition table area (eg. the MBR) take precedence
+	sfdiskInput := buildDiskImagePartitionList(schema, included)
+	cmd := exec.Command("sfdisk", "--no-reread", imagePath)
+	cmd.Stdin = sfdiskInput
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return osutil.OutputErr(output, err)
+	}
+
+	for i := range included {
+		ps := &included[i]
+		if !ps.HasFilesystem() {
+			rw, err := gadget.NewRawStructureWriter(lv.RootDir, ps)
+			if err != nil {
+				return err
+			}
+			if err := rw.Write(f); err != nil {
+				return fmt.Errorf("cannot write structure %v: %v", ps, err)
+			}
+			continue
+		}
+		contentDir, ok := fsContentDirs[ps.Index]
+		if !ok {
+			return fmt.Errorf("cannot write structure %v: no content directory", ps)
+		}
+		if err := writeFilesystemImage(f, imagePath, ps, contentDir); err != nil {
+			return fmt.Errorf("cannot write structure %v: %v", ps, err)
+		}
+	}
+
+	return f.Sync()
+}
+
+// buildDiskImagePartitionList returns the sfdisk input describing the
+// partitions of the given structures in a disk image.
+func buildDiskImagePartitionList(schema string, structures []gadget.LaidOutStructure) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "label: %s\n", schema)
+	for _, ps := range structures {
+		if !ps.IsPartition() {
+			continue
+		}
+		fmt.Fprintf(buf, "start=%12d, size=%12d, type=%s", ps.StartOffset/quantity.Offset(diskImageSectorSize),
+			ps.Size/diskImageSectorSize, partitionType(schema, ps.Type))
+		if schema == "gpt" {
+			fmt.Fprintf(buf, ", name=%q", ps.Name)
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+	return buf
+}
+
+// writeFilesystemImage creates the filesystem of the structure with the
+// given content in a scratch file and copies it into the disk image.
+func writeFilesystemImage(out io.WriteSeeker, imagePath string, ps *gadget.LaidOutStructure, contentDir string) error {
+	fsImage := fmt.Sprintf("%s.part%d", imagePath, ps.Index)
+	defer os.Remove(fsImage)
+
+	fsf, err := os.Create(fsImage)
+	if err != nil {
+		return err
+	}
+	defer fsf.Close()
+	if err := fsf.Truncate(int64(ps.Size)); err != nil {
+		return err
+	}
+	if err := mkfsWithContent(ps.Filesystem, fsImage, ps.Label, contentDir, ps.Size, diskImageSectorSize); err != nil {
+		return err
+	}
+
+	if _, err := out.Seek(int64(ps.StartOffset), io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, io.LimitReader(fsf, int64(ps.Size))); err != nil {
+		return err
+	}
+	return nil
+}
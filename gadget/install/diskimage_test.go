@@ -0,0 +1,153 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package install_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/gadget/install"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type diskImageTestSuite struct {
+	testutil.BaseTest
+
+	dir        string
+	gadgetRoot string
+}
+
+var _ = Suite(&diskImageTestSuite{})
+
+func (s *diskImageTestSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	s.dir = c.MkDir()
+	s.gadgetRoot = filepath.Join(c.MkDir(), "gadget")
+}
+
+const diskImageGadgetContent = `volumes:
+  pc:
+    bootloader: grub
+    structure:
+      - name: mbr
+        type: mbr
+        size: 440
+        content:
+          - image: pc-boot.img
+      - name: BIOS Boot
+        type: DA,21686148-6449-6E6F-744E-656564454649
+        size: 1M
+        offset: 1M
+        content:
+          - image: pc-core.img
+      - name: ubuntu-seed
+        role: system-seed
+        filesystem: vfat
+        type: EF,C12A7328-F81F-11D2-BA4B-00A0C93EC93B
+        size: 2M
+      - name: ubuntu-data
+        role: system-data
+        filesystem: ext4
+        type: 83,0FC63DAF-8483-4772-8E79-3D69D8477DE4
+        size: 4M
+`
+
+func (s *diskImageTestSuite) TestWriteDiskImage(c *C) {
+	sfdiskInput := filepath.Join(s.dir, "sfdisk.input")
+	cmdSfdisk := testutil.MockCommand(c, "sfdisk", fmt.Sprintf("cat > %s", sfdiskInput))
+	defer cmdSfdisk.Restore()
+
+	seedContent := filepath.Join(s.dir, "seed-content")
+	var mkfsCalls []string
+	restore := install.MockMkfsWithContent(func(typ, img, label, contentRootDir string, deviceSize, sectorSize quantity.Size) error {
+		mkfsCalls = append(mkfsCalls, fmt.Sprintf("%s %s %s %v %v", typ, label, contentRootDir, deviceSize, sectorSize))
+		f, err := os.OpenFile(img, os.O_WRONLY, 0644)
+		c.Assert(err, IsNil)
+		defer f.Close()
+		_, err = f.WriteString("seed filesystem")
+		return err
+	})
+	defer restore()
+
+	err := makeMockGadget(s.gadgetRoot, diskImageGadgetContent)
+	c.Assert(err, IsNil)
+	pv, err := mustLayOutVolumeFromGadget(c, s.gadgetRoot, "", uc20Mod)
+	c.Assert(err, IsNil)
+
+	imagePath := filepath.Join(s.dir, "pc.img")
+	err = install.WriteDiskImage(imagePath, pv, map[int]string{2: seedContent})
+	c.Assert(err, IsNil)
+
+	c.Check(cmdSfdisk.Calls(), DeepEquals, [][]string{
+		{"sfdisk", "--no-reread", imagePath},
+	})
+	c.Check(sfdiskInput, testutil.FileEquals, `label: gpt
+start=        2048, size=        2048, type=21686148-6449-6E6F-744E-656564454649, name="BIOS Boot"
+start=        4096, size=        4096, type=C12A7328-F81F-11D2-BA4B-00A0C93EC93B, name="ubuntu-seed"
+`)
+	c.Check(mkfsCalls, DeepEquals, []string{
+		fmt.Sprintf("vfat ubuntu-seed %s 2097152 512", seedContent),
+	})
+
+	img, err := ioutil.ReadFile(imagePath)
+	c.Assert(err, IsNil)
+	// system-data is left out, with room for the backup GPT at the end
+	c.Assert(img, HasLen, 4*1024*1024+33*512)
+	c.Check(string(img[:len("pc-boot.img content")]), Equals, "pc-boot.img content")
+	c.Check(string(img[1024*1024:1024*1024+len("pc-core.img content")]), Equals, "pc-core.img content")
+	c.Check(string(img[2*1024*1024:2*1024*1024+len("seed filesystem")]), Equals, "seed filesystem")
+
+	// scratch filesystem images are cleaned up
+	matches, err := filepath.Glob(imagePath + ".part*")
+	c.Assert(err, IsNil)
+	c.Check(matches, HasLen, 0)
+}
+
+func (s *diskImageTestSuite) TestWriteDiskImageNoContentDir(c *C) {
+	cmdSfdisk := testutil.MockCommand(c, "sfdisk", "")
+	defer cmdSfdisk.Restore()
+
+	err := makeMockGadget(s.gadgetRoot, diskImageGadgetContent)
+	c.Assert(err, IsNil)
+	pv, err := mustLayOutVolumeFromGadget(c, s.gadgetRoot, "", uc20Mod)
+	c.Assert(err, IsNil)
+
+	err = install.WriteDiskImage(filepath.Join(s.dir, "pc.img"), pv, nil)
+	c.Assert(err, ErrorMatches, `cannot write structure #2 \("ubuntu-seed"\): no content directory`)
+}
+
+func (s *diskImageTestSuite) TestWriteDiskImageSfdiskError(c *C) {
+	cmdSfdisk := testutil.MockCommand(c, "sfdisk", "echo 'sfdisk failed'; exit 1")
+	defer cmdSfdisk.Restore()
+
+	err := makeMockGadget(s.gadgetRoot, diskImageGadgetContent)
+	c.Assert(err, IsNil)
+	pv, err := mustLayOutVolumeFromGadget(c, s.gadgetRoot, "", uc20Mod)
+	c.Assert(err, IsNil)
+
+	err = install.WriteDiskImage(filepath.Join(s.dir, "pc.img"), pv, nil)
+	c.Assert(err, ErrorMatches, "sfdisk failed")
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/quantity"
 )
 
 var (
@@ -70,3 +71,11 @@ func MockEnsureNodesExist(f func(dss []gadget.OnDiskStructure, timeout time.Dura
 		ensureNodesExist = old
 	}
 }
+
+func MockMkfsWithContent(f func(typ, img, label, contentRootDir string, deviceSize, sectorSize quantity.Size) error) (restore func()) {
+	old := mkfsWithContent
+	mkfsWithContent = f
+	return func() {
+		mkfsWithContent = old
+	}
+}
@@ -119,7 +119,14 @@ func Run(model gadget.Model, gadgetRoot, kernelRoot, device string, options Opti
 		}
 	}
 
+	progress := func(stage Stage) {
+		if options.Progress != nil {
+			options.Progress(stage)
+		}
+	}
+
 	var created []gadget.OnDiskStructure
+	progress(StagePartitioning)
 	timings.Run(perfTimings, "create-partitions", "Create partitions", func(timings.Measurer) {
 		created, err = createMissingPartitions(diskLayout, lv)
 	})
@@ -155,6 +162,7 @@ func Run(model gadget.Model, gadgetRoot, kernelRoot, device string, options Opti
 		logger.Noticef("created new partition %v for structure %v (size %v) %s",
 			part.Node, part, part.Size.IECString(), roleFmt)
 		if options.Encrypt && roleNeedsEncryption(part.Role) {
+			progress(StageEncryptionSetup)
 			var keys *EncryptionKeySet
 			timings.Run(perfTimings, fmt.Sprintf("make-key-set[%s]", roleOrLabelOrName(part)), fmt.Sprintf("Create encryption key set for %s", roleOrLabelOrName(part)), func(timings.Measurer) {
 				keys, err = makeKeySet()
@@ -192,6 +200,7 @@ func Run(model gadget.Model, gadgetRoot, kernelRoot, device string, options Opti
 		// matches what is on the disk, but sometimes there may not be a sector
 		// size specified in the gadget.yaml, but we will always have the sector
 		// size from the physical disk device
+		progress(StageCopying)
 		timings.Run(perfTimings, fmt.Sprintf("make-filesystem[%s]", roleOrLabelOrName(part)), fmt.Sprintf("Create filesystem for %s", part.Node), func(timings.Measurer) {
 			err = makeFilesystem(&part, diskLayout.SectorSize)
 		})
@@ -28,8 +28,23 @@ type Options struct {
 	Mount bool
 	// Encrypt the data partition
 	Encrypt bool
+	// Progress, if set, is called when entering a new stage
+	Progress func(stage Stage)
 }
 
+// Stage is a stage of bootstrapping the partitions of a device.
+type Stage string
+
+const (
+	// StagePartitioning is the stage creating the partitions.
+	StagePartitioning Stage = "partitioning"
+	// StageEncryptionSetup is the stage setting up encrypted devices.
+	StageEncryptionSetup Stage = "encryption-setup"
+	// StageCopying is the stage creating filesystems and writing
+	// their content.
+	StageCopying Stage = "copying"
+)
+
 // EncryptionKeySet is a set of encryption keys.
 type EncryptionKeySet struct {
 	Key         secboot.EncryptionKey
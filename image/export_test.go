@@ -34,6 +34,7 @@ var (
 	MakeLabel            = makeLabel
 	SetupSeed            = setupSeed
 	InstallCloudConfig   = installCloudConfig
+	ClampModTimes        = clampModTimes
 )
 
 func (tsto *ToolingStore) User() *auth.UserState {
@@ -62,3 +63,13 @@ func MockWriteResolvedContent(f func(prepareImageDir string, info *gadget.Info,
 		writeResolvedContent = oldWriteResolvedContent
 	}
 }
+
+var WriteDiskImage = writeDiskImage
+
+func MockInstallWriteDiskImage(f func(imagePath string, lv *gadget.LaidOutVolume, fsContentDirs map[int]string) error) (restore func()) {
+	old := installWriteDiskImage
+	installWriteDiskImage = f
+	return func() {
+		installWriteDiskImage = old
+	}
+}
@@ -58,6 +58,7 @@ type Store interface {
 	Download(ctx context.Context, name, targetFn string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, user *auth.UserState, dlOpts *store.DownloadOptions) error
 
 	Assertion(assertType *asserts.AssertionType, primaryKey []string, user *auth.UserState) (asserts.Assertion, error)
+	SeqFormingAssertion(assertType *asserts.AssertionType, sequenceKey []string, sequence int, user *auth.UserState) (asserts.Assertion, error)
 }
 
 // ToolingStore wraps access to the store for tools.
@@ -370,6 +371,27 @@ func (tsto *ToolingStore) AssertionFetcher(db *asserts.Database, save func(asser
 	return asserts.NewFetcher(db, retrieve, save2)
 }
 
+// LatestValidationSetSequences returns the latest sequences of the
+// validation sets declared by the model without a sequence, keyed by
+// their sequence keys (account-id/name).
+func (tsto *ToolingStore) LatestValidationSetSequences(model *asserts.Model) (map[string]int, error) {
+	var seqs map[string]int
+	for _, mvs := range model.ValidationSets() {
+		if mvs.Sequence != 0 {
+			continue
+		}
+		a, err := tsto.sto.SeqFormingAssertion(asserts.ValidationSetType, []string{model.Series(), mvs.AccountID, mvs.Name}, 0, tsto.user)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find latest sequence of validation set %q: %v", mvs.SequenceKey(), err)
+		}
+		if seqs == nil {
+			seqs = make(map[string]int)
+		}
+		seqs[mvs.SequenceKey()] = a.(*asserts.ValidationSet).Sequence()
+	}
+	return seqs, nil
+}
+
 // FetchAndCheckSnapAssertions fetches and cross checks the snap assertions matching the given snap file using the provided asserts.Fetcher and assertion database.
 func FetchAndCheckSnapAssertions(snapPath string, info *snap.Info, f asserts.Fetcher, db asserts.RODatabase) (*asserts.SnapDeclaration, error) {
 	sha3_384, size, err := asserts.SnapFileSHA3_384(snapPath)
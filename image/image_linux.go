@@ -24,6 +24,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -34,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget"
+	"github.com/snapcore/snapd/gadget/install"
 	"github.com/snapcore/snapd/osutil"
 
 	// to set sysconfig.ApplyFilesystemOnlyDefaults hook
@@ -131,7 +133,114 @@ func Prepare(opts *Options) error {
 		return err
 	}
 
-	return setupSeed(tsto, model, opts)
+	if opts.DiskImageFormat != "" {
+		if err := validateDiskImageFormat(opts.DiskImageFormat, model); err != nil {
+			return err
+		}
+	}
+
+	if err := setupSeed(tsto, model, opts); err != nil {
+		return err
+	}
+
+	if !opts.SourceDateEpoch.IsZero() {
+		if err := clampModTimes(opts.PrepareDir, opts.SourceDateEpoch); err != nil {
+			return err
+		}
+	}
+
+	if opts.DiskImageFormat != "" {
+		return writeDiskImage(model, opts)
+	}
+	return nil
+}
+
+func validateDiskImageFormat(format string, model *asserts.Model) error {
+	if format != "raw" && format != "qcow2" {
+		return fmt.Errorf("cannot assemble disk image: unsupported format %q", format)
+	}
+	if model.Grade() == asserts.ModelGradeUnset {
+		return fmt.Errorf("cannot assemble disk image: only supported for UC20 models")
+	}
+	return nil
+}
+
+var installWriteDiskImage = install.WriteDiskImage
+
+// writeDiskImage assembles a disk image of the gadget volume carrying the
+// system-seed out of the prepared seed and the resolved content of the
+// other structures, converting it to qcow2 if requested. The image is
+// written to <PrepareDir>/<volume-name>.{img,qcow2}.
+func writeDiskImage(model *asserts.Model, opts *Options) error {
+	fullPrepareDir, err := filepath.Abs(opts.PrepareDir)
+	if err != nil {
+		return err
+	}
+	gadgetUnpackDir := filepath.Join(fullPrepareDir, "gadget")
+	kernelUnpackDir := filepath.Join(fullPrepareDir, "kernel")
+
+	gadgetInfo, err := gadget.ReadInfo(gadgetUnpackDir, model)
+	if err != nil {
+		return err
+	}
+	for volName, vol := range gadgetInfo.Volumes {
+		pvol, err := gadget.LayoutVolume(gadgetUnpackDir, kernelUnpackDir, vol, gadget.DefaultConstraints)
+		if err != nil {
+			return err
+		}
+		hasSeed := false
+		fsContentDirs := make(map[int]string)
+		for i, ps := range pvol.LaidOutStructure {
+			if !ps.HasFilesystem() {
+				continue
+			}
+			// same layout as used by writeResolvedContent
+			dir := filepath.Join(fullPrepareDir, "resolved-content", volName, fmt.Sprintf("part%d", i))
+			if ps.Role == gadget.SystemSeed {
+				hasSeed = true
+				dir = filepath.Join(fullPrepareDir, "system-seed")
+			}
+			fsContentDirs[ps.Index] = dir
+		}
+		if !hasSeed {
+			continue
+		}
+
+		rawImage := filepath.Join(fullPrepareDir, volName+".img")
+		if err := installWriteDiskImage(rawImage, pvol, fsContentDirs); err != nil {
+			return fmt.Errorf("cannot assemble disk image for volume %q: %v", volName, err)
+		}
+		if opts.DiskImageFormat != "qcow2" {
+			return nil
+		}
+		qcow2Image := filepath.Join(fullPrepareDir, volName+".qcow2")
+		cmd := exec.Command("qemu-img", "convert", "-f", "raw", "-O", "qcow2", rawImage, qcow2Image)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("cannot convert disk image to qcow2: %v", osutil.OutputErr(output, err))
+		}
+		return os.Remove(rawImage)
+	}
+	return fmt.Errorf("cannot assemble disk image: gadget has no volume with a system-seed structure")
+}
+
+// clampModTimes sets the modification time of all the files and
+// directories under dir that are more recent than t to t. Symlinks are
+// left alone.
+func clampModTimes(dir string, t time.Time) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if info.ModTime().After(t) {
+			if err := os.Chtimes(path, t, t); err != nil {
+				return fmt.Errorf("cannot set modification time of %q: %v", path, err)
+			}
+		}
+		return nil
+	})
 }
 
 // these are postponed, not implemented or abandoned, not finalized,
@@ -264,7 +373,11 @@ func setupSeed(tsto *ToolingStore, model *asserts.Model, opts *Options) error {
 	} else {
 		// Core 20, writing for the system-seed partition
 		seedDir = filepath.Join(opts.PrepareDir, "system-seed")
-		label = makeLabel(time.Now())
+		now := opts.SourceDateEpoch
+		if now.IsZero() {
+			now = time.Now()
+		}
+		label = makeLabel(now)
 		bootRootDir = seedDir
 
 		// sanity check target
@@ -283,10 +396,18 @@ func setupSeed(tsto *ToolingStore, model *asserts.Model, opts *Options) error {
 		return err
 	}
 
+	// pin the validation sets of the model that do not specify a
+	// sequence to their latest one
+	valSetSeqs, err := tsto.LatestValidationSetSequences(model)
+	if err != nil {
+		return err
+	}
+
 	wOpts := &seedwriter.Options{
-		SeedDir:        seedDir,
-		Label:          label,
-		DefaultChannel: opts.Channel,
+		SeedDir:                seedDir,
+		Label:                  label,
+		DefaultChannel:         opts.Channel,
+		ValidationSetSequences: valSetSeqs,
 
 		TestSkipCopyUnverifiedModel: osutil.GetenvBool("UBUNTU_IMAGE_SKIP_COPY_UNVERIFIED_MODEL"),
 	}
@@ -177,6 +177,19 @@ func (s *imageSuite) Assertion(assertType *asserts.AssertionType, primaryKey []s
 	return ref.Resolve(s.StoreSigning.Find)
 }
 
+func (s *imageSuite) SeqFormingAssertion(assertType *asserts.AssertionType, sequenceKey []string, sequence int, user *auth.UserState) (asserts.Assertion, error) {
+	headers, err := asserts.HeadersFromSequenceKey(assertType, sequenceKey)
+	if err != nil {
+		return nil, err
+	}
+	if sequence <= 0 {
+		sequence = -1
+	} else {
+		sequence--
+	}
+	return s.StoreSigning.FindSequence(assertType, headers, sequence, -1)
+}
+
 // TODO: use seedtest.SampleSnapYaml for some of these
 const packageGadget = `
 name: pc
@@ -1097,6 +1110,126 @@ func (s *imageSuite) TestSetupSeedWithBase(c *C) {
 	})
 }
 
+func (s *imageSuite) makeValidationSet(c *C, sequence string, snaps ...interface{}) {
+	vs, err := s.Brands.Signing("my-brand").Sign(asserts.ValidationSetType, map[string]interface{}{
+		"type":         "validation-set",
+		"authority-id": "my-brand",
+		"series":       "16",
+		"account-id":   "my-brand",
+		"name":         "base-set",
+		"sequence":     sequence,
+		"snaps":        snaps,
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	err = s.StoreSigning.Add(vs)
+	c.Assert(err, IsNil)
+}
+
+func (s *imageSuite) TestSetupSeedValidationSetsPinned(c *C) {
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	model := s.Brands.Model("my-brand", "my-model", map[string]interface{}{
+		"architecture":   "amd64",
+		"gadget":         "pc18",
+		"kernel":         "pc-kernel",
+		"base":           "core18",
+		"required-snaps": []interface{}{"other-base"},
+		"validation-sets": []interface{}{
+			map[string]interface{}{
+				"name": "base-set",
+				"mode": "enforce",
+			},
+		},
+	})
+
+	rootdir := filepath.Join(c.MkDir(), "image")
+	s.setupSnaps(c, map[string]string{
+		"core18":     "canonical",
+		"pc18":       "canonical",
+		"pc-kernel":  "canonical",
+		"snapd":      "canonical",
+		"other-base": "other",
+	}, "")
+	otherBaseSnap := map[string]interface{}{
+		"name":     "other-base",
+		"id":       s.AssertedSnapID("other-base"),
+		"revision": "18",
+	}
+	s.makeValidationSet(c, "1", otherBaseSnap)
+	s.makeValidationSet(c, "2", otherBaseSnap)
+
+	opts := &image.Options{
+		PrepareDir: filepath.Dir(rootdir),
+	}
+
+	err := image.SetupSeed(s.tsto, model, opts)
+	c.Assert(err, IsNil)
+
+	// the latest validation set was embedded in the seed
+	seeddir := filepath.Join(rootdir, "var/lib/snapd/seed")
+	_, _, roDB := s.loadSeed(c, seeddir)
+	a, err := roDB.Find(asserts.ValidationSetType, map[string]string{
+		"series":     "16",
+		"account-id": "my-brand",
+		"name":       "base-set",
+		"sequence":   "2",
+	})
+	c.Assert(err, IsNil)
+	c.Check(a.(*asserts.ValidationSet).Sequence(), Equals, 2)
+	_, err = roDB.Find(asserts.ValidationSetType, map[string]string{
+		"series":     "16",
+		"account-id": "my-brand",
+		"name":       "base-set",
+		"sequence":   "1",
+	})
+	c.Check(asserts.IsNotFound(err), Equals, true)
+}
+
+func (s *imageSuite) TestSetupSeedValidationSetsNotSatisfied(c *C) {
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	model := s.Brands.Model("my-brand", "my-model", map[string]interface{}{
+		"architecture":   "amd64",
+		"gadget":         "pc18",
+		"kernel":         "pc-kernel",
+		"base":           "core18",
+		"required-snaps": []interface{}{"other-base"},
+		"validation-sets": []interface{}{
+			map[string]interface{}{
+				"name":     "base-set",
+				"sequence": "1",
+				"mode":     "enforce",
+			},
+		},
+	})
+
+	rootdir := filepath.Join(c.MkDir(), "image")
+	s.setupSnaps(c, map[string]string{
+		"core18":     "canonical",
+		"pc18":       "canonical",
+		"pc-kernel":  "canonical",
+		"snapd":      "canonical",
+		"other-base": "other",
+	}, "")
+	s.makeValidationSet(c, "1", map[string]interface{}{
+		"name":     "other-base",
+		"id":       s.AssertedSnapID("other-base"),
+		"revision": "17",
+	})
+
+	opts := &image.Options{
+		PrepareDir: filepath.Dir(rootdir),
+	}
+
+	err := image.SetupSeed(s.tsto, model, opts)
+	c.Assert(err, ErrorMatches, `(?s)cannot seed snaps not satisfying the model validation sets: validation sets assertions are not met:
+- snaps at wrong revisions:
+  - other-base \(required at revision 17 by sets my-brand/base-set\)`)
+}
+
 func (s *imageSuite) TestSetupSeedWithBaseWithCloudConf(c *C) {
 	restore := image.MockTrusted(s.StoreSigning.Trusted)
 	defer restore()
@@ -2696,6 +2829,33 @@ func (s *imageSuite) TestCore20MakeLabel(c *C) {
 	c.Check(image.MakeLabel(time.Date(2019, 10, 30, 0, 0, 0, 0, time.UTC)), Equals, "20191030")
 }
 
+func (s *imageSuite) TestClampModTimes(c *C) {
+	dir := c.MkDir()
+	old := filepath.Join(dir, "old")
+	recent := filepath.Join(dir, "sub", "recent")
+	c.Assert(os.MkdirAll(filepath.Dir(recent), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(old, nil, 0644), IsNil)
+	c.Assert(ioutil.WriteFile(recent, nil, 0644), IsNil)
+	c.Assert(os.Symlink("recent", filepath.Join(dir, "sub", "link")), IsNil)
+
+	epoch := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	oldTime := epoch.Add(-time.Hour)
+	c.Assert(os.Chtimes(old, oldTime, oldTime), IsNil)
+
+	err := image.ClampModTimes(dir, epoch)
+	c.Assert(err, IsNil)
+
+	for _, p := range []string{dir, filepath.Dir(recent), recent} {
+		fi, err := os.Stat(p)
+		c.Assert(err, IsNil)
+		c.Check(fi.ModTime().Equal(epoch), Equals, true, Commentf(p))
+	}
+	// files older than the epoch are left alone
+	fi, err := os.Stat(old)
+	c.Assert(err, IsNil)
+	c.Check(fi.ModTime().Equal(oldTime), Equals, true)
+}
+
 func (s *imageSuite) makeSnap(c *C, yamlKey string, files [][]string, revno snap.Revision, publisher string) {
 	if publisher == "" {
 		publisher = "canonical"
@@ -2733,6 +2893,191 @@ func (s *imageSuite) makeUC20Model(extraHeaders map[string]interface{}) *asserts
 	return s.Brands.Model("my-brand", "my-model", headers)
 }
 
+func (s *imageSuite) TestSetupSeedCore20SourceDateEpoch(c *C) {
+	bootloader.Force(nil)
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	model := s.makeUC20Model(nil)
+
+	prepareDir := c.MkDir()
+
+	s.makeSnap(c, "snapd", nil, snap.R(1), "")
+	s.makeSnap(c, "core20", nil, snap.R(20), "")
+	s.makeSnap(c, "pc-kernel=20", nil, snap.R(1), "")
+	gadgetContent := [][]string{
+		{"grub-recovery.conf", "# recovery grub.cfg"},
+		{"grub.conf", "# boot grub.cfg"},
+		{"meta/gadget.yaml", pcUC20GadgetYaml},
+	}
+	s.makeSnap(c, "pc=20", gadgetContent, snap.R(22), "")
+	s.makeSnap(c, "required20", nil, snap.R(21), "other")
+
+	opts := &image.Options{
+		PrepareDir:      prepareDir,
+		SourceDateEpoch: time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	err := image.SetupSeed(s.tsto, model, opts)
+	c.Assert(err, IsNil)
+
+	// the recovery system label is derived from the given time
+	systems, err := filepath.Glob(filepath.Join(prepareDir, "system-seed", "systems", "*"))
+	c.Assert(err, IsNil)
+	c.Assert(systems, HasLen, 1)
+	c.Check(filepath.Base(systems[0]), Equals, "20200601")
+}
+
+const pcUC20DiskImageGadgetYaml = `
+ volumes:
+   pc:
+     bootloader: grub
+     structure:
+       - name: ubuntu-seed
+         role: system-seed
+         filesystem: vfat
+         type: EF,C12A7328-F81F-11D2-BA4B-00A0C93EC93B
+         size: 100M
+       - name: ubuntu-data
+         role: system-data
+         filesystem: ext4
+         type: 83,0FC63DAF-8483-4772-8E79-3D69D8477DE4
+         size: 200M
+`
+
+func (s *imageSuite) setupCore20DiskImageSeed(c *C, model *asserts.Model, prepareDir string) {
+	s.makeSnap(c, "snapd", nil, snap.R(1), "")
+	s.makeSnap(c, "core20", nil, snap.R(20), "")
+	s.makeSnap(c, "pc-kernel=20", nil, snap.R(1), "")
+	gadgetContent := [][]string{
+		{"grub-recovery.conf", "# recovery grub.cfg"},
+		{"grub.conf", "# boot grub.cfg"},
+		{"meta/gadget.yaml", pcUC20DiskImageGadgetYaml},
+	}
+	s.makeSnap(c, "pc=20", gadgetContent, snap.R(22), "")
+	s.makeSnap(c, "required20", nil, snap.R(21), "other")
+
+	err := image.SetupSeed(s.tsto, model, &image.Options{PrepareDir: prepareDir})
+	c.Assert(err, IsNil)
+}
+
+func (s *imageSuite) TestWriteDiskImageCore20Raw(c *C) {
+	bootloader.Force(nil)
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	model := s.makeUC20Model(nil)
+	prepareDir := c.MkDir()
+	s.setupCore20DiskImageSeed(c, model, prepareDir)
+
+	cmdQemuImg := testutil.MockCommand(c, "qemu-img", "")
+	defer cmdQemuImg.Restore()
+
+	calls := 0
+	restore = image.MockInstallWriteDiskImage(func(imagePath string, lv *gadget.LaidOutVolume, fsContentDirs map[int]string) error {
+		calls++
+		c.Check(imagePath, Equals, filepath.Join(prepareDir, "pc.img"))
+		c.Check(lv.LaidOutStructure, HasLen, 2)
+		c.Check(fsContentDirs, DeepEquals, map[int]string{
+			0: filepath.Join(prepareDir, "system-seed"),
+			1: filepath.Join(prepareDir, "resolved-content", "pc", "part1"),
+		})
+		return nil
+	})
+	defer restore()
+
+	err := image.WriteDiskImage(model, &image.Options{
+		PrepareDir:      prepareDir,
+		DiskImageFormat: "raw",
+	})
+	c.Assert(err, IsNil)
+	c.Check(calls, Equals, 1)
+	c.Check(cmdQemuImg.Calls(), HasLen, 0)
+}
+
+func (s *imageSuite) TestWriteDiskImageCore20Qcow2(c *C) {
+	bootloader.Force(nil)
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	model := s.makeUC20Model(nil)
+	prepareDir := c.MkDir()
+	s.setupCore20DiskImageSeed(c, model, prepareDir)
+
+	cmdQemuImg := testutil.MockCommand(c, "qemu-img", "")
+	defer cmdQemuImg.Restore()
+
+	rawImage := filepath.Join(prepareDir, "pc.img")
+	restore = image.MockInstallWriteDiskImage(func(imagePath string, lv *gadget.LaidOutVolume, fsContentDirs map[int]string) error {
+		return ioutil.WriteFile(imagePath, []byte("raw image"), 0644)
+	})
+	defer restore()
+
+	err := image.WriteDiskImage(model, &image.Options{
+		PrepareDir:      prepareDir,
+		DiskImageFormat: "qcow2",
+	})
+	c.Assert(err, IsNil)
+	c.Check(cmdQemuImg.Calls(), DeepEquals, [][]string{
+		{"qemu-img", "convert", "-f", "raw", "-O", "qcow2", rawImage, filepath.Join(prepareDir, "pc.qcow2")},
+	})
+	// the intermediate raw image is removed
+	c.Check(rawImage, testutil.FileAbsent)
+}
+
+func (s *imageSuite) TestWriteDiskImageCore20Error(c *C) {
+	bootloader.Force(nil)
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	model := s.makeUC20Model(nil)
+	prepareDir := c.MkDir()
+	s.setupCore20DiskImageSeed(c, model, prepareDir)
+
+	restore = image.MockInstallWriteDiskImage(func(imagePath string, lv *gadget.LaidOutVolume, fsContentDirs map[int]string) error {
+		return fmt.Errorf("boom")
+	})
+	defer restore()
+
+	err := image.WriteDiskImage(model, &image.Options{
+		PrepareDir:      prepareDir,
+		DiskImageFormat: "raw",
+	})
+	c.Assert(err, ErrorMatches, `cannot assemble disk image for volume "pc": boom`)
+}
+
+func (s *imageSuite) TestPrepareDiskImageUnsupported(c *C) {
+	restore := image.MockTrusted(s.StoreSigning.Trusted)
+	defer restore()
+
+	uc18Model := s.Brands.Model("my-brand", "my-model", map[string]interface{}{
+		"architecture": "amd64",
+		"gadget":       "pc18",
+		"kernel":       "pc-kernel",
+		"base":         "core18",
+	})
+	uc20Model := s.makeUC20Model(nil)
+
+	for _, tc := range []struct {
+		model  *asserts.Model
+		format string
+		err    string
+	}{
+		{uc18Model, "raw", `cannot assemble disk image: only supported for UC20 models`},
+		{uc20Model, "vmdk", `cannot assemble disk image: unsupported format "vmdk"`},
+	} {
+		fn := filepath.Join(c.MkDir(), "model.assertion")
+		err := ioutil.WriteFile(fn, asserts.Encode(tc.model), 0644)
+		c.Assert(err, IsNil)
+
+		err = image.Prepare(&image.Options{
+			ModelFile:       fn,
+			DiskImageFormat: tc.format,
+		})
+		c.Check(err, ErrorMatches, tc.err)
+	}
+}
+
 func (s *imageSuite) TestSetupSeedCore20Grub(c *C) {
 	bootloader.Force(nil)
 	restore := image.MockTrusted(s.StoreSigning.Trusted)
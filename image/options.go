@@ -19,6 +19,10 @@
 
 package image
 
+import (
+	"time"
+)
+
 type Options struct {
 	ModelFile string
 	Classic   bool
@@ -41,6 +45,18 @@ type Options struct {
 	Architecture string
 
 	Customizations Customizations
+
+	// SourceDateEpoch, if not zero, is used instead of the current
+	// time for anything time dependent in the image and modification
+	// times of the written files are clamped to it, so that builds
+	// given the same inputs are reproducible. It is usually taken from
+	// SOURCE_DATE_EPOCH.
+	SourceDateEpoch time.Time
+
+	// DiskImageFormat, if set, requests to also assemble a bootable
+	// disk image of the volume carrying the system-seed in the given
+	// format, either "raw" or "qcow2" (UC20 only).
+	DiskImageFormat string
 }
 
 // Customizatons defines possible image customizations. Not all of
@@ -64,6 +64,7 @@ var (
 	isRootWritableOverlay = osutil.IsRootWritableOverlay
 	kernelFeatures        = apparmor_sandbox.KernelFeatures
 	parserFeatures        = apparmor_sandbox.ParserFeatures
+	cacheKey              = apparmor_sandbox.CacheKey
 
 	// make sure that apparmor profile fulfills the late discarding backend
 	// interface
@@ -400,6 +401,10 @@ func (b *Backend) prepareProfiles(snapInfo *snap.Info, opts interfaces.Confineme
 				}
 			}
 		}
+		// what was recorded about the cache is no longer accurate
+		if err := os.RemoveAll(profileCacheManifestDir()); err != nil {
+			logger.Noticef("cannot remove apparmor profile cache manifests: %s", err)
+		}
 	}
 
 	// Get the files that this snap should have
@@ -455,6 +460,12 @@ func (b *Backend) Setup(snapInfo *snap.Info, opts interfaces.ConfinementOptions,
 		return err
 	}
 
+	// Unchanged profiles whose cache is known to be out of date are
+	// reloaded like changed ones.
+	pc := openProfileCache()
+	unchanged, stale := pc.partition(prof.unchanged)
+	changed := append(prof.changed[:len(prof.changed):len(prof.changed)], stale...)
+
 	// Load all changed profiles with a flag that asks apparmor to skip reading
 	// the cache (since we know those changed for sure).  This allows us to
 	// work despite time being wrong (e.g. in the past). For more details see
@@ -465,7 +476,7 @@ func (b *Backend) Setup(snapInfo *snap.Info, opts interfaces.ConfinementOptions,
 		aaFlags |= skipKernelLoad
 	}
 	timings.Run(tm, "load-profiles[changed]", fmt.Sprintf("load changed security profiles of snap %q", snapInfo.InstanceName()), func(nesttm timings.Measurer) {
-		errReloadChanged = loadProfiles(prof.changed, apparmor_sandbox.CacheDir, aaFlags)
+		errReloadChanged = loadProfiles(changed, apparmor_sandbox.CacheDir, aaFlags)
 	})
 
 	// Load all unchanged profiles anyway. This ensures those are correct in
@@ -477,9 +488,10 @@ func (b *Backend) Setup(snapInfo *snap.Info, opts interfaces.ConfinementOptions,
 		aaFlags |= skipKernelLoad
 	}
 	timings.Run(tm, "load-profiles[unchanged]", fmt.Sprintf("load unchanged security profiles of snap %q", snapInfo.InstanceName()), func(nesttm timings.Measurer) {
-		errReloadOther = loadProfiles(prof.unchanged, apparmor_sandbox.CacheDir, aaFlags)
+		errReloadOther = loadProfiles(unchanged, apparmor_sandbox.CacheDir, aaFlags)
 	})
 	errUnload := unloadProfiles(prof.removed, apparmor_sandbox.CacheDir)
+	pc.update(loadedProfiles(changed, errReloadChanged, unchanged, errReloadOther), prof.removed)
 	if errReloadChanged != nil {
 		return errReloadChanged
 	}
@@ -489,6 +501,19 @@ func (b *Backend) Setup(snapInfo *snap.Info, opts interfaces.ConfinementOptions,
 	return errUnload
 }
 
+// loadedProfiles returns the profiles of the batches that were loaded
+// successfully.
+func loadedProfiles(changed []string, errChanged error, unchanged []string, errUnchanged error) []string {
+	var loaded []string
+	if errChanged == nil {
+		loaded = append(loaded, changed...)
+	}
+	if errUnchanged == nil {
+		loaded = append(loaded, unchanged...)
+	}
+	return loaded
+}
+
 // SetupMany creates and loads apparmor profiles for multiple snaps.
 // The snaps can be in developer mode to make security violations non-fatal to
 // the offending application process.
@@ -512,6 +537,11 @@ func (b *Backend) SetupMany(snaps []*snap.Info, confinement func(snapName string
 	}
 
 	if !fallback {
+		pc := openProfileCache()
+		var stale []string
+		allUnchangedPaths, stale = pc.partition(allUnchangedPaths)
+		allChangedPaths = append(allChangedPaths, stale...)
+
 		aaFlags := skipReadCache | conserveCPU
 		if b.preseed {
 			aaFlags |= skipKernelLoad
@@ -531,6 +561,7 @@ func (b *Backend) SetupMany(snaps []*snap.Info, confinement func(snapName string
 		})
 
 		errUnload := unloadProfiles(allRemovedPaths, apparmor_sandbox.CacheDir)
+		pc.update(loadedProfiles(allChangedPaths, errReloadChanged, allUnchangedPaths, errReloadOther), allRemovedPaths)
 		if errReloadChanged != nil {
 			logger.Noticef("failed to batch-reload changed profiles: %s", errReloadChanged)
 			fallback = true
@@ -566,6 +597,7 @@ func (b *Backend) Remove(snapName string) error {
 	_, removed, errEnsure := osutil.EnsureDirStateGlobs(dir, globs, nil)
 	// always try to unload affected profiles
 	errUnload := unloadProfiles(removed, cache)
+	openProfileCache().update(nil, removed)
 	if errEnsure != nil {
 		return fmt.Errorf("cannot synchronize security files for snap %q: %s", snapName, errEnsure)
 	}
@@ -585,6 +617,7 @@ func (b *Backend) RemoveLate(snapName string, rev snap.Revision, typ snap.Type)
 	// only removes profiles from the cache
 	// always try to unload the affected profile
 	errUnload := unloadProfiles(removed, apparmor_sandbox.CacheDir)
+	openProfileCache().update(nil, removed)
 	if errEnsure != nil {
 		return fmt.Errorf("cannot remove security profiles for snap %q (%s): %s", snapName, rev, errEnsure)
 	}
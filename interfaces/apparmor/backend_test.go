@@ -20,6 +20,9 @@
 package apparmor_test
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -27,6 +30,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -41,6 +45,7 @@ import (
 	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/timings"
 )
@@ -169,6 +174,8 @@ func (s *backendSuite) SetUpTest(c *C) {
 	s.parserCmd = testutil.MockCommand(c, "apparmor_parser", fakeAppArmorParser)
 
 	apparmor.MockRuntimeNumCPU(func() int { return 99 })
+	// Unless tested explicitly, rely on the cache of apparmor_parser only
+	s.AddCleanup(apparmor.MockCacheKey("", errors.New("no cache key")))
 }
 
 func (s *backendSuite) TearDownTest(c *C) {
@@ -614,6 +621,142 @@ apps:
     cmd: snapcraft
 `
 
+func (s *backendSuite) readProfileCacheManifest(c *C, key string) map[string]string {
+	data, err := ioutil.ReadFile(filepath.Join(apparmor_sandbox.CacheDir, "snapd", key+".json"))
+	c.Assert(err, IsNil)
+	var manifest struct {
+		Profiles map[string]string `json:"profiles"`
+	}
+	c.Assert(json.Unmarshal(data, &manifest), IsNil)
+	return manifest.Profiles
+}
+
+func (s *backendSuite) writeProfileCacheManifest(c *C, key string, profiles map[string]string) {
+	data, err := json.Marshal(map[string]interface{}{"profiles": profiles})
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(apparmor_sandbox.CacheDir, "snapd"), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(apparmor_sandbox.CacheDir, "snapd", key+".json"), data, 0644), IsNil)
+}
+
+func profileSha256(c *C, path string) string {
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+func (s *backendSuite) TestProfileCacheRecordsLoadedProfiles(c *C) {
+	restore := apparmor.MockCacheKey("key-1", nil)
+	defer restore()
+
+	// a profile compiled outside of snapd, for example during boot
+	otherProfile := filepath.Join(dirs.SnapAppArmorDir, "snap.other.app")
+	c.Assert(os.MkdirAll(dirs.SnapAppArmorDir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(otherProfile, []byte("other"), 0644), IsNil)
+
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 1)
+	updateNSProfile := filepath.Join(dirs.SnapAppArmorDir, "snap-update-ns.samba")
+	profile := filepath.Join(dirs.SnapAppArmorDir, "snap.samba.smbd")
+	c.Check(s.readProfileCacheManifest(c, "key-1"), DeepEquals, map[string]string{
+		"snap.other.app":       profileSha256(c, otherProfile),
+		"snap-update-ns.samba": profileSha256(c, updateNSProfile),
+		"snap.samba.smbd":      profileSha256(c, profile),
+	})
+
+	s.RemoveSnap(c, snapInfo)
+	c.Check(s.readProfileCacheManifest(c, "key-1"), DeepEquals, map[string]string{
+		"snap.other.app": profileSha256(c, otherProfile),
+	})
+}
+
+func (s *backendSuite) TestProfileCacheReloadsStaleUnchangedProfiles(c *C) {
+	restore := apparmor.MockCacheKey("key-1", nil)
+	defer restore()
+
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 1)
+	updateNSProfile := filepath.Join(dirs.SnapAppArmorDir, "snap-update-ns.samba")
+	profile := filepath.Join(dirs.SnapAppArmorDir, "snap.samba.smbd")
+
+	// the compiled form of the profile does not match its content, as
+	// if it was never loaded after being written
+	manifest := s.readProfileCacheManifest(c, "key-1")
+	manifest["snap.samba.smbd"] = "stale"
+	s.writeProfileCacheManifest(c, "key-1", manifest)
+
+	s.parserCmd.ForgetCalls()
+	err := s.Backend.Setup(snapInfo, interfaces.ConfinementOptions{}, s.Repo, s.meas)
+	c.Assert(err, IsNil)
+	c.Check(s.parserCmd.Calls(), DeepEquals, [][]string{
+		{"apparmor_parser", "--replace", "--write-cache", "-O", "no-expr-simplify", fmt.Sprintf("--cache-loc=%s/var/cache/apparmor", s.RootDir), "--skip-read-cache", "--quiet", profile},
+		{"apparmor_parser", "--replace", "--write-cache", "-O", "no-expr-simplify", fmt.Sprintf("--cache-loc=%s/var/cache/apparmor", s.RootDir), "--quiet", updateNSProfile},
+	})
+	c.Check(s.readProfileCacheManifest(c, "key-1")["snap.samba.smbd"], Equals, profileSha256(c, profile))
+
+	// with a new kernel the profiles compiled during boot are trusted
+	restore = apparmor.MockCacheKey("key-2", nil)
+	defer restore()
+	s.parserCmd.ForgetCalls()
+	err = s.Backend.Setup(snapInfo, interfaces.ConfinementOptions{}, s.Repo, s.meas)
+	c.Assert(err, IsNil)
+	c.Check(s.parserCmd.Calls(), DeepEquals, [][]string{
+		{"apparmor_parser", "--replace", "--write-cache", "-O", "no-expr-simplify", fmt.Sprintf("--cache-loc=%s/var/cache/apparmor", s.RootDir), "--quiet", updateNSProfile, profile},
+	})
+	c.Check(s.readProfileCacheManifest(c, "key-2"), HasLen, 2)
+	// the manifest of the previous kernel is kept
+	c.Check(s.readProfileCacheManifest(c, "key-1"), HasLen, 2)
+}
+
+func (s *backendSuite) TestProfileCacheSetupManyReloadsStaleUnchangedProfiles(c *C) {
+	restore := apparmor.MockCacheKey("key-1", nil)
+	defer restore()
+
+	snapInfo1 := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 1)
+	snapInfo2 := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SomeSnapYamlV1, 1)
+	profile1 := filepath.Join(dirs.SnapAppArmorDir, "snap.samba.smbd")
+	profile2 := filepath.Join(dirs.SnapAppArmorDir, "snap.some-snap.someapp")
+
+	manifest := s.readProfileCacheManifest(c, "key-1")
+	c.Assert(manifest, HasLen, 4)
+	manifest["snap.some-snap.someapp"] = "stale"
+	s.writeProfileCacheManifest(c, "key-1", manifest)
+
+	s.parserCmd.ForgetCalls()
+	setupManyInterface, ok := s.Backend.(interfaces.SecurityBackendSetupMany)
+	c.Assert(ok, Equals, true)
+	errs := setupManyInterface.SetupMany([]*snap.Info{snapInfo1, snapInfo2}, func(snapName string) interfaces.ConfinementOptions { return interfaces.ConfinementOptions{} }, s.Repo, s.meas)
+	c.Assert(errs, HasLen, 0)
+	c.Assert(s.parserCmd.Calls(), HasLen, 2)
+	changedCall := s.parserCmd.Calls()[0]
+	c.Check(changedCall[len(changedCall)-1], Equals, profile2)
+	c.Check(strutil.ListContains(changedCall, "--skip-read-cache"), Equals, true)
+	unchangedCall := s.parserCmd.Calls()[1]
+	c.Check(strutil.ListContains(unchangedCall, "--skip-read-cache"), Equals, false)
+	c.Check(strutil.ListContains(unchangedCall, profile1), Equals, true)
+	c.Check(strutil.ListContains(unchangedCall, profile2), Equals, false)
+
+	c.Check(s.readProfileCacheManifest(c, "key-1")["snap.some-snap.someapp"], Equals, profileSha256(c, profile2))
+}
+
+func (s *backendSuite) TestProfileCachePrunesOldManifests(c *C) {
+	for i, key := range []string{"key-1", "key-2", "key-3", "key-4"} {
+		s.writeProfileCacheManifest(c, key, nil)
+		mtime := time.Now().Add(time.Duration(i-10) * time.Hour)
+		c.Assert(os.Chtimes(filepath.Join(apparmor_sandbox.CacheDir, "snapd", key+".json"), mtime, mtime), IsNil)
+	}
+
+	restore := apparmor.MockCacheKey("key-5", nil)
+	defer restore()
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 1)
+
+	manifests, err := filepath.Glob(filepath.Join(apparmor_sandbox.CacheDir, "snapd", "*.json"))
+	c.Assert(err, IsNil)
+	c.Check(manifests, DeepEquals, []string{
+		filepath.Join(apparmor_sandbox.CacheDir, "snapd", "key-2.json"),
+		filepath.Join(apparmor_sandbox.CacheDir, "snapd", "key-3.json"),
+		filepath.Join(apparmor_sandbox.CacheDir, "snapd", "key-4.json"),
+		filepath.Join(apparmor_sandbox.CacheDir, "snapd", "key-5.json"),
+	})
+}
+
 func (s *backendSuite) TestInstallingSnapDoesntBreakSnapsWithPrefixName(c *C) {
 	snapcraftProfile := filepath.Join(dirs.SnapAppArmorDir, "snap.snapcraft.snapcraft")
 	snapcraftPrProfile := filepath.Join(dirs.SnapAppArmorDir, "snap.snapcraft-pr.snapcraft-pr")
@@ -1332,6 +1475,17 @@ func (s *backendSuite) testCoreOrSnapdOnCoreCleansApparmorCache(c *C, coreOrSnap
 	c.Check(l, DeepEquals, []string{dotKept, dirsAreKept, sunCanaryKept, snapCanaryKept, symlinksAreKept})
 }
 
+func (s *backendSuite) TestCoreOnCoreForgetsProfileCacheManifests(c *C) {
+	restorer := release.MockOnClassic(false)
+	defer restorer()
+	coreInfo := snaptest.MockInfo(c, coreYaml, &snap.SideInfo{Revision: snap.R(111)})
+	s.writeVanillaSnapConfineProfile(c, coreInfo)
+	s.writeProfileCacheManifest(c, "key-1", map[string]string{"snap.canary.meep": "hash"})
+
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, "", coreYaml, 111)
+	c.Check(filepath.Join(apparmor_sandbox.CacheDir, "snapd"), testutil.FileAbsent)
+}
+
 // snap-confine policy when NFS is not used.
 func (s *backendSuite) TestSetupSnapConfineGeneratedPolicyNoNFS(c *C) {
 	// Make it appear as if NFS was not used.
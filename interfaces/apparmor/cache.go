@@ -0,0 +1,228 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmor
+
+import (
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
+)
+
+// maxProfileCacheManifests is the number of cache keys, that is kernel and
+// parser combinations, for which the content of compiled profiles is
+// remembered. Keeping more than one allows going back and forth between
+// kernels without recompiling all the profiles.
+const maxProfileCacheManifests = 4
+
+var profileCacheMu sync.Mutex
+
+func profileCacheManifestDir() string {
+	return filepath.Join(apparmor_sandbox.CacheDir, "snapd")
+}
+
+// profileCache remembers the content hashes of the profiles whose compiled
+// form was written to the apparmor cache, for a given cache key. It is
+// shared by all snaps.
+//
+// apparmor_parser decides whether a cached profile is still valid by
+// comparing modification times, which is unreliable when the clock is
+// wrong. The content hashes allow snapd to tell for sure whether the cache
+// of a profile that did not change on disk can be trusted.
+type profileCache struct {
+	key string
+	// known is set when profiles were already recorded for the key.
+	known  bool
+	hashes map[string]string
+}
+
+type profileCacheManifest struct {
+	Profiles map[string]string `json:"profiles"`
+}
+
+// openProfileCache returns the profile cache for the running kernel and
+// apparmor parser. It returns nil if the cache key cannot be determined, in
+// which case the cache of apparmor_parser is used as is.
+func openProfileCache() *profileCache {
+	key, err := cacheKey()
+	if err != nil {
+		logger.Debugf("cannot determine apparmor cache key: %v", err)
+		return nil
+	}
+	pc := &profileCache{key: key, hashes: make(map[string]string)}
+	if err := pc.read(); err != nil && !os.IsNotExist(err) {
+		logger.Noticef("cannot read apparmor profile cache manifest: %v", err)
+		return nil
+	}
+	return pc
+}
+
+func (pc *profileCache) manifestPath() string {
+	return filepath.Join(profileCacheManifestDir(), pc.key+".json")
+}
+
+func (pc *profileCache) read() error {
+	data, err := ioutil.ReadFile(pc.manifestPath())
+	if err != nil {
+		return err
+	}
+	var manifest profileCacheManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return err
+	}
+	pc.known = true
+	pc.hashes = manifest.Profiles
+	if pc.hashes == nil {
+		pc.hashes = make(map[string]string)
+	}
+	return nil
+}
+
+func profileHash(path string) (string, error) {
+	digest, _, err := osutil.FileDigest(path, crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest), nil
+}
+
+// partition splits the given unchanged profiles into the ones whose
+// compiled form in the cache is known to match their content and the stale
+// ones that must be compiled afresh. If no profiles were recorded for the
+// key yet, for example because they were compiled during boot, the cache of
+// apparmor_parser is trusted.
+func (pc *profileCache) partition(unchanged []string) (trusted, stale []string) {
+	if pc == nil || !pc.known {
+		return unchanged, nil
+	}
+	for _, path := range unchanged {
+		hash, err := profileHash(path)
+		if err != nil || pc.hashes[filepath.Base(path)] != hash {
+			stale = append(stale, path)
+			continue
+		}
+		trusted = append(trusted, path)
+	}
+	return trusted, stale
+}
+
+// update records the content of the given loaded profiles and forgets the
+// removed ones.
+func (pc *profileCache) update(loaded, removed []string) {
+	if pc == nil || (len(loaded) == 0 && len(removed) == 0) {
+		return
+	}
+
+	profileCacheMu.Lock()
+	defer profileCacheMu.Unlock()
+
+	// other snaps may have been set up since the cache was opened
+	if err := pc.read(); err != nil && !os.IsNotExist(err) {
+		logger.Noticef("cannot read apparmor profile cache manifest: %v", err)
+		return
+	}
+	if !pc.known {
+		// the profiles on disk were compiled by apparmor_parser on
+		// its own, for example during boot, trust them as they are
+		pc.seed()
+	}
+	for _, path := range loaded {
+		hash, err := profileHash(path)
+		if err != nil {
+			delete(pc.hashes, filepath.Base(path))
+			continue
+		}
+		pc.hashes[filepath.Base(path)] = hash
+	}
+	for _, name := range removed {
+		delete(pc.hashes, name)
+	}
+
+	data, err := json.Marshal(&profileCacheManifest{Profiles: pc.hashes})
+	if err != nil {
+		logger.Noticef("cannot encode apparmor profile cache manifest: %v", err)
+		return
+	}
+	if err := os.MkdirAll(profileCacheManifestDir(), 0755); err != nil {
+		logger.Noticef("cannot create apparmor profile cache manifest directory: %v", err)
+		return
+	}
+	if err := osutil.AtomicWriteFile(pc.manifestPath(), data, 0644, 0); err != nil {
+		logger.Noticef("cannot write apparmor profile cache manifest: %v", err)
+		return
+	}
+	pc.known = true
+	pruneProfileCacheManifests(pc.key)
+}
+
+// seed records the content of all the profiles on disk.
+func (pc *profileCache) seed() {
+	paths, err := filepath.Glob(filepath.Join(dirs.SnapAppArmorDir, "*"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		// skip temporary files created by snapd
+		if strings.HasSuffix(path, "~") {
+			continue
+		}
+		if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		if hash, err := profileHash(path); err == nil {
+			pc.hashes[filepath.Base(path)] = hash
+		}
+	}
+}
+
+// pruneProfileCacheManifests removes the manifests of the least recently
+// used cache keys, keeping at most maxProfileCacheManifests.
+func pruneProfileCacheManifests(currentKey string) {
+	infos, err := ioutil.ReadDir(profileCacheManifestDir())
+	if err != nil {
+		return
+	}
+	var manifests []os.FileInfo
+	for _, fi := range infos {
+		if !fi.Mode().IsRegular() || !strings.HasSuffix(fi.Name(), ".json") || fi.Name() == currentKey+".json" {
+			continue
+		}
+		manifests = append(manifests, fi)
+	}
+	if len(manifests) < maxProfileCacheManifests {
+		return
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].ModTime().After(manifests[j].ModTime())
+	})
+	for _, fi := range manifests[maxProfileCacheManifests-1:] {
+		os.Remove(filepath.Join(profileCacheManifestDir(), fi.Name()))
+	}
+}
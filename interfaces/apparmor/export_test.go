@@ -37,6 +37,17 @@ var (
 	DefaultOtherBaseTemplateRules   = defaultOtherBaseTemplateRules
 )
 
+// MockCacheKey mocks the apparmor cache key of the running system.
+func MockCacheKey(key string, err error) (restore func()) {
+	old := cacheKey
+	cacheKey = func() (string, error) {
+		return key, err
+	}
+	return func() {
+		cacheKey = old
+	}
+}
+
 func MockRuntimeNumCPU(new func() int) (restore func()) {
 	old := runtimeNumCPU
 	runtimeNumCPU = new
@@ -546,6 +546,18 @@ func snippetFromLayout(layout *snap.Layout) string {
 
 // Implementation of methods required by interfaces.Specification
 
+// ConnectionsAffect returns whether connections of the given interface can
+// contribute apparmor snippets.
+func (spec *Specification) ConnectionsAffect(iface interfaces.Interface) bool {
+	_, plugDefiner := iface.(interface {
+		AppArmorConnectedPlug(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	_, slotDefiner := iface.(interface {
+		AppArmorConnectedSlot(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	return plugDefiner || slotDefiner
+}
+
 // AddConnectedPlug records apparmor-specific side-effects of having a connected plug.
 func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	type definer interface {
@@ -523,3 +523,11 @@ func (s *specSuite) TestSetSuppressHomeIx(c *C) {
 	s.spec.SetSuppressHomeIx()
 	c.Assert(s.spec.SuppressHomeIx(), Equals, true)
 }
+
+func (s *specSuite) TestConnectionsAffect(c *C) {
+	spec := &apparmor.Specification{}
+	c.Check(spec.ConnectionsAffect(&ifacetest.TestInterface{InterfaceName: "test"}), Equals, true)
+	// an interface that does not define any connected side-effects
+	iface := struct{ interfaces.Interface }{&ifacetest.TestInterface{InterfaceName: "test"}}
+	c.Check(spec.ConnectionsAffect(iface), Equals, false)
+}
@@ -26,11 +26,13 @@ import (
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/dbus"
 	"github.com/snapcore/snapd/interfaces/kmod"
+	"github.com/snapcore/snapd/interfaces/landlock"
 	"github.com/snapcore/snapd/interfaces/mount"
 	"github.com/snapcore/snapd/interfaces/seccomp"
 	"github.com/snapcore/snapd/interfaces/systemd"
 	"github.com/snapcore/snapd/interfaces/udev"
 	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
+	landlock_sandbox "github.com/snapcore/snapd/sandbox/landlock"
 )
 
 var All []interfaces.SecurityBackend = backends()
@@ -72,6 +74,14 @@ func backends() []interfaces.SecurityBackend {
 	switch apparmor_sandbox.ProbedLevel() {
 	case apparmor_sandbox.Partial, apparmor_sandbox.Full:
 		all = append(all, &apparmor.Backend{})
+	default:
+		// Without apparmor fall back to confining access to the
+		// filesystem with landlock, if the kernel supports it. This does
+		// not lift forced devmode, as landlock does not mediate anything
+		// else.
+		if landlock_sandbox.IsSupported() {
+			all = append(all, &landlock.Backend{})
+		}
 	}
 	return all
 }
@@ -24,6 +24,7 @@ import (
 
 	"github.com/snapcore/snapd/interfaces/backends"
 	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
+	landlock_sandbox "github.com/snapcore/snapd/sandbox/landlock"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -55,6 +56,28 @@ func (s *backendsSuite) TestIsAppArmorEnabled(c *C) {
 	}
 }
 
+func (s *backendsSuite) TestIsLandlockEnabled(c *C) {
+	for _, level := range []apparmor_sandbox.LevelType{apparmor_sandbox.Unsupported, apparmor_sandbox.Unusable, apparmor_sandbox.Partial, apparmor_sandbox.Full} {
+		for _, abi := range []int{0, 1} {
+			restore := apparmor_sandbox.MockLevel(level)
+			defer restore()
+			restore = landlock_sandbox.MockABIVersion(abi)
+			defer restore()
+
+			all := backends.Backends()
+			names := make([]string, len(all))
+			for i, backend := range all {
+				names[i] = string(backend.Name())
+			}
+			if abi > 0 && (level == apparmor_sandbox.Unsupported || level == apparmor_sandbox.Unusable) {
+				c.Check(names, testutil.Contains, "landlock", Commentf("%v %v", level, abi))
+			} else {
+				c.Check(names, Not(testutil.Contains), "landlock", Commentf("%v %v", level, abi))
+			}
+		}
+	}
+}
+
 func (s *backendsSuite) TestEssentialOrdering(c *C) {
 	restore := apparmor_sandbox.MockLevel(apparmor_sandbox.Full)
 	defer restore()
@@ -32,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/dbus"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
 	"github.com/snapcore/snapd/interfaces/kmod"
+	"github.com/snapcore/snapd/interfaces/landlock"
 	"github.com/snapcore/snapd/interfaces/mount"
 	"github.com/snapcore/snapd/interfaces/seccomp"
 	"github.com/snapcore/snapd/interfaces/systemd"
@@ -88,6 +89,19 @@ type kmodDefiner4 interface {
 	KModPermanentSlot(spec *kmod.Specification, slot *snap.SlotInfo) error
 }
 
+type landlockDefiner1 interface {
+	LandlockConnectedPlug(spec *landlock.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+}
+type landlockDefiner2 interface {
+	LandlockConnectedSlot(spec *landlock.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+}
+type landlockDefiner3 interface {
+	LandlockPermanentPlug(spec *landlock.Specification, plug *snap.PlugInfo) error
+}
+type landlockDefiner4 interface {
+	LandlockPermanentSlot(spec *landlock.Specification, slot *snap.SlotInfo) error
+}
+
 type mountDefiner1 interface {
 	MountConnectedPlug(spec *mount.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
 }
@@ -158,6 +172,11 @@ var allGoodDefiners = []reflect.Type{
 	reflect.TypeOf((*kmodDefiner3)(nil)).Elem(),
 	reflect.TypeOf((*kmodDefiner4)(nil)).Elem(),
 	// mount
+	reflect.TypeOf((*landlockDefiner1)(nil)).Elem(),
+	reflect.TypeOf((*landlockDefiner2)(nil)).Elem(),
+	reflect.TypeOf((*landlockDefiner3)(nil)).Elem(),
+	reflect.TypeOf((*landlockDefiner4)(nil)).Elem(),
+
 	reflect.TypeOf((*mountDefiner1)(nil)).Elem(),
 	reflect.TypeOf((*mountDefiner2)(nil)).Elem(),
 	reflect.TypeOf((*mountDefiner3)(nil)).Elem(),
@@ -179,9 +198,20 @@ var allGoodDefiners = []reflect.Type{
 	reflect.TypeOf((*udevDefiner4)(nil)).Elem(),
 }
 
+// interfacesWithoutDefiners lists the interfaces which legitimately do not
+// implement any specification methods.
+var interfacesWithoutDefiners = map[string]bool{
+	// service-control only grants access to snapd itself, through snapctl
+	// restart --peer, so it needs no confinement rules on either side.
+	"service-control": true,
+}
+
 // Check that each interface defines at least one definer method we recognize.
 func (s *AllSuite) TestEachInterfaceImplementsSomeBackendMethods(c *C) {
 	for _, iface := range builtin.Interfaces() {
+		if interfacesWithoutDefiners[iface.Name()] {
+			continue
+		}
 		bogus := true
 		for _, definer := range allGoodDefiners {
 			if reflect.TypeOf(iface).Implements(definer) {
@@ -363,7 +393,7 @@ func (s *AllSuite) TestUnexpectedSpecSignatures(c *C) {
 	var sigs []funcSig
 
 	// All the valid signatures from all the specification definers from all the backends.
-	for _, backend := range []string{"AppArmor", "SecComp", "UDev", "DBus", "Systemd", "KMod"} {
+	for _, backend := range []string{"AppArmor", "SecComp", "UDev", "DBus", "Systemd", "KMod", "Landlock"} {
 		backendLower := strings.ToLower(backend)
 		sigs = append(sigs, []funcSig{{
 			name: fmt.Sprintf("%sPermanentPlug", backend),
@@ -26,6 +26,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/kmod"
+	"github.com/snapcore/snapd/interfaces/landlock"
 	"github.com/snapcore/snapd/interfaces/mount"
 	"github.com/snapcore/snapd/interfaces/seccomp"
 	"github.com/snapcore/snapd/interfaces/udev"
@@ -65,6 +66,8 @@ type commonInterface struct {
 	permanentPlugKModModules []string
 	permanentSlotKModModules []string
 
+	connectedPlugLandlock []landlock.Rule
+
 	usesPtraceTrace      bool
 	suppressPtraceTrace  bool
 	suppressHomeIx       bool
@@ -139,6 +142,15 @@ func (iface *commonInterface) KModConnectedSlot(spec *kmod.Specification, plug *
 	return nil
 }
 
+func (iface *commonInterface) LandlockConnectedPlug(spec *landlock.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	for _, rule := range iface.connectedPlugLandlock {
+		if err := spec.AddRule(rule.Access, rule.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (iface *commonInterface) MountConnectedPlug(spec *mount.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	for _, entry := range iface.connectedPlugMount {
 		if err := spec.AddMountEntry(entry); err != nil {
@@ -56,20 +56,44 @@ func (a filesAAPerm) String() string {
 	panic(fmt.Sprintf("invalid perm: %d", a))
 }
 
+// filesPathVariables are the variables that can be used as whole path
+// components in the paths of the files interfaces, along with the AppArmor
+// expression they expand to. Since they stand for locations of the user
+// running the snap, accesses through them are restricted to owned files.
+var filesPathVariables = []struct {
+	name, aare string
+}{
+	{"$HOME", "@{HOME}"},
+	{"$SNAP_REAL_HOME", "@{HOME}"},
+	{"$UID", "[0-9]*"},
+}
+
+// expandPathVariables returns the given path with the variables replaced by
+// their AppArmor expression, and whether any variable was used.
+func expandPathVariables(p string) (expanded string, templated bool) {
+	// Note that the {personal,system}-files interface impose
+	// limitations on the variables usage - system-files forbids the
+	// home variables, personal only allows starting with one in the
+	// path.
+	for _, v := range filesPathVariables {
+		if strings.Contains(p, v.name) {
+			p = strings.Replace(p, v.name, v.aare, -1)
+			templated = true
+		}
+	}
+	return p, templated
+}
+
 func formatPath(ip interface{}) (string, error) {
 	p, ok := ip.(string)
 	if !ok {
 		return "", fmt.Errorf("%[1]v (%[1]T) is not a string", ip)
 	}
 	prefix := ""
-	// Note that the {personal,system}-files interface impose
-	// limitations on the $HOME usage - system-files forbids it,
-	// personal only allows starting with $HOME in the path.
-	if strings.Contains(p, "$HOME") {
-		p = strings.Replace(p, "$HOME", "@{HOME}", -1)
+	p, templated := expandPathVariables(filepath.Clean(p))
+	if templated {
 		prefix = "owner "
 	}
-	p = filepath.Clean(p)
 	p += "{,/,/**}"
 
 	return fmt.Sprintf("%s%q", prefix, p), nil
@@ -86,6 +110,39 @@ func allowPathAccess(buf *bytes.Buffer, perm filesAAPerm, paths []interface{}) e
 	return nil
 }
 
+// allowParentsCreation allows creating the missing parent directories of the
+// given paths. Neither top-level directories nor the directory a home
+// variable stands for can be created.
+func allowParentsCreation(buf *bytes.Buffer, paths []interface{}) error {
+	seen := make(map[string]bool)
+	for _, rawPath := range paths {
+		p, ok := rawPath.(string)
+		if !ok {
+			return fmt.Errorf("%[1]v (%[1]T) is not a string", rawPath)
+		}
+		comps := strings.Split(filepath.Clean(p), "/")
+		// comps[0] is either empty for absolute paths or a home
+		// variable, the last component is the path itself
+		start := 2
+		if comps[0] == "" {
+			start = 3
+		}
+		for i := start; i < len(comps); i++ {
+			dir, templated := expandPathVariables(strings.Join(comps[:i], "/"))
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			prefix := ""
+			if templated {
+				prefix = "owner "
+			}
+			fmt.Fprintf(buf, "%s%q w,\n", prefix, dir+"/")
+		}
+	}
+	return nil
+}
+
 func (iface *commonFilesInterface) validatePaths(attrName string, paths []interface{}) error {
 	for _, npp := range paths {
 		np, ok := npp.(string)
@@ -113,6 +170,9 @@ func (iface *commonFilesInterface) validateSinglePath(np string) error {
 	if err := apparmor.ValidateNoAppArmorRegexp(p); err != nil {
 		return err
 	}
+	if err := validatePathVariables(p); err != nil {
+		return err
+	}
 
 	// extraPathValidation must be implemented by the interface
 	// that build on top of the abstract commonFilesInterface
@@ -125,6 +185,28 @@ func (iface *commonFilesInterface) validateSinglePath(np string) error {
 	return nil
 }
 
+// validatePathVariables checks that only known variables are used and that
+// they are used as whole path components, so that their expansion cannot be
+// combined with other characters to escape the intended location.
+func validatePathVariables(p string) error {
+	for _, comp := range strings.Split(p, "/") {
+		if !strings.Contains(comp, "$") {
+			continue
+		}
+		known := false
+		for _, v := range filesPathVariables {
+			if comp == v.name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%q must only use $HOME, $SNAP_REAL_HOME or $UID as whole path components", p)
+		}
+	}
+	return nil
+}
+
 func (iface *commonFilesInterface) BeforePreparePlug(plug *snap.PlugInfo) error {
 	hasValidAttr := false
 	for _, att := range []string{"read", "write"} {
@@ -143,14 +225,24 @@ func (iface *commonFilesInterface) BeforePreparePlug(plug *snap.PlugInfo) error
 	if !hasValidAttr {
 		return fmt.Errorf(`cannot add %s plug: needs valid "read" or "write" attribute`, iface.name)
 	}
+	if create, ok := plug.Attrs["create"]; ok {
+		if _, ok := create.(bool); !ok {
+			return fmt.Errorf(`cannot add %s plug: "create" must be a boolean`, iface.name)
+		}
+		if _, ok := plug.Attrs["write"]; !ok {
+			return fmt.Errorf(`cannot add %s plug: "create" can only be used with "write"`, iface.name)
+		}
+	}
 
 	return nil
 }
 
 func (iface *commonFilesInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	var reads, writes []interface{}
+	var create bool
 	_ = plug.Attr("read", &reads)
 	_ = plug.Attr("write", &writes)
+	_ = plug.Attr("create", &create)
 
 	errPrefix := fmt.Sprintf(`cannot connect plug %s: `, plug.Name())
 	buf := bytes.NewBufferString(iface.apparmorHeader)
@@ -160,6 +252,11 @@ func (iface *commonFilesInterface) AppArmorConnectedPlug(spec *apparmor.Specific
 	if err := allowPathAccess(buf, filesWrite, writes); err != nil {
 		return fmt.Errorf("%s%v", errPrefix, err)
 	}
+	if create {
+		if err := allowParentsCreation(buf, writes); err != nil {
+			return fmt.Errorf("%s%v", errPrefix, err)
+		}
+	}
 	spec.AddSnippet(buf.String())
 
 	return nil
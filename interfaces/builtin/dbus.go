@@ -350,12 +350,34 @@ func (iface *dbusInterface) AppArmorConnectedPlug(spec *apparmor.Specification,
 	return nil
 }
 
+func (iface *dbusInterface) DBusConnectedPlug(spec *dbus.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	bus, name, err := iface.getAttribs(plug)
+	if err != nil {
+		return err
+	}
+
+	busSlot, nameSlot, err := iface.getAttribs(slot)
+	if err != nil {
+		return err
+	}
+
+	// ensure that we only connect to slot with matching attributes
+	if bus != busSlot || name != nameSlot {
+		return nil
+	}
+
+	spec.AddProxyRule(bus, dbus.ProxyTalk, name, "")
+	return nil
+}
+
 func (iface *dbusInterface) DBusPermanentSlot(spec *dbus.Specification, slot *snap.SlotInfo) error {
 	bus, name, err := iface.getAttribs(slot)
 	if err != nil {
 		return err
 	}
 
+	spec.AddProxyRule(bus, dbus.ProxyOwn, name, "")
+
 	// only system services need bus policy
 	if bus != "system" {
 		return nil
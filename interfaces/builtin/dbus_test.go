@@ -367,6 +367,24 @@ func (s *DbusInterfaceSuite) TestPermanentSlotDBusSession(c *C) {
 	err := dbusSpec.AddPermanentSlot(s.iface, s.sessionSlotInfo)
 	c.Assert(err, IsNil)
 	c.Assert(dbusSpec.SecurityTags(), HasLen, 0)
+	c.Check(dbusSpec.ProxyRulesForTag("snap.test-dbus.test-session-provider", "session"), DeepEquals, []string{"--own=org.test-session-slot"})
+	c.Check(dbusSpec.ProxyRulesForTag("snap.test-dbus.test-session-provider", "system"), HasLen, 0)
+}
+
+func (s *DbusInterfaceSuite) TestConnectedPlugDBusProxy(c *C) {
+	dbusSpec := &dbus.Specification{}
+	err := dbusSpec.AddConnectedPlug(s.iface, s.connectedSystemPlug, s.connectedSystemSlot)
+	c.Assert(err, IsNil)
+	c.Check(dbusSpec.ProxyRulesForTag("snap.test-dbus.test-system-consumer", "system"), DeepEquals, []string{"--talk=org.test-system-connected"})
+	c.Check(dbusSpec.ProxyRulesForTag("snap.test-dbus.test-system-consumer", "session"), HasLen, 0)
+	// no bus policy is needed on the plug side
+	c.Check(dbusSpec.SecurityTags(), HasLen, 0)
+
+	// plugs and slots with mismatching attributes are not connected
+	dbusSpec = &dbus.Specification{}
+	err = dbusSpec.AddConnectedPlug(s.iface, s.systemPlug, s.connectedSystemSlot)
+	c.Assert(err, IsNil)
+	c.Check(dbusSpec.ProxyRulesForTag("snap.test-dbus.test-system-consumer", "system"), HasLen, 0)
 }
 
 func (s *DbusInterfaceSuite) TestPermanentSlotDBusSystem(c *C) {
@@ -374,6 +392,7 @@ func (s *DbusInterfaceSuite) TestPermanentSlotDBusSystem(c *C) {
 	err := dbusSpec.AddPermanentSlot(s.iface, s.systemSlotInfo)
 	c.Assert(err, IsNil)
 	c.Assert(dbusSpec.SecurityTags(), DeepEquals, []string{"snap.test-dbus.test-system-provider"})
+	c.Check(dbusSpec.ProxyRulesForTag("snap.test-dbus.test-system-provider", "system"), DeepEquals, []string{"--own=org.test-system-slot"})
 	snippet := dbusSpec.SnippetForTag("snap.test-dbus.test-system-provider")
 	c.Check(snippet, testutil.Contains, "<policy user=\"root\">\n    <allow own=\"org.test-system-slot\"/>")
 	c.Check(snippet, testutil.Contains, "<policy context=\"default\">\n    <allow send_destination=\"org.test-system-slot\"/>")
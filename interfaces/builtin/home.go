@@ -21,9 +21,11 @@ package builtin
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/landlock"
 	"github.com/snapcore/snapd/snap"
 )
 
@@ -103,15 +105,42 @@ func (iface *homeInterface) BeforePreparePlug(plug *snap.PlugInfo) error {
 	if r, ok := plug.Attrs["read"]; ok && r != "all" {
 		return fmt.Errorf(`home plug requires "read" be 'all'`)
 	}
+	if ro, ok := plug.Attrs["read-only"]; ok {
+		if _, ok := ro.(bool); !ok {
+			return fmt.Errorf(`home plug requires "read-only" be a boolean`)
+		}
+	}
+
+	return nil
+}
 
+// ValidatePlugAttrsOverride allows the administrator to restrict a connection
+// to read-only access.
+func (iface *homeInterface) ValidatePlugAttrsOverride(plug *snap.PlugInfo, attrs map[string]interface{}) error {
+	for name, value := range attrs {
+		if name != "read-only" {
+			return fmt.Errorf("cannot override attribute %q of home plug", name)
+		}
+		if value != true {
+			return fmt.Errorf(`home plug attribute "read-only" can only be overridden with true`)
+		}
+	}
 	return nil
 }
 
 func (iface *homeInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	var read string
 	_ = plug.Attr("read", &read)
+	var readOnly bool
+	_ = plug.Attr("read-only", &readOnly)
 	// 'owner' is the standard policy
-	spec.AddSnippet(homeConnectedPlugAppArmor)
+	snippet := homeConnectedPlugAppArmor
+	if readOnly {
+		// drop all write access granted by the standard policy
+		snippet = strings.Replace(snippet, "rwkl###HOME_IX###", "r###HOME_IX###", -1)
+		snippet = strings.Replace(snippet, "owner /run/user/[0-9]*/gvfs/*/**  w,\n", "", 1)
+	}
+	spec.AddSnippet(snippet)
 
 	// 'all' grants standard policy plus read access to home without owner
 	// match
@@ -121,6 +150,28 @@ func (iface *homeInterface) AppArmorConnectedPlug(spec *apparmor.Specification,
 	return nil
 }
 
+// LandlockConnectedPlug grants access to the real home directory of the user.
+// Unlike with apparmor hidden files cannot be excluded, since landlock rules
+// apply to the whole hierarchy beneath a path.
+func (iface *homeInterface) LandlockConnectedPlug(spec *landlock.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	var read string
+	_ = plug.Attr("read", &read)
+	var readOnly bool
+	_ = plug.Attr("read-only", &readOnly)
+	access := landlock.ReadWrite
+	if readOnly {
+		access = landlock.ReadOnly
+	}
+	if err := spec.AddRule(access, "$SNAP_REAL_HOME"); err != nil {
+		return err
+	}
+	// 'all' grants read access to the home directories of all users
+	if read == "all" {
+		return spec.AddRule(landlock.ReadOnly, "/home")
+	}
+	return nil
+}
+
 func init() {
 	registerIface(&homeInterface{commonInterface{
 		name:                 "home",
@@ -25,6 +25,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/landlock"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
@@ -171,6 +172,64 @@ apps:
 	c.Check(apparmorSpec.SnippetForTag("snap.home-plug-snap.app2"), testutil.Contains, `# Allow non-owner read`)
 }
 
+func (s *HomeInterfaceSuite) TestSanitizePlugWithBadReadOnlyAttrib(c *C) {
+	const mockSnapYaml = `name: home-plug-snap
+version: 1.0
+plugs:
+ home:
+  read-only: yes-please
+`
+	info := snaptest.MockInfo(c, mockSnapYaml, nil)
+	plug := info.Plugs["home"]
+	c.Assert(interfaces.BeforePreparePlug(s.iface, plug), ErrorMatches,
+		`home plug requires "read-only" be a boolean`)
+}
+
+func (s *HomeInterfaceSuite) TestConnectedPlugAppArmorReadOnly(c *C) {
+	plug := interfaces.NewConnectedPlug(s.plugInfo, nil, map[string]interface{}{"read-only": true})
+
+	apparmorSpec := &apparmor.Specification{}
+	err := apparmorSpec.AddConnectedPlug(s.iface, plug, s.slot)
+	c.Assert(err, IsNil)
+	snippet := apparmorSpec.SnippetForTag("snap.other.app")
+	c.Check(snippet, testutil.Contains, `owner @{HOME}/[^s.]**             r###HOME_IX###,`)
+	c.Check(snippet, testutil.Contains, `owner /run/user/[0-9]*/gvfs/{,**} r,`)
+	c.Check(snippet, Not(testutil.Contains), `rwkl`)
+	c.Check(snippet, Not(testutil.Contains), `gvfs/*/**  w,`)
+}
+
+func (s *HomeInterfaceSuite) TestConnectedPlugLandlock(c *C) {
+	spec := &landlock.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Check(spec.RulesForTag("snap.other.app"), DeepEquals, []landlock.Rule{
+		{Access: landlock.ReadWrite, Path: "$SNAP_REAL_HOME"},
+	})
+
+	plug := interfaces.NewConnectedPlug(s.plugInfo, nil, map[string]interface{}{"read-only": true})
+	spec = &landlock.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, plug, s.slot), IsNil)
+	c.Check(spec.RulesForTag("snap.other.app"), DeepEquals, []landlock.Rule{
+		{Access: landlock.ReadOnly, Path: "$SNAP_REAL_HOME"},
+	})
+
+	plug = interfaces.NewConnectedPlug(s.plugInfo, nil, map[string]interface{}{"read": "all"})
+	spec = &landlock.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, plug, s.slot), IsNil)
+	c.Check(spec.RulesForTag("snap.other.app"), DeepEquals, []landlock.Rule{
+		{Access: landlock.ReadOnly, Path: "/home"},
+		{Access: landlock.ReadWrite, Path: "$SNAP_REAL_HOME"},
+	})
+}
+
+func (s *HomeInterfaceSuite) TestValidatePlugAttrsOverride(c *C) {
+	overrider := s.iface.(interfaces.PlugAttrsOverrider)
+	c.Check(overrider.ValidatePlugAttrsOverride(s.plugInfo, map[string]interface{}{"read-only": true}), IsNil)
+	c.Check(overrider.ValidatePlugAttrsOverride(s.plugInfo, map[string]interface{}{"read-only": false}), ErrorMatches,
+		`home plug attribute "read-only" can only be overridden with true`)
+	c.Check(overrider.ValidatePlugAttrsOverride(s.plugInfo, map[string]interface{}{"read": "all"}), ErrorMatches,
+		`cannot override attribute "read" of home plug`)
+}
+
 func (s *HomeInterfaceSuite) TestInterfaces(c *C) {
 	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
 }
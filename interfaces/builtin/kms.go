@@ -0,0 +1,189 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/snap"
+)
+
+const kmsSummary = `allows access to a specific DRM device node`
+
+// kms slots are defined by the gadget or the system for a specific DRM card
+// so that, for instance, a kiosk snap can own one display through the
+// primary node while another snap only uses the render node of the card.
+// Since the cards are device-specific, do not auto-connect.
+const kmsBaseDeclarationSlots = `
+  kms:
+    allow-installation:
+      slot-snap-type:
+        - core
+        - gadget
+    deny-auto-connection: true
+`
+
+const kmsConnectedPlugAppArmorPrimary = `
+# Description: Can access the DRM primary node of a specific card, allowing
+# to perform kernel mode setting on its displays.
+/dev/dri/ r,
+/dev/dri/card%[1]d rw,
+/run/udev/data/c226:%[1]d r,
+/sys/class/drm/ r,
+/sys/devices/**/drm/card%[1]d/{,*} r,
+`
+
+const kmsConnectedPlugAppArmorConnector = `
+# Description: Can query the state of the %[2]s connector of the card.
+/sys/devices/**/drm/card%[1]d/card%[1]d-%[2]s/{,**} r,
+`
+
+const kmsConnectedPlugAppArmorAllConnectors = `
+# Description: Can query the state of all the connectors of the card.
+/sys/devices/**/drm/card%[1]d/card%[1]d-*/{,**} r,
+`
+
+const kmsConnectedPlugAppArmorRender = `
+# Description: Can access the DRM render node of a specific card, allowing
+# to use its GPU for rendering but not to drive its displays.
+/dev/dri/ r,
+/dev/dri/renderD%[1]d rw,
+/run/udev/data/c226:%[1]d r,
+/sys/class/drm/ r,
+/sys/devices/**/drm/renderD%[1]d/{,*} r,
+`
+
+const (
+	kmsNodePrimary = "primary"
+	kmsNodeRender  = "render"
+
+	// The kernel allocates render nodes at a minor offset of 128 from
+	// the primary node of the same card.
+	kmsRenderMinorBase = 128
+)
+
+// Pattern to match DRM connector names, e.g. HDMI-A-1, eDP-1 or DSI-1.
+var kmsConnectorPattern = regexp.MustCompile("^[A-Za-z][A-Za-z0-9-]*$")
+
+type kmsInterface struct{}
+
+func (iface *kmsInterface) Name() string {
+	return "kms"
+}
+
+func (iface *kmsInterface) StaticInfo() interfaces.StaticInfo {
+	return interfaces.StaticInfo{
+		Summary:              kmsSummary,
+		BaseDeclarationSlots: kmsBaseDeclarationSlots,
+	}
+}
+
+func (iface *kmsInterface) String() string {
+	return iface.Name()
+}
+
+// kmsSlotAttrs holds the validated attributes of a kms slot.
+type kmsSlotAttrs struct {
+	card      int64
+	node      string
+	connector string
+}
+
+func kmsAttrs(slot interfaces.Attrer) (*kmsSlotAttrs, error) {
+	attrs := &kmsSlotAttrs{node: kmsNodePrimary}
+	if err := slot.Attr("card", &attrs.card); err != nil {
+		return nil, fmt.Errorf("kms slot must have a card attribute with the index of the DRM card")
+	}
+	if attrs.card < 0 || attrs.card >= kmsRenderMinorBase {
+		return nil, fmt.Errorf("kms card attribute must be between 0 and %d", kmsRenderMinorBase-1)
+	}
+	if v, ok := slot.Lookup("node"); ok {
+		node, ok := v.(string)
+		if !ok || (node != kmsNodePrimary && node != kmsNodeRender) {
+			return nil, fmt.Errorf("kms node attribute must be %q or %q", kmsNodePrimary, kmsNodeRender)
+		}
+		attrs.node = node
+	}
+	if v, ok := slot.Lookup("connector"); ok {
+		connector, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("kms connector attribute must be a string")
+		}
+		attrs.connector = connector
+	}
+	if attrs.connector != "" {
+		if attrs.node != kmsNodePrimary {
+			return nil, fmt.Errorf("kms connector attribute can only be used with the %s node", kmsNodePrimary)
+		}
+		if !kmsConnectorPattern.MatchString(attrs.connector) {
+			return nil, fmt.Errorf("kms connector attribute must be a valid connector name")
+		}
+	}
+	return attrs, nil
+}
+
+func (iface *kmsInterface) BeforePrepareSlot(slot *snap.SlotInfo) error {
+	_, err := kmsAttrs(slot)
+	return err
+}
+
+func (iface *kmsInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	attrs, err := kmsAttrs(slot)
+	if err != nil {
+		return err
+	}
+	if attrs.node == kmsNodeRender {
+		spec.AddSnippet(fmt.Sprintf(kmsConnectedPlugAppArmorRender, kmsRenderMinorBase+attrs.card))
+		return nil
+	}
+	spec.AddSnippet(fmt.Sprintf(kmsConnectedPlugAppArmorPrimary, attrs.card))
+	if attrs.connector != "" {
+		spec.AddSnippet(fmt.Sprintf(kmsConnectedPlugAppArmorConnector, attrs.card, attrs.connector))
+	} else {
+		spec.AddSnippet(fmt.Sprintf(kmsConnectedPlugAppArmorAllConnectors, attrs.card))
+	}
+	return nil
+}
+
+func (iface *kmsInterface) UDevConnectedPlug(spec *udev.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	attrs, err := kmsAttrs(slot)
+	if err != nil {
+		return err
+	}
+	if attrs.node == kmsNodeRender {
+		spec.TagDevice(fmt.Sprintf(`SUBSYSTEM=="drm", KERNEL=="renderD%d"`, kmsRenderMinorBase+attrs.card))
+	} else {
+		spec.TagDevice(fmt.Sprintf(`SUBSYSTEM=="drm", KERNEL=="card%d"`, attrs.card))
+	}
+	return nil
+}
+
+func (iface *kmsInterface) AutoConnect(*snap.PlugInfo, *snap.SlotInfo) bool {
+	// Allow what is allowed in the declarations
+	return true
+}
+
+func init() {
+	registerIface(&kmsInterface{})
+}
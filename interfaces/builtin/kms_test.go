@@ -0,0 +1,198 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type kmsInterfaceSuite struct {
+	testutil.BaseTest
+	iface interfaces.Interface
+
+	primarySlotInfo   *snap.SlotInfo
+	primarySlot       *interfaces.ConnectedSlot
+	connectorSlotInfo *snap.SlotInfo
+	connectorSlot     *interfaces.ConnectedSlot
+	renderSlotInfo    *snap.SlotInfo
+	renderSlot        *interfaces.ConnectedSlot
+
+	plugInfo *snap.PlugInfo
+	plug     *interfaces.ConnectedPlug
+}
+
+var _ = Suite(&kmsInterfaceSuite{
+	iface: builtin.MustInterface("kms"),
+})
+
+func (s *kmsInterfaceSuite) SetUpTest(c *C) {
+	gadgetSnapInfo := snaptest.MockInfo(c, `
+name: some-device
+version: 0
+type: gadget
+slots:
+  card0:
+    interface: kms
+    card: 0
+  card1-hdmi:
+    interface: kms
+    card: 1
+    node: primary
+    connector: HDMI-A-1
+  card1-render:
+    interface: kms
+    card: 1
+    node: render
+`, nil)
+	s.primarySlotInfo = gadgetSnapInfo.Slots["card0"]
+	s.primarySlot = interfaces.NewConnectedSlot(s.primarySlotInfo, nil, nil)
+	s.connectorSlotInfo = gadgetSnapInfo.Slots["card1-hdmi"]
+	s.connectorSlot = interfaces.NewConnectedSlot(s.connectorSlotInfo, nil, nil)
+	s.renderSlotInfo = gadgetSnapInfo.Slots["card1-render"]
+	s.renderSlot = interfaces.NewConnectedSlot(s.renderSlotInfo, nil, nil)
+
+	consumingSnapInfo := snaptest.MockInfo(c, `
+name: kiosk
+version: 0
+plugs:
+  kms:
+    interface: kms
+apps:
+  app:
+    command: foo
+    plugs: [kms]
+`, nil)
+	s.plugInfo = consumingSnapInfo.Plugs["kms"]
+	s.plug = interfaces.NewConnectedPlug(s.plugInfo, nil, nil)
+}
+
+func (s *kmsInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "kms")
+}
+
+func (s *kmsInterfaceSuite) TestSanitizeSlot(c *C) {
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.primarySlotInfo), IsNil)
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.connectorSlotInfo), IsNil)
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.renderSlotInfo), IsNil)
+}
+
+func (s *kmsInterfaceSuite) TestSanitizeSlotUnhappy(c *C) {
+	const mockSnapYaml = `name: some-device
+type: gadget
+version: 1.0
+slots:
+  kms:
+$t
+`
+
+	for _, t := range []struct {
+		attrs string
+		err   string
+	}{
+		{``, `kms slot must have a card attribute with the index of the DRM card`},
+		{`card: foo`, `kms slot must have a card attribute with the index of the DRM card`},
+		{`card: -1`, `kms card attribute must be between 0 and 127`},
+		{`card: 128`, `kms card attribute must be between 0 and 127`},
+		{"card: 0\nnode: cursor", `kms node attribute must be "primary" or "render"`},
+		{"card: 0\nnode: 1", `kms node attribute must be "primary" or "render"`},
+		{"card: 0\nconnector: [HDMI-A-1]", `kms connector attribute must be a string`},
+		{"card: 0\nconnector: HDMI/A", `kms connector attribute must be a valid connector name`},
+		{"card: 0\nconnector: 1-HDMI", `kms connector attribute must be a valid connector name`},
+		{"card: 0\nnode: render\nconnector: HDMI-A-1", `kms connector attribute can only be used with the primary node`},
+	} {
+		attrs := "    " + strings.Replace(t.attrs, "\n", "\n    ", -1)
+		yml := strings.Replace(mockSnapYaml, "$t", attrs, -1)
+		info := snaptest.MockInfo(c, yml, nil)
+		slot := info.Slots["kms"]
+		c.Check(interfaces.BeforePrepareSlot(s.iface, slot), ErrorMatches, t.err, Commentf("unexpected error for %q", t.attrs))
+	}
+}
+
+func (s *kmsInterfaceSuite) TestAppArmorSpecPrimary(c *C) {
+	spec := &apparmor.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.primarySlot), IsNil)
+	c.Assert(spec.SecurityTags(), DeepEquals, []string{"snap.kiosk.app"})
+	snippet := spec.SnippetForTag("snap.kiosk.app")
+	c.Check(snippet, testutil.Contains, "/dev/dri/card0 rw,\n")
+	c.Check(snippet, testutil.Contains, "/run/udev/data/c226:0 r,\n")
+	c.Check(snippet, testutil.Contains, "/sys/devices/**/drm/card0/card0-*/{,**} r,\n")
+	c.Check(snippet, Not(testutil.Contains), "renderD")
+}
+
+func (s *kmsInterfaceSuite) TestAppArmorSpecConnector(c *C) {
+	spec := &apparmor.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.connectorSlot), IsNil)
+	snippet := spec.SnippetForTag("snap.kiosk.app")
+	c.Check(snippet, testutil.Contains, "/dev/dri/card1 rw,\n")
+	c.Check(snippet, testutil.Contains, "/sys/devices/**/drm/card1/card1-HDMI-A-1/{,**} r,\n")
+	c.Check(snippet, Not(testutil.Contains), "card1-*")
+}
+
+func (s *kmsInterfaceSuite) TestAppArmorSpecRender(c *C) {
+	spec := &apparmor.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.renderSlot), IsNil)
+	snippet := spec.SnippetForTag("snap.kiosk.app")
+	c.Check(snippet, testutil.Contains, "/dev/dri/renderD129 rw,\n")
+	c.Check(snippet, testutil.Contains, "/run/udev/data/c226:129 r,\n")
+	c.Check(snippet, Not(testutil.Contains), "/dev/dri/card")
+}
+
+func (s *kmsInterfaceSuite) TestUDevSpec(c *C) {
+	spec := &udev.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.primarySlot), IsNil)
+	c.Assert(spec.Snippets(), HasLen, 2)
+	c.Check(spec.Snippets()[0], Equals, `# kms
+SUBSYSTEM=="drm", KERNEL=="card0", TAG+="snap_kiosk_app"`)
+	c.Check(spec.Snippets(), testutil.Contains, fmt.Sprintf(`TAG=="snap_kiosk_app", RUN+="%v/snap-device-helper $env{ACTION} snap_kiosk_app $devpath $major:$minor"`, dirs.DistroLibExecDir))
+
+	spec = &udev.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.renderSlot), IsNil)
+	c.Assert(spec.Snippets(), HasLen, 2)
+	c.Check(spec.Snippets()[0], Equals, `# kms
+SUBSYSTEM=="drm", KERNEL=="renderD129", TAG+="snap_kiosk_app"`)
+}
+
+func (s *kmsInterfaceSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Assert(si.ImplicitOnCore, Equals, false)
+	c.Assert(si.ImplicitOnClassic, Equals, false)
+	c.Assert(si.Summary, Equals, `allows access to a specific DRM device node`)
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "kms")
+}
+
+func (s *kmsInterfaceSuite) TestAutoConnect(c *C) {
+	c.Check(s.iface.AutoConnect(nil, nil), Equals, true)
+}
+
+func (s *kmsInterfaceSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
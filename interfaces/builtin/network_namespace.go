@@ -0,0 +1,104 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin
+
+const networkNamespaceSummary = `allows creating and managing network namespaces`
+
+const networkNamespaceBaseDeclarationSlots = `
+  network-namespace:
+    allow-installation:
+      slot-snap-type:
+        - core
+    deny-auto-connection: true
+`
+
+const networkNamespaceConnectedPlugAppArmor = `
+# Description: Can create and manage network namespaces via the standard
+# 'ip netns' command (man ip-netns(8)) as well as the virtual ethernet pairs
+# used to link them to the host. This interface is restricted because it
+# gives privileged access to networking and should only be used with trusted
+# apps, such as container runtimes or VPN managers.
+
+# Network namespaces via 'ip netns'. In order to create network namespaces
+# that persist outside of the process and be entered (eg, via
+# 'ip netns exec ...') the ip command uses mount namespaces such that
+# applications can open the /run/netns/NAME object and use it with setns(2).
+capability sys_admin, # for setns()
+network netlink raw,
+
+/ r,
+/run/netns/ r,     # only 'r' since snap-confine will create this for us
+/run/netns/* rw,
+mount options=(rw, rshared) -> /run/netns/,
+mount options=(rw, bind) /run/netns/ -> /run/netns/,
+mount options=(rw, bind) / -> /run/netns/*,
+umount /run/netns/*,
+
+# 'ip netns exec foo /bin/sh'
+mount options=(rw, rslave) /,
+mount options=(rw, rslave), # LP: #1648245
+umount /sys/,
+mount fstype=sysfs -> /sys/,
+/etc/netns/{,**} r,
+
+# Eg, nsenter --net=/run/netns/... <command>
+/{,usr/}{,s}bin/nsenter ixr,
+/{,usr/}{,s}bin/ip ixr,
+
+# Creating veth pairs and moving links between namespaces is done over
+# rtnetlink and requires net_admin in the namespaces involved.
+capability net_admin,
+/sys/class/net/ r,
+/sys/devices/virtual/net/{,**} r,
+/sys/devices/**/net/{,**} r,
+@{PROC}/@{pid}/net/ r,
+@{PROC}/@{pid}/net/** r,
+@{PROC}/@{pid}/ns/net r,
+@{PROC}/sys/net/ipv{4,6}/conf/*/forwarding rw,
+`
+
+const networkNamespaceConnectedPlugSecComp = `
+# Description: Can create and manage network namespaces and the virtual
+# ethernet pairs used to link them to the host.
+bind
+
+mount
+umount
+umount2
+
+unshare
+setns - CLONE_NEWNET
+
+# for managing links and addresses, including veth pairs
+socket AF_NETLINK - NETLINK_ROUTE
+socket AF_NETLINK - NETLINK_GENERIC
+`
+
+func init() {
+	registerIface(&commonInterface{
+		name:                  "network-namespace",
+		summary:               networkNamespaceSummary,
+		implicitOnCore:        true,
+		implicitOnClassic:     true,
+		baseDeclarationSlots:  networkNamespaceBaseDeclarationSlots,
+		connectedPlugAppArmor: networkNamespaceConnectedPlugAppArmor,
+		connectedPlugSecComp:  networkNamespaceConnectedPlugSecComp,
+	})
+}
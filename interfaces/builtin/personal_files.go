@@ -51,11 +51,11 @@ type personalFilesInterface struct {
 }
 
 func validateSinglePathHome(np string) error {
-	if !strings.HasPrefix(np, "$HOME/") {
-		return fmt.Errorf(`%q must start with "$HOME/"`, np)
+	if !strings.HasPrefix(np, "$HOME/") && !strings.HasPrefix(np, "$SNAP_REAL_HOME/") {
+		return fmt.Errorf(`%q must start with "$HOME/" or "$SNAP_REAL_HOME/"`, np)
 	}
-	if strings.Count(np, "$HOME") > 1 {
-		return fmt.Errorf(`$HOME must only be used at the start of the path of %q`, np)
+	if strings.Count(np, "$HOME")+strings.Count(np, "$SNAP_REAL_HOME") > 1 {
+		return fmt.Errorf(`$HOME or $SNAP_REAL_HOME must only be used at the start of the path of %q`, np)
 	}
 	return nil
 }
@@ -87,6 +87,42 @@ owner "@{HOME}/.write-file{,/,/**}" rwkl,
 `)
 }
 
+func (s *personalFilesInterfaceSuite) TestConnectedPlugAppArmorTemplatedCreate(c *C) {
+	const mockSnapYaml = `name: other
+version: 1.0
+plugs:
+ personal-files:
+  read: [$SNAP_REAL_HOME/.read-file]
+  write: [$SNAP_REAL_HOME/.config/other/settings, $HOME/.config/other/cache, $HOME/.local/share/$UID]
+  create: true
+apps:
+ app:
+  command: foo
+  plugs: [personal-files]
+`
+	info := snaptest.MockInfo(c, mockSnapYaml, nil)
+	plugInfo := info.Plugs["personal-files"]
+	c.Assert(interfaces.BeforePreparePlug(s.iface, plugInfo), IsNil)
+	plug := interfaces.NewConnectedPlug(plugInfo, nil, nil)
+
+	apparmorSpec := &apparmor.Specification{}
+	err := apparmorSpec.AddConnectedPlug(s.iface, plug, s.slot)
+	c.Assert(err, IsNil)
+	c.Check(apparmorSpec.SnippetForTag("snap.other.app"), Equals, `
+# Description: Can access specific personal files or directories in the 
+# users's home directory.
+# This is restricted because it gives file access to arbitrary locations.
+owner "@{HOME}/.read-file{,/,/**}" rk,
+owner "@{HOME}/.config/other/settings{,/,/**}" rwkl,
+owner "@{HOME}/.config/other/cache{,/,/**}" rwkl,
+owner "@{HOME}/.local/share/[0-9]*{,/,/**}" rwkl,
+owner "@{HOME}/.config/" w,
+owner "@{HOME}/.config/other/" w,
+owner "@{HOME}/.local/" w,
+owner "@{HOME}/.local/share/" w,
+`)
+}
+
 func (s *personalFilesInterfaceSuite) TestSanitizeSlot(c *C) {
 	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.slotInfo), IsNil)
 }
@@ -101,7 +137,8 @@ version: 1.0
 plugs:
  personal-files:
   read: ["$HOME/file1", "$HOME/.hidden1"]
-  write: ["$HOME/dir1", "$HOME/.hidden2"]
+  write: ["$HOME/dir1", "$HOME/.hidden2", "$SNAP_REAL_HOME/dir2", "$HOME/.cache/$UID"]
+  create: false
 `
 	info := snaptest.MockInfo(c, mockSnapYaml, nil)
 	plug := info.Plugs["personal-files"]
@@ -123,19 +160,26 @@ plugs:
 		{`read: ""`, `"read" must be a list of strings`},
 		{`read: [ 123 ]`, `"read" must be a list of strings`},
 		{`read: [ "$HOME/foo/./bar" ]`, `cannot use "\$HOME/foo/./bar": try "\$HOME/foo/bar"`},
-		{`read: [ "../foo" ]`, `"../foo" must start with "\$HOME/"`},
+		{`read: [ "../foo" ]`, `"../foo" must start with "\$HOME/" or "\$SNAP_REAL_HOME/"`},
 		{`read: [ "/foo[" ]`, `"/foo\[" contains a reserved apparmor char from .*`},
 		{`write: ""`, `"write" must be a list of strings`},
 		{`write: bar`, `"write" must be a list of strings`},
 		{`read: [ "~/foo" ]`, `"~/foo" cannot contain "~"`},
 		{`read: [ "$HOME/foo/~/foo" ]`, `"\$HOME/foo/~/foo" cannot contain "~"`},
 		{`read: [ "$HOME/foo/../foo" ]`, `cannot use "\$HOME/foo/../foo": try "\$HOME/foo"`},
-		{`read: [ "$HOME/home/$HOME/foo" ]`, `\$HOME must only be used at the start of the path of "\$HOME/home/\$HOME/foo"`},
-		{`read: [ "$HOME/sweet/$HOME" ]`, `\$HOME must only be used at the start of the path of "\$HOME/sweet/\$HOME"`},
+		{`read: [ "$HOME/home/$HOME/foo" ]`, `\$HOME or \$SNAP_REAL_HOME must only be used at the start of the path of "\$HOME/home/\$HOME/foo"`},
+		{`read: [ "$HOME/sweet/$HOME" ]`, `\$HOME or \$SNAP_REAL_HOME must only be used at the start of the path of "\$HOME/sweet/\$HOME"`},
+		{`read: [ "$SNAP_REAL_HOME/foo/$HOME" ]`, `\$HOME or \$SNAP_REAL_HOME must only be used at the start of the path of "\$SNAP_REAL_HOME/foo/\$HOME"`},
+		{`read: [ "$SNAP_REAL_HOME/../foo" ]`, `cannot use "\$SNAP_REAL_HOME/../foo": try "foo"`},
+		{`read: [ "$HOME/.cache-$UID" ]`, `"\$HOME/.cache-\$UID" must only use \$HOME, \$SNAP_REAL_HOME or \$UID as whole path components`},
+		{`read: [ "$HOME/$USER/foo" ]`, `"\$HOME/\$USER/foo" must only use \$HOME, \$SNAP_REAL_HOME or \$UID as whole path components`},
+		{`read: [ "$SNAP_REAL_HOMEX/foo" ]`, `"\$SNAP_REAL_HOMEX/foo" must only use \$HOME, \$SNAP_REAL_HOME or \$UID as whole path components`},
+		{"write: [ \"$HOME/foo\" ]\n  create: yes-please", `"create" must be a boolean`},
+		{"read: [ \"$HOME/foo\" ]\n  create: true", `"create" can only be used with "write"`},
 		{`read: [ "/@{FOO}" ]`, `"/@{FOO}" contains a reserved apparmor char from .*`},
 		{`read: [ "/home/@{HOME}/foo" ]`, `"/home/@{HOME}/foo" contains a reserved apparmor char from .*`},
 		{`read: [ "${HOME}/foo" ]`, `"\${HOME}/foo" contains a reserved apparmor char from .*`},
-		{`read: [ "$HOME" ]`, `"\$HOME" must start with "\$HOME/"`},
+		{`read: [ "$HOME" ]`, `"\$HOME" must start with "\$HOME/" or "\$SNAP_REAL_HOME/"`},
 	}
 
 	for _, t := range testCases {
@@ -0,0 +1,76 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/snap"
+)
+
+const registrySummary = `allows accessing a view of a configuration registry`
+
+const registryBaseDeclarationSlots = `
+  registry:
+    allow-installation:
+      slot-snap-type:
+        - core
+    deny-auto-connection: true
+`
+
+// the view attribute is <registry>/<view>
+var validRegistryView = regexp.MustCompile("^[a-z0-9](?:-?[a-z0-9])*/[a-z0-9](?:-?[a-z0-9])*$")
+
+// registryInterface gives access to a view of a registry through snapctl,
+// which goes through the snapd socket available to all snaps, so no
+// security policy is needed.
+type registryInterface struct {
+	commonInterface
+}
+
+func (iface *registryInterface) BeforePreparePlug(plug *snap.PlugInfo) error {
+	account, ok := plug.Attrs["account"].(string)
+	if !ok || account == "" {
+		return fmt.Errorf(`registry plug must have an "account" attribute`)
+	}
+	if !asserts.IsValidAccountID(account) {
+		return fmt.Errorf(`registry plug must have a valid "account" attribute: %q`, account)
+	}
+	view, ok := plug.Attrs["view"].(string)
+	if !ok || view == "" {
+		return fmt.Errorf(`registry plug must have a "view" attribute`)
+	}
+	if !validRegistryView.MatchString(view) {
+		return fmt.Errorf(`registry plug must have a "view" attribute in the format <registry>/<view>: %q`, view)
+	}
+	return nil
+}
+
+func init() {
+	registerIface(&registryInterface{commonInterface{
+		name:                 "registry",
+		summary:              registrySummary,
+		implicitOnCore:       true,
+		implicitOnClassic:    true,
+		baseDeclarationSlots: registryBaseDeclarationSlots,
+	}})
+}
@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type RegistryInterfaceSuite struct {
+	iface    interfaces.Interface
+	slotInfo *snap.SlotInfo
+	plugInfo *snap.PlugInfo
+}
+
+var _ = Suite(&RegistryInterfaceSuite{
+	iface: builtin.MustInterface("registry"),
+})
+
+const registryConsumerYaml = `name: consumer
+version: 0
+plugs:
+ wifi:
+  interface: registry
+  account: acme
+  view: network/wifi-setup
+apps:
+ app:
+  command: foo
+  plugs: [wifi]
+`
+
+func (s *RegistryInterfaceSuite) SetUpTest(c *C) {
+	s.slotInfo = &snap.SlotInfo{
+		Snap:      &snap.Info{SuggestedName: "core", SnapType: snap.TypeOS},
+		Name:      "registry",
+		Interface: "registry",
+	}
+	info := snaptest.MockInfo(c, registryConsumerYaml, nil)
+	s.plugInfo = info.Plugs["wifi"]
+}
+
+func (s *RegistryInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "registry")
+}
+
+func (s *RegistryInterfaceSuite) TestSanitizeSlot(c *C) {
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.slotInfo), IsNil)
+}
+
+func (s *RegistryInterfaceSuite) TestSanitizePlug(c *C) {
+	c.Assert(interfaces.BeforePreparePlug(s.iface, s.plugInfo), IsNil)
+}
+
+func (s *RegistryInterfaceSuite) TestSanitizePlugErrors(c *C) {
+	for _, t := range []struct {
+		attrs map[string]interface{}
+		err   string
+	}{
+		{map[string]interface{}{"view": "network/wifi-setup"}, `registry plug must have an "account" attribute`},
+		{map[string]interface{}{"account": "a_b", "view": "network/wifi-setup"}, `registry plug must have a valid "account" attribute: "a_b"`},
+		{map[string]interface{}{"account": "acme"}, `registry plug must have a "view" attribute`},
+		{map[string]interface{}{"account": "acme", "view": 42}, `registry plug must have a "view" attribute`},
+		{map[string]interface{}{"account": "acme", "view": "network"}, `registry plug must have a "view" attribute in the format <registry>/<view>: "network"`},
+		{map[string]interface{}{"account": "acme", "view": "network/wifi/setup"}, `registry plug must have a "view" attribute in the format <registry>/<view>: "network/wifi/setup"`},
+	} {
+		plug := &snap.PlugInfo{
+			Snap:      s.plugInfo.Snap,
+			Name:      "wifi",
+			Interface: "registry",
+			Attrs:     t.attrs,
+		}
+		c.Check(interfaces.BeforePreparePlug(s.iface, plug), ErrorMatches, t.err)
+	}
+}
+
+func (s *RegistryInterfaceSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Check(si.ImplicitOnCore, Equals, true)
+	c.Check(si.ImplicitOnClassic, Equals, true)
+	c.Check(si.Summary, Equals, "allows accessing a view of a configuration registry")
+	c.Check(si.BaseDeclarationSlots, testutil.Contains, "registry")
+}
+
+func (s *RegistryInterfaceSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
@@ -19,6 +19,10 @@
 
 package builtin
 
+import (
+	"github.com/snapcore/snapd/interfaces/landlock"
+)
+
 const removableMediaSummary = `allows access to mounted removable storage`
 
 const removableMediaBaseDeclarationSlots = `
@@ -49,6 +53,13 @@ const removableMediaConnectedPlugAppArmor = `
 /mnt/** rwkl,
 `
 
+// Mount points could be in /run/media/<user>/*, /media/<user>/* or /mnt.
+var removableMediaConnectedPlugLandlock = []landlock.Rule{
+	{Access: landlock.ReadWrite, Path: "/media"},
+	{Access: landlock.ReadWrite, Path: "/run/media"},
+	{Access: landlock.ReadWrite, Path: "/mnt"},
+}
+
 func init() {
 	registerIface(&commonInterface{
 		name:                  "removable-media",
@@ -57,5 +68,6 @@ func init() {
 		implicitOnClassic:     true,
 		baseDeclarationSlots:  removableMediaBaseDeclarationSlots,
 		connectedPlugAppArmor: removableMediaConnectedPlugAppArmor,
+		connectedPlugLandlock: removableMediaConnectedPlugLandlock,
 	})
 }
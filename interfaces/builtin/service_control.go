@@ -0,0 +1,102 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/snap"
+)
+
+const serviceControlSummary = `allows controlling the services of the slot snap through snapctl`
+
+// service-control slots are provided by snaps whose services can be
+// restarted by the connected plug snaps with snapctl restart --peer, for
+// instance by a manager snap. Restarting services of another snap is
+// privileged, so do not auto-connect.
+const serviceControlBaseDeclarationSlots = `
+  service-control:
+    allow-installation:
+      slot-snap-type:
+        - app
+    deny-auto-connection: true
+`
+
+type serviceControlInterface struct{}
+
+func (iface *serviceControlInterface) Name() string {
+	return "service-control"
+}
+
+func (iface *serviceControlInterface) StaticInfo() interfaces.StaticInfo {
+	return interfaces.StaticInfo{
+		Summary:              serviceControlSummary,
+		BaseDeclarationSlots: serviceControlBaseDeclarationSlots,
+	}
+}
+
+func (iface *serviceControlInterface) String() string {
+	return iface.Name()
+}
+
+// ServiceControlSlotServices returns the names of the services of the slot
+// snap which can be controlled through the slot, nil meaning all of them.
+func ServiceControlSlotServices(slot interfaces.Attrer) ([]string, error) {
+	v, ok := slot.Lookup("services")
+	if !ok {
+		return nil, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("service-control services attribute must be a list of strings")
+	}
+	services := make([]string, 0, len(list))
+	for _, item := range list {
+		name, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("service-control services attribute must be a list of strings")
+		}
+		services = append(services, name)
+	}
+	return services, nil
+}
+
+func (iface *serviceControlInterface) BeforePrepareSlot(slot *snap.SlotInfo) error {
+	services, err := ServiceControlSlotServices(slot)
+	if err != nil {
+		return err
+	}
+	for _, name := range services {
+		app, ok := slot.Snap.Apps[name]
+		if !ok || !app.IsService() {
+			return fmt.Errorf("service-control services attribute refers to unknown service %q", name)
+		}
+	}
+	return nil
+}
+
+func (iface *serviceControlInterface) AutoConnect(*snap.PlugInfo, *snap.SlotInfo) bool {
+	return true
+}
+
+func init() {
+	registerIface(&serviceControlInterface{})
+}
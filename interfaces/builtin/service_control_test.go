@@ -0,0 +1,167 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type serviceControlInterfaceSuite struct {
+	iface interfaces.Interface
+
+	slotInfo *snap.SlotInfo
+	slot     *interfaces.ConnectedSlot
+	plugInfo *snap.PlugInfo
+	plug     *interfaces.ConnectedPlug
+}
+
+var _ = Suite(&serviceControlInterfaceSuite{
+	iface: builtin.MustInterface("service-control"),
+})
+
+const serviceControlProducerYaml = `name: producer
+version: 0
+slots:
+  control:
+    interface: service-control
+    services: [svc1]
+apps:
+  svc1:
+    command: foo
+    daemon: simple
+  svc2:
+    command: foo
+    daemon: simple
+  cmd:
+    command: foo
+`
+
+const serviceControlConsumerYaml = `name: manager
+version: 0
+plugs:
+  control:
+    interface: service-control
+apps:
+  app:
+    command: foo
+    plugs: [control]
+`
+
+func (s *serviceControlInterfaceSuite) SetUpTest(c *C) {
+	producer := snaptest.MockInfo(c, serviceControlProducerYaml, nil)
+	s.slotInfo = producer.Slots["control"]
+	s.slot = interfaces.NewConnectedSlot(s.slotInfo, nil, nil)
+
+	consumer := snaptest.MockInfo(c, serviceControlConsumerYaml, nil)
+	s.plugInfo = consumer.Plugs["control"]
+	s.plug = interfaces.NewConnectedPlug(s.plugInfo, nil, nil)
+}
+
+func (s *serviceControlInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "service-control")
+}
+
+func (s *serviceControlInterfaceSuite) TestSanitizeSlot(c *C) {
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.slotInfo), IsNil)
+
+	services, err := builtin.ServiceControlSlotServices(s.slotInfo)
+	c.Assert(err, IsNil)
+	c.Check(services, DeepEquals, []string{"svc1"})
+}
+
+func (s *serviceControlInterfaceSuite) TestSanitizeSlotAllServices(c *C) {
+	info := snaptest.MockInfo(c, `name: producer
+version: 0
+slots:
+  control: service-control
+apps:
+  svc1:
+    command: foo
+    daemon: simple
+`, nil)
+	slot := info.Slots["control"]
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, slot), IsNil)
+
+	services, err := builtin.ServiceControlSlotServices(slot)
+	c.Assert(err, IsNil)
+	c.Check(services, IsNil)
+}
+
+func (s *serviceControlInterfaceSuite) TestSanitizeSlotUnhappy(c *C) {
+	for _, t := range []struct {
+		services string
+		err      string
+	}{
+		{`svc1`, `service-control services attribute must be a list of strings`},
+		{`[1]`, `service-control services attribute must be a list of strings`},
+		{`[svc3]`, `service-control services attribute refers to unknown service "svc3"`},
+		{`[cmd]`, `service-control services attribute refers to unknown service "cmd"`},
+	} {
+		info := snaptest.MockInfo(c, `name: producer
+version: 0
+slots:
+  control:
+    interface: service-control
+    services: `+t.services+`
+apps:
+  svc1:
+    command: foo
+    daemon: simple
+  cmd:
+    command: foo
+`, nil)
+		c.Check(interfaces.BeforePrepareSlot(s.iface, info.Slots["control"]), ErrorMatches, t.err, Commentf(t.services))
+	}
+}
+
+func (s *serviceControlInterfaceSuite) TestSanitizePlug(c *C) {
+	c.Assert(interfaces.BeforePreparePlug(s.iface, s.plugInfo), IsNil)
+}
+
+func (s *serviceControlInterfaceSuite) TestAppArmorSpec(c *C) {
+	// the services are controlled through snapctl, no extra permissions
+	// are needed
+	spec := &apparmor.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Check(spec.SecurityTags(), HasLen, 0)
+}
+
+func (s *serviceControlInterfaceSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Assert(si.ImplicitOnCore, Equals, false)
+	c.Assert(si.ImplicitOnClassic, Equals, false)
+	c.Assert(si.Summary, Equals, `allows controlling the services of the slot snap through snapctl`)
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "service-control")
+}
+
+func (s *serviceControlInterfaceSuite) TestAutoConnect(c *C) {
+	c.Check(s.iface.AutoConnect(s.plugInfo, s.slotInfo), Equals, true)
+}
+
+func (s *serviceControlInterfaceSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
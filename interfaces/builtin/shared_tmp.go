@@ -0,0 +1,66 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin
+
+import (
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/mount"
+)
+
+const sharedTmpSummary = `allows applications to use the host or a shared /tmp`
+
+const sharedTmpBaseDeclarationSlots = `
+  shared-tmp:
+    allow-installation:
+      slot-snap-type:
+        - core
+    deny-auto-connection: true
+`
+
+// sharedTmpInterface gates the "tmp-mode" application attribute.
+//
+// Applications normally see the private /tmp of their snap. Applications
+// with tmp-mode set to "host" see the /tmp of the host instead, while
+// applications with tmp-mode set to "shared" see a /tmp shared with all the
+// other applications using the same mode. In both cases the /tmp directory
+// is set up by snap-confine, as instructed by the mount backend.
+type sharedTmpInterface struct {
+	commonInterface
+}
+
+func (iface *sharedTmpInterface) MountConnectedPlug(spec *mount.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	for _, app := range plug.Apps() {
+		switch app.TmpMode {
+		case "host", "shared":
+			spec.SetTmpMode(app.SecurityTag(), app.TmpMode)
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerIface(&sharedTmpInterface{commonInterface{
+		name:                 "shared-tmp",
+		summary:              sharedTmpSummary,
+		implicitOnCore:       true,
+		implicitOnClassic:    true,
+		baseDeclarationSlots: sharedTmpBaseDeclarationSlots,
+	}})
+}
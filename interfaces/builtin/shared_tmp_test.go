@@ -0,0 +1,105 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type SharedTmpInterfaceSuite struct {
+	iface    interfaces.Interface
+	slotInfo *snap.SlotInfo
+	slot     *interfaces.ConnectedSlot
+	plugInfo *snap.PlugInfo
+	plug     *interfaces.ConnectedPlug
+}
+
+var _ = Suite(&SharedTmpInterfaceSuite{
+	iface: builtin.MustInterface("shared-tmp"),
+})
+
+const sharedTmpConsumerYaml = `name: consumer
+version: 0
+apps:
+ host:
+  tmp-mode: host
+  plugs: [shared-tmp]
+ shared:
+  tmp-mode: shared
+  plugs: [shared-tmp]
+ private:
+  plugs: [shared-tmp]
+`
+
+const sharedTmpCoreYaml = `name: core
+version: 0
+type: os
+slots:
+  shared-tmp:
+`
+
+func (s *SharedTmpInterfaceSuite) SetUpTest(c *C) {
+	s.plug, s.plugInfo = MockConnectedPlug(c, sharedTmpConsumerYaml, nil, "shared-tmp")
+	s.slot, s.slotInfo = MockConnectedSlot(c, sharedTmpCoreYaml, nil, "shared-tmp")
+}
+
+func (s *SharedTmpInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "shared-tmp")
+}
+
+func (s *SharedTmpInterfaceSuite) TestSanitizeSlot(c *C) {
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.slotInfo), IsNil)
+}
+
+func (s *SharedTmpInterfaceSuite) TestSanitizePlug(c *C) {
+	c.Assert(interfaces.BeforePreparePlug(s.iface, s.plugInfo), IsNil)
+}
+
+func (s *SharedTmpInterfaceSuite) TestMountSpec(c *C) {
+	spec := &mount.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Check(spec.TmpModes(), DeepEquals, map[string]string{
+		"snap.consumer.host":   "host",
+		"snap.consumer.shared": "shared",
+	})
+	c.Check(spec.MountEntries(), HasLen, 0)
+}
+
+func (s *SharedTmpInterfaceSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Assert(si.ImplicitOnCore, Equals, true)
+	c.Assert(si.ImplicitOnClassic, Equals, true)
+	c.Assert(si.Summary, Equals, `allows applications to use the host or a shared /tmp`)
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "shared-tmp")
+}
+
+func (s *SharedTmpInterfaceSuite) TestAutoConnect(c *C) {
+	c.Assert(s.iface.AutoConnect(s.plugInfo, s.slotInfo), Equals, true)
+}
+
+func (s *SharedTmpInterfaceSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
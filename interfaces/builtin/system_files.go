@@ -56,6 +56,9 @@ func validateSinglePathSystem(np string) error {
 	if strings.Contains(np, "$HOME") {
 		return fmt.Errorf(`$HOME cannot be used in %q`, np)
 	}
+	if strings.Contains(np, "$SNAP_REAL_HOME") {
+		return fmt.Errorf(`$SNAP_REAL_HOME cannot be used in %q`, np)
+	}
 
 	return nil
 }
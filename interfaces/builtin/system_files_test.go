@@ -86,6 +86,40 @@ func (s *systemFilesInterfaceSuite) TestConnectedPlugAppArmor(c *C) {
 `)
 }
 
+func (s *systemFilesInterfaceSuite) TestConnectedPlugAppArmorTemplatedCreate(c *C) {
+	const mockSnapYaml = `name: other
+version: 1.0
+plugs:
+ system-files:
+  read: [/run/user/$UID/other.sock]
+  write: [/var/lib/other/data/db, /var/lib/other/cache, /srv]
+  create: true
+apps:
+ app:
+  command: foo
+  plugs: [system-files]
+`
+	info := snaptest.MockInfo(c, mockSnapYaml, nil)
+	plugInfo := info.Plugs["system-files"]
+	c.Assert(interfaces.BeforePreparePlug(s.iface, plugInfo), IsNil)
+	plug := interfaces.NewConnectedPlug(plugInfo, nil, nil)
+
+	apparmorSpec := &apparmor.Specification{}
+	err := apparmorSpec.AddConnectedPlug(s.iface, plug, s.slot)
+	c.Assert(err, IsNil)
+	c.Check(apparmorSpec.SnippetForTag("snap.other.app"), Equals, `
+# Description: Can access specific system files or directories.
+# This is restricted because it gives file access to arbitrary locations.
+owner "/run/user/[0-9]*/other.sock{,/,/**}" rk,
+"/var/lib/other/data/db{,/,/**}" rwkl,
+"/var/lib/other/cache{,/,/**}" rwkl,
+"/srv{,/,/**}" rwkl,
+"/var/lib/" w,
+"/var/lib/other/" w,
+"/var/lib/other/data/" w,
+`)
+}
+
 func (s *systemFilesInterfaceSuite) TestSanitizeSlot(c *C) {
 	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.slotInfo), IsNil)
 }
@@ -133,6 +167,9 @@ plugs:
 		{`read: [ "$HOME/sweet/$HOME" ]`, `"\$HOME/sweet/\$HOME" must start with "/"`},
 		{`read: [ "/@{FOO}" ]`, `"/@{FOO}" contains a reserved apparmor char from .*`},
 		{`read: [ "/home/@{HOME}/foo" ]`, `"/home/@{HOME}/foo" contains a reserved apparmor char from .*`},
+		{`read: [ "/home/$SNAP_REAL_HOME/foo" ]`, `\$SNAP_REAL_HOME cannot be used in "/home/\$SNAP_REAL_HOME/foo"`},
+		{`read: [ "/run/user/$UID-foo" ]`, `"/run/user/\$UID-foo" must only use \$HOME, \$SNAP_REAL_HOME or \$UID as whole path components`},
+		{"write: [ \"/foo\" ]\n  create: 1", `"create" must be a boolean`},
 	}
 
 	for _, t := range testCases {
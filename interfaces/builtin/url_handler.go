@@ -0,0 +1,57 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin
+
+const urlHandlerSummary = `allows applications to register as handlers of URL schemes`
+
+const urlHandlerBaseDeclarationPlugs = `
+  url-handler:
+    allow-installation: false
+    deny-auto-connection: true
+`
+
+const urlHandlerBaseDeclarationSlots = `
+  url-handler:
+    allow-installation:
+      slot-snap-type:
+        - core
+    deny-auto-connection: true
+`
+
+// urlHandlerInterface gates the "url-schemes" application attribute.
+//
+// Applications listing URL schemes in snap.yaml are registered as the
+// handlers of those schemes with the desktop environment when the snap is
+// linked. As a scheme can only be handled meaningfully by a single
+// application, installing a plug of this interface requires a snap
+// declaration granting it, which is how the ownership of schemes is
+// mediated between snaps. The interface itself has no security policy.
+//
+// Only implicitOnClassic as there is no desktop environment to register
+// the handlers with on core.
+func init() {
+	registerIface(&commonInterface{
+		name:                 "url-handler",
+		summary:              urlHandlerSummary,
+		implicitOnClassic:    true,
+		baseDeclarationPlugs: urlHandlerBaseDeclarationPlugs,
+		baseDeclarationSlots: urlHandlerBaseDeclarationSlots,
+	})
+}
@@ -0,0 +1,98 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type urlHandlerSuite struct {
+	iface    interfaces.Interface
+	slotInfo *snap.SlotInfo
+	slot     *interfaces.ConnectedSlot
+	plugInfo *snap.PlugInfo
+	plug     *interfaces.ConnectedPlug
+}
+
+var _ = Suite(&urlHandlerSuite{
+	iface: builtin.MustInterface("url-handler"),
+})
+
+const urlHandlerConsumerYaml = `name: consumer
+version: 0
+apps:
+ app:
+  command: foo
+  url-schemes: [zoommtg]
+  plugs: [url-handler]
+`
+
+const urlHandlerCoreYaml = `name: core
+version: 0
+type: os
+slots:
+  url-handler:
+`
+
+func (s *urlHandlerSuite) SetUpTest(c *C) {
+	s.plug, s.plugInfo = MockConnectedPlug(c, urlHandlerConsumerYaml, nil, "url-handler")
+	s.slot, s.slotInfo = MockConnectedSlot(c, urlHandlerCoreYaml, nil, "url-handler")
+}
+
+func (s *urlHandlerSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "url-handler")
+}
+
+func (s *urlHandlerSuite) TestSanitizeSlot(c *C) {
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.slotInfo), IsNil)
+}
+
+func (s *urlHandlerSuite) TestSanitizePlug(c *C) {
+	c.Assert(interfaces.BeforePreparePlug(s.iface, s.plugInfo), IsNil)
+}
+
+func (s *urlHandlerSuite) TestNoAppArmorPolicy(c *C) {
+	apparmorSpec := &apparmor.Specification{}
+	err := apparmorSpec.AddConnectedPlug(s.iface, s.plug, s.slot)
+	c.Assert(err, IsNil)
+	c.Assert(apparmorSpec.SecurityTags(), HasLen, 0)
+}
+
+func (s *urlHandlerSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
+
+func (s *urlHandlerSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Assert(si.ImplicitOnCore, Equals, false)
+	c.Assert(si.ImplicitOnClassic, Equals, true)
+	c.Assert(si.Summary, Equals, `allows applications to register as handlers of URL schemes`)
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "url-handler")
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "deny-auto-connection: true")
+	c.Assert(si.BaseDeclarationPlugs, testutil.Contains, "url-handler")
+	c.Assert(si.BaseDeclarationPlugs, testutil.Contains, "deny-auto-connection: true")
+	c.Assert(si.BaseDeclarationPlugs, testutil.Contains, "allow-installation: false")
+}
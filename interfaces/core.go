@@ -184,6 +184,13 @@ type SlotSanitizer interface {
 	BeforePrepareSlot(slot *snap.SlotInfo) error
 }
 
+// PlugAttrsOverrider can be implemented by Interfaces that allow the system
+// administrator to override attributes of the plug side of a connection. The
+// overrides should only narrow down the permissions granted by the connection.
+type PlugAttrsOverrider interface {
+	ValidatePlugAttrsOverride(plug *snap.PlugInfo, attrs map[string]interface{}) error
+}
+
 // StaticInfo describes various static-info of a given interface.
 //
 // The Summary must be a one-line string of length suitable for listing views.
@@ -248,6 +255,15 @@ type Specification interface {
 	AddConnectedPlug(iface Interface, plug *ConnectedPlug, slot *ConnectedSlot) error
 }
 
+// ConnectionAwareSpecification is implemented by specifications that can
+// tell whether connections of a given interface contribute to them.
+type ConnectionAwareSpecification interface {
+	Specification
+	// ConnectionsAffect returns whether connecting or disconnecting plugs
+	// and slots of the given interface can change the specification.
+	ConnectionsAffect(iface Interface) bool
+}
+
 // SecuritySystem is a name of a security system.
 type SecuritySystem string
 
@@ -266,6 +282,8 @@ const (
 	SecurityKMod SecuritySystem = "kmod"
 	// SecuritySystemd identifies the systemd services security system.
 	SecuritySystemd SecuritySystem = "systemd"
+	// SecurityLandlock identifies the landlock security system.
+	SecurityLandlock SecuritySystem = "landlock"
 )
 
 var isValidBusName = regexp.MustCompile(`^[a-zA-Z_-][a-zA-Z0-9_-]*(\.[a-zA-Z_-][a-zA-Z0-9_-]*)+$`).MatchString
@@ -25,6 +25,11 @@
 // Each configuration is an XML file containing <busconfig>...</busconfig>.
 // Particular security snippets define whole <policy>...</policy> entires.
 // This is explained in detail in https://dbus.freedesktop.org/doc/dbus-daemon.1.html
+//
+// When the dbus-proxy feature is enabled, snappy additionally writes the
+// filtering policy of an xdg-dbus-proxy for each application, so that the
+// access of snaps to the buses can be mediated on systems where AppArmor
+// cannot mediate D-Bus.
 package dbus
 
 import (
@@ -32,8 +37,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
@@ -171,9 +178,66 @@ func (b *Backend) Setup(snapInfo *snap.Info, opts interfaces.ConfinementOptions,
 	if err != nil {
 		return fmt.Errorf("cannot synchronize DBus configuration files for snap %q: %s", snapName, err)
 	}
+
+	if err := b.setupProxyPolicy(spec.(*Specification), snapInfo); err != nil {
+		return fmt.Errorf("cannot synchronize DBus proxy policy files for snap %q: %s", snapName, err)
+	}
 	return nil
 }
 
+func proxyPolicyGlob(snapName string) string {
+	return fmt.Sprintf("%s.*-bus", interfaces.SecurityTagGlob(snapName))
+}
+
+// setupProxyPolicy writes the arguments of the D-Bus proxy of each
+// application or hook of the snap, for each bus, or removes them if the
+// dbus-proxy feature is disabled.
+func (b *Backend) setupProxyPolicy(spec *Specification, snapInfo *snap.Info) error {
+	var content map[string]osutil.FileState
+	if features.DBusProxy.IsEnabled() {
+		content = deriveProxyContent(spec, snapInfo)
+	}
+	if len(content) > 0 {
+		if err := os.MkdirAll(dirs.SnapDBusProxyPolicyDir, 0755); err != nil {
+			return err
+		}
+	}
+	_, _, err := osutil.EnsureDirState(dirs.SnapDBusProxyPolicyDir, proxyPolicyGlob(snapInfo.InstanceName()), content)
+	return err
+}
+
+// deriveProxyContent returns the arguments of the D-Bus proxies as a
+// content map applicable to EnsureDirState. The arguments are NUL
+// terminated so that they can be passed to xdg-dbus-proxy with --args.
+func deriveProxyContent(spec *Specification, snapInfo *snap.Info) map[string]osutil.FileState {
+	var tags []string
+	for _, appInfo := range snapInfo.Apps {
+		tags = append(tags, appInfo.SecurityTag())
+	}
+	for _, hookInfo := range snapInfo.Hooks {
+		tags = append(tags, hookInfo.SecurityTag())
+	}
+
+	var content map[string]osutil.FileState
+	for _, tag := range tags {
+		for _, bus := range []string{"session", "system"} {
+			args := spec.ProxyRulesForTag(tag, bus)
+			if len(args) == 0 {
+				continue
+			}
+			if content == nil {
+				content = make(map[string]osutil.FileState)
+			}
+			args = append([]string{"--filter"}, args...)
+			content[fmt.Sprintf("%s.%s-bus", tag, bus)] = &osutil.MemoryFileState{
+				Content: []byte(strings.Join(args, "\x00") + "\x00"),
+				Mode:    0644,
+			}
+		}
+	}
+	return content
+}
+
 // Remove removes dbus configuration files of a given snap.
 //
 // This method should be called after removing a snap.
@@ -183,6 +247,10 @@ func (b *Backend) Remove(snapName string) error {
 	if err != nil {
 		return fmt.Errorf("cannot synchronize DBus configuration files for snap %q: %s", snapName, err)
 	}
+	_, _, err = osutil.EnsureDirState(dirs.SnapDBusProxyPolicyDir, proxyPolicyGlob(snapName), nil)
+	if err != nil {
+		return fmt.Errorf("cannot synchronize DBus proxy policy files for snap %q: %s", snapName, err)
+	}
 	return nil
 }
 
@@ -28,6 +28,7 @@ import (
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/dbus"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
@@ -279,6 +280,53 @@ func (s *backendSuite) TestAppBoundIfaces(c *C) {
 	c.Check(err, IsNil)
 }
 
+func (s *backendSuite) mockDBusProxyFeature(c *C, enabled bool) {
+	f := features.DBusProxy.ControlFile()
+	if !enabled {
+		c.Assert(os.RemoveAll(f), IsNil)
+		return
+	}
+	c.Assert(os.MkdirAll(filepath.Dir(f), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(f, nil, 0644), IsNil)
+}
+
+func (s *backendSuite) TestSetupWritesProxyPolicy(c *C) {
+	s.mockDBusProxyFeature(c, true)
+	s.Iface.DBusPermanentSlotCallback = func(spec *dbus.Specification, slot *snap.SlotInfo) error {
+		spec.AddProxyRule("session", dbus.ProxyOwn, "org.samba", "")
+		spec.AddProxyRule("system", dbus.ProxyTalk, "org.freedesktop.NetworkManager", "")
+		return nil
+	}
+	for _, opts := range testedConfinementOpts {
+		snapInfo := s.InstallSnap(c, opts, "", ifacetest.SambaYamlV1, 0)
+		c.Check(filepath.Join(dirs.SnapDBusProxyPolicyDir, "snap.samba.smbd.session-bus"), testutil.FileEquals, "--filter\x00--own=org.samba\x00")
+		c.Check(filepath.Join(dirs.SnapDBusProxyPolicyDir, "snap.samba.smbd.system-bus"), testutil.FileEquals, "--filter\x00--talk=org.freedesktop.NetworkManager\x00")
+		// no bus policy was requested
+		c.Check(filepath.Join(dirs.SnapDBusSystemPolicyDir, "snap.samba.smbd.conf"), testutil.FileAbsent)
+
+		s.RemoveSnap(c, snapInfo)
+		c.Check(filepath.Join(dirs.SnapDBusProxyPolicyDir, "snap.samba.smbd.session-bus"), testutil.FileAbsent)
+		c.Check(filepath.Join(dirs.SnapDBusProxyPolicyDir, "snap.samba.smbd.system-bus"), testutil.FileAbsent)
+	}
+}
+
+func (s *backendSuite) TestSetupProxyPolicyFeatureDisabled(c *C) {
+	s.Iface.DBusPermanentSlotCallback = func(spec *dbus.Specification, slot *snap.SlotInfo) error {
+		spec.AddProxyRule("session", dbus.ProxyOwn, "org.samba", "")
+		return nil
+	}
+	s.mockDBusProxyFeature(c, true)
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	policy := filepath.Join(dirs.SnapDBusProxyPolicyDir, "snap.samba.smbd.session-bus")
+	c.Check(policy, testutil.FilePresent)
+
+	// disabling the feature removes the policy on the next setup
+	s.mockDBusProxyFeature(c, false)
+	snapInfo = s.UpdateSnap(c, snapInfo, interfaces.ConfinementOptions{}, ifacetest.SambaYamlV1, 0)
+	c.Check(policy, testutil.FileAbsent)
+	s.RemoveSnap(c, snapInfo)
+}
+
 func (s *backendSuite) TestSandboxFeatures(c *C) {
 	c.Assert(s.Backend.SandboxFeatures(), DeepEquals, []string{"mediated-bus-access"})
 }
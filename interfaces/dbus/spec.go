@@ -21,17 +21,34 @@ package dbus
 
 import (
 	"bytes"
+	"fmt"
 	"sort"
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/snap"
 )
 
+// ProxyPolicy is the kind of access granted by a rule of the D-Bus proxy
+// mediating the access of a snap to a bus, see xdg-dbus-proxy(1).
+type ProxyPolicy string
+
+const (
+	ProxySee       ProxyPolicy = "see"
+	ProxyTalk      ProxyPolicy = "talk"
+	ProxyOwn       ProxyPolicy = "own"
+	ProxyCall      ProxyPolicy = "call"
+	ProxyBroadcast ProxyPolicy = "broadcast"
+)
+
 // Specification keeps all the dbus snippets.
 type Specification struct {
 	// Snippets are indexed by security tag.
 	snippets     map[string][]string
 	securityTags []string
+
+	// proxyRules are the arguments of the D-Bus proxy indexed by
+	// security tag and bus.
+	proxyRules map[string]map[string][]string
 }
 
 // AddSnippet adds a new dbus snippet.
@@ -77,8 +94,56 @@ func (spec *Specification) SecurityTags() []string {
 	return tags
 }
 
+// AddProxyRule adds a rule to the policy of the D-Bus proxy for the given
+// bus, either "system" or "session". The rule is only meaningful for the
+// call and broadcast policies and has the form [METHOD][@PATH].
+func (spec *Specification) AddProxyRule(bus string, policy ProxyPolicy, name, rule string) {
+	if len(spec.securityTags) == 0 {
+		return
+	}
+	if spec.proxyRules == nil {
+		spec.proxyRules = make(map[string]map[string][]string)
+	}
+	arg := fmt.Sprintf("--%s=%s", policy, name)
+	if rule != "" {
+		arg += "=" + rule
+	}
+	for _, tag := range spec.securityTags {
+		if spec.proxyRules[tag] == nil {
+			spec.proxyRules[tag] = make(map[string][]string)
+		}
+		args := spec.proxyRules[tag][bus]
+		i := sort.SearchStrings(args, arg)
+		if i < len(args) && args[i] == arg {
+			continue
+		}
+		args = append(args, "")
+		copy(args[i+1:], args[i:])
+		args[i] = arg
+		spec.proxyRules[tag][bus] = args
+	}
+}
+
+// ProxyRulesForTag returns the sorted arguments of the D-Bus proxy for the
+// given security tag and bus.
+func (spec *Specification) ProxyRulesForTag(tag, bus string) []string {
+	return append([]string(nil), spec.proxyRules[tag][bus]...)
+}
+
 // Implementation of methods required by interfaces.Specification
 
+// ConnectionsAffect returns whether connections of the given interface can
+// contribute D-Bus policy snippets.
+func (spec *Specification) ConnectionsAffect(iface interfaces.Interface) bool {
+	_, plugDefiner := iface.(interface {
+		DBusConnectedPlug(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	_, slotDefiner := iface.(interface {
+		DBusConnectedSlot(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	return plugDefiner || slotDefiner
+}
+
 // AddConnectedPlug records dbus-specific side-effects of having a connected plug.
 func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	type definer interface {
@@ -103,3 +103,44 @@ func (s *specSuite) TestSpecificationIface(c *C) {
 
 	c.Assert(s.spec.SnippetForTag("non-existing"), Equals, "")
 }
+
+func (s *specSuite) TestProxyRules(c *C) {
+	iface := &ifacetest.TestInterface{
+		InterfaceName: "test",
+		DBusConnectedPlugCallback: func(spec *dbus.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			spec.AddProxyRule("session", dbus.ProxyTalk, "org.foo", "")
+			spec.AddProxyRule("session", dbus.ProxyCall, "org.bar", "org.bar.Baz.*@/org/bar/*")
+			spec.AddProxyRule("system", dbus.ProxySee, "org.foo", "")
+			// duplicated rules are ignored
+			spec.AddProxyRule("session", dbus.ProxyTalk, "org.foo", "")
+			return nil
+		},
+		DBusPermanentSlotCallback: func(spec *dbus.Specification, slot *snap.SlotInfo) error {
+			spec.AddProxyRule("session", dbus.ProxyOwn, "org.foo", "")
+			return nil
+		},
+	}
+	c.Assert(s.spec.AddConnectedPlug(iface, s.plug, s.slot), IsNil)
+	c.Assert(s.spec.AddPermanentSlot(iface, s.slotInfo), IsNil)
+	// rules added outside of the callbacks are ignored
+	s.spec.AddProxyRule("session", dbus.ProxyOwn, "org.other", "")
+
+	c.Check(s.spec.ProxyRulesForTag("snap.snap1.app1", "session"), DeepEquals, []string{
+		"--call=org.bar=org.bar.Baz.*@/org/bar/*",
+		"--talk=org.foo",
+	})
+	c.Check(s.spec.ProxyRulesForTag("snap.snap1.app1", "system"), DeepEquals, []string{"--see=org.foo"})
+	c.Check(s.spec.ProxyRulesForTag("snap.snap2.app2", "session"), DeepEquals, []string{"--own=org.foo"})
+	c.Check(s.spec.ProxyRulesForTag("snap.snap2.app2", "system"), HasLen, 0)
+	c.Check(s.spec.ProxyRulesForTag("non-existing", "session"), HasLen, 0)
+	// proxy rules alone do not result in bus policy
+	c.Check(s.spec.SecurityTags(), HasLen, 0)
+}
+
+func (s *specSuite) TestConnectionsAffect(c *C) {
+	spec := &dbus.Specification{}
+	c.Check(spec.ConnectionsAffect(&ifacetest.TestInterface{InterfaceName: "test"}), Equals, true)
+	// an interface that does not define any connected side-effects
+	iface := struct{ interfaces.Interface }{&ifacetest.TestInterface{InterfaceName: "test"}}
+	c.Check(spec.ConnectionsAffect(iface), Equals, false)
+}
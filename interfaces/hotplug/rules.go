@@ -0,0 +1,147 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hotplug
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+// MatchRuleRequirer can be implemented by hotplug interfaces that must only
+// create slots for devices matching one of the declared match rules, e.g.
+// because creating a slot for every device handled by the interface would
+// be too broad.
+type MatchRuleRequirer interface {
+	HotplugRequiresMatchRule() bool
+}
+
+// MatchRule describes USB devices for which a slot of the given interface
+// should be created when they are plugged, as declared by the gadget (through
+// its defaults) or by the system administrator.
+type MatchRule struct {
+	// Interface is the name of the hotplug interface of the slot.
+	Interface string `json:"interface"`
+	// Name is the name of the slot created for a matching device. When
+	// left empty the name is derived from the attributes of the device.
+	Name string `json:"name,omitempty"`
+	// USBVendor is the USB vendor ID of the device as 4 hex digits.
+	USBVendor string `json:"usb-vendor,omitempty"`
+	// USBProduct is the USB product ID of the device as 4 hex digits.
+	USBProduct string `json:"usb-product,omitempty"`
+	// USBInterfaceClass is the class of any of the USB interfaces of the
+	// device as 2 hex digits.
+	USBInterfaceClass string `json:"usb-interface-class,omitempty"`
+}
+
+var (
+	usbIDPattern    = regexp.MustCompile("^[0-9a-f]{4}$")
+	usbClassPattern = regexp.MustCompile("^[0-9a-f]{2}$")
+)
+
+// Validate checks that the match rule is well formed.
+func (r *MatchRule) Validate() error {
+	if r.Interface == "" {
+		return fmt.Errorf("interface must be set")
+	}
+	if r.Name != "" {
+		if err := snap.ValidateSlotName(r.Name); err != nil {
+			return err
+		}
+	}
+	if r.USBVendor == "" && r.USBInterfaceClass == "" {
+		return fmt.Errorf("usb-vendor or usb-interface-class must be set")
+	}
+	if r.USBProduct != "" && r.USBVendor == "" {
+		return fmt.Errorf("usb-product cannot be set without usb-vendor")
+	}
+	if r.USBVendor != "" && !usbIDPattern.MatchString(r.USBVendor) {
+		return fmt.Errorf("invalid usb-vendor %q: must be 4 lowercase hex digits", r.USBVendor)
+	}
+	if r.USBProduct != "" && !usbIDPattern.MatchString(r.USBProduct) {
+		return fmt.Errorf("invalid usb-product %q: must be 4 lowercase hex digits", r.USBProduct)
+	}
+	if r.USBInterfaceClass != "" && !usbClassPattern.MatchString(r.USBInterfaceClass) {
+		return fmt.Errorf("invalid usb-interface-class %q: must be 2 lowercase hex digits", r.USBInterfaceClass)
+	}
+	return nil
+}
+
+// Matches returns true if the given USB device matches the rule.
+func (r *MatchRule) Matches(di *HotplugDeviceInfo) bool {
+	if bus, _ := di.Attribute("ID_BUS"); bus != "usb" {
+		return false
+	}
+	if r.USBVendor != "" {
+		if vendor, _ := di.Attribute("ID_VENDOR_ID"); strings.ToLower(vendor) != r.USBVendor {
+			return false
+		}
+	}
+	if r.USBProduct != "" {
+		if product, _ := di.Attribute("ID_MODEL_ID"); strings.ToLower(product) != r.USBProduct {
+			return false
+		}
+	}
+	if r.USBInterfaceClass != "" {
+		// ID_USB_INTERFACES is a list of class, subclass and protocol
+		// triplets of all the interfaces of the device, each one
+		// preceded by ':', e.g. ":0a0000:020201:"
+		ifaces, _ := di.Attribute("ID_USB_INTERFACES")
+		if !strings.Contains(strings.ToLower(ifaces), ":"+r.USBInterfaceClass) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateMatchRules checks that the given match rules are well formed and
+// that the names of the slots they create are unique.
+func ValidateMatchRules(rules []*MatchRule) error {
+	names := make(map[string]bool)
+	for i, r := range rules {
+		if r == nil {
+			return fmt.Errorf("invalid hotplug match rule #%d: rule cannot be empty", i)
+		}
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("invalid hotplug match rule #%d: %v", i, err)
+		}
+		if r.Name == "" {
+			continue
+		}
+		if names[r.Name] {
+			return fmt.Errorf("invalid hotplug match rule #%d: slot name %q is already used", i, r.Name)
+		}
+		names[r.Name] = true
+	}
+	return nil
+}
+
+// FindMatchRule returns the first of the given rules matching the device, or
+// nil if none does.
+func FindMatchRule(rules []*MatchRule, di *HotplugDeviceInfo) *MatchRule {
+	for _, r := range rules {
+		if r.Matches(di) {
+			return r
+		}
+	}
+	return nil
+}
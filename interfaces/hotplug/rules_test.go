@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hotplug
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type rulesSuite struct{}
+
+var _ = Suite(&rulesSuite{})
+
+func (s *rulesSuite) TestValidateMatchRulesHappy(c *C) {
+	rules := []*MatchRule{
+		{Interface: "serial-port", Name: "gps", USBVendor: "1546", USBProduct: "01a7"},
+		{Interface: "serial-port", USBVendor: "0403"},
+		{Interface: "hidraw", Name: "keypad", USBInterfaceClass: "03"},
+	}
+	c.Check(ValidateMatchRules(rules), IsNil)
+	c.Check(ValidateMatchRules(nil), IsNil)
+}
+
+func (s *rulesSuite) TestValidateMatchRulesErrors(c *C) {
+	for _, tc := range []struct {
+		rule *MatchRule
+		err  string
+	}{
+		{nil, `invalid hotplug match rule #0: rule cannot be empty`},
+		{&MatchRule{USBVendor: "1546"}, `invalid hotplug match rule #0: interface must be set`},
+		{&MatchRule{Interface: "hidraw", Name: "foo!", USBVendor: "1546"}, `invalid hotplug match rule #0: invalid slot name: "foo!"`},
+		{&MatchRule{Interface: "hidraw", Name: "foo"}, `invalid hotplug match rule #0: usb-vendor or usb-interface-class must be set`},
+		{&MatchRule{Interface: "hidraw", USBProduct: "01a7", USBInterfaceClass: "03"}, `invalid hotplug match rule #0: usb-product cannot be set without usb-vendor`},
+		{&MatchRule{Interface: "hidraw", USBVendor: "154"}, `invalid hotplug match rule #0: invalid usb-vendor "154": must be 4 lowercase hex digits`},
+		{&MatchRule{Interface: "hidraw", USBVendor: "ABCD"}, `invalid hotplug match rule #0: invalid usb-vendor "ABCD": must be 4 lowercase hex digits`},
+		{&MatchRule{Interface: "hidraw", USBVendor: "1546", USBProduct: "xyzw"}, `invalid hotplug match rule #0: invalid usb-product "xyzw": must be 4 lowercase hex digits`},
+		{&MatchRule{Interface: "hidraw", USBInterfaceClass: "003"}, `invalid hotplug match rule #0: invalid usb-interface-class "003": must be 2 lowercase hex digits`},
+	} {
+		err := ValidateMatchRules([]*MatchRule{tc.rule})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.rule))
+	}
+
+	rules := []*MatchRule{
+		{Interface: "serial-port", Name: "dev", USBVendor: "1546"},
+		{Interface: "hidraw", Name: "dev", USBVendor: "0403"},
+	}
+	c.Check(ValidateMatchRules(rules), ErrorMatches, `invalid hotplug match rule #1: slot name "dev" is already used`)
+}
+
+func (s *rulesSuite) TestMatches(c *C) {
+	di, err := NewHotplugDeviceInfo(map[string]string{
+		"DEVPATH":           "/sys/foo/bar",
+		"DEVNAME":           "/dev/ttyACM0",
+		"SUBSYSTEM":         "tty",
+		"ID_BUS":            "usb",
+		"ID_VENDOR_ID":      "1546",
+		"ID_MODEL_ID":       "01A7",
+		"ID_USB_INTERFACES": ":020201:0a0000:",
+	})
+	c.Assert(err, IsNil)
+
+	for _, tc := range []struct {
+		rule    MatchRule
+		matches bool
+	}{
+		{MatchRule{USBVendor: "1546"}, true},
+		{MatchRule{USBVendor: "1546", USBProduct: "01a7"}, true},
+		{MatchRule{USBVendor: "1546", USBProduct: "01a8"}, false},
+		{MatchRule{USBVendor: "0403"}, false},
+		{MatchRule{USBInterfaceClass: "02"}, true},
+		{MatchRule{USBInterfaceClass: "0a"}, true},
+		{MatchRule{USBInterfaceClass: "03"}, false},
+		{MatchRule{USBInterfaceClass: "00"}, false},
+		{MatchRule{USBVendor: "1546", USBInterfaceClass: "03"}, false},
+	} {
+		c.Check(tc.rule.Matches(di), Equals, tc.matches, Commentf("%+v", tc.rule))
+	}
+
+	// only USB devices are matched
+	di, err = NewHotplugDeviceInfo(map[string]string{
+		"DEVPATH":      "/sys/foo/bar",
+		"ID_VENDOR_ID": "1546",
+	})
+	c.Assert(err, IsNil)
+	rule := &MatchRule{USBVendor: "1546"}
+	c.Check(rule.Matches(di), Equals, false)
+}
+
+func (s *rulesSuite) TestFindMatchRule(c *C) {
+	di, err := NewHotplugDeviceInfo(map[string]string{
+		"DEVPATH":      "/sys/foo/bar",
+		"ID_BUS":       "usb",
+		"ID_VENDOR_ID": "1546",
+		"ID_MODEL_ID":  "01a7",
+	})
+	c.Assert(err, IsNil)
+
+	rules := []*MatchRule{
+		{Name: "other", USBVendor: "0403"},
+		{Name: "gps", USBVendor: "1546", USBProduct: "01a7"},
+		{Name: "any-ublox", USBVendor: "1546"},
+	}
+	c.Check(FindMatchRule(rules, di), Equals, rules[1])
+	c.Check(FindMatchRule(rules[:1], di), IsNil)
+	c.Check(FindMatchRule(nil, di), IsNil)
+}
@@ -129,3 +129,17 @@ func (b *TestSecurityBackendDiscardingLate) RemoveLate(snapName string, rev snap
 	}
 	return b.RemoveLateCallback(snapName, rev, typ)
 }
+
+// TestSecurityBackendConnectionAware is a security backend whose
+// specifications tell which interfaces affect them when connected.
+type TestSecurityBackendConnectionAware struct {
+	TestSecurityBackend
+
+	// ConnectionsAffectCallback is an callback that is optionally called
+	// to decide whether connections of an interface affect the backend.
+	ConnectionsAffectCallback func(iface interfaces.Interface) bool
+}
+
+func (b *TestSecurityBackendConnectionAware) NewSpecification() interfaces.Specification {
+	return &ConnectionAwareSpecification{ConnectionsAffectCallback: b.ConnectionsAffectCallback}
+}
@@ -79,3 +79,19 @@ func (spec *Specification) AddPermanentSlot(iface interfaces.Interface, slot *sn
 	}
 	return nil
 }
+
+// ConnectionAwareSpecification is a specification intended for testing that
+// tells which interfaces affect it when connected.
+type ConnectionAwareSpecification struct {
+	Specification
+	ConnectionsAffectCallback func(iface interfaces.Interface) bool
+}
+
+// ConnectionsAffect calls the callback if one is defined, otherwise all
+// interfaces are considered to affect the specification.
+func (spec *ConnectionAwareSpecification) ConnectionsAffect(iface interfaces.Interface) bool {
+	if spec.ConnectionsAffectCallback == nil {
+		return true
+	}
+	return spec.ConnectionsAffectCallback(iface)
+}
@@ -59,6 +59,18 @@ func (spec *Specification) Modules() map[string]bool {
 
 // Implementation of methods required by interfaces.Specification
 
+// ConnectionsAffect returns whether connections of the given interface can
+// contribute kernel modules to load or module options.
+func (spec *Specification) ConnectionsAffect(iface interfaces.Interface) bool {
+	_, plugDefiner := iface.(interface {
+		KModConnectedPlug(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	_, slotDefiner := iface.(interface {
+		KModConnectedSlot(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	return plugDefiner || slotDefiner
+}
+
 // AddConnectedPlug records kmod-specific side-effects of having a connected plug.
 func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	type definer interface {
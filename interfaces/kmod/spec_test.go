@@ -127,3 +127,11 @@ func (s *specSuite) TestSpecificationIface(c *C) {
 	c.Assert(s.spec.Modules(), DeepEquals, map[string]bool{
 		"module1": true, "module2": true, "module3": true, "module4": true})
 }
+
+func (s *specSuite) TestConnectionsAffect(c *C) {
+	spec := &kmod.Specification{}
+	c.Check(spec.ConnectionsAffect(&ifacetest.TestInterface{InterfaceName: "test"}), Equals, true)
+	// an interface that does not define any connected side-effects
+	iface := struct{ interfaces.Interface }{&ifacetest.TestInterface{InterfaceName: "test"}}
+	c.Check(spec.ConnectionsAffect(iface), Equals, false)
+}
@@ -0,0 +1,151 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package landlock implements a backend which confines access to the
+// filesystem of snap applications and hooks with landlock.
+//
+// The backend is used on systems where apparmor is not available, as is the
+// case on Fedora or Arch Linux, and narrows the gap between strict
+// confinement and devmode there. Interfaces may grant access to additional
+// parts of the filesystem by providing rules via their respective
+// "Landlock*" methods for interfaces.SecurityLandlock security system.
+//
+// The rules are stored in /var/lib/snapd/landlock/<security-tag>.rules, one
+// rule per line, in the form "<access> <path>" where access is either "r" or
+// "rw". Paths may start with a variable like $SNAP_DATA which is expanded by
+// snap-confine. Before executing the application snap-confine translates the
+// rules into a landlock ruleset and applies it to the process. When no rules
+// exist for a security tag the process is not confined by landlock.
+package landlock
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/sandbox/landlock"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timings"
+)
+
+// Backend is responsible for maintaining landlock rules for snap applications
+// and hooks.
+type Backend struct{}
+
+// Initialize does nothing.
+func (b *Backend) Initialize(*interfaces.SecurityBackendOptions) error {
+	return nil
+}
+
+// Name returns the name of the backend.
+func (b *Backend) Name() interfaces.SecuritySystem {
+	return interfaces.SecurityLandlock
+}
+
+// Setup creates the landlock rules files specific to a given snap. Snaps
+// using devmode or classic confinement are not confined by landlock, unless
+// jailmode is also used.
+//
+// This method should be called after changing plug, slots, connections
+// between them or application present in the snap.
+func (b *Backend) Setup(snapInfo *snap.Info, opts interfaces.ConfinementOptions, repo *interfaces.Repository, tm timings.Measurer) error {
+	snapName := snapInfo.InstanceName()
+	// Get the rules that apply to this snap
+	spec, err := repo.SnapSpecification(b.Name(), snapName)
+	if err != nil {
+		return fmt.Errorf("cannot obtain landlock specification for snap %q: %s", snapName, err)
+	}
+
+	content := deriveContent(spec.(*Specification), snapInfo, opts)
+	dir := dirs.SnapLandlockDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory for landlock rules %q: %s", dir, err)
+	}
+	glob := interfaces.SecurityTagGlob(snapName)
+	_, _, err = osutil.EnsureDirState(dir, glob, content)
+	if err != nil {
+		return fmt.Errorf("cannot synchronize landlock rules for snap %q: %s", snapName, err)
+	}
+	return nil
+}
+
+// Remove removes the landlock rules files specific to a given snap.
+//
+// This method should be called after removing a snap.
+func (b *Backend) Remove(snapName string) error {
+	glob := interfaces.SecurityTagGlob(snapName)
+	_, _, err := osutil.EnsureDirState(dirs.SnapLandlockDir, glob, nil)
+	if err != nil {
+		return fmt.Errorf("cannot synchronize landlock rules for snap %q: %s", snapName, err)
+	}
+	return nil
+}
+
+func deriveContent(spec *Specification, snapInfo *snap.Info, opts interfaces.ConfinementOptions) map[string]osutil.FileState {
+	if (opts.DevMode || opts.Classic) && !opts.JailMode {
+		return nil
+	}
+	var tags []string
+	for _, hookInfo := range snapInfo.Hooks {
+		tags = append(tags, hookInfo.SecurityTag())
+	}
+	for _, appInfo := range snapInfo.Apps {
+		tags = append(tags, appInfo.SecurityTag())
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	content := make(map[string]osutil.FileState, len(tags))
+	for _, tag := range tags {
+		content[tag+".rules"] = &osutil.MemoryFileState{
+			Content: generateContent(spec.RulesForTag(tag)),
+			Mode:    0644,
+		}
+	}
+	return content
+}
+
+func generateContent(rules []Rule) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("# This file is automatically generated.\n")
+	for _, rule := range baseRules {
+		fmt.Fprintf(&buffer, "%s\n", rule)
+	}
+	if len(rules) > 0 {
+		buffer.WriteString("# Rules granted by interfaces\n")
+		for _, rule := range rules {
+			fmt.Fprintf(&buffer, "%s\n", rule)
+		}
+	}
+	return buffer.Bytes()
+}
+
+// NewSpecification returns a new landlock specification.
+func (b *Backend) NewSpecification() interfaces.Specification {
+	return &Specification{}
+}
+
+// SandboxFeatures returns the list of features supported by snapd for
+// confining snaps with landlock.
+func (b *Backend) SandboxFeatures() []string {
+	return []string{fmt.Sprintf("abi:%d", landlock.ABIVersion())}
+}
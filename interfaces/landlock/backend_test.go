@@ -0,0 +1,142 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/landlock"
+	"github.com/snapcore/snapd/osutil"
+	landlock_sandbox "github.com/snapcore/snapd/sandbox/landlock"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timings"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type backendSuite struct {
+	ifacetest.BackendSuite
+	meas *timings.Span
+}
+
+var _ = Suite(&backendSuite{})
+
+const baseRules = `# This file is automatically generated.
+r /bin
+r /etc
+r /lib
+r /lib32
+r /lib64
+r /libx32
+r /sbin
+r /usr
+r /snap
+r /var/lib/snapd
+r /proc
+r /sys
+rw /dev
+r /run
+rw /tmp
+rw /var/tmp
+r $SNAP
+rw $SNAP_DATA
+rw $SNAP_COMMON
+rw $SNAP_USER_DATA
+rw $SNAP_USER_COMMON
+rw $XDG_RUNTIME_DIR
+`
+
+func (s *backendSuite) SetUpTest(c *C) {
+	s.Backend = &landlock.Backend{}
+	s.BackendSuite.SetUpTest(c)
+	c.Assert(s.Repo.AddBackend(s.Backend), IsNil)
+
+	perf := timings.New(nil)
+	s.meas = perf.StartSpan("", "")
+}
+
+func (s *backendSuite) TestName(c *C) {
+	c.Check(s.Backend.Name(), Equals, interfaces.SecurityLandlock)
+}
+
+func (s *backendSuite) TestInstallingSnapWritesRules(c *C) {
+	s.Iface.LandlockPermanentSlotCallback = func(spec *landlock.Specification, slot *snap.SlotInfo) error {
+		return spec.AddRule(landlock.ReadWrite, "/media")
+	}
+	for _, opts := range []interfaces.ConfinementOptions{{}, {JailMode: true}, {DevMode: true, JailMode: true}} {
+		snapInfo := s.InstallSnap(c, opts, "", ifacetest.SambaYamlWithHook, 0)
+		c.Check(filepath.Join(dirs.SnapLandlockDir, "snap.samba.smbd.rules"), testutil.FileEquals,
+			baseRules+"# Rules granted by interfaces\nrw /media\n")
+		c.Check(filepath.Join(dirs.SnapLandlockDir, "snap.samba.nmbd.rules"), testutil.FileEquals,
+			baseRules+"# Rules granted by interfaces\nrw /media\n")
+		c.Check(filepath.Join(dirs.SnapLandlockDir, "snap.samba.hook.configure.rules"), testutil.FileEquals,
+			baseRules+"# Rules granted by interfaces\nrw /media\n")
+		s.RemoveSnap(c, snapInfo)
+	}
+}
+
+func (s *backendSuite) TestInstallingSnapInDevModeOrClassic(c *C) {
+	for _, opts := range []interfaces.ConfinementOptions{{DevMode: true}, {Classic: true}} {
+		snapInfo := s.InstallSnap(c, opts, "", ifacetest.SambaYamlV1, 0)
+		c.Check(filepath.Join(dirs.SnapLandlockDir, "snap.samba.smbd.rules"), testutil.FileAbsent)
+		s.RemoveSnap(c, snapInfo)
+	}
+}
+
+func (s *backendSuite) TestRemovingSnapRemovesRules(c *C) {
+	path := filepath.Join(dirs.SnapLandlockDir, "snap.samba.smbd.rules")
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1, 0)
+	c.Assert(osutil.FileExists(path), Equals, true)
+	s.RemoveSnap(c, snapInfo)
+	c.Assert(osutil.FileExists(path), Equals, false)
+}
+
+func (s *backendSuite) TestUpdatingSnapToOneWithFewerApps(c *C) {
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", ifacetest.SambaYamlV1WithNmbd, 0)
+	c.Assert(osutil.FileExists(filepath.Join(dirs.SnapLandlockDir, "snap.samba.nmbd.rules")), Equals, true)
+	snapInfo = s.UpdateSnap(c, snapInfo, interfaces.ConfinementOptions{}, ifacetest.SambaYamlV1, 0)
+	c.Check(filepath.Join(dirs.SnapLandlockDir, "snap.samba.nmbd.rules"), testutil.FileAbsent)
+	c.Check(filepath.Join(dirs.SnapLandlockDir, "snap.samba.smbd.rules"), testutil.FilePresent)
+	s.RemoveSnap(c, snapInfo)
+}
+
+func (s *backendSuite) TestBaseRulesAreValid(c *C) {
+	spec := &landlock.Specification{}
+	for _, line := range strings.Split(strings.TrimSpace(baseRules), "\n")[1:] {
+		fields := strings.SplitN(line, " ", 2)
+		c.Check(spec.AddRule(landlock.Access(fields[0]), fields[1]), IsNil, Commentf("%s", line))
+	}
+}
+
+func (s *backendSuite) TestSandboxFeatures(c *C) {
+	restore := landlock_sandbox.MockABIVersion(3)
+	defer restore()
+	c.Assert(s.Backend.SandboxFeatures(), DeepEquals, []string{"abi:3"})
+}
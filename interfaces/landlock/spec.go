@@ -0,0 +1,245 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/snap"
+)
+
+// Access describes the kind of filesystem access granted by a rule.
+type Access string
+
+const (
+	// ReadOnly allows reading and executing files beneath a path.
+	ReadOnly Access = "r"
+	// ReadWrite allows reading, executing, creating, modifying and
+	// removing files beneath a path.
+	ReadWrite Access = "rw"
+)
+
+// Rule grants access to the file hierarchy beneath a path.
+//
+// The path is either absolute or starts with one of the variables that
+// snap-confine expands before applying the ruleset, for instance
+// $SNAP_USER_DATA or $SNAP_REAL_HOME.
+type Rule struct {
+	Access Access
+	Path   string
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("%s %s", r.Access, r.Path)
+}
+
+// variables lists the variables that may start the path of a rule.
+var variables = []string{
+	"$SNAP",
+	"$SNAP_DATA",
+	"$SNAP_COMMON",
+	"$SNAP_USER_DATA",
+	"$SNAP_USER_COMMON",
+	"$SNAP_REAL_HOME",
+	"$XDG_RUNTIME_DIR",
+}
+
+func validateRule(r Rule) error {
+	if r.Access != ReadOnly && r.Access != ReadWrite {
+		return fmt.Errorf("invalid landlock access %q", r.Access)
+	}
+	if strings.ContainsAny(r.Path, "\n\x00") {
+		return fmt.Errorf("invalid landlock path %q: contains invalid characters", r.Path)
+	}
+	path := r.Path
+	if strings.HasPrefix(path, "$") {
+		known := false
+		for _, v := range variables {
+			if path == v || strings.HasPrefix(path, v+"/") {
+				// check the remainder as if the variable was "/"
+				path = strings.TrimPrefix(path, v)
+				if path == "" {
+					path = "/"
+				}
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("invalid landlock path %q: unknown variable", r.Path)
+		}
+	}
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("invalid landlock path %q: must be absolute", r.Path)
+	}
+	if filepath.Clean(path) != path {
+		return fmt.Errorf("invalid landlock path %q: must be clean", r.Path)
+	}
+	return nil
+}
+
+// Specification assists in collecting landlock rules associated with an
+// interface.
+//
+// Unlike the Backend itself (which is stateless and non-persistent) this type
+// holds internal state that is used by the landlock backend during the
+// interface setup process.
+type Specification struct {
+	// securityTags contains a list of security tags to which the rules
+	// added via AddRule apply.
+	securityTags []string
+	// rules are indexed by security tag.
+	rules map[string][]Rule
+}
+
+func (spec *Specification) setScope(securityTags []string) (restore func()) {
+	spec.securityTags = securityTags
+	return func() {
+		spec.securityTags = nil
+	}
+}
+
+// AddRule adds a rule granting access to the given path to all the
+// applications and hooks in the current scope, ignoring duplicates.
+func (spec *Specification) AddRule(access Access, path string) error {
+	rule := Rule{Access: access, Path: path}
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+	if len(spec.securityTags) == 0 {
+		return nil
+	}
+	if spec.rules == nil {
+		spec.rules = make(map[string][]Rule)
+	}
+	for _, tag := range spec.securityTags {
+		if containsRule(spec.rules[tag], rule) {
+			continue
+		}
+		spec.rules[tag] = append(spec.rules[tag], rule)
+		sort.Slice(spec.rules[tag], func(i, j int) bool {
+			return spec.rules[tag][i].String() < spec.rules[tag][j].String()
+		})
+	}
+	return nil
+}
+
+func containsRule(rules []Rule, rule Rule) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns a deep copy of all the added rules, indexed by security tag.
+func (spec *Specification) Rules() map[string][]Rule {
+	result := make(map[string][]Rule, len(spec.rules))
+	for tag, rules := range spec.rules {
+		result[tag] = append([]Rule(nil), rules...)
+	}
+	return result
+}
+
+// RulesForTag returns the rules for the given security tag.
+func (spec *Specification) RulesForTag(tag string) []Rule {
+	return append([]Rule(nil), spec.rules[tag]...)
+}
+
+// SecurityTags returns a list of security tags which have rules.
+func (spec *Specification) SecurityTags() []string {
+	tags := make([]string, 0, len(spec.rules))
+	for tag := range spec.rules {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Implementation of methods required by interfaces.Specification
+
+// ConnectionsAffect returns whether connections of the given interface can
+// contribute landlock rules.
+func (spec *Specification) ConnectionsAffect(iface interfaces.Interface) bool {
+	_, plugDefiner := iface.(interface {
+		LandlockConnectedPlug(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	_, slotDefiner := iface.(interface {
+		LandlockConnectedSlot(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	return plugDefiner || slotDefiner
+}
+
+// AddConnectedPlug records landlock-specific side-effects of having a connected plug.
+func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	type definer interface {
+		LandlockConnectedPlug(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	}
+	if iface, ok := iface.(definer); ok {
+		restore := spec.setScope(plug.SecurityTags())
+		defer restore()
+		return iface.LandlockConnectedPlug(spec, plug, slot)
+	}
+	return nil
+}
+
+// AddConnectedSlot records landlock-specific side-effects of having a connected slot.
+func (spec *Specification) AddConnectedSlot(iface interfaces.Interface, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	type definer interface {
+		LandlockConnectedSlot(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	}
+	if iface, ok := iface.(definer); ok {
+		restore := spec.setScope(slot.SecurityTags())
+		defer restore()
+		return iface.LandlockConnectedSlot(spec, plug, slot)
+	}
+	return nil
+}
+
+// AddPermanentPlug records landlock-specific side-effects of having a plug.
+func (spec *Specification) AddPermanentPlug(iface interfaces.Interface, plug *snap.PlugInfo) error {
+	type definer interface {
+		LandlockPermanentPlug(spec *Specification, plug *snap.PlugInfo) error
+	}
+	if iface, ok := iface.(definer); ok {
+		restore := spec.setScope(plug.SecurityTags())
+		defer restore()
+		return iface.LandlockPermanentPlug(spec, plug)
+	}
+	return nil
+}
+
+// AddPermanentSlot records landlock-specific side-effects of having a slot.
+func (spec *Specification) AddPermanentSlot(iface interfaces.Interface, slot *snap.SlotInfo) error {
+	type definer interface {
+		LandlockPermanentSlot(spec *Specification, slot *snap.SlotInfo) error
+	}
+	if iface, ok := iface.(definer); ok {
+		restore := spec.setScope(slot.SecurityTags())
+		defer restore()
+		return iface.LandlockPermanentSlot(spec, slot)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/landlock"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type specSuite struct {
+	iface    *ifacetest.TestInterface
+	spec     *landlock.Specification
+	plugInfo *snap.PlugInfo
+	plug     *interfaces.ConnectedPlug
+	slotInfo *snap.SlotInfo
+	slot     *interfaces.ConnectedSlot
+}
+
+var _ = Suite(&specSuite{
+	iface: &ifacetest.TestInterface{
+		InterfaceName: "test",
+		LandlockConnectedPlugCallback: func(spec *landlock.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			return spec.AddRule(landlock.ReadWrite, "/connected-plug")
+		},
+		LandlockConnectedSlotCallback: func(spec *landlock.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			return spec.AddRule(landlock.ReadOnly, "/connected-slot")
+		},
+		LandlockPermanentPlugCallback: func(spec *landlock.Specification, plug *snap.PlugInfo) error {
+			return spec.AddRule(landlock.ReadWrite, "$SNAP_REAL_HOME")
+		},
+		LandlockPermanentSlotCallback: func(spec *landlock.Specification, slot *snap.SlotInfo) error {
+			return spec.AddRule(landlock.ReadOnly, "/permanent-slot")
+		},
+	},
+})
+
+const specTestYaml = `name: snap1
+version: 0
+apps:
+  app1:
+    plugs: [name]
+hooks:
+  configure:
+    plugs: [name]
+plugs:
+  name:
+    interface: test
+`
+
+const specTestSlotYaml = `name: snap2
+version: 0
+apps:
+  app2:
+    slots: [name]
+slots:
+  name:
+    interface: test
+`
+
+func (s *specSuite) SetUpTest(c *C) {
+	s.spec = &landlock.Specification{}
+	s.plugInfo = snaptest.MockInfo(c, specTestYaml, nil).Plugs["name"]
+	s.slotInfo = snaptest.MockInfo(c, specTestSlotYaml, nil).Slots["name"]
+	s.plug = interfaces.NewConnectedPlug(s.plugInfo, nil, nil)
+	s.slot = interfaces.NewConnectedSlot(s.slotInfo, nil, nil)
+}
+
+// The landlock.Specification can be used through the interfaces.Specification interface
+func (s *specSuite) TestSpecificationIface(c *C) {
+	var r interfaces.Specification = s.spec
+	c.Assert(r.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(r.AddConnectedSlot(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(r.AddPermanentPlug(s.iface, s.plugInfo), IsNil)
+	c.Assert(r.AddPermanentSlot(s.iface, s.slotInfo), IsNil)
+	plugRules := []landlock.Rule{
+		{Access: landlock.ReadWrite, Path: "$SNAP_REAL_HOME"},
+		{Access: landlock.ReadWrite, Path: "/connected-plug"},
+	}
+	slotRules := []landlock.Rule{
+		{Access: landlock.ReadOnly, Path: "/connected-slot"},
+		{Access: landlock.ReadOnly, Path: "/permanent-slot"},
+	}
+	c.Assert(s.spec.Rules(), DeepEquals, map[string][]landlock.Rule{
+		"snap.snap1.app1":           plugRules,
+		"snap.snap1.hook.configure": plugRules,
+		"snap.snap2.app2":           slotRules,
+	})
+	c.Assert(s.spec.SecurityTags(), DeepEquals, []string{
+		"snap.snap1.app1", "snap.snap1.hook.configure", "snap.snap2.app2"})
+	c.Assert(s.spec.RulesForTag("snap.snap2.app2"), DeepEquals, slotRules)
+	c.Assert(s.spec.RulesForTag("snap.snap2.other"), HasLen, 0)
+}
+
+// AddRule ignores duplicated rules
+func (s *specSuite) TestDeduplication(c *C) {
+	var r interfaces.Specification = s.spec
+	c.Assert(r.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(r.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(s.spec.RulesForTag("snap.snap1.app1"), DeepEquals, []landlock.Rule{
+		{Access: landlock.ReadWrite, Path: "/connected-plug"},
+	})
+}
+
+// AddRule outside of the scope of a plug or slot does nothing
+func (s *specSuite) TestAddRuleWithoutScope(c *C) {
+	c.Assert(s.spec.AddRule(landlock.ReadOnly, "/foo"), IsNil)
+	c.Assert(s.spec.Rules(), HasLen, 0)
+}
+
+func (s *specSuite) TestAddRuleValidation(c *C) {
+	for _, t := range []struct {
+		access landlock.Access
+		path   string
+		err    string
+	}{
+		{landlock.ReadOnly, "/foo", ""},
+		{landlock.ReadWrite, "/", ""},
+		{landlock.ReadWrite, "$SNAP_USER_DATA", ""},
+		{landlock.ReadWrite, "$SNAP_REAL_HOME/Documents", ""},
+		{landlock.ReadWrite, "$XDG_RUNTIME_DIR/doc", ""},
+		{"x", "/foo", `invalid landlock access "x"`},
+		{landlock.ReadOnly, "foo", `invalid landlock path "foo": must be absolute`},
+		{landlock.ReadOnly, "/foo/", `invalid landlock path "/foo/": must be clean`},
+		{landlock.ReadOnly, "/foo/../bar", `invalid landlock path "/foo/../bar": must be clean`},
+		{landlock.ReadOnly, "$SNAP_USER_DATA/..", `invalid landlock path "\$SNAP_USER_DATA/..": must be clean`},
+		{landlock.ReadOnly, "$HOME", `invalid landlock path "\$HOME": unknown variable`},
+		{landlock.ReadOnly, "$SNAP_DATAX", `invalid landlock path "\$SNAP_DATAX": unknown variable`},
+		{landlock.ReadOnly, "/foo\nrw /", `invalid landlock path "/foo\\nrw /": contains invalid characters`},
+	} {
+		err := s.spec.AddRule(t.access, t.path)
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf("%q", t.path))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf("%q", t.path))
+		}
+	}
+}
+
+func (s *specSuite) TestConnectionsAffect(c *C) {
+	spec := &landlock.Specification{}
+	c.Check(spec.ConnectionsAffect(&ifacetest.TestInterface{InterfaceName: "test"}), Equals, true)
+	// an interface that does not define any connected side-effects
+	iface := struct{ interfaces.Interface }{&ifacetest.TestInterface{InterfaceName: "test"}}
+	c.Check(spec.ConnectionsAffect(iface), Equals, false)
+}
@@ -0,0 +1,61 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock
+
+// baseRules are the landlock rules granted to every application and hook of
+// a strictly confined snap. Everything not covered by these rules, or by the
+// rules contributed by connected interfaces, is inaccessible. In particular
+// the home directories of the users and removable media are off limits unless
+// the respective interfaces are connected.
+//
+// Note that landlock only mediates access to the filesystem, so unlike
+// apparmor these rules do not restrict network, IPC or capabilities. Access
+// to devices is left to the device cgroup.
+var baseRules = []Rule{
+	// The root filesystem of the snap mount namespace, which is
+	// typically provided by the base snap.
+	{ReadOnly, "/bin"},
+	{ReadOnly, "/etc"},
+	{ReadOnly, "/lib"},
+	{ReadOnly, "/lib32"},
+	{ReadOnly, "/lib64"},
+	{ReadOnly, "/libx32"},
+	{ReadOnly, "/sbin"},
+	{ReadOnly, "/usr"},
+	// Snaps, including the snap itself, and snapd state that tools like
+	// snapctl rely on.
+	{ReadOnly, "/snap"},
+	{ReadOnly, "/var/lib/snapd"},
+	// Kernel interfaces.
+	{ReadOnly, "/proc"},
+	{ReadOnly, "/sys"},
+	{ReadWrite, "/dev"},
+	{ReadOnly, "/run"},
+	// The private /tmp of the snap.
+	{ReadWrite, "/tmp"},
+	{ReadWrite, "/var/tmp"},
+	// The data directories of the snap.
+	{ReadOnly, "$SNAP"},
+	{ReadWrite, "$SNAP_DATA"},
+	{ReadWrite, "$SNAP_COMMON"},
+	{ReadWrite, "$SNAP_USER_DATA"},
+	{ReadWrite, "$SNAP_USER_COMMON"},
+	{ReadWrite, "$XDG_RUNTIME_DIR"},
+}
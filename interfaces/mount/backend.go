@@ -67,12 +67,12 @@ func (b *Backend) Setup(snapInfo *snap.Info, confinement interfaces.ConfinementO
 	spec.(*Specification).AddLayout(snapInfo)
 	content := deriveContent(spec.(*Specification), snapInfo)
 	// synchronize the content with the filesystem
-	glob := fmt.Sprintf("snap.%s.*fstab", snapName)
+	globs := profileGlobs(snapName)
 	dir := dirs.SnapMountPolicyDir
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("cannot create directory for mount configuration files %q: %s", dir, err)
 	}
-	if _, _, err := osutil.EnsureDirState(dir, glob, content); err != nil {
+	if _, _, err := osutil.EnsureDirStateGlobs(dir, globs, content); err != nil {
 		return fmt.Errorf("cannot synchronize mount configuration files for snap %q: %s", snapName, err)
 	}
 	if err := UpdateSnapNamespace(snapName); err != nil {
@@ -85,14 +85,22 @@ func (b *Backend) Setup(snapInfo *snap.Info, confinement interfaces.ConfinementO
 //
 // This method should be called after removing a snap.
 func (b *Backend) Remove(snapName string) error {
-	glob := fmt.Sprintf("snap.%s.*fstab", snapName)
-	_, _, err := osutil.EnsureDirState(dirs.SnapMountPolicyDir, glob, nil)
+	_, _, err := osutil.EnsureDirStateGlobs(dirs.SnapMountPolicyDir, profileGlobs(snapName), nil)
 	if err != nil {
 		return fmt.Errorf("cannot synchronize mount configuration files for snap %q: %s", snapName, err)
 	}
 	return DiscardSnapNamespace(snapName)
 }
 
+// profileGlobs returns the globs matching all the mount configuration files
+// of the given snap.
+func profileGlobs(snapName string) []string {
+	return []string{
+		fmt.Sprintf("snap.%s.*fstab", snapName),
+		fmt.Sprintf("snap.%s.*.tmp", snapName),
+	}
+}
+
 // addMountProfile adds a mount profile with the given name, based on the given entries.
 //
 // If there are no entries no profile is generated.
@@ -107,7 +115,7 @@ func addMountProfile(content map[string]osutil.FileState, fname string, entries
 	content[fname] = &osutil.MemoryFileState{Content: buffer.Bytes(), Mode: 0644}
 }
 
-// deriveContent computes .fstab tables based on requests made to the specification.
+// deriveContent computes .fstab tables and .tmp files based on requests made to the specification.
 func deriveContent(spec *Specification, snapInfo *snap.Info) map[string]osutil.FileState {
 	content := make(map[string]osutil.FileState, 2)
 	snapName := snapInfo.InstanceName()
@@ -117,6 +125,11 @@ func deriveContent(spec *Specification, snapInfo *snap.Info) map[string]osutil.F
 	// Add the per-snap user-fstab file.
 	// This file will be read by snap-update-ns in the per-user pass.
 	addMountProfile(content, fmt.Sprintf("snap.%s.user-fstab", snapName), spec.UserMountEntries())
+	// Add the per-app /tmp mode files.
+	// Those files are read by snap-confine when starting the application.
+	for tag, mode := range spec.TmpModes() {
+		content[fmt.Sprintf("%s.tmp", tag)] = &osutil.MemoryFileState{Content: []byte(mode + "\n"), Mode: 0644}
+	}
 	return content
 }
 
@@ -87,6 +87,10 @@ func (s *backendSuite) TestRemove(c *C) {
 	err = ioutil.WriteFile(snapCanaryToGo, []byte("ni! ni! ni!"), 0644)
 	c.Assert(err, IsNil)
 
+	tmpCanaryToGo := filepath.Join(dirs.SnapMountPolicyDir, "snap.hello-world.hello-world.tmp")
+	err = ioutil.WriteFile(tmpCanaryToGo, []byte("host\n"), 0644)
+	c.Assert(err, IsNil)
+
 	appCanaryToStay := filepath.Join(dirs.SnapMountPolicyDir, "snap.i-stay.really.fstab")
 	err = ioutil.WriteFile(appCanaryToStay, []byte("stay!"), 0644)
 	c.Assert(err, IsNil)
@@ -113,6 +117,7 @@ func (s *backendSuite) TestRemove(c *C) {
 	c.Assert(osutil.FileExists(snapCanaryToGo), Equals, false)
 	c.Assert(osutil.FileExists(appCanaryToGo), Equals, false)
 	c.Assert(osutil.FileExists(hookCanaryToGo), Equals, false)
+	c.Assert(osutil.FileExists(tmpCanaryToGo), Equals, false)
 	c.Assert(appCanaryToStay, testutil.FileEquals, "stay!")
 	c.Assert(snapCanaryToStay, testutil.FileEquals, "stay!")
 	c.Assert(cmd.Calls(), DeepEquals, [][]string{{"snap-discard-ns", "hello-world"}})
@@ -173,6 +178,30 @@ func (s *backendSuite) TestSetupSetsupSimple(c *C) {
 	c.Check(string(content), Equals, fsEntry3.String()+"\n")
 }
 
+func (s *backendSuite) TestSetupTmpModes(c *C) {
+	s.Iface.MountPermanentPlugCallback = func(spec *mount.Specification, plug *snap.PlugInfo) error {
+		spec.SetTmpMode("snap.snap-name.app1", "host")
+		spec.SetTmpMode("snap.snap-name.app2", "shared")
+		return nil
+	}
+
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, "", mockSnapYaml, 0)
+
+	fn := filepath.Join(dirs.SnapMountPolicyDir, "snap.snap-name.app1.tmp")
+	c.Check(fn, testutil.FileEquals, "host\n")
+	fn = filepath.Join(dirs.SnapMountPolicyDir, "snap.snap-name.app2.tmp")
+	c.Check(fn, testutil.FileEquals, "shared\n")
+	// there are no mount entries so no fstab was written
+	fn = filepath.Join(dirs.SnapMountPolicyDir, "snap.snap-name.fstab")
+	c.Check(osutil.FileExists(fn), Equals, false)
+
+	// the files go away when the mode is no longer requested
+	s.Iface.MountPermanentPlugCallback = nil
+	s.UpdateSnap(c, snapInfo, interfaces.ConfinementOptions{}, mockSnapYaml, 0)
+	fn = filepath.Join(dirs.SnapMountPolicyDir, "snap.snap-name.app1.tmp")
+	c.Check(osutil.FileExists(fn), Equals, false)
+}
+
 func (s *backendSuite) TestSetupSetsupWithoutDir(c *C) {
 	s.Iface.MountPermanentPlugCallback = func(spec *mount.Specification, plug *snap.PlugInfo) error {
 		return spec.AddMountEntry(osutil.MountEntry{})
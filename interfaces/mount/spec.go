@@ -45,6 +45,10 @@ type Specification struct {
 	general  []osutil.MountEntry
 	user     []osutil.MountEntry
 	overname []osutil.MountEntry
+
+	// tmpModes maps security tags to the /tmp sharing mode requested by
+	// the corresponding application.
+	tmpModes map[string]string
 }
 
 // AddMountEntry adds a new mount entry.
@@ -140,6 +144,25 @@ func (spec *Specification) AddLayout(si *snap.Info) {
 	}
 }
 
+// SetTmpMode records that the application with the given security tag
+// should see the given kind of /tmp ("host" or "shared") instead of the
+// private one set up by snap-confine.
+func (spec *Specification) SetTmpMode(securityTag, mode string) {
+	if spec.tmpModes == nil {
+		spec.tmpModes = make(map[string]string)
+	}
+	spec.tmpModes[securityTag] = mode
+}
+
+// TmpModes returns a copy of the recorded /tmp modes, keyed by security tag.
+func (spec *Specification) TmpModes() map[string]string {
+	result := make(map[string]string, len(spec.tmpModes))
+	for tag, mode := range spec.tmpModes {
+		result[tag] = mode
+	}
+	return result
+}
+
 // MountEntries returns a copy of the added mount entries.
 func (spec *Specification) MountEntries() []osutil.MountEntry {
 	result := make([]osutil.MountEntry, 0, len(spec.overname)+len(spec.layout)+len(spec.general))
@@ -180,6 +203,18 @@ func unclashMountEntries(entries []osutil.MountEntry) {
 
 // Implementation of methods required by interfaces.Specification
 
+// ConnectionsAffect returns whether connections of the given interface can
+// contribute mount entries.
+func (spec *Specification) ConnectionsAffect(iface interfaces.Interface) bool {
+	_, plugDefiner := iface.(interface {
+		MountConnectedPlug(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	_, slotDefiner := iface.(interface {
+		MountConnectedSlot(spec *Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error
+	})
+	return plugDefiner || slotDefiner
+}
+
 // AddConnectedPlug records mount-specific side-effects of having a connected plug.
 func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	type definer interface {
@@ -224,3 +224,11 @@ func (s *specSuite) TestParallelInstanceMountEntriesReal(c *C) {
 	})
 	c.Assert(s.spec.UserMountEntries(), HasLen, 0)
 }
+
+func (s *specSuite) TestConnectionsAffect(c *C) {
+	spec := &mount.Specification{}
+	c.Check(spec.ConnectionsAffect(&ifacetest.TestInterface{InterfaceName: "test"}), Equals, true)
+	// an interface that does not define any connected side-effects
+	iface := struct{ interfaces.Interface }{&ifacetest.TestInterface{InterfaceName: "test"}}
+	c.Check(spec.ConnectionsAffect(iface), Equals, false)
+}
@@ -609,6 +609,7 @@ var (
 		"hidraw":                    {"core", "gadget"},
 		"i2c":                       {"core", "gadget"},
 		"iio":                       {"core", "gadget"},
+		"kms":                       {"core", "gadget"},
 		"kubernetes-support":        {"core"},
 		"location-control":          {"app"},
 		"location-observe":          {"app"},
@@ -629,8 +630,10 @@ var (
 		"pulseaudio":                {"app", "core"},
 		"pwm":                       {"core", "gadget"},
 		"raw-volume":                {"core", "gadget"},
+		"registry":                  {"core"},
 		"sd-control":                {"core"},
 		"serial-port":               {"core", "gadget"},
+		"service-control":           {"app"},
 		"spi":                       {"core", "gadget"},
 		"storage-framework-service": {"app"},
 		"thumbnailer-service":       {"app"},
@@ -642,6 +645,7 @@ var (
 		"unity8-calendar":           {"app"},
 		"unity8-contacts":           {"app"},
 		"upower-observe":            {"app", "core"},
+		"url-handler":               {"core"},
 		"wayland":                   {"app", "core"},
 		"x11":                       {"app", "core"},
 		// snowflakes
@@ -724,6 +728,7 @@ func (s *baseDeclSuite) TestPlugInstallation(c *C) {
 		"tee":                   true,
 		"uinput":                true,
 		"unity8":                true,
+		"url-handler":           true,
 	}
 
 	for _, iface := range all {
@@ -961,6 +966,7 @@ func (s *baseDeclSuite) TestSanity(c *C) {
 		"udisks2":               true,
 		"uinput":                true,
 		"unity8":                true,
+		"url-handler":           true,
 		"wayland":               true,
 	}
 
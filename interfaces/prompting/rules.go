@@ -0,0 +1,327 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package prompting implements the storage and matching of the rules
+// created by users in reply to prompts for file access by snaps.
+package prompting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// Outcome is the decision of a rule.
+type Outcome string
+
+const (
+	OutcomeAllow Outcome = "allow"
+	OutcomeDeny  Outcome = "deny"
+)
+
+// Permissions which can be granted or denied by rules.
+const (
+	PermissionRead    = "read"
+	PermissionWrite   = "write"
+	PermissionExecute = "execute"
+)
+
+var (
+	// ErrNoMatchingRule is returned by Decide when no rule applies to
+	// the request, in which case the user should be prompted.
+	ErrNoMatchingRule = errors.New("no rule matches the request")
+	// ErrRuleNotFound is returned when there is no rule with the given
+	// ID for the user.
+	ErrRuleNotFound = errors.New("rule not found")
+)
+
+// supportedInterfaces are the interfaces whose file accesses can be
+// prompted for.
+var supportedInterfaces = []string{"home", "removable-media"}
+
+var supportedPermissions = []string{PermissionRead, PermissionWrite, PermissionExecute}
+
+var timeNow = time.Now
+
+// Rule is a decision taken by a user about the access of a snap to paths
+// matching a pattern through an interface.
+type Rule struct {
+	ID          string    `json:"id"`
+	Timestamp   time.Time `json:"timestamp"`
+	User        uint32    `json:"user"`
+	Snap        string    `json:"snap"`
+	Interface   string    `json:"interface"`
+	PathPattern string    `json:"path-pattern"`
+	Permissions []string  `json:"permissions"`
+	Outcome     Outcome   `json:"outcome"`
+}
+
+// ValidatePathPattern checks that the given pattern is an absolute path,
+// optionally using '*' to match any characters but '/', '?' to match any
+// character but '/', and '**' as a whole path component to match any number
+// of directories.
+func ValidatePathPattern(pattern string) error {
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("invalid path pattern %q: must be absolute", pattern)
+	}
+	for _, comp := range strings.Split(pattern[1:], "/") {
+		if strings.Contains(comp, "**") && comp != "**" {
+			return fmt.Errorf("invalid path pattern %q: '**' can only be used as a whole path component", pattern)
+		}
+		if comp == "." || comp == ".." {
+			return fmt.Errorf("invalid path pattern %q: must be clean", pattern)
+		}
+	}
+	return nil
+}
+
+// pathPatternRegexp converts a valid path pattern into a regular
+// expression.
+func pathPatternRegexp(pattern string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	re.WriteString("^")
+	comps := strings.Split(pattern[1:], "/")
+	for i, comp := range comps {
+		if comp == "**" {
+			if i == len(comps)-1 {
+				// trailing '**' matches any descendant
+				re.WriteString("(/.*)?")
+			} else {
+				re.WriteString("(/[^/]+)*")
+			}
+			continue
+		}
+		re.WriteString("/")
+		for _, r := range comp {
+			switch r {
+			case '*':
+				re.WriteString("[^/]*")
+			case '?':
+				re.WriteString("[^/]")
+			default:
+				re.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+	}
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
+
+func (r *Rule) validate() error {
+	if r.Snap == "" {
+		return fmt.Errorf("snap must be set")
+	}
+	if !strutil.ListContains(supportedInterfaces, r.Interface) {
+		return fmt.Errorf("unsupported interface %q", r.Interface)
+	}
+	if err := ValidatePathPattern(r.PathPattern); err != nil {
+		return err
+	}
+	if len(r.Permissions) == 0 {
+		return fmt.Errorf("permissions must be set")
+	}
+	for _, perm := range r.Permissions {
+		if !strutil.ListContains(supportedPermissions, perm) {
+			return fmt.Errorf("unsupported permission %q", perm)
+		}
+	}
+	if r.Outcome != OutcomeAllow && r.Outcome != OutcomeDeny {
+		return fmt.Errorf("invalid outcome %q", r.Outcome)
+	}
+	return nil
+}
+
+// matches returns whether the rule applies to the access of the given
+// path with the given permission.
+func (r *Rule) matches(path, permission string) (bool, error) {
+	if !strutil.ListContains(r.Permissions, permission) {
+		return false, nil
+	}
+	re, err := pathPatternRegexp(r.PathPattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(path), nil
+}
+
+type ruleDBData struct {
+	LastID uint64  `json:"last-id"`
+	Rules  []*Rule `json:"rules"`
+}
+
+// RuleDB holds the rules of all the users, persisted on disk.
+type RuleDB struct {
+	mu   sync.Mutex
+	data ruleDBData
+}
+
+func rulesFile() string {
+	return filepath.Join(dirs.SnapInterfacesRequestsStateDir, "rules.json")
+}
+
+// NewRuleDB returns a RuleDB with the rules loaded from disk.
+func NewRuleDB() (*RuleDB, error) {
+	db := &RuleDB{}
+	content, err := ioutil.ReadFile(rulesFile())
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read prompting rules: %v", err)
+	}
+	if err := json.Unmarshal(content, &db.data); err != nil {
+		return nil, fmt.Errorf("cannot decode prompting rules: %v", err)
+	}
+	return db, nil
+}
+
+func (db *RuleDB) save() error {
+	content, err := json.Marshal(&db.data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dirs.SnapInterfacesRequestsStateDir, 0755); err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(rulesFile(), content, 0600, 0)
+}
+
+// Rules returns the rules of the given user, optionally only those for the
+// given snap and interface, oldest first.
+func (db *RuleDB) Rules(user uint32, snapName, iface string) []*Rule {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	rules := []*Rule{}
+	for _, r := range db.data.Rules {
+		if r.User != user {
+			continue
+		}
+		if snapName != "" && r.Snap != snapName {
+			continue
+		}
+		if iface != "" && r.Interface != iface {
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// AddRule validates and stores a new rule for the given user.
+func (db *RuleDB) AddRule(user uint32, snapName, iface, pathPattern string, permissions []string, outcome Outcome) (*Rule, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var perms []string
+	for _, perm := range permissions {
+		if !strutil.ListContains(perms, perm) {
+			perms = append(perms, perm)
+		}
+	}
+	sort.Strings(perms)
+	rule := &Rule{
+		Timestamp:   timeNow(),
+		User:        user,
+		Snap:        snapName,
+		Interface:   iface,
+		PathPattern: pathPattern,
+		Permissions: perms,
+		Outcome:     outcome,
+	}
+	if err := rule.validate(); err != nil {
+		return nil, fmt.Errorf("cannot add prompting rule: %v", err)
+	}
+
+	db.data.LastID++
+	rule.ID = strconv.FormatUint(db.data.LastID, 16)
+	db.data.Rules = append(db.data.Rules, rule)
+	if err := db.save(); err != nil {
+		db.data.Rules = db.data.Rules[:len(db.data.Rules)-1]
+		return nil, fmt.Errorf("cannot save prompting rules: %v", err)
+	}
+	return rule, nil
+}
+
+// RemoveRule removes the rule with the given ID belonging to the user.
+func (db *RuleDB) RemoveRule(user uint32, id string) (*Rule, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i, r := range db.data.Rules {
+		if r.ID != id || r.User != user {
+			continue
+		}
+		rules := make([]*Rule, 0, len(db.data.Rules)-1)
+		rules = append(rules, db.data.Rules[:i]...)
+		rules = append(rules, db.data.Rules[i+1:]...)
+		old := db.data.Rules
+		db.data.Rules = rules
+		if err := db.save(); err != nil {
+			db.data.Rules = old
+			return nil, fmt.Errorf("cannot save prompting rules: %v", err)
+		}
+		return r, nil
+	}
+	return nil, ErrRuleNotFound
+}
+
+// Decide returns the outcome of the rules of the user for the access of
+// the given snap to the path with the given permission through the
+// interface. Deny rules take precedence over allow rules. If no rule
+// matches ErrNoMatchingRule is returned and the user should be prompted.
+func (db *RuleDB) Decide(user uint32, snapName, iface, path, permission string) (Outcome, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var matched bool
+	for _, r := range db.data.Rules {
+		if r.User != user || r.Snap != snapName || r.Interface != iface {
+			continue
+		}
+		ok, err := r.matches(path, permission)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+		if r.Outcome == OutcomeDeny {
+			return OutcomeDeny, nil
+		}
+		matched = true
+	}
+	if matched {
+		return OutcomeAllow, nil
+	}
+	return "", ErrNoMatchingRule
+}
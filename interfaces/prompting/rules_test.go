@@ -0,0 +1,216 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package prompting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type rulesSuite struct {
+	testutil.BaseTest
+
+	now time.Time
+}
+
+var _ = Suite(&rulesSuite{})
+
+func (s *rulesSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.now = time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	s.AddCleanup(prompting.MockTimeNow(func() time.Time { return s.now }))
+}
+
+func (s *rulesSuite) TestPathPatterns(c *C) {
+	for _, t := range []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"/home/test/Documents/foo.txt", "/home/test/Documents/foo.txt", true},
+		{"/home/test/Documents/foo.txt", "/home/test/Documents/foo.txt.bak", false},
+		{"/home/test/Documents/*.txt", "/home/test/Documents/foo.txt", true},
+		{"/home/test/Documents/*.txt", "/home/test/Documents/sub/foo.txt", false},
+		{"/home/test/Documents/fo?.txt", "/home/test/Documents/foo.txt", true},
+		{"/home/test/Documents/fo?.txt", "/home/test/Documents/fooo.txt", false},
+		{"/home/test/Documents/**", "/home/test/Documents", true},
+		{"/home/test/Documents/**", "/home/test/Documents/sub/foo.txt", true},
+		{"/home/test/Documents/**", "/home/test/Pictures/foo.png", false},
+		{"/home/test/**/*.png", "/home/test/foo.png", true},
+		{"/home/test/**/*.png", "/home/test/Pictures/2021/foo.png", true},
+		{"/home/test/**/*.png", "/home/test/Pictures/foo.txt", false},
+		{"/media/[usb]/foo", "/media/[usb]/foo", true},
+		{"/media/[usb]/foo", "/media/u/foo", false},
+	} {
+		re, err := prompting.PathPatternRegexp(t.pattern)
+		c.Assert(err, IsNil)
+		c.Check(re.MatchString(t.path), Equals, t.matches, Commentf("%q %q", t.pattern, t.path))
+	}
+}
+
+func (s *rulesSuite) TestValidatePathPattern(c *C) {
+	c.Check(prompting.ValidatePathPattern("/home/test/**"), IsNil)
+	c.Check(prompting.ValidatePathPattern("home/test"), ErrorMatches, `invalid path pattern "home/test": must be absolute`)
+	c.Check(prompting.ValidatePathPattern("/home/test/foo**"), ErrorMatches, `invalid path pattern "/home/test/foo\*\*": '\*\*' can only be used as a whole path component`)
+	c.Check(prompting.ValidatePathPattern("/home/test/../foo"), ErrorMatches, `invalid path pattern "/home/test/../foo": must be clean`)
+}
+
+func (s *rulesSuite) TestAddRulePersisted(c *C) {
+	db, err := prompting.NewRuleDB()
+	c.Assert(err, IsNil)
+	c.Check(db.Rules(1000, "", ""), HasLen, 0)
+
+	rule, err := db.AddRule(1000, "foo", "home", "/home/test/Documents/**", []string{"write", "read", "read"}, prompting.OutcomeAllow)
+	c.Assert(err, IsNil)
+	c.Check(rule, DeepEquals, &prompting.Rule{
+		ID:          "1",
+		Timestamp:   s.now,
+		User:        1000,
+		Snap:        "foo",
+		Interface:   "home",
+		PathPattern: "/home/test/Documents/**",
+		Permissions: []string{"read", "write"},
+		Outcome:     prompting.OutcomeAllow,
+	})
+	_, err = db.AddRule(1001, "foo", "removable-media", "/media/**", []string{"read"}, prompting.OutcomeDeny)
+	c.Assert(err, IsNil)
+
+	c.Check(filepath.Join(dirs.SnapInterfacesRequestsStateDir, "rules.json"), testutil.FilePresent)
+
+	// the rules are loaded again
+	db, err = prompting.NewRuleDB()
+	c.Assert(err, IsNil)
+	rules := db.Rules(1000, "", "")
+	c.Assert(rules, HasLen, 1)
+	c.Check(rules[0].ID, Equals, "1")
+	c.Check(rules[0].Timestamp.Equal(s.now), Equals, true)
+	c.Check(db.Rules(1001, "foo", "removable-media"), HasLen, 1)
+	c.Check(db.Rules(1001, "bar", ""), HasLen, 0)
+	c.Check(db.Rules(1001, "", "home"), HasLen, 0)
+
+	// IDs are not reused
+	rule, err = db.AddRule(1000, "bar", "home", "/home/test/**", []string{"read"}, prompting.OutcomeAllow)
+	c.Assert(err, IsNil)
+	c.Check(rule.ID, Equals, "3")
+}
+
+func (s *rulesSuite) TestAddRuleErrors(c *C) {
+	db, err := prompting.NewRuleDB()
+	c.Assert(err, IsNil)
+
+	for _, t := range []struct {
+		snap, iface, pattern string
+		perms                []string
+		outcome              prompting.Outcome
+		err                  string
+	}{
+		{"", "home", "/home/**", []string{"read"}, prompting.OutcomeAllow, `snap must be set`},
+		{"foo", "camera", "/home/**", []string{"read"}, prompting.OutcomeAllow, `unsupported interface "camera"`},
+		{"foo", "home", "home", []string{"read"}, prompting.OutcomeAllow, `invalid path pattern "home": must be absolute`},
+		{"foo", "home", "/home/**", nil, prompting.OutcomeAllow, `permissions must be set`},
+		{"foo", "home", "/home/**", []string{"read", "delete"}, prompting.OutcomeAllow, `unsupported permission "delete"`},
+		{"foo", "home", "/home/**", []string{"read"}, "maybe", `invalid outcome "maybe"`},
+	} {
+		_, err := db.AddRule(1000, t.snap, t.iface, t.pattern, t.perms, t.outcome)
+		c.Check(err, ErrorMatches, "cannot add prompting rule: "+t.err)
+	}
+	c.Check(db.Rules(1000, "", ""), HasLen, 0)
+}
+
+func (s *rulesSuite) TestRemoveRule(c *C) {
+	db, err := prompting.NewRuleDB()
+	c.Assert(err, IsNil)
+
+	rule, err := db.AddRule(1000, "foo", "home", "/home/test/**", []string{"read"}, prompting.OutcomeAllow)
+	c.Assert(err, IsNil)
+
+	// rules of other users cannot be removed
+	_, err = db.RemoveRule(1001, rule.ID)
+	c.Check(err, Equals, prompting.ErrRuleNotFound)
+
+	removed, err := db.RemoveRule(1000, rule.ID)
+	c.Assert(err, IsNil)
+	c.Check(removed, DeepEquals, rule)
+	c.Check(db.Rules(1000, "", ""), HasLen, 0)
+
+	_, err = db.RemoveRule(1000, rule.ID)
+	c.Check(err, Equals, prompting.ErrRuleNotFound)
+
+	db, err = prompting.NewRuleDB()
+	c.Assert(err, IsNil)
+	c.Check(db.Rules(1000, "", ""), HasLen, 0)
+}
+
+func (s *rulesSuite) TestDecide(c *C) {
+	db, err := prompting.NewRuleDB()
+	c.Assert(err, IsNil)
+
+	_, err = db.AddRule(1000, "foo", "home", "/home/test/**", []string{"read"}, prompting.OutcomeAllow)
+	c.Assert(err, IsNil)
+	_, err = db.AddRule(1000, "foo", "home", "/home/test/.ssh/**", []string{"read", "write"}, prompting.OutcomeDeny)
+	c.Assert(err, IsNil)
+
+	for _, t := range []struct {
+		user       uint32
+		snap       string
+		iface      string
+		path       string
+		permission string
+		outcome    prompting.Outcome
+	}{
+		{1000, "foo", "home", "/home/test/foo.txt", "read", prompting.OutcomeAllow},
+		{1000, "foo", "home", "/home/test/.ssh/id_rsa", "read", prompting.OutcomeDeny},
+		{1000, "foo", "home", "/home/test/.ssh/id_rsa", "write", prompting.OutcomeDeny},
+		{1000, "foo", "home", "/home/test/foo.txt", "write", ""},
+		{1000, "bar", "home", "/home/test/foo.txt", "read", ""},
+		{1000, "foo", "removable-media", "/home/test/foo.txt", "read", ""},
+		{1001, "foo", "home", "/home/test/foo.txt", "read", ""},
+	} {
+		outcome, err := db.Decide(t.user, t.snap, t.iface, t.path, t.permission)
+		if t.outcome == "" {
+			c.Check(err, Equals, prompting.ErrNoMatchingRule, Commentf("%+v", t))
+		} else {
+			c.Check(err, IsNil)
+			c.Check(outcome, Equals, t.outcome, Commentf("%+v", t))
+		}
+	}
+}
+
+func (s *rulesSuite) TestNewRuleDBError(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapInterfacesRequestsStateDir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dirs.SnapInterfacesRequestsStateDir, "rules.json"), []byte("{"), 0600), IsNil)
+
+	_, err := prompting.NewRuleDB()
+	c.Check(err, ErrorMatches, "cannot decode prompting rules: .*")
+}
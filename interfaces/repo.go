@@ -880,6 +880,28 @@ func (r *Repository) Backends() []SecurityBackend {
 	return result
 }
 
+// BackendsAffectedByConnections returns the security backends whose state can
+// change when plugs and slots of the given interface are connected or
+// disconnected. Backends whose specifications cannot tell are always
+// included.
+func (r *Repository) BackendsAffectedByConnections(ifaceName string) []SecurityBackend {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	iface := r.ifaces[ifaceName]
+	result := make([]SecurityBackend, 0, len(r.backends))
+	for _, backend := range r.backends {
+		if iface != nil {
+			spec, ok := backend.NewSpecification().(ConnectionAwareSpecification)
+			if ok && !spec.ConnectionsAffect(iface) {
+				continue
+			}
+		}
+		result = append(result, backend)
+	}
+	return result
+}
+
 // Interfaces returns object holding a lists of all the plugs and slots and their connections.
 func (r *Repository) Interfaces() *Interfaces {
 	r.m.Lock()
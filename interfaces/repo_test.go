@@ -226,6 +226,30 @@ func (s *RepositorySuite) TestBackends(c *C) {
 	c.Assert(s.emptyRepo.Backends(), DeepEquals, []SecurityBackend{b2, b1})
 }
 
+func (s *RepositorySuite) TestBackendsAffectedByConnections(c *C) {
+	b1 := &ifacetest.TestSecurityBackend{BackendName: "b1"}
+	b2 := &ifacetest.TestSecurityBackendConnectionAware{
+		TestSecurityBackend: ifacetest.TestSecurityBackend{BackendName: "b2"},
+		ConnectionsAffectCallback: func(iface Interface) bool {
+			return iface.Name() == "a"
+		},
+	}
+	b3 := &ifacetest.TestSecurityBackendConnectionAware{
+		TestSecurityBackend: ifacetest.TestSecurityBackend{BackendName: "b3"},
+	}
+	c.Assert(s.emptyRepo.AddBackend(b1), IsNil)
+	c.Assert(s.emptyRepo.AddBackend(b2), IsNil)
+	c.Assert(s.emptyRepo.AddBackend(b3), IsNil)
+	c.Assert(s.emptyRepo.AddInterface(&ifacetest.TestInterface{InterfaceName: "a"}), IsNil)
+	c.Assert(s.emptyRepo.AddInterface(&ifacetest.TestInterface{InterfaceName: "b"}), IsNil)
+
+	c.Check(s.emptyRepo.BackendsAffectedByConnections("a"), DeepEquals, []SecurityBackend{b1, b2, b3})
+	// backends that cannot tell are always affected
+	c.Check(s.emptyRepo.BackendsAffectedByConnections("b"), DeepEquals, []SecurityBackend{b1, b3})
+	// and so are all backends for unknown interfaces
+	c.Check(s.emptyRepo.BackendsAffectedByConnections("unknown"), DeepEquals, []SecurityBackend{b1, b2, b3})
+}
+
 // Tests for Repository.Interface()
 
 func (s *RepositorySuite) TestInterface(c *C) {
@@ -99,3 +99,19 @@ var (
 
 	ParallelCompile = parallelCompile
 )
+
+func MockCgroupPidsOfSnap(f func(string) (map[string][]int, error)) (restore func()) {
+	old := cgroupPidsOfSnap
+	cgroupPidsOfSnap = f
+	return func() {
+		cgroupPidsOfSnap = old
+	}
+}
+
+func MockProcDir(dir string) (restore func()) {
+	old := procDir
+	procDir = dir
+	return func() {
+		procDir = old
+	}
+}
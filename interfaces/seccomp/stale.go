@@ -0,0 +1,153 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seccomp
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/sandbox/cgroup"
+)
+
+var (
+	cgroupPidsOfSnap = cgroup.PidsOfSnap
+
+	procDir = "/proc"
+)
+
+// userHZ is the unit of the process start time found in /proc/<pid>/stat,
+// it is fixed by the kernel ABI on all supported architectures.
+const userHZ = 100
+
+// StaleProcesses returns the running processes of the given snap, grouped by
+// security tag, that were started before the seccomp profile of their
+// security tag was last updated.
+//
+// The seccomp filter of a process is loaded by snap-confine when the process
+// is started and cannot be replaced afterwards, so such processes keep using
+// the stale profile until they are restarted. Processes started within the
+// same second as the profile update may not be reported.
+func StaleProcesses(snapName string) (map[string][]int, error) {
+	pidsByTag, err := cgroupPidsOfSnap(snapName)
+	if err != nil {
+		return nil, err
+	}
+	if len(pidsByTag) == 0 {
+		return nil, nil
+	}
+
+	btime, err := bootTime()
+	if err != nil {
+		return nil, err
+	}
+
+	var stale map[string][]int
+	for tag, pids := range pidsByTag {
+		fi, err := os.Stat(filepath.Join(dirs.SnapSeccompDir, tag+".bin"))
+		if os.IsNotExist(err) {
+			// no profile, nothing can be stale
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// the start time of processes is only known with a precision of
+		// one second
+		updated := fi.ModTime().Truncate(time.Second)
+		for _, pid := range pids {
+			started, err := processStartTime(pid, btime)
+			if os.IsNotExist(err) {
+				// the process is gone
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if started.Before(updated) {
+				if stale == nil {
+					stale = make(map[string][]int)
+				}
+				stale[tag] = append(stale[tag], pid)
+			}
+		}
+	}
+	return stale, nil
+}
+
+// bootTime returns the time the system was booted at, as found in the btime
+// entry of /proc/stat.
+func bootTime() (time.Time, error) {
+	f, err := os.Open(filepath.Join(procDir, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "btime" {
+			continue
+		}
+		secs, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse boot time: %v", err)
+		}
+		return time.Unix(secs, 0), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("cannot find boot time in %s", f.Name())
+}
+
+// processStartTime returns the time the given process was started at.
+func processStartTime(pid int, btime time.Time) (time.Time, error) {
+	filename := filepath.Join(procDir, strconv.Itoa(pid), "stat")
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return time.Time{}, err
+	}
+	contents := string(data)
+
+	// the start time is the token at index 19 after the '(process name)'
+	// entry, since the name can contain spaces and the ')' character,
+	// search backwards for the end of it, see proc(5)
+	idx := strings.LastIndexByte(contents, ')')
+	if idx < 0 || idx+2 > len(contents) {
+		return time.Time{}, fmt.Errorf("cannot parse %s", filename)
+	}
+	tokens := strings.Fields(contents[idx+2:])
+	if len(tokens) < 20 {
+		return time.Time{}, fmt.Errorf("cannot parse %s", filename)
+	}
+	ticks, err := strconv.ParseUint(tokens[19], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse %s: %v", filename, err)
+	}
+	return btime.Add(time.Duration(ticks) * time.Second / userHZ), nil
+}
@@ -0,0 +1,138 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package seccomp_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces/seccomp"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type staleSuite struct {
+	testutil.BaseTest
+
+	procDir string
+}
+
+var _ = Suite(&staleSuite{})
+
+const mockBootTime = 1600000000
+
+func (s *staleSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+	c.Assert(os.MkdirAll(dirs.SnapSeccompDir, 0755), IsNil)
+
+	s.procDir = c.MkDir()
+	s.AddCleanup(seccomp.MockProcDir(s.procDir))
+	procStat := fmt.Sprintf("cpu  1 2 3 4\nbtime %d\nprocesses 100\n", mockBootTime)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.procDir, "stat"), []byte(procStat), 0644), IsNil)
+}
+
+func (s *staleSuite) mockProcess(c *C, pid int, startedAfterBoot time.Duration) {
+	ticks := int64(startedAfterBoot / (10 * time.Millisecond))
+	stat := fmt.Sprintf("%d (snap app) S 1 %d %d 0 -1 4194560 1 0 0 0 0 0 0 0 20 0 1 0 %d 1000 10 18446744073709551615\n", pid, pid, pid, ticks)
+	c.Assert(os.MkdirAll(filepath.Join(s.procDir, fmt.Sprint(pid)), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.procDir, fmt.Sprint(pid), "stat"), []byte(stat), 0644), IsNil)
+}
+
+func (s *staleSuite) mockProfile(c *C, tag string, updatedAfterBoot time.Duration) {
+	path := filepath.Join(dirs.SnapSeccompDir, tag+".bin")
+	c.Assert(ioutil.WriteFile(path, nil, 0644), IsNil)
+	mtime := time.Unix(mockBootTime, 0).Add(updatedAfterBoot)
+	c.Assert(os.Chtimes(path, mtime, mtime), IsNil)
+}
+
+func (s *staleSuite) TestStaleProcesses(c *C) {
+	restore := seccomp.MockCgroupPidsOfSnap(func(snapName string) (map[string][]int, error) {
+		c.Check(snapName, Equals, "foo")
+		return map[string][]int{
+			"snap.foo.app":        {10, 11, 12},
+			"snap.foo.svc":        {20},
+			"snap.foo.no-profile": {30},
+		}, nil
+	})
+	defer restore()
+
+	s.mockProfile(c, "snap.foo.app", 100*time.Second)
+	s.mockProfile(c, "snap.foo.svc", 100*time.Second)
+	// started before the profile was updated
+	s.mockProcess(c, 10, 50*time.Second)
+	// started after the profile was updated
+	s.mockProcess(c, 11, 200*time.Second)
+	// pid 12 is gone
+	// started within the same second as the profile update
+	s.mockProcess(c, 20, 100*time.Second+500*time.Millisecond)
+	s.mockProcess(c, 30, time.Second)
+
+	stale, err := seccomp.StaleProcesses("foo")
+	c.Assert(err, IsNil)
+	c.Check(stale, DeepEquals, map[string][]int{
+		"snap.foo.app": {10},
+	})
+}
+
+func (s *staleSuite) TestStaleProcessesNoProcesses(c *C) {
+	restore := seccomp.MockCgroupPidsOfSnap(func(snapName string) (map[string][]int, error) {
+		return map[string][]int{}, nil
+	})
+	defer restore()
+
+	stale, err := seccomp.StaleProcesses("foo")
+	c.Assert(err, IsNil)
+	c.Check(stale, HasLen, 0)
+}
+
+func (s *staleSuite) TestStaleProcessesErrors(c *C) {
+	restore := seccomp.MockCgroupPidsOfSnap(func(snapName string) (map[string][]int, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	defer restore()
+
+	_, err := seccomp.StaleProcesses("foo")
+	c.Assert(err, ErrorMatches, "boom")
+
+	restore = seccomp.MockCgroupPidsOfSnap(func(snapName string) (map[string][]int, error) {
+		return map[string][]int{"snap.foo.app": {10}}, nil
+	})
+	defer restore()
+	s.mockProfile(c, "snap.foo.app", 100*time.Second)
+
+	// malformed stat of the process
+	c.Assert(os.MkdirAll(filepath.Join(s.procDir, "10"), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.procDir, "10", "stat"), []byte("10 (app) S 1"), 0644), IsNil)
+	_, err = seccomp.StaleProcesses("foo")
+	c.Assert(err, ErrorMatches, `cannot parse .*/10/stat`)
+
+	// no boot time
+	c.Assert(ioutil.WriteFile(filepath.Join(s.procDir, "stat"), []byte("cpu  1 2 3 4\n"), 0644), IsNil)
+	_, err = seccomp.StaleProcesses("foo")
+	c.Assert(err, ErrorMatches, `cannot find boot time in .*/stat`)
+}
@@ -179,3 +179,11 @@ slots:
 		"slot2":       {ExecStart: "permanent-slot"},
 	})
 }
+
+func (s *specSuite) TestConnectionsAffect(c *C) {
+	spec := &systemd.Specification{}
+	c.Check(spec.ConnectionsAffect(&ifacetest.TestInterface{InterfaceName: "test"}), Equals, true)
+	// an interface that does not define any connected side-effects
+	iface := struct{ interfaces.Interface }{&ifacetest.TestInterface{InterfaceName: "test"}}
+	c.Check(spec.ConnectionsAffect(iface), Equals, false)
+}
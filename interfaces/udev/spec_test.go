@@ -166,3 +166,11 @@ func (s *specSuite) TestControlsDeviceCgroup(c *C) {
 	s.spec.SetControlsDeviceCgroup()
 	c.Assert(s.spec.ControlsDeviceCgroup(), Equals, true)
 }
+
+func (s *specSuite) TestConnectionsAffect(c *C) {
+	spec := &udev.Specification{}
+	c.Check(spec.ConnectionsAffect(&ifacetest.TestInterface{InterfaceName: "test"}), Equals, true)
+	// an interface that does not define any connected side-effects
+	iface := struct{ interfaces.Interface }{&ifacetest.TestInterface{InterfaceName: "test"}}
+	c.Check(spec.ConnectionsAffect(iface), Equals, false)
+}
@@ -62,11 +62,43 @@ type Disk interface {
 	// does not have partitions for example.
 	HasPartitions() bool
 
+	// Health returns the wear and health information the kernel reports
+	// for the disk. Fields which are not known for the disk, as is the case
+	// for anything but eMMC flash at the moment, are left unset.
+	Health() (*Health, error)
+
 	// TODO: add function to get some properties like an associated /dev node
 	//       for a disk for better user error reporting, i.e. /dev/vda3 is much
 	//       more helpful than 252:3
 }
 
+// Health is the health information of a disk device.
+type Health struct {
+	// LifeTimeUsed is the estimated percentage of the rated life time of the
+	// device that has been used up, in steps of 10%, or 0 if unknown. For
+	// eMMC devices this is the worst of the estimates for the different
+	// types of memory of the device.
+	LifeTimeUsed int
+	// PreEOL is the state of the reserved blocks of the device, one of
+	// "normal", "warning" (80% of the reserved blocks are consumed) or
+	// "urgent" (90% of the reserved blocks are consumed), or empty if
+	// unknown.
+	PreEOL string
+}
+
+// Worn returns whether the device is close to wearing out, i.e. whether at
+// least 90% of its estimated life time is used or its reserved blocks are
+// mostly consumed.
+func (h *Health) Worn() bool {
+	return h.LifeTimeUsed >= 90 || h.PreEOL == "warning" || h.PreEOL == "urgent"
+}
+
+// Usage is the size and free space of a mounted filesystem, in bytes.
+type Usage struct {
+	Size uint64
+	Free uint64
+}
+
 // PartitionNotFoundError is an error where a partition matching the SearchType
 // was not found. SearchType can be either "partition-label" or
 // "filesystem-label" to indicate searching by the partition label or the
@@ -40,3 +40,12 @@ func DiskFromMountPoint(mountpoint string, opts *Options) (Disk, error) {
 var diskFromMountPoint = func(mountpoint string, opts *Options) (Disk, error) {
 	return nil, osutil.ErrDarwin
 }
+
+// UsageForMountPoint is not implemented on darwin
+func UsageForMountPoint(mountpoint string) (*Usage, error) {
+	return nil, osutil.ErrDarwin
+}
+
+var usageForMountPoint = func(mountpoint string) (*Usage, error) {
+	return nil, osutil.ErrDarwin
+}
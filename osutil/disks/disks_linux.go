@@ -25,12 +25,14 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/osutil"
@@ -506,3 +508,76 @@ func (d *disk) HasPartitions() bool {
 	//       d.partitions is empty or not
 	return d.hasPartitions
 }
+
+// eMMC devices report the life time estimates for their two types of memory
+// as "0x01 0x02", with 0x01 meaning that 0-10% of the life time is used,
+// 0x02 10-20% and so on up to 0x0a, and 0x0b meaning that the estimated
+// life time was exceeded
+func parseLifeTime(s string) (int, error) {
+	used := 0
+	for _, f := range strings.Fields(s) {
+		v, err := strconv.ParseUint(f, 0, 8)
+		if err != nil {
+			return 0, err
+		}
+		if v > 0x0a {
+			v = 0x0a
+		}
+		if int(v)*10 > used {
+			used = int(v) * 10
+		}
+	}
+	return used, nil
+}
+
+var preEOLStates = map[string]string{
+	"0x01": "normal",
+	"0x02": "warning",
+	"0x03": "urgent",
+}
+
+func (d *disk) Health() (*Health, error) {
+	h := &Health{}
+	devDir := filepath.Join(dirs.SysfsDir, "dev", "block", d.Dev(), "device")
+
+	lifeTime, err := ioutil.ReadFile(filepath.Join(devDir, "life_time"))
+	switch {
+	case err == nil:
+		h.LifeTimeUsed, err = parseLifeTime(string(lifeTime))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse life time estimate of disk %s: %v", d.Dev(), err)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	preEOL, err := ioutil.ReadFile(filepath.Join(devDir, "pre_eol_info"))
+	switch {
+	case err == nil:
+		h.PreEOL = preEOLStates[strings.TrimSpace(string(preEOL))]
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	return h, nil
+}
+
+var syscallStatfs = syscall.Statfs
+
+// UsageForMountPoint returns the size and free space of the filesystem
+// mounted at the given mount point.
+func UsageForMountPoint(mountpoint string) (*Usage, error) {
+	// call the unexported version that may be mocked by tests
+	return usageForMountPoint(mountpoint)
+}
+
+var usageForMountPoint = func(mountpoint string) (*Usage, error) {
+	var st syscall.Statfs_t
+	if err := syscallStatfs(mountpoint, &st); err != nil {
+		return nil, err
+	}
+	return &Usage{
+		Size: st.Blocks * uint64(st.Bsize),
+		Free: st.Bavail * uint64(st.Bsize),
+	}, nil
+}
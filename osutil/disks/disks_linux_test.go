@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"syscall"
 
 	. "gopkg.in/check.v1"
 
@@ -110,6 +111,71 @@ func (s *diskSuite) TestDiskFromNameHappy(c *C) {
 	c.Assert(d.Dev(), Equals, "1:2")
 }
 
+func (s *diskSuite) mockMmcDisk(c *C) disks.Disk {
+	restore := disks.MockUdevPropertiesForDevice(func(dev string) (map[string]string, error) {
+		c.Assert(dev, Equals, "mmcblk0")
+		return map[string]string{
+			"MAJOR":   "179",
+			"MINOR":   "0",
+			"DEVTYPE": "disk",
+		}, nil
+	})
+	defer restore()
+
+	d, err := disks.DiskFromDeviceName("mmcblk0")
+	c.Assert(err, IsNil)
+	return d
+}
+
+func (s *diskSuite) TestDiskHealthEmmc(c *C) {
+	d := s.mockMmcDisk(c)
+
+	devDir := filepath.Join(dirs.SysfsDir, "dev/block/179:0/device")
+	c.Assert(os.MkdirAll(devDir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(devDir, "life_time"), []byte("0x02 0x09\n"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(devDir, "pre_eol_info"), []byte("0x01\n"), 0644), IsNil)
+
+	h, err := d.Health()
+	c.Assert(err, IsNil)
+	c.Check(h, DeepEquals, &disks.Health{LifeTimeUsed: 90, PreEOL: "normal"})
+	c.Check(h.Worn(), Equals, true)
+
+	// exceeded life time is reported as fully used
+	c.Assert(ioutil.WriteFile(filepath.Join(devDir, "life_time"), []byte("0x0b 0x01\n"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(devDir, "pre_eol_info"), []byte("0x03\n"), 0644), IsNil)
+	h, err = d.Health()
+	c.Assert(err, IsNil)
+	c.Check(h, DeepEquals, &disks.Health{LifeTimeUsed: 100, PreEOL: "urgent"})
+
+	c.Assert(ioutil.WriteFile(filepath.Join(devDir, "life_time"), []byte("garbage\n"), 0644), IsNil)
+	_, err = d.Health()
+	c.Check(err, ErrorMatches, `cannot parse life time estimate of disk 179:0: .*`)
+}
+
+func (s *diskSuite) TestDiskHealthUnknown(c *C) {
+	d := s.mockMmcDisk(c)
+
+	h, err := d.Health()
+	c.Assert(err, IsNil)
+	c.Check(h, DeepEquals, &disks.Health{})
+	c.Check(h.Worn(), Equals, false)
+}
+
+func (s *diskSuite) TestUsageForMountPoint(c *C) {
+	restore := disks.MockSyscallStatfs(func(path string, st *syscall.Statfs_t) error {
+		c.Check(path, Equals, "/run/mnt/data")
+		st.Bsize = 4096
+		st.Blocks = 1000
+		st.Bavail = 100
+		return nil
+	})
+	defer restore()
+
+	u, err := disks.UsageForMountPoint("/run/mnt/data")
+	c.Assert(err, IsNil)
+	c.Check(u, DeepEquals, &disks.Usage{Size: 4096 * 1000, Free: 4096 * 100})
+}
+
 func (s *diskSuite) TestDiskFromNameUnhappyPartition(c *C) {
 	restore := disks.MockUdevPropertiesForDevice(func(dev string) (map[string]string, error) {
 		c.Assert(dev, Equals, "sda1")
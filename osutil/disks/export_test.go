@@ -19,7 +19,10 @@
 
 package disks
 
-import "fmt"
+import (
+	"fmt"
+	"syscall"
+)
 
 func MockUdevPropertiesForDevice(new func(string) (map[string]string, error)) (restore func()) {
 	old := udevadmProperties
@@ -41,3 +44,11 @@ func MockUdevPropertiesForDevice(new func(string) (map[string]string, error)) (r
 		udevadmProperties = old
 	}
 }
+
+func MockSyscallStatfs(f func(string, *syscall.Statfs_t) error) (restore func()) {
+	old := syscallStatfs
+	syscallStatfs = f
+	return func() {
+		syscallStatfs = old
+	}
+}
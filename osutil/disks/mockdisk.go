@@ -40,6 +40,9 @@ type MockDiskMapping struct {
 	PartitionLabelToPartUUID map[string]string
 	DiskHasPartitions        bool
 	DevNum                   string
+	// DiskHealth is returned by Health, if unset an empty Health is
+	// returned.
+	DiskHealth *Health
 }
 
 // FindMatchingPartitionUUIDWithFsLabel returns a matching PartitionUUID
@@ -74,6 +77,15 @@ func (d *MockDiskMapping) HasPartitions() bool {
 	return d.DiskHasPartitions
 }
 
+// Health returns the mocked health of the disk. Part of the Disk interface.
+func (d *MockDiskMapping) Health() (*Health, error) {
+	osutil.MustBeTestBinary("mock disks only to be used in tests")
+	if d.DiskHealth == nil {
+		return &Health{}, nil
+	}
+	return d.DiskHealth, nil
+}
+
 // MountPointIsFromDisk returns if the disk that the specified mount point comes
 // from is the same disk as the object. Part of the Disk interface.
 func (d *MockDiskMapping) MountPointIsFromDisk(mountpoint string, opts *Options) (bool, error) {
@@ -188,3 +200,21 @@ func MockMountPointDisksToPartitionMapping(mockedMountPoints map[Mountpoint]*Moc
 		diskFromMountPoint = old
 	}
 }
+
+// MockUsageForMountPoint will mock UsageForMountPoint such that the usage
+// from the provided map of mount points is returned.
+func MockUsageForMountPoint(mockedUsage map[string]*Usage) (restore func()) {
+	osutil.MustBeTestBinary("mock disks only to be used in tests")
+
+	old := usageForMountPoint
+	usageForMountPoint = func(mountpoint string) (*Usage, error) {
+		usage, ok := mockedUsage[mountpoint]
+		if !ok {
+			return nil, fmt.Errorf("mountpoint %s not mocked", mountpoint)
+		}
+		return usage, nil
+	}
+	return func() {
+		usageForMountPoint = old
+	}
+}
@@ -0,0 +1,98 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package fswatch notifies about changes to files and directories.
+//
+// Changes are reported through a callback. Bursts of changes, as are typical
+// when a file is written or a directory is populated, are coalesced so that
+// the callback is called once with all the paths that changed.
+package fswatch
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotSupported is returned by New on systems without inotify.
+var ErrNotSupported = errors.New("file watching is not supported")
+
+// coalescer collects changed paths and hands them to notify once no more
+// changes were seen for the given delay.
+type coalescer struct {
+	delay  time.Duration
+	notify func(paths []string)
+
+	mu      sync.Mutex
+	pending map[string]bool
+	timer   *time.Timer
+	stopped bool
+}
+
+func newCoalescer(delay time.Duration, notify func(paths []string)) *coalescer {
+	return &coalescer{
+		delay:   delay,
+		notify:  notify,
+		pending: make(map[string]bool),
+	}
+}
+
+// add records that the given path changed.
+func (c *coalescer) add(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return
+	}
+	c.pending[path] = true
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.delay, c.flush)
+	} else {
+		c.timer.Reset(c.delay)
+	}
+}
+
+func (c *coalescer) flush() {
+	c.mu.Lock()
+	if c.stopped || len(c.pending) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	paths := make([]string, 0, len(c.pending))
+	for p := range c.pending {
+		paths = append(paths, p)
+	}
+	c.pending = make(map[string]bool)
+	c.timer = nil
+	c.mu.Unlock()
+
+	sort.Strings(paths)
+	c.notify(paths)
+}
+
+// stop discards pending changes, notify is not called anymore.
+func (c *coalescer) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
@@ -0,0 +1,36 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package fswatch
+
+import (
+	"time"
+)
+
+// Watcher is not supported on darwin.
+type Watcher struct{}
+
+func New(delay time.Duration, notify func(paths []string)) (*Watcher, error) {
+	return nil, ErrNotSupported
+}
+
+func (w *Watcher) Add(path string) error          { return ErrNotSupported }
+func (w *Watcher) AddRecursive(path string) error { return ErrNotSupported }
+func (w *Watcher) Remove(path string) error       { return ErrNotSupported }
+func (w *Watcher) Close() error                   { return nil }
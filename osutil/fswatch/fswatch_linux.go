@@ -0,0 +1,256 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package fswatch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/snapcore/snapd/logger"
+)
+
+const watchMask = unix.IN_ATTRIB | unix.IN_CLOSE_WRITE | unix.IN_CREATE |
+	unix.IN_DELETE | unix.IN_DELETE_SELF | unix.IN_MODIFY |
+	unix.IN_MOVE_SELF | unix.IN_MOVED_FROM | unix.IN_MOVED_TO
+
+// Watcher watches files and directories for changes using inotify.
+type Watcher struct {
+	fd int
+	// wakeup is a pipe used to interrupt the reading loop on Close
+	wakeup [2]int
+	co     *coalescer
+
+	mu sync.Mutex
+	// paths of the watches, by watch descriptor
+	paths map[int]string
+	// watch descriptors, by path
+	wds map[string]int
+	// recursive is true for directories watched recursively, including
+	// their sub-directories
+	recursive map[string]bool
+
+	done chan struct{}
+}
+
+// New returns a watcher which calls notify with the paths that changed, once
+// no further changes were seen for the given delay. notify is called from a
+// separate goroutine.
+func New(delay time.Duration, notify func(paths []string)) (*Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("cannot initialize inotify: %v", err)
+	}
+	w := &Watcher{
+		fd:        fd,
+		co:        newCoalescer(delay, notify),
+		paths:     make(map[int]string),
+		wds:       make(map[string]int),
+		recursive: make(map[string]bool),
+		done:      make(chan struct{}),
+	}
+	if err := unix.Pipe2(w.wakeup[:], unix.O_CLOEXEC|unix.O_NONBLOCK); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("cannot create wakeup pipe: %v", err)
+	}
+	go w.loop()
+	return w, nil
+}
+
+// Add watches the given file or directory. For directories, changes to the
+// entries of the directory are reported, but not changes below them.
+func (w *Watcher) Add(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.addLocked(filepath.Clean(path))
+}
+
+// AddRecursive watches the given directory and all the directories below it,
+// including the ones created later.
+func (w *Watcher) AddRecursive(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.addTreeLocked(filepath.Clean(path))
+}
+
+func (w *Watcher) addTreeLocked(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path != root && os.IsNotExist(err) {
+				// removed while walking
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if err := w.addLocked(path); err != nil {
+			return err
+		}
+		w.recursive[path] = true
+		return nil
+	})
+}
+
+func (w *Watcher) addLocked(path string) error {
+	wd, err := unix.InotifyAddWatch(w.fd, path, watchMask)
+	if err != nil {
+		return fmt.Errorf("cannot watch %q: %v", path, err)
+	}
+	w.paths[wd] = path
+	w.wds[path] = wd
+	return nil
+}
+
+// Remove stops watching the given path. Paths watched recursively stop being
+// watched together with all the directories below them.
+func (w *Watcher) Remove(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	path = filepath.Clean(path)
+	if _, ok := w.wds[path]; !ok {
+		return fmt.Errorf("cannot remove watch for %q: not watched", path)
+	}
+	toRemove := []string{path}
+	if w.recursive[path] {
+		prefix := path + "/"
+		for p := range w.recursive {
+			if len(p) > len(prefix) && p[:len(prefix)] == prefix {
+				toRemove = append(toRemove, p)
+			}
+		}
+	}
+	for _, p := range toRemove {
+		wd := w.wds[p]
+		w.forgetLocked(wd)
+		// the watch is gone already if the path was removed
+		unix.InotifyRmWatch(w.fd, uint32(wd))
+	}
+	return nil
+}
+
+func (w *Watcher) forgetLocked(wd int) {
+	path := w.paths[wd]
+	delete(w.paths, wd)
+	delete(w.wds, path)
+	delete(w.recursive, path)
+}
+
+// Close stops watching all paths. Pending changes are not reported.
+func (w *Watcher) Close() error {
+	w.co.stop()
+	unix.Write(w.wakeup[1], []byte{0})
+	<-w.done
+	unix.Close(w.wakeup[0])
+	unix.Close(w.wakeup[1])
+	return unix.Close(w.fd)
+}
+
+func (w *Watcher) loop() {
+	defer close(w.done)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	fds := []unix.PollFd{
+		{Fd: int32(w.fd), Events: unix.POLLIN},
+		{Fd: int32(w.wakeup[0]), Events: unix.POLLIN},
+	}
+	for {
+		if _, err := unix.Poll(fds, -1); err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			logger.Noticef("cannot poll inotify events: %v", err)
+			return
+		}
+		if fds[1].Revents != 0 {
+			// closing
+			return
+		}
+		n, err := unix.Read(w.fd, buf)
+		if err != nil {
+			if err == unix.EAGAIN || err == unix.EINTR {
+				continue
+			}
+			logger.Noticef("cannot read inotify events: %v", err)
+			return
+		}
+		w.handleEvents(buf[:n])
+	}
+}
+
+func (w *Watcher) handleEvents(buf []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buf); {
+		ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + unix.SizeofInotifyEvent
+		offset = nameStart + int(ev.Len)
+		if offset > len(buf) {
+			// cannot happen, the kernel only returns whole events
+			break
+		}
+
+		if ev.Mask&unix.IN_Q_OVERFLOW != 0 {
+			// events were lost, report all the watched paths
+			for _, p := range w.paths {
+				w.co.add(p)
+			}
+			continue
+		}
+
+		wd := int(ev.Wd)
+		dir, ok := w.paths[wd]
+		if !ok {
+			continue
+		}
+		path := dir
+		if ev.Len > 0 {
+			name := buf[nameStart:offset]
+			// the name is padded with NUL bytes
+			for i, b := range name {
+				if b == 0 {
+					name = name[:i]
+					break
+				}
+			}
+			path = filepath.Join(dir, string(name))
+		}
+
+		if ev.Mask&unix.IN_IGNORED != 0 {
+			// the watched path was removed or unmounted
+			w.forgetLocked(wd)
+			continue
+		}
+		if ev.Mask&unix.IN_ISDIR != 0 && ev.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 && w.recursive[dir] {
+			if err := w.addTreeLocked(path); err != nil {
+				logger.Debugf("cannot watch new directory: %v", err)
+			}
+		}
+		w.co.add(path)
+	}
+}
@@ -0,0 +1,160 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package fswatch_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/osutil/fswatch"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type fswatchSuite struct {
+	dir     string
+	changes chan []string
+	w       *fswatch.Watcher
+}
+
+var _ = Suite(&fswatchSuite{})
+
+func (s *fswatchSuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+	s.changes = make(chan []string, 10)
+	w, err := fswatch.New(20*time.Millisecond, func(paths []string) {
+		s.changes <- paths
+	})
+	c.Assert(err, IsNil)
+	s.w = w
+}
+
+func (s *fswatchSuite) TearDownTest(c *C) {
+	c.Check(s.w.Close(), IsNil)
+}
+
+func (s *fswatchSuite) waitChanges(c *C) []string {
+	select {
+	case paths := <-s.changes:
+		return paths
+	case <-time.After(5 * time.Second):
+		c.Fatalf("timeout waiting for changes")
+	}
+	return nil
+}
+
+func (s *fswatchSuite) checkNoChanges(c *C) {
+	select {
+	case paths := <-s.changes:
+		c.Errorf("unexpected changes: %v", paths)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (s *fswatchSuite) TestWatchDirCoalesces(c *C) {
+	c.Assert(s.w.Add(s.dir), IsNil)
+
+	foo := filepath.Join(s.dir, "foo")
+	bar := filepath.Join(s.dir, "bar")
+	c.Assert(ioutil.WriteFile(foo, []byte("foo"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(bar, []byte("bar"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(foo, []byte("more foo"), 0644), IsNil)
+
+	c.Check(s.waitChanges(c), DeepEquals, []string{bar, foo})
+	s.checkNoChanges(c)
+
+	c.Assert(os.Remove(bar), IsNil)
+	c.Check(s.waitChanges(c), DeepEquals, []string{bar})
+}
+
+func (s *fswatchSuite) TestWatchFile(c *C) {
+	foo := filepath.Join(s.dir, "foo")
+	c.Assert(ioutil.WriteFile(foo, nil, 0644), IsNil)
+	c.Assert(s.w.Add(foo), IsNil)
+
+	// changes to other files are not reported
+	c.Assert(ioutil.WriteFile(filepath.Join(s.dir, "bar"), nil, 0644), IsNil)
+	s.checkNoChanges(c)
+
+	c.Assert(ioutil.WriteFile(foo, []byte("foo"), 0644), IsNil)
+	c.Check(s.waitChanges(c), DeepEquals, []string{foo})
+}
+
+func (s *fswatchSuite) TestWatchNonRecursive(c *C) {
+	sub := filepath.Join(s.dir, "sub")
+	c.Assert(os.Mkdir(sub, 0755), IsNil)
+	c.Assert(s.w.Add(s.dir), IsNil)
+
+	c.Assert(ioutil.WriteFile(filepath.Join(sub, "foo"), nil, 0644), IsNil)
+	s.checkNoChanges(c)
+}
+
+func (s *fswatchSuite) TestWatchRecursive(c *C) {
+	sub := filepath.Join(s.dir, "sub")
+	c.Assert(os.Mkdir(sub, 0755), IsNil)
+	c.Assert(s.w.AddRecursive(s.dir), IsNil)
+
+	foo := filepath.Join(sub, "foo")
+	c.Assert(ioutil.WriteFile(foo, nil, 0644), IsNil)
+	c.Check(s.waitChanges(c), DeepEquals, []string{foo})
+
+	// new directories are watched too
+	newSub := filepath.Join(sub, "new")
+	c.Assert(os.Mkdir(newSub, 0755), IsNil)
+	c.Check(s.waitChanges(c), DeepEquals, []string{newSub})
+	bar := filepath.Join(newSub, "bar")
+	c.Assert(ioutil.WriteFile(bar, nil, 0644), IsNil)
+	c.Check(s.waitChanges(c), DeepEquals, []string{bar})
+}
+
+func (s *fswatchSuite) TestRemove(c *C) {
+	sub := filepath.Join(s.dir, "sub")
+	c.Assert(os.Mkdir(sub, 0755), IsNil)
+	c.Assert(s.w.AddRecursive(s.dir), IsNil)
+
+	c.Assert(s.w.Remove(s.dir), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.dir, "foo"), nil, 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(sub, "foo"), nil, 0644), IsNil)
+	s.checkNoChanges(c)
+
+	c.Check(s.w.Remove(s.dir), ErrorMatches, `cannot remove watch for ".*": not watched`)
+}
+
+func (s *fswatchSuite) TestAddMissing(c *C) {
+	err := s.w.Add(filepath.Join(s.dir, "missing"))
+	c.Check(err, ErrorMatches, `cannot watch ".*/missing": no such file or directory`)
+}
+
+func (s *fswatchSuite) TestCloseDiscardsPending(c *C) {
+	w, err := fswatch.New(time.Hour, func(paths []string) {
+		c.Errorf("unexpected notification: %v", paths)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(w.Add(s.dir), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.dir, "foo"), nil, 0644), IsNil)
+	// give the watcher a chance to see the change
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(w.Close(), IsNil)
+}
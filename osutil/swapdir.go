@@ -0,0 +1,131 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package osutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// errExchangeUnsupported is returned by exchangeDirs when the kernel or the
+// filesystem cannot exchange two directories atomically.
+var errExchangeUnsupported = errors.New("cannot exchange directories atomically")
+
+// AtomicSwapDir exchanges the directories src and dst, so that dst has the
+// content src used to have and src has the previous content of dst. If dst
+// does not exist src is simply renamed to dst.
+//
+// The content of src is synced to disk before the exchange and the parent
+// directories are synced after it, so that dst is never observed, even after
+// a crash, with only some of the new content. The exchange uses
+// renameat2(RENAME_EXCHANGE) where available; otherwise it falls back to a
+// sequence of renames which leaves a short window where dst is missing, but
+// is never partially written.
+//
+// Both directories must be on the same filesystem. It is the caller's
+// responsibility to remove src once it is no longer needed.
+func AtomicSwapDir(src, dst string) error {
+	if !IsDirectory(src) {
+		return fmt.Errorf("cannot swap %q and %q: %q is not a directory", src, dst, src)
+	}
+	if !snapdUnsafeIO {
+		if err := syncTree(src); err != nil {
+			return fmt.Errorf("cannot sync %q: %v", src, err)
+		}
+	}
+
+	if !FileExists(dst) {
+		return AtomicRename(src, dst)
+	}
+	if !IsDirectory(dst) {
+		return fmt.Errorf("cannot swap %q and %q: %q is not a directory", src, dst, dst)
+	}
+
+	err := exchangeDirs(src, dst)
+	if err == errExchangeUnsupported {
+		err = renameSwapDirs(src, dst)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot swap %q and %q: %v", src, dst, err)
+	}
+
+	if snapdUnsafeIO {
+		return nil
+	}
+	return syncParents(src, dst)
+}
+
+// renameSwapDirs exchanges src and dst with three renames.
+func renameSwapDirs(src, dst string) error {
+	old := dst + ".old~"
+	if err := os.Rename(dst, old); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		// put things back the way they were
+		if rerr := os.Rename(old, dst); rerr != nil {
+			return fmt.Errorf("%v (and cannot restore %q: %v)", err, dst, rerr)
+		}
+		return err
+	}
+	return os.Rename(old, src)
+}
+
+// syncTree syncs the directory at root and everything it contains.
+func syncTree(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return f.Sync()
+	})
+}
+
+// syncParents syncs the parent directories of the given paths.
+func syncParents(paths ...string) error {
+	synced := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		parent := filepath.Dir(filepath.Clean(p))
+		if synced[parent] {
+			continue
+		}
+		d, err := os.Open(parent)
+		if err != nil {
+			return err
+		}
+		err = d.Sync()
+		d.Close()
+		if err != nil {
+			return err
+		}
+		synced[parent] = true
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package osutil
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+var sysRenameat2 = unix.Renameat2
+
+// exchangeDirs atomically exchanges src and dst with renameat2(2).
+func exchangeDirs(src, dst string) error {
+	err := sysRenameat2(unix.AT_FDCWD, src, unix.AT_FDCWD, dst, unix.RENAME_EXCHANGE)
+	switch err {
+	case unix.ENOSYS, unix.EINVAL:
+		// the kernel is older than 3.15 or the filesystem does not
+		// support RENAME_EXCHANGE
+		return errExchangeUnsupported
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package osutil_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type swapDirSuite struct {
+	testutil.BaseTest
+	dir string
+}
+
+var _ = Suite(&swapDirSuite{})
+
+func (s *swapDirSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.dir = c.MkDir()
+}
+
+func (s *swapDirSuite) mkDir(c *C, name string, files map[string]string) string {
+	dir := filepath.Join(s.dir, name)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	for fname, content := range files {
+		c.Assert(ioutil.WriteFile(filepath.Join(dir, fname), []byte(content), 0644), IsNil)
+	}
+	return dir
+}
+
+func (s *swapDirSuite) checkSwapped(c *C, src, dst string) {
+	c.Check(filepath.Join(dst, "new.cfg"), testutil.FileEquals, "new")
+	c.Check(filepath.Join(dst, "old.cfg"), testutil.FileAbsent)
+	c.Check(filepath.Join(src, "old.cfg"), testutil.FileEquals, "old")
+	c.Check(filepath.Join(src, "new.cfg"), testutil.FileAbsent)
+}
+
+func (s *swapDirSuite) TestSwap(c *C) {
+	for _, unsafeIO := range []bool{true, false} {
+		restore := osutil.SetUnsafeIO(unsafeIO)
+		src := s.mkDir(c, "src", map[string]string{"new.cfg": "new"})
+		dst := s.mkDir(c, "dst", map[string]string{"old.cfg": "old"})
+
+		err := osutil.AtomicSwapDir(src, dst)
+		restore()
+		c.Assert(err, IsNil)
+		s.checkSwapped(c, src, dst)
+
+		c.Assert(os.RemoveAll(src), IsNil)
+		c.Assert(os.RemoveAll(dst), IsNil)
+	}
+}
+
+func (s *swapDirSuite) TestSwapUsesRenameExchange(c *C) {
+	var calls []string
+	restore := osutil.MockRenameat2(func(olddirfd int, oldpath string, newdirfd int, newpath string, flags uint) error {
+		c.Check(flags&(1<<1), Not(Equals), uint(0), Commentf("RENAME_EXCHANGE not set"))
+		calls = append(calls, oldpath, newpath)
+		return nil
+	})
+	defer restore()
+
+	src := s.mkDir(c, "src", map[string]string{"new.cfg": "new"})
+	dst := s.mkDir(c, "dst", map[string]string{"old.cfg": "old"})
+
+	c.Assert(osutil.AtomicSwapDir(src, dst), IsNil)
+	c.Check(calls, DeepEquals, []string{src, dst})
+}
+
+func (s *swapDirSuite) TestSwapFallback(c *C) {
+	for _, errno := range []syscall.Errno{syscall.ENOSYS, syscall.EINVAL} {
+		restore := osutil.MockRenameat2(func(olddirfd int, oldpath string, newdirfd int, newpath string, flags uint) error {
+			return errno
+		})
+		src := s.mkDir(c, "src", map[string]string{"new.cfg": "new"})
+		dst := s.mkDir(c, "dst", map[string]string{"old.cfg": "old"})
+
+		err := osutil.AtomicSwapDir(src, dst)
+		restore()
+		c.Assert(err, IsNil)
+		s.checkSwapped(c, src, dst)
+		c.Check(dst+".old~", testutil.FileAbsent)
+
+		c.Assert(os.RemoveAll(src), IsNil)
+		c.Assert(os.RemoveAll(dst), IsNil)
+	}
+}
+
+func (s *swapDirSuite) TestSwapError(c *C) {
+	restore := osutil.MockRenameat2(func(olddirfd int, oldpath string, newdirfd int, newpath string, flags uint) error {
+		return syscall.EXDEV
+	})
+	defer restore()
+
+	src := s.mkDir(c, "src", map[string]string{"new.cfg": "new"})
+	dst := s.mkDir(c, "dst", map[string]string{"old.cfg": "old"})
+
+	err := osutil.AtomicSwapDir(src, dst)
+	c.Assert(err, ErrorMatches, `cannot swap ".*/src" and ".*/dst": invalid cross-device link`)
+	c.Check(filepath.Join(dst, "old.cfg"), testutil.FileEquals, "old")
+}
+
+func (s *swapDirSuite) TestSwapNoDestination(c *C) {
+	src := s.mkDir(c, "src", map[string]string{"new.cfg": "new"})
+	dst := filepath.Join(s.dir, "dst")
+
+	c.Assert(osutil.AtomicSwapDir(src, dst), IsNil)
+	c.Check(filepath.Join(dst, "new.cfg"), testutil.FileEquals, "new")
+	c.Check(src, testutil.FileAbsent)
+}
+
+func (s *swapDirSuite) TestSwapNotDirectories(c *C) {
+	dir := s.mkDir(c, "dir", nil)
+	file := filepath.Join(s.dir, "file")
+	c.Assert(ioutil.WriteFile(file, nil, 0644), IsNil)
+
+	err := osutil.AtomicSwapDir(file, dir)
+	c.Check(err, ErrorMatches, `cannot swap ".*/file" and ".*/dir": ".*/file" is not a directory`)
+	err = osutil.AtomicSwapDir(dir, file)
+	c.Check(err, ErrorMatches, `cannot swap ".*/dir" and ".*/file": ".*/file" is not a directory`)
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/snapasserts"
 	"github.com/snapcore/snapd/asserts/sysdb"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 )
@@ -80,6 +81,30 @@ func DB(s *state.State) asserts.RODatabase {
 	return cachedDB(s)
 }
 
+// snapAssertionsFetchError maps the per-group errors of bulk fetching
+// the assertions for a snap to what one-by-one fetching would have
+// returned. A missing store assertion is not an error.
+func snapAssertionsFetchError(rpe *resolvePoolError, sha3_384, storeID string) error {
+	if storeID != "" {
+		storeGroup := (&asserts.Ref{Type: asserts.StoreType, PrimaryKey: []string{storeID}}).Unique()
+		if e := rpe.errors[storeGroup]; asserts.IsNotFound(e) || e == asserts.ErrUnresolved {
+			delete(rpe.errors, storeGroup)
+		}
+	}
+	revGroup := (&asserts.Ref{Type: asserts.SnapRevisionType, PrimaryKey: []string{sha3_384}}).Unique()
+	switch e := rpe.errors[revGroup]; {
+	case e == asserts.ErrUnresolved:
+		headers, _ := asserts.HeadersFromPrimaryKey(asserts.SnapRevisionType, []string{sha3_384})
+		return &asserts.NotFoundError{Type: asserts.SnapRevisionType, Headers: headers}
+	case e != nil:
+		return e
+	}
+	if len(rpe.errors) != 0 {
+		return rpe
+	}
+	return nil
+}
+
 // doValidateSnap fetches the relevant assertions for the snap being installed and cross checks them with the snap.
 func doValidateSnap(t *state.Task, _ *tomb.Tomb) error {
 	st := t.State()
@@ -103,7 +128,7 @@ func doValidateSnap(t *state.Task, _ *tomb.Tomb) error {
 
 	modelAs := deviceCtx.Model()
 
-	err = doFetch(st, snapsup.UserID, deviceCtx, func(f asserts.Fetcher) error {
+	fetching := func(f asserts.Fetcher) error {
 		if err := snapasserts.FetchSnapAssertions(f, sha3_384); err != nil {
 			return err
 		}
@@ -121,7 +146,15 @@ func doValidateSnap(t *state.Task, _ *tomb.Tomb) error {
 		}
 
 		return nil
-	})
+	}
+	err = bulkFetch(st, snapsup.UserID, deviceCtx, fetching)
+	if _, ok := err.(*bulkAssertionFallbackError); ok {
+		logger.Noticef("bulk fetching of snap assertions failed, falling back to one-by-one assertion fetching: %v", err)
+		err = doFetch(st, snapsup.UserID, deviceCtx, fetching)
+	}
+	if rpe, ok := err.(*resolvePoolError); ok {
+		err = snapAssertionsFetchError(rpe, sha3_384, modelAs.Store())
+	}
 	if notFound, ok := err.(*asserts.NotFoundError); ok {
 		if notFound.Type == asserts.SnapRevisionType {
 			return fmt.Errorf("cannot verify snap %q, no matching signatures found", snapsup.InstanceName())
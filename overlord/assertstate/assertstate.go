@@ -23,13 +23,17 @@
 package assertstate
 
 import (
+	"crypto"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
@@ -127,13 +131,21 @@ func (e *refreshControlError) Error() string {
 // the snapInfos, looking for the needed refresh control validation assertions,
 // it returns a validated subset in validated and a summary error if not all
 // candidates validated. ignoreValidation is a set of snap-instance-names that
-// should not be gated.
+// should not be gated. With the check-refresh-metadata feature enabled the
+// store metadata of all the candidates is also checked against their
+// snap-revision and snap-declaration assertions.
 func ValidateRefreshes(s *state.State, snapInfos []*snap.Info, ignoreValidation map[string]bool, userID int, deviceCtx snapstate.DeviceContext) (validated []*snap.Info, err error) {
 	// maps gated snap-ids to gating snap-ids
 	controlled := make(map[string][]string)
 	// maps gating snap-ids to their snap names
 	gatingNames := make(map[string]string)
 
+	tr := config.NewTransaction(s)
+	checkMetadata, err := features.Flag(tr, features.CheckRefreshMetadata)
+	if err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+
 	db := DB(s)
 	snapStates, err := snapstate.All(s)
 	if err != nil {
@@ -171,6 +183,12 @@ func ValidateRefreshes(s *state.State, snapInfos []*snap.Info, ignoreValidation
 
 	var errs []error
 	for _, candInfo := range snapInfos {
+		if checkMetadata {
+			if err := crossCheckRefreshMetadata(s, candInfo, userID, deviceCtx); err != nil {
+				errs = append(errs, fmt.Errorf("cannot refresh %q to revision %s: %v", candInfo.InstanceName(), candInfo.Revision, err))
+				continue
+			}
+		}
 		if ignoreValidation[candInfo.InstanceName()] {
 			validated = append(validated, candInfo)
 			continue
@@ -233,6 +251,32 @@ func ValidateRefreshes(s *state.State, snapInfos []*snap.Info, ignoreValidation
 	return validated, nil
 }
 
+// crossCheckRefreshMetadata checks the metadata of the refresh candidate
+// obtained from the store against the assertions of the revision it
+// designates, before anything is downloaded.
+func crossCheckRefreshMetadata(s *state.State, candInfo *snap.Info, userID int, deviceCtx snapstate.DeviceContext) error {
+	// the store provides the hex sha3-384 of the snap while assertions
+	// use its encoded digest
+	hash, err := hex.DecodeString(candInfo.Sha3_384)
+	if err != nil || len(hash) == 0 {
+		return fmt.Errorf("store metadata has no valid sha3-384")
+	}
+	sha3_384, err := asserts.EncodeDigest(crypto.SHA3_384, hash)
+	if err != nil {
+		return fmt.Errorf("store metadata has no valid sha3-384: %v", err)
+	}
+	err = doFetch(s, userID, deviceCtx, func(f asserts.Fetcher) error {
+		return snapasserts.FetchSnapAssertions(f, sha3_384)
+	})
+	if notFound, ok := err.(*asserts.NotFoundError); ok && notFound.Type == asserts.SnapRevisionType {
+		return fmt.Errorf("no snap-revision assertion matches the store metadata")
+	}
+	if err != nil {
+		return err
+	}
+	return snapasserts.CrossCheck(candInfo.InstanceName(), sha3_384, uint64(candInfo.Size), &candInfo.SideInfo, DB(s))
+}
+
 // BaseDeclaration returns the base-declaration assertion with policies governing all snaps.
 func BaseDeclaration(s *state.State) (*asserts.BaseDeclaration, error) {
 	// TODO: switch keeping this in the DB and have it revisioned/updated
@@ -277,6 +321,16 @@ func Publisher(s *state.State, snapID string) (*asserts.Account, error) {
 	return a.(*asserts.Account), nil
 }
 
+// snapPublisherID returns the id of the publisher of the given snap-id as
+// found in its snap-declaration.
+func snapPublisherID(s *state.State, snapID string) (string, error) {
+	snapDecl, err := SnapDeclaration(s, snapID)
+	if err != nil {
+		return "", fmt.Errorf("cannot find snap-declaration for snap-id %q: %v", snapID, err)
+	}
+	return snapDecl.PublisherID(), nil
+}
+
 // Store returns the store assertion with the given name/id if it is
 // present in the system assertion database.
 func Store(s *state.State, store string) (*asserts.Store, error) {
@@ -332,6 +386,8 @@ func delayedCrossMgrInit() {
 	snapstate.AutoAliases = AutoAliases
 	// hook the helper for getting enforced validation sets
 	snapstate.EnforcedValidationSets = EnforcedValidationSets
+	// hook the publisher lookup used when snaps replace other snaps
+	snapstate.SnapPublisherID = snapPublisherID
 }
 
 // AutoRefreshAssertions tries to refresh all assertions
@@ -339,9 +395,45 @@ func AutoRefreshAssertions(s *state.State, userID int) error {
 	if err := RefreshSnapDeclarations(s, userID); err != nil {
 		return err
 	}
+	if err := RefreshAccountKeyRevocations(s, userID); err != nil {
+		return err
+	}
 	return RefreshValidationSetAssertions(s, userID)
 }
 
+// RefreshAccountKeyRevocations tries to fetch revocations for all the
+// account-keys in the system assertion database, so that assertions
+// signed with revoked keys get rejected from then on even if the
+// device was offline when the revocation was published.
+func RefreshAccountKeyRevocations(s *state.State, userID int) error {
+	deviceCtx, err := snapstate.DevicePastSeeding(s, nil)
+	if err != nil {
+		return err
+	}
+
+	keys, err := cachedDB(s).FindMany(asserts.AccountKeyType, nil)
+	if asserts.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fetching := func(f asserts.Fetcher) error {
+		for _, k := range keys {
+			ref := &asserts.Ref{
+				Type:       asserts.AccountKeyRevocationType,
+				PrimaryKey: []string{k.(*asserts.AccountKey).PublicKeyID()},
+			}
+			if err := f.Fetch(ref); err != nil && !asserts.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+	return doFetch(s, userID, deviceCtx, fetching)
+}
+
 // RefreshValidationSetAssertions tries to refresh all validation set
 // assertions.
 func RefreshValidationSetAssertions(s *state.State, userID int) error {
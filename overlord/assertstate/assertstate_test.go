@@ -45,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
 	"github.com/snapcore/snapd/overlord/state"
@@ -748,6 +749,29 @@ func (s *assertMgrSuite) TestValidateSnap(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *assertMgrSuite) TestValidateSnapBulk(c *C) {
+	s.TestValidateSnap(c)
+
+	// the snap-revision and the store assertion were requested
+	// together from the store
+	requestedTypes := s.fakeStore.(*fakeStore).requestedTypes
+	c.Assert(len(requestedTypes) > 0, Equals, true)
+	c.Check(requestedTypes[0], DeepEquals, []string{"snap-revision", "store"})
+}
+
+func (s *assertMgrSuite) TestValidateSnapBulkFallback(c *C) {
+	// test that if we get a 4xx or 500 error from the store trying bulk
+	// assertion fetching we fall back to fetching one by one
+	s.fakeStore.(*fakeStore).snapActionErr = &store.UnexpectedHTTPStatusError{StatusCode: 400}
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	s.TestValidateSnap(c)
+
+	c.Check(logbuf.String(), Matches, "(?m).*bulk fetching of snap assertions failed, falling back to one-by-one assertion fetching:.*HTTP status code 400.*")
+}
+
 func (s *assertMgrSuite) TestValidateSnapStoreNotFound(c *C) {
 	s.prereqSnapAssertions(c, 10)
 
@@ -1553,6 +1577,64 @@ func (s *assertMgrSuite) TestRefreshSnapDeclarationsMany32WithStore(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *assertMgrSuite) TestRefreshAccountKeyRevocationsNop(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setModel(sysdb.GenericClassicModel())
+
+	err := assertstate.RefreshAccountKeyRevocations(s.state, 0)
+	c.Assert(err, IsNil)
+}
+
+func (s *assertMgrSuite) TestRefreshAccountKeyRevocations(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setModel(sysdb.GenericClassicModel())
+
+	err := assertstate.Add(s.state, s.storeSigning.StoreAccountKey(""))
+	c.Assert(err, IsNil)
+	err = assertstate.Add(s.state, s.dev1Acct)
+	c.Assert(err, IsNil)
+	err = assertstate.Add(s.state, s.dev1AcctKey)
+	c.Assert(err, IsNil)
+
+	// nothing revoked yet
+	err = assertstate.RefreshAccountKeyRevocations(s.state, 0)
+	c.Assert(err, IsNil)
+
+	revocation, err := s.storeSigning.RootSigning.Sign(asserts.AccountKeyRevocationType, map[string]interface{}{
+		"public-key-sha3-384": s.dev1AcctKey.PublicKeyID(),
+		"account-id":          s.dev1Acct.AccountID(),
+		"timestamp":           time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	err = s.storeSigning.Add(revocation)
+	c.Assert(err, IsNil)
+
+	err = assertstate.RefreshAccountKeyRevocations(s.state, 0)
+	c.Assert(err, IsNil)
+
+	_, err = assertstate.DB(s.state).Find(asserts.AccountKeyRevocationType, map[string]string{
+		"public-key-sha3-384": s.dev1AcctKey.PublicKeyID(),
+	})
+	c.Assert(err, IsNil)
+
+	// assertions signed with the revoked key are now rejected
+	snapBuild, err := s.dev1Signing.Sign(asserts.SnapBuildType, map[string]interface{}{
+		"series":        "16",
+		"snap-id":       "foo-id",
+		"snap-sha3-384": makeDigest(1),
+		"snap-size":     "1000",
+		"grade":         "stable",
+		"timestamp":     time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	err = assertstate.Add(s.state, snapBuild)
+	c.Check(err, ErrorMatches, `assertion is signed with revoked public key .*`)
+}
+
 func (s *assertMgrSuite) TestValidateRefreshesNothing(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -1589,6 +1671,42 @@ func (s *assertMgrSuite) TestValidateRefreshesNoControl(c *C) {
 	c.Check(validated, DeepEquals, []*snap.Info{fooRefresh})
 }
 
+func (s *assertMgrSuite) TestValidateRefreshesCheckMetadata(c *C) {
+	s.prereqSnapAssertions(c, 10, 11)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "experimental.check-refresh-metadata", true)
+	tr.Commit()
+
+	refresh := func(rev, digestRev int) *snap.Info {
+		return &snap.Info{
+			SideInfo: snap.SideInfo{RealName: "foo", SnapID: "snap-id-1", Revision: snap.R(rev)},
+			DownloadInfo: snap.DownloadInfo{
+				Sha3_384: fmt.Sprintf("%x", fakeHash(digestRev)),
+				Size:     int64(len(fakeSnap(digestRev))),
+			},
+		}
+	}
+
+	good := refresh(10, 10)
+	validated, err := assertstate.ValidateRefreshes(s.state, []*snap.Info{good}, nil, 0, s.trivialDeviceCtx)
+	c.Assert(err, IsNil)
+	c.Check(validated, DeepEquals, []*snap.Info{good})
+
+	// the metadata of revision 11 points to the content of revision 10
+	validated, err = assertstate.ValidateRefreshes(s.state, []*snap.Info{refresh(11, 10)}, nil, 0, s.trivialDeviceCtx)
+	c.Assert(err, ErrorMatches, `cannot refresh "foo" to revision 11: snap "foo" does not have expected ID or revision according to assertions \(metadata is broken or tampered\).*`)
+	c.Check(validated, HasLen, 0)
+
+	// no assertions for the content
+	validated, err = assertstate.ValidateRefreshes(s.state, []*snap.Info{refresh(12, 12)}, nil, 0, s.trivialDeviceCtx)
+	c.Assert(err, ErrorMatches, `cannot refresh "foo" to revision 12: no snap-revision assertion matches the store metadata`)
+	c.Check(validated, HasLen, 0)
+}
+
 func (s *assertMgrSuite) TestValidateRefreshesMissingValidation(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -2114,6 +2232,13 @@ func (s *assertMgrSuite) TestPublisher(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(acct.AccountID(), Equals, s.dev1Acct.AccountID())
 	c.Check(acct.Username(), Equals, "developer1")
+
+	publisherID, err := assertstate.SnapPublisherID(s.state, "foo-id")
+	c.Assert(err, IsNil)
+	c.Check(publisherID, Equals, s.dev1Acct.AccountID())
+
+	_, err = assertstate.SnapPublisherID(s.state, "snap-id-other")
+	c.Check(err, ErrorMatches, `cannot find snap-declaration for snap-id "snap-id-other": .*`)
 }
 
 func (s *assertMgrSuite) TestStore(c *C) {
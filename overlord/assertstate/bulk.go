@@ -200,6 +200,22 @@ func bulkRefreshValidationSetAsserts(s *state.State, vsets map[string]*Validatio
 	return fmt.Errorf("cannot refresh validation set assertions: %v", err)
 }
 
+// bulkFetch fetches in batches through the store the assertions
+// requested via fetching together with their prerequisites, instead
+// of one at a time. Each requested assertion is tracked in its own
+// group named after the Unique() of its reference, errors about them
+// are then reported via a *resolvePoolError keyed by those groups.
+func bulkFetch(s *state.State, userID int, deviceCtx snapstate.DeviceContext, fetching func(asserts.Fetcher) error) error {
+	db := cachedDB(s)
+	pool := asserts.NewPool(db, maxGroups)
+
+	if err := fetching(pool.Fetcher()); err != nil {
+		return err
+	}
+
+	return resolvePool(s, pool, nil, userID, deviceCtx)
+}
+
 // marker error to request falling back to the old implemention for assertion
 // refreshes
 type bulkAssertionFallbackError struct {
@@ -21,7 +21,8 @@ package assertstate
 
 // expose for testing
 var (
-	DoFetch = doFetch
+	DoFetch         = doFetch
+	SnapPublisherID = snapPublisherID
 )
 
 func MockMaxGroups(n int) (restore func()) {
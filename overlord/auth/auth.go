@@ -31,6 +31,7 @@ import (
 	"gopkg.in/macaroon.v1"
 
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
 )
 
 // AuthState represents current authenticated users as tracked in state
@@ -62,6 +63,48 @@ type UserState struct {
 	Discharges      []string `json:"discharges,omitempty"`
 	StoreMacaroon   string   `json:"store-macaroon,omitempty"`
 	StoreDischarges []string `json:"store-discharges,omitempty"`
+	// Roles restricts what the user can do through the snapd API, a
+	// user without roles is unrestricted.
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Roles which can be assigned to users to restrict their access to the
+// snapd API.
+const (
+	// RoleReadOnly allows querying snapd, it is implied by all the
+	// other roles.
+	RoleReadOnly = "read-only"
+	// RoleServiceControl allows starting, stopping and restarting the
+	// services of snaps.
+	RoleServiceControl = "service-control"
+	// RoleSnapInstall allows installing, refreshing and removing snaps.
+	RoleSnapInstall = "snap-install"
+	// RoleSystemAdmin allows everything, it implies all the other roles.
+	RoleSystemAdmin = "system-admin"
+)
+
+var validRoles = []string{RoleReadOnly, RoleServiceControl, RoleSnapInstall, RoleSystemAdmin}
+
+// ValidateRoles checks that the given roles are known.
+func ValidateRoles(roles []string) error {
+	for _, role := range roles {
+		if !strutil.ListContains(validRoles, role) {
+			return fmt.Errorf("invalid role %q", role)
+		}
+	}
+	return nil
+}
+
+// HasRole returns whether the user was granted the given role, either
+// directly or through a role implying it.
+func (u *UserState) HasRole(role string) bool {
+	if len(u.Roles) == 0 {
+		return true
+	}
+	if role == RoleReadOnly {
+		return true
+	}
+	return strutil.ListContains(u.Roles, role) || strutil.ListContains(u.Roles, RoleSystemAdmin)
 }
 
 // identificationOnly returns a *UserState with only the
@@ -328,6 +328,36 @@ func (as *authSuite) TestUserHasStoreAuth(c *C) {
 	c.Check(user.HasStoreAuth(), Equals, false)
 }
 
+func (as *authSuite) TestUserHasRole(c *C) {
+	// users without roles are unrestricted
+	user := &auth.UserState{}
+	c.Check(user.HasRole(auth.RoleReadOnly), Equals, true)
+	c.Check(user.HasRole(auth.RoleSystemAdmin), Equals, true)
+
+	user.Roles = []string{auth.RoleReadOnly}
+	c.Check(user.HasRole(auth.RoleReadOnly), Equals, true)
+	c.Check(user.HasRole(auth.RoleServiceControl), Equals, false)
+	c.Check(user.HasRole(auth.RoleSnapInstall), Equals, false)
+	c.Check(user.HasRole(auth.RoleSystemAdmin), Equals, false)
+
+	user.Roles = []string{auth.RoleServiceControl}
+	c.Check(user.HasRole(auth.RoleReadOnly), Equals, true)
+	c.Check(user.HasRole(auth.RoleServiceControl), Equals, true)
+	c.Check(user.HasRole(auth.RoleSnapInstall), Equals, false)
+	c.Check(user.HasRole(auth.RoleSystemAdmin), Equals, false)
+
+	user.Roles = []string{auth.RoleSystemAdmin}
+	c.Check(user.HasRole(auth.RoleServiceControl), Equals, true)
+	c.Check(user.HasRole(auth.RoleSnapInstall), Equals, true)
+	c.Check(user.HasRole(auth.RoleSystemAdmin), Equals, true)
+}
+
+func (as *authSuite) TestValidateRoles(c *C) {
+	c.Check(auth.ValidateRoles(nil), IsNil)
+	c.Check(auth.ValidateRoles([]string{"read-only", "service-control", "snap-install", "system-admin"}), IsNil)
+	c.Check(auth.ValidateRoles([]string{"read-only", "root"}), ErrorMatches, `invalid role "root"`)
+}
+
 func (as *authSuite) TestUpdateUser(c *C) {
 	as.state.Lock()
 	user, _ := auth.NewUser(as.state, "username", "email@test.com", "macaroon", []string{"discharge"})
@@ -43,6 +43,8 @@ type Transaction struct {
 	state    *state.State
 	pristine map[string]map[string]*json.RawMessage // snap => key => value
 	changes  map[string]map[string]interface{}
+	// setKeys are the keys set in the transaction, by snap
+	setKeys map[string][]string
 }
 
 // NewTransaction creates a new configuration transaction initialized with the given state.
@@ -183,6 +185,10 @@ func (t *Transaction) Set(instanceName, key string, value interface{}) error {
 	}
 
 	t.changes[instanceName] = config
+	if t.setKeys == nil {
+		t.setKeys = make(map[string][]string)
+	}
+	t.setKeys[instanceName] = append(t.setKeys[instanceName], key)
 	return nil
 }
 
@@ -340,9 +346,11 @@ func (t *Transaction) Commit() {
 	}
 
 	t.state.Set("config", t.pristine)
+	notifyWatchers(t.state, t.changes, t.setKeys)
 
 	// The cache has been flushed, reset it.
 	t.changes = make(map[string]map[string]interface{})
+	t.setKeys = nil
 }
 
 func applyChanges(config map[string]*json.RawMessage, changes map[string]interface{}) {
@@ -0,0 +1,101 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package config
+
+import (
+	"strings"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type configWatchersKey struct{}
+
+type configWatcher struct {
+	instanceName string
+	key          string
+	changed      chan struct{}
+}
+
+func (w *configWatcher) matches(instanceName, changedKey string) bool {
+	if w.instanceName != instanceName {
+		return false
+	}
+	// changes of a key's parents or children can change its value
+	return w.key == changedKey || strings.HasPrefix(changedKey, w.key+".") || strings.HasPrefix(w.key, changedKey+".")
+}
+
+func cachedWatchers(st *state.State) map[*configWatcher]bool {
+	watchers, _ := st.Cached(configWatchersKey{}).(map[*configWatcher]bool)
+	if watchers == nil {
+		watchers = make(map[*configWatcher]bool)
+		st.Cache(configWatchersKey{}, watchers)
+	}
+	return watchers
+}
+
+// Watch returns a channel which is closed once a transaction changing the
+// given key of the snap, or one of its parents or children, is
+// committed, and a function to stop watching. The value of the key might
+// be unchanged though, if it was set to the same value.
+//
+// The provided state must be locked by the caller, also when calling the
+// returned function.
+func Watch(st *state.State, instanceName, key string) (changed <-chan struct{}, stop func()) {
+	w := &configWatcher{
+		instanceName: instanceName,
+		key:          key,
+		changed:      make(chan struct{}),
+	}
+	cachedWatchers(st)[w] = true
+	return w.changed, func() {
+		delete(cachedWatchers(st), w)
+	}
+}
+
+// notifyWatchers notifies the watchers of the given changes and of the
+// keys that were set, whose previous children might not be part of the
+// changes, the state must be locked.
+func notifyWatchers(st *state.State, changes map[string]map[string]interface{}, setKeys map[string][]string) {
+	watchers := cachedWatchers(st)
+	if len(watchers) == 0 {
+		return
+	}
+	for instanceName, snapChanges := range changes {
+		changed := append(changesOf(instanceName, snapChanges), setKeys[instanceName]...)
+		for _, key := range changed {
+			for w := range watchers {
+				if w.matches(instanceName, key) {
+					close(w.changed)
+					delete(watchers, w)
+				}
+			}
+		}
+	}
+}
+
+// changesOf returns the changed keys of the snap, without the snap name
+// prefix.
+func changesOf(instanceName string, snapChanges map[string]interface{}) []string {
+	keys := changes(instanceName, snapChanges)
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, instanceName+".")
+	}
+	return keys
+}
@@ -0,0 +1,92 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package config_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type watchSuite struct {
+	state *state.State
+}
+
+var _ = Suite(&watchSuite{})
+
+func (s *watchSuite) SetUpTest(c *C) {
+	s.state = state.New(nil)
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *watchSuite) TestWatch(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	key, stopKey := config.Watch(s.state, "test-snap", "a.b")
+	defer stopKey()
+	parent, stopParent := config.Watch(s.state, "test-snap", "a")
+	defer stopParent()
+	child, stopChild := config.Watch(s.state, "test-snap", "a.b.c")
+	defer stopChild()
+	sibling, stopSibling := config.Watch(s.state, "test-snap", "a.bb")
+	defer stopSibling()
+	other, stopOther := config.Watch(s.state, "other-snap", "a.b")
+	defer stopOther()
+
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "a.b", map[string]interface{}{"d": 1}), IsNil)
+	c.Check(isClosed(key), Equals, false)
+	tr.Commit()
+
+	c.Check(isClosed(key), Equals, true)
+	c.Check(isClosed(parent), Equals, true)
+	c.Check(isClosed(child), Equals, true)
+	c.Check(isClosed(sibling), Equals, false)
+	c.Check(isClosed(other), Equals, false)
+
+	// watchers are notified once
+	tr = config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "a.bb", 1), IsNil)
+	tr.Commit()
+	c.Check(isClosed(sibling), Equals, true)
+}
+
+func (s *watchSuite) TestWatchStop(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	changed, stop := config.Watch(s.state, "test-snap", "a")
+	stop()
+
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "a", 1), IsNil)
+	tr.Commit()
+	c.Check(isClosed(changed), Equals, false)
+}
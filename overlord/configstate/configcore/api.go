@@ -0,0 +1,53 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.api.rate-limit"] = true
+	supportedConfigurations["core.api.max-changes"] = true
+	supportedConfigurations["core.api.metrics"] = true
+}
+
+func validateAPISettings(tr config.Conf) error {
+	if err := validateBoolFlag(tr, "api.metrics"); err != nil {
+		return err
+	}
+	for _, option := range []string{"api.rate-limit", "api.max-changes"} {
+		value, err := coreCfg(tr, option)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("%s must be a positive number or 0 to disable, not %q", option, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type apiSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&apiSuite{})
+
+func (s *apiSuite) TestConfigureAPILimitsHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"api.rate-limit":  "120",
+			"api.max-changes": "0",
+			"api.metrics":     "true",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *apiSuite) TestConfigureAPILimitsInvalid(c *C) {
+	for _, t := range []struct {
+		option, value string
+	}{
+		{"api.rate-limit", "-1"},
+		{"api.rate-limit", "lots"},
+		{"api.max-changes", "1.5"},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				t.option: t.value,
+			},
+		})
+		c.Check(err, ErrorMatches, t.option+` must be a positive number or 0 to disable, not "`+t.value+`"`)
+	}
+}
+
+func (s *apiSuite) TestConfigureAPIMetricsInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"api.metrics": "maybe",
+		},
+	})
+	c.Check(err, ErrorMatches, `api.metrics can only be set to 'true' or 'false'`)
+}
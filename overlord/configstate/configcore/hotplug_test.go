@@ -0,0 +1,59 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/hotplug"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type hotplugSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&hotplugSuite{})
+
+func (s *hotplugSuite) TestConfigureHotplugRulesHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"hotplug.rules": []*hotplug.MatchRule{
+				{Interface: "serial-port", Name: "gps", USBVendor: "1546", USBProduct: "01a7"},
+				{Interface: "hidraw", USBInterfaceClass: "03"},
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *hotplugSuite) TestConfigureHotplugRulesInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"hotplug.rules": []*hotplug.MatchRule{
+				{Interface: "serial-port", Name: "gps", USBVendor: "1546"},
+				{Interface: "serial-port", Name: "gps", USBVendor: "0403"},
+			},
+		},
+	})
+	c.Assert(err, ErrorMatches, `invalid hotplug match rule #1: slot name "gps" is already used`)
+}
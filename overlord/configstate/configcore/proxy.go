@@ -23,14 +23,18 @@ package configcore
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var proxyConfigKeys = map[string]bool{
@@ -47,6 +51,8 @@ func init() {
 	supportedConfigurations["core.proxy.ftp"] = true
 	supportedConfigurations["core.proxy.no-proxy"] = true
 	supportedConfigurations["core.proxy.store"] = true
+	supportedConfigurations["core.proxy.lan-peers"] = true
+	supportedConfigurations["core.proxy.lan-cache-listen"] = true
 }
 
 func etcEnvironment() string {
@@ -120,3 +126,31 @@ func validateProxyStore(tr config.Conf) error {
 	}
 	return err
 }
+
+func validateProxyLANPeers(tr config.Conf) error {
+	lanPeers, err := coreCfg(tr, "proxy.lan-peers")
+	if err != nil {
+		return err
+	}
+	for _, peer := range strutil.CommaSeparatedList(lanPeers) {
+		u, err := url.Parse(peer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("cannot set proxy.lan-peers: %q is not a http or https URL", peer)
+		}
+	}
+
+	listen, err := coreCfg(tr, "proxy.lan-cache-listen")
+	if err != nil {
+		return err
+	}
+	if listen != "" {
+		_, port, err := net.SplitHostPort(listen)
+		if err == nil {
+			_, err = strconv.ParseUint(port, 10, 16)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot set proxy.lan-cache-listen: %q is not a <host>:<port> address", listen)
+		}
+	}
+	return nil
+}
@@ -189,3 +189,51 @@ func (s *proxySuite) TestConfigureProxyStoreNoURL(c *C) {
 	err = configcore.Run(coreDev, conf)
 	c.Check(err, ErrorMatches, `cannot set proxy.store to "foo" with a matching store assertion with url unset`)
 }
+
+func (s *proxySuite) TestConfigureProxyLANPeers(c *C) {
+	for _, v := range []string{"http://192.168.1.2:8080", "http://peer-1/,https://peer-2", ""} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"proxy.lan-peers": v,
+			},
+		})
+		c.Check(err, IsNil, Commentf("%q", v))
+	}
+}
+
+func (s *proxySuite) TestConfigureProxyLANCacheListen(c *C) {
+	for _, v := range []string{":8080", "192.168.1.2:8080", "[::1]:8080", ""} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"proxy.lan-cache-listen": v,
+			},
+		})
+		c.Check(err, IsNil, Commentf("%q", v))
+	}
+}
+
+func (s *proxySuite) TestConfigureProxyLANCacheListenUnhappy(c *C) {
+	for _, v := range []string{"8080", "host", ":http", ":70000"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"proxy.lan-cache-listen": v,
+			},
+		})
+		c.Check(err, ErrorMatches, `cannot set proxy.lan-cache-listen: ".*" is not a <host>:<port> address`, Commentf("%q", v))
+	}
+}
+
+func (s *proxySuite) TestConfigureProxyLANPeersUnhappy(c *C) {
+	for _, v := range []string{"peer-1", "http://peer-1,ftp://peer-2", "http://"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"proxy.lan-peers": v,
+			},
+		})
+		c.Check(err, ErrorMatches, `cannot set proxy.lan-peers: ".*" is not a http or https URL`, Commentf("%q", v))
+	}
+}
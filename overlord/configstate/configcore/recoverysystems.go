@@ -0,0 +1,60 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.recovery-systems.keep"] = true
+	supportedConfigurations["core.recovery-systems.interval"] = true
+}
+
+func validateRecoverySystemsSettings(tr config.Conf) error {
+	keep, err := coreCfg(tr, "recovery-systems.keep")
+	if err != nil {
+		return err
+	}
+	if keep != "" {
+		if n, err := strconv.Atoi(keep); err != nil || n < 0 {
+			return fmt.Errorf("recovery-systems.keep must be a positive number or 0 to disable, not %q", keep)
+		}
+	}
+	intervalStr, err := coreCfg(tr, "recovery-systems.interval")
+	if err != nil {
+		return err
+	}
+	if intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			return fmt.Errorf("recovery-systems.interval cannot be parsed: %v", err)
+		}
+		if interval < 24*time.Hour {
+			return fmt.Errorf("recovery-systems.interval must be a value of at least 24 hours")
+		}
+	}
+	return nil
+}
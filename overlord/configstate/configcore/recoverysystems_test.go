@@ -0,0 +1,88 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type recoverySystemsSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&recoverySystemsSuite{})
+
+func (s *recoverySystemsSuite) SetUpTest(c *C) {
+	s.configcoreSuite.SetUpTest(c)
+
+	err := os.MkdirAll(filepath.Join(dirs.GlobalRootDir, "/etc/"), 0755)
+	c.Assert(err, IsNil)
+
+	err = ioutil.WriteFile(filepath.Join(dirs.GlobalRootDir, "/etc/environment"), nil, 0644)
+	c.Assert(err, IsNil)
+}
+
+func (s *recoverySystemsSuite) TestConfigureRecoverySystemsHappy(c *C) {
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"recovery-systems.keep":     "3",
+			"recovery-systems.interval": "168h",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *recoverySystemsSuite) TestConfigureRecoverySystemsKeepInvalid(c *C) {
+	for _, keep := range []string{"-1", "foo"} {
+		err := configcore.Run(coreDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"recovery-systems.keep": keep,
+			},
+		})
+		c.Check(err, ErrorMatches, `recovery-systems.keep must be a positive number or 0 to disable, not ".*"`)
+	}
+}
+
+func (s *recoverySystemsSuite) TestConfigureRecoverySystemsIntervalInvalid(c *C) {
+	err := configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"recovery-systems.interval": "invalid",
+		},
+	})
+	c.Check(err, ErrorMatches, `recovery-systems.interval cannot be parsed:.*`)
+
+	err = configcore.Run(coreDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"recovery-systems.interval": "12h",
+		},
+	})
+	c.Check(err, ErrorMatches, `recovery-systems.interval must be a value of at least 24 hours`)
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/timeutil"
 )
@@ -38,6 +39,11 @@ func init() {
 	supportedConfigurations["core.refresh.metered"] = true
 	supportedConfigurations["core.refresh.retain"] = true
 	supportedConfigurations["core.refresh.rate-limit"] = true
+	supportedConfigurations["core.refresh.rate-limit-schedule"] = true
+	supportedConfigurations["core.refresh.deltas"] = true
+	supportedConfigurations["core.refresh.approval"] = true
+	supportedConfigurations["core.refresh.gc-threshold"] = true
+	supportedConfigurations["core.refresh.download-chunks"] = true
 }
 
 func reportOrIgnoreInvalidManageRefreshes(tr config.Conf, optName string) error {
@@ -85,6 +91,28 @@ func validateRefreshSchedule(tr config.Conf) error {
 		return fmt.Errorf("refresh.metered value %q is invalid", refreshOnMeteredStr)
 	}
 
+	refreshDeltasStr, err := coreCfg(tr, "refresh.deltas")
+	if err != nil {
+		return err
+	}
+	switch refreshDeltasStr {
+	case "", "required":
+		// noop
+	default:
+		return fmt.Errorf("refresh.deltas value %q is invalid", refreshDeltasStr)
+	}
+
+	refreshApprovalStr, err := coreCfg(tr, "refresh.approval")
+	if err != nil {
+		return err
+	}
+	switch refreshApprovalStr {
+	case "", "required":
+		// noop
+	default:
+		return fmt.Errorf("refresh.approval value %q is invalid", refreshApprovalStr)
+	}
+
 	// check (new) refresh.timer
 	refreshTimerStr, err := coreCfg(tr, "refresh.timer")
 	if err != nil {
@@ -146,3 +174,46 @@ func validateRefreshRateLimit(tr config.Conf) error {
 	}
 	return nil
 }
+
+func validateRefreshRateLimitSchedule(tr config.Conf) error {
+	rateLimitSchedule, err := coreCfg(tr, "refresh.rate-limit-schedule")
+	if err != nil {
+		return err
+	}
+	// reset is fine
+	if len(rateLimitSchedule) == 0 {
+		return nil
+	}
+	_, err = snapstate.ParseRateLimitSchedule(rateLimitSchedule)
+	return err
+}
+
+func validateRefreshDownloadChunks(tr config.Conf) error {
+	downloadChunks, err := coreCfg(tr, "refresh.download-chunks")
+	if err != nil {
+		return err
+	}
+	// unset downloads snaps with a single request
+	if len(downloadChunks) == 0 {
+		return nil
+	}
+	if n, err := strconv.ParseUint(downloadChunks, 10, 8); err != nil || n > snapstate.MaxDownloadChunks {
+		return fmt.Errorf("refresh.download-chunks must be a number between 0 and %d, not %q", snapstate.MaxDownloadChunks, downloadChunks)
+	}
+	return nil
+}
+
+func validateRefreshGCThreshold(tr config.Conf) error {
+	gcThreshold, err := coreCfg(tr, "refresh.gc-threshold")
+	if err != nil {
+		return err
+	}
+	// unset disables the garbage collection
+	if len(gcThreshold) == 0 {
+		return nil
+	}
+	if _, err := strutil.ParseByteSize(gcThreshold); err != nil {
+		return fmt.Errorf("refresh.gc-threshold %v", err)
+	}
+	return nil
+}
@@ -20,6 +20,7 @@
 package configcore_test
 
 import (
+	"fmt"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -170,6 +171,126 @@ func (s *refreshSuite) TestConfigureRefreshHoldOnMeteredHappy(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *refreshSuite) TestConfigureRefreshDeltasInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.deltas": "invalid",
+		},
+	})
+	c.Assert(err, ErrorMatches, `refresh\.deltas value "invalid" is invalid`)
+}
+
+func (s *refreshSuite) TestConfigureRefreshDeltasHappy(c *C) {
+	for _, v := range []string{"required", ""} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.deltas": v,
+			},
+		})
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshApprovalInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.approval": "maybe",
+		},
+	})
+	c.Assert(err, ErrorMatches, `refresh\.approval value "maybe" is invalid`)
+}
+
+func (s *refreshSuite) TestConfigureRefreshApprovalHappy(c *C) {
+	for _, v := range []string{"required", ""} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.approval": v,
+			},
+		})
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshGCThresholdInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.gc-threshold": "lots",
+		},
+	})
+	c.Assert(err, ErrorMatches, `refresh\.gc-threshold cannot parse "lots": .*`)
+}
+
+func (s *refreshSuite) TestConfigureRefreshGCThresholdHappy(c *C) {
+	for _, v := range []string{"500MB", "2GB", ""} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.gc-threshold": v,
+			},
+		})
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshDownloadChunksHappy(c *C) {
+	for _, v := range []string{"0", "4", "16", ""} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.download-chunks": v,
+			},
+		})
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshDownloadChunksInvalid(c *C) {
+	for _, v := range []string{"-1", "17", "lots"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.download-chunks": v,
+			},
+		})
+		c.Check(err, ErrorMatches, fmt.Sprintf(`refresh.download-chunks must be a number between 0 and 16, not %q`, v))
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshRateLimitScheduleHappy(c *C) {
+	for _, v := range []string{"mon-fri,9:00-17:00,1MB", "mon-fri,9:00-17:00,1MB;sat-sun,0B", ""} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"refresh.rate-limit-schedule": v,
+			},
+		})
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshRateLimitScheduleInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.rate-limit-schedule": "mon-fri,9:00-17:00,lots",
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot parse rate limit window "mon-fri,9:00-17:00,lots": .*`)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"refresh.rate-limit-schedule": "mon-fri,9:00-17:00,1MB,,sat-sun,0B",
+		},
+	})
+	c.Assert(err, ErrorMatches, `cannot parse rate limit window "mon-fri,9:00-17:00,1MB,,sat-sun,0B": windows must be separated by ";"`)
+}
+
 func (s *refreshSuite) TestConfigureRefreshRetainHappy(c *C) {
 	err := configcore.Run(classicDev, &mockConf{
 		state: s.state,
@@ -55,7 +55,16 @@ func init() {
 	validateOnly := &flags{validatedOnlyStateConfig: true}
 	addWithStateHandler(validateRefreshSchedule, nil, validateOnly)
 	addWithStateHandler(validateRefreshRateLimit, nil, validateOnly)
+	addWithStateHandler(validateRefreshRateLimitSchedule, nil, validateOnly)
+	addWithStateHandler(validateProxyLANPeers, nil, validateOnly)
+	addWithStateHandler(validateRefreshGCThreshold, nil, validateOnly)
+	addWithStateHandler(validateRefreshDownloadChunks, nil, validateOnly)
 	addWithStateHandler(validateAutomaticSnapshotsExpiration, nil, validateOnly)
+	addWithStateHandler(validateAutomaticSnapshotsSchedule, nil, validateOnly)
+	addWithStateHandler(validateSnapshotsEncryption, nil, validateOnly)
+	addWithStateHandler(validateHotplugRules, nil, validateOnly)
+	addWithStateHandler(validateAPISettings, nil, validateOnly)
+	addWithStateHandler(validateRecoverySystemsSettings, nil, validateOnly)
 }
 
 type withStateHandler struct {
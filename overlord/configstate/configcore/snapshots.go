@@ -22,14 +22,20 @@ package configcore
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 func init() {
 	// add supported configuration of this module
 	supportedConfigurations["core.snapshots.automatic.retention"] = true
+	supportedConfigurations["core.snapshots.automatic.schedule"] = true
+	supportedConfigurations["core.snapshots.automatic.snaps"] = true
+	supportedConfigurations["core.snapshots.encryption"] = true
 }
 
 func validateAutomaticSnapshotsExpiration(tr config.Conf) error {
@@ -48,3 +54,32 @@ func validateAutomaticSnapshotsExpiration(tr config.Conf) error {
 	}
 	return nil
 }
+
+func validateAutomaticSnapshotsSchedule(tr config.Conf) error {
+	scheduleStr, err := coreCfg(tr, "snapshots.automatic.schedule")
+	if err != nil {
+		return err
+	}
+	if scheduleStr != "" {
+		if _, err := timeutil.ParseSchedule(scheduleStr); err != nil {
+			return fmt.Errorf("snapshots.automatic.schedule cannot be parsed: %v", err)
+		}
+	}
+
+	snapsStr, err := coreCfg(tr, "snapshots.automatic.snaps")
+	if err != nil {
+		return err
+	}
+	if snapsStr != "" {
+		for _, name := range strings.Split(snapsStr, ",") {
+			if err := snap.ValidateInstanceName(strings.TrimSpace(name)); err != nil {
+				return fmt.Errorf("snapshots.automatic.snaps contains an invalid snap name: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateSnapshotsEncryption(tr config.Conf) error {
+	return validateBoolFlag(tr, "snapshots.encryption")
+}
@@ -70,3 +70,52 @@ func (s *refreshSuite) TestConfigureAutomaticSnapshotsExpirationInvalid(c *C) {
 	})
 	c.Assert(err, ErrorMatches, `snapshots.automatic.retention cannot be parsed:.*`)
 }
+
+func (s *snapshotsSuite) TestConfigureAutomaticSnapshotsScheduleHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.automatic.schedule": "mon,02:00",
+			"snapshots.automatic.snaps":    "foo, bar_instance",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *snapshotsSuite) TestConfigureAutomaticSnapshotsScheduleInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.automatic.schedule": "sometimes",
+		},
+	})
+	c.Assert(err, ErrorMatches, `snapshots.automatic.schedule cannot be parsed: .*`)
+}
+
+func (s *snapshotsSuite) TestConfigureAutomaticSnapshotsSnapsInvalid(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.automatic.snaps": "foo,Bad--Name",
+		},
+	})
+	c.Assert(err, ErrorMatches, `snapshots.automatic.snaps contains an invalid snap name: .*`)
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsEncryption(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.encryption": "true",
+		},
+	})
+	c.Assert(err, IsNil)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]interface{}{
+			"snapshots.encryption": "maybe",
+		},
+	})
+	c.Assert(err, ErrorMatches, `snapshots.encryption can only be set to 'true' or 'false'`)
+}
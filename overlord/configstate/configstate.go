@@ -22,12 +22,18 @@
 package configstate
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/snapcore/snapd/asserts/snapasserts"
 	"github.com/snapcore/snapd/gadget"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
 	"github.com/snapcore/snapd/overlord/devicestate"
@@ -35,6 +41,8 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/configschema"
+	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/sysconfig"
 )
 
@@ -98,10 +106,51 @@ func ConfigureInstalled(st *state.State, snapName string, patch map[string]inter
 		return nil, err
 	}
 
+	// reject invalid values before running the configure hook
+	if len(patch) > 0 {
+		tr := config.NewTransaction(st)
+		if err := config.Patch(tr, snapName, patch); err != nil {
+			return nil, err
+		}
+		if err := validateConfigSchema(tr, snapName); err != nil {
+			return nil, err
+		}
+		if err := validateRequiredConfiguration(tr, snapName); err != nil {
+			return nil, err
+		}
+	}
+
 	taskset := Configure(st, snapName, patch, flags)
 	return taskset, nil
 }
 
+// ReplaceInstalled returns a taskset replacing the whole configuration of
+// the given installed snap with conf. Top-level options that are not part
+// of conf are unset, so the result does not depend on the configuration
+// the snap had before.
+func ReplaceInstalled(st *state.State, snapName string, conf map[string]interface{}, flags int) (*state.TaskSet, error) {
+	if snapName == "core" {
+		// the system configuration also holds options managed by
+		// snapd itself which must not be unset
+		return nil, fmt.Errorf("cannot replace the system configuration")
+	}
+
+	tr := config.NewTransaction(st)
+	var current map[string]interface{}
+	if err := tr.Get(snapName, "", &current); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+
+	patch := make(map[string]interface{}, len(current)+len(conf))
+	for name := range current {
+		patch[name] = nil
+	}
+	for name, value := range conf {
+		patch[name] = value
+	}
+	return ConfigureInstalled(st, snapName, patch, flags)
+}
+
 // Configure returns a taskset to apply the given configuration patch.
 func Configure(st *state.State, snapName string, patch map[string]interface{}, flags int) *state.TaskSet {
 	summary := fmt.Sprintf(i18n.G("Run configure hook of %q snap"), snapName)
@@ -130,6 +179,137 @@ func Configure(st *state.State, snapName string, patch map[string]interface{}, f
 	return state.NewTaskSet(task)
 }
 
+// ConfigSchema returns the configuration schema shipped by the installed
+// snap, or nil if it does not have one.
+func ConfigSchema(st *state.State, snapName string) (*configschema.Schema, error) {
+	// the "core" snap/pseudonym is validated internally
+	if snapName == "core" {
+		return nil, nil
+	}
+	info, err := snapstate.CurrentInfo(st, snapName)
+	if err != nil {
+		return nil, err
+	}
+	return configschema.ReadFile(info.MountDir())
+}
+
+// validateConfigSchema checks the configuration of the snap in the
+// transaction against the schema of the snap, if any. The errors about
+// invalid values are *configschema.ValidationError.
+func validateConfigSchema(tr *config.Transaction, snapName string) error {
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(tr.State(), snapName, &snapst); err == state.ErrNoState || !snapst.IsInstalled() {
+		return nil
+	}
+	schema, err := ConfigSchema(tr.State(), snapName)
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		return nil
+	}
+	var doc interface{}
+	if err := tr.Get(snapName, "", &doc); err != nil {
+		if !config.IsNoOption(err) {
+			return err
+		}
+		doc = map[string]interface{}{}
+	}
+	return schema.Validate(doc)
+}
+
+var assertstateEnforcedValidationSets = assertstate.EnforcedValidationSets
+
+// enforcedValidationSets returns the validation sets in enforce mode or
+// nil if there are none, without needing the assertion database then.
+func enforcedValidationSets(st *state.State) (*snapasserts.ValidationSets, error) {
+	tracked, err := assertstate.ValidationSets(st)
+	if err != nil {
+		return nil, err
+	}
+	for _, vs := range tracked {
+		if vs.Mode == assertstate.Enforce {
+			return assertstateEnforcedValidationSets(st)
+		}
+	}
+	return nil, nil
+}
+
+// validateRequiredConfiguration checks that the changes to the
+// configuration of the snap in the transaction keep the values required
+// by the validation sets in enforce mode.
+func validateRequiredConfiguration(tr *config.Transaction, snapName string) error {
+	st := tr.State()
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, snapName, &snapst); err == state.ErrNoState || !snapst.IsInstalled() {
+		return nil
+	}
+	valsets, err := enforcedValidationSets(st)
+	if err != nil || valsets == nil {
+		return err
+	}
+	snapRef := naming.NewSnapRef(snap.InstanceSnap(snapName), snapst.CurrentSideInfo().SnapID)
+	required := valsets.RequiredConfiguration(snapRef)
+	if len(required) == 0 {
+		return nil
+	}
+
+	prefix := snapName + "."
+	var changed []string
+	for _, change := range tr.Changes() {
+		if strings.HasPrefix(change, prefix) {
+			changed = append(changed, change[len(prefix):])
+		}
+	}
+	touches := func(key string) bool {
+		for _, change := range changed {
+			if change == key || strings.HasPrefix(change, key+".") || strings.HasPrefix(key, change+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	keys := make([]string, 0, len(required))
+	for key := range required {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !touches(key) {
+			continue
+		}
+		var value interface{}
+		if err := tr.Get(snapName, key, &value); err != nil && !config.IsNoOption(err) {
+			return err
+		}
+		cc := required[key]
+		if !configValueMatches(value, cc.Value) {
+			return fmt.Errorf("cannot change configuration %q of snap %q: required to be %q by validation sets %s", key, snapName, cc.Value, strings.Join(cc.Sets, ","))
+		}
+	}
+	return nil
+}
+
+// configValueMatches returns whether the value matches the required one,
+// which is interpreted as by snap set, that is as JSON if possible or
+// as a string otherwise.
+func configValueMatches(value interface{}, required string) bool {
+	if value == nil {
+		return false
+	}
+	var want interface{}
+	if err := json.Unmarshal([]byte(required), &want); err != nil {
+		want = required
+	}
+	have, err1 := json.Marshal(value)
+	expected, err2 := json.Marshal(want)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return bytes.Equal(have, expected)
+}
+
 // RemapSnapFromRequest renames a snap as received from an API request
 func RemapSnapFromRequest(snapName string) string {
 	if snapName == "system" {
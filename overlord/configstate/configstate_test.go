@@ -25,10 +25,14 @@ import (
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/asserts/snapasserts"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/gadget"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/configstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
@@ -36,6 +40,8 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/configschema"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/sysconfig"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -177,6 +183,150 @@ func (s *tasksetsSuite) TestConfigureInstalledConflict(c *C) {
 	c.Check(err, ErrorMatches, `snap "test-snap" has "other-change" change in progress`)
 }
 
+func (s *tasksetsSuite) TestConfigureInstalledSchema(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("/")
+
+	snaptest.MockSnapWithFiles(c, "name: test-snap\nversion: 1\n", &snap.SideInfo{Revision: snap.R(1)}, [][]string{
+		{"meta/config-schema.json", `{"properties": {"mode": {"enum": ["fast", "slow"]}}}`},
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "test-snap", Revision: snap.R(1)},
+		},
+		Current:  snap.R(1),
+		Active:   true,
+		SnapType: "app",
+	})
+
+	_, err := configstate.ConfigureInstalled(s.state, "test-snap", map[string]interface{}{"mode": "medium"}, 0)
+	c.Check(err, ErrorMatches, `invalid configuration option "mode": must be one of "fast", "slow"`)
+	c.Check(err, FitsTypeOf, &configschema.ValidationError{})
+
+	ts, err := configstate.ConfigureInstalled(s.state, "test-snap", map[string]interface{}{"mode": "fast"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(ts.Tasks(), HasLen, 1)
+
+	// nothing was committed
+	var mode string
+	tr := config.NewTransaction(s.state)
+	c.Check(config.IsNoOption(tr.Get("test-snap", "mode", &mode)), Equals, true)
+}
+
+func (s *tasksetsSuite) TestConfigureInstalledRequiredConfiguration(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "test-snap", Revision: snap.R(1)},
+		},
+		Current:  snap.R(1),
+		Active:   true,
+		SnapType: "app",
+	})
+
+	assertstate.UpdateValidationSet(s.state, &assertstate.ValidationSetTracking{
+		AccountID: "account-id",
+		Name:      "my-set",
+		Mode:      assertstate.Enforce,
+		Current:   1,
+	})
+	valset := assertstest.FakeAssertion(map[string]interface{}{
+		"type":         "validation-set",
+		"format":       "1",
+		"authority-id": "account-id",
+		"series":       "16",
+		"account-id":   "account-id",
+		"name":         "my-set",
+		"sequence":     "1",
+		"snaps": []interface{}{
+			map[string]interface{}{
+				"name":     "test-snap",
+				"id":       "testsnapidididididididididididid",
+				"presence": "required",
+				"configuration": []interface{}{
+					map[string]interface{}{"key": "mode", "value": "fast"},
+					map[string]interface{}{"key": "limits.max", "value": "10"},
+				},
+			},
+		},
+	}).(*asserts.ValidationSet)
+	restore := configstate.MockAssertstateEnforcedValidationSets(func(st *state.State) (*snapasserts.ValidationSets, error) {
+		valsets := snapasserts.NewValidationSets()
+		c.Assert(valsets.Add(valset), IsNil)
+		return valsets, nil
+	})
+	defer restore()
+
+	for _, tc := range []struct {
+		patch map[string]interface{}
+		err   string
+	}{
+		{map[string]interface{}{"mode": "fast"}, ""},
+		{map[string]interface{}{"limits.max": 10}, ""},
+		{map[string]interface{}{"limits": map[string]interface{}{"max": 10, "min": 1}}, ""},
+		// unrelated keys can be changed
+		{map[string]interface{}{"other": "value"}, ""},
+		{map[string]interface{}{"mode": "slow"}, `cannot change configuration "mode" of snap "test-snap": required to be "fast" by validation sets account-id/my-set`},
+		{map[string]interface{}{"mode": nil}, `cannot change configuration "mode" of snap "test-snap": required to be "fast" by validation sets account-id/my-set`},
+		{map[string]interface{}{"limits.max": "10"}, `cannot change configuration "limits.max" of snap "test-snap": required to be "10" by validation sets account-id/my-set`},
+		{map[string]interface{}{"limits": nil}, `cannot change configuration "limits.max" of snap "test-snap": required to be "10" by validation sets account-id/my-set`},
+	} {
+		_, err := configstate.ConfigureInstalled(s.state, "test-snap", tc.patch, 0)
+		if tc.err == "" {
+			c.Check(err, IsNil, Commentf("%v", tc.patch))
+		} else {
+			c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.patch))
+		}
+	}
+}
+
+func (s *tasksetsSuite) TestReplaceInstalled(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "test-snap", Revision: snap.R(1)},
+		},
+		Current:  snap.R(1),
+		Active:   true,
+		SnapType: "app",
+	})
+
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("test-snap", "foo", "bar"), IsNil)
+	c.Assert(tr.Set("test-snap", "baz.qux", 1), IsNil)
+	tr.Commit()
+
+	ts, err := configstate.ReplaceInstalled(s.state, "test-snap", map[string]interface{}{
+		"baz":   map[string]interface{}{"quux": 2},
+		"other": "value",
+	}, 0)
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 1)
+
+	var hookContext struct {
+		Patch map[string]interface{} `json:"patch"`
+	}
+	c.Assert(ts.Tasks()[0].Get("hook-context", &hookContext), IsNil)
+	c.Check(hookContext.Patch, DeepEquals, map[string]interface{}{
+		"foo":   nil,
+		"baz":   map[string]interface{}{"quux": 2.0},
+		"other": "value",
+	})
+}
+
+func (s *tasksetsSuite) TestReplaceInstalledSystem(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := configstate.ReplaceInstalled(s.state, "core", map[string]interface{}{"foo": "bar"}, 0)
+	c.Check(err, ErrorMatches, `cannot replace the system configuration`)
+}
+
 func (s *tasksetsSuite) TestConfigureNotInstalled(c *C) {
 	patch := map[string]interface{}{"foo": "bar"}
 	s.state.Lock()
@@ -20,7 +20,9 @@
 package configstate
 
 import (
+	"github.com/snapcore/snapd/asserts/snapasserts"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sysconfig"
 )
 
@@ -41,3 +43,11 @@ func MockConfigcoreEarly(mock func(dev sysconfig.Device, cfg config.Conf, values
 		configcoreEarly = old
 	}
 }
+
+func MockAssertstateEnforcedValidationSets(f func(st *state.State) (*snapasserts.ValidationSets, error)) (restore func()) {
+	old := assertstateEnforcedValidationSets
+	assertstateEnforcedValidationSets = f
+	return func() {
+		assertstateEnforcedValidationSets = old
+	}
+}
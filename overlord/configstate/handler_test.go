@@ -437,3 +437,62 @@ volumes:
 	c.Assert(err, IsNil)
 	c.Check(foo, Equals, "bar")
 }
+
+const mockSchemaSnapYaml = `
+name: test-snap
+version: 1
+hooks:
+    configure:
+`
+
+const mockConfigSchema = `{
+	"type": "object",
+	"properties": {
+		"port": {"type": "integer", "minimum": 1}
+	}
+}`
+
+func (s *configureHandlerSuite) mockSnapWithConfigSchema(c *C) {
+	snaptest.MockSnapWithFiles(c, mockSchemaSnapYaml, &snap.SideInfo{Revision: snap.R(1)}, [][]string{
+		{"meta/config-schema.json", mockConfigSchema},
+	})
+	s.state.Lock()
+	defer s.state.Unlock()
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{RealName: "test-snap", Revision: snap.R(1)}},
+		Current:  snap.R(1),
+		SnapType: "app",
+	})
+}
+
+func (s *configureHandlerSuite) TestDoneValidatesSchema(c *C) {
+	s.mockSnapWithConfigSchema(c)
+
+	s.context.Lock()
+	tr := configstate.ContextTransaction(s.context)
+	// as if set by the hook with snapctl set
+	c.Assert(tr.Set("test-snap", "port", 0), IsNil)
+	s.context.Unlock()
+
+	c.Check(s.handler.Done(), ErrorMatches, `invalid configuration option "port": must be at least 1`)
+
+	s.context.Lock()
+	c.Assert(tr.Set("test-snap", "port", 80), IsNil)
+	s.context.Unlock()
+
+	c.Check(s.handler.Done(), IsNil)
+}
+
+func (s *configureHandlerSuite) TestDoneNoChanges(c *C) {
+	s.mockSnapWithConfigSchema(c)
+
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	// invalid, but not changed by the hook
+	c.Assert(tr.Set("test-snap", "port", 0), IsNil)
+	tr.Commit()
+	s.state.Unlock()
+
+	c.Check(s.handler.Done(), IsNil)
+}
@@ -122,9 +122,19 @@ func (h *configureHandler) Before() error {
 }
 
 // Done is called by the HookManager after the configure hook has exited
-// successfully.
+// successfully, before the configuration is committed.
 func (h *configureHandler) Done() error {
-	return nil
+	h.context.Lock()
+	defer h.context.Unlock()
+
+	tr := ContextTransaction(h.context)
+	if len(tr.Changes()) == 0 {
+		return nil
+	}
+	if err := validateConfigSchema(tr, h.context.InstanceName()); err != nil {
+		return err
+	}
+	return validateRequiredConfiguration(tr, h.context.InstanceName())
 }
 
 // Error is called by the HookManager after the configure hook has exited
@@ -39,6 +39,7 @@ import (
 	"github.com/snapcore/snapd/kernel/fde"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/fswatch"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
@@ -62,6 +63,8 @@ import (
 var (
 	cloudInitStatus   = sysconfig.CloudInitStatus
 	restrictCloudInit = sysconfig.RestrictCloudInit
+
+	fswatchNew = fswatch.New
 )
 
 // EarlyConfig is a hook set by configstate that can process early configuration
@@ -100,9 +103,12 @@ type DeviceManager struct {
 
 	ensureTriedRecoverySystemRan bool
 
+	ensureDataStorageHealthyRan bool
+
 	cloudInitAlreadyRestricted           bool
 	cloudInitErrorAttemptStart           *time.Time
 	cloudInitEnabledInactiveAttemptStart *time.Time
+	cloudInitWatcher                     *fswatch.Watcher
 
 	lastBecomeOperationalAttempt time.Time
 	becomeOperationalBackoff     time.Duration
@@ -804,6 +810,7 @@ func (m *DeviceManager) ensureCloudInitRestricted() error {
 		case sysconfig.CloudInitDisabledPermanently, sysconfig.CloudInitRestrictedBySnapd:
 			// already been permanently disabled, nothing to do
 			m.cloudInitAlreadyRestricted = true
+			m.stopWatchingCloudInit()
 			return nil
 		case sysconfig.CloudInitNotFound:
 			// no cloud init at all
@@ -860,12 +867,18 @@ func (m *DeviceManager) ensureCloudInitRestricted() error {
 				m.cloudInitEnabledInactiveAttemptStart = &now
 			}
 
-			// keep re-scheduling again in 10 seconds until we hit 5 minutes
+			// keep re-scheduling until we hit 5 minutes, either when
+			// cloud-init changes its status or, if we cannot watch for
+			// that, again in 10 seconds
 			timeSinceFirstAttempt := timeNow().Sub(*m.cloudInitEnabledInactiveAttemptStart)
 			if timeSinceFirstAttempt <= 5*time.Minute {
 				// TODO: should we log a message here about waiting for cloud-init
 				//       to be in a "known state"?
-				m.state.EnsureBefore(10 * time.Second)
+				if m.watchCloudInit() {
+					m.state.EnsureBefore(5*time.Minute - timeSinceFirstAttempt)
+				} else {
+					m.state.EnsureBefore(10 * time.Second)
+				}
 				return nil
 			}
 
@@ -920,11 +933,46 @@ func (m *DeviceManager) ensureCloudInitRestricted() error {
 		logger.Noticef("System initialized, cloud-init %s, %s", statusMsg, actionMsg)
 
 		m.cloudInitAlreadyRestricted = true
+		m.stopWatchingCloudInit()
 	}
 
 	return nil
 }
 
+// watchCloudInit watches the runtime directory of cloud-init, where it
+// records its status, so that an ensure pass happens as soon as the status
+// changes. It returns false if the directory cannot be watched.
+func (m *DeviceManager) watchCloudInit() bool {
+	if m.cloudInitWatcher != nil {
+		return true
+	}
+	cloudInitRunDir := filepath.Join(dirs.GlobalRootDir, "/run/cloud-init")
+	if !osutil.IsDirectory(cloudInitRunDir) {
+		return false
+	}
+	w, err := fswatchNew(time.Second, func([]string) {
+		m.state.EnsureBefore(0)
+	})
+	if err != nil {
+		logger.Debugf("cannot watch cloud-init status: %v", err)
+		return false
+	}
+	if err := w.Add(cloudInitRunDir); err != nil {
+		logger.Debugf("cannot watch cloud-init status: %v", err)
+		w.Close()
+		return false
+	}
+	m.cloudInitWatcher = w
+	return true
+}
+
+func (m *DeviceManager) stopWatchingCloudInit() {
+	if m.cloudInitWatcher != nil {
+		m.cloudInitWatcher.Close()
+		m.cloudInitWatcher = nil
+	}
+}
+
 func (m *DeviceManager) ensureInstalled() error {
 	m.state.Lock()
 	defer m.state.Unlock()
@@ -1216,6 +1264,18 @@ func (m *DeviceManager) Ensure() error {
 		if err := m.ensureTriedRecoverySystem(); err != nil {
 			errs = append(errs, err)
 		}
+
+		if err := m.ensurePendingRemodel(); err != nil {
+			errs = append(errs, err)
+		}
+
+		if err := m.ensureAutoRecoverySystem(); err != nil {
+			errs = append(errs, err)
+		}
+
+		if err := m.ensureDataStorageHealthy(); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	if len(errs) > 0 {
@@ -1231,6 +1291,7 @@ func (m *DeviceManager) ResetToPostBootState() {
 	m.bootOkRan = false
 	m.bootRevisionsUpdated = false
 	m.ensureTriedRecoverySystemRan = false
+	m.ensureDataStorageHealthyRan = false
 }
 
 var errNoSaveSupport = errors.New("no save directory before UC20")
@@ -1369,6 +1430,49 @@ func (m *DeviceManager) Serial() (*asserts.Serial, error) {
 	return findSerial(m.state, nil)
 }
 
+// RegistrationStatus describes the progress of the device registration.
+type RegistrationStatus struct {
+	// Serial is the serial of the device once registered.
+	Serial string `json:"serial,omitempty"`
+	// ChangeID is the ID of the registration change in progress, if any.
+	ChangeID string `json:"change-id,omitempty"`
+	// EnsureAttempts is the number of registration changes started
+	// since snapd started.
+	EnsureAttempts int `json:"ensure-attempts"`
+	// NextAttempt is when the next registration change can be
+	// started at the earliest.
+	NextAttempt time.Time `json:"next-attempt,omitempty"`
+	// Attempts are the most recent attempts at getting a serial.
+	Attempts []RegistrationAttempt `json:"attempts,omitempty"`
+}
+
+// RegistrationStatus returns the progress of the device registration,
+// useful to diagnose devices that fail to register.
+func (m *DeviceManager) RegistrationStatus() (*RegistrationStatus, error) {
+	device, err := m.device()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &RegistrationStatus{
+		Serial:         device.Serial,
+		EnsureAttempts: ensureOperationalAttempts(m.state),
+	}
+	if err := m.state.Get("registration-attempts", &status.Attempts); err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	for _, chg := range m.state.Changes() {
+		if chg.Kind() == "become-operational" && !chg.Status().Ready() {
+			status.ChangeID = chg.ID()
+			break
+		}
+	}
+	if device.Serial == "" && status.ChangeID == "" && !m.lastBecomeOperationalAttempt.IsZero() {
+		status.NextAttempt = m.lastBecomeOperationalAttempt.Add(m.becomeOperationalBackoff)
+	}
+	return status, nil
+}
+
 type SystemModeInfo struct {
 	Mode              string
 	HasModeenv        bool
@@ -1432,6 +1536,11 @@ type System struct {
 	Brand *asserts.Account
 	// Actions available for this system
 	Actions []SystemAction
+	// Description is the human readable description of the system, if
+	// it was created with one
+	Description string
+	// Tags of the system, if it was created with any
+	Tags []string
 }
 
 var defaultSystemActions = []SystemAction{
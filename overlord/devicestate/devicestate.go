@@ -25,6 +25,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"sync"
 
@@ -50,6 +51,7 @@ import (
 var (
 	snapstateInstallWithDeviceContext = snapstate.InstallWithDeviceContext
 	snapstateUpdateWithDeviceContext  = snapstate.UpdateWithDeviceContext
+	snapstateReplacementTasks         = snapstate.ReplacementTasks
 )
 
 // findModel returns the device model assertion.
@@ -107,6 +109,66 @@ func findSerial(st *state.State, device *auth.DeviceState) (*asserts.Serial, err
 	return a.(*asserts.Serial), nil
 }
 
+// DeviceGroups returns the device-group assertions of the device
+// brand and model that include the device serial, ordered by group
+// name. It returns no groups if the device has no serial yet.
+func DeviceGroups(st *state.State) ([]*asserts.DeviceGroup, error) {
+	serial, err := findSerial(st, nil)
+	if err == state.ErrNoState {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	as, err := assertstate.DB(st).FindMany(asserts.DeviceGroupType, map[string]string{
+		"brand-id": serial.BrandID(),
+		"model":    serial.Model(),
+	})
+	if asserts.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*asserts.DeviceGroup
+	for _, a := range as {
+		group := a.(*asserts.DeviceGroup)
+		if group.Contains(serial.Serial()) {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Group() < groups[j].Group()
+	})
+	return groups, nil
+}
+
+// SnapPolicy returns the snap-policy assertion of the device brand and
+// model, if any.
+func SnapPolicy(st *state.State) (*asserts.SnapPolicy, error) {
+	device, err := internal.Device(st)
+	if err != nil {
+		return nil, err
+	}
+	if device.Brand == "" || device.Model == "" {
+		return nil, nil
+	}
+
+	a, err := assertstate.DB(st).Find(asserts.SnapPolicyType, map[string]string{
+		"brand-id": device.Brand,
+		"model":    device.Model,
+	})
+	if asserts.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.(*asserts.SnapPolicy), nil
+}
+
 // auto-refresh
 func canAutoRefresh(st *state.State) (bool, error) {
 	// we need to be seeded first
@@ -236,6 +298,8 @@ func delayedCrossMgrInit() {
 	snapstate.IsOnMeteredConnection = netutil.IsOnMeteredConnection
 	snapstate.DeviceCtx = DeviceCtx
 	snapstate.Remodeling = Remodeling
+	snapstate.DeviceGroups = DeviceGroups
+	snapstate.SnapPolicy = SnapPolicy
 }
 
 // proxyStore returns the store assertion for the proxy store if one is set.
@@ -410,6 +474,28 @@ func remodelTasks(ctx context.Context, st *state.State, current, new *asserts.Mo
 		tss = append(tss, ts)
 	}
 
+	// snaps replacing installed snaps get installed, then the data,
+	// connections and service state of the replaced snaps get migrated
+	// to them and the replaced snaps removed
+	replacements := make(map[string]string)
+	replacementTss := make(map[string]*state.TaskSet)
+	for _, modelSnap := range new.SnapsWithoutEssential() {
+		if modelSnap.Replaces == "" {
+			continue
+		}
+		replacedNotInstalled, err := notInstalled(st, modelSnap.Replaces)
+		if err != nil {
+			return nil, err
+		}
+		needsInstall, err := notInstalled(st, modelSnap.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !replacedNotInstalled && needsInstall {
+			replacements[modelSnap.Name] = modelSnap.Replaces
+		}
+	}
+
 	// add new required-snaps, no longer required snaps will be cleaned
 	// in "set-model"
 	for _, snapRef := range new.RequiredNoEssentialSnaps() {
@@ -426,7 +512,22 @@ func remodelTasks(ctx context.Context, st *state.State, current, new *asserts.Mo
 				return nil, err
 			}
 			tss = append(tss, ts)
+			if replacements[snapRef.SnapName()] != "" {
+				replacementTss[snapRef.SnapName()] = ts
+			}
+		}
+	}
+	// optional snaps get installed only when replacing installed snaps
+	for _, modelSnap := range new.SnapsWithoutEssential() {
+		if modelSnap.Presence != "optional" || replacements[modelSnap.Name] == "" {
+			continue
+		}
+		ts, err := snapstateInstallWithDeviceContext(ctx, st, modelSnap.Name, nil, userID, snapstate.Flags{}, deviceCtx, fromChange)
+		if err != nil {
+			return nil, err
 		}
+		tss = append(tss, ts)
+		replacementTss[modelSnap.Name] = ts
 	}
 	// TODO: Validate that all bases and default-providers are part
 	//       of the install tasksets and error if not. If the
@@ -505,6 +606,7 @@ func remodelTasks(ctx context.Context, st *state.State, current, new *asserts.Mo
 	}
 
 	recoverySetupTaskID := ""
+	var createRecoveryTasks *state.TaskSet
 	if new.Grade() != asserts.ModelGradeUnset {
 		// create a recovery when remodeling to a UC20 system, actual
 		// policy for possible remodels has already been verified by the
@@ -514,7 +616,7 @@ func remodelTasks(ctx context.Context, st *state.State, current, new *asserts.Mo
 		if err != nil {
 			return nil, fmt.Errorf("cannot select non-conflicting label for recovery system %q: %v", labelBase, err)
 		}
-		createRecoveryTasks, err := createRecoverySystemTasks(st, label, snapSetupTasks)
+		createRecoveryTasks, err = createRecoverySystemTasks(st, label, snapSetupTasks)
 		if err != nil {
 			return nil, err
 		}
@@ -531,6 +633,33 @@ func remodelTasks(ctx context.Context, st *state.State, current, new *asserts.Mo
 		recoverySetupTaskID = createRecoveryTasks.Tasks()[0].ID()
 	}
 
+	// Migrate the replaced snaps once everything is downloaded (and
+	// the recovery system is created), right before the snaps replacing
+	// them get installed, and remove them once those are installed.
+	for _, modelSnap := range new.SnapsWithoutEssential() {
+		installTs := replacementTss[modelSnap.Name]
+		if installTs == nil {
+			continue
+		}
+		prepare, finish, err := snapstateReplacementTasks(st, replacements[modelSnap.Name], installTs)
+		if err != nil {
+			return nil, err
+		}
+		_, _, installFirst, installLast, err := extractDownloadInstallEdgesFromTs(installTs)
+		if err != nil {
+			return nil, fmt.Errorf("cannot remodel: %v", err)
+		}
+		if lastDownloadInChain != nil {
+			prepare.WaitFor(lastDownloadInChain)
+		}
+		if createRecoveryTasks != nil {
+			prepare.WaitAll(createRecoveryTasks)
+		}
+		installFirst.WaitAll(prepare)
+		finish.WaitFor(installLast)
+		tss = append(tss, prepare, finish)
+	}
+
 	// Set the new model assertion - this *must* be the last thing done
 	// by the change.
 	setModel := st.NewTask("set-model", i18n.G("Set new model assertion"))
@@ -573,66 +702,17 @@ func AllowUC20RemodelTesting(allow bool) (restore func()) {
 // - Make sure this works with Core 20 as well, in the Core 20 case
 //   we must enforce the default-channels from the model as well
 func Remodel(st *state.State, new *asserts.Model) (*state.Change, error) {
-	var seeded bool
-	err := st.Get("seeded", &seeded)
-	if err != nil && err != state.ErrNoState {
-		return nil, err
-	}
-	if !seeded {
-		return nil, fmt.Errorf("cannot remodel until fully seeded")
-	}
-
-	current, err := findModel(st)
+	current, err := checkRemodel(st, new)
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := findSerial(st, nil); err != nil {
-		if err == state.ErrNoState {
-			return nil, fmt.Errorf("cannot remodel without a serial")
-		}
-		return nil, err
-	}
-
-	if current.Series() != new.Series() {
-		return nil, fmt.Errorf("cannot remodel to different series yet")
-	}
-
-	// TODO:UC20: support remodel, also ensure we never remodel to a lower
-	// grade
-	if !allowUC20RemodelTesting {
-		if current.Grade() != asserts.ModelGradeUnset {
-			return nil, fmt.Errorf("cannot remodel Ubuntu Core 20 models yet")
-		}
-		if new.Grade() != asserts.ModelGradeUnset {
-			return nil, fmt.Errorf("cannot remodel to Ubuntu Core 20 models yet")
-		}
-	} else {
-		// also disallows remodel from non-UC20 (grade unset) to UC20
-		if current.Grade() != new.Grade() {
-			return nil, fmt.Errorf("cannot remodel from grade %v to grade %v", current.Grade(), new.Grade())
-		}
-	}
-
 	// TODO: we need dedicated assertion language to permit for
 	// model transitions before we allow cross vault
 	// transitions.
 
 	remodelKind := ClassifyRemodel(current, new)
 
-	// TODO: should we restrict remodel from one arch to another?
-	// There are valid use-cases here though, i.e. amd64 machine that
-	// remodels itself to/from i386 (if the HW can do both 32/64 bit)
-	if current.Architecture() != new.Architecture() {
-		return nil, fmt.Errorf("cannot remodel to different architectures yet")
-	}
-
-	// calculate snap differences between the two models
-	// FIXME: this needs work to switch from core->bases
-	if current.Base() == "" && new.Base() != "" {
-		return nil, fmt.Errorf("cannot remodel from core to bases yet")
-	}
-
 	// Do we do this only for the more complicated cases (anything
 	// more than adding required-snaps really)?
 	if err := snapstate.CheckChangeConflictRunExclusively(st, "remodel"); err != nil {
@@ -705,6 +785,66 @@ func Remodel(st *state.State, new *asserts.Model) (*state.Change, error) {
 	return chg, nil
 }
 
+// checkRemodel checks that the device can be remodeled to the new model
+// and returns the current model.
+func checkRemodel(st *state.State, new *asserts.Model) (*asserts.Model, error) {
+	var seeded bool
+	err := st.Get("seeded", &seeded)
+	if err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	if !seeded {
+		return nil, fmt.Errorf("cannot remodel until fully seeded")
+	}
+
+	current, err := findModel(st)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := findSerial(st, nil); err != nil {
+		if err == state.ErrNoState {
+			return nil, fmt.Errorf("cannot remodel without a serial")
+		}
+		return nil, err
+	}
+
+	if current.Series() != new.Series() {
+		return nil, fmt.Errorf("cannot remodel to different series yet")
+	}
+
+	// TODO:UC20: support remodel, also ensure we never remodel to a lower
+	// grade
+	if !allowUC20RemodelTesting {
+		if current.Grade() != asserts.ModelGradeUnset {
+			return nil, fmt.Errorf("cannot remodel Ubuntu Core 20 models yet")
+		}
+		if new.Grade() != asserts.ModelGradeUnset {
+			return nil, fmt.Errorf("cannot remodel to Ubuntu Core 20 models yet")
+		}
+	} else {
+		// also disallows remodel from non-UC20 (grade unset) to UC20
+		if current.Grade() != new.Grade() {
+			return nil, fmt.Errorf("cannot remodel from grade %v to grade %v", current.Grade(), new.Grade())
+		}
+	}
+
+	// TODO: should we restrict remodel from one arch to another?
+	// There are valid use-cases here though, i.e. amd64 machine that
+	// remodels itself to/from i386 (if the HW can do both 32/64 bit)
+	if current.Architecture() != new.Architecture() {
+		return nil, fmt.Errorf("cannot remodel to different architectures yet")
+	}
+
+	// calculate snap differences between the two models
+	// FIXME: this needs work to switch from core->bases
+	if current.Base() == "" && new.Base() != "" {
+		return nil, fmt.Errorf("cannot remodel from core to bases yet")
+	}
+
+	return current, nil
+}
+
 // Remodeling returns true whether there's a remodeling in progress
 func Remodeling(st *state.State) bool {
 	for _, chg := range st.Changes() {
@@ -725,6 +865,9 @@ type recoverySystemSetup struct {
 	// SnapSetupTasks is a list of task IDs that carry snap setup
 	// information, relevant only during remodel, set when tasks are created
 	SnapSetupTasks []string `json:"snap-setup-tasks"`
+	// Metadata is the description and tags of the recovery system, if
+	// any were provided
+	Metadata *RecoverySystemMetadata `json:"metadata,omitempty"`
 }
 
 func pickRecoverySystemLabel(labelBase string) (string, error) {
@@ -784,6 +927,18 @@ func createRecoverySystemTasks(st *state.State, label string, snapSetupTasks []s
 }
 
 func CreateRecoverySystem(st *state.State, label string) (*state.Change, error) {
+	return CreateRecoverySystemWithMetadata(st, label, nil)
+}
+
+// CreateRecoverySystemWithMetadata creates a recovery system with the
+// given label like CreateRecoverySystem, recording the given
+// description and tags in the metadata of the system.
+func CreateRecoverySystemWithMetadata(st *state.State, label string, meta *RecoverySystemMetadata) (*state.Change, error) {
+	if meta != nil {
+		if err := meta.validate(); err != nil {
+			return nil, err
+		}
+	}
 	var seeded bool
 	err := st.Get("seeded", &seeded)
 	if err != nil && err != state.ErrNoState {
@@ -797,6 +952,17 @@ func CreateRecoverySystem(st *state.State, label string) (*state.Change, error)
 	if err != nil {
 		return nil, err
 	}
+	if meta != nil {
+		create := ts.Tasks()[0]
+		setup, err := taskRecoverySystemSetup(create)
+		if err != nil {
+			return nil, err
+		}
+		setup.Metadata = meta
+		if err := setTaskRecoverySystemSetup(create, setup); err != nil {
+			return nil, err
+		}
+	}
 	chg.AddAll(ts)
 	return chg, nil
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil/fswatch"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
@@ -935,6 +936,69 @@ fi`)
 	c.Assert(strings.TrimSpace(s.logbuf.String()), Matches, `.*System initialized, cloud-init failed to transition to done or error state after 5 minutes, disabled permanently.*`)
 }
 
+func (s *cloudInitSuite) TestCloudInitRunningWatchesStatusChanges(c *C) {
+	cmd := testutil.MockCommand(c, "cloud-init", `
+if [ "$1" = "status" ]; then
+	echo "status: running"
+else
+	echo "unexpected args $*"
+	exit 1
+fi`)
+	defer cmd.Restore()
+
+	cloudInitRunDir := filepath.Join(dirs.GlobalRootDir, "/run/cloud-init")
+	c.Assert(os.MkdirAll(cloudInitRunDir, 0755), IsNil)
+
+	notified := make(chan []string, 1)
+	r := devicestate.MockFswatchNew(func(delay time.Duration, notify func(paths []string)) (*fswatch.Watcher, error) {
+		c.Check(delay, Equals, time.Second)
+		return fswatch.New(time.Millisecond, func(paths []string) {
+			notify(paths)
+			select {
+			case notified <- paths:
+			default:
+			}
+		})
+	})
+	defer r()
+
+	err := devicestate.EnsureCloudInitRestricted(s.mgr)
+	c.Assert(err, IsNil)
+	c.Assert(devicestate.DeviceManagerWatchesCloudInit(s.mgr), Equals, true)
+	defer devicestate.DeviceManagerStopWatchingCloudInit(s.mgr)
+
+	// cloud-init records its status
+	statusFile := filepath.Join(cloudInitRunDir, "status.json")
+	c.Assert(ioutil.WriteFile(statusFile, []byte("{}"), 0644), IsNil)
+	select {
+	case paths := <-notified:
+		c.Check(paths, DeepEquals, []string{statusFile})
+	case <-time.After(5 * time.Second):
+		c.Fatalf("cloud-init status change was not noticed")
+	}
+}
+
+func (s *cloudInitSuite) TestCloudInitRunningNoStatusDirPolls(c *C) {
+	cmd := testutil.MockCommand(c, "cloud-init", `
+if [ "$1" = "status" ]; then
+	echo "status: running"
+else
+	echo "unexpected args $*"
+	exit 1
+fi`)
+	defer cmd.Restore()
+
+	r := devicestate.MockFswatchNew(func(delay time.Duration, notify func(paths []string)) (*fswatch.Watcher, error) {
+		c.Errorf("unexpected watcher")
+		return nil, fmt.Errorf("unexpected")
+	})
+	defer r()
+
+	err := devicestate.EnsureCloudInitRestricted(s.mgr)
+	c.Assert(err, IsNil)
+	c.Check(devicestate.DeviceManagerWatchesCloudInit(s.mgr), Equals, false)
+}
+
 func (s *cloudInitSuite) TestCloudInitTakingTooLongDisablesFasterEnsures(c *C) {
 	// same test as TestCloudInitTakingTooLongDisables, but with a faster
 	// re-ensure cycle to ensure that if we get scheduled to run Ensure() sooner
@@ -218,6 +218,7 @@ func (s *deviceMgrInstallModeSuite) doRunChangeTestWithEncryption(c *C, grade st
 		brOpts = options
 		installSealingObserver = obs
 		installRunCalled++
+		options.Progress(install.StageCopying)
 		var keysForRoles map[string]*install.EncryptionKeySet
 		if tc.encrypt {
 			keysForRoles = map[string]*install.EncryptionKeySet{
@@ -320,6 +321,8 @@ func (s *deviceMgrInstallModeSuite) doRunChangeTestWithEncryption(c *C, grade st
 	// in the right way
 	c.Assert(brGadgetRoot, Equals, filepath.Join(dirs.SnapMountDir, "/pc/1"))
 	c.Assert(brDevice, Equals, "")
+	c.Assert(brOpts.Progress, NotNil)
+	brOpts.Progress = nil
 	if tc.encrypt {
 		c.Assert(brOpts, DeepEquals, install.Options{
 			Mount:   true,
@@ -424,6 +427,99 @@ func (s *deviceMgrInstallModeSuite) TestInstallExpTasks(c *C) {
 	c.Check(s.restartRequests, DeepEquals, []state.RestartType{state.RestartSystemNow})
 }
 
+func (s *deviceMgrInstallModeSuite) TestInstallProgress(c *C) {
+	restore := release.MockOnClassic(false)
+	defer restore()
+
+	var progressDuringCopy *devicestate.InstallProgress
+	restore = devicestate.MockInstallRun(func(mod gadget.Model, gadgetRoot, kernelRoot, device string, options install.Options, _ gadget.ContentObserver, _ timings.Measurer) (*install.InstalledSystemSideData, error) {
+		options.Progress(install.StageCopying)
+
+		s.state.Lock()
+		defer s.state.Unlock()
+		var err error
+		progressDuringCopy, err = s.mgr.InstallProgress()
+		c.Check(err, IsNil)
+		return nil, nil
+	})
+	defer restore()
+
+	err := ioutil.WriteFile(filepath.Join(dirs.GlobalRootDir, "/var/lib/snapd/modeenv"),
+		[]byte("mode=install\n"), 0644)
+	c.Assert(err, IsNil)
+
+	s.state.Lock()
+	s.makeMockInstalledPcGadget(c, "dangerous", "", "")
+	devicestate.SetSystemMode(s.mgr, "install")
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	installSystem := s.findInstallSystem()
+	c.Assert(installSystem, NotNil)
+	c.Check(installSystem.Err(), IsNil)
+
+	c.Check(progressDuringCopy, DeepEquals, &devicestate.InstallProgress{
+		Stage:    "copying",
+		Done:     3,
+		Total:    6,
+		ChangeID: installSystem.ID(),
+	})
+
+	setupRunSystemTask := installSystem.Tasks()[0]
+	c.Assert(setupRunSystemTask.Kind(), Equals, "setup-run-system")
+	var stage string
+	c.Assert(setupRunSystemTask.Get("install-stage", &stage), IsNil)
+	c.Check(stage, Equals, "making-bootable")
+
+	progress, err := s.mgr.InstallProgress()
+	c.Assert(err, IsNil)
+	c.Check(progress, DeepEquals, &devicestate.InstallProgress{
+		Stage:    "done",
+		Done:     6,
+		Total:    6,
+		ChangeID: installSystem.ID(),
+	})
+}
+
+func (s *deviceMgrInstallModeSuite) TestInstallProgressSeeding(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	devicestate.SetSystemMode(s.mgr, "install")
+	s.state.Set("seeded", false)
+	chg := s.state.NewChange("seed", "Initialize system state")
+	t1 := s.state.NewTask("prerequisites", "...")
+	t1.SetStatus(state.DoneStatus)
+	t2 := s.state.NewTask("mark-seeded", "...")
+	chg.AddTask(t1)
+	chg.AddTask(t2)
+
+	progress, err := s.mgr.InstallProgress()
+	c.Assert(err, IsNil)
+	c.Check(progress, DeepEquals, &devicestate.InstallProgress{
+		Stage:      "seeding",
+		Done:       0,
+		Total:      6,
+		StageDone:  1,
+		StageTotal: 2,
+		ChangeID:   chg.ID(),
+	})
+}
+
+func (s *deviceMgrInstallModeSuite) TestInstallProgressNotInstallMode(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	devicestate.SetSystemMode(s.mgr, "run")
+
+	_, err := s.mgr.InstallProgress()
+	c.Assert(err, ErrorMatches, `cannot report install progress in "run" mode`)
+}
+
 func (s *deviceMgrInstallModeSuite) TestInstallWithInstallDeviceHookExpTasks(c *C) {
 	restore := release.MockOnClassic(false)
 	defer restore()
@@ -836,6 +836,263 @@ func (s *deviceMgrRemodelSuite) TestRemodelRereg(c *C) {
 	c.Assert(tPrepareRemodeling.WaitTasks(), DeepEquals, []*state.Task{tRequestSerial})
 }
 
+func (s *deviceMgrRemodelSuite) TestPlanRemodel(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", true)
+
+	// set a model assertion
+	s.makeModelAssertionInState(c, "canonical", "pc-model", map[string]interface{}{
+		"architecture":   "amd64",
+		"kernel":         "pc-kernel",
+		"gadget":         "pc",
+		"base":           "core18",
+		"required-snaps": []interface{}{"old-required-snap"},
+	})
+	s.makeSerialAssertionInState(c, "canonical", "pc-model", "1234")
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand:  "canonical",
+		Model:  "pc-model",
+		Serial: "1234",
+	})
+	si := &snap.SideInfo{RealName: "old-required-snap", Revision: snap.R(1)}
+	snapstate.Set(s.state, "old-required-snap", &snapstate.SnapState{
+		SnapType: "app",
+		Active:   true,
+		Sequence: []*snap.SideInfo{si},
+		Current:  si.Revision,
+		Flags:    snapstate.Flags{Required: true},
+	})
+
+	new := s.brands.Model("canonical", "pc-model", map[string]interface{}{
+		"architecture":   "amd64",
+		"kernel":         "pc-kernel=18",
+		"gadget":         "pc",
+		"base":           "core18",
+		"required-snaps": []interface{}{"new-required-snap"},
+		"revision":       "1",
+	})
+	plan, err := devicestate.PlanRemodel(s.state, new)
+	c.Assert(err, IsNil)
+	c.Check(plan, DeepEquals, &devicestate.RemodelPlan{
+		Kind: "revision update remodel",
+		Install: []devicestate.RemodelPlanSnap{
+			{Name: "new-required-snap"},
+		},
+		Switch: []devicestate.RemodelPlanSnap{
+			{Name: "pc-kernel", Channel: "18"},
+		},
+		NoLongerRequired: []string{"old-required-snap"},
+		Assertions: []string{
+			"model canonical/pc-model (1)",
+			"snap-declaration of new-required-snap",
+			"snap-revision of new-required-snap",
+		},
+		Reboot: true,
+	})
+
+	// nothing was started
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
+func (s *deviceMgrRemodelSuite) TestPlanRemodelRereg(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", true)
+
+	// set a model assertion
+	s.makeModelAssertionInState(c, "canonical", "pc-model", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+		"base":         "core18",
+	})
+	s.makeSerialAssertionInState(c, "canonical", "pc-model", "orig-serial")
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand:  "canonical",
+		Model:  "pc-model",
+		Serial: "orig-serial",
+	})
+
+	new := s.brands.Model("canonical", "rereg-model", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+		"base":         "core18",
+	})
+	plan, err := devicestate.PlanRemodel(s.state, new)
+	c.Assert(err, IsNil)
+	c.Check(plan, DeepEquals, &devicestate.RemodelPlan{
+		Kind: "re-registration remodel",
+		Assertions: []string{
+			"model canonical/rereg-model (0)",
+			"serial for canonical/rereg-model",
+		},
+	})
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
+func (s *deviceMgrRemodelSuite) TestPlanRemodelUnhappy(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", false)
+
+	newModel := s.brands.Model("canonical", "pc", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+	})
+	_, err := devicestate.PlanRemodel(s.state, newModel)
+	c.Assert(err, ErrorMatches, "cannot remodel until fully seeded")
+}
+
+func (s *deviceMgrRemodelSuite) setupQueuedRemodel(c *C) *asserts.Model {
+	s.state.Set("seeded", true)
+	s.state.Set("refresh-privacy-key", "some-privacy-key")
+
+	// set a model assertion
+	s.makeModelAssertionInState(c, "canonical", "pc-model", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+		"base":         "core18",
+	})
+	s.makeSerialAssertionInState(c, "canonical", "pc-model", "1234")
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand:  "canonical",
+		Model:  "pc-model",
+		Serial: "1234",
+	})
+
+	return s.brands.Model("canonical", "pc-model", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+		"base":         "core18",
+		"revision":     "1",
+	})
+}
+
+func (s *deviceMgrRemodelSuite) TestQueueRemodelNextBoot(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := devicestate.MockOsutilBootID("boot-1")
+	defer restore()
+
+	new := s.setupQueuedRemodel(c)
+	err := devicestate.QueueRemodel(s.state, new)
+	c.Assert(err, IsNil)
+
+	pending, err := devicestate.PendingRemodel(s.state)
+	c.Assert(err, IsNil)
+	c.Check(pending, DeepEquals, new)
+
+	// nothing happens until the next boot
+	s.state.Unlock()
+	err = devicestate.EnsurePendingRemodel(s.mgr)
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(s.state.Changes(), HasLen, 0)
+
+	restore = devicestate.MockOsutilBootID("boot-2")
+	defer restore()
+
+	s.state.Unlock()
+	err = devicestate.EnsurePendingRemodel(s.mgr)
+	s.state.Lock()
+	c.Assert(err, IsNil)
+
+	c.Assert(s.state.Changes(), HasLen, 1)
+	chg := s.state.Changes()[0]
+	c.Check(chg.Kind(), Equals, "remodel")
+	c.Check(chg.Summary(), Equals, "Refresh model assertion from revision 0 to 1")
+
+	_, err = devicestate.PendingRemodel(s.state)
+	c.Check(err, Equals, state.ErrNoState)
+}
+
+func (s *deviceMgrRemodelSuite) TestQueueRemodelAfterAutoRefresh(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := devicestate.MockOsutilBootID("boot-1")
+	defer restore()
+	now := time.Now()
+	restore = devicestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	new := s.setupQueuedRemodel(c)
+	err := devicestate.QueueRemodel(s.state, new)
+	c.Assert(err, IsNil)
+
+	// a refresh before the remodel was queued does not count
+	s.state.Set("last-refresh", now.Add(-time.Hour))
+	s.state.Unlock()
+	err = devicestate.EnsurePendingRemodel(s.mgr)
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(s.state.Changes(), HasLen, 0)
+
+	s.state.Set("last-refresh", now.Add(time.Hour))
+	s.state.Unlock()
+	err = devicestate.EnsurePendingRemodel(s.mgr)
+	s.state.Lock()
+	c.Assert(err, IsNil)
+
+	c.Assert(s.state.Changes(), HasLen, 1)
+	c.Check(s.state.Changes()[0].Kind(), Equals, "remodel")
+	_, err = devicestate.PendingRemodel(s.state)
+	c.Check(err, Equals, state.ErrNoState)
+}
+
+func (s *deviceMgrRemodelSuite) TestQueueRemodelConflictKeepsPending(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := devicestate.MockOsutilBootID("boot-1")
+	defer restore()
+
+	new := s.setupQueuedRemodel(c)
+	err := devicestate.QueueRemodel(s.state, new)
+	c.Assert(err, IsNil)
+
+	// another change is in progress
+	chg := s.state.NewChange("other", "...")
+	chg.AddTask(s.state.NewTask("some-task", "..."))
+
+	restore = devicestate.MockOsutilBootID("boot-2")
+	defer restore()
+
+	s.state.Unlock()
+	err = devicestate.EnsurePendingRemodel(s.mgr)
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(s.state.Changes(), HasLen, 1)
+
+	// the remodel is still queued
+	pending, err := devicestate.PendingRemodel(s.state)
+	c.Assert(err, IsNil)
+	c.Check(pending, DeepEquals, new)
+}
+
+func (s *deviceMgrRemodelSuite) TestQueueRemodelUnhappy(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", false)
+
+	newModel := s.brands.Model("canonical", "pc", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+	})
+	err := devicestate.QueueRemodel(s.state, newModel)
+	c.Assert(err, ErrorMatches, "cannot remodel until fully seeded")
+
+	_, err = devicestate.PendingRemodel(s.state)
+	c.Check(err, Equals, state.ErrNoState)
+}
+
 func (s *deviceMgrRemodelSuite) TestRemodelClash(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -1836,6 +2093,128 @@ func (s *deviceMgrRemodelSuite) TestRemodelUC20RequiredSnapsAndRecoverySystem(c
 	}
 }
 
+func (s *deviceMgrRemodelSuite) TestRemodelUC20SnapReplacement(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", true)
+	s.state.Set("refresh-privacy-key", "some-privacy-key")
+
+	restore := devicestate.MockSnapstateInstallWithDeviceContext(func(ctx context.Context, st *state.State, name string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags, deviceCtx snapstate.DeviceContext, fromChange string) (*state.TaskSet, error) {
+		c.Check(name, Equals, "new-snap")
+		c.Check(flags.Required, Equals, true)
+
+		tDownload := s.state.NewTask("fake-download", fmt.Sprintf("Download %s", name))
+		tValidate := s.state.NewTask("validate-snap", fmt.Sprintf("Validate %s", name))
+		tValidate.WaitFor(tDownload)
+		tInstall := s.state.NewTask("fake-install", fmt.Sprintf("Install %s", name))
+		tInstall.WaitFor(tValidate)
+		ts := state.NewTaskSet(tDownload, tValidate, tInstall)
+		ts.MarkEdge(tValidate, snapstate.DownloadAndChecksDoneEdge)
+		return ts, nil
+	})
+	defer restore()
+
+	restore = devicestate.MockSnapstateReplacementTasks(func(st *state.State, oldName string, installTs *state.TaskSet) (prepare, finish *state.TaskSet, err error) {
+		c.Check(oldName, Equals, "old-snap")
+		c.Check(installTs.Tasks()[0].Summary(), Equals, "Download new-snap")
+
+		tMigrate := s.state.NewTask("fake-migrate", fmt.Sprintf("Migrate %s", oldName))
+		tTransfer := s.state.NewTask("fake-transfer", fmt.Sprintf("Transfer %s", oldName))
+		return state.NewTaskSet(tMigrate), state.NewTaskSet(tTransfer), nil
+	})
+	defer restore()
+
+	restore = devicestate.AllowUC20RemodelTesting(true)
+	defer restore()
+
+	si := &snap.SideInfo{RealName: "old-snap", SnapID: snaptest.AssertedSnapID("old-snap"), Revision: snap.R(1)}
+	snaptest.MockSnap(c, "name: old-snap\nversion: 1.0\n", si)
+	snapstate.Set(s.state, "old-snap", &snapstate.SnapState{
+		SnapType: "app",
+		Active:   true,
+		Sequence: []*snap.SideInfo{si},
+		Current:  si.Revision,
+	})
+
+	modelSnaps := []interface{}{
+		map[string]interface{}{
+			"name":            "pc-kernel",
+			"id":              snaptest.AssertedSnapID("pc-kernel"),
+			"type":            "kernel",
+			"default-channel": "20",
+		},
+		map[string]interface{}{
+			"name":            "pc",
+			"id":              snaptest.AssertedSnapID("pc"),
+			"type":            "gadget",
+			"default-channel": "20",
+		},
+	}
+	s.makeModelAssertionInState(c, "canonical", "pc-model", map[string]interface{}{
+		"architecture": "amd64",
+		"base":         "core20",
+		"grade":        "dangerous",
+		"snaps": append(modelSnaps, map[string]interface{}{
+			"name": "old-snap",
+			"id":   snaptest.AssertedSnapID("old-snap"),
+		}),
+	})
+	s.makeSerialAssertionInState(c, "canonical", "pc-model", "serial")
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand:  "canonical",
+		Model:  "pc-model",
+		Serial: "serial",
+	})
+
+	new := s.brands.Model("canonical", "pc-model", map[string]interface{}{
+		"architecture": "amd64",
+		"base":         "core20",
+		"grade":        "dangerous",
+		"revision":     "1",
+		"snaps": append(modelSnaps, map[string]interface{}{
+			"name":     "new-snap",
+			"id":       snaptest.AssertedSnapID("new-snap"),
+			"replaces": "old-snap",
+		}),
+	})
+	chg, err := devicestate.Remodel(s.state, new)
+	c.Assert(err, IsNil)
+
+	tl := chg.Tasks()
+	// new snap (3 tasks) + recovery system (2 tasks) + migration (2 tasks) + set-model
+	c.Assert(tl, HasLen, 3+2+2+1)
+
+	tValidate := tl[1]
+	tInstall := tl[2]
+	tCreateRecovery := tl[3]
+	tFinalizeRecovery := tl[4]
+	tMigrate := tl[5]
+	tTransfer := tl[6]
+	tSetModel := tl[7]
+
+	c.Assert(tMigrate.Kind(), Equals, "fake-migrate")
+	c.Assert(tTransfer.Kind(), Equals, "fake-transfer")
+	c.Assert(tSetModel.Kind(), Equals, "set-model")
+
+	// data is migrated once everything is downloaded and the recovery
+	// system is created
+	c.Check(tMigrate.WaitTasks(), DeepEquals, []*state.Task{
+		tValidate,
+		tCreateRecovery,
+		tFinalizeRecovery,
+	})
+	// the new snap is installed after the migration
+	c.Check(tInstall.WaitTasks(), DeepEquals, []*state.Task{
+		tValidate,
+		tCreateRecovery,
+		tFinalizeRecovery,
+		tMigrate,
+	})
+	// and connections are transferred after it got installed
+	c.Check(tTransfer.WaitTasks(), DeepEquals, []*state.Task{tInstall})
+	c.Check(tSetModel.WaitTasks(), DeepEquals, tl[:7])
+}
+
 type remodelUC20LabelConflictsTestCase struct {
 	now              time.Time
 	breakPermissions bool
@@ -799,6 +799,163 @@ func (s *deviceMgrSerialSuite) TestDoRequestSerialMaxTentatives(c *C) {
 	c.Check(chg.Err(), ErrorMatches, `(?s).*cannot retrieve request-id for making a request for a serial: unexpected status 501.*`)
 }
 
+func (s *deviceMgrSerialSuite) TestDoRequestSerialFallbackDeviceService(c *C) {
+	privKey, _ := assertstest.GenerateKey(testKeyLength)
+
+	// immediate
+	r := devicestate.MockRetryInterval(0)
+	defer r()
+
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer failingServer.Close()
+
+	bhv := &devicestatetest.DeviceServiceBehavior{
+		RequestIDURLPath: "/svc/request-id",
+		SerialURLPath:    "/svc/serial",
+	}
+	mockServer := s.mockServer(c, "REQID-1", bhv)
+	defer mockServer.Close()
+
+	restore := devicestate.MockBaseStoreURL(mockServer.URL)
+	defer restore()
+
+	// setup state as done by first-boot/Ensure/doGenerateDeviceKey
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.makeModelAssertionInState(c, "canonical", "pc", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+	})
+
+	devicestatetest.MockGadget(c, s.state, "pc", snap.R(2), nil)
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("pc", "device-service.url", failingServer.URL+"/svc/"), IsNil)
+	c.Assert(tr.Set("pc", "device-service.fallback-urls", []interface{}{mockServer.URL + "/svc/"}), IsNil)
+	tr.Commit()
+
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand: "canonical",
+		Model: "pc",
+		KeyID: privKey.PublicKey().ID(),
+	})
+	devicestate.KeypairManager(s.mgr).Put(privKey)
+
+	t := s.state.NewTask("request-serial", "test")
+	chg := s.state.NewChange("become-operational", "...")
+	chg.AddTask(t)
+
+	// avoid full seeding
+	s.seeding()
+
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	// the primary device service failed, the fallback one is next
+	c.Check(chg.Status(), Equals, state.DoingStatus)
+	var vault int
+	c.Assert(t.Get("serial-vault", &vault), IsNil)
+	c.Check(vault, Equals, 1)
+
+	status, err := s.mgr.RegistrationStatus()
+	c.Assert(err, IsNil)
+	c.Check(status.Serial, Equals, "")
+	c.Check(status.ChangeID, Equals, chg.ID())
+	c.Assert(status.Attempts, HasLen, 1)
+	c.Check(status.Attempts[0].URL, Equals, failingServer.URL+"/svc/serial")
+	c.Check(status.Attempts[0].Outcome, Equals, "retry")
+	c.Check(status.Attempts[0].Error, Equals, "cannot retrieve request-id for making a request for a serial: unexpected status 503")
+	c.Check(status.Attempts[0].Time.IsZero(), Equals, false)
+
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	device, err := devicestatetest.Device(s.state)
+	c.Assert(err, IsNil)
+	c.Check(device.Serial, Equals, "9999")
+
+	status, err = s.mgr.RegistrationStatus()
+	c.Assert(err, IsNil)
+	c.Check(status.Serial, Equals, "9999")
+	c.Check(status.ChangeID, Equals, "")
+	c.Assert(status.Attempts, HasLen, 2)
+	c.Check(status.Attempts[1].URL, Equals, mockServer.URL+"/svc/serial")
+	c.Check(status.Attempts[1].Outcome, Equals, "serial")
+	c.Check(status.Attempts[1].Error, Equals, "")
+}
+
+func (s *deviceMgrSerialSuite) TestRegistrationAttemptsHistoryIsCapped(c *C) {
+	privKey, _ := assertstest.GenerateKey(testKeyLength)
+
+	// immediate
+	r := devicestate.MockRetryInterval(0)
+	defer r()
+
+	// this will trigger a reply 501 in the mock server
+	mockServer := s.mockServer(c, devicestatetest.ReqIDFailID501, nil)
+	defer mockServer.Close()
+
+	restore := devicestate.MockBaseStoreURL(mockServer.URL)
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.makeModelAssertionInState(c, "canonical", "pc", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+	})
+
+	devicestatetest.MockGadget(c, s.state, "pc", snap.R(2), nil)
+
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand: "canonical",
+		Model: "pc",
+		KeyID: privKey.PublicKey().ID(),
+	})
+	devicestate.KeypairManager(s.mgr).Put(privKey)
+
+	var attempts []devicestate.RegistrationAttempt
+	for i := 0; i < 25; i++ {
+		attempts = append(attempts, devicestate.RegistrationAttempt{Outcome: "retry", Error: fmt.Sprintf("old-%d", i)})
+	}
+	s.state.Set("registration-attempts", attempts)
+
+	t := s.state.NewTask("request-serial", "test")
+	chg := s.state.NewChange("become-operational", "...")
+	chg.AddTask(t)
+
+	// avoid full seeding
+	s.seeding()
+
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.DoingStatus)
+
+	status, err := s.mgr.RegistrationStatus()
+	c.Assert(err, IsNil)
+	c.Assert(status.Attempts, HasLen, 20)
+	c.Check(status.Attempts[0].Error, Equals, "old-6")
+	c.Check(status.Attempts[19].Outcome, Equals, "retry")
+	c.Check(status.Attempts[19].Error, Equals, "cannot retrieve request-id for making a request for a serial: unexpected status 501")
+
+	// there is no fallback device service
+	var vault int
+	c.Check(t.Get("serial-vault", &vault), Equals, state.ErrNoState)
+}
+
 type simulateNoNetRoundTripper struct{}
 
 func (s *simulateNoNetRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
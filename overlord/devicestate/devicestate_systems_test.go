@@ -23,8 +23,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -37,7 +39,9 @@ import (
 	"github.com/snapcore/snapd/bootloader/bootloadertest"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -305,6 +309,27 @@ func (s *deviceMgrSystemsSuite) TestListSeedSystemsNoCurrent(c *C) {
 	}})
 }
 
+func (s *deviceMgrSystemsSuite) TestListSeedSystemsWithMetadata(c *C) {
+	err := ioutil.WriteFile(filepath.Join(dirs.SnapSeedDir, "systems", s.mockedSystemSeeds[1].label, "snapd-metadata.json"),
+		[]byte(`{"description":"before the upgrade","tags":["pre-upgrade","manual"]}`), 0644)
+	c.Assert(err, IsNil)
+	// broken metadata does not make the system unusable
+	err = ioutil.WriteFile(filepath.Join(dirs.SnapSeedDir, "systems", s.mockedSystemSeeds[2].label, "snapd-metadata.json"),
+		[]byte(`{`), 0644)
+	c.Assert(err, IsNil)
+
+	systems, err := s.mgr.Systems()
+	c.Assert(err, IsNil)
+	c.Assert(systems, HasLen, 3)
+	c.Check(systems[0].Description, Equals, "")
+	c.Check(systems[0].Tags, HasLen, 0)
+	c.Check(systems[1].Description, Equals, "before the upgrade")
+	c.Check(systems[1].Tags, DeepEquals, []string{"pre-upgrade", "manual"})
+	c.Check(systems[2].Label, Equals, s.mockedSystemSeeds[2].label)
+	c.Check(systems[2].Description, Equals, "")
+	c.Check(s.logbuf.String(), Matches, `(?s).*cannot read metadata of system "other-20200318": .*`)
+}
+
 func (s *deviceMgrSystemsSuite) TestListSeedSystemsCurrentSingleSeeded(c *C) {
 	s.state.Lock()
 	s.state.Set("seeded-systems", []devicestate.SeededSystem{
@@ -1269,6 +1294,55 @@ func (s *deviceMgrSystemsCreateSuite) TestDeviceManagerCreateRecoverySystemTasks
 	c.Assert(otherTaskID, Equals, tskCreate.ID())
 }
 
+func (s *deviceMgrSystemsCreateSuite) TestDeviceManagerCreateRecoverySystemWithMetadata(c *C) {
+	devicestate.SetBootOkRan(s.mgr, true)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	chg, err := devicestate.CreateRecoverySystemWithMetadata(s.state, "1234", &devicestate.RecoverySystemMetadata{
+		Description: "before the upgrade",
+		Tags:        []string{"pre-upgrade", "manual"},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(chg, NotNil)
+	tsks := chg.Tasks()
+	c.Assert(tsks, HasLen, 2)
+	var systemSetupData map[string]interface{}
+	err = tsks[0].Get("recovery-system-setup", &systemSetupData)
+	c.Assert(err, IsNil)
+	c.Assert(systemSetupData, DeepEquals, map[string]interface{}{
+		"label":            "1234",
+		"directory":        filepath.Join(boot.InitramfsUbuntuSeedDir, "systems/1234"),
+		"snap-setup-tasks": nil,
+		"metadata": map[string]interface{}{
+			"description": "before the upgrade",
+			"tags":        []interface{}{"pre-upgrade", "manual"},
+		},
+	})
+}
+
+func (s *deviceMgrSystemsCreateSuite) TestDeviceManagerCreateRecoverySystemWithMetadataInvalid(c *C) {
+	devicestate.SetBootOkRan(s.mgr, true)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	for _, tc := range []struct {
+		meta *devicestate.RecoverySystemMetadata
+		err  string
+	}{
+		{&devicestate.RecoverySystemMetadata{Tags: []string{"Foo"}}, `invalid recovery system tag "Foo"`},
+		{&devicestate.RecoverySystemMetadata{Tags: []string{"foo-"}}, `invalid recovery system tag "foo-"`},
+		{&devicestate.RecoverySystemMetadata{Tags: []string{""}}, `invalid recovery system tag ""`},
+		{&devicestate.RecoverySystemMetadata{Description: "two\nlines"}, `recovery system description must be a single line`},
+		{&devicestate.RecoverySystemMetadata{Description: strings.Repeat("x", 257)}, `recovery system description cannot be longer than 256 characters`},
+	} {
+		chg, err := devicestate.CreateRecoverySystemWithMetadata(s.state, "1234", tc.meta)
+		c.Check(err, ErrorMatches, tc.err)
+		c.Check(chg, IsNil)
+	}
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
 func (s *deviceMgrSystemsCreateSuite) TestDeviceManagerCreateRecoverySystemTasksWhenDirExists(c *C) {
 	devicestate.SetBootOkRan(s.mgr, true)
 
@@ -2174,3 +2248,155 @@ func (s *systemSnapTrackingSuite) TestSnapFilePurgeWhenNoLog(c *C) {
 	err := devicestate.PurgeNewSystemSnapFiles(flog)
 	c.Assert(err, IsNil)
 }
+
+func (s *deviceMgrSystemsCreateSuite) setRecoverySystemsPolicy(c *C, keep int, interval string) {
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "recovery-systems.keep", keep), IsNil)
+	if interval != "" {
+		c.Assert(tr.Set("core", "recovery-systems.interval", interval), IsNil)
+	}
+	tr.Commit()
+}
+
+func (s *deviceMgrSystemsCreateSuite) TestEnsureAutoRecoverySystemDisabled(c *C) {
+	s.state.Lock()
+	s.mockStandardSnapsModeenvAndBootloaderState(c)
+	s.state.Unlock()
+
+	c.Assert(devicestate.EnsureAutoRecoverySystem(s.mgr), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 0)
+	var auto map[string]interface{}
+	c.Check(s.state.Get("auto-recovery-systems", &auto), Equals, state.ErrNoState)
+}
+
+func (s *deviceMgrSystemsCreateSuite) TestEnsureAutoRecoverySystemAfterEssentialRefresh(c *C) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	restore := devicestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	var freeSpaceChecks []string
+	restore = devicestate.MockOsutilCheckFreeSpace(func(path string, minSize uint64) error {
+		freeSpaceChecks = append(freeSpaceChecks, path)
+		return nil
+	})
+	defer restore()
+
+	s.state.Lock()
+	s.mockStandardSnapsModeenvAndBootloaderState(c)
+	s.setRecoverySystemsPolicy(c, 2, "")
+	s.state.Unlock()
+
+	// the first run only records the current state of the device
+	c.Assert(devicestate.EnsureAutoRecoverySystem(s.mgr), IsNil)
+	s.state.Lock()
+	c.Check(s.state.Changes(), HasLen, 0)
+	// and then the kernel gets refreshed
+	s.makeSnapInState(c, "pc-kernel", snap.R(5))
+	s.state.Unlock()
+
+	c.Assert(devicestate.EnsureAutoRecoverySystem(s.mgr), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(freeSpaceChecks, DeepEquals, []string{boot.InitramfsUbuntuSeedDir})
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Kind(), Equals, "create-recovery-system")
+	c.Check(chgs[0].Summary(), Equals, `Create new recovery system with label "20210601"`)
+
+	var auto map[string]interface{}
+	c.Assert(s.state.Get("auto-recovery-systems", &auto), IsNil)
+	c.Check(auto["change-id"], Equals, chgs[0].ID())
+	c.Check(auto["label"], Equals, "20210601")
+
+	// nothing happens while the change is in progress
+	s.state.Unlock()
+	c.Assert(devicestate.EnsureAutoRecoverySystem(s.mgr), IsNil)
+	s.state.Lock()
+	c.Check(s.state.Changes(), HasLen, 1)
+}
+
+func (s *deviceMgrSystemsCreateSuite) TestEnsureAutoRecoverySystemNotEnoughSpace(c *C) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	restore := devicestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	restore = devicestate.MockOsutilCheckFreeSpace(func(path string, minSize uint64) error {
+		return &osutil.NotEnoughDiskSpaceError{Path: path, Delta: 1024}
+	})
+	defer restore()
+
+	s.state.Lock()
+	s.mockStandardSnapsModeenvAndBootloaderState(c)
+	s.setRecoverySystemsPolicy(c, 2, "24h")
+	s.state.Unlock()
+
+	c.Assert(devicestate.EnsureAutoRecoverySystem(s.mgr), IsNil)
+	// a day later a recovery system is due
+	now = now.Add(24 * time.Hour)
+	c.Assert(devicestate.EnsureAutoRecoverySystem(s.mgr), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 0)
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Matches, `cannot create recovery system automatically: .*`)
+	var auto map[string]interface{}
+	c.Assert(s.state.Get("auto-recovery-systems", &auto), IsNil)
+	c.Check(auto["last-attempt"], Equals, now.Format(time.RFC3339))
+}
+
+func (s *deviceMgrSystemsCreateSuite) TestEnsureAutoRecoverySystemPrunes(c *C) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	restore := devicestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	var dropped []string
+	restore = devicestate.MockBootDropRecoverySystem(func(dev boot.Device, label string) error {
+		dropped = append(dropped, label)
+		return nil
+	})
+	defer restore()
+
+	s.state.Lock()
+	s.mockStandardSnapsModeenvAndBootloaderState(c)
+	s.setRecoverySystemsPolicy(c, 2, "")
+	s.state.Unlock()
+
+	c.Assert(devicestate.EnsureAutoRecoverySystem(s.mgr), IsNil)
+
+	for _, label := range []string{"20210101", "20210301", "20210501"} {
+		c.Assert(os.MkdirAll(filepath.Join(boot.InitramfsUbuntuSeedDir, "systems", label), 0755), IsNil)
+	}
+
+	s.state.Lock()
+	// pretend the creation of a recovery system has completed
+	chg := s.state.NewChange("create-recovery-system", "...")
+	t := s.state.NewTask("create-recovery-system", "...")
+	chg.AddTask(t)
+	t.SetStatus(state.DoneStatus)
+	var auto map[string]interface{}
+	c.Assert(s.state.Get("auto-recovery-systems", &auto), IsNil)
+	auto["labels"] = []string{"20210101", "20210301"}
+	auto["change-id"] = chg.ID()
+	auto["label"] = "20210501"
+	s.state.Set("auto-recovery-systems", auto)
+	s.state.Unlock()
+
+	c.Assert(devicestate.EnsureAutoRecoverySystem(s.mgr), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(dropped, DeepEquals, []string{"20210101"})
+	c.Check(filepath.Join(boot.InitramfsUbuntuSeedDir, "systems/20210101"), testutil.FileAbsent)
+	c.Check(filepath.Join(boot.InitramfsUbuntuSeedDir, "systems/20210301"), testutil.FilePresent)
+	c.Check(filepath.Join(boot.InitramfsUbuntuSeedDir, "systems/20210501"), testutil.FilePresent)
+
+	auto = nil
+	c.Assert(s.state.Get("auto-recovery-systems", &auto), IsNil)
+	c.Check(auto["labels"], DeepEquals, []interface{}{"20210301", "20210501"})
+	c.Check(auto["change-id"], IsNil)
+	// no new recovery system was requested
+	c.Check(s.state.Changes(), HasLen, 1)
+}
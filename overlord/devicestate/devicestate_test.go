@@ -1023,6 +1023,97 @@ func makeMockRepoWithConnectedSnaps(c *C, st *state.State, info11, core11 *snap.
 	ifacerepo.Replace(st, repo)
 }
 
+func (s *deviceMgrSuite) addDeviceGroup(c *C, group string, serials []interface{}, extra map[string]interface{}) {
+	headers := map[string]interface{}{
+		"brand-id":  "canonical",
+		"model":     "pc-model",
+		"group":     group,
+		"serials":   serials,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	for k, v := range extra {
+		headers[k] = v
+	}
+	a, err := s.brands.Signing("canonical").Sign(asserts.DeviceGroupType, headers, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(assertstate.Add(s.state, a), IsNil)
+}
+
+func (s *deviceMgrSuite) TestDeviceGroups(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// no serial yet
+	groups, err := devicestate.DeviceGroups(s.state)
+	c.Assert(err, IsNil)
+	c.Check(groups, HasLen, 0)
+
+	s.makeModelAssertionInState(c, "canonical", "pc-model", map[string]interface{}{
+		"architecture": "amd64",
+		"kernel":       "pc-kernel",
+		"gadget":       "pc",
+	})
+	s.makeSerialAssertionInState(c, "canonical", "pc-model", "1234")
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand:  "canonical",
+		Model:  "pc-model",
+		Serial: "1234",
+	})
+
+	// no groups
+	groups, err = devicestate.DeviceGroups(s.state)
+	c.Assert(err, IsNil)
+	c.Check(groups, HasLen, 0)
+
+	s.addDeviceGroup(c, "fleet-b", []interface{}{"1234"}, map[string]interface{}{
+		"refresh-schedule": "mon,10:00-12:00",
+	})
+	s.addDeviceGroup(c, "fleet-a", []interface{}{"0000", "1234"}, nil)
+	s.addDeviceGroup(c, "other", []interface{}{"5678"}, nil)
+
+	groups, err = devicestate.DeviceGroups(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(groups, HasLen, 2)
+	c.Check(groups[0].Group(), Equals, "fleet-a")
+	c.Check(groups[1].Group(), Equals, "fleet-b")
+}
+
+func (s *deviceMgrSuite) TestSnapPolicy(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// no model yet
+	policy, err := devicestate.SnapPolicy(s.state)
+	c.Assert(err, IsNil)
+	c.Check(policy, IsNil)
+
+	devicestatetest.SetDevice(s.state, &auth.DeviceState{
+		Brand: "canonical",
+		Model: "pc-model",
+	})
+
+	// no policy
+	policy, err = devicestate.SnapPolicy(s.state)
+	c.Assert(err, IsNil)
+	c.Check(policy, IsNil)
+
+	for _, model := range []string{"pc-model", "other-model"} {
+		a, err := s.brands.Signing("canonical").Sign(asserts.SnapPolicyType, map[string]interface{}{
+			"brand-id":        "canonical",
+			"model":           model,
+			"forbidden-snaps": []interface{}{"foo-" + model},
+			"timestamp":       time.Now().Format(time.RFC3339),
+		}, nil, "")
+		c.Assert(err, IsNil)
+		c.Assert(assertstate.Add(s.state, a), IsNil)
+	}
+
+	policy, err = devicestate.SnapPolicy(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(policy, NotNil)
+	c.Check(policy.ForbiddenSnaps(), DeepEquals, []string{"foo-pc-model"})
+}
+
 func (s *deviceMgrSuite) TestCanManageRefreshes(c *C) {
 	st := s.state
 	st.Lock()
@@ -30,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/gadget/install"
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/kernel/fde"
+	"github.com/snapcore/snapd/osutil/fswatch"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/overlord/storecontext"
@@ -141,6 +142,14 @@ func MockSnapstateInstallWithDeviceContext(f func(ctx context.Context, st *state
 	}
 }
 
+func MockSnapstateReplacementTasks(f func(st *state.State, oldName string, installTs *state.TaskSet) (prepare, finish *state.TaskSet, err error)) (restore func()) {
+	old := snapstateReplacementTasks
+	snapstateReplacementTasks = f
+	return func() {
+		snapstateReplacementTasks = old
+	}
+}
+
 func MockSnapstateUpdateWithDeviceContext(f func(st *state.State, name string, opts *snapstate.RevisionOptions, userID int, flags snapstate.Flags, deviceCtx snapstate.DeviceContext, fromChange string) (*state.TaskSet, error)) (restore func()) {
 	old := snapstateUpdateWithDeviceContext
 	snapstateUpdateWithDeviceContext = f
@@ -324,6 +333,22 @@ func MockRestrictCloudInit(f func(sysconfig.CloudInitState, *sysconfig.CloudInit
 	}
 }
 
+func MockFswatchNew(f func(delay time.Duration, notify func(paths []string)) (*fswatch.Watcher, error)) (restore func()) {
+	old := fswatchNew
+	fswatchNew = f
+	return func() {
+		fswatchNew = old
+	}
+}
+
+func DeviceManagerWatchesCloudInit(m *DeviceManager) bool {
+	return m.cloudInitWatcher != nil
+}
+
+func DeviceManagerStopWatchingCloudInit(m *DeviceManager) {
+	m.stopWatchingCloudInit()
+}
+
 func DeviceManagerHasFDESetupHook(mgr *DeviceManager) (bool, error) {
 	return mgr.hasFDESetupHook()
 }
@@ -339,3 +364,41 @@ func DeviceManagerCheckEncryption(mgr *DeviceManager, st *state.State, deviceCtx
 func DeviceManagerCheckFDEFeatures(mgr *DeviceManager, st *state.State) error {
 	return mgr.checkFDEFeatures(st)
 }
+
+func MockOsutilBootID(mockID string) (restore func()) {
+	old := osutilBootID
+	osutilBootID = func() (string, error) {
+		return mockID, nil
+	}
+	return func() {
+		osutilBootID = old
+	}
+}
+
+func EnsurePendingRemodel(m *DeviceManager) error {
+	return m.ensurePendingRemodel()
+}
+
+func MockOsutilCheckFreeSpace(f func(path string, minSize uint64) error) (restore func()) {
+	old := osutilCheckFreeSpace
+	osutilCheckFreeSpace = f
+	return func() {
+		osutilCheckFreeSpace = old
+	}
+}
+
+func MockBootDropRecoverySystem(f func(dev boot.Device, label string) error) (restore func()) {
+	old := bootDropRecoverySystem
+	bootDropRecoverySystem = f
+	return func() {
+		bootDropRecoverySystem = old
+	}
+}
+
+func EnsureAutoRecoverySystem(m *DeviceManager) error {
+	return m.ensureAutoRecoverySystem()
+}
+
+func EnsureDataStorageHealthy(m *DeviceManager) error {
+	return m.ensureDataStorageHealthy()
+}
@@ -270,6 +270,13 @@ func (m *DeviceManager) doSetupRunSystem(t *state.Task, _ *tomb.Tomb) error {
 		}
 	}
 
+	setInstallStage(t, InstallStagePartitioning)
+	bopts.Progress = func(stage install.Stage) {
+		st.Lock()
+		defer st.Unlock()
+		setInstallStage(t, string(stage))
+	}
+
 	var installedSystem *install.InstalledSystemSideData
 	// run the create partition code
 	logger.Noticef("create and deploy partitions")
@@ -320,6 +327,7 @@ func (m *DeviceManager) doSetupRunSystem(t *state.Task, _ *tomb.Tomb) error {
 	opts := &sysconfig.Options{TargetRootDir: boot.InstallHostWritableDir, GadgetDir: gadgetDir}
 	// configure cloud init
 	setSysconfigCloudOptions(opts, gadgetDir, model)
+	setInstallStage(t, InstallStageConfiguring)
 	timings.Run(perfTimings, "sysconfig-configure-target-system", "Configure target system", func(timings.Measurer) {
 		err = sysconfigConfigureTargetSystem(model, opts)
 	})
@@ -342,6 +350,7 @@ func (m *DeviceManager) doSetupRunSystem(t *state.Task, _ *tomb.Tomb) error {
 		RecoverySystemDir: recoverySystemDir,
 		UnpackedGadgetDir: gadgetDir,
 	}
+	setInstallStage(t, InstallStageMakingBootable)
 	timings.Run(perfTimings, "boot-make-runnable", "Make target system runnable", func(timings.Measurer) {
 		err = bootMakeRunnable(deviceCtx.Model(), bootWith, trustedInstallObserver)
 	})
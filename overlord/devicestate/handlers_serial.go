@@ -281,7 +281,7 @@ func retryErr(t *state.Task, nTentatives int, reason string, a ...interface{}) e
 		return fmt.Errorf(reason, a...)
 	}
 	t.Errorf(reason, a...)
-	return &state.Retry{After: retryInterval}
+	return &state.Retry{After: retryInterval, Reason: fmt.Sprintf(reason, a...)}
 }
 
 type serverError struct {
@@ -356,7 +356,7 @@ func prepareSerialRequest(t *state.Task, regCtx registrationContext, privKey ass
 			// as soon as the user configured the network of the
 			// device
 			noNetworkRetryInterval := retryInterval / 2
-			return "", &state.Retry{After: noNetworkRetryInterval}
+			return "", &state.Retry{After: noNetworkRetryInterval, Reason: noNetworkReason}
 		}
 
 		return "", retryErr(t, nTentatives, "cannot retrieve request-id for making a request for a serial: %v", err)
@@ -511,6 +511,13 @@ func getSerial(t *state.Task, regCtx registrationContext, privKey asserts.Privat
 		return nil, nil, err
 	}
 
+	// once a serial-request was accepted we keep polling the same
+	// device service
+	polling := serialSup.SerialRequest != ""
+	defer func() {
+		recordRegistrationAttempt(t, cfg, polling, err)
+	}()
+
 	// NB: until we get at least an Accepted (202) we need to
 	// retry from scratch creating a new request-id because the
 	// previous one used could have expired
@@ -572,6 +579,11 @@ type serialRequestConfig struct {
 	headers          map[string]string
 	proposedSerial   string
 	body             []byte
+
+	// vault is the index of the device service being used among
+	// the nVaults configured ones
+	vault   int
+	nVaults int
 }
 
 func (cfg *serialRequestConfig) applyHeaders(req *http.Request) {
@@ -608,14 +620,31 @@ func getSerialRequestConfig(t *state.Task, regCtx registrationContext, client *h
 		}
 
 		if svcURI != "" {
-			svcURL, err = url.Parse(svcURI)
+			svcURL, err = parseDeviceServiceURL(svcURI)
 			if err != nil {
-				return nil, fmt.Errorf("cannot parse device registration base URL %q: %v", svcURI, err)
+				return nil, err
 			}
-			if !strings.HasSuffix(svcURL.Path, "/") {
-				svcURL.Path += "/"
+		}
+
+		var fallbackURIs []string
+		err = tr.GetMaybe(gadgetName, "device-service.fallback-urls", &fallbackURIs)
+		if err != nil {
+			return nil, err
+		}
+		vaults := []*url.URL{svcURL}
+		for _, fallbackURI := range fallbackURIs {
+			fallbackURL, err := parseDeviceServiceURL(fallbackURI)
+			if err != nil {
+				return nil, err
 			}
+			vaults = append(vaults, fallbackURL)
 		}
+		if err := t.Get("serial-vault", &cfg.vault); err != nil && err != state.ErrNoState {
+			return nil, err
+		}
+		cfg.nVaults = len(vaults)
+		cfg.vault %= cfg.nVaults
+		svcURL = vaults[cfg.vault]
 
 		err = tr.GetMaybe(gadgetName, "device-service.headers", &cfg.headers)
 		if err != nil {
@@ -646,6 +675,82 @@ func getSerialRequestConfig(t *state.Task, regCtx registrationContext, client *h
 	return &cfg, nil
 }
 
+func parseDeviceServiceURL(svcURI string) (*url.URL, error) {
+	svcURL, err := url.Parse(svcURI)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse device registration base URL %q: %v", svcURI, err)
+	}
+	if !strings.HasSuffix(svcURL.Path, "/") {
+		svcURL.Path += "/"
+	}
+	return svcURL, nil
+}
+
+const (
+	noNetworkReason = "no network"
+
+	// maxRegistrationAttempts is the number of registration attempts
+	// kept in the history
+	maxRegistrationAttempts = 20
+)
+
+// RegistrationAttempt describes an attempt at getting a serial from a
+// device service.
+type RegistrationAttempt struct {
+	Time time.Time `json:"time"`
+	URL  string    `json:"url"`
+	// Outcome is one of "serial", "poll", "retry" or "error".
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// recordRegistrationAttempt adds the outcome of an attempt at getting a
+// serial to the registration attempts history. On retriable failures of
+// a device service, unless a serial-request was already accepted by it
+// or the device has no network, the next configured device service is
+// tried next.
+func recordRegistrationAttempt(t *state.Task, cfg *serialRequestConfig, polling bool, err error) {
+	st := t.State()
+
+	attempt := RegistrationAttempt{
+		Time: timeNow(),
+		URL:  cfg.serialRequestURL,
+	}
+	nextVault := false
+	switch e := err.(type) {
+	case nil:
+		attempt.Outcome = "serial"
+	case *state.Retry:
+		attempt.Outcome = "retry"
+		attempt.Error = e.Reason
+		nextVault = !polling && e.Reason != noNetworkReason
+	default:
+		if err == errPoll {
+			attempt.Outcome = "poll"
+		} else {
+			attempt.Outcome = "error"
+			attempt.Error = err.Error()
+		}
+	}
+
+	var attempts []RegistrationAttempt
+	if err := st.Get("registration-attempts", &attempts); err != nil && err != state.ErrNoState {
+		logger.Noticef("cannot get device registration attempts: %v", err)
+		return
+	}
+	attempts = append(attempts, attempt)
+	if len(attempts) > maxRegistrationAttempts {
+		attempts = attempts[len(attempts)-maxRegistrationAttempts:]
+	}
+	st.Set("registration-attempts", attempts)
+
+	if nextVault && cfg.nVaults > 1 {
+		next := (cfg.vault + 1) % cfg.nVaults
+		logger.Noticef("Device service at %s failed, trying the next one (%d of %d)", cfg.serialRequestURL, next+1, cfg.nVaults)
+		t.Set("serial-vault", next)
+	}
+}
+
 func (m *DeviceManager) doRequestSerial(t *state.Task, _ *tomb.Tomb) error {
 	st := t.State()
 	st.Lock()
@@ -253,6 +253,11 @@ func (m *DeviceManager) doCreateRecoverySystem(t *state.Task, _ *tomb.Tomb) (err
 		return fmt.Errorf("cannot create a recovery system with label %q for %v: %v", label, model.Model(), err)
 	}
 	logger.Debugf("recovery system dir: %v", systemDirectory)
+	if setup.Metadata != nil {
+		if err := writeRecoverySystemMetadata(systemDirectory, setup.Metadata); err != nil {
+			return fmt.Errorf("cannot write metadata of recovery system %q: %v", label, err)
+		}
+	}
 
 	// 2. keep track of the system in task state
 	if err := setTaskRecoverySystemSetup(t, setup); err != nil {
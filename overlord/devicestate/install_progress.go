@@ -0,0 +1,164 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// Stages of installing a system reported by InstallProgress, in order.
+const (
+	InstallStageSeeding         = "seeding"
+	InstallStagePartitioning    = "partitioning"
+	InstallStageEncryptionSetup = "encryption-setup"
+	InstallStageCopying         = "copying"
+	InstallStageConfiguring     = "configuring"
+	InstallStageMakingBootable  = "making-bootable"
+	// InstallStageDone is reported once the system is installed and
+	// the device is about to reboot into run mode.
+	InstallStageDone = "done"
+)
+
+var installStages = []string{
+	InstallStageSeeding,
+	InstallStagePartitioning,
+	InstallStageEncryptionSetup,
+	InstallStageCopying,
+	InstallStageConfiguring,
+	InstallStageMakingBootable,
+}
+
+// InstallProgress describes the progress of installing the system in
+// install mode, so that installers can render it.
+type InstallProgress struct {
+	// Stage is the current stage of the installation.
+	Stage string `json:"stage"`
+	// Done and Total are the number of completed stages and the total
+	// number of stages of the installation.
+	Done  int `json:"done"`
+	Total int `json:"total"`
+	// StageDone and StageTotal describe the progress within the
+	// current stage, when known.
+	StageDone  int `json:"stage-done,omitempty"`
+	StageTotal int `json:"stage-total,omitempty"`
+	// ChangeID is the ID of the change carrying out the current stage.
+	ChangeID string `json:"change-id,omitempty"`
+	// Error is set if the installation failed.
+	Error string `json:"error,omitempty"`
+}
+
+// setInstallStage records the current stage of the installation carried
+// out by the given setup-run-system task.
+func setInstallStage(t *state.Task, stage string) {
+	t.Set("install-stage", stage)
+	for i, s := range installStages {
+		if s == stage {
+			t.SetProgress(stage, i, len(installStages))
+			break
+		}
+	}
+}
+
+func lastChangeOfKind(st *state.State, kind string) *state.Change {
+	var last *state.Change
+	for _, chg := range st.Changes() {
+		if chg.Kind() != kind {
+			continue
+		}
+		if last == nil || chg.SpawnTime().After(last.SpawnTime()) {
+			last = chg
+		}
+	}
+	return last
+}
+
+// InstallProgress returns the progress of installing the system, it
+// can only be used in install mode.
+func (m *DeviceManager) InstallProgress() (*InstallProgress, error) {
+	if mode := m.SystemMode(SysAny); mode != "install" {
+		return nil, fmt.Errorf("cannot report install progress in %q mode", mode)
+	}
+
+	progress := &InstallProgress{
+		Stage: InstallStageSeeding,
+		Total: len(installStages),
+	}
+	setProgress := func(stage string) {
+		progress.Stage = stage
+		for i, s := range installStages {
+			if s == stage {
+				progress.Done = i
+				return
+			}
+		}
+		progress.Done = progress.Total
+	}
+
+	var seeded bool
+	if err := m.state.Get("seeded", &seeded); err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	if !seeded {
+		if chg := lastChangeOfKind(m.state, "seed"); chg != nil {
+			progress.ChangeID = chg.ID()
+			for _, t := range chg.Tasks() {
+				if t.Status().Ready() {
+					progress.StageDone++
+				}
+				progress.StageTotal++
+			}
+			if err := chg.Err(); err != nil {
+				progress.Error = err.Error()
+			}
+		}
+		return progress, nil
+	}
+
+	chg := lastChangeOfKind(m.state, "install-system")
+	if chg == nil {
+		// seeded but the installation has not started yet
+		setProgress(InstallStagePartitioning)
+		return progress, nil
+	}
+	progress.ChangeID = chg.ID()
+	if err := chg.Err(); err != nil {
+		progress.Error = err.Error()
+	}
+
+	stage := InstallStagePartitioning
+	for _, t := range chg.Tasks() {
+		if t.Kind() != "setup-run-system" {
+			continue
+		}
+		if err := t.Get("install-stage", &stage); err != nil && err != state.ErrNoState {
+			return nil, err
+		}
+		if t.Status() == state.DoneStatus {
+			stage = InstallStageMakingBootable
+		}
+	}
+	if chg.Status() == state.DoneStatus {
+		stage = InstallStageDone
+	}
+	setProgress(stage)
+	return progress, nil
+}
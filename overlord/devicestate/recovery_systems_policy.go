@@ -0,0 +1,335 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
+)
+
+var (
+	osutilCheckFreeSpace   = osutil.CheckFreeSpace
+	bootDropRecoverySystem = boot.DropRecoverySystem
+)
+
+// retry interval after a recovery system could not be created
+// automatically, e.g. due to lack of disk space
+const autoRecoverySystemRetryInterval = 6 * time.Hour
+
+// autoRecoverySystems tracks the recovery systems managed by the
+// recovery-systems.keep policy.
+type autoRecoverySystems struct {
+	// Labels of the managed recovery systems, oldest first.
+	Labels []string `json:"labels,omitempty"`
+	// LastCreated is the time the newest managed recovery system was
+	// created.
+	LastCreated time.Time `json:"last-created"`
+	// LastAttempt is the time of the last failed attempt at creating
+	// a recovery system.
+	LastAttempt time.Time `json:"last-attempt,omitempty"`
+	// Essential holds the model and the revisions of the essential
+	// snaps the newest managed recovery system was created with.
+	Essential map[string]string `json:"essential,omitempty"`
+	// ChangeID is the ID of the change creating a recovery system, if
+	// any is in progress.
+	ChangeID string `json:"change-id,omitempty"`
+	// Label of the recovery system being created.
+	Label string `json:"label,omitempty"`
+}
+
+// recoverySystemsPolicy returns the number of recovery systems to keep
+// and the interval at which new ones are created, as set with
+// recovery-systems.{keep,interval}. A keep value of 0 means that the
+// policy is disabled, an interval of 0 that recovery systems are only
+// created after a remodel or a refresh of essential snaps.
+func recoverySystemsPolicy(st *state.State) (keep int, interval time.Duration, err error) {
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "recovery-systems.keep", &keep); err != nil && !config.IsNoOption(err) {
+		return 0, 0, err
+	}
+	var intervalStr string
+	if err := tr.Get("core", "recovery-systems.interval", &intervalStr); err != nil && !config.IsNoOption(err) {
+		return 0, 0, err
+	}
+	if intervalStr != "" {
+		interval, err = time.ParseDuration(intervalStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("cannot parse recovery-systems.interval: %v", err)
+		}
+	}
+	return keep, interval, nil
+}
+
+// essentialRevisions returns the model and the revisions of the
+// essential snaps currently installed, keyed by snap name.
+func essentialRevisions(st *state.State, model *asserts.Model) (map[string]string, error) {
+	essential := map[string]string{
+		"model": fmt.Sprintf("%s/%s/%d", model.BrandID(), model.Model(), model.Revision()),
+	}
+	for _, sn := range model.EssentialSnaps() {
+		var snapst snapstate.SnapState
+		if err := snapstate.Get(st, sn.SnapName(), &snapst); err != nil {
+			if err == state.ErrNoState {
+				continue
+			}
+			return nil, err
+		}
+		essential[sn.SnapName()] = snapst.Current.String()
+	}
+	return essential, nil
+}
+
+func sameEssentialRevisions(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// recoverySystemRequiredSpace returns an estimate of the disk space
+// needed on ubuntu-seed to create a recovery system, that is the size
+// of the essential snaps not already present in the seed.
+func recoverySystemRequiredSpace(st *state.State, model *asserts.Model) (uint64, error) {
+	var required uint64
+	for _, sn := range model.EssentialSnaps() {
+		info, err := snapstate.CurrentInfo(st, sn.SnapName())
+		if err != nil {
+			if _, ok := err.(*snap.NotInstalledError); ok {
+				continue
+			}
+			return 0, err
+		}
+		seedFile := filepath.Join(boot.InitramfsUbuntuSeedDir, "snaps", filepath.Base(info.MountFile()))
+		if osutil.FileExists(seedFile) {
+			continue
+		}
+		fi, err := os.Stat(info.MountFile())
+		if err != nil {
+			return 0, err
+		}
+		required += uint64(fi.Size())
+	}
+	return required, nil
+}
+
+// remodelRecoverySystemLabel returns the label of the recovery system
+// created by the most recent remodel that completed after the given
+// time, if any.
+func remodelRecoverySystemLabel(st *state.State, after time.Time) string {
+	var label string
+	var newest time.Time
+	for _, chg := range st.Changes() {
+		if chg.Kind() != "remodel" || chg.Status() != state.DoneStatus {
+			continue
+		}
+		if !chg.ReadyTime().After(after) || chg.ReadyTime().Before(newest) {
+			continue
+		}
+		for _, t := range chg.Tasks() {
+			if t.Kind() != "create-recovery-system" {
+				continue
+			}
+			setup, err := taskRecoverySystemSetup(t)
+			if err != nil {
+				continue
+			}
+			label = setup.Label
+			newest = chg.ReadyTime()
+		}
+	}
+	return label
+}
+
+// ensureAutoRecoverySystem implements the recovery-systems.keep policy,
+// a recovery system is created periodically and after a remodel or
+// a refresh of essential snaps, while the oldest managed recovery
+// systems beyond the number to keep are removed.
+func (m *DeviceManager) ensureAutoRecoverySystem() error {
+	if release.OnClassic {
+		return nil
+	}
+	if m.SystemMode(SysHasModeenv) != "run" {
+		return nil
+	}
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	var seeded bool
+	if err := m.state.Get("seeded", &seeded); err != nil && err != state.ErrNoState {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+
+	keep, interval, err := recoverySystemsPolicy(m.state)
+	if err != nil {
+		return err
+	}
+	if keep == 0 {
+		return nil
+	}
+
+	deviceCtx, err := DeviceCtx(m.state, nil, nil)
+	if err == state.ErrNoState {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	model := deviceCtx.Model()
+	if model.Grade() == asserts.ModelGradeUnset {
+		return nil
+	}
+
+	var auto autoRecoverySystems
+	if err := m.state.Get("auto-recovery-systems", &auto); err != nil && err != state.ErrNoState {
+		return err
+	}
+	defer m.state.Set("auto-recovery-systems", &auto)
+
+	essential, err := essentialRevisions(m.state, model)
+	if err != nil {
+		return err
+	}
+	now := timeNow()
+
+	if auto.ChangeID != "" {
+		chg := m.state.Change(auto.ChangeID)
+		if chg != nil && !chg.Status().Ready() {
+			// wait for the recovery system to be created
+			return nil
+		}
+		if chg != nil && chg.Status() == state.DoneStatus {
+			auto.Labels = append(auto.Labels, auto.Label)
+			auto.LastCreated = now
+			auto.Essential = essential
+		} else {
+			auto.LastAttempt = now
+		}
+		auto.ChangeID = ""
+		auto.Label = ""
+	}
+
+	if auto.Essential == nil {
+		// start tracking from the current state of the device
+		auto.Essential = essential
+		auto.LastCreated = now
+	}
+
+	if !sameEssentialRevisions(auto.Essential, essential) {
+		if label := remodelRecoverySystemLabel(m.state, auto.LastCreated); label != "" {
+			// a recovery system was created by the remodel already
+			auto.Labels = append(auto.Labels, label)
+			auto.LastCreated = now
+			auto.Essential = essential
+		}
+	}
+
+	if err := m.pruneAutoRecoverySystems(deviceCtx, &auto, keep); err != nil {
+		return err
+	}
+
+	due := !sameEssentialRevisions(auto.Essential, essential)
+	if interval > 0 && now.Sub(auto.LastCreated) >= interval {
+		due = true
+	}
+	if !due || now.Sub(auto.LastAttempt) < autoRecoverySystemRetryInterval {
+		return nil
+	}
+	if err := snapstate.CheckChangeConflictRunExclusively(m.state, "create-recovery-system"); err != nil {
+		// try again once the other changes are done
+		return nil
+	}
+
+	required, err := recoverySystemRequiredSpace(m.state, model)
+	if err != nil {
+		return err
+	}
+	if err := osutilCheckFreeSpace(boot.InitramfsUbuntuSeedDir, required); err != nil {
+		auto.LastAttempt = now
+		m.state.Warnf("cannot create recovery system automatically: %v", err)
+		return nil
+	}
+
+	labelBase := now.Format("20060102")
+	label, err := pickRecoverySystemLabel(labelBase)
+	if err != nil {
+		return fmt.Errorf("cannot select non-conflicting label for recovery system %q: %v", labelBase, err)
+	}
+	chg, err := CreateRecoverySystem(m.state, label)
+	if err != nil {
+		auto.LastAttempt = now
+		return err
+	}
+	logger.Noticef("Creating recovery system %q automatically in change %s", label, chg.ID())
+	auto.ChangeID = chg.ID()
+	auto.Label = label
+	m.state.EnsureBefore(0)
+	return nil
+}
+
+// pruneAutoRecoverySystems removes the oldest managed recovery systems
+// beyond the number to keep.
+func (m *DeviceManager) pruneAutoRecoverySystems(deviceCtx snapstate.DeviceContext, auto *autoRecoverySystems, keep int) error {
+	if len(auto.Labels) <= keep {
+		return nil
+	}
+	if auto.ChangeID != "" {
+		return nil
+	}
+	if err := snapstate.CheckChangeConflictRunExclusively(m.state, "create-recovery-system"); err != nil {
+		// try again once the other changes are done
+		return nil
+	}
+	prune := auto.Labels[:len(auto.Labels)-keep]
+	for len(prune) > 0 {
+		label := prune[0]
+		if err := bootDropRecoverySystem(deviceCtx, label); err != nil {
+			return fmt.Errorf("cannot drop recovery system %q: %v", label, err)
+		}
+		// TODO: remove the seed snaps no longer used by any system
+		if err := os.RemoveAll(filepath.Join(boot.InitramfsUbuntuSeedDir, "systems", label)); err != nil {
+			return fmt.Errorf("cannot remove recovery system %q: %v", label, err)
+		}
+		logger.Noticef("Removed recovery system %q", label)
+		prune = prune[1:]
+		auto.Labels = auto.Labels[1:]
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/naming"
+)
+
+// RemodelPlan describes what remodeling the device to a new model would do.
+type RemodelPlan struct {
+	// Kind is the kind of remodel, as described by RemodelKind.
+	Kind string `json:"kind"`
+	// Install lists the snaps that would be installed.
+	Install []RemodelPlanSnap `json:"install,omitempty"`
+	// Switch lists the installed snaps that would be switched to a new
+	// channel, or become the kernel or base of the device.
+	Switch []RemodelPlanSnap `json:"switch,omitempty"`
+	// Remove lists the snaps that would be removed once replaced.
+	Remove []string `json:"remove,omitempty"`
+	// NoLongerRequired lists the snaps that the new model no longer
+	// requires, they are kept installed but can then be removed.
+	NoLongerRequired []string `json:"no-longer-required,omitempty"`
+	// Assertions lists the assertions the remodel needs.
+	Assertions []string `json:"assertions"`
+	// RecoverySystem is set if a new recovery system would be created.
+	RecoverySystem bool `json:"recovery-system,omitempty"`
+	// Reboot is set if the device is expected to reboot.
+	Reboot bool `json:"reboot"`
+	// Reseal is set if the disk encryption keys would be resealed.
+	Reseal bool `json:"reseal"`
+}
+
+// RemodelPlanSnap describes a snap of a remodel plan.
+type RemodelPlanSnap struct {
+	Name     string `json:"name"`
+	Channel  string `json:"channel,omitempty"`
+	Replaces string `json:"replaces,omitempty"`
+}
+
+// PlanRemodel computes what remodeling the device to the new model would
+// do, without starting a change, so that the remodel can be reviewed
+// before being executed. It mirrors the tasks that Remodel would create.
+func PlanRemodel(st *state.State, new *asserts.Model) (*RemodelPlan, error) {
+	current, err := checkRemodel(st, new)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := ClassifyRemodel(current, new)
+	plan := &RemodelPlan{
+		Kind:       kind.String(),
+		Assertions: []string{fmt.Sprintf("model %s/%s (%d)", new.BrandID(), new.Model(), new.Revision())},
+	}
+	if kind == ReregRemodel {
+		plan.Assertions = append(plan.Assertions, fmt.Sprintf("serial for %s/%s", new.BrandID(), new.Model()))
+	}
+
+	install := func(name, channel, replaces string) {
+		plan.Install = append(plan.Install, RemodelPlanSnap{Name: name, Channel: channel, Replaces: replaces})
+		plan.Assertions = append(plan.Assertions,
+			fmt.Sprintf("snap-declaration of %s", name),
+			fmt.Sprintf("snap-revision of %s", name))
+	}
+	installOrSwitch := func(name, channel string) error {
+		needsInstall, err := notInstalled(st, name)
+		if err != nil {
+			return err
+		}
+		if needsInstall {
+			install(name, channel, "")
+		} else {
+			plan.Switch = append(plan.Switch, RemodelPlanSnap{Name: name})
+		}
+		return nil
+	}
+
+	// kernel
+	if current.Kernel() == new.Kernel() && current.KernelTrack() != new.KernelTrack() {
+		plan.Switch = append(plan.Switch, RemodelPlanSnap{Name: new.Kernel(), Channel: new.KernelTrack()})
+		plan.Reboot = true
+	}
+	if current.Kernel() != new.Kernel() {
+		if err := installOrSwitch(new.Kernel(), new.KernelTrack()); err != nil {
+			return nil, err
+		}
+		plan.Reboot = true
+	}
+	// base
+	if current.Base() != new.Base() {
+		if err := installOrSwitch(new.Base(), ""); err != nil {
+			return nil, err
+		}
+		plan.Reboot = true
+	}
+	// gadget
+	if current.Gadget() == new.Gadget() && current.GadgetTrack() != new.GadgetTrack() {
+		plan.Switch = append(plan.Switch, RemodelPlanSnap{Name: new.Gadget(), Channel: new.GadgetTrack()})
+	}
+	if current.Gadget() != new.Gadget() {
+		install(new.Gadget(), new.GadgetTrack(), "")
+	}
+
+	// replacements, see remodelTasks
+	replacements := make(map[string]string)
+	for _, modelSnap := range new.SnapsWithoutEssential() {
+		if modelSnap.Replaces == "" {
+			continue
+		}
+		replacedNotInstalled, err := notInstalled(st, modelSnap.Replaces)
+		if err != nil {
+			return nil, err
+		}
+		needsInstall, err := notInstalled(st, modelSnap.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !replacedNotInstalled && needsInstall {
+			replacements[modelSnap.Name] = modelSnap.Replaces
+		}
+	}
+	for _, snapRef := range new.RequiredNoEssentialSnaps() {
+		needsInstall, err := notInstalled(st, snapRef.SnapName())
+		if err != nil {
+			return nil, err
+		}
+		if needsInstall {
+			install(snapRef.SnapName(), "", replacements[snapRef.SnapName()])
+		}
+	}
+	for _, modelSnap := range new.SnapsWithoutEssential() {
+		if modelSnap.Presence != "optional" || replacements[modelSnap.Name] == "" {
+			continue
+		}
+		install(modelSnap.Name, "", replacements[modelSnap.Name])
+	}
+	for _, inst := range plan.Install {
+		if inst.Replaces != "" {
+			plan.Remove = append(plan.Remove, inst.Replaces)
+		}
+	}
+
+	// snaps that set-model will unmark as required
+	requiredSnaps := getAllRequiredSnapsForModel(new)
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return nil, err
+	}
+	for snapName, snapst := range snapStates {
+		typ, err := snapst.Type()
+		if err != nil {
+			return nil, err
+		}
+		if typ != snap.TypeApp && typ != snap.TypeBase && typ != snap.TypeKernel {
+			continue
+		}
+		if snapst.Flags.Required && !requiredSnaps.Contains(naming.Snap(snapName)) {
+			plan.NoLongerRequired = append(plan.NoLongerRequired, snapName)
+		}
+	}
+	sort.Strings(plan.NoLongerRequired)
+
+	if new.Grade() != asserts.ModelGradeUnset {
+		// a new recovery system is created and tried, which also
+		// reseals the keys of encrypted devices
+		plan.RecoverySystem = true
+		plan.Reboot = true
+		plan.Reseal = osutil.FileExists(filepath.Join(dirs.SnapFDEDir, "sealed-keys"))
+	}
+
+	return plan, nil
+}
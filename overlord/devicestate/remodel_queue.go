@@ -0,0 +1,133 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var osutilBootID = osutil.BootID
+
+type pendingRemodel struct {
+	// Model is the encoded new model assertion.
+	Model    string    `json:"model"`
+	QueuedAt time.Time `json:"queued-at"`
+	BootID   string    `json:"boot-id"`
+}
+
+// QueueRemodel checks that the device can be remodeled to the new model
+// and records the remodel to be started at the next maintenance window,
+// that is after the next auto-refresh attempt, or on the next boot,
+// whichever comes first. A previously queued remodel is replaced.
+func QueueRemodel(st *state.State, new *asserts.Model) error {
+	if _, err := checkRemodel(st, new); err != nil {
+		return err
+	}
+	bootID, err := osutilBootID()
+	if err != nil {
+		return fmt.Errorf("cannot queue remodel: %v", err)
+	}
+	st.Set("pending-remodel", &pendingRemodel{
+		Model:    string(asserts.Encode(new)),
+		QueuedAt: timeNow(),
+		BootID:   bootID,
+	})
+	return nil
+}
+
+// PendingRemodel returns the model the device is queued to be
+// remodeled to, or state.ErrNoState if there is none.
+func PendingRemodel(st *state.State) (*asserts.Model, error) {
+	var pending pendingRemodel
+	if err := st.Get("pending-remodel", &pending); err != nil {
+		return nil, err
+	}
+	return pending.model()
+}
+
+func (p *pendingRemodel) model() (*asserts.Model, error) {
+	a, err := asserts.Decode([]byte(p.Model))
+	if err != nil {
+		return nil, fmt.Errorf("internal error: cannot decode queued model: %v", err)
+	}
+	model, ok := a.(*asserts.Model)
+	if !ok {
+		return nil, fmt.Errorf("internal error: queued model is a %q assertion", a.Type().Name)
+	}
+	return model, nil
+}
+
+// ensurePendingRemodel starts a queued remodel once the device rebooted
+// or auto-refresh ran since it was queued.
+func (m *DeviceManager) ensurePendingRemodel() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	var pending pendingRemodel
+	err := m.state.Get("pending-remodel", &pending)
+	if err == state.ErrNoState {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	due := false
+	if bootID, err := osutilBootID(); err == nil && bootID != pending.BootID {
+		due = true
+	}
+	var lastRefresh time.Time
+	if err := m.state.Get("last-refresh", &lastRefresh); err != nil && err != state.ErrNoState {
+		return err
+	}
+	if lastRefresh.After(pending.QueuedAt) {
+		due = true
+	}
+	if !due {
+		return nil
+	}
+
+	new, err := pending.model()
+	if err != nil {
+		m.state.Set("pending-remodel", nil)
+		return err
+	}
+	chg, err := Remodel(m.state, new)
+	if _, ok := err.(*snapstate.ChangeConflictError); ok {
+		// try again once the conflicting changes are done
+		logger.Debugf("cannot start queued remodel yet: %v", err)
+		return nil
+	}
+	m.state.Set("pending-remodel", nil)
+	if err != nil {
+		m.state.Warnf("cannot start queued remodel to %s/%s (%d): %v", new.BrandID(), new.Model(), new.Revision(), err)
+		return nil
+	}
+	logger.Noticef("Started queued remodel to %s/%s (%d) in change %s", new.BrandID(), new.Model(), new.Revision(), chg.ID())
+	m.state.EnsureBefore(0)
+	return nil
+}
@@ -0,0 +1,176 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snapfile"
+)
+
+// InstallSeedSupplement returns the task sets to install the snaps of a
+// seed supplement on an already seeded device. A seed supplement is a
+// directory with an "assertions" and a "snaps" subdirectory, like a
+// seed without a model. Its assertions are added to the system
+// assertion database and every snap must be fully asserted by them or
+// by assertions already known, snaps are then installed the same way
+// they would be during seeding without contacting the store. Snaps that
+// are already installed are skipped, but it is an error if nothing is
+// left to install. The names of the snaps to be installed are returned
+// as well.
+func InstallSeedSupplement(st *state.State, dir string) ([]string, []*state.TaskSet, error) {
+	var seeded bool
+	err := st.Get("seeded", &seeded)
+	if err != nil && err != state.ErrNoState {
+		return nil, nil, err
+	}
+	if !seeded {
+		return nil, nil, fmt.Errorf("cannot install seed supplement until the system is seeded")
+	}
+
+	model, err := findModel(st)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := addSeedSupplementAssertions(st, filepath.Join(dir, "assertions")); err != nil {
+		return nil, nil, err
+	}
+
+	snapsDir := filepath.Join(dir, "snaps")
+	snapPaths, err := filepath.Glob(filepath.Join(snapsDir, "*.snap"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(snapPaths) == 0 {
+		return nil, nil, fmt.Errorf("cannot install seed supplement: no snaps in %q", snapsDir)
+	}
+	sort.Strings(snapPaths)
+
+	installed, err := snapstate.All(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	// all the snaps the new ones can rely on for their bases and
+	// default providers
+	available := make([]*snap.Info, 0, len(installed)+len(snapPaths))
+	for _, snapst := range installed {
+		info, err := snapst.CurrentInfo()
+		if err != nil {
+			return nil, nil, err
+		}
+		available = append(available, info)
+	}
+
+	db := assertstate.DB(st)
+	var infos []*snap.Info
+	paths := make(map[*snap.Info]string, len(snapPaths))
+	for _, path := range snapPaths {
+		si, err := snapasserts.DeriveSideInfo(path, db)
+		if err != nil {
+			if asserts.IsNotFound(err) {
+				return nil, nil, fmt.Errorf("cannot install seed supplement: cannot find signatures with metadata for snap %q", filepath.Base(path))
+			}
+			return nil, nil, fmt.Errorf("cannot install seed supplement: %v", err)
+		}
+		if _, ok := installed[si.RealName]; ok {
+			continue
+		}
+		snapf, err := snapfile.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		info, err := snap.ReadInfoFromSnapFile(snapf, si)
+		if err != nil {
+			return nil, nil, err
+		}
+		infos = append(infos, info)
+		paths[info] = path
+	}
+	if len(infos) == 0 {
+		return nil, nil, fmt.Errorf("cannot install seed supplement: all its snaps are already installed")
+	}
+
+	if errs := snap.ValidateBasesAndProviders(append(available, infos...)); errs != nil {
+		// only report the first error encountered
+		return nil, nil, fmt.Errorf("cannot install seed supplement: %v", errs[0])
+	}
+
+	// install in the same order seeding would
+	sort.Stable(snap.ByType(infos))
+	names := make([]string, 0, len(infos))
+	tss := make([]*state.TaskSet, 0, len(infos))
+	for _, info := range infos {
+		flags := snapstate.Flags{
+			Classic: info.NeedsClassic(),
+			// as with seeding, for dangerous models allow all
+			// devmode snaps
+			ApplySnapDevMode: model.Grade() == asserts.ModelDangerous,
+		}
+		ts, _, err := snapstate.InstallPath(st, &info.SideInfo, paths[info], "", "", flags)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n := len(tss); n > 0 {
+			ts.WaitAll(tss[n-1])
+		}
+		tss = append(tss, ts)
+		names = append(names, info.InstanceName())
+	}
+	return names, tss, nil
+}
+
+func addSeedSupplementAssertions(st *state.State, assertsDir string) error {
+	dc, err := ioutil.ReadDir(assertsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("cannot install seed supplement: no assertions in %q", filepath.Dir(assertsDir))
+		}
+		return fmt.Errorf("cannot read seed supplement assertions: %v", err)
+	}
+
+	batch := asserts.NewBatch(nil)
+	for _, fi := range dc {
+		f, err := os.Open(filepath.Join(assertsDir, fi.Name()))
+		if err != nil {
+			return fmt.Errorf("cannot read seed supplement assertions: %v", err)
+		}
+		_, err = batch.AddStream(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("cannot read seed supplement assertions: %v", err)
+		}
+	}
+
+	if err := assertstate.AddBatch(st, batch, &asserts.CommitOptions{Precheck: true}); err != nil {
+		return fmt.Errorf("cannot add seed supplement assertions: %v", err)
+	}
+	return nil
+}
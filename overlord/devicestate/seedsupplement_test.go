@@ -0,0 +1,202 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/seed/seedtest"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type seedSupplementSuite struct {
+	deviceMgrBaseSuite
+
+	dir string
+}
+
+var _ = Suite(&seedSupplementSuite{})
+
+func (s *seedSupplementSuite) SetUpTest(c *C) {
+	s.deviceMgrBaseSuite.SetUpTest(c)
+
+	s.dir = c.MkDir()
+	c.Assert(os.MkdirAll(filepath.Join(s.dir, "assertions"), 0755), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(s.dir, "snaps"), 0755), IsNil)
+
+	s.setPCModelInState(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", true)
+
+	si := &snap.SideInfo{RealName: "core", SnapID: "core-id", Revision: snap.R(1)}
+	snaptest.MockSnap(c, "name: core\nversion: 1\ntype: os", si)
+	snapstate.Set(s.state, "core", &snapstate.SnapState{
+		SnapType: "os",
+		Active:   true,
+		Sequence: []*snap.SideInfo{si},
+		Current:  si.Revision,
+	})
+}
+
+// addSupplementSnap puts a snap and its assertions into the supplement,
+// optionally leaving out the snap-revision.
+func (s *seedSupplementSuite) addSupplementSnap(c *C, snapYaml, snapID string, withRevision bool) {
+	info := snaptest.MockInfo(c, snapYaml, nil)
+	src := snaptest.MakeTestSnapWithFiles(c, snapYaml, nil)
+	dst := filepath.Join(s.dir, "snaps", fmt.Sprintf("%s_1.snap", info.SnapName()))
+	c.Assert(os.Rename(src, dst), IsNil)
+
+	snapDecl, err := s.storeSigning.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"series":       "16",
+		"snap-name":    info.SnapName(),
+		"snap-id":      snapID,
+		"publisher-id": "canonical",
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	as := []asserts.Assertion{snapDecl}
+
+	if withRevision {
+		sha3_384, size, err := asserts.SnapFileSHA3_384(dst)
+		c.Assert(err, IsNil)
+		snapRev, err := s.storeSigning.Sign(asserts.SnapRevisionType, map[string]interface{}{
+			"snap-sha3-384": sha3_384,
+			"snap-size":     fmt.Sprintf("%d", size),
+			"snap-id":       snapID,
+			"developer-id":  "canonical",
+			"snap-revision": "1",
+			"timestamp":     time.Now().UTC().Format(time.RFC3339),
+		}, nil, "")
+		c.Assert(err, IsNil)
+		as = append(as, snapRev)
+	}
+
+	seedtest.WriteAssertions(filepath.Join(s.dir, "assertions", info.SnapName()), as...)
+}
+
+func (s *seedSupplementSuite) TestInstallSeedSupplement(c *C) {
+	s.addSupplementSnap(c, "name: foo\nversion: 1\nbase: bar", "foo-id", true)
+	s.addSupplementSnap(c, "name: bar\nversion: 1\ntype: base", "bar-id", true)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	names, tss, err := devicestate.InstallSeedSupplement(s.state, s.dir)
+	c.Assert(err, IsNil)
+	// the base goes first
+	c.Check(names, DeepEquals, []string{"bar", "foo"})
+	c.Assert(tss, HasLen, 2)
+	c.Check(tss[1].Tasks()[0].WaitTasks(), DeepEquals, tss[0].Tasks())
+
+	snapsup, err := snapstate.TaskSnapSetup(tss[1].Tasks()[0])
+	c.Assert(err, IsNil)
+	c.Check(snapsup.SideInfo, DeepEquals, &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(1),
+	})
+	c.Check(snapsup.SnapPath, Equals, filepath.Join(s.dir, "snaps", "foo_1.snap"))
+
+	// the supplement assertions are now known
+	_, err = assertstate.SnapDeclaration(s.state, "foo-id")
+	c.Check(err, IsNil)
+}
+
+func (s *seedSupplementSuite) TestInstallSeedSupplementSkipsInstalled(c *C) {
+	s.addSupplementSnap(c, "name: foo\nversion: 1", "foo-id", true)
+	s.addSupplementSnap(c, "name: core\nversion: 1\ntype: os", "core-id", true)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	names, tss, err := devicestate.InstallSeedSupplement(s.state, s.dir)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"foo"})
+	c.Check(tss, HasLen, 1)
+}
+
+func (s *seedSupplementSuite) TestInstallSeedSupplementNothingToInstall(c *C) {
+	s.addSupplementSnap(c, "name: core\nversion: 1\ntype: os", "core-id", true)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, _, err := devicestate.InstallSeedSupplement(s.state, s.dir)
+	c.Check(err, ErrorMatches, "cannot install seed supplement: all its snaps are already installed")
+}
+
+func (s *seedSupplementSuite) TestInstallSeedSupplementNotSeeded(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("seeded", nil)
+
+	_, _, err := devicestate.InstallSeedSupplement(s.state, s.dir)
+	c.Check(err, ErrorMatches, "cannot install seed supplement until the system is seeded")
+}
+
+func (s *seedSupplementSuite) TestInstallSeedSupplementUnasserted(c *C) {
+	s.addSupplementSnap(c, "name: foo\nversion: 1", "foo-id", false)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, _, err := devicestate.InstallSeedSupplement(s.state, s.dir)
+	c.Check(err, ErrorMatches, `cannot install seed supplement: cannot find signatures with metadata for snap "foo_1.snap"`)
+}
+
+func (s *seedSupplementSuite) TestInstallSeedSupplementMissingBase(c *C) {
+	s.addSupplementSnap(c, "name: foo\nversion: 1\nbase: core20", "foo-id", true)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, _, err := devicestate.InstallSeedSupplement(s.state, s.dir)
+	c.Check(err, ErrorMatches, `cannot install seed supplement: cannot use snap "foo": base "core20" is missing`)
+}
+
+func (s *seedSupplementSuite) TestInstallSeedSupplementNoSnaps(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, _, err := devicestate.InstallSeedSupplement(s.state, s.dir)
+	c.Check(err, ErrorMatches, `cannot install seed supplement: no snaps in ".*/snaps"`)
+}
+
+func (s *seedSupplementSuite) TestInstallSeedSupplementNoAssertions(c *C) {
+	c.Assert(os.RemoveAll(filepath.Join(s.dir, "assertions")), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, _, err := devicestate.InstallSeedSupplement(s.state, s.dir)
+	c.Check(err, ErrorMatches, `cannot install seed supplement: no assertions in ".*"`)
+}
@@ -0,0 +1,99 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"path/filepath"
+
+	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/disks"
+	"github.com/snapcore/snapd/release"
+)
+
+// DataStorage is the health of the disk backing the ubuntu-data partition
+// and the usage of the partition.
+type DataStorage struct {
+	// Disk is the "major:minor" number of the disk.
+	Disk   string
+	Health *disks.Health
+	Usage  *disks.Usage
+}
+
+// DataStorage returns the health and usage of the storage backing
+// ubuntu-data. It is only available in run mode of UC20 systems, otherwise
+// nil is returned.
+func (m *DeviceManager) DataStorage() (*DataStorage, error) {
+	if release.OnClassic || m.SystemMode(SysHasModeenv) != "run" {
+		return nil, nil
+	}
+
+	opts := &disks.Options{
+		IsDecryptedDevice: osutil.FileExists(filepath.Join(dirs.SnapFDEDir, "marker")),
+	}
+	disk, err := disks.DiskFromMountPoint(boot.InitramfsDataDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	health, err := disk.Health()
+	if err != nil {
+		return nil, err
+	}
+	usage, err := disks.UsageForMountPoint(boot.InitramfsDataDir)
+	if err != nil {
+		return nil, err
+	}
+	return &DataStorage{
+		Disk:   disk.Dev(),
+		Health: health,
+		Usage:  usage,
+	}, nil
+}
+
+// ensureDataStorageHealthy warns once per boot if the disk backing
+// ubuntu-data is close to wearing out.
+func (m *DeviceManager) ensureDataStorageHealthy() error {
+	if m.ensureDataStorageHealthyRan {
+		return nil
+	}
+	m.ensureDataStorageHealthyRan = true
+
+	storage, err := m.DataStorage()
+	if err != nil {
+		// not being able to tell is not worth failing the ensure over
+		logger.Debugf("cannot check health of ubuntu-data storage: %v", err)
+		return nil
+	}
+	if storage == nil || !storage.Health.Worn() {
+		return nil
+	}
+
+	m.state.Lock()
+	defer m.state.Unlock()
+	if storage.Health.LifeTimeUsed >= 90 {
+		m.state.Warnf(i18n.G("disk %s backing ubuntu-data is close to wearing out: %d%% of its estimated life time is used"), storage.Disk, storage.Health.LifeTimeUsed)
+	} else {
+		m.state.Warnf(i18n.G("disk %s backing ubuntu-data is close to wearing out: reserved blocks are in %q state"), storage.Disk, storage.Health.PreEOL)
+	}
+	return nil
+}
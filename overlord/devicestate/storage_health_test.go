@@ -0,0 +1,141 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil/disks"
+	"github.com/snapcore/snapd/overlord/devicestate"
+)
+
+type deviceMgrStorageSuite struct {
+	deviceMgrBaseSuite
+}
+
+var _ = Suite(&deviceMgrStorageSuite{})
+
+func (s *deviceMgrStorageSuite) SetUpTest(c *C) {
+	s.deviceMgrBaseSuite.SetUpTest(c)
+
+	s.AddCleanup(disks.MockUsageForMountPoint(map[string]*disks.Usage{
+		boot.InitramfsDataDir: {Size: 1000, Free: 200},
+	}))
+}
+
+func (s *deviceMgrStorageSuite) mockDataDisk(health *disks.Health, decrypted bool) {
+	s.AddCleanup(disks.MockMountPointDisksToPartitionMapping(map[disks.Mountpoint]*disks.MockDiskMapping{
+		{Mountpoint: boot.InitramfsDataDir, IsDecryptedDevice: decrypted}: {
+			DevNum:     "179:0",
+			DiskHealth: health,
+		},
+	}))
+}
+
+func (s *deviceMgrStorageSuite) TestDataStorage(c *C) {
+	devicestate.SetSystemMode(s.mgr, "run")
+	s.mockDataDisk(&disks.Health{LifeTimeUsed: 30, PreEOL: "normal"}, false)
+
+	storage, err := s.mgr.DataStorage()
+	c.Assert(err, IsNil)
+	c.Check(storage, DeepEquals, &devicestate.DataStorage{
+		Disk:   "179:0",
+		Health: &disks.Health{LifeTimeUsed: 30, PreEOL: "normal"},
+		Usage:  &disks.Usage{Size: 1000, Free: 200},
+	})
+}
+
+func (s *deviceMgrStorageSuite) TestDataStorageEncrypted(c *C) {
+	devicestate.SetSystemMode(s.mgr, "run")
+	s.mockDataDisk(nil, true)
+	c.Assert(os.MkdirAll(dirs.SnapFDEDir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dirs.SnapFDEDir, "marker"), nil, 0644), IsNil)
+
+	storage, err := s.mgr.DataStorage()
+	c.Assert(err, IsNil)
+	c.Check(storage.Disk, Equals, "179:0")
+}
+
+func (s *deviceMgrStorageSuite) TestDataStorageNotUC20RunMode(c *C) {
+	for _, mode := range []string{"", "recover", "install"} {
+		devicestate.SetSystemMode(s.mgr, mode)
+		storage, err := s.mgr.DataStorage()
+		c.Assert(err, IsNil)
+		c.Check(storage, IsNil)
+	}
+}
+
+func (s *deviceMgrStorageSuite) TestEnsureDataStorageHealthyWarnsOnce(c *C) {
+	devicestate.SetSystemMode(s.mgr, "run")
+	s.mockDataDisk(&disks.Health{LifeTimeUsed: 90, PreEOL: "normal"}, false)
+
+	c.Assert(devicestate.EnsureDataStorageHealthy(s.mgr), IsNil)
+	c.Assert(devicestate.EnsureDataStorageHealthy(s.mgr), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `disk 179:0 backing ubuntu-data is close to wearing out: 90% of its estimated life time is used`)
+}
+
+func (s *deviceMgrStorageSuite) TestEnsureDataStorageHealthyPreEOL(c *C) {
+	devicestate.SetSystemMode(s.mgr, "run")
+	s.mockDataDisk(&disks.Health{LifeTimeUsed: 50, PreEOL: "urgent"}, false)
+
+	c.Assert(devicestate.EnsureDataStorageHealthy(s.mgr), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Equals, `disk 179:0 backing ubuntu-data is close to wearing out: reserved blocks are in "urgent" state`)
+}
+
+func (s *deviceMgrStorageSuite) TestEnsureDataStorageHealthyNoWarning(c *C) {
+	devicestate.SetSystemMode(s.mgr, "run")
+	s.mockDataDisk(&disks.Health{LifeTimeUsed: 80, PreEOL: "normal"}, false)
+
+	c.Assert(devicestate.EnsureDataStorageHealthy(s.mgr), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.AllWarnings(), HasLen, 0)
+}
+
+func (s *deviceMgrStorageSuite) TestEnsureDataStorageHealthyErrorIgnored(c *C) {
+	devicestate.SetSystemMode(s.mgr, "run")
+	s.mockDataDisk(nil, false)
+	// no usage for ubuntu-data
+	restore := disks.MockUsageForMountPoint(nil)
+	defer restore()
+
+	c.Assert(devicestate.EnsureDataStorageHealthy(s.mgr), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.AllWarnings(), HasLen, 0)
+}
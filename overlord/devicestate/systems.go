@@ -20,9 +20,14 @@
 package devicestate
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/boot"
@@ -96,6 +101,15 @@ func systemFromSeed(label string, current *currentSystem) (*System, error) {
 		Brand:   brand,
 		Actions: defaultSystemActions,
 	}
+	meta, err := readRecoverySystemMetadata(filepath.Join(dirs.SnapSeedDir, "systems", label))
+	if err != nil {
+		// the system is still usable
+		logger.Noticef("cannot read metadata of system %q: %v", label, err)
+	}
+	if meta != nil {
+		system.Description = meta.Description
+		system.Tags = meta.Tags
+	}
 	if current.sameAs(system) {
 		system.Current = true
 		system.Actions = current.actions
@@ -103,6 +117,61 @@ func systemFromSeed(label string, current *currentSystem) (*System, error) {
 	return system, nil
 }
 
+// RecoverySystemMetadata carries the human readable description and
+// the tags of a recovery system.
+type RecoverySystemMetadata struct {
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+const maxRecoverySystemDescriptionLength = 256
+
+var validRecoverySystemTag = regexp.MustCompile(`^[a-z0-9](?:-?[a-z0-9])*$`)
+
+func (meta *RecoverySystemMetadata) validate() error {
+	if utf8.RuneCountInString(meta.Description) > maxRecoverySystemDescriptionLength {
+		return fmt.Errorf("recovery system description cannot be longer than %d characters", maxRecoverySystemDescriptionLength)
+	}
+	if strings.ContainsAny(meta.Description, "\n\r") {
+		return fmt.Errorf("recovery system description must be a single line")
+	}
+	for _, tag := range meta.Tags {
+		if !validRecoverySystemTag.MatchString(tag) {
+			return fmt.Errorf("invalid recovery system tag %q", tag)
+		}
+	}
+	return nil
+}
+
+// the metadata is kept next to the seed files of the recovery system,
+// it is ignored when the seed is loaded
+const recoverySystemMetadataFile = "snapd-metadata.json"
+
+func writeRecoverySystemMetadata(systemDirectory string, meta *RecoverySystemMetadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(filepath.Join(systemDirectory, recoverySystemMetadataFile), data, 0644, 0)
+}
+
+// readRecoverySystemMetadata returns the metadata of the recovery
+// system in the given directory, or nil if it has none.
+func readRecoverySystemMetadata(systemDirectory string) (*RecoverySystemMetadata, error) {
+	data, err := ioutil.ReadFile(filepath.Join(systemDirectory, recoverySystemMetadataFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta RecoverySystemMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
 type currentSystem struct {
 	*seededSystem
 	actions []SystemAction
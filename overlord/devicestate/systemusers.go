@@ -0,0 +1,96 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// SystemUserImport records the consumption of a system-user assertion
+// to create a user on the device.
+type SystemUserImport struct {
+	BrandID   string    `json:"brand-id"`
+	Email     string    `json:"email"`
+	Username  string    `json:"username"`
+	Revision  int       `json:"revision"`
+	SignKeyID string    `json:"sign-key-sha3-384"`
+	Serial    string    `json:"serial,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// SystemUserImports returns the records of the system-user assertions
+// used so far to create users on the device, oldest first.
+func SystemUserImports(st *state.State) ([]SystemUserImport, error) {
+	var imports []SystemUserImport
+	err := st.Get("system-user-imports", &imports)
+	if err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// RecordSystemUserImport records that the given system-user assertion
+// was used to create the user with the given username on the device
+// with the given serial, which can be empty if the device is not
+// registered yet.
+func RecordSystemUserImport(st *state.State, su *asserts.SystemUser, username, serial string) error {
+	imports, err := SystemUserImports(st)
+	if err != nil {
+		return err
+	}
+	imports = append(imports, SystemUserImport{
+		BrandID:   su.BrandID(),
+		Email:     su.Email(),
+		Username:  username,
+		Revision:  su.Revision(),
+		SignKeyID: su.SignKeyID(),
+		Serial:    serial,
+		Time:      timeNow(),
+	})
+	st.Set("system-user-imports", imports)
+	return nil
+}
+
+// CheckSystemUserMaxUses checks that the given system-user assertion
+// has not been used already as many times as it allows on the device.
+func CheckSystemUserMaxUses(st *state.State, su *asserts.SystemUser) error {
+	maxUses := su.MaxUses()
+	if maxUses == 0 {
+		return nil
+	}
+	imports, err := SystemUserImports(st)
+	if err != nil {
+		return err
+	}
+	uses := 0
+	for _, imp := range imports {
+		if imp.BrandID == su.BrandID() && imp.Email == su.Email() {
+			uses++
+		}
+	}
+	if uses >= maxUses {
+		return fmt.Errorf("system-user assertion for %q already used %d time(s), the maximum allowed", su.Email(), uses)
+	}
+	return nil
+}
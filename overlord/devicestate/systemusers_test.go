@@ -0,0 +1,127 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/devicestate"
+)
+
+type systemUsersSuite struct {
+	deviceMgrBaseSuite
+}
+
+var _ = Suite(&systemUsersSuite{})
+
+func (s *systemUsersSuite) makeSystemUser(c *C, email string, extras map[string]interface{}) *asserts.SystemUser {
+	headers := map[string]interface{}{
+		"brand-id":  "my-brand",
+		"email":     email,
+		"series":    []interface{}{"16"},
+		"models":    []interface{}{"my-model"},
+		"name":      "Some User",
+		"username":  "someuser",
+		"since":     time.Now().Add(-time.Hour).Format(time.RFC3339),
+		"until":     time.Now().Add(time.Hour).Format(time.RFC3339),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	for k, v := range extras {
+		headers[k] = v
+	}
+	a, err := s.brands.Signing("my-brand").Sign(asserts.SystemUserType, headers, nil, "")
+	c.Assert(err, IsNil)
+	return a.(*asserts.SystemUser)
+}
+
+func (s *systemUsersSuite) TestRecordSystemUserImport(c *C) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	restore := devicestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	imports, err := devicestate.SystemUserImports(s.state)
+	c.Assert(err, IsNil)
+	c.Check(imports, HasLen, 0)
+
+	su1 := s.makeSystemUser(c, "foo@example.com", nil)
+	su2 := s.makeSystemUser(c, "bar@example.com", map[string]interface{}{
+		"revision": "2",
+	})
+	c.Assert(devicestate.RecordSystemUserImport(s.state, su1, "foo", ""), IsNil)
+	c.Assert(devicestate.RecordSystemUserImport(s.state, su2, "bar", "serial1"), IsNil)
+
+	imports, err = devicestate.SystemUserImports(s.state)
+	c.Assert(err, IsNil)
+	c.Check(imports, DeepEquals, []devicestate.SystemUserImport{
+		{
+			BrandID:   "my-brand",
+			Email:     "foo@example.com",
+			Username:  "foo",
+			Revision:  0,
+			SignKeyID: su1.SignKeyID(),
+			Time:      now,
+		}, {
+			BrandID:   "my-brand",
+			Email:     "bar@example.com",
+			Username:  "bar",
+			Revision:  2,
+			SignKeyID: su2.SignKeyID(),
+			Serial:    "serial1",
+			Time:      now,
+		},
+	})
+}
+
+func (s *systemUsersSuite) TestCheckSystemUserMaxUsesUnlimited(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	su := s.makeSystemUser(c, "foo@example.com", nil)
+	for i := 0; i < 3; i++ {
+		c.Assert(devicestate.CheckSystemUserMaxUses(s.state, su), IsNil)
+		c.Assert(devicestate.RecordSystemUserImport(s.state, su, "foo", ""), IsNil)
+	}
+}
+
+func (s *systemUsersSuite) TestCheckSystemUserMaxUses(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	su := s.makeSystemUser(c, "foo@example.com", map[string]interface{}{
+		"format":   "2",
+		"max-uses": "2",
+	})
+	other := s.makeSystemUser(c, "bar@example.com", nil)
+	c.Assert(devicestate.RecordSystemUserImport(s.state, other, "bar", ""), IsNil)
+	c.Assert(devicestate.RecordSystemUserImport(s.state, other, "bar", ""), IsNil)
+
+	for i := 0; i < 2; i++ {
+		c.Assert(devicestate.CheckSystemUserMaxUses(s.state, su), IsNil)
+		c.Assert(devicestate.RecordSystemUserImport(s.state, su, "foo", ""), IsNil)
+	}
+	err := devicestate.CheckSystemUserMaxUses(s.state, su)
+	c.Check(err, ErrorMatches, `system-user assertion for "foo@example.com" already used 2 time\(s\), the maximum allowed`)
+}
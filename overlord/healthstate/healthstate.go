@@ -176,7 +176,7 @@ func appendHealth(ctx *hookstate.Context, health *HealthState) error {
 	hs[ctx.InstanceName()] = health
 	st.Set("health", hs)
 
-	return nil
+	return revertOnError(ctx, health)
 }
 
 // SetFromHookContext extracts the health of a snap from a hook
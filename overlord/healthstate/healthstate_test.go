@@ -30,6 +30,7 @@ import (
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/healthstate"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -252,3 +253,113 @@ func (s *healthSuite) TestSetFromHookContextEmpty(c *check.C) {
 	// no health in the context -> no health in state
 	c.Check(s.state.Get("health", &hs), check.Equals, state.ErrNoState)
 }
+
+func (s *healthSuite) mockRefreshedSnap(c *check.C, refreshTime time.Time, optIn bool) {
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "test-snap", Revision: snap.R(41)},
+			{RealName: "test-snap", Revision: snap.R(42)},
+		},
+		Current:         snap.R(42),
+		Active:          true,
+		SnapType:        "app",
+		LastRefreshTime: &refreshTime,
+	})
+	if optIn {
+		tr := config.NewTransaction(s.state)
+		c.Assert(tr.Set("test-snap", "refresh.health-revert", true), check.IsNil)
+		c.Assert(tr.Set("test-snap", "refresh.health-window", "1h"), check.IsNil)
+		tr.Commit()
+	}
+}
+
+func (s *healthSuite) TestSetFromHookContextRevertsRefresh(c *check.C) {
+	now := time.Now()
+	defer healthstate.MockTimeNow(func() time.Time { return now })()
+	var reverted []string
+	defer healthstate.MockSnapstateRevert(func(st *state.State, name string, flags snapstate.Flags) (*state.TaskSet, error) {
+		reverted = append(reverted, name)
+		return state.NewTaskSet(st.NewTask("fake-revert", "...")), nil
+	})()
+
+	ctx, err := hookstate.NewContext(nil, s.state, &hookstate.HookSetup{Snap: "test-snap"}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	ctx.Lock()
+	defer ctx.Unlock()
+	s.mockRefreshedSnap(c, now.Add(-time.Minute), true)
+
+	ctx.Set("health", &healthstate.HealthState{Status: healthstate.ErrorStatus, Message: "database is broken"})
+	c.Assert(healthstate.SetFromHookContext(ctx), check.IsNil)
+
+	c.Check(reverted, check.DeepEquals, []string{"test-snap"})
+	chgs := s.state.Changes()
+	c.Assert(chgs, check.HasLen, 1)
+	c.Check(chgs[0].Kind(), check.Equals, "revert-snap")
+	c.Check(chgs[0].Summary(), check.Equals, `Revert "test-snap" snap after it reported an error health status`)
+	c.Check(chgs[0].Tasks(), check.HasLen, 1)
+}
+
+func (s *healthSuite) TestSetFromHookContextNoRevert(c *check.C) {
+	now := time.Now()
+	defer healthstate.MockTimeNow(func() time.Time { return now })()
+	defer healthstate.MockSnapstateRevert(func(st *state.State, name string, flags snapstate.Flags) (*state.TaskSet, error) {
+		c.Fatalf("unexpected revert of %q", name)
+		return nil, nil
+	})()
+
+	ctx, err := hookstate.NewContext(nil, s.state, &hookstate.HookSetup{Snap: "test-snap"}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	for _, t := range []struct {
+		refreshTime time.Time
+		optIn       bool
+		status      healthstate.HealthStatus
+	}{
+		// not opted in
+		{now.Add(-time.Minute), false, healthstate.ErrorStatus},
+		// outside of the window
+		{now.Add(-2 * time.Hour), true, healthstate.ErrorStatus},
+		// not an error
+		{now.Add(-time.Minute), true, healthstate.WaitingStatus},
+	} {
+		s.mockRefreshedSnap(c, t.refreshTime, t.optIn)
+		ctx.Set("health", &healthstate.HealthState{Status: t.status, Message: "database is broken"})
+		c.Assert(healthstate.SetFromHookContext(ctx), check.IsNil)
+	}
+	c.Check(s.state.Changes(), check.HasLen, 0)
+}
+
+func (s *healthSuite) TestSetFromHookContextFailsRefresh(c *check.C) {
+	now := time.Now()
+	defer healthstate.MockTimeNow(func() time.Time { return now })()
+
+	s.state.Lock()
+	s.mockRefreshedSnap(c, now.Add(-time.Minute), true)
+	chg := s.state.NewChange("refresh-snap", "...")
+	link := s.state.NewTask("link-snap", "...")
+	link.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "test-snap", Revision: snap.R(42)},
+	})
+	chg.AddTask(link)
+	task := healthstate.Hook(s.state, "test-snap", snap.R(42))
+	chg.AddTask(task)
+	s.state.Unlock()
+
+	ctx, err := hookstate.NewContext(task, s.state, &hookstate.HookSetup{Snap: "test-snap", Revision: snap.R(42)}, nil, "")
+	c.Assert(err, check.IsNil)
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	ctx.Set("health", &healthstate.HealthState{Revision: snap.R(42), Status: healthstate.ErrorStatus, Message: "database is broken"})
+	err = healthstate.SetFromHookContext(ctx)
+	c.Check(err, check.ErrorMatches, `snap "test-snap" reported an error health status after being refreshed: database is broken`)
+	// the health is recorded nevertheless
+	health, err := healthstate.Get(s.state, "test-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(health.Status, check.Equals, healthstate.ErrorStatus)
+}
@@ -0,0 +1,157 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package healthstate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+const defaultRevertWindow = 10 * time.Minute
+
+var (
+	snapstateRevert = snapstate.Revert
+	timeNow         = time.Now
+)
+
+// revertWindow returns how long after a refresh an error health status
+// reported by the snap gets the refresh reverted, or 0 if the snap did not
+// opt into this with "snap set <snap> refresh.health-revert=true". The
+// window can be changed with refresh.health-window.
+func revertWindow(st *state.State, instanceName string) (time.Duration, error) {
+	tr := config.NewTransaction(st)
+
+	var enabled bool
+	if err := tr.Get(instanceName, "refresh.health-revert", &enabled); err != nil {
+		if config.IsNoOption(err) {
+			return 0, nil
+		}
+		logger.Noticef("cannot use refresh.health-revert configuration of snap %q: %v", instanceName, err)
+		return 0, nil
+	}
+	if !enabled {
+		return 0, nil
+	}
+
+	var windowStr string
+	err := tr.Get(instanceName, "refresh.health-window", &windowStr)
+	if err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if windowStr == "" {
+		return defaultRevertWindow, nil
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err == nil && window <= 0 {
+		err = fmt.Errorf("window must be positive")
+	}
+	if err != nil {
+		logger.Noticef("cannot use refresh.health-window configuration of snap %q: %v", instanceName, err)
+		return defaultRevertWindow, nil
+	}
+	return window, nil
+}
+
+// shouldRevert returns whether the latest refresh of the snap should be
+// reverted because of the given health.
+func shouldRevert(st *state.State, instanceName string, health *HealthState) (bool, error) {
+	if health.Status != ErrorStatus {
+		return false, nil
+	}
+	window, err := revertWindow(st, instanceName)
+	if err != nil || window == 0 {
+		return false, err
+	}
+
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, instanceName, &snapst); err != nil {
+		if err == state.ErrNoState {
+			return false, nil
+		}
+		return false, err
+	}
+	if !snapst.Active || snapst.LastRefreshTime == nil {
+		return false, nil
+	}
+	if !health.Revision.Unset() && health.Revision != snapst.Current {
+		return false, nil
+	}
+	// only the latest refresh is reverted, never a revert
+	n := len(snapst.Sequence)
+	if n < 2 || snapst.LastIndex(snapst.Current) != n-1 {
+		return false, nil
+	}
+	return timeNow().Sub(*snapst.LastRefreshTime) <= window, nil
+}
+
+// refreshesSnap returns whether the change refreshes the given snap.
+func refreshesSnap(chg *state.Change, instanceName string) bool {
+	for _, t := range chg.Tasks() {
+		if t.Kind() != "link-snap" {
+			continue
+		}
+		snapsup, err := snapstate.TaskSnapSetup(t)
+		if err != nil {
+			continue
+		}
+		if snapsup.InstanceName() == instanceName && !snapsup.Revert {
+			return true
+		}
+	}
+	return false
+}
+
+// revertOnError reverts the latest refresh of a snap reporting an error
+// health status, if the snap opted into this. While the refresh is still in
+// progress, as when the check-health hook that runs as part of it reports
+// the error, the hook task fails and the refresh gets undone; otherwise a
+// change reverting the snap is created.
+// Must be called with the state lock held.
+func revertOnError(ctx *hookstate.Context, health *HealthState) error {
+	st := ctx.State()
+	instanceName := ctx.InstanceName()
+
+	revert, err := shouldRevert(st, instanceName, health)
+	if err != nil || !revert {
+		return err
+	}
+
+	if task, ok := ctx.Task(); ok && task.Change() != nil && refreshesSnap(task.Change(), instanceName) {
+		return fmt.Errorf("snap %q reported an error health status after being refreshed: %s", instanceName, health.Message)
+	}
+
+	ts, err := snapstateRevert(st, instanceName, snapstate.Flags{})
+	if err != nil {
+		logger.Noticef("cannot revert snap %q which reported an error health status: %v", instanceName, err)
+		return nil
+	}
+	chg := st.NewChange("revert-snap", fmt.Sprintf("Revert %q snap after it reported an error health status", instanceName))
+	chg.AddAll(ts)
+	chg.Set("snap-names", []string{instanceName})
+	st.EnsureBefore(0)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hookstate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/strutil"
+)
+
+// checkpointHooks are the long-running hooks that can record checkpoints
+// to be resumed from if snapd restarts while they run.
+var checkpointHooks = []string{"install-device", "post-refresh"}
+
+// maxCheckpointLen is the maximum length of a checkpoint token.
+const maxCheckpointLen = 4096
+
+// CanCheckpoint returns whether the hook run under the context can record
+// checkpoints.
+func (c *Context) CanCheckpoint() bool {
+	return !c.IsEphemeral() && strutil.ListContains(checkpointHooks, c.HookName())
+}
+
+// Checkpoint returns the token of the last checkpoint recorded by the hook,
+// or an empty string if there is none. Note that the context needs to be
+// locked and unlocked by the caller.
+func (c *Context) Checkpoint() string {
+	var token string
+	if err := c.Get("checkpoint", &token); err != nil {
+		return ""
+	}
+	return token
+}
+
+// SetCheckpoint records a checkpoint of the hook. The token is persisted
+// with the hook task so that if snapd restarts before the hook finished,
+// the hook is run again with the token in SNAP_HOOK_CHECKPOINT and can
+// resume from it. Note that the context needs to be locked and unlocked by
+// the caller.
+func (c *Context) SetCheckpoint(token string) error {
+	if !c.CanCheckpoint() {
+		return fmt.Errorf("cannot record checkpoints from the %q hook", c.HookName())
+	}
+	if token == "" {
+		return fmt.Errorf("checkpoint token cannot be empty")
+	}
+	if len(token) > maxCheckpointLen {
+		return fmt.Errorf("checkpoint token is longer than %d bytes", maxCheckpointLen)
+	}
+	c.Set("checkpoint", token)
+	return nil
+}
@@ -48,6 +48,9 @@ type Context struct {
 	cache  map[interface{}]interface{}
 	onDone []func() error
 
+	// timeoutLimit is the timeout limit declared by the hook itself
+	timeoutLimit time.Duration
+
 	mutex        sync.Mutex
 	mutexChecker int32
 }
@@ -117,7 +120,17 @@ func (c *Context) HookName() string {
 
 // Timeout returns the maximum time this hook can run
 func (c *Context) Timeout() time.Duration {
-	return c.setup.Timeout
+	timeout := c.setup.Timeout
+	if c.timeoutLimit > 0 {
+		// the hook can only shorten its timeout
+		if timeout == 0 {
+			timeout = defaultHookTimeout
+		}
+		if c.timeoutLimit < timeout {
+			return c.timeoutLimit
+		}
+	}
+	return timeout
 }
 
 // ID returns the ID of the context.
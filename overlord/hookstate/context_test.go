@@ -21,6 +21,8 @@ package hookstate
 
 import (
 	"encoding/json"
+	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -184,3 +186,59 @@ func (s *contextSuite) TestChangeID(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(context.ChangeID(), Equals, chg.ID())
 }
+
+func (s *contextSuite) TestCheckpoint(c *C) {
+	s.state.Lock()
+	task := s.state.NewTask("test-task", "my test task")
+	s.state.Unlock()
+	setup := &HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: "install-device"}
+	context, err := NewContext(task, s.state, setup, nil, "")
+	c.Assert(err, IsNil)
+	c.Check(context.CanCheckpoint(), Equals, true)
+
+	context.Lock()
+	defer context.Unlock()
+
+	c.Check(context.Checkpoint(), Equals, "")
+	c.Assert(context.SetCheckpoint("step-1"), IsNil)
+	c.Assert(context.SetCheckpoint("step-2"), IsNil)
+	c.Check(context.Checkpoint(), Equals, "step-2")
+
+	// the checkpoint is kept with the task
+	var data map[string]interface{}
+	c.Assert(task.Get("hook-context", &data), IsNil)
+	c.Check(data["checkpoint"], Equals, "step-2")
+
+	c.Check(context.SetCheckpoint(""), ErrorMatches, "checkpoint token cannot be empty")
+	c.Check(context.SetCheckpoint(strings.Repeat("x", 4097)), ErrorMatches, "checkpoint token is longer than 4096 bytes")
+}
+
+func (s *contextSuite) TestCheckpointUnsupported(c *C) {
+	c.Check(s.context.CanCheckpoint(), Equals, false)
+	s.context.Lock()
+	c.Check(s.context.SetCheckpoint("step-1"), ErrorMatches, `cannot record checkpoints from the "test-hook" hook`)
+	s.context.Unlock()
+
+	setup := &HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: "install-device"}
+	context, err := NewContext(nil, s.state, setup, nil, "")
+	c.Assert(err, IsNil)
+	c.Check(context.CanCheckpoint(), Equals, false)
+}
+
+func (s *contextSuite) TestTimeoutLimit(c *C) {
+	c.Check(s.context.Timeout(), Equals, time.Duration(0))
+
+	// the hook can shorten the default timeout
+	s.context.timeoutLimit = time.Minute
+	c.Check(s.context.Timeout(), Equals, time.Minute)
+
+	// but not extend it
+	s.context.timeoutLimit = 2 * defaultHookTimeout
+	c.Check(s.context.Timeout(), Equals, defaultHookTimeout)
+
+	// nor the one requested for the hook
+	s.setup.Timeout = 30 * time.Second
+	c.Check(s.context.Timeout(), Equals, 30*time.Second)
+	s.context.timeoutLimit = 10 * time.Second
+	c.Check(s.context.Timeout(), Equals, 10*time.Second)
+}
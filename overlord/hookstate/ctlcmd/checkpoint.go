@@ -0,0 +1,75 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+var (
+	shortCheckpointHelp = i18n.G("Record the progress of a long-running hook")
+	longCheckpointHelp  = i18n.G(`
+The checkpoint command records the progress of a long-running hook, so that
+if snapd restarts while the hook runs, the hook is run again with the last
+recorded token in $SNAP_HOOK_CHECKPOINT and can resume from there instead of
+starting from scratch.
+
+    $ snapctl checkpoint partitions-created
+
+Without a token, the last recorded one is printed.
+
+Checkpoints can be recorded from the install-device and post-refresh hooks.
+`)
+)
+
+func init() {
+	addCommand("checkpoint", shortCheckpointHelp, longCheckpointHelp, func() command { return &checkpointCommand{} })
+}
+
+type checkpointCommand struct {
+	baseCommand
+
+	Positional struct {
+		Token string `positional-arg-name:"<token>"`
+	} `positional-args:"yes"`
+}
+
+func (c *checkpointCommand) Execute([]string) error {
+	ctx := c.context()
+	if ctx == nil {
+		return fmt.Errorf(i18n.G("cannot use checkpoint command without a context"))
+	}
+	if !ctx.CanCheckpoint() {
+		return fmt.Errorf(i18n.G("cannot use checkpoint command from the %q hook"), ctx.HookName())
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	if c.Positional.Token == "" {
+		if token := ctx.Checkpoint(); token != "" {
+			c.printf("%s\n", token)
+		}
+		return nil
+	}
+	return ctx.SetCheckpoint(c.Positional.Token)
+}
@@ -0,0 +1,84 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
+	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+type checkpointSuite struct {
+	state       *state.State
+	mockHandler *hooktest.MockHandler
+}
+
+var _ = Suite(&checkpointSuite{})
+
+func (s *checkpointSuite) SetUpTest(c *C) {
+	s.state = state.New(nil)
+	s.mockHandler = hooktest.NewMockHandler()
+}
+
+func (s *checkpointSuite) context(c *C, hook string) *hookstate.Context {
+	s.state.Lock()
+	task := s.state.NewTask("test-task", "my test task")
+	s.state.Unlock()
+	setup := &hookstate.HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: hook}
+	ctx, err := hookstate.NewContext(task, s.state, setup, s.mockHandler, "")
+	c.Assert(err, IsNil)
+	return ctx
+}
+
+func (s *checkpointSuite) TestCheckpoint(c *C) {
+	ctx := s.context(c, "post-refresh")
+
+	stdout, stderr, err := ctlcmd.Run(ctx, []string{"checkpoint"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "")
+	c.Check(string(stderr), Equals, "")
+
+	_, _, err = ctlcmd.Run(ctx, []string{"checkpoint", "data-migrated"}, 0)
+	c.Assert(err, IsNil)
+
+	ctx.Lock()
+	c.Check(ctx.Checkpoint(), Equals, "data-migrated")
+	ctx.Unlock()
+
+	stdout, _, err = ctlcmd.Run(ctx, []string{"checkpoint"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "data-migrated\n")
+}
+
+func (s *checkpointSuite) TestCheckpointBadHook(c *C) {
+	ctx := s.context(c, "configure")
+
+	_, _, err := ctlcmd.Run(ctx, []string{"checkpoint", "foo"}, 0)
+	c.Check(err, ErrorMatches, `cannot use checkpoint command from the "configure" hook`)
+}
+
+func (s *checkpointSuite) TestCheckpointNoContext(c *C) {
+	_, _, err := ctlcmd.Run(nil, []string{"checkpoint", "foo"}, 0)
+	c.Check(err, ErrorMatches, `cannot use checkpoint command without a context`)
+}
@@ -125,7 +125,7 @@ func (f *ForbiddenCommand) Execute(args []string) error {
 
 // nonRootAllowed lists the commands that can be performed even when snapctl
 // is invoked not by root.
-var nonRootAllowed = []string{"get", "services", "set-health", "is-connected", "system-mode"}
+var nonRootAllowed = []string{"get", "services", "set-health", "is-connected", "system-mode", "watch"}
 
 // Run runs the requested command.
 func Run(context *hookstate.Context, args []string, uid uint32) (stdout, stderr []byte, err error) {
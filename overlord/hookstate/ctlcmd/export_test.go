@@ -116,3 +116,11 @@ func MockAutoRefreshForGatingSnap(f func(st *state.State, gatingSnap string) err
 		autoRefreshForGatingSnap = old
 	}
 }
+
+func MockSnapPublisher(f func(st *state.State, snapName string) string) (restore func()) {
+	old := snapPublisher
+	snapPublisher = f
+	return func() {
+		snapPublisher = old
+	}
+}
@@ -22,13 +22,17 @@ package ctlcmd
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/configstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/registrystate"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 )
 
@@ -38,6 +42,8 @@ type getCommand struct {
 	// these two options are mutually exclusive
 	ForceSlotSide bool `long:"slot" description:"return attribute values from the slot side of the connection"`
 	ForcePlugSide bool `long:"plug" description:"return attribute values from the plug side of the connection"`
+	Peer          bool `long:"peer" description:"return information about the snap on the other side of the connection"`
+	View          bool `long:"view" description:"return values of the registry view accessed through the plug"`
 
 	Positional struct {
 		PlugOrSlotSpec string   `positional-args:"true" positional-arg-name:":<plug|slot>"`
@@ -80,6 +86,26 @@ by explicitly requesting that via the --plug and --slot command line options:
     $ snapctl get :myplug --slot usb-vendor
 
 This requests the "usb-vendor" setting from the slot that is connected to "myplug".
+
+Information about the other side of the connection, that is the name of the
+connected snap, its publisher, the name of its plug or slot and its attributes,
+may be printed with the --peer option:
+
+    $ snapctl get --peer :myplug
+    {
+        "attributes": {
+            "path": "/dev/ttyUSB0"
+        },
+        "publisher": "acme",
+        "slot": "serial",
+        "snap": "gadget"
+    }
+    $ snapctl get --peer :myplug snap attributes.path
+
+Values of a registry may be printed through the view accessed by a connected
+registry plug with the --view option:
+
+    $ snapctl get --view :wifi ssid
 `)
 
 func init() {
@@ -153,7 +179,10 @@ func (c *getCommand) Execute(args []string) error {
 		if snap != "" {
 			return fmt.Errorf(`"snapctl get %s" not supported, use "snapctl get :%s" instead`, c.Positional.PlugOrSlotSpec, parts[1])
 		}
-		if len(c.Positional.Keys) == 0 {
+		if c.View {
+			return c.getRegistryValues(context, name)
+		}
+		if len(c.Positional.Keys) == 0 && !c.Peer {
 			return fmt.Errorf(i18n.G("get which attribute?"))
 		}
 
@@ -167,10 +196,47 @@ func (c *getCommand) Execute(args []string) error {
 	return c.getConfigSetting(context)
 }
 
+func (c *getCommand) getRegistryValues(context *hookstate.Context, plugName string) error {
+	if c.ForcePlugSide || c.ForceSlotSide || c.Peer {
+		return fmt.Errorf("cannot use --plug, --slot or --peer with --view")
+	}
+
+	context.Lock()
+	defer context.Unlock()
+
+	view, err := registrystate.PlugView(context.State(), context.InstanceName(), plugName)
+	if err != nil {
+		return err
+	}
+	values, err := registrystate.Get(context.State(), view, c.Positional.Keys)
+	if err != nil {
+		return err
+	}
+
+	if len(c.Positional.Keys) == 0 {
+		// print everything readable through the view as a document
+		bytes, err := json.MarshalIndent(values, "", "\t")
+		if err != nil {
+			return err
+		}
+		c.printf("%s\n", string(bytes))
+		return nil
+	}
+	return c.printValues(func(key string) (interface{}, bool, error) {
+		return values[key], true, nil
+	})
+}
+
 func (c *getCommand) getConfigSetting(context *hookstate.Context) error {
 	if c.ForcePlugSide || c.ForceSlotSide {
 		return fmt.Errorf("cannot use --plug or --slot without <snap>:<plug|slot> argument")
 	}
+	if c.Peer {
+		return fmt.Errorf("cannot use --peer without <snap>:<plug|slot> argument")
+	}
+	if c.View {
+		return fmt.Errorf("cannot use --view without :<plug> argument")
+	}
 
 	context.Lock()
 	transaction := configstate.ContextTransaction(context)
@@ -291,6 +357,9 @@ func (c *getCommand) getInterfaceSetting(context *hookstate.Context, plugOrSlot
 	if c.ForcePlugSide && c.ForceSlotSide {
 		return fmt.Errorf("cannot use --plug and --slot together")
 	}
+	if c.Peer && (c.ForcePlugSide || c.ForceSlotSide) {
+		return fmt.Errorf("cannot use --peer together with --plug or --slot")
+	}
 
 	isPlugSide := (hookType == preparePlugHook || hookType == unpreparePlugHook || hookType == connectPlugHook || hookType == disconnectPlugHook)
 	if err = validatePlugOrSlot(attrsTask, isPlugSide, plugOrSlot); err != nil {
@@ -298,9 +367,14 @@ func (c *getCommand) getInterfaceSetting(context *hookstate.Context, plugOrSlot
 	}
 
 	var which string
-	if c.ForcePlugSide || (isPlugSide && !c.ForceSlotSide) {
+	switch {
+	case c.Peer && isPlugSide:
+		which = "slot"
+	case c.Peer:
 		which = "plug"
-	} else {
+	case c.ForcePlugSide || (isPlugSide && !c.ForceSlotSide):
+		which = "plug"
+	default:
 		which = "slot"
 	}
 
@@ -316,6 +390,10 @@ func (c *getCommand) getInterfaceSetting(context *hookstate.Context, plugOrSlot
 		return fmt.Errorf(i18n.G("internal error: cannot get %s from appropriate task"), which)
 	}
 
+	if c.Peer {
+		return c.printPeer(context, attrsTask, which, staticAttrs, dynamicAttrs)
+	}
+
 	return c.printValues(func(key string) (interface{}, bool, error) {
 		subkeys, err := config.ParseKey(key)
 		if err != nil {
@@ -336,3 +414,78 @@ func (c *getCommand) getInterfaceSetting(context *hookstate.Context, plugOrSlot
 		return nil, false, err
 	})
 }
+
+// snapPublisher returns the username of the publisher of the given snap,
+// or an empty string if it is not known, e.g. for snaps installed locally.
+var snapPublisher = func(st *state.State, snapName string) string {
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, snapName, &snapst); err != nil {
+		return ""
+	}
+	si := snapst.CurrentSideInfo()
+	if si == nil || si.SnapID == "" {
+		return ""
+	}
+	acct, err := assertstate.Publisher(st, si.SnapID)
+	if err != nil {
+		return ""
+	}
+	return acct.Username()
+}
+
+// printPeer prints the requested keys of a document describing the given
+// side of the connection, or the whole document if no keys were given.
+// The state must be locked by the caller.
+func (c *getCommand) printPeer(context *hookstate.Context, attrsTask *state.Task, which string, staticAttrs, dynamicAttrs map[string]interface{}) error {
+	var peerSnap, peerName string
+	if which == "plug" {
+		var plugRef interfaces.PlugRef
+		if err := attrsTask.Get("plug", &plugRef); err != nil {
+			return fmt.Errorf(i18n.G("internal error: cannot get %s from appropriate task"), which)
+		}
+		peerSnap, peerName = plugRef.Snap, plugRef.Name
+	} else {
+		var slotRef interfaces.SlotRef
+		if err := attrsTask.Get("slot", &slotRef); err != nil {
+			return fmt.Errorf(i18n.G("internal error: cannot get %s from appropriate task"), which)
+		}
+		peerSnap, peerName = slotRef.Snap, slotRef.Name
+	}
+
+	// static attributes take precedence, as when getting a single attribute
+	attrs := make(map[string]interface{}, len(staticAttrs)+len(dynamicAttrs))
+	for k, v := range dynamicAttrs {
+		attrs[k] = v
+	}
+	for k, v := range staticAttrs {
+		attrs[k] = v
+	}
+	peer := map[string]interface{}{
+		"snap":       peerSnap,
+		which:        peerName,
+		"attributes": attrs,
+	}
+	if publisher := snapPublisher(context.State(), peerSnap); publisher != "" {
+		peer["publisher"] = publisher
+	}
+
+	if len(c.Positional.Keys) == 0 {
+		for k := range peer {
+			c.Positional.Keys = append(c.Positional.Keys, k)
+		}
+		sort.Strings(c.Positional.Keys)
+		c.Document = true
+	}
+
+	return c.printValues(func(key string) (interface{}, bool, error) {
+		subkeys, err := config.ParseKey(key)
+		if err != nil {
+			return nil, false, err
+		}
+		var value interface{}
+		if err := getAttribute(context.InstanceName(), subkeys, 0, peer, &value); err != nil {
+			return nil, false, err
+		}
+		return value, true, nil
+	})
+}
@@ -80,6 +80,9 @@ var getTests = []struct {
 }, {
 	args:  "get --slot key",
 	error: "cannot use --plug or --slot without <snap>:<plug|slot> argument",
+}, {
+	args:  "get --peer key",
+	error: "cannot use --peer without <snap>:<plug|slot> argument",
 }, {
 	args:  "get --foo",
 	error: ".*unknown flag.*foo.*",
@@ -379,6 +382,24 @@ var getPlugAttributesTests = []struct {
 }, {
 	args:  "get : foo",
 	error: "plug or slot name not provided",
+}, {
+	args:   "get --peer :aplug",
+	stdout: "{\n\t\"attributes\": {\n\t\t\"battr\": \"bar\",\n\t\t\"dyn-slot-attr\": \"d\"\n\t},\n\t\"slot\": \"bslot\",\n\t\"snap\": \"b\"\n}\n",
+}, {
+	args:   "get --peer :aplug snap",
+	stdout: "b\n",
+}, {
+	args:   "get --peer :aplug attributes.battr",
+	stdout: "bar\n",
+}, {
+	args:  "get --peer :aplug publisher",
+	error: `no "publisher" attribute`,
+}, {
+	args:  "get --peer --slot :aplug battr",
+	error: "cannot use --peer together with --plug or --slot",
+}, {
+	args:  "get --peer :bslot",
+	error: `unknown plug or slot "bslot"`,
 }}
 
 func (s *getAttrSuite) TestPlugHookTests(c *C) {
@@ -420,6 +441,12 @@ var getSlotAttributesTests = []struct {
 }, {
 	args:  "get --slot --plug :aplug x",
 	error: `cannot use --plug and --slot together`,
+}, {
+	args:   "get --peer :bslot plug snap",
+	stdout: "{\n\t\"plug\": \"aplug\",\n\t\"snap\": \"a\"\n}\n",
+}, {
+	args:   "get --peer :bslot attributes.dyn-plug-attr",
+	stdout: "c\n",
 }}
 
 func (s *getAttrSuite) TestSlotHookTests(c *C) {
@@ -436,3 +463,16 @@ func (s *getAttrSuite) TestSlotHookTests(c *C) {
 		}
 	}
 }
+
+func (s *getAttrSuite) TestPeerPublisher(c *C) {
+	restore := ctlcmd.MockSnapPublisher(func(st *state.State, snapName string) string {
+		c.Check(snapName, Equals, "b")
+		return "b-publisher"
+	})
+	defer restore()
+
+	stdout, stderr, err := ctlcmd.Run(s.mockPlugHookContext, []string{"get", "--peer", ":aplug", "publisher"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stderr), Equals, "")
+	c.Check(string(stdout), Equals, "b-publisher\n")
+}
@@ -27,13 +27,17 @@ import (
 	"time"
 
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/jsonutil"
 	"github.com/snapcore/snapd/overlord/configstate"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var finalTasks map[string]bool
@@ -140,7 +144,130 @@ func runServiceCommand(context *hookstate.Context, inst *servicestate.Instructio
 	if err != nil {
 		return err
 	}
+	return controlServices(context, appInfos, inst)
+}
+
+// runPeerServiceCommand runs the service command on services of the snaps
+// connected to the given service-control plug of the context snap.
+func runPeerServiceCommand(context *hookstate.Context, plugName string, inst *servicestate.Instruction) error {
+	if context == nil {
+		return fmt.Errorf(i18n.G("cannot %s without a context"), inst.Action)
+	}
+
+	st := context.State()
+	peers, err := serviceControlPeers(st, context.InstanceName(), plugName)
+	if err != nil {
+		return err
+	}
 
+	// group the services by snap, keeping the order
+	var peerNames []string
+	namesByPeer := make(map[string][]string)
+	for _, name := range inst.Names {
+		peerName := strings.SplitN(name, ".", 2)[0]
+		allowed, ok := peers[peerName]
+		if !ok {
+			return fmt.Errorf(i18n.G("snap %q is not connected to plug %q"), peerName, plugName)
+		}
+		if allowed != nil {
+			if name == peerName {
+				return fmt.Errorf(i18n.G("cannot %s all the services of snap %q: only %s can be controlled through plug %q"), inst.Action, peerName, strutil.Quoted(allowed), plugName)
+			}
+			if !strutil.ListContains(allowed, name[len(peerName)+1:]) {
+				return fmt.Errorf(i18n.G("cannot %s service %q through plug %q"), inst.Action, name, plugName)
+			}
+		}
+		if _, ok := namesByPeer[peerName]; !ok {
+			peerNames = append(peerNames, peerName)
+		}
+		namesByPeer[peerName] = append(namesByPeer[peerName], name)
+	}
+
+	var appInfos []*snap.AppInfo
+	for _, peerName := range peerNames {
+		infos, err := getServiceInfos(st, peerName, namesByPeer[peerName])
+		if err != nil {
+			return err
+		}
+		appInfos = append(appInfos, infos...)
+	}
+	return controlServices(context, appInfos, inst)
+}
+
+// serviceControlPeers returns the snaps connected to the given
+// service-control plug of the snap, along with the names of the services
+// which can be controlled through each connection, nil meaning all of
+// them.
+func serviceControlPeers(st *state.State, snapName, plugName string) (map[string][]string, error) {
+	st.Lock()
+	defer st.Unlock()
+
+	info, err := snapstate.CurrentInfo(st, snapName)
+	if err != nil {
+		return nil, fmt.Errorf("internal error: cannot get snap info: %s", err)
+	}
+	plug := info.Plugs[plugName]
+	if plug == nil {
+		return nil, fmt.Errorf(i18n.G("snap %q has no plug named %q"), snapName, plugName)
+	}
+	if plug.Interface != "service-control" {
+		return nil, fmt.Errorf(i18n.G("cannot control peer services through plug %q: interface %q is not service-control"), plugName, plug.Interface)
+	}
+
+	conns, err := ifacestate.ConnectionStates(st)
+	if err != nil {
+		return nil, fmt.Errorf("internal error: cannot get connections: %s", err)
+	}
+	peers := make(map[string][]string)
+	for refStr, connState := range conns {
+		if connState.Undesired || connState.HotplugGone {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(refStr)
+		if err != nil {
+			return nil, fmt.Errorf("internal error: %s", err)
+		}
+		if connRef.PlugRef.Snap != snapName || connRef.PlugRef.Name != plugName {
+			continue
+		}
+		peerInfo, err := snapstate.CurrentInfo(st, connRef.SlotRef.Snap)
+		if err != nil {
+			return nil, fmt.Errorf("internal error: cannot get snap info: %s", err)
+		}
+		slot := peerInfo.Slots[connRef.SlotRef.Name]
+		if slot == nil {
+			// inactive connection
+			continue
+		}
+		allowed, err := builtin.ServiceControlSlotServices(slot)
+		if err != nil {
+			return nil, err
+		}
+		// a peer snap can be connected through several slots, the
+		// services allowed by each of them add up
+		prev, ok := peers[connRef.SlotRef.Snap]
+		switch {
+		case !ok:
+			peers[connRef.SlotRef.Snap] = allowed
+		case prev == nil || allowed == nil:
+			peers[connRef.SlotRef.Snap] = nil
+		default:
+			for _, name := range allowed {
+				if !strutil.ListContains(prev, name) {
+					prev = append(prev, name)
+				}
+			}
+			peers[connRef.SlotRef.Snap] = prev
+		}
+	}
+	if len(peers) == 0 {
+		return nil, fmt.Errorf(i18n.G("plug %q is not connected"), plugName)
+	}
+	return peers, nil
+}
+
+func controlServices(context *hookstate.Context, appInfos []*snap.AppInfo, inst *servicestate.Instruction) error {
+	st := context.State()
 	flags := &servicestate.Flags{CreateExecCommandTasks: true}
 	// passing context so we can ignore self-conflicts with the current change
 	st.Lock()
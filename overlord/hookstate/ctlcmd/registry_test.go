@@ -0,0 +1,172 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
+	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
+	"github.com/snapcore/snapd/overlord/registrystate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type registryViewSuite struct {
+	state       *state.State
+	mockContext *hookstate.Context
+	mockHandler *hooktest.MockHandler
+}
+
+var _ = Suite(&registryViewSuite{})
+
+const registryCoreYaml = `name: core
+version: 1
+type: os
+slots:
+ registry:
+`
+
+const registryTestSnapYaml = `name: test-snap
+version: 1
+plugs:
+ wifi:
+  interface: registry
+  account: can0nical
+  view: network/wifi-setup
+ other:
+  interface: registry
+  account: can0nical
+  view: network/wifi-setup
+`
+
+func (s *registryViewSuite) SetUpTest(c *C) {
+	s.mockHandler = hooktest.NewMockHandler()
+
+	s.state = state.New(nil)
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	storeSigning := assertstest.NewStoreStack("can0nical", nil)
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   storeSigning.Trusted,
+	})
+	c.Assert(err, IsNil)
+	assertstate.ReplaceDB(s.state, db)
+	c.Assert(db.Add(storeSigning.StoreAccountKey("")), IsNil)
+
+	a, err := storeSigning.Sign(asserts.RegistryType, map[string]interface{}{
+		"authority-id": "can0nical",
+		"account-id":   "can0nical",
+		"name":         "network",
+		"views": map[string]interface{}{
+			"wifi-setup": map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{"request": "ssid", "storage": "wifi.ssid"},
+					map[string]interface{}{"request": "password", "storage": "wifi.psk", "access": "write"},
+				},
+			},
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(assertstate.Add(s.state, a), IsNil)
+
+	repo := interfaces.NewRepository()
+	for _, iface := range builtin.Interfaces() {
+		c.Assert(repo.AddInterface(iface), IsNil)
+	}
+	c.Assert(repo.AddSnap(snaptest.MockInfo(c, registryCoreYaml, nil)), IsNil)
+	c.Assert(repo.AddSnap(snaptest.MockInfo(c, registryTestSnapYaml, nil)), IsNil)
+	_, err = repo.Connect(&interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "test-snap", Name: "wifi"},
+		SlotRef: interfaces.SlotRef{Snap: "core", Name: "registry"},
+	}, nil, nil, nil, nil, nil)
+	c.Assert(err, IsNil)
+	ifacerepo.Replace(s.state, repo)
+
+	task := s.state.NewTask("test-task", "my test task")
+	setup := &hookstate.HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: "test-hook"}
+	s.mockContext, err = hookstate.NewContext(task, s.state, setup, s.mockHandler, "")
+	c.Assert(err, IsNil)
+}
+
+func (s *registryViewSuite) TestSetGetView(c *C) {
+	stdout, stderr, err := ctlcmd.Run(s.mockContext, []string{"set", "--view", ":wifi", "ssid=home", "password=secret"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "")
+	c.Check(string(stderr), Equals, "")
+
+	stdout, stderr, err = ctlcmd.Run(s.mockContext, []string{"get", "--view", ":wifi", "ssid"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "home\n")
+	c.Check(string(stderr), Equals, "")
+
+	stdout, _, err = ctlcmd.Run(s.mockContext, []string{"get", "--view", ":wifi"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "{\n\t\"ssid\": \"home\"\n}\n")
+
+	// the write-only password was stored nonetheless
+	s.state.Lock()
+	defer s.state.Unlock()
+	view, err := registrystate.View(s.state, "can0nical", "network", "wifi-setup")
+	c.Assert(err, IsNil)
+	_, err = registrystate.Get(s.state, view, []string{"password"})
+	c.Check(err, ErrorMatches, `cannot find "password" in view can0nical/network/wifi-setup: .*`)
+}
+
+func (s *registryViewSuite) TestUnsetView(c *C) {
+	_, _, err := ctlcmd.Run(s.mockContext, []string{"set", "--view", ":wifi", "ssid=home"}, 0)
+	c.Assert(err, IsNil)
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"set", "--view", ":wifi", "ssid!"}, 0)
+	c.Assert(err, IsNil)
+
+	stdout, _, err := ctlcmd.Run(s.mockContext, []string{"get", "--view", ":wifi"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "{}\n")
+}
+
+func (s *registryViewSuite) TestViewErrors(c *C) {
+	_, _, err := ctlcmd.Run(s.mockContext, []string{"set", "--view", ":wifi", "password"}, 0)
+	c.Check(err, ErrorMatches, `invalid parameter: "password" \(want key=value\)`)
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"set", "--view", ":wifi", "foo=bar"}, 0)
+	c.Check(err, ErrorMatches, `cannot find "foo" in view can0nical/network/wifi-setup: no matching write rule`)
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"set", "--view", "ssid=home"}, 0)
+	c.Check(err, ErrorMatches, `cannot use --view without :<plug> argument`)
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"get", "--view", "ssid"}, 0)
+	c.Check(err, ErrorMatches, `cannot use --view without :<plug> argument`)
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"get", "--view", "--peer", ":wifi"}, 0)
+	c.Check(err, ErrorMatches, `cannot use --plug, --slot or --peer with --view`)
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"get", "--view", ":other", "ssid"}, 0)
+	c.Check(err, ErrorMatches, `cannot access registry through plug "other" of snap "test-snap": plug is not connected`)
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"get", "--view", ":foo", "ssid"}, 0)
+	c.Check(err, ErrorMatches, `cannot find registry plug "foo" of snap "test-snap"`)
+}
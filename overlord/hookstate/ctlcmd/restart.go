@@ -29,7 +29,12 @@ var (
 	shortRestartHelp = i18n.G("Restart services")
 	longRestartHelp  = i18n.G(`
 The restart command restarts the given services of the snap. If executed from the
-"configure" hook, the services will be restarted after the hook finishes.`)
+"configure" hook, the services will be restarted after the hook finishes.
+
+With --peer, the given services of a snap connected to the given
+service-control plug of the snap are restarted instead, for instance:
+
+$ snapctl restart --peer control other-snap.svc`)
 )
 
 func init() {
@@ -41,7 +46,8 @@ type restartCommand struct {
 	Positional struct {
 		ServiceNames []string `positional-arg-name:"<service>" required:"yes"`
 	} `positional-args:"yes" required:"yes"`
-	Reload bool `long:"reload" description:"Reload the given services if they support it (see man systemctl for details)"`
+	Reload bool   `long:"reload" description:"Reload the given services if they support it (see man systemctl for details)"`
+	Peer   string `long:"peer" description:"Restart services of the snap connected to the given service-control plug"`
 }
 
 func (c *restartCommand) Execute(args []string) error {
@@ -52,5 +58,8 @@ func (c *restartCommand) Execute(args []string) error {
 			Reload: c.Reload,
 		},
 	}
+	if c.Peer != "" {
+		return runPeerServiceCommand(c.context(), c.Peer, &inst)
+	}
 	return runServiceCommand(c.context(), &inst)
 }
@@ -639,3 +639,184 @@ test-snap.test-service  enabled  active   -
 `[1:])
 	c.Check(string(stderr), Equals, "")
 }
+
+const managerSnapYaml = `name: manager-snap
+version: 1.0
+plugs:
+ control:
+  interface: service-control
+ other:
+  interface: network
+apps:
+ app:
+  command: bin/app
+`
+
+const peerSnapYaml = `name: peer-snap
+version: 1.0
+slots:
+ control:
+  interface: service-control
+  services: [svc1]
+apps:
+ svc1:
+  command: bin/service
+  daemon: simple
+ svc2:
+  command: bin/service
+  daemon: simple
+`
+
+func (s *servicectlSuite) mockPeerSnaps(c *C, connected bool) *hookstate.Context {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	for _, yaml := range []string{managerSnapYaml, peerSnapYaml} {
+		info := snaptest.MockSnapCurrent(c, yaml, &snap.SideInfo{Revision: snap.R(1)})
+		snapstate.Set(s.st, info.InstanceName(), &snapstate.SnapState{
+			Active:   true,
+			Sequence: []*snap.SideInfo{{RealName: info.SnapName(), Revision: info.Revision}},
+			Current:  info.Revision,
+		})
+	}
+	if connected {
+		s.st.Set("conns", map[string]interface{}{
+			"manager-snap:control peer-snap:control": map[string]interface{}{
+				"interface": "service-control",
+			},
+		})
+	}
+
+	task := s.st.NewTask("test-task", "my test task")
+	setup := &hookstate.HookSetup{Snap: "manager-snap", Revision: snap.R(1), Hook: "test-hook"}
+	context, err := hookstate.NewContext(task, s.st, setup, s.mockHandler, "")
+	c.Assert(err, IsNil)
+	return context
+}
+
+func (s *servicectlSuite) TestRestartPeerCommand(c *C) {
+	context := s.mockPeerSnaps(c, true)
+
+	var serviceChangeFuncCalled bool
+	restore := mockServiceChangeFunc(func(appInfos []*snap.AppInfo, inst *servicestate.Instruction) {
+		serviceChangeFuncCalled = true
+		c.Assert(appInfos, HasLen, 1)
+		c.Check(appInfos[0].Snap.InstanceName(), Equals, "peer-snap")
+		c.Check(appInfos[0].Name, Equals, "svc1")
+		c.Check(inst, DeepEquals, &servicestate.Instruction{
+			Action: "restart",
+			Names:  []string{"peer-snap.svc1"},
+		})
+	})
+	defer restore()
+	_, _, err := ctlcmd.Run(context, []string{"restart", "--peer", "control", "peer-snap.svc1"}, 0)
+	c.Check(err, ErrorMatches, "forced error")
+	c.Check(serviceChangeFuncCalled, Equals, true)
+}
+
+func (s *servicectlSuite) TestRestartPeerCommandErrors(c *C) {
+	restore := mockServiceChangeFunc(func(appInfos []*snap.AppInfo, inst *servicestate.Instruction) {
+		c.Fatalf("unexpected service control")
+	})
+	defer restore()
+
+	context := s.mockPeerSnaps(c, true)
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--peer", "control", "peer-snap.svc2"}, `cannot restart service "peer-snap.svc2" through plug "control"`},
+		{[]string{"--peer", "control", "peer-snap"}, `cannot restart all the services of snap "peer-snap": only "svc1" can be controlled through plug "control"`},
+		{[]string{"--peer", "control", "test-snap.test-service"}, `snap "test-snap" is not connected to plug "control"`},
+		{[]string{"--peer", "other", "peer-snap.svc1"}, `cannot control peer services through plug "other": interface "network" is not service-control`},
+		{[]string{"--peer", "missing", "peer-snap.svc1"}, `snap "manager-snap" has no plug named "missing"`},
+	} {
+		args := append([]string{"restart"}, t.args...)
+		_, _, err := ctlcmd.Run(context, args, 0)
+		c.Check(err, ErrorMatches, t.err, Commentf("%v", t.args))
+	}
+}
+
+func (s *servicectlSuite) TestRestartPeerCommandNotConnected(c *C) {
+	context := s.mockPeerSnaps(c, false)
+
+	_, _, err := ctlcmd.Run(context, []string{"restart", "--peer", "control", "peer-snap.svc1"}, 0)
+	c.Check(err, ErrorMatches, `plug "control" is not connected`)
+}
+
+const peerSnapTwoSlotsYaml = `name: peer-snap
+version: 1.0
+slots:
+ control:
+  interface: service-control
+  services: [svc1]
+ control2:
+  interface: service-control
+  services: [svc2]
+ control-all:
+  interface: service-control
+apps:
+ svc1:
+  command: bin/service
+  daemon: simple
+ svc2:
+  command: bin/service
+  daemon: simple
+ svc3:
+  command: bin/service
+  daemon: simple
+`
+
+func (s *servicectlSuite) TestRestartPeerCommandSeveralSlots(c *C) {
+	context := s.mockPeerSnaps(c, false)
+
+	s.st.Lock()
+	snaptest.MockSnapCurrent(c, peerSnapTwoSlotsYaml, &snap.SideInfo{Revision: snap.R(1)})
+	s.st.Set("conns", map[string]interface{}{
+		"manager-snap:control peer-snap:control": map[string]interface{}{
+			"interface": "service-control",
+		},
+		"manager-snap:control peer-snap:control2": map[string]interface{}{
+			"interface": "service-control",
+		},
+	})
+	s.st.Unlock()
+
+	var names []string
+	restore := mockServiceChangeFunc(func(appInfos []*snap.AppInfo, inst *servicestate.Instruction) {
+		names = nil
+		for _, app := range appInfos {
+			names = append(names, app.Name)
+		}
+	})
+	defer restore()
+
+	// the services allowed by both slots can be controlled
+	_, _, err := ctlcmd.Run(context, []string{"restart", "--peer", "control", "peer-snap.svc1", "peer-snap.svc2"}, 0)
+	c.Check(err, ErrorMatches, "forced error")
+	c.Check(names, DeepEquals, []string{"svc1", "svc2"})
+
+	_, _, err = ctlcmd.Run(context, []string{"restart", "--peer", "control", "peer-snap.svc3"}, 0)
+	c.Check(err, ErrorMatches, `cannot restart service "peer-snap.svc3" through plug "control"`)
+	_, _, err = ctlcmd.Run(context, []string{"restart", "--peer", "control", "peer-snap"}, 0)
+	c.Check(err, ErrorMatches, `cannot restart all the services of snap "peer-snap": only "svc[12]", "svc[12]" can be controlled through plug "control"`)
+
+	// a slot which does not restrict the services allows all of them
+	s.st.Lock()
+	s.st.Set("conns", map[string]interface{}{
+		"manager-snap:control peer-snap:control": map[string]interface{}{
+			"interface": "service-control",
+		},
+		"manager-snap:control peer-snap:control-all": map[string]interface{}{
+			"interface": "service-control",
+		},
+	})
+	s.st.Unlock()
+
+	_, _, err = ctlcmd.Run(context, []string{"restart", "--peer", "control", "peer-snap.svc3"}, 0)
+	c.Check(err, ErrorMatches, "forced error")
+	c.Check(names, DeepEquals, []string{"svc3"})
+	_, _, err = ctlcmd.Run(context, []string{"restart", "--peer", "control", "peer-snap"}, 0)
+	c.Check(err, ErrorMatches, "forced error")
+	c.Check(names, HasLen, 3)
+}
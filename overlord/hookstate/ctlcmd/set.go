@@ -29,6 +29,7 @@ import (
 	"github.com/snapcore/snapd/overlord/configstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/registrystate"
 )
 
 type setCommand struct {
@@ -41,6 +42,7 @@ type setCommand struct {
 
 	String bool `short:"s" description:"parse the value as a string"`
 	Typed  bool `short:"t" description:"parse the value strictly as JSON document"`
+	View   bool `long:"view" description:"set values of the registry view accessed through the plug"`
 }
 
 var shortSetHelp = i18n.G("Changes configuration options")
@@ -63,6 +65,11 @@ Plug and slot attributes may be set in the respective prepare and connect hooks
 naming the respective plug or slot:
 
     $ snapctl set :myplug path=/dev/ttyS0
+
+Values of a registry may be set through the view accessed by a connected
+registry plug with the --view option:
+
+    $ snapctl set --view :wifi ssid=home
 `)
 
 func init() {
@@ -86,6 +93,9 @@ func (s *setCommand) Execute(args []string) error {
 	// treat PlugOrSlotSpec argument as key=value if it contains '=' or doesn't contain ':' - this is to support
 	// values such as "device-service.url=192.168.0.1:5555" and error out on invalid key=value if only "key" is given.
 	if strings.Contains(s.Positional.PlugOrSlotSpec, "=") || !strings.Contains(s.Positional.PlugOrSlotSpec, ":") {
+		if s.View {
+			return fmt.Errorf("cannot use --view without :<plug> argument")
+		}
 		s.Positional.ConfValues = append([]string{s.Positional.PlugOrSlotSpec}, s.Positional.ConfValues[0:]...)
 		s.Positional.PlugOrSlotSpec = ""
 		return s.setConfigSetting(context)
@@ -99,9 +109,61 @@ func (s *setCommand) Execute(args []string) error {
 	if snap != "" {
 		return fmt.Errorf(`"snapctl set %s" not supported, use "snapctl set :%s" instead`, s.Positional.PlugOrSlotSpec, parts[1])
 	}
+	if s.View {
+		return s.setRegistryValues(context, name)
+	}
 	return s.setInterfaceSetting(context, name)
 }
 
+func (s *setCommand) parseValue(raw string) (interface{}, error) {
+	if s.String {
+		return raw, nil
+	}
+	var value interface{}
+	if err := jsonutil.DecodeWithNumber(strings.NewReader(raw), &value); err != nil {
+		if s.Typed {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+
+		// Not valid JSON-- just save the string as-is.
+		return raw, nil
+	}
+	return value, nil
+}
+
+func (s *setCommand) setRegistryValues(context *hookstate.Context, plugName string) error {
+	if len(s.Positional.ConfValues) == 0 {
+		return fmt.Errorf(i18n.G("set which value?"))
+	}
+
+	values := make(map[string]interface{}, len(s.Positional.ConfValues))
+	for _, patchValue := range s.Positional.ConfValues {
+		parts := strings.SplitN(patchValue, "=", 2)
+		if len(parts) == 1 && strings.HasSuffix(patchValue, "!") {
+			values[strings.TrimSuffix(patchValue, "!")] = nil
+			continue
+		}
+		if len(parts) != 2 {
+			return fmt.Errorf(i18n.G("invalid parameter: %q (want key=value)"), patchValue)
+		}
+		value, err := s.parseValue(parts[1])
+		if err != nil {
+			return err
+		}
+		values[parts[0]] = value
+	}
+
+	context.Lock()
+	defer context.Unlock()
+
+	view, err := registrystate.PlugView(context.State(), context.InstanceName(), plugName)
+	if err != nil {
+		return err
+	}
+	_, err = registrystate.Set(context.State(), view, values, context.InstanceName())
+	return err
+}
+
 func (s *setCommand) setConfigSetting(context *hookstate.Context) error {
 	context.Lock()
 	tr := configstate.ContextTransaction(context)
@@ -119,18 +181,9 @@ func (s *setCommand) setConfigSetting(context *hookstate.Context) error {
 		}
 		key := parts[0]
 
-		var value interface{}
-		if s.String {
-			value = parts[1]
-		} else {
-			if err := jsonutil.DecodeWithNumber(strings.NewReader(parts[1]), &value); err != nil {
-				if s.Typed {
-					return fmt.Errorf("failed to parse JSON: %w", err)
-				}
-
-				// Not valid JSON-- just save the string as-is.
-				value = parts[1]
-			}
+		value, err := s.parseValue(parts[1])
+		if err != nil {
+			return err
 		}
 
 		tr.Set(s.context().InstanceName(), key, value)
@@ -0,0 +1,133 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// watchTimeoutCode is the exit code of watch when the key did not change
+// before the timeout.
+const watchTimeoutCode = 1
+
+// maxWatchTimeout is the longest watch can wait, it must be shorter than
+// the timeout of the requests of snapctl to snapd.
+const maxWatchTimeout = 90 * time.Second
+
+type watchCommand struct {
+	baseCommand
+
+	Positional struct {
+		Key string `positional-arg-name:"<key>" description:"option key"`
+	} `positional-args:"yes" required:"yes"`
+
+	Timeout time.Duration `long:"timeout" default:"60s" description:"Maximum time to wait for the key to change, at most 90s"`
+	Typed   bool          `short:"t" description:"strict typing with nulls and quoted strings"`
+}
+
+var shortWatchHelp = i18n.G("Wait for a configuration option to change")
+var longWatchHelp = i18n.G(`
+The watch command waits for the given configuration option of the current
+snap to change, and prints its new value like the get command does:
+
+    $ snapctl watch username
+    frank
+
+Changes of the parents or children of a nested option are taken into
+account. If the option does not change before the timeout, 60 seconds by
+default, watch exits with status 1 without printing anything, so that
+services can wait for changes with:
+
+    $ while ! value=$(snapctl watch username); do :; done
+`)
+
+func init() {
+	addCommand("watch", shortWatchHelp, longWatchHelp, func() command { return &watchCommand{} })
+}
+
+func getCommittedConfig(st *state.State, instanceName, key string) (interface{}, error) {
+	var value interface{}
+	tr := config.NewTransaction(st)
+	if err := tr.Get(instanceName, key, &value); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c *watchCommand) Execute(args []string) error {
+	context := c.context()
+	if context == nil {
+		return fmt.Errorf("cannot watch without a context")
+	}
+	if c.Timeout <= 0 || c.Timeout > maxWatchTimeout {
+		return fmt.Errorf(i18n.G("watch timeout must be positive and at most %s"), maxWatchTimeout)
+	}
+
+	instanceName := context.InstanceName()
+	key := c.Positional.Key
+	if _, err := config.ParseKey(key); err != nil {
+		return err
+	}
+
+	st := context.State()
+	st.Lock()
+	defer st.Unlock()
+
+	initial, err := getCommittedConfig(st, instanceName, key)
+	if err != nil {
+		return err
+	}
+
+	timeout := time.NewTimer(c.Timeout)
+	defer timeout.Stop()
+	for {
+		changed, stop := config.Watch(st, instanceName, key)
+		st.Unlock()
+		timedOut := false
+		select {
+		case <-changed:
+		case <-timeout.C:
+			timedOut = true
+		}
+		st.Lock()
+		stop()
+		if timedOut {
+			return &UnsuccessfulError{ExitCode: watchTimeoutCode}
+		}
+
+		value, err := getCommittedConfig(st, instanceName, key)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(value, initial) {
+			get := getCommand{baseCommand: c.baseCommand, Typed: c.Typed}
+			get.Positional.Keys = []string{key}
+			return get.printValues(func(string) (interface{}, bool, error) {
+				return value, true, nil
+			})
+		}
+	}
+}
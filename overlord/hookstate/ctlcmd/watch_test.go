@@ -0,0 +1,125 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ctlcmd_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
+	"github.com/snapcore/snapd/overlord/hookstate/hooktest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+type watchSuite struct {
+	st          *state.State
+	mockContext *hookstate.Context
+}
+
+var _ = Suite(&watchSuite{})
+
+func (s *watchSuite) SetUpTest(c *C) {
+	s.st = state.New(nil)
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	task := s.st.NewTask("test-task", "my test task")
+	setup := &hookstate.HookSetup{Snap: "test-snap", Revision: snap.R(1), Hook: "test-hook"}
+	var err error
+	s.mockContext, err = hookstate.NewContext(task, s.st, setup, hooktest.NewMockHandler(), "")
+	c.Assert(err, IsNil)
+
+	tr := config.NewTransaction(s.st)
+	tr.Set("test-snap", "a.b", "initial")
+	tr.Commit()
+}
+
+func (s *watchSuite) set(c *C, key string, value interface{}) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	tr := config.NewTransaction(s.st)
+	c.Check(tr.Set("test-snap", key, value), IsNil)
+	tr.Commit()
+}
+
+func (s *watchSuite) TestWatch(c *C) {
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		// setting the same value is not a change
+		s.set(c, "a.b", "initial")
+		time.Sleep(50 * time.Millisecond)
+		s.set(c, "a.c", "other")
+		time.Sleep(50 * time.Millisecond)
+		s.set(c, "a.b", "new")
+	}()
+
+	stdout, stderr, err := ctlcmd.Run(s.mockContext, []string{"watch", "--timeout=10s", "a.b"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "new\n")
+	c.Check(string(stderr), Equals, "")
+}
+
+func (s *watchSuite) TestWatchParent(c *C) {
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.set(c, "a.b", map[string]interface{}{"c": 1})
+	}()
+
+	stdout, _, err := ctlcmd.Run(s.mockContext, []string{"watch", "--timeout=10s", "a"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "{\n\t\"b\": {\n\t\t\"c\": 1\n\t}\n}\n")
+}
+
+func (s *watchSuite) TestWatchUnset(c *C) {
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.set(c, "a.b", nil)
+	}()
+
+	stdout, _, err := ctlcmd.Run(s.mockContext, []string{"watch", "-t", "--timeout=10s", "a.b"}, 0)
+	c.Assert(err, IsNil)
+	c.Check(string(stdout), Equals, "null\n")
+}
+
+func (s *watchSuite) TestWatchTimeout(c *C) {
+	stdout, _, err := ctlcmd.Run(s.mockContext, []string{"watch", "--timeout=50ms", "a.b"}, 0)
+	c.Check(err, DeepEquals, &ctlcmd.UnsuccessfulError{ExitCode: 1})
+	c.Check(stdout, HasLen, 0)
+}
+
+func (s *watchSuite) TestWatchErrors(c *C) {
+	_, _, err := ctlcmd.Run(s.mockContext, []string{"watch", "--timeout=2m", "a.b"}, 0)
+	c.Check(err, ErrorMatches, `watch timeout must be positive and at most 1m30s`)
+
+	_, _, err = ctlcmd.Run(s.mockContext, []string{"watch", "a..b"}, 0)
+	c.Check(err, ErrorMatches, `invalid option name: ""`)
+
+	_, _, err = ctlcmd.Run(nil, []string{"watch", "a.b"}, 0)
+	c.Check(err, ErrorMatches, `cannot watch without a context`)
+}
+
+func (s *watchSuite) TestWatchAllowedForNonRoot(c *C) {
+	_, _, err := ctlcmd.Run(s.mockContext, []string{"watch", "--timeout=10ms", "a.b"}, 1000)
+	c.Check(err, DeepEquals, &ctlcmd.UnsuccessfulError{ExitCode: 1})
+}
@@ -323,15 +323,28 @@ func (m *HookManager) runHook(context *Context, snapst *snapstate.SnapState, hoo
 			return fmt.Errorf("cannot find %q snap", hooksup.Snap)
 		}
 
-		info, err := snapst.CurrentInfo()
+		var info *snap.Info
+		var err error
+		if !hooksup.Revision.Unset() && hooksup.Revision != snapst.Current {
+			// hook of a revision which is not linked (yet), e.g.
+			// migrate-data
+			snapName, _ := snap.SplitInstanceName(hooksup.Snap)
+			info, err = snap.ReadInfo(hooksup.Snap, &snap.SideInfo{RealName: snapName, Revision: hooksup.Revision})
+		} else {
+			info, err = snapst.CurrentInfo()
+		}
 		if err != nil {
 			return fmt.Errorf("cannot read %q snap details: %v", hooksup.Snap, err)
 		}
 
-		hookExists = info.Hooks[hooksup.Hook] != nil
+		hookInfo := info.Hooks[hooksup.Hook]
+		hookExists = hookInfo != nil
 		if !hookExists && !hooksup.Optional {
 			return fmt.Errorf("snap %q has no %q hook", hooksup.Snap, hooksup.Hook)
 		}
+		if hookExists && hookInfo.Limits != nil {
+			context.timeoutLimit = time.Duration(hookInfo.Limits.Timeout)
+		}
 	}
 
 	if hookExists || mustHijack {
@@ -427,7 +440,24 @@ func (m *HookManager) runHook(context *Context, snapst *snapstate.SnapState, hoo
 }
 
 func runHookImpl(c *Context, tomb *tomb.Tomb) ([]byte, error) {
-	return runHookAndWait(c.InstanceName(), c.SnapRevision(), c.HookName(), c.ID(), c.Timeout(), tomb)
+	return runHookAndWait(c.InstanceName(), c.SnapRevision(), c.HookName(), c.ID(), c.Timeout(), hookEnv(c), tomb)
+}
+
+// hookEnv returns the environment specific to the hook run by the context.
+func hookEnv(c *Context) []string {
+	c.Lock()
+	defer c.Unlock()
+
+	var env []string
+	var prevRev snap.Revision
+	if err := c.Get("previous-revision", &prevRev); err == nil {
+		env = append(env, fmt.Sprintf("SNAP_PREVIOUS_REVISION=%s", prevRev))
+	}
+	if token := c.Checkpoint(); token != "" {
+		logger.Noticef("Resuming %q hook of snap %q from checkpoint", c.HookName(), c.InstanceName())
+		env = append(env, fmt.Sprintf("SNAP_HOOK_CHECKPOINT=%s", token))
+	}
+	return env
 }
 
 var runHook = runHookImpl
@@ -466,7 +496,7 @@ func snapCmd() string {
 
 var defaultHookTimeout = 10 * time.Minute
 
-func runHookAndWait(snapName string, revision snap.Revision, hookName, hookContext string, timeout time.Duration, tomb *tomb.Tomb) ([]byte, error) {
+func runHookAndWait(snapName string, revision snap.Revision, hookName, hookContext string, timeout time.Duration, extraEnv []string, tomb *tomb.Tomb) ([]byte, error) {
 	argv := []string{snapCmd(), "run", "--hook", hookName, "-r", revision.String(), snapName}
 	if timeout == 0 {
 		timeout = defaultHookTimeout
@@ -481,6 +511,7 @@ func runHookAndWait(snapName string, revision snap.Revision, hookName, hookConte
 		// hook would fail during transition.
 		fmt.Sprintf("SNAP_CONTEXT=%s", hookContext),
 	}
+	env = append(env, extraEnv...)
 
 	return osutil.RunAndWait(argv, env, timeout, tomb)
 }
@@ -31,6 +31,7 @@ import (
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
 )
 
 func init() {
@@ -38,6 +39,7 @@ func init() {
 	snapstate.SetupPreRefreshHook = SetupPreRefreshHook
 	snapstate.SetupPostRefreshHook = SetupPostRefreshHook
 	snapstate.SetupRemoveHook = SetupRemoveHook
+	snapstate.SetupMigrateDataHook = SetupMigrateDataHook
 	snapstate.SetupGateAutoRefreshHook = SetupGateAutoRefreshHook
 }
 
@@ -78,6 +80,23 @@ func SetupPreRefreshHook(st *state.State, snapName string) *state.Task {
 	return task
 }
 
+// SetupMigrateDataHook returns a task running the migrate-data hook of the
+// given, not yet linked, revision of a snap being refreshed from fromRev
+// across an epoch boundary. The hook gets the previous revision in
+// SNAP_PREVIOUS_REVISION to find the data to migrate.
+func SetupMigrateDataHook(st *state.State, snapName string, rev, fromRev snap.Revision) *state.Task {
+	hooksup := &HookSetup{
+		Snap:     snapName,
+		Revision: rev,
+		Hook:     "migrate-data",
+	}
+
+	summary := fmt.Sprintf(i18n.G("Run migrate-data hook of %q snap"), hooksup.Snap)
+	return HookTask(st, summary, hooksup, map[string]interface{}{
+		"previous-revision": fromRev,
+	})
+}
+
 type gateAutoRefreshHookHandler struct {
 	context             *Context
 	refreshAppAwareness bool
@@ -328,5 +347,6 @@ func setupHooks(hookMgr *HookManager) {
 	hookMgr.Register(regexp.MustCompile("^post-refresh$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^pre-refresh$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^remove$"), handlerGenerator)
+	hookMgr.Register(regexp.MustCompile("^migrate-data$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^gate-auto-refresh$"), gateAutoRefreshHandlerGenerator)
 }
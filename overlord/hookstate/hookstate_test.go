@@ -1359,3 +1359,79 @@ func (s *parallelInstancesHookManagerSuite) TestHookTaskEnsureHookRan(c *C) {
 
 	c.Check(s.manager.NumRunningHooks(), Equals, 0)
 }
+
+func (s *hookManagerSuite) TestMigrateDataHookOfNotLinkedRevision(c *C) {
+	envFile := filepath.Join(c.MkDir(), "env")
+	cmd := testutil.MockCommand(c, "snap", fmt.Sprintf(`echo "$SNAP_PREVIOUS_REVISION" > %s`, envFile))
+	defer cmd.Restore()
+
+	// revision 2 is mounted but not linked yet
+	snaptest.MockSnapInstance(c, "test-snap", `name: test-snap
+version: 2.0
+hooks:
+    migrate-data:
+`, &snap.SideInfo{RealName: "test-snap", SnapID: "some-snap-id", Revision: snap.R(2)})
+
+	s.state.Lock()
+	// only run the migrate-data hook
+	s.task.SetStatus(state.DoneStatus)
+	task := hookstate.SetupMigrateDataHook(s.state, "test-snap", snap.R(2), snap.R(1))
+	chg := s.state.NewChange("refresh", "...")
+	chg.AddTask(task)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(chg.Err(), IsNil)
+	c.Check(task.Status(), Equals, state.DoneStatus)
+	c.Check(cmd.Calls(), DeepEquals, [][]string{{
+		"snap", "run", "--hook", "migrate-data", "-r", "2", "test-snap",
+	}})
+	c.Check(envFile, testutil.FileEquals, "1\n")
+}
+
+func (s *hookManagerSuite) TestHookResumedFromCheckpoint(c *C) {
+	envFile := filepath.Join(c.MkDir(), "env")
+	cmd := testutil.MockCommand(c, "snap", fmt.Sprintf(`echo "$SNAP_HOOK_CHECKPOINT" > %s`, envFile))
+	defer cmd.Restore()
+
+	// the hook recorded a checkpoint before snapd restarted
+	s.state.Lock()
+	s.task.Set("hook-context", map[string]interface{}{"checkpoint": "step-2"})
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.task.Status(), Equals, state.DoneStatus)
+	c.Check(envFile, testutil.FileEquals, "step-2\n")
+}
+
+func (s *hookManagerSuite) TestHookTimeoutLimit(c *C) {
+	// the hook declares a shorter timeout than the default one
+	snaptest.MockSnapInstance(c, "test-snap", `name: test-snap
+version: 1.0
+hooks:
+    configure:
+        limits:
+            timeout: 30s
+`, &snap.SideInfo{RealName: "test-snap", SnapID: "some-snap-id", Revision: snap.R(1)})
+
+	var timeout time.Duration
+	restore := hookstate.MockRunHook(func(ctx *hookstate.Context, tomb *tomb.Tomb) ([]byte, error) {
+		timeout = ctx.Timeout()
+		return nil, nil
+	})
+	defer restore()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.task.Status(), Equals, state.DoneStatus)
+	c.Check(timeout, Equals, 30*time.Second)
+}
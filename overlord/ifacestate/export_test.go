@@ -35,6 +35,7 @@ var (
 	ConnectPriv                  = connect
 	DisconnectPriv               = disconnectTasks
 	GetConns                     = getConns
+	ContentConsumers             = contentConsumers
 	SetConns                     = setConns
 	DefaultDeviceKey             = defaultDeviceKey
 	RemoveDevice                 = removeDevice
@@ -56,6 +57,7 @@ var (
 	SetHotplugChangeAttrs        = setHotplugChangeAttrs
 	AllocHotplugSeq              = allocHotplugSeq
 	AddHotplugSeqWaitTask        = addHotplugSeqWaitTask
+	RecordConnectionEvent        = recordConnectionEvent
 	AddHotplugSlot               = addHotplugSlot
 
 	BatchConnectTasks                = batchConnectTasks
@@ -88,6 +90,12 @@ func MockContentLinkRetryTimeout(d time.Duration) (restore func()) {
 	return func() { contentLinkRetryTimeout = old }
 }
 
+func MockTimeNow(f func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = f
+	return func() { timeNow = old }
+}
+
 func MockHotplugRetryTimeout(d time.Duration) (restore func()) {
 	old := hotplugRetryTimeout
 	hotplugRetryTimeout = d
@@ -386,6 +386,18 @@ func setDynamicHookAttributes(task *state.Task, plugAttrs, slotAttrs map[string]
 	task.Set("slot-dynamic", slotAttrs)
 }
 
+// ConnectedServicesChanged is called after a plug of a snap was connected or
+// disconnected, so that the services of the snap that are ordered against the
+// services connected to the plug can be regenerated. It is set by servicestate.
+var ConnectedServicesChanged func(st *state.State, instanceName, plugName string) error
+
+func connectedServicesChanged(st *state.State, plugRef interfaces.PlugRef) error {
+	if ConnectedServicesChanged == nil {
+		return nil
+	}
+	return ConnectedServicesChanged(st, plugRef.Snap, plugRef.Name)
+}
+
 func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) error {
 	st := task.State()
 	st.Lock()
@@ -459,6 +471,16 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) error {
 	if err != nil {
 		return fmt.Errorf("failed to get hook attributes: %s", err)
 	}
+	var adminPlugAttrs map[string]interface{}
+	if err := task.Get("plug-admin", &adminPlugAttrs); err != nil && err != state.ErrNoState {
+		return err
+	}
+	// attributes overridden by the administrator take precedence over
+	// the ones set by the hooks, they are still subject to the policy
+	// checks below
+	for k, v := range adminPlugAttrs {
+		plugDynamicAttrs[k] = v
+	}
 
 	var policyChecker interfaces.PolicyFunc
 
@@ -489,13 +511,9 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) error {
 	}
 
 	if !delayedSetupProfiles {
-		slotOpts := confinementOptions(slotSnapst.Flags)
-		if err := m.setupSnapSecurity(task, slot.Snap, slotOpts, perfTimings); err != nil {
-			return err
-		}
-
-		plugOpts := confinementOptions(plugSnapst.Flags)
-		if err := m.setupSnapSecurity(task, plug.Snap, plugOpts, perfTimings); err != nil {
+		snaps := []*snap.Info{slot.Snap, plug.Snap}
+		opts := []interfaces.ConfinementOptions{confinementOptions(slotSnapst.Flags), confinementOptions(plugSnapst.Flags)}
+		if err := m.setupConnectionSecurity(task, plug.Interface, snaps, opts, perfTimings); err != nil {
 			return err
 		}
 	} else {
@@ -517,12 +535,28 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) error {
 		DynamicPlugAttrs: conn.Plug.DynamicAttrs(),
 		StaticSlotAttrs:  conn.Slot.StaticAttrs(),
 		DynamicSlotAttrs: conn.Slot.DynamicAttrs(),
+		AdminPlugAttrs:   adminPlugAttrs,
 		Auto:             autoConnect,
 		ByGadget:         byGadget,
 		HotplugKey:       slot.HotplugKey,
 	}
 	setConns(st, conns)
 
+	by := ConnectionByUser
+	switch {
+	case byGadget:
+		by = ConnectionByGadget
+	case autoConnect:
+		by = ConnectionByAutoConnect
+	}
+	if err := recordConnectionEvent(task, "connect", by, conn.Interface(), connRef, connectionJustification(st, plug, slot)); err != nil {
+		return err
+	}
+
+	if err := connectedServicesChanged(st, plugRef); err != nil {
+		return err
+	}
+
 	// the dynamic attributes might have been updated by the interface's BeforeConnectPlug/Slot code,
 	// so we need to update the task for connect-plug- and connect-slot- hooks to see new values.
 	setDynamicHookAttributes(task, conn.Plug.DynamicAttrs(), conn.Slot.DynamicAttrs())
@@ -590,15 +624,26 @@ func (m *InterfaceManager) doDisconnect(task *state.Task, _ *tomb.Tomb) error {
 		return fmt.Errorf("snapd changed, please retry the operation: %v", err)
 	}
 
+	snaps := make([]*snap.Info, 0, len(snapStates))
+	opts := make([]interfaces.ConfinementOptions, 0, len(snapStates))
 	for _, snapst := range snapStates {
 		snapInfo, err := snapst.CurrentInfo()
 		if err != nil {
 			return err
 		}
-		opts := confinementOptions(snapst.Flags)
-		if err := m.setupSnapSecurity(task, snapInfo, opts, perfTimings); err != nil {
+		snaps = append(snaps, snapInfo)
+		opts = append(opts, confinementOptions(snapst.Flags))
+	}
+	var batched bool
+	if err := task.Get("batched", &batched); err != nil && err != state.ErrNoState {
+		return err
+	}
+	if !batched {
+		if err := m.setupConnectionSecurity(task, conn.Interface, snaps, opts, perfTimings); err != nil {
 			return err
 		}
+	} else {
+		logger.Debugf("Disconnect handler: skipping setupSnapSecurity for %d snaps", len(snaps))
 	}
 
 	// "auto-disconnect" flag indicates it's a disconnect triggered automatically as part of snap removal;
@@ -615,6 +660,7 @@ func (m *InterfaceManager) doDisconnect(task *state.Task, _ *tomb.Tomb) error {
 		return fmt.Errorf("internal error: cannot read 'by-hotplug' flag: %s", err)
 	}
 
+	ifaceName := conn.Interface
 	switch {
 	case forget:
 		delete(conns, cref.ID())
@@ -633,7 +679,18 @@ func (m *InterfaceManager) doDisconnect(task *state.Task, _ *tomb.Tomb) error {
 	}
 	setConns(st, conns)
 
-	return nil
+	by := ConnectionByUser
+	switch {
+	case byHotplug:
+		by = ConnectionByHotplug
+	case autoDisconnect:
+		by = ConnectionBySnapRemoval
+	}
+	if err := recordConnectionEvent(task, "disconnect", by, ifaceName, &cref, ""); err != nil {
+		return err
+	}
+
+	return connectedServicesChanged(st, plugRef)
 }
 
 func (m *InterfaceManager) undoDisconnect(task *state.Task, _ *tomb.Tomb) error {
@@ -701,19 +758,20 @@ func (m *InterfaceManager) undoDisconnect(task *state.Task, _ *tomb.Tomb) error
 		return err
 	}
 
-	slotOpts := confinementOptions(slotSnapst.Flags)
-	if err := m.setupSnapSecurity(task, slot.Snap, slotOpts, perfTimings); err != nil {
-		return err
-	}
-	plugOpts := confinementOptions(plugSnapst.Flags)
-	if err := m.setupSnapSecurity(task, plug.Snap, plugOpts, perfTimings); err != nil {
+	snaps := []*snap.Info{slot.Snap, plug.Snap}
+	opts := []interfaces.ConfinementOptions{confinementOptions(slotSnapst.Flags), confinementOptions(plugSnapst.Flags)}
+	if err := m.setupConnectionSecurity(task, plug.Interface, snaps, opts, perfTimings); err != nil {
 		return err
 	}
 
 	conns[connRef.ID()] = &oldconn
 	setConns(st, conns)
 
-	return nil
+	if err := recordConnectionEvent(task, "connect", ConnectionByUndo, oldconn.Interface, connRef, ""); err != nil {
+		return err
+	}
+
+	return connectedServicesChanged(st, plugRef)
 }
 
 func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
@@ -734,6 +792,11 @@ func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
+	var ifaceName string
+	if conn, ok := conns[connRef.ID()]; ok {
+		ifaceName = conn.Interface
+	}
+
 	var old connState
 	err = task.Get("old-conn", &old)
 	if err != nil && err != state.ErrNoState {
@@ -746,15 +809,28 @@ func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
 	}
 	setConns(st, conns)
 
+	if err := recordConnectionEvent(task, "disconnect", ConnectionByUndo, ifaceName, &connRef, ""); err != nil {
+		return err
+	}
+
 	if err := m.repo.Disconnect(connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name); err != nil {
 		return err
 	}
 
-	var delayedSetupProfiles bool
+	if err := connectedServicesChanged(st, plugRef); err != nil {
+		return err
+	}
+
+	var delayedSetupProfiles, batched bool
 	if err := task.Get("delayed-setup-profiles", &delayedSetupProfiles); err != nil && err != state.ErrNoState {
 		return err
 	}
-	if delayedSetupProfiles {
+	if err := task.Get("batched", &batched); err != nil && err != state.ErrNoState {
+		return err
+	}
+	// the security of batched connections is set up at the end of the
+	// batch, which is undone first, so it needs to be set up here
+	if delayedSetupProfiles && !batched {
 		logger.Debugf("Connect undo handler: skipping setupSnapSecurity for snaps %q and %q", connRef.PlugRef.Snap, connRef.SlotRef.Snap)
 		return nil
 	}
@@ -784,16 +860,63 @@ func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
 	if err != nil {
 		return err
 	}
-	slotOpts := confinementOptions(slotSnapst.Flags)
-	if err := m.setupSnapSecurity(task, slot.Snap, slotOpts, perfTimings); err != nil {
+	snaps := []*snap.Info{slot.Snap, plug.Snap}
+	opts := []interfaces.ConfinementOptions{confinementOptions(slotSnapst.Flags), confinementOptions(plugSnapst.Flags)}
+	return m.setupConnectionSecurity(task, plug.Interface, snaps, opts, perfTimings)
+}
+
+// doSetupConnectionProfiles sets up the security of the snaps affected by
+// a batch of connect and disconnect operations, once they were all
+// performed. Only the backends affected by connections of the involved
+// interfaces are set up.
+func (m *InterfaceManager) doSetupConnectionProfiles(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	perfTimings := state.TimingsForTask(task)
+	defer perfTimings.Save(st)
+
+	var snapNames, ifaceNames []string
+	if err := task.Get("snaps", &snapNames); err != nil {
 		return err
 	}
-	plugOpts := confinementOptions(plugSnapst.Flags)
-	if err := m.setupSnapSecurity(task, plug.Snap, plugOpts, perfTimings); err != nil {
+	if err := task.Get("interfaces", &ifaceNames); err != nil {
 		return err
 	}
 
-	return nil
+	snaps := make([]*snap.Info, 0, len(snapNames))
+	opts := make([]interfaces.ConfinementOptions, 0, len(snapNames))
+	for _, instanceName := range snapNames {
+		var snapst snapstate.SnapState
+		if err := snapstate.Get(st, instanceName, &snapst); err != nil {
+			if err == state.ErrNoState {
+				task.Logf("skipping security profiles setup for snap %q, snap doesn't exist", instanceName)
+				continue
+			}
+			return err
+		}
+		snapInfo, err := snapst.CurrentInfo()
+		if err != nil {
+			return err
+		}
+		snaps = append(snaps, snapInfo)
+		opts = append(opts, confinementOptions(snapst.Flags))
+	}
+
+	affected := make(map[interfaces.SecuritySystem]bool)
+	for _, ifaceName := range ifaceNames {
+		for _, backend := range m.repo.BackendsAffectedByConnections(ifaceName) {
+			affected[backend.Name()] = true
+		}
+	}
+	var backends []interfaces.SecurityBackend
+	for _, backend := range m.repo.Backends() {
+		if affected[backend.Name()] {
+			backends = append(backends, backend)
+		}
+	}
+	return m.setupSecurityForBackends(task, backends, snaps, opts, perfTimings)
 }
 
 // timeout for shared content retry
@@ -1255,6 +1378,80 @@ func (m *InterfaceManager) doAutoConnect(task *state.Task, _ *tomb.Tomb) error {
 	return nil
 }
 
+// doTransferConnections creates tasks to connect the snap replacing
+// another snap the same way the replaced snap is connected, plugs and
+// slots are matched by name and interface.
+func (m *InterfaceManager) doTransferConnections(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	snapsup, err := snapstate.TaskSnapSetup(task)
+	if err != nil {
+		return err
+	}
+	var oldName string
+	if err := task.Get("migrate-from", &oldName); err != nil {
+		return err
+	}
+	newName := snapsup.InstanceName()
+
+	conns, err := getConns(st)
+	if err != nil {
+		return err
+	}
+
+	// no conflict checks are needed, replacing snaps happens as part
+	// of a remodel which excludes any other change
+	newconns := make(map[string]*interfaces.ConnRef)
+	connOpts := make(map[string]*connectOpts)
+	for id, cstate := range conns {
+		if cstate.Undesired || cstate.HotplugGone {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return err
+		}
+		if connRef.PlugRef.Snap != oldName && connRef.SlotRef.Snap != oldName {
+			continue
+		}
+		plugRef, slotRef := connRef.PlugRef, connRef.SlotRef
+		if plugRef.Snap == oldName {
+			plugRef.Snap = newName
+		}
+		if slotRef.Snap == oldName {
+			slotRef.Snap = newName
+		}
+		plug := m.repo.Plug(plugRef.Snap, plugRef.Name)
+		slot := m.repo.Slot(slotRef.Snap, slotRef.Name)
+		if plug == nil || slot == nil || plug.Interface != cstate.Interface || slot.Interface != cstate.Interface {
+			task.Logf("cannot transfer connection %s to snap %q: no matching plug or slot", id, newName)
+			continue
+		}
+		newConnRef := interfaces.NewConnRef(plug, slot)
+		key := newConnRef.ID()
+		if existing := conns[key]; existing != nil && !existing.Undesired {
+			// already connected, e.g. auto-connected on install
+			continue
+		}
+		newconns[key] = newConnRef
+		connOpts[key] = &connectOpts{AutoConnect: cstate.Auto, ByGadget: cstate.ByGadget}
+	}
+
+	ts, _, err := batchConnectTasks(st, snapsup, newconns, connOpts)
+	if err != nil {
+		return err
+	}
+	if len(ts.Tasks()) > 0 {
+		snapstate.InjectTasks(task, ts)
+		st.EnsureBefore(0)
+	}
+
+	task.SetStatus(state.DoneStatus)
+	return nil
+}
+
 // doAutoDisconnect creates tasks for disconnecting all interfaces of a snap and running its interface hooks.
 func (m *InterfaceManager) doAutoDisconnect(task *state.Task, _ *tomb.Tomb) error {
 	st := task.State()
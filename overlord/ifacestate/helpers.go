@@ -40,6 +40,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/timings"
 )
 
@@ -365,6 +366,12 @@ func (m *InterfaceManager) removeConnections(snapName string) error {
 }
 
 func (m *InterfaceManager) setupSecurityByBackend(task *state.Task, snaps []*snap.Info, opts []interfaces.ConfinementOptions, tm timings.Measurer) error {
+	return m.setupSecurityForBackends(task, m.repo.Backends(), snaps, opts, tm)
+}
+
+// setupSecurityForBackends sets up the security of the given snaps, limited
+// to the given backends.
+func (m *InterfaceManager) setupSecurityForBackends(task *state.Task, backends []interfaces.SecurityBackend, snaps []*snap.Info, opts []interfaces.ConfinementOptions, tm timings.Measurer) error {
 	if len(snaps) != len(opts) {
 		return fmt.Errorf("internal error: setupSecurityByBackend received an unexpected number of snaps (expected: %d, got %d)", len(opts), len(snaps))
 	}
@@ -373,13 +380,17 @@ func (m *InterfaceManager) setupSecurityByBackend(task *state.Task, snaps []*sna
 		confOpts[snapInfo.InstanceName()] = opts[i]
 	}
 
+	if err := m.recordSecuritySetupScope(task, snaps, backends); err != nil {
+		return err
+	}
+
 	st := task.State()
 	st.Unlock()
 	defer st.Lock()
 
 	// Setup all affected snaps, start with the most important security
 	// backend and run it for all snaps. See LP: 1802581
-	for _, backend := range m.repo.Backends() {
+	for _, backend := range backends {
 		errs := interfaces.SetupMany(m.repo, backend, snaps, func(snapName string) interfaces.ConfinementOptions {
 			return confOpts[snapName]
 		}, tm)
@@ -396,6 +407,79 @@ func (m *InterfaceManager) setupSnapSecurity(task *state.Task, snapInfo *snap.In
 	return m.setupSecurityByBackend(task, []*snap.Info{snapInfo}, []interfaces.ConfinementOptions{opts}, tm)
 }
 
+// setupConnectionSecurity sets up the security of the snaps on either end of
+// a connection of the given interface which was made or removed. Only the
+// backends that connections of the interface can affect are set up, one
+// snap after another.
+func (m *InterfaceManager) setupConnectionSecurity(task *state.Task, ifaceName string, snaps []*snap.Info, opts []interfaces.ConfinementOptions, tm timings.Measurer) error {
+	if len(snaps) != len(opts) {
+		return fmt.Errorf("internal error: setupConnectionSecurity received an unexpected number of snaps (expected: %d, got %d)", len(opts), len(snaps))
+	}
+	backends := m.repo.BackendsAffectedByConnections(ifaceName)
+	for i := range snaps {
+		if err := m.setupSecurityForBackends(task, backends, snaps[i:i+1], opts[i:i+1], tm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SecuritySetupScope describes the security state regenerated by a task.
+type SecuritySetupScope struct {
+	// Snaps are the snaps whose security profiles were set up.
+	Snaps []string `json:"snaps"`
+	// Backends are the security backends that were set up.
+	Backends []interfaces.SecuritySystem `json:"backends"`
+	// Skipped are the security backends that were left alone because
+	// the task could not affect them.
+	Skipped []interfaces.SecuritySystem `json:"skipped,omitempty"`
+}
+
+// TaskSecuritySetupScope returns the security state regenerated by the
+// given task. state.ErrNoState is returned if the task did not set up the
+// security of any snap.
+func TaskSecuritySetupScope(task *state.Task) (*SecuritySetupScope, error) {
+	var scope SecuritySetupScope
+	if err := task.Get("security-setup-scope", &scope); err != nil {
+		return nil, err
+	}
+	return &scope, nil
+}
+
+// recordSecuritySetupScope adds the given snaps and backends to the
+// security setup scope of the task.
+func (m *InterfaceManager) recordSecuritySetupScope(task *state.Task, snaps []*snap.Info, backends []interfaces.SecurityBackend) error {
+	scope, err := TaskSecuritySetupScope(task)
+	if err == state.ErrNoState {
+		scope = &SecuritySetupScope{}
+	} else if err != nil {
+		return err
+	}
+	for _, snapInfo := range snaps {
+		if !strutil.ListContains(scope.Snaps, snapInfo.InstanceName()) {
+			scope.Snaps = append(scope.Snaps, snapInfo.InstanceName())
+		}
+	}
+	used := make(map[interfaces.SecuritySystem]bool, len(scope.Backends)+len(backends))
+	for _, name := range scope.Backends {
+		used[name] = true
+	}
+	for _, backend := range backends {
+		if !used[backend.Name()] {
+			used[backend.Name()] = true
+			scope.Backends = append(scope.Backends, backend.Name())
+		}
+	}
+	scope.Skipped = nil
+	for _, backend := range m.repo.Backends() {
+		if !used[backend.Name()] {
+			scope.Skipped = append(scope.Skipped, backend.Name())
+		}
+	}
+	task.Set("security-setup-scope", scope)
+	return nil
+}
+
 func (m *InterfaceManager) removeSnapSecurity(task *state.Task, instanceName string) error {
 	st := task.State()
 	for _, backend := range m.repo.Backends() {
@@ -447,6 +531,9 @@ type connState struct {
 	DynamicPlugAttrs map[string]interface{} `json:"plug-dynamic,omitempty"`
 	StaticSlotAttrs  map[string]interface{} `json:"slot-static,omitempty"`
 	DynamicSlotAttrs map[string]interface{} `json:"slot-dynamic,omitempty"`
+	// AdminPlugAttrs are the plug attributes overridden by the
+	// administrator, they are also part of DynamicPlugAttrs.
+	AdminPlugAttrs map[string]interface{} `json:"plug-admin,omitempty"`
 	// Hotplug-related attributes: HotplugGone indicates a connection that
 	// disappeared because the device was removed, but may potentially be
 	// restored in the future if we see the device again. HotplugKey is the
@@ -1136,6 +1223,14 @@ func connectDisconnectAffectedSnaps(t *state.Task) ([]string, error) {
 	return []string{plugRef.Snap, slotRef.Snap}, nil
 }
 
+func setupConnectionProfilesAffectedSnaps(t *state.Task) ([]string, error) {
+	var snapNames []string
+	if err := t.Get("snaps", &snapNames); err != nil {
+		return nil, fmt.Errorf("internal error: cannot obtain snaps from task: %s", t.Summary())
+	}
+	return snapNames, nil
+}
+
 func checkSystemSnapIsPresent(st *state.State) bool {
 	st.Lock()
 	defer st.Unlock()
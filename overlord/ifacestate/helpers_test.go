@@ -167,6 +167,40 @@ func (s *helpersSuite) TestGetConns(c *C) {
 	}
 }
 
+func (s *helpersSuite) TestContentConsumers(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	s.st.Set("conns", map[string]interface{}{
+		"consumer-a:content provider:content": map[string]interface{}{
+			"interface": "content",
+		},
+		"consumer-b:themes provider:themes": map[string]interface{}{
+			"interface": "content",
+		},
+		"consumer-b:fonts provider:fonts": map[string]interface{}{
+			"interface": "content",
+		},
+		"disconnected:content provider:content": map[string]interface{}{
+			"interface": "content",
+			"undesired": true,
+		},
+		"provider:content other:content": map[string]interface{}{
+			"interface": "content",
+		},
+		"app:network core:network": map[string]interface{}{
+			"interface": "network",
+		},
+	})
+
+	consumers, err := ifacestate.ContentConsumers(s.st, "provider")
+	c.Assert(err, IsNil)
+	c.Check(consumers, DeepEquals, []string{"consumer-a", "consumer-b"})
+
+	consumers, err = ifacestate.ContentConsumers(s.st, "core")
+	c.Assert(err, IsNil)
+	c.Check(consumers, HasLen, 0)
+}
+
 func (s *helpersSuite) TestSetConns(c *C) {
 	s.st.Lock()
 	defer s.st.Unlock()
@@ -0,0 +1,132 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// Causes of connection events.
+const (
+	ConnectionByUser        = "user"
+	ConnectionByAutoConnect = "auto-connect"
+	ConnectionByGadget      = "gadget"
+	ConnectionByHotplug     = "hotplug"
+	ConnectionBySnapRemoval = "snap-removal"
+	ConnectionByUndo        = "undo"
+)
+
+// maxConnectionHistory is the number of connection events kept in the
+// state, older events are dropped.
+const maxConnectionHistory = 1000
+
+var timeNow = time.Now
+
+// ConnectionEvent records a connection or disconnection of a plug and a slot.
+type ConnectionEvent struct {
+	Time      time.Time          `json:"time"`
+	Action    string             `json:"action"`
+	Interface string             `json:"interface,omitempty"`
+	Plug      interfaces.PlugRef `json:"plug"`
+	Slot      interfaces.SlotRef `json:"slot"`
+	// By is the cause of the event, one of the ConnectionBy* constants.
+	By string `json:"by"`
+	// RequestedBy identifies the API user that requested the change the
+	// event is part of, if known.
+	RequestedBy string `json:"requested-by,omitempty"`
+	// Justification describes the declaration rule that governed the
+	// connection.
+	Justification string `json:"justification,omitempty"`
+}
+
+// ConnectionHistory returns the recorded connection events, oldest first.
+func ConnectionHistory(st *state.State) ([]*ConnectionEvent, error) {
+	var history []*ConnectionEvent
+	if err := st.Get("conns-history", &history); err != nil && err != state.ErrNoState {
+		return nil, fmt.Errorf("cannot obtain connection history: %v", err)
+	}
+	return history, nil
+}
+
+// recordConnectionEvent appends an event for the connection to the
+// connection history.
+func recordConnectionEvent(task *state.Task, action, by, ifaceName string, connRef *interfaces.ConnRef, justification string) error {
+	st := task.State()
+	history, err := ConnectionHistory(st)
+	if err != nil {
+		return err
+	}
+
+	var requestedBy string
+	if chg := task.Change(); chg != nil {
+		if err := chg.Get("requested-by", &requestedBy); err != nil && err != state.ErrNoState {
+			return err
+		}
+	}
+
+	history = append(history, &ConnectionEvent{
+		Time:          timeNow(),
+		Action:        action,
+		Interface:     ifaceName,
+		Plug:          connRef.PlugRef,
+		Slot:          connRef.SlotRef,
+		By:            by,
+		RequestedBy:   requestedBy,
+		Justification: justification,
+	})
+	if len(history) > maxConnectionHistory {
+		history = history[len(history)-maxConnectionHistory:]
+	}
+	st.Set("conns-history", history)
+	return nil
+}
+
+// connectionJustification describes the declaration rule that governs the
+// connection of the given plug and slot, following the precedence used by
+// policy.ConnectCandidate.
+func connectionJustification(st *state.State, plug *snap.PlugInfo, slot *snap.SlotInfo) string {
+	if plug.Snap.SnapID == "" || slot.Snap.SnapID == "" {
+		return "no policy check for snaps without snap-declaration"
+	}
+	ifaceName := plug.Interface
+	if decl, err := assertstate.SnapDeclaration(st, plug.Snap.SnapID); err == nil && decl.PlugRule(ifaceName) != nil {
+		return fmt.Sprintf("plug rule of snap-declaration of %q revision %d", decl.SnapName(), decl.Revision())
+	}
+	if decl, err := assertstate.SnapDeclaration(st, slot.Snap.SnapID); err == nil && decl.SlotRule(ifaceName) != nil {
+		return fmt.Sprintf("slot rule of snap-declaration of %q revision %d", decl.SnapName(), decl.Revision())
+	}
+	baseDecl, err := assertstate.BaseDeclaration(st)
+	if err != nil {
+		return ""
+	}
+	if baseDecl.PlugRule(ifaceName) != nil {
+		return "plug rule of base-declaration"
+	}
+	if baseDecl.SlotRule(ifaceName) != nil {
+		return "slot rule of base-declaration"
+	}
+	return ""
+}
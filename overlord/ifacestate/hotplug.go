@@ -115,6 +115,11 @@ func (m *InterfaceManager) hotplugDeviceAdded(devinfo *hotplug.HotplugDeviceInfo
 		return
 	}
 
+	if hotplugHeld(st) {
+		logger.Noticef("hotplug device %s ignored during maintenance hold", devinfo)
+		return
+	}
+
 	defaultKey, err := defaultDeviceKey(devinfo, deviceKeyVersion)
 	if err != nil {
 		logger.Noticef("cannot compute default hotplug key for device %s: %v", devinfo, err.Error())
@@ -137,6 +142,11 @@ func (m *InterfaceManager) hotplugDeviceAdded(devinfo *hotplug.HotplugDeviceInfo
 		logger.Noticef("internal error: cannot get gadget information: %v", err)
 	}
 
+	rulesByInterface, err := hotplugMatchRules(st)
+	if err != nil {
+		logger.Noticef("cannot get hotplug match rules: %v", err)
+	}
+
 	hotplugIfaces := m.repo.AllHotplugInterfaces()
 	gadgetSlotsByInterface := make(map[string][]*snap.SlotInfo)
 	if gadget != nil {
@@ -174,6 +184,18 @@ InterfacesLoop:
 			continue
 		}
 
+		// the slot of a device matching a rule is named after the rule,
+		// interfaces may also require a matching rule to create any slot
+		rule := hotplug.FindMatchRule(rulesByInterface[iface.Name()], devinfo)
+		if rule == nil {
+			if req, ok := iface.(hotplug.MatchRuleRequirer); ok && req.HotplugRequiresMatchRule() {
+				logger.Debugf("ignoring device %s, interface %q (no matching hotplug rule)", devinfo, iface.Name())
+				continue
+			}
+		} else if rule.Name != "" {
+			proposedSlot.Name = rule.Name
+		}
+
 		// Check the key when we know the interface wants to create a hotplug slot, doing this earlier would generate too much log noise about irrelevant devices
 		key, err := deviceKey(devinfo, iface, defaultKey)
 		if err != nil {
@@ -254,6 +276,11 @@ func (m *InterfaceManager) hotplugDeviceRemoved(devinfo *hotplug.HotplugDeviceIn
 	devs := m.hotplugDevicePaths[devPath]
 	delete(m.hotplugDevicePaths, devPath)
 
+	if hotplugHeld(st) {
+		logger.Noticef("removal of hotplug device %s ignored during maintenance hold", devinfo)
+		return
+	}
+
 	var changed bool
 	for _, dev := range devs {
 		hotplugKey := dev.hotplugKey
@@ -298,6 +325,13 @@ func (m *InterfaceManager) hotplugEnumerationDone() {
 	st.Lock()
 	defer st.Unlock()
 
+	if hotplugHeld(st) {
+		logger.Noticef("removal of missing hotplug devices skipped during maintenance hold")
+		m.enumeratedDeviceKeys = nil
+		m.enumerationDone = true
+		return
+	}
+
 	hotplugSlots, err := getHotplugSlots(st)
 	if err != nil {
 		logger.Noticef("internal error obtaining hotplug slots: %v", err.Error())
@@ -328,6 +362,24 @@ func (m *InterfaceManager) hotplugEnumerationDone() {
 	m.enumerationDone = true
 }
 
+// hotplugMatchRules returns the hotplug match rules declared through the
+// hotplug.rules system option, grouped by interface.
+func hotplugMatchRules(st *state.State) (map[string][]*hotplug.MatchRule, error) {
+	var rules []*hotplug.MatchRule
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "hotplug.rules", &rules); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	rulesByInterface := make(map[string][]*hotplug.MatchRule)
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		rulesByInterface[rule.Interface] = append(rulesByInterface[rule.Interface], rule)
+	}
+	return rulesByInterface, nil
+}
+
 func (m *InterfaceManager) hotplugEnabled() (bool, error) {
 	tr := config.NewTransaction(m.state)
 	return features.Flag(tr, features.Hotplug)
@@ -453,3 +505,14 @@ func removeDevice(st *state.State, ifaceName string, hotplugKey snap.HotplugKey)
 
 	return state.NewTaskSet(hotplugDisconnect, removeSlot)
 }
+
+// hotplugHeld returns whether hotplug changes must not be created because
+// of a maintenance hold.
+func hotplugHeld(st *state.State) bool {
+	hold, err := snapstate.Maintenance(st)
+	if err != nil {
+		logger.Noticef("cannot get maintenance hold: %v", err)
+		return false
+	}
+	return hold != nil
+}
@@ -332,6 +332,90 @@ func (s *hotplugSuite) TestHotplugAddBasic(c *C) {
 	c.Check(s.handledByGadgetCalled, Equals, 0)
 }
 
+func (s *hotplugSuite) TestHotplugAddMaintenanceHold(c *C) {
+	s.MockModel(c, nil)
+
+	st := s.state
+	st.Lock()
+	_, err := snapstate.HoldMaintenance(st, time.Time{})
+	st.Unlock()
+	c.Assert(err, IsNil)
+
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "a/path", "ACTION": "add", "SUBSYSTEM": "foo"})
+	c.Assert(err, IsNil)
+	s.udevMon.AddDevice(di)
+
+	c.Assert(s.o.Settle(5*time.Second), IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), HasLen, 0)
+
+	slot, err := s.mgr.Repository().SlotForHotplugKey("test-a", "key-1")
+	c.Assert(err, IsNil)
+	c.Check(slot, IsNil)
+}
+
+func (s *hotplugSuite) TestHotplugAddWithMatchRules(c *C) {
+	s.MockModel(c, nil)
+
+	proposeSlot := func(deviceInfo *hotplug.HotplugDeviceInfo) (*hotplug.ProposedSlot, error) {
+		return &hotplug.ProposedSlot{}, nil
+	}
+	for _, name := range []string{"test-e", "test-f"} {
+		iface := &ifacetest.TestHotplugInterface{
+			TestInterface:                 ifacetest.TestInterface{InterfaceName: name},
+			HotplugDeviceDetectedCallback: proposeSlot,
+			RequiresMatchRule:             true,
+		}
+		c.Assert(s.mgr.Repository().AddInterface(iface), IsNil)
+		s.AddCleanup(builtin.MockInterface(iface))
+	}
+
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "hotplug.rules", []interface{}{
+		map[string]interface{}{"interface": "test-a", "name": "gps", "usb-vendor": "1546"},
+		map[string]interface{}{"interface": "test-e", "name": "keypad", "usb-interface-class": "03"},
+		map[string]interface{}{"interface": "test-f", "name": "other", "usb-vendor": "0403"},
+	})
+	tr.Commit()
+	s.state.Unlock()
+
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{
+		"DEVPATH":           "a/path",
+		"ACTION":            "add",
+		"SUBSYSTEM":         "foo",
+		"ID_BUS":            "usb",
+		"ID_VENDOR_ID":      "1546",
+		"ID_MODEL_ID":       "01a7",
+		"ID_USB_INTERFACES": ":030000:",
+	})
+	c.Assert(err, IsNil)
+	s.udevMon.AddDevice(di)
+
+	c.Assert(s.o.Settle(5*time.Second), IsNil)
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	repo := s.mgr.Repository()
+	// named after the matching rules
+	slots := repo.AllSlots("test-a")
+	c.Assert(slots, HasLen, 1)
+	c.Check(slots[0].Name, Equals, "gps")
+	slots = repo.AllSlots("test-e")
+	c.Assert(slots, HasLen, 1)
+	c.Check(slots[0].Name, Equals, "keypad")
+	// no matching rule
+	c.Check(repo.AllSlots("test-f"), HasLen, 0)
+	// no rules for the interface
+	slots = repo.AllSlots("test-b")
+	c.Assert(slots, HasLen, 1)
+	c.Check(slots[0].Name, Equals, "hotplugslot-b")
+}
+
 func (s *hotplugSuite) TestHotplugConnectWithGadgetSlot(c *C) {
 	s.MockModel(c, map[string]interface{}{
 		"gadget": "the-gadget",
@@ -26,6 +26,7 @@ import (
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/backends"
+	"github.com/snapcore/snapd/interfaces/prompting"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
@@ -59,6 +60,10 @@ type InterfaceManager struct {
 	// maps sysfs path -> [(interface name, device key)...]
 	hotplugDevicePaths map[string][]deviceData
 
+	// rules created in reply to prompts, loaded on first use
+	promptingRulesMu sync.Mutex
+	promptingRules   *prompting.RuleDB
+
 	// extras
 	extraInterfaces []interfaces.Interface
 	extraBackends   []interfaces.SecurityBackend
@@ -98,10 +103,14 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 	addHandler("connect", m.doConnect, m.undoConnect)
 	addHandler("disconnect", m.doDisconnect, m.undoDisconnect)
 	addHandler("setup-profiles", m.doSetupProfiles, m.undoSetupProfiles)
+	// batched connect and disconnect tasks set up security themselves
+	// when undone
+	addHandler("setup-connection-profiles", m.doSetupConnectionProfiles, nil)
 	addHandler("remove-profiles", m.doRemoveProfiles, m.doSetupProfiles)
 	addHandler("discard-conns", m.doDiscardConns, m.undoDiscardConns)
 	addHandler("auto-connect", m.doAutoConnect, m.undoAutoConnect)
 	addHandler("auto-disconnect", m.doAutoDisconnect, nil)
+	addHandler("transfer-connections", m.doTransferConnections, nil)
 	addHandler("hotplug-add-slot", m.doHotplugAddSlot, nil)
 	addHandler("hotplug-connect", m.doHotplugConnect, nil)
 	addHandler("hotplug-update-slot", m.doHotplugUpdateSlot, nil)
@@ -23,6 +23,7 @@ package ifacestate
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -30,11 +31,14 @@ import (
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/policy"
+	"github.com/snapcore/snapd/interfaces/utils"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var connectRetryTimeout = time.Second * 5
@@ -84,10 +88,17 @@ type connectOpts struct {
 	AutoConnect bool
 
 	DelayedSetupProfiles bool
+	// Batched is set when the connection is made as part of a batch
+	// whose security setup happens once at the end; unlike with
+	// DelayedSetupProfiles, undoing the connection sets up security.
+	Batched bool
+
+	// AdminPlugAttrs are the plug attributes overridden by the
+	// administrator.
+	AdminPlugAttrs map[string]interface{}
 }
 
 // Connect returns a set of tasks for connecting an interface.
-//
 func Connect(st *state.State, plugSnap, plugName, slotSnap, slotName string) (*state.TaskSet, error) {
 	if err := snapstate.CheckChangeConflictMany(st, []string{plugSnap, slotSnap}, ""); err != nil {
 		return nil, err
@@ -96,6 +107,44 @@ func Connect(st *state.State, plugSnap, plugName, slotSnap, slotName string) (*s
 	return connect(st, plugSnap, plugName, slotSnap, slotName, connectOpts{})
 }
 
+// ConnectWithAttrs returns a set of tasks for connecting an interface with
+// plug attributes overridden by the administrator. The overrides take
+// precedence over the attributes of the plug and are stored with the
+// connection, they are only accepted by interfaces implementing
+// interfaces.PlugAttrsOverrider.
+func ConnectWithAttrs(st *state.State, plugSnap, plugName, slotSnap, slotName string, plugAttrs map[string]interface{}) (*state.TaskSet, error) {
+	if len(plugAttrs) == 0 {
+		return Connect(st, plugSnap, plugName, slotSnap, slotName)
+	}
+
+	plugAttrs = utils.NormalizeInterfaceAttributes(plugAttrs).(map[string]interface{})
+	if err := validatePlugAttrsOverride(st, plugSnap, plugName, plugAttrs); err != nil {
+		return nil, err
+	}
+
+	if err := snapstate.CheckChangeConflictMany(st, []string{plugSnap, slotSnap}, ""); err != nil {
+		return nil, err
+	}
+
+	return connect(st, plugSnap, plugName, slotSnap, slotName, connectOpts{AdminPlugAttrs: plugAttrs})
+}
+
+func validatePlugAttrsOverride(st *state.State, plugSnap, plugName string, plugAttrs map[string]interface{}) error {
+	repo := ifacerepo.Get(st)
+	plug := repo.Plug(plugSnap, plugName)
+	if plug == nil {
+		return fmt.Errorf("snap %q has no plug named %q", plugSnap, plugName)
+	}
+	overrider, ok := repo.Interface(plug.Interface).(interfaces.PlugAttrsOverrider)
+	if !ok {
+		return fmt.Errorf("cannot override attributes of plug %s:%s: interface %q does not support it", plugSnap, plugName, plug.Interface)
+	}
+	if err := overrider.ValidatePlugAttrsOverride(plug, plugAttrs); err != nil {
+		return fmt.Errorf("cannot override attributes of plug %s:%s: %v", plugSnap, plugName, err)
+	}
+	return nil
+}
+
 func connect(st *state.State, plugSnap, plugName, slotSnap, slotName string, flags connectOpts) (*state.TaskSet, error) {
 	// TODO: Store the intent-to-connect in the state so that we automatically
 	// try to reconnect on reboot (reconnection can fail or can connect with
@@ -208,6 +257,9 @@ func connect(st *state.State, plugSnap, plugName, slotSnap, slotName string, fla
 	if flags.DelayedSetupProfiles {
 		connectInterface.Set("delayed-setup-profiles", true)
 	}
+	if flags.Batched {
+		connectInterface.Set("batched", true)
+	}
 
 	// Expose a copy of all plug and slot attributes coming from yaml to interface hooks. The hooks will be able
 	// to modify them but all attributes will be checked against assertions after the hooks are run.
@@ -216,6 +268,9 @@ func connect(st *state.State, plugSnap, plugName, slotSnap, slotName string, fla
 	connectInterface.Set("slot-static", slotStatic)
 	connectInterface.Set("plug-dynamic", emptyDynamicAttrs)
 	connectInterface.Set("slot-dynamic", emptyDynamicAttrs)
+	if len(flags.AdminPlugAttrs) > 0 {
+		connectInterface.Set("plug-admin", flags.AdminPlugAttrs)
+	}
 
 	// The main 'connect' task should wait on prepare-slot- hook or on prepare-plug- hook (whichever is present),
 	// but not on both. While there would be no harm in waiting for both, it's not needed as prepare-slot- will
@@ -344,10 +399,126 @@ func Forget(st *state.State, repo *interfaces.Repository, connRef *interfaces.Co
 	return ts, nil
 }
 
+// ConnectionOp is a connect or disconnect operation that is part of a
+// batch, see Batch.
+type ConnectionOp struct {
+	// Action is either "connect" or "disconnect".
+	Action  string
+	ConnRef interfaces.ConnRef
+	// PlugAttrs are plug attributes overridden when connecting.
+	PlugAttrs map[string]interface{}
+	// Forget is set to forget the connection when disconnecting.
+	Forget bool
+}
+
+// Batch returns a set of tasks performing the given connect and disconnect
+// operations one after another. The security profiles of the snaps
+// affected by the operations are set up only once, after all the
+// operations were performed, by a setup-connection-profiles task. Connect
+// operations of connections that already exist are skipped.
+func Batch(st *state.State, repo *interfaces.Repository, ops []*ConnectionOp) (*state.TaskSet, error) {
+	var affected []string
+	for _, op := range ops {
+		affected = appendUnique(affected, op.ConnRef.PlugRef.Snap, op.ConnRef.SlotRef.Snap)
+	}
+	if err := snapstate.CheckChangeConflictMany(st, affected, ""); err != nil {
+		return nil, err
+	}
+
+	setupProfiles := st.NewTask("setup-connection-profiles", i18n.G("Setup security profiles of snaps affected by connection changes"))
+
+	ts := state.NewTaskSet()
+	var snapNames, ifaceNames []string
+	var prev *state.Task
+	for _, op := range ops {
+		var opTs *state.TaskSet
+		var err error
+		plugSnap, plugName := op.ConnRef.PlugRef.Snap, op.ConnRef.PlugRef.Name
+		slotSnap, slotName := op.ConnRef.SlotRef.Snap, op.ConnRef.SlotRef.Name
+		switch op.Action {
+		case "connect":
+			attrs := op.PlugAttrs
+			if len(attrs) > 0 {
+				attrs = utils.NormalizeInterfaceAttributes(attrs).(map[string]interface{})
+				if err := validatePlugAttrsOverride(st, plugSnap, plugName, attrs); err != nil {
+					return nil, err
+				}
+			}
+			opts := connectOpts{DelayedSetupProfiles: true, Batched: true, AdminPlugAttrs: attrs}
+			opTs, err = connect(st, plugSnap, plugName, slotSnap, slotName, opts)
+			if _, ok := err.(*ErrAlreadyConnected); ok {
+				continue
+			}
+		case "disconnect":
+			opts := disconnectOpts{Forget: op.Forget, Batched: true}
+			if conn, cerr := repo.Connection(&op.ConnRef); cerr == nil {
+				opTs, err = disconnectTasks(st, conn, opts)
+			} else if op.Forget {
+				opTs = forgetTasks(st, &op.ConnRef)
+			} else {
+				err = cerr
+			}
+		default:
+			err = fmt.Errorf("internal error: unsupported connection operation %q", op.Action)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// operations are performed in the order they were given
+		if prev != nil {
+			opTs.WaitFor(prev)
+		}
+		for _, t := range opTs.Tasks() {
+			if t.Kind() == "connect" || t.Kind() == "disconnect" {
+				prev = t
+			}
+		}
+		setupProfiles.WaitFor(prev)
+		// connect-plug- and connect-slot- hooks must see the
+		// connection in the security profiles
+		afterConnectTask, _ := opTs.Edge(AfterConnectHooksEdge)
+		if afterConnectTask != nil {
+			afterConnectTask.WaitFor(setupProfiles)
+		}
+		ts.AddAll(opTs)
+		// AddAll does not carry over the edges, the combined set
+		// marks the hooks of its first connection
+		if prevAfterConnect, _ := ts.Edge(AfterConnectHooksEdge); afterConnectTask != nil && prevAfterConnect == nil {
+			ts.MarkEdge(afterConnectTask, AfterConnectHooksEdge)
+		}
+
+		snapNames = appendUnique(snapNames, slotSnap, plugSnap)
+		if plug := repo.Plug(plugSnap, plugName); plug != nil {
+			ifaceNames = appendUnique(ifaceNames, plug.Interface)
+		} else {
+			// an inactive connection is being forgotten, the
+			// interface cannot be known
+			ifaceNames = appendUnique(ifaceNames, "")
+		}
+	}
+	if len(ts.Tasks()) > 0 {
+		setupProfiles.Set("snaps", snapNames)
+		setupProfiles.Set("interfaces", ifaceNames)
+		ts.AddTask(setupProfiles)
+	}
+	return ts, nil
+}
+
+func appendUnique(list []string, strs ...string) []string {
+	for _, str := range strs {
+		if !strutil.ListContains(list, str) {
+			list = append(list, str)
+		}
+	}
+	return list
+}
+
 type disconnectOpts struct {
 	AutoDisconnect bool
 	ByHotplug      bool
 	Forget         bool
+	Batched        bool
 }
 
 // forgetTasks creates a set of tasks for forgetting an inactive connection
@@ -397,6 +568,9 @@ func disconnectTasks(st *state.State, conn *interfaces.Connection, flags disconn
 	if flags.ByHotplug {
 		disconnectTask.Set("by-hotplug", true)
 	}
+	if flags.Batched {
+		disconnectTask.Set("batched", true)
+	}
 
 	ts := state.NewTaskSet()
 	var prev *state.Task
@@ -520,6 +694,35 @@ func CheckInterfaces(st *state.State, snapInfo *snap.Info, deviceCtx snapstate.D
 
 var once sync.Once
 
+// contentConsumers returns the sorted names of the snaps with content plugs
+// connected to slots of the given snap.
+func contentConsumers(st *state.State, instanceName string) ([]string, error) {
+	conns, err := getConns(st)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var consumers []string
+	for id, cstate := range conns {
+		if cstate.Interface != "content" || cstate.Undesired || cstate.HotplugGone {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return nil, err
+		}
+		consumer := connRef.PlugRef.Snap
+		if connRef.SlotRef.Snap != instanceName || consumer == instanceName || seen[consumer] {
+			continue
+		}
+		seen[consumer] = true
+		consumers = append(consumers, consumer)
+	}
+	sort.Strings(consumers)
+	return consumers, nil
+}
+
 func delayedCrossMgrInit() {
 	once.Do(func() {
 		// hook interface checks into snapstate installation logic
@@ -531,6 +734,10 @@ func delayedCrossMgrInit() {
 		// hook into conflict checks mechanisms
 		snapstate.AddAffectedSnapsByKind("connect", connectDisconnectAffectedSnaps)
 		snapstate.AddAffectedSnapsByKind("disconnect", connectDisconnectAffectedSnaps)
+		snapstate.AddAffectedSnapsByKind("setup-connection-profiles", setupConnectionProfilesAffectedSnaps)
+
+		// let transactional removals check content dependencies
+		snapstate.ContentConsumers = contentConsumers
 	})
 }
 
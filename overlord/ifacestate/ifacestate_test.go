@@ -43,6 +43,7 @@ import (
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
@@ -3951,6 +3952,196 @@ func (s *interfaceManagerSuite) TestConnectTracksConnectionsInState(c *C) {
 	})
 }
 
+func (s *interfaceManagerSuite) TestConnectDisconnectRecordsHistory(c *C) {
+	s.MockModel(c, nil)
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	restore := ifacestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	s.mockIfaces(c, &ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	_ = s.manager(c)
+
+	s.state.Lock()
+
+	ts, err := ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	c.Assert(err, IsNil)
+	ts.Tasks()[2].Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: "consumer",
+		},
+	})
+	change := s.state.NewChange("connect", "")
+	change.Set("requested-by", "joe")
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	c.Assert(change.Err(), IsNil)
+	conn := s.getConnection(c, "consumer", "plug", "producer", "slot")
+	ts, err = ifacestate.Disconnect(s.state, conn)
+	c.Assert(err, IsNil)
+	change = s.state.NewChange("disconnect", "")
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Assert(change.Err(), IsNil)
+
+	plugRef := interfaces.PlugRef{Snap: "consumer", Name: "plug"}
+	slotRef := interfaces.SlotRef{Snap: "producer", Name: "slot"}
+	history, err := ifacestate.ConnectionHistory(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 2)
+	c.Check(history[0].Time.Equal(now), Equals, true)
+	history[0].Time = time.Time{}
+	history[1].Time = time.Time{}
+	c.Check(history, DeepEquals, []*ifacestate.ConnectionEvent{{
+		Action:        "connect",
+		Interface:     "test",
+		Plug:          plugRef,
+		Slot:          slotRef,
+		By:            ifacestate.ConnectionByUser,
+		RequestedBy:   "joe",
+		Justification: "no policy check for snaps without snap-declaration",
+	}, {
+		Action:    "disconnect",
+		Interface: "test",
+		Plug:      plugRef,
+		Slot:      slotRef,
+		By:        ifacestate.ConnectionByUser,
+	}})
+}
+
+func (s *interfaceManagerSuite) TestConnectionHistoryIsCapped(c *C) {
+	s.mockIfaces(c, &ifacetest.TestInterface{InterfaceName: "test"})
+	_ = s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	history := make([]*ifacestate.ConnectionEvent, 1000)
+	for i := range history {
+		history[i] = &ifacestate.ConnectionEvent{Action: "connect", Interface: fmt.Sprintf("iface-%d", i)}
+	}
+	s.state.Set("conns-history", history)
+
+	chg := s.state.NewChange("sample", "...")
+	t := s.state.NewTask("connect", "...")
+	chg.AddTask(t)
+	cref := &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}
+	c.Assert(ifacestate.RecordConnectionEvent(t, "disconnect", ifacestate.ConnectionByUser, "test", cref, ""), IsNil)
+
+	history, err := ifacestate.ConnectionHistory(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 1000)
+	c.Check(history[0].Interface, Equals, "iface-1")
+	c.Check(history[999].Action, Equals, "disconnect")
+	c.Check(history[999].Interface, Equals, "test")
+}
+
+type attrsOverridingInterface struct {
+	ifacetest.TestInterface
+}
+
+func (t *attrsOverridingInterface) ValidatePlugAttrsOverride(plug *snap.PlugInfo, attrs map[string]interface{}) error {
+	if _, ok := attrs["bad"]; ok {
+		return fmt.Errorf("bad attribute")
+	}
+	return nil
+}
+
+func (s *interfaceManagerSuite) TestConnectWithAttrsTracksConnectionsInState(c *C) {
+	s.MockModel(c, nil)
+
+	s.mockIfaces(c, &attrsOverridingInterface{ifacetest.TestInterface{InterfaceName: "test"}}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	_ = s.manager(c)
+
+	s.state.Lock()
+
+	ts, err := ifacestate.ConnectWithAttrs(s.state, "consumer", "plug", "producer", "slot", map[string]interface{}{"attr1": "narrow", "read-only": true})
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 5)
+
+	ts.Tasks()[2].Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: "consumer",
+		},
+	})
+
+	change := s.state.NewChange("connect", "")
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(change.Err(), IsNil)
+	c.Check(change.Status(), Equals, state.DoneStatus)
+	var conns map[string]interface{}
+	err = s.state.Get("conns", &conns)
+	c.Assert(err, IsNil)
+	c.Check(conns, DeepEquals, map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{
+			"interface":    "test",
+			"plug-static":  map[string]interface{}{"attr1": "value1"},
+			"plug-dynamic": map[string]interface{}{"attr1": "narrow", "read-only": true},
+			"plug-admin":   map[string]interface{}{"attr1": "narrow", "read-only": true},
+			"slot-static":  map[string]interface{}{"attr2": "value2"},
+		},
+	})
+
+	// the overrides are used for the connection
+	repo := s.manager(c).Repository()
+	conn, err := repo.Connection(&interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	})
+	c.Assert(err, IsNil)
+	var attr1 string
+	c.Assert(conn.Plug.Attr("attr1", &attr1), IsNil)
+	c.Check(attr1, Equals, "narrow")
+}
+
+func (s *interfaceManagerSuite) TestConnectWithAttrsErrors(c *C) {
+	s.MockModel(c, nil)
+
+	s.mockIfaces(c, &attrsOverridingInterface{ifacetest.TestInterface{InterfaceName: "test"}}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	_ = s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := ifacestate.ConnectWithAttrs(s.state, "consumer", "plug", "producer", "slot", map[string]interface{}{"bad": true})
+	c.Check(err, ErrorMatches, `cannot override attributes of plug consumer:plug: bad attribute`)
+
+	_, err = ifacestate.ConnectWithAttrs(s.state, "consumer", "otherplug", "producer", "otherslot", map[string]interface{}{"read-only": true})
+	c.Check(err, ErrorMatches, `cannot override attributes of plug consumer:otherplug: interface "test2" does not support it`)
+
+	_, err = ifacestate.ConnectWithAttrs(s.state, "consumer", "missing", "producer", "slot", map[string]interface{}{"read-only": true})
+	c.Check(err, ErrorMatches, `snap "consumer" has no plug named "missing"`)
+
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
 func (s *interfaceManagerSuite) TestConnectSetsUpSecurity(c *C) {
 	s.MockModel(c, nil)
 
@@ -3990,6 +4181,206 @@ func (s *interfaceManagerSuite) TestConnectSetsUpSecurity(c *C) {
 	c.Check(s.secBackend.SetupCalls[1].Options, Equals, interfaces.ConfinementOptions{})
 }
 
+func (s *interfaceManagerSuite) TestConnectSetsUpAffectedBackendsOnly(c *C) {
+	s.MockModel(c, nil)
+
+	affected := &ifacetest.TestSecurityBackend{BackendName: "affected"}
+	unaffected := &ifacetest.TestSecurityBackendConnectionAware{
+		TestSecurityBackend: ifacetest.TestSecurityBackend{BackendName: "unaffected"},
+		ConnectionsAffectCallback: func(iface interfaces.Interface) bool {
+			return iface.Name() != "test"
+		},
+	}
+	s.AddCleanup(ifacestate.MockSecurityBackends([]interfaces.SecurityBackend{affected, unaffected}))
+
+	s.mockIfaces(c, &ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+	_ = s.manager(c)
+	affected.SetupCalls = nil
+	unaffected.SetupCalls = nil
+
+	s.state.Lock()
+	ts, err := ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	c.Assert(err, IsNil)
+	ts.Tasks()[0].Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: "consumer",
+		},
+	})
+
+	change := s.state.NewChange("connect", "")
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(change.Err(), IsNil)
+	c.Check(change.Status(), Equals, state.DoneStatus)
+
+	c.Assert(affected.SetupCalls, HasLen, 2)
+	c.Check(affected.SetupCalls[0].SnapInfo.InstanceName(), Equals, "producer")
+	c.Check(affected.SetupCalls[1].SnapInfo.InstanceName(), Equals, "consumer")
+	c.Check(unaffected.SetupCalls, HasLen, 0)
+
+	var connectTask *state.Task
+	for _, t := range change.Tasks() {
+		if t.Kind() == "connect" {
+			connectTask = t
+		}
+	}
+	c.Assert(connectTask, NotNil)
+	scope, err := ifacestate.TaskSecuritySetupScope(connectTask)
+	c.Assert(err, IsNil)
+	c.Check(scope, DeepEquals, &ifacestate.SecuritySetupScope{
+		Snaps:    []string{"producer", "consumer"},
+		Backends: []interfaces.SecuritySystem{"affected"},
+		Skipped:  []interfaces.SecuritySystem{"unaffected"},
+	})
+}
+
+func (s *interfaceManagerSuite) setupBatch(c *C) (*interfaces.Repository, []*ifacestate.ConnectionOp) {
+	s.MockModel(c, nil)
+
+	s.mockIfaces(c, &ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, consumer2Yaml)
+	s.mockSnap(c, producerYaml)
+
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{"interface": "test"},
+	})
+	s.state.Unlock()
+
+	mgr := s.manager(c)
+	s.secBackend.SetupCalls = nil
+
+	ops := []*ifacestate.ConnectionOp{{
+		Action: "disconnect",
+		ConnRef: interfaces.ConnRef{
+			PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+			SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+		},
+	}, {
+		Action: "connect",
+		ConnRef: interfaces.ConnRef{
+			PlugRef: interfaces.PlugRef{Snap: "consumer2", Name: "plug"},
+			SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+		},
+	}}
+	return mgr.Repository(), ops
+}
+
+func (s *interfaceManagerSuite) TestBatch(c *C) {
+	repo, ops := s.setupBatch(c)
+
+	s.state.Lock()
+	ts, err := ifacestate.Batch(s.state, repo, ops)
+	c.Assert(err, IsNil)
+	change := s.state.NewChange("batch", "")
+	change.AddAll(ts)
+
+	var disconnectTask, connectTask, setupTask *state.Task
+	for _, t := range ts.Tasks() {
+		switch t.Kind() {
+		case "disconnect":
+			disconnectTask = t
+		case "connect":
+			connectTask = t
+		case "setup-connection-profiles":
+			setupTask = t
+		}
+	}
+	c.Assert(setupTask, NotNil)
+	c.Check(setupTask.WaitTasks(), testutil.Contains, disconnectTask)
+	c.Check(setupTask.WaitTasks(), testutil.Contains, connectTask)
+	// operations are performed in order
+	c.Check(ts.Tasks()[0].Kind(), Equals, "run-hook")
+	c.Check(connectTask.WaitTasks(), testutil.Contains, disconnectTask)
+	// the connect-slot- hook sees the security profiles set up
+	afterConnect, _ := ts.Edge(ifacestate.AfterConnectHooksEdge)
+	c.Assert(afterConnect, NotNil)
+	c.Check(afterConnect.WaitTasks(), testutil.Contains, setupTask)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(change.Err(), IsNil)
+	c.Check(change.Status(), Equals, state.DoneStatus)
+
+	var conns map[string]interface{}
+	c.Assert(s.state.Get("conns", &conns), IsNil)
+	c.Check(conns, HasLen, 1)
+	c.Check(conns["consumer2:plug producer:slot"], NotNil)
+
+	// security was set up only once, for all affected snaps
+	c.Assert(s.secBackend.SetupCalls, HasLen, 3)
+	c.Check(s.secBackend.SetupCalls[0].SnapInfo.InstanceName(), Equals, "producer")
+	c.Check(s.secBackend.SetupCalls[1].SnapInfo.InstanceName(), Equals, "consumer")
+	c.Check(s.secBackend.SetupCalls[2].SnapInfo.InstanceName(), Equals, "consumer2")
+	_, err = ifacestate.TaskSecuritySetupScope(connectTask)
+	c.Check(err, Equals, state.ErrNoState)
+	_, err = ifacestate.TaskSecuritySetupScope(disconnectTask)
+	c.Check(err, Equals, state.ErrNoState)
+}
+
+func (s *interfaceManagerSuite) TestBatchUndo(c *C) {
+	repo, ops := s.setupBatch(c)
+
+	s.state.Lock()
+	ts, err := ifacestate.Batch(s.state, repo, ops)
+	c.Assert(err, IsNil)
+	change := s.state.NewChange("batch", "")
+	change.AddAll(ts)
+	terr := s.state.NewTask("error-trigger", "provoking total undo")
+	terr.WaitAll(ts)
+	change.AddTask(terr)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(change.Status(), Equals, state.ErrorStatus)
+
+	var conns map[string]interface{}
+	c.Assert(s.state.Get("conns", &conns), IsNil)
+	c.Check(conns, DeepEquals, map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{"interface": "test"},
+	})
+
+	// undoing the operations set up security again
+	var names []string
+	for _, call := range s.secBackend.SetupCalls[3:] {
+		names = append(names, call.SnapInfo.InstanceName())
+	}
+	c.Check(names, DeepEquals, []string{"producer", "consumer2", "producer", "consumer"})
+}
+
+func (s *interfaceManagerSuite) TestBatchSkipsExistingConnections(c *C) {
+	repo, ops := s.setupBatch(c)
+	// connecting an existing connection is a no-op
+	ops[0].Action = "connect"
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	ts, err := ifacestate.Batch(s.state, repo, ops[:1])
+	c.Assert(err, IsNil)
+	c.Check(ts.Tasks(), HasLen, 0)
+
+	ops[1].Action = "frobnicate"
+	_, err = ifacestate.Batch(s.state, repo, ops)
+	c.Check(err, ErrorMatches, `internal error: unsupported connection operation "frobnicate"`)
+}
+
 func (s *interfaceManagerSuite) TestConnectSetsHotplugKeyFromTheSlot(c *C) {
 	s.MockModel(c, nil)
 
@@ -4846,6 +5237,66 @@ func (s *interfaceManagerSuite) TestManagerTransitionConnectionsCore(c *C) {
 	})
 }
 
+func (s *interfaceManagerSuite) TestTransferConnections(c *C) {
+	s.mockIfaces(c, &ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	newInfo := s.mockSnap(c, consumer2Yaml)
+	s.mockSnap(c, producerYaml)
+
+	s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{
+			"interface": "test",
+		},
+		// consumer2 has no matching plug
+		"consumer:otherplug producer:otherslot": map[string]interface{}{
+			"interface": "test2",
+		},
+		// undesired connections are not transferred
+		"consumer:plug producer:slot2": map[string]interface{}{
+			"interface": "test", "auto": true, "undesired": true,
+		},
+	})
+
+	task := s.state.NewTask("transfer-connections", "...")
+	task.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: newInfo.SnapName(),
+			Revision: newInfo.Revision,
+		},
+	})
+	task.Set("migrate-from", "consumer")
+	change := s.state.NewChange("remodel", "")
+	change.AddTask(task)
+
+	s.state.Unlock()
+	s.se.Ensure()
+	s.se.Wait()
+	s.se.Stop()
+	s.state.Lock()
+
+	c.Assert(task.Status(), Equals, state.DoneStatus)
+
+	var connectTasks []*state.Task
+	for _, t := range change.Tasks() {
+		if t.Kind() == "connect" {
+			connectTasks = append(connectTasks, t)
+		}
+	}
+	c.Assert(connectTasks, HasLen, 1)
+	var plugRef interfaces.PlugRef
+	var slotRef interfaces.SlotRef
+	c.Assert(connectTasks[0].Get("plug", &plugRef), IsNil)
+	c.Assert(connectTasks[0].Get("slot", &slotRef), IsNil)
+	c.Check(plugRef, Equals, interfaces.PlugRef{Snap: "consumer2", Name: "plug"})
+	c.Check(slotRef, Equals, interfaces.SlotRef{Snap: "producer", Name: "slot"})
+	// the transferred connection was a manual one
+	c.Check(connectTasks[0].Has("auto"), Equals, false)
+}
+
 func (s *interfaceManagerSuite) TestManagerTransitionConnectionsCoreUndo(c *C) {
 	s.mockSnap(c, ubuntuCoreSnapYaml)
 	s.mockSnap(c, coreSnapYaml)
@@ -5117,6 +5568,16 @@ func (s *interfaceManagerSuite) TestUndoConnect(c *C) {
 	notConnected, _ := err.(*interfaces.NotConnectedError)
 	c.Check(notConnected, NotNil)
 
+	// the undo is recorded in the history
+	history, err := ifacestate.ConnectionHistory(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 2)
+	c.Check(history[0].Action, Equals, "connect")
+	c.Check(history[1].Action, Equals, "disconnect")
+	c.Check(history[1].By, Equals, ifacestate.ConnectionByUndo)
+	c.Check(history[1].Plug, Equals, cref.PlugRef)
+	c.Check(history[1].Slot, Equals, cref.SlotRef)
+
 	c.Assert(s.secBackend.SetupCalls, HasLen, 4)
 	c.Check(s.secBackend.SetupCalls[0].SnapInfo.InstanceName(), Equals, "producer")
 	c.Check(s.secBackend.SetupCalls[1].SnapInfo.InstanceName(), Equals, "consumer")
@@ -8816,3 +9277,34 @@ func (s *interfaceManagerSuite) TestResolveDisconnectMatrixTypical(c *C) {
 		}
 	}
 }
+
+func (s *interfaceManagerSuite) TestPromptingRulesFeatureDisabled(c *C) {
+	mgr := s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := mgr.PromptingRules()
+	c.Assert(err, ErrorMatches, `experimental feature disabled - test it by setting 'experimental.apparmor-prompting' to true`)
+}
+
+func (s *interfaceManagerSuite) TestPromptingRules(c *C) {
+	mgr := s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "experimental.apparmor-prompting", true)
+	tr.Commit()
+
+	rules, err := mgr.PromptingRules()
+	c.Assert(err, IsNil)
+	c.Assert(rules, NotNil)
+	c.Check(rules.Rules(1000, "", ""), HasLen, 0)
+
+	// the same rules are returned on subsequent calls
+	again, err := mgr.PromptingRules()
+	c.Assert(err, IsNil)
+	c.Check(again, Equals, rules)
+}
@@ -0,0 +1,56 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/features"
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+)
+
+// PromptingRules returns the rules created by users in reply to prompts for
+// file access by snaps, loading them on first use. It fails if the
+// experimental apparmor-prompting feature is disabled.
+//
+// The state must be locked by the caller.
+func (m *InterfaceManager) PromptingRules() (*prompting.RuleDB, error) {
+	tr := config.NewTransaction(m.state)
+	enabled, err := features.Flag(tr, features.AppArmorPrompting)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		_, confName := features.AppArmorPrompting.ConfigOption()
+		return nil, fmt.Errorf("experimental feature disabled - test it by setting '%s' to true", confName)
+	}
+
+	m.promptingRulesMu.Lock()
+	defer m.promptingRulesMu.Unlock()
+	if m.promptingRules == nil {
+		rules, err := prompting.NewRuleDB()
+		if err != nil {
+			return nil, err
+		}
+		m.promptingRules = rules
+	}
+	return m.promptingRules, nil
+}
@@ -70,6 +70,7 @@ import (
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
 	"github.com/snapcore/snapd/overlord/snapshotstate"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
@@ -180,7 +181,7 @@ func (s *baseMgrsSuite) SetUpTest(c *C) {
 	})
 
 	s.automaticSnapshots = nil
-	r := snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string) (*client.Snapshot, error) {
+	r := snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, flags *backend.SaveFlags) (*client.Snapshot, error) {
 		s.automaticSnapshots = append(s.automaticSnapshots, automaticSnapshotCall{InstanceName: si.InstanceName(), SnapConfig: cfg, Usernames: usernames})
 		return nil, nil
 	})
@@ -1027,29 +1028,44 @@ func (s *baseMgrsSuite) mockStore(c *C) *httptest.Server {
 				Snap        json.RawMessage `json:"snap"`
 				InstanceKey string          `json:"instance-key"`
 				// For assertions
-				Key           string   `json:"key"`
-				AssertionURLs []string `json:"assertion-stream-urls"`
+				Key           string                   `json:"key"`
+				AssertionURLs []string                 `json:"assertion-stream-urls"`
+				ErrorList     []map[string]interface{} `json:"error-list,omitempty"`
 			}
 			var results []resultJSON
 			for _, a := range input.Actions {
 				if a.Action == "fetch-assertions" {
 					urls := []string{}
+					var errorList []map[string]interface{}
 					for _, ar := range a.Assertions {
 						ref := &asserts.Ref{
 							Type:       asserts.Type(ar.Type),
 							PrimaryKey: ar.PrimaryKey,
 						}
 						_, err := ref.Resolve(s.storeSigning.Find)
+						if asserts.IsNotFound(err) {
+							errorList = append(errorList, map[string]interface{}{
+								"code":        "not-found",
+								"message":     "not found",
+								"type":        ar.Type,
+								"primary-key": ar.PrimaryKey,
+							})
+							continue
+						}
 						if err != nil {
-							panic("missing assertions not supported")
+							panic(err)
 						}
 						urls = append(urls, fmt.Sprintf("%s/v2/assertions/%s", baseURL.String(), ref.Unique()))
 
 					}
+					if len(errorList) != 0 {
+						urls = nil
+					}
 					results = append(results, resultJSON{
 						Result:        "fetch-assertions",
 						Key:           a.Key,
 						AssertionURLs: urls,
+						ErrorList:     errorList,
 					})
 					continue
 				}
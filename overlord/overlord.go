@@ -44,6 +44,7 @@ import (
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/patch"
+	"github.com/snapcore/snapd/overlord/registrystate"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/snapshotstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
@@ -186,6 +187,7 @@ func New(restartBehavior RestartBehavior) (*Overlord, error) {
 		return nil, err
 	}
 	healthstate.Init(hookMgr)
+	registrystate.Init(hookMgr)
 
 	// the shared task runner should be added last!
 	o.stateEng.AddManager(o.runner)
@@ -0,0 +1,255 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package registrystate implements the manager and state aspects
+// responsible for the configuration registries shared between snaps.
+package registrystate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/registry"
+)
+
+// Init registers the handler of the observe-view- hooks, run to notify
+// snaps of changes to the registry views they plug.
+func Init(hookManager *hookstate.HookManager) {
+	hookManager.Register(regexp.MustCompile("^observe-view-[-a-z0-9]+$"), newObserveViewHandler)
+}
+
+func newObserveViewHandler(ctx *hookstate.Context) hookstate.Handler {
+	return &observeViewHandler{}
+}
+
+type observeViewHandler struct{}
+
+func (h *observeViewHandler) Before() error                 { return nil }
+func (h *observeViewHandler) Done() error                   { return nil }
+func (h *observeViewHandler) Error(err error) (bool, error) { return false, nil }
+
+// databags returns the data bags of all registries, by account and
+// registry name.
+func databags(st *state.State) (map[string]map[string]registry.JSONDataBag, error) {
+	var bags map[string]map[string]registry.JSONDataBag
+	if err := st.Get("registry-databags", &bags); err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	if bags == nil {
+		bags = make(map[string]map[string]registry.JSONDataBag)
+	}
+	return bags, nil
+}
+
+func databag(st *state.State, reg *registry.Registry) (registry.JSONDataBag, error) {
+	bags, err := databags(st)
+	if err != nil {
+		return nil, err
+	}
+	if bag := bags[reg.Account][reg.Name]; bag != nil {
+		return bag, nil
+	}
+	return registry.NewJSONDataBag(), nil
+}
+
+func setDatabag(st *state.State, reg *registry.Registry, bag registry.JSONDataBag) error {
+	bags, err := databags(st)
+	if err != nil {
+		return err
+	}
+	if bags[reg.Account] == nil {
+		bags[reg.Account] = make(map[string]registry.JSONDataBag)
+	}
+	bags[reg.Account][reg.Name] = bag
+	st.Set("registry-databags", bags)
+	return nil
+}
+
+// View returns the view of the registry as defined by the registry
+// assertion of the given account.
+func View(st *state.State, account, registryName, viewName string) (*registry.View, error) {
+	a, err := assertstate.DB(st).Find(asserts.RegistryType, map[string]string{
+		"account-id": account,
+		"name":       registryName,
+	})
+	if asserts.IsNotFound(err) {
+		return nil, fmt.Errorf("cannot find registry %s/%s: no registry assertion", account, registryName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	reg := a.(*asserts.Registry).Registry()
+	view := reg.View(viewName)
+	if view == nil {
+		return nil, fmt.Errorf("cannot find view %q in registry %s/%s", viewName, account, registryName)
+	}
+	return view, nil
+}
+
+// PlugView returns the view of the registry accessed through the given
+// registry plug of the snap, which must be connected.
+func PlugView(st *state.State, snapName, plugName string) (*registry.View, error) {
+	repo := ifacerepo.Get(st)
+	plug := repo.Plug(snapName, plugName)
+	if plug == nil || plug.Interface != "registry" {
+		return nil, fmt.Errorf("cannot find registry plug %q of snap %q", plugName, snapName)
+	}
+	conns, err := repo.Connected(snapName, plugName)
+	if err != nil {
+		return nil, err
+	}
+	if len(conns) == 0 {
+		return nil, fmt.Errorf("cannot access registry through plug %q of snap %q: plug is not connected", plugName, snapName)
+	}
+	account, registryName, viewName, err := plugViewAttrs(plug.Attrs)
+	if err != nil {
+		return nil, err
+	}
+	return View(st, account, registryName, viewName)
+}
+
+func plugViewAttrs(attrs map[string]interface{}) (account, registryName, viewName string, err error) {
+	account, _ = attrs["account"].(string)
+	view, _ := attrs["view"].(string)
+	parts := strings.Split(view, "/")
+	if account == "" || len(parts) != 2 {
+		return "", "", "", fmt.Errorf("internal error: invalid registry plug attributes")
+	}
+	return account, parts[0], parts[1], nil
+}
+
+// Get returns the values of the requests through the view. If no requests
+// are given, everything the view can read is returned.
+func Get(st *state.State, view *registry.View, requests []string) (map[string]interface{}, error) {
+	bag, err := databag(st, view.Registry())
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		value, err := view.Get(bag, "")
+		if registry.IsNotFound(err) {
+			return map[string]interface{}{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return value.(map[string]interface{}), nil
+	}
+	values := make(map[string]interface{}, len(requests))
+	for _, request := range requests {
+		value, err := view.Get(bag, request)
+		if err != nil {
+			return nil, err
+		}
+		values[request] = value
+	}
+	return values, nil
+}
+
+// Set sets the values of the requests through the view, a nil value
+// unsets the request. Either all values are set or none. The snaps
+// plugging views of the registry whose values changed as a result, other
+// than originSnap, are notified by running their observe-view-<plug>
+// hook, in the returned change, which is nil if there are none.
+func Set(st *state.State, view *registry.View, values map[string]interface{}, originSnap string) (*state.Change, error) {
+	reg := view.Registry()
+	bag, err := databag(st, reg)
+	if err != nil {
+		return nil, err
+	}
+	newBag := bag.Copy()
+
+	requests := make([]string, 0, len(values))
+	for request := range values {
+		requests = append(requests, request)
+	}
+	// shorter requests first, so that more specific ones override them
+	sort.Strings(requests)
+	for _, request := range requests {
+		if err := view.Set(newBag, request, values[request]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := setDatabag(st, reg, newBag); err != nil {
+		return nil, err
+	}
+	return notifyObservers(st, reg, bag, newBag, originSnap)
+}
+
+func notifyObservers(st *state.State, reg *registry.Registry, oldBag, newBag registry.JSONDataBag, originSnap string) (*state.Change, error) {
+	repo := ifacerepo.Get(st)
+	var tasks []*state.Task
+	for _, plug := range repo.AllPlugs("registry") {
+		snapName := plug.Snap.InstanceName()
+		if snapName == originSnap {
+			continue
+		}
+		hookName := "observe-view-" + plug.Name
+		if plug.Snap.Hooks[hookName] == nil {
+			continue
+		}
+		account, registryName, viewName, err := plugViewAttrs(plug.Attrs)
+		if err != nil || account != reg.Account || registryName != reg.Name {
+			continue
+		}
+		view := reg.View(viewName)
+		if view == nil {
+			continue
+		}
+		conns, err := repo.Connected(snapName, plug.Name)
+		if err != nil || len(conns) == 0 {
+			continue
+		}
+		oldValue, _ := view.Get(oldBag, "")
+		newValue, _ := view.Get(newBag, "")
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		hooksup := &hookstate.HookSetup{
+			Snap:     snapName,
+			Hook:     hookName,
+			Optional: true,
+		}
+		summary := fmt.Sprintf(i18n.G("Run hook %s of snap %q"), hooksup.Hook, hooksup.Snap)
+		tasks = append(tasks, hookstate.HookTask(st, summary, hooksup, nil))
+	}
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+
+	summary := fmt.Sprintf(i18n.G("Notify snaps of changes to registry %s/%s"), reg.Account, reg.Name)
+	chg := st.NewChange("observe-registry", summary)
+	for _, t := range tasks {
+		// the hooks of different snaps can run in parallel
+		chg.AddTask(t)
+	}
+	st.EnsureBefore(0)
+	return chg, nil
+}
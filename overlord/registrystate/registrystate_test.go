@@ -0,0 +1,227 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package registrystate_test
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
+	"github.com/snapcore/snapd/overlord/registrystate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type registrySuite struct {
+	state *state.State
+	repo  *interfaces.Repository
+}
+
+var _ = Suite(&registrySuite{})
+
+const coreYaml = `name: core
+version: 1
+type: os
+slots:
+ registry:
+`
+
+const managerYaml = `name: manager
+version: 1
+plugs:
+ admin:
+  interface: registry
+  account: can0nical
+  view: network/wifi-admin
+hooks:
+ observe-view-admin:
+`
+
+const setupYaml = `name: setup
+version: 1
+plugs:
+ wifi:
+  interface: registry
+  account: can0nical
+  view: network/wifi-setup
+hooks:
+ observe-view-wifi:
+`
+
+func (s *registrySuite) SetUpTest(c *C) {
+	s.state = state.New(nil)
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	storeSigning := assertstest.NewStoreStack("can0nical", nil)
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   storeSigning.Trusted,
+	})
+	c.Assert(err, IsNil)
+	assertstate.ReplaceDB(s.state, db)
+	c.Assert(db.Add(storeSigning.StoreAccountKey("")), IsNil)
+
+	a, err := storeSigning.Sign(asserts.RegistryType, map[string]interface{}{
+		"authority-id": "can0nical",
+		"account-id":   "can0nical",
+		"name":         "network",
+		"views": map[string]interface{}{
+			"wifi-setup": map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{"request": "ssid", "storage": "wifi.ssid"},
+					map[string]interface{}{"request": "password", "storage": "wifi.psk", "access": "write"},
+				},
+			},
+			"wifi-admin": map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{"storage": "wifi"},
+				},
+			},
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(assertstate.Add(s.state, a), IsNil)
+
+	s.repo = interfaces.NewRepository()
+	for _, iface := range builtin.Interfaces() {
+		c.Assert(s.repo.AddInterface(iface), IsNil)
+	}
+	for _, yaml := range []string{coreYaml, managerYaml, setupYaml} {
+		c.Assert(s.repo.AddSnap(snaptest.MockInfo(c, yaml, nil)), IsNil)
+	}
+	for _, plug := range []interfaces.PlugRef{{Snap: "manager", Name: "admin"}, {Snap: "setup", Name: "wifi"}} {
+		_, err := s.repo.Connect(&interfaces.ConnRef{PlugRef: plug, SlotRef: interfaces.SlotRef{Snap: "core", Name: "registry"}}, nil, nil, nil, nil, nil)
+		c.Assert(err, IsNil)
+	}
+	ifacerepo.Replace(s.state, s.repo)
+}
+
+func (s *registrySuite) TestView(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	view, err := registrystate.View(s.state, "can0nical", "network", "wifi-setup")
+	c.Assert(err, IsNil)
+	c.Check(view.Name, Equals, "wifi-setup")
+	c.Check(view.Registry().Name, Equals, "network")
+
+	_, err = registrystate.View(s.state, "can0nical", "foo", "wifi-setup")
+	c.Check(err, ErrorMatches, `cannot find registry can0nical/foo: no registry assertion`)
+	_, err = registrystate.View(s.state, "can0nical", "network", "foo")
+	c.Check(err, ErrorMatches, `cannot find view "foo" in registry can0nical/network`)
+}
+
+func (s *registrySuite) TestPlugView(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	view, err := registrystate.PlugView(s.state, "setup", "wifi")
+	c.Assert(err, IsNil)
+	c.Check(view.Name, Equals, "wifi-setup")
+
+	_, err = registrystate.PlugView(s.state, "setup", "foo")
+	c.Check(err, ErrorMatches, `cannot find registry plug "foo" of snap "setup"`)
+
+	c.Assert(s.repo.Disconnect("setup", "wifi", "core", "registry"), IsNil)
+	_, err = registrystate.PlugView(s.state, "setup", "wifi")
+	c.Check(err, ErrorMatches, `cannot access registry through plug "wifi" of snap "setup": plug is not connected`)
+}
+
+func (s *registrySuite) TestSetGet(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	setup, err := registrystate.PlugView(s.state, "setup", "wifi")
+	c.Assert(err, IsNil)
+	admin, err := registrystate.PlugView(s.state, "manager", "admin")
+	c.Assert(err, IsNil)
+
+	values, err := registrystate.Get(s.state, setup, nil)
+	c.Assert(err, IsNil)
+	c.Check(values, DeepEquals, map[string]interface{}{})
+
+	chg, err := registrystate.Set(s.state, setup, map[string]interface{}{
+		"ssid":     "home",
+		"password": "secret",
+	}, "setup")
+	c.Assert(err, IsNil)
+	// the manager snap observes the change
+	c.Assert(chg, NotNil)
+	c.Check(chg.Kind(), Equals, "observe-registry")
+	c.Assert(chg.Tasks(), HasLen, 1)
+	var hooksup hookstate.HookSetup
+	c.Assert(chg.Tasks()[0].Get("hook-setup", &hooksup), IsNil)
+	c.Check(hooksup, Equals, hookstate.HookSetup{Snap: "manager", Hook: "observe-view-admin", Optional: true})
+
+	values, err = registrystate.Get(s.state, setup, []string{"ssid"})
+	c.Assert(err, IsNil)
+	c.Check(values, DeepEquals, map[string]interface{}{"ssid": "home"})
+	values, err = registrystate.Get(s.state, admin, nil)
+	c.Assert(err, IsNil)
+	c.Check(values, DeepEquals, map[string]interface{}{
+		"wifi": map[string]interface{}{"ssid": "home", "psk": "secret"},
+	})
+
+	// the password cannot be read back through the setup view
+	_, err = registrystate.Get(s.state, setup, []string{"password"})
+	c.Check(err, ErrorMatches, `cannot find "password" in view can0nical/network/wifi-setup: .*`)
+
+	// changing something the setup view cannot see does not notify it
+	chg, err = registrystate.Set(s.state, admin, map[string]interface{}{"wifi.psk": "other"}, "manager")
+	c.Assert(err, IsNil)
+	c.Check(chg, IsNil)
+
+	chg, err = registrystate.Set(s.state, admin, map[string]interface{}{"wifi.ssid": "work"}, "manager")
+	c.Assert(err, IsNil)
+	c.Assert(chg, NotNil)
+	c.Assert(chg.Tasks(), HasLen, 1)
+	c.Assert(chg.Tasks()[0].Get("hook-setup", &hooksup), IsNil)
+	c.Check(hooksup.Snap, Equals, "setup")
+}
+
+func (s *registrySuite) TestSetAllOrNothing(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	setup, err := registrystate.PlugView(s.state, "setup", "wifi")
+	c.Assert(err, IsNil)
+
+	_, err = registrystate.Set(s.state, setup, map[string]interface{}{
+		"ssid": "home",
+		"foo":  "bar",
+	}, "setup")
+	c.Check(err, ErrorMatches, `cannot find "foo" in view can0nical/network/wifi-setup: no matching write rule`)
+
+	values, err := registrystate.Get(s.state, setup, nil)
+	c.Assert(err, IsNil)
+	c.Check(values, DeepEquals, map[string]interface{}{})
+}
@@ -109,7 +109,7 @@ func PatchQuotas(st *state.State, grps ...*quota.Group) (map[string]*quota.Group
 
 // CreateQuotaInState creates a quota group with the given paremeters
 // in the state.  It takes the current map of all quota groups.
-func CreateQuotaInState(st *state.State, quotaName string, parentGrp *quota.Group, snaps []string, memoryLimit quantity.Size, allGrps map[string]*quota.Group) (*quota.Group, map[string]*quota.Group, error) {
+func CreateQuotaInState(st *state.State, quotaName string, parentGrp *quota.Group, snaps []string, memoryLimit quantity.Size, cpuSet []int, journalLimit *quota.JournalQuota, allGrps map[string]*quota.Group) (*quota.Group, map[string]*quota.Group, error) {
 	// make sure that the parent group exists if we are creating a sub-group
 	var grp *quota.Group
 	var err error
@@ -130,6 +130,18 @@ func CreateQuotaInState(st *state.State, quotaName string, parentGrp *quota.Grou
 	}
 	updatedGrps = append(updatedGrps, grp)
 
+	if len(cpuSet) != 0 {
+		if err := grp.SetCPUSet(cpuSet); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if journalLimit != nil {
+		if err := grp.SetJournalLimit(journalLimit); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// put the snaps in the group
 	grp.Snaps = snaps
 	// update the modified groups in state
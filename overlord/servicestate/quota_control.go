@@ -30,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/overlord/servicestate/internal"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/systemd"
 )
@@ -83,13 +84,43 @@ func quotaGroupsAvailable(st *state.State) error {
 	return nil
 }
 
+func quotaCPUSetAvailable(cpuSet []int) error {
+	if len(cpuSet) == 0 {
+		return nil
+	}
+	// AllowedCPUs= was introduced in systemd 244
+	if systemdVersion < 244 {
+		return fmt.Errorf("systemd version too old: snap quota CPU sets require systemd 244 and newer (currently have %d)", systemdVersion)
+	}
+	return nil
+}
+
+func quotaJournalAvailable(journalLimit *quota.JournalQuota) error {
+	if journalLimit == nil {
+		return nil
+	}
+	// LogNamespace= was introduced in systemd 245
+	if systemdVersion < 245 {
+		return fmt.Errorf("systemd version too old: snap quota journal limits require systemd 245 and newer (currently have %d)", systemdVersion)
+	}
+	return nil
+}
+
 // CreateQuota attempts to create the specified quota group with the specified
-// snaps in it.
+// snaps in it. The processes of the group are pinned to the CPUs in cpuSet if
+// it is not empty, and their services log to a journal namespace with the
+// given limits if journalLimit is set.
 // TODO: should this use something like QuotaGroupUpdate with fewer fields?
-func CreateQuota(st *state.State, name string, parentName string, snaps []string, memoryLimit quantity.Size) (*state.TaskSet, error) {
+func CreateQuota(st *state.State, name string, parentName string, snaps []string, memoryLimit quantity.Size, cpuSet []int, journalLimit *quota.JournalQuota) (*state.TaskSet, error) {
 	if err := quotaGroupsAvailable(st); err != nil {
 		return nil, err
 	}
+	if err := quotaCPUSetAvailable(cpuSet); err != nil {
+		return nil, err
+	}
+	if err := quotaJournalAvailable(journalLimit); err != nil {
+		return nil, err
+	}
 
 	allGrps, err := AllQuotas(st)
 	if err != nil {
@@ -127,11 +158,13 @@ func CreateQuota(st *state.State, name string, parentName string, snaps []string
 
 	// create the task with the action in it
 	qc := QuotaControlAction{
-		Action:      "create",
-		QuotaName:   name,
-		MemoryLimit: memoryLimit,
-		AddSnaps:    snaps,
-		ParentName:  parentName,
+		Action:       "create",
+		QuotaName:    name,
+		MemoryLimit:  memoryLimit,
+		CPUSet:       cpuSet,
+		JournalLimit: journalLimit,
+		AddSnaps:     snaps,
+		ParentName:   parentName,
 	}
 
 	ts := state.NewTaskSet()
@@ -203,6 +236,14 @@ type QuotaGroupUpdate struct {
 	// NewMemoryLimit is the new memory limit to be used for the quota group. If
 	// zero, then the quota group's memory limit is not changed.
 	NewMemoryLimit quantity.Size
+
+	// NewCPUSet is the new set of CPUs the processes of the quota group are
+	// pinned to. If empty, then the quota group's CPU set is not changed.
+	NewCPUSet []int
+
+	// NewJournalLimit is the new limit of the journal namespace of the quota
+	// group. If nil, then the quota group's journal limit is not changed.
+	NewJournalLimit *quota.JournalQuota
 }
 
 // UpdateQuota updates the quota as per the options.
@@ -224,6 +265,13 @@ func UpdateQuota(st *state.State, name string, updateOpts QuotaGroupUpdate) (*st
 		return nil, fmt.Errorf("group %q does not exist", name)
 	}
 
+	if err := quotaCPUSetAvailable(updateOpts.NewCPUSet); err != nil {
+		return nil, err
+	}
+	if err := quotaJournalAvailable(updateOpts.NewJournalLimit); err != nil {
+		return nil, err
+	}
+
 	// check that the memory limit is not being decreased
 	if updateOpts.NewMemoryLimit != 0 {
 		// we disallow decreasing the memory limit because it is difficult to do
@@ -250,10 +298,12 @@ func UpdateQuota(st *state.State, name string, updateOpts QuotaGroupUpdate) (*st
 
 	// create the action and the correspoding task set
 	qc := QuotaControlAction{
-		Action:      "update",
-		QuotaName:   name,
-		MemoryLimit: updateOpts.NewMemoryLimit,
-		AddSnaps:    updateOpts.AddSnaps,
+		Action:       "update",
+		QuotaName:    name,
+		MemoryLimit:  updateOpts.NewMemoryLimit,
+		CPUSet:       updateOpts.NewCPUSet,
+		JournalLimit: updateOpts.NewJournalLimit,
+		AddSnaps:     updateOpts.AddSnaps,
 	}
 
 	ts := state.NewTaskSet()
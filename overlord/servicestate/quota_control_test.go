@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/systemd"
@@ -216,7 +217,7 @@ func (s *quotaControlSuite) TestCreateQuotaNotEnabled(c *C) {
 	tr.Commit()
 
 	// try to create an empty quota group
-	_, err := servicestate.CreateQuota(s.state, "foo", "", nil, quantity.SizeGiB)
+	_, err := servicestate.CreateQuota(s.state, "foo", "", nil, quantity.SizeGiB, nil, nil)
 	c.Assert(err, ErrorMatches, `experimental feature disabled - test it by setting 'experimental.quota-groups' to true`)
 }
 
@@ -230,10 +231,43 @@ func (s *quotaControlSuite) TestCreateQuotaSystemdTooOld(c *C) {
 	err := servicestate.CheckSystemdVersion()
 	c.Assert(err, IsNil)
 
-	_, err = servicestate.CreateQuota(s.state, "foo", "", nil, quantity.SizeGiB)
+	_, err = servicestate.CreateQuota(s.state, "foo", "", nil, quantity.SizeGiB, nil, nil)
 	c.Assert(err, ErrorMatches, `systemd version too old: snap quotas requires systemd 230 and newer \(currently have 229\)`)
 }
 
+func (s *quotaControlSuite) TestQuotaCPUSetSystemdTooOld(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	r := servicestate.MockSystemdVersion(243)
+	defer r()
+
+	_, err := servicestate.CreateQuota(s.state, "foo", "", nil, quantity.SizeGiB, []int{0}, nil)
+	c.Assert(err, ErrorMatches, `systemd version too old: snap quota CPU sets require systemd 244 and newer \(currently have 243\)`)
+
+	err = servicestatetest.MockQuotaInState(s.state, "foo", "", nil, quantity.SizeGiB)
+	c.Assert(err, IsNil)
+	_, err = servicestate.UpdateQuota(s.state, "foo", servicestate.QuotaGroupUpdate{NewCPUSet: []int{0}})
+	c.Assert(err, ErrorMatches, `systemd version too old: snap quota CPU sets require systemd 244 and newer \(currently have 243\)`)
+}
+
+func (s *quotaControlSuite) TestQuotaJournalLimitSystemdTooOld(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	r := servicestate.MockSystemdVersion(244)
+	defer r()
+
+	journal := &quota.JournalQuota{Size: quantity.SizeMiB}
+	_, err := servicestate.CreateQuota(s.state, "foo", "", nil, quantity.SizeGiB, nil, journal)
+	c.Assert(err, ErrorMatches, `systemd version too old: snap quota journal limits require systemd 245 and newer \(currently have 244\)`)
+
+	err = servicestatetest.MockQuotaInState(s.state, "foo", "", nil, quantity.SizeGiB)
+	c.Assert(err, IsNil)
+	_, err = servicestate.UpdateQuota(s.state, "foo", servicestate.QuotaGroupUpdate{NewJournalLimit: journal})
+	c.Assert(err, ErrorMatches, `systemd version too old: snap quota journal limits require systemd 245 and newer \(currently have 244\)`)
+}
+
 func (s *quotaControlSuite) TestCreateQuotaPrecond(c *C) {
 	st := s.state
 	st.Lock()
@@ -255,7 +289,7 @@ func (s *quotaControlSuite) TestCreateQuotaPrecond(c *C) {
 	}
 
 	for _, t := range tests {
-		_, err := servicestate.CreateQuota(st, t.name, "", t.snaps, t.mem)
+		_, err := servicestate.CreateQuota(st, t.name, "", t.snaps, t.mem, nil, nil)
 		c.Check(err, ErrorMatches, t.err)
 	}
 }
@@ -272,7 +306,7 @@ func (s *quotaControlSuite) TestRemoveQuotaPreseeding(c *C) {
 	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
 
 	// create a quota group
-	ts, err := servicestate.CreateQuota(s.state, "foo", "", []string{"test-snap"}, quantity.SizeGiB)
+	ts, err := servicestate.CreateQuota(s.state, "foo", "", []string{"test-snap"}, quantity.SizeGiB, nil, nil)
 	c.Assert(err, IsNil)
 
 	chg := st.NewChange("quota-control", "...")
@@ -333,7 +367,7 @@ func (s *quotaControlSuite) TestCreateUpdateRemoveQuotaHappy(c *C) {
 	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
 
 	// create the quota group
-	ts, err := servicestate.CreateQuota(st, "foo", "", []string{"test-snap"}, quantity.SizeGiB)
+	ts, err := servicestate.CreateQuota(st, "foo", "", []string{"test-snap"}, quantity.SizeGiB, nil, nil)
 	c.Assert(err, IsNil)
 
 	chg := st.NewChange("quota-control", "...")
@@ -454,7 +488,7 @@ func (s *quotaControlSuite) TestEnsureSnapAbsentFromQuotaGroup(c *C) {
 	snaptest.MockSnapCurrent(c, testYaml2, si2)
 
 	// create a quota group
-	ts, err := servicestate.CreateQuota(s.state, "foo", "", []string{"test-snap", "test-snap2"}, quantity.SizeGiB)
+	ts, err := servicestate.CreateQuota(s.state, "foo", "", []string{"test-snap", "test-snap2"}, quantity.SizeGiB, nil, nil)
 	c.Assert(err, IsNil)
 
 	chg := st.NewChange("quota-control", "...")
@@ -569,7 +603,7 @@ func (s *quotaControlSuite) TestRemoveQuotaPrecond(c *C) {
 }
 
 func (s *quotaControlSuite) createQuota(c *C, name string, limit quantity.Size, snaps ...string) {
-	ts, err := servicestate.CreateQuota(s.state, name, "", snaps, limit)
+	ts, err := servicestate.CreateQuota(s.state, name, "", snaps, limit, nil, nil)
 	c.Assert(err, IsNil)
 
 	chg := s.state.NewChange("quota-control", "...")
@@ -634,7 +668,7 @@ func (s *quotaControlSuite) TestSnapOpCreateQuotaConflict(c *C) {
 	chg1 := s.state.NewChange("disable", "...")
 	chg1.AddAll(ts)
 
-	_, err = servicestate.CreateQuota(s.state, "foo", "", []string{"test-snap"}, quantity.SizeGiB)
+	_, err = servicestate.CreateQuota(s.state, "foo", "", []string{"test-snap"}, quantity.SizeGiB, nil, nil)
 	c.Assert(err, ErrorMatches, `snap "test-snap" has "disable" change in progress`)
 }
 
@@ -675,7 +709,7 @@ func (s *quotaControlSuite) TestCreateQuotaSnapOpConflict(c *C) {
 	snapstate.Set(s.state, "test-snap", s.testSnapState)
 	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
 
-	ts, err := servicestate.CreateQuota(s.state, "foo", "", []string{"test-snap"}, quantity.SizeGiB)
+	ts, err := servicestate.CreateQuota(s.state, "foo", "", []string{"test-snap"}, quantity.SizeGiB, nil, nil)
 	c.Assert(err, IsNil)
 	chg1 := s.state.NewChange("quota-control", "...")
 	chg1.AddAll(ts)
@@ -922,11 +956,11 @@ func (s *quotaControlSuite) TestCreateQuotaCreateQuotaConflict(c *C) {
 	snapstate.Set(s.state, "test-snap2", snapst2)
 	snaptest.MockSnapCurrent(c, testYaml2, si2)
 
-	ts, err := servicestate.CreateQuota(st, "foo", "", []string{"test-snap"}, quantity.SizeGiB)
+	ts, err := servicestate.CreateQuota(st, "foo", "", []string{"test-snap"}, quantity.SizeGiB, nil, nil)
 	c.Assert(err, IsNil)
 	chg1 := s.state.NewChange("quota-control", "...")
 	chg1.AddAll(ts)
 
-	_, err = servicestate.CreateQuota(st, "foo", "", []string{"test-snap2"}, 2*quantity.SizeGiB)
+	_, err = servicestate.CreateQuota(st, "foo", "", []string{"test-snap2"}, 2*quantity.SizeGiB, nil, nil)
 	c.Assert(err, ErrorMatches, `quota group "foo" has "quota-control" change in progress`)
 }
@@ -62,6 +62,16 @@ type QuotaControlAction struct {
 	// value to be set.
 	MemoryLimit quantity.Size
 
+	// CPUSet is the set of CPUs the processes in the quota group are allowed
+	// to run on, either the initial set for the "create" action, or if
+	// non-empty for the "update" action, the new set to use.
+	CPUSet []int `json:"cpu-set,omitempty"`
+
+	// JournalLimit is the limit of the journal namespace of the quota group,
+	// either the initial limit for the "create" action, or if set for the
+	// "update" action, the new limit to use.
+	JournalLimit *quota.JournalQuota `json:"journal-limit,omitempty"`
+
 	// ParentName is the name of the parent for the quota group if it is being
 	// created. Eventually this could be used with the "update" action to
 	// support moving quota groups from one parent to another, but that is
@@ -268,7 +278,7 @@ func quotaCreate(st *state.State, action QuotaControlAction, allGrps map[string]
 		return nil, nil, err
 	}
 
-	return internal.CreateQuotaInState(st, action.QuotaName, parentGrp, action.AddSnaps, action.MemoryLimit, allGrps)
+	return internal.CreateQuotaInState(st, action.QuotaName, parentGrp, action.AddSnaps, action.MemoryLimit, action.CPUSet, action.JournalLimit, allGrps)
 }
 
 func quotaRemove(st *state.State, action QuotaControlAction, allGrps map[string]*quota.Group) (*quota.Group, map[string]*quota.Group, error) {
@@ -292,6 +302,14 @@ func quotaRemove(st *state.State, action QuotaControlAction, allGrps map[string]
 		return nil, nil, fmt.Errorf("internal error, MemoryLimit option cannot be used with remove action")
 	}
 
+	if len(action.CPUSet) != 0 {
+		return nil, nil, fmt.Errorf("internal error, CPUSet option cannot be used with remove action")
+	}
+
+	if action.JournalLimit != nil {
+		return nil, nil, fmt.Errorf("internal error, JournalLimit option cannot be used with remove action")
+	}
+
 	// XXX: remove this limitation eventually
 	if len(grp.SubGroups) != 0 {
 		return nil, nil, fmt.Errorf("cannot remove quota group with sub-groups, remove the sub-groups first")
@@ -378,6 +396,20 @@ func quotaUpdate(st *state.State, action QuotaControlAction, allGrps map[string]
 		grp.MemoryLimit = action.MemoryLimit
 	}
 
+	// if the CPU set is not empty then change it too
+	if len(action.CPUSet) != 0 {
+		if err := grp.SetCPUSet(action.CPUSet); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// if the journal limit is set then change it too
+	if action.JournalLimit != nil {
+		if err := grp.SetJournalLimit(action.JournalLimit); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// update the quota group state
 	allGrps, err := internal.PatchQuotas(st, modifiedGrps...)
 	if err != nil {
@@ -453,6 +485,7 @@ func ensureSnapServicesForGroup(st *state.State, t *state.Task, grp *quota.Group
 	}
 
 	grpsToStart := []*quota.Group{}
+	journalNamespacesToRestart := []string{}
 	appsToRestartBySnap = map[*snap.Info][]*snap.AppInfo{}
 
 	collectModifiedUnits := func(app *snap.AppInfo, grp *quota.Group, unitType string, name, old, new string) {
@@ -492,6 +525,14 @@ func ensureSnapServicesForGroup(st *state.State, t *state.Task, grp *quota.Group
 				grpsToStart = append(grpsToStart, grp)
 			}
 
+		case "journald":
+			// journald only reads the configuration of a namespace when
+			// it is started, so restart an already configured namespace
+			// to apply the new limits
+			if old != "" {
+				journalNamespacesToRestart = append(journalNamespacesToRestart, grp.JournalNamespaceName())
+			}
+
 		case "service":
 			// in this case, the only way that a service could have been changed
 			// was if it was moved into or out of a slice, in both cases we need
@@ -524,6 +565,12 @@ func ensureSnapServicesForGroup(st *state.State, t *state.Task, grp *quota.Group
 		}
 	}
 
+	for _, ns := range journalNamespacesToRestart {
+		if err := systemSysd.Restart(fmt.Sprintf("systemd-journald@%s.service", ns), 5*time.Second); err != nil {
+			return nil, err
+		}
+	}
+
 	// after starting all the grps that we modified from EnsureSnapServices,
 	// we need to handle the case where a quota was removed, this will only
 	// happen one at a time and can be identified by the grp provided to us
@@ -20,17 +20,24 @@
 package servicestate_test
 
 import (
+	"fmt"
+	"path/filepath"
+	"time"
+
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/systemd"
+	"github.com/snapcore/snapd/testutil"
 )
 
 type quotaHandlersSuite struct {
@@ -1060,6 +1067,121 @@ func (s *quotaHandlersSuite) TestQuotaUpdateChangeMemLimit(c *C) {
 	c.Assert(err, ErrorMatches, "cannot decrease memory limit of existing quota-group, remove and re-create it to decrease the limit")
 }
 
+func (s *quotaHandlersSuite) TestQuotaCPUSet(c *C) {
+	r := s.mockSystemctlCalls(c, join(
+		// CreateQuota for foo
+		systemctlCallsForCreateQuota("foo", "test-snap"),
+
+		// UpdateQuota for foo - an existing slice was changed, so all we need
+		// to is daemon-reload
+		[]expectedSystemctl{{expArgs: []string{"daemon-reload"}}},
+	))
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	// setup the snap so it exists
+	snapstate.Set(s.state, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
+
+	// create a quota group pinned to some CPUs
+	qc := servicestate.QuotaControlAction{
+		Action:      "create",
+		QuotaName:   "foo",
+		MemoryLimit: quantity.SizeGiB,
+		CPUSet:      []int{0, 1},
+		AddSnaps:    []string{"test-snap"},
+	}
+	err := s.callDoQuotaControl(&qc)
+	c.Assert(err, IsNil)
+
+	sliceFileName := filepath.Join(dirs.SnapServicesDir, "snap.foo.slice")
+	c.Check(sliceFileName, testutil.FileContains, "\nAllowedCPUs=0,1\n")
+
+	// pin it to other CPUs
+	qc2 := servicestate.QuotaControlAction{
+		Action:    "update",
+		QuotaName: "foo",
+		CPUSet:    []int{2, 3},
+	}
+	err = s.callDoQuotaControl(&qc2)
+	c.Assert(err, IsNil)
+
+	grp, err := servicestate.GetQuota(st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(grp.CPUSet, DeepEquals, []int{2, 3})
+	c.Check(grp.MemoryLimit, Equals, quantity.SizeGiB)
+	c.Check(sliceFileName, testutil.FileContains, "\nAllowedCPUs=2,3\n")
+
+	// invalid CPU sets are rejected
+	qc3 := servicestate.QuotaControlAction{
+		Action:    "update",
+		QuotaName: "foo",
+		CPUSet:    []int{2, 2},
+	}
+	err = s.callDoQuotaControl(&qc3)
+	c.Assert(err, ErrorMatches, "CPU 2 is repeated in group CPU set")
+}
+
+func (s *quotaHandlersSuite) TestQuotaJournalLimit(c *C) {
+	r := s.mockSystemctlCalls(c, join(
+		// CreateQuota for foo
+		systemctlCallsForCreateQuota("foo", "test-snap"),
+
+		// UpdateQuota for foo - only the configuration of the journal
+		// namespace was changed, so the namespace is restarted
+		[]expectedSystemctl{
+			{expArgs: []string{"stop", "systemd-journald@snap-foo.service"}},
+			{
+				expArgs: []string{"show", "--property=ActiveState", "systemd-journald@snap-foo.service"},
+				output:  "ActiveState=inactive",
+			},
+			{expArgs: []string{"start", "systemd-journald@snap-foo.service"}},
+		},
+	))
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	// setup the snap so it exists
+	snapstate.Set(s.state, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
+
+	// create a quota group with its own journal namespace
+	qc := servicestate.QuotaControlAction{
+		Action:       "create",
+		QuotaName:    "foo",
+		MemoryLimit:  quantity.SizeGiB,
+		JournalLimit: &quota.JournalQuota{Size: 64 * quantity.SizeMiB},
+		AddSnaps:     []string{"test-snap"},
+	}
+	err := s.callDoQuotaControl(&qc)
+	c.Assert(err, IsNil)
+
+	svcFileName := filepath.Join(dirs.SnapServicesDir, "snap.test-snap.svc1.service")
+	c.Check(svcFileName, testutil.FileContains, "\nLogNamespace=snap-foo\n")
+	journalConfFileName := filepath.Join(dirs.SnapSystemdDir, "journald@snap-foo.conf")
+	c.Check(journalConfFileName, testutil.FileContains, fmt.Sprintf("\nSystemMaxUse=%d\n", 64*quantity.SizeMiB))
+
+	// rate limit the journal
+	qc2 := servicestate.QuotaControlAction{
+		Action:       "update",
+		QuotaName:    "foo",
+		JournalLimit: &quota.JournalQuota{RateCount: 10, RatePeriod: time.Second},
+	}
+	err = s.callDoQuotaControl(&qc2)
+	c.Assert(err, IsNil)
+
+	grp, err := servicestate.GetQuota(st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(grp.JournalLimit, DeepEquals, &quota.JournalQuota{RateCount: 10, RatePeriod: time.Second})
+	c.Check(journalConfFileName, testutil.FileContains, "\nRateLimitIntervalSec=1000000us\nRateLimitBurst=10\n")
+}
+
 func (s *quotaHandlersSuite) TestQuotaUpdateAddSnap(c *C) {
 	r := s.mockSystemctlCalls(c, join(
 		// CreateQuota for foo
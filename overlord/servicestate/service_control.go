@@ -55,6 +55,10 @@ type ServiceAction struct {
 	// inactive and not disabled services in snap-name, and also svc1 regardless
 	// of the state svc1 is in.
 	ExplicitServices []string `json:"explicit-services,omitempty"`
+	// Users restricts the action on user daemons to the sessions of the
+	// users with the given uids, all sessions are targeted if it is
+	// empty.
+	Users []int `json:"users,omitempty"`
 }
 
 func (m *ServiceManager) doServiceControl(t *state.Task, _ *tomb.Tomb) error {
@@ -127,6 +131,7 @@ func (m *ServiceManager) doServiceControl(t *state.Task, _ *tomb.Tomb) error {
 		disable := sc.ActionModifier == "disable"
 		flags := &wrappers.StopServicesFlags{
 			Disable: disable,
+			Users:   sc.Users,
 		}
 		st.Unlock()
 		err := wrappers.StopServices(services, flags, snap.StopReasonOther, meter, perfTimings)
@@ -151,6 +156,7 @@ func (m *ServiceManager) doServiceControl(t *state.Task, _ *tomb.Tomb) error {
 		enable := sc.ActionModifier == "enable"
 		flags := &wrappers.StartServicesFlags{
 			Enable: enable,
+			Users:  sc.Users,
 		}
 		st.Unlock()
 		err = wrappers.StartServices(startupOrdered, nil, flags, meter, perfTimings)
@@ -172,12 +178,13 @@ func (m *ServiceManager) doServiceControl(t *state.Task, _ *tomb.Tomb) error {
 			}
 		}
 	case "restart":
+		flags := &wrappers.RestartServicesFlags{Users: sc.Users}
 		st.Unlock()
-		err := wrappers.RestartServices(startupOrdered, explicitServicesSystemdUnits, nil, meter, perfTimings)
+		err := wrappers.RestartServices(startupOrdered, explicitServicesSystemdUnits, flags, meter, perfTimings)
 		st.Lock()
 		return err
 	case "reload-or-restart":
-		flags := &wrappers.RestartServicesFlags{Reload: true}
+		flags := &wrappers.RestartServicesFlags{Reload: true, Users: sc.Users}
 		st.Unlock()
 		err := wrappers.RestartServices(startupOrdered, explicitServicesSystemdUnits, flags, meter, perfTimings)
 		st.Lock()
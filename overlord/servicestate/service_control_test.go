@@ -423,6 +423,120 @@ func (s *serviceControlSuite) TestControlUnknownInstruction(c *C) {
 	c.Assert(err, ErrorMatches, `unknown action "boo"`)
 }
 
+const servicesSnapYamlUserDaemons = `name: test-snap
+version: 1.0
+apps:
+  foo:
+    daemon: simple
+  user-foo:
+    daemon: simple
+    daemon-scope: user
+`
+
+func (s *serviceControlSuite) mockTestSnapWithUserDaemons(c *C) *snap.Info {
+	si := snap.SideInfo{
+		RealName: "test-snap",
+		Revision: snap.R(7),
+	}
+	info := snaptest.MockSnap(c, servicesSnapYamlUserDaemons, &si)
+	snapstate.Set(s.state, "test-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{&si},
+		Current:  snap.R(7),
+		SnapType: "app",
+	})
+	return info
+}
+
+func (s *serviceControlSuite) TestControlUsersSelf(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	info := s.mockTestSnapWithUserDaemons(c)
+	inst := &servicestate.Instruction{
+		Action:       "restart",
+		Names:        []string{"test-snap"},
+		Users:        client.UsersSelf,
+		RequesterUID: 1000,
+	}
+
+	tss, err := servicestate.Control(st, info.Services(), inst, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(tss, HasLen, 1)
+	c.Assert(tss[0].Tasks(), HasLen, 1)
+	var sa servicestate.ServiceAction
+	c.Assert(tss[0].Tasks()[0].Get("service-action", &sa), IsNil)
+	// only the user daemon is restarted, in the session of the requester
+	c.Check(sa, DeepEquals, servicestate.ServiceAction{
+		SnapName: "test-snap",
+		Action:   "restart",
+		Services: []string{"user-foo"},
+		Users:    []int{1000},
+	})
+}
+
+func (s *serviceControlSuite) TestControlUsersAll(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	info := s.mockTestSnapWithUserDaemons(c)
+	inst := &servicestate.Instruction{
+		Action:       "stop",
+		Names:        []string{"test-snap"},
+		Users:        client.UsersAll,
+		RequesterUID: 1000,
+		StopOptions:  client.StopOptions{Disable: true},
+	}
+
+	tss, err := servicestate.Control(st, info.Services(), inst, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(tss, HasLen, 1)
+	c.Assert(tss[0].Tasks(), HasLen, 1)
+	var sa servicestate.ServiceAction
+	c.Assert(tss[0].Tasks()[0].Get("service-action", &sa), IsNil)
+	c.Check(sa, DeepEquals, servicestate.ServiceAction{
+		SnapName:       "test-snap",
+		Action:         "stop",
+		ActionModifier: "disable",
+		Services:       []string{"user-foo"},
+	})
+}
+
+func (s *serviceControlSuite) TestControlUsersErrors(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	info := s.mockTestSnapWithUserDaemons(c)
+
+	for _, t := range []struct {
+		inst *servicestate.Instruction
+		apps []*snap.AppInfo
+		err  string
+	}{{
+		inst: &servicestate.Instruction{Action: "start", Users: client.UsersAll},
+		apps: []*snap.AppInfo{info.Apps["foo"]},
+		err:  `cannot start user daemons: no user daemons found among the given services`,
+	}, {
+		inst: &servicestate.Instruction{Action: "start", Users: client.UsersSelf, StartOptions: client.StartOptions{Enable: true}},
+		apps: info.Services(),
+		err:  `cannot enable or disable user daemons of a single user`,
+	}, {
+		inst: &servicestate.Instruction{Action: "stop", Users: client.UsersSelf, StopOptions: client.StopOptions{Disable: true}},
+		apps: info.Services(),
+		err:  `cannot enable or disable user daemons of a single user`,
+	}, {
+		inst: &servicestate.Instruction{Action: "stop", Users: "some-user"},
+		apps: info.Services(),
+		err:  `invalid users selector "some-user"`,
+	}} {
+		_, err := servicestate.Control(st, t.apps, t.inst, nil, nil)
+		c.Check(err, ErrorMatches, t.err)
+	}
+}
+
 func (s *serviceControlSuite) TestControlStopDisableMultipleInstruction(c *C) {
 	st := s.state
 	st.Lock()
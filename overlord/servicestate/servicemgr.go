@@ -30,12 +30,14 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/snapdenv"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/timings"
 	"github.com/snapcore/snapd/wrappers"
@@ -201,6 +203,60 @@ func delayedCrossMgrInit() {
 	snapstate.AddAffectedSnapsByAttr("service-action", serviceControlAffectedSnaps)
 	snapstate.SnapServiceOptions = SnapServiceOptions
 	snapstate.EnsureSnapAbsentFromQuotaGroup = EnsureSnapAbsentFromQuota
+	ifacestate.ConnectedServicesChanged = connectedServicesChanged
+}
+
+// connectedServicesChanged regenerates the service units of the given snap
+// when the connections of one of its plugs changed and some of its services
+// are ordered against the services connected to that plug.
+func connectedServicesChanged(st *state.State, instanceName, plugName string) error {
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, instanceName, &snapst); err != nil {
+		if err == state.ErrNoState {
+			return nil
+		}
+		return err
+	}
+	if !snapst.Active {
+		// the units will be generated when the snap is linked
+		return nil
+	}
+	info, err := snapst.CurrentInfo()
+	if err != nil {
+		return err
+	}
+
+	ordered := false
+	for _, app := range info.Services() {
+		if strutil.ListContains(app.AfterPlugs, plugName) || strutil.ListContains(app.RequiresPlugs, plugName) {
+			ordered = true
+			break
+		}
+	}
+	if !ordered {
+		return nil
+	}
+
+	snapSvcOpts, err := SnapServiceOptions(st, instanceName, nil)
+	if err != nil {
+		return err
+	}
+
+	ensureOpts := &wrappers.EnsureSnapServicesOptions{
+		Preseeding: snapdenv.Preseeding(),
+	}
+	deviceCtx, err := snapstate.DeviceCtx(st, nil, nil)
+	if err != nil {
+		return err
+	}
+	if !deviceCtx.Classic() && deviceCtx.Model().Base() != "" {
+		ensureOpts.RequireMountedSnapdSnap = true
+	}
+
+	snapsMap := map[*snap.Info]*wrappers.SnapServiceOptions{
+		info: snapSvcOpts,
+	}
+	return wrappers.EnsureSnapServices(snapsMap, ensureOpts, nil, progress.Null)
 }
 
 func serviceControlAffectedSnaps(t *state.Task) ([]string, error) {
@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
@@ -319,6 +320,71 @@ func (s *ensureSnapServiceSuite) TestEnsureSnapServicesSimpleWritesServicesFiles
 	c.Assert(s.restartRequests, HasLen, 0)
 }
 
+func (s *ensureSnapServiceSuite) TestConnectedServicesChangedRegeneratesUnits(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, `name: test-snap
+version: v1
+plugs:
+  db:
+    interface: content
+    target: $SNAP_DATA/db
+apps:
+  svc1:
+    command: bin.sh
+    daemon: simple
+    plugs: [db, network]
+    requires-plugs: [db]
+`, s.testSnapSideInfo)
+
+	dbSideInfo := &snap.SideInfo{RealName: "db", Revision: snap.R(1)}
+	snapstate.Set(s.state, "db", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{dbSideInfo},
+		Current:  snap.R(1),
+		Active:   true,
+		SnapType: "app",
+	})
+	snaptest.MockSnapCurrent(c, `name: db
+version: v1
+slots:
+  db-socket:
+    interface: content
+    read: [$SNAP_DATA/socket]
+apps:
+  server:
+    command: bin.sh
+    daemon: simple
+    slots: [db-socket]
+`, dbSideInfo)
+
+	s.state.Set("conns", map[string]interface{}{
+		"test-snap:db db:db-socket": map[string]interface{}{"interface": "content"},
+	})
+
+	s.AddCleanup(snapstatetest.MockDeviceModel(s.uc16Model))
+
+	// only a single daemon-reload is expected
+	r := s.mockSystemctlCalls(c, []expectedSystemctl{
+		{
+			expArgs: []string{"daemon-reload"},
+		},
+	})
+	defer r()
+
+	// no service is ordered against the network plug, nothing happens
+	err := ifacestate.ConnectedServicesChanged(s.state, "test-snap", "network")
+	c.Assert(err, IsNil)
+	svcFile := filepath.Join(dirs.GlobalRootDir, "/etc/systemd/system/snap.test-snap.svc1.service")
+	c.Assert(svcFile, testutil.FileAbsent)
+
+	err = ifacestate.ConnectedServicesChanged(s.state, "test-snap", "db")
+	c.Assert(err, IsNil)
+	c.Assert(svcFile, testutil.FileContains, "\nRequires=snap.db.server.service\n")
+	c.Assert(svcFile, testutil.FileContains, " snap.db.server.service snapd.apparmor.service\n")
+}
+
 func (s *ensureSnapServiceSuite) TestEnsureSnapServicesSkipsSnapdSnap(c *C) {
 	s.state.Lock()
 	// add an unexpected snapd snap which has services in it, but we
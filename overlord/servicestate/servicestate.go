@@ -20,6 +20,7 @@
 package servicestate
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"sort"
@@ -27,21 +28,31 @@ import (
 	"time"
 
 	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/overlord/cmdstate"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/systemd"
+	"github.com/snapcore/snapd/timeout"
+	userclient "github.com/snapcore/snapd/usersession/client"
 	"github.com/snapcore/snapd/wrappers"
 )
 
 type Instruction struct {
 	Action string   `json:"action"`
 	Names  []string `json:"names"`
+	// Users restricts the instruction to user daemons, in the session
+	// of the requester or in the sessions of all users.
+	Users client.UserSelector `json:"users,omitempty"`
+	// RequesterUID is the uid of the user whose session is targeted
+	// with client.UsersSelf.
+	RequesterUID int `json:"-"`
 	client.StartOptions
 	client.StopOptions
 	client.RestartOptions
@@ -49,6 +60,44 @@ type Instruction struct {
 
 type ServiceActionConflictError struct{ error }
 
+// userDaemonsOnly returns the user daemons out of appInfos if the
+// instruction is restricted to user sessions, or all of appInfos otherwise.
+func (inst *Instruction) userDaemonsOnly(appInfos []*snap.AppInfo) ([]*snap.AppInfo, error) {
+	switch inst.Users {
+	case "":
+		return appInfos, nil
+	case client.UsersSelf:
+		// services are enabled for all users, not per user
+		if inst.Enable || inst.Disable {
+			return nil, fmt.Errorf("cannot enable or disable user daemons of a single user")
+		}
+	case client.UsersAll:
+		// nothing more to check
+	default:
+		return nil, fmt.Errorf("invalid users selector %q", inst.Users)
+	}
+
+	userDaemons := make([]*snap.AppInfo, 0, len(appInfos))
+	for _, app := range appInfos {
+		if app.DaemonScope == snap.UserDaemon {
+			userDaemons = append(userDaemons, app)
+		}
+	}
+	if len(userDaemons) == 0 {
+		return nil, fmt.Errorf("cannot %s user daemons: no user daemons found among the given services", inst.Action)
+	}
+	return userDaemons, nil
+}
+
+// userUids returns the uids of the users whose sessions are targeted by
+// the instruction, nil meaning all of them.
+func (inst *Instruction) userUids() []int {
+	if inst.Users == client.UsersSelf {
+		return []int{inst.RequesterUID}
+	}
+	return nil
+}
+
 func computeExplicitServices(appInfos []*snap.AppInfo, names []string) map[string][]string {
 	explicitServices := make(map[string][]string, len(appInfos))
 	// requested maps "snapname.appname" to app name.
@@ -98,7 +147,7 @@ func serviceControlTs(st *state.State, appInfos []*snap.AppInfo, inst *Instructi
 			return nil, err
 		}
 
-		cmd := &ServiceAction{SnapName: snapName}
+		cmd := &ServiceAction{SnapName: snapName, Users: inst.userUids()}
 		switch {
 		case inst.Action == "start":
 			cmd.Action = "start"
@@ -154,6 +203,11 @@ func Control(st *state.State, appInfos []*snap.AppInfo, inst *Instruction, flags
 	var tts []*state.TaskSet
 	var ctlcmds []string
 
+	appInfos, err := inst.userDaemonsOnly(appInfos)
+	if err != nil {
+		return nil, err
+	}
+
 	// create exec-command tasks for compatibility with old snapd
 	if flags != nil && flags.CreateExecCommandTasks {
 		switch {
@@ -238,6 +292,10 @@ func Control(st *state.State, appInfos []*snap.AppInfo, inst *Instruction, flags
 type StatusDecorator struct {
 	sysd           systemd.Systemd
 	globalUserSysd systemd.Systemd
+	// userClient is set to query the status of user daemons in the
+	// session of the user with uid
+	userClient *userclient.Client
+	uid        int
 }
 
 // NewStatusDecorator returns a new StatusDecorator.
@@ -250,6 +308,49 @@ func NewStatusDecorator(rep interface {
 	}
 }
 
+// NewUserStatusDecorator returns a new StatusDecorator reporting the status
+// of user daemons in the session of the user with the given uid.
+func NewUserStatusDecorator(rep interface {
+	Notify(string)
+}, uid int) *StatusDecorator {
+	sd := NewStatusDecorator(rep)
+	sd.userClient = userclient.NewForUids(uid)
+	sd.uid = uid
+	return sd
+}
+
+// userSessionStatus returns the status of the given user units in the
+// session of the user of the decorator.
+func (sd *StatusDecorator) userSessionStatus(unitNames []string) ([]*systemd.UnitStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout.DefaultTimeout))
+	defer cancel()
+	statuses, err := sd.userClient.ServiceStatus(ctx, unitNames)
+	if err != nil {
+		return nil, err
+	}
+	userSts, ok := statuses[sd.uid]
+	if !ok {
+		// the user has no session, so nothing is active for them
+		sts, err := sd.globalUserSysd.Status(unitNames...)
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range sts {
+			st.Active = false
+		}
+		return sts, nil
+	}
+	sts := make([]*systemd.UnitStatus, len(userSts))
+	for i, st := range userSts {
+		sts[i] = &systemd.UnitStatus{
+			UnitName: st.Name,
+			Enabled:  st.Enabled,
+			Active:   st.Active,
+		}
+	}
+	return sts, nil
+}
+
 // DecorateWithStatus adds service status information to the given
 // client.AppInfo associated with the given snap.AppInfo.
 // If the snap is inactive or the app is not service it does nothing.
@@ -272,7 +373,7 @@ func (sd *StatusDecorator) DecorateWithStatus(appInfo *client.AppInfo, snapApp *
 	}
 
 	// collect all services for a single call to systemctl
-	extra := len(snapApp.Sockets)
+	extra := len(snapApp.Sockets) + len(snapApp.Paths)
 	if snapApp.Timer != nil {
 		extra++
 	}
@@ -285,6 +386,12 @@ func (sd *StatusDecorator) DecorateWithStatus(appInfo *client.AppInfo, snapApp *
 		sockSvcFileToName[sockUnit] = sock.Name
 		serviceNames = append(serviceNames, sockUnit)
 	}
+	pathSvcFileToName := make(map[string]string, len(snapApp.Paths))
+	for _, path := range snapApp.Paths {
+		pathUnit := filepath.Base(path.File())
+		pathSvcFileToName[pathUnit] = path.Name
+		serviceNames = append(serviceNames, pathUnit)
+	}
 	if snapApp.Timer != nil {
 		timerUnit := filepath.Base(snapApp.Timer.File())
 		serviceNames = append(serviceNames, timerUnit)
@@ -292,7 +399,13 @@ func (sd *StatusDecorator) DecorateWithStatus(appInfo *client.AppInfo, snapApp *
 
 	// sysd.Status() makes sure that we get only the units we asked
 	// for and raises an error otherwise
-	sts, err := sysd.Status(serviceNames...)
+	var sts []*systemd.UnitStatus
+	var err error
+	if snapApp.DaemonScope == snap.UserDaemon && sd.userClient != nil {
+		sts, err = sd.userSessionStatus(serviceNames)
+	} else {
+		sts, err = sysd.Status(serviceNames...)
+	}
 	if err != nil {
 		return fmt.Errorf("cannot get status of services of app %q: %v", appInfo.Name, err)
 	}
@@ -318,6 +431,13 @@ func (sd *StatusDecorator) DecorateWithStatus(appInfo *client.AppInfo, snapApp *
 				Active:  st.Active,
 				Type:    "socket",
 			})
+		case ".path":
+			appInfo.Activators = append(appInfo.Activators, client.AppActivator{
+				Name:    pathSvcFileToName[st.UnitName],
+				Enabled: st.Enabled,
+				Active:  st.Active,
+				Type:    "path",
+			})
 		}
 	}
 	// Decorate with D-Bus names that activate this service
@@ -381,5 +501,80 @@ func SnapServiceOptions(st *state.State, instanceName string, quotaGroups map[st
 		}
 	}
 
+	// and the services of other snaps connected to the plugs of this snap,
+	// in case any of the services are ordered against them
+	opts.PlugServices, err = connectedPlugServices(st, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
 	return opts, nil
 }
+
+// connectedPlugServices returns a map of the plug names of the given snap to
+// the services of the snaps providing the slots connected to each plug.
+func connectedPlugServices(st *state.State, instanceName string) (map[string][]*snap.AppInfo, error) {
+	conns, err := ifacestate.ConnectionStates(st)
+	if err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+
+	var plugServices map[string][]*snap.AppInfo
+	slotSnapInfos := make(map[string]*snap.Info)
+	for id, connState := range conns {
+		if connState.Undesired || connState.HotplugGone {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return nil, err
+		}
+		if connRef.PlugRef.Snap != instanceName {
+			continue
+		}
+
+		slotSnapName := connRef.SlotRef.Snap
+		slotSnapInfo, ok := slotSnapInfos[slotSnapName]
+		if !ok {
+			var snapst snapstate.SnapState
+			err := snapstate.Get(st, slotSnapName, &snapst)
+			if err != nil && err != state.ErrNoState {
+				return nil, err
+			}
+			if err == nil {
+				slotSnapInfo, err = snapst.CurrentInfo()
+				if err != nil && err != snapstate.ErrNoCurrent {
+					return nil, err
+				}
+			}
+			slotSnapInfos[slotSnapName] = slotSnapInfo
+		}
+		if slotSnapInfo == nil {
+			continue
+		}
+		slot := slotSnapInfo.Slots[connRef.SlotRef.Name]
+		if slot == nil {
+			continue
+		}
+
+		for _, app := range slot.Apps {
+			if !app.IsService() {
+				continue
+			}
+			if plugServices == nil {
+				plugServices = make(map[string][]*snap.AppInfo)
+			}
+			plugName := connRef.PlugRef.Name
+			plugServices[plugName] = append(plugServices[plugName], app)
+		}
+	}
+
+	// sort the services to have stable units
+	for _, svcs := range plugServices {
+		sort.Slice(svcs, func(i, j int) bool {
+			return svcs[i].ServiceName() < svcs[j].ServiceName()
+		})
+	}
+
+	return plugServices, nil
+}
@@ -21,7 +21,10 @@ package servicestate_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -34,9 +37,11 @@ import (
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/wrappers"
@@ -263,6 +268,71 @@ UnitFileState=%s
 	}
 }
 
+func (s *statusDecoratorSuite) TestDecorateWithStatusUserSession(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+	snp := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(1),
+		},
+	}
+	err := os.MkdirAll(snp.MountDir(), 0755)
+	c.Assert(err, IsNil)
+	err = os.Symlink(snp.Revision.String(), filepath.Join(filepath.Dir(snp.MountDir()), "current"))
+	c.Assert(err, IsNil)
+
+	// mock the session agent of the user
+	sock := filepath.Join(dirs.XdgRuntimeDirBase, "1000", "snapd-session-agent.socket")
+	c.Assert(os.MkdirAll(filepath.Dir(sock), 0700), IsNil)
+	l, err := net.Listen("unix", sock)
+	c.Assert(err, IsNil)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v1/service-status")
+		c.Check(r.URL.Query().Get("services"), Equals, "snap.foo.svc.service")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type": "sync", "result": [{"name": "snap.foo.svc.service", "enabled": true, "active": true}]}`))
+	})}
+	go srv.Serve(l)
+	defer srv.Shutdown(context.Background())
+
+	r := systemd.MockSystemctl(func(args ...string) (buf []byte, err error) {
+		c.Check(args, DeepEquals, []string{"--user", "--global", "is-enabled", "snap.foo.svc.service"})
+		return []byte("enabled\n"), nil
+	})
+	defer r()
+
+	snapApp := &snap.AppInfo{
+		Snap:        snp,
+		Name:        "svc",
+		Daemon:      "simple",
+		DaemonScope: snap.UserDaemon,
+	}
+
+	app := &client.AppInfo{
+		Snap:   snp.InstanceName(),
+		Name:   "svc",
+		Daemon: "simple",
+	}
+	sd := servicestate.NewUserStatusDecorator(nil, 1000)
+	err = sd.DecorateWithStatus(app, snapApp)
+	c.Assert(err, IsNil)
+	c.Check(app.Active, Equals, true)
+	c.Check(app.Enabled, Equals, true)
+
+	// a user without a session has nothing active
+	app = &client.AppInfo{
+		Snap:   snp.InstanceName(),
+		Name:   "svc",
+		Daemon: "simple",
+	}
+	sd = servicestate.NewUserStatusDecorator(nil, 1001)
+	err = sd.DecorateWithStatus(app, snapApp)
+	c.Assert(err, IsNil)
+	c.Check(app.Active, Equals, false)
+	c.Check(app.Enabled, Equals, true)
+}
+
 type snapServiceOptionsSuite struct {
 	testutil.BaseTest
 	state *state.State
@@ -373,3 +443,56 @@ func (s *snapServiceOptionsSuite) TestSnapServiceOptionsQuotaGroups(c *C) {
 		QuotaGroup:   grp,
 	})
 }
+
+func (s *snapServiceOptionsSuite) TestSnapServiceOptionsPlugServices(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	si := &snap.SideInfo{RealName: "db", Revision: snap.R(1)}
+	snapstate.Set(st, "db", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{si},
+		Current:  si.Revision,
+	})
+	snaptest.MockSnapCurrent(c, `name: db
+version: 1
+slots:
+  db-socket:
+    interface: content
+    read: [$SNAP_DATA/socket]
+apps:
+  server:
+    daemon: simple
+    slots: [db-socket]
+  helper:
+    daemon: simple
+    slots: [db-socket]
+  cli:
+    slots: [db-socket]
+`, si)
+
+	st.Set("conns", map[string]interface{}{
+		"foo:db db:db-socket":   map[string]interface{}{"interface": "content"},
+		"foo:gone db:db-socket": map[string]interface{}{"interface": "content", "undesired": true},
+		"bar:db db:db-socket":   map[string]interface{}{"interface": "content"},
+		"foo:net core:network":  map[string]interface{}{"interface": "network"},
+	})
+
+	opts, err := servicestate.SnapServiceOptions(st, "foo", nil)
+	c.Assert(err, IsNil)
+	c.Assert(opts.PlugServices, HasLen, 1)
+	svcs := opts.PlugServices["db"]
+	c.Assert(svcs, HasLen, 2)
+	c.Check(svcs[0].Snap.InstanceName(), Equals, "db")
+	c.Check(svcs[0].Name, Equals, "helper")
+	c.Check(svcs[1].Name, Equals, "server")
+
+	// no connections of the snap
+	opts, err = servicestate.SnapServiceOptions(st, "other", nil)
+	c.Assert(err, IsNil)
+	c.Check(opts, DeepEquals, &wrappers.SnapServiceOptions{})
+}
@@ -54,6 +54,8 @@ const (
 	archiveName  = "archive.tgz"
 	metadataName = "meta.json"
 	metaHashName = "meta.sha3_384"
+	// configName holds the configuration of encrypted snapshots
+	configName = "conf.json"
 
 	userArchivePrefix = "user/"
 	userArchiveSuffix = ".tgz"
@@ -301,11 +303,31 @@ func EstimateSnapshotSize(si *snap.Info, usernames []string) (uint64, error) {
 	return total, nil
 }
 
+// SaveFlags carries extra flags to drive save behavior.
+type SaveFlags struct {
+	// Encrypt the archives and configuration of the snapshot with
+	// the snapshot encryption key of the device, generating it if
+	// needed. Encrypted snapshots can only be restored on a device
+	// with the same key.
+	Encrypt bool
+}
+
 // Save a snapshot
-func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string) (*client.Snapshot, error) {
+func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, flags *SaveFlags) (*client.Snapshot, error) {
 	if err := os.MkdirAll(dirs.SnapshotsDir, 0700); err != nil {
 		return nil, err
 	}
+	if flags == nil {
+		flags = &SaveFlags{}
+	}
+	var key []byte
+	if flags.Encrypt {
+		var err error
+		key, err = encryptionKey(true)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	snapshot := &client.Snapshot{
 		SetID:    id,
@@ -320,6 +342,11 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 		Conf:     cfg,
 		// Note: Auto is no longer set in the Snapshot.
 	}
+	if key != nil {
+		snapshot.Encryption = EncryptionFormat
+		// the configuration goes into its own, encrypted, entry
+		snapshot.Conf = nil
+	}
 
 	aw, err := osutil.NewAtomicFile(Filename(snapshot), 0600, 0, osutil.NoChown, osutil.NoChown)
 	if err != nil {
@@ -328,19 +355,32 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 	// if things worked, we'll commit (and Cancel becomes a NOP)
 	defer aw.Cancel()
 
+	opts, err := snap.ReadSnapshotYaml(si)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read snapshot options of snap %q: %v", si.InstanceName(), err)
+	}
+	revdir := filepath.Base(si.DataDir())
+
 	w := zip.NewWriter(aw)
 	defer w.Close() // note this does not close the file descriptor (that's done by hand on the atomic writer, above)
-	if err := addDirToZip(ctx, snapshot, w, "root", archiveName, si.DataDir()); err != nil {
+	exclude := tarExcludes(opts, revdir, "$SNAP_DATA", "$SNAP_COMMON")
+	if err := addDirToZip(ctx, snapshot, w, "root", archiveName, si.DataDir(), exclude, key); err != nil {
 		return nil, err
 	}
+	if key != nil && cfg != nil {
+		if err := addConfigToZip(snapshot, w, cfg, key); err != nil {
+			return nil, err
+		}
+	}
 
 	users, err := usersForUsernames(usernames)
 	if err != nil {
 		return nil, err
 	}
 
+	exclude = tarExcludes(opts, revdir, "$SNAP_USER_DATA", "$SNAP_USER_COMMON")
 	for _, usr := range users {
-		if err := addDirToZip(ctx, snapshot, w, usr.Username, userArchiveName(usr), si.UserDataDir(usr.HomeDir)); err != nil {
+		if err := addDirToZip(ctx, snapshot, w, usr.Username, userArchiveName(usr), si.UserDataDir(usr.HomeDir), exclude, key); err != nil {
 			return nil, err
 		}
 	}
@@ -378,7 +418,45 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 
 var isTesting = snapdenv.Testing()
 
-func addDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username string, entry, dir string) error {
+// tarExcludes returns the exclude patterns of the snapshot options for the
+// given data and common directory variables, relative to their parent
+// directory as the archives are created from there.
+func tarExcludes(opts *snap.SnapshotOptions, revdir, dataVar, commonVar string) []string {
+	var exclude []string
+	for _, p := range opts.ExcludesUnder(dataVar) {
+		exclude = append(exclude, path.Join(revdir, p))
+	}
+	for _, p := range opts.ExcludesUnder(commonVar) {
+		exclude = append(exclude, path.Join("common", p))
+	}
+	return exclude
+}
+
+func addConfigToZip(snapshot *client.Snapshot, w *zip.Writer, cfg map[string]interface{}, key []byte) error {
+	configWriter, err := w.Create(configName)
+	if err != nil {
+		return err
+	}
+	var sz osutil.Sizer
+	hasher := crypto.SHA3_384.New()
+	ew, err := newEncryptingWriter(io.MultiWriter(configWriter, hasher, &sz), key)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(ew).Encode(cfg); err != nil {
+		return err
+	}
+	if err := ew.Close(); err != nil {
+		return err
+	}
+	snapshot.SHA3_384[configName] = fmt.Sprintf("%x", hasher.Sum(nil))
+	snapshot.Size += sz.Size()
+	return nil
+}
+
+// addDirToZip adds the data in dir, and the common data next to it, as a
+// compressed tar archive to the zip, encrypted if a key is given.
+func addDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username string, entry, dir string, exclude []string, key []byte) error {
 	parent, revdir := filepath.Split(dir)
 	exists, isDir, err := osutil.DirExists(parent)
 	if err != nil {
@@ -398,6 +476,13 @@ func addDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer,
 		"--format", "gnu",
 		"--directory", parent,
 	}
+	if len(exclude) > 0 {
+		// exclusions must come before the directories to archive
+		tarArgs = append(tarArgs, "--anchored", "--wildcards")
+		for _, p := range exclude {
+			tarArgs = append(tarArgs, "--exclude="+p)
+		}
+	}
 
 	noRev, noCommon := true, true
 
@@ -443,7 +528,17 @@ func addDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer,
 	hasher := crypto.SHA3_384.New()
 
 	cmd := tarAsUser(username, tarArgs...)
+	// hash and size are of the data as stored, so that snapshots can be
+	// checked without decrypting them
 	cmd.Stdout = io.MultiWriter(archiveWriter, hasher, &sz)
+	var ew *encryptingWriter
+	if key != nil {
+		ew, err = newEncryptingWriter(cmd.Stdout, key)
+		if err != nil {
+			return err
+		}
+		cmd.Stdout = ew
+	}
 	matchCounter := &strutil.MatchCounter{
 		// keep at most 5 matches
 		N: 5,
@@ -469,6 +564,11 @@ func addDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer,
 		}
 		return fmt.Errorf("tar failed: %v", err)
 	}
+	if ew != nil {
+		if err := ew.Close(); err != nil {
+			return err
+		}
+	}
 
 	snapshot.SHA3_384[entry] = fmt.Sprintf("%x", hasher.Sum(nil))
 	snapshot.Size += sz.Size()
@@ -757,6 +857,9 @@ func checkDuplicatedSnapshotSetWithContentHash(ctx context.Context, contentHash
 
 func unpackVerifySnapshotImport(ctx context.Context, r io.Reader, realSetID uint64, flags *ImportFlags) (snapNames []string, err error) {
 	var exportFound bool
+	var meta exportMetadata
+	// hashes of the imported files, calculated as they are unpacked
+	hashes := make(map[string]string)
 
 	tr := tar.NewReader(r)
 	var tarErr error
@@ -799,8 +902,10 @@ func unpackVerifySnapshotImport(ctx context.Context, r io.Reader, realSetID uint
 		}
 
 		if header.Name == "export.json" {
-			// XXX: read into memory and validate once we
-			// hashes in export.json
+			dec := json.NewDecoder(tr)
+			if err := dec.Decode(&meta); err != nil {
+				return nil, fmt.Errorf("cannot read export.json: %v", err)
+			}
 			exportFound = true
 			continue
 		}
@@ -817,9 +922,11 @@ func unpackVerifySnapshotImport(ctx context.Context, r io.Reader, realSetID uint
 			return nil, fmt.Errorf("unexpected filename in import stream: %v", header.Name)
 		}
 		targetPath := path.Join(dirs.SnapshotsDir, fmt.Sprintf("%d_%s", realSetID, l[1]))
-		if err := writeOneSnapshotFile(targetPath, tr); err != nil {
+		hasher := crypto.SHA3_384.New()
+		if err := writeOneSnapshotFile(targetPath, io.TeeReader(tr, hasher)); err != nil {
 			return snapNames, err
 		}
+		hashes[header.Name] = fmt.Sprintf("%x", hasher.Sum(nil))
 
 		r, err := backendOpen(targetPath, realSetID)
 		if err != nil {
@@ -836,7 +943,17 @@ func unpackVerifySnapshotImport(ctx context.Context, r io.Reader, realSetID uint
 	if !exportFound {
 		return nil, fmt.Errorf("no export.json file in uploaded data")
 	}
-	// XXX: validate using the unmarshalled export.json hashes here
+	// export.json lists all the files of the export, and exports
+	// from newer versions of snapd also carry their hashes
+	for _, name := range meta.Files {
+		got, ok := hashes[name]
+		if !ok {
+			return snapNames, fmt.Errorf("incomplete import: %q is missing", name)
+		}
+		if expected, ok := meta.SHA3_384[name]; ok && expected != got {
+			return snapNames, fmt.Errorf("cannot verify %q: expected hash %s, got %s", name, expected, got)
+		}
+	}
 
 	return snapNames, nil
 }
@@ -845,6 +962,9 @@ type exportMetadata struct {
 	Format int       `json:"format"`
 	Date   time.Time `json:"date"`
 	Files  []string  `json:"files"`
+	// SHA3_384 holds the hashes of the files, calculated while
+	// they are streamed
+	SHA3_384 map[string]string `json:"sha3-384,omitempty"`
 }
 
 type SnapshotExport struct {
@@ -926,14 +1046,15 @@ func NewSnapshotExport(ctx context.Context, setID uint64) (se *SnapshotExport, e
 func (se *SnapshotExport) Init() error {
 	// Export once into a dummy writer so that we can set the size
 	// of the export. This is then used to set the Content-Length
-	// in the response correctly.
+	// in the response correctly. The size does not depend on the
+	// content of the snapshot files so they are not read for this.
 	//
 	// Note that the size of the generated tar could change if the
 	// time switches between this export and the export we stream
 	// to the client to a time after the year 2242. This is unlikely
 	// but a known issue with this approach here.
 	var sz osutil.Sizer
-	if err := se.StreamTo(&sz); err != nil {
+	if err := se.streamTo(&sz, true); err != nil {
 		return fmt.Errorf("cannot calculcate the size for %v: %s", se.setID, err)
 	}
 	se.size = sz.Size()
@@ -955,9 +1076,27 @@ type contentJSON struct {
 	ContentHash []byte `json:"content-hash"`
 }
 
+// zeroReader reads an endless stream of zeros.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// StreamTo writes the export as a tar archive to the given writer. The
+// snapshot files are hashed as they are written and their hashes are
+// recorded in export.json, which comes last.
 func (se *SnapshotExport) StreamTo(w io.Writer) error {
+	return se.streamTo(w, false)
+}
+
+func (se *SnapshotExport) streamTo(w io.Writer, sizeOnly bool) error {
 	// write out a tar
 	var files []string
+	hashes := make(map[string]string, len(se.snapshotFiles))
 	tw := tar.NewWriter(w)
 	defer tw.Close()
 
@@ -1000,19 +1139,28 @@ func (se *SnapshotExport) StreamTo(w io.Writer) error {
 		if err = tw.WriteHeader(hdr); err != nil {
 			return fmt.Errorf("cannot write header for %v: %v", stat.Name(), err)
 		}
-		if _, err := io.Copy(tw, snapshotFile); err != nil {
+		hasher := crypto.SHA3_384.New()
+		var data io.Reader = io.TeeReader(snapshotFile, hasher)
+		if sizeOnly {
+			// the hash has a fixed length whatever the content
+			data = io.LimitReader(zeroReader{}, hdr.Size)
+		}
+		if _, err := io.Copy(tw, data); err != nil {
 			return fmt.Errorf("cannot write data for %v: %v", stat.Name(), err)
 		}
 
-		files = append(files, path.Base(snapshotFile.Name()))
+		name := path.Base(snapshotFile.Name())
+		files = append(files, name)
+		hashes[name] = fmt.Sprintf("%x", hasher.Sum(nil))
 	}
 
 	// write the metadata last, then the client can use that to
 	// validate the archive is complete
 	meta := exportMetadata{
-		Format: 1,
-		Date:   timeNow(),
-		Files:  files,
+		Format:   1,
+		Date:     timeNow(),
+		Files:    files,
+		SHA3_384: hashes,
 	}
 	metaDataBuf, err := json.Marshal(&meta)
 	if err != nil {
@@ -23,6 +23,7 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto"
 	"crypto/sha256"
@@ -449,7 +450,7 @@ func (s *snapshotSuite) TestIterSetIDoverride(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	cfg := map[string]interface{}{"some-setting": false}
 
-	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"})
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, uint64(12))
 
@@ -559,11 +560,11 @@ func (s *snapshotSuite) TestAddDirToZipBails(c *check.C) {
 	buf, restore := logger.MockLogger()
 	defer restore()
 	// note as the zip is nil this would panic if it didn't bail
-	c.Check(backend.AddDirToZip(nil, snapshot, nil, "", "an/entry", filepath.Join(s.root, "nonexistent")), check.IsNil)
+	c.Check(backend.AddDirToZip(nil, snapshot, nil, "", "an/entry", filepath.Join(s.root, "nonexistent"), nil, nil), check.IsNil)
 	// no log for the non-existent case
 	c.Check(buf.String(), check.Equals, "")
 	buf.Reset()
-	c.Check(backend.AddDirToZip(nil, snapshot, nil, "", "an/entry", "/etc/passwd"), check.IsNil)
+	c.Check(backend.AddDirToZip(nil, snapshot, nil, "", "an/entry", "/etc/passwd", nil, nil), check.IsNil)
 	c.Check(buf.String(), check.Matches, "(?m).* is not a directory.")
 }
 
@@ -577,7 +578,7 @@ func (s *snapshotSuite) TestAddDirToZipTarFails(c *check.C) {
 
 	var buf bytes.Buffer
 	z := zip.NewWriter(&buf)
-	c.Assert(backend.AddDirToZip(ctx, nil, z, "", "an/entry", d), check.ErrorMatches, ".* context canceled")
+	c.Assert(backend.AddDirToZip(ctx, nil, z, "", "an/entry", d, nil, nil), check.ErrorMatches, ".* context canceled")
 }
 
 func (s *snapshotSuite) TestAddDirToZip(c *check.C) {
@@ -591,7 +592,7 @@ func (s *snapshotSuite) TestAddDirToZip(c *check.C) {
 	snapshot := &client.Snapshot{
 		SHA3_384: map[string]string{},
 	}
-	c.Assert(backend.AddDirToZip(context.Background(), snapshot, z, "", "an/entry", d), check.IsNil)
+	c.Assert(backend.AddDirToZip(context.Background(), snapshot, z, "", "an/entry", d, nil, nil), check.IsNil)
 	z.Close() // write out the central directory
 
 	c.Check(snapshot.SHA3_384, check.HasLen, 1)
@@ -604,6 +605,57 @@ func (s *snapshotSuite) TestAddDirToZip(c *check.C) {
 	c.Check(r.File[0].Name, check.Equals, "an/entry")
 }
 
+func (s *snapshotSuite) TestAddDirToZipExclude(c *check.C) {
+	d := filepath.Join(s.root, "foo")
+	c.Assert(os.MkdirAll(filepath.Join(d, "bar", "cache"), 0755), check.IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(s.root, "common"), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(d, "bar", "baz"), []byte("hello\n"), 0644), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(d, "bar", "cache", "big"), []byte("cached\n"), 0644), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.root, "common", "keep"), []byte("keep\n"), 0644), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(s.root, "common", "debug.log"), []byte("log\n"), 0644), check.IsNil)
+
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	snapshot := &client.Snapshot{
+		SHA3_384: map[string]string{},
+	}
+	exclude := []string{"foo/bar/cache", "common/*.log"}
+	c.Assert(backend.AddDirToZip(context.Background(), snapshot, z, "", "an/entry", d, exclude, nil), check.IsNil)
+	z.Close()
+
+	br := bytes.NewReader(buf.Bytes())
+	r, err := zip.NewReader(br, int64(br.Len()))
+	c.Assert(err, check.IsNil)
+	c.Assert(r.File, check.HasLen, 1)
+	rc, err := r.File[0].Open()
+	c.Assert(err, check.IsNil)
+	defer rc.Close()
+	gz, err := gzip.NewReader(rc)
+	c.Assert(err, check.IsNil)
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.IsNil)
+		names = append(names, strings.TrimSuffix(hdr.Name, "/"))
+	}
+	sort.Strings(names)
+	c.Check(names, check.DeepEquals, []string{"common", "common/keep", "foo", "foo/bar", "foo/bar/baz"})
+}
+
+func (s *snapshotSuite) TestSaveInvalidSnapshotYaml(c *check.C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	metaDir := filepath.Join(info.MountDir(), "meta")
+	c.Assert(os.MkdirAll(metaDir, 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(metaDir, "snapshots.yaml"), []byte("exclude: [/etc]"), 0644), check.IsNil)
+
+	_, err := backend.Save(context.TODO(), 12, info, nil, nil, nil)
+	c.Assert(err, check.ErrorMatches, `cannot read snapshot options of snap "hello-snap": cannot validate snapshots.yaml: .*`)
+}
+
 func (s *snapshotSuite) TestHappyRoundtrip(c *check.C) {
 	s.testHappyRoundtrip(c, "marker")
 }
@@ -637,7 +689,7 @@ func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
 	cfg := map[string]interface{}{"some-setting": false}
 	shID := uint64(12)
 
-	shw, err := backend.Save(context.TODO(), shID, info, cfg, []string{"snapuser"})
+	shw, err := backend.Save(context.TODO(), shID, info, cfg, []string{"snapuser"}, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, shID)
 	c.Check(shw.Snap, check.Equals, info.InstanceName())
@@ -701,6 +753,67 @@ func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
 	}
 }
 
+func (s *snapshotSuite) TestHappyRoundtripEncrypted(c *check.C) {
+	if os.Geteuid() == 0 {
+		c.Skip("this test cannot run as root (runuser will fail)")
+	}
+	logger.SimpleSetup()
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: snap.E("42*")}
+	cfg := map[string]interface{}{"some-setting": false}
+
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, &backend.SaveFlags{Encrypt: true})
+	c.Assert(err, check.IsNil)
+	c.Check(shw.Encryption, check.Equals, backend.EncryptionFormat)
+	c.Check(shw.Conf, check.IsNil)
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz", "conf.json", "user/snapuser.tgz"})
+
+	key, err := ioutil.ReadFile(backend.EncryptionKeyFile())
+	c.Assert(err, check.IsNil)
+	c.Check(key, check.HasLen, 32)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Encryption, check.Equals, backend.EncryptionFormat)
+	c.Check(shr.Conf, check.IsNil)
+	// the archives can be checked without the key
+	c.Check(shr.Check(context.TODO(), nil), check.IsNil)
+	restoredCfg, err := shr.Config()
+	c.Assert(err, check.IsNil)
+	c.Check(restoredCfg, check.DeepEquals, cfg)
+
+	// the archives are not stored in the clear
+	zr, err := zip.OpenReader(backend.Filename(shw))
+	c.Assert(err, check.IsNil)
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Name != "archive.tgz" {
+			continue
+		}
+		rc, err := f.Open()
+		c.Assert(err, check.IsNil)
+		_, err = gzip.NewReader(rc)
+		c.Check(err, check.Equals, gzip.ErrHeader)
+		rc.Close()
+	}
+
+	newroot := c.MkDir()
+	c.Assert(os.MkdirAll(filepath.Join(newroot, "home/snapuser"), 0755), check.IsNil)
+	dirs.SetRootDir(newroot)
+
+	// the key is needed to restore
+	_, err = shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf)
+	c.Assert(err, check.ErrorMatches, `cannot find snapshot encryption key ".*/snapshots.key"`)
+
+	c.Assert(os.MkdirAll(filepath.Dir(backend.EncryptionKeyFile()), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(backend.EncryptionKeyFile(), key, 0600), check.IsNil)
+	rs, err := shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf)
+	c.Assert(err, check.IsNil)
+	rs.Cleanup()
+	c.Check(exec.Command("diff", "-urN", "-x*.zip", s.root, newroot).Run(), check.IsNil)
+}
+
 func (s *snapshotSuite) TestOpenSetIDoverride(c *check.C) {
 	if os.Geteuid() == 0 {
 		c.Skip("this test cannot run as root (runuser will fail)")
@@ -711,7 +824,7 @@ func (s *snapshotSuite) TestOpenSetIDoverride(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	cfg := map[string]interface{}{"some-setting": false}
 
-	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"})
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, uint64(12))
 
@@ -735,7 +848,7 @@ func (s *snapshotSuite) TestRestoreRoundtripDifferentRevision(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	shID := uint64(12)
 
-	shw, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"})
+	shw, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.Revision, check.Equals, info.Revision)
 
@@ -917,6 +1030,17 @@ func (s *snapshotSuite) TestImport(c *check.C) {
 	err = createTestExportFile(tarFile4, flags)
 	c.Check(err, check.IsNil)
 
+	// create exported snapshots with hashes in export.json
+	tarFile5 := path.Join(tempdir, "exported5.snapshot")
+	err = createTestExportFile(tarFile5, &createTestExportFlags{exportJSON: true, withHashes: true})
+	c.Check(err, check.IsNil)
+	tarFile6 := path.Join(tempdir, "exported6.snapshot")
+	err = createTestExportFile(tarFile6, &createTestExportFlags{exportJSON: true, withHashes: true, wrongHash: true})
+	c.Check(err, check.IsNil)
+	tarFile7 := path.Join(tempdir, "exported7.snapshot")
+	err = createTestExportFile(tarFile7, &createTestExportFlags{exportJSON: true, withHashes: true, missingFile: true})
+	c.Check(err, check.IsNil)
+
 	type tableT struct {
 		setID      uint64
 		filename   string
@@ -930,6 +1054,9 @@ func (s *snapshotSuite) TestImport(c *check.C) {
 		{14, tarFile3, false, "cannot import snapshot 14: cannot read snapshot import: unexpected EOF"},
 		{14, tarFile4, false, "cannot import snapshot 14: unexpected directory in import file"},
 		{14, tarFile1, true, "cannot import snapshot 14: already in progress for this set id"},
+		{14, tarFile5, false, ""},
+		{14, tarFile6, false, `cannot import snapshot 14: cannot verify "5_bar_1.0_199.zip": expected hash 0+, got [0-9a-f]+`},
+		{14, tarFile7, false, `cannot import snapshot 14: incomplete import: "5_baz_1.0_199.zip" is missing`},
 	}
 
 	for i, t := range table {
@@ -1000,7 +1127,7 @@ func (s *snapshotSuite) TestImportDuplicated(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	shID := uint64(12)
 
-	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"})
+	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil)
 	c.Assert(err, check.IsNil)
 
 	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
@@ -1030,7 +1157,7 @@ func (s *snapshotSuite) TestImportExportRoundtrip(c *check.C) {
 	cfg := map[string]interface{}{"some-setting": false}
 	shID := uint64(12)
 
-	shw, err := backend.Save(ctx, shID, info, cfg, []string{"snapuser"})
+	shw, err := backend.Save(ctx, shID, info, cfg, []string{"snapuser"}, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, shID)
 
@@ -1177,7 +1304,7 @@ func (s *snapshotSuite) TestExportTwice(c *check.C) {
 	}
 	// create a snapshot
 	shID := uint64(12)
-	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"})
+	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil)
 	c.Check(err, check.IsNil)
 
 	// content.json + num_files + export.json + footer
@@ -1217,6 +1344,49 @@ func (s *snapshotSuite) TestExportTwice(c *check.C) {
 	c.Check(buf.Len(), check.Equals, int(expectedSize))
 }
 
+func (s *snapshotSuite) TestExportRecordsHashes(c *check.C) {
+	info := &snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "hello-snap",
+			Revision: snap.R(42),
+			SnapID:   "hello-id",
+		},
+		Version: "v1.33",
+	}
+	shID := uint64(12)
+	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil)
+	c.Assert(err, check.IsNil)
+
+	se, err := backend.NewSnapshotExport(context.Background(), shID)
+	c.Assert(err, check.IsNil)
+	defer se.Close()
+	buf := bytes.NewBuffer(nil)
+	c.Assert(se.StreamTo(buf), check.IsNil)
+
+	content, err := ioutil.ReadFile(filepath.Join(dirs.SnapshotsDir, "12_hello-snap_v1.33_42.zip"))
+	c.Assert(err, check.IsNil)
+	hasher := crypto.SHA3_384.New()
+	hasher.Write(content)
+
+	var meta struct {
+		Files    []string          `json:"files"`
+		SHA3_384 map[string]string `json:"sha3-384"`
+	}
+	tr := tar.NewReader(buf)
+	for {
+		hdr, err := tr.Next()
+		c.Assert(err, check.IsNil)
+		if hdr.Name == "export.json" {
+			c.Assert(json.NewDecoder(tr).Decode(&meta), check.IsNil)
+			break
+		}
+	}
+	c.Check(meta.Files, check.DeepEquals, []string{"12_hello-snap_v1.33_42.zip"})
+	c.Check(meta.SHA3_384, check.DeepEquals, map[string]string{
+		"12_hello-snap_v1.33_42.zip": fmt.Sprintf("%x", hasher.Sum(nil)),
+	})
+}
+
 func (s *snapshotSuite) TestExportUnhappy(c *check.C) {
 	se, err := backend.NewSnapshotExport(context.Background(), 5)
 	c.Assert(err, check.ErrorMatches, "no snapshot data found for 5")
@@ -1227,6 +1397,10 @@ type createTestExportFlags struct {
 	exportJSON      bool
 	withDir         bool
 	corruptChecksum bool
+	// list the files and their hashes in export.json
+	withHashes  bool
+	missingFile bool
+	wrongHash   bool
 }
 
 func createTestExportFile(filename string, flags *createTestExportFlags) error {
@@ -1238,6 +1412,8 @@ func createTestExportFile(filename string, flags *createTestExportFlags) error {
 	tw := tar.NewWriter(tf)
 	defer tw.Close()
 
+	var files []string
+	hashes := map[string]string{}
 	for _, s := range []string{"foo", "bar", "baz"} {
 		fname := fmt.Sprintf("5_%s_1.0_199.zip", s)
 
@@ -1285,6 +1461,17 @@ func createTestExportFile(filename string, flags *createTestExportFlags) error {
 		fmt.Fprintf(metaSha3Writer, "%x\n", hasher.Sum(nil))
 		zipW.Close()
 
+		files = append(files, fname)
+		fileHash := crypto.SHA3_384.New()
+		fileHash.Write(buf.Bytes())
+		hashes[fname] = fmt.Sprintf("%x", fileHash.Sum(nil))
+		if flags.wrongHash && s == "bar" {
+			hashes[fname] = strings.Repeat("0", len(hashes[fname]))
+		}
+		if flags.missingFile && s == "baz" {
+			continue
+		}
+
 		hdr := &tar.Header{
 			Name: fname,
 			Mode: 0644,
@@ -1315,6 +1502,17 @@ func createTestExportFile(filename string, flags *createTestExportFlags) error {
 
 	if flags.exportJSON {
 		exp := fmt.Sprintf(`{"format":1, "date":"%s"}`, time.Now().Format(time.RFC3339))
+		if flags.withHashes {
+			filesJSON, err := json.Marshal(files)
+			if err != nil {
+				return err
+			}
+			hashesJSON, err := json.Marshal(hashes)
+			if err != nil {
+				return err
+			}
+			exp = fmt.Sprintf(`{"format":1, "date":"%s", "files":%s, "sha3-384":%s}`, time.Now().Format(time.RFC3339), filesJSON, hashesJSON)
+		}
 		hdr := &tar.Header{
 			Name: "export.json",
 			Mode: 0644,
@@ -1509,7 +1707,7 @@ func (s *snapshotSuite) TestSnapshotExportContentHash(c *check.C) {
 		Version: "v1.33",
 	}
 	shID := uint64(12)
-	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"})
+	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil)
 	c.Check(err, check.IsNil)
 
 	// now export it
@@ -1531,7 +1729,7 @@ func (s *snapshotSuite) TestSnapshotExportContentHash(c *check.C) {
 		},
 		Version: "v1.33",
 	}
-	shw, err = backend.Save(ctx, shID, info, nil, []string{"snapuser"})
+	shw, err = backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil)
 	c.Check(err, check.IsNil)
 
 	export3, err := backend.NewSnapshotExport(ctx, shw.SetID)
@@ -0,0 +1,249 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// EncryptionFormat identifies how the archives of encrypted snapshots are
+// encrypted: they are split in chunks, each sealed with AES-256-GCM using
+// a nonce made of a random prefix, written at the start of the entry, and
+// the chunk counter. The last chunk is marked so that a truncated archive
+// cannot be mistaken for a complete one.
+const EncryptionFormat = "aes-256-gcm-v1"
+
+const (
+	encryptionKeySize   = 32
+	encryptionChunkSize = 64 * 1024
+	noncePrefixSize     = 8
+)
+
+var randRead = rand.Read
+
+func encryptionKeyFile() string {
+	return filepath.Join(dirs.SnapDeviceDir, "snapshots.key")
+}
+
+// encryptionKey returns the key used to encrypt snapshots, generating it
+// first if it does not exist yet and create is set.
+func encryptionKey(create bool) ([]byte, error) {
+	fn := encryptionKeyFile()
+	key, err := ioutil.ReadFile(fn)
+	switch {
+	case err == nil:
+		if len(key) != encryptionKeySize {
+			return nil, fmt.Errorf("invalid snapshot encryption key in %q", fn)
+		}
+		return key, nil
+	case !os.IsNotExist(err):
+		return nil, err
+	case !create:
+		return nil, fmt.Errorf("cannot find snapshot encryption key %q", fn)
+	}
+
+	key = make([]byte, encryptionKeySize)
+	if _, err := randRead(key); err != nil {
+		return nil, fmt.Errorf("cannot generate snapshot encryption key: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return nil, err
+	}
+	if err := osutil.AtomicWriteFile(fn, key, 0600, 0); err != nil {
+		return nil, fmt.Errorf("cannot store snapshot encryption key: %v", err)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(aead cipher.AEAD, prefix []byte, counter uint32) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	return nonce
+}
+
+func chunkAdditionalData(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// encryptingWriter encrypts what is written to it, in chunks. It must be
+// closed to write out the last chunk.
+type encryptingWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+}
+
+func newEncryptingWriter(w io.Writer, key []byte) (*encryptingWriter, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := randRead(prefix); err != nil {
+		return nil, fmt.Errorf("cannot generate nonce: %v", err)
+	}
+	if _, err := w.Write(prefix); err != nil {
+		return nil, err
+	}
+	return &encryptingWriter{
+		w:      w,
+		aead:   aead,
+		prefix: prefix,
+		buf:    make([]byte, 0, encryptionChunkSize),
+	}, nil
+}
+
+func (ew *encryptingWriter) seal(final bool) error {
+	if ew.counter == math.MaxUint32 {
+		return errors.New("too much data to encrypt")
+	}
+	out := ew.aead.Seal(nil, chunkNonce(ew.aead, ew.prefix, ew.counter), ew.buf, chunkAdditionalData(final))
+	ew.counter++
+	ew.buf = ew.buf[:0]
+	_, err := ew.w.Write(out)
+	return err
+}
+
+func (ew *encryptingWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		// a full chunk is only sealed once there is more data, so
+		// that the last chunk is always sealed by Close
+		if len(ew.buf) == cap(ew.buf) {
+			if err := ew.seal(false); err != nil {
+				return n, err
+			}
+		}
+		k := copy(ew.buf[len(ew.buf):cap(ew.buf)], p)
+		ew.buf = ew.buf[:len(ew.buf)+k]
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close seals and writes out the last chunk; it does not close the
+// underlying writer.
+func (ew *encryptingWriter) Close() error {
+	return ew.seal(true)
+}
+
+// decryptingReader decrypts what was written by an encryptingWriter.
+type decryptingReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	chunk   []byte
+	plain   []byte
+	done    bool
+}
+
+func newDecryptingReader(r io.Reader, key []byte) (*decryptingReader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("cannot read nonce: %v", err)
+	}
+	return &decryptingReader{
+		r:      r,
+		aead:   aead,
+		prefix: prefix,
+		chunk:  make([]byte, encryptionChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (dr *decryptingReader) next() error {
+	n, err := io.ReadFull(dr.r, dr.chunk)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	nonce := chunkNonce(dr.aead, dr.prefix, dr.counter)
+	data := dr.chunk[:n]
+	// only the last chunk can be short, but it can also be full
+	final := n < len(dr.chunk)
+	plain, err := dr.aead.Open(nil, nonce, data, chunkAdditionalData(final))
+	if err != nil && !final {
+		final = true
+		plain, err = dr.aead.Open(nil, nonce, data, chunkAdditionalData(final))
+	}
+	if err != nil {
+		return fmt.Errorf("cannot decrypt chunk %d: %v", dr.counter, err)
+	}
+	dr.counter++
+	dr.plain = plain
+	if final {
+		dr.done = true
+		var extra [1]byte
+		switch _, err := io.ReadFull(dr.r, extra[:]); err {
+		case io.EOF:
+			// all good
+		case nil:
+			return errors.New("unexpected data after the last chunk")
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (dr *decryptingReader) Read(p []byte) (int, error) {
+	for len(dr.plain) == 0 {
+		if dr.done {
+			return 0, io.EOF
+		}
+		if err := dr.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, dr.plain)
+	dr.plain = dr.plain[n:]
+	return n, nil
+}
@@ -0,0 +1,133 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+)
+
+type cryptSuite struct{}
+
+var _ = check.Suite(&cryptSuite{})
+
+func (s *cryptSuite) SetUpTest(c *check.C) {
+	dirs.SetRootDir(c.MkDir())
+}
+
+func (s *cryptSuite) TearDownTest(c *check.C) {
+	dirs.SetRootDir("")
+}
+
+var testKey = bytes.Repeat([]byte{42}, 32)
+
+func encrypt(c *check.C, data []byte) []byte {
+	var buf bytes.Buffer
+	ew, err := backend.NewEncryptingWriter(&buf, testKey)
+	c.Assert(err, check.IsNil)
+	_, err = ew.Write(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(ew.Close(), check.IsNil)
+	return buf.Bytes()
+}
+
+func decrypt(data, key []byte) ([]byte, error) {
+	dr, err := backend.NewDecryptingReader(bytes.NewReader(data), key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(dr)
+}
+
+func (s *cryptSuite) TestRoundtrip(c *check.C) {
+	chunk := backend.EncryptionChunkSize
+	for _, size := range []int{0, 1, chunk - 1, chunk, chunk + 1, 3 * chunk} {
+		comm := check.Commentf("size %d", size)
+		data := bytes.Repeat([]byte("x"), size)
+		encrypted := encrypt(c, data)
+		c.Check(bytes.Contains(encrypted, []byte("xxxx")), check.Equals, false, comm)
+
+		decrypted, err := decrypt(encrypted, testKey)
+		c.Assert(err, check.IsNil, comm)
+		c.Check(decrypted, check.DeepEquals, data, comm)
+	}
+}
+
+func (s *cryptSuite) TestDecryptErrors(c *check.C) {
+	chunk := backend.EncryptionChunkSize
+	data := bytes.Repeat([]byte("x"), 2*chunk)
+	encrypted := encrypt(c, data)
+	// nonce prefix and two full chunks, the second one being the final one
+	c.Assert(encrypted, check.HasLen, 8+2*(chunk+16))
+
+	_, err := decrypt(encrypted, bytes.Repeat([]byte{1}, 32))
+	c.Check(err, check.ErrorMatches, "cannot decrypt chunk 0: .*")
+
+	tampered := append([]byte(nil), encrypted...)
+	tampered[100] ^= 1
+	_, err = decrypt(tampered, testKey)
+	c.Check(err, check.ErrorMatches, "cannot decrypt chunk 0: .*")
+
+	// the final chunk is missing
+	_, err = decrypt(encrypted[:8+chunk+16], testKey)
+	c.Check(err, check.ErrorMatches, "cannot decrypt chunk 1: .*")
+
+	// the final chunk is cut short
+	_, err = decrypt(encrypted[:len(encrypted)-1], testKey)
+	c.Check(err, check.ErrorMatches, "cannot decrypt chunk 1: .*")
+
+	_, err = decrypt(append(encrypted, 0), testKey)
+	c.Check(err, check.ErrorMatches, "unexpected data after the last chunk")
+
+	_, err = decrypt(encrypted[:4], testKey)
+	c.Check(err, check.ErrorMatches, "cannot read nonce: unexpected EOF")
+}
+
+func (s *cryptSuite) TestEncryptionKey(c *check.C) {
+	_, err := backend.EncryptionKey(false)
+	c.Check(err, check.ErrorMatches, `cannot find snapshot encryption key ".*/var/lib/snapd/device/snapshots.key"`)
+
+	key, err := backend.EncryptionKey(true)
+	c.Assert(err, check.IsNil)
+	c.Check(key, check.HasLen, 32)
+	st, err := os.Stat(backend.EncryptionKeyFile())
+	c.Assert(err, check.IsNil)
+	c.Check(st.Mode().Perm(), check.Equals, os.FileMode(0600))
+
+	// the key is only generated once
+	key2, err := backend.EncryptionKey(true)
+	c.Assert(err, check.IsNil)
+	c.Check(key2, check.DeepEquals, key)
+	key2, err = backend.EncryptionKey(false)
+	c.Assert(err, check.IsNil)
+	c.Check(key2, check.DeepEquals, key)
+
+	c.Assert(ioutil.WriteFile(backend.EncryptionKeyFile(), []byte("short"), 0600), check.IsNil)
+	_, err = backend.EncryptionKey(false)
+	c.Check(err, check.ErrorMatches, `invalid snapshot encryption key in ".*"`)
+	c.Check(filepath.Base(backend.EncryptionKeyFile()), check.Equals, "snapshots.key")
+}
@@ -144,3 +144,12 @@ func MockFilepathGlob(new func(pattern string) (matches []string, err error)) (r
 func (se *SnapshotExport) ContentHash() []byte {
 	return se.contentHash
 }
+
+var (
+	NewEncryptingWriter = newEncryptingWriter
+	NewDecryptingReader = newDecryptingReader
+	EncryptionKey       = encryptionKey
+	EncryptionKeyFile   = encryptionKeyFile
+)
+
+const EncryptionChunkSize = encryptionChunkSize
@@ -188,6 +188,39 @@ func (r *Reader) Check(ctx context.Context, usernames []string) error {
 	return nil
 }
 
+// Config returns the snap configuration saved in the snapshot, decrypting
+// it if the snapshot is encrypted.
+func (r *Reader) Config() (map[string]interface{}, error) {
+	if r.Encryption == "" {
+		return r.Conf, nil
+	}
+	if r.Encryption != EncryptionFormat {
+		return nil, fmt.Errorf("unsupported snapshot encryption %q", r.Encryption)
+	}
+	if _, ok := r.SHA3_384[configName]; !ok {
+		// the snap had no configuration
+		return nil, nil
+	}
+	key, err := encryptionKey(false)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := zipMember(r.File, configName)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	dr, err := newDecryptingReader(body, key)
+	if err != nil {
+		return nil, err
+	}
+	var cfg map[string]interface{}
+	if err := jsonutil.DecodeWithNumber(dr, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot read configuration from snapshot %q: %v", r.Name(), err)
+	}
+	return cfg, nil
+}
+
 // Logf is the type implemented by logging functions.
 type Logf func(format string, args ...interface{})
 
@@ -217,10 +250,26 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 		curdir = current.String()
 	}
 
+	var key []byte
+	if r.Encryption != "" {
+		if r.Encryption != EncryptionFormat {
+			return rs, fmt.Errorf("unsupported snapshot encryption %q", r.Encryption)
+		}
+		var err error
+		key, err = encryptionKey(false)
+		if err != nil {
+			return rs, err
+		}
+	}
+
 	for entry := range r.SHA3_384 {
 		if err := ctx.Err(); err != nil {
 			return rs, err
 		}
+		if entry == configName {
+			// restored by the caller, via Config
+			continue
+		}
 
 		var dest string
 		isUser := isUserArchive(entry)
@@ -328,6 +377,13 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 			"--directory", tempdir)
 		cmd.Env = []string{}
 		cmd.Stdin = tr
+		if key != nil {
+			dr, err := newDecryptingReader(tr, key)
+			if err != nil {
+				return rs, fmt.Errorf("cannot decrypt snapshot %q entry %q: %v", r.Name(), entry, err)
+			}
+			cmd.Stdin = dr
+		}
 		matchCounter := &strutil.MatchCounter{N: 1}
 		cmd.Stderr = matchCounter
 		cmd.Stdout = os.Stderr
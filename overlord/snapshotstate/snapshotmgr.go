@@ -35,6 +35,8 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 var (
@@ -53,6 +55,9 @@ var (
 	backendCleanupAbandondedImports = backend.CleanupAbandondedImports
 
 	autoExpirationInterval = time.Hour * 24 // interval between forgetExpiredSnapshots runs as part of Ensure()
+
+	// maximum time between scheduled snapshots, regardless of the schedule
+	maxScheduledSnapshotInterval = time.Hour * 24 * 31
 )
 
 // SnapshotManager takes snapshots of active snaps
@@ -60,6 +65,9 @@ type SnapshotManager struct {
 	state *state.State
 
 	lastForgetExpiredSnapshotTime time.Time
+
+	nextScheduledSnapshot time.Time
+	lastSnapshotSchedule  string
 }
 
 // Manager returns a new SnapshotManager
@@ -82,12 +90,16 @@ func Manager(st *state.State, runner *state.TaskRunner) *SnapshotManager {
 
 // Ensure is part of the overlord.StateManager interface.
 func (mgr *SnapshotManager) Ensure() error {
+	var errForget error
 	// process expired snapshots once a day.
 	if time.Now().After(mgr.lastForgetExpiredSnapshotTime.Add(autoExpirationInterval)) {
-		return mgr.forgetExpiredSnapshots()
+		errForget = mgr.forgetExpiredSnapshots()
 	}
 
-	return nil
+	if err := mgr.ensureScheduledSnapshot(); err != nil {
+		return err
+	}
+	return errForget
 }
 
 func (mgr *SnapshotManager) StartUp() error {
@@ -145,6 +157,83 @@ func (mgr *SnapshotManager) forgetExpiredSnapshots() error {
 	return nil
 }
 
+func scheduledSnapshotInFlight(st *state.State) bool {
+	for _, chg := range st.Changes() {
+		if chg.Kind() == "scheduled-snapshot" && !chg.Status().Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureScheduledSnapshot takes a snapshot of the configured snaps when
+// the schedule set via snapshots.automatic.schedule says it is time to.
+func (mgr *SnapshotManager) ensureScheduledSnapshot() error {
+	st := mgr.state
+	st.Lock()
+	defer st.Unlock()
+
+	schedule, scheduleStr, snapNames, err := snapshotScheduleConfig(st)
+	if err != nil {
+		return err
+	}
+	if len(schedule) == 0 {
+		mgr.nextScheduledSnapshot = time.Time{}
+		mgr.lastSnapshotSchedule = ""
+		return nil
+	}
+	if scheduleStr != mgr.lastSnapshotSchedule {
+		// the schedule has changed
+		mgr.nextScheduledSnapshot = time.Time{}
+		mgr.lastSnapshotSchedule = scheduleStr
+	}
+
+	if scheduledSnapshotInFlight(st) {
+		return nil
+	}
+
+	now := time.Now()
+	if mgr.nextScheduledSnapshot.IsZero() {
+		var last time.Time
+		if err := st.Get("last-scheduled-snapshot", &last); err != nil && err != state.ErrNoState {
+			return err
+		}
+		if last.IsZero() {
+			// anchor the schedule to when it was first seen, so
+			// that the first snapshot happens in the next window
+			last = now
+			st.Set("last-scheduled-snapshot", last)
+		}
+		mgr.nextScheduledSnapshot = now.Add(timeutil.Next(schedule, last, maxScheduledSnapshotInterval))
+		logger.Debugf("Next scheduled snapshot at %s.", mgr.nextScheduledSnapshot.Format(time.RFC3339))
+	}
+	if now.Before(mgr.nextScheduledSnapshot) {
+		return nil
+	}
+
+	setID, snapsSaved, ts, err := scheduledSnapshot(st, snapNames)
+	if err != nil {
+		if _, ok := err.(*snapstate.ChangeConflictError); ok {
+			// try again on the next Ensure
+			logger.Debugf("Scheduled snapshot postponed: %v", err)
+			return nil
+		}
+		if err != snapstate.ErrNothingToDo {
+			return err
+		}
+		logger.Debugf("Scheduled snapshot skipped: no snaps to save.")
+	} else {
+		msg := fmt.Sprintf("Save scheduled snapshot #%d of snaps %s", setID, strutil.Quoted(snapsSaved))
+		chg := st.NewChange("scheduled-snapshot", msg)
+		chg.AddAll(ts)
+		st.EnsureBefore(0)
+	}
+
+	st.Set("last-scheduled-snapshot", now)
+	mgr.nextScheduledSnapshot = time.Time{}
+	return nil
+}
+
 func (SnapshotManager) affectedSnaps(t *state.Task) ([]string, error) {
 	if k := t.Kind(); k == "check-snapshot" || k == "forget-snapshot" {
 		// check and forget don't affect snaps
@@ -166,6 +255,9 @@ type snapshotSetup struct {
 	Filename string        `json:"filename,omitempty"`
 	Current  snap.Revision `json:"current"`
 	Auto     bool          `json:"auto,omitempty"`
+	// Expiration overrides the expiration of automatic snapshots
+	Expiration time.Duration `json:"expiration,omitempty"`
+	Encrypt    bool          `json:"encrypt,omitempty"`
 }
 
 func filename(setID uint64, si *snap.Info) string {
@@ -192,6 +284,10 @@ func prepareSave(task *state.Task) (snapshot *snapshotSetup, cur *snap.Info, cfg
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	snapshot.Encrypt, err = snapshotEncryptionEnabled(st)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	// updating snapshot-setup with the filename, for use in undo
 	snapshot.Filename = filename(snapshot.SetID, cur)
 	task.Set("snapshot-setup", &snapshot)
@@ -203,9 +299,12 @@ func prepareSave(task *state.Task) (snapshot *snapshotSetup, cur *snap.Info, cfg
 
 	// this should be done last because of it modifies the state and the caller needs to undo this if other operation fails.
 	if snapshot.Auto {
-		expiration, err := AutomaticSnapshotExpiration(st)
-		if err != nil {
-			return nil, nil, nil, err
+		expiration := snapshot.Expiration
+		if expiration == 0 {
+			expiration, err = AutomaticSnapshotExpiration(st)
+			if err != nil {
+				return nil, nil, nil, err
+			}
 		}
 		if err := saveExpiration(st, snapshot.SetID, time.Now().Add(expiration)); err != nil {
 			return nil, nil, nil, err
@@ -220,7 +319,8 @@ func doSave(task *state.Task, tomb *tomb.Tomb) error {
 	if err != nil {
 		return err
 	}
-	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users)
+	flags := &backend.SaveFlags{Encrypt: snapshot.Encrypt}
+	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users, flags)
 	if err != nil {
 		st := task.State()
 		st.Lock()
@@ -304,7 +404,13 @@ func doRestore(task *state.Task, tomb *tomb.Tomb) error {
 		return err
 	}
 
-	raw, err := marshalSnapConfig(reader.Conf)
+	cfg, err := reader.Config()
+	if err != nil {
+		backendRevert(restoreState)
+		return fmt.Errorf("cannot read saved config: %v", err)
+	}
+
+	raw, err := marshalSnapConfig(cfg)
 	if err != nil {
 		backendRevert(restoreState)
 		return fmt.Errorf("cannot marshal saved config: %v", err)
@@ -430,9 +536,10 @@ func delayedCrossMgrInit() {
 	snapstate.AutomaticSnapshot = AutomaticSnapshot
 	snapstate.AutomaticSnapshotExpiration = AutomaticSnapshotExpiration
 	snapstate.EstimateSnapshotSize = EstimateSnapshotSize
+	snapstate.DowngradeSnapshot = DowngradeSnapshot
 }
 
-func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]interface{}, []string) (*client.Snapshot, error)) (restore func()) {
+func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *backend.SaveFlags) (*client.Snapshot, error)) (restore func()) {
 	old := backendSave
 	backendSave = f
 	return func() {
@@ -35,8 +35,10 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapshotstate"
 	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
@@ -248,6 +250,181 @@ func (s *snapshotSuite) TestEnsureForgetSnapshotsConflictWithExportSnapshot(c *c
 	s.testEnsureForgetSnapshotsConflict(c, "export-snapshot")
 }
 
+func mockActiveSnaps(names ...string) (restore func()) {
+	return snapshotstate.MockSnapstateAll(func(*state.State) (map[string]*snapstate.SnapState, error) {
+		all := make(map[string]*snapstate.SnapState, len(names))
+		for _, name := range names {
+			all[name] = &snapstate.SnapState{Active: true}
+		}
+		return all, nil
+	})
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshotDisabledByDefault(c *check.C) {
+	defer mockActiveSnaps("foo")()
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	var last time.Time
+	c.Check(st.Get("last-scheduled-snapshot", &last), check.Equals, state.ErrNoState)
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshotAnchorsFirstRun(c *check.C) {
+	defer mockActiveSnaps("foo")()
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.automatic.schedule", "mon,02:00")
+	tr.Commit()
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	// the first snapshot waits for the next window of the schedule
+	c.Check(st.Changes(), check.HasLen, 0)
+	var last time.Time
+	c.Assert(st.Get("last-scheduled-snapshot", &last), check.IsNil)
+	c.Check(time.Since(last) < time.Minute, check.Equals, true)
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshot(c *check.C) {
+	defer mockActiveSnaps("foo", "bar", "baz")()
+	defer snapshotstate.MockSnapstateCheckChangeConflictMany(func(_ *state.State, names []string, _ string) error {
+		c.Check(names, check.DeepEquals, []string{"foo", "bar"})
+		return nil
+	})()
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.automatic.schedule", "mon,02:00")
+	tr.Set("core", "snapshots.automatic.snaps", "foo, bar,not-installed")
+	tr.Set("core", "snapshots.automatic.retention", "48h")
+	tr.Commit()
+	// pretend the last scheduled snapshot was a long time ago
+	st.Set("last-scheduled-snapshot", time.Date(2001, 3, 11, 11, 24, 0, 0, time.UTC))
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	chgs := st.Changes()
+	c.Assert(chgs, check.HasLen, 1)
+	chg := chgs[0]
+	c.Check(chg.Kind(), check.Equals, "scheduled-snapshot")
+	c.Check(chg.Summary(), check.Equals, `Save scheduled snapshot #1 of snaps "foo", "bar"`)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	for i, name := range []string{"foo", "bar"} {
+		c.Check(tasks[i].Kind(), check.Equals, "save-snapshot")
+		var snapshot map[string]interface{}
+		c.Assert(tasks[i].Get("snapshot-setup", &snapshot), check.IsNil)
+		c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+			"set-id":     1.,
+			"snap":       name,
+			"current":    "unset",
+			"auto":       true,
+			"expiration": float64(48 * time.Hour),
+		})
+	}
+	var last time.Time
+	c.Assert(st.Get("last-scheduled-snapshot", &last), check.IsNil)
+	c.Check(time.Since(last) < time.Minute, check.Equals, true)
+	st.Unlock()
+
+	// no new snapshot while the previous one is in flight
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 1)
+}
+
+func (snapshotSuite) TestEnsureScheduledSnapshotConflict(c *check.C) {
+	defer mockActiveSnaps("foo")()
+	defer snapshotstate.MockSnapstateCheckChangeConflictMany(func(*state.State, []string, string) error {
+		return &snapstate.ChangeConflictError{Snap: "foo", ChangeKind: "refresh"}
+	})()
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	lastSnapshot := time.Date(2001, 3, 11, 11, 24, 0, 0, time.UTC)
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.automatic.schedule", "mon,02:00")
+	tr.Commit()
+	st.Set("last-scheduled-snapshot", lastSnapshot)
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	// nothing was done, the snapshot is retried on the next Ensure
+	c.Check(st.Changes(), check.HasLen, 0)
+	var last time.Time
+	c.Assert(st.Get("last-scheduled-snapshot", &last), check.IsNil)
+	c.Check(last.Equal(lastSnapshot), check.Equals, true)
+}
+
+func (snapshotSuite) TestDoSaveScheduledExpiration(c *check.C) {
+	snapInfo := snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "a-snap",
+			Revision: snap.R(-1),
+		},
+		Version: "1.33",
+	}
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snapInfo, nil
+	})()
+	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) {
+		return nil, nil
+	})()
+	defer snapshotstate.MockBackendSave(func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *backend.SaveFlags) (*client.Snapshot, error) {
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	task := st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{
+		"set-id":     42,
+		"snap":       "a-snap",
+		"auto":       true,
+		"expiration": 72 * time.Hour,
+	})
+	st.Unlock()
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	var snapshots map[uint64]struct {
+		ExpiryTime time.Time `json:"expiry-time"`
+	}
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	expiry := snapshots[42].ExpiryTime
+	c.Check(expiry.After(time.Now().Add(71*time.Hour)), check.Equals, true)
+	c.Check(expiry.Before(time.Now().Add(73*time.Hour)), check.Equals, true)
+}
+
 func (snapshotSuite) TestFilename(c *check.C) {
 	si := &snap.Info{
 		SideInfo: snap.SideInfo{
@@ -278,7 +455,7 @@ func (snapshotSuite) TestDoSave(c *check.C) {
 		buf := json.RawMessage(`{"hello": "there"}`)
 		return &buf, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, flags *backend.SaveFlags) (*client.Snapshot, error) {
 		c.Check(id, check.Equals, uint64(42))
 		c.Check(si, check.DeepEquals, &snapInfo)
 		c.Check(cfg, check.DeepEquals, map[string]interface{}{"hello": "there"})
@@ -299,12 +476,53 @@ func (snapshotSuite) TestDoSave(c *check.C) {
 	c.Assert(err, check.IsNil)
 }
 
+func (snapshotSuite) TestDoSaveEncrypted(c *check.C) {
+	snapInfo := snap.Info{
+		SideInfo: snap.SideInfo{
+			RealName: "a-snap",
+			Revision: snap.R(-1),
+		},
+		Version: "1.33",
+	}
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snapInfo, nil
+	})()
+	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) {
+		return nil, nil
+	})()
+	var saveFlags *backend.SaveFlags
+	defer snapshotstate.MockBackendSave(func(_ context.Context, _ uint64, _ *snap.Info, _ map[string]interface{}, _ []string, flags *backend.SaveFlags) (*client.Snapshot, error) {
+		saveFlags = flags
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.encryption", true)
+	tr.Commit()
+	task := st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{
+		"set-id": 42,
+		"snap":   "a-snap",
+	})
+	st.Unlock()
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+	c.Check(saveFlags, check.DeepEquals, &backend.SaveFlags{Encrypt: true})
+
+	st.Lock()
+	defer st.Unlock()
+	var snapshot map[string]interface{}
+	c.Assert(task.Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot["encrypt"], check.Equals, true)
+}
+
 func (snapshotSuite) TestDoSaveFailsWithNoSnap(c *check.C) {
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
 		return nil, errors.New("bzzt")
 	})()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, flags *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -331,7 +549,7 @@ func (snapshotSuite) TestDoSaveFailsWithNoSnapshot(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, flags *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -355,7 +573,7 @@ func (snapshotSuite) TestDoSaveFailsBackendError(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, flags *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, errors.New("bzzt")
 	})()
 
@@ -384,7 +602,7 @@ func (snapshotSuite) TestDoSaveFailsConfigError(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) {
 		return nil, errors.New("bzzt")
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, flags *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -415,7 +633,7 @@ func (snapshotSuite) TestDoSaveFailsBadConfig(c *check.C) {
 		buf := json.RawMessage(`"hello-there"`)
 		return &buf, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, flags *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -448,7 +666,7 @@ func (snapshotSuite) TestDoSaveFailureRemovesStateEntry(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(_ *state.State, snapname string) (*json.RawMessage, error) {
 		return nil, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, flags *backend.SaveFlags) (*client.Snapshot, error) {
 		var expirations map[uint64]interface{}
 		st.Lock()
 		defer st.Unlock()
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/client"
@@ -36,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 var (
@@ -144,6 +146,64 @@ func AutomaticSnapshotExpiration(st *state.State) (time.Duration, error) {
 	return defaultAutomaticSnapshotExpiration, nil
 }
 
+// snapshotEncryptionEnabled returns whether the data of new snapshots
+// should be encrypted, as set via snapshots.encryption.
+func snapshotEncryptionEnabled(st *state.State) (bool, error) {
+	var encrypt bool
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "snapshots.encryption", &encrypt); err != nil && !config.IsNoOption(err) {
+		return false, err
+	}
+	return encrypt, nil
+}
+
+// scheduledSnapshotExpiration returns how long scheduled snapshots are
+// kept. Setting a schedule is an explicit opt-in, so unlike for automatic
+// snapshots taken on removal the default expiration applies on all systems
+// and when snapshots.automatic.retention is "no".
+func scheduledSnapshotExpiration(st *state.State) (time.Duration, error) {
+	expiration, err := AutomaticSnapshotExpiration(st)
+	if err != nil {
+		return 0, err
+	}
+	if expiration == 0 {
+		return defaultAutomaticSnapshotExpiration, nil
+	}
+	return expiration, nil
+}
+
+// snapshotScheduleConfig returns the schedule for automatic snapshots as
+// set via snapshots.automatic.schedule, along with the snaps configured
+// via snapshots.automatic.snaps. An empty schedule means scheduled
+// snapshots are disabled, no snaps means all active snaps.
+func snapshotScheduleConfig(st *state.State) (schedule []*timeutil.Schedule, scheduleStr string, snapNames []string, err error) {
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "snapshots.automatic.schedule", &scheduleStr); err != nil && !config.IsNoOption(err) {
+		return nil, "", nil, err
+	}
+	if scheduleStr == "" {
+		return nil, "", nil, nil
+	}
+	schedule, err = timeutil.ParseSchedule(scheduleStr)
+	if err != nil {
+		logger.Noticef("snapshots.automatic.schedule cannot be parsed: %v", err)
+		return nil, "", nil, nil
+	}
+
+	var snapsStr string
+	if err := tr.Get("core", "snapshots.automatic.snaps", &snapsStr); err != nil && !config.IsNoOption(err) {
+		return nil, "", nil, err
+	}
+	if snapsStr != "" {
+		for _, name := range strings.Split(snapsStr, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				snapNames = append(snapNames, name)
+			}
+		}
+	}
+	return schedule, scheduleStr, snapNames, nil
+}
+
 // saveExpiration saves expiration date of the given snapshot set, in the state.
 // The state needs to be locked by the caller.
 func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
@@ -446,6 +506,79 @@ func AutomaticSnapshot(st *state.State, snapName string) (ts *state.TaskSet, err
 	return ts, nil
 }
 
+// scheduledSnapshot creates a taskset for saving the data of the given
+// snaps, or of all active snaps if none are given, in a snapshot set that
+// expires like an automatic one. Snaps that are not installed are skipped.
+func scheduledSnapshot(st *state.State, snapNames []string) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
+	active, err := allActiveSnapNames(st)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if len(snapNames) == 0 {
+		snapsSaved = active
+	} else {
+		for _, name := range snapNames {
+			if strutil.ListContains(active, name) && !strutil.ListContains(snapsSaved, name) {
+				snapsSaved = append(snapsSaved, name)
+			}
+		}
+	}
+	if len(snapsSaved) == 0 {
+		return 0, nil, nil, snapstate.ErrNothingToDo
+	}
+
+	if err := snapstateCheckChangeConflictMany(st, snapsSaved, ""); err != nil {
+		return 0, nil, nil, err
+	}
+
+	expiration, err := scheduledSnapshotExpiration(st)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	setID, err = newSnapshotSetID(st)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	ts = state.NewTaskSet()
+	for _, name := range snapsSaved {
+		desc := fmt.Sprintf("Save data of snap %q in scheduled snapshot set #%d", name, setID)
+		task := st.NewTask("save-snapshot", desc)
+		snapshot := snapshotSetup{
+			SetID:      setID,
+			Snap:       name,
+			Auto:       true,
+			Expiration: expiration,
+		}
+		task.Set("snapshot-setup", &snapshot)
+		ts.AddTask(task)
+	}
+
+	return setID, snapsSaved, ts, nil
+}
+
+// DowngradeSnapshot creates a taskset for saving the data of a snap before
+// it gets downgraded to an older revision. Unlike automatic snapshots these
+// do not expire, so that the data can be restored should the older revision
+// not work out.
+// Note that the state must be locked by the caller.
+func DowngradeSnapshot(st *state.State, snapName string) (setID uint64, ts *state.TaskSet, err error) {
+	setID, err = newSnapshotSetID(st)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	desc := fmt.Sprintf("Save data of snap %q in snapshot set #%d before downgrading it", snapName, setID)
+	task := st.NewTask("save-snapshot", desc)
+	snapshot := snapshotSetup{
+		SetID: setID,
+		Snap:  snapName,
+	}
+	task.Set("snapshot-setup", &snapshot)
+
+	return setID, state.NewTaskSet(task), nil
+}
+
 // Restore creates a taskset for restoring a snapshot's data.
 // Note that the state must be locked by the caller.
 func Restore(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error) {
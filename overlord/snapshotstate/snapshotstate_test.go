@@ -1188,7 +1188,7 @@ func (snapshotSuite) TestRestoreIntegration(c *check.C) {
 			c.Assert(os.MkdirAll(filepath.Join(home, "snap", name, "common", "common-"+name), 0755), check.IsNil)
 		}
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"})
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"}, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1265,7 +1265,7 @@ func (snapshotSuite) TestRestoreIntegrationFails(c *check.C) {
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, fmt.Sprint(i+1), "canary-"+name), 0755), check.IsNil)
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, "common", "common-"+name), 0755), check.IsNil)
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"})
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"}, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1648,6 +1648,33 @@ func (snapshotSuite) TestAutomaticSnapshot(c *check.C) {
 	})
 }
 
+func (snapshotSuite) TestDowngradeSnapshot(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	// automatic snapshots being disabled does not matter
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.automatic.retention", "no")
+	tr.Commit()
+
+	setID, ts, err := snapshotstate.DowngradeSnapshot(st, "foo")
+	c.Assert(err, check.IsNil)
+	c.Check(setID, check.Equals, uint64(1))
+
+	tasks := ts.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "save-snapshot")
+	c.Check(tasks[0].Summary(), check.Equals, `Save data of snap "foo" in snapshot set #1 before downgrading it`)
+	var snapshot map[string]interface{}
+	c.Check(tasks[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+		"set-id":  1.,
+		"snap":    "foo",
+		"current": "unset",
+	})
+}
+
 func (snapshotSuite) TestAutomaticSnapshotDefaultClassic(c *check.C) {
 	release.MockOnClassic(true)
 
@@ -36,9 +36,12 @@ import (
 // If Manual is set it is the target of an enabled manual alias.
 // Auto is set to the target for an automatic alias, enabled or
 // disabled depending on the automatic aliases flag state.
+// ManualArgs optionally carries arguments that a manual alias passes
+// to its target ahead of the ones given to the alias itself.
 type AliasTarget struct {
-	Manual string `json:"manual,omitempty"`
-	Auto   string `json:"auto,omitempty"`
+	Manual     string   `json:"manual,omitempty"`
+	ManualArgs []string `json:"manual-args,omitempty"`
+	Auto       string   `json:"auto,omitempty"`
 }
 
 // Effective returns the target to use considering whether automatic
@@ -57,6 +60,27 @@ func (at *AliasTarget) Effective(autoDisabled bool) string {
 	return ""
 }
 
+// EffectiveArgs returns the arguments to use with the Effective target,
+// only manual aliases can carry arguments.
+func (at *AliasTarget) EffectiveArgs() []string {
+	if at == nil || at.Manual == "" {
+		return nil
+	}
+	return at.ManualArgs
+}
+
+func aliasArgsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 /*
    State for aliases for a snap is tracked in SnapState with:
 
@@ -71,8 +95,8 @@ func (at *AliasTarget) Effective(autoDisabled bool) string {
    * automatic aliases listed with their target application in the
      snap-declaration of the snap (using AliasTarget.Auto)
 
-   * manual aliases setup with "snap alias SNAP.APP ALIAS" (tracked
-     using AliasTarget.Manual)
+   * manual aliases setup with "snap alias SNAP.APP ALIAS [ARGS...]"
+     (tracked using AliasTarget.Manual and AliasTarget.ManualArgs)
 
    Further
 
@@ -110,13 +134,16 @@ func applyAliasesChange(snapName string, prevAutoDisabled bool, prevAliases map[
 			remove = append(remove, &backend.Alias{
 				Name:   alias,
 				Target: snap.JoinSnapApp(snapName, effTgt),
+				Args:   prevTargets.EffectiveArgs(),
 			})
 		}
 	}
 	for alias, newTargets := range newAliases {
 		prevTgt := prevAliases[alias].Effective(prevAutoDisabled)
 		newTgt := newTargets.Effective(newAutoDisabled)
-		if prevTgt == newTgt {
+		prevArgs := prevAliases[alias].EffectiveArgs()
+		newArgs := newTargets.EffectiveArgs()
+		if prevTgt == newTgt && aliasArgsEqual(prevArgs, newArgs) {
 			// nothing to do
 			continue
 		}
@@ -124,12 +151,14 @@ func applyAliasesChange(snapName string, prevAutoDisabled bool, prevAliases map[
 			remove = append(remove, &backend.Alias{
 				Name:   alias,
 				Target: snap.JoinSnapApp(snapName, prevTgt),
+				Args:   prevArgs,
 			})
 		}
 		if newTgt != "" {
 			add = append(add, &backend.Alias{
 				Name:   alias,
 				Target: snap.JoinSnapApp(snapName, newTgt),
+				Args:   newArgs,
 			})
 		}
 	}
@@ -231,10 +260,11 @@ func refreshAliases(st *state.State, info *snap.Info, curAliases map[string]*Ali
 		}
 		newTarget := newAliases[alias]
 		if newTarget == nil {
-			newAliases[alias] = &AliasTarget{Manual: curTarget.Manual}
+			newAliases[alias] = &AliasTarget{Manual: curTarget.Manual, ManualArgs: curTarget.ManualArgs}
 		} else {
 			// alias is both manually setup but has an underlying auto-alias
 			newAliases[alias].Manual = curTarget.Manual
+			newAliases[alias].ManualArgs = curTarget.ManualArgs
 		}
 	}
 	return newAliases, nil
@@ -374,7 +404,8 @@ func checkSnapAliasConflict(st *state.State, instanceName string) error {
 }
 
 // disableAliases returns newAliases corresponding to the disabling of
-// curAliases, for manual aliases that means removed.
+// curAliases, for manual aliases that means removed. The arguments of
+// the removed manual aliases can be retrieved with manualAliasesArgs.
 func disableAliases(curAliases map[string]*AliasTarget) (newAliases map[string]*AliasTarget, disabledManual map[string]string) {
 	newAliases = make(map[string]*AliasTarget, len(curAliases))
 	disabledManual = make(map[string]string, len(curAliases))
@@ -392,9 +423,26 @@ func disableAliases(curAliases map[string]*AliasTarget) (newAliases map[string]*
 	return newAliases, disabledManual
 }
 
+// manualAliasesArgs returns the arguments of the manual aliases in
+// curAliases that have any.
+func manualAliasesArgs(curAliases map[string]*AliasTarget) map[string][]string {
+	var manualArgs map[string][]string
+	for alias, curTarget := range curAliases {
+		if curTarget.Manual == "" || len(curTarget.ManualArgs) == 0 {
+			continue
+		}
+		if manualArgs == nil {
+			manualArgs = make(map[string][]string)
+		}
+		manualArgs[alias] = curTarget.ManualArgs
+	}
+	return manualArgs
+}
+
 // reenableAliases returns newAliases corresponding to the re-enabling over
-// curAliases of disabledManual manual aliases.
-func reenableAliases(info *snap.Info, curAliases map[string]*AliasTarget, disabledManual map[string]string) (newAliases map[string]*AliasTarget) {
+// curAliases of disabledManual manual aliases, with their disabledManualArgs
+// arguments.
+func reenableAliases(info *snap.Info, curAliases map[string]*AliasTarget, disabledManual map[string]string, disabledManualArgs map[string][]string) (newAliases map[string]*AliasTarget) {
 	newAliases = make(map[string]*AliasTarget, len(curAliases))
 	for alias, aliasTarget := range curAliases {
 		newAliases[alias] = aliasTarget
@@ -408,10 +456,11 @@ func reenableAliases(info *snap.Info, curAliases map[string]*AliasTarget, disabl
 
 		newTarget := newAliases[alias]
 		if newTarget == nil {
-			newAliases[alias] = &AliasTarget{Manual: manual}
+			newAliases[alias] = &AliasTarget{Manual: manual, ManualArgs: disabledManualArgs[alias]}
 		} else {
 			manualTarget := *newTarget
 			manualTarget.Manual = manual
+			manualTarget.ManualArgs = disabledManualArgs[alias]
 			newAliases[alias] = &manualTarget
 		}
 	}
@@ -437,7 +486,7 @@ func pruneAutoAliases(curAliases map[string]*AliasTarget, autoAliases []string)
 		if curTarget.Manual == "" {
 			delete(newAliases, alias)
 		} else {
-			newAliases[alias] = &AliasTarget{Manual: curTarget.Manual}
+			newAliases[alias] = &AliasTarget{Manual: curTarget.Manual, ManualArgs: curTarget.ManualArgs}
 		}
 	}
 	return newAliases
@@ -530,9 +579,22 @@ func (m *SnapManager) ensureAliasesV2() error {
 
 // Alias sets up a manual alias from alias to app in snapName.
 func Alias(st *state.State, instanceName, app, alias string) (*state.TaskSet, error) {
+	return AliasWithArgs(st, instanceName, app, alias, nil)
+}
+
+// AliasWithArgs sets up a manual alias from alias to app in snapName
+// that runs app with args prepended to the arguments given to the alias.
+//
+// TODO: manual aliases are system-wide, support per-user aliases.
+func AliasWithArgs(st *state.State, instanceName, app, alias string, args []string) (*state.TaskSet, error) {
 	if err := snap.ValidateAlias(alias); err != nil {
 		return nil, err
 	}
+	for _, arg := range args {
+		if strings.ContainsRune(arg, 0) {
+			return nil, fmt.Errorf("cannot use alias %q: argument %q contains a NUL byte", alias, arg)
+		}
+	}
 
 	var snapst SnapState
 	err := Get(st, instanceName, &snapst)
@@ -555,14 +617,17 @@ func Alias(st *state.State, instanceName, app, alias string) (*state.TaskSet, er
 	manualAlias := st.NewTask("alias", fmt.Sprintf(i18n.G("Setup manual alias %q => %q for snap %q"), alias, app, snapsup.InstanceName()))
 	manualAlias.Set("alias", alias)
 	manualAlias.Set("target", app)
+	if len(args) > 0 {
+		manualAlias.Set("args", args)
+	}
 	manualAlias.Set("snap-setup", &snapsup)
 
 	return state.NewTaskSet(manualAlias), nil
 }
 
-// manualAliases returns newAliases with a manual alias to target setup over
-// curAliases.
-func manualAlias(info *snap.Info, curAliases map[string]*AliasTarget, target, alias string) (newAliases map[string]*AliasTarget, err error) {
+// manualAliases returns newAliases with a manual alias to target with
+// args setup over curAliases.
+func manualAlias(info *snap.Info, curAliases map[string]*AliasTarget, target, alias string, args []string) (newAliases map[string]*AliasTarget, err error) {
 	if app := info.Apps[target]; app == nil || app.IsService() {
 		var reason string
 		if app == nil {
@@ -579,10 +644,11 @@ func manualAlias(info *snap.Info, curAliases map[string]*AliasTarget, target, al
 
 	newTarget := newAliases[alias]
 	if newTarget == nil {
-		newAliases[alias] = &AliasTarget{Manual: target}
+		newAliases[alias] = &AliasTarget{Manual: target, ManualArgs: args}
 	} else {
 		manualTarget := *newTarget
 		manualTarget.Manual = target
+		manualTarget.ManualArgs = args
 		newAliases[alias] = &manualTarget
 	}
 
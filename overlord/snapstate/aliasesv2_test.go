@@ -610,6 +610,78 @@ func (s *snapmgrTestSuite) TestAliasRunThrough(c *C) {
 	})
 }
 
+func (s *snapmgrTestSuite) TestAliasWithArgsRunThrough(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "alias-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{RealName: "alias-snap", Revision: snap.R(11)},
+		},
+		Current: snap.R(11),
+		Active:  true,
+	})
+
+	chg := s.state.NewChange("alias", "manual alias")
+	ts, err := snapstate.AliasWithArgs(s.state, "alias-snap", "cmd1", "alias1", []string{"-E"})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	defer s.se.Stop()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus, Commentf("%v", chg.Err()))
+
+	var snapst snapstate.SnapState
+	err = snapstate.Get(s.state, "alias-snap", &snapst)
+	c.Assert(err, IsNil)
+	c.Check(snapst.Aliases, DeepEquals, map[string]*snapstate.AliasTarget{
+		"alias1": {Manual: "cmd1", ManualArgs: []string{"-E"}},
+	})
+
+	// changing only the arguments recreates the alias
+	chg = s.state.NewChange("alias", "manual alias")
+	ts, err = snapstate.AliasWithArgs(s.state, "alias-snap", "cmd1", "alias1", []string{"-E", "-v"})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus, Commentf("%v", chg.Err()))
+	expected := fakeOps{
+		{
+			op:      "update-aliases",
+			aliases: []*backend.Alias{{Name: "alias1", Target: "alias-snap.cmd1", Args: []string{"-E"}}},
+		},
+		{
+			op:        "update-aliases",
+			rmAliases: []*backend.Alias{{Name: "alias1", Target: "alias-snap.cmd1", Args: []string{"-E"}}},
+			aliases:   []*backend.Alias{{Name: "alias1", Target: "alias-snap.cmd1", Args: []string{"-E", "-v"}}},
+		},
+	}
+	// start with an easier-to-read error if this fails:
+	c.Assert(s.fakeBackend.ops.Ops(), DeepEquals, expected.Ops())
+	c.Assert(s.fakeBackend.ops, DeepEquals, expected)
+
+	err = snapstate.Get(s.state, "alias-snap", &snapst)
+	c.Assert(err, IsNil)
+	c.Check(snapst.Aliases, DeepEquals, map[string]*snapstate.AliasTarget{
+		"alias1": {Manual: "cmd1", ManualArgs: []string{"-E", "-v"}},
+	})
+}
+
+func (s *snapmgrTestSuite) TestAliasWithArgsNUL(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := snapstate.AliasWithArgs(s.state, "alias-snap", "cmd1", "alias1", []string{"a\x00b"})
+	c.Assert(err, ErrorMatches, `cannot use alias "alias1": argument "a\\x00b" contains a NUL byte`)
+}
+
 func (s *snapmgrTestSuite) TestParallelInstanceAliasRunThrough(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -274,6 +274,10 @@ func (m *autoRefresh) Ensure() error {
 	if ok, err := CanAutoRefresh(m.state); err != nil || !ok {
 		return err
 	}
+	if maintenanceHeld(m.state) {
+		logger.Debugf("Auto refresh skipped during maintenance hold.")
+		return nil
+	}
 
 	// get lastRefresh and schedule
 	lastRefresh, err := m.LastRefresh()
@@ -364,16 +368,45 @@ func (m *autoRefresh) Ensure() error {
 				return nil
 			}
 
-			err = m.launchAutoRefresh(refreshSchedule)
+			err = m.launchAutoRefresh(refreshSchedule, nil)
 			if _, ok := err.(*httputil.PersistentNetworkError); !ok {
 				m.nextRefresh = time.Time{}
 			} // else - refresh will be retried after refreshRetryDelay
 		}
+
+		if err == nil {
+			err = m.ensureScheduledSnapsRefresh(now, lastRefresh)
+		}
 	}
 
 	return err
 }
 
+// ensureScheduledSnapsRefresh refreshes the snaps with their own refresh
+// schedule when it is due, independently of the system refresh schedule.
+func (m *autoRefresh) ensureScheduledSnapsRefresh(now, lastRefresh time.Time) error {
+	if autoRefreshInFlight(m.state) {
+		return nil
+	}
+
+	due, err := snapsDueForScheduledRefresh(m.state)
+	if err != nil || len(due) == 0 {
+		return err
+	}
+
+	can, err := m.canRefreshRespectingMetered(now, lastRefresh)
+	if err != nil || !can {
+		return err
+	}
+
+	// same delay between attempts as for the system refresh schedule
+	if !m.lastRefreshAttempt.IsZero() && m.lastRefreshAttempt.Add(refreshRetryDelay).After(time.Now()) {
+		return nil
+	}
+
+	return m.launchAutoRefresh(nil, due)
+}
+
 // isRefreshHeld returns whether an auto-refresh is currently held back or not,
 // as indicated by m.EffectiveRefreshHold().
 func (m *autoRefresh) isRefreshHeld() (bool, time.Time, error) {
@@ -438,6 +471,21 @@ func (m *autoRefresh) refreshScheduleWithDefaultsFallback() (ts []*timeutil.Sche
 		m.managedDeniedLogged = false
 	}
 
+	// a schedule set for the device group takes precedence over the
+	// local configuration
+	scheduleAsStr, err = deviceGroupRefreshSchedule(m.state)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if scheduleAsStr != "" {
+		ts, err = timeutil.ParseSchedule(scheduleAsStr)
+		if err != nil {
+			logger.Noticef("cannot use device group refresh schedule: %s", err)
+			return refreshScheduleDefault()
+		}
+		return ts, scheduleAsStr, false, nil
+	}
+
 	tr := config.NewTransaction(m.state)
 	// try the new refresh.timer config option first
 	err = tr.Get("core", "refresh.timer", &scheduleAsStr)
@@ -489,7 +537,9 @@ func autoRefreshSummary(updated []string) string {
 }
 
 // launchAutoRefresh creates the auto-refresh taskset and a change for it.
-func (m *autoRefresh) launchAutoRefresh(refreshSchedule []*timeutil.Schedule) error {
+// If names is not empty only the given snaps, which have their own refresh
+// schedule, are refreshed.
+func (m *autoRefresh) launchAutoRefresh(refreshSchedule []*timeutil.Schedule, names []string) error {
 	perfTimings := timings.New(map[string]string{"ensure": "auto-refresh"})
 	tm := perfTimings.StartSpan("auto-refresh", "query store and setup auto-refresh change")
 	defer func() {
@@ -498,9 +548,25 @@ func (m *autoRefresh) launchAutoRefresh(refreshSchedule []*timeutil.Schedule) er
 	}()
 
 	m.lastRefreshAttempt = time.Now()
+	if len(names) > 0 {
+		if err := recordScheduledRefreshAttempts(m.state, names, m.lastRefreshAttempt); err != nil {
+			return err
+		}
+	}
+
+	approvalRequired, err := refreshApprovalRequired(m.state)
+	if err != nil {
+		return err
+	}
 
 	// NOTE: this will unlock and re-lock state for network ops
-	updated, tasksets, err := AutoRefresh(auth.EnsureContextTODO(), m.state)
+	var updated []string
+	var tasksets []*state.TaskSet
+	if approvalRequired {
+		updated, tasksets, err = StageRefreshes(auth.EnsureContextTODO(), m.state, names)
+	} else {
+		updated, tasksets, err = autoRefreshSnaps(auth.EnsureContextTODO(), m.state, names)
+	}
 
 	// TODO: we should have some way to lock just creating and starting changes,
 	//       as that would alleviate this race condition we are guarding against
@@ -525,15 +591,24 @@ func (m *autoRefresh) launchAutoRefresh(refreshSchedule []*timeutil.Schedule) er
 		logger.Noticef("Cannot prepare auto-refresh change due to a permanent network error: %s", err)
 		return err
 	}
-	m.state.Set("last-refresh", time.Now())
+	if len(names) == 0 {
+		m.state.Set("last-refresh", time.Now())
+	}
 	if err != nil {
 		logger.Noticef("Cannot prepare auto-refresh change: %s", err)
 		return err
 	}
 
 	msg := autoRefreshSummary(updated)
+	if approvalRequired {
+		msg = stageRefreshSummary(updated)
+	}
 	if msg == "" {
-		logger.Noticef(i18n.G("auto-refresh: all snaps are up-to-date"))
+		if len(names) > 0 {
+			logger.Noticef("auto-refresh: snaps %s with their own refresh schedule are up-to-date", strutil.Quoted(names))
+		} else {
+			logger.Noticef(i18n.G("auto-refresh: all snaps are up-to-date"))
+		}
 		return nil
 	}
 
@@ -622,14 +697,22 @@ var asyncPendingRefreshNotification = func(context context.Context, client *user
 // inhibitRefresh returns an error if refresh is inhibited by running apps.
 //
 // Internally the snap state is updated to remember when the inhibition first
-// took place. Apps can inhibit refreshes for up to "maxInhibition", beyond
-// that period the refresh will go ahead despite application activity.
+// took place. Apps can inhibit refreshes for up to the inhibition timeout of
+// the snap, beyond that period the inhibition action of the snap is taken:
+// the refresh goes ahead despite application activity, is skipped for this
+// cycle starting a new inhibition window, or the users are only notified
+// about it. Past "maxInhibition" the refresh always goes ahead.
 func inhibitRefresh(st *state.State, snapst *SnapState, info *snap.Info, checker func(*snap.Info) error) error {
 	checkerErr := checker(info)
 	if checkerErr == nil {
 		return nil
 	}
 
+	timeout, action, err := refreshInhibitPolicy(st, info.InstanceName())
+	if err != nil {
+		return err
+	}
+
 	// Get pending refresh information from compatible errors or synthesize a new one.
 	var refreshInfo *userclient.PendingSnapRefreshInfo
 	if err, ok := checkerErr.(*BusySnapError); ok {
@@ -643,21 +726,38 @@ func inhibitRefresh(st *state.State, snapst *SnapState, info *snap.Info, checker
 	// Decide on what to do depending on the state of the snap and the remaining
 	// inhibition time.
 	now := time.Now()
-	switch {
-	case snapst.RefreshInhibitedTime == nil:
+	if snapst.RefreshInhibitedTime == nil {
 		// If the snap did not have inhibited refresh yet then commence a new
 		// window, during which refreshes are postponed, by storing the current
 		// time in the snap state's RefreshInhibitedTime field. This field is
 		// reset to nil on successful refresh.
 		snapst.RefreshInhibitedTime = &now
-		refreshInfo.TimeRemaining = (maxInhibition - now.Sub(*snapst.RefreshInhibitedTime)).Truncate(time.Second)
 		Set(st, info.InstanceName(), snapst)
-	case now.Sub(*snapst.RefreshInhibitedTime) < maxInhibition:
-		// If we are still in the allowed window then just return the error but
-		// don't change the snap state again.
+	}
+	inhibited := now.Sub(*snapst.RefreshInhibitedTime)
+	window := now.Sub(inhibitionWindowStart(snapst))
+	switch {
+	case inhibited < maxInhibition && window < timeout:
+		// If we are still in the allowed window then just return the error.
 		// TODO: as time left shrinks, send additional notifications with
 		// increasing frequency, allowing the user to understand the urgency.
-		refreshInfo.TimeRemaining = (maxInhibition - now.Sub(*snapst.RefreshInhibitedTime)).Truncate(time.Second)
+		remaining := timeout - window
+		if left := maxInhibition - inhibited; left < remaining {
+			remaining = left
+		}
+		refreshInfo.TimeRemaining = remaining.Truncate(time.Second)
+	case inhibited < maxInhibition && action == RefreshInhibitActionSkip:
+		// The snap asked for the refresh to be skipped for this cycle,
+		// the next attempt starts a new inhibition window, until the hard
+		// limit is reached.
+		logger.Noticef("skipping refresh of snap %q inhibited by running apps", info.InstanceName())
+		snapst.RefreshInhibitSkippedTime = &now
+		Set(st, info.InstanceName(), snapst)
+		return checkerErr
+	case inhibited < maxInhibition && action == RefreshInhibitActionNotify:
+		// Keep inhibiting the refresh and only notify the user, with the
+		// time left until the hard limit is reached.
+		refreshInfo.TimeRemaining = (maxInhibition - inhibited).Truncate(time.Second)
 	default:
 		// If we run out of time then consume the error that would normally
 		// inhibit refresh and notify the user that the snap is refreshing right
@@ -666,6 +766,20 @@ func inhibitRefresh(st *state.State, snapst *SnapState, info *snap.Info, checker
 		checkerErr = nil
 	}
 
+	// Record a notice so that clients can present the pending refresh and
+	// the user can acknowledge it.
+	data := make(map[string]string)
+	if refreshInfo.BusyAppName != "" {
+		data["busy-app"] = refreshInfo.BusyAppName
+	}
+	if refreshInfo.TimeRemaining > 0 {
+		data["time-remaining"] = refreshInfo.TimeRemaining.String()
+	}
+	st.AddNotice(nil, state.RefreshInhibitNotice, info.InstanceName(), &state.AddNoticeOptions{
+		Data:        data,
+		RequiresAck: true,
+	})
+
 	// Send the notification asynchronously to avoid holding the state lock.
 	asyncPendingRefreshNotification(context.TODO(), userclient.New(), refreshInfo)
 	return checkerErr
@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// RefreshInhibitAction is what happens to a refresh inhibited by running
+// apps once the inhibition window of the snap is over.
+type RefreshInhibitAction string
+
+const (
+	// RefreshInhibitActionForce refreshes the snap despite its running apps.
+	RefreshInhibitActionForce RefreshInhibitAction = "force"
+	// RefreshInhibitActionSkip skips the refresh for this cycle.
+	RefreshInhibitActionSkip RefreshInhibitAction = "skip"
+	// RefreshInhibitActionNotify only notifies the users about the
+	// pending refresh.
+	RefreshInhibitActionNotify RefreshInhibitAction = "notify"
+)
+
+// RefreshInhibition describes an ongoing inhibition of the refreshes of a
+// snap by its running apps.
+type RefreshInhibition struct {
+	// ProceedTime is when the inhibition window of the snap is over and
+	// Action is taken.
+	ProceedTime time.Time
+	Action      RefreshInhibitAction
+}
+
+// refreshInhibitPolicy returns how long the running apps of the given snap
+// can inhibit its refreshes and what happens once that is over, as set with
+// "snap set <snap> refresh.inhibit-timeout=<duration>" and
+// "snap set <snap> refresh.inhibit-action=force|skip|notify". The timeout
+// cannot exceed maxInhibition.
+func refreshInhibitPolicy(st *state.State, instanceName string) (timeout time.Duration, action RefreshInhibitAction, err error) {
+	tr := config.NewTransaction(st)
+
+	timeout = maxInhibition
+	var timeoutStr string
+	if err := tr.Get(instanceName, "refresh.inhibit-timeout", &timeoutStr); err != nil && !config.IsNoOption(err) {
+		return 0, "", err
+	}
+	if timeoutStr != "" {
+		d, err := time.ParseDuration(timeoutStr)
+		switch {
+		case err != nil:
+			logger.Noticef("cannot use refresh.inhibit-timeout configuration of snap %q: %v", instanceName, err)
+		case d <= 0 || d > maxInhibition:
+			logger.Noticef("cannot use refresh.inhibit-timeout configuration of snap %q: must be positive and at most %s", instanceName, maxInhibition)
+		default:
+			timeout = d
+		}
+	}
+
+	action = RefreshInhibitActionForce
+	var actionStr string
+	if err := tr.Get(instanceName, "refresh.inhibit-action", &actionStr); err != nil && !config.IsNoOption(err) {
+		return 0, "", err
+	}
+	switch a := RefreshInhibitAction(actionStr); a {
+	case "":
+	case RefreshInhibitActionForce, RefreshInhibitActionSkip, RefreshInhibitActionNotify:
+		action = a
+	default:
+		logger.Noticef("cannot use refresh.inhibit-action configuration of snap %q: unknown action %q", instanceName, actionStr)
+	}
+
+	return timeout, action, nil
+}
+
+// RefreshInhibitionInfo returns the details of the ongoing inhibition of the
+// refreshes of the given snap by its running apps, or nil if there is none.
+func RefreshInhibitionInfo(st *state.State, instanceName string, snapst *SnapState) (*RefreshInhibition, error) {
+	if snapst.RefreshInhibitedTime == nil {
+		return nil, nil
+	}
+	timeout, action, err := refreshInhibitPolicy(st, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	proceed := inhibitionWindowStart(snapst).Add(timeout)
+	if limit := snapst.RefreshInhibitedTime.Add(maxInhibition); limit.Before(proceed) {
+		proceed = limit
+	}
+	return &RefreshInhibition{
+		ProceedTime: proceed,
+		Action:      action,
+	}, nil
+}
+
+// inhibitionWindowStart returns when the current inhibition window of the
+// refreshes of the given snap started, that is when the refresh was first
+// inhibited or last skipped.
+func inhibitionWindowStart(snapst *SnapState) time.Time {
+	if snapst.RefreshInhibitSkippedTime != nil {
+		return *snapst.RefreshInhibitSkippedTime
+	}
+	return *snapst.RefreshInhibitedTime
+}
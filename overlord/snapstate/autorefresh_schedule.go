@@ -0,0 +1,161 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+// snapRefreshSchedule returns the auto-refresh schedule set for the given
+// snap with "snap set <snap> refresh.timer=<schedule>", or nil if the snap
+// follows the system refresh schedule.
+func snapRefreshSchedule(st *state.State, instanceName string) ([]*timeutil.Schedule, error) {
+	tr := config.NewTransaction(st)
+
+	var scheduleStr string
+	err := tr.Get(instanceName, "refresh.timer", &scheduleStr)
+	if err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	if scheduleStr == "" {
+		return nil, nil
+	}
+	schedule, err := timeutil.ParseSchedule(scheduleStr)
+	if err != nil {
+		logger.Noticef("cannot use refresh.timer configuration of snap %q: %v", instanceName, err)
+		return nil, nil
+	}
+	return schedule, nil
+}
+
+// snapRefreshDue returns whether a snap with its own refresh schedule is due
+// to be auto-refreshed, that is whether a window of its schedule started
+// since the snap was last refreshed or a refresh of it was last attempted.
+// hasSchedule is false for snaps following the system refresh schedule.
+func snapRefreshDue(st *state.State, instanceName string, snapst *SnapState, lastAttempt time.Time) (hasSchedule, due bool, err error) {
+	schedule, err := snapRefreshSchedule(st, instanceName)
+	if err != nil || schedule == nil {
+		return false, false, err
+	}
+
+	last := lastAttempt
+	if snapst.LastRefreshTime != nil && snapst.LastRefreshTime.After(last) {
+		last = *snapst.LastRefreshTime
+	}
+	if last.IsZero() {
+		// never refreshed, go by the last system refresh instead
+		last, err = getTime(st, "last-refresh")
+		if err != nil {
+			return true, false, err
+		}
+	}
+	if last.IsZero() {
+		return true, true, nil
+	}
+	return true, timeutil.Next(schedule, last, maxPostponement) == 0, nil
+}
+
+// autoRefreshGatedBySchedule returns the snaps that have their own refresh
+// schedule and are not due to be refreshed, these are left out of the
+// auto-refreshes happening on the system refresh schedule.
+func autoRefreshGatedBySchedule(st *state.State, snapStates map[string]*SnapState) (map[string]bool, error) {
+	gated := make(map[string]bool)
+	for instanceName, snapst := range snapStates {
+		hasSchedule, due, err := snapRefreshDue(st, instanceName, snapst, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		if hasSchedule && !due {
+			gated[instanceName] = true
+		}
+	}
+	return gated, nil
+}
+
+// scheduledRefreshAttempts returns the times of the last auto-refresh
+// attempts of the snaps with their own refresh schedule.
+func scheduledRefreshAttempts(st *state.State) (map[string]time.Time, error) {
+	var attempts map[string]time.Time
+	err := st.Get("scheduled-refresh-attempts", &attempts)
+	if err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// recordScheduledRefreshAttempts records an auto-refresh attempt of the
+// given snaps with their own refresh schedule, dropping the attempts of
+// snaps that are not installed anymore.
+func recordScheduledRefreshAttempts(st *state.State, names []string, t time.Time) error {
+	attempts, err := scheduledRefreshAttempts(st)
+	if err != nil {
+		return err
+	}
+	snapStates, err := All(st)
+	if err != nil {
+		return err
+	}
+	updated := make(map[string]time.Time, len(attempts)+len(names))
+	for name, attempt := range attempts {
+		if snapStates[name] != nil {
+			updated[name] = attempt
+		}
+	}
+	for _, name := range names {
+		updated[name] = t
+	}
+	st.Set("scheduled-refresh-attempts", updated)
+	return nil
+}
+
+// snapsDueForScheduledRefresh returns the sorted names of the snaps with
+// their own refresh schedule which are due to be auto-refreshed.
+func snapsDueForScheduledRefresh(st *state.State) ([]string, error) {
+	snapStates, err := All(st)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := scheduledRefreshAttempts(st)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []string
+	for instanceName, snapst := range snapStates {
+		if !snapst.Active || snapst.DevMode {
+			continue
+		}
+		hasSchedule, isDue, err := snapRefreshDue(st, instanceName, snapst, attempts[instanceName])
+		if err != nil {
+			return nil, err
+		}
+		if hasSchedule && isDue {
+			due = append(due, instanceName)
+		}
+	}
+	sort.Strings(due)
+	return due, nil
+}
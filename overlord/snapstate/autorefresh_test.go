@@ -24,11 +24,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/logger"
@@ -51,6 +53,8 @@ type autoRefreshStore struct {
 
 	ops []string
 
+	refreshedSnaps [][]string
+
 	err error
 
 	snapActionOpsFunc func()
@@ -75,8 +79,9 @@ func (r *autoRefreshStore) SnapAction(ctx context.Context, currentSnaps []*store
 	if ctx == nil || !auth.IsEnsureContext(ctx) {
 		panic("Ensure marked context required")
 	}
-	if len(currentSnaps) != len(actions) || len(currentSnaps) == 0 {
-		panic("expected in test one action for each current snaps, and at least one snap")
+	// snaps with their own refresh schedule are refreshed on their own
+	if len(actions) > len(currentSnaps) || len(actions) == 0 {
+		panic("expected in test at most one action for each current snaps, and at least one snap")
 	}
 	for _, a := range actions {
 		if a.Action != "refresh" {
@@ -86,6 +91,13 @@ func (r *autoRefreshStore) SnapAction(ctx context.Context, currentSnaps []*store
 
 	r.ops = append(r.ops, "list-refresh")
 
+	names := make([]string, 0, len(actions))
+	for _, a := range actions {
+		names = append(names, a.InstanceName)
+	}
+	sort.Strings(names)
+	r.refreshedSnaps = append(r.refreshedSnaps, names)
+
 	return nil, nil, r.err
 }
 
@@ -151,6 +163,24 @@ func (s *autoRefreshTestSuite) TestLastRefresh(c *C) {
 	c.Check(lastRefresh.Year(), Equals, time.Now().Year())
 }
 
+func (s *autoRefreshTestSuite) TestAutoRefreshMaintenanceHold(c *C) {
+	s.state.Lock()
+	_, err := snapstate.HoldMaintenance(s.state, time.Time{})
+	s.state.Unlock()
+	c.Assert(err, IsNil)
+
+	af := snapstate.NewAutoRefresh(s.state)
+	c.Check(af.Ensure(), IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+
+	s.state.Lock()
+	snapstate.ReleaseMaintenance(s.state)
+	s.state.Unlock()
+
+	c.Check(af.Ensure(), IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+}
+
 func (s *autoRefreshTestSuite) TestLastRefreshRefreshManaged(c *C) {
 	snapstate.CanManageRefreshes = func(st *state.State) bool {
 		return true
@@ -227,6 +257,35 @@ func (s *autoRefreshTestSuite) TestRefreshManagedTimerWins(c *C) {
 	c.Check(err, IsNil)
 }
 
+func (s *autoRefreshTestSuite) TestRefreshScheduleDeviceGroup(c *C) {
+	snapstate.DeviceGroups = func(st *state.State) ([]*asserts.DeviceGroup, error) {
+		return []*asserts.DeviceGroup{
+			MakeDeviceGroup("fleet-a", nil),
+			MakeDeviceGroup("fleet-b", map[string]interface{}{
+				"refresh-schedule": "mon,10:00-12:00",
+			}),
+			MakeDeviceGroup("fleet-c", map[string]interface{}{
+				"refresh-schedule": "fri,10:00-12:00",
+			}),
+		}, nil
+	}
+	defer func() { snapstate.DeviceGroups = nil }()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// the device group schedule takes precedence over the local one
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.timer", "00:00-12:00")
+	tr.Commit()
+
+	af := snapstate.NewAutoRefresh(s.state)
+	refreshScheduleStr, legacy, err := af.RefreshSchedule()
+	c.Check(err, IsNil)
+	c.Check(refreshScheduleStr, Equals, "mon,10:00-12:00")
+	c.Check(legacy, Equals, false)
+}
+
 func (s *autoRefreshTestSuite) TestRefreshManagedDenied(c *C) {
 	canManageCalled := false
 	snapstate.CanManageRefreshes = func(st *state.State) bool {
@@ -914,6 +973,12 @@ func (s *autoRefreshTestSuite) TestInitialInhibitRefreshWithinInhibitWindow(c *C
 	})
 	c.Assert(err, ErrorMatches, `snap "pkg" has running apps or hooks`)
 	c.Check(notificationCount, Equals, 1)
+
+	notices := s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.RefreshInhibitNotice}})
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].String(), Equals, "Notice 1 (public:refresh-inhibit:pkg)")
+	c.Check(notices[0].Data(), DeepEquals, map[string]string{"time-remaining": "336h0m0s"})
+	c.Check(notices[0].Pending(), Equals, true)
 }
 
 func (s *autoRefreshTestSuite) TestSubsequentInhibitRefreshWithinInhibitWindow(c *C) {
@@ -976,3 +1041,288 @@ func (s *autoRefreshTestSuite) TestInhibitRefreshRefreshesWhenOverdue(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(notificationCount, Equals, 1)
 }
+
+func (s *autoRefreshTestSuite) TestInhibitRefreshWithinConfiguredTimeout(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("pkg", "refresh.inhibit-timeout", "48h")
+	tr.Commit()
+
+	notificationCount := 0
+	restore := snapstate.MockAsyncPendingRefreshNotification(func(ctx context.Context, client *userclient.Client, refreshInfo *userclient.PendingSnapRefreshInfo) {
+		notificationCount++
+		c.Check(refreshInfo.TimeRemaining, Equals, 48*time.Hour)
+	})
+	defer restore()
+
+	si := &snap.SideInfo{RealName: "pkg", Revision: snap.R(1)}
+	info := &snap.Info{SideInfo: *si}
+	snapst := &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{si},
+		Current:  si.Revision,
+	}
+	err := snapstate.InhibitRefresh(s.state, snapst, info, func(si *snap.Info) error {
+		return &snapstate.BusySnapError{SnapInfo: si}
+	})
+	c.Assert(err, ErrorMatches, `snap "pkg" has running apps or hooks`)
+	c.Check(notificationCount, Equals, 1)
+
+	inhibition, err := snapstate.RefreshInhibitionInfo(s.state, "pkg", snapst)
+	c.Assert(err, IsNil)
+	c.Check(inhibition, DeepEquals, &snapstate.RefreshInhibition{
+		ProceedTime: snapst.RefreshInhibitedTime.Add(48 * time.Hour),
+		Action:      snapstate.RefreshInhibitActionForce,
+	})
+}
+
+func (s *autoRefreshTestSuite) TestInhibitRefreshConfiguredTimeoutOver(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	pastInstant := time.Now().Add(-72 * time.Hour)
+	si := &snap.SideInfo{RealName: "pkg", Revision: snap.R(1)}
+	info := &snap.Info{SideInfo: *si}
+
+	for _, t := range []struct {
+		action        string
+		inhibited     bool
+		notifications int
+	}{
+		{"", false, 1},
+		{"force", false, 1},
+		{"skip", true, 0},
+		{"notify", true, 1},
+	} {
+		tr := config.NewTransaction(s.state)
+		tr.Set("pkg", "refresh.inhibit-timeout", "48h")
+		tr.Set("pkg", "refresh.inhibit-action", t.action)
+		tr.Commit()
+
+		notificationCount := 0
+		restore := snapstate.MockAsyncPendingRefreshNotification(func(ctx context.Context, client *userclient.Client, refreshInfo *userclient.PendingSnapRefreshInfo) {
+			notificationCount++
+			if t.inhibited {
+				// the time left until the hard limit, with second
+				// granularity as in the tests above
+				c.Check(refreshInfo.TimeRemaining, Equals, snapstate.MaxInhibition-72*time.Hour-time.Second)
+			} else {
+				c.Check(refreshInfo.TimeRemaining, Equals, time.Duration(0))
+			}
+		})
+
+		snapst := &snapstate.SnapState{
+			Sequence:             []*snap.SideInfo{si},
+			Current:              si.Revision,
+			RefreshInhibitedTime: &pastInstant,
+		}
+		err := snapstate.InhibitRefresh(s.state, snapst, info, func(si *snap.Info) error {
+			return &snapstate.BusySnapError{SnapInfo: si}
+		})
+		if t.inhibited {
+			c.Check(err, ErrorMatches, `snap "pkg" has running apps or hooks`, Commentf(t.action))
+		} else {
+			c.Check(err, IsNil, Commentf(t.action))
+		}
+		c.Check(notificationCount, Equals, t.notifications, Commentf(t.action))
+		restore()
+	}
+}
+
+func (s *autoRefreshTestSuite) TestInhibitRefreshSkipStartsNewWindow(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("pkg", "refresh.inhibit-timeout", "48h")
+	tr.Set("pkg", "refresh.inhibit-action", "skip")
+	tr.Commit()
+
+	var notified []time.Duration
+	restore := snapstate.MockAsyncPendingRefreshNotification(func(ctx context.Context, client *userclient.Client, refreshInfo *userclient.PendingSnapRefreshInfo) {
+		notified = append(notified, refreshInfo.TimeRemaining)
+	})
+	defer restore()
+
+	pastInstant := time.Now().Add(-72 * time.Hour)
+	si := &snap.SideInfo{RealName: "pkg", Revision: snap.R(1)}
+	info := &snap.Info{SideInfo: *si}
+	snapst := &snapstate.SnapState{
+		Sequence:             []*snap.SideInfo{si},
+		Current:              si.Revision,
+		RefreshInhibitedTime: &pastInstant,
+	}
+	busy := func(si *snap.Info) error {
+		return &snapstate.BusySnapError{SnapInfo: si}
+	}
+
+	// the refresh is skipped silently for this cycle
+	err := snapstate.InhibitRefresh(s.state, snapst, info, busy)
+	c.Assert(err, ErrorMatches, `snap "pkg" has running apps or hooks`)
+	c.Check(notified, HasLen, 0)
+	c.Assert(snapst.RefreshInhibitSkippedTime, NotNil)
+	c.Check(snapst.RefreshInhibitedTime, DeepEquals, &pastInstant)
+
+	inhibition, err := snapstate.RefreshInhibitionInfo(s.state, "pkg", snapst)
+	c.Assert(err, IsNil)
+	c.Check(inhibition.ProceedTime, DeepEquals, snapst.RefreshInhibitSkippedTime.Add(48*time.Hour))
+
+	// the next attempt is inhibited within a new window
+	err = snapstate.InhibitRefresh(s.state, snapst, info, busy)
+	c.Assert(err, ErrorMatches, `snap "pkg" has running apps or hooks`)
+	c.Assert(notified, HasLen, 1)
+	c.Check(notified[0] > 47*time.Hour && notified[0] <= 48*time.Hour, Equals, true)
+}
+
+func (s *autoRefreshTestSuite) TestInhibitRefreshActionCannotExceedMaxInhibition(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("pkg", "refresh.inhibit-timeout", "1000h")
+	tr.Set("pkg", "refresh.inhibit-action", "skip")
+	tr.Commit()
+
+	restore := snapstate.MockAsyncPendingRefreshNotification(func(ctx context.Context, client *userclient.Client, refreshInfo *userclient.PendingSnapRefreshInfo) {})
+	defer restore()
+
+	pastInstant := time.Now().Add(-snapstate.MaxInhibition * 2)
+	si := &snap.SideInfo{RealName: "pkg", Revision: snap.R(1)}
+	info := &snap.Info{SideInfo: *si}
+	snapst := &snapstate.SnapState{
+		Sequence:             []*snap.SideInfo{si},
+		Current:              si.Revision,
+		RefreshInhibitedTime: &pastInstant,
+	}
+	err := snapstate.InhibitRefresh(s.state, snapst, info, func(si *snap.Info) error {
+		return &snapstate.BusySnapError{SnapInfo: si}
+	})
+	c.Assert(err, IsNil)
+
+	// the invalid timeout is ignored
+	inhibition, err := snapstate.RefreshInhibitionInfo(s.state, "pkg", snapst)
+	c.Assert(err, IsNil)
+	c.Check(inhibition, DeepEquals, &snapstate.RefreshInhibition{
+		ProceedTime: pastInstant.Add(snapstate.MaxInhibition),
+		Action:      snapstate.RefreshInhibitActionSkip,
+	})
+}
+
+func (s *autoRefreshTestSuite) TestRefreshInhibitionInfoNotInhibited(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	inhibition, err := snapstate.RefreshInhibitionInfo(s.state, "pkg", &snapstate.SnapState{})
+	c.Assert(err, IsNil)
+	c.Check(inhibition, IsNil)
+}
+
+func (s *autoRefreshTestSuite) TestSnapRefreshTimerGatesSystemRefresh(c *C) {
+	s.state.Lock()
+	lastRefresh := time.Now()
+	snapstate.Set(s.state, "other-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "other-snap", Revision: snap.R(2), SnapID: "other-snap-id"},
+		},
+		Current:         snap.R(2),
+		SnapType:        "app",
+		LastRefreshTime: &lastRefresh,
+	})
+	tr := config.NewTransaction(s.state)
+	tr.Set("other-snap", "refresh.timer", "00:00-24:00")
+	tr.Commit()
+	s.state.Unlock()
+
+	af := snapstate.NewAutoRefresh(s.state)
+	err := af.Ensure()
+	c.Check(err, IsNil)
+
+	// other-snap was refreshed within the current window of its own
+	// schedule and is left out of the system auto-refresh
+	c.Check(s.store.refreshedSnaps, DeepEquals, [][]string{{"some-snap"}})
+}
+
+func (s *autoRefreshTestSuite) TestSnapRefreshTimerDue(c *C) {
+	s.state.Lock()
+	// the system refresh is not due
+	s.state.Set("last-refresh", time.Now())
+	lastRefresh := time.Now().Add(-48 * time.Hour)
+	snapstate.Set(s.state, "other-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "other-snap", Revision: snap.R(2), SnapID: "other-snap-id"},
+		},
+		Current:         snap.R(2),
+		SnapType:        "app",
+		LastRefreshTime: &lastRefresh,
+	})
+	tr := config.NewTransaction(s.state)
+	tr.Set("other-snap", "refresh.timer", "00:00-24:00")
+	tr.Commit()
+	s.state.Unlock()
+
+	assertionsRefreshed := 0
+	snapstate.AutoRefreshAssertions = func(st *state.State, userID int) error {
+		assertionsRefreshed++
+		return nil
+	}
+	defer func() { snapstate.AutoRefreshAssertions = nil }()
+
+	af := snapstate.NewAutoRefresh(s.state)
+	err := af.Ensure()
+	c.Check(err, IsNil)
+
+	// only the snap with its own due schedule got refreshed, going
+	// through the regular auto-refresh
+	c.Check(s.store.refreshedSnaps, DeepEquals, [][]string{{"other-snap"}})
+	c.Check(assertionsRefreshed, Equals, 1)
+
+	s.state.Lock()
+	var attempts map[string]time.Time
+	c.Check(s.state.Get("scheduled-refresh-attempts", &attempts), IsNil)
+	c.Check(attempts["other-snap"].IsZero(), Equals, false)
+	// the system refresh time is unchanged
+	var systemRefresh time.Time
+	c.Check(s.state.Get("last-refresh", &systemRefresh), IsNil)
+	c.Check(systemRefresh.Before(attempts["other-snap"]), Equals, true)
+	s.state.Unlock()
+
+	// and the attempt is not repeated within the same window, also
+	// by a new manager
+	err = af.Ensure()
+	c.Check(err, IsNil)
+	af = snapstate.NewAutoRefresh(s.state)
+	err = af.Ensure()
+	c.Check(err, IsNil)
+	c.Check(s.store.refreshedSnaps, DeepEquals, [][]string{{"other-snap"}})
+}
+
+func (s *autoRefreshTestSuite) TestSnapRefreshTimerInvalidFollowsSystemSchedule(c *C) {
+	s.state.Lock()
+	lastRefresh := time.Now()
+	snapstate.Set(s.state, "other-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "other-snap", Revision: snap.R(2), SnapID: "other-snap-id"},
+		},
+		Current:         snap.R(2),
+		SnapType:        "app",
+		LastRefreshTime: &lastRefresh,
+	})
+	tr := config.NewTransaction(s.state)
+	tr.Set("other-snap", "refresh.timer", "invalid")
+	tr.Commit()
+	s.state.Unlock()
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	af := snapstate.NewAutoRefresh(s.state)
+	err := af.Ensure()
+	c.Check(err, IsNil)
+
+	c.Check(s.store.refreshedSnaps, DeepEquals, [][]string{{"other-snap", "some-snap"}})
+	c.Check(logbuf.String(), testutil.Contains, `cannot use refresh.timer configuration of snap "other-snap"`)
+}
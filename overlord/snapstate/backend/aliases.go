@@ -27,12 +27,53 @@ import (
 	"syscall"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
 )
 
 // Alias represents a command alias with a name and its application target.
+// If Args is set the alias is written as a small wrapper script that runs
+// the target with the given arguments prepended to the ones passed to the
+// alias, instead of as a plain symlink.
 type Alias struct {
-	Name   string `json:"name"`
-	Target string `json:"target"`
+	Name   string   `json:"name"`
+	Target string   `json:"target"`
+	Args   []string `json:"args,omitempty"`
+}
+
+// aliasWrapperMarker prefixes the line naming the target in alias wrapper
+// scripts, it is used to recognize them when removing the aliases of a snap.
+const aliasWrapperMarker = "# snapd alias for "
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func aliasWrapperContent(alias *Alias) []byte {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "#!/bin/sh\n%s%s\n", aliasWrapperMarker, alias.Target)
+	fmt.Fprintf(&buf, "exec /usr/bin/snap run %s", shellQuote(alias.Target))
+	for _, arg := range alias.Args {
+		fmt.Fprintf(&buf, " %s", shellQuote(arg))
+	}
+	buf.WriteString(" \"$@\"\n")
+	return []byte(buf.String())
+}
+
+// aliasWrapperTarget returns the target of the alias wrapper script at
+// path, or "" if path is not an alias wrapper.
+func aliasWrapperTarget(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := f.Read(head)
+	lines := strings.SplitN(string(head[:n]), "\n", 3)
+	if len(lines) < 3 || lines[0] != "#!/bin/sh" || !strings.HasPrefix(lines[1], aliasWrapperMarker) {
+		return ""
+	}
+	return strings.TrimPrefix(lines[1], aliasWrapperMarker)
 }
 
 // MatchingAliases returns the subset of aliases that exist on disk and have the expected targets.
@@ -63,6 +104,15 @@ func (b Backend) UpdateAliases(add []*Alias, remove []*Alias) error {
 			}
 		}
 
+		if len(alias.Args) > 0 {
+			if err := osutil.AtomicWriteFile(p, aliasWrapperContent(alias), 0755, 0); err != nil {
+				return fmt.Errorf("cannot create alias wrapper: %v", err)
+			}
+			// completion of the target would not account for the
+			// extra arguments
+			continue
+		}
+
 		err := os.Symlink(alias.Target, p)
 		if err != nil {
 			return fmt.Errorf("cannot create alias symlink: %v", err)
@@ -92,9 +142,12 @@ func removeSymlinksTo(dir, snapName string) error {
 	for _, cand := range cands {
 		target, err := os.Readlink(cand)
 		if err, ok := err.(*os.PathError); ok && err.Err == syscall.EINVAL {
-			continue
-		}
-		if err != nil {
+			// not a symlink, but possibly an alias wrapper
+			target = aliasWrapperTarget(cand)
+			if target == "" {
+				continue
+			}
+		} else if err != nil {
 			if firstErr == nil {
 				firstErr = err
 			}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/snapstate/backend"
+	"github.com/snapcore/snapd/testutil"
 )
 
 type aliasesSuite struct {
@@ -365,3 +366,50 @@ func (s *aliasesSuite) TestRemoveSnapAliasesWithCompleter(c *C) {
 	c.Check(matchingAs, DeepEquals, []*backend.Alias{{Name: "baz", Target: "y.baz"}, {Name: "yy", Target: "y"}})
 	c.Check(matchingCs, DeepEquals, []*backend.Alias{{Name: "baz", Target: "y.baz"}, {Name: "yy", Target: "y"}})
 }
+
+func (s *aliasesSuite) TestUpdateAliasesAddWithArgs(c *C) {
+	mkCompleters(c, s.base, "x.python3")
+	aliases := []*backend.Alias{{Name: "py", Target: "x.python3", Args: []string{"-E", "it's"}}}
+
+	err := s.be.UpdateAliases(aliases, nil)
+	c.Assert(err, IsNil)
+
+	p := filepath.Join(dirs.SnapBinariesDir, "py")
+	fi, err := os.Lstat(p)
+	c.Assert(err, IsNil)
+	c.Check(fi.Mode().IsRegular(), Equals, true)
+	c.Check(fi.Mode().Perm(), Equals, os.FileMode(0755))
+	c.Check(p, testutil.FileEquals, `#!/bin/sh
+# snapd alias for x.python3
+exec /usr/bin/snap run 'x.python3' '-E' 'it'\''s' "$@"
+`)
+	// no completion as the arguments are not accounted for
+	c.Check(filepath.Join(dirs.CompletersDir, "py"), testutil.FileAbsent)
+
+	// replacing it with a plain alias
+	err = s.be.UpdateAliases([]*backend.Alias{{Name: "py", Target: "x.python3"}}, aliases)
+	c.Assert(err, IsNil)
+	target, err := os.Readlink(p)
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, "x.python3")
+}
+
+func (s *aliasesSuite) TestRemoveSnapAliasesWithArgs(c *C) {
+	aliases := []*backend.Alias{
+		{Name: "py", Target: "x.python3", Args: []string{"-E"}},
+		{Name: "yy", Target: "y.app", Args: []string{"-v"}},
+	}
+
+	err := s.be.UpdateAliases(aliases, nil)
+	c.Assert(err, IsNil)
+	// a regular file that is not an alias wrapper is left alone
+	other := filepath.Join(dirs.SnapBinariesDir, "other")
+	c.Assert(ioutil.WriteFile(other, []byte("#!/bin/sh\nexec x.python3\n"), 0755), IsNil)
+
+	err = s.be.RemoveSnapAliases("x")
+	c.Assert(err, IsNil)
+
+	c.Check(filepath.Join(dirs.SnapBinariesDir, "py"), testutil.FileAbsent)
+	c.Check(filepath.Join(dirs.SnapBinariesDir, "yy"), testutil.FilePresent)
+	c.Check(other, testutil.FilePresent)
+}
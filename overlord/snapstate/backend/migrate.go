@@ -0,0 +1,135 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/sys"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+)
+
+// migratedDataDirs returns the existing data directories of the current
+// revision of oldSnap, including the common ones, along with the matching
+// data directories of newSnap.
+func migratedDataDirs(newSnap, oldSnap *snap.Info) (oldDirs, newDirs []string, err error) {
+	revDirs, err := snapDataDirs(oldSnap)
+	if err != nil {
+		return nil, nil, err
+	}
+	// XDG_RUNTIME_DIRs are not carried over, they hold nothing that
+	// survives a reboot
+	commonDirs, err := filepath.Glob(oldSnap.CommonDataHomeDir())
+	if err != nil {
+		return nil, nil, err
+	}
+	commonDirs = append(commonDirs, oldSnap.CommonDataDir())
+
+	add := func(oldDir, newSuffix string) {
+		if !osutil.IsDirectory(oldDir) {
+			return
+		}
+		// replace the trailing "$old-name/$old-suffix" with
+		// "$new-name/$new-suffix"
+		newDir := filepath.Join(filepath.Dir(filepath.Dir(oldDir)), newSnap.InstanceName(), newSuffix)
+		oldDirs = append(oldDirs, oldDir)
+		newDirs = append(newDirs, newDir)
+	}
+	for _, oldDir := range revDirs {
+		add(oldDir, filepath.Base(newSnap.DataDir()))
+	}
+	for _, oldDir := range commonDirs {
+		add(oldDir, filepath.Base(newSnap.CommonDataDir()))
+	}
+	return oldDirs, newDirs, nil
+}
+
+// MigrateSnapData copies the data of the current revision of oldSnap, and
+// the data common to all its revisions, to the data directories of newSnap
+// which replaces it. The data directories of newSnap must be empty.
+func (b Backend) MigrateSnapData(newSnap, oldSnap *snap.Info, meter progress.Meter) (err error) {
+	oldDirs, newDirs, err := migratedDataDirs(newSnap, oldSnap)
+	if err != nil {
+		return err
+	}
+
+	done := make([]string, 0, len(newDirs))
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, newDir := range done {
+			if err := os.RemoveAll(newDir); err != nil {
+				logger.Noticef("while undoing migration of data directory %q: %v", newDir, err)
+			}
+		}
+	}()
+
+	for i, oldDir := range oldDirs {
+		newDir := newDirs[i]
+		// an empty directory may have been created already for the
+		// new snap, anything else cannot be overwritten
+		if err := os.Remove(newDir); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot migrate data of snap %q to %q: %v", oldSnap.InstanceName(), newDir, err)
+		}
+		if err := mkdirParentLike(newDir, filepath.Dir(oldDir)); err != nil {
+			return err
+		}
+		if err := osutil.CopyFile(oldDir, newDir, osutil.CopyFlagPreserveAll|osutil.CopyFlagSync); err != nil {
+			// remove the directory, in case it was a partial success
+			if e := os.RemoveAll(newDir); e != nil && !os.IsNotExist(e) {
+				logger.Noticef("while removing partially-copied data directory %q: %v", newDir, e)
+			}
+			return fmt.Errorf("cannot copy %q to %q: %v", oldDir, newDir, err)
+		}
+		done = append(done, newDir)
+	}
+	return nil
+}
+
+// UndoMigrateSnapData removes the data of oldSnap copied to newSnap by
+// MigrateSnapData.
+func (b Backend) UndoMigrateSnapData(newSnap, oldSnap *snap.Info, meter progress.Meter) error {
+	_, newDirs, err := migratedDataDirs(newSnap, oldSnap)
+	if err != nil {
+		return err
+	}
+	return removeDirs(newDirs)
+}
+
+// mkdirParentLike creates the parent directory of path, if missing, with
+// the same ownership as the given reference directory.
+func mkdirParentLike(path, reference string) error {
+	fi, err := os.Stat(reference)
+	if err != nil {
+		return err
+	}
+	uid, gid := sys.UserID(0), sys.GroupID(0)
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		uid, gid = sys.UserID(st.Uid), sys.GroupID(st.Gid)
+	}
+	return osutil.MkdirAllChown(filepath.Dir(path), fi.Mode().Perm(), uid, gid)
+}
@@ -0,0 +1,105 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/snapstate/backend"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type migrateSuite struct {
+	be      backend.Backend
+	tempdir string
+}
+
+var _ = Suite(&migrateSuite{})
+
+func (s *migrateSuite) SetUpTest(c *C) {
+	s.tempdir = c.MkDir()
+	dirs.SetRootDir(s.tempdir)
+}
+
+func (s *migrateSuite) TearDownTest(c *C) {
+	dirs.SetRootDir("")
+}
+
+func (s *migrateSuite) populateOldData(c *C) (oldSnap, newSnap *snap.Info) {
+	oldSnap = snaptest.MockSnap(c, "name: old-snap\nversion: 1.0\n", &snap.SideInfo{Revision: snap.R(10)})
+	newSnap = &snap.Info{SideInfo: snap.SideInfo{RealName: "new-snap", Revision: snap.R(3)}}
+
+	homeDir := filepath.Join(s.tempdir, "home", "user1", "snap")
+	for _, d := range []string{
+		oldSnap.DataDir(),
+		oldSnap.CommonDataDir(),
+		filepath.Join(homeDir, "old-snap", "10"),
+		filepath.Join(homeDir, "old-snap", "common"),
+	} {
+		c.Assert(os.MkdirAll(d, 0755), IsNil)
+		c.Assert(ioutil.WriteFile(filepath.Join(d, "canary"), []byte(d), 0644), IsNil)
+	}
+	return oldSnap, newSnap
+}
+
+func (s *migrateSuite) TestMigrateSnapData(c *C) {
+	oldSnap, newSnap := s.populateOldData(c)
+	// the new snap has empty data directories already
+	c.Assert(os.MkdirAll(newSnap.DataDir(), 0755), IsNil)
+	c.Assert(os.MkdirAll(newSnap.CommonDataDir(), 0755), IsNil)
+
+	err := s.be.MigrateSnapData(newSnap, oldSnap, progress.Null)
+	c.Assert(err, IsNil)
+
+	homeDir := filepath.Join(s.tempdir, "home", "user1", "snap")
+	c.Check(filepath.Join(dirs.SnapDataDir, "new-snap", "3", "canary"), testutil.FileEquals, oldSnap.DataDir())
+	c.Check(filepath.Join(dirs.SnapDataDir, "new-snap", "common", "canary"), testutil.FileEquals, oldSnap.CommonDataDir())
+	c.Check(filepath.Join(homeDir, "new-snap", "3", "canary"), testutil.FileEquals, filepath.Join(homeDir, "old-snap", "10"))
+	c.Check(filepath.Join(homeDir, "new-snap", "common", "canary"), testutil.FileEquals, filepath.Join(homeDir, "old-snap", "common"))
+	// the old data is left alone
+	c.Check(filepath.Join(oldSnap.DataDir(), "canary"), testutil.FilePresent)
+
+	err = s.be.UndoMigrateSnapData(newSnap, oldSnap, progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(osutil.IsDirectory(filepath.Join(dirs.SnapDataDir, "new-snap", "3")), Equals, false)
+	c.Check(osutil.IsDirectory(filepath.Join(homeDir, "new-snap", "common")), Equals, false)
+	c.Check(filepath.Join(oldSnap.DataDir(), "canary"), testutil.FilePresent)
+}
+
+func (s *migrateSuite) TestMigrateSnapDataRefusesToOverwrite(c *C) {
+	oldSnap, newSnap := s.populateOldData(c)
+	c.Assert(os.MkdirAll(newSnap.CommonDataDir(), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(newSnap.CommonDataDir(), "existing"), nil, 0644), IsNil)
+
+	err := s.be.MigrateSnapData(newSnap, oldSnap, progress.Null)
+	c.Assert(err, ErrorMatches, `cannot migrate data of snap "old-snap" to ".*/new-snap/common": .*`)
+	// what was migrated before the failure is removed again
+	c.Check(osutil.IsDirectory(newSnap.DataDir()), Equals, false)
+	c.Check(filepath.Join(newSnap.CommonDataDir(), "existing"), testutil.FilePresent)
+}
@@ -26,6 +26,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
@@ -624,8 +625,16 @@ func (f *fakeStore) Download(ctx context.Context, name, targetFn string, snapInf
 	if user != nil {
 		macaroon = user.StoreMacaroon
 	}
+	// simulate applying the delta the store offered
+	if dlOpts.DeltaApplied != nil && len(snapInfo.Deltas) == 1 {
+		dlOpts.DeltaApplied()
+	}
+	// the callback cannot be compared, drop it from the recorded options
+	opts := *dlOpts
+	opts.DeltaApplied = nil
+	dlOpts = &opts
 	// only add the options if they contain anything interesting
-	if *dlOpts == (store.DownloadOptions{}) {
+	if reflect.DeepEqual(*dlOpts, store.DownloadOptions{}) {
 		dlOpts = nil
 	}
 	f.downloads = append(f.downloads, fakeDownload{
@@ -898,6 +907,26 @@ func (f *fakeSnappyBackend) CopySnapData(newInfo, oldInfo *snap.Info, p progress
 	return f.maybeErrForLastOp()
 }
 
+func (f *fakeSnappyBackend) MigrateSnapData(newInfo, oldInfo *snap.Info, p progress.Meter) error {
+	p.Notify("migrate-data")
+	f.appendOp(&fakeOp{
+		op:   "migrate-data",
+		path: newInfo.MountDir(),
+		old:  oldInfo.MountDir(),
+	})
+	return f.maybeErrForLastOp()
+}
+
+func (f *fakeSnappyBackend) UndoMigrateSnapData(newInfo, oldInfo *snap.Info, p progress.Meter) error {
+	p.Notify("undo-migrate-data")
+	f.appendOp(&fakeOp{
+		op:   "undo-migrate-data",
+		path: newInfo.MountDir(),
+		old:  oldInfo.MountDir(),
+	})
+	return f.maybeErrForLastOp()
+}
+
 func (f *fakeSnappyBackend) LinkSnap(info *snap.Info, dev boot.Device, linkCtx backend.LinkContext, tm timings.Measurer) (rebootRequired bool, err error) {
 	if info.MountDir() == f.linkSnapWaitTrigger {
 		f.linkSnapWaitCh <- 1
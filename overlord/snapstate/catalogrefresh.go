@@ -88,6 +88,10 @@ func (r *catalogRefresh) Ensure() error {
 		return nil
 	}
 
+	if maintenanceHeld(r.state) {
+		return nil
+	}
+
 	now := time.Now()
 	delay := catalogRefreshDelayBase
 	if r.nextCatalogRefresh.IsZero() {
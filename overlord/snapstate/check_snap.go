@@ -435,6 +435,42 @@ func checkBases(st *state.State, snapInfo, curInfo *snap.Info, _ snap.Container,
 	return fmt.Errorf("cannot find required base %q", snapInfo.Base)
 }
 
+// checkURLSchemes checks that the URL schemes handled by the snap are not
+// already handled by another installed snap.
+func checkURLSchemes(st *state.State, snapInfo, curInfo *snap.Info, _ snap.Container, flags Flags, deviceCtx DeviceContext) error {
+	schemes := make(map[string]bool)
+	for _, app := range snapInfo.Apps {
+		for _, scheme := range app.URLSchemes {
+			schemes[scheme] = true
+		}
+	}
+	if len(schemes) == 0 {
+		return nil
+	}
+
+	snapStates, err := All(st)
+	if err != nil {
+		return err
+	}
+	for otherSnap, snapst := range snapStates {
+		if otherSnap == snapInfo.InstanceName() {
+			continue
+		}
+		otherInfo, err := snapst.CurrentInfo()
+		if err != nil {
+			return err
+		}
+		for _, app := range otherInfo.Apps {
+			for _, scheme := range app.URLSchemes {
+				if schemes[scheme] {
+					return fmt.Errorf("cannot install snap %q: URL scheme %q is already handled by snap %q", snapInfo.InstanceName(), scheme, otherSnap)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func checkEpochs(_ *state.State, snapInfo, curInfo *snap.Info, _ snap.Container, _ Flags, deviceCtx DeviceContext) error {
 	if curInfo == nil {
 		return nil
@@ -572,4 +608,5 @@ func init() {
 	AddCheckSnapCallback(checkGadgetOrKernel)
 	AddCheckSnapCallback(checkBases)
 	AddCheckSnapCallback(checkEpochs)
+	AddCheckSnapCallback(checkURLSchemes)
 }
@@ -779,6 +779,60 @@ base: some-base
 	c.Check(err, ErrorMatches, "cannot find required base \"some-base\"")
 }
 
+func (s *checkSnapSuite) TestCheckSnapURLSchemesConflict(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	si := &snap.SideInfo{RealName: "other-handler", Revision: snap.R(1), SnapID: "other-handler-id"}
+	snaptest.MockSnap(c, `
+name: other-handler
+version: 1
+apps:
+  app:
+    url-schemes: [bar, foo]
+    plugs: [url-handler]
+`, si)
+	snapstate.Set(st, "other-handler", &snapstate.SnapState{
+		SnapType: "app",
+		Active:   true,
+		Sequence: []*snap.SideInfo{si},
+		Current:  si.Revision,
+	})
+
+	for _, t := range []struct {
+		schemes string
+		err     string
+	}{
+		{"[baz]", ""},
+		{"[baz, foo]", `cannot install snap "handler": URL scheme "foo" is already handled by snap "other-handler"`},
+	} {
+		info, err := snap.InfoFromSnapYaml([]byte(fmt.Sprintf(`name: handler
+version: 1
+apps:
+  app:
+    url-schemes: %s
+    plugs: [url-handler]
+`, t.schemes)))
+		c.Assert(err, IsNil)
+
+		var openSnapFile = func(path string, si *snap.SideInfo) (*snap.Info, snap.Container, error) {
+			return info, emptyContainer(c), nil
+		}
+		restore := snapstate.MockOpenSnapFile(openSnapFile)
+
+		st.Unlock()
+		err = snapstate.CheckSnap(st, "snap-path", "handler", nil, nil, snapstate.Flags{}, nil)
+		st.Lock()
+		restore()
+		if t.err == "" {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, t.err)
+		}
+	}
+}
+
 func (s *checkSnapSuite) TestCheckSnapBasesNoneHappy(c *C) {
 	st := state.New(nil)
 	st.Lock()
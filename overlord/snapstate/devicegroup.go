@@ -0,0 +1,69 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// DeviceGroups allows to hook getting the device-group assertions the
+// device belongs to, ordered by group name, into refresh scheduling and
+// channel selection. It gets hooked from devicestate.
+var DeviceGroups func(st *state.State) ([]*asserts.DeviceGroup, error)
+
+// deviceGroupRefreshSchedule returns the refresh schedule set for the
+// device by its device groups, if any. If several groups set one, the
+// schedule of the first group wins.
+func deviceGroupRefreshSchedule(st *state.State) (string, error) {
+	if DeviceGroups == nil {
+		return "", nil
+	}
+	groups, err := DeviceGroups(st)
+	if err != nil {
+		return "", err
+	}
+	for _, group := range groups {
+		if schedule := group.RefreshSchedule(); schedule != "" {
+			return schedule, nil
+		}
+	}
+	return "", nil
+}
+
+// checkDeviceGroupChannel checks that all the device groups of the
+// device allow tracking the given channel.
+func checkDeviceGroupChannel(st *state.State, snapName, channel string) error {
+	if DeviceGroups == nil {
+		return nil
+	}
+	groups, err := DeviceGroups(st)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if !group.AllowsChannel(channel) {
+			return fmt.Errorf("cannot use channel %q for snap %q: not allowed for device group %q", channel, snapName, group.Group())
+		}
+	}
+	return nil
+}
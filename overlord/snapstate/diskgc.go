@@ -0,0 +1,191 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	diskGCCheckWait = time.Duration(1 * time.Hour)
+	diskGCLastCheck time.Time
+
+	pruneDownloadCache = func() (uint64, error) {
+		return store.NewCacheManager(dirs.SnapDownloadCacheDir, 0).Prune()
+	}
+)
+
+// diskGCThreshold returns the free space of the snapd state directory below
+// which old revisions and cached downloads get garbage collected, as set
+// with refresh.gc-threshold, or 0 if disk-pressure garbage collection is
+// disabled.
+func diskGCThreshold(st *state.State) (uint64, error) {
+	tr := config.NewTransaction(st)
+
+	var thresholdStr string
+	err := tr.Get("core", "refresh.gc-threshold", &thresholdStr)
+	if err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if thresholdStr == "" {
+		return 0, nil
+	}
+	threshold, err := strutil.ParseByteSize(thresholdStr)
+	if err != nil {
+		logger.Noticef("cannot use refresh.gc-threshold configuration: %v", err)
+		return 0, nil
+	}
+	return uint64(threshold), nil
+}
+
+// oldRevisionsRemoval returns the tasks to remove all revisions of the
+// installed snaps but the current ones and the ones in use for booting,
+// along with the names of the affected snaps and the size of the revisions
+// to be removed. Snaps with conflicting changes are skipped.
+func oldRevisionsRemoval(st *state.State) (tasksets []*state.TaskSet, names []string, size uint64, err error) {
+	snapStates, err := All(st)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	instanceNames := make([]string, 0, len(snapStates))
+	for instanceName := range snapStates {
+		instanceNames = append(instanceNames, instanceName)
+	}
+	sort.Strings(instanceNames)
+
+	deviceCtx, err := DeviceCtxFromState(st, nil)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	inUseCheck := inUseFor(deviceCtx)
+
+	for _, instanceName := range instanceNames {
+		snapst := snapStates[instanceName]
+		if len(snapst.Sequence) < 2 {
+			continue
+		}
+		if err := CheckChangeConflict(st, instanceName, nil); err != nil {
+			logger.Debugf("cannot remove old revisions of snap %q: %v", instanceName, err)
+			continue
+		}
+		typ, err := snapst.Type()
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		inUse, err := inUseCheck(typ)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		var tasks []*state.Task
+		for _, si := range snapst.Sequence {
+			if si.Revision == snapst.Current || inUse(instanceName, si.Revision) {
+				continue
+			}
+			if fi, err := os.Stat(snap.MountFile(instanceName, si.Revision)); err == nil {
+				size += uint64(fi.Size())
+			}
+			ts := removeInactiveRevision(st, instanceName, si.SnapID, si.Revision, typ)
+			if len(tasks) > 0 {
+				ts.WaitFor(tasks[len(tasks)-1])
+			}
+			tasks = append(tasks, ts.Tasks()...)
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		ts := state.NewTaskSet(tasks...)
+		ts.JoinLane(st.NewLane())
+		tasksets = append(tasksets, ts)
+		names = append(names, instanceName)
+	}
+	return tasksets, names, size, nil
+}
+
+// ensureDiskSpaceGC removes cached downloads and schedules the removal of
+// old revisions when the free space of the snapd state directory drops
+// below the configured threshold, warning about what is being reclaimed.
+func (m *SnapManager) ensureDiskSpaceGC() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	now := time.Now()
+	if diskGCLastCheck.After(now.Add(-diskGCCheckWait)) {
+		return nil
+	}
+	diskGCLastCheck = now
+
+	threshold, err := diskGCThreshold(m.state)
+	if err != nil || threshold == 0 {
+		return err
+	}
+
+	path := dirs.SnapdStateDir(dirs.GlobalRootDir)
+	if err := osutilCheckFreeSpace(path, threshold); err == nil {
+		return nil
+	} else if _, ok := err.(*osutil.NotEnoughDiskSpaceError); !ok {
+		return err
+	}
+
+	cacheReclaimed, err := pruneDownloadCache()
+	if err != nil {
+		logger.Noticef("cannot prune download cache: %v", err)
+	}
+
+	tasksets, names, revisionsSize, err := oldRevisionsRemoval(m.state)
+	if err != nil {
+		return err
+	}
+	if len(tasksets) > 0 {
+		chg := m.state.NewChange("disk-gc", fmt.Sprintf(i18n.G("Remove old revisions of snaps %s to reclaim disk space"), strutil.Quoted(names)))
+		for _, ts := range tasksets {
+			chg.AddAll(ts)
+		}
+		chg.Set("snap-names", names)
+	}
+
+	var reclaimed []string
+	if cacheReclaimed > 0 {
+		reclaimed = append(reclaimed, fmt.Sprintf(i18n.G("removed cached downloads (%s)"), strutil.SizeToStr(int64(cacheReclaimed))))
+	}
+	if len(names) > 0 {
+		reclaimed = append(reclaimed, fmt.Sprintf(i18n.G("removing old revisions of snaps %s (%s)"), strutil.Quoted(names), strutil.SizeToStr(int64(revisionsSize))))
+	}
+	if len(reclaimed) == 0 {
+		logger.Noticef("free space in %q is below %s but there is nothing to reclaim", path, strutil.SizeToStr(int64(threshold)))
+		return nil
+	}
+	m.state.Warnf(i18n.G("free space in %q dropped below %s: %s"), path, strutil.SizeToStr(int64(threshold)), strings.Join(reclaimed, i18n.G(" and ")))
+	return nil
+}
@@ -0,0 +1,166 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (s *snapmgrTestSuite) setupDiskGC(c *C, threshold string, free uint64) (checks *int, prunes *int) {
+	checks, prunes = new(int), new(int)
+	s.AddCleanup(snapstate.MockDiskGCLastCheck(time.Time{}))
+	s.AddCleanup(snapstate.MockOsutilCheckFreeSpace(func(path string, required uint64) error {
+		*checks++
+		c.Check(path, Equals, dirs.SnapdStateDir(dirs.GlobalRootDir))
+		if free < required {
+			return &osutil.NotEnoughDiskSpaceError{Path: path, Delta: int64(required - free)}
+		}
+		return nil
+	}))
+	s.AddCleanup(snapstate.MockPruneDownloadCache(func() (uint64, error) {
+		*prunes++
+		return 2048, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.gc-threshold", threshold)
+	tr.Commit()
+
+	si1 := &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(1)}
+	si2 := &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(2)}
+	si3 := &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(3)}
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{si1, si2, si3},
+		Current:  snap.R(3),
+		SnapType: "app",
+	})
+	snapstate.Set(s.state, "other-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "other-snap", SnapID: "other-snap-id", Revision: snap.R(1)},
+		},
+		Current:  snap.R(1),
+		SnapType: "app",
+	})
+
+	c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0700), IsNil)
+	for _, si := range []*snap.SideInfo{si1, si2} {
+		c.Assert(ioutil.WriteFile(snap.MountFile("some-snap", si.Revision), make([]byte, 1024), 0600), IsNil)
+	}
+	return checks, prunes
+}
+
+func (s *snapmgrTestSuite) TestDiskGCBelowThreshold(c *C) {
+	checks, prunes := s.setupDiskGC(c, "1MB", 1024)
+
+	c.Assert(snapstate.EnsureDiskSpaceGC(s.snapmgr), IsNil)
+	c.Check(*checks, Equals, 1)
+	c.Check(*prunes, Equals, 1)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	chg := chgs[0]
+	c.Check(chg.Kind(), Equals, "disk-gc")
+	c.Check(chg.Summary(), Equals, `Remove old revisions of snaps "some-snap" to reclaim disk space`)
+	c.Check(taskKinds(chg.Tasks()), DeepEquals, []string{
+		"clear-snap",
+		"discard-snap",
+		"clear-snap",
+		"discard-snap",
+	})
+	var revs []snap.Revision
+	for _, t := range chg.Tasks() {
+		if t.Kind() != "clear-snap" {
+			continue
+		}
+		snapsup, err := snapstate.TaskSnapSetup(t)
+		c.Assert(err, IsNil)
+		revs = append(revs, snapsup.Revision())
+	}
+	c.Check(revs, DeepEquals, []snap.Revision{snap.R(1), snap.R(2)})
+
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Matches, `free space in ".*" dropped below 1MB: removed cached downloads \(2kB\) and removing old revisions of snaps "some-snap" \(2kB\)`)
+
+	// not checked again right away
+	c.Assert(snapstate.EnsureDiskSpaceGC(s.snapmgr), IsNil)
+	c.Check(*checks, Equals, 1)
+}
+
+func (s *snapmgrTestSuite) TestDiskGCSkipsConflictingSnaps(c *C) {
+	_, prunes := s.setupDiskGC(c, "1MB", 1024)
+
+	s.state.Lock()
+	chg := s.state.NewChange("refresh", "...")
+	t := s.state.NewTask("link-snap", "...")
+	t.Set("snap-setup", &snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: "some-snap"}})
+	chg.AddTask(t)
+	s.state.Unlock()
+
+	c.Assert(snapstate.EnsureDiskSpaceGC(s.snapmgr), IsNil)
+	c.Check(*prunes, Equals, 1)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.state.Changes(), HasLen, 1)
+	warns := s.state.AllWarnings()
+	c.Assert(warns, HasLen, 1)
+	c.Check(warns[0].String(), Matches, `free space in ".*" dropped below 1MB: removed cached downloads \(2kB\)`)
+}
+
+func (s *snapmgrTestSuite) TestDiskGCEnoughSpace(c *C) {
+	checks, prunes := s.setupDiskGC(c, "1MB", 2*1024*1024)
+
+	c.Assert(snapstate.EnsureDiskSpaceGC(s.snapmgr), IsNil)
+	c.Check(*checks, Equals, 1)
+	c.Check(*prunes, Equals, 0)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 0)
+	c.Check(s.state.AllWarnings(), HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestDiskGCDisabled(c *C) {
+	checks, prunes := s.setupDiskGC(c, "", 0)
+
+	c.Assert(snapstate.EnsureDiskSpaceGC(s.snapmgr), IsNil)
+	c.Check(*checks, Equals, 0)
+	c.Check(*prunes, Equals, 0)
+}
@@ -0,0 +1,75 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// Downgrade initiates a change reverting a snap to an older revision that
+// is not installed anymore but is still available from the store. The data
+// of the current revision is saved first in the snapshot set whose id is
+// returned, which is kept even if the downgrade fails so that the data can
+// be restored from it with "snap restore".
+// Note that the state must be locked by the caller.
+func Downgrade(st *state.State, name string, rev snap.Revision, userID int, flags Flags) (snapshotSetID uint64, ts *state.TaskSet, err error) {
+	var snapst SnapState
+	err = Get(st, name, &snapst)
+	if err != nil && err != state.ErrNoState {
+		return 0, nil, err
+	}
+	if !snapst.IsInstalled() {
+		return 0, nil, &snap.NotInstalledError{Snap: name}
+	}
+
+	if rev.Local() {
+		return 0, nil, fmt.Errorf("cannot downgrade snap %q to local revision %s", name, rev)
+	}
+	if snapst.LastIndex(rev) >= 0 {
+		return 0, nil, fmt.Errorf("cannot downgrade snap %q to revision %s: revision is installed, revert to it instead", name, rev)
+	}
+	if !snapst.Current.Local() && rev.N >= snapst.Current.N {
+		return 0, nil, fmt.Errorf("cannot downgrade snap %q to revision %s: not older than current revision %s", name, rev, snapst.Current)
+	}
+
+	updateTs, err := Update(st, name, &RevisionOptions{Revision: rev}, userID, flags)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	snapshotSetID, snapshotTs, err := DowngradeSnapshot(st, name)
+	if err != nil {
+		return 0, nil, err
+	}
+	// the snapshot is in a lane of its own, so that it is not undone, and
+	// the snapshot forgotten, when the refresh fails; a failure to save
+	// the snapshot still aborts the refresh waiting on it
+	snapshotTs.JoinLane(st.NewLane())
+	updateTs.WaitAll(snapshotTs)
+
+	ts = state.NewTaskSet(snapshotTs.Tasks()...)
+	if err := ts.AddAllWithEdges(updateTs); err != nil {
+		return 0, nil, err
+	}
+	return snapshotSetID, ts, nil
+}
@@ -0,0 +1,149 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapmgrTestSuite) mockDowngradeSnapshot(c *C) {
+	old := snapstate.DowngradeSnapshot
+	snapstate.DowngradeSnapshot = func(st *state.State, instanceName string) (uint64, *state.TaskSet, error) {
+		c.Check(instanceName, Equals, "some-snap")
+		task := st.NewTask("save-snapshot", "...")
+		return 42, state.NewTaskSet(task), nil
+	}
+	s.AddCleanup(func() { snapstate.DowngradeSnapshot = old })
+}
+
+func (s *snapmgrTestSuite) setSomeSnapRevisions(revs ...int) {
+	var seq []*snap.SideInfo
+	for _, rev := range revs {
+		seq = append(seq, &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(rev)})
+	}
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:          true,
+		Sequence:        seq,
+		Current:         seq[len(seq)-1].Revision,
+		SnapType:        "app",
+		TrackingChannel: "latest/stable",
+	})
+}
+
+func (s *snapmgrTestSuite) TestDowngrade(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockDowngradeSnapshot(c)
+	s.setSomeSnapRevisions(11)
+
+	setID, ts, err := snapstate.Downgrade(s.state, "some-snap", snap.R(7), s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	c.Check(setID, Equals, uint64(42))
+
+	tasks := ts.Tasks()
+	c.Assert(len(tasks) > 1, Equals, true)
+	snapshot := tasks[0]
+	c.Check(snapshot.Kind(), Equals, "save-snapshot")
+	// the snapshot is kept if the downgrade fails
+	c.Assert(snapshot.Lanes(), HasLen, 1)
+	snapshotLane := snapshot.Lanes()[0]
+	c.Check(snapshotLane, Not(Equals), 0)
+	for _, t := range tasks[1:] {
+		c.Check(t.Lanes(), Not(testutil.Contains), snapshotLane)
+	}
+	c.Check(tasks[1].Kind(), Equals, "prerequisites")
+	c.Check(tasks[1].WaitTasks(), DeepEquals, []*state.Task{snapshot})
+
+	snapsup, err := snapstate.TaskSnapSetup(tasks[1])
+	c.Assert(err, IsNil)
+	c.Check(snapsup.Revision(), Equals, snap.R(7))
+
+	_, err = ts.Edge(snapstate.DownloadAndChecksDoneEdge)
+	c.Check(err, IsNil)
+}
+
+func (s *snapmgrTestSuite) TestDowngradeFailureKeepsSnapshot(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockDowngradeSnapshot(c)
+	snapshotUndone := false
+	s.o.TaskRunner().AddHandler("save-snapshot", func(*state.Task, *tomb.Tomb) error {
+		return nil
+	}, func(*state.Task, *tomb.Tomb) error {
+		snapshotUndone = true
+		return nil
+	})
+	s.setSomeSnapRevisions(11)
+	s.fakeBackend.linkSnapFailTrigger = filepath.Join(dirs.SnapMountDir, "some-snap/7")
+
+	_, ts, err := snapstate.Downgrade(s.state, "some-snap", snap.R(7), s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg := s.state.NewChange("revert-snap", "...")
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	defer s.se.Stop()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(ts.Tasks()[0].Status(), Equals, state.DoneStatus)
+	c.Check(snapshotUndone, Equals, false)
+
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Current, Equals, snap.R(11))
+}
+
+func (s *snapmgrTestSuite) TestDowngradeErrors(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockDowngradeSnapshot(c)
+
+	_, _, err := snapstate.Downgrade(s.state, "some-snap", snap.R(7), s.user.ID, snapstate.Flags{})
+	c.Check(err, ErrorMatches, `snap "some-snap" is not installed`)
+
+	s.setSomeSnapRevisions(7, 11)
+
+	for _, t := range []struct {
+		rev snap.Revision
+		err string
+	}{
+		{snap.R(-1), `cannot downgrade snap "some-snap" to local revision x1`},
+		{snap.R(7), `cannot downgrade snap "some-snap" to revision 7: revision is installed, revert to it instead`},
+		{snap.R(11), `cannot downgrade snap "some-snap" to revision 11: revision is installed, revert to it instead`},
+		{snap.R(12), `cannot downgrade snap "some-snap" to revision 12: not older than current revision 11`},
+	} {
+		_, _, err := snapstate.Downgrade(s.state, "some-snap", t.rev, s.user.ID, snapstate.Flags{})
+		c.Check(err, ErrorMatches, t.err)
+	}
+}
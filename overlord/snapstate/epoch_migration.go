@@ -0,0 +1,75 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// injectMigrateDataHook makes a refresh crossing an epoch boundary run the
+// migrate-data hook of the new revision, if it has one, once the snap data
+// was copied and the security profiles of the new revision are set up but
+// before the new revision is linked. t is the mount-snap task of the
+// refresh, oldInfo and newInfo describe the current and the new revision.
+func injectMigrateDataHook(t *state.Task, snapsup *SnapSetup, oldInfo, newInfo *snap.Info) error {
+	if oldInfo == nil || snapsup.Flags.Revert {
+		return nil
+	}
+	if oldInfo.Epoch.Equal(&newInfo.Epoch) || newInfo.Hooks["migrate-data"] == nil {
+		return nil
+	}
+
+	snapsupTaskID := t.ID()
+	if err := t.Get("snap-setup-task", &snapsupTaskID); err != nil && err != state.ErrNoState {
+		return err
+	}
+	var setupProfiles, linkSnap *state.Task
+	chg := t.Change()
+	for _, other := range chg.Tasks() {
+		var id string
+		if err := other.Get("snap-setup-task", &id); err != nil && err != state.ErrNoState {
+			return err
+		}
+		if id != snapsupTaskID {
+			continue
+		}
+		switch other.Kind() {
+		case "setup-profiles":
+			setupProfiles = other
+		case "link-snap":
+			linkSnap = other
+		}
+	}
+	if setupProfiles == nil || linkSnap == nil {
+		return fmt.Errorf("internal error: cannot find where to run the migrate-data hook of snap %q", snapsup.InstanceName())
+	}
+
+	hook := SetupMigrateDataHook(t.State(), snapsup.InstanceName(), snapsup.Revision(), oldInfo.Revision)
+	hook.WaitFor(setupProfiles)
+	linkSnap.WaitFor(hook)
+	for _, lane := range t.Lanes() {
+		hook.JoinLane(lane)
+	}
+	chg.AddTask(hook)
+	return nil
+}
@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapmgrTestSuite) refreshTasksForMigrateData(c *C) (snapsup *snapstate.SnapSetup, mount, setupProfiles, link *state.Task) {
+	snapsup = &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "some-snap", Revision: snap.R(2)},
+	}
+	chg := s.state.NewChange("refresh", "...")
+	prepare := s.state.NewTask("prepare-snap", "...")
+	prepare.Set("snap-setup", snapsup)
+	chg.AddTask(prepare)
+	prev := prepare
+	for _, kind := range []string{"mount-snap", "copy-snap-data", "setup-profiles", "link-snap"} {
+		t := s.state.NewTask(kind, "...")
+		t.Set("snap-setup-task", prepare.ID())
+		t.WaitFor(prev)
+		t.JoinLane(1)
+		chg.AddTask(t)
+		prev = t
+		switch kind {
+		case "mount-snap":
+			mount = t
+		case "setup-profiles":
+			setupProfiles = t
+		case "link-snap":
+			link = t
+		}
+	}
+	return snapsup, mount, setupProfiles, link
+}
+
+func (s *snapmgrTestSuite) TestInjectMigrateDataHook(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapsup, mount, setupProfiles, link := s.refreshTasksForMigrateData(c)
+	oldInfo := &snap.Info{SideInfo: snap.SideInfo{Revision: snap.R(1)}, Epoch: snap.E("1")}
+	newInfo := &snap.Info{SideInfo: snap.SideInfo{Revision: snap.R(2)}, Epoch: snap.E("2*")}
+	newInfo.Hooks = map[string]*snap.HookInfo{
+		"migrate-data": {Snap: newInfo, Name: "migrate-data"},
+	}
+
+	err := snapstate.InjectMigrateDataHook(mount, snapsup, oldInfo, newInfo)
+	c.Assert(err, IsNil)
+
+	tasks := mount.Change().Tasks()
+	c.Assert(tasks, HasLen, 6)
+	hook := tasks[5]
+	c.Check(hook.Kind(), Equals, "run-hook")
+	c.Check(hook.Summary(), Equals, `Run migrate-data hook of "some-snap" snap`)
+	c.Check(hook.WaitTasks(), DeepEquals, []*state.Task{setupProfiles})
+	c.Check(link.WaitTasks(), testutil.Contains, hook)
+	c.Check(hook.Lanes(), DeepEquals, []int{1})
+
+	var hooksup hookstate.HookSetup
+	c.Assert(hook.Get("hook-setup", &hooksup), IsNil)
+	c.Check(hooksup, DeepEquals, hookstate.HookSetup{
+		Snap:     "some-snap",
+		Revision: snap.R(2),
+		Hook:     "migrate-data",
+	})
+	var hookContext map[string]interface{}
+	c.Assert(hook.Get("hook-context", &hookContext), IsNil)
+	c.Check(hookContext, DeepEquals, map[string]interface{}{"previous-revision": "1"})
+}
+
+func (s *snapmgrTestSuite) TestInjectMigrateDataHookNotNeeded(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapsup, mount, _, _ := s.refreshTasksForMigrateData(c)
+	oldInfo := &snap.Info{SideInfo: snap.SideInfo{Revision: snap.R(1)}, Epoch: snap.E("1")}
+	withHook := func(info *snap.Info) *snap.Info {
+		info.Hooks = map[string]*snap.HookInfo{
+			"migrate-data": {Snap: info, Name: "migrate-data"},
+		}
+		return info
+	}
+
+	for _, t := range []struct {
+		oldInfo, newInfo *snap.Info
+	}{
+		// same epoch
+		{oldInfo, withHook(&snap.Info{Epoch: snap.E("1")})},
+		// no hook
+		{oldInfo, &snap.Info{Epoch: snap.E("2*")}},
+		// not a refresh
+		{nil, withHook(&snap.Info{Epoch: snap.E("2*")})},
+	} {
+		err := snapstate.InjectMigrateDataHook(mount, snapsup, t.oldInfo, t.newInfo)
+		c.Assert(err, IsNil)
+		c.Check(mount.Change().Tasks(), HasLen, 5)
+	}
+}
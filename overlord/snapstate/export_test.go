@@ -197,6 +197,22 @@ func MockLocalInstallLastCleanup(t time.Time) (restore func()) {
 	}
 }
 
+func MockDiskGCLastCheck(t time.Time) (restore func()) {
+	old := diskGCLastCheck
+	diskGCLastCheck = t
+	return func() {
+		diskGCLastCheck = old
+	}
+}
+
+func MockPruneDownloadCache(f func() (uint64, error)) (restore func()) {
+	old := pruneDownloadCache
+	pruneDownloadCache = f
+	return func() {
+		pruneDownloadCache = old
+	}
+}
+
 func MockAsyncPendingRefreshNotification(fn func(context.Context, *userclient.Client, *userclient.PendingSnapRefreshInfo)) (restore func()) {
 	old := asyncPendingRefreshNotification
 	asyncPendingRefreshNotification = fn
@@ -290,6 +306,8 @@ var (
 	MaxInhibition  = maxInhibition
 )
 
+var InjectMigrateDataHook = injectMigrateDataHook
+
 type RefreshCandidate = refreshCandidate
 
 func NewBusySnapError(info *snap.Info, pids []int, busyAppNames, busyHookNames []string) *BusySnapError {
@@ -319,6 +337,22 @@ func MockSecurityProfilesDiscardLate(fn func(snapName string, rev snap.Revision,
 	}
 }
 
+func MockContentConsumers(fn func(st *state.State, instanceName string) ([]string, error)) (restore func()) {
+	old := ContentConsumers
+	ContentConsumers = fn
+	return func() {
+		ContentConsumers = old
+	}
+}
+
+func MockSnapPublisherID(fn func(st *state.State, snapID string) (string, error)) (restore func()) {
+	old := SnapPublisherID
+	SnapPublisherID = fn
+	return func() {
+		SnapPublisherID = old
+	}
+}
+
 type HoldState = holdState
 
 var (
@@ -363,3 +397,5 @@ func MockSnapsToRefresh(f func(gatingTask *state.Task) ([]*refreshCandidate, err
 		snapsToRefresh = old
 	}
 }
+
+var EnsureDiskSpaceGC = (*SnapManager).ensureDiskSpaceGC
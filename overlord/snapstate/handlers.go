@@ -50,6 +50,7 @@ import (
 	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
 	"github.com/snapcore/snapd/timings"
 	"github.com/snapcore/snapd/wrappers"
 )
@@ -67,6 +68,12 @@ var SecurityProfilesRemoveLate = func(snapName string, rev snap.Revision, typ sn
 	panic("internal error: snapstate.SecurityProfilesRemoveLate is unset")
 }
 
+// ContentConsumers is a hook set by ifacestate, it returns the snaps with
+// content plugs connected to slots of the given snap.
+var ContentConsumers = func(st *state.State, instanceName string) ([]string, error) {
+	panic("internal error: snapstate.ContentConsumers is unset")
+}
+
 // TaskSnapSetup returns the SnapSetup with task params hold by or referred to by the task.
 func TaskSnapSetup(t *state.Task) (*SnapSetup, error) {
 	var snapsup SnapSetup
@@ -334,7 +341,8 @@ func (m *SnapManager) installPrereqs(t *state.Task, base string, prereq []string
 	}
 
 	// for base snaps we need to wait until the change is done
-	// (either finished or failed)
+	// (either finished or failed), unless they are installed by this
+	// change, then only the tasks needing them wait
 	onInFlightErr := &state.Retry{After: prerequisitesRetryTimeout}
 
 	var tsBase *state.TaskSet
@@ -344,6 +352,9 @@ func (m *SnapManager) installPrereqs(t *state.Task, base string, prereq []string
 			requireTypeBase := true
 			tsBase, err = m.installOneBaseOrRequired(st, base, requireTypeBase, defaultBaseSnapsChannel(), onInFlightErr, userID)
 		})
+		if err == error(onInFlightErr) {
+			err = prereqInFlight(t, base)
+		}
 		if err != nil {
 			return prereqError("snap base", base, err)
 		}
@@ -365,6 +376,9 @@ func (m *SnapManager) installPrereqs(t *state.Task, base string, prereq []string
 			noTypeBaseCheck := false
 			tsSnapd, err = m.installOneBaseOrRequired(st, "snapd", noTypeBaseCheck, defaultSnapdSnapsChannel(), onInFlightErr, userID)
 		})
+		if err == error(onInFlightErr) {
+			err = prereqInFlight(t, "snapd")
+		}
 		if err != nil {
 			return prereqError("system snap", "snapd", err)
 		}
@@ -380,17 +394,13 @@ func (m *SnapManager) installPrereqs(t *state.Task, base string, prereq []string
 	// add the base if needed, prereqs else must wait on this
 	if tsBase != nil {
 		tsBase.JoinLane(st.NewLane())
-		for _, t := range chg.Tasks() {
-			t.WaitAll(tsBase)
-		}
+		waitForPrereq(chg, tsBase)
 		chg.AddAll(tsBase)
 	}
 	// add snapd if needed, everything must wait on this
 	if tsSnapd != nil {
 		tsSnapd.JoinLane(st.NewLane())
-		for _, t := range chg.Tasks() {
-			t.WaitAll(tsSnapd)
-		}
+		waitForPrereq(chg, tsSnapd)
 		chg.AddAll(tsSnapd)
 	}
 
@@ -401,6 +411,70 @@ func (m *SnapManager) installPrereqs(t *state.Task, base string, prereq []string
 	return nil
 }
 
+// prereqIndependentKinds are the kinds of tasks which only fetch, check
+// and mount a snap, they do not need its base or snapd to be in place.
+var prereqIndependentKinds = map[string]bool{
+	"prerequisites": true,
+	"prepare-snap":  true,
+	"download-snap": true,
+	"validate-snap": true,
+	"mount-snap":    true,
+}
+
+// waitForPrereq makes the tasks of the change wait for the given prereq
+// task set, except for the ones that do not depend on it, so that other
+// snaps of the change keep being downloaded and mounted concurrently
+// while the prerequisite is installed.
+func waitForPrereq(chg *state.Change, prereqTs *state.TaskSet) {
+	for _, t := range chg.Tasks() {
+		if prereqIndependentKinds[t.Kind()] {
+			continue
+		}
+		t.WaitAll(prereqTs)
+	}
+}
+
+// prereqInFlight is used when the given prerequisite is being installed
+// already. If that happens in the change of t, the tasks of its snap
+// which depend on the prerequisite are made to wait for it there,
+// otherwise t is retried later.
+func prereqInFlight(t *state.Task, prereqName string) error {
+	snapsup, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	snapName := snapsup.InstanceName()
+
+	var prereqTasks, waiting []*state.Task
+	for _, tc := range t.Change().Tasks() {
+		if tc == t || tc.Status().Ready() {
+			continue
+		}
+		tcSnapsup, err := TaskSnapSetup(tc)
+		if err != nil {
+			// not a task of a snap
+			continue
+		}
+		switch tcSnapsup.InstanceName() {
+		case prereqName:
+			prereqTasks = append(prereqTasks, tc)
+		case snapName:
+			if !prereqIndependentKinds[tc.Kind()] {
+				waiting = append(waiting, tc)
+			}
+		}
+	}
+	if len(prereqTasks) == 0 || len(waiting) == 0 {
+		return &state.Retry{After: prerequisitesRetryTimeout}
+	}
+	for _, w := range waiting {
+		for _, pt := range prereqTasks {
+			w.WaitFor(pt)
+		}
+	}
+	return nil
+}
+
 func prereqError(what, snapName string, err error) error {
 	if _, ok := err.(*state.Retry); ok {
 		return err
@@ -542,6 +616,134 @@ func autoRefreshRateLimited(st *state.State) (rate int64) {
 	return val
 }
 
+// ParseRateLimitSchedule parses the windows of a refresh.rate-limit-schedule
+// value. Windows are separated by ";", each is a schedule as accepted by
+// timeutil.ParseSchedule followed by the rate limit applying during it, e.g.
+// "mon-fri,9:00-17:00,1MB;sat-sun,8:00-20:00,5MB". The schedule of a window
+// can itself be made of several ",," separated events.
+func ParseRateLimitSchedule(value string) ([]store.RateLimitWindow, error) {
+	var windows []store.RateLimitWindow
+	for _, window := range strings.Split(value, ";") {
+		idx := strings.LastIndex(window, ",")
+		if idx < 0 {
+			return nil, fmt.Errorf("cannot parse rate limit window %q: expected <schedule>,<rate>", window)
+		}
+		// a rate in the middle of the schedule means windows were
+		// separated with ",", as in "mon,1MB,,fri,2MB", which is
+		// ambiguous
+		for _, event := range strings.Split(window[:idx], ",,") {
+			last := event[strings.LastIndex(event, ",")+1:]
+			if _, err := strutil.ParseByteSize(last); err == nil {
+				return nil, fmt.Errorf("cannot parse rate limit window %q: windows must be separated by \";\"", window)
+			}
+		}
+		sched, err := timeutil.ParseSchedule(window[:idx])
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse rate limit window %q: %v", window, err)
+		}
+		// NOTE ParseByteSize errors on negative rates
+		rate, err := strutil.ParseByteSize(window[idx+1:])
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse rate limit window %q: %v", window, err)
+		}
+		windows = append(windows, store.RateLimitWindow{Schedule: sched, Rate: rate})
+	}
+	return windows, nil
+}
+
+// autoRefreshRateLimitSchedule returns the rate limit windows of
+// auto-refreshes, if any.
+func autoRefreshRateLimitSchedule(st *state.State) []store.RateLimitWindow {
+	tr := config.NewTransaction(st)
+
+	var value string
+	if err := tr.Get("core", "refresh.rate-limit-schedule", &value); err != nil || value == "" {
+		return nil
+	}
+	windows, err := ParseRateLimitSchedule(value)
+	if err != nil {
+		logger.Noticef("Ignoring invalid refresh.rate-limit-schedule: %v", err)
+		return nil
+	}
+	return windows
+}
+
+// downloadLANPeers returns the URLs of the snapd instances on the local
+// network that snaps are downloaded from first, as set with proxy.lan-peers.
+func downloadLANPeers(st *state.State) []string {
+	tr := config.NewTransaction(st)
+
+	var peers string
+	if err := tr.Get("core", "proxy.lan-peers", &peers); err != nil {
+		return nil
+	}
+	return strutil.CommaSeparatedList(peers)
+}
+
+// MaxDownloadChunks is the maximum number of parallel ranged requests
+// refresh.download-chunks can be set to.
+const MaxDownloadChunks = 16
+
+// downloadChunks returns the number of parallel ranged requests used to
+// download large snaps, as set with refresh.download-chunks.
+func downloadChunks(st *state.State) int {
+	tr := config.NewTransaction(st)
+
+	var chunks int
+	if err := tr.Get("core", "refresh.download-chunks", &chunks); err != nil {
+		return 0
+	}
+	if chunks < 0 || chunks > MaxDownloadChunks {
+		return 0
+	}
+	return chunks
+}
+
+// refreshDeltasRequired returns whether downloads must fail rather than
+// fall back to the full snap when an available delta cannot be used, as
+// set with refresh.deltas=required.
+func refreshDeltasRequired(st *state.State) bool {
+	tr := config.NewTransaction(st)
+
+	var deltas string
+	if err := tr.Get("core", "refresh.deltas", &deltas); err != nil {
+		return false
+	}
+	return deltas == "required"
+}
+
+// downloadStats is recorded per snap under "download-stats" in the
+// api-data of the change so that the data usage of refreshes can be
+// audited.
+type downloadStats struct {
+	// DeltaAvailable is set when the store offered a delta.
+	DeltaAvailable bool `json:"delta-available"`
+	// DeltaRequired is set when falling back to a full download was not
+	// allowed.
+	DeltaRequired bool `json:"delta-required"`
+	// DeltaApplied is set when the snap was obtained by applying the
+	// delta.
+	DeltaApplied bool  `json:"delta-applied"`
+	FullSize     int64 `json:"full-size"`
+	DeltaSize    int64 `json:"delta-size,omitempty"`
+}
+
+func newDownloadStats(info *snap.DownloadInfo, deltasRequired bool) *downloadStats {
+	stats := &downloadStats{
+		DeltaRequired: deltasRequired,
+		FullSize:      info.Size,
+	}
+	if len(info.Deltas) == 1 {
+		stats.DeltaAvailable = true
+		stats.DeltaSize = info.Deltas[0].Size
+	}
+	return stats
+}
+
+func (stats *downloadStats) deltaApplied() {
+	stats.DeltaApplied = true
+}
+
 func downloadSnapParams(st *state.State, t *state.Task) (*SnapSetup, StoreService, *auth.UserState, error) {
 	snapsup, err := TaskSnapSetup(t)
 	if err != nil {
@@ -566,6 +768,10 @@ func downloadSnapParams(st *state.State, t *state.Task) (*SnapSetup, StoreServic
 func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	st := t.State()
 	var rate int64
+	var rateSchedule []store.RateLimitWindow
+	var deltasRequired bool
+	var lanPeers []string
+	var chunks int
 
 	st.Lock()
 	perfTimings := state.TimingsForTask(t)
@@ -573,7 +779,11 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	if snapsup != nil && snapsup.IsAutoRefresh {
 		// NOTE rate is never negative
 		rate = autoRefreshRateLimited(st)
+		rateSchedule = autoRefreshRateLimitSchedule(st)
 	}
+	deltasRequired = refreshDeltasRequired(st)
+	lanPeers = downloadLANPeers(st)
+	chunks = downloadChunks(st)
 	st.Unlock()
 	if err != nil {
 		return err
@@ -583,9 +793,14 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	targetFn := snapsup.MountFile()
 
 	dlOpts := &store.DownloadOptions{
-		IsAutoRefresh: snapsup.IsAutoRefresh,
-		RateLimit:     rate,
-	}
+		IsAutoRefresh:     snapsup.IsAutoRefresh,
+		RateLimit:         rate,
+		DeltasRequired:    deltasRequired,
+		RateLimitSchedule: rateSchedule,
+		LANPeers:          lanPeers,
+		Chunks:            chunks,
+	}
+	var stats *downloadStats
 	if snapsup.DownloadInfo == nil {
 		var storeInfo store.SnapActionResult
 		// COMPATIBILITY - this task was created from an older version
@@ -595,16 +810,23 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 		if err != nil {
 			return err
 		}
+		stats = newDownloadStats(&storeInfo.DownloadInfo, deltasRequired)
+		dlOpts.DeltaApplied = stats.deltaApplied
 		timings.Run(perfTimings, "download", fmt.Sprintf("download snap %q", snapsup.SnapName()), func(timings.Measurer) {
 			err = theStore.Download(tomb.Context(nil), snapsup.SnapName(), targetFn, &storeInfo.DownloadInfo, meter, user, dlOpts)
 		})
 		snapsup.SideInfo = &storeInfo.SideInfo
 	} else {
+		stats = newDownloadStats(snapsup.DownloadInfo, deltasRequired)
+		dlOpts.DeltaApplied = stats.deltaApplied
 		timings.Run(perfTimings, "download", fmt.Sprintf("download snap %q", snapsup.SnapName()), func(timings.Measurer) {
 			err = theStore.Download(tomb.Context(nil), snapsup.SnapName(), targetFn, snapsup.DownloadInfo, meter, user, dlOpts)
 		})
 	}
 	if err != nil {
+		st.Lock()
+		t.Logf("Download failed (%s).", store.ErrorKindOf(err))
+		st.Unlock()
 		return err
 	}
 
@@ -613,12 +835,38 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	// update the snap setup for the follow up tasks
 	st.Lock()
 	t.Set("snap-setup", snapsup)
+	if err := downloadStatsTrace(t, snapsup.InstanceName(), stats); err != nil {
+		st.Unlock()
+		return err
+	}
 	perfTimings.Save(st)
 	st.Unlock()
 
 	return nil
 }
 
+func downloadStatsTrace(t *state.Task, instanceName string, stats *downloadStats) error {
+	chg := t.Change()
+	var data map[string]interface{}
+	err := chg.Get("api-data", &data)
+	if err != nil && err != state.ErrNoState {
+		return err
+	}
+	if len(data) == 0 {
+		data = make(map[string]interface{})
+	}
+
+	curStats, _ := data["download-stats"].(map[string]interface{})
+	if curStats == nil {
+		curStats = make(map[string]interface{})
+	}
+	curStats[instanceName] = stats
+	data["download-stats"] = curStats
+
+	chg.Set("api-data", data)
+	return nil
+}
+
 var (
 	mountPollInterval = 1 * time.Second
 )
@@ -707,9 +955,10 @@ func (m *SnapManager) doMountSnap(t *state.Task, _ *tomb.Tomb) error {
 	}
 
 	// double check that the snap is mounted
+	var newInfo *snap.Info
 	var readInfoErr error
 	for i := 0; i < 10; i++ {
-		_, readInfoErr = readInfo(snapsup.InstanceName(), snapsup.SideInfo, errorOnBroken)
+		newInfo, readInfoErr = readInfo(snapsup.InstanceName(), snapsup.SideInfo, errorOnBroken)
 		if readInfoErr == nil {
 			break
 		}
@@ -744,7 +993,11 @@ func (m *SnapManager) doMountSnap(t *state.Task, _ *tomb.Tomb) error {
 	if installRecord != nil {
 		t.Set("install-record", installRecord)
 	}
+	err = injectMigrateDataHook(t, snapsup, curInfo, newInfo)
 	st.Unlock()
+	if err != nil {
+		return err
+	}
 
 	if snapsup.Flags.RemoveSnapPath {
 		if err := os.Remove(snapsup.SnapPath); err != nil {
@@ -1201,7 +1454,12 @@ func (m *SnapManager) doLinkSnap(t *state.Task, _ *tomb.Tomb) (err error) {
 	if snapsup.Required { // set only on install and left alone on refresh
 		snapst.Required = true
 	}
+	if !isInstalled && len(snapsup.MigratedDisabledServices) != 0 {
+		// keep services disabled in the snap replaced by this one
+		snapst.LastActiveDisabledServices = snapsup.MigratedDisabledServices
+	}
 	oldRefreshInhibitedTime := snapst.RefreshInhibitedTime
+	oldRefreshInhibitSkippedTime := snapst.RefreshInhibitSkippedTime
 	oldLastRefreshTime := snapst.LastRefreshTime
 	// only set userID if unset or logged out in snapst and if we
 	// actually have an associated user
@@ -1313,11 +1571,13 @@ func (m *SnapManager) doLinkSnap(t *state.Task, _ *tomb.Tomb) (err error) {
 	t.Set("old-current", oldCurrent)
 	t.Set("old-candidate-index", oldCandidateIndex)
 	t.Set("old-refresh-inhibited-time", oldRefreshInhibitedTime)
+	t.Set("old-refresh-inhibit-skipped-time", oldRefreshInhibitSkippedTime)
 	t.Set("old-cohort-key", oldCohortKey)
 	t.Set("old-last-refresh-time", oldLastRefreshTime)
 
 	// Record the fact that the snap was refreshed successfully.
 	snapst.RefreshInhibitedTime = nil
+	snapst.RefreshInhibitSkippedTime = nil
 	if !snapsup.Revert {
 		now := timeNow()
 		snapst.LastRefreshTime = &now
@@ -1572,6 +1832,10 @@ func (m *SnapManager) undoLinkSnap(t *state.Task, _ *tomb.Tomb) error {
 	if err := t.Get("old-refresh-inhibited-time", &oldRefreshInhibitedTime); err != nil && err != state.ErrNoState {
 		return err
 	}
+	var oldRefreshInhibitSkippedTime *time.Time
+	if err := t.Get("old-refresh-inhibit-skipped-time", &oldRefreshInhibitSkippedTime); err != nil && err != state.ErrNoState {
+		return err
+	}
 	var oldLastRefreshTime *time.Time
 	if err := t.Get("old-last-refresh-time", &oldLastRefreshTime); err != nil && err != state.ErrNoState {
 		return err
@@ -1623,6 +1887,7 @@ func (m *SnapManager) undoLinkSnap(t *state.Task, _ *tomb.Tomb) error {
 	snapst.JailMode = oldJailMode
 	snapst.Classic = oldClassic
 	snapst.RefreshInhibitedTime = oldRefreshInhibitedTime
+	snapst.RefreshInhibitSkippedTime = oldRefreshInhibitSkippedTime
 	snapst.LastRefreshTime = oldLastRefreshTime
 	snapst.CohortKey = oldCohortKey
 
@@ -2540,7 +2805,7 @@ func (m *SnapManager) undoRefreshAliases(t *state.Task, _ *tomb.Tomb) error {
 			// automatic aliases of other were disabled, undo that
 			autoDisabled = false
 		}
-		otherAliases := reenableAliases(otherCurInfo, otherSnapState.Aliases, otherDisabled.Manual)
+		otherAliases := reenableAliases(otherCurInfo, otherSnapState.Aliases, otherDisabled.Manual, otherDisabled.ManualArgs)
 		// check for conflicts taking into account
 		// re-enabled aliases
 		conflicts, err := checkAliasesConflicts(st, otherSnap, autoDisabled, otherAliases, newSnapStates)
@@ -2674,6 +2939,11 @@ func (m *SnapManager) doAlias(t *state.Task, _ *tomb.Tomb) error {
 	if err != nil {
 		return err
 	}
+	var args []string
+	err = t.Get("args", &args)
+	if err != nil && err != state.ErrNoState {
+		return err
+	}
 
 	snapName := snapsup.InstanceName()
 	curInfo, err := snapst.CurrentInfo()
@@ -2683,7 +2953,7 @@ func (m *SnapManager) doAlias(t *state.Task, _ *tomb.Tomb) error {
 
 	autoDisabled := snapst.AutoAliasesDisabled
 	curAliases := snapst.Aliases
-	newAliases, err := manualAlias(curInfo, curAliases, target, alias)
+	newAliases, err := manualAlias(curInfo, curAliases, target, alias, args)
 	if err != nil {
 		return err
 	}
@@ -2780,6 +3050,8 @@ type otherDisabledAliases struct {
 	Auto bool `json:"auto,omitempty"`
 	// Manual records which manual aliases were removed by prefer
 	Manual map[string]string `json:"manual,omitempty"`
+	// ManualArgs records the arguments of the removed manual aliases
+	ManualArgs map[string][]string `json:"manual-args,omitempty"`
 }
 
 func (m *SnapManager) doPreferAliases(t *state.Task, _ *tomb.Tomb) error {
@@ -2819,6 +3091,7 @@ func (m *SnapManager) doPreferAliases(t *state.Task, _ *tomb.Tomb) error {
 			return err
 		}
 
+		disabledManualArgs := manualAliasesArgs(otherSnapState.Aliases)
 		otherAliases, disabledManual := disableAliases(otherSnapState.Aliases)
 
 		added, removed, err := applyAliasesChange(otherSnap, otherSnapState.AutoAliasesDisabled, otherSnapState.Aliases, autoDis, otherAliases, m.backend, otherSnapState.AliasesPending)
@@ -2831,6 +3104,7 @@ func (m *SnapManager) doPreferAliases(t *state.Task, _ *tomb.Tomb) error {
 
 		var otherDisabled otherDisabledAliases
 		otherDisabled.Manual = disabledManual
+		otherDisabled.ManualArgs = disabledManualArgs
 		otherSnapState.Aliases = otherAliases
 		// disable automatic aliases as needed
 		if !otherSnapState.AutoAliasesDisabled && len(otherAliases) != 0 {
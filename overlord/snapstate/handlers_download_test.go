@@ -295,3 +295,257 @@ func (s *downloadSnapSuite) TestDoDownloadRateLimitedIntegration(c *C) {
 	})
 
 }
+
+func (s *downloadSnapSuite) TestDoDownloadRateLimitScheduleIntegration(c *C) {
+	s.state.Lock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.rate-limit-schedule", "mon-fri,9:00-17:00,1MB")
+	tr.Commit()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+		Flags: snapstate.Flags{
+			IsAutoRefresh: true,
+		},
+	})
+	s.state.NewChange("dummy", "...").AddTask(t)
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	windows, err := snapstate.ParseRateLimitSchedule("mon-fri,9:00-17:00,1MB")
+	c.Assert(err, IsNil)
+	c.Assert(s.fakeStore.downloads, DeepEquals, []fakeDownload{
+		{
+			name:   "foo",
+			target: filepath.Join(dirs.SnapBlobDir, "foo_11.snap"),
+			opts: &store.DownloadOptions{
+				IsAutoRefresh:     true,
+				RateLimitSchedule: windows,
+			},
+		},
+	})
+}
+
+func (s *downloadSnapSuite) TestDoDownloadLANPeers(c *C) {
+	s.state.Lock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "proxy.lan-peers", "http://peer-1:8080, http://peer-2")
+	tr.Commit()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+	})
+	s.state.NewChange("dummy", "...").AddTask(t)
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	c.Assert(s.fakeStore.downloads, DeepEquals, []fakeDownload{
+		{
+			name:   "foo",
+			target: filepath.Join(dirs.SnapBlobDir, "foo_11.snap"),
+			opts: &store.DownloadOptions{
+				LANPeers: []string{"http://peer-1:8080", "http://peer-2"},
+			},
+		},
+	})
+}
+
+func (s *downloadSnapSuite) TestDoDownloadChunks(c *C) {
+	s.state.Lock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.download-chunks", 4)
+	tr.Commit()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+		},
+	})
+	s.state.NewChange("dummy", "...").AddTask(t)
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	c.Assert(s.fakeStore.downloads, DeepEquals, []fakeDownload{
+		{
+			name:   "foo",
+			target: filepath.Join(dirs.SnapBlobDir, "foo_11.snap"),
+			opts: &store.DownloadOptions{
+				Chunks: 4,
+			},
+		},
+	})
+}
+
+func (s *downloadSnapSuite) TestParseRateLimitSchedule(c *C) {
+	windows, err := snapstate.ParseRateLimitSchedule("mon-fri,9:00-17:00,1MB;sat-sun,8:00-20:00,5kB")
+	c.Assert(err, IsNil)
+	c.Assert(windows, HasLen, 2)
+	c.Check(windows[0].Rate, Equals, int64(1000*1000))
+	c.Check(windows[0].Schedule, HasLen, 1)
+	c.Check(windows[0].Schedule[0].String(), Equals, "mon-fri,09:00-17:00")
+	c.Check(windows[1].Rate, Equals, int64(5*1000))
+	c.Check(windows[1].Schedule[0].String(), Equals, "sat-sun,08:00-20:00")
+
+	// the schedule of a window can have several events
+	windows, err = snapstate.ParseRateLimitSchedule("mon,9:00-12:00,,fri,13:00-17:00,1MB")
+	c.Assert(err, IsNil)
+	c.Assert(windows, HasLen, 1)
+	c.Check(windows[0].Rate, Equals, int64(1000*1000))
+	c.Check(windows[0].Schedule, HasLen, 2)
+
+	for _, t := range []struct {
+		value string
+		err   string
+	}{
+		{"1MB", `cannot parse rate limit window "1MB": expected <schedule>,<rate>`},
+		{"mon-fri,9:00-17:00", `cannot parse rate limit window "mon-fri,9:00-17:00": cannot parse "9:00-17:00": .*`},
+		{"mon-fri,9:00-17:00,-1MB", `cannot parse rate limit window "mon-fri,9:00-17:00,-1MB": .*`},
+		{"foo,9:00-17:00,1MB", `cannot parse rate limit window "foo,9:00-17:00,1MB": .*`},
+		{"mon-fri,9:00-17:00,1MB;", `cannot parse rate limit window "": expected <schedule>,<rate>`},
+		{"mon-fri,9:00-17:00,1MB;;sat,5kB", `cannot parse rate limit window "": expected <schedule>,<rate>`},
+		// windows separated in an ambiguous way
+		{"mon-fri,9:00-17:00,1MB,,sat-sun,8:00-20:00,5kB", `cannot parse rate limit window "mon-fri,9:00-17:00,1MB,,sat-sun,8:00-20:00,5kB": windows must be separated by ";"`},
+		{"mon,1MB,,fri,2MB", `cannot parse rate limit window "mon,1MB,,fri,2MB": windows must be separated by ";"`},
+		{"mon-fri,9:00-17:00,1MB,sat,5kB", `cannot parse rate limit window "mon-fri,9:00-17:00,1MB,sat,5kB": .*`},
+	} {
+		_, err := snapstate.ParseRateLimitSchedule(t.value)
+		c.Check(err, ErrorMatches, t.err, Commentf("%q", t.value))
+	}
+}
+
+func (s *downloadSnapSuite) TestDoDownloadDeltasRequiredAndStats(c *C) {
+	s.state.Lock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.deltas", "required")
+	tr.Commit()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+			Size:        10000,
+			Deltas: []snap.DeltaInfo{
+				{FromRevision: 10, ToRevision: 11, Format: "xdelta3", Size: 100},
+			},
+		},
+	})
+	chg := s.state.NewChange("dummy", "...")
+	chg.AddTask(t)
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(s.fakeStore.downloads, DeepEquals, []fakeDownload{
+		{
+			name:   "foo",
+			target: filepath.Join(dirs.SnapBlobDir, "foo_11.snap"),
+			opts: &store.DownloadOptions{
+				DeltasRequired: true,
+			},
+		},
+	})
+
+	var apiData map[string]interface{}
+	c.Assert(chg.Get("api-data", &apiData), IsNil)
+	c.Check(apiData, DeepEquals, map[string]interface{}{
+		"download-stats": map[string]interface{}{
+			"foo": map[string]interface{}{
+				"delta-available": true,
+				"delta-required":  true,
+				"delta-applied":   true,
+				"full-size":       float64(10000),
+				"delta-size":      float64(100),
+			},
+		},
+	})
+}
+
+func (s *downloadSnapSuite) TestDoDownloadStatsNoDelta(c *C) {
+	s.state.Lock()
+
+	si := &snap.SideInfo{
+		RealName: "foo",
+		SnapID:   "foo-id",
+		Revision: snap.R(11),
+	}
+	t := s.state.NewTask("download-snap", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: si,
+		DownloadInfo: &snap.DownloadInfo{
+			DownloadURL: "http://some-url.com/snap",
+			Size:        10000,
+		},
+	})
+	chg := s.state.NewChange("dummy", "...")
+	chg.AddTask(t)
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var apiData map[string]interface{}
+	c.Assert(chg.Get("api-data", &apiData), IsNil)
+	c.Check(apiData, DeepEquals, map[string]interface{}{
+		"download-stats": map[string]interface{}{
+			"foo": map[string]interface{}{
+				"delta-available": false,
+				"delta-required":  false,
+				"delta-applied":   false,
+				"full-size":       float64(10000),
+			},
+		},
+	})
+}
@@ -22,6 +22,7 @@ package snapstate_test
 import (
 	"fmt"
 	"os"
+	"sort"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -33,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
 )
 
 type prereqSuite struct {
@@ -218,6 +220,164 @@ func (s *prereqSuite) TestDoPrereqTalksToStoreAndQueues(c *C) {
 	c.Check(linkedSnaps, DeepEquals, expectedLinkedSnaps)
 }
 
+func (s *prereqSuite) TestDoPrereqOtherSnapsDoNotWaitToDownloadAndMount(c *C) {
+	s.state.Lock()
+
+	snapstate.Set(s.state, "core", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "core", Revision: snap.R(1)},
+		},
+		Current:  snap.R(1),
+		SnapType: "os",
+	})
+
+	t := s.state.NewTask("prerequisites", "test")
+	t.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: "foo",
+			Revision: snap.R(33),
+		},
+		Base: "some-base",
+	})
+	chg := s.state.NewChange("dummy", "...")
+	chg.AddTask(t)
+
+	// tasks of another snap installed in the same change, held back by
+	// a task nobody handles so that they do not run
+	blocker := s.state.NewTask("blocker", "...")
+	chg.AddTask(blocker)
+	otherTasks := make(map[string]*state.Task)
+	for _, kind := range []string{"download-snap", "validate-snap", "mount-snap", "link-snap"} {
+		ot := s.state.NewTask(kind, "...")
+		ot.WaitFor(blocker)
+		chg.AddTask(ot)
+		otherTasks[kind] = ot
+	}
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(t.Status(), Equals, state.DoneStatus)
+
+	var baseLink *state.Task
+	for _, bt := range chg.Tasks() {
+		if bt.Kind() != "link-snap" || bt == otherTasks["link-snap"] {
+			continue
+		}
+		snapsup, err := snapstate.TaskSnapSetup(bt)
+		c.Assert(err, IsNil)
+		c.Check(snapsup.InstanceName(), Equals, "some-base")
+		baseLink = bt
+	}
+	c.Assert(baseLink, NotNil)
+
+	// fetching and mounting the other snap can happen while the base is
+	// being installed
+	for _, kind := range []string{"download-snap", "validate-snap", "mount-snap"} {
+		c.Check(otherTasks[kind].WaitTasks(), DeepEquals, []*state.Task{blocker}, Commentf("kind %s", kind))
+	}
+	// but linking must wait for it
+	c.Check(otherTasks["link-snap"].WaitTasks(), testutil.Contains, baseLink)
+}
+
+func (s *prereqSuite) TestDoPrereqSnapsWithContentPrereqsDoNotWaitOnEachOther(c *C) {
+	s.state.Lock()
+
+	snapstate.Set(s.state, "core", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "core", Revision: snap.R(1)},
+		},
+		Current:  snap.R(1),
+		SnapType: "os",
+	})
+
+	chg := s.state.NewChange("dummy", "...")
+	prereqTasks := make(map[string]*state.Task)
+	snapTasks := make(map[string]map[string]*state.Task)
+	for name, contentPrereq := range map[string]string{"foo": "content-a", "bar": "content-b"} {
+		t := s.state.NewTask("prerequisites", name)
+		t.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo: &snap.SideInfo{
+				RealName: name,
+				Revision: snap.R(33),
+			},
+			Base:   "some-base",
+			Prereq: []string{contentPrereq},
+		})
+		chg.AddTask(t)
+		prereqTasks[name] = t
+
+		snapTasks[name] = make(map[string]*state.Task)
+		prev := t
+		for _, kind := range []string{"download-snap", "mount-snap", "link-snap"} {
+			st := s.state.NewTask(kind, "...")
+			st.Set("snap-setup-task", t.ID())
+			st.WaitFor(prev)
+			chg.AddTask(st)
+			snapTasks[name][kind] = st
+			prev = st
+		}
+	}
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	for name, t := range prereqTasks {
+		c.Check(t.Status(), Equals, state.DoneStatus, Commentf("snap %s", name))
+	}
+
+	// the content prerequisites of both snaps and the base, only once,
+	// are installed by the change
+	var linkedSnaps []string
+	var baseLink *state.Task
+	for _, t := range chg.Tasks() {
+		if t.Kind() != "link-snap" || t == snapTasks["foo"]["link-snap"] || t == snapTasks["bar"]["link-snap"] {
+			continue
+		}
+		snapsup, err := snapstate.TaskSnapSetup(t)
+		c.Assert(err, IsNil)
+		linkedSnaps = append(linkedSnaps, snapsup.InstanceName())
+		if snapsup.InstanceName() == "some-base" {
+			baseLink = t
+		}
+	}
+	sort.Strings(linkedSnaps)
+	c.Check(linkedSnaps, DeepEquals, []string{"content-a", "content-b", "some-base"})
+	c.Assert(baseLink, NotNil)
+
+	isBaseTask := func(t *state.Task) bool {
+		snapsup, err := snapstate.TaskSnapSetup(t)
+		return err == nil && snapsup.InstanceName() == "some-base"
+	}
+	// nothing up to mounting a snap waits for the base
+	for _, t := range chg.Tasks() {
+		switch t.Kind() {
+		case "prerequisites", "download-snap", "mount-snap":
+		default:
+			continue
+		}
+		if isBaseTask(t) {
+			continue
+		}
+		for _, wt := range t.WaitTasks() {
+			c.Check(isBaseTask(wt), Equals, false, Commentf("%s %q waits for %s of the base", t.Kind(), t.Summary(), wt.Kind()))
+		}
+	}
+	for name, tasks := range snapTasks {
+		c.Check(tasks["download-snap"].WaitTasks(), DeepEquals, []*state.Task{prereqTasks[name]}, Commentf("snap %s", name))
+		// but linking both snaps waits for it
+		c.Check(tasks["link-snap"].WaitTasks(), testutil.Contains, baseLink, Commentf("snap %s", name))
+	}
+}
+
 func (s *prereqSuite) TestDoPrereqRetryWhenBaseInFlight(c *C) {
 	restore := snapstate.MockPrerequisitesRetryTimeout(1 * time.Millisecond)
 	defer restore()
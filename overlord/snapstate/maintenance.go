@@ -0,0 +1,92 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// MaintenanceHold is a hold on auto-refreshes, hotplug changes and other
+// background activity requested by an operator to freeze the state of the
+// device, for instance during critical production windows.
+type MaintenanceHold struct {
+	Since time.Time `json:"since"`
+	// Until is when the hold is released on its own, if set.
+	Until time.Time `json:"until,omitempty"`
+}
+
+// Maintenance returns the current maintenance hold, or nil if there is
+// none. An expired hold is cleared.
+func Maintenance(st *state.State) (*MaintenanceHold, error) {
+	var hold MaintenanceHold
+	err := st.Get("maintenance-hold", &hold)
+	if err == state.ErrNoState {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hold.Until.IsZero() && !timeNow().Before(hold.Until) {
+		logger.Noticef("Maintenance hold expired at %s.", hold.Until.Format(time.RFC3339))
+		st.Set("maintenance-hold", nil)
+		return nil, nil
+	}
+	return &hold, nil
+}
+
+// HoldMaintenance puts the device on maintenance hold until it is released
+// or the given deadline passes, if not zero. Holding again updates the
+// deadline.
+func HoldMaintenance(st *state.State, until time.Time) (*MaintenanceHold, error) {
+	now := timeNow()
+	if !until.IsZero() && !until.After(now) {
+		return nil, fmt.Errorf("cannot hold maintenance until %s: time is in the past", until.Format(time.RFC3339))
+	}
+	hold, err := Maintenance(st)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		hold = &MaintenanceHold{Since: now}
+	}
+	hold.Until = until
+	st.Set("maintenance-hold", hold)
+	return hold, nil
+}
+
+// ReleaseMaintenance releases the maintenance hold, if any.
+func ReleaseMaintenance(st *state.State) {
+	st.Set("maintenance-hold", nil)
+}
+
+// maintenanceHeld returns whether background activity should be skipped
+// because of a maintenance hold.
+func maintenanceHeld(st *state.State) bool {
+	hold, err := Maintenance(st)
+	if err != nil {
+		logger.Noticef("Cannot get maintenance hold: %v", err)
+		return false
+	}
+	return hold != nil
+}
@@ -0,0 +1,108 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type maintenanceSuite struct {
+	state *state.State
+	now   time.Time
+}
+
+var _ = Suite(&maintenanceSuite{})
+
+func (s *maintenanceSuite) SetUpTest(c *C) {
+	s.state = state.New(nil)
+	s.now = time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+}
+
+func (s *maintenanceSuite) TestHoldAndRelease(c *C) {
+	restore := snapstate.MockTimeNow(func() time.Time { return s.now })
+	defer restore()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	hold, err := snapstate.Maintenance(st)
+	c.Assert(err, IsNil)
+	c.Check(hold, IsNil)
+
+	hold, err = snapstate.HoldMaintenance(st, time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(hold, DeepEquals, &snapstate.MaintenanceHold{Since: s.now})
+
+	// holding again sets the deadline but keeps the start
+	until := s.now.Add(2 * time.Hour)
+	s.now = s.now.Add(time.Hour)
+	hold, err = snapstate.HoldMaintenance(st, until)
+	c.Assert(err, IsNil)
+	c.Check(hold.Since.Equal(s.now.Add(-time.Hour)), Equals, true)
+	c.Check(hold.Until.Equal(until), Equals, true)
+
+	hold, err = snapstate.Maintenance(st)
+	c.Assert(err, IsNil)
+	c.Assert(hold, NotNil)
+	c.Check(hold.Until.Equal(until), Equals, true)
+
+	snapstate.ReleaseMaintenance(st)
+	hold, err = snapstate.Maintenance(st)
+	c.Assert(err, IsNil)
+	c.Check(hold, IsNil)
+}
+
+func (s *maintenanceSuite) TestHoldExpires(c *C) {
+	restore := snapstate.MockTimeNow(func() time.Time { return s.now })
+	defer restore()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	_, err := snapstate.HoldMaintenance(st, s.now.Add(time.Hour))
+	c.Assert(err, IsNil)
+
+	s.now = s.now.Add(time.Hour)
+	hold, err := snapstate.Maintenance(st)
+	c.Assert(err, IsNil)
+	c.Check(hold, IsNil)
+
+	var raw interface{}
+	c.Check(st.Get("maintenance-hold", &raw), Equals, state.ErrNoState)
+}
+
+func (s *maintenanceSuite) TestHoldInThePast(c *C) {
+	restore := snapstate.MockTimeNow(func() time.Time { return s.now })
+	defer restore()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	_, err := snapstate.HoldMaintenance(st, s.now.Add(-time.Minute))
+	c.Check(err, ErrorMatches, `cannot hold maintenance until 2021-06-01T09:59:00Z: time is in the past`)
+}
@@ -0,0 +1,233 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"fmt"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// SnapPublisherID is a hook set by assertstate, it returns the id of the
+// publisher of the snap with the given snap id.
+var SnapPublisherID = func(st *state.State, snapID string) (string, error) {
+	panic("internal error: snapstate.SnapPublisherID is unset")
+}
+
+// ReplacementTasks returns the tasks migrating the installed snap oldName
+// to the snap installed by installTs which replaces it. The prepare task
+// set stops the services of oldName and copies its data over to the new
+// snap, it needs to run before installTs. The finish task set transfers
+// the connections of oldName to the new snap and removes oldName, it
+// needs to run after installTs. The service state of oldName is carried
+// over when the new snap is linked.
+func ReplacementTasks(st *state.State, oldName string, installTs *state.TaskSet) (prepare, finish *state.TaskSet, err error) {
+	var snapst SnapState
+	if err := Get(st, oldName, &snapst); err != nil && err != state.ErrNoState {
+		return nil, nil, err
+	}
+	if !snapst.IsInstalled() {
+		return nil, nil, &snap.NotInstalledError{Snap: oldName}
+	}
+	info, err := snapst.CurrentInfo()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Type() != snap.TypeApp {
+		return nil, nil, fmt.Errorf("cannot replace snap %q of type %q, only app snaps can be replaced", oldName, info.Type())
+	}
+
+	var snapsupTask *state.Task
+	for _, t := range installTs.Tasks() {
+		if t.Kind() != "link-snap" {
+			continue
+		}
+		var id string
+		if err := t.Get("snap-setup-task", &id); err != nil {
+			return nil, nil, err
+		}
+		snapsupTask = st.Task(id)
+		break
+	}
+	if snapsupTask == nil {
+		return nil, nil, fmt.Errorf("internal error: cannot find the snap setup of the snap replacing %q", oldName)
+	}
+	newSnapsup, err := TaskSnapSetup(snapsupTask)
+	if err != nil {
+		return nil, nil, err
+	}
+	newName := newSnapsup.InstanceName()
+
+	oldSnapsup := SnapSetup{
+		SideInfo: &snap.SideInfo{
+			SnapID:   info.SnapID,
+			RealName: snap.InstanceSnap(oldName),
+			Revision: snapst.Current,
+		},
+		Type:        info.Type(),
+		PlugsOnly:   len(info.Slots) == 0,
+		InstanceKey: snapst.InstanceKey,
+	}
+
+	prepare = state.NewTaskSet()
+	var prev *state.Task
+	if snapst.Active {
+		stopSnapServices := st.NewTask("stop-snap-services", fmt.Sprintf(i18n.G("Stop snap %q services"), oldName))
+		stopSnapServices.Set("snap-setup", oldSnapsup)
+		stopSnapServices.Set("stop-reason", snap.StopReasonRemove)
+		prepare.AddTask(stopSnapServices)
+		prev = stopSnapServices
+	}
+	migrateData := st.NewTask("migrate-snap-data", fmt.Sprintf(i18n.G("Migrate data of snap %q to snap %q"), oldName, newName))
+	migrateData.Set("snap-setup-task", snapsupTask.ID())
+	migrateData.Set("migrate-from", oldName)
+	if prev != nil {
+		migrateData.WaitFor(prev)
+	}
+	prepare.AddTask(migrateData)
+
+	transferConns := st.NewTask("transfer-connections", fmt.Sprintf(i18n.G("Transfer connections of snap %q to snap %q"), oldName, newName))
+	transferConns.Set("snap-setup-task", snapsupTask.ID())
+	transferConns.Set("migrate-from", oldName)
+	finish = state.NewTaskSet(transferConns)
+
+	// the old snap is required by the current model, but it is
+	// replaced and not required anymore once the remodel is done
+	removeTs, _, err := removeTasks(st, oldName, snap.R(0), &RemoveFlags{skipPolicyCheck: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	removeTs.WaitFor(transferConns)
+	finish.AddAll(removeTs)
+
+	return prepare, finish, nil
+}
+
+// replacedSnapInfo returns the info of the snap being replaced as
+// recorded in the given migration task.
+func replacedSnapInfo(t *state.Task) (*SnapState, *snap.Info, error) {
+	var oldName string
+	if err := t.Get("migrate-from", &oldName); err != nil {
+		return nil, nil, err
+	}
+	var snapst SnapState
+	if err := Get(t.State(), oldName, &snapst); err != nil {
+		return nil, nil, err
+	}
+	info, err := snapst.CurrentInfo()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &snapst, info, nil
+}
+
+// checkReplacementPublisher checks that the snap being replaced and the
+// snap replacing it come from the same publisher.
+func checkReplacementPublisher(st *state.State, oldInfo *snap.Info, snapsup *SnapSetup) error {
+	oldSnapID, newSnapID := oldInfo.SnapID, snapsup.SideInfo.SnapID
+	if oldSnapID == "" && newSnapID == "" {
+		// both are unasserted
+		return nil
+	}
+	if oldSnapID == "" || newSnapID == "" {
+		return fmt.Errorf("cannot replace snap %q with snap %q: cannot replace asserted snaps with unasserted ones or vice versa", oldInfo.InstanceName(), snapsup.InstanceName())
+	}
+	oldPublisher, err := SnapPublisherID(st, oldSnapID)
+	if err != nil {
+		return err
+	}
+	newPublisher, err := SnapPublisherID(st, newSnapID)
+	if err != nil {
+		return err
+	}
+	if oldPublisher != newPublisher {
+		return fmt.Errorf("cannot replace snap %q with snap %q: snaps have different publishers", oldInfo.InstanceName(), snapsup.InstanceName())
+	}
+	return nil
+}
+
+// replacementInfo returns a minimal info for the snap replacing another,
+// enough to locate its data directories before it is mounted.
+func replacementInfo(snapsup *SnapSetup) *snap.Info {
+	info := &snap.Info{SideInfo: *snapsup.SideInfo}
+	info.InstanceKey = snapsup.InstanceKey
+	return info
+}
+
+func (m *SnapManager) doMigrateSnapData(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	snapsup, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	oldSnapst, oldInfo, err := replacedSnapInfo(t)
+	if err != nil {
+		return err
+	}
+	if err := checkReplacementPublisher(st, oldInfo, snapsup); err != nil {
+		return err
+	}
+
+	pb := NewTaskProgressAdapterUnlocked(t)
+	st.Unlock()
+	err = m.backend.MigrateSnapData(replacementInfo(snapsup), oldInfo, pb)
+	st.Lock()
+	if err != nil {
+		return err
+	}
+
+	// the services disabled in the old snap stay disabled in the new
+	// one, see doLinkSnap
+	snapsup.MigratedDisabledServices = oldSnapst.LastActiveDisabledServices
+	return SetTaskSnapSetup(t, snapsup)
+}
+
+func (m *SnapManager) undoMigrateSnapData(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	snapsup, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	_, oldInfo, err := replacedSnapInfo(t)
+	if err != nil {
+		return err
+	}
+
+	pb := NewTaskProgressAdapterUnlocked(t)
+	st.Unlock()
+	err = m.backend.UndoMigrateSnapData(replacementInfo(snapsup), oldInfo, pb)
+	st.Lock()
+	if err != nil {
+		return err
+	}
+
+	snapsup.MigratedDisabledServices = nil
+	return SetTaskSnapSetup(t, snapsup)
+}
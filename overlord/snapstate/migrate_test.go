@@ -0,0 +1,166 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"context"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (s *snapmgrTestSuite) setupReplacement(c *C) (installTs, prepare, finish *state.TaskSet) {
+	snapstate.Set(s.state, "old-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "old-snap", SnapID: "old-snap-id", Revision: snap.R(7)},
+		},
+		Current:                    snap.R(7),
+		SnapType:                   "app",
+		Required:                   true,
+		LastActiveDisabledServices: []string{"svc1"},
+	})
+
+	installTs, err := snapstate.Install(context.Background(), s.state, "some-snap", nil, 0, snapstate.Flags{Required: true})
+	c.Assert(err, IsNil)
+
+	prepare, finish, err = snapstate.ReplacementTasks(s.state, "old-snap", installTs)
+	c.Assert(err, IsNil)
+	return installTs, prepare, finish
+}
+
+func (s *snapmgrTestSuite) TestReplacementTasks(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	installTs, prepare, finish := s.setupReplacement(c)
+
+	c.Check(taskKinds(prepare.Tasks()), DeepEquals, []string{"stop-snap-services", "migrate-snap-data"})
+	kinds := taskKinds(finish.Tasks())
+	c.Assert(len(kinds) > 1, Equals, true)
+	c.Check(kinds[0], Equals, "transfer-connections")
+	c.Check(kinds[len(kinds)-1], Equals, "discard-snap")
+
+	linkSnap := installTs.Tasks()[len(installTs.Tasks())-1]
+	for _, t := range installTs.Tasks() {
+		if t.Kind() == "link-snap" {
+			linkSnap = t
+		}
+	}
+	var snapsupTaskID string
+	c.Assert(linkSnap.Get("snap-setup-task", &snapsupTaskID), IsNil)
+	for _, t := range []*state.Task{prepare.Tasks()[1], finish.Tasks()[0]} {
+		var id, from string
+		c.Assert(t.Get("snap-setup-task", &id), IsNil)
+		c.Check(id, Equals, snapsupTaskID)
+		c.Assert(t.Get("migrate-from", &from), IsNil)
+		c.Check(from, Equals, "old-snap")
+	}
+	// the old snap is removed only once connections got transferred
+	c.Check(finish.Tasks()[1].WaitTasks(), DeepEquals, []*state.Task{finish.Tasks()[0]})
+}
+
+func (s *snapmgrTestSuite) TestReplacementTasksOnlyApps(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	installTs, err := snapstate.Install(context.Background(), s.state, "some-snap", nil, 0, snapstate.Flags{})
+	c.Assert(err, IsNil)
+
+	_, _, err = snapstate.ReplacementTasks(s.state, "core", installTs)
+	c.Assert(err, ErrorMatches, `cannot replace snap "core" of type "os", only app snaps can be replaced`)
+
+	_, _, err = snapstate.ReplacementTasks(s.state, "not-installed", installTs)
+	c.Assert(err, ErrorMatches, `snap "not-installed" is not installed`)
+}
+
+func (s *snapmgrTestSuite) TestReplacementRunThrough(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := snapstate.MockSnapPublisherID(func(st *state.State, snapID string) (string, error) {
+		return "publisher-id", nil
+	})
+	defer restore()
+
+	installTs, prepare, finish := s.setupReplacement(c)
+	installTs.WaitAll(prepare)
+	finish.WaitAll(installTs)
+
+	chg := s.state.NewChange("remodel", "...")
+	chg.AddAll(prepare)
+	chg.AddAll(installTs)
+	chg.AddAll(finish)
+
+	s.state.Unlock()
+	defer s.se.Stop()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Assert(chg.Err(), IsNil)
+	c.Assert(chg.Status(), Equals, state.DoneStatus)
+
+	c.Check(s.fakeBackend.ops.First("migrate-data"), DeepEquals, &fakeOp{
+		op:   "migrate-data",
+		path: snap.MountDir("some-snap", snap.R(11)),
+		old:  snap.MountDir("old-snap", snap.R(7)),
+	})
+
+	var snapst snapstate.SnapState
+	c.Check(snapstate.Get(s.state, "old-snap", &snapst), Equals, state.ErrNoState)
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Required, Equals, true)
+	c.Check(snapst.LastActiveDisabledServices, DeepEquals, []string{"svc1"})
+}
+
+func (s *snapmgrTestSuite) TestReplacementDifferentPublisher(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := snapstate.MockSnapPublisherID(func(st *state.State, snapID string) (string, error) {
+		return snapID + "-publisher", nil
+	})
+	defer restore()
+
+	installTs, prepare, finish := s.setupReplacement(c)
+	installTs.WaitAll(prepare)
+	finish.WaitAll(installTs)
+
+	chg := s.state.NewChange("remodel", "...")
+	chg.AddAll(prepare)
+	chg.AddAll(installTs)
+	chg.AddAll(finish)
+
+	s.state.Unlock()
+	defer s.se.Stop()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Assert(chg.Err(), ErrorMatches, `(?s).*cannot replace snap "old-snap" with snap "some-snap": snaps have different publishers.*`)
+	c.Check(s.fakeBackend.ops.First("migrate-data"), IsNil)
+
+	// the old snap is still there
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "old-snap", &snapst), IsNil)
+	c.Check(snapst.Active, Equals, true)
+}
@@ -97,3 +97,27 @@ func ClassicModel() *asserts.Model {
 	}
 	return assertstest.FakeAssertion(headers).(*asserts.Model)
 }
+
+func MakeDeviceGroup(group string, override map[string]interface{}) *asserts.DeviceGroup {
+	headers := map[string]interface{}{
+		"type":         "device-group",
+		"authority-id": "brand",
+		"brand-id":     "brand",
+		"model":        "baz-3000",
+		"group":        group,
+		"serials":      []interface{}{"serial"},
+		"timestamp":    "2018-01-01T08:00:00+00:00",
+	}
+	return assertstest.FakeAssertion(headers, override).(*asserts.DeviceGroup)
+}
+
+func MakeSnapPolicy(override map[string]interface{}) *asserts.SnapPolicy {
+	headers := map[string]interface{}{
+		"type":         "snap-policy",
+		"authority-id": "brand",
+		"brand-id":     "brand",
+		"model":        "baz-3000",
+		"timestamp":    "2018-01-01T08:00:00+00:00",
+	}
+	return assertstest.FakeAssertion(headers, override).(*asserts.SnapPolicy)
+}
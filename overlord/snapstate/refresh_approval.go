@@ -0,0 +1,327 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// PendingRefresh holds information about a revision of a snap that was
+// downloaded by an auto-refresh and is waiting for approval before being
+// applied.
+type PendingRefresh struct {
+	Revision   snap.Revision `json:"revision"`
+	Version    string        `json:"version,omitempty"`
+	Channel    string        `json:"channel,omitempty"`
+	StagedTime time.Time     `json:"staged-time"`
+}
+
+// stagedRefresh is how a pending refresh is kept in the state.
+type stagedRefresh struct {
+	Candidate  *refreshCandidate `json:"candidate"`
+	StagedTime time.Time         `json:"staged-time"`
+}
+
+// refreshApprovalRequired returns whether auto-refreshes must only stage
+// the new revisions, leaving it to the operator to apply them.
+func refreshApprovalRequired(st *state.State) (bool, error) {
+	tr := config.NewTransaction(st)
+
+	var approval string
+	err := tr.Get("core", "refresh.approval", &approval)
+	if err != nil && !config.IsNoOption(err) {
+		return false, err
+	}
+	return approval == "required", nil
+}
+
+// stagedRefreshes returns the pending refreshes from the state, dropping
+// the ones that no longer apply because the snap was removed or refreshed
+// to the staged revision in the meantime.
+func stagedRefreshes(st *state.State) (map[string]*stagedRefresh, error) {
+	staged, _, err := loadStagedRefreshes(st)
+	return staged, err
+}
+
+// loadStagedRefreshes is like stagedRefreshes but also returns the pending
+// refreshes that were dropped.
+func loadStagedRefreshes(st *state.State) (staged map[string]*stagedRefresh, dropped []*stagedRefresh, err error) {
+	err = st.Get("pending-refreshes", &staged)
+	if err != nil && err != state.ErrNoState {
+		return nil, nil, err
+	}
+
+	for instanceName, sr := range staged {
+		var snapst SnapState
+		err := Get(st, instanceName, &snapst)
+		if err != nil && err != state.ErrNoState {
+			return nil, nil, err
+		}
+		if !snapst.IsInstalled() || snapst.Current == sr.Candidate.Revision() {
+			delete(staged, instanceName)
+			dropped = append(dropped, sr)
+		}
+	}
+	return staged, dropped, nil
+}
+
+// pruneStagedRefreshes is like stagedRefreshes but also forgets about the
+// dropped pending refreshes in the state, removing their downloaded snap
+// files.
+func pruneStagedRefreshes(st *state.State) (map[string]*stagedRefresh, error) {
+	staged, dropped, err := loadStagedRefreshes(st)
+	if err != nil {
+		return nil, err
+	}
+	if len(dropped) == 0 {
+		return staged, nil
+	}
+	for _, sr := range dropped {
+		if err := discardStagedSnapFile(st, sr); err != nil {
+			return nil, err
+		}
+	}
+	st.Set("pending-refreshes", staged)
+	return staged, nil
+}
+
+// discardStagedSnapFile removes the snap file downloaded for the given
+// pending refresh, unless its revision is part of the snap sequence and so
+// the file is in use.
+func discardStagedSnapFile(st *state.State, sr *stagedRefresh) error {
+	var snapst SnapState
+	err := Get(st, sr.Candidate.InstanceName(), &snapst)
+	if err != nil && err != state.ErrNoState {
+		return err
+	}
+	if snapst.LastIndex(sr.Candidate.Revision()) >= 0 {
+		return nil
+	}
+	if err := os.Remove(sr.Candidate.MountFile()); err != nil && !os.IsNotExist(err) {
+		logger.Noticef("cannot remove staged snap file: %v", err)
+	}
+	return nil
+}
+
+// PendingRefreshInfo returns the pending refresh of the given snap or nil if
+// there is none.
+func PendingRefreshInfo(st *state.State, instanceName string) (*PendingRefresh, error) {
+	staged, err := stagedRefreshes(st)
+	if err != nil {
+		return nil, err
+	}
+	sr := staged[instanceName]
+	if sr == nil {
+		return nil, nil
+	}
+	return &PendingRefresh{
+		Revision:   sr.Candidate.Revision(),
+		Version:    sr.Candidate.Version,
+		Channel:    sr.Candidate.Channel,
+		StagedTime: sr.StagedTime,
+	}, nil
+}
+
+// StageRefreshes downloads the available updates of the given snaps, or of
+// all snaps if none are given, without applying them. Once downloaded the
+// new revisions are recorded as pending refreshes to be applied with
+// ApplyPendingRefreshes.
+func StageRefreshes(ctx context.Context, st *state.State, names []string) ([]string, []*state.TaskSet, error) {
+	userID := 0
+
+	if AutoRefreshAssertions != nil {
+		if err := AutoRefreshAssertions(st, userID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	user, err := userFromUserID(st, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshOpts := &store.RefreshOptions{IsAutoRefresh: true}
+	candidates, snapstateByInstance, ignoreValidationByInstanceName, err := refreshCandidates(ctx, st, names, user, refreshOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	deviceCtx, err := DeviceCtxFromState(st, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	hints, err := refreshHintsFromCandidates(st, candidates, ignoreValidationByInstanceName, deviceCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	st.Set("refresh-candidates", hints)
+
+	staged, err := pruneStagedRefreshes(st)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stagedNames []string
+	var tasksets []*state.TaskSet
+	for _, up := range candidates {
+		instanceName := up.InstanceName()
+		cand := hints[instanceName]
+		if cand == nil {
+			// filtered out by refreshHintsFromCandidates
+			continue
+		}
+		if sr := staged[instanceName]; sr != nil && sr.Candidate.Revision() == cand.Revision() {
+			// already waiting for approval
+			continue
+		}
+		if err := checkChangeConflictIgnoringOneChange(st, instanceName, snapstateByInstance[instanceName], ""); err != nil {
+			logger.Noticef("cannot stage refresh of snap %q: %v", instanceName, err)
+			continue
+		}
+
+		snapsup := cand.SnapSetup
+		revisionStr := fmt.Sprintf(" (%s)", snapsup.Revision())
+		download := st.NewTask("download-snap", fmt.Sprintf(i18n.G("Download snap %q%s from channel %q"), instanceName, revisionStr, snapsup.Channel))
+		download.Set("snap-setup", &snapsup)
+		stage := st.NewTask("stage-refresh", fmt.Sprintf(i18n.G("Record pending refresh of snap %q%s"), instanceName, revisionStr))
+		stage.Set("snap-setup-task", download.ID())
+		stage.Set("version", cand.Version)
+		stage.WaitFor(download)
+
+		ts := state.NewTaskSet(download, stage)
+		ts.JoinLane(st.NewLane())
+		tasksets = append(tasksets, ts)
+		stagedNames = append(stagedNames, instanceName)
+	}
+
+	sort.Strings(stagedNames)
+	return stagedNames, tasksets, nil
+}
+
+func (m *SnapManager) doStageRefresh(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	snapsup, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	var version string
+	if err := t.Get("version", &version); err != nil && err != state.ErrNoState {
+		return err
+	}
+
+	staged, err := pruneStagedRefreshes(st)
+	if err != nil {
+		return err
+	}
+	if staged == nil {
+		staged = make(map[string]*stagedRefresh)
+	}
+	if prev := staged[snapsup.InstanceName()]; prev != nil && prev.Candidate.Revision() != snapsup.Revision() {
+		// replaced by a newer revision
+		if err := discardStagedSnapFile(st, prev); err != nil {
+			return err
+		}
+	}
+	staged[snapsup.InstanceName()] = &stagedRefresh{
+		Candidate: &refreshCandidate{
+			SnapSetup: *snapsup,
+			Version:   version,
+		},
+		StagedTime: timeNow(),
+	}
+	st.Set("pending-refreshes", staged)
+	return nil
+}
+
+// ApplyPendingRefreshes creates the tasks to refresh the given snaps, or all
+// snaps if none are given, to the revisions staged for them by auto-refreshes
+// awaiting approval.
+func ApplyPendingRefreshes(ctx context.Context, st *state.State, names []string, userID int) ([]string, []*state.TaskSet, error) {
+	staged, err := stagedRefreshes(st)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(names) == 0 {
+		for instanceName := range staged {
+			names = append(names, instanceName)
+		}
+		sort.Strings(names)
+	}
+
+	toUpdate := make([]minimalInstallInfo, 0, len(names))
+	for _, instanceName := range names {
+		sr := staged[instanceName]
+		if sr == nil {
+			return nil, nil, fmt.Errorf("snap %q has no pending refresh", instanceName)
+		}
+		// the refresh is now operator initiated
+		cand := *sr.Candidate
+		cand.Flags.IsAutoRefresh = false
+		toUpdate = append(toUpdate, &cand)
+	}
+	if len(toUpdate) == 0 {
+		return nil, nil, nil
+	}
+
+	deviceCtx, err := DeviceCtxFromState(st, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	flags := &Flags{}
+	updated, tasksets, err := doUpdate(ctx, st, nil, toUpdate, nil, userID, flags, deviceCtx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tasksets = finalizeUpdate(st, tasksets, len(updated) > 0, updated, userID, flags)
+	return updated, tasksets, nil
+}
+
+func stageRefreshSummary(staged []string) string {
+	switch len(staged) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf(i18n.G("Stage auto-refresh of snap %q for approval"), staged[0])
+	case 2, 3:
+		// TRANSLATORS: the %s is a comma-separated list of quoted snap names
+		return fmt.Sprintf(i18n.G("Stage auto-refresh of snaps %s for approval"), strutil.Quoted(staged))
+	default:
+		return fmt.Sprintf(i18n.G("Stage auto-refresh of %d snaps for approval"), len(staged))
+	}
+}
@@ -0,0 +1,227 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (s *snapmgrTestSuite) stageSomeSnapRefresh(c *C) {
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(1)},
+		},
+		Current:         snap.R(1),
+		SnapType:        "app",
+		TrackingChannel: "latest/stable",
+	})
+
+	staged, tss, err := snapstate.StageRefreshes(context.Background(), s.state, nil)
+	c.Assert(err, IsNil)
+	c.Check(staged, DeepEquals, []string{"some-snap"})
+	c.Assert(tss, HasLen, 1)
+	c.Check(taskKinds(tss[0].Tasks()), DeepEquals, []string{"download-snap", "stage-refresh"})
+
+	chg := s.state.NewChange("auto-refresh", "...")
+	chg.AddAll(tss[0])
+
+	s.state.Unlock()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Assert(chg.Err(), IsNil)
+	c.Assert(chg.Status(), Equals, state.DoneStatus)
+}
+
+func (s *snapmgrTestSuite) TestStageRefreshes(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	restore := snapstate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	defer s.se.Stop()
+
+	s.stageSomeSnapRefresh(c)
+
+	// the snap itself was not refreshed
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Current, Equals, snap.R(1))
+
+	pending, err := snapstate.PendingRefreshInfo(s.state, "some-snap")
+	c.Assert(err, IsNil)
+	c.Check(pending, DeepEquals, &snapstate.PendingRefresh{
+		Revision:   snap.R(11),
+		Version:    "some-snap",
+		Channel:    "latest/stable",
+		StagedTime: now,
+	})
+
+	// already staged revisions are not staged again
+	staged, tss, err := snapstate.StageRefreshes(context.Background(), s.state, nil)
+	c.Assert(err, IsNil)
+	c.Check(staged, HasLen, 0)
+	c.Check(tss, HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestApplyPendingRefreshes(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	defer s.se.Stop()
+
+	s.stageSomeSnapRefresh(c)
+
+	updated, tss, err := snapstate.ApplyPendingRefreshes(context.Background(), s.state, nil, 0)
+	c.Assert(err, IsNil)
+	c.Check(updated, DeepEquals, []string{"some-snap"})
+	c.Assert(tss, HasLen, 2)
+	verifyLastTasksetIsReRefresh(c, tss)
+
+	snapsup, err := snapstate.TaskSnapSetup(tss[0].Tasks()[0])
+	c.Assert(err, IsNil)
+	c.Check(snapsup.Revision(), Equals, snap.R(11))
+	// approved refreshes are not auto-refreshes anymore
+	checkIsAutoRefresh(c, tss[0].Tasks(), false)
+}
+
+func (s *snapmgrTestSuite) TestApplyPendingRefreshesNoPending(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	updated, tss, err := snapstate.ApplyPendingRefreshes(context.Background(), s.state, nil, 0)
+	c.Assert(err, IsNil)
+	c.Check(updated, HasLen, 0)
+	c.Check(tss, HasLen, 0)
+
+	_, _, err = snapstate.ApplyPendingRefreshes(context.Background(), s.state, []string{"some-snap"}, 0)
+	c.Assert(err, ErrorMatches, `snap "some-snap" has no pending refresh`)
+}
+
+func (s *snapmgrTestSuite) TestPendingRefreshDroppedOnceApplied(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	defer s.se.Stop()
+
+	s.stageSomeSnapRefresh(c)
+
+	// pretend the snap got refreshed to the staged revision
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	snapst.Sequence = append(snapst.Sequence, &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(11)})
+	snapst.Current = snap.R(11)
+	snapstate.Set(s.state, "some-snap", &snapst)
+
+	pending, err := snapstate.PendingRefreshInfo(s.state, "some-snap")
+	c.Assert(err, IsNil)
+	c.Check(pending, IsNil)
+}
+
+func mockStagedSnapFile(c *C, instanceName string, rev snap.Revision) string {
+	fn := snap.MountFile(instanceName, rev)
+	c.Assert(os.MkdirAll(filepath.Dir(fn), 0755), IsNil)
+	c.Assert(os.WriteFile(fn, nil, 0644), IsNil)
+	return fn
+}
+
+func (s *snapmgrTestSuite) TestStagedRefreshReplacedRemovesSnapFile(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	defer s.se.Stop()
+
+	s.stageSomeSnapRefresh(c)
+
+	// pretend an older revision was staged before
+	var staged map[string]map[string]interface{}
+	c.Assert(s.state.Get("pending-refreshes", &staged), IsNil)
+	staged["some-snap"]["candidate"].(map[string]interface{})["side-info"].(map[string]interface{})["revision"] = "7"
+	s.state.Set("pending-refreshes", staged)
+	oldFile := mockStagedSnapFile(c, "some-snap", snap.R(7))
+
+	staged2, tss, err := snapstate.StageRefreshes(context.Background(), s.state, nil)
+	c.Assert(err, IsNil)
+	c.Check(staged2, DeepEquals, []string{"some-snap"})
+	c.Assert(tss, HasLen, 1)
+	chg := s.state.NewChange("auto-refresh", "...")
+	chg.AddAll(tss[0])
+
+	s.state.Unlock()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Assert(chg.Err(), IsNil)
+	c.Check(osutil.FileExists(oldFile), Equals, false)
+
+	pending, err := snapstate.PendingRefreshInfo(s.state, "some-snap")
+	c.Assert(err, IsNil)
+	c.Assert(pending, NotNil)
+	c.Check(pending.Revision, Equals, snap.R(11))
+}
+
+func (s *snapmgrTestSuite) TestStagedRefreshOfRemovedSnapRemovesSnapFile(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	defer s.se.Stop()
+
+	s.stageSomeSnapRefresh(c)
+	stagedFile := mockStagedSnapFile(c, "some-snap", snap.R(11))
+
+	snapstate.Set(s.state, "some-snap", nil)
+
+	_, tss, err := snapstate.StageRefreshes(context.Background(), s.state, nil)
+	c.Assert(err, IsNil)
+	c.Check(tss, HasLen, 0)
+	c.Check(osutil.FileExists(stagedFile), Equals, false)
+
+	var staged map[string]interface{}
+	c.Assert(s.state.Get("pending-refreshes", &staged), IsNil)
+	c.Check(staged, HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestStagedRefreshAppliedKeepsSnapFile(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	defer s.se.Stop()
+
+	s.stageSomeSnapRefresh(c)
+	stagedFile := mockStagedSnapFile(c, "some-snap", snap.R(11))
+
+	// pretend the snap got refreshed to the staged revision
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	snapst.Sequence = append(snapst.Sequence, &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(11)})
+	snapst.Current = snap.R(11)
+	snapstate.Set(s.state, "some-snap", &snapst)
+
+	_, _, err := snapstate.StageRefreshes(context.Background(), s.state, nil)
+	c.Assert(err, IsNil)
+	c.Check(osutil.FileExists(stagedFile), Equals, true)
+}
@@ -129,6 +129,9 @@ func (r *refreshHints) Ensure() error {
 	if ok, err := CanAutoRefresh(r.state); err != nil || !ok {
 		return err
 	}
+	if maintenanceHeld(r.state) {
+		return nil
+	}
 
 	needsUpdate, err := r.needsUpdate()
 	if err != nil {
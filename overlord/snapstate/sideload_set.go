@@ -0,0 +1,174 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// InstallPathMany returns the task sets for installing the given snap files
+// together, as a "sideload set". Bases, content providers and
+// default-providers of the snaps which are found among the files are
+// installed before the snaps needing them, so that the set can be installed
+// without reaching the store.
+// Note that the state must be locked by the caller.
+func InstallPathMany(st *state.State, sideInfos []*snap.SideInfo, paths []string, flags Flags) ([]*state.TaskSet, []*snap.Info, error) {
+	if len(sideInfos) != len(paths) {
+		return nil, nil, fmt.Errorf("internal error: %d side infos for %d snap files", len(sideInfos), len(paths))
+	}
+
+	tss := make([]*state.TaskSet, 0, len(paths))
+	infos := make([]*snap.Info, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for i, path := range paths {
+		ts, info, err := InstallPath(st, sideInfos[i], path, "", "", flags)
+		if err != nil {
+			return nil, nil, err
+		}
+		if seen[info.InstanceName()] {
+			return nil, nil, fmt.Errorf("cannot install snap %q more than once", info.InstanceName())
+		}
+		seen[info.InstanceName()] = true
+		tss = append(tss, ts)
+		infos = append(infos, info)
+	}
+
+	deps, err := sideloadSetDependencies(infos)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, ts := range tss {
+		for _, j := range deps[i] {
+			ts.WaitAll(tss[j])
+		}
+		ts.JoinLane(st.NewLane())
+	}
+	return tss, infos, nil
+}
+
+// sideloadSetDependencies returns, for each of the given snaps, the indexes
+// of the snaps among them which need to be installed first. Bases, the core
+// snap for snaps without a base and the snapd snap are hard requirements.
+// Default-providers and providers of matching content slots are soft ones,
+// they are skipped when they would introduce a cycle as the prerequisites
+// handling copes with those being installed concurrently.
+func sideloadSetDependencies(infos []*snap.Info) ([][]int, error) {
+	byName := make(map[string]int, len(infos))
+	for i, info := range infos {
+		byName[info.InstanceName()] = i
+	}
+	deps := make([][]int, len(infos))
+	// dependsOn returns whether i depends on j, directly or not
+	var dependsOn func(i, j int, visited map[int]bool) bool
+	dependsOn = func(i, j int, visited map[int]bool) bool {
+		if visited[i] {
+			return false
+		}
+		visited[i] = true
+		for _, k := range deps[i] {
+			if k == j || dependsOn(k, j, visited) {
+				return true
+			}
+		}
+		return false
+	}
+	addDep := func(i int, name string, hard bool) error {
+		j, ok := byName[name]
+		if !ok || j == i {
+			return nil
+		}
+		for _, k := range deps[i] {
+			if k == j {
+				return nil
+			}
+		}
+		if dependsOn(j, i, make(map[int]bool)) {
+			if hard {
+				return fmt.Errorf("cannot install snaps %q and %q: they depend on each other", infos[i].InstanceName(), name)
+			}
+			return nil
+		}
+		deps[i] = append(deps[i], j)
+		return nil
+	}
+
+	for i, info := range infos {
+		switch info.Type() {
+		case snap.TypeSnapd:
+			continue
+		case snap.TypeOS, snap.TypeBase, snap.TypeKernel, snap.TypeGadget:
+			if err := addDep(i, "snapd", true); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		base := info.Base
+		if base == "" {
+			base = defaultCoreSnapName
+		}
+		for _, name := range []string{"snapd", base} {
+			if err := addDep(i, name, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, info := range infos {
+		for name := range snap.NeededDefaultProviders(info) {
+			if err := addDep(i, name, false); err != nil {
+				return nil, err
+			}
+		}
+		for _, plug := range info.Plugs {
+			if plug.Interface != "content" {
+				continue
+			}
+			tag := contentTag(plug.Attrs, plug.Name)
+			for j, provider := range infos {
+				if j == i || !hasContentSlot(provider, tag) {
+					continue
+				}
+				if err := addDep(i, provider.InstanceName(), false); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return deps, nil
+}
+
+func contentTag(attrs map[string]interface{}, name string) string {
+	if tag, ok := attrs["content"].(string); ok && tag != "" {
+		return tag
+	}
+	return name
+}
+
+func hasContentSlot(info *snap.Info, tag string) bool {
+	for _, slot := range info.Slots {
+		if slot.Interface == "content" && contentTag(slot.Attrs, slot.Name) == tag {
+			return true
+		}
+	}
+	return false
+}
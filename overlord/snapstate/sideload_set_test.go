@@ -0,0 +1,145 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/backend"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+func waitsForAll(t *state.Task, ts *state.TaskSet) bool {
+	waits := make(map[string]bool)
+	for _, wt := range t.WaitTasks() {
+		waits[wt.ID()] = true
+	}
+	for _, prereq := range ts.Tasks() {
+		if !waits[prereq.ID()] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *snapmgrTestSuite) TestInstallPathManyOrdersDependencies(c *C) {
+	snapstate.MockOpenSnapFile(backend.OpenSnapFile)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	paths := []string{
+		makeTestSnap(c, `name: app-snap
+version: 1.0
+base: some-base
+plugs:
+  data:
+    interface: content
+    target: $SNAP/data
+    default-provider: provider-snap
+`),
+		makeTestSnap(c, `name: provider-snap
+version: 1.0
+base: some-base
+slots:
+  data:
+    interface: content
+    read: [$SNAP/data]
+`),
+		makeTestSnap(c, `name: some-base
+version: 1.0
+type: base
+`),
+	}
+	sideInfos := []*snap.SideInfo{
+		{RealName: "app-snap"},
+		{RealName: "provider-snap"},
+		{RealName: "some-base"},
+	}
+
+	tss, infos, err := snapstate.InstallPathMany(s.state, sideInfos, paths, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	c.Assert(tss, HasLen, 3)
+	c.Assert(infos, HasLen, 3)
+	c.Check(infos[0].InstanceName(), Equals, "app-snap")
+
+	app, provider, base := tss[0].Tasks()[0], tss[1].Tasks()[0], tss[2].Tasks()[0]
+	c.Check(waitsForAll(app, tss[1]), Equals, true)
+	c.Check(waitsForAll(app, tss[2]), Equals, true)
+	c.Check(waitsForAll(provider, tss[2]), Equals, true)
+	c.Check(waitsForAll(provider, tss[0]), Equals, false)
+	c.Check(base.WaitTasks(), HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestInstallPathManyMutualContentProviders(c *C) {
+	snapstate.MockOpenSnapFile(backend.OpenSnapFile)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	paths := []string{
+		makeTestSnap(c, `name: snap-a
+version: 1.0
+plugs:
+  b-data:
+    interface: content
+    target: $SNAP/b
+slots:
+  a-data:
+    interface: content
+    read: [$SNAP/a]
+`),
+		makeTestSnap(c, `name: snap-b
+version: 1.0
+plugs:
+  a-data:
+    interface: content
+    target: $SNAP/a
+slots:
+  b-data:
+    interface: content
+    read: [$SNAP/b]
+`),
+	}
+	sideInfos := []*snap.SideInfo{{RealName: "snap-a"}, {RealName: "snap-b"}}
+
+	// content providers are soft dependencies, the cycle is broken
+	tss, _, err := snapstate.InstallPathMany(s.state, sideInfos, paths, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	c.Assert(tss, HasLen, 2)
+	c.Check(waitsForAll(tss[0].Tasks()[0], tss[1]), Equals, true)
+	c.Check(waitsForAll(tss[1].Tasks()[0], tss[0]), Equals, false)
+}
+
+func (s *snapmgrTestSuite) TestInstallPathManyDuplicated(c *C) {
+	snapstate.MockOpenSnapFile(backend.OpenSnapFile)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	path := makeTestSnap(c, `name: some-snap
+version: 1.0
+`)
+	sideInfos := []*snap.SideInfo{{RealName: "some-snap"}, {RealName: "some-snap"}}
+	_, _, err := snapstate.InstallPathMany(s.state, sideInfos, []string{path, path}, snapstate.Flags{})
+	c.Assert(err, ErrorMatches, `cannot install snap "some-snap" more than once`)
+}
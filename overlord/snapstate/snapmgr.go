@@ -98,6 +98,11 @@ type SnapSetup struct {
 	// InstanceKey is set by the user during installation and differs for
 	// each instance of given snap
 	InstanceKey string `json:"instance-key,omitempty"`
+
+	// MigratedDisabledServices are the services that were disabled in
+	// the snap replaced by this one, they are disabled when this snap
+	// gets installed
+	MigratedDisabledServices []string `json:"migrated-disabled-services,omitempty"`
 }
 
 func (snapsup *SnapSetup) InstanceName() string {
@@ -172,6 +177,11 @@ type SnapState struct {
 	// attempted but inhibited because the snap was busy. This value is
 	// reset on each successful refresh.
 	RefreshInhibitedTime *time.Time `json:"refresh-inhibited-time,omitempty"`
+	// RefreshInhibitSkippedTime records the time when an inhibited
+	// refresh was last skipped, which starts a new inhibition window, as
+	// asked by the "skip" refresh inhibition action. This value is reset
+	// on each successful refresh.
+	RefreshInhibitSkippedTime *time.Time `json:"refresh-inhibit-skipped-time,omitempty"`
 
 	// LastRefreshTime records the time when the snap was last refreshed.
 	LastRefreshTime *time.Time `json:"last-refresh-time,omitempty"`
@@ -290,6 +300,9 @@ var AutomaticSnapshot func(st *state.State, instanceName string) (ts *state.Task
 var AutomaticSnapshotExpiration func(st *state.State) (time.Duration, error)
 var EstimateSnapshotSize func(st *state.State, instanceName string, users []string) (uint64, error)
 
+// DowngradeSnapshot allows to hook snapshot manager's DowngradeSnapshot.
+var DowngradeSnapshot func(st *state.State, instanceName string) (setID uint64, ts *state.TaskSet, err error)
+
 func readInfo(name string, si *snap.SideInfo, flags int) (*snap.Info, error) {
 	info, err := snapReadInfo(name, si)
 	if err != nil && flags&errorOnBroken != 0 {
@@ -438,6 +451,8 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 	runner.AddHandler("toggle-snap-flags", m.doToggleSnapFlags, nil)
 	runner.AddHandler("check-rerefresh", m.doCheckReRefresh, nil)
 	runner.AddHandler("conditional-auto-refresh", m.doConditionalAutoRefresh, nil)
+	runner.AddHandler("stage-refresh", m.doStageRefresh, nil)
+	runner.AddHandler("migrate-snap-data", m.doMigrateSnapData, m.undoMigrateSnapData)
 
 	// FIXME: drop the task entirely after a while
 	// (having this wart here avoids yet-another-patch)
@@ -877,6 +892,7 @@ func (m *SnapManager) Ensure() error {
 		m.refreshHints.Ensure(),
 		m.catalogRefresh.Ensure(),
 		m.localInstallCleanup(),
+		m.ensureDiskSpaceGC(),
 	}
 
 	//FIXME: use firstErr helper
@@ -0,0 +1,69 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// SnapPolicy allows to hook getting the snap-policy assertion of the
+// device model, if any, into install and refresh decisions. It gets
+// hooked from devicestate.
+var SnapPolicy func(st *state.State) (*asserts.SnapPolicy, error)
+
+func snapPolicy(st *state.State) (*asserts.SnapPolicy, error) {
+	if SnapPolicy == nil {
+		return nil, nil
+	}
+	return SnapPolicy(st)
+}
+
+// heldRevision returns the revision the snap policy of the device holds
+// the snap at, or an unset revision if the snap is not held.
+func heldRevision(st *state.State, instanceName string) (snap.Revision, error) {
+	policy, err := snapPolicy(st)
+	if err != nil || policy == nil {
+		return snap.Revision{}, err
+	}
+	return snap.R(policy.HeldRevision(snap.InstanceSnap(instanceName))), nil
+}
+
+// checkSnapPolicy checks that the snap policy of the device allows using
+// the given revision of the snap. An unset revision only checks that the
+// snap is not forbidden.
+func checkSnapPolicy(st *state.State, instanceName string, revision snap.Revision) error {
+	policy, err := snapPolicy(st)
+	if err != nil || policy == nil {
+		return err
+	}
+	snapName := snap.InstanceSnap(instanceName)
+	if policy.IsForbidden(snapName) {
+		return fmt.Errorf("cannot use snap %q: forbidden by the device snap policy", snapName)
+	}
+	held := snap.R(policy.HeldRevision(snapName))
+	if !held.Unset() && !revision.Unset() && revision != held {
+		return fmt.Errorf("cannot use revision %s of snap %q: held at revision %s by the device snap policy", revision, snapName, held)
+	}
+	return nil
+}
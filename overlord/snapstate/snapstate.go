@@ -32,6 +32,7 @@ import (
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/gadget"
@@ -220,6 +221,9 @@ func doInstall(st *state.State, snapst *SnapState, snapsup *SnapSetup, flags int
 	if snapsup.InstanceName() == "system" {
 		return nil, fmt.Errorf("cannot install reserved snap name 'system'")
 	}
+	if err := checkSnapPolicy(st, snapsup.InstanceName(), snapsup.Revision()); err != nil {
+		return nil, err
+	}
 	if snapst.IsInstalled() && !snapst.Active {
 		return nil, fmt.Errorf("cannot update disabled snap %q", snapsup.InstanceName())
 	}
@@ -570,6 +574,10 @@ var SetupRemoveHook = func(st *state.State, snapName string) *state.Task {
 	panic("internal error: snapstate.SetupRemoveHook is unset")
 }
 
+var SetupMigrateDataHook = func(st *state.State, snapName string, rev, fromRev snap.Revision) *state.Task {
+	panic("internal error: snapstate.SetupMigrateDataHook is unset")
+}
+
 var CheckHealthHook = func(st *state.State, snapName string, rev snap.Revision) *state.Task {
 	panic("internal error: snapstate.CheckHealthHook is unset")
 }
@@ -947,7 +955,24 @@ func InstallWithDeviceContext(ctx context.Context, st *state.State, name string,
 		return nil, fmt.Errorf("invalid instance name: %v", err)
 	}
 
-	sar, err := installInfo(ctx, st, name, opts, userID, flags, deviceCtx)
+	if err := checkDeviceGroupChannel(st, name, opts.Channel); err != nil {
+		return nil, err
+	}
+
+	if err := checkSnapPolicy(st, name, opts.Revision); err != nil {
+		return nil, err
+	}
+	revOpts := opts
+	held, err := heldRevision(st, name)
+	if err != nil {
+		return nil, err
+	}
+	if !held.Unset() {
+		// install the revision the device snap policy holds the snap at
+		revOpts = &RevisionOptions{Channel: opts.Channel, Revision: held}
+	}
+
+	sar, err := installInfo(ctx, st, name, revOpts, userID, flags, deviceCtx)
 	if err != nil {
 		return nil, err
 	}
@@ -1037,6 +1062,10 @@ func InstallMany(st *state.State, names []string, userID int) ([]string, []*stat
 			return nil, nil, fmt.Errorf("invalid instance name: %v", err)
 		}
 
+		if err := checkSnapPolicy(st, name, snap.Revision{}); err != nil {
+			return nil, nil, err
+		}
+
 		toInstall = append(toInstall, name)
 	}
 
@@ -1515,6 +1544,17 @@ func autoAliasesUpdate(st *state.State, names []string, updates []minimalInstall
 // channel and constrains set by device model, or an error if switching to
 // requested channel is forbidden.
 func resolveChannel(st *state.State, snapName, oldChannel, newChannel string, deviceCtx DeviceContext) (effectiveChannel string, err error) {
+	effectiveChannel, err = resolveModelChannel(snapName, oldChannel, newChannel, deviceCtx)
+	if err != nil || effectiveChannel == "" {
+		return effectiveChannel, err
+	}
+	if err := checkDeviceGroupChannel(st, snapName, effectiveChannel); err != nil {
+		return "", err
+	}
+	return effectiveChannel, nil
+}
+
+func resolveModelChannel(snapName, oldChannel, newChannel string, deviceCtx DeviceContext) (effectiveChannel string, err error) {
 	if newChannel == "" {
 		return "", nil
 	}
@@ -1719,6 +1759,10 @@ func UpdateWithDeviceContext(st *state.State, name string, opts *RevisionOptions
 		return nil, err
 	}
 
+	if err := checkSnapPolicy(st, name, opts.Revision); err != nil {
+		return nil, err
+	}
+
 	if opts.Channel == "" {
 		// default to tracking the same channel
 		opts.Channel = snapst.TrackingChannel
@@ -1898,6 +1942,12 @@ var AutoRefreshAssertions func(st *state.State, userID int) error
 // snaps on the system. In addition to that it will also refresh important
 // assertions.
 func AutoRefresh(ctx context.Context, st *state.State) ([]string, []*state.TaskSet, error) {
+	return autoRefreshSnaps(ctx, st, nil)
+}
+
+// autoRefreshSnaps is like AutoRefresh but limited to the given snaps if
+// names is not empty.
+func autoRefreshSnaps(ctx context.Context, st *state.State, names []string) ([]string, []*state.TaskSet, error) {
 	userID := 0
 
 	if AutoRefreshAssertions != nil {
@@ -1913,11 +1963,11 @@ func AutoRefresh(ctx context.Context, st *state.State) ([]string, []*state.TaskS
 	}
 	if !gateAutoRefreshHook {
 		// old-style refresh (gate-auto-refresh-hook feature disabled)
-		return UpdateMany(ctx, st, nil, userID, &Flags{IsAutoRefresh: true})
+		return UpdateMany(ctx, st, names, userID, &Flags{IsAutoRefresh: true})
 	}
 
 	// TODO: rename to autoRefreshTasks when old auto refresh logic gets removed.
-	return autoRefreshPhase1(ctx, st, "")
+	return autoRefreshPhase1ForSnaps(ctx, st, "", names)
 }
 
 // autoRefreshPhase1 creates gate-auto-refresh hooks and conditional-auto-refresh
@@ -1925,6 +1975,13 @@ func AutoRefresh(ctx context.Context, st *state.State) ([]string, []*state.TaskS
 // to the snaps affecting the given snap only; it defaults to all snaps if nil.
 // The state needs to be locked by the caller.
 func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap string) ([]string, []*state.TaskSet, error) {
+	return autoRefreshPhase1ForSnaps(ctx, st, forGatingSnap, nil)
+}
+
+// autoRefreshPhase1ForSnaps is like autoRefreshPhase1 but only refreshes the
+// given snaps if onlySnaps is not empty, the refresh candidates of all snaps
+// are still recorded.
+func autoRefreshPhase1ForSnaps(ctx context.Context, st *state.State, forGatingSnap string, onlySnaps []string) ([]string, []*state.TaskSet, error) {
 	user, err := userFromUserID(st, 0)
 	if err != nil {
 		return nil, nil, err
@@ -1962,6 +2019,9 @@ func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap strin
 			// filtered out by refreshHintsFromCandidates
 			continue
 		}
+		if len(onlySnaps) > 0 && !strutil.ListContains(onlySnaps, up.InstanceName()) {
+			continue
+		}
 
 		snapst := snapstateByInstance[up.InstanceName()]
 		if err := checkChangeConflictIgnoringOneChange(st, up.InstanceName(), snapst, fromChange); err != nil {
@@ -2303,6 +2363,17 @@ func canRemove(st *state.State, si *snap.Info, snapst *SnapState, removeAll bool
 type RemoveFlags struct {
 	// Remove the snap without creating snapshot data
 	Purge bool
+	// Transaction specifies whether RemoveMany removes the snaps
+	// independently or all of them or none at all.
+	Transaction client.TransactionType
+	// CascadeContent makes a transactional RemoveMany also remove the
+	// snaps consuming content from the removed snaps instead of
+	// refusing to remove the providers.
+	CascadeContent bool
+
+	// skipPolicyCheck is set when removing snaps replaced by other
+	// snaps, they are allowed to go even if required by the model
+	skipPolicyCheck bool
 }
 
 // Remove returns a set of tasks for removing snap.
@@ -2380,8 +2451,10 @@ func removeTasks(st *state.State, name string, revision snap.Revision, flags *Re
 	}
 
 	// check if this is something that can be removed
-	if err := canRemove(st, info, &snapst, removeAll, deviceCtx); err != nil {
-		return nil, 0, fmt.Errorf("snap %q is not removable: %v", name, err)
+	if flags == nil || !flags.skipPolicyCheck {
+		if err := canRemove(st, info, &snapst, removeAll, deviceCtx); err != nil {
+			return nil, 0, fmt.Errorf("snap %q is not removable: %v", name, err)
+		}
 	}
 
 	// main/current SnapSetup
@@ -2524,15 +2597,31 @@ func removeInactiveRevision(st *state.State, name, snapID string, revision snap.
 
 // RemoveMany removes everything from the given list of names.
 // Note that the state must be locked by the caller.
-func RemoveMany(st *state.State, names []string) ([]string, []*state.TaskSet, error) {
+func RemoveMany(st *state.State, names []string, flags *RemoveFlags) ([]string, []*state.TaskSet, error) {
+	if flags == nil {
+		flags = &RemoveFlags{}
+	}
+	transactional := flags.Transaction == client.TransactionAllSnaps
+	if transactional {
+		var err error
+		names, err = contentRemovalSet(st, names, flags.CascadeContent)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	removed := make([]string, 0, len(names))
 	tasksets := make([]*state.TaskSet, 0, len(names))
 
 	var totalSnapshotsSize uint64
 	path := dirs.SnapdStateDir(dirs.GlobalRootDir)
 
+	var transactionLane int
+	if transactional {
+		transactionLane = st.NewLane()
+	}
 	for _, name := range names {
-		ts, snapshotSize, err := removeTasks(st, name, snap.R(0), nil)
+		ts, snapshotSize, err := removeTasks(st, name, snap.R(0), &RemoveFlags{Purge: flags.Purge})
 		// FIXME: is this expected behavior?
 		if _, ok := err.(*snap.NotInstalledError); ok {
 			continue
@@ -2542,7 +2631,11 @@ func RemoveMany(st *state.State, names []string) ([]string, []*state.TaskSet, er
 		}
 		totalSnapshotsSize += snapshotSize
 		removed = append(removed, name)
-		ts.JoinLane(st.NewLane())
+		if transactional {
+			ts.JoinLane(transactionLane)
+		} else {
+			ts.JoinLane(st.NewLane())
+		}
 		tasksets = append(tasksets, ts)
 	}
 
@@ -2562,9 +2655,76 @@ func RemoveMany(st *state.State, names []string) ([]string, []*state.TaskSet, er
 		}
 	}
 
+	if transactional {
+		deferIrreversibleRemoveTasks(tasksets)
+	}
+
 	return removed, tasksets, nil
 }
 
+// irreversibleRemoveTasks are the kinds of the removal tasks that cannot be
+// undone.
+var irreversibleRemoveTasks = map[string]bool{
+	"clear-snap":   true,
+	"discard-snap": true,
+}
+
+// deferIrreversibleRemoveTasks makes the removal tasks that cannot be undone
+// wait for all the other tasks of a transactional removal, so that a failure
+// removing any of the snaps can still be undone for all of them.
+func deferIrreversibleRemoveTasks(tasksets []*state.TaskSet) {
+	var reversible, irreversible []*state.Task
+	for _, ts := range tasksets {
+		for _, t := range ts.Tasks() {
+			if irreversibleRemoveTasks[t.Kind()] {
+				irreversible = append(irreversible, t)
+			} else {
+				reversible = append(reversible, t)
+			}
+		}
+	}
+	for _, t := range irreversible {
+		t.WaitAll(state.NewTaskSet(reversible...))
+	}
+}
+
+// contentRemovalSet checks the content interface connections of the snaps
+// to remove, refusing to remove snaps providing content to snaps not being
+// removed or, if cascade is set, adding those consumers to the snaps to
+// remove.
+func contentRemovalSet(st *state.State, names []string, cascade bool) ([]string, error) {
+	toRemove := make(map[string]bool, len(names))
+	for _, name := range names {
+		toRemove[name] = true
+	}
+
+	removalSet := append([]string(nil), names...)
+	for i := 0; i < len(removalSet); i++ {
+		provider := removalSet[i]
+		consumers, err := ContentConsumers(st, provider)
+		if err != nil {
+			return nil, err
+		}
+		var remaining []string
+		for _, consumer := range consumers {
+			if !toRemove[consumer] {
+				remaining = append(remaining, consumer)
+			}
+		}
+		if len(remaining) == 0 {
+			continue
+		}
+		if !cascade {
+			return nil, fmt.Errorf("cannot remove snap %q: snaps %s consume its content and are not being removed", provider, strutil.Quoted(remaining))
+		}
+		for _, consumer := range remaining {
+			toRemove[consumer] = true
+			removalSet = append(removalSet, consumer)
+		}
+	}
+	return removalSet, nil
+}
+
 // Revert returns a set of tasks for reverting to the previous version of the snap.
 // Note that the state must be locked by the caller.
 func Revert(st *state.State, name string, flags Flags) (*state.TaskSet, error) {
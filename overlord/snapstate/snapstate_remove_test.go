@@ -27,6 +27,7 @@ import (
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/configstate/config"
@@ -1200,7 +1201,7 @@ func (s *snapmgrTestSuite) TestRemoveMany(c *C) {
 		Current: snap.R(1),
 	})
 
-	removed, tts, err := snapstate.RemoveMany(s.state, []string{"one", "two"})
+	removed, tts, err := snapstate.RemoveMany(s.state, []string{"one", "two"}, nil)
 	c.Assert(err, IsNil)
 	c.Assert(tts, HasLen, 2)
 	c.Check(removed, DeepEquals, []string{"one", "two"})
@@ -1226,6 +1227,104 @@ func (s *snapmgrTestSuite) TestRemoveMany(c *C) {
 	}
 }
 
+func (s *snapmgrTestSuite) setupRemoveManyContentSnaps(c *C) {
+	for _, name := range []string{"provider", "consumer", "other"} {
+		snapstate.Set(s.state, name, &snapstate.SnapState{
+			Active: true,
+			Sequence: []*snap.SideInfo{
+				{RealName: name, SnapID: name + "-id", Revision: snap.R(1)},
+			},
+			Current: snap.R(1),
+		})
+	}
+}
+
+func (s *snapmgrTestSuite) mockContentConsumers(c *C) {
+	s.AddCleanup(snapstate.MockContentConsumers(func(st *state.State, instanceName string) ([]string, error) {
+		if instanceName == "provider" {
+			return []string{"consumer"}, nil
+		}
+		return nil, nil
+	}))
+}
+
+func (s *snapmgrTestSuite) TestRemoveManyTransactional(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupRemoveManyContentSnaps(c)
+	s.mockContentConsumers(c)
+
+	removed, tts, err := snapstate.RemoveMany(s.state, []string{"provider", "consumer", "other"}, &snapstate.RemoveFlags{Transaction: client.TransactionAllSnaps})
+	c.Assert(err, IsNil)
+	c.Check(removed, DeepEquals, []string{"provider", "consumer", "other"})
+	c.Assert(tts, HasLen, 3)
+
+	var reversible []*state.Task
+	var irreversible []*state.Task
+	for _, ts := range tts {
+		for _, t := range ts.Tasks() {
+			// all tasksets share the same lane
+			c.Assert(t.Lanes(), DeepEquals, []int{1})
+			if t.Kind() == "clear-snap" || t.Kind() == "discard-snap" {
+				irreversible = append(irreversible, t)
+			} else {
+				reversible = append(reversible, t)
+			}
+		}
+	}
+	c.Assert(irreversible, HasLen, 6)
+	// nothing is removed for good before all snaps got unlinked
+	for _, t := range irreversible {
+		for _, r := range reversible {
+			c.Check(t.WaitTasks(), testutil.DeepContains, r)
+		}
+	}
+}
+
+func (s *snapmgrTestSuite) TestRemoveManyTransactionalRefusesContentProvider(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupRemoveManyContentSnaps(c)
+	s.mockContentConsumers(c)
+
+	_, _, err := snapstate.RemoveMany(s.state, []string{"provider", "other"}, &snapstate.RemoveFlags{Transaction: client.TransactionAllSnaps})
+	c.Assert(err, ErrorMatches, `cannot remove snap "provider": snaps "consumer" consume its content and are not being removed`)
+	c.Check(s.state.TaskCount(), Equals, 0)
+}
+
+func (s *snapmgrTestSuite) TestRemoveManyTransactionalCascadeContent(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupRemoveManyContentSnaps(c)
+	s.mockContentConsumers(c)
+
+	removed, tts, err := snapstate.RemoveMany(s.state, []string{"provider"}, &snapstate.RemoveFlags{
+		Transaction:    client.TransactionAllSnaps,
+		CascadeContent: true,
+	})
+	c.Assert(err, IsNil)
+	c.Check(removed, DeepEquals, []string{"provider", "consumer"})
+	c.Check(tts, HasLen, 2)
+}
+
+func (s *snapmgrTestSuite) TestRemoveManyPerSnapIgnoresContent(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setupRemoveManyContentSnaps(c)
+	s.AddCleanup(snapstate.MockContentConsumers(func(st *state.State, instanceName string) ([]string, error) {
+		c.Fatalf("unexpected call")
+		return nil, nil
+	}))
+
+	removed, _, err := snapstate.RemoveMany(s.state, []string{"provider"}, &snapstate.RemoveFlags{Transaction: client.TransactionPerSnap})
+	c.Assert(err, IsNil)
+	c.Check(removed, DeepEquals, []string{"provider"})
+}
+
 func (s *snapmgrTestSuite) testRemoveManyDiskSpaceCheck(c *C, featureFlag, automaticSnapshot, freeSpaceCheckFail bool) error {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -1291,7 +1390,7 @@ func (s *snapmgrTestSuite) testRemoveManyDiskSpaceCheck(c *C, featureFlag, autom
 		Current: snap.R(1),
 	})
 
-	_, _, err := snapstate.RemoveMany(s.state, []string{"one", "two"})
+	_, _, err := snapstate.RemoveMany(s.state, []string{"one", "two"}, nil)
 	if featureFlag && automaticSnapshot {
 		c.Check(snapshotSizeCall, Equals, 2)
 		c.Check(checkFreeSpaceCall, Equals, 1)
@@ -122,12 +122,14 @@ func (s *snapmgrTestSuite) SetUpTest(c *C) {
 	oldSetupPreRefreshHook := snapstate.SetupPreRefreshHook
 	oldSetupPostRefreshHook := snapstate.SetupPostRefreshHook
 	oldSetupRemoveHook := snapstate.SetupRemoveHook
+	oldSetupMigrateDataHook := snapstate.SetupMigrateDataHook
 	oldSnapServiceOptions := snapstate.SnapServiceOptions
 	oldEnsureSnapAbsentFromQuotaGroup := snapstate.EnsureSnapAbsentFromQuotaGroup
 	snapstate.SetupInstallHook = hookstate.SetupInstallHook
 	snapstate.SetupPreRefreshHook = hookstate.SetupPreRefreshHook
 	snapstate.SetupPostRefreshHook = hookstate.SetupPostRefreshHook
 	snapstate.SetupRemoveHook = hookstate.SetupRemoveHook
+	snapstate.SetupMigrateDataHook = hookstate.SetupMigrateDataHook
 	snapstate.SnapServiceOptions = servicestate.SnapServiceOptions
 	snapstate.EnsureSnapAbsentFromQuotaGroup = servicestate.EnsureSnapAbsentFromQuota
 
@@ -160,6 +162,7 @@ func (s *snapmgrTestSuite) SetUpTest(c *C) {
 		snapstate.SetupPreRefreshHook = oldSetupPreRefreshHook
 		snapstate.SetupPostRefreshHook = oldSetupPostRefreshHook
 		snapstate.SetupRemoveHook = oldSetupRemoveHook
+		snapstate.SetupMigrateDataHook = oldSetupMigrateDataHook
 		snapstate.SnapServiceOptions = oldSnapServiceOptions
 		snapstate.EnsureSnapAbsentFromQuotaGroup = oldEnsureSnapAbsentFromQuotaGroup
 
@@ -269,6 +272,7 @@ func AddForeignTaskHandlers(runner *state.TaskRunner, tracker ForeignTaskTracker
 	runner.AddHandler("validate-snap", fakeHandler, nil)
 	runner.AddHandler("transition-ubuntu-core", fakeHandler, nil)
 	runner.AddHandler("transition-to-snapd-snap", fakeHandler, nil)
+	runner.AddHandler("transfer-connections", fakeHandler, nil)
 
 	// Add handler to test full aborting of changes
 	erroringHandler := func(task *state.Task, _ *tomb.Tomb) error {
@@ -6053,6 +6057,199 @@ func (s *snapmgrTestSuite) TestResolveChannelPinnedTrack(c *C) {
 	}
 }
 
+func (s *snapmgrTestSuite) TestResolveChannelDeviceGroups(c *C) {
+	groups := []*asserts.DeviceGroup{
+		MakeDeviceGroup("fleet-a", map[string]interface{}{
+			"allowed-channels": []interface{}{"stable", "candidate"},
+		}),
+		MakeDeviceGroup("fleet-b", map[string]interface{}{
+			"allowed-channels": []interface{}{"stable", "2.0/candidate"},
+		}),
+		MakeDeviceGroup("fleet-c", nil),
+	}
+	snapstate.DeviceGroups = func(st *state.State) ([]*asserts.DeviceGroup, error) {
+		return groups, nil
+	}
+	defer func() { snapstate.DeviceGroups = nil }()
+
+	deviceCtx := &snapstatetest.TrivialDeviceContext{DeviceModel: DefaultModel()}
+	for _, tc := range []struct {
+		cur, new, exp, err string
+	}{
+		{new: ""},
+		{new: "stable", exp: "stable"},
+		{new: "stable/hotfix", exp: "stable/hotfix"},
+		{new: "2.0/candidate", exp: "2.0/candidate"},
+		{new: "candidate", cur: "2.0/stable", exp: "2.0/candidate"},
+		{new: "candidate", err: `cannot use channel "candidate" for snap "some-snap": not allowed for device group "fleet-b"`},
+		{new: "edge", err: `cannot use channel "edge" for snap "some-snap": not allowed for device group "fleet-a"`},
+	} {
+		s.state.Lock()
+		ch, err := snapstate.ResolveChannel(s.state, "some-snap", tc.cur, tc.new, deviceCtx)
+		s.state.Unlock()
+		comment := Commentf("%#v", tc)
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err, comment)
+		} else {
+			c.Check(err, IsNil, comment)
+			c.Check(ch, Equals, tc.exp, comment)
+		}
+	}
+}
+
+func (s *snapmgrTestSuite) TestInstallChannelNotAllowedForDeviceGroup(c *C) {
+	snapstate.DeviceGroups = func(st *state.State) ([]*asserts.DeviceGroup, error) {
+		return []*asserts.DeviceGroup{
+			MakeDeviceGroup("fleet-a", map[string]interface{}{
+				"allowed-channels": []interface{}{"stable"},
+			}),
+		}, nil
+	}
+	defer func() { snapstate.DeviceGroups = nil }()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	opts := &snapstate.RevisionOptions{Channel: "edge"}
+	_, err := snapstate.Install(context.Background(), s.state, "some-snap", opts, 0, snapstate.Flags{})
+	c.Assert(err, ErrorMatches, `cannot use channel "edge" for snap "some-snap": not allowed for device group "fleet-a"`)
+}
+
+func (s *snapmgrTestSuite) mockSnapPolicy(override map[string]interface{}) {
+	policy := MakeSnapPolicy(override)
+	snapstate.SnapPolicy = func(st *state.State) (*asserts.SnapPolicy, error) {
+		return policy, nil
+	}
+	s.AddCleanup(func() { snapstate.SnapPolicy = nil })
+}
+
+func (s *snapmgrTestSuite) TestInstallForbiddenBySnapPolicy(c *C) {
+	s.mockSnapPolicy(map[string]interface{}{
+		"forbidden-snaps": []interface{}{"some-snap"},
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := snapstate.Install(context.Background(), s.state, "some-snap", nil, 0, snapstate.Flags{})
+	c.Assert(err, ErrorMatches, `cannot use snap "some-snap": forbidden by the device snap policy`)
+	_, err = snapstate.Install(context.Background(), s.state, "some-snap_foo", nil, 0, snapstate.Flags{})
+	c.Assert(err, ErrorMatches, `cannot use snap "some-snap": forbidden by the device snap policy`)
+	_, _, err = snapstate.InstallMany(s.state, []string{"some-other-snap", "some-snap"}, 0)
+	c.Assert(err, ErrorMatches, `cannot use snap "some-snap": forbidden by the device snap policy`)
+	c.Check(s.fakeBackend.ops.Count("storesvc-snap-action:action"), Equals, 0)
+}
+
+func (s *snapmgrTestSuite) TestInstallHeldBySnapPolicy(c *C) {
+	s.mockSnapPolicy(map[string]interface{}{
+		"held-snaps": []interface{}{
+			map[string]interface{}{"name": "some-snap", "revision": "7"},
+		},
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	opts := &snapstate.RevisionOptions{Revision: snap.R(11)}
+	_, err := snapstate.Install(context.Background(), s.state, "some-snap", opts, 0, snapstate.Flags{})
+	c.Assert(err, ErrorMatches, `cannot use revision 11 of snap "some-snap": held at revision 7 by the device snap policy`)
+
+	opts = &snapstate.RevisionOptions{Channel: "some-channel"}
+	ts, err := snapstate.Install(context.Background(), s.state, "some-snap", opts, 0, snapstate.Flags{})
+	c.Assert(err, IsNil)
+
+	op := s.fakeBackend.ops.MustFindOp(c, "storesvc-snap-action:action")
+	c.Check(op.action, DeepEquals, store.SnapAction{
+		Action:       "install",
+		InstanceName: "some-snap",
+		Revision:     snap.R(7),
+	})
+	c.Check(op.revno, Equals, snap.R(7))
+
+	// the channel to track is unaffected
+	snapsup, err := snapstate.TaskSnapSetup(ts.Tasks()[0])
+	c.Assert(err, IsNil)
+	c.Check(snapsup.Channel, Equals, "some-channel")
+	c.Check(snapsup.Revision(), Equals, snap.R(7))
+}
+
+func (s *snapmgrTestSuite) TestUpdateHeldAtCurrentBySnapPolicy(c *C) {
+	s.mockSnapPolicy(map[string]interface{}{
+		"held-snaps": []interface{}{
+			map[string]interface{}{"name": "some-snap", "revision": "7"},
+		},
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(7)},
+		},
+		Current:         snap.R(7),
+		SnapType:        "app",
+		TrackingChannel: "latest/stable",
+	})
+
+	_, err := snapstate.Update(s.state, "some-snap", nil, 0, snapstate.Flags{})
+	c.Assert(err, Equals, store.ErrNoUpdateAvailable)
+
+	_, err = snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Revision: snap.R(11)}, 0, snapstate.Flags{})
+	c.Assert(err, ErrorMatches, `cannot use revision 11 of snap "some-snap": held at revision 7 by the device snap policy`)
+
+	updates, _, err := snapstate.UpdateMany(context.Background(), s.state, nil, 0, nil)
+	c.Assert(err, IsNil)
+	c.Check(updates, HasLen, 0)
+	c.Check(s.fakeBackend.ops.Count("storesvc-snap-action:action"), Equals, 0)
+}
+
+func (s *snapmgrTestSuite) TestUpdateManySnapPolicy(c *C) {
+	s.mockSnapPolicy(map[string]interface{}{
+		"forbidden-snaps": []interface{}{"some-other-snap"},
+		"held-snaps": []interface{}{
+			map[string]interface{}{"name": "some-snap", "revision": "7"},
+		},
+	})
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(1)},
+		},
+		Current:  snap.R(1),
+		SnapType: "app",
+	})
+	snapstate.Set(s.state, "some-other-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: []*snap.SideInfo{
+			{RealName: "some-other-snap", SnapID: "some-other-snap-id", Revision: snap.R(1)},
+		},
+		Current:  snap.R(1),
+		SnapType: "app",
+	})
+
+	updates, _, err := snapstate.UpdateMany(context.Background(), s.state, nil, 0, nil)
+	c.Assert(err, IsNil)
+	c.Check(updates, DeepEquals, []string{"some-snap"})
+
+	// the forbidden snap is not refreshed, the held one only to its
+	// held revision
+	c.Check(s.fakeBackend.ops.Count("storesvc-snap-action:action"), Equals, 1)
+	op := s.fakeBackend.ops.MustFindOp(c, "storesvc-snap-action:action")
+	c.Check(op.action, DeepEquals, store.SnapAction{
+		Action:       "refresh",
+		SnapID:       "some-snap-id",
+		InstanceName: "some-snap",
+		Revision:     snap.R(7),
+	})
+	c.Check(op.revno, Equals, snap.R(7))
+}
+
 func (s *snapmgrTestSuite) TestGadgetUpdateTaskAddedOnInstall(c *C) {
 	restore := release.MockOnClassic(false)
 	defer restore()
@@ -259,6 +259,18 @@ func updateInfo(st *state.State, snapst *SnapState, opts *RevisionOptions, userI
 		return nil, err
 	}
 
+	if err := checkSnapPolicy(st, curInfo.InstanceName(), snap.Revision{}); err != nil {
+		return nil, err
+	}
+	held, err := heldRevision(st, curInfo.InstanceName())
+	if err != nil {
+		return nil, err
+	}
+	if !held.Unset() && held == curInfo.Revision {
+		// the device snap policy holds the snap at its current revision
+		return nil, store.ErrNoUpdateAvailable
+	}
+
 	var storeFlags store.SnapActionFlags
 	if flags.IgnoreValidation {
 		storeFlags = store.SnapActionIgnoreValidation
@@ -281,6 +293,14 @@ func updateInfo(st *state.State, snapst *SnapState, opts *RevisionOptions, userI
 		action.Epoch = curInfo.Epoch
 	}
 
+	if !held.Unset() {
+		// refresh to the revision the device snap policy holds the
+		// snap at, cannot specify a channel at the same time
+		action.Channel = ""
+		action.CohortKey = ""
+		action.Revision = held
+	}
+
 	theStore := Store(st, deviceCtx)
 	st.Unlock() // calls to the store should be done without holding the state lock
 	res, _, err := theStore.SnapAction(context.TODO(), curSnaps, []*store.SnapAction{action}, nil, user, refreshOpts)
@@ -467,6 +487,20 @@ func refreshCandidates(ctx context.Context, st *state.State, names []string, use
 		fallbackID = user.ID
 	}
 
+	// snaps with their own refresh schedule only auto-refresh when due
+	var gatedBySchedule map[string]bool
+	if len(names) == 0 && opts.IsAutoRefresh {
+		gatedBySchedule, err = autoRefreshGatedBySchedule(st, snapStates)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	policy, err := snapPolicy(st)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	actionsByUserID := make(map[int][]*store.SnapAction)
 	stateByInstanceName := make(map[string]*SnapState, len(snapStates))
 	ignoreValidationByInstanceName := make(map[string]bool)
@@ -488,6 +522,23 @@ func refreshCandidates(ctx context.Context, st *state.State, names []string, use
 			return
 		}
 
+		if gatedBySchedule[installed.InstanceName] {
+			return
+		}
+
+		var held snap.Revision
+		if policy != nil {
+			snapName := snap.InstanceSnap(installed.InstanceName)
+			if policy.IsForbidden(snapName) {
+				return
+			}
+			held = snap.R(policy.HeldRevision(snapName))
+			if !held.Unset() && held == installed.Revision {
+				// held at the current revision
+				return
+			}
+		}
+
 		stateByInstanceName[installed.InstanceName] = snapst
 
 		if len(names) == 0 {
@@ -502,6 +553,8 @@ func refreshCandidates(ctx context.Context, st *state.State, names []string, use
 			Action:       "refresh",
 			SnapID:       installed.SnapID,
 			InstanceName: installed.InstanceName,
+			// unset unless held by the device snap policy
+			Revision: held,
 		})
 		if snapst.IgnoreValidation {
 			ignoreValidationByInstanceName[installed.InstanceName] = true
@@ -584,6 +637,16 @@ func installCandidates(st *state.State, names []string, channel string, user *au
 			// the desired channel
 			Channel: channel,
 		}
+		held, err := heldRevision(st, name)
+		if err != nil {
+			return nil, err
+		}
+		if !held.Unset() {
+			// install the revision the device snap policy holds the
+			// snap at
+			actions[i].Channel = ""
+			actions[i].Revision = held
+		}
 	}
 
 	// TODO: possibly support a deviceCtx
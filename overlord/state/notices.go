@@ -0,0 +1,339 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultNoticeExpireAfter is how long notices are kept after they last
+// occurred.
+var DefaultNoticeExpireAfter = time.Hour * 24 * 7
+
+// NoticeType is the kind of a notice, it determines what the key and the
+// data of the notice mean.
+type NoticeType string
+
+const (
+	// RefreshInhibitNotice is recorded when the refresh of a snap is held
+	// back because some of its apps are running. The key is the instance
+	// name of the snap.
+	RefreshInhibitNotice NoticeType = "refresh-inhibit"
+
+	// ThemeInstallNotice is recorded when themes used by the desktop of a
+	// user are provided by snaps that are not installed. The key lists
+	// the snaps, separated by commas.
+	ThemeInstallNotice NoticeType = "theme-install"
+)
+
+type jsonNotice struct {
+	ID            string            `json:"id"`
+	UserID        *uint32           `json:"user-id,omitempty"`
+	Type          NoticeType        `json:"type"`
+	Key           string            `json:"key"`
+	FirstOccurred time.Time         `json:"first-occurred"`
+	LastOccurred  time.Time         `json:"last-occurred"`
+	Occurrences   int               `json:"occurrences"`
+	Data          map[string]string `json:"data,omitempty"`
+	RequiresAck   bool              `json:"requires-ack,omitempty"`
+	LastAcked     *time.Time        `json:"last-acked,omitempty"`
+	ExpireAfter   string            `json:"expire-after,omitempty"`
+}
+
+// Notice is something that happened which clients, like the desktop
+// session of a user, should be informed about. A notice is either for
+// all users or for a single one. Repeated occurrences of a notice with
+// the same type and key for the same user are recorded in one notice.
+type Notice struct {
+	id string
+	// the user the notice is for, nil for all users
+	userID *uint32
+
+	noticeType NoticeType
+	key        string
+
+	// the first and last time the notice occurred, and how many times
+	firstOccurred time.Time
+	lastOccurred  time.Time
+	occurrences   int
+
+	// structured payload of the last occurrence, meaning depends on type
+	data map[string]string
+
+	// whether the notice needs to be acknowledged by the user, and when
+	// that was last done
+	requiresAck bool
+	lastAcked   time.Time
+
+	// how much time after it last occurred should the notice be dropped
+	expireAfter time.Duration
+}
+
+func (n *Notice) String() string {
+	userID := "public"
+	if n.userID != nil {
+		userID = strconv.FormatUint(uint64(*n.userID), 10)
+	}
+	return fmt.Sprintf("Notice %s (%s:%s:%s)", n.id, userID, n.noticeType, n.key)
+}
+
+// ID returns the unique identifier of the notice.
+func (n *Notice) ID() string {
+	return n.id
+}
+
+// Data returns the payload of the last occurrence of the notice.
+func (n *Notice) Data() map[string]string {
+	return n.data
+}
+
+// Pending returns whether the notice requires acknowledgment and was not
+// acknowledged since it last occurred.
+func (n *Notice) Pending() bool {
+	return n.requiresAck && n.lastAcked.Before(n.lastOccurred)
+}
+
+// VisibleTo returns whether the notice is for all users or for the given
+// one. The root user can see all notices.
+func (n *Notice) VisibleTo(uid uint32) bool {
+	return n.userID == nil || uid == 0 || *n.userID == uid
+}
+
+func (n *Notice) expiredBefore(now time.Time) bool {
+	return n.lastOccurred.Add(n.expireAfter).Before(now)
+}
+
+func (n *Notice) MarshalJSON() ([]byte, error) {
+	jn := jsonNotice{
+		ID:            n.id,
+		UserID:        n.userID,
+		Type:          n.noticeType,
+		Key:           n.key,
+		FirstOccurred: n.firstOccurred,
+		LastOccurred:  n.lastOccurred,
+		Occurrences:   n.occurrences,
+		Data:          n.data,
+		RequiresAck:   n.requiresAck,
+		ExpireAfter:   n.expireAfter.String(),
+	}
+	if !n.lastAcked.IsZero() {
+		jn.LastAcked = &n.lastAcked
+	}
+	return json.Marshal(jn)
+}
+
+func (n *Notice) UnmarshalJSON(data []byte) error {
+	var jn jsonNotice
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return err
+	}
+	n.id = jn.ID
+	n.userID = jn.UserID
+	n.noticeType = jn.Type
+	n.key = jn.Key
+	n.firstOccurred = jn.FirstOccurred
+	n.lastOccurred = jn.LastOccurred
+	n.occurrences = jn.Occurrences
+	n.data = jn.Data
+	n.requiresAck = jn.RequiresAck
+	if jn.LastAcked != nil {
+		n.lastAcked = *jn.LastAcked
+	}
+	if jn.ExpireAfter != "" {
+		var err error
+		n.expireAfter, err = time.ParseDuration(jn.ExpireAfter)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type noticeKey struct {
+	userID     uint32
+	public     bool
+	noticeType NoticeType
+	key        string
+}
+
+func (n *Notice) uniqueKey() noticeKey {
+	k := noticeKey{noticeType: n.noticeType, key: n.key}
+	if n.userID == nil {
+		k.public = true
+	} else {
+		k.userID = *n.userID
+	}
+	return k
+}
+
+// flattenNotices returns all non-expired notices as a flat list, for
+// serialising. Call with the lock held.
+func (s *State) flattenNotices() []*Notice {
+	now := time.Now()
+	flat := make([]*Notice, 0, len(s.notices))
+	for _, n := range s.notices {
+		if n.expiredBefore(now) {
+			continue
+		}
+		flat = append(flat, n)
+	}
+	return flat
+}
+
+// unflattenNotices takes a flat list of notices and replaces the notices
+// map with them, ignoring expired notices in the process. Call with the
+// lock held.
+func (s *State) unflattenNotices(flat []*Notice) {
+	now := time.Now()
+	s.notices = make(map[noticeKey]*Notice, len(flat))
+	for _, n := range flat {
+		if n.expiredBefore(now) {
+			continue
+		}
+		s.notices[n.uniqueKey()] = n
+	}
+}
+
+// AddNoticeOptions holds optional parameters for AddNotice.
+type AddNoticeOptions struct {
+	// Data is the structured payload of the occurrence.
+	Data map[string]string
+	// RequiresAck is whether the notice needs to be acknowledged with
+	// AckNotice, an acknowledged notice becomes pending again when it
+	// occurs again.
+	RequiresAck bool
+}
+
+// AddNotice records an occurrence of a notice for the given user, or for
+// all users if userID is nil. If a notice with the same type and key for
+// the same user exists it is updated, otherwise a new one is added. The
+// ID of the notice is returned.
+func (s *State) AddNotice(userID *uint32, noticeType NoticeType, key string, opts *AddNoticeOptions) string {
+	if opts == nil {
+		opts = &AddNoticeOptions{}
+	}
+	s.writing()
+
+	now := time.Now().UTC()
+	n := &Notice{
+		userID:     userID,
+		noticeType: noticeType,
+		key:        key,
+	}
+	uniqueKey := n.uniqueKey()
+	if existing, ok := s.notices[uniqueKey]; ok {
+		n = existing
+	} else {
+		s.lastNoticeId++
+		n.id = strconv.Itoa(s.lastNoticeId)
+		n.firstOccurred = now
+		n.expireAfter = DefaultNoticeExpireAfter
+		s.notices[uniqueKey] = n
+	}
+	n.lastOccurred = now
+	n.occurrences++
+	n.data = opts.Data
+	n.requiresAck = opts.RequiresAck
+	return n.id
+}
+
+// NoticeFilter selects notices.
+type NoticeFilter struct {
+	// UserID selects the notices visible to the given user, if set.
+	UserID *uint32
+	// Types selects notices of any of the given types, if set.
+	Types []NoticeType
+	// Pending selects only notices waiting for acknowledgment.
+	Pending bool
+}
+
+func (f *NoticeFilter) matches(n *Notice) bool {
+	if f == nil {
+		return true
+	}
+	if f.UserID != nil && !n.VisibleTo(*f.UserID) {
+		return false
+	}
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if n.noticeType == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Pending && !n.Pending() {
+		return false
+	}
+	return true
+}
+
+type byLastOccurred []*Notice
+
+func (a byLastOccurred) Len() int           { return len(a) }
+func (a byLastOccurred) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byLastOccurred) Less(i, j int) bool { return a[i].lastOccurred.Before(a[j].lastOccurred) }
+
+// Notices returns the notices selected by the filter, all of them if it
+// is nil, sorted by the time they last occurred.
+func (s *State) Notices(filter *NoticeFilter) []*Notice {
+	s.reading()
+
+	var notices []*Notice
+	for _, n := range s.flattenNotices() {
+		if filter.matches(n) {
+			notices = append(notices, n)
+		}
+	}
+	sort.Sort(byLastOccurred(notices))
+	return notices
+}
+
+// Notice returns the notice with the given ID, or nil if there is none.
+func (s *State) Notice(id string) *Notice {
+	s.reading()
+	for _, n := range s.notices {
+		if n.id == id {
+			return n
+		}
+	}
+	return nil
+}
+
+// AckNotice records that the notice with the given ID was acknowledged by
+// its user. It returns false if there is no such notice visible to the
+// user.
+func (s *State) AckNotice(uid uint32, id string) bool {
+	n := s.Notice(id)
+	if n == nil || !n.VisibleTo(uid) {
+		return false
+	}
+	s.writing()
+	n.lastAcked = time.Now().UTC()
+	return true
+}
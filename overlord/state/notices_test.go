@@ -0,0 +1,141 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state_test
+
+import (
+	"encoding/json"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+func (stateSuite) TestAddNotice(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	uid := uint32(1000)
+	id1 := st.AddNotice(nil, state.RefreshInhibitNotice, "foo", nil)
+	id2 := st.AddNotice(&uid, state.RefreshInhibitNotice, "foo", nil)
+	id3 := st.AddNotice(nil, state.RefreshInhibitNotice, "bar", &state.AddNoticeOptions{
+		Data: map[string]string{"busy-app": "bar"},
+	})
+	c.Check(id1, check.Equals, "1")
+	c.Check(id2, check.Equals, "2")
+	c.Check(id3, check.Equals, "3")
+
+	// another occurrence updates the existing notice
+	id := st.AddNotice(nil, state.RefreshInhibitNotice, "foo", &state.AddNoticeOptions{
+		Data: map[string]string{"busy-app": "foo"},
+	})
+	c.Check(id, check.Equals, "1")
+
+	notices := st.Notices(nil)
+	c.Assert(notices, check.HasLen, 3)
+	c.Check(notices[0].String(), check.Equals, "Notice 2 (1000:refresh-inhibit:foo)")
+	c.Check(notices[1].String(), check.Equals, "Notice 3 (public:refresh-inhibit:bar)")
+	c.Check(notices[2].String(), check.Equals, "Notice 1 (public:refresh-inhibit:foo)")
+	c.Check(notices[2].Data(), check.DeepEquals, map[string]string{"busy-app": "foo"})
+}
+
+func (stateSuite) TestNoticesFilter(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	uid1 := uint32(1000)
+	uid2 := uint32(1001)
+	st.AddNotice(nil, state.RefreshInhibitNotice, "public", nil)
+	st.AddNotice(&uid1, state.RefreshInhibitNotice, "user1", nil)
+	st.AddNotice(&uid2, "other-type", "user2", nil)
+
+	ids := func(notices []*state.Notice) []string {
+		var l []string
+		for _, n := range notices {
+			l = append(l, n.ID())
+		}
+		return l
+	}
+
+	c.Check(ids(st.Notices(&state.NoticeFilter{UserID: &uid1})), check.DeepEquals, []string{"1", "2"})
+	c.Check(ids(st.Notices(&state.NoticeFilter{UserID: &uid2})), check.DeepEquals, []string{"1", "3"})
+	root := uint32(0)
+	c.Check(ids(st.Notices(&state.NoticeFilter{UserID: &root})), check.DeepEquals, []string{"1", "2", "3"})
+	c.Check(ids(st.Notices(&state.NoticeFilter{Types: []state.NoticeType{"other-type"}})), check.DeepEquals, []string{"3"})
+}
+
+func (stateSuite) TestAckNotice(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	uid := uint32(1000)
+	other := uint32(1001)
+	id := st.AddNotice(&uid, state.RefreshInhibitNotice, "foo", &state.AddNoticeOptions{RequiresAck: true})
+	st.AddNotice(&uid, state.RefreshInhibitNotice, "no-ack", nil)
+
+	pending := st.Notices(&state.NoticeFilter{Pending: true})
+	c.Assert(pending, check.HasLen, 1)
+	c.Check(pending[0].ID(), check.Equals, id)
+	c.Check(pending[0].Pending(), check.Equals, true)
+
+	// other users cannot acknowledge it
+	c.Check(st.AckNotice(other, id), check.Equals, false)
+	c.Check(st.AckNotice(uid, "999"), check.Equals, false)
+
+	c.Check(st.AckNotice(uid, id), check.Equals, true)
+	c.Check(st.Notice(id).Pending(), check.Equals, false)
+	c.Check(st.Notices(&state.NoticeFilter{Pending: true}), check.HasLen, 0)
+
+	// occurring again makes it pending again
+	st.AddNotice(&uid, state.RefreshInhibitNotice, "foo", &state.AddNoticeOptions{RequiresAck: true})
+	c.Check(st.Notice(id).Pending(), check.Equals, true)
+}
+
+func (stateSuite) TestNoticesMarshal(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	uid := uint32(1000)
+	st.AddNotice(&uid, state.RefreshInhibitNotice, "foo", &state.AddNoticeOptions{
+		Data:        map[string]string{"busy-app": "foo"},
+		RequiresAck: true,
+	})
+	st.AckNotice(uid, "1")
+
+	buf, err := json.Marshal(st)
+	c.Assert(err, check.IsNil)
+
+	st2 := state.New(nil)
+	st2.Lock()
+	defer st2.Unlock()
+	c.Assert(json.Unmarshal(buf, st2), check.IsNil)
+
+	notices := st2.Notices(nil)
+	c.Assert(notices, check.HasLen, 1)
+	c.Check(notices[0].String(), check.Equals, "Notice 1 (1000:refresh-inhibit:foo)")
+	c.Check(notices[0].Data(), check.DeepEquals, map[string]string{"busy-app": "foo"})
+	c.Check(notices[0].Pending(), check.Equals, false)
+
+	// the notice ids continue where they left off
+	c.Check(st2.AddNotice(nil, state.RefreshInhibitNotice, "bar", nil), check.Equals, "2")
+}
@@ -20,25 +20,36 @@
 package state
 
 import (
+	"strings"
+
 	"github.com/snapcore/snapd/timings"
 )
 
 // TimingsForTask creates a new Timings tree for the given task.
 // Returned Timings tree has "task-id", "change-id" and "task-kind"
-// tags set automatically from the respective task.
+// tags set automatically from the respective task, as well as the
+// change tags set by TagTimingsWithChange.
 func TimingsForTask(task *Task) *timings.Timings {
-	tags := map[string]string{
+	t := timings.New(map[string]string{
 		"task-id":     task.ID(),
 		"task-kind":   task.Kind(),
 		"task-status": task.Status().String(),
-	}
+	})
 	if chg := task.Change(); chg != nil {
-		tags["change-id"] = chg.ID()
+		TagTimingsWithChange(t, chg)
 	}
-	return timings.New(tags)
+	return t
 }
 
-// TagTimingsWithChange sets the "change-id" tag on the Timings object.
+// TagTimingsWithChange sets the "change-id" and "change-kind" tags on the
+// Timings object, and the "snap-names" tag with the comma separated names
+// of the snaps affected by the change, if known. The change tags allow
+// finding the timings after the change itself was pruned.
 func TagTimingsWithChange(t *timings.Timings, change *Change) {
 	t.AddTag("change-id", change.ID())
+	t.AddTag("change-kind", change.Kind())
+	var snapNames []string
+	if err := change.Get("snap-names", &snapNames); err == nil && len(snapNames) > 0 {
+		t.AddTag("snap-names", strings.Join(snapNames, ","))
+	}
 }
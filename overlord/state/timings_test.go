@@ -71,7 +71,29 @@ func (s *timingsSuite) TestTagTimingsWithChange(c *C) {
 	c.Assert(tims, HasLen, 1)
 	c.Check(tims[0].NestedTimings, HasLen, 0)
 	c.Check(tims[0].Tags, DeepEquals, map[string]string{
-		"change-id": chg.ID(),
+		"change-id":   chg.ID(),
+		"change-kind": "change",
+	})
+}
+
+func (s *timingsSuite) TestTagTimingsWithChangeSnapNames(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	chg := s.st.NewChange("refresh-snap", "...")
+	chg.Set("snap-names", []string{"foo", "bar"})
+
+	timing := timings.New(nil)
+	state.TagTimingsWithChange(timing, chg)
+	timing.Save(s.st)
+
+	tims, err := timings.Get(s.st, 1, func(tags map[string]string) bool { return true })
+	c.Assert(err, IsNil)
+	c.Assert(tims, HasLen, 1)
+	c.Check(tims[0].Tags, DeepEquals, map[string]string{
+		"change-id":   chg.ID(),
+		"change-kind": "refresh-snap",
+		"snap-names":  "foo,bar",
 	})
 }
 
@@ -95,6 +117,7 @@ func (s *timingsSuite) TestTimingsForTask(c *C) {
 	c.Check(tims[0].NestedTimings, HasLen, 1)
 	c.Check(tims[0].Tags, DeepEquals, map[string]string{
 		"change-id":   chg.ID(),
+		"change-kind": "change",
 		"task-id":     task.ID(),
 		"task-kind":   "kind",
 		"task-status": "Doing",
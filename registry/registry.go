@@ -0,0 +1,416 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package registry implements registries, configuration shared between
+// snaps. The configuration of a registry is kept in a data bag and is
+// accessed through views, which map the requests of the snaps to paths
+// of the data bag and restrict whether they can be read or written.
+// Registries are defined by registry assertions.
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/snap/configschema"
+)
+
+var (
+	validName   = regexp.MustCompile("^[a-z0-9](?:-?[a-z0-9])*$")
+	validSubkey = regexp.MustCompile("^(?:[a-z0-9]+-?)*[a-z](?:-?[a-z0-9])*$")
+)
+
+type accessType int
+
+const (
+	readWrite accessType = iota
+	read
+	write
+)
+
+var accessTypeStrings = []string{"read-write", "read", "write"}
+
+func newAccessType(access string) (accessType, error) {
+	if access == "" {
+		return readWrite, nil
+	}
+	for i, s := range accessTypeStrings {
+		if s == access {
+			return accessType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("expected access to be one of %s but was %q", strings.Join(accessTypeStrings, ", "), access)
+}
+
+func (a accessType) canRead() bool  { return a == readWrite || a == read }
+func (a accessType) canWrite() bool { return a == readWrite || a == write }
+
+// NotFoundError is returned when a request cannot be fulfilled because it
+// is not matched by any rule of the view or because no value is stored
+// for it.
+type NotFoundError struct {
+	Account  string
+	Registry string
+	View     string
+	Request  string
+	Cause    string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("cannot find %q in view %s/%s/%s: %s", e.Request, e.Account, e.Registry, e.View, e.Cause)
+}
+
+// IsNotFound returns whether the error is a NotFoundError.
+func IsNotFound(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+// Registry holds the views of a registry and the schema its data bag must
+// comply with, if any.
+type Registry struct {
+	Account string
+	Name    string
+	Schema  *configschema.Schema
+
+	views map[string]*View
+}
+
+// New returns a registry with the given views, each a map holding a list
+// of "rules". Each rule maps a "request", a dotted path that snaps use to
+// access the configuration, to a "storage" dotted path in the data bag,
+// with an optional "access" of "read", "write" or "read-write" (the
+// default).
+func New(account, registryName string, views map[string]interface{}, schema *configschema.Schema) (*Registry, error) {
+	if !validName.MatchString(registryName) {
+		return nil, fmt.Errorf("invalid registry name %q", registryName)
+	}
+	if len(views) == 0 {
+		return nil, fmt.Errorf("cannot define registry: no views")
+	}
+
+	reg := &Registry{
+		Account: account,
+		Name:    registryName,
+		Schema:  schema,
+		views:   make(map[string]*View, len(views)),
+	}
+	for name, v := range views {
+		if !validName.MatchString(name) {
+			return nil, fmt.Errorf("invalid view name %q", name)
+		}
+		viewMap, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot define view %q: view must be a map", name)
+		}
+		view, err := newView(reg, name, viewMap)
+		if err != nil {
+			return nil, fmt.Errorf("cannot define view %q: %v", name, err)
+		}
+		reg.views[name] = view
+	}
+	return reg, nil
+}
+
+// View returns the view with the given name, or nil if there is none.
+func (r *Registry) View(name string) *View {
+	return r.views[name]
+}
+
+// Views returns the names of the views of the registry, sorted.
+func (r *Registry) Views() []string {
+	names := make([]string, 0, len(r.views))
+	for name := range r.views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// View gives access to the configuration of a registry as described by
+// its rules.
+type View struct {
+	Name     string
+	registry *Registry
+	rules    []*viewRule
+}
+
+type viewRule struct {
+	request []string
+	storage []string
+	access  accessType
+}
+
+func newView(reg *Registry, name string, viewMap map[string]interface{}) (*View, error) {
+	rulesRaw, ok := viewMap["rules"]
+	if !ok {
+		return nil, fmt.Errorf("view must have a \"rules\" field")
+	}
+	rules, ok := rulesRaw.([]interface{})
+	if !ok || len(rules) == 0 {
+		return nil, fmt.Errorf("\"rules\" must be a non-empty list")
+	}
+
+	view := &View{Name: name, registry: reg}
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		ruleMap, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("each rule must be a map")
+		}
+		var fields [3]string
+		for i, field := range []string{"request", "storage", "access"} {
+			if v, ok := ruleMap[field]; ok {
+				s, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("%q of a rule must be a string", field)
+				}
+				fields[i] = s
+			}
+		}
+		request, storage, access := fields[0], fields[1], fields[2]
+		if storage == "" {
+			return nil, fmt.Errorf("rules must have a \"storage\" field")
+		}
+		if request == "" {
+			request = storage
+		}
+		requestPath, err := parsePath(request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request %q: %v", request, err)
+		}
+		storagePath, err := parsePath(storage)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage %q: %v", storage, err)
+		}
+		accessType, err := newAccessType(access)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule for request %q: %v", request, err)
+		}
+		if seen[request] {
+			return nil, fmt.Errorf("request %q is mapped more than once", request)
+		}
+		seen[request] = true
+		view.rules = append(view.rules, &viewRule{
+			request: requestPath,
+			storage: storagePath,
+			access:  accessType,
+		})
+	}
+	// the most specific rules are matched first
+	sort.SliceStable(view.rules, func(i, j int) bool {
+		return len(view.rules[i].request) > len(view.rules[j].request)
+	})
+	return view, nil
+}
+
+// Registry returns the registry the view belongs to.
+func (v *View) Registry() *Registry {
+	return v.registry
+}
+
+func (v *View) notFound(request, format string, a ...interface{}) *NotFoundError {
+	return &NotFoundError{
+		Account:  v.registry.Account,
+		Registry: v.registry.Name,
+		View:     v.Name,
+		Request:  request,
+		Cause:    fmt.Sprintf(format, a...),
+	}
+}
+
+// Get returns the value for the request from the data bag. An empty
+// request returns everything the view can read.
+func (v *View) Get(bag JSONDataBag, request string) (interface{}, error) {
+	path, err := parsePath(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// a rule covering the whole request
+	for _, rule := range v.rules {
+		if !rule.access.canRead() || !hasPrefix(path, rule.request) {
+			continue
+		}
+		storage := append(append([]string(nil), rule.storage...), path[len(rule.request):]...)
+		value, ok := bag.get(storage)
+		if !ok {
+			return nil, v.notFound(request, "no value was found under path %q", strings.Join(storage, "."))
+		}
+		return value, nil
+	}
+
+	// rules for parts of the request, their values are put together
+	var result map[string]interface{}
+	for _, rule := range v.rules {
+		if !rule.access.canRead() || !hasPrefix(rule.request, path) {
+			continue
+		}
+		value, ok := bag.get(rule.storage)
+		if !ok {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]interface{})
+		}
+		setIn(result, rule.request[len(path):], value)
+	}
+	if result == nil {
+		return nil, v.notFound(request, "no matching read rule or no value")
+	}
+	return result, nil
+}
+
+// Set sets the value for the request in the data bag, a nil value unsets
+// it. The data bag is checked against the schema of the registry, if any,
+// and should be discarded on error.
+func (v *View) Set(bag JSONDataBag, request string, value interface{}) error {
+	path, err := parsePath(request)
+	if err != nil {
+		return err
+	}
+	if len(path) == 0 {
+		return fmt.Errorf("cannot set empty request")
+	}
+
+	for _, rule := range v.rules {
+		if !hasPrefix(path, rule.request) {
+			continue
+		}
+		if !rule.access.canWrite() {
+			return fmt.Errorf("cannot set %q in view %s/%s/%s: no write access", request, v.registry.Account, v.registry.Name, v.Name)
+		}
+		storage := append(append([]string(nil), rule.storage...), path[len(rule.request):]...)
+		if err := bag.set(storage, value); err != nil {
+			return fmt.Errorf("cannot set %q in view %s/%s/%s: %v", request, v.registry.Account, v.registry.Name, v.Name, err)
+		}
+		if v.registry.Schema != nil {
+			if err := v.registry.Schema.Validate(map[string]interface{}(bag)); err != nil {
+				return fmt.Errorf("cannot set %q in view %s/%s/%s: %v", request, v.registry.Account, v.registry.Name, v.Name, err)
+			}
+		}
+		return nil
+	}
+	return v.notFound(request, "no matching write rule")
+}
+
+// JSONDataBag holds the configuration of a registry as a JSON document.
+type JSONDataBag map[string]interface{}
+
+// NewJSONDataBag returns an empty data bag.
+func NewJSONDataBag() JSONDataBag {
+	return JSONDataBag{}
+}
+
+// Copy returns a deep copy of the data bag.
+func (bag JSONDataBag) Copy() JSONDataBag {
+	data, err := json.Marshal(bag)
+	if err != nil {
+		// the data bag only ever holds JSON values
+		panic(fmt.Sprintf("internal error: cannot marshal data bag: %v", err))
+	}
+	var copy JSONDataBag
+	if err := json.Unmarshal(data, &copy); err != nil {
+		panic(fmt.Sprintf("internal error: cannot unmarshal data bag: %v", err))
+	}
+	if copy == nil {
+		copy = JSONDataBag{}
+	}
+	return copy
+}
+
+func (bag JSONDataBag) get(path []string) (interface{}, bool) {
+	var cur interface{} = map[string]interface{}(bag)
+	for _, subkey := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[subkey]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func (bag JSONDataBag) set(path []string, value interface{}) error {
+	m := map[string]interface{}(bag)
+	for i, subkey := range path[:len(path)-1] {
+		next, ok := m[subkey]
+		if !ok {
+			if value == nil {
+				return nil
+			}
+			next = make(map[string]interface{})
+			m[subkey] = next
+		}
+		nextMap, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("value under %q is not a map", strings.Join(path[:i+1], "."))
+		}
+		m = nextMap
+	}
+	last := path[len(path)-1]
+	if value == nil {
+		delete(m, last)
+	} else {
+		m[last] = value
+	}
+	return nil
+}
+
+func parsePath(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	subkeys := strings.Split(path, ".")
+	for _, subkey := range subkeys {
+		if !validSubkey.MatchString(subkey) {
+			return nil, fmt.Errorf("invalid subkey %q", subkey)
+		}
+	}
+	return subkeys, nil
+}
+
+func hasPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func setIn(m map[string]interface{}, path []string, value interface{}) {
+	for _, subkey := range path[:len(path)-1] {
+		next, ok := m[subkey].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[subkey] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
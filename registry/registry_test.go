@@ -0,0 +1,181 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package registry_test
+
+import (
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/registry"
+	"github.com/snapcore/snapd/snap/configschema"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type registrySuite struct{}
+
+var _ = Suite(&registrySuite{})
+
+func rules(rs ...map[string]interface{}) map[string]interface{} {
+	l := make([]interface{}, len(rs))
+	for i, r := range rs {
+		l[i] = r
+	}
+	return map[string]interface{}{"rules": l}
+}
+
+func (s *registrySuite) newRegistry(c *C, schema *configschema.Schema) *registry.Registry {
+	reg, err := registry.New("acme", "network", map[string]interface{}{
+		"wifi-setup": rules(
+			map[string]interface{}{"request": "ssid", "storage": "wifi.ssid"},
+			map[string]interface{}{"request": "password", "storage": "wifi.psk", "access": "write"},
+			map[string]interface{}{"request": "status", "storage": "wifi.status", "access": "read"},
+		),
+		"wifi-admin": rules(
+			map[string]interface{}{"storage": "wifi"},
+		),
+	}, schema)
+	c.Assert(err, IsNil)
+	return reg
+}
+
+func (s *registrySuite) TestNew(c *C) {
+	reg := s.newRegistry(c, nil)
+	c.Check(reg.Account, Equals, "acme")
+	c.Check(reg.Name, Equals, "network")
+	c.Check(reg.Views(), DeepEquals, []string{"wifi-admin", "wifi-setup"})
+	view := reg.View("wifi-setup")
+	c.Assert(view, NotNil)
+	c.Check(view.Name, Equals, "wifi-setup")
+	c.Check(view.Registry(), Equals, reg)
+	c.Check(reg.View("foo"), IsNil)
+}
+
+func (s *registrySuite) TestNewErrors(c *C) {
+	for _, t := range []struct {
+		name  string
+		views map[string]interface{}
+		err   string
+	}{
+		{"Net", map[string]interface{}{"v": rules(map[string]interface{}{"storage": "a"})}, `invalid registry name "Net"`},
+		{"net", nil, `cannot define registry: no views`},
+		{"net", map[string]interface{}{"V": rules(map[string]interface{}{"storage": "a"})}, `invalid view name "V"`},
+		{"net", map[string]interface{}{"v": "foo"}, `cannot define view "v": view must be a map`},
+		{"net", map[string]interface{}{"v": map[string]interface{}{}}, `cannot define view "v": view must have a "rules" field`},
+		{"net", map[string]interface{}{"v": map[string]interface{}{"rules": []interface{}{}}}, `cannot define view "v": "rules" must be a non-empty list`},
+		{"net", map[string]interface{}{"v": map[string]interface{}{"rules": []interface{}{"a"}}}, `cannot define view "v": each rule must be a map`},
+		{"net", map[string]interface{}{"v": rules(map[string]interface{}{"request": "a"})}, `cannot define view "v": rules must have a "storage" field`},
+		{"net", map[string]interface{}{"v": rules(map[string]interface{}{"storage": []interface{}{"a"}})}, `cannot define view "v": "storage" of a rule must be a string`},
+		{"net", map[string]interface{}{"v": rules(map[string]interface{}{"request": "a..b", "storage": "a"})}, `cannot define view "v": invalid request "a..b": invalid subkey ""`},
+		{"net", map[string]interface{}{"v": rules(map[string]interface{}{"storage": "A"})}, `cannot define view "v": invalid request "A": invalid subkey "A"`},
+		{"net", map[string]interface{}{"v": rules(map[string]interface{}{"request": "a", "storage": "b", "access": "all"})}, `cannot define view "v": invalid rule for request "a": expected access to be one of read-write, read, write but was "all"`},
+		{"net", map[string]interface{}{"v": rules(map[string]interface{}{"request": "a", "storage": "b"}, map[string]interface{}{"request": "a", "storage": "c"})}, `cannot define view "v": request "a" is mapped more than once`},
+	} {
+		_, err := registry.New("acme", t.name, t.views, nil)
+		c.Check(err, ErrorMatches, t.err)
+	}
+}
+
+func (s *registrySuite) TestSetGet(c *C) {
+	reg := s.newRegistry(c, nil)
+	setup := reg.View("wifi-setup")
+	admin := reg.View("wifi-admin")
+	bag := registry.NewJSONDataBag()
+
+	c.Assert(setup.Set(bag, "ssid", "home"), IsNil)
+	c.Assert(setup.Set(bag, "password", "secret"), IsNil)
+	c.Check(bag, DeepEquals, registry.JSONDataBag{
+		"wifi": map[string]interface{}{"ssid": "home", "psk": "secret"},
+	})
+
+	value, err := setup.Get(bag, "ssid")
+	c.Assert(err, IsNil)
+	c.Check(value, Equals, "home")
+
+	// the whole storage is accessible through the admin view
+	value, err = admin.Get(bag, "wifi.psk")
+	c.Assert(err, IsNil)
+	c.Check(value, Equals, "secret")
+	value, err = admin.Get(bag, "")
+	c.Assert(err, IsNil)
+	c.Check(value, DeepEquals, map[string]interface{}{
+		"wifi": map[string]interface{}{"ssid": "home", "psk": "secret"},
+	})
+
+	// write-only values cannot be read
+	_, err = setup.Get(bag, "password")
+	c.Check(err, ErrorMatches, `cannot find "password" in view acme/network/wifi-setup: no matching read rule or no value`)
+	c.Check(registry.IsNotFound(err), Equals, true)
+
+	// read-only values cannot be written
+	c.Assert(admin.Set(bag, "wifi.status", "connected"), IsNil)
+	err = setup.Set(bag, "status", "disconnected")
+	c.Check(err, ErrorMatches, `cannot set "status" in view acme/network/wifi-setup: no write access`)
+
+	// everything readable through the view
+	value, err = setup.Get(bag, "")
+	c.Assert(err, IsNil)
+	c.Check(value, DeepEquals, map[string]interface{}{"ssid": "home", "status": "connected"})
+
+	// unset
+	c.Assert(setup.Set(bag, "ssid", nil), IsNil)
+	_, err = setup.Get(bag, "ssid")
+	c.Check(err, ErrorMatches, `cannot find "ssid" in view acme/network/wifi-setup: no value was found under path "wifi.ssid"`)
+
+	err = setup.Set(bag, "foo", "bar")
+	c.Check(err, ErrorMatches, `cannot find "foo" in view acme/network/wifi-setup: no matching write rule`)
+	err = setup.Set(bag, "", "bar")
+	c.Check(err, ErrorMatches, `cannot set empty request`)
+	err = admin.Set(bag, "wifi.psk.foo", "bar")
+	c.Check(err, ErrorMatches, `cannot set "wifi.psk.foo" in view acme/network/wifi-admin: value under "wifi.psk" is not a map`)
+}
+
+func (s *registrySuite) TestSetSchema(c *C) {
+	schema, err := configschema.Parse([]byte(`{
+	"type": "object",
+	"properties": {
+		"wifi": {
+			"type": "object",
+			"properties": {
+				"ssid": {"type": "string", "maxLength": 32},
+				"psk": {"type": "string", "minLength": 8}
+			}
+		}
+	}
+}`))
+	c.Assert(err, IsNil)
+	reg := s.newRegistry(c, schema)
+	setup := reg.View("wifi-setup")
+	bag := registry.NewJSONDataBag()
+
+	c.Assert(setup.Set(bag, "password", "12345678"), IsNil)
+	err = setup.Set(bag, "password", "1234")
+	c.Check(err, ErrorMatches, `cannot set "password" in view acme/network/wifi-setup: invalid configuration option "wifi.psk": .*`)
+}
+
+func (s *registrySuite) TestCopy(c *C) {
+	bag := registry.JSONDataBag{"a": map[string]interface{}{"b": "c"}}
+	copy := bag.Copy()
+	c.Check(copy, DeepEquals, bag)
+	copy["a"].(map[string]interface{})["b"] = "d"
+	c.Check(bag["a"], DeepEquals, map[string]interface{}{"b": "c"})
+	c.Check(registry.NewJSONDataBag().Copy(), DeepEquals, registry.JSONDataBag{})
+}
@@ -21,6 +21,8 @@ package apparmor
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -125,6 +127,52 @@ func ParserFeatures() ([]string, error) {
 	return appArmorAssessment.ParserFeatures()
 }
 
+// ParserVersion returns the version reported by the AppArmor parser, like
+// "3.0.4".
+func ParserVersion() (string, error) {
+	parser, err := findAppArmorParser()
+	if err != nil {
+		return "", err
+	}
+	output, err := exec.Command(parser, "--version").Output()
+	if err != nil {
+		return "", osutil.OutputErr(output, err)
+	}
+	// the first line is "AppArmor parser version 3.0.4", possibly
+	// followed by copyright notices
+	line := strings.SplitN(string(output), "\n", 2)[0]
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("cannot parse apparmor parser version from %q", line)
+	}
+	return fields[len(fields)-1], nil
+}
+
+// CacheKey returns a key identifying the compiled profiles that are valid
+// for the running kernel and the available AppArmor parser. Compiled
+// profiles can be reused for as long as the key does not change, the key
+// changes whenever the kernel or parser features or the parser version
+// change.
+func CacheKey() (string, error) {
+	kernelFeatures, err := KernelFeatures()
+	if err != nil {
+		return "", fmt.Errorf("cannot probe apparmor kernel features: %v", err)
+	}
+	parserFeatures, err := ParserFeatures()
+	if err != nil {
+		return "", fmt.Errorf("cannot probe apparmor parser features: %v", err)
+	}
+	parserVersion, err := ParserVersion()
+	if err != nil {
+		return "", fmt.Errorf("cannot obtain apparmor parser version: %v", err)
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "kernel-features: %s\n", strings.Join(kernelFeatures, " "))
+	fmt.Fprintf(h, "parser-features: %s\n", strings.Join(parserFeatures, " "))
+	fmt.Fprintf(h, "parser-version: %s\n", parserVersion)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // ParserMtime returns the mtime of the AppArmor parser, else 0.
 func ParserMtime() int64 {
 	var mtime int64
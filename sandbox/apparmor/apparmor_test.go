@@ -20,6 +20,7 @@
 package apparmor_test
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -268,6 +269,94 @@ func (s *apparmorSuite) TestAppArmorParserMtime(c *C) {
 	c.Check(mtime, Equals, int64(0))
 }
 
+func (s *apparmorSuite) TestParserVersion(c *C) {
+	mockParserCmd := testutil.MockCommand(c, "apparmor_parser", `
+echo "AppArmor parser version 3.0.4"
+echo "Copyright (C) 1999-2008 Novell Inc."
+`)
+	defer mockParserCmd.Restore()
+	restore := apparmor.MockParserSearchPath(mockParserCmd.BinDir())
+	defer restore()
+
+	version, err := apparmor.ParserVersion()
+	c.Assert(err, IsNil)
+	c.Check(version, Equals, "3.0.4")
+	c.Check(mockParserCmd.Calls(), DeepEquals, [][]string{{"apparmor_parser", "--version"}})
+}
+
+func (s *apparmorSuite) TestParserVersionErrors(c *C) {
+	restore := apparmor.MockParserSearchPath(c.MkDir())
+	defer restore()
+	_, err := apparmor.ParserVersion()
+	c.Check(err, Equals, os.ErrNotExist)
+
+	mockParserCmd := testutil.MockCommand(c, "apparmor_parser", "echo boom; exit 1")
+	defer mockParserCmd.Restore()
+	restore = apparmor.MockParserSearchPath(mockParserCmd.BinDir())
+	defer restore()
+	_, err = apparmor.ParserVersion()
+	c.Check(err, ErrorMatches, "boom")
+
+	mockParserCmd = testutil.MockCommand(c, "apparmor_parser", "")
+	defer mockParserCmd.Restore()
+	restore = apparmor.MockParserSearchPath(mockParserCmd.BinDir())
+	defer restore()
+	_, err = apparmor.ParserVersion()
+	c.Check(err, ErrorMatches, `cannot parse apparmor parser version from ""`)
+}
+
+func (s *apparmorSuite) TestCacheKey(c *C) {
+	mockParserCmd := testutil.MockCommand(c, "apparmor_parser", `echo "AppArmor parser version 3.0.4"`)
+	defer mockParserCmd.Restore()
+	restore := apparmor.MockParserSearchPath(mockParserCmd.BinDir())
+	defer restore()
+
+	restore = apparmor.MockFeatures([]string{"network", "policy"}, nil, []string{"unsafe"}, nil)
+	defer restore()
+	key1, err := apparmor.CacheKey()
+	c.Assert(err, IsNil)
+	c.Check(key1, HasLen, 64)
+	key, err := apparmor.CacheKey()
+	c.Assert(err, IsNil)
+	c.Check(key, Equals, key1)
+
+	// a kernel with different features needs different compiled profiles
+	restore = apparmor.MockFeatures([]string{"network", "policy", "signal"}, nil, []string{"unsafe"}, nil)
+	defer restore()
+	key2, err := apparmor.CacheKey()
+	c.Assert(err, IsNil)
+	c.Check(key2, Not(Equals), key1)
+
+	// and so does a different parser version
+	mockParserCmd = testutil.MockCommand(c, "apparmor_parser", `echo "AppArmor parser version 3.0.5"`)
+	defer mockParserCmd.Restore()
+	restore = apparmor.MockParserSearchPath(mockParserCmd.BinDir())
+	defer restore()
+	key3, err := apparmor.CacheKey()
+	c.Assert(err, IsNil)
+	c.Check(key3, Not(Equals), key2)
+	c.Check(key3, Not(Equals), key1)
+}
+
+func (s *apparmorSuite) TestCacheKeyErrors(c *C) {
+	restore := apparmor.MockFeatures(nil, errors.New("kernel boom"), nil, nil)
+	defer restore()
+	_, err := apparmor.CacheKey()
+	c.Check(err, ErrorMatches, "cannot probe apparmor kernel features: kernel boom")
+
+	restore = apparmor.MockFeatures(nil, nil, nil, errors.New("parser boom"))
+	defer restore()
+	_, err = apparmor.CacheKey()
+	c.Check(err, ErrorMatches, "cannot probe apparmor parser features: parser boom")
+
+	restore = apparmor.MockParserSearchPath(c.MkDir())
+	defer restore()
+	restore = apparmor.MockFeatures(nil, nil, nil, nil)
+	defer restore()
+	_, err = apparmor.CacheKey()
+	c.Check(err, ErrorMatches, "cannot obtain apparmor parser version: file does not exist")
+}
+
 func (s *apparmorSuite) TestFeaturesProbedOnce(c *C) {
 	apparmor.FreshAppArmorAssessment()
 
@@ -27,6 +27,7 @@ var (
 	ProbeCgroupVersion      = probeCgroupVersion
 	ParsePid                = parsePid
 	DoCreateTransientScope  = doCreateTransientScope
+	DoSetScopeLimits        = doSetScopeLimits
 	SessionOrMaybeSystemBus = sessionOrMaybeSystemBus
 
 	ErrDBusUnknownMethod    = errDBusUnknownMethod
@@ -92,4 +93,12 @@ func MockDoCreateTransientScope(fn func(conn *dbus.Conn, unitName string, pid in
 	}
 }
 
+func MockDoSetScopeLimits(fn func(conn *dbus.Conn, unitName string, opts *TrackingOptions) error) func() {
+	old := doSetScopeLimits
+	doSetScopeLimits = fn
+	return func() {
+		doSetScopeLimits = old
+	}
+}
+
 func FreezerCgroupV1Dir() string { return freezerCgroupV1Dir }
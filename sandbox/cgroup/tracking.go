@@ -25,6 +25,16 @@ type TrackingOptions struct {
 	// AllowSessionBus controls if CreateTransientScopeForTracking will
 	// consider using the session bus for making the request.
 	AllowSessionBus bool
+	// MemoryMax, when non-zero, is the maximum memory usage of the
+	// processes in the scope, in bytes.
+	MemoryMax uint64
+	// CPUQuota, when non-zero, is the maximum CPU time the processes in
+	// the scope can use, as a percentage of the time of a single CPU.
+	CPUQuota int
+}
+
+func (opts *TrackingOptions) hasLimits() bool {
+	return opts.MemoryMax != 0 || opts.CPUQuota != 0
 }
 
 // CreateTransientScopeForTracking puts the current process in a transient scope.
@@ -147,6 +157,11 @@ tryAgain:
 		logger.Debugf("systemd could not associate process %d with transient scope %s", pid, unitName)
 		return ErrCannotTrackProcess
 	}
+	if opts.hasLimits() {
+		if err := doSetScopeLimits(conn, unitName, opts); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -318,6 +333,40 @@ var doCreateTransientScope = func(conn *dbus.Conn, unitName string, pid int) err
 	return nil
 }
 
+// doSetScopeLimits applies the resource limits of the tracking options to
+// the given transient scope by asking systemd via the specified DBus
+// connection.
+var doSetScopeLimits = func(conn *dbus.Conn, unitName string, opts *TrackingOptions) error {
+	type property struct {
+		Name  string
+		Value interface{}
+	}
+	var properties []property
+	if opts.MemoryMax != 0 {
+		properties = append(properties, property{"MemoryMax", opts.MemoryMax})
+	}
+	if opts.CPUQuota != 0 {
+		// the quota is expressed as CPU time per second of wall time
+		properties = append(properties, property{"CPUQuotaPerSecUSec", uint64(opts.CPUQuota) * 10000})
+	}
+
+	// SetUnitProperties takes the unit name, whether the change is
+	// runtime only, and the properties to set, its signature is "sba(sv)".
+	systemd := conn.Object("org.freedesktop.systemd1", "/org/freedesktop/systemd1")
+	call := systemd.Call(
+		"org.freedesktop.systemd1.Manager.SetUnitProperties",
+		0,
+		unitName,
+		true,
+		properties,
+	)
+	if call.Err != nil {
+		return fmt.Errorf("cannot set resource limits of transient scope %q: %v", unitName, call.Err)
+	}
+	logger.Debugf("set resource limits of transient scope %s", unitName)
+	return nil
+}
+
 var randomUUID = func() (string, error) {
 	// The source of the bytes generated here is the same as that of
 	// /dev/urandom which doesn't block and is sufficient for our purposes
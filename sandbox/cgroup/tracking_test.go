@@ -689,3 +689,117 @@ func (s *trackingSuite) TestConfirmSystemdServiceTrackingSad(c *C) {
 	err := cgroup.ConfirmSystemdServiceTracking("snap.pkg.app")
 	c.Assert(err, Equals, cgroup.ErrCannotTrackProcess)
 }
+
+func (s *trackingSuite) TestCreateTransientScopeForTrackingWithLimits(c *C) {
+	restore := dbusutil.MockConnections(dbustest.StubConnection, dbustest.StubConnection)
+	defer restore()
+	restore = cgroup.MockOsGetuid(0)
+	defer restore()
+	restore = cgroup.MockOsGetpid(312123)
+	defer restore()
+	uuid := "cc98cd01-6a25-46bd-b71b-82069b71b770"
+	restore = cgroup.MockRandomUUID(uuid)
+	defer restore()
+	restore = cgroup.MockDoCreateTransientScope(func(conn *dbus.Conn, unitName string, pid int) error {
+		return nil
+	})
+	defer restore()
+	restore = cgroup.MockCgroupProcessPathInTrackingCgroup(func(pid int) (string, error) {
+		return "/system.slice/snap.pkg.hook.configure." + uuid + ".scope", nil
+	})
+	defer restore()
+
+	var limitsUnit string
+	var limitsOpts *cgroup.TrackingOptions
+	restore = cgroup.MockDoSetScopeLimits(func(conn *dbus.Conn, unitName string, opts *cgroup.TrackingOptions) error {
+		limitsUnit = unitName
+		limitsOpts = opts
+		return nil
+	})
+	defer restore()
+
+	// no limits, nothing to set
+	err := cgroup.CreateTransientScopeForTracking("snap.pkg.hook.configure", &cgroup.TrackingOptions{})
+	c.Assert(err, IsNil)
+	c.Check(limitsOpts, IsNil)
+
+	opts := &cgroup.TrackingOptions{MemoryMax: 64 * 1024 * 1024, CPUQuota: 50}
+	err = cgroup.CreateTransientScopeForTracking("snap.pkg.hook.configure", opts)
+	c.Assert(err, IsNil)
+	c.Check(limitsUnit, Equals, "snap.pkg.hook.configure."+uuid+".scope")
+	c.Check(limitsOpts, Equals, opts)
+
+	restore = cgroup.MockDoSetScopeLimits(func(conn *dbus.Conn, unitName string, opts *cgroup.TrackingOptions) error {
+		return fmt.Errorf("boom")
+	})
+	defer restore()
+	err = cgroup.CreateTransientScopeForTracking("snap.pkg.hook.configure", opts)
+	c.Assert(err, ErrorMatches, "boom")
+}
+
+func checkAndRespondToSetUnitProperties(c *C, msg *dbus.Message, scopeName string, properties [][]interface{}) *dbus.Message {
+	type Property struct {
+		Name  string
+		Value interface{}
+	}
+	// Signature of SetUnitProperties, string, boolean and array of Property.
+	requestSig := dbus.SignatureOf("", true, []Property{})
+
+	c.Assert(msg.Type, Equals, dbus.TypeMethodCall)
+	c.Check(msg.Headers, DeepEquals, map[dbus.HeaderField]dbus.Variant{
+		dbus.FieldDestination: dbus.MakeVariant("org.freedesktop.systemd1"),
+		dbus.FieldPath:        dbus.MakeVariant(dbus.ObjectPath("/org/freedesktop/systemd1")),
+		dbus.FieldInterface:   dbus.MakeVariant("org.freedesktop.systemd1.Manager"),
+		dbus.FieldMember:      dbus.MakeVariant("SetUnitProperties"),
+		dbus.FieldSignature:   dbus.MakeVariant(requestSig),
+	})
+	c.Check(msg.Body, DeepEquals, []interface{}{scopeName, true, properties})
+
+	return &dbus.Message{
+		Type: dbus.TypeMethodReply,
+		Headers: map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldReplySerial: dbus.MakeVariant(msg.Serial()),
+			dbus.FieldSender:      dbus.MakeVariant(":1"), // This does not matter.
+		},
+	}
+}
+
+func (s *trackingSuite) TestDoSetScopeLimitsHappy(c *C) {
+	conn, err := dbustest.Connection(func(msg *dbus.Message, n int) ([]*dbus.Message, error) {
+		switch n {
+		case 0:
+			return []*dbus.Message{checkAndRespondToSetUnitProperties(c, msg, "foo.scope", [][]interface{}{
+				{"MemoryMax", dbus.MakeVariant(uint64(64 * 1024 * 1024))},
+				{"CPUQuotaPerSecUSec", dbus.MakeVariant(uint64(500000))},
+			})}, nil
+		case 1:
+			return []*dbus.Message{checkAndRespondToSetUnitProperties(c, msg, "foo.scope", [][]interface{}{
+				{"CPUQuotaPerSecUSec", dbus.MakeVariant(uint64(2000000))},
+			})}, nil
+		}
+		return nil, fmt.Errorf("unexpected message #%d: %s", n, msg)
+	})
+	c.Assert(err, IsNil)
+	defer conn.Close()
+
+	err = cgroup.DoSetScopeLimits(conn, "foo.scope", &cgroup.TrackingOptions{MemoryMax: 64 * 1024 * 1024, CPUQuota: 50})
+	c.Assert(err, IsNil)
+	// the quota can span multiple CPUs
+	err = cgroup.DoSetScopeLimits(conn, "foo.scope", &cgroup.TrackingOptions{CPUQuota: 200})
+	c.Assert(err, IsNil)
+}
+
+func (s *trackingSuite) TestDoSetScopeLimitsError(c *C) {
+	conn, err := dbustest.Connection(func(msg *dbus.Message, n int) ([]*dbus.Message, error) {
+		switch n {
+		case 0:
+			return []*dbus.Message{checkAndFailToStartTransientUnit(c, msg, "org.freedesktop.DBus.Error.InvalidArgs")}, nil
+		}
+		return nil, fmt.Errorf("unexpected message #%d: %s", n, msg)
+	})
+	c.Assert(err, IsNil)
+	defer conn.Close()
+
+	err = cgroup.DoSetScopeLimits(conn, "foo.scope", &cgroup.TrackingOptions{MemoryMax: 1024})
+	c.Assert(err, ErrorMatches, `cannot set resource limits of transient scope "foo.scope": .*`)
+}
@@ -0,0 +1,65 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock
+
+import (
+	"sync"
+)
+
+var landlockProber = &landlockProbe{}
+
+// ABIVersion returns the version of the landlock ABI supported by the
+// running kernel, or 0 if landlock is not available.
+func ABIVersion() int {
+	return landlockProber.abiVersion()
+}
+
+// IsSupported returns true if the running kernel supports landlock.
+func IsSupported() bool {
+	return ABIVersion() > 0
+}
+
+// probing
+
+type landlockProbe struct {
+	probedVersion int
+
+	once sync.Once
+}
+
+func (llp *landlockProbe) abiVersion() int {
+	llp.once.Do(func() {
+		llp.probedVersion = probeABIVersion()
+	})
+	return llp.probedVersion
+}
+
+// mocking
+
+func MockABIVersion(version int) (restore func()) {
+	old := landlockProber
+	landlockProber = &landlockProbe{
+		probedVersion: version,
+	}
+	landlockProber.once.Do(func() {})
+	return func() {
+		landlockProber = old
+	}
+}
@@ -0,0 +1,47 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock
+
+import (
+	"syscall"
+)
+
+const (
+	// landlock_create_ruleset has the same number on all architectures.
+	sysLandlockCreateRuleset = 444
+	// landlockCreateRulesetVersion makes landlock_create_ruleset return
+	// the highest supported ABI version instead of creating a ruleset.
+	landlockCreateRulesetVersion = 1 << 0
+)
+
+var syscallLandlockCreateRuleset = func(attr, size, flags uintptr) (uintptr, syscall.Errno) {
+	r, _, errno := syscall.Syscall(sysLandlockCreateRuleset, attr, size, flags)
+	return r, errno
+}
+
+func probeABIVersion() int {
+	v, errno := syscallLandlockCreateRuleset(0, 0, landlockCreateRulesetVersion)
+	if errno != 0 {
+		// ENOSYS when the kernel was built without landlock,
+		// EOPNOTSUPP when it was disabled at boot time
+		return 0
+	}
+	return int(v)
+}
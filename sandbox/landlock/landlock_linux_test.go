@@ -0,0 +1,71 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package landlock_test
+
+import (
+	"syscall"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/sandbox/landlock"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type landlockSuite struct{}
+
+var _ = Suite(&landlockSuite{})
+
+func (s *landlockSuite) TestMockABIVersion(c *C) {
+	restore := landlock.MockABIVersion(0)
+	defer restore()
+	c.Check(landlock.ABIVersion(), Equals, 0)
+	c.Check(landlock.IsSupported(), Equals, false)
+
+	restore = landlock.MockABIVersion(3)
+	defer restore()
+	c.Check(landlock.ABIVersion(), Equals, 3)
+	c.Check(landlock.IsSupported(), Equals, true)
+}
+
+func (s *landlockSuite) TestProbeABIVersion(c *C) {
+	var calls int
+	restore := landlock.MockSyscallLandlockCreateRuleset(func(attr, size, flags uintptr) (uintptr, syscall.Errno) {
+		calls++
+		c.Check(attr, Equals, uintptr(0))
+		c.Check(size, Equals, uintptr(0))
+		c.Check(flags, Equals, uintptr(1))
+		return 2, 0
+	})
+	defer restore()
+	c.Check(landlock.ProbeABIVersion(), Equals, 2)
+	c.Check(calls, Equals, 1)
+}
+
+func (s *landlockSuite) TestProbeABIVersionUnsupported(c *C) {
+	for _, errno := range []syscall.Errno{syscall.ENOSYS, syscall.EOPNOTSUPP} {
+		restore := landlock.MockSyscallLandlockCreateRuleset(func(attr, size, flags uintptr) (uintptr, syscall.Errno) {
+			return ^uintptr(0), errno
+		})
+		c.Check(landlock.ProbeABIVersion(), Equals, 0)
+		restore()
+	}
+}
@@ -28,3 +28,11 @@ type InternalSnap16 = internal.Snap16
 var (
 	LoadAssertions = loadAssertions
 )
+
+func MockVerifyWorkers(n int) (restore func()) {
+	old := verifyWorkers
+	verifyWorkers = func() int { return n }
+	return func() {
+		verifyWorkers = old
+	}
+}
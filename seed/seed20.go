@@ -35,6 +35,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/asserts/snapasserts"
@@ -67,6 +69,10 @@ type seed20 struct {
 
 	essCache map[string]*Snap
 
+	// unverified holds the asserted snaps whose hash still needs to be
+	// checked against their snap-revision, keyed by path
+	unverified map[string]*unverifiedSnap
+
 	snaps []*Snap
 	// modes holds a matching applicable modes set for each snap in snaps
 	modes             [][]string
@@ -260,7 +266,10 @@ func (e *noSnapDeclarationError) Error() string {
 	return fmt.Sprintf("cannot find snap-declaration for snap name: %s", e.snapRef.SnapName())
 }
 
-func (s *seed20) lookupVerifiedRevision(snapRef naming.SnapRef, snapsDir string) (snapPath string, snapRev *asserts.SnapRevision, snapDecl *asserts.SnapDeclaration, err error) {
+// lookupRevision finds the snap-revision and snap-declaration for the
+// given snap and checks the size of its file, the hash of the file is
+// checked later by verifySnaps.
+func (s *seed20) lookupRevision(snapRef naming.SnapRef, snapsDir string) (snapPath string, snapRev *asserts.SnapRevision, snapDecl *asserts.SnapDeclaration, err error) {
 	snapID := snapRef.ID()
 	if snapID != "" {
 		snapDecl = s.snapDeclsByID[snapID]
@@ -296,17 +305,85 @@ func (s *seed20) lookupVerifiedRevision(snapRef naming.SnapRef, snapsDir string)
 		return "", nil, nil, fmt.Errorf("cannot validate %q for snap %q (snap-id %q), wrong size", snapPath, snapName, snapID)
 	}
 
-	snapSHA3_384, _, err := asserts.SnapFileSHA3_384(snapPath)
+	return snapPath, snapRev, snapDecl, nil
+}
+
+type unverifiedSnap struct {
+	path     string
+	snapName string
+	snapRev  *asserts.SnapRevision
+}
+
+func (u *unverifiedSnap) verify() error {
+	snapSHA3_384, _, err := asserts.SnapFileSHA3_384(u.path)
 	if err != nil {
-		return "", nil, nil, err
+		return err
 	}
 
-	if snapSHA3_384 != snapRev.SnapSHA3_384() {
-		return "", nil, nil, fmt.Errorf("cannot validate %q for snap %q (snap-id %q), hash mismatch with snap-revision", snapPath, snapName, snapID)
+	if snapSHA3_384 != u.snapRev.SnapSHA3_384() {
+		return fmt.Errorf("cannot validate %q for snap %q (snap-id %q), hash mismatch with snap-revision", u.path, u.snapName, u.snapRev.SnapID())
+	}
+	return nil
+}
+
+// verifyWorkers returns how many snaps are hashed in parallel
+var verifyWorkers = runtime.NumCPU
+
+// verifySnaps checks the hashes of the snaps loaded so far against
+// their snap-revision assertions, hashing snaps in parallel.
+func (s *seed20) verifySnaps(tm timings.Measurer) error {
+	var pending []*unverifiedSnap
+	for _, sn := range s.snaps {
+		if u := s.unverified[sn.Path]; u != nil {
+			pending = append(pending, u)
+			// could appear twice in s.snaps
+			delete(s.unverified, sn.Path)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
 
+	n := verifyWorkers()
+	if n > len(pending) {
+		n = len(pending)
+	}
+	if n < 1 {
+		n = 1
 	}
 
-	return snapPath, snapRev, snapDecl, nil
+	errs := make([]error, len(pending))
+	timings.Run(tm, "verify-snaps", fmt.Sprintf("verify hashes of %d snaps", len(pending)), func(nested timings.Measurer) {
+		idx := make(chan int)
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for w := 0; w < n; w++ {
+			go func() {
+				defer wg.Done()
+				for i := range idx {
+					errs[i] = pending[i].verify()
+				}
+			}()
+		}
+		for i := range pending {
+			idx <- i
+		}
+		close(idx)
+		wg.Wait()
+	})
+
+	var firstErr error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		// keep the snap for verification by a later load
+		s.unverified[pending[i].path] = pending[i]
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
 
 func (s *seed20) lookupSnap(snapRef naming.SnapRef, optSnap *internal.Snap20, channel string, snapsDir string, cache map[string]*Snap, tm timings.Measurer) (*Snap, error) {
@@ -333,12 +410,20 @@ func (s *seed20) lookupSnap(snapRef naming.SnapRef, optSnap *internal.Snap20, ch
 		channel = ""
 	} else {
 		var err error
-		timings.Run(tm, "derive-side-info", fmt.Sprintf("hash and derive side info for snap %q", snapRef.SnapName()), func(nested timings.Measurer) {
+		timings.Run(tm, "derive-side-info", fmt.Sprintf("derive side info for snap %q", snapRef.SnapName()), func(nested timings.Measurer) {
 			var snapRev *asserts.SnapRevision
 			var snapDecl *asserts.SnapDeclaration
-			path, snapRev, snapDecl, err = s.lookupVerifiedRevision(snapRef, snapsDir)
+			path, snapRev, snapDecl, err = s.lookupRevision(snapRef, snapsDir)
 			if err == nil {
 				sideInfo = snapasserts.SideInfoFromSnapAssertions(snapDecl, snapRev)
+				if s.unverified == nil {
+					s.unverified = make(map[string]*unverifiedSnap)
+				}
+				s.unverified[path] = &unverifiedSnap{
+					path:     path,
+					snapName: snapDecl.SnapName(),
+					snapRev:  snapRev,
+				}
 			}
 		})
 		if err != nil {
@@ -425,7 +510,7 @@ func (s *seed20) LoadMeta(tm timings.Measurer) error {
 		}
 	}
 
-	return nil
+	return s.verifySnaps(tm)
 }
 
 func (s *seed20) LoadEssentialMeta(essentialTypes []snap.Type, tm timings.Measurer) error {
@@ -493,6 +578,7 @@ func (s *seed20) loadEssentialMeta(filterEssential func(*asserts.ModelSnap) bool
 		}
 	}
 
+	var gadgetSnap *Snap
 	for _, modelSnap := range essSnaps {
 		seedSnap, err := s.addModelSnap(modelSnap, essential, filterEssential, s.essCache, tm)
 		if err != nil {
@@ -502,17 +588,26 @@ func (s *seed20) loadEssentialMeta(filterEssential func(*asserts.ModelSnap) bool
 			return err
 		}
 		if modelSnap.SnapType == "gadget" {
-			// sanity
-			info, err := readInfo(seedSnap.Path, seedSnap.SideInfo)
-			if err != nil {
-				return err
-			}
-			if info.Base != model.Base() {
-				return fmt.Errorf("cannot use gadget snap because its base %q is different from model base %q", info.Base, model.Base())
-			}
-			// TODO: when we allow extend models for classic
-			// we need to add the gadget base here
+			gadgetSnap = seedSnap
+		}
+	}
+
+	// verify the essential snaps before looking into them
+	if err := s.verifySnaps(tm); err != nil {
+		return err
+	}
+
+	if gadgetSnap != nil {
+		// sanity
+		info, err := readInfo(gadgetSnap.Path, gadgetSnap.SideInfo)
+		if err != nil {
+			return err
+		}
+		if info.Base != model.Base() {
+			return fmt.Errorf("cannot use gadget snap because its base %q is different from model base %q", info.Base, model.Base())
 		}
+		// TODO: when we allow extend models for classic
+		// we need to add the gadget base here
 	}
 
 	return nil
@@ -550,6 +550,64 @@ func (s *seed20Suite) TestLoadMetaWrongHashSnap(c *C) {
 	c.Check(err, ErrorMatches, `cannot validate ".*pc_1\.snap" for snap "pc" \(snap-id "pc.*"\), hash mismatch with snap-revision`)
 }
 
+func (s *seed20Suite) TestLoadEssentialMetaWrongHashSnapStaysUnverified(c *C) {
+	sysLabel := "20191031"
+	sysDir := s.makeCore20MinimalSeed(c, sysLabel)
+
+	pcRev := s.AssertedSnapRevision("pc")
+	wrongRev, err := s.StoreSigning.Sign(asserts.SnapRevisionType, map[string]interface{}{
+		"snap-sha3-384": strings.Repeat("B", 64),
+		"snap-size":     pcRev.HeaderString("snap-size"),
+		"snap-id":       s.AssertedSnapID("pc"),
+		"developer-id":  "canonical",
+		"snap-revision": pcRev.HeaderString("snap-revision"),
+		"timestamp":     time.Now().UTC().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+
+	s.massageAssertions(c, filepath.Join(sysDir, "assertions", "snaps"), func(a asserts.Assertion) asserts.Assertion {
+		if a.Type() == asserts.SnapRevisionType && a.HeaderString("snap-id") == s.AssertedSnapID("pc") {
+			return wrongRev
+		}
+		return a
+	})
+
+	seed20, err := seed.Open(s.SeedDir, sysLabel)
+	c.Assert(err, IsNil)
+
+	err = seed20.LoadAssertions(s.db, s.commitTo)
+	c.Assert(err, IsNil)
+
+	allEssential := []snap.Type{snap.TypeSnapd, snap.TypeKernel, snap.TypeBase, snap.TypeGadget}
+	err = seed20.LoadEssentialMeta(allEssential, s.perfTimings)
+	c.Check(err, ErrorMatches, `cannot validate ".*pc_1\.snap" for snap "pc" \(snap-id "pc.*"\), hash mismatch with snap-revision`)
+
+	// the cached essential snaps do not skip verification
+	err = seed20.LoadEssentialMeta(allEssential, s.perfTimings)
+	c.Check(err, ErrorMatches, `cannot validate ".*pc_1\.snap" for snap "pc" \(snap-id "pc.*"\), hash mismatch with snap-revision`)
+}
+
+func (s *seed20Suite) TestLoadMetaVerifyWorkers(c *C) {
+	sysLabel := "20191031"
+	s.makeCore20MinimalSeed(c, sysLabel)
+
+	for _, n := range []int{0, 1, 2, 16} {
+		restore := seed.MockVerifyWorkers(n)
+
+		seed20, err := seed.Open(s.SeedDir, sysLabel)
+		c.Assert(err, IsNil)
+
+		err = seed20.LoadAssertions(s.db, s.commitTo)
+		c.Assert(err, IsNil)
+
+		err = seed20.LoadMeta(s.perfTimings)
+		c.Assert(err, IsNil, Commentf("workers: %d", n))
+		c.Check(seed20.EssentialSnaps(), HasLen, 4)
+
+		restore()
+	}
+}
+
 func (s *seed20Suite) TestLoadMetaWrongGadgetBase(c *C) {
 	sysLabel := "20191031"
 	sysDir := s.makeCore20MinimalSeed(c, sysLabel)
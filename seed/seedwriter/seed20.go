@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/seed/internal"
@@ -255,11 +256,20 @@ func (tr *tree20) writeAssertions(db asserts.RODatabase, modelRefs []*asserts.Re
 	modelOnly := func(aRef *asserts.Ref) bool { return aRef.Type == asserts.ModelType }
 	excludeModel := func(aRef *asserts.Ref) bool { return aRef.Type != asserts.ModelType }
 
+	// write the model related assertions in a stable order, independent
+	// of the order they were fetched in, loading sorts out prerequisites
+	// anyway
+	sortedModelRefs := make([]*asserts.Ref, len(modelRefs))
+	copy(sortedModelRefs, modelRefs)
+	sort.Slice(sortedModelRefs, func(i, j int) bool {
+		return sortedModelRefs[i].Unique() < sortedModelRefs[j].Unique()
+	})
+
 	modelRefsGen := func(include func(*asserts.Ref) bool) func(stop <-chan struct{}) <-chan *asserts.Ref {
 		return func(stop <-chan struct{}) <-chan *asserts.Ref {
 			refs := make(chan *asserts.Ref)
 			go func() {
-				for _, aRef := range modelRefs {
+				for _, aRef := range sortedModelRefs {
 					if include(aRef) {
 						if !pushRef(refs, aRef, stop) {
 							return
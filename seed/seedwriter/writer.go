@@ -23,6 +23,7 @@ package seedwriter
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/snapcore/snapd/asserts"
@@ -44,6 +45,12 @@ type Options struct {
 	// The label for the recovery system for Core20 models
 	Label string
 
+	// ValidationSetSequences maps the sequence keys (account-id/name)
+	// of validation sets declared by the model without a sequence to
+	// the sequence they get pinned to in the seed, usually the latest
+	// one at the time of writing
+	ValidationSetSequences map[string]int
+
 	// TestSkipCopyUnverifiedModel is set to support naive tests
 	// using an unverified model, the resulting image is broken
 	TestSkipCopyUnverifiedModel bool
@@ -174,6 +181,8 @@ type Writer struct {
 
 	modelRefs []*asserts.Ref
 
+	validationSets []*asserts.ValidationSet
+
 	optionsSnaps []*OptionsSnap
 	// consumedOptSnapNum counts which options snaps have been consumed
 	// by either cross matching or matching with a model snap
@@ -478,6 +487,11 @@ func (w *Writer) Start(db asserts.RODatabase, newFetcher NewFetcherFunc) (RefAss
 		}
 	}
 
+	// fetch the validation sets declared by the model (and prereqs)
+	if err := w.fetchValidationSets(f); err != nil {
+		return nil, err
+	}
+
 	w.modelRefs = f.Refs()
 
 	if err := w.tree.mkFixedDirs(); err != nil {
@@ -487,6 +501,59 @@ func (w *Writer) Start(db asserts.RODatabase, newFetcher NewFetcherFunc) (RefAss
 	return f, nil
 }
 
+func (w *Writer) fetchValidationSets(f asserts.Fetcher) error {
+	for _, mvs := range w.model.ValidationSets() {
+		seq := mvs.Sequence
+		if seq == 0 {
+			seq = w.opts.ValidationSetSequences[mvs.SequenceKey()]
+			if seq <= 0 {
+				return fmt.Errorf("cannot pin validation set %q of the model to a sequence", mvs.SequenceKey())
+			}
+		}
+		ref := &asserts.Ref{
+			Type:       asserts.ValidationSetType,
+			PrimaryKey: []string{w.model.Series(), mvs.AccountID, mvs.Name, strconv.Itoa(seq)},
+		}
+		if err := f.Fetch(ref); err != nil {
+			return fmt.Errorf("cannot fetch validation set %q at sequence %d: %v", mvs.SequenceKey(), seq, err)
+		}
+		a, err := ref.Resolve(w.db.Find)
+		if err != nil {
+			return fmt.Errorf("internal error: cannot find just fetched validation set %q: %v", mvs.SequenceKey(), err)
+		}
+		w.validationSets = append(w.validationSets, a.(*asserts.ValidationSet))
+	}
+	return nil
+}
+
+// checkValidationSets checks that the seed snaps satisfy the validation
+// sets declared by the model.
+func (w *Writer) checkValidationSets() error {
+	if len(w.validationSets) == 0 {
+		return nil
+	}
+	valsets := snapasserts.NewValidationSets()
+	for _, vs := range w.validationSets {
+		if err := valsets.Add(vs); err != nil {
+			return err
+		}
+	}
+	if err := valsets.Conflict(); err != nil {
+		return err
+	}
+
+	snaps := make([]*snapasserts.InstalledSnap, 0, len(w.snapsFromModel)+len(w.extraSnaps))
+	for _, sns := range [][]*SeedSnap{w.snapsFromModel, w.extraSnaps} {
+		for _, sn := range sns {
+			snaps = append(snaps, snapasserts.NewInstalledSnap(sn.SnapName(), sn.Info.SnapID, sn.Info.Revision))
+		}
+	}
+	if err := valsets.CheckInstalledSnaps(snaps); err != nil {
+		return fmt.Errorf("cannot seed snaps not satisfying the model validation sets: %v", err)
+	}
+	return nil
+}
+
 // LocalSnaps returns a list of seed snaps that are local.  The writer
 // delegates to produce *snap.Info for them to then be set via
 // SetInfo. If matching snap assertions can be found as well they can
@@ -941,7 +1008,9 @@ func (w *Writer) downloaded(seedSnaps []*SeedSnap) error {
 // Downloaded checks the downloaded snaps metadata provided via
 // setting it into the SeedSnaps returned by the previous
 // SnapsToDownload. It also returns whether the seed snap set is
-// complete or SnapsToDownload should be called again.
+// complete or SnapsToDownload should be called again. Once complete the
+// seed snap set is checked against the validation sets declared by the
+// model.
 func (w *Writer) Downloaded() (complete bool, err error) {
 	if err := w.checkStep(downloadedStep); err != nil {
 		return false, err
@@ -1003,6 +1072,10 @@ func (w *Writer) Downloaded() (complete bool, err error) {
 		panic(fmt.Sprintf("unknown to-download set: %d", w.toDownload))
 	}
 
+	if err := w.checkValidationSets(); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
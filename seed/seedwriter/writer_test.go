@@ -1028,6 +1028,155 @@ func (s *writerSuite) TestSeedSnapsWriteMetaCore18StoreAssertion(c *C) {
 	c.Check(p, testutil.FilePresent)
 }
 
+func (s *writerSuite) makeValidationSet(c *C, sequence string, snaps ...interface{}) {
+	vs, err := s.Brands.Signing("my-brand").Sign(asserts.ValidationSetType, map[string]interface{}{
+		"type":         "validation-set",
+		"authority-id": "my-brand",
+		"series":       "16",
+		"account-id":   "my-brand",
+		"name":         "base-set",
+		"sequence":     sequence,
+		"snaps":        snaps,
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	err = s.StoreSigning.Add(vs)
+	c.Assert(err, IsNil)
+}
+
+func (s *writerSuite) TestSeedSnapsWriteMetaCore18ValidationSets(c *C) {
+	s.makeSnap(c, "snapd", "")
+	s.makeSnap(c, "core18", "")
+	s.makeSnap(c, "pc-kernel=18", "")
+	s.makeSnap(c, "pc=18", "")
+	s.makeSnap(c, "cont-producer", "developerid")
+
+	s.makeValidationSet(c, "1", map[string]interface{}{
+		"name":     "cont-producer",
+		"id":       s.AssertedSnapID("cont-producer"),
+		"revision": "1",
+	})
+	s.makeValidationSet(c, "2", map[string]interface{}{
+		"name":     "cont-producer",
+		"id":       s.AssertedSnapID("cont-producer"),
+		"revision": "1",
+	}, map[string]interface{}{
+		"name":     "cont-consumer",
+		"id":       s.AssertedSnapID("cont-consumer"),
+		"presence": "invalid",
+	})
+
+	model := s.Brands.Model("my-brand", "my-model", map[string]interface{}{
+		"display-name":   "my model",
+		"architecture":   "amd64",
+		"base":           "core18",
+		"gadget":         "pc=18",
+		"kernel":         "pc-kernel=18",
+		"required-snaps": []interface{}{"cont-producer"},
+		"validation-sets": []interface{}{
+			map[string]interface{}{
+				"name": "base-set",
+				"mode": "enforce",
+			},
+		},
+	})
+
+	// the unpinned validation set gets pinned at writing time
+	s.opts.ValidationSetSequences = map[string]int{"my-brand/base-set": 2}
+
+	complete, w, err := s.upToDownloaded(c, model, s.fillDownloadedSnap)
+	c.Assert(err, IsNil)
+	c.Check(complete, Equals, true)
+
+	err = w.SeedSnaps(nil)
+	c.Assert(err, IsNil)
+
+	err = w.WriteMeta()
+	c.Assert(err, IsNil)
+
+	// the validation set was embedded in the seed
+	seedAssertsDir := filepath.Join(s.opts.SeedDir, "assertions")
+	c.Check(filepath.Join(seedAssertsDir, "16,my-brand,base-set,2.validation-set"), testutil.FilePresent)
+	c.Check(filepath.Join(seedAssertsDir, "16,my-brand,base-set,1.validation-set"), testutil.FileAbsent)
+}
+
+func (s *writerSuite) TestDownloadedValidationSetsNotSatisfied(c *C) {
+	s.makeSnap(c, "snapd", "")
+	s.makeSnap(c, "core18", "")
+	s.makeSnap(c, "pc-kernel=18", "")
+	s.makeSnap(c, "pc=18", "")
+
+	s.makeValidationSet(c, "3", map[string]interface{}{
+		"name": "other-snap",
+		"id":   "otheridididididididididididididi",
+	})
+
+	model := s.Brands.Model("my-brand", "my-model", map[string]interface{}{
+		"display-name": "my model",
+		"architecture": "amd64",
+		"base":         "core18",
+		"gadget":       "pc=18",
+		"kernel":       "pc-kernel=18",
+		"validation-sets": []interface{}{
+			map[string]interface{}{
+				"name":     "base-set",
+				"sequence": "3",
+				"mode":     "prefer-enforce",
+			},
+		},
+	})
+
+	_, _, err := s.upToDownloaded(c, model, s.fillDownloadedSnap)
+	c.Check(err, ErrorMatches, `(?s)cannot seed snaps not satisfying the model validation sets: validation sets assertions are not met:
+- missing required snaps:
+  - other-snap \(required by sets my-brand/base-set\)`)
+}
+
+func (s *writerSuite) TestStartValidationSetNotPinned(c *C) {
+	model := s.Brands.Model("my-brand", "my-model", map[string]interface{}{
+		"display-name": "my model",
+		"architecture": "amd64",
+		"base":         "core18",
+		"gadget":       "pc=18",
+		"kernel":       "pc-kernel=18",
+		"validation-sets": []interface{}{
+			map[string]interface{}{
+				"name": "base-set",
+				"mode": "enforce",
+			},
+		},
+	})
+
+	w, err := seedwriter.New(model, s.opts)
+	c.Assert(err, IsNil)
+
+	_, err = w.Start(s.db, s.newFetcher)
+	c.Check(err, ErrorMatches, `cannot pin validation set "my-brand/base-set" of the model to a sequence`)
+}
+
+func (s *writerSuite) TestStartValidationSetNotFound(c *C) {
+	model := s.Brands.Model("my-brand", "my-model", map[string]interface{}{
+		"display-name": "my model",
+		"architecture": "amd64",
+		"base":         "core18",
+		"gadget":       "pc=18",
+		"kernel":       "pc-kernel=18",
+		"validation-sets": []interface{}{
+			map[string]interface{}{
+				"name":     "base-set",
+				"sequence": "7",
+				"mode":     "enforce",
+			},
+		},
+	})
+
+	w, err := seedwriter.New(model, s.opts)
+	c.Assert(err, IsNil)
+
+	_, err = w.Start(s.db, s.newFetcher)
+	c.Check(err, ErrorMatches, `cannot fetch validation set "my-brand/base-set" at sequence 7: .*not found`)
+}
+
 func (s *writerSuite) TestLocalSnaps(c *C) {
 	model := s.Brands.Model("my-brand", "my-model", map[string]interface{}{
 		"display-name":   "my model",
@@ -0,0 +1,324 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package configschema implements the validation of snap configuration
+// against the schema shipped by snaps in meta/config-schema.json.
+//
+// The schema is a subset of JSON Schema: the type, properties,
+// additionalProperties, required, items, enum, minimum, maximum,
+// minLength, maxLength, pattern, minItems and maxItems keywords are
+// supported. Unknown keywords are rejected, so that constraints are never
+// silently ignored. Title, description and default are carried for the
+// benefit of user interfaces.
+package configschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/snapcore/snapd/strutil"
+)
+
+// Filename is the path of the schema, relative to the root of the snap.
+const Filename = "meta/config-schema.json"
+
+var validTypes = []string{"object", "array", "string", "integer", "number", "boolean", "null"}
+
+// Schema describes the valid values of a configuration option.
+type Schema struct {
+	// the meta keywords are accepted but ignored
+	MetaSchema string `json:"$schema,omitempty"`
+	ID         string `json:"$id,omitempty"`
+	Comment    string `json:"$comment,omitempty"`
+
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+
+	Type string `json:"type,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	Enum []interface{} `json:"enum,omitempty"`
+
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ValidationError is returned when a configuration value does not match
+// the schema.
+type ValidationError struct {
+	// Path is the dotted path of the invalid option, empty for the
+	// whole configuration.
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("invalid configuration: %s", e.Message)
+	}
+	return fmt.Sprintf("invalid configuration option %q: %s", e.Path, e.Message)
+}
+
+// Parse parses and checks the schema.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("cannot parse configuration schema: %v", err)
+	}
+	if err := s.check(""); err != nil {
+		return nil, fmt.Errorf("invalid configuration schema: %v", err)
+	}
+	return &s, nil
+}
+
+// ReadFile returns the schema of the snap mounted in the given directory,
+// or nil if it does not have one.
+func ReadFile(snapDir string) (*Schema, error) {
+	data, err := ioutil.ReadFile(filepath.Join(snapDir, Filename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func (s *Schema) check(path string) error {
+	where := ""
+	if path != "" {
+		where = fmt.Sprintf(" of %q", path)
+	}
+	if s.Type != "" && !strutil.ListContains(validTypes, s.Type) {
+		return fmt.Errorf("unknown type %q%s", s.Type, where)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern%s: %v", where, err)
+		}
+		s.pattern = re
+	}
+	for _, name := range s.Required {
+		if s.Properties[name] == nil && s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			return fmt.Errorf("required property %q%s is not allowed", name, where)
+		}
+	}
+	for name, prop := range s.Properties {
+		if prop == nil {
+			return fmt.Errorf("property %q%s has no schema", name, where)
+		}
+		if err := prop.check(join(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.check(path + "[]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// normalize converts the numbers of the value to float64, as they are
+// found in the enum values of the schema.
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if f, err := x.Float64(); err == nil {
+			return f
+		}
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, item := range x {
+			out[i] = normalize(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, item := range x {
+			out[k] = normalize(item)
+		}
+		return out
+	}
+	return v
+}
+
+func typeOf(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if x == float64(int64(x)) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Validate checks the given configuration document, as obtained from a
+// configuration transaction, against the schema.
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate("", normalize(value))
+}
+
+func (s *Schema) validate(path string, v interface{}) error {
+	fail := func(format string, a ...interface{}) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, a...)}
+	}
+
+	typ := typeOf(v)
+	if s.Type != "" && s.Type != typ && !(s.Type == "number" && typ == "integer") {
+		return fail("expected %s, got %s", s.Type, typ)
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if reflect.DeepEqual(allowed, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("must be one of %s", enumString(s.Enum))
+		}
+	}
+
+	switch x := v.(type) {
+	case float64:
+		if s.Minimum != nil && x < *s.Minimum {
+			return fail("must be at least %s", formatNumber(*s.Minimum))
+		}
+		if s.Maximum != nil && x > *s.Maximum {
+			return fail("must be at most %s", formatNumber(*s.Maximum))
+		}
+	case string:
+		n := len([]rune(x))
+		if s.MinLength != nil && n < *s.MinLength {
+			return fail("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fail("must be at most %d characters long", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(x) {
+			return fail("must match %q", s.Pattern)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(x) < *s.MinItems {
+			return fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(x) > *s.MaxItems {
+			return fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range x {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := x[name]; !ok {
+				return fail("missing required option %q", name)
+			}
+		}
+		names := make([]string, 0, len(x))
+		for name := range x {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop := s.Properties[name]
+			if prop == nil {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return &ValidationError{Path: join(path, name), Message: "unknown option"}
+				}
+				continue
+			}
+			if err := prop.validate(join(path, name), x[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func enumString(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, v := range enum {
+		b, err := json.Marshal(v)
+		if err != nil {
+			values[i] = fmt.Sprint(v)
+			continue
+		}
+		values[i] = string(b)
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,155 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configschema_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/snap/configschema"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type schemaSuite struct{}
+
+var _ = Suite(&schemaSuite{})
+
+const testSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"additionalProperties": false,
+	"required": ["name"],
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "maxLength": 8, "pattern": "^[a-z]+$"},
+		"port": {"type": "integer", "minimum": 1, "maximum": 65535, "default": 8080},
+		"ratio": {"type": "number", "minimum": 0, "maximum": 1},
+		"debug": {"type": "boolean"},
+		"mode": {"enum": ["fast", "slow", 3]},
+		"hosts": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string"}},
+		"nested": {
+			"type": "object",
+			"properties": {"level": {"type": "integer"}}
+		}
+	}
+}`
+
+func (s *schemaSuite) parse(c *C) *configschema.Schema {
+	schema, err := configschema.Parse([]byte(testSchema))
+	c.Assert(err, IsNil)
+	return schema
+}
+
+// decode decodes the document like configuration transactions do.
+func decode(c *C, doc string) interface{} {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	c.Assert(dec.Decode(&v), IsNil)
+	return v
+}
+
+func (s *schemaSuite) TestValidateHappy(c *C) {
+	schema := s.parse(c)
+	for _, doc := range []string{
+		`{"name": "foo"}`,
+		`{"name": "foo", "port": 80, "ratio": 0.5, "debug": true}`,
+		`{"name": "foo", "ratio": 1, "mode": "slow"}`,
+		`{"name": "foo", "mode": 3}`,
+		`{"name": "foo", "hosts": ["a", "b"], "nested": {"level": 2, "other": "x"}}`,
+	} {
+		c.Check(schema.Validate(decode(c, doc)), IsNil, Commentf(doc))
+	}
+}
+
+func (s *schemaSuite) TestValidateUnhappy(c *C) {
+	schema := s.parse(c)
+	for _, t := range []struct {
+		doc string
+		err string
+	}{
+		{`[]`, `invalid configuration: expected object, got array`},
+		{`{}`, `invalid configuration: missing required option "name"`},
+		{`{"name": "f"}`, `invalid configuration option "name": must be at least 2 characters long`},
+		{`{"name": "foobarbaz"}`, `invalid configuration option "name": must be at most 8 characters long`},
+		{`{"name": "Foo"}`, `invalid configuration option "name": must match "\^\[a-z\]\+\$"`},
+		{`{"name": 1}`, `invalid configuration option "name": expected string, got integer`},
+		{`{"name": "foo", "port": 0}`, `invalid configuration option "port": must be at least 1`},
+		{`{"name": "foo", "port": 65536}`, `invalid configuration option "port": must be at most 65535`},
+		{`{"name": "foo", "port": 1.5}`, `invalid configuration option "port": expected integer, got number`},
+		{`{"name": "foo", "ratio": 1.5}`, `invalid configuration option "ratio": must be at most 1`},
+		{`{"name": "foo", "debug": "yes"}`, `invalid configuration option "debug": expected boolean, got string`},
+		{`{"name": "foo", "mode": "medium"}`, `invalid configuration option "mode": must be one of "fast", "slow", 3`},
+		{`{"name": "foo", "hosts": []}`, `invalid configuration option "hosts": must have at least 1 items`},
+		{`{"name": "foo", "hosts": ["a", "b", "c"]}`, `invalid configuration option "hosts": must have at most 2 items`},
+		{`{"name": "foo", "hosts": ["a", 1]}`, `invalid configuration option "hosts\[1\]": expected string, got integer`},
+		{`{"name": "foo", "nested": {"level": "high"}}`, `invalid configuration option "nested.level": expected integer, got string`},
+		{`{"name": "foo", "other": 1}`, `invalid configuration option "other": unknown option`},
+	} {
+		err := schema.Validate(decode(c, t.doc))
+		c.Check(err, ErrorMatches, t.err, Commentf(t.doc))
+		c.Check(err, FitsTypeOf, &configschema.ValidationError{})
+	}
+}
+
+func (s *schemaSuite) TestParseErrors(c *C) {
+	for _, t := range []struct {
+		schema string
+		err    string
+	}{
+		{`{`, `cannot parse configuration schema: .*`},
+		{`{"type": "object", "format": "email"}`, `cannot parse configuration schema: json: unknown field "format"`},
+		{`{"type": "list"}`, `invalid configuration schema: unknown type "list"`},
+		{`{"properties": {"a": {"properties": {"b": {"type": "foo"}}}}}`, `invalid configuration schema: unknown type "foo" of "a.b"`},
+		{`{"properties": {"a": {"pattern": "("}}}`, `invalid configuration schema: invalid pattern of "a": .*`},
+		{`{"items": {"type": "foo"}}`, `invalid configuration schema: unknown type "foo" of "\[\]"`},
+		{`{"properties": {"a": null}}`, `invalid configuration schema: property "a" has no schema`},
+		{`{"required": ["b"], "additionalProperties": false}`, `invalid configuration schema: required property "b" is not allowed`},
+	} {
+		_, err := configschema.Parse([]byte(t.schema))
+		c.Check(err, ErrorMatches, t.err, Commentf(t.schema))
+	}
+}
+
+func (s *schemaSuite) TestReadFile(c *C) {
+	snapDir := c.MkDir()
+
+	schema, err := configschema.ReadFile(snapDir)
+	c.Assert(err, IsNil)
+	c.Check(schema, IsNil)
+
+	c.Assert(os.MkdirAll(filepath.Join(snapDir, "meta"), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(snapDir, configschema.Filename), []byte(testSchema), 0644), IsNil)
+	schema, err = configschema.ReadFile(snapDir)
+	c.Assert(err, IsNil)
+	c.Assert(schema, NotNil)
+	c.Check(schema.Properties["port"].Default, Equals, float64(8080))
+
+	// the schema can be served to user interfaces
+	data, err := json.Marshal(schema.Properties["port"])
+	c.Assert(err, IsNil)
+	c.Check(string(data), Equals, `{"default":8080,"type":"integer","minimum":1,"maximum":65535}`)
+}
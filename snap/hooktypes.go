@@ -38,6 +38,8 @@ var supportedHooks = []*HookType{
 	NewHookType(regexp.MustCompile("^check-health$")),
 	NewHookType(regexp.MustCompile("^fde-setup$")),
 	NewHookType(regexp.MustCompile("^gate-auto-refresh$")),
+	NewHookType(regexp.MustCompile("^migrate-data$")),
+	NewHookType(regexp.MustCompile("^observe-view-[-a-z0-9]+$")),
 }
 
 // HookType represents a pattern of supported hook names.
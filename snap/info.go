@@ -32,6 +32,7 @@ import (
 	"time"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/sys"
 	"github.com/snapcore/snapd/snap/naming"
@@ -843,6 +844,18 @@ type SocketInfo struct {
 	SocketMode   os.FileMode
 }
 
+// PathInfo provides information on paths watched to activate an
+// application. Exactly one of the conditions is expected to be set.
+type PathInfo struct {
+	App *AppInfo
+
+	Name              string
+	PathExists        string
+	PathChanged       string
+	PathModified      string
+	DirectoryNotEmpty string
+}
+
 // TimerInfo provides information on application timer.
 type TimerInfo struct {
 	App *AppInfo
@@ -902,6 +915,12 @@ type AppInfo struct {
 	RefreshMode     string
 	StopMode        StopModeType
 	InstallMode     string
+	TmpMode         string
+
+	// MimeTypes and URLSchemes list what the application registers to
+	// handle with the desktop environment
+	MimeTypes  []string
+	URLSchemes []string
 
 	// TODO: this should go away once we have more plumbing and can change
 	// things vs refactor
@@ -912,6 +931,7 @@ type AppInfo struct {
 	Plugs   map[string]*PlugInfo
 	Slots   map[string]*SlotInfo
 	Sockets map[string]*SocketInfo
+	Paths   map[string]*PathInfo
 
 	Environment strutil.OrderedMap
 
@@ -920,6 +940,12 @@ type AppInfo struct {
 	After  []string
 	Before []string
 
+	// list of plugs of the service whose connected slots are provided
+	// by services of other snaps that this service will start after, or
+	// that it requires
+	AfterPlugs    []string
+	RequiresPlugs []string
+
 	Timer *TimerInfo
 
 	Autostart string
@@ -962,9 +988,23 @@ type HookInfo struct {
 	Environment  strutil.OrderedMap
 	CommandChain []string
 
+	// Limits are the resource limits the hook runs under, if any.
+	Limits *HookLimits
+
 	Explicit bool
 }
 
+// HookLimits holds the resource limits of a hook.
+type HookLimits struct {
+	// Memory is the maximum memory usage of the hook processes.
+	Memory quantity.Size
+	// CPUPercentage is the maximum CPU time the hook processes can use,
+	// as a percentage of the time of a single CPU.
+	CPUPercentage int
+	// Timeout is the maximum duration of the hook.
+	Timeout timeout.Timeout
+}
+
 // SystemUsernameInfo provides information about a system username (ie, a
 // UNIX user and group with the same name). The scope defines visibility of the
 // username wrt the snap and the system. Defined scopes:
@@ -985,6 +1025,11 @@ func (socket *SocketInfo) File() string {
 	return filepath.Join(socket.App.serviceDir(), socket.App.SecurityTag()+"."+socket.Name+".socket")
 }
 
+// File returns the path to the *.path file
+func (path *PathInfo) File() string {
+	return filepath.Join(path.App.serviceDir(), path.App.SecurityTag()+"."+path.Name+".path")
+}
+
 // File returns the path to the *.timer file
 func (timer *TimerInfo) File() string {
 	return filepath.Join(timer.App.serviceDir(), timer.App.SecurityTag()+".timer")
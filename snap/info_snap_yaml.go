@@ -28,6 +28,7 @@ import (
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/metautil"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/timeout"
@@ -86,6 +87,9 @@ type appYaml struct {
 	RefreshMode     string          `yaml:"refresh-mode,omitempty"`
 	StopMode        StopModeType    `yaml:"stop-mode,omitempty"`
 	InstallMode     string          `yaml:"install-mode,omitempty"`
+	TmpMode         string          `yaml:"tmp-mode,omitempty"`
+	MimeTypes       []string        `yaml:"mime-types,omitempty"`
+	URLSchemes      []string        `yaml:"url-schemes,omitempty"`
 
 	RestartCond  RestartCondition `yaml:"restart-condition,omitempty"`
 	RestartDelay timeout.Timeout  `yaml:"restart-delay,omitempty"`
@@ -100,9 +104,14 @@ type appYaml struct {
 
 	Sockets map[string]socketsYaml `yaml:"sockets,omitempty"`
 
+	Paths map[string]pathsYaml `yaml:"paths,omitempty"`
+
 	After  []string `yaml:"after,omitempty"`
 	Before []string `yaml:"before,omitempty"`
 
+	AfterPlugs    []string `yaml:"after-plugs,omitempty"`
+	RequiresPlugs []string `yaml:"requires-plugs,omitempty"`
+
 	Timer string `yaml:"timer,omitempty"`
 
 	Autostart string `yaml:"autostart,omitempty"`
@@ -113,6 +122,13 @@ type hookYaml struct {
 	SlotNames    []string           `yaml:"slots,omitempty"`
 	Environment  strutil.OrderedMap `yaml:"environment,omitempty"`
 	CommandChain []string           `yaml:"command-chain,omitempty"`
+	Limits       *hookLimitsYaml    `yaml:"limits,omitempty"`
+}
+
+type hookLimitsYaml struct {
+	Memory        quantity.Size   `yaml:"memory,omitempty"`
+	CPUPercentage int             `yaml:"cpu-percentage,omitempty"`
+	Timeout       timeout.Timeout `yaml:"timeout,omitempty"`
 }
 
 type layoutYaml struct {
@@ -130,6 +146,13 @@ type socketsYaml struct {
 	SocketMode   os.FileMode `yaml:"socket-mode,omitempty"`
 }
 
+type pathsYaml struct {
+	PathExists        string `yaml:"path-exists,omitempty"`
+	PathChanged       string `yaml:"path-changed,omitempty"`
+	PathModified      string `yaml:"path-modified,omitempty"`
+	DirectoryNotEmpty string `yaml:"directory-not-empty,omitempty"`
+}
+
 // InfoFromSnapYaml creates a new info based on the given snap.yaml data
 func InfoFromSnapYaml(yamlData []byte) (*Info, error) {
 	return infoFromSnapYaml(yamlData, new(scopedTracker))
@@ -372,8 +395,13 @@ func setAppsFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) error {
 			StopMode:        yApp.StopMode,
 			RefreshMode:     yApp.RefreshMode,
 			InstallMode:     yApp.InstallMode,
+			TmpMode:         yApp.TmpMode,
+			MimeTypes:       yApp.MimeTypes,
+			URLSchemes:      yApp.URLSchemes,
 			Before:          yApp.Before,
 			After:           yApp.After,
+			AfterPlugs:      yApp.AfterPlugs,
+			RequiresPlugs:   yApp.RequiresPlugs,
 			Autostart:       yApp.Autostart,
 			WatchdogTimeout: yApp.WatchdogTimeout,
 		}
@@ -386,6 +414,9 @@ func setAppsFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) error {
 		if len(yApp.Sockets) > 0 {
 			app.Sockets = make(map[string]*SocketInfo, len(yApp.Sockets))
 		}
+		if len(yApp.Paths) > 0 {
+			app.Paths = make(map[string]*PathInfo, len(yApp.Paths))
+		}
 		if len(yApp.ActivatesOn) > 0 {
 			app.ActivatesOn = make([]*SlotInfo, 0, len(yApp.ActivatesOn))
 		}
@@ -435,6 +466,21 @@ func setAppsFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) error {
 			app.Slots[slotName] = slot
 			slot.Apps[appName] = app
 		}
+		for _, plugs := range []struct {
+			field string
+			names []string
+		}{
+			{"after-plugs", yApp.AfterPlugs},
+			{"requires-plugs", yApp.RequiresPlugs},
+		} {
+			for _, plugName := range plugs.names {
+				// only plugs listed by the app itself, not the
+				// ones bound to all apps implicitly
+				if !strutil.ListContains(yApp.PlugNames, plugName) {
+					return fmt.Errorf("invalid %s value %q on app %q: plug not listed in the app plugs", plugs.field, plugName, appName)
+				}
+			}
+		}
 		for _, slotName := range yApp.ActivatesOn {
 			slot, ok := snap.Slots[slotName]
 			if !ok {
@@ -454,6 +500,16 @@ func setAppsFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) error {
 				SocketMode:   data.SocketMode,
 			}
 		}
+		for name, data := range yApp.Paths {
+			app.Paths[name] = &PathInfo{
+				App:               app,
+				Name:              name,
+				PathExists:        data.PathExists,
+				PathChanged:       data.PathChanged,
+				PathModified:      data.PathModified,
+				DirectoryNotEmpty: data.DirectoryNotEmpty,
+			}
+		}
 		if yApp.Timer != "" {
 			app.Timer = &TimerInfo{
 				App:   app,
@@ -482,6 +538,13 @@ func setHooksFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) {
 			CommandChain: yHook.CommandChain,
 			Explicit:     true,
 		}
+		if yHook.Limits != nil {
+			hook.Limits = &HookLimits{
+				Memory:        yHook.Limits.Memory,
+				CPUPercentage: yHook.Limits.CPUPercentage,
+				Timeout:       yHook.Limits.Timeout,
+			}
+		}
 		if len(y.Plugs) > 0 || len(yHook.PlugNames) > 0 {
 			hook.Plugs = make(map[string]*PlugInfo)
 		}
@@ -27,6 +27,7 @@ import (
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/gadget/quantity"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/testutil"
@@ -939,6 +940,39 @@ hooks:
 	})
 }
 
+func (s *YamlSuite) TestUnmarshalHookWithLimits(c *C) {
+	// NOTE: yaml content cannot use tabs, indent the section with spaces.
+	info, err := snap.InfoFromSnapYaml([]byte(`
+name: snap
+hooks:
+    test-hook:
+        limits:
+            memory: 64M
+            cpu-percentage: 50
+            timeout: 2m
+    other-hook:
+`))
+	c.Assert(err, IsNil)
+
+	c.Check(info.Hooks["test-hook"].Limits, DeepEquals, &snap.HookLimits{
+		Memory:        64 * quantity.SizeMiB,
+		CPUPercentage: 50,
+		Timeout:       timeout.Timeout(2 * time.Minute),
+	})
+	c.Check(info.Hooks["other-hook"].Limits, IsNil)
+}
+
+func (s *YamlSuite) TestUnmarshalHookWithBadLimits(c *C) {
+	_, err := snap.InfoFromSnapYaml([]byte(`
+name: snap
+hooks:
+    test-hook:
+        limits:
+            memory: lots
+`))
+	c.Assert(err, ErrorMatches, `.*cannot parse size "lots".*`)
+}
+
 func (s *YamlSuite) TestUnmarshalUnsupportedHook(c *C) {
 	s.restore()
 	hookType := snap.NewHookType(regexp.MustCompile("not-test-hook"))
@@ -1773,6 +1807,44 @@ apps:
 	})
 }
 
+func (s *YamlSuite) TestSnapYamlAppStartOrderPlugs(c *C) {
+	y := []byte(`name: wat
+version: 42
+apps:
+ foo:
+   daemon: simple
+   plugs: [db]
+   after-plugs: [db]
+   requires-plugs: [db]
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+
+	app := info.Apps["foo"]
+	c.Check(app.AfterPlugs, DeepEquals, []string{"db"})
+	c.Check(app.RequiresPlugs, DeepEquals, []string{"db"})
+}
+
+func (s *YamlSuite) TestSnapYamlAppStartOrderPlugsNotListed(c *C) {
+	for _, field := range []string{"after-plugs", "requires-plugs"} {
+		// db is implicitly bound to the app but not listed by it
+		y := []byte(`name: wat
+version: 42
+plugs:
+ db:
+   interface: content
+apps:
+ foo:
+   daemon: simple
+   plugs: [network]
+   ` + field + `: [db]
+`)
+		info, err := snap.InfoFromSnapYaml(y)
+		c.Check(info, IsNil)
+		c.Check(err, ErrorMatches, `invalid `+field+` value "db" on app "foo": plug not listed in the app plugs`)
+	}
+}
+
 func (s *YamlSuite) TestSnapYamlWatchdog(c *C) {
 	y := []byte(`
 name: foo
@@ -1855,6 +1927,36 @@ apps:
 	c.Check(app.Timer, DeepEquals, &snap.TimerInfo{App: app, Timer: "mon,10:00-12:00"})
 }
 
+func (s *YamlSuite) TestSnapYamlAppPaths(c *C) {
+	y := []byte(`name: wat
+version: 42
+apps:
+ foo:
+   daemon: oneshot
+   paths:
+     spool:
+       directory-not-empty: $SNAP_COMMON/spool
+     trigger:
+       path-exists: $SNAP_DATA/trigger
+
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+	app := info.Apps["foo"]
+	c.Check(app.Paths, DeepEquals, map[string]*snap.PathInfo{
+		"spool": {
+			App:               app,
+			Name:              "spool",
+			DirectoryNotEmpty: "$SNAP_COMMON/spool",
+		},
+		"trigger": {
+			App:        app,
+			Name:       "trigger",
+			PathExists: "$SNAP_DATA/trigger",
+		},
+	})
+}
+
 func (s *YamlSuite) TestSnapYamlAppAutostart(c *C) {
 	yAutostart := []byte(`name: wat
 version: 42
@@ -1908,6 +2010,25 @@ apps:
 		true)
 }
 
+func (s *YamlSuite) TestSnapYamlAppHandlers(c *C) {
+	y := []byte(`name: wat
+version: 42
+apps:
+ foo:
+   command: bin/foo
+   mime-types: [image/png, text/x-foo]
+   url-schemes: [wat]
+ bar:
+   command: bin/bar
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+	c.Check(info.Apps["foo"].MimeTypes, DeepEquals, []string{"image/png", "text/x-foo"})
+	c.Check(info.Apps["foo"].URLSchemes, DeepEquals, []string{"wat"})
+	c.Check(info.Apps["bar"].MimeTypes, HasLen, 0)
+	c.Check(info.Apps["bar"].URLSchemes, HasLen, 0)
+}
+
 func (s *YamlSuite) TestSnapYamlCommandChain(c *C) {
 	yAutostart := []byte(`name: wat
 version: 42
@@ -164,6 +164,15 @@ func ValidateSocket(name string) error {
 	return nil
 }
 
+// ValidatePathName checks if a string can be used as a name for a path (for
+// path activation).
+func ValidatePathName(name string) error {
+	if !isValidName(name) {
+		return fmt.Errorf("invalid path name: %q", name)
+	}
+	return nil
+}
+
 // ValidSnapID is a regular expression describing a valid snapd-id
 var ValidSnapID = regexp.MustCompile("^[a-z0-9A-Z]{32}$")
 
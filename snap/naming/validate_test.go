@@ -244,6 +244,17 @@ func (s *ValidateSuite) TestValidateSocketName(c *C) {
 	}
 }
 
+func (s *ValidateSuite) TestValidatePathName(c *C) {
+	for _, name := range []string{"a", "spool", "a-0a", "1-or-2"} {
+		err := naming.ValidatePathName(name)
+		c.Assert(err, IsNil)
+	}
+	for _, name := range []string{"", "-", "a--a", "a-", "a a", "a.b", "A"} {
+		err := naming.ValidatePathName(name)
+		c.Assert(err, ErrorMatches, `invalid path name: ".*"`)
+	}
+}
+
 func (s *ValidateSuite) TestValidateSlotPlugInterfaceName(c *C) {
 	valid := []string{
 		"a",
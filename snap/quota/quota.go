@@ -25,6 +25,7 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"time"
 
 	// TODO: move this to snap/quantity? or similar
 	"github.com/snapcore/snapd/gadget/quantity"
@@ -59,6 +60,17 @@ type Group struct {
 	// ExhaustionBehavior. MemoryLimit is expressed in bytes.
 	MemoryLimit quantity.Size `json:"memory-limit,omitempty"`
 
+	// CPUSet is the set of CPUs the processes in the group are allowed to run
+	// on, identified by their number. If empty, the processes can run on all
+	// the CPUs allowed for the parent group, or on any CPU for root groups.
+	// The CPU set of a sub-group must be a subset of the one of its parent.
+	CPUSet []int `json:"cpu-set,omitempty"`
+
+	// JournalLimit, if set, makes the services in the group and in its
+	// sub-groups without their own journal limit log to a dedicated journal
+	// namespace, which is subject to the given limits.
+	JournalLimit *JournalQuota `json:"journal-limit,omitempty"`
+
 	// ParentGroup is the the parent group that this group is a child of. If it
 	// is empty, then this is a "root" quota group.
 	ParentGroup string `json:"parent-group,omitempty"`
@@ -72,6 +84,32 @@ type Group struct {
 	Snaps []string `json:"snaps,omitempty"`
 }
 
+// JournalQuota are the limits of the journal namespace of a quota group.
+type JournalQuota struct {
+	// Size is the maximum disk space the journal namespace can use. If zero,
+	// the default journald limits apply.
+	Size quantity.Size `json:"size,omitempty"`
+
+	// RateCount is the number of messages that can be logged in each
+	// RatePeriod, further messages in the period are dropped. If zero, the
+	// default journald rate limit applies.
+	RateCount  int           `json:"rate-count,omitempty"`
+	RatePeriod time.Duration `json:"rate-period,omitempty"`
+}
+
+func (j *JournalQuota) validate() error {
+	if j.RateCount < 0 || j.RatePeriod < 0 {
+		return fmt.Errorf("journal rate limit cannot be negative")
+	}
+	if (j.RateCount == 0) != (j.RatePeriod == 0) {
+		return fmt.Errorf("journal rate limit needs both a message count and a period")
+	}
+	if j.RatePeriod != 0 && j.RatePeriod < time.Microsecond {
+		return fmt.Errorf("journal rate limit period must be at least 1us")
+	}
+	return nil
+}
+
 // NewGroup creates a new top quota group with the given name and memory limit.
 func NewGroup(name string, memLimit quantity.Size) (*Group, error) {
 	grp := &Group{
@@ -159,6 +197,16 @@ func (grp *Group) validate() error {
 	// TODO: probably there is a minimum amount of bytes here that is
 	// technically usable/enforcable, should we check that too?
 
+	if err := grp.validateCPUSet(); err != nil {
+		return err
+	}
+
+	if grp.JournalLimit != nil {
+		if err := grp.JournalLimit.validate(); err != nil {
+			return err
+		}
+	}
+
 	if grp.ParentGroup != "" && grp.Name == grp.ParentGroup {
 		return fmt.Errorf("group has circular parent reference to itself")
 	}
@@ -193,6 +241,88 @@ func (grp *Group) validate() error {
 	return nil
 }
 
+func (grp *Group) validateCPUSet() error {
+	seen := make(map[int]bool, len(grp.CPUSet))
+	for _, cpu := range grp.CPUSet {
+		if cpu < 0 {
+			return fmt.Errorf("invalid CPU %d in group CPU set", cpu)
+		}
+		if seen[cpu] {
+			return fmt.Errorf("CPU %d is repeated in group CPU set", cpu)
+		}
+		seen[cpu] = true
+	}
+
+	// sub-groups cannot escape the CPUs allowed for their parent group
+	if grp.parentGroup != nil && len(grp.parentGroup.CPUSet) != 0 {
+		parentCPUs := make(map[int]bool, len(grp.parentGroup.CPUSet))
+		for _, cpu := range grp.parentGroup.CPUSet {
+			parentCPUs[cpu] = true
+		}
+		for _, cpu := range grp.CPUSet {
+			if !parentCPUs[cpu] {
+				return fmt.Errorf("sub-group CPU set includes CPU %d not allowed for parent group %s", cpu, grp.parentGroup.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetCPUSet sets the set of CPUs the processes in the group are allowed to
+// run on. The CPU sets of the existing sub-groups must remain subsets of the
+// new one.
+func (grp *Group) SetCPUSet(cpus []int) error {
+	oldCPUs := grp.CPUSet
+	grp.CPUSet = cpus
+
+	err := grp.validate()
+	if err == nil {
+		for _, sub := range grp.subGroups {
+			if err = sub.validateCPUSet(); err != nil {
+				err = fmt.Errorf("cannot restrict CPU set of group with sub-group %s: %v", sub.Name, err)
+				break
+			}
+		}
+	}
+	if err != nil {
+		grp.CPUSet = oldCPUs
+		return err
+	}
+	return nil
+}
+
+// SetJournalLimit sets the limits of the journal namespace of the group.
+func (grp *Group) SetJournalLimit(journal *JournalQuota) error {
+	oldJournal := grp.JournalLimit
+	grp.JournalLimit = journal
+
+	if err := grp.validate(); err != nil {
+		grp.JournalLimit = oldJournal
+		return err
+	}
+	return nil
+}
+
+// JournalNamespaceName returns the name of the journal namespace the services
+// in the group log to, which is the one of the closest group up the tree with
+// a journal limit, or "" if they log to the system journal.
+func (grp *Group) JournalNamespaceName() string {
+	for g := grp; g != nil; g = g.parentGroup {
+		if g.JournalLimit != nil {
+			return "snap-" + g.Name
+		}
+	}
+	return ""
+}
+
+// JournalConfFileName returns the name of the journald configuration file of
+// the journal namespace of the group, which only exists if the group has a
+// journal limit.
+func (grp *Group) JournalConfFileName() string {
+	return fmt.Sprintf("journald@snap-%s.conf", grp.Name)
+}
+
 // NewSubGroup creates a new sub group under the current group.
 func (grp *Group) NewSubGroup(name string, memLimit quantity.Size) (*Group, error) {
 	// TODO: implement a maximum sub-group depth
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"math"
 	"testing"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -276,6 +277,65 @@ func (ts *quotaTestSuite) TestComplexSubGroups(c *C) {
 	c.Assert(subsubsub1.SliceFileName(), Equals, "snap.myroot-sub1-subsub1-subsubsub1.slice")
 }
 
+func (ts *quotaTestSuite) TestSetCPUSet(c *C) {
+	rootGrp, err := quota.NewGroup("myroot", quantity.SizeMiB)
+	c.Assert(err, IsNil)
+
+	c.Assert(rootGrp.SetCPUSet([]int{0, 1, 2}), IsNil)
+	c.Check(rootGrp.CPUSet, DeepEquals, []int{0, 1, 2})
+
+	c.Check(rootGrp.SetCPUSet([]int{0, -1}), ErrorMatches, "invalid CPU -1 in group CPU set")
+	c.Check(rootGrp.SetCPUSet([]int{1, 1}), ErrorMatches, "CPU 1 is repeated in group CPU set")
+	// the CPU set is unchanged on errors
+	c.Check(rootGrp.CPUSet, DeepEquals, []int{0, 1, 2})
+
+	sub, err := rootGrp.NewSubGroup("sub", quantity.SizeMiB/2)
+	c.Assert(err, IsNil)
+	c.Check(sub.SetCPUSet([]int{2, 3}), ErrorMatches, "sub-group CPU set includes CPU 3 not allowed for parent group myroot")
+	c.Assert(sub.SetCPUSet([]int{2}), IsNil)
+
+	// the parent group cannot drop CPUs still allowed for its sub-groups
+	c.Check(rootGrp.SetCPUSet([]int{0, 1}), ErrorMatches, "cannot restrict CPU set of group with sub-group sub: sub-group CPU set includes CPU 2 not allowed for parent group myroot")
+	c.Check(rootGrp.CPUSet, DeepEquals, []int{0, 1, 2})
+	c.Assert(rootGrp.SetCPUSet([]int{2, 5}), IsNil)
+}
+
+func (ts *quotaTestSuite) TestSetJournalLimit(c *C) {
+	rootGrp, err := quota.NewGroup("myroot", quantity.SizeMiB)
+	c.Assert(err, IsNil)
+	sub, err := rootGrp.NewSubGroup("sub", quantity.SizeMiB/2)
+	c.Assert(err, IsNil)
+
+	c.Check(rootGrp.JournalNamespaceName(), Equals, "")
+	c.Check(sub.JournalNamespaceName(), Equals, "")
+
+	journal := &quota.JournalQuota{Size: 64 * quantity.SizeMiB, RateCount: 100, RatePeriod: time.Second}
+	c.Assert(rootGrp.SetJournalLimit(journal), IsNil)
+	c.Check(rootGrp.JournalLimit, DeepEquals, journal)
+	c.Check(rootGrp.JournalNamespaceName(), Equals, "snap-myroot")
+	c.Check(rootGrp.JournalConfFileName(), Equals, "journald@snap-myroot.conf")
+	// sub-groups log to the namespace of their parent
+	c.Check(sub.JournalNamespaceName(), Equals, "snap-myroot")
+
+	for _, t := range []struct {
+		journal *quota.JournalQuota
+		err     string
+	}{
+		{&quota.JournalQuota{RateCount: -1, RatePeriod: time.Second}, "journal rate limit cannot be negative"},
+		{&quota.JournalQuota{RateCount: 100}, "journal rate limit needs both a message count and a period"},
+		{&quota.JournalQuota{RatePeriod: time.Second}, "journal rate limit needs both a message count and a period"},
+		{&quota.JournalQuota{RateCount: 1, RatePeriod: time.Nanosecond}, "journal rate limit period must be at least 1us"},
+	} {
+		c.Check(rootGrp.SetJournalLimit(t.journal), ErrorMatches, t.err)
+		// the limit is unchanged on errors
+		c.Check(rootGrp.JournalLimit, DeepEquals, journal)
+	}
+
+	// a sub-group can use its own namespace, with no specific limits
+	c.Assert(sub.SetJournalLimit(&quota.JournalQuota{}), IsNil)
+	c.Check(sub.JournalNamespaceName(), Equals, "snap-sub")
+}
+
 func (ts *quotaTestSuite) TestResolveCrossReferences(c *C) {
 	tt := []struct {
 		grps    map[string]*quota.Group
@@ -0,0 +1,108 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snap
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SnapshotOptions holds the options a snap can declare in
+// meta/snapshots.yaml to tune how its data is saved in snapshots.
+type SnapshotOptions struct {
+	// Exclude is a list of paths, possibly with wildcards, that are
+	// left out of snapshots. Each must start with one of $SNAP_DATA,
+	// $SNAP_COMMON, $SNAP_USER_DATA or $SNAP_USER_COMMON.
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+var snapshotExcludeVars = []string{
+	"$SNAP_DATA",
+	"$SNAP_COMMON",
+	"$SNAP_USER_DATA",
+	"$SNAP_USER_COMMON",
+}
+
+func validateSnapshotExclude(pattern string) error {
+	for _, v := range snapshotExcludeVars {
+		rest := strings.TrimPrefix(pattern, v+"/")
+		if rest == pattern {
+			continue
+		}
+		if rest == "" || filepath.Clean(rest) != rest || rest == ".." || strings.HasPrefix(rest, "../") {
+			return fmt.Errorf("snapshot exclude path must be clean and below %s: %q", v, pattern)
+		}
+		return nil
+	}
+	return fmt.Errorf("snapshot exclude path must start with one of %s: %q", strings.Join(snapshotExcludeVars, ", "), pattern)
+}
+
+// Validate checks that the snapshot options are valid.
+func (opts *SnapshotOptions) Validate() error {
+	for _, pattern := range opts.Exclude {
+		if err := validateSnapshotExclude(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExcludesUnder returns the exclude patterns that start with the given
+// variable, relative to the directory the variable points to.
+func (opts *SnapshotOptions) ExcludesUnder(v string) []string {
+	var patterns []string
+	for _, pattern := range opts.Exclude {
+		if rest := strings.TrimPrefix(pattern, v+"/"); rest != pattern {
+			patterns = append(patterns, rest)
+		}
+	}
+	return patterns
+}
+
+// ReadSnapshotYaml reads the snapshot options from meta/snapshots.yaml
+// of the given installed snap. A snap without meta/snapshots.yaml has
+// no options.
+func ReadSnapshotYaml(si *Info) (*SnapshotOptions, error) {
+	fn := filepath.Join(si.MountDir(), "meta", "snapshots.yaml")
+	data, err := ioutil.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return &SnapshotOptions{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return readSnapshotYaml(data)
+}
+
+func readSnapshotYaml(data []byte) (*SnapshotOptions, error) {
+	var opts SnapshotOptions
+	if err := yaml.UnmarshalStrict(data, &opts); err != nil {
+		return nil, fmt.Errorf("cannot parse snapshots.yaml: %v", err)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("cannot validate snapshots.yaml: %v", err)
+	}
+	return &opts, nil
+}
@@ -0,0 +1,93 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snap_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type snapshotSuite struct{}
+
+var _ = Suite(&snapshotSuite{})
+
+func (s *snapshotSuite) SetUpTest(c *C) {
+	dirs.SetRootDir(c.MkDir())
+}
+
+func (s *snapshotSuite) TearDownTest(c *C) {
+	dirs.SetRootDir("")
+}
+
+func (s *snapshotSuite) mockSnapWithSnapshotYaml(c *C, snapshotYaml string) *snap.Info {
+	info := snaptest.MockSnap(c, "name: foo\nversion: 1.0", &snap.SideInfo{Revision: snap.R(1)})
+	if snapshotYaml != "" {
+		fn := filepath.Join(info.MountDir(), "meta", "snapshots.yaml")
+		c.Assert(ioutil.WriteFile(fn, []byte(snapshotYaml), 0644), IsNil)
+	}
+	return info
+}
+
+func (s *snapshotSuite) TestReadSnapshotYamlMissing(c *C) {
+	info := s.mockSnapWithSnapshotYaml(c, "")
+	opts, err := snap.ReadSnapshotYaml(info)
+	c.Assert(err, IsNil)
+	c.Check(opts, DeepEquals, &snap.SnapshotOptions{})
+}
+
+func (s *snapshotSuite) TestReadSnapshotYaml(c *C) {
+	info := s.mockSnapWithSnapshotYaml(c, `exclude:
+  - $SNAP_DATA/cache
+  - $SNAP_COMMON/*.log
+  - $SNAP_USER_COMMON/.cache/*
+`)
+	opts, err := snap.ReadSnapshotYaml(info)
+	c.Assert(err, IsNil)
+	c.Check(opts.Exclude, DeepEquals, []string{"$SNAP_DATA/cache", "$SNAP_COMMON/*.log", "$SNAP_USER_COMMON/.cache/*"})
+
+	c.Check(opts.ExcludesUnder("$SNAP_DATA"), DeepEquals, []string{"cache"})
+	c.Check(opts.ExcludesUnder("$SNAP_COMMON"), DeepEquals, []string{"*.log"})
+	c.Check(opts.ExcludesUnder("$SNAP_USER_DATA"), HasLen, 0)
+	c.Check(opts.ExcludesUnder("$SNAP_USER_COMMON"), DeepEquals, []string{".cache/*"})
+}
+
+func (s *snapshotSuite) TestReadSnapshotYamlInvalid(c *C) {
+	for _, t := range []struct {
+		yaml string
+		err  string
+	}{
+		{"exclude: [$SNAP/foo]", `cannot validate snapshots.yaml: snapshot exclude path must start with one of \$SNAP_DATA, \$SNAP_COMMON, \$SNAP_USER_DATA, \$SNAP_USER_COMMON: "\$SNAP/foo"`},
+		{"exclude: [$SNAP_DATA]", `cannot validate snapshots.yaml: snapshot exclude path must start with one of .*`},
+		{"exclude: [$SNAP_DATA/]", `cannot validate snapshots.yaml: snapshot exclude path must be clean and below \$SNAP_DATA: "\$SNAP_DATA/"`},
+		{"exclude: [$SNAP_DATA/../foo]", `cannot validate snapshots.yaml: snapshot exclude path must be clean and below \$SNAP_DATA: .*`},
+		{"exclude: [$SNAP_COMMON/foo//bar]", `cannot validate snapshots.yaml: snapshot exclude path must be clean and below \$SNAP_COMMON: .*`},
+		{"include: [$SNAP_DATA/foo]", `(?s)cannot parse snapshots.yaml: .*`},
+	} {
+		info := s.mockSnapWithSnapshotYaml(c, t.yaml)
+		_, err := snap.ReadSnapshotYaml(info)
+		c.Check(err, ErrorMatches, t.err, Commentf(t.yaml))
+	}
+}
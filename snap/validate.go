@@ -174,6 +174,19 @@ func ValidateHook(hook *HookInfo) error {
 		}
 	}
 
+	return validateHookLimits(hook.Limits)
+}
+
+func validateHookLimits(limits *HookLimits) error {
+	if limits == nil {
+		return nil
+	}
+	if limits.CPUPercentage < 0 {
+		return fmt.Errorf("hook cpu-percentage limit cannot be negative")
+	}
+	if limits.Timeout < 0 {
+		return fmt.Errorf("hook timeout limit cannot be negative")
+	}
 	return nil
 }
 
@@ -389,6 +402,11 @@ func Validate(info *Info) error {
 		return err
 	}
 
+	// ensure that url-schemes are handled by a single app
+	if err := ValidateURLSchemes(info); err != nil {
+		return err
+	}
+
 	return ValidateLayoutAll(info)
 }
 
@@ -553,6 +571,62 @@ func validateAppSocket(socket *SocketInfo) error {
 	return validateSocketAddr(socket, "listen-stream", socket.ListenStream)
 }
 
+// validateAppPath checks a path watched to activate a service. The watched
+// paths are restricted to the writable areas of the snap, which its services
+// can access without any additional interface.
+func validateAppPath(path *PathInfo) error {
+	if !path.App.IsService() {
+		return errors.New("paths are only applicable to services")
+	}
+
+	if err := naming.ValidatePathName(path.Name); err != nil {
+		return err
+	}
+
+	var fieldName, watched string
+	for _, cond := range []struct {
+		field string
+		value string
+	}{
+		{"path-exists", path.PathExists},
+		{"path-changed", path.PathChanged},
+		{"path-modified", path.PathModified},
+		{"directory-not-empty", path.DirectoryNotEmpty},
+	} {
+		if cond.value == "" {
+			continue
+		}
+		if fieldName != "" {
+			return fmt.Errorf("cannot use %q and %q together", fieldName, cond.field)
+		}
+		fieldName, watched = cond.field, cond.value
+	}
+	if fieldName == "" {
+		return errors.New("one of path-exists, path-changed, path-modified or directory-not-empty must be defined")
+	}
+
+	if clean := filepath.Clean(watched); clean != watched {
+		return fmt.Errorf("invalid %q: %q should be written as %q", fieldName, watched, clean)
+	}
+
+	switch path.App.DaemonScope {
+	case SystemDaemon:
+		if !(strings.HasPrefix(watched, "$SNAP_DATA/") || strings.HasPrefix(watched, "$SNAP_COMMON/") || strings.HasPrefix(watched, "$XDG_RUNTIME_DIR/")) {
+			return fmt.Errorf(
+				"invalid %q: system daemon paths must have a prefix of $SNAP_DATA, $SNAP_COMMON or $XDG_RUNTIME_DIR", fieldName)
+		}
+	case UserDaemon:
+		if !(strings.HasPrefix(watched, "$SNAP_USER_DATA/") || strings.HasPrefix(watched, "$SNAP_USER_COMMON/") || strings.HasPrefix(watched, "$XDG_RUNTIME_DIR/")) {
+			return fmt.Errorf(
+				"invalid %q: user daemon paths must have a prefix of $SNAP_USER_DATA, $SNAP_USER_COMMON, or $XDG_RUNTIME_DIR", fieldName)
+		}
+	default:
+		return fmt.Errorf("invalid %q: cannot validate paths for daemon-scope %q", fieldName, path.App.DaemonScope)
+	}
+
+	return nil
+}
+
 // validateAppOrderCycles checks for cycles in app ordering dependencies
 func validateAppOrderCycles(apps []*AppInfo) error {
 	if _, err := SortServices(apps); err != nil {
@@ -585,6 +659,15 @@ func validateAppOrderNames(app *AppInfo, dependencies []string) error {
 	return nil
 }
 
+func validateAppOrderPlugs(app *AppInfo, field string, plugs []string) error {
+	// we must be a service to request ordering, the plugs were checked
+	// to be listed by the app when parsing snap.yaml
+	if len(plugs) > 0 && !app.IsService() {
+		return fmt.Errorf("must be a service to define %s ordering", field)
+	}
+	return nil
+}
+
 func validateAppTimeouts(app *AppInfo) error {
 	type T struct {
 		desc    string
@@ -760,6 +843,12 @@ func ValidateApp(app *AppInfo) error {
 		}
 	}
 
+	for _, path := range app.Paths {
+		if err := validateAppPath(path); err != nil {
+			return fmt.Errorf("invalid definition of path %q: %v", path.Name, err)
+		}
+	}
+
 	if err := validateAppActivatesOn(app); err != nil {
 		return err
 	}
@@ -773,6 +862,12 @@ func ValidateApp(app *AppInfo) error {
 	if err := validateAppOrderNames(app, app.After); err != nil {
 		return err
 	}
+	if err := validateAppOrderPlugs(app, "after-plugs", app.AfterPlugs); err != nil {
+		return err
+	}
+	if err := validateAppOrderPlugs(app, "requires-plugs", app.RequiresPlugs); err != nil {
+		return err
+	}
 
 	if err := validateAppTimeouts(app); err != nil {
 		return err
@@ -796,6 +891,21 @@ func ValidateApp(app *AppInfo) error {
 	default:
 		return fmt.Errorf(`"install-mode" field contains invalid value %q`, app.InstallMode)
 	}
+	// validate tmp-mode
+	switch app.TmpMode {
+	case "", "private":
+		// valid
+	case "host", "shared":
+		// using a non-private /tmp is gated by the "shared-tmp" interface
+		if !appHasPlugOfInterface(app, "shared-tmp") {
+			return fmt.Errorf(`"shared-tmp" interface plug is required when "tmp-mode" is %q`, app.TmpMode)
+		}
+	default:
+		return fmt.Errorf(`"tmp-mode" field contains invalid value %q`, app.TmpMode)
+	}
+	if err := validateAppHandlers(app); err != nil {
+		return err
+	}
 	if app.StopMode != "" && app.Daemon == "" {
 		return fmt.Errorf(`"stop-mode" cannot be used for %q, only for services`, app.Name)
 	}
@@ -809,6 +919,50 @@ func ValidateApp(app *AppInfo) error {
 	return validateAppTimer(app)
 }
 
+// validMimeType matches the type/subtype names allowed by RFC 6838.
+var validMimeType = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9!#$&^_.+-]{0,126}/[a-zA-Z0-9][a-zA-Z0-9!#$&^_.+-]{0,126}$`)
+
+// validURLScheme matches the URL schemes allowed by RFC 3986, restricted
+// to lowercase as schemes are case-insensitive.
+var validURLScheme = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+
+func validateAppHandlers(app *AppInfo) error {
+	if len(app.MimeTypes) == 0 && len(app.URLSchemes) == 0 {
+		return nil
+	}
+	if app.IsService() {
+		return fmt.Errorf(`"mime-types" and "url-schemes" cannot be used for %q, only for applications`, app.Name)
+	}
+	for _, mimeType := range app.MimeTypes {
+		if !validMimeType.MatchString(mimeType) {
+			return fmt.Errorf(`"mime-types" field contains invalid value %q`, mimeType)
+		}
+		// handling URL schemes is mediated separately
+		if strings.HasPrefix(strings.ToLower(mimeType), "x-scheme-handler/") {
+			return fmt.Errorf(`"mime-types" field cannot contain %q, use "url-schemes" instead`, mimeType)
+		}
+	}
+	for _, scheme := range app.URLSchemes {
+		if !validURLScheme.MatchString(scheme) {
+			return fmt.Errorf(`"url-schemes" field contains invalid value %q`, scheme)
+		}
+	}
+	// handling URL schemes is gated by the "url-handler" interface
+	if len(app.URLSchemes) > 0 && !appHasPlugOfInterface(app, "url-handler") {
+		return fmt.Errorf(`"url-handler" interface plug is required when "url-schemes" are used`)
+	}
+	return nil
+}
+
+func appHasPlugOfInterface(app *AppInfo, iface string) bool {
+	for _, plug := range app.Plugs {
+		if plug.Interface == iface {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidatePathVariables ensures that given path contains only $SNAP, $SNAP_DATA or $SNAP_COMMON.
 func ValidatePathVariables(path string) error {
 	for path != "" {
@@ -1075,6 +1229,22 @@ func ValidateCommonIDs(info *Info) error {
 	return nil
 }
 
+// ValidateURLSchemes checks that each URL scheme is handled by a
+// single application of the snap.
+func ValidateURLSchemes(info *Info) error {
+	seen := make(map[string]string)
+	for _, app := range info.Apps {
+		for _, scheme := range app.URLSchemes {
+			if other, was := seen[scheme]; was && other != app.Name {
+				return fmt.Errorf("application %q url-scheme %q must be unique, already used by application %q",
+					app.Name, scheme, other)
+			}
+			seen[scheme] = app.Name
+		}
+	}
+	return nil
+}
+
 func ValidateSystemUsernames(info *Info) error {
 	for username := range info.SystemUsernames {
 		if !osutil.IsValidUsername(username) {
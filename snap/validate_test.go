@@ -170,6 +170,17 @@ func (s *ValidateSuite) TestValidateHook(c *C) {
 	}
 }
 
+func (s *ValidateSuite) TestValidateHookLimits(c *C) {
+	hook := &HookInfo{Name: "configure", Limits: &HookLimits{Memory: 1024, CPUPercentage: 200, Timeout: 10}}
+	c.Check(ValidateHook(hook), IsNil)
+
+	hook.Limits = &HookLimits{CPUPercentage: -1}
+	c.Check(ValidateHook(hook), ErrorMatches, `hook cpu-percentage limit cannot be negative`)
+
+	hook.Limits = &HookLimits{Timeout: -1}
+	c.Check(ValidateHook(hook), ErrorMatches, `hook timeout limit cannot be negative`)
+}
+
 // ValidateApp
 
 func (s *ValidateSuite) TestValidateAppSockets(c *C) {
@@ -421,6 +432,69 @@ func (s *ValidateSuite) TestValidateAppUserSocketsInvalidListenStreamPort(c *C)
 	}
 }
 
+func (s *ValidateSuite) TestValidateAppPaths(c *C) {
+	app := createSampleApp()
+	app.Sockets = nil
+	path := &PathInfo{App: app, Name: "spool"}
+	app.Paths = map[string]*PathInfo{"spool": path}
+
+	for _, tc := range []struct {
+		scope DaemonScope
+		path  PathInfo
+		err   string
+	}{{
+		path: PathInfo{DirectoryNotEmpty: "$SNAP_COMMON/spool"},
+	}, {
+		path: PathInfo{PathExists: "$SNAP_DATA/trigger"},
+	}, {
+		path: PathInfo{PathChanged: "$XDG_RUNTIME_DIR/trigger"},
+	}, {
+		scope: UserDaemon,
+		path:  PathInfo{PathModified: "$SNAP_USER_DATA/trigger"},
+	}, {
+		path: PathInfo{},
+		err:  `invalid definition of path "spool": one of path-exists, path-changed, path-modified or directory-not-empty must be defined`,
+	}, {
+		path: PathInfo{PathExists: "$SNAP_DATA/a", PathChanged: "$SNAP_DATA/b"},
+		err:  `invalid definition of path "spool": cannot use "path-exists" and "path-changed" together`,
+	}, {
+		path: PathInfo{PathExists: "$SNAP_DATA/../a"},
+		err:  `invalid definition of path "spool": invalid "path-exists": "\$SNAP_DATA/../a" should be written as "a"`,
+	}, {
+		path: PathInfo{PathModified: "/etc/passwd"},
+		err:  `invalid definition of path "spool": invalid "path-modified": system daemon paths must have a prefix of .*`,
+	}, {
+		scope: UserDaemon,
+		path:  PathInfo{DirectoryNotEmpty: "$SNAP_COMMON/spool"},
+		err:   `invalid definition of path "spool": invalid "directory-not-empty": user daemon paths must have a prefix of .*`,
+	}} {
+		app.DaemonScope = SystemDaemon
+		if tc.scope != "" {
+			app.DaemonScope = tc.scope
+		}
+		*path = tc.path
+		path.App = app
+		path.Name = "spool"
+		err := ValidateApp(app)
+		if tc.err == "" {
+			c.Check(err, IsNil, Commentf("%+v", tc.path))
+		} else {
+			c.Check(err, ErrorMatches, tc.err, Commentf("%+v", tc.path))
+		}
+	}
+
+	// invalid names
+	path.Name = "a--a"
+	path.PathExists = "$SNAP_DATA/trigger"
+	c.Check(ValidateApp(app), ErrorMatches, `invalid definition of path "a--a": invalid path name: "a--a"`)
+
+	// only services can be activated by paths
+	path.Name = "spool"
+	app.Daemon = ""
+	app.DaemonScope = ""
+	c.Check(ValidateApp(app), ErrorMatches, `invalid definition of path "spool": paths are only applicable to services`)
+}
+
 func (s *ValidateSuite) TestAppWhitelistSimple(c *C) {
 	c.Check(ValidateApp(&AppInfo{Name: "foo", Command: "foo"}), IsNil)
 	c.Check(ValidateApp(&AppInfo{Name: "foo", StopCommand: "foo"}), IsNil)
@@ -1449,6 +1523,52 @@ apps:
 	}
 }
 
+func (s *ValidateSuite) TestValidateAppOrderPlugs(c *C) {
+	meta := []byte(`
+name: foo
+version: 1.0
+plugs:
+  db:
+    interface: content
+`)
+	tcs := []struct {
+		name string
+		desc []byte
+		err  string
+	}{{
+		name: "all good",
+		desc: []byte(`
+apps:
+ foo:
+   daemon: simple
+   plugs: [db, network]
+   after-plugs: [db]
+   requires-plugs: [db]
+`),
+	}, {
+		name: "not a daemon",
+		desc: []byte(`
+apps:
+ foo:
+   plugs: [db]
+   after-plugs: [db]
+`),
+		err: `invalid definition of application "foo": must be a service to define after-plugs ordering`,
+	}}
+	for _, tc := range tcs {
+		c.Logf("trying %q", tc.name)
+		info, err := InfoFromSnapYaml(append(meta, tc.desc...))
+		c.Assert(err, IsNil)
+
+		err = Validate(info)
+		if tc.err != "" {
+			c.Assert(err, ErrorMatches, tc.err)
+		} else {
+			c.Assert(err, IsNil)
+		}
+	}
+}
+
 func (s *ValidateSuite) TestValidateAppWatchdogTimeout(c *C) {
 	s.testValidateAppTimeout(c, "watchdog")
 }
@@ -2017,6 +2137,110 @@ func (s *ValidateSuite) TestAppInstallMode(c *C) {
 	c.Check(err, ErrorMatches, `"install-mode" cannot be used for "foo", only for services`)
 }
 
+func (s *ValidateSuite) TestAppTmpMode(c *C) {
+	sharedTmp := map[string]*PlugInfo{"tmp": {Name: "tmp", Interface: "shared-tmp"}}
+	for _, t := range []struct {
+		tmpMode string
+		plugs   map[string]*PlugInfo
+		err     string
+	}{
+		// good
+		{"", nil, ""},
+		{"private", nil, ""},
+		{"host", sharedTmp, ""},
+		{"shared", sharedTmp, ""},
+		// bad
+		{"host", nil, `"shared-tmp" interface plug is required when "tmp-mode" is "host"`},
+		{"shared", map[string]*PlugInfo{"shared-tmp": {Name: "shared-tmp", Interface: "home"}}, `"shared-tmp" interface plug is required when "tmp-mode" is "shared"`},
+		{"invalid-thing", sharedTmp, `"tmp-mode" field contains invalid value "invalid-thing"`},
+	} {
+		err := ValidateApp(&AppInfo{Name: "foo", TmpMode: t.tmpMode, Plugs: t.plugs})
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf("%q", t.tmpMode))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf("%q", t.tmpMode))
+		}
+	}
+}
+
+func (s *ValidateSuite) TestAppHandlers(c *C) {
+	urlHandler := map[string]*PlugInfo{"handler": {Name: "handler", Interface: "url-handler"}}
+	for _, t := range []struct {
+		daemon     string
+		mimeTypes  []string
+		urlSchemes []string
+		plugs      map[string]*PlugInfo
+		err        string
+	}{
+		// good
+		{"", nil, nil, nil, ""},
+		{"", []string{"image/png", "application/vnd.foo+json"}, nil, nil, ""},
+		{"", nil, []string{"zoommtg", "web+foo", "x.y-z"}, urlHandler, ""},
+		// bad
+		{"simple", []string{"image/png"}, nil, nil, `"mime-types" and "url-schemes" cannot be used for "foo", only for applications`},
+		{"", []string{"image"}, nil, nil, `"mime-types" field contains invalid value "image"`},
+		{"", []string{"image/png;text/plain"}, nil, nil, `"mime-types" field contains invalid value "image/png;text/plain"`},
+		{"", []string{"x-scheme-handler/foo"}, nil, nil, `"mime-types" field cannot contain "x-scheme-handler/foo", use "url-schemes" instead`},
+		{"", nil, []string{"Foo"}, urlHandler, `"url-schemes" field contains invalid value "Foo"`},
+		{"", nil, []string{"foo:"}, urlHandler, `"url-schemes" field contains invalid value "foo:"`},
+		{"", nil, []string{"1foo"}, urlHandler, `"url-schemes" field contains invalid value "1foo"`},
+		{"", nil, []string{"foo"}, nil, `"url-handler" interface plug is required when "url-schemes" are used`},
+	} {
+		app := &AppInfo{Name: "foo", Daemon: t.daemon, MimeTypes: t.mimeTypes, URLSchemes: t.urlSchemes, Plugs: t.plugs}
+		if t.daemon != "" {
+			app.DaemonScope = SystemDaemon
+		}
+		err := ValidateApp(app)
+		comment := Commentf("%q %q", t.mimeTypes, t.urlSchemes)
+		if t.err == "" {
+			c.Check(err, IsNil, comment)
+		} else {
+			c.Check(err, ErrorMatches, t.err, comment)
+		}
+	}
+}
+
+func (s *ValidateSuite) TestValidateURLSchemes(c *C) {
+	meta := `
+name: foo
+version: 1.0
+plugs:
+  url-handler:
+`
+	good := meta + `
+apps:
+  foo:
+    url-schemes: [foo, foo-bar]
+  bar:
+    url-schemes: [bar]
+`
+	bad := meta + `
+apps:
+  foo:
+    url-schemes: [foo]
+  bar:
+    url-schemes: [foo]
+`
+	for i, tc := range []struct {
+		meta string
+		err  string
+	}{
+		{good, ""},
+		{bad, `application ("bar" url-scheme "foo" must be unique, already used by application "foo"|"foo" url-scheme "foo" must be unique, already used by application "bar")`},
+	} {
+		c.Logf("tc #%v", i)
+		info, err := InfoFromSnapYaml([]byte(tc.meta))
+		c.Assert(err, IsNil)
+
+		err = Validate(info)
+		if tc.err == "" {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, tc.err)
+		}
+	}
+}
+
 func (s *ValidateSuite) TestValidateLinks(c *C) {
 	info, err := InfoFromSnapYaml([]byte(`name: foo
 version: 1.0
@@ -209,6 +209,40 @@ func (cm *CacheManager) cleanup() error {
 	return lastErr
 }
 
+// Prune removes all the items from the cache that are not referenced
+// elsewhere in the filesystem, returning the amount of disk space that was
+// reclaimed.
+func (cm *CacheManager) Prune() (reclaimed uint64, err error) {
+	fil, err := ioutil.ReadDir(cm.cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var lastErr error
+	for _, fi := range fil {
+		n, err := hardLinkCount(fi)
+		if err != nil {
+			logger.Noticef("cannot inspect cache: %s", err)
+		}
+		// items referenced elsewhere do not take any extra space
+		if n > 1 {
+			continue
+		}
+		if err := osRemove(cm.path(fi.Name())); err != nil {
+			if !os.IsNotExist(err) {
+				logger.Noticef("cannot prune cache: %s", err)
+				lastErr = err
+			}
+			continue
+		}
+		reclaimed += uint64(fi.Size())
+	}
+	return reclaimed, lastErr
+}
+
 // hardLinkCount returns the number of hardlinks for the given path
 func hardLinkCount(fi os.FileInfo) (uint64, error) {
 	if stat, ok := fi.Sys().(*syscall.Stat_t); ok && stat != nil {
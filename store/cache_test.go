@@ -183,6 +183,32 @@ func (s *cacheSuite) TestClenaupContinuesOnError(c *C) {
 	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[0])), Equals, true)
 }
 
+func (s *cacheSuite) TestPrune(c *C) {
+	cacheKeys, testFiles := s.makeTestFiles(c, 3)
+	// the first two files are now only in the cache
+	for _, p := range testFiles[:2] {
+		err := os.Remove(p)
+		c.Assert(err, IsNil)
+	}
+
+	reclaimed, err := s.cm.Prune()
+	c.Assert(err, IsNil)
+	// each test file holds a single digit
+	c.Check(reclaimed, Equals, uint64(2))
+
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[0])), Equals, false)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[1])), Equals, false)
+	// still referenced elsewhere
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[2])), Equals, true)
+}
+
+func (s *cacheSuite) TestPruneNoCacheDir(c *C) {
+	cm := store.NewCacheManager(filepath.Join(c.MkDir(), "missing"), 1)
+	reclaimed, err := cm.Prune()
+	c.Assert(err, IsNil)
+	c.Check(reclaimed, Equals, uint64(0))
+}
+
 func (s *cacheSuite) TestHardLinkCount(c *C) {
 	p := filepath.Join(s.tmp, "foo")
 	err := ioutil.WriteFile(p, nil, 0644)
@@ -23,15 +23,19 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/juju/ratelimit"
@@ -45,6 +49,7 @@ import (
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 type downloadSuite struct {
@@ -82,10 +87,12 @@ func (s *downloadSuite) TestActualDownload(c *C) {
 	var buf SillyBuffer
 	// keep tests happy
 	sha3 := ""
+	downloaded := store.DownloadedBytes()
 	err := store.Download(context.TODO(), "foo", sha3, mockServer.URL, nil, theStore, &buf, 0, nil, nil)
 	c.Assert(err, IsNil)
 	c.Check(buf.String(), Equals, "response-data")
 	c.Check(n, Equals, 1)
+	c.Check(store.DownloadedBytes()-downloaded, Equals, int64(len("response-data")))
 }
 
 func (s *downloadSuite) TestActualDownloadAutoRefresh(c *C) {
@@ -288,6 +295,74 @@ func (s *downloadSuite) TestActualDownload500Once(c *C) {
 	c.Check(n, Equals, 2)
 }
 
+func (s *downloadSuite) TestActualDownloadErrorCounts(c *C) {
+	n := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.WriteHeader(503)
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	before := store.ErrorCounts()[store.ErrorKindServer]
+	theStore := store.New(&store.Config{}, nil)
+	var buf SillyBuffer
+	err := store.Download(context.TODO(), "foo", "sha3", mockServer.URL, nil, theStore, &buf, 0, nil, nil)
+	c.Assert(err, FitsTypeOf, &store.DownloadError{})
+	c.Check(store.ErrorKindOf(err), Equals, store.ErrorKindServer)
+	c.Check(n, Equals, 5)
+	// every attempt is counted
+	c.Check(store.ErrorCounts()[store.ErrorKindServer]-before, Equals, 5)
+}
+
+func (s *downloadSuite) TestActualDownloadPaymentRequired(c *C) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(402)
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	theStore := store.New(&store.Config{}, nil)
+	var buf SillyBuffer
+	err := store.Download(context.TODO(), "foo", "sha3", mockServer.URL, nil, theStore, &buf, 0, nil, nil)
+	c.Assert(err, ErrorMatches, "please buy foo before installing it")
+	c.Check(store.ErrorKindOf(err), Equals, store.ErrorKindPaymentRequired)
+}
+
+func (s *downloadSuite) TestErrorKindOf(c *C) {
+	dnsErr := &url.Error{Op: "Get", URL: "https://api.snapcraft.io", Err: &net.OpError{
+		Op:  "dial",
+		Err: &net.DNSError{Err: "no such host", Name: "api.snapcraft.io"},
+	}}
+	unreachableErr := &url.Error{Op: "Get", URL: "https://api.snapcraft.io", Err: &net.OpError{
+		Op:  "dial",
+		Err: &os.SyscallError{Syscall: "connect", Err: syscall.ENETUNREACH},
+	}}
+	refusedErr := &url.Error{Op: "Get", URL: "https://api.snapcraft.io", Err: &net.OpError{
+		Op:  "dial",
+		Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED},
+	}}
+	tlsErr := &url.Error{Op: "Get", URL: "https://api.snapcraft.io", Err: x509.UnknownAuthorityError{}}
+
+	for _, t := range []struct {
+		err  error
+		kind store.ErrorKind
+	}{
+		{dnsErr, store.ErrorKindDNS},
+		{unreachableErr, store.ErrorKindNetwork},
+		{refusedErr, store.ErrorKindNetwork},
+		{tlsErr, store.ErrorKindTLS},
+		{&store.DownloadError{Code: 429}, store.ErrorKindRateLimited},
+		{&store.DownloadError{Code: 502}, store.ErrorKindServer},
+		{&store.DownloadError{Code: 404}, store.ErrorKindOther},
+		{fmt.Errorf("cannot download: %w", &store.DownloadError{Code: 503}), store.ErrorKindServer},
+		{&store.PaymentRequiredError{Name: "foo"}, store.ErrorKindPaymentRequired},
+		{errors.New("some error"), store.ErrorKindOther},
+	} {
+		c.Check(store.ErrorKindOf(t.err), Equals, t.kind, Commentf("%v", t.err))
+	}
+}
+
 // SillyBuffer is a ReadWriteSeeker buffer with a limited size for the tests
 // (bytes does not implement an ReadWriteSeeker)
 type SillyBuffer struct {
@@ -674,14 +749,98 @@ func (s *downloadSuite) TestDownloadWithDelta(c *C) {
 		})
 		defer restore()
 
+		deltaApplied := false
+		dlOpts := &store.DownloadOptions{
+			DeltaApplied: func() { deltaApplied = true },
+		}
+
 		theStore := store.New(&store.Config{}, nil)
 		path := filepath.Join(c.MkDir(), "subdir", "downloaded-file")
-		err := theStore.Download(context.TODO(), "foo", path, &testCase.info, nil, nil, nil)
+		err := theStore.Download(context.TODO(), "foo", path, &testCase.info, nil, nil, dlOpts)
 
 		c.Assert(err, IsNil)
 		defer os.Remove(path)
 		c.Assert(path, testutil.FileEquals, testCase.expectedContent)
+		c.Check(deltaApplied, Equals, testCase.expectedContent == "snap-content-via-delta")
+	}
+}
+
+func (s *downloadSuite) TestDownloadWithDeltaRequiredNoFallback(c *C) {
+	origUseDeltas := os.Getenv("SNAPD_USE_DELTAS_EXPERIMENTAL")
+	defer os.Setenv("SNAPD_USE_DELTAS_EXPERIMENTAL", origUseDeltas)
+	c.Assert(os.Setenv("SNAPD_USE_DELTAS_EXPERIMENTAL", "1"), IsNil)
+
+	var urls []string
+	restore := store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		urls = append(urls, url)
+		w.Write([]byte(url + "-content"))
+		return nil
+	})
+	defer restore()
+	restore = store.MockApplyDelta(func(_ *store.Store, name string, deltaPath string, deltaInfo *snap.DeltaInfo, targetPath string, targetSha3_384 string) error {
+		return errors.New("xdelta3 failed")
+	})
+	defer restore()
+
+	info := &snap.DownloadInfo{
+		AnonDownloadURL: "full-snap-url",
+		Deltas: []snap.DeltaInfo{
+			{AnonDownloadURL: "delta-url", Format: "xdelta3"},
+		},
+	}
+
+	theStore := store.New(&store.Config{}, nil)
+	path := filepath.Join(c.MkDir(), "subdir", "downloaded-file")
+	err := theStore.Download(context.TODO(), "foo", path, info, nil, nil, &store.DownloadOptions{DeltasRequired: true})
+	c.Assert(err, ErrorMatches, `cannot download or apply delta for foo and deltas are required: xdelta3 failed`)
+	// no fallback to the full snap
+	c.Check(urls, DeepEquals, []string{"delta-url"})
+	c.Check(path, testutil.FileAbsent)
+}
+
+func (s *downloadSuite) TestDownloadWithDeltaRequiredDeltasUnsupported(c *C) {
+	origUseDeltas := os.Getenv("SNAPD_USE_DELTAS_EXPERIMENTAL")
+	defer os.Setenv("SNAPD_USE_DELTAS_EXPERIMENTAL", origUseDeltas)
+	c.Assert(os.Setenv("SNAPD_USE_DELTAS_EXPERIMENTAL", "0"), IsNil)
+
+	restore := store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		c.Fatalf("unexpected download of %q", url)
+		return nil
+	})
+	defer restore()
+
+	info := &snap.DownloadInfo{
+		AnonDownloadURL: "full-snap-url",
+		Deltas: []snap.DeltaInfo{
+			{AnonDownloadURL: "delta-url", Format: "xdelta3"},
+		},
+	}
+
+	theStore := store.New(&store.Config{}, nil)
+	path := filepath.Join(c.MkDir(), "subdir", "downloaded-file")
+	err := theStore.Download(context.TODO(), "foo", path, info, nil, nil, &store.DownloadOptions{DeltasRequired: true})
+	c.Assert(err, ErrorMatches, `cannot use delta for foo and deltas are required: deltas are not supported on this system`)
+}
+
+func (s *downloadSuite) TestDownloadWithDeltaRequiredNoDeltaAvailable(c *C) {
+	restore := store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		c.Check(url, Equals, "full-snap-url")
+		w.Write([]byte("full-snap-url-content"))
+		return nil
+	})
+	defer restore()
+
+	content := "full-snap-url-content"
+	info := &snap.DownloadInfo{
+		AnonDownloadURL: "full-snap-url",
+		Size:            int64(len(content)),
 	}
+
+	theStore := store.New(&store.Config{}, nil)
+	path := filepath.Join(c.MkDir(), "subdir", "downloaded-file")
+	err := theStore.Download(context.TODO(), "foo", path, info, nil, nil, &store.DownloadOptions{DeltasRequired: true})
+	c.Assert(err, IsNil)
+	c.Check(path, testutil.FileEquals, content)
 }
 
 func (s *downloadSuite) TestActualDownloadRateLimited(c *C) {
@@ -705,3 +864,79 @@ func (s *downloadSuite) TestActualDownloadRateLimited(c *C) {
 	c.Check(buf.String(), Equals, canary)
 	c.Check(ratelimitReaderUsed, Equals, true)
 }
+
+func mustParseSchedule(c *C, s string) []*timeutil.Schedule {
+	sched, err := timeutil.ParseSchedule(s)
+	c.Assert(err, IsNil)
+	return sched
+}
+
+func (s *downloadSuite) TestRateLimitAt(c *C) {
+	opts := &store.DownloadOptions{
+		RateLimit: 100,
+		RateLimitSchedule: []store.RateLimitWindow{
+			{Schedule: mustParseSchedule(c, "mon-fri,9:00-17:00"), Rate: 1000},
+			{Schedule: mustParseSchedule(c, "mon-sun,8:00-18:00"), Rate: 0},
+		},
+	}
+	for _, t := range []struct {
+		when time.Time
+		rate int64
+	}{
+		// Wednesday during business hours
+		{time.Date(2021, 10, 13, 10, 0, 0, 0, time.Local), 1000},
+		// Wednesday early in the morning
+		{time.Date(2021, 10, 13, 8, 30, 0, 0, time.Local), 0},
+		// Wednesday at night
+		{time.Date(2021, 10, 13, 23, 0, 0, 0, time.Local), 100},
+		// Saturday
+		{time.Date(2021, 10, 16, 10, 0, 0, 0, time.Local), 0},
+	} {
+		c.Check(opts.RateLimitAt(t.when), Equals, t.rate, Commentf("%s", t.when))
+	}
+}
+
+func (s *downloadSuite) TestActualDownloadRateLimitSchedule(c *C) {
+	var rates []float64
+	restore := store.MockRatelimitReader(func(r io.Reader, bucket *ratelimit.Bucket) io.Reader {
+		rates = append(rates, bucket.Rate())
+		return r
+	})
+	defer restore()
+
+	// downloading on a Wednesday during business hours
+	restore = store.MockTimeNow(func() time.Time {
+		return time.Date(2021, 10, 13, 10, 0, 0, 0, time.Local)
+	})
+	defer restore()
+
+	canary := "downloaded data"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, canary)
+	}))
+	defer ts.Close()
+
+	dlOpts := &store.DownloadOptions{
+		RateLimitSchedule: []store.RateLimitWindow{
+			{Schedule: mustParseSchedule(c, "mon-fri,9:00-17:00"), Rate: 1000},
+		},
+	}
+	theStore := store.New(&store.Config{}, nil)
+	var buf SillyBuffer
+	err := store.Download(context.TODO(), "example-name", "", ts.URL, nil, theStore, &buf, 0, nil, dlOpts)
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Equals, canary)
+	c.Check(rates, DeepEquals, []float64{1000})
+
+	// no limit applies at night
+	rates = nil
+	restore = store.MockTimeNow(func() time.Time {
+		return time.Date(2021, 10, 13, 23, 0, 0, 0, time.Local)
+	})
+	defer restore()
+	buf = SillyBuffer{}
+	err = store.Download(context.TODO(), "example-name", "", ts.URL, nil, theStore, &buf, 0, nil, dlOpts)
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Equals, canary)
+	c.Check(rates, HasLen, 0)
+}
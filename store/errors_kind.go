@@ -0,0 +1,128 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+
+	"github.com/snapcore/snapd/httputil"
+)
+
+// ErrorKind classifies the errors of store requests, so that network or
+// proxy misconfigurations can be told apart from store outages.
+type ErrorKind string
+
+const (
+	ErrorKindDNS             ErrorKind = "dns"
+	ErrorKindNetwork         ErrorKind = "network"
+	ErrorKindTimeout         ErrorKind = "timeout"
+	ErrorKindTLS             ErrorKind = "tls"
+	ErrorKindRateLimited     ErrorKind = "rate-limited"
+	ErrorKindServer          ErrorKind = "server-error"
+	ErrorKindPaymentRequired ErrorKind = "payment-required"
+	ErrorKindOther           ErrorKind = "other"
+)
+
+// PaymentRequiredError is returned when downloading a snap that must be
+// bought first.
+type PaymentRequiredError struct {
+	Name string
+}
+
+func (e *PaymentRequiredError) Error() string {
+	return fmt.Sprintf("please buy %s before installing it", e.Name)
+}
+
+func errorKindOfStatus(code int) ErrorKind {
+	switch {
+	case code == 402:
+		return ErrorKindPaymentRequired
+	case code == 429:
+		return ErrorKindRateLimited
+	case code >= 500:
+		return ErrorKindServer
+	}
+	return ErrorKindOther
+}
+
+// ErrorKindOf returns the kind of the given error of a store request.
+func ErrorKindOf(err error) ErrorKind {
+	var dlErr *DownloadError
+	if errors.As(err, &dlErr) {
+		return errorKindOfStatus(dlErr.Code)
+	}
+	var payErr *PaymentRequiredError
+	if errors.As(err, &payErr) {
+		return ErrorKindPaymentRequired
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorKindDNS
+	}
+	if httputil.NoNetwork(err) {
+		return ErrorKindNetwork
+	}
+	var certErr x509.CertificateInvalidError
+	var authErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &certErr) || errors.As(err, &authErr) || errors.As(err, &hostErr) || errors.As(err, &recordErr) {
+		return ErrorKindTLS
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorKindTimeout
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return ErrorKindNetwork
+	}
+	return ErrorKindOther
+}
+
+var (
+	errorCountsMu sync.Mutex
+	errorCounts   = map[ErrorKind]int{}
+)
+
+// recordError counts the failed request attempt.
+func recordError(kind ErrorKind) {
+	errorCountsMu.Lock()
+	defer errorCountsMu.Unlock()
+	errorCounts[kind]++
+}
+
+// ErrorCounts returns the number of failed store request attempts by
+// kind of error since snapd started, retries included.
+func ErrorCounts() map[ErrorKind]int {
+	errorCountsMu.Lock()
+	defer errorCountsMu.Unlock()
+	counts := make(map[ErrorKind]int, len(errorCounts))
+	for kind, n := range errorCounts {
+		counts[kind] = n
+	}
+	return counts
+}
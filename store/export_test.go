@@ -95,6 +95,14 @@ func MockConnCheckStrategy(t *testutil.BaseTest, strategy retry.Strategy) {
 	})
 }
 
+func MockChunkedDownloadMinSize(size int64) (restore func()) {
+	old := chunkedDownloadMinSize
+	chunkedDownloadMinSize = size
+	return func() {
+		chunkedDownloadMinSize = old
+	}
+}
+
 func MockDownloadSpeedParams(measureWindow time.Duration, minSpeed float64) (restore func()) {
 	oldSpeedMeasureWindow := downloadSpeedMeasureWindow
 	oldSpeedMin := downloadSpeedMin
@@ -229,6 +237,18 @@ func NewRequestOptions(mth string, url *url.URL) *requestOptions {
 	}
 }
 
+func MockTimeNow(f func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = f
+	return func() {
+		timeNow = old
+	}
+}
+
+func (opts *DownloadOptions) RateLimitAt(t time.Time) int64 {
+	return opts.rateLimitAt(t)
+}
+
 func MockRatelimitReader(f func(r io.Reader, bucket *ratelimit.Bucket) io.Reader) (restore func()) {
 	oldRatelimitReader := ratelimitReader
 	ratelimitReader = f
@@ -0,0 +1,259 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// OCISnapLayerMediaType is the media type of the layer holding the
+	// snap blob of an OCI artifact mirroring a snap.
+	OCISnapLayerMediaType = "application/vnd.snapcraft.snap"
+	// OCIAssertionsLayerMediaType is the media type of the layer holding
+	// the assertions needed to verify the snap blob.
+	OCIAssertionsLayerMediaType = "application/vnd.snapcraft.assertions"
+
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// limits for what is read in memory
+	maxOCIManifestSize   = 4 * 1024 * 1024
+	maxOCIAssertionsSize = 16 * 1024 * 1024
+)
+
+var (
+	ociRepositoryRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*$`)
+	ociDigestRegexp     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// OCIReference references an artifact mirroring a snap in an OCI registry,
+// by the digest of its manifest.
+type OCIReference struct {
+	Registry   string
+	Repository string
+	Digest     string
+}
+
+func (ref *OCIReference) String() string {
+	return fmt.Sprintf("%s/%s@%s", ref.Registry, ref.Repository, ref.Digest)
+}
+
+// ParseOCIReference parses a reference of the form
+// <registry>/<repository>@sha256:<hex>. Only references by digest are
+// supported, as tags are mutable.
+func ParseOCIReference(s string) (*OCIReference, error) {
+	nameAndDigest := strings.SplitN(s, "@", 2)
+	if len(nameAndDigest) != 2 {
+		return nil, fmt.Errorf("invalid OCI reference %q: must reference the artifact by digest", s)
+	}
+	name, digest := nameAndDigest[0], nameAndDigest[1]
+	if !ociDigestRegexp.MatchString(digest) {
+		return nil, fmt.Errorf("invalid OCI reference %q: unsupported digest %q", s, digest)
+	}
+	regAndRepo := strings.SplitN(name, "/", 2)
+	if len(regAndRepo) != 2 || regAndRepo[0] == "" {
+		return nil, fmt.Errorf("invalid OCI reference %q: missing registry", s)
+	}
+	if !ociRepositoryRegexp.MatchString(regAndRepo[1]) {
+		return nil, fmt.Errorf("invalid OCI reference %q: invalid repository %q", s, regAndRepo[1])
+	}
+	return &OCIReference{
+		Registry:   regAndRepo[0],
+		Repository: regAndRepo[1],
+		Digest:     digest,
+	}, nil
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+func (ref *OCIReference) url(kind, digest string) string {
+	return fmt.Sprintf("https://%s/v2/%s/%s/%s", ref.Registry, ref.Repository, kind, digest)
+}
+
+func ociGet(ctx context.Context, client *http.Client, url, accept string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d for %s", resp.StatusCode, url)
+	}
+	return resp, nil
+}
+
+// digestVerifier checks that the data written to it matches a digest.
+type digestVerifier struct {
+	digest string
+	h      hash.Hash
+	size   int64
+}
+
+func newDigestVerifier(digest string) *digestVerifier {
+	return &digestVerifier{digest: digest, h: sha256.New()}
+}
+
+func (v *digestVerifier) Write(p []byte) (int, error) {
+	v.size += int64(len(p))
+	return v.h.Write(p)
+}
+
+func (v *digestVerifier) verify() error {
+	actual := "sha256:" + hex.EncodeToString(v.h.Sum(nil))
+	if actual != v.digest {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", v.digest, actual)
+	}
+	return nil
+}
+
+func ociFetchManifest(ctx context.Context, client *http.Client, ref *OCIReference) (*ociManifest, error) {
+	resp, err := ociGet(ctx, client, ref.url("manifests", ref.Digest), ociManifestMediaType)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxOCIManifestSize))
+	if err != nil {
+		return nil, err
+	}
+	v := newDigestVerifier(ref.Digest)
+	v.Write(data)
+	if err := v.verify(); err != nil {
+		return nil, fmt.Errorf("cannot verify manifest: %v", err)
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("cannot decode manifest: %v", err)
+	}
+	if manifest.SchemaVersion != 2 {
+		return nil, fmt.Errorf("unsupported manifest schema version %d", manifest.SchemaVersion)
+	}
+	return &manifest, nil
+}
+
+func ociFetchBlob(ctx context.Context, client *http.Client, ref *OCIReference, layer *ociDescriptor, w io.Writer, limit int64) error {
+	if !ociDigestRegexp.MatchString(layer.Digest) {
+		return fmt.Errorf("unsupported digest %q for %s layer", layer.Digest, layer.MediaType)
+	}
+	if limit > 0 && layer.Size > limit {
+		return fmt.Errorf("%s layer is too big", layer.MediaType)
+	}
+	resp, err := ociGet(ctx, client, ref.url("blobs", layer.Digest), "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	v := newDigestVerifier(layer.Digest)
+	// read at most one byte more than announced to detect oversized blobs
+	if _, err := io.Copy(io.MultiWriter(w, v), io.LimitReader(resp.Body, layer.Size+1)); err != nil {
+		return err
+	}
+	if v.size != layer.Size {
+		return fmt.Errorf("cannot verify %s layer: size mismatch: expected %d, got %d", layer.MediaType, layer.Size, v.size)
+	}
+	if err := v.verify(); err != nil {
+		return fmt.Errorf("cannot verify %s layer: %v", layer.MediaType, err)
+	}
+	return nil
+}
+
+// DownloadOCI fetches the artifact mirroring a snap referenced by ref from
+// its OCI registry. The snap blob is written to targetPath and the
+// assertions needed to verify it are returned. The manifest and all layers
+// are verified against their digests; the snap itself still needs to be
+// verified against the returned assertions like a regular store download.
+func DownloadOCI(ctx context.Context, client *http.Client, ref *OCIReference, targetPath string) (assertions []byte, err error) {
+	manifest, err := ociFetchManifest(ctx, client, ref)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch %s: %v", ref, err)
+	}
+
+	var snapLayer, assertsLayer *ociDescriptor
+	for i := range manifest.Layers {
+		layer := &manifest.Layers[i]
+		switch layer.MediaType {
+		case OCISnapLayerMediaType:
+			snapLayer = layer
+		case OCIAssertionsLayerMediaType:
+			assertsLayer = layer
+		}
+	}
+	if snapLayer == nil {
+		return nil, fmt.Errorf("cannot fetch %s: no %s layer", ref, OCISnapLayerMediaType)
+	}
+	if assertsLayer == nil {
+		return nil, fmt.Errorf("cannot fetch %s: no %s layer", ref, OCIAssertionsLayerMediaType)
+	}
+
+	var buf bytes.Buffer
+	if err := ociFetchBlob(ctx, client, ref, assertsLayer, &buf, maxOCIAssertionsSize); err != nil {
+		return nil, fmt.Errorf("cannot fetch %s: %v", ref, err)
+	}
+
+	w, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := w.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(targetPath)
+		}
+	}()
+	if err := ociFetchBlob(ctx, client, ref, snapLayer, w, 0); err != nil {
+		return nil, fmt.Errorf("cannot fetch %s: %v", ref, err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,181 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type ociSuite struct {
+	testutil.BaseTest
+
+	blobs    map[string][]byte
+	manifest []byte
+}
+
+var _ = Suite(&ociSuite{})
+
+func ociDigest(data []byte) string {
+	h := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+func (s *ociSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+
+	s.blobs = make(map[string][]byte)
+	s.setManifest(c, map[string][]byte{
+		store.OCISnapLayerMediaType:       []byte("snap-blob"),
+		store.OCIAssertionsLayerMediaType: []byte("assertions"),
+	})
+}
+
+func (s *ociSuite) setManifest(c *C, layers map[string][]byte) {
+	type layer struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+		Size      int    `json:"size"`
+	}
+	manifest := struct {
+		SchemaVersion int     `json:"schemaVersion"`
+		Layers        []layer `json:"layers"`
+	}{SchemaVersion: 2}
+	for _, mediaType := range []string{store.OCISnapLayerMediaType, store.OCIAssertionsLayerMediaType} {
+		data, ok := layers[mediaType]
+		if !ok {
+			continue
+		}
+		digest := ociDigest(data)
+		s.blobs[digest] = data
+		manifest.Layers = append(manifest.Layers, layer{MediaType: mediaType, Digest: digest, Size: len(data)})
+	}
+	var err error
+	s.manifest, err = json.Marshal(manifest)
+	c.Assert(err, IsNil)
+}
+
+func (s *ociSuite) mockRegistry(c *C) (*httptest.Server, *store.OCIReference) {
+	digest := ociDigest(s.manifest)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		switch {
+		case r.URL.Path == "/v2/snaps/some-snap/manifests/"+digest:
+			c.Check(r.Header.Get("Accept"), Equals, "application/vnd.oci.image.manifest.v1+json")
+			w.Write(s.manifest)
+		case strings.HasPrefix(r.URL.Path, "/v2/snaps/some-snap/blobs/"):
+			data, ok := s.blobs[strings.TrimPrefix(r.URL.Path, "/v2/snaps/some-snap/blobs/")]
+			if !ok {
+				w.WriteHeader(404)
+				return
+			}
+			w.Write(data)
+		default:
+			c.Errorf("unexpected request to %q", r.URL.Path)
+			w.WriteHeader(404)
+		}
+	}))
+	s.AddCleanup(srv.Close)
+
+	ref, err := store.ParseOCIReference(fmt.Sprintf("%s/snaps/some-snap@%s", strings.TrimPrefix(srv.URL, "https://"), digest))
+	c.Assert(err, IsNil)
+	return srv, ref
+}
+
+func (s *ociSuite) TestParseOCIReference(c *C) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	ref, err := store.ParseOCIReference("registry.example.com:5000/mirror/some-snap@" + digest)
+	c.Assert(err, IsNil)
+	c.Check(ref, DeepEquals, &store.OCIReference{
+		Registry:   "registry.example.com:5000",
+		Repository: "mirror/some-snap",
+		Digest:     digest,
+	})
+	c.Check(ref.String(), Equals, "registry.example.com:5000/mirror/some-snap@"+digest)
+}
+
+func (s *ociSuite) TestParseOCIReferenceErrors(c *C) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	for _, t := range []struct {
+		ref string
+		err string
+	}{
+		{"registry.example.com/some-snap:latest", `invalid OCI reference .*: must reference the artifact by digest`},
+		{"registry.example.com/some-snap@sha1:abcd", `invalid OCI reference .*: unsupported digest "sha1:abcd"`},
+		{"some-snap@" + digest, `invalid OCI reference .*: missing registry`},
+		{"registry.example.com/Some-Snap@" + digest, `invalid OCI reference .*: invalid repository "Some-Snap"`},
+	} {
+		_, err := store.ParseOCIReference(t.ref)
+		c.Check(err, ErrorMatches, t.err, Commentf(t.ref))
+	}
+}
+
+func (s *ociSuite) TestDownloadOCI(c *C) {
+	srv, ref := s.mockRegistry(c)
+
+	target := filepath.Join(c.MkDir(), "some-snap.snap")
+	assertions, err := store.DownloadOCI(context.Background(), srv.Client(), ref, target)
+	c.Assert(err, IsNil)
+	c.Check(string(assertions), Equals, "assertions")
+	c.Check(target, testutil.FileEquals, "snap-blob")
+}
+
+func (s *ociSuite) TestDownloadOCIBadLayerDigest(c *C) {
+	srv, ref := s.mockRegistry(c)
+	// serve something else than what the manifest references
+	s.blobs[ociDigest([]byte("snap-blob"))] = []byte("evil-blob")
+
+	target := filepath.Join(c.MkDir(), "some-snap.snap")
+	_, err := store.DownloadOCI(context.Background(), srv.Client(), ref, target)
+	c.Assert(err, ErrorMatches, `cannot fetch .*: cannot verify application/vnd.snapcraft.snap layer: digest mismatch: .*`)
+	c.Check(osutil.FileExists(target), Equals, false)
+}
+
+func (s *ociSuite) TestDownloadOCIBadManifestDigest(c *C) {
+	srv, ref := s.mockRegistry(c)
+	// the registry serves a manifest not matching the referenced digest
+	s.manifest = append(s.manifest, ' ')
+
+	_, err := store.DownloadOCI(context.Background(), srv.Client(), ref, filepath.Join(c.MkDir(), "some-snap.snap"))
+	c.Assert(err, ErrorMatches, `cannot fetch .*: cannot verify manifest: digest mismatch: .*`)
+}
+
+func (s *ociSuite) TestDownloadOCIMissingAssertions(c *C) {
+	s.setManifest(c, map[string][]byte{
+		store.OCISnapLayerMediaType: []byte("snap-blob"),
+	})
+	srv, ref := s.mockRegistry(c)
+
+	_, err := store.DownloadOCI(context.Background(), srv.Client(), ref, filepath.Join(c.MkDir(), "some-snap.snap"))
+	c.Assert(err, ErrorMatches, `cannot fetch .*: no application/vnd.snapcraft.assertions layer`)
+}
@@ -847,8 +847,12 @@ func (s *Store) doRequest(ctx context.Context, client *http.Client, reqOptions *
 
 		resp, err := client.Do(req)
 		if err != nil {
+			recordError(ErrorKindOf(err))
 			return nil, err
 		}
+		if resp.StatusCode == 402 || resp.StatusCode == 429 || resp.StatusCode >= 500 {
+			recordError(errorKindOfStatus(resp.StatusCode))
+		}
 
 		wwwAuth := resp.Header.Get("WWW-Authenticate")
 		if resp.StatusCode == 401 && authRefreshes < 4 {
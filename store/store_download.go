@@ -34,6 +34,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/ratelimit"
@@ -48,6 +49,7 @@ import (
 	"github.com/snapcore/snapd/progress"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snapdtool"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 var commandFromSystemSnap = snapdtool.CommandFromSystemSnap
@@ -204,10 +206,36 @@ func (e HashError) Error() string {
 	return fmt.Sprintf("sha3-384 mismatch for %q: got %s but expected %s", e.name, e.sha3_384, e.targetSha3_384)
 }
 
+// RateLimitWindow is a rate limit applying to downloads while the current
+// time is included in its schedule.
+type RateLimitWindow struct {
+	Schedule []*timeutil.Schedule
+	// Rate is the limit in bytes per second, 0 means unlimited.
+	Rate int64
+}
+
 type DownloadOptions struct {
 	RateLimit           int64
 	IsAutoRefresh       bool
 	LeavePartialOnError bool
+	// DeltasRequired makes Download fail instead of falling back to a
+	// full download when an available delta cannot be used.
+	DeltasRequired bool
+	// DeltaApplied, if set, is called when the snap was obtained by
+	// downloading and applying a delta.
+	DeltaApplied func()
+	// Chunks is the number of parallel ranged requests used to download
+	// large snaps, they are downloaded with a single request if it is
+	// lower than 2 or a rate limit is set.
+	Chunks int
+	// RateLimitSchedule overrides RateLimit during its windows, the
+	// first window that includes the current time applies. The rate
+	// limit is re-evaluated as the download progresses.
+	RateLimitSchedule []RateLimitWindow
+	// LANPeers are the URLs of snapd instances on the local network
+	// that are tried, in order, before the store. Snaps obtained from
+	// them are only used if their hash matches.
+	LANPeers []string
 }
 
 // Download downloads the snap addressed by download info and returns its
@@ -224,17 +252,37 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		return nil
 	}
 
+	if dlOpts != nil && len(dlOpts.LANPeers) > 0 && downloadInfo.Sha3_384 != "" {
+		err := s.downloadFromLANPeers(ctx, name, targetPath, downloadInfo, pbar, dlOpts.LANPeers)
+		if err == nil {
+			return nil
+		}
+		if cancelled(ctx) {
+			return err
+		}
+		logger.Debugf("%v, downloading it from the store.", err)
+	}
+
+	deltasRequired := dlOpts != nil && dlOpts.DeltasRequired
 	if s.useDeltas() {
 		logger.Debugf("Available deltas returned by store: %v", downloadInfo.Deltas)
 
 		if len(downloadInfo.Deltas) == 1 {
 			err := s.downloadAndApplyDelta(name, targetPath, downloadInfo, pbar, user, dlOpts)
 			if err == nil {
+				if dlOpts != nil && dlOpts.DeltaApplied != nil {
+					dlOpts.DeltaApplied()
+				}
 				return nil
 			}
+			if deltasRequired {
+				return fmt.Errorf("cannot download or apply delta for %s and deltas are required: %v", name, err)
+			}
 			// We revert to normal downloads if there is any error.
 			logger.Noticef("Cannot download or apply deltas for %s: %v", name, err)
 		}
+	} else if deltasRequired && len(downloadInfo.Deltas) != 0 {
+		return fmt.Errorf("cannot use delta for %s and deltas are required: deltas are not supported on this system", name)
 	}
 
 	partialPath := targetPath + ".partial"
@@ -256,6 +304,7 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		}
 		if dlOpts == nil || !dlOpts.LeavePartialOnError || fi == nil || fi.Size() == 0 {
 			os.Remove(w.Name())
+			os.Remove(chunkedStatePath(w.Name()))
 		}
 	}()
 	if resume > 0 {
@@ -274,7 +323,25 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		url = downloadInfo.DownloadURL
 	}
 
-	if downloadInfo.Size == 0 || resume < downloadInfo.Size {
+	chunked := useChunkedDownload(downloadInfo, dlOpts)
+	if chunked {
+		err = downloadChunked(ctx, name, downloadInfo.Sha3_384, url, user, s, w, downloadInfo.Size, pbar, dlOpts)
+		if err == errRangesUnsupported {
+			logger.Debugf("Cannot download %q in chunks, downloading it with a single request.", url)
+			os.Remove(chunkedStatePath(w.Name()))
+			if err = w.Truncate(0); err != nil {
+				return err
+			}
+			resume = 0
+			chunked = false
+		} else if err != nil {
+			logger.Debugf("chunked download of %q failed: %#v", url, err)
+		}
+	}
+
+	if chunked {
+		// already downloaded and checked
+	} else if downloadInfo.Size == 0 || resume < downloadInfo.Size {
 		err = download(ctx, name, downloadInfo.Sha3_384, url, user, s, w, resume, pbar, dlOpts)
 		if err != nil {
 			logger.Debugf("download of %q failed: %#v", url, err)
@@ -318,6 +385,7 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 	if err := os.Rename(w.Name(), targetPath); err != nil {
 		return err
 	}
+	os.Remove(chunkedStatePath(partialPath))
 
 	if err := w.Sync(); err != nil {
 		return err
@@ -454,6 +522,63 @@ func (w *TransferSpeedMonitoringWriter) Err() error {
 
 var ratelimitReader = ratelimit.Reader
 
+var timeNow = time.Now
+
+// downloadedBytes is the number of bytes of snaps downloaded since snapd
+// started.
+var downloadedBytes int64
+
+// downloadCounter counts the bytes written to it as downloaded.
+type downloadCounter struct{}
+
+func (downloadCounter) Write(p []byte) (int, error) {
+	atomic.AddInt64(&downloadedBytes, int64(len(p)))
+	return len(p), nil
+}
+
+// DownloadedBytes returns the number of bytes of snaps downloaded since
+// snapd started, from the store or from LAN peers.
+func DownloadedBytes() int64 {
+	return atomic.LoadInt64(&downloadedBytes)
+}
+
+// rateLimitAt returns the rate limit that applies at the given time, 0 if
+// downloads are not limited.
+func (opts *DownloadOptions) rateLimitAt(t time.Time) int64 {
+	for _, window := range opts.RateLimitSchedule {
+		if timeutil.Includes(window.Schedule, t) {
+			return window.Rate
+		}
+	}
+	return opts.RateLimit
+}
+
+// scheduledRateLimitReader limits the rate of reads to the rate limit that
+// applies at the time of each read.
+type scheduledRateLimitReader struct {
+	r       io.Reader
+	opts    *DownloadOptions
+	rate    int64
+	limited io.Reader
+}
+
+func newScheduledRateLimitReader(r io.Reader, opts *DownloadOptions) *scheduledRateLimitReader {
+	return &scheduledRateLimitReader{r: r, opts: opts, rate: -1}
+}
+
+func (sr *scheduledRateLimitReader) Read(p []byte) (int, error) {
+	if rate := sr.opts.rateLimitAt(timeNow()); rate != sr.rate {
+		logger.Debugf("Download rate limit is now %d bytes per second.", rate)
+		sr.rate = rate
+		sr.limited = sr.r
+		if rate > 0 {
+			bucket := ratelimit.NewBucketWithRate(float64(rate), 2*rate)
+			sr.limited = ratelimitReader(sr.r, bucket)
+		}
+	}
+	return sr.limited.Read(p)
+}
+
 var download = downloadImpl
 
 // download writes an http.Request showing a progress.Meter
@@ -534,7 +659,7 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 		case 200, 206: // OK, Partial Content
 		case 402: // Payment Required
 
-			return fmt.Errorf("please buy %s before installing it", name)
+			return &PaymentRequiredError{Name: name}
 		default:
 			return &DownloadError{Code: resp.StatusCode, URL: resp.Request.URL}
 		}
@@ -549,10 +674,12 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 			logger.Debugf("Download size for %s: %d", downloadURL, resp.ContentLength)
 		}
 		pbar.Start(name, dlSize)
-		mw := io.MultiWriter(w, h, pbar, tc)
+		mw := io.MultiWriter(w, h, pbar, tc, downloadCounter{})
 		var limiter io.Reader
 		limiter = resp.Body
-		if limit := dlOpts.RateLimit; limit > 0 {
+		if len(dlOpts.RateLimitSchedule) > 0 {
+			limiter = newScheduledRateLimitReader(resp.Body, dlOpts)
+		} else if limit := dlOpts.RateLimit; limit > 0 {
 			bucket := ratelimit.NewBucketWithRate(float64(limit), 2*limit)
 			limiter = ratelimitReader(resp.Body, bucket)
 		}
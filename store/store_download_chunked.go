@@ -0,0 +1,322 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+
+	"gopkg.in/retry.v1"
+)
+
+// chunkedDownloadMinSize is the size from which snaps are downloaded in
+// parallel chunks, if requested through DownloadOptions.Chunks.
+var chunkedDownloadMinSize int64 = 64 * 1024 * 1024
+
+// chunkedStateSaveInterval is the minimum interval between saves of the
+// state of a chunked download while chunks are being downloaded.
+var chunkedStateSaveInterval = time.Second
+
+// errRangesUnsupported is returned when the server does not honour ranged
+// requests and chunks cannot be downloaded.
+var errRangesUnsupported = errors.New("server does not support ranged requests")
+
+// downloadChunk is the part of the snap from Start (included) to End
+// (excluded), of which Done bytes were already downloaded.
+type downloadChunk struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Done  int64 `json:"done"`
+}
+
+// chunkedDownload is the state of a chunked download, it is saved next to
+// the partial file so that the download can be resumed after a restart.
+type chunkedDownload struct {
+	Sha3_384 string           `json:"sha3-384"`
+	Size     int64            `json:"size"`
+	Chunks   []*downloadChunk `json:"chunks"`
+
+	mu        sync.Mutex
+	path      string
+	lastSaved time.Time
+	pbar      progress.Meter
+}
+
+func chunkedStatePath(partialPath string) string {
+	return partialPath + ".chunks"
+}
+
+func useChunkedDownload(downloadInfo *snap.DownloadInfo, dlOpts *DownloadOptions) bool {
+	if dlOpts == nil || dlOpts.Chunks < 2 || dlOpts.RateLimit > 0 || len(dlOpts.RateLimitSchedule) > 0 {
+		return false
+	}
+	return downloadInfo.Sha3_384 != "" && downloadInfo.Size >= chunkedDownloadMinSize
+}
+
+// loadChunkedDownload returns the saved state of the chunked download into
+// partialPath if it matches the snap, or a new state splitting the snap in
+// the given number of chunks otherwise.
+func loadChunkedDownload(partialPath, sha3_384 string, size int64, n int) *chunkedDownload {
+	statePath := chunkedStatePath(partialPath)
+	var cd chunkedDownload
+	if content, err := ioutil.ReadFile(statePath); err == nil {
+		if err := json.Unmarshal(content, &cd); err == nil && cd.Sha3_384 == sha3_384 && cd.Size == size {
+			cd.path = statePath
+			return &cd
+		}
+		logger.Debugf("Ignoring unusable chunked download state %q.", statePath)
+	}
+
+	cd = chunkedDownload{Sha3_384: sha3_384, Size: size, path: statePath}
+	chunkSize := (size + int64(n) - 1) / int64(n)
+	for start := int64(0); start < size; start += chunkSize {
+		end := start + chunkSize
+		if end > size {
+			end = size
+		}
+		cd.Chunks = append(cd.Chunks, &downloadChunk{Start: start, End: end})
+	}
+	return &cd
+}
+
+// save writes the state of the download, the lock must be held.
+func (cd *chunkedDownload) save() error {
+	content, err := json.Marshal(cd)
+	if err != nil {
+		return err
+	}
+	cd.lastSaved = time.Now()
+	return osutil.AtomicWriteFile(cd.path, content, 0600, 0)
+}
+
+// doneLocked returns the number of bytes downloaded so far, the lock must be
+// held.
+func (cd *chunkedDownload) doneLocked() int64 {
+	var done int64
+	for _, chunk := range cd.Chunks {
+		done += chunk.Done
+	}
+	return done
+}
+
+// chunkWriter writes the data of a chunk at its position in the partial
+// file, keeping track of the progress of the download.
+type chunkWriter struct {
+	cd    *chunkedDownload
+	chunk *downloadChunk
+	w     io.WriterAt
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	cw.cd.mu.Lock()
+	offset := cw.chunk.Start + cw.chunk.Done
+	cw.cd.mu.Unlock()
+
+	n, err := cw.w.WriteAt(p, offset)
+
+	cw.cd.mu.Lock()
+	defer cw.cd.mu.Unlock()
+	cw.chunk.Done += int64(n)
+	cw.cd.pbar.Set(float64(cw.cd.doneLocked()))
+	if time.Since(cw.cd.lastSaved) >= chunkedStateSaveInterval {
+		if serr := cw.cd.save(); serr != nil {
+			logger.Debugf("Cannot save chunked download state: %v", serr)
+		}
+	}
+	return n, err
+}
+
+// downloadChunked downloads the snap into w using as many parallel
+// ranged requests as there are chunks, retrying each chunk on its own. The
+// hash of the snap is checked once all the chunks are downloaded.
+func downloadChunked(ctx context.Context, name, sha3_384, downloadURL string, user *auth.UserState, s *Store, w *os.File, size int64, pbar progress.Meter, dlOpts *DownloadOptions) error {
+	storeURL, err := url.Parse(downloadURL)
+	if err != nil {
+		return err
+	}
+
+	cdnHeader, err := s.cdnHeader()
+	if err != nil {
+		return err
+	}
+
+	cd := loadChunkedDownload(w.Name(), sha3_384, size, dlOpts.Chunks)
+	if err := w.Truncate(size); err != nil {
+		return err
+	}
+
+	if pbar == nil {
+		pbar = progress.Null
+	}
+	cd.pbar = pbar
+
+	cd.mu.Lock()
+	done := cd.doneLocked()
+	err = cd.save()
+	cd.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if done > 0 {
+		logger.Debugf("Resuming chunked download of %q at %d.", w.Name(), done)
+	}
+
+	dlCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pbar.Start(name, float64(size))
+	errs := make([]error, len(cd.Chunks))
+	var wg sync.WaitGroup
+	for i, chunk := range cd.Chunks {
+		wg.Add(1)
+		go func(i int, chunk *downloadChunk) {
+			defer wg.Done()
+			errs[i] = s.downloadChunk(dlCtx, name, storeURL, cdnHeader, user, cd, chunk, w, dlOpts)
+			if errs[i] != nil {
+				// no point in downloading the other chunks
+				cancel()
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+	pbar.Finished()
+
+	cd.mu.Lock()
+	if err := cd.save(); err != nil {
+		logger.Debugf("Cannot save chunked download state: %v", err)
+	}
+	cd.mu.Unlock()
+
+	// report the error that caused the other chunks to be cancelled
+	for _, err := range errs {
+		if err != nil && err != context.Canceled {
+			return err
+		}
+	}
+	if cancelled(ctx) {
+		return fmt.Errorf("the download has been cancelled: %s", ctx.Err())
+	}
+
+	h := crypto.SHA3_384.New()
+	if _, err := w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(h, w); err != nil {
+		return err
+	}
+	actualSha3 := fmt.Sprintf("%x", h.Sum(nil))
+	if sha3_384 != actualSha3 {
+		// the chunks cannot be trusted anymore
+		os.Remove(cd.path)
+		return HashError{name, actualSha3, sha3_384}
+	}
+	return nil
+}
+
+func (s *Store) downloadChunk(ctx context.Context, name string, storeURL *url.URL, cdnHeader string, user *auth.UserState, cd *chunkedDownload, chunk *downloadChunk, w io.WriterAt, dlOpts *DownloadOptions) error {
+	var finalErr error
+	startTime := time.Now()
+	for attempt := retry.Start(downloadRetryStrategy, nil); attempt.Next(); {
+		cd.mu.Lock()
+		start := chunk.Start + chunk.Done
+		cd.mu.Unlock()
+		if start >= chunk.End {
+			return nil
+		}
+
+		reqOptions := downloadReqOpts(storeURL, cdnHeader, dlOpts)
+		reqOptions.ExtraHeaders["Range"] = fmt.Sprintf("bytes=%d-%d", start, chunk.End-1)
+
+		httputil.MaybeLogRetryAttempt(reqOptions.URL.String(), attempt, startTime)
+
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
+		var resp *http.Response
+		cli := s.newHTTPClient(nil)
+		resp, finalErr = s.doRequest(ctx, cli, reqOptions, user)
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
+		if finalErr != nil {
+			if httputil.ShouldRetryAttempt(attempt, finalErr) {
+				continue
+			}
+			break
+		}
+		if httputil.ShouldRetryHttpResponse(attempt, resp) {
+			resp.Body.Close()
+			continue
+		}
+
+		switch resp.StatusCode {
+		case 206: // Partial Content
+		case 200:
+			resp.Body.Close()
+			return errRangesUnsupported
+		case 402: // Payment Required
+			resp.Body.Close()
+			return &PaymentRequiredError{Name: name}
+		default:
+			resp.Body.Close()
+			return &DownloadError{Code: resp.StatusCode, URL: resp.Request.URL}
+		}
+
+		cw := &chunkWriter{cd: cd, chunk: chunk, w: w}
+		_, finalErr = io.Copy(io.MultiWriter(cw, downloadCounter{}), io.LimitReader(resp.Body, chunk.End-start))
+		resp.Body.Close()
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
+		if finalErr == nil {
+			cd.mu.Lock()
+			if chunk.Start+chunk.Done < chunk.End {
+				finalErr = io.ErrUnexpectedEOF
+			}
+			cd.mu.Unlock()
+		}
+		if finalErr != nil {
+			if httputil.ShouldRetryAttempt(attempt, finalErr) {
+				// the next attempt resumes where this one stopped
+				continue
+			}
+			break
+		}
+		return nil
+	}
+	return finalErr
+}
@@ -0,0 +1,129 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+)
+
+// lanPeerBlobPath is the path, relative to the URL of a LAN peer, under
+// which it serves the snaps it downloaded, addressed by their sha3-384.
+const lanPeerBlobPath = "v2/lan-cache"
+
+func lanPeerBlobURL(peer, sha3_384 string) (string, error) {
+	u, err := url.Parse(peer)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	u.Path = path.Join("/", u.Path, lanPeerBlobPath, sha3_384)
+	return u.String(), nil
+}
+
+// downloadFromLANPeers tries to download the snap from the LAN peers in
+// order. The content served by a peer is not trusted: it is only used if
+// its size and hash match the download info obtained from the store.
+func (s *Store) downloadFromLANPeers(ctx context.Context, name, targetPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, peers []string) error {
+	partialPath := targetPath + ".lan-partial"
+	defer os.Remove(partialPath)
+
+	for _, peer := range peers {
+		blobURL, err := lanPeerBlobURL(peer, downloadInfo.Sha3_384)
+		if err != nil {
+			logger.Noticef("Ignoring invalid LAN peer %q: %v", peer, err)
+			continue
+		}
+		if err := s.downloadFromLANPeer(ctx, name, partialPath, blobURL, downloadInfo, pbar); err != nil {
+			if cancelled(ctx) {
+				return fmt.Errorf("the download has been cancelled: %s", ctx.Err())
+			}
+			logger.Debugf("Cannot download %q from LAN peer %q: %v", name, peer, err)
+			continue
+		}
+		if err := os.Rename(partialPath, targetPath); err != nil {
+			return err
+		}
+		logger.Debugf("Downloaded %q from LAN peer %q.", name, peer)
+		return s.cacher.Put(downloadInfo.Sha3_384, targetPath)
+	}
+	return fmt.Errorf("cannot download %q from any LAN peer", name)
+}
+
+func (s *Store) downloadFromLANPeer(ctx context.Context, name, partialPath, blobURL string, downloadInfo *snap.DownloadInfo, pbar progress.Meter) error {
+	// no authorization is sent to the peers
+	req, err := http.NewRequest("GET", blobURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.newHTTPClient(nil).Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if downloadInfo.Size > 0 && resp.ContentLength >= 0 && resp.ContentLength != downloadInfo.Size {
+		return fmt.Errorf("unexpected size %d, expected %d", resp.ContentLength, downloadInfo.Size)
+	}
+
+	w, err := os.OpenFile(partialPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if pbar == nil {
+		pbar = progress.Null
+	}
+	h := crypto.SHA3_384.New()
+	pbar.Start(name, float64(downloadInfo.Size))
+	// never read more than the expected size from an untrusted peer
+	body := io.Reader(resp.Body)
+	if downloadInfo.Size > 0 {
+		body = io.LimitReader(resp.Body, downloadInfo.Size+1)
+	}
+	n, err := io.Copy(io.MultiWriter(w, h, pbar, downloadCounter{}), body)
+	pbar.Finished()
+	if err != nil {
+		return err
+	}
+	if downloadInfo.Size > 0 && n != downloadInfo.Size {
+		return fmt.Errorf("unexpected size %d, expected %d", n, downloadInfo.Size)
+	}
+	actualSha3 := fmt.Sprintf("%x", h.Sum(nil))
+	if actualSha3 != downloadInfo.Sha3_384 {
+		return HashError{name, actualSha3, downloadInfo.Sha3_384}
+	}
+	return w.Sync()
+}
@@ -31,6 +31,8 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/sha3"
@@ -1003,6 +1005,135 @@ func (s *storeDownloadSuite) TestDownloadTimeout(c *C) {
 	defer mockServer.Close()
 }
 
+// mockRangeServer serves the content, honouring ranged requests or not, and
+// returns a function listing the ranges of the requests received so far.
+func (s *storeDownloadSuite) mockRangeServer(c *C, content []byte, honourRanges bool) (server *httptest.Server, requestedRanges func() []string) {
+	var mu sync.Mutex
+	var ranges []string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		if !honourRanges {
+			r.Header.Del("Range")
+		}
+		http.ServeContent(w, r, "foo.snap", time.Time{}, bytes.NewReader(content))
+	}))
+	s.AddCleanup(server.Close)
+	requestedRanges = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+	return server, requestedRanges
+}
+
+func mockChunkedSnap(c *C, content []byte, url string) *snap.Info {
+	h := crypto.SHA3_384.New()
+	h.Write(content)
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.AnonDownloadURL = url
+	snap.DownloadURL = "AUTH-URL"
+	snap.Sha3_384 = fmt.Sprintf("%x", h.Sum(nil))
+	snap.Size = int64(len(content))
+	return snap
+}
+
+func (s *storeDownloadSuite) TestDownloadChunked(c *C) {
+	restore := store.MockChunkedDownloadMinSize(100)
+	defer restore()
+
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	mockServer, ranges := s.mockRangeServer(c, content, true)
+	snap := mockChunkedSnap(c, content, mockServer.URL)
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Chunks: 4})
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".partial", testutil.FileAbsent)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+	requested := ranges()
+	sort.Strings(requested)
+	c.Check(requested, DeepEquals, []string{
+		"bytes=0-249", "bytes=250-499", "bytes=500-749", "bytes=750-999",
+	})
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedSmallSnap(c *C) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	mockServer, ranges := s.mockRangeServer(c, content, true)
+	snap := mockChunkedSnap(c, content, mockServer.URL)
+
+	// the snap is too small to be downloaded in chunks
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Chunks: 4})
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(ranges(), DeepEquals, []string{""})
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedResume(c *C) {
+	restore := store.MockChunkedDownloadMinSize(100)
+	defer restore()
+
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	mockServer, ranges := s.mockRangeServer(c, content, true)
+	snap := mockChunkedSnap(c, content, mockServer.URL)
+
+	// a previous download got the first chunk and half of the second one
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	partial := make([]byte, len(content))
+	copy(partial, content[:750])
+	c.Assert(ioutil.WriteFile(targetFn+".partial", partial, 0600), IsNil)
+	state := fmt.Sprintf(`{"sha3-384":%q,"size":1000,"chunks":[{"start":0,"end":500,"done":500},{"start":500,"end":1000,"done":250}]}`, snap.Sha3_384)
+	c.Assert(ioutil.WriteFile(targetFn+".partial.chunks", []byte(state), 0600), IsNil)
+
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Chunks: 2})
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+	c.Check(ranges(), DeepEquals, []string{"bytes=750-999"})
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedRangesUnsupported(c *C) {
+	restore := store.MockChunkedDownloadMinSize(100)
+	defer restore()
+
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	mockServer, ranges := s.mockRangeServer(c, content, false)
+	snap := mockChunkedSnap(c, content, mockServer.URL)
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Chunks: 2})
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, content)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+	// the chunked requests were answered with the whole snap, which was
+	// then downloaded with a single request
+	requested := ranges()
+	c.Check(requested[0], Matches, "bytes=.*")
+	c.Check(requested, testutil.DeepContains, "")
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedHashError(c *C) {
+	restore := store.MockChunkedDownloadMinSize(100)
+	defer restore()
+
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	mockServer, _ := s.mockRangeServer(c, content, true)
+	snap := mockChunkedSnap(c, content, mockServer.URL)
+	snap.Sha3_384 = "bad-hash"
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, &snap.DownloadInfo, nil, nil, &store.DownloadOptions{Chunks: 2})
+	c.Assert(err, FitsTypeOf, store.HashError{})
+	c.Check(targetFn+".partial", testutil.FileAbsent)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+}
+
 func (s *storeDownloadSuite) TestTransferSpeedMonitoringWriterHappy(c *C) {
 	origCtx := context.TODO()
 	w, ctx := store.NewTransferSpeedMonitoringWriterAndContext(origCtx, 50*time.Millisecond, 1)
@@ -1081,3 +1212,75 @@ func (s *storeDownloadSuite) TestDownloadTimeoutOnHeaders(c *C) {
 	close(quit)
 	c.Assert(err, ErrorMatches, `.*net/http: timeout awaiting response headers`)
 }
+
+func (s *storeDownloadSuite) TestDownloadFromLANPeer(c *C) {
+	expectedContent := []byte("I was downloaded from a peer")
+
+	restore := store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		c.Fatalf("unexpected download from the store")
+		return nil
+	})
+	defer restore()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.AnonDownloadURL = "anon-url"
+	snap.Size = int64(len(expectedContent))
+	snap.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(expectedContent))
+
+	var paths []string
+	mockPeer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		c.Check(r.Header.Get("Authorization"), Equals, "")
+		c.Check(r.Header.Get("Snap-Device-Authorization"), Equals, "")
+		w.Write(expectedContent)
+	}))
+	defer mockPeer.Close()
+
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	dlOpts := &store.DownloadOptions{LANPeers: []string{mockPeer.URL + "/"}}
+	err := s.store.Download(s.ctx, "foo", path, &snap.DownloadInfo, nil, s.localUser, dlOpts)
+	c.Assert(err, IsNil)
+
+	c.Check(path, testutil.FileEquals, expectedContent)
+	c.Check(paths, DeepEquals, []string{"/v2/lan-cache/" + snap.Sha3_384})
+	c.Check(osutil.FileExists(path+".lan-partial"), Equals, false)
+}
+
+func (s *storeDownloadSuite) TestDownloadFromLANPeersFallback(c *C) {
+	expectedContent := []byte("I was downloaded")
+
+	downloads := 0
+	restore := store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		downloads++
+		c.Check(url, Equals, "anon-url")
+		w.Write(expectedContent)
+		return nil
+	})
+	defer restore()
+
+	snap := &snap.Info{}
+	snap.RealName = "foo"
+	snap.AnonDownloadURL = "anon-url"
+	snap.Size = int64(len(expectedContent))
+	snap.Sha3_384 = fmt.Sprintf("%x", sha3.Sum384(expectedContent))
+
+	missingPeer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+	}))
+	defer missingPeer.Close()
+	// serves content of the right size but with the wrong hash
+	badPeer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.ToUpper(expectedContent))
+	}))
+	defer badPeer.Close()
+
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	dlOpts := &store.DownloadOptions{LANPeers: []string{"ftp://peer", missingPeer.URL, badPeer.URL}}
+	err := s.store.Download(s.ctx, "foo", path, &snap.DownloadInfo, nil, nil, dlOpts)
+	c.Assert(err, IsNil)
+
+	c.Check(downloads, Equals, 1)
+	c.Check(path, testutil.FileEquals, expectedContent)
+	c.Check(osutil.FileExists(path+".lan-partial"), Equals, false)
+}
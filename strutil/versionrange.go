@@ -0,0 +1,107 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package strutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VersionRange is a set of constraints on versions, like ">=1.2 <2.0",
+// which must all be satisfied by a version for it to be in the range.
+// Versions are ordered as by VersionCompare.
+type VersionRange struct {
+	constraints []versionConstraint
+}
+
+type versionConstraint struct {
+	op      string
+	version string
+}
+
+// the two character operators need to be tried first
+var versionRangeOps = []string{"<=", ">=", "!=", "<", ">", "="}
+
+// ParseVersionRange parses a version range made of space separated
+// constraints, each of which is one of the operators "<", "<=", "=", "!=",
+// ">=" or ">" directly followed by a version.
+func ParseVersionRange(s string) (*VersionRange, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("version range cannot be empty")
+	}
+	vr := &VersionRange{constraints: make([]versionConstraint, 0, len(fields))}
+	for _, f := range fields {
+		var op string
+		for _, o := range versionRangeOps {
+			if strings.HasPrefix(f, o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("invalid version constraint %q: missing operator", f)
+		}
+		version := f[len(op):]
+		if version == "" || strings.ContainsAny(version[:1], "<>=!") || !VersionIsValid(version) {
+			return nil, fmt.Errorf("invalid version constraint %q: invalid version %q", f, version)
+		}
+		vr.constraints = append(vr.constraints, versionConstraint{op: op, version: version})
+	}
+	return vr, nil
+}
+
+// Match returns whether the version satisfies all the constraints of the
+// range. Invalid versions are never in a range.
+func (vr *VersionRange) Match(version string) bool {
+	for _, c := range vr.constraints {
+		res, err := VersionCompare(version, c.version)
+		if err != nil {
+			return false
+		}
+		var ok bool
+		switch c.op {
+		case "<":
+			ok = res < 0
+		case "<=":
+			ok = res <= 0
+		case "=":
+			ok = res == 0
+		case "!=":
+			ok = res != 0
+		case ">=":
+			ok = res >= 0
+		case ">":
+			ok = res > 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (vr *VersionRange) String() string {
+	l := make([]string, len(vr.constraints))
+	for i, c := range vr.constraints {
+		l[i] = c.op + c.version
+	}
+	return strings.Join(l, " ")
+}
@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package strutil_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/strutil"
+)
+
+type versionRangeSuite struct{}
+
+var _ = Suite(&versionRangeSuite{})
+
+func (s *versionRangeSuite) TestMatch(c *C) {
+	for _, t := range []struct {
+		rng     string
+		version string
+		match   bool
+	}{
+		{">=1.2 <2.0", "1.2", true},
+		{">=1.2 <2.0", "1.10", true},
+		{">=1.2 <2.0", "1.9~pre1", true},
+		{">=1.2 <2.0", "2.0", false},
+		{">=1.2 <2.0", "2.0~pre1", true},
+		{">=1.2 <2.0", "1.1", false},
+		{">1.2", "1.2", false},
+		{">1.2", "1.2.1", true},
+		{"<=1.2", "1.2", true},
+		{"<=1.2", "1.2-1", false},
+		{"=1.2", "1.2", true},
+		{"=1.2", "1.02", true},
+		{"!=1.2", "1.2", false},
+		{"!=1.2", "1.3", true},
+		{"  >=1   <3  !=2 ", "2", false},
+		{">=1 <3 !=2", "2.5", true},
+		// invalid versions never match
+		{"!=1.2", "1.2--", false},
+	} {
+		vr, err := strutil.ParseVersionRange(t.rng)
+		c.Assert(err, IsNil, Commentf("%q", t.rng))
+		c.Check(vr.Match(t.version), Equals, t.match, Commentf("%q %q", t.rng, t.version))
+	}
+}
+
+func (s *versionRangeSuite) TestString(c *C) {
+	vr, err := strutil.ParseVersionRange("  >=1.2   <2.0 ")
+	c.Assert(err, IsNil)
+	c.Check(vr.String(), Equals, ">=1.2 <2.0")
+}
+
+func (s *versionRangeSuite) TestParseErrors(c *C) {
+	for _, t := range []struct {
+		rng string
+		err string
+	}{
+		{"", `version range cannot be empty`},
+		{"   ", `version range cannot be empty`},
+		{"1.2", `invalid version constraint "1.2": missing operator`},
+		{">=1.2 2.0", `invalid version constraint "2.0": missing operator`},
+		{">=", `invalid version constraint ">=": invalid version ""`},
+		{"<1.2--", `invalid version constraint "<1.2--": invalid version "1.2--"`},
+		{"=>1.2", `invalid version constraint "=>1.2": invalid version ">1.2"`},
+	} {
+		_, err := strutil.ParseVersionRange(t.rng)
+		c.Check(err, ErrorMatches, t.err, Commentf("%q", t.rng))
+	}
+}
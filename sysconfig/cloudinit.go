@@ -201,17 +201,14 @@ func installCloudInitCfgDir(src, targetdir string, opts *cloudInitConfigInstallO
 		return nil
 	}
 
-	ubuntuDataCloudCfgDir := filepath.Join(ubuntuDataCloudDir(targetdir), "cloud.cfg.d/")
-	if err := os.MkdirAll(ubuntuDataCloudCfgDir, 0755); err != nil {
-		return fmt.Errorf("cannot make cloud config dir: %v", err)
-	}
-
-	for _, cc := range ccl {
-		if err := osutil.CopyFile(cc, filepath.Join(ubuntuDataCloudCfgDir, opts.Prefix+filepath.Base(cc)), 0); err != nil {
-			return err
+	return updateCloudCfgDir(targetdir, func(cfgDir string) error {
+		for _, cc := range ccl {
+			if err := osutil.CopyFile(cc, filepath.Join(cfgDir, opts.Prefix+filepath.Base(cc)), 0); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // installGadgetCloudInitCfg installs a single cloud-init config file from the
@@ -219,23 +216,64 @@ func installCloudInitCfgDir(src, targetdir string, opts *cloudInitConfigInstallO
 // parses and returns what datasources are detected to be in use for the gadget
 // cloud-config.
 func installGadgetCloudInitCfg(src, targetdir string) (*cloudDatasourcesInUseResult, error) {
-	ubuntuDataCloudCfgDir := filepath.Join(ubuntuDataCloudDir(targetdir), "cloud.cfg.d/")
-	if err := os.MkdirAll(ubuntuDataCloudCfgDir, 0755); err != nil {
-		return nil, fmt.Errorf("cannot make cloud config dir: %v", err)
-	}
-
 	datasourcesRes, err := cloudDatasourcesInUse(src)
 	if err != nil {
 		return nil, err
 	}
 
-	configFile := filepath.Join(ubuntuDataCloudCfgDir, "80_device_gadget.cfg")
-	if err := osutil.CopyFile(src, configFile, 0); err != nil {
+	err = updateCloudCfgDir(targetdir, func(cfgDir string) error {
+		return osutil.CopyFile(src, filepath.Join(cfgDir, "80_device_gadget.cfg"), 0)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return datasourcesRes, nil
 }
 
+// updateCloudCfgDir updates the cloud config dir under targetdir with the
+// given function, which is called with a staging copy of the directory. The
+// staging copy then replaces the cloud config dir atomically, so that
+// cloud-init never observes a partially written configuration.
+func updateCloudCfgDir(targetdir string, update func(cfgDir string) error) error {
+	ubuntuDataCloudCfgDir := filepath.Join(ubuntuDataCloudDir(targetdir), "cloud.cfg.d")
+	cloudDir := filepath.Dir(ubuntuDataCloudCfgDir)
+	if err := os.MkdirAll(cloudDir, 0755); err != nil {
+		return fmt.Errorf("cannot make cloud config dir: %v", err)
+	}
+
+	stagingDir, err := ioutil.TempDir(cloudDir, "cloud.cfg.d.")
+	if err != nil {
+		return fmt.Errorf("cannot make cloud config dir: %v", err)
+	}
+	// after the swap this holds the previous configuration
+	defer os.RemoveAll(stagingDir)
+	if err := os.Chmod(stagingDir, 0755); err != nil {
+		return fmt.Errorf("cannot make cloud config dir: %v", err)
+	}
+
+	// carry over the existing configuration
+	existing, err := ioutil.ReadDir(ubuntuDataCloudCfgDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, fi := range existing {
+		src := filepath.Join(ubuntuDataCloudCfgDir, fi.Name())
+		if fi.Mode().IsRegular() {
+			err = osutil.CopyFile(src, filepath.Join(stagingDir, fi.Name()), osutil.CopyFlagPreserveAll)
+		} else {
+			err = osutil.CopySpecialFile(src, stagingDir)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot copy cloud config: %v", err)
+		}
+	}
+
+	if err := update(stagingDir); err != nil {
+		return err
+	}
+	return osutil.AtomicSwapDir(stagingDir, ubuntuDataCloudCfgDir)
+}
+
 func configureCloudInit(model *asserts.Model, opts *Options) (err error) {
 	if opts.TargetRootDir == "" {
 		return fmt.Errorf("unable to configure cloud-init, missing target dir")
@@ -258,6 +258,37 @@ func (s *sysconfigSuite) TestInstallModeCloudInitInstallsOntoHostRunModeWithGadg
 	c.Check(filepath.Join(ubuntuDataCloudCfg, "90_bar.cfg"), testutil.FileEquals, "bar.cfg config")
 }
 
+func (s *sysconfigSuite) TestInstallModeCloudInitKeepsExistingConfigAndSwapsDir(c *C) {
+	cloudCfgSrcDir := s.makeCloudCfgSrcDirFiles(c)
+
+	ubuntuDataCloud := filepath.Join(boot.InstallHostWritableDir, "_writable_defaults/etc/cloud")
+	ubuntuDataCloudCfg := filepath.Join(ubuntuDataCloud, "cloud.cfg.d")
+	c.Assert(os.MkdirAll(ubuntuDataCloudCfg, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(ubuntuDataCloudCfg, "10_existing.cfg"), []byte("existing config"), 0600), IsNil)
+
+	err := sysconfig.ConfigureTargetSystem(fake20Model("dangerous"), &sysconfig.Options{
+		AllowCloudInit:  true,
+		CloudInitSrcDir: cloudCfgSrcDir,
+		TargetRootDir:   boot.InstallHostWritableDir,
+	})
+	c.Assert(err, IsNil)
+
+	c.Check(filepath.Join(ubuntuDataCloudCfg, "10_existing.cfg"), testutil.FileEquals, "existing config")
+	c.Check(filepath.Join(ubuntuDataCloudCfg, "90_foo.cfg"), testutil.FileEquals, "foo.cfg config")
+	c.Check(filepath.Join(ubuntuDataCloudCfg, "90_bar.cfg"), testutil.FileEquals, "bar.cfg config")
+	fi, err := os.Stat(filepath.Join(ubuntuDataCloudCfg, "10_existing.cfg"))
+	c.Assert(err, IsNil)
+	c.Check(fi.Mode().Perm(), Equals, os.FileMode(0600))
+	fi, err = os.Stat(ubuntuDataCloudCfg)
+	c.Assert(err, IsNil)
+	c.Check(fi.Mode().Perm(), Equals, os.FileMode(0755))
+
+	// the staging directory, holding the old configuration, is gone
+	matches, err := filepath.Glob(filepath.Join(ubuntuDataCloud, "*"))
+	c.Assert(err, IsNil)
+	c.Check(matches, DeepEquals, []string{ubuntuDataCloudCfg})
+}
+
 func (s *sysconfigSuite) TestCloudInitStatusUnhappy(c *C) {
 	cmd := testutil.MockCommand(c, "cloud-init", `
 echo cloud-init borken
@@ -103,6 +103,10 @@ func (s *emulation) CurrentTasksCount(unit string) (uint64, error) {
 	return 0, errNotImplemented
 }
 
+func (s *emulation) CurrentCPUUsage(unit string) (time.Duration, error) {
+	return 0, errNotImplemented
+}
+
 func (s *emulation) IsEnabled(service string) (bool, error) {
 	return false, errNotImplemented
 }
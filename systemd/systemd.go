@@ -270,6 +270,9 @@ type Systemd interface {
 	// threads if enabled, etc) part of the unit, which can be a service or a
 	// slice.
 	CurrentTasksCount(unit string) (uint64, error)
+	// CurrentCPUUsage returns the CPU time consumed so far by the
+	// specified unit.
+	CurrentCPUUsage(unit string) (time.Duration, error)
 }
 
 // A Log is a single entry in the systemd journal.
@@ -301,6 +304,9 @@ const (
 	// the default target for systemd socket units that we generate
 	SocketsTarget = "sockets.target"
 
+	// the default target for systemd path units that we generate
+	PathsTarget = "paths.target"
+
 	// the default target for systemd timer units that we generate
 	TimersTarget = "timers.target"
 
@@ -476,6 +482,7 @@ var extendedProperties = []string{"Id", "ActiveState", "UnitFileState", "Type"}
 var unitProperties = map[string][]string{
 	".timer":  baseProperties,
 	".socket": baseProperties,
+	".path":   baseProperties,
 	// in service units, Type is the daemon type
 	".service": extendedProperties,
 	// in mount units, Type is the fs type
@@ -663,6 +670,19 @@ func (s *systemd) CurrentMemoryUsage(unit string) (quantity.Size, error) {
 	return quantity.Size(memBytes), nil
 }
 
+func (s *systemd) CurrentCPUUsage(unit string) (time.Duration, error) {
+	cpuNSec, err := s.getPropertyUintValue(unit, "CPUUsageNSec")
+	if err != nil && err != errNotSet {
+		return 0, err
+	}
+
+	if err == errNotSet {
+		return 0, fmt.Errorf("CPU usage unavailable")
+	}
+
+	return time.Duration(cpuNSec), nil
+}
+
 func (s *systemd) InactiveEnterTimestamp(unit string) (time.Time, error) {
 	timeStr, err := s.getPropertyStringValue(unit, "InactiveEnterTimestamp")
 	if err != nil {
@@ -691,7 +711,7 @@ func (s *systemd) Status(unitNames ...string) ([]*UnitStatus, error) {
 	var extendedUnits []string
 
 	for _, name := range unitNames {
-		if strings.HasSuffix(name, ".timer") || strings.HasSuffix(name, ".socket") {
+		if strings.HasSuffix(name, ".timer") || strings.HasSuffix(name, ".socket") || strings.HasSuffix(name, ".path") {
 			limitedUnits = append(limitedUnits, name)
 		} else {
 			extendedUnits = append(extendedUnits, name)
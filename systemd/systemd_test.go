@@ -219,10 +219,14 @@ UnitFileState=enabled
 Id=other.socket
 ActiveState=active
 UnitFileState=disabled
+
+Id=spool.path
+ActiveState=active
+UnitFileState=enabled
 `[1:]),
 	}
 	s.errors = []error{nil}
-	out, err := New(SystemMode, s.rep).Status("foo.service", "bar.service", "baz.service", "missing.service", "some.timer", "other.socket")
+	out, err := New(SystemMode, s.rep).Status("foo.service", "bar.service", "baz.service", "missing.service", "some.timer", "other.socket", "spool.path")
 	c.Assert(err, IsNil)
 	c.Check(out, DeepEquals, []*UnitStatus{
 		{
@@ -259,12 +263,17 @@ UnitFileState=disabled
 			Active:    true,
 			Enabled:   false,
 			Installed: true,
+		}, {
+			UnitName:  "spool.path",
+			Active:    true,
+			Enabled:   true,
+			Installed: true,
 		},
 	})
 	c.Check(s.rep.msgs, IsNil)
 	c.Assert(s.argses, DeepEquals, [][]string{
 		{"show", "--property=Id,ActiveState,UnitFileState,Type", "foo.service", "bar.service", "baz.service", "missing.service"},
-		{"show", "--property=Id,ActiveState,UnitFileState", "some.timer", "other.socket"},
+		{"show", "--property=Id,ActiveState,UnitFileState", "some.timer", "other.socket", "spool.path"},
 	})
 }
 
@@ -1415,15 +1424,19 @@ func (s *SystemdTestSuite) TestCurrentUsageFamilyReallyInvalid(c *C) {
 	s.outs = [][]byte{
 		[]byte(`gahstringsarehard`),
 		[]byte(`gahstringsarehard`),
+		[]byte(`gahstringsarehard`),
 	}
 	sysd := New(SystemMode, s.rep)
 	_, err := sysd.CurrentMemoryUsage("bar.service")
 	c.Assert(err, ErrorMatches, `invalid property format from systemd for MemoryCurrent \(got gahstringsarehard\)`)
 	_, err = sysd.CurrentTasksCount("bar.service")
 	c.Assert(err, ErrorMatches, `invalid property format from systemd for TasksCurrent \(got gahstringsarehard\)`)
+	_, err = sysd.CurrentCPUUsage("bar.service")
+	c.Assert(err, ErrorMatches, `invalid property format from systemd for CPUUsageNSec \(got gahstringsarehard\)`)
 	c.Check(s.argses, DeepEquals, [][]string{
 		{"show", "--property", "MemoryCurrent", "bar.service"},
 		{"show", "--property", "TasksCurrent", "bar.service"},
+		{"show", "--property", "CPUUsageNSec", "bar.service"},
 	})
 }
 
@@ -1431,15 +1444,19 @@ func (s *SystemdTestSuite) TestCurrentUsageFamilyInactive(c *C) {
 	s.outs = [][]byte{
 		[]byte(`MemoryCurrent=[not set]`),
 		[]byte(`TasksCurrent=[not set]`),
+		[]byte(`CPUUsageNSec=[not set]`),
 	}
 	sysd := New(SystemMode, s.rep)
 	_, err := sysd.CurrentMemoryUsage("bar.service")
 	c.Assert(err, ErrorMatches, "memory usage unavailable")
 	_, err = sysd.CurrentTasksCount("bar.service")
 	c.Assert(err, ErrorMatches, "tasks count unavailable")
+	_, err = sysd.CurrentCPUUsage("bar.service")
+	c.Assert(err, ErrorMatches, "CPU usage unavailable")
 	c.Check(s.argses, DeepEquals, [][]string{
 		{"show", "--property", "MemoryCurrent", "bar.service"},
 		{"show", "--property", "TasksCurrent", "bar.service"},
+		{"show", "--property", "CPUUsageNSec", "bar.service"},
 	})
 }
 
@@ -1464,6 +1481,7 @@ func (s *SystemdTestSuite) TestCurrentUsageFamilyHappy(c *C) {
 		[]byte(`MemoryCurrent=1024`),
 		[]byte(`MemoryCurrent=18446744073709551615`), // special value from systemd bug
 		[]byte(`TasksCurrent=10`),
+		[]byte(`CPUUsageNSec=2500000000`),
 	}
 	sysd := New(SystemMode, s.rep)
 	memUsage, err := sysd.CurrentMemoryUsage("bar.service")
@@ -1476,10 +1494,14 @@ func (s *SystemdTestSuite) TestCurrentUsageFamilyHappy(c *C) {
 	tasksUsage, err := sysd.CurrentTasksCount("bar.service")
 	c.Assert(tasksUsage, Equals, uint64(10))
 	c.Assert(err, IsNil)
+	cpuUsage, err := sysd.CurrentCPUUsage("bar.service")
+	c.Assert(err, IsNil)
+	c.Assert(cpuUsage, Equals, 2500*time.Millisecond)
 	c.Check(s.argses, DeepEquals, [][]string{
 		{"show", "--property", "MemoryCurrent", "bar.service"},
 		{"show", "--property", "MemoryCurrent", "bar.service"},
 		{"show", "--property", "TasksCurrent", "bar.service"},
+		{"show", "--property", "CPUUsageNSec", "bar.service"},
 	})
 }
 
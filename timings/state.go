@@ -48,12 +48,17 @@ type rootTimingsJSON struct {
 type TimingsInfo struct {
 	Tags          map[string]string
 	NestedTimings []*TimingJSON
+	StartTime     time.Time
 	Duration      time.Duration
 }
 
 // Maximum number of timings to keep in state. It can be changed only while holding state lock.
 var MaxTimings = 100
 
+// Maximum age of timings kept in state, older timings are dropped when
+// new ones are saved. It can be changed only while holding state lock.
+var MaxTimingsAge = 30 * 24 * time.Hour
+
 // Duration threshold - timings below the threshold will not be saved in the state.
 // It can be changed only while holding state lock.
 var DurationThreshold = 5 * time.Millisecond
@@ -152,9 +157,31 @@ func (t *Timings) Save(s GetSaver) {
 	if len(stateTimings) > MaxTimings {
 		stateTimings = stateTimings[len(stateTimings)-MaxTimings:]
 	}
+	// the stop time of the new timings stands for the current time,
+	// not to consume the clock once more
+	if root, ok := data.(*rootTimingsJSON); ok && !root.StopTime.IsZero() {
+		stateTimings = dropExpired(stateTimings, root.StopTime)
+	}
 	s.SaveTimings(stateTimings)
 }
 
+// dropExpired drops the timings which stopped more than MaxTimingsAge
+// before now, timings are kept in the order they were saved so it is
+// enough to look for the first one which is recent enough. Timings without
+// a stop time are kept.
+func dropExpired(stateTimings []*json.RawMessage, now time.Time) []*json.RawMessage {
+	cutoff := now.Add(-MaxTimingsAge)
+	for i, raw := range stateTimings {
+		var tm struct {
+			StopTime time.Time `json:"stop-time"`
+		}
+		if err := json.Unmarshal(*raw, &tm); err != nil || tm.StopTime.IsZero() || tm.StopTime.After(cutoff) {
+			return stateTimings[i:]
+		}
+	}
+	return nil
+}
+
 // Get returns timings for which filter predicate is true and filters
 // out nested timings whose level is greater than maxLevel.
 // Negative maxLevel value disables filtering by level.
@@ -172,8 +199,9 @@ func Get(s GetSaver, maxLevel int, filter func(tags map[string]string) bool) ([]
 			continue
 		}
 		res := &TimingsInfo{
-			Tags:     tm.Tags,
-			Duration: timeDuration(tm.StartTime, tm.StopTime),
+			Tags:      tm.Tags,
+			StartTime: tm.StartTime,
+			Duration:  timeDuration(tm.StartTime, tm.StopTime),
 		}
 		// negative maxLevel means no level filtering, take all nested timings
 		if maxLevel < 0 {
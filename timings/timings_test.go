@@ -327,10 +327,47 @@ func (s *timingsSuite) TestPurgeOnSave(c *C) {
 	c.Check(stateTimings[2].(map[string]interface{})["tags"], DeepEquals, map[string]interface{}{"number": "9"})
 }
 
+func (s *timingsSuite) TestPurgeExpiredOnSave(c *C) {
+	oldMaxTimingsAge := timings.MaxTimingsAge
+	timings.MaxTimingsAge = time.Hour
+	defer func() {
+		timings.MaxTimingsAge = oldMaxTimingsAge
+	}()
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	for i := 0; i < 3; i++ {
+		t := timings.New(map[string]string{"number": fmt.Sprintf("%d", i)})
+		m := t.StartSpan("...", "...")
+		m.Stop()
+		t.Save(s.st)
+		// the first two timings are more than an hour apart
+		if i == 0 {
+			s.fakeTime = s.fakeTime.Add(2 * time.Hour)
+		}
+	}
+
+	var stateTimings []interface{}
+	c.Assert(s.st.Get("timings", &stateTimings), IsNil)
+
+	// the first timing expired
+	c.Assert(stateTimings, HasLen, 2)
+	c.Check(stateTimings[0].(map[string]interface{})["tags"], DeepEquals, map[string]interface{}{"number": "1"})
+	c.Check(stateTimings[1].(map[string]interface{})["tags"], DeepEquals, map[string]interface{}{"number": "2"})
+}
+
 func (s *timingsSuite) TestGet(c *C) {
 	s.st.Lock()
 	defer s.st.Unlock()
 
+	startTime := func(i int) time.Time {
+		// each iteration below takes 4 calls to the mocked now, 1ms each
+		t, err := time.Parse(time.RFC3339, "2019-03-11T09:01:00.0Z")
+		c.Assert(err, IsNil)
+		return t.Add(time.Duration(4*i+1) * time.Millisecond)
+	}
+
 	// three timings, with 2 nested measures
 	for i := 0; i < 3; i++ {
 		timing := timings.New(map[string]string{"foo": fmt.Sprintf("%d", i)})
@@ -351,8 +388,9 @@ func (s *timingsSuite) TestGet(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(tm, DeepEquals, []*timings.TimingsInfo{
 		{
-			Tags:     map[string]string{"foo": "1"},
-			Duration: 3000000,
+			Tags:      map[string]string{"foo": "1"},
+			StartTime: startTime(1),
+			Duration:  3000000,
 			NestedTimings: []*timings.TimingJSON{
 				{Level: 0, Label: "doing something-1", Summary: "...", Duration: 3000000},
 				{Level: 1, Label: "nested measurement", Summary: "...", Duration: 1000000},
@@ -364,22 +402,25 @@ func (s *timingsSuite) TestGet(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(tmOnlyLevel0, DeepEquals, []*timings.TimingsInfo{
 		{
-			Tags:     map[string]string{"foo": "0"},
-			Duration: 3000000,
+			Tags:      map[string]string{"foo": "0"},
+			StartTime: startTime(0),
+			Duration:  3000000,
 			NestedTimings: []*timings.TimingJSON{
 				{Level: 0, Label: "doing something-0", Summary: "...", Duration: 3000000},
 			},
 		},
 		{
-			Tags:     map[string]string{"foo": "1"},
-			Duration: 3000000,
+			Tags:      map[string]string{"foo": "1"},
+			StartTime: startTime(1),
+			Duration:  3000000,
 			NestedTimings: []*timings.TimingJSON{
 				{Level: 0, Label: "doing something-1", Summary: "...", Duration: 3000000},
 			},
 		},
 		{
-			Tags:     map[string]string{"foo": "2"},
-			Duration: 3000000,
+			Tags:      map[string]string{"foo": "2"},
+			StartTime: startTime(2),
+			Duration:  3000000,
 			NestedTimings: []*timings.TimingJSON{
 				{Level: 0, Label: "doing something-2", Summary: "...", Duration: 3000000},
 			},
@@ -20,16 +20,52 @@
 package agent
 
 import (
+	"encoding/json"
 	"syscall"
 	"time"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/desktop/notification"
 )
 
 var (
 	SessionInfoCmd                = sessionInfoCmd
 	ServiceControlCmd             = serviceControlCmd
+	ServiceStatusCmd              = serviceStatusCmd
 	PendingRefreshNotificationCmd = pendingRefreshNotificationCmd
+	DecisionCmd                   = decisionCmd
 )
 
+var ParseGSettingsString = parseGSettingsString
+
+func (s *SessionAgent) ReportMissingThemes() error {
+	return s.reportMissingThemes()
+}
+
+func MockGSettingsGet(f func(schema, key string) (string, error)) (restore func()) {
+	old := gsettingsGet
+	gsettingsGet = f
+	return func() {
+		gsettingsGet = old
+	}
+}
+
+func MockReportThemes(f func(gtkThemes, iconThemes, soundThemes []string) (*client.ThemesReport, error)) (restore func()) {
+	old := reportThemes
+	reportThemes = f
+	return func() {
+		reportThemes = old
+	}
+}
+
+func MockMaxDecisionTimeout(d time.Duration) (restore func()) {
+	old := maxDecisionTimeout
+	maxDecisionTimeout = d
+	return func() {
+		maxDecisionTimeout = old
+	}
+}
+
 func MockStopTimeouts(stop, kill time.Duration) (restore func()) {
 	oldStopTimeout := stopTimeout
 	stopTimeout = stop
@@ -59,3 +95,16 @@ func MockNoBus(agent *SessionAgent) (restore func()) {
 		agent.bus = bus
 	}
 }
+
+func NewDecisionObserver(id notification.ID) (observer notification.Observer, result func() map[string]interface{}) {
+	o := &decisionObserver{id: id}
+	return o, func() map[string]interface{} {
+		if o.result == nil {
+			return nil
+		}
+		var m map[string]interface{}
+		data, _ := json.Marshal(o.result)
+		json.Unmarshal(data, &m)
+		return m
+	}
+}
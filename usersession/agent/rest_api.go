@@ -20,6 +20,7 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"mime"
@@ -34,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/desktop/notification"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/timeout"
 )
@@ -42,7 +44,9 @@ var restApi = []*Command{
 	rootCmd,
 	sessionInfoCmd,
 	serviceControlCmd,
+	serviceStatusCmd,
 	pendingRefreshNotificationCmd,
+	decisionCmd,
 }
 
 var (
@@ -61,10 +65,20 @@ var (
 		POST: postServiceControl,
 	}
 
+	serviceStatusCmd = &Command{
+		Path: "/v1/service-status",
+		GET:  serviceStatus,
+	}
+
 	pendingRefreshNotificationCmd = &Command{
 		Path: "/v1/notifications/pending-refresh",
 		POST: postPendingRefreshNotification,
 	}
+
+	decisionCmd = &Command{
+		Path: "/v1/decisions",
+		POST: postDecision,
+	}
 )
 
 func sessionInfo(c *Command, r *http.Request) Response {
@@ -157,6 +171,42 @@ func serviceStop(inst *serviceInstruction, sysd systemd.Systemd) Response {
 	})
 }
 
+func serviceRestart(inst *serviceInstruction, sysd systemd.Systemd) Response {
+	// Refuse to restart non-snap services
+	for _, service := range inst.Services {
+		if !strings.HasPrefix(service, "snap.") {
+			return InternalError("cannot restart non-snap service %v", service)
+		}
+	}
+
+	restartErrors := make(map[string]string)
+	for _, service := range inst.Services {
+		var err error
+		if inst.Action == "reload-or-restart" {
+			err = sysd.ReloadOrRestart(service)
+		} else {
+			err = sysd.Restart(service, stopTimeout)
+		}
+		if err != nil {
+			restartErrors[service] = err.Error()
+		}
+	}
+	if len(restartErrors) == 0 {
+		return SyncResponse(nil)
+	}
+	return SyncResponse(&resp{
+		Type:   ResponseTypeError,
+		Status: 500,
+		Result: &errorResult{
+			Message: "some user services failed to restart",
+			Kind:    errorKindServiceControl,
+			Value: map[string]interface{}{
+				"restart-errors": restartErrors,
+			},
+		},
+	})
+}
+
 func serviceDaemonReload(inst *serviceInstruction, sysd systemd.Systemd) Response {
 	if len(inst.Services) != 0 {
 		return InternalError("daemon-reload should not be called with any services")
@@ -168,9 +218,11 @@ func serviceDaemonReload(inst *serviceInstruction, sysd systemd.Systemd) Respons
 }
 
 var serviceInstructionDispTable = map[string]func(*serviceInstruction, systemd.Systemd) Response{
-	"start":         serviceStart,
-	"stop":          serviceStop,
-	"daemon-reload": serviceDaemonReload,
+	"start":             serviceStart,
+	"stop":              serviceStop,
+	"restart":           serviceRestart,
+	"reload-or-restart": serviceRestart,
+	"daemon-reload":     serviceDaemonReload,
 }
 
 var systemdLock sync.Mutex
@@ -219,6 +271,42 @@ func postServiceControl(c *Command, r *http.Request) Response {
 	return impl(&inst, sysd)
 }
 
+type serviceUnitStatus struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Active  bool   `json:"active"`
+}
+
+func serviceStatus(c *Command, r *http.Request) Response {
+	services := strutil.CommaSeparatedList(r.URL.Query().Get("services"))
+	if len(services) == 0 {
+		return BadRequest("cannot get status of services without a list of services")
+	}
+	// Refuse to report on non-snap units
+	for _, service := range services {
+		if !strings.HasPrefix(service, "snap.") {
+			return BadRequest("cannot get status of non-snap service %v", service)
+		}
+	}
+
+	systemdLock.Lock()
+	defer systemdLock.Unlock()
+	sysd := systemd.New(systemd.UserMode, dummyReporter{})
+	sts, err := sysd.Status(services...)
+	if err != nil {
+		return InternalError("cannot get status of services: %v", err)
+	}
+	result := make([]serviceUnitStatus, len(sts))
+	for i, st := range sts {
+		result[i] = serviceUnitStatus{
+			Name:    st.UnitName,
+			Enabled: st.Enabled,
+			Active:  st.Active,
+		}
+	}
+	return SyncResponse(result)
+}
+
 func postPendingRefreshNotification(c *Command, r *http.Request) Response {
 	if ok, resp := validateJSONRequest(r); !ok {
 		return resp
@@ -309,3 +397,170 @@ func postPendingRefreshNotification(c *Command, r *http.Request) Response {
 	}
 	return SyncResponse(nil)
 }
+
+// decisionKinds are the kinds of interactive decisions snapd may ask
+// the user to make.
+var decisionKinds = map[string]bool{
+	"prompt":                   true,
+	"theme-install":            true,
+	"refresh-inhibit-override": true,
+}
+
+// Action keys of the notification presented for a decision.
+const (
+	decisionAllow       = "allow"
+	decisionAllowAlways = "allow-always"
+	decisionDeny        = "deny"
+	decisionDenyAlways  = "deny-always"
+)
+
+// maxDecisionTimeout is the longest time a decision request waits for the
+// user to answer before it is considered denied.
+var maxDecisionTimeout = 5 * time.Minute
+
+// decisionRequest holds a request for an interactive user decision provided
+// by snapd.
+type decisionRequest struct {
+	Kind         string        `json:"kind"`
+	Summary      string        `json:"summary"`
+	Body         string        `json:"body,omitempty"`
+	DesktopEntry string        `json:"desktop-entry,omitempty"`
+	Timeout      time.Duration `json:"timeout,omitempty"`
+}
+
+// decisionResult holds the outcome of a decision request. A request that
+// was dismissed or that timed out is reported as not answered and denied.
+type decisionResult struct {
+	Answered bool `json:"answered"`
+	Allow    bool `json:"allow"`
+	Remember bool `json:"remember"`
+}
+
+// decisionObserver waits for the user to act on a single notification.
+type decisionObserver struct {
+	id     notification.ID
+	result *decisionResult
+}
+
+var errDecisionMade = fmt.Errorf("decision made")
+
+func (o *decisionObserver) NotificationClosed(id notification.ID, reason notification.CloseReason) error {
+	if id != o.id {
+		return nil
+	}
+	o.result = &decisionResult{}
+	return errDecisionMade
+}
+
+func (o *decisionObserver) ActionInvoked(id notification.ID, actionKey string) error {
+	if id != o.id {
+		return nil
+	}
+	result := &decisionResult{Answered: true}
+	switch actionKey {
+	case decisionAllow:
+		result.Allow = true
+	case decisionAllowAlways:
+		result.Allow = true
+		result.Remember = true
+	case decisionDeny:
+	case decisionDenyAlways:
+		result.Remember = true
+	default:
+		// not one of ours, keep waiting
+		return nil
+	}
+	o.result = result
+	return errDecisionMade
+}
+
+func postDecision(c *Command, r *http.Request) Response {
+	if ok, resp := validateJSONRequest(r); !ok {
+		return resp
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var req decisionRequest
+	if err := decoder.Decode(&req); err != nil {
+		return BadRequest("cannot decode request body into decision request: %v", err)
+	}
+	if !decisionKinds[req.Kind] {
+		return BadRequest("unknown decision kind %q", req.Kind)
+	}
+	if req.Summary == "" {
+		return BadRequest("cannot request a decision without a summary")
+	}
+	timeout := maxDecisionTimeout
+	if req.Timeout > 0 && req.Timeout < timeout {
+		timeout = req.Timeout
+	}
+
+	// Note that since the connection is shared, we are not closing it.
+	if c.s.bus == nil {
+		return SyncResponse(&resp{
+			Type:   ResponseTypeError,
+			Status: 500,
+			Result: &errorResult{
+				Message: fmt.Sprintf("cannot connect to the session bus"),
+			},
+		})
+	}
+
+	notifySrv := notification.New(c.s.bus)
+	hints := []notification.Hint{
+		notification.WithUrgency(notification.CriticalUrgency),
+		notification.WithResident(),
+	}
+	desktopEntry := req.DesktopEntry
+	if desktopEntry == "" {
+		desktopEntry = "io.snapcraft.SessionAgent"
+	}
+	hints = append(hints, notification.WithDesktopEntry(desktopEntry))
+
+	msg := &notification.Message{
+		Summary: req.Summary,
+		Body:    req.Body,
+		Actions: []notification.Action{
+			{ActionKey: decisionAllow, LocalizedText: i18n.G("Allow")},
+			{ActionKey: decisionAllowAlways, LocalizedText: i18n.G("Always allow")},
+			{ActionKey: decisionDeny, LocalizedText: i18n.G("Deny")},
+			{ActionKey: decisionDenyAlways, LocalizedText: i18n.G("Always deny")},
+		},
+		Hints: hints,
+	}
+	id, err := notifySrv.SendNotification(msg)
+	if err != nil {
+		return SyncResponse(&resp{
+			Type:   ResponseTypeError,
+			Status: 500,
+			Result: &errorResult{
+				Message: fmt.Sprintf("cannot send notification message: %v", err),
+			},
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	// TODO: an action invoked before the signal match is in place is
+	// missed and the request times out as denied.
+	observer := &decisionObserver{id: id}
+	err = notifySrv.ObserveNotifications(ctx, observer)
+	if observer.result == nil || observer.result.Answered {
+		// resident notifications stay around after an action is
+		// invoked, withdraw the question as it is settled now
+		notifySrv.CloseNotification(id)
+	}
+	if observer.result != nil {
+		return SyncResponse(observer.result)
+	}
+	if err == context.DeadlineExceeded || err == context.Canceled {
+		return SyncResponse(&decisionResult{})
+	}
+	return SyncResponse(&resp{
+		Type:   ResponseTypeError,
+		Status: 500,
+		Result: &errorResult{
+			Message: fmt.Sprintf("cannot wait for decision: %v", err),
+		},
+	})
+}
@@ -362,6 +362,155 @@ func (s *restSuite) TestServicesStopReportsTimeout(c *C) {
 	})
 }
 
+func (s *restSuite) TestServicesRestart(c *C) {
+	req := httptest.NewRequest("POST", "/v1/service-control", bytes.NewBufferString(`{"action":"restart","services":["snap.foo.service", "snap.bar.service"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	agent.ServiceControlCmd.POST(agent.ServiceControlCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 200)
+	c.Check(rec.HeaderMap.Get("Content-Type"), Equals, "application/json")
+
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Type, Equals, agent.ResponseTypeSync)
+	c.Check(rsp.Result, Equals, nil)
+
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"--user", "stop", "snap.foo.service"},
+		{"--user", "show", "--property=ActiveState", "snap.foo.service"},
+		{"--user", "start", "snap.foo.service"},
+		{"--user", "stop", "snap.bar.service"},
+		{"--user", "show", "--property=ActiveState", "snap.bar.service"},
+		{"--user", "start", "snap.bar.service"},
+	})
+}
+
+func (s *restSuite) TestServicesReloadOrRestart(c *C) {
+	req := httptest.NewRequest("POST", "/v1/service-control", bytes.NewBufferString(`{"action":"reload-or-restart","services":["snap.foo.service"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	agent.ServiceControlCmd.POST(agent.ServiceControlCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 200)
+
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"--user", "reload-or-restart", "snap.foo.service"},
+	})
+}
+
+func (s *restSuite) TestServicesRestartNonSnap(c *C) {
+	req := httptest.NewRequest("POST", "/v1/service-control", bytes.NewBufferString(`{"action":"restart","services":["snap.foo.service", "not-snap.bar.service"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	agent.ServiceControlCmd.POST(agent.ServiceControlCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 500)
+
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Type, Equals, agent.ResponseTypeError)
+	c.Check(rsp.Result, DeepEquals, map[string]interface{}{
+		"message": "cannot restart non-snap service not-snap.bar.service",
+	})
+
+	// No services were restarted on the error.
+	c.Check(s.sysdLog, HasLen, 0)
+}
+
+func (s *restSuite) TestServicesRestartReportsFailures(c *C) {
+	restore := systemd.MockSystemctl(func(cmd ...string) ([]byte, error) {
+		if cmd[1] == "reload-or-restart" && cmd[2] == "snap.bar.service" {
+			return nil, fmt.Errorf("mock systemctl error")
+		}
+		return nil, nil
+	})
+	defer restore()
+
+	req := httptest.NewRequest("POST", "/v1/service-control", bytes.NewBufferString(`{"action":"reload-or-restart","services":["snap.foo.service", "snap.bar.service"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	agent.ServiceControlCmd.POST(agent.ServiceControlCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 500)
+
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Type, Equals, agent.ResponseTypeError)
+	c.Check(rsp.Result, DeepEquals, map[string]interface{}{
+		"message": "some user services failed to restart",
+		"kind":    "service-control",
+		"value": map[string]interface{}{
+			"restart-errors": map[string]interface{}{
+				"snap.bar.service": "mock systemctl error",
+			},
+		},
+	})
+}
+
+func (s *restSuite) TestServiceStatus(c *C) {
+	// the agent.ServiceStatus end point only supports GET requests
+	c.Check(agent.ServiceStatusCmd.PUT, IsNil)
+	c.Check(agent.ServiceStatusCmd.POST, IsNil)
+	c.Check(agent.ServiceStatusCmd.DELETE, IsNil)
+	c.Assert(agent.ServiceStatusCmd.GET, NotNil)
+
+	c.Check(agent.ServiceStatusCmd.Path, Equals, "/v1/service-status")
+
+	var sysdLog [][]string
+	restore := systemd.MockSystemctl(func(cmd ...string) ([]byte, error) {
+		sysdLog = append(sysdLog, cmd)
+		return []byte(`Type=simple
+Id=snap.foo.service
+ActiveState=active
+UnitFileState=enabled
+
+Type=simple
+Id=snap.bar.service
+ActiveState=inactive
+UnitFileState=disabled
+`), nil
+	})
+	defer restore()
+
+	req := httptest.NewRequest("GET", "/v1/service-status?services=snap.foo.service,snap.bar.service", nil)
+	rec := httptest.NewRecorder()
+	agent.ServiceStatusCmd.GET(agent.ServiceStatusCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 200)
+	c.Check(rec.HeaderMap.Get("Content-Type"), Equals, "application/json")
+
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Type, Equals, agent.ResponseTypeSync)
+	c.Check(rsp.Result, DeepEquals, []interface{}{
+		map[string]interface{}{"name": "snap.foo.service", "enabled": true, "active": true},
+		map[string]interface{}{"name": "snap.bar.service", "enabled": false, "active": false},
+	})
+
+	c.Check(sysdLog, DeepEquals, [][]string{
+		{"--user", "show", "--property=Id,ActiveState,UnitFileState,Type", "snap.foo.service", "snap.bar.service"},
+	})
+}
+
+func (s *restSuite) TestServiceStatusErrors(c *C) {
+	for _, t := range []struct {
+		query string
+		msg   string
+	}{
+		{"", "cannot get status of services without a list of services"},
+		{"?services=snap.foo.service,not-snap.bar.service", "cannot get status of non-snap service not-snap.bar.service"},
+	} {
+		req := httptest.NewRequest("GET", "/v1/service-status"+t.query, nil)
+		rec := httptest.NewRecorder()
+		agent.ServiceStatusCmd.GET(agent.ServiceStatusCmd, req).ServeHTTP(rec, req)
+		c.Check(rec.Code, Equals, 400)
+
+		var rsp resp
+		c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+		c.Check(rsp.Type, Equals, agent.ResponseTypeError)
+		c.Check(rsp.Result, DeepEquals, map[string]interface{}{
+			"message": t.msg,
+		})
+	}
+	c.Check(s.sysdLog, HasLen, 0)
+}
+
 func (s *restSuite) TestPostPendingRefreshNotificationMalformedContentType(c *C) {
 	req := httptest.NewRequest("POST", "/v1/notifications/pending-refresh", bytes.NewBufferString(""))
 	req.Header.Set("Content-Type", "text/plain/joke")
@@ -596,3 +745,158 @@ func (s *restSuite) TestPostPendingRefreshNotificationNotificationServerFailure(
 	c.Check(rsp.Type, Equals, agent.ResponseTypeError)
 	c.Check(rsp.Result, DeepEquals, map[string]interface{}{"message": "cannot send notification message: org.freedesktop.DBus.Error.Failed"})
 }
+
+func (s *restSuite) postDecision(c *C, body string) <-chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		req := httptest.NewRequest("POST", "/v1/decisions", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		agent.DecisionCmd.POST(agent.DecisionCmd, req).ServeHTTP(rec, req)
+		done <- rec
+	}()
+	return done
+}
+
+func (s *restSuite) waitForDecisionNotification(c *C) *notificationtest.FdoNotification {
+	for i := 0; i < 500; i++ {
+		if notifications := s.notify.GetAll(); len(notifications) > 0 {
+			c.Assert(notifications, HasLen, 1)
+			return notifications[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Fatalf("decision notification was not sent")
+	return nil
+}
+
+func (s *restSuite) checkDecisionResult(c *C, rec *httptest.ResponseRecorder, expected map[string]interface{}) {
+	c.Check(rec.Code, Equals, 200)
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Type, Equals, agent.ResponseTypeSync)
+	c.Check(rsp.Result, DeepEquals, expected)
+}
+
+func (s *restSuite) testPostDecisionAction(c *C, actionKey string, expected map[string]interface{}) {
+	done := s.postDecision(c, `{"kind":"theme-install","summary":"Install themes?","body":"Some snaps need themes."}`)
+	n := s.waitForDecisionNotification(c)
+	c.Check(n.Summary, Equals, "Install themes?")
+	c.Check(n.Body, Equals, "Some snaps need themes.")
+	c.Check(n.Actions, DeepEquals, []string{
+		"allow", "Allow",
+		"allow-always", "Always allow",
+		"deny", "Deny",
+		"deny-always", "Always deny",
+	})
+	c.Check(n.Hints, DeepEquals, map[string]dbus.Variant{
+		"urgency":       dbus.MakeVariant(byte(notification.CriticalUrgency)),
+		"resident":      dbus.MakeVariant(true),
+		"desktop-entry": dbus.MakeVariant("io.snapcraft.SessionAgent"),
+	})
+
+	// the action may be emitted before the agent observes signals, so
+	// keep invoking it until the request completes
+	for {
+		c.Assert(s.notify.InvokeAction(n.ID, actionKey), IsNil)
+		select {
+		case rec := <-done:
+			s.checkDecisionResult(c, rec, expected)
+			// a settled question is withdrawn
+			c.Check(s.notify.Get(n.ID), IsNil)
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func (s *restSuite) TestPostDecisionAllow(c *C) {
+	s.testPostDecisionAction(c, "allow", map[string]interface{}{
+		"answered": true, "allow": true, "remember": false,
+	})
+}
+
+func (s *restSuite) TestPostDecisionAllowAlways(c *C) {
+	s.testPostDecisionAction(c, "allow-always", map[string]interface{}{
+		"answered": true, "allow": true, "remember": true,
+	})
+}
+
+func (s *restSuite) TestPostDecisionDeny(c *C) {
+	s.testPostDecisionAction(c, "deny", map[string]interface{}{
+		"answered": true, "allow": false, "remember": false,
+	})
+}
+
+func (s *restSuite) TestPostDecisionDenyAlways(c *C) {
+	s.testPostDecisionAction(c, "deny-always", map[string]interface{}{
+		"answered": true, "allow": false, "remember": true,
+	})
+}
+
+func (s *restSuite) TestDecisionObserverDismissed(c *C) {
+	observer, result := agent.NewDecisionObserver(42)
+	// other notifications are ignored
+	c.Check(observer.NotificationClosed(41, notification.CloseReasonDismissed), IsNil)
+	c.Check(observer.ActionInvoked(41, "allow"), IsNil)
+	// as are unknown actions
+	c.Check(observer.ActionInvoked(42, "foo"), IsNil)
+	c.Check(result(), IsNil)
+
+	c.Check(observer.NotificationClosed(42, notification.CloseReasonDismissed), NotNil)
+	c.Check(result(), DeepEquals, map[string]interface{}{
+		"answered": false, "allow": false, "remember": false,
+	})
+}
+
+func (s *restSuite) TestPostDecisionTimeout(c *C) {
+	restore := agent.MockMaxDecisionTimeout(50 * time.Millisecond)
+	defer restore()
+
+	rec := <-s.postDecision(c, `{"kind":"refresh-inhibit-override","summary":"Refresh now?","timeout":3600000000000}`)
+	s.checkDecisionResult(c, rec, map[string]interface{}{
+		"answered": false, "allow": false, "remember": false,
+	})
+	// the unanswered question is withdrawn
+	c.Check(s.notify.GetAll(), HasLen, 0)
+}
+
+func (s *restSuite) TestPostDecisionErrors(c *C) {
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{"kind":syntaxerror}`, "cannot decode request body into decision request: invalid character 's' looking for beginning of value"},
+		{`{"kind":"foo","summary":"bar"}`, `unknown decision kind "foo"`},
+		{`{"kind":"prompt"}`, "cannot request a decision without a summary"},
+	} {
+		rec := <-s.postDecision(c, tc.body)
+		c.Check(rec.Code, Equals, 400, Commentf("%s", tc.body))
+		var rsp resp
+		c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+		c.Check(rsp.Type, Equals, agent.ResponseTypeError)
+		c.Check(rsp.Result, DeepEquals, map[string]interface{}{"message": tc.err})
+	}
+	c.Check(s.notify.GetAll(), HasLen, 0)
+}
+
+func (s *restSuite) TestPostDecisionNoSessionBus(c *C) {
+	restore := agent.MockNoBus(s.agent)
+	defer restore()
+
+	rec := <-s.postDecision(c, `{"kind":"prompt","summary":"Allow access?"}`)
+	c.Check(rec.Code, Equals, 500)
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Result, DeepEquals, map[string]interface{}{"message": "cannot connect to the session bus"})
+}
+
+func (s *restSuite) TestPostDecisionNotificationServerFailure(c *C) {
+	s.notify.SetError(&dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"})
+
+	rec := <-s.postDecision(c, `{"kind":"prompt","summary":"Allow access?"}`)
+	c.Check(rec.Code, Equals, 500)
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), IsNil)
+	c.Check(rsp.Result, DeepEquals, map[string]interface{}{"message": "cannot send notification message: org.freedesktop.DBus.Error.Failed"})
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/netutil"
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/sys"
 	"github.com/snapcore/snapd/systemd"
 )
@@ -238,6 +239,9 @@ func (s *SessionAgent) Start() {
 	s.tomb.Go(s.runServer)
 	s.tomb.Go(s.shutdownServerOnKill)
 	s.tomb.Go(s.exitOnIdle)
+	if s.bus != nil && osutil.ExecutableExists("gsettings") {
+		s.tomb.Go(s.reportMissingThemes)
+	}
 	systemd.SdNotify("READY=1")
 }
 
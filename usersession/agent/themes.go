@@ -0,0 +1,87 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package agent
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+var gsettingsGet = func(schema, key string) (string, error) {
+	output, err := exec.Command("gsettings", "get", schema, key).Output()
+	if err != nil {
+		return "", osutil.OutputErr(output, err)
+	}
+	return parseGSettingsString(string(output))
+}
+
+// parseGSettingsString unquotes the GVariant string printed by
+// "gsettings get", e.g. 'Yaru-dark'.
+func parseGSettingsString(output string) (string, error) {
+	value := strings.TrimSpace(output)
+	if len(value) < 2 || value[0] != '\'' || value[len(value)-1] != '\'' {
+		return "", fmt.Errorf("cannot parse gsettings string value %q", value)
+	}
+	return value[1 : len(value)-1], nil
+}
+
+var reportThemes = func(gtkThemes, iconThemes, soundThemes []string) (*client.ThemesReport, error) {
+	cli := client.New(nil)
+	return cli.ReportThemes(gtkThemes, iconThemes, soundThemes)
+}
+
+func gsettingsThemes(schema, key string) []string {
+	value, err := gsettingsGet(schema, key)
+	if err != nil {
+		logger.Debugf("cannot read %s %s: %v", schema, key, err)
+		return nil
+	}
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// reportMissingThemes reads the themes configured for the desktop
+// session and reports them to snapd, which offers to install snaps
+// providing the themes snapped applications cannot find.
+func (s *SessionAgent) reportMissingThemes() error {
+	// errors are only logged: returning one would kill the agent
+	gtkThemes := gsettingsThemes("org.gnome.desktop.interface", "gtk-theme")
+	iconThemes := gsettingsThemes("org.gnome.desktop.interface", "icon-theme")
+	soundThemes := gsettingsThemes("org.gnome.desktop.sound", "theme-name")
+	if len(gtkThemes) == 0 && len(iconThemes) == 0 && len(soundThemes) == 0 {
+		return nil
+	}
+	report, err := reportThemes(gtkThemes, iconThemes, soundThemes)
+	if err != nil {
+		logger.Noticef("cannot report desktop themes to snapd: %v", err)
+		return nil
+	}
+	if len(report.Snaps) > 0 {
+		logger.Debugf("snaps providing missing themes: %s", strings.Join(report.Snaps, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2021 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package agent_test
+
+import (
+	"fmt"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/usersession/agent"
+)
+
+type themesSuite struct{}
+
+var _ = Suite(&themesSuite{})
+
+func (s *themesSuite) TestParseGSettingsString(c *C) {
+	value, err := agent.ParseGSettingsString("'Yaru-dark'\n")
+	c.Check(err, IsNil)
+	c.Check(value, Equals, "Yaru-dark")
+
+	value, err = agent.ParseGSettingsString("''\n")
+	c.Check(err, IsNil)
+	c.Check(value, Equals, "")
+
+	_, err = agent.ParseGSettingsString("@as []\n")
+	c.Check(err, ErrorMatches, `cannot parse gsettings string value "@as \[\]"`)
+}
+
+func (s *themesSuite) TestReportMissingThemes(c *C) {
+	restore := agent.MockGSettingsGet(func(schema, key string) (string, error) {
+		switch schema + " " + key {
+		case "org.gnome.desktop.interface gtk-theme":
+			return "Foo-gtk", nil
+		case "org.gnome.desktop.interface icon-theme":
+			return "Foo-icons", nil
+		case "org.gnome.desktop.sound theme-name":
+			return "", fmt.Errorf("no such schema")
+		}
+		c.Fatalf("unexpected key %s %s", schema, key)
+		return "", nil
+	})
+	defer restore()
+	var calls [][][]string
+	restore = agent.MockReportThemes(func(gtkThemes, iconThemes, soundThemes []string) (*client.ThemesReport, error) {
+		calls = append(calls, [][]string{gtkThemes, iconThemes, soundThemes})
+		return &client.ThemesReport{Snaps: []string{"gtk-theme-foo"}}, nil
+	})
+	defer restore()
+
+	sa := &agent.SessionAgent{}
+	c.Check(sa.ReportMissingThemes(), IsNil)
+	c.Check(calls, DeepEquals, [][][]string{{{"Foo-gtk"}, {"Foo-icons"}, nil}})
+}
+
+func (s *themesSuite) TestReportMissingThemesNothingConfigured(c *C) {
+	restore := agent.MockGSettingsGet(func(schema, key string) (string, error) {
+		return "", nil
+	})
+	defer restore()
+	restore = agent.MockReportThemes(func(gtkThemes, iconThemes, soundThemes []string) (*client.ThemesReport, error) {
+		c.Errorf("unexpected report")
+		return nil, nil
+	})
+	defer restore()
+
+	sa := &agent.SessionAgent{}
+	c.Check(sa.ReportMissingThemes(), IsNil)
+}
+
+func (s *themesSuite) TestReportMissingThemesError(c *C) {
+	restore := agent.MockGSettingsGet(func(schema, key string) (string, error) {
+		return "Foo", nil
+	})
+	defer restore()
+	restore = agent.MockReportThemes(func(gtkThemes, iconThemes, soundThemes []string) (*client.ThemesReport, error) {
+		return nil, fmt.Errorf("cannot connect")
+	})
+	defer restore()
+
+	// errors are logged but not returned, not to stop the agent
+	sa := &agent.SessionAgent{}
+	c.Check(sa.ReportMissingThemes(), IsNil)
+}
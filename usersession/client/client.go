@@ -31,6 +31,7 @@ import (
 	"net/url"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -52,6 +53,9 @@ func dialSessionAgent(network, address string) (net.Conn, error) {
 
 type Client struct {
 	doer *http.Client
+	// uids restricts the session agents that are talked to, all
+	// agents are used if it is nil
+	uids map[int]bool
 }
 
 func New() *Client {
@@ -61,6 +65,17 @@ func New() *Client {
 	}
 }
 
+// NewForUids creates a client that only talks to the session agents
+// of the given users.
+func NewForUids(uids ...int) *Client {
+	client := New()
+	client.uids = make(map[int]bool, len(uids))
+	for _, uid := range uids {
+		client.uids[uid] = true
+	}
+	return client
+}
+
 type Error struct {
 	Kind    string      `json:"kind"`
 	Value   interface{} `json:"value"`
@@ -116,6 +131,9 @@ func (client *Client) doMany(ctx context.Context, method, urlpath string, query
 				// (i.e. /run/user/NNNN).
 				return
 			}
+			if client.uids != nil && !client.uids[uid] {
+				return
+			}
 			response := response{uid: uid}
 			defer func() {
 				mu.Lock()
@@ -226,18 +244,18 @@ func decodeServiceErrors(uid int, errorValue map[string]interface{}, kind string
 	return failures, err
 }
 
-func (client *Client) serviceControlCall(ctx context.Context, action string, services []string) (startFailures, stopFailures []ServiceFailure, err error) {
+func (client *Client) serviceControlCall(ctx context.Context, action string, services []string) (startFailures, stopFailures, restartFailures []ServiceFailure, err error) {
 	headers := map[string]string{"Content-Type": "application/json"}
 	reqBody, err := json.Marshal(map[string]interface{}{
 		"action":   action,
 		"services": services,
 	})
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	responses, err := client.doMany(ctx, "POST", "/v1/service-control", nil, headers, reqBody)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	for _, resp := range responses {
 		if agentErr, ok := resp.err.(*Error); ok && agentErr.Kind == "service-control" {
@@ -246,29 +264,81 @@ func (client *Client) serviceControlCall(ctx context.Context, action string, ser
 				startFailures = append(startFailures, failures...)
 				failures, _ = decodeServiceErrors(resp.uid, errorValue, "stop-errors")
 				stopFailures = append(stopFailures, failures...)
+				failures, _ = decodeServiceErrors(resp.uid, errorValue, "restart-errors")
+				restartFailures = append(restartFailures, failures...)
 			}
 		}
 		if resp.err != nil && err == nil {
 			err = resp.err
 		}
 	}
-	return startFailures, stopFailures, err
+	return startFailures, stopFailures, restartFailures, err
 }
 
 func (client *Client) ServicesDaemonReload(ctx context.Context) error {
-	_, _, err := client.serviceControlCall(ctx, "daemon-reload", nil)
+	_, _, _, err := client.serviceControlCall(ctx, "daemon-reload", nil)
 	return err
 }
 
 func (client *Client) ServicesStart(ctx context.Context, services []string) (startFailures, stopFailures []ServiceFailure, err error) {
-	return client.serviceControlCall(ctx, "start", services)
+	startFailures, stopFailures, _, err = client.serviceControlCall(ctx, "start", services)
+	return startFailures, stopFailures, err
 }
 
 func (client *Client) ServicesStop(ctx context.Context, services []string) (stopFailures []ServiceFailure, err error) {
-	_, stopFailures, err = client.serviceControlCall(ctx, "stop", services)
+	_, stopFailures, _, err = client.serviceControlCall(ctx, "stop", services)
 	return stopFailures, err
 }
 
+// ServicesRestart restarts the given user services, or reloads them
+// if reload is set and they support it.
+func (client *Client) ServicesRestart(ctx context.Context, services []string, reload bool) (restartFailures []ServiceFailure, err error) {
+	action := "restart"
+	if reload {
+		action = "reload-or-restart"
+	}
+	_, _, restartFailures, err = client.serviceControlCall(ctx, action, services)
+	return restartFailures, err
+}
+
+// ServiceUnitStatus holds the status of a user service unit as
+// reported by a session agent.
+type ServiceUnitStatus struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Active  bool   `json:"active"`
+}
+
+// ServiceStatus returns the status of the given user services in the
+// sessions of the users, keyed by uid.
+func (client *Client) ServiceStatus(ctx context.Context, services []string) (map[int][]*ServiceUnitStatus, error) {
+	q := url.Values{}
+	q.Set("services", strings.Join(services, ","))
+	responses, err := client.doMany(ctx, "GET", "/v1/service-status", q, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[int][]*ServiceUnitStatus)
+	for _, resp := range responses {
+		if resp.err != nil {
+			if err == nil {
+				err = resp.err
+			}
+			continue
+		}
+		var sts []*ServiceUnitStatus
+		if decodeErr := json.Unmarshal(resp.Result, &sts); decodeErr != nil {
+			if err == nil {
+				err = decodeErr
+			}
+			continue
+		}
+		statuses[resp.uid] = sts
+	}
+	return statuses, err
+}
+
 // PendingSnapRefreshInfo holds information about pending snap refresh provided to userd.
 type PendingSnapRefreshInfo struct {
 	InstanceName        string        `json:"instance-name"`
@@ -287,3 +357,57 @@ func (client *Client) PendingRefreshNotification(ctx context.Context, refreshInf
 	_, err = client.doMany(ctx, "POST", "/v1/notifications/pending-refresh", nil, headers, reqBody)
 	return err
 }
+
+// DecisionRequest holds a request for an interactive user decision.
+type DecisionRequest struct {
+	// Kind is one of "prompt", "theme-install" or
+	// "refresh-inhibit-override".
+	Kind         string        `json:"kind"`
+	Summary      string        `json:"summary"`
+	Body         string        `json:"body,omitempty"`
+	DesktopEntry string        `json:"desktop-entry,omitempty"`
+	Timeout      time.Duration `json:"timeout,omitempty"`
+}
+
+// Decision holds the answer of a user to a decision request. Requests
+// that were dismissed or that timed out are reported as not answered and
+// not allowed.
+type Decision struct {
+	Answered bool `json:"answered"`
+	Allow    bool `json:"allow"`
+	Remember bool `json:"remember"`
+}
+
+// RequestDecision asks the users to make an interactive decision and
+// returns their answers, keyed by uid. The call blocks until every user
+// answered or the request timed out.
+func (client *Client) RequestDecision(ctx context.Context, req *DecisionRequest) (map[int]*Decision, error) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := client.doMany(ctx, "POST", "/v1/decisions", nil, headers, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make(map[int]*Decision)
+	for _, resp := range responses {
+		if resp.err != nil {
+			if err == nil {
+				err = resp.err
+			}
+			continue
+		}
+		var decision Decision
+		if decodeErr := json.Unmarshal(resp.Result, &decision); decodeErr != nil {
+			if err == nil {
+				err = decodeErr
+			}
+			continue
+		}
+		decisions[resp.uid] = &decision
+	}
+	return decisions, err
+}
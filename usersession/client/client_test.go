@@ -21,6 +21,7 @@ package client_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -447,6 +448,109 @@ func (s *clientSuite) TestServicesStopFailure(c *C) {
 	})
 }
 
+func (s *clientSuite) TestServicesRestart(c *C) {
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v1/service-control")
+		decoder := json.NewDecoder(r.Body)
+		var inst map[string]interface{}
+		c.Assert(decoder.Decode(&inst), IsNil)
+		c.Check(inst, DeepEquals, map[string]interface{}{
+			"action":   "reload-or-restart",
+			"services": []interface{}{"service1.service"},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write([]byte(`{
+  "type": "sync",
+  "result": null
+}`))
+	})
+	failures, err := s.cli.ServicesRestart(context.Background(), []string{"service1.service"}, true)
+	c.Assert(err, IsNil)
+	c.Check(failures, HasLen, 0)
+}
+
+func (s *clientSuite) TestServicesRestartFailureForUids(c *C) {
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Host, Equals, "1000")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(500)
+		w.Write([]byte(`{
+  "type": "error",
+  "result": {
+    "kind": "service-control",
+    "message": "some user services failed to restart",
+    "value": {
+      "restart-errors": {
+        "service2.service": "failed to restart"
+      }
+    }
+  }
+}`))
+	})
+	cli := client.NewForUids(1000)
+	failures, err := cli.ServicesRestart(context.Background(), []string{"service1.service", "service2.service"}, false)
+	c.Assert(err, ErrorMatches, "some user services failed to restart")
+	c.Check(failures, DeepEquals, []client.ServiceFailure{{
+		Uid:     1000,
+		Service: "service2.service",
+		Error:   "failed to restart",
+	}})
+}
+
+func (s *clientSuite) TestServiceStatus(c *C) {
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v1/service-status")
+		c.Check(r.URL.Query().Get("services"), Equals, "service1.service,service2.service")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		if r.Host == "42" {
+			w.Write([]byte(`{
+  "type": "sync",
+  "result": [{"name": "service1.service", "enabled": true, "active": true},
+             {"name": "service2.service", "enabled": false, "active": false}]
+}`))
+			return
+		}
+		w.Write([]byte(`{
+  "type": "sync",
+  "result": [{"name": "service1.service", "enabled": true, "active": false},
+             {"name": "service2.service", "enabled": true, "active": true}]
+}`))
+	})
+	sts, err := s.cli.ServiceStatus(context.Background(), []string{"service1.service", "service2.service"})
+	c.Assert(err, IsNil)
+	c.Check(sts, DeepEquals, map[int][]*client.ServiceUnitStatus{
+		42: {
+			{Name: "service1.service", Enabled: true, Active: true},
+			{Name: "service2.service", Enabled: false, Active: false},
+		},
+		1000: {
+			{Name: "service1.service", Enabled: true, Active: false},
+			{Name: "service2.service", Enabled: true, Active: true},
+		},
+	})
+}
+
+func (s *clientSuite) TestServiceStatusForUids(c *C) {
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Host, Equals, "42")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write([]byte(`{
+  "type": "sync",
+  "result": [{"name": "service1.service", "enabled": true, "active": true}]
+}`))
+	})
+	cli := client.NewForUids(42)
+	sts, err := cli.ServiceStatus(context.Background(), []string{"service1.service"})
+	c.Assert(err, IsNil)
+	c.Check(sts, DeepEquals, map[int][]*client.ServiceUnitStatus{
+		42: {{Name: "service1.service", Enabled: true, Active: true}},
+	})
+}
+
 func (s *clientSuite) TestPendingRefreshNotification(c *C) {
 	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		c.Assert(r.URL.Path, Equals, "/v1/notifications/pending-refresh")
@@ -457,3 +561,54 @@ func (s *clientSuite) TestPendingRefreshNotification(c *C) {
 	err := s.cli.PendingRefreshNotification(context.Background(), &client.PendingSnapRefreshInfo{})
 	c.Assert(err, IsNil)
 }
+
+func (s *clientSuite) TestRequestDecision(c *C) {
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.URL.Path, Equals, "/v1/decisions")
+		c.Check(r.Header.Get("Content-Type"), Equals, "application/json")
+		var req map[string]interface{}
+		c.Assert(json.NewDecoder(r.Body).Decode(&req), IsNil)
+		c.Check(req, DeepEquals, map[string]interface{}{
+			"kind":    "theme-install",
+			"summary": "Install the missing themes?",
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		if r.Host == "42" {
+			w.Write([]byte(`{"type": "sync", "result": {"answered": true, "allow": true, "remember": true}}`))
+			return
+		}
+		w.Write([]byte(`{"type": "sync", "result": {"answered": false, "allow": false, "remember": false}}`))
+	})
+	decisions, err := s.cli.RequestDecision(context.Background(), &client.DecisionRequest{
+		Kind:    "theme-install",
+		Summary: "Install the missing themes?",
+	})
+	c.Assert(err, IsNil)
+	c.Check(decisions, DeepEquals, map[int]*client.Decision{
+		42:   {Answered: true, Allow: true, Remember: true},
+		1000: {},
+	})
+}
+
+func (s *clientSuite) TestRequestDecisionError(c *C) {
+	s.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Host == "42" {
+			w.WriteHeader(400)
+			w.Write([]byte(`{"type": "error", "result": {"message": "unknown decision kind \"foo\""}}`))
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(`{"type": "sync", "result": {"answered": true, "allow": true}}`))
+	})
+	decisions, err := s.cli.RequestDecision(context.Background(), &client.DecisionRequest{
+		Kind:    "foo",
+		Summary: "summary",
+	})
+	c.Check(err, ErrorMatches, `unknown decision kind "foo"`)
+	c.Check(decisions, DeepEquals, map[int]*client.Decision{
+		1000: {Answered: true, Allow: true},
+	})
+}
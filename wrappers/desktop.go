@@ -172,6 +172,24 @@ func rewriteIconLine(s *snap.Info, line string) (string, error) {
 	return line, nil
 }
 
+// rewriteMimeTypeLine drops URL scheme handlers from a "MimeType=" line,
+// those can only be registered via the "url-schemes" of an application
+// in snap.yaml. It returns "" if no MIME type is left.
+func rewriteMimeTypeLine(line string) string {
+	mimeTypes := strings.Split(strings.SplitN(line, "=", 2)[1], ";")
+	kept := make([]string, 0, len(mimeTypes))
+	for _, mimeType := range mimeTypes {
+		if mimeType == "" || strings.HasPrefix(strings.ToLower(mimeType), "x-scheme-handler/") {
+			continue
+		}
+		kept = append(kept, mimeType)
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return "MimeType=" + strings.Join(kept, ";") + ";"
+}
+
 func sanitizeDesktopFile(s *snap.Info, desktopFile string, rawcontent []byte) []byte {
 	var newContent bytes.Buffer
 	mountDir := []byte(s.MountDir())
@@ -195,6 +213,16 @@ func sanitizeDesktopFile(s *snap.Info, desktopFile string, rawcontent []byte) []
 			bline = []byte(line)
 		}
 
+		// drop URL scheme handlers from mime type lines
+		if bytes.HasPrefix(bline, []byte("MimeType=")) {
+			line := rewriteMimeTypeLine(string(bline))
+			if line == "" {
+				logger.Debugf("ignoring mime types in source desktop file %q: no mime type left", filepath.Base(desktopFile))
+				continue
+			}
+			bline = []byte(line)
+		}
+
 		// rewrite icon line if it references an icon theme icon
 		if bytes.HasPrefix(bline, []byte("Icon=")) {
 			line, err := rewriteIconLine(s, string(bline))
@@ -235,6 +263,31 @@ func updateDesktopDatabase(desktopFiles []string) error {
 	return nil
 }
 
+// handlersDesktopFile returns the path of the desktop file registering the
+// MIME types and URL schemes handled by app. Application names cannot
+// contain dots so this does not clash with the desktop files of the snap.
+func handlersDesktopFile(app *snap.AppInfo) string {
+	return filepath.Join(dirs.SnapDesktopFilesDir, fmt.Sprintf("%s_%s.handlers.desktop", app.Snap.DesktopPrefix(), app.Name))
+}
+
+func handlersDesktopFileContent(app *snap.AppInfo) []byte {
+	mimeTypes := make([]string, 0, len(app.MimeTypes)+len(app.URLSchemes))
+	mimeTypes = append(mimeTypes, app.MimeTypes...)
+	for _, scheme := range app.URLSchemes {
+		mimeTypes = append(mimeTypes, "x-scheme-handler/"+scheme)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("[Desktop Entry]\n")
+	fmt.Fprintf(&buf, "X-SnapInstanceName=%s\n", app.Snap.InstanceName())
+	buf.WriteString("Type=Application\n")
+	fmt.Fprintf(&buf, "Name=%s\n", app.Name)
+	buf.WriteString("NoDisplay=true\n")
+	fmt.Fprintf(&buf, "Exec=%s %%U\n", app.WrapperPath())
+	fmt.Fprintf(&buf, "MimeType=%s;\n", strings.Join(mimeTypes, ";"))
+	return buf.Bytes()
+}
+
 // AddSnapDesktopFiles puts in place the desktop files for the applications from the snap.
 func AddSnapDesktopFiles(s *snap.Info) (err error) {
 	var created []string
@@ -277,8 +330,20 @@ func AddSnapDesktopFiles(s *snap.Info) (err error) {
 		created = append(created, installedDesktopFileName)
 	}
 
+	// register the MIME types and URL schemes handled by the applications
+	for _, app := range s.Apps {
+		if len(app.MimeTypes) == 0 && len(app.URLSchemes) == 0 {
+			continue
+		}
+		handlersFile := handlersDesktopFile(app)
+		if err := osutil.AtomicWriteFile(handlersFile, handlersDesktopFileContent(app), 0755, 0); err != nil {
+			return err
+		}
+		created = append(created, handlersFile)
+	}
+
 	// updates mime info etc
-	if err := updateDesktopDatabase(desktopFiles); err != nil {
+	if err := updateDesktopDatabase(created); err != nil {
 		return err
 	}
 
@@ -95,6 +95,40 @@ func (s *desktopSuite) TestAddPackageDesktopFiles(c *C) {
 	})
 }
 
+func (s *desktopSuite) TestAddRemovePackageDesktopFilesHandlers(c *C) {
+	info := snaptest.MockSnap(c, `
+name: foo
+version: 1.0
+apps:
+    viewer:
+        mime-types: [image/png, image/x-foo]
+        url-schemes: [foo]
+        plugs: [url-handler]
+    other:
+`, &snap.SideInfo{Revision: snap.R(11)})
+
+	err := wrappers.AddSnapDesktopFiles(info)
+	c.Assert(err, IsNil)
+
+	handlersFile := filepath.Join(dirs.SnapDesktopFilesDir, "foo_viewer.handlers.desktop")
+	c.Check(handlersFile, testutil.FileEquals, fmt.Sprintf(`[Desktop Entry]
+X-SnapInstanceName=foo
+Type=Application
+Name=viewer
+NoDisplay=true
+Exec=%s/foo.viewer %%U
+MimeType=image/png;image/x-foo;x-scheme-handler/foo;
+`, dirs.SnapBinariesDir))
+	c.Check(filepath.Join(dirs.SnapDesktopFilesDir, "foo_other.handlers.desktop"), testutil.FileAbsent)
+	c.Check(s.mockUpdateDesktopDatabase.Calls(), DeepEquals, [][]string{
+		{"update-desktop-database", dirs.SnapDesktopFilesDir},
+	})
+
+	err = wrappers.RemoveSnapDesktopFiles(info)
+	c.Assert(err, IsNil)
+	c.Check(handlersFile, testutil.FileAbsent)
+}
+
 func (s *desktopSuite) TestRemovePackageDesktopFiles(c *C) {
 	mockDesktopFilePath := filepath.Join(dirs.SnapDesktopFilesDir, "foo_foobar.desktop")
 
@@ -244,6 +278,26 @@ Name=foo
 `)
 }
 
+func (s *sanitizeDesktopFileSuite) TestSanitizeFiltersSchemeHandlers(c *C) {
+	snap := &snap.Info{SideInfo: snap.SideInfo{RealName: "foo", Revision: snap.R(12)}}
+	desktopContent := []byte(`[Desktop Entry]
+Name=foo
+MimeType=image/png;x-scheme-handler/http;X-Scheme-Handler/foo;text/plain
+[Desktop Action other]
+Name=other
+MimeType=x-scheme-handler/https;
+`)
+
+	e := wrappers.SanitizeDesktopFile(snap, "foo.desktop", desktopContent)
+	c.Assert(string(e), Equals, `[Desktop Entry]
+X-SnapInstanceName=foo
+Name=foo
+MimeType=image/png;text/plain;
+[Desktop Action other]
+Name=other
+`)
+}
+
 func (s *sanitizeDesktopFileSuite) TestSanitizeFiltersExecPrefix(c *C) {
 	snap, err := snap.InfoFromSnapYaml([]byte(`
 name: snap
@@ -30,6 +30,7 @@ var (
 	// services
 	GenerateSnapServiceFile = generateSnapServiceFile
 	GenerateSnapSocketFiles = generateSnapSocketFiles
+	GenerateSnapPathFiles   = generateSnapPathFiles
 	GenerateSnapTimerFile   = generateSnapTimerFile
 
 	// dbus
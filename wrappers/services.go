@@ -99,9 +99,49 @@ TasksAccounting=true
 
 	fmt.Fprintf(&buf, template, grp.Name, grp.MemoryLimit)
 
+	if len(grp.CPUSet) != 0 {
+		cpus := make([]string, len(grp.CPUSet))
+		for i, cpu := range grp.CPUSet {
+			cpus[i] = strconv.Itoa(cpu)
+		}
+		fmt.Fprintf(&buf, `
+# Pin the processes to the CPUs of the group
+AllowedCPUs=%s
+`, strings.Join(cpus, ","))
+	}
+
 	return buf.Bytes(), nil
 }
 
+// generateGroupJournaldConfFile generates the journald configuration of the
+// journal namespace of the specified quota group.
+func generateGroupJournaldConfFile(grp *quota.Group) []byte {
+	buf := bytes.Buffer{}
+
+	fmt.Fprintf(&buf, `# Journal namespace for snap quota group %s
+[Journal]
+`, grp.Name)
+
+	journal := grp.JournalLimit
+	if journal.Size != 0 {
+		fmt.Fprintf(&buf, "SystemMaxUse=%[1]d\nRuntimeMaxUse=%[1]d\n", journal.Size)
+	}
+	if journal.RateCount != 0 {
+		fmt.Fprintf(&buf, "RateLimitIntervalSec=%dus\nRateLimitBurst=%d\n", journal.RatePeriod/time.Microsecond, journal.RateCount)
+	}
+
+	return buf.Bytes()
+}
+
+// userSessionClient returns a client for the session agents of the
+// given users, or of all users if none are given.
+func userSessionClient(uids []int) *client.Client {
+	if len(uids) == 0 {
+		return client.New()
+	}
+	return client.NewForUids(uids...)
+}
+
 func stopUserServices(cli *client.Client, inter interacter, services ...string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout.DefaultTimeout))
 	defer cancel()
@@ -125,7 +165,17 @@ func startUserServices(cli *client.Client, inter interacter, services ...string)
 	return err
 }
 
-func stopService(sysd systemd.Systemd, app *snap.AppInfo, inter interacter) error {
+func restartUserServices(cli *client.Client, inter interacter, reload bool, services ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout.DefaultTimeout))
+	defer cancel()
+	failures, err := cli.ServicesRestart(ctx, services, reload)
+	for _, f := range failures {
+		inter.Notify(fmt.Sprintf("Could not restart service %q for uid %d: %s", f.Service, f.Uid, f.Error))
+	}
+	return err
+}
+
+func stopService(sysd systemd.Systemd, cli *client.Client, app *snap.AppInfo, inter interacter) error {
 	serviceName := app.ServiceName()
 	tout := serviceStopTimeout(app)
 
@@ -133,6 +183,9 @@ func stopService(sysd systemd.Systemd, app *snap.AppInfo, inter interacter) erro
 	for _, socket := range app.Sockets {
 		extraServices = append(extraServices, filepath.Base(socket.File()))
 	}
+	for _, path := range app.Paths {
+		extraServices = append(extraServices, filepath.Base(path.File()))
+	}
 	if app.Timer != nil {
 		extraServices = append(extraServices, filepath.Base(app.Timer.File()))
 	}
@@ -163,7 +216,6 @@ func stopService(sysd systemd.Systemd, app *snap.AppInfo, inter interacter) erro
 
 	case snap.UserDaemon:
 		extraServices = append(extraServices, serviceName)
-		cli := client.New()
 		return stopUserServices(cli, inter, extraServices...)
 	}
 
@@ -231,6 +283,9 @@ func enableServices(apps []*snap.AppInfo, inter interacter) (disable func(), err
 // StartServicesFlags carries extra flags for StartServices.
 type StartServicesFlags struct {
 	Enable bool
+	// Users restricts starting user daemons to the sessions of the
+	// given users, they are started in all sessions if it is empty.
+	Users []int
 }
 
 // StartServices starts service units for the applications from the snap which
@@ -243,7 +298,7 @@ func StartServices(apps []*snap.AppInfo, disabledSvcs []string, flags *StartServ
 
 	systemSysd := systemd.New(systemd.SystemMode, inter)
 	userSysd := systemd.New(systemd.GlobalUserMode, inter)
-	cli := client.New()
+	cli := userSessionClient(flags.Users)
 
 	var disableEnabledServices func()
 
@@ -260,16 +315,16 @@ func StartServices(apps []*snap.AppInfo, disabledSvcs []string, flags *StartServ
 	systemServices := make([]string, 0, len(apps))
 	userServices := make([]string, 0, len(apps))
 
-	// gather all non-sockets, non-timers, and non-dbus activated
+	// gather all non-sockets, non-paths, non-timers, and non-dbus activated
 	// services to enable first
 	for _, app := range apps {
 		// they're *supposed* to be all services, but checking doesn't hurt
 		if !app.IsService() {
 			continue
 		}
-		// sockets and timers are enabled and started separately (and unconditionally) further down.
+		// sockets, paths and timers are enabled and started separately (and unconditionally) further down.
 		// dbus activatable services are started on first use.
-		if len(app.Sockets) == 0 && app.Timer == nil && len(app.ActivatesOn) == 0 {
+		if len(app.Sockets) == 0 && len(app.Paths) == 0 && app.Timer == nil && len(app.ActivatesOn) == 0 {
 			if strutil.ListContains(disabledSvcs, app.Name) {
 				continue
 			}
@@ -293,7 +348,7 @@ func StartServices(apps []*snap.AppInfo, disabledSvcs []string, flags *StartServ
 		return err
 	}
 
-	// handle sockets and timers
+	// handle sockets, paths and timers
 	for _, app := range apps {
 		// they're *supposed* to be all services, but checking doesn't hurt
 		if !app.IsService() {
@@ -313,7 +368,7 @@ func StartServices(apps []*snap.AppInfo, disabledSvcs []string, flags *StartServ
 				return
 			}
 
-			if e := stopService(sysd, app, inter); e != nil {
+			if e := stopService(sysd, cli, app, inter); e != nil {
 				inter.Notify(fmt.Sprintf("While trying to stop previously started service %q: %v", app.ServiceName(), e))
 			}
 			for _, socket := range app.Sockets {
@@ -322,6 +377,12 @@ func StartServices(apps []*snap.AppInfo, disabledSvcs []string, flags *StartServ
 					inter.Notify(fmt.Sprintf("While trying to disable previously enabled socket service %q: %v", socketService, e))
 				}
 			}
+			for _, path := range app.Paths {
+				pathService := filepath.Base(path.File())
+				if e := sysd.Disable(pathService); e != nil {
+					inter.Notify(fmt.Sprintf("While trying to disable previously enabled path service %q: %v", pathService, e))
+				}
+			}
 			if app.Timer != nil {
 				timerService := filepath.Base(app.Timer.File())
 				if e := sysd.Disable(timerService); e != nil {
@@ -352,6 +413,28 @@ func StartServices(apps []*snap.AppInfo, disabledSvcs []string, flags *StartServ
 			}
 		}
 
+		for _, path := range app.Paths {
+			pathService := filepath.Base(path.File())
+			// enable the path
+			if err = sysd.Enable(pathService); err != nil {
+				return err
+			}
+
+			switch app.DaemonScope {
+			case snap.SystemDaemon:
+				timings.Run(tm, "start-system-path-service", fmt.Sprintf("start system path service %q", pathService), func(nested timings.Measurer) {
+					err = sysd.Start(pathService)
+				})
+			case snap.UserDaemon:
+				timings.Run(tm, "start-user-path-service", fmt.Sprintf("start user path service %q", pathService), func(nested timings.Measurer) {
+					err = startUserServices(cli, inter, pathService)
+				})
+			}
+			if err != nil {
+				return err
+			}
+		}
+
 		if app.Timer != nil {
 			timerService := filepath.Base(app.Timer.File())
 			// enable the timer
@@ -461,11 +544,16 @@ type SnapServiceOptions struct {
 
 	// QuotaGroup is the quota group for all services in the specified snap.
 	QuotaGroup *quota.Group
+
+	// PlugServices maps plug names of the snap to the services of other
+	// snaps providing the slots the plugs are connected to. It is used to
+	// order services declaring after-plugs or requires-plugs.
+	PlugServices map[string][]*snap.AppInfo
 }
 
 // ObserveChangeCallback can be invoked by EnsureSnapServices to observe
 // the previous content of a unit and the new on a change.
-// unitType can be "service", "socket", "timer". name is empty for a timer.
+// unitType can be "service", "socket", "path", "timer". name is empty for a timer.
 type ObserveChangeCallback func(app *snap.AppInfo, grp *quota.Group, unitType string, name, old, new string)
 
 // EnsureSnapServicesOptions is the set of options applying to the
@@ -600,6 +688,7 @@ func EnsureSnapServices(snaps map[*snap.Info]*SnapServiceOptions, opts *EnsureSn
 			// VitalityRank
 			genServiceOpts.VitalityRank = snapSvcOpts.VitalityRank
 			genServiceOpts.QuotaGroup = snapSvcOpts.QuotaGroup
+			genServiceOpts.PlugServices = snapSvcOpts.PlugServices
 
 			if snapSvcOpts.QuotaGroup != nil {
 				if err := neededQuotaGrps.AddAllNecessaryGroups(snapSvcOpts.QuotaGroup); err != nil {
@@ -641,6 +730,18 @@ func EnsureSnapServices(snaps map[*snap.Info]*SnapServiceOptions, opts *EnsureSn
 				}
 			}
 
+			// Generate systemd .path files if needed
+			pathFiles, err := generateSnapPathFiles(app)
+			if err != nil {
+				return err
+			}
+			for name, content := range pathFiles {
+				path := app.Paths[name].File()
+				if err := handleFileModification(app, "path", name, path, content); err != nil {
+					return err
+				}
+			}
+
 			if app.Timer != nil {
 				content, err := generateSnapTimerFile(app)
 				if err != nil {
@@ -692,6 +793,28 @@ func EnsureSnapServices(snaps map[*snap.Info]*SnapServiceOptions, opts *EnsureSn
 		if err := handleSliceModification(grp, path, content); err != nil {
 			return err
 		}
+
+		if grp.JournalLimit == nil {
+			continue
+		}
+		// journald reads the configuration of a namespace when it is
+		// started, so no daemon-reload is needed
+		content = generateGroupJournaldConfFile(grp)
+		path = filepath.Join(dirs.SnapSystemdDir, grp.JournalConfFileName())
+		old, modifiedFile, err := tryFileUpdate(path, content)
+		if err != nil {
+			return err
+		}
+		if modifiedFile {
+			if observeChange != nil {
+				var oldContent []byte
+				if old != nil {
+					oldContent = old.Content
+				}
+				observeChange(nil, grp, "journald", grp.Name, string(oldContent), string(content))
+			}
+			modifiedUnitsPreviousState[path] = old
+		}
 	}
 
 	if !preseeding {
@@ -720,6 +843,10 @@ type AddSnapServicesOptions struct {
 	// QuotaGroup is the quota group for all services in the specified snap.
 	QuotaGroup *quota.Group
 
+	// PlugServices maps plug names of the snap to the services of other
+	// snaps providing the slots the plugs are connected to.
+	PlugServices map[string][]*snap.AppInfo
+
 	// RequireMountedSnapdSnap is whether the generated units should depend on
 	// the snapd snap being mounted, this is specific to systems like UC18 and
 	// UC20 which have the snapd snap and need to have units generated
@@ -742,6 +869,7 @@ func AddSnapServices(s *snap.Info, opts *AddSnapServicesOptions, inter interacte
 		// set the per-snap service options
 		m[s].VitalityRank = opts.VitalityRank
 		m[s].QuotaGroup = opts.QuotaGroup
+		m[s].PlugServices = opts.PlugServices
 
 		// copy the globally applicable opts from AddSnapServicesOptions to
 		// EnsureSnapServicesOptions, since those options override the per-snap opts
@@ -756,15 +884,20 @@ func AddSnapServices(s *snap.Info, opts *AddSnapServicesOptions, inter interacte
 // StopServicesFlags carries extra flags for StopServices.
 type StopServicesFlags struct {
 	Disable bool
+	// Users restricts stopping user daemons to the sessions of the
+	// given users, they are stopped in all sessions if it is empty.
+	Users []int
 }
 
 // StopServices stops and optionally disables service units for the applications
 // from the snap which are services.
 func StopServices(apps []*snap.AppInfo, flags *StopServicesFlags, reason snap.ServiceStopReason, inter interacter, tm timings.Measurer) error {
 	sysd := systemd.New(systemd.SystemMode, inter)
+	userSysd := systemd.New(systemd.GlobalUserMode, inter)
 	if flags == nil {
 		flags = &StopServicesFlags{}
 	}
+	cli := userSessionClient(flags.Users)
 
 	if reason != snap.StopReasonOther {
 		logger.Debugf("StopServices called for %q, reason: %v", apps, reason)
@@ -790,9 +923,13 @@ func StopServices(apps []*snap.AppInfo, flags *StopServicesFlags, reason snap.Se
 
 		var err error
 		timings.Run(tm, "stop-service", fmt.Sprintf("stop service %q", app.ServiceName()), func(nested timings.Measurer) {
-			err = stopService(sysd, app, inter)
+			err = stopService(sysd, cli, app, inter)
 			if err == nil && flags.Disable {
-				err = sysd.Disable(app.ServiceName())
+				if app.DaemonScope == snap.UserDaemon {
+					err = userSysd.Disable(app.ServiceName())
+				} else {
+					err = sysd.Disable(app.ServiceName())
+				}
 			}
 		})
 		if err != nil {
@@ -852,6 +989,11 @@ func RemoveQuotaGroup(grp *quota.Group, inter interacter) error {
 
 	systemSysd := systemd.New(systemd.SystemMode, inter)
 
+	// remove the journald configuration of the namespace of the group, if any
+	if err := os.Remove(filepath.Join(dirs.SnapSystemdDir, grp.JournalConfFileName())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
 	// remove the slice file
 	err := os.Remove(filepath.Join(dirs.SnapServicesDir, grp.SliceFileName()))
 	if err != nil && !os.IsNotExist(err) {
@@ -906,6 +1048,18 @@ func RemoveSnapServices(s *snap.Info, inter interacter) error {
 			}
 		}
 
+		for _, pathInfo := range app.Paths {
+			path := pathInfo.File()
+			pathServiceName := filepath.Base(path)
+			if err := sysd.Disable(pathServiceName); err != nil {
+				return err
+			}
+
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				logger.Noticef("Failed to remove path file %q for %q: %v", path, serviceName, err)
+			}
+		}
+
 		if app.Timer != nil {
 			path := app.Timer.File()
 
@@ -955,6 +1109,29 @@ func genServiceNames(snap *snap.Info, appNames []string) []string {
 	return names
 }
 
+// genPlugServiceNames returns the unit names of the services of other snaps
+// connected to the given plugs of the app. Services of a different daemon
+// scope are skipped, as systemd cannot order units across its system and user
+// instances.
+func genPlugServiceNames(appInfo *snap.AppInfo, plugNames []string, plugServices map[string][]*snap.AppInfo) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, plugName := range plugNames {
+		for _, svc := range plugServices[plugName] {
+			if svc.DaemonScope != appInfo.DaemonScope {
+				continue
+			}
+			name := svc.ServiceName()
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // TODO: this should not accept AddSnapServicesOptions, it should use some other
 // subset of options, specifically it should not accept Preseeding as an option
 // here
@@ -1001,6 +1178,9 @@ Description=Service for snap application {{.App.Snap.InstanceName}}.{{.App.Name}
 {{- if .MountUnit }}
 Requires={{.MountUnit}}
 {{- end }}
+{{- if .Requires}}
+Requires={{ stringsJoin .Requires " " }}
+{{- end}}
 {{- if .PrerequisiteTarget}}
 Wants={{.PrerequisiteTarget}}
 {{- end}}
@@ -1065,7 +1245,10 @@ OOMScoreAdjust={{.OOMAdjustScore}}
 {{- if .SliceUnit}}
 Slice={{.SliceUnit}}
 {{- end}}
-{{- if not (or .App.Sockets .App.Timer .App.ActivatesOn) }}
+{{- if .LogNamespace}}
+LogNamespace={{.LogNamespace}}
+{{- end}}
+{{- if not (or .App.Sockets .App.Paths .App.Timer .App.ActivatesOn) }}
 
 [Install]
 WantedBy={{.ServicesTarget}}
@@ -1135,8 +1318,10 @@ WantedBy={{.ServicesTarget}}
 		BusName                  string
 		Before                   []string
 		After                    []string
+		Requires                 []string
 		InterfaceServiceSnippets string
 		SliceUnit                string
+		LogNamespace             string
 
 		Home    string
 		EnvVars string
@@ -1159,9 +1344,16 @@ WantedBy={{.ServicesTarget}}
 		Before: genServiceNames(appInfo.Snap, appInfo.Before),
 		After:  genServiceNames(appInfo.Snap, appInfo.After),
 
+		Requires: genPlugServiceNames(appInfo, appInfo.RequiresPlugs, opts.PlugServices),
+
 		// systemd runs as PID 1 so %h will not work.
 		Home: "/root",
 	}
+	// services that are required are also started before this one
+	orderPlugs := make([]string, 0, len(appInfo.AfterPlugs)+len(appInfo.RequiresPlugs))
+	orderPlugs = append(orderPlugs, appInfo.AfterPlugs...)
+	orderPlugs = append(orderPlugs, appInfo.RequiresPlugs...)
+	wrapperData.After = append(wrapperData.After, genPlugServiceNames(appInfo, orderPlugs, opts.PlugServices)...)
 	switch appInfo.DaemonScope {
 	case snap.SystemDaemon:
 		wrapperData.ServicesTarget = systemd.ServicesTarget
@@ -1181,6 +1373,7 @@ WantedBy={{.ServicesTarget}}
 	// check the quota group slice
 	if opts.QuotaGroup != nil {
 		wrapperData.SliceUnit = opts.QuotaGroup.SliceFileName()
+		wrapperData.LogNamespace = opts.QuotaGroup.JournalNamespaceName()
 	}
 
 	// Add extra "After" targets
@@ -1278,27 +1471,113 @@ func generateSnapSocketFiles(app *snap.AppInfo) (map[string][]byte, error) {
 	return socketFiles, nil
 }
 
+func genServicePathFile(appInfo *snap.AppInfo, pathName string) []byte {
+	pathTemplate := `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Path {{.PathName}} for snap application {{.App.Snap.InstanceName}}.{{.App.Name}}
+{{- if .MountUnit}}
+Requires={{.MountUnit}}
+After={{.MountUnit}}
+{{- end}}
+X-Snappy=yes
+
+[Path]
+Unit={{.ServiceFileName}}
+{{- if .PathExists}}
+PathExists={{.PathExists}}
+{{- end}}
+{{- if .PathChanged}}
+PathChanged={{.PathChanged}}
+{{- end}}
+{{- if .PathModified}}
+PathModified={{.PathModified}}
+{{- end}}
+{{- if .DirectoryNotEmpty}}
+DirectoryNotEmpty={{.DirectoryNotEmpty}}
+{{- end}}
+
+[Install]
+WantedBy={{.PathsTarget}}
+`
+	var templateOut bytes.Buffer
+	t := template.Must(template.New("path-wrapper").Parse(pathTemplate))
+
+	path := appInfo.Paths[pathName]
+	wrapperData := struct {
+		App               *snap.AppInfo
+		ServiceFileName   string
+		PathsTarget       string
+		MountUnit         string
+		PathName          string
+		PathExists        string
+		PathChanged       string
+		PathModified      string
+		DirectoryNotEmpty string
+	}{
+		App:               appInfo,
+		ServiceFileName:   filepath.Base(appInfo.ServiceFile()),
+		PathsTarget:       systemd.PathsTarget,
+		PathName:          pathName,
+		PathExists:        renderSnapPath(appInfo, path.PathExists),
+		PathChanged:       renderSnapPath(appInfo, path.PathChanged),
+		PathModified:      renderSnapPath(appInfo, path.PathModified),
+		DirectoryNotEmpty: renderSnapPath(appInfo, path.DirectoryNotEmpty),
+	}
+	switch appInfo.DaemonScope {
+	case snap.SystemDaemon:
+		wrapperData.MountUnit = filepath.Base(systemd.MountUnitPath(appInfo.Snap.MountDir()))
+	case snap.UserDaemon:
+		// nothing
+	default:
+		panic("unknown snap.DaemonScope")
+	}
+
+	if err := t.Execute(&templateOut, wrapperData); err != nil {
+		// this can never happen, except we forget a variable
+		logger.Panicf("Unable to execute template: %v", err)
+	}
+
+	return templateOut.Bytes()
+}
+
+func generateSnapPathFiles(app *snap.AppInfo) (map[string][]byte, error) {
+	if err := snap.ValidateApp(app); err != nil {
+		return nil, err
+	}
+
+	pathFiles := make(map[string][]byte)
+	for name := range app.Paths {
+		pathFiles[name] = genServicePathFile(app, name)
+	}
+	return pathFiles, nil
+}
+
 func renderListenStream(socket *snap.SocketInfo) string {
-	s := socket.App.Snap
-	listenStream := socket.ListenStream
-	switch socket.App.DaemonScope {
+	return renderSnapPath(socket.App, socket.ListenStream)
+}
+
+// renderSnapPath expands the snap specific variables in the given path to
+// values understood by systemd for the daemon scope of the app.
+func renderSnapPath(app *snap.AppInfo, path string) string {
+	s := app.Snap
+	switch app.DaemonScope {
 	case snap.SystemDaemon:
-		listenStream = strings.Replace(listenStream, "$SNAP_DATA", s.DataDir(), -1)
+		path = strings.Replace(path, "$SNAP_DATA", s.DataDir(), -1)
 		// TODO: when we support User/Group in the generated
 		// systemd unit, adjust this accordingly
 		serviceUserUid := sys.UserID(0)
 		runtimeDir := s.UserXdgRuntimeDir(serviceUserUid)
-		listenStream = strings.Replace(listenStream, "$XDG_RUNTIME_DIR", runtimeDir, -1)
-		listenStream = strings.Replace(listenStream, "$SNAP_COMMON", s.CommonDataDir(), -1)
+		path = strings.Replace(path, "$XDG_RUNTIME_DIR", runtimeDir, -1)
+		path = strings.Replace(path, "$SNAP_COMMON", s.CommonDataDir(), -1)
 	case snap.UserDaemon:
-		listenStream = strings.Replace(listenStream, "$SNAP_USER_DATA", s.UserDataDir("%h"), -1)
-		listenStream = strings.Replace(listenStream, "$SNAP_USER_COMMON", s.UserCommonDataDir("%h"), -1)
+		path = strings.Replace(path, "$SNAP_USER_DATA", s.UserDataDir("%h"), -1)
+		path = strings.Replace(path, "$SNAP_USER_COMMON", s.UserCommonDataDir("%h"), -1)
 		// FIXME: find some way to share code with snap.UserXdgRuntimeDir()
-		listenStream = strings.Replace(listenStream, "$XDG_RUNTIME_DIR", fmt.Sprintf("%%t/snap.%s", s.InstanceName()), -1)
+		path = strings.Replace(path, "$XDG_RUNTIME_DIR", fmt.Sprintf("%%t/snap.%s", s.InstanceName()), -1)
 	default:
 		panic("unknown snap.DaemonScope")
 	}
-	return listenStream
+	return path
 }
 
 func generateSnapTimerFile(app *snap.AppInfo) ([]byte, error) {
@@ -1577,6 +1856,9 @@ func generateOnCalendarSchedules(schedule []*timeutil.Schedule) []string {
 
 type RestartServicesFlags struct {
 	Reload bool
+	// Users restricts restarting user daemons to the sessions of the
+	// given users, they are restarted in all sessions if it is empty.
+	Users []int
 }
 
 // Restart or reload active services in `svcs`.
@@ -1588,18 +1870,28 @@ type RestartServicesFlags struct {
 // restarted no matter it's state, it should be included in the
 // explicitServices list.
 // The list of explicitServices needs to use systemd unit names.
+// User daemons are restarted in each user session they are active in, or
+// in all sessions if they were mentioned explicitly.
 // TODO: change explicitServices format to be less unusual, more consistent
 // (introduce AppRef?)
 func RestartServices(svcs []*snap.AppInfo, explicitServices []string,
 	flags *RestartServicesFlags, inter interacter, tm timings.Measurer) error {
 	sysd := systemd.New(systemd.SystemMode, inter)
+	if flags == nil {
+		flags = &RestartServicesFlags{}
+	}
 
 	unitNames := make([]string, 0, len(svcs))
+	userUnitNames := make([]string, 0, len(svcs))
 	for _, srv := range svcs {
 		// they're *supposed* to be all services, but checking doesn't hurt
 		if !srv.IsService() {
 			continue
 		}
+		if srv.DaemonScope == snap.UserDaemon {
+			userUnitNames = append(userUnitNames, srv.ServiceName())
+			continue
+		}
 		unitNames = append(unitNames, srv.ServiceName())
 	}
 
@@ -1619,7 +1911,7 @@ func RestartServices(svcs []*snap.AppInfo, explicitServices []string,
 
 		var err error
 		timings.Run(tm, "restart-service", fmt.Sprintf("restart service %s", unit.UnitName), func(nested timings.Measurer) {
-			if flags != nil && flags.Reload {
+			if flags.Reload {
 				err = sysd.ReloadOrRestart(unit.UnitName)
 			} else {
 				// note: stop followed by start, not just 'restart'
@@ -1631,6 +1923,47 @@ func RestartServices(svcs []*snap.AppInfo, explicitServices []string,
 			return err
 		}
 	}
+
+	if len(userUnitNames) == 0 {
+		return nil
+	}
+	return restartUserDaemons(userUnitNames, explicitServices, flags, inter, tm)
+}
+
+// restartUserDaemons restarts the given user daemons in the user sessions
+// they are active in, and the explicitly mentioned ones in all sessions.
+func restartUserDaemons(unitNames []string, explicitServices []string, flags *RestartServicesFlags, inter interacter, tm timings.Measurer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout.DefaultTimeout))
+	defer cancel()
+	statuses, err := userSessionClient(flags.Users).ServiceStatus(ctx, unitNames)
+	if err != nil {
+		return err
+	}
+
+	uids := make([]int, 0, len(statuses))
+	for uid := range statuses {
+		uids = append(uids, uid)
+	}
+	sort.Ints(uids)
+
+	for _, uid := range uids {
+		var toRestart []string
+		for _, unit := range statuses[uid] {
+			if !unit.Active && !strutil.ListContains(explicitServices, unit.Name) {
+				continue
+			}
+			toRestart = append(toRestart, unit.Name)
+		}
+		if len(toRestart) == 0 {
+			continue
+		}
+		timings.Run(tm, "restart-user-services", fmt.Sprintf("restart user services for uid %d", uid), func(nested timings.Measurer) {
+			err = restartUserServices(client.NewForUids(uid), inter, flags.Reload, toRestart...)
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -556,6 +556,150 @@ WantedBy=sockets.target
 	})
 }
 
+func (s *servicesWrapperGenSuite) TestGenerateSnapUserServiceWithSockets(c *C) {
+	const serviceExpected = `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Service for snap application some-snap.app
+X-Snappy=yes
+
+[Service]
+EnvironmentFile=-/etc/environment
+ExecStart=/usr/bin/snap run some-snap.app
+SyslogIdentifier=some-snap.app
+Restart=on-failure
+WorkingDirectory=/var/snap/some-snap/44
+TimeoutStopSec=30
+Type=simple
+`
+	const sockExpected = `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Socket sock for snap application some-snap.app
+X-Snappy=yes
+
+[Socket]
+Service=snap.some-snap.app.service
+FileDescriptorName=sock
+ListenStream=%t/snap.some-snap/sock.socket
+
+[Install]
+WantedBy=sockets.target
+`
+
+	si := &snap.Info{
+		SuggestedName: "some-snap",
+		Version:       "1.0",
+		SideInfo:      snap.SideInfo{Revision: snap.R(44)},
+	}
+	service := &snap.AppInfo{
+		Snap:        si,
+		Name:        "app",
+		Command:     "bin/foo start",
+		Daemon:      "simple",
+		DaemonScope: snap.UserDaemon,
+		StopTimeout: timeout.DefaultTimeout,
+		Plugs:       map[string]*snap.PlugInfo{"network-bind": {Interface: "network-bind"}},
+		Sockets: map[string]*snap.SocketInfo{
+			"sock": {
+				Name:         "sock",
+				ListenStream: "$XDG_RUNTIME_DIR/sock.socket",
+			},
+		},
+	}
+	service.Sockets["sock"].App = service
+
+	// the service is only started by the per-user socket, so it is not
+	// installed into the default target of the user session
+	generatedWrapper, err := wrappers.GenerateSnapServiceFile(service, nil)
+	c.Assert(err, IsNil)
+	c.Check(string(generatedWrapper), Equals, serviceExpected)
+
+	generatedSockets, err := wrappers.GenerateSnapSocketFiles(service)
+	c.Assert(err, IsNil)
+	c.Check(generatedSockets, DeepEquals, map[string][]byte{
+		"sock": []byte(sockExpected),
+	})
+}
+
+func (s *servicesWrapperGenSuite) TestGenerateSnapServiceWithPaths(c *C) {
+	const spoolExpectedFmt = `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Path spool for snap application some-snap.app
+Requires=%s-some\x2dsnap-44.mount
+After=%s-some\x2dsnap-44.mount
+X-Snappy=yes
+
+[Path]
+Unit=snap.some-snap.app.service
+DirectoryNotEmpty=%s/spool
+
+[Install]
+WantedBy=paths.target
+`
+	const userTriggerExpected = `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Path trigger for snap application some-snap.user-app
+X-Snappy=yes
+
+[Path]
+Unit=snap.some-snap.user-app.service
+PathModified=%h/snap/some-snap/44/trigger
+
+[Install]
+WantedBy=paths.target
+`
+
+	si := &snap.Info{
+		SuggestedName: "some-snap",
+		Version:       "1.0",
+		SideInfo:      snap.SideInfo{Revision: snap.R(44)},
+	}
+	service := &snap.AppInfo{
+		Snap:        si,
+		Name:        "app",
+		Command:     "bin/foo start",
+		Daemon:      "oneshot",
+		DaemonScope: snap.SystemDaemon,
+		Paths: map[string]*snap.PathInfo{
+			"spool": {
+				Name:              "spool",
+				DirectoryNotEmpty: "$SNAP_COMMON/spool",
+			},
+		},
+	}
+	service.Paths["spool"].App = service
+
+	generatedWrapper, err := wrappers.GenerateSnapServiceFile(service, nil)
+	c.Assert(err, IsNil)
+	c.Check(strings.Contains(string(generatedWrapper), "[Install]"), Equals, false)
+
+	generatedPaths, err := wrappers.GenerateSnapPathFiles(service)
+	c.Assert(err, IsNil)
+	c.Check(generatedPaths, DeepEquals, map[string][]byte{
+		"spool": []byte(fmt.Sprintf(spoolExpectedFmt, mountUnitPrefix, mountUnitPrefix, si.CommonDataDir())),
+	})
+
+	userService := &snap.AppInfo{
+		Snap:        si,
+		Name:        "user-app",
+		Command:     "bin/foo start",
+		Daemon:      "simple",
+		DaemonScope: snap.UserDaemon,
+		Paths: map[string]*snap.PathInfo{
+			"trigger": {
+				Name:         "trigger",
+				PathModified: "$SNAP_USER_DATA/trigger",
+			},
+		},
+	}
+	userService.Paths["trigger"].App = userService
+
+	generatedPaths, err = wrappers.GenerateSnapPathFiles(userService)
+	c.Assert(err, IsNil)
+	c.Check(generatedPaths, DeepEquals, map[string][]byte{
+		"trigger": []byte(userTriggerExpected),
+	})
+}
+
 func (s *servicesWrapperGenSuite) TestServiceAfterBefore(c *C) {
 	const expectedServiceFmt = `[Unit]
 # Auto-generated, DO NOT EDIT
@@ -647,6 +791,81 @@ WantedBy=multi-user.target
 	}
 }
 
+func (s *servicesWrapperGenSuite) TestServiceAfterRequiresPlugs(c *C) {
+	const expectedServiceFmt = `[Unit]
+# Auto-generated, DO NOT EDIT
+Description=Service for snap application snap.app
+Requires=%s-snap-44.mount
+Requires=snap.db.server.service
+Wants=network.target
+After=%s-snap-44.mount network.target snap.other.svc.service snap.db.server.service snapd.apparmor.service
+X-Snappy=yes
+
+[Service]
+EnvironmentFile=-/etc/environment
+ExecStart=/usr/bin/snap run snap.app
+SyslogIdentifier=snap.app
+Restart=on-failure
+WorkingDirectory=/var/snap/snap/44
+TimeoutStopSec=30
+Type=simple
+
+[Install]
+WantedBy=multi-user.target
+`
+
+	service := &snap.AppInfo{
+		Snap: &snap.Info{
+			SuggestedName: "snap",
+			Version:       "0.3.4",
+			SideInfo:      snap.SideInfo{Revision: snap.R(44)},
+		},
+		Name:        "app",
+		Command:     "bin/foo start",
+		Daemon:      "simple",
+		DaemonScope: snap.SystemDaemon,
+		StopTimeout: timeout.DefaultTimeout,
+		Plugs: map[string]*snap.PlugInfo{
+			"db":    {Name: "db", Interface: "content"},
+			"other": {Name: "other", Interface: "content"},
+		},
+		AfterPlugs:    []string{"other", "db"},
+		RequiresPlugs: []string{"db"},
+	}
+	plugServices := map[string][]*snap.AppInfo{
+		"db": {{
+			Name:        "server",
+			Snap:        &snap.Info{SuggestedName: "db"},
+			Daemon:      "simple",
+			DaemonScope: snap.SystemDaemon,
+		}, {
+			// user daemons cannot be ordered against system ones
+			Name:        "agent",
+			Snap:        &snap.Info{SuggestedName: "db"},
+			Daemon:      "simple",
+			DaemonScope: snap.UserDaemon,
+		}},
+		"other": {{
+			Name:        "svc",
+			Snap:        &snap.Info{SuggestedName: "other"},
+			Daemon:      "simple",
+			DaemonScope: snap.SystemDaemon,
+		}},
+		"unused": {{
+			Name:        "svc",
+			Snap:        &snap.Info{SuggestedName: "unused"},
+			Daemon:      "simple",
+			DaemonScope: snap.SystemDaemon,
+		}},
+	}
+
+	generatedWrapper, err := wrappers.GenerateSnapServiceFile(service, &wrappers.AddSnapServicesOptions{PlugServices: plugServices})
+	c.Assert(err, IsNil)
+
+	expectedService := fmt.Sprintf(expectedServiceFmt, mountUnitPrefix, mountUnitPrefix)
+	c.Assert(string(generatedWrapper), Equals, expectedService)
+}
+
 func (s *servicesWrapperGenSuite) TestServiceTimerUnit(c *C) {
 	const expectedServiceFmt = `[Unit]
 # Auto-generated, DO NOT EDIT
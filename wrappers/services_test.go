@@ -301,6 +301,77 @@ TasksAccounting=true
 	c.Assert(svcFile, testutil.FileEquals, svcContent)
 }
 
+func (s *servicesTestSuite) TestEnsureSnapServicesWithQuotaCPUSet(c *C) {
+	info := snaptest.MockSnap(c, packageHello, &snap.SideInfo{Revision: snap.R(12)})
+
+	grp, err := quota.NewGroup("foogroup", quantity.SizeGiB)
+	c.Assert(err, IsNil)
+	c.Assert(grp.SetCPUSet([]int{0, 2, 3}), IsNil)
+
+	m := map[*snap.Info]*wrappers.SnapServiceOptions{
+		info: {QuotaGroup: grp},
+	}
+
+	err = wrappers.EnsureSnapServices(m, nil, nil, progress.Null)
+	c.Assert(err, IsNil)
+
+	sliceFile := filepath.Join(s.tempdir, "/etc/systemd/system/snap.foogroup.slice")
+	c.Assert(sliceFile, testutil.FileEquals, fmt.Sprintf(`[Unit]
+Description=Slice for snap quota group foogroup
+Before=slices.target
+X-Snappy=yes
+
+[Slice]
+# Always enable memory accounting otherwise the MemoryMax setting does nothing.
+MemoryAccounting=true
+MemoryMax=%[1]d
+# for compatibility with older versions of systemd
+MemoryLimit=%[1]d
+
+# Always enable task accounting in order to be able to count the processes/
+# threads, etc for a slice
+TasksAccounting=true
+
+# Pin the processes to the CPUs of the group
+AllowedCPUs=0,2,3
+`, quantity.SizeGiB))
+}
+
+func (s *servicesTestSuite) TestEnsureSnapServicesWithQuotaJournalLimit(c *C) {
+	info := snaptest.MockSnap(c, packageHello, &snap.SideInfo{Revision: snap.R(12)})
+
+	grp, err := quota.NewGroup("foogroup", quantity.SizeGiB)
+	c.Assert(err, IsNil)
+	c.Assert(grp.SetJournalLimit(&quota.JournalQuota{
+		Size:       64 * quantity.SizeMiB,
+		RateCount:  100,
+		RatePeriod: 10 * time.Second,
+	}), IsNil)
+
+	m := map[*snap.Info]*wrappers.SnapServiceOptions{
+		info: {QuotaGroup: grp},
+	}
+
+	err = wrappers.EnsureSnapServices(m, nil, nil, progress.Null)
+	c.Assert(err, IsNil)
+
+	svcFile := filepath.Join(s.tempdir, "/etc/systemd/system/snap.hello-snap.svc1.service")
+	c.Check(svcFile, testutil.FileContains, "\nSlice=snap.foogroup.slice\nLogNamespace=snap-foogroup\n")
+
+	journalConfFile := filepath.Join(s.tempdir, "/etc/systemd/journald@snap-foogroup.conf")
+	c.Check(journalConfFile, testutil.FileEquals, fmt.Sprintf(`# Journal namespace for snap quota group foogroup
+[Journal]
+SystemMaxUse=%[1]d
+RuntimeMaxUse=%[1]d
+RateLimitIntervalSec=10000000us
+RateLimitBurst=100
+`, 64*quantity.SizeMiB))
+
+	// the configuration is removed along with the group
+	c.Assert(wrappers.RemoveQuotaGroup(grp, progress.Null), IsNil)
+	c.Check(journalConfFile, testutil.FileAbsent)
+}
+
 type changesObservation struct {
 	snapName string
 	grp      *quota.Group
@@ -3054,6 +3125,49 @@ func (s *servicesTestSuite) TestStartSnapSocketEnableStart(c *C) {
 	}, Commentf("calls: %v", s.sysdLog))
 }
 
+func (s *servicesTestSuite) TestStartSnapPathEnableStart(c *C) {
+	svc1Name := "snap.hello-snap.svc1.service"
+	svc2Path := "snap.hello-snap.svc2.spool.path"
+	svc3Path := "snap.hello-snap.svc3.trigger.path"
+
+	info := snaptest.MockSnap(c, packageHello+`
+ svc2:
+  command: bin/hello
+  daemon: oneshot
+  paths:
+    spool:
+      directory-not-empty: $SNAP_COMMON/spool
+ svc3:
+  command: bin/hello
+  daemon: simple
+  daemon-scope: user
+  paths:
+    trigger:
+      path-exists: $SNAP_USER_COMMON/trigger
+`, &snap.SideInfo{Revision: snap.R(12)})
+
+	err := wrappers.AddSnapServices(info, nil, progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(s.tempdir, "/etc/systemd/system", svc2Path), testutil.FileContains, "\nDirectoryNotEmpty=")
+	c.Check(filepath.Join(s.tempdir, "/etc/systemd/user", svc3Path), testutil.FileContains, "\nPathExists=%h/snap/hello-snap/common/trigger\n")
+	s.sysdLog = nil
+
+	// fix the apps order to make the test stable
+	apps := []*snap.AppInfo{info.Apps["svc1"], info.Apps["svc2"], info.Apps["svc3"]}
+	flags := &wrappers.StartServicesFlags{Enable: true}
+	err = wrappers.StartServices(apps, nil, flags, &progress.Null, s.perfTimings)
+	c.Assert(err, IsNil)
+	c.Assert(s.sysdLog, HasLen, 6, Commentf("len: %v calls: %v", len(s.sysdLog), s.sysdLog))
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"enable", svc1Name},
+		{"enable", svc2Path},
+		{"start", svc2Path},
+		{"--user", "--global", "enable", svc3Path},
+		{"--user", "start", svc3Path},
+		{"start", svc1Name},
+	}, Commentf("calls: %v", s.sysdLog))
+}
+
 func (s *servicesTestSuite) TestStartSnapTimerEnableStart(c *C) {
 	svc1Name := "snap.hello-snap.svc1.service"
 	// svc2Name := "snap.hello-snap.svc2.service"
@@ -3453,6 +3567,87 @@ apps:
 	})
 }
 
+func (s *servicesTestSuite) TestRestartUserDaemons(c *C) {
+	const userServicesYaml = `name: test-snap
+version: 1.0
+apps:
+  svc1:
+    command: bin/foo
+    daemon: simple
+  usvc1:
+    command: bin/foo
+    daemon: simple
+    daemon-scope: user
+  usvc2:
+    command: bin/foo
+    daemon: simple
+    daemon-scope: user
+`
+	srvFile1 := "snap.test-snap.svc1.service"
+	usrSrvFile1 := "snap.test-snap.usvc1.service"
+	usrSrvFile2 := "snap.test-snap.usvc2.service"
+
+	info := snaptest.MockSnap(c, userServicesYaml, &snap.SideInfo{Revision: snap.R(1)})
+
+	r := systemd.MockSystemctl(func(cmd ...string) ([]byte, error) {
+		s.sysdLog = append(s.sysdLog, cmd)
+		states := map[string]systemdtest.ServiceState{
+			srvFile1:    {ActiveState: "active", UnitFileState: "enabled"},
+			usrSrvFile1: {ActiveState: "active", UnitFileState: "enabled"},
+			usrSrvFile2: {ActiveState: "inactive", UnitFileState: "enabled"},
+		}
+		if cmd[0] == "--user" {
+			cmd = cmd[1:]
+		}
+		if out := systemdtest.HandleMockAllUnitsActiveOutput(cmd, states); out != nil {
+			return out, nil
+		}
+		return []byte("ActiveState=inactive\n"), nil
+	})
+	defer r()
+
+	services := info.Services()
+	sort.Sort(snap.AppInfoBySnapApp(services))
+	flags := &wrappers.RestartServicesFlags{Users: []int{os.Getuid()}}
+	c.Assert(wrappers.RestartServices(services, nil, flags, progress.Null, s.perfTimings), IsNil)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"show", "--property=Id,ActiveState,UnitFileState,Type", srvFile1},
+		{"stop", srvFile1},
+		{"show", "--property=ActiveState", srvFile1},
+		{"start", srvFile1},
+		{"--user", "show", "--property=Id,ActiveState,UnitFileState,Type", usrSrvFile1, usrSrvFile2},
+		{"--user", "stop", usrSrvFile1},
+		{"--user", "show", "--property=ActiveState", usrSrvFile1},
+		{"--user", "start", usrSrvFile1},
+	})
+
+	// explicitly mentioned user daemons are restarted regardless of
+	// their state
+	s.sysdLog = nil
+	flags = &wrappers.RestartServicesFlags{Reload: true}
+	c.Assert(wrappers.RestartServices(services, []string{usrSrvFile2}, flags, progress.Null, s.perfTimings), IsNil)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"show", "--property=Id,ActiveState,UnitFileState,Type", srvFile1},
+		{"reload-or-restart", srvFile1},
+		{"--user", "show", "--property=Id,ActiveState,UnitFileState,Type", usrSrvFile1, usrSrvFile2},
+		{"--user", "reload-or-restart", usrSrvFile1},
+		{"--user", "reload-or-restart", usrSrvFile2},
+	})
+}
+
+func (s *servicesTestSuite) TestRestartUserDaemonsOtherUser(c *C) {
+	info := snaptest.MockSnap(c, packageHello+`
+ svc1:
+  daemon: simple
+  daemon-scope: user
+`, &snap.SideInfo{Revision: snap.R(12)})
+
+	// there is no session agent running for this user
+	flags := &wrappers.RestartServicesFlags{Users: []int{os.Getuid() + 1}}
+	c.Assert(wrappers.RestartServices(info.Services(), nil, flags, progress.Null, s.perfTimings), IsNil)
+	c.Check(s.sysdLog, HasLen, 0)
+}
+
 func (s *servicesTestSuite) TestStopAndDisableServices(c *C) {
 	info := snaptest.MockSnap(c, packageHello+`
  svc1:
@@ -3473,3 +3668,25 @@ func (s *servicesTestSuite) TestStopAndDisableServices(c *C) {
 		{"disable", svcFile},
 	})
 }
+
+func (s *servicesTestSuite) TestStopAndDisableUserDaemons(c *C) {
+	info := snaptest.MockSnap(c, packageHello+`
+ svc1:
+  daemon: simple
+  daemon-scope: user
+`, &snap.SideInfo{Revision: snap.R(12)})
+	svcFile := "snap.hello-snap.svc1.service"
+
+	err := wrappers.AddSnapServices(info, nil, progress.Null)
+	c.Assert(err, IsNil)
+
+	s.sysdLog = nil
+	flags := &wrappers.StopServicesFlags{Disable: true, Users: []int{os.Getuid()}}
+	err = wrappers.StopServices(info.Services(), flags, "", progress.Null, s.perfTimings)
+	c.Assert(err, IsNil)
+	c.Check(s.sysdLog, DeepEquals, [][]string{
+		{"--user", "stop", svcFile},
+		{"--user", "show", "--property=ActiveState", svcFile},
+		{"--user", "--global", "disable", svcFile},
+	})
+}